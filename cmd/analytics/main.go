@@ -2,27 +2,40 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	analyticsAdapters "github.com/Keneke-Einar/delivertrack/internal/analytics/adapters"
 	analyticsApp "github.com/Keneke-Einar/delivertrack/internal/analytics/app"
 	"go.uber.org/zap"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
 	authAdapters "github.com/Keneke-Einar/delivertrack/pkg/auth/adapters"
 	authApp "github.com/Keneke-Einar/delivertrack/pkg/auth/app"
-	authPorts "github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
 
+	"github.com/Keneke-Einar/delivertrack/migrations"
 	"github.com/Keneke-Einar/delivertrack/pkg/config"
 	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	healthcheck "github.com/Keneke-Einar/delivertrack/pkg/health"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/http/httpmiddleware"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
 
 	"github.com/Keneke-Einar/delivertrack/proto/analytics"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -31,6 +44,10 @@ import (
 
 var version = "dev"
 
+// shutdownTimeout bounds how long in-flight HTTP requests may take to finish
+// once shutdown starts
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	cfg, err := config.Load("analytics")
 	if err != nil {
@@ -39,16 +56,23 @@ func main() {
 
 	port := cfg.Service.Port
 	databaseURL := cfg.Database.URL
-	jwtSecret := cfg.Auth.JWTSecret
 
 	// Initialize logger
 	lg, err := logger.NewLogger(cfg.Logging, "analytics")
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	logger.SetGlobal(lg)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), "analytics-service", version)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize database
-	db, err := postgres.New(databaseURL)
+	db, err := postgres.New(databaseURL, postgres.OptionsFromConfig(cfg.Database))
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -56,16 +80,60 @@ func main() {
 
 	lg.Info("Database connection established")
 
+	// Apply pending schema migrations, or refuse to start against an outdated schema
+	applied, err := migrate.Ensure(context.Background(), db.DB, migrations.FS, cfg.Database.AutoMigrate)
+	if err != nil {
+		log.Fatalf("Database schema check failed, set DB_AUTO_MIGRATE=true to apply pending migrations: %v", err)
+	}
+	for _, migration := range applied {
+		lg.Info("Applied database migration", zap.String("migration", migration.String()))
+	}
+
 	// Wire up dependencies using layered architecture
 
 	// Auth layer
 	userRepo := authAdapters.NewPostgresUserRepository(db.DB)
-	tokenService := authAdapters.NewJWTTokenService(jwtSecret, cfg.Auth.JWTExpiration)
+	tokenService, err := authAdapters.NewJWTTokenServiceFromConfig(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT keys: %v", err)
+	}
 	authService := authApp.NewAuthService(userRepo, tokenService)
+	loginAttempts, err := authAdapters.NewLoginAttemptStore(cfg.Auth.Lockout.Store, db.DB)
+	if err != nil {
+		log.Fatalf("Failed to create login attempt store: %v", err)
+	}
+	authService.SetLockout(loginAttempts, authApp.LockoutConfig{
+		MaxFailures:   cfg.Auth.Lockout.MaxFailures,
+		IPMaxFailures: cfg.Auth.Lockout.IPMaxFailures,
+		Window:        cfg.Auth.Lockout.Window,
+		Cooldown:      cfg.Auth.Lockout.Cooldown,
+	})
+	authService.SetAPIKeyRepository(authAdapters.NewPostgresAPIKeyRepository(db.DB))
+
+	// Audit log of account and other sensitive changes, written in the background
+	auditStore := audit.NewPostgresStore(db.DB)
+	auditWriter := audit.NewWriter(auditStore, audit.DefaultBufferSize, lg)
+	defer auditWriter.Close()
+	authService.SetAuditWriter(auditWriter)
+
+	// Behind the gateway, trust the identity headers it forwards instead of
+	// validating every token again
+	var trustedGateway *identity.Verifier
+	if cfg.Auth.TrustGatewayHeaders {
+		trustedGateway = identity.NewVerifier(cfg.Auth.GatewaySecret)
+	}
 	authHandler := authAdapters.NewHTTPHandler(authService, cfg.Auth.JWTExpiration)
+	trustedProxies, err := httputil.ParseTrustedProxies(cfg.Service.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	authHandler.SetTrustedProxies(trustedProxies)
 
 	// Analytics layer
 	analyticsRepo := analyticsAdapters.NewPostgresMetricRepository(db.DB)
+	courierStatsRepo := analyticsAdapters.NewPostgresCourierStatsRepository(db.DB)
+	routeStatsRepo := analyticsAdapters.NewPostgresRouteStatsRepository(db.DB)
+	customerStatsRepo := analyticsAdapters.NewPostgresCustomerStatsRepository(db.DB)
 
 	// Initialize RabbitMQ consumer for event handling
 	rabbitMQURL := cfg.RabbitMQ.URL
@@ -74,10 +142,37 @@ func main() {
 		log.Fatalf("Failed to create RabbitMQ consumer: %v", err)
 	}
 	defer consumer.Close()
-
-	analyticsService := analyticsApp.NewAnalyticsService(analyticsRepo, consumer, lg)
+	// Events wait for their metrics' batch, so several are handled at once
+	consumer.SetConcurrency(cfg.Analytics.ConsumerConcurrency)
+
+	analyticsService := analyticsApp.NewAnalyticsService(analyticsRepo, courierStatsRepo, consumer, lg)
+	analyticsService.SetRouteStats(routeStatsRepo)
+	analyticsService.SetCustomerStats(customerStatsRepo)
+	analyticsService.SetBatching(analyticsApp.BatchConfig{
+		MaxRows:       cfg.Analytics.BatchSize,
+		FlushInterval: cfg.Analytics.FlushInterval,
+	})
+	reportStore, err := analyticsAdapters.NewFileReportStore(cfg.Analytics.Reports.Dir)
+	if err != nil {
+		log.Fatalf("Failed to create report store: %v", err)
+	}
+	analyticsService.SetReportStore(reportStore, analyticsApp.ReportConfig{
+		TTL:           cfg.Analytics.Reports.TTL,
+		Timeout:       cfg.Analytics.Reports.Timeout,
+		MaxConcurrent: cfg.Analytics.Reports.MaxConcurrent,
+	})
+	// Reports a previous process was generating will never finish
+	interrupted, err := analyticsService.SweepReports(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to sweep reports: %v", err)
+	}
+	if interrupted > 0 {
+		lg.Info("Failed reports interrupted by a restart", zap.Int("reports", interrupted))
+	}
+	defer analyticsService.Shutdown()
 	analyticsHTTPHandler := analyticsAdapters.NewHTTPHandler(analyticsService)
 	analyticsGRPCHandler := analyticsAdapters.NewGRPCHandler(analyticsService)
+	analyticsGRPCHandler.SetReportBaseURL(cfg.Analytics.Reports.BaseURL)
 
 	// Start event consumption
 	if err := analyticsService.StartEventConsumption(); err != nil {
@@ -85,31 +180,63 @@ func main() {
 	}
 	lg.Info("Started consuming delivery events")
 
+	// Readiness depends on the database and the consumer's broker connection
+	checker := healthcheck.NewChecker("analytics", healthcheck.DefaultTimeout)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("rabbitmq", consumer.Check)
+
 	// Setup HTTP router
 	mux := http.NewServeMux()
 
 	// Public routes
-	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/health/live", checker.LiveHandler)
+	mux.HandleFunc("/health/ready", checker.ReadyHandler)
+	mux.HandleFunc("/health", checker.ReadyHandler) // probed by the gateway
 	mux.HandleFunc("/", rootHandler)
 	mux.HandleFunc("/login", authHandler.Login)
 	mux.HandleFunc("/register", authHandler.Register)
 
 	// Protected routes - analytics endpoints
-	mux.HandleFunc("/metrics", authMiddleware(authService, analyticsHTTPHandler.RecordMetric))
-	mux.HandleFunc("/stats/deliveries", authMiddleware(authService, analyticsHTTPHandler.GetDeliveryStats))
+	protected := httpmiddleware.Auth(authService, httpmiddleware.WithTrustedGateway(trustedGateway))
+	admin := httpmiddleware.Auth(authService,
+		httpmiddleware.WithTrustedGateway(trustedGateway),
+		httpmiddleware.WithRequiredRoles(authDomain.RoleAdmin, authDomain.RoleSuperAdmin))
+	mux.HandleFunc("/metrics", protected(analyticsHTTPHandler.RecordMetric))
+	mux.HandleFunc("/stats/deliveries", protected(analyticsHTTPHandler.GetDeliveryStats))
+	mux.HandleFunc("/stats/couriers/", protected(analyticsHTTPHandler.GetCourierPerformance))
+	mux.HandleFunc("/stats/dashboard", protected(analyticsHTTPHandler.GetDashboard))
+	mux.HandleFunc("/stats/route-efficiency", protected(analyticsHTTPHandler.GetRouteEfficiency))
+	mux.HandleFunc("/stats/me", protected(analyticsHTTPHandler.GetCustomerAnalytics))
+	mux.HandleFunc("/reports", protected(analyticsHTTPHandler.CreateReport))
+	mux.HandleFunc("/reports/", protected(analyticsHTTPHandler.GetReport))
+
+	// Event ingestion buffer depth and flush latency (admin only)
+	mux.HandleFunc("/stats/ingestion", admin(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(analyticsService.IngestionStats())
+	}))
 
 	// Start HTTP server in a goroutine
+	httpServer := &http.Server{
+		Addr:    ":" + port,
+		Handler: tracing.HTTPHandler(httputil.RequestID(mux), "analytics-service"),
+	}
 	go func() {
 		lg.Info("Analytics HTTP service starting",
 			zap.String("version", version),
 			zap.String("port", port))
 		lg.Info("HTTP endpoints available",
 			zap.Strings("endpoints", []string{
+				"GET /health/live", "GET /health/ready",
 				"POST /login", "POST /register",
-				"POST /metrics", "GET /stats/deliveries",
+				"POST /metrics", "GET /stats/deliveries", "GET /stats/couriers/{id}?period=day|week|month",
+				"GET /stats/dashboard?from=&to=&bucket=hour|day", "GET /stats/route-efficiency?from=&to=&courier_id=",
+				"GET /stats/me?from=&to=&customer_id=",
+				"GET /stats/ingestion",
+				"POST /reports", "GET /reports/{id}", "GET /reports/{id}/status",
 			}))
 
-		if err := http.ListenAndServe(":"+port, mux); err != nil {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			lg.Fatal("Failed to start HTTP server", zap.Error(err))
 		}
 	}()
@@ -122,29 +249,37 @@ func main() {
 			zap.String("port", grpcPort), zap.Error(err))
 	}
 
-	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			grpcinterceptors.ErrorHandlingUnaryServerInterceptor(),
-			grpcinterceptors.LoggingUnaryServerInterceptor(lg),
-			grpcinterceptors.AuthUnaryServerInterceptor(authService),
-			grpcinterceptors.UnaryServerInterceptor(),
-		),
-		grpc.ChainStreamInterceptor(
-			grpcinterceptors.ErrorHandlingStreamServerInterceptor(),
-			grpcinterceptors.LoggingStreamServerInterceptor(lg),
-			grpcinterceptors.AuthStreamServerInterceptor(authService),
-			grpcinterceptors.StreamServerInterceptor(),
-		),
+	grpcServer := grpcinterceptors.NewServer(lg, authService, cfg.Logging.SlowRequestThreshold,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	)
 	analytics.RegisterAnalyticsServiceServer(grpcServer, analyticsGRPCHandler)
 
 	// Register health service
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	checker.SetGRPCServer(healthServer)
+	go checker.Run(context.Background(), healthcheck.DefaultInterval)
 
 	reflection.Register(grpcServer) // Enable reflection for debugging
 
+	// On SIGINT/SIGTERM drain HTTP and gRPC calls. Serve then returns and the
+	// deferred closes flush buffered metrics, acking the events waiting on
+	// them, and audit entries before the consumer and database are closed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+
+		lg.Info("Shutting down analytics service")
+		checker.Shutdown()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			lg.Error("Failed to drain HTTP requests", zap.Error(err))
+		}
+		grpcServer.GracefulStop()
+	}()
+
 	lg.Info("Analytics gRPC service starting",
 		zap.String("version", version),
 		zap.String("port", grpcPort))
@@ -154,52 +289,8 @@ func main() {
 	}
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"status":"ok","service":"analytics"}`)
-}
-
 func rootHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{"service":"analytics","version":"%s"}`, version)
 }
-
-// authMiddleware validates JWT token and adds user info to context
-func authMiddleware(authService authPorts.AuthService, next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error":"unauthorized","message":"Authorization header required"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Check Bearer token format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid authorization header format"}`, http.StatusUnauthorized)
-			return
-		}
-
-		token := parts[1]
-
-		// Validate token
-		claims, err := authService.ValidateToken(r.Context(), token)
-		if err != nil {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid or expired token"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "username", claims.Username)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		ctx = context.WithValue(ctx, "customer_id", claims.CustomerID)
-		ctx = context.WithValue(ctx, "courier_id", claims.CourierID)
-
-		// Call next handler with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	}
-}
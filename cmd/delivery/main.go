@@ -2,31 +2,45 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
-	"strings"
+	"os"
+	"time"
 
 	deliveryAdapters "github.com/Keneke-Einar/delivertrack/internal/delivery/adapters"
 	deliveryApp "github.com/Keneke-Einar/delivertrack/internal/delivery/app"
 	"go.uber.org/zap"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
 	authAdapters "github.com/Keneke-Einar/delivertrack/pkg/auth/adapters"
 	authApp "github.com/Keneke-Einar/delivertrack/pkg/auth/app"
-	authPorts "github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
 	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
 
+	"github.com/Keneke-Einar/delivertrack/migrations"
 	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcclient"
 	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	healthcheck "github.com/Keneke-Einar/delivertrack/pkg/health"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/http/httpmiddleware"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
 
 	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"github.com/Keneke-Einar/delivertrack/proto/tracking"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -40,16 +54,23 @@ func main() {
 
 	port := cfg.Service.Port
 	databaseURL := cfg.Database.URL
-	jwtSecret := cfg.Auth.JWTSecret
 
 	// Initialize logger
 	lg, err := logger.NewLogger(cfg.Logging, "delivery")
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	logger.SetGlobal(lg)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), "delivery-service", version)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize database
-	db, err := postgres.New(databaseURL)
+	db, err := postgres.New(databaseURL, postgres.OptionsFromConfig(cfg.Database))
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -57,31 +78,65 @@ func main() {
 
 	lg.Info("Database connection established")
 
-	// Initialize gRPC clients for inter-service communication
-	deliveryConn, err := grpc.NewClient(cfg.Services.Delivery,
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(grpcinterceptors.UnaryClientInterceptor()),
-		grpc.WithStreamInterceptor(grpcinterceptors.StreamClientInterceptor()),
-	)
+	// Apply pending schema migrations, or refuse to start against an outdated schema
+	applied, err := migrate.Ensure(context.Background(), db.DB, migrations.FS, cfg.Database.AutoMigrate)
 	if err != nil {
-		lg.Fatal("Failed to connect to delivery service", zap.Error(err))
+		log.Fatalf("Database schema check failed, set DB_AUTO_MIGRATE=true to apply pending migrations: %v", err)
+	}
+	for _, migration := range applied {
+		lg.Info("Applied database migration", zap.String("migration", migration.String()))
 	}
-	defer deliveryConn.Close()
-	deliveryClient := delivery.NewDeliveryServiceClient(deliveryConn)
-
-	lg.Info("gRPC clients initialized")
 
 	// Auth layer
 	userRepo := authAdapters.NewPostgresUserRepository(db.DB)
-	tokenService := authAdapters.NewJWTTokenService(jwtSecret, cfg.Auth.JWTExpiration)
+	tokenService, err := authAdapters.NewJWTTokenServiceFromConfig(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT keys: %v", err)
+	}
 	authService := authApp.NewAuthService(userRepo, tokenService)
+	loginAttempts, err := authAdapters.NewLoginAttemptStore(cfg.Auth.Lockout.Store, db.DB)
+	if err != nil {
+		log.Fatalf("Failed to create login attempt store: %v", err)
+	}
+	authService.SetLockout(loginAttempts, authApp.LockoutConfig{
+		MaxFailures:   cfg.Auth.Lockout.MaxFailures,
+		IPMaxFailures: cfg.Auth.Lockout.IPMaxFailures,
+		Window:        cfg.Auth.Lockout.Window,
+		Cooldown:      cfg.Auth.Lockout.Cooldown,
+	})
+	authService.SetAPIKeyRepository(authAdapters.NewPostgresAPIKeyRepository(db.DB))
+
+	// Audit log of account and other sensitive changes, written in the background
+	auditStore := audit.NewPostgresStore(db.DB)
+	auditWriter := audit.NewWriter(auditStore, audit.DefaultBufferSize, lg)
+	defer auditWriter.Close()
+	authService.SetAuditWriter(auditWriter)
+
+	// Behind the gateway, trust the identity headers it forwards instead of
+	// validating every token again
+	var trustedGateway *identity.Verifier
+	if cfg.Auth.TrustGatewayHeaders {
+		trustedGateway = identity.NewVerifier(cfg.Auth.GatewaySecret)
+	}
 	authHandler := authAdapters.NewHTTPHandler(authService, cfg.Auth.JWTExpiration)
+	trustedProxies, err := httputil.ParseTrustedProxies(cfg.Service.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	authHandler.SetTrustedProxies(trustedProxies)
 
 	// Delivery layer
 	deliveryRepo := deliveryAdapters.NewPostgresDeliveryRepository(db.DB)
 
-	// Initialize geocoding service
-	geocodingSvc := geocoding.NewHTTPGeocodingService(lg)
+	// Initialize geocoding service: prefer env vars (set in docker-compose), then config
+	geocodingCfg := geocoding.ConfigFromEnv(cfg.Geocoding)
+	geocodingSvc, err := geocoding.NewGeocodingService(geocodingCfg, lg)
+	if err != nil {
+		lg.Fatal("Failed to initialize geocoding service", zap.Error(err))
+	}
+	lg.Info("Geocoding provider configured",
+		zap.String("provider", geocodingCfg.Provider),
+		zap.String("fallback_provider", geocodingCfg.FallbackProvider))
 
 	// Initialize RabbitMQ publisher for event publishing
 	rabbitMQURL := cfg.RabbitMQ.URL
@@ -91,72 +146,174 @@ func main() {
 	}
 	defer publisher.Close()
 
-	deliveryService := deliveryApp.NewDeliveryService(deliveryRepo, publisher, deliveryClient, geocodingSvc, lg)
+	// Proof-of-delivery photos and signatures are kept on local disk
+	blobDir := os.Getenv("DELIVERY_BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "data/blobs"
+	}
+	blobStore, err := deliveryAdapters.NewFilesystemBlobStore(blobDir)
+	if err != nil {
+		lg.Fatal("Failed to initialize blob store", zap.Error(err))
+	}
+
+	deliveryService := deliveryApp.NewDeliveryService(deliveryRepo, geocodingSvc, blobStore, lg)
+	deliveryService.SetBulkCreateConfig(deliveryApp.BulkCreateConfig{
+		MaxBatchSize:   cfg.Delivery.BulkMaxBatchSize,
+		GeocodeWorkers: cfg.Delivery.BulkGeocodeWorkers,
+	})
+
+	// Courier availability; assignments mark couriers busy and completions free them
+	courierRepo := deliveryAdapters.NewPostgresCourierRepository(db.DB)
+	courierService := deliveryApp.NewCourierService(courierRepo, lg)
+	deliveryService.SetCourierRepository(courierRepo)
+	deliveryService.SetAuditWriter(auditWriter)
+
+	// Route planning starts from the courier's last location in the tracking
+	// service, and delivery reads may embed the latest location and ETA. Tracking waits for this service at startup, so the connection is
+	// made lazily to avoid the two services waiting on each other.
+	trackingGRPCConfig := cfg.GRPC
+	trackingGRPCConfig.ConnectTimeout = 0
+	trackingConn, err := grpcclient.New(context.Background(), "tracking", cfg.Services.Tracking, trackingGRPCConfig)
+	if err != nil {
+		lg.Fatal("Failed to configure tracking service client", zap.Error(err))
+	}
+	defer trackingConn.Close()
+	trackingClient := tracking.NewTrackingServiceClient(trackingConn)
+	deliveryService.SetCourierLocator(deliveryAdapters.NewTrackingCourierLocator(trackingClient))
+	deliveryService.SetDeliveryTracker(deliveryAdapters.NewTrackingDeliveryTracker(trackingClient), cfg.Delivery.TrackingTimeout)
+	pricing, warning, err := pricingConfig(cfg)
+	if err != nil {
+		lg.Fatal("Invalid pricing configuration", zap.Error(err))
+	}
+	if warning != "" {
+		lg.Warn(warning)
+	}
+	zoneLocator := deliveryAdapters.NewTrackingZoneLocator(trackingClient)
+	deliveryService.SetPricing(pricing, zoneLocator)
+
+	// Scheduled deliveries are booked into their drop-off zone's slots
+	slotLocation, err := time.LoadLocation(cfg.Delivery.Slots.Timezone)
+	if err != nil {
+		lg.Fatal("Invalid slot timezone", zap.String("timezone", cfg.Delivery.Slots.Timezone), zap.Error(err))
+	}
+	deliveryService.SetSlots(deliveryAdapters.NewPostgresSlotCapacityRepository(db.DB), zoneLocator, slotLocation)
+
+	// Readiness depends on the database and the broker; without the tracking
+	// service only route planning degrades
+	checker := healthcheck.NewChecker("delivery", healthcheck.DefaultTimeout)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("rabbitmq", publisher.Check)
+	checker.AddOptional("tracking", healthcheck.GRPC(trackingConn))
+
+	// Start outbox dispatcher to publish delivery events committed with their mutations
+	outboxRepo := deliveryAdapters.NewPostgresOutboxRepository(db.DB)
+	outboxDispatcher := deliveryApp.NewOutboxDispatcher(outboxRepo, publisher, deliveryApp.DefaultOutboxDispatcherConfig(), lg)
+
+	// Admins replay published events to consumers that missed them
+	replayService := deliveryApp.NewEventReplayService(outboxRepo, publisher, lg)
+	replayService.SetAuditWriter(auditWriter)
+
+	// Webhooks are queued from the outbox and POSTed to merchants in the background
+	webhookRepo := deliveryAdapters.NewPostgresWebhookRepository(db.DB)
+	webhookService := deliveryApp.NewWebhookService(webhookRepo, lg)
+	outboxDispatcher.SetWebhooks(webhookService)
+	webhookConfig := deliveryApp.DefaultWebhookDispatcherConfig()
+	webhookConfig.MaxAttempts = cfg.Delivery.WebhookMaxAttempts
+	webhookConfig.RequestTimeout = cfg.Delivery.WebhookTimeout
+	webhookConfig.AllowPrivateNetworks = cfg.Delivery.WebhookAllowPrivateNetworks
+	webhookDispatcher := deliveryApp.NewWebhookDispatcher(webhookRepo, webhookConfig, lg)
+
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	go outboxDispatcher.Run(dispatcherCtx)
+	go webhookDispatcher.Run(dispatcherCtx)
+
+	// Flag deliveries still open after their scheduled window; the late events go out through the outbox
+	lateDetector := deliveryApp.NewLateDeliveryDetector(deliveryRepo, deliveryApp.DefaultLateDetectorConfig(), lg)
+	go lateDetector.Run(dispatcherCtx)
+
 	deliveryHTTPHandler := deliveryAdapters.NewHTTPHandler(deliveryService)
 	geocodingHTTPHandler := geocoding.NewHTTPHandler(geocodingSvc)
 	deliveryGRPCHandler := deliveryAdapters.NewGRPCHandler(deliveryService)
+	courierHTTPHandler := deliveryAdapters.NewCourierHTTPHandler(courierService)
 
 	// Setup HTTP router with middleware
-	mux := http.NewServeMux()
+	mux := httputil.NewRouter()
+	// Credentials are kept for the gRPC calls to the tracking service
+	protected := httpmiddleware.Auth(authService,
+		httpmiddleware.WithTrustedGateway(trustedGateway),
+		httpmiddleware.WithForwardedAuthorization())
+	admin := httpmiddleware.Auth(authService,
+		httpmiddleware.WithTrustedGateway(trustedGateway),
+		httpmiddleware.WithForwardedAuthorization(),
+		httpmiddleware.WithRequiredRoles(authDomain.RoleAdmin, authDomain.RoleSuperAdmin))
 
 	// Public routes
-	mux.HandleFunc("/health", healthHandler)
-	mux.HandleFunc("/api/auth/login", authHandler.Login)
-	mux.HandleFunc("/api/auth/register", authHandler.Register)
+	mux.HandleFunc("GET /health/live", checker.LiveHandler)
+	mux.HandleFunc("GET /health/ready", checker.ReadyHandler)
+	mux.HandleFunc("GET /health", checker.ReadyHandler) // probed by the gateway
+	mux.HandleFunc("GET /{$}", rootHandler)
+	mux.HandleFunc("POST /api/auth/login", authHandler.Login)
+	mux.HandleFunc("POST /api/auth/register", authHandler.Register)
 
 	// Geocoding routes (public - no auth required)
-	mux.HandleFunc("/geocode/forward", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
-			return
-		}
-		geocodingHTTPHandler.ForwardGeocode(w, r)
-	})
-	mux.HandleFunc("/geocode/reverse", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
+	mux.HandleFunc("POST /geocode/forward", geocodingHTTPHandler.ForwardGeocode)
+	mux.HandleFunc("POST /geocode/reverse", geocodingHTTPHandler.ReverseGeocode)
+	mux.HandleFunc("GET /geocode/autocomplete", geocodingHTTPHandler.Autocomplete)
+
+	// Outbox and geocoding cache metrics
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := outboxDispatcher.Metrics(r.Context())
+		if err != nil {
+			http.Error(w, `{"error":"failed to collect metrics"}`, http.StatusInternalServerError)
 			return
 		}
-		geocodingHTTPHandler.ReverseGeocode(w, r)
-	})
-	mux.HandleFunc("/geocode/autocomplete", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
-			return
+		response := map[string]interface{}{"outbox": metrics}
+		if cached, ok := geocodingSvc.(interface{ CacheStats() geocoding.CacheStats }); ok {
+			response["geocoding_cache"] = cached.CacheStats()
 		}
-		geocodingHTTPHandler.Autocomplete(w, r)
+		response["webhooks"] = webhookDispatcher.Metrics()
+		response["audit"] = map[string]int64{"dropped": auditWriter.Dropped()}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
 	})
 
-	// Catch-all root handler (must be last)
-	mux.HandleFunc("/", rootHandler)
+	// Public tracking by number (no auth required; returns a redacted view)
+	mux.HandleFunc("GET /track/{number}", deliveryHTTPHandler.TrackByNumber)
 
 	// Protected routes - delivery endpoints
 	// Routes use bare paths (gateway strips /api/delivery prefix before proxying)
-	mux.HandleFunc("/deliveries", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			authMiddleware(authService, deliveryHTTPHandler.ListDeliveries)(w, r)
-		} else if r.Method == http.MethodPost {
-			authMiddleware(authService, deliveryHTTPHandler.CreateDelivery)(w, r)
-		} else {
-			http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
-		}
-	})
-	mux.HandleFunc("/deliveries/", func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/deliveries/")
-		if path == "" {
-			http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Check if path ends with /status
-		if strings.HasSuffix(path, "/status") {
-			// Handle PUT /deliveries/:id/status
-			authMiddleware(authService, deliveryHTTPHandler.UpdateDeliveryStatus)(w, r)
-		} else {
-			// Handle GET /deliveries/:id
-			authMiddleware(authService, deliveryHTTPHandler.GetDelivery)(w, r)
-		}
-	})
+	mux.HandleFunc("GET /deliveries", protected(deliveryHTTPHandler.ListDeliveries))
+	mux.HandleFunc("POST /deliveries", protected(deliveryHTTPHandler.CreateDelivery))
+	mux.HandleFunc("GET /deliveries/search", protected(deliveryHTTPHandler.SearchDeliveries))
+	mux.HandleFunc("POST /deliveries/bulk", protected(deliveryHTTPHandler.BulkCreateDeliveries))
+	mux.HandleFunc("POST /deliveries/quote", protected(deliveryHTTPHandler.QuoteDelivery))
+	mux.HandleFunc("GET /deliveries/slots", protected(deliveryHTTPHandler.GetSlotAvailability))
+	mux.HandleFunc("GET /deliveries/{id}", protected(deliveryHTTPHandler.GetDelivery))
+	mux.HandleFunc("PUT /deliveries/{id}/status", protected(deliveryHTTPHandler.UpdateDeliveryStatus))
+	mux.HandleFunc("GET /deliveries/{id}/confirmation-code", protected(deliveryHTTPHandler.GetConfirmationCode))
+	mux.HandleFunc("POST /deliveries/{id}/confirm", protected(deliveryHTTPHandler.ConfirmDelivery))
+	mux.HandleFunc("POST /deliveries/{id}/cancel", protected(deliveryHTTPHandler.CancelDelivery))
+
+	// Protected routes - courier availability
+	mux.HandleFunc("GET /couriers", protected(courierHTTPHandler.ListCouriers))
+	mux.HandleFunc("PUT /couriers/me/status", protected(courierHTTPHandler.UpdateMyStatus))
+	mux.HandleFunc("GET /couriers/{id}/route", protected(deliveryHTTPHandler.GetCourierRoute))
+
+	// Protected routes - webhook subscriptions
+	webhookHTTPHandler := deliveryAdapters.NewWebhookHTTPHandler(webhookService)
+	mux.HandleFunc("POST /webhooks", protected(webhookHTTPHandler.CreateWebhook))
+	mux.HandleFunc("GET /webhooks", protected(webhookHTTPHandler.ListWebhooks))
+	mux.HandleFunc("GET /webhooks/{id}", protected(webhookHTTPHandler.GetWebhook))
+	mux.HandleFunc("PUT /webhooks/{id}", protected(webhookHTTPHandler.UpdateWebhook))
+	mux.HandleFunc("DELETE /webhooks/{id}", protected(webhookHTTPHandler.DeleteWebhook))
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", protected(webhookHTTPHandler.ListWebhookDeliveries))
+
+	// Protected routes - audit log (admin only)
+	mux.HandleFunc("GET /admin/audit", admin(audit.NewHTTPHandler(auditStore).ListEntries))
+	mux.HandleFunc("POST /admin/events/replay", admin(deliveryAdapters.NewReplayHTTPHandler(replayService).ReplayEvents))
+	mux.HandleFunc("GET /admin/slot-capacities/{zone}", admin(deliveryHTTPHandler.GetSlotCapacities))
+	mux.HandleFunc("PUT /admin/slot-capacities/{zone}", admin(deliveryHTTPHandler.UpdateSlotCapacities))
 
 	// Wrap with CORS middleware
 	httpHandler := corsMiddleware(mux)
@@ -168,13 +325,20 @@ func main() {
 			zap.String("port", port))
 		lg.Info("HTTP endpoints available",
 			zap.Strings("endpoints", []string{
+				"GET /health/live", "GET /health/ready",
 				"POST /login", "POST /register",
-				"POST /deliveries", "GET /deliveries/:id",
-				"PUT /deliveries/:id/status", "GET /deliveries?status=xxx",
+				"POST /deliveries", "POST /deliveries/bulk", "POST /deliveries/quote", "GET /deliveries/slots?date=xxx&zone=xxx", "GET /deliveries/:id",
+				"PUT /deliveries/:id/status", "POST /deliveries/:id/confirm", "POST /deliveries/:id/cancel",
+				"GET /deliveries?status=xxx",
+				"GET /deliveries/search", "GET /track/:tracking_number",
+				"PUT /couriers/me/status", "GET /couriers?status=available", "GET /couriers/:id/route",
+				"POST /webhooks", "GET /webhooks", "GET|PUT|DELETE /webhooks/:id", "GET /webhooks/:id/deliveries",
+				"GET /admin/audit?entity=xxx&id=xxx", "POST /admin/events/replay", "GET|PUT /admin/slot-capacities/:zone",
 				"POST /geocode/forward", "POST /geocode/reverse", "GET /geocode/autocomplete",
+				"GET /metrics",
 			}))
 
-		if err := http.ListenAndServe(":"+port, httpHandler); err != nil {
+		if err := http.ListenAndServe(":"+port, tracing.HTTPHandler(httputil.RequestID(httpHandler), "delivery-service")); err != nil {
 			lg.Fatal("Failed to start HTTP server", zap.Error(err))
 		}
 	}()
@@ -187,26 +351,21 @@ func main() {
 			zap.String("port", grpcPort), zap.Error(err))
 	}
 
-	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			grpcinterceptors.ErrorHandlingUnaryServerInterceptor(),
-			grpcinterceptors.LoggingUnaryServerInterceptor(lg),
-			grpcinterceptors.AuthUnaryServerInterceptor(authService),
-			grpcinterceptors.UnaryServerInterceptor(),
-		),
-		grpc.ChainStreamInterceptor(
-			grpcinterceptors.ErrorHandlingStreamServerInterceptor(),
-			grpcinterceptors.LoggingStreamServerInterceptor(lg),
-			grpcinterceptors.AuthStreamServerInterceptor(authService),
-			grpcinterceptors.StreamServerInterceptor(),
-		),
+	grpcServer := grpcinterceptors.NewServer(lg, authService, cfg.Logging.SlowRequestThreshold,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		// Accept the keepalive pings sent by grpcclient connections
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.GRPC.KeepaliveTime / 2,
+			PermitWithoutStream: true,
+		}),
 	)
 	delivery.RegisterDeliveryServiceServer(grpcServer, deliveryGRPCHandler)
 
 	// Register health service
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	checker.SetGRPCServer(healthServer)
+	go checker.Run(dispatcherCtx, healthcheck.DefaultInterval)
 
 	reflection.Register(grpcServer) // Enable reflection for debugging
 
@@ -219,60 +378,12 @@ func main() {
 	}
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"status":"ok","service":"delivery"}`)
-}
-
 func rootHandler(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
-	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{"service":"delivery","version":"%s"}`, version)
 }
 
-// authMiddleware validates JWT token and adds user info to context
-func authMiddleware(authService authPorts.AuthService, next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error":"unauthorized","message":"Authorization header required"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Check Bearer token format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid authorization header format"}`, http.StatusUnauthorized)
-			return
-		}
-
-		token := parts[1]
-
-		// Validate token
-		claims, err := authService.ValidateToken(r.Context(), token)
-		if err != nil {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid or expired token"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "username", claims.Username)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		ctx = context.WithValue(ctx, "customer_id", claims.CustomerID)
-		ctx = context.WithValue(ctx, "courier_id", claims.CourierID)
-
-		// Call next handler with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	}
-}
-
 // corsMiddleware adds CORS headers
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
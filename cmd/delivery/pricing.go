@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	deliveryApp "github.com/Keneke-Einar/delivertrack/internal/delivery/app"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+)
+
+// weekdays maps the day names accepted in surcharge windows
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// pricingConfig builds the quote pricing from configuration. Without a quote
+// secret, one is derived from the JWT secret so every replica signs alike; a
+// deployment with neither gets a random one and the returned warning, since
+// its quotes only survive on the replica that issued them.
+func pricingConfig(cfg *config.Config) (deliveryApp.PricingConfig, string, error) {
+	pricing := cfg.Delivery.Pricing
+	model := domain.PricingModel{
+		Currency:     strings.ToUpper(pricing.Currency),
+		BaseFeeCents: pricing.BaseFeeCents,
+		PerKmCents:   pricing.PerKmCents,
+		Location:     time.UTC,
+	}
+	if pricing.Timezone != "" {
+		location, err := time.LoadLocation(pricing.Timezone)
+		if err != nil {
+			return deliveryApp.PricingConfig{}, "", fmt.Errorf("invalid pricing timezone %q: %w", pricing.Timezone, err)
+		}
+		model.Location = location
+	}
+
+	for _, pair := range pricing.ZonePairs {
+		if pair.Pickup == "" || pair.Delivery == "" {
+			return deliveryApp.PricingConfig{}, "", fmt.Errorf("zone pair needs a pickup and a delivery zone, use %q for any", domain.AnyZone)
+		}
+		model.ZonePairs = append(model.ZonePairs, domain.ZonePairRate{
+			PickupZone:   pair.Pickup,
+			DeliveryZone: pair.Delivery,
+			PerKmCents:   pair.PerKmCents,
+		})
+	}
+
+	for _, surcharge := range pricing.Surcharges {
+		if surcharge.StartHour < 0 || surcharge.StartHour > 23 || surcharge.EndHour < 0 || surcharge.EndHour > 24 {
+			return deliveryApp.PricingConfig{}, "", fmt.Errorf("surcharge %q has hours outside 0-24", surcharge.Name)
+		}
+		rule := domain.ScheduledSurcharge{
+			Name:      surcharge.Name,
+			StartHour: surcharge.StartHour,
+			EndHour:   surcharge.EndHour % 24,
+			Percent:   surcharge.Percent,
+		}
+		for _, name := range surcharge.Weekdays {
+			day, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return deliveryApp.PricingConfig{}, "", fmt.Errorf("surcharge %q has unknown weekday %q", surcharge.Name, name)
+			}
+			rule.Weekdays = append(rule.Weekdays, day)
+		}
+		model.Surcharges = append(model.Surcharges, rule)
+	}
+
+	var warning string
+	secret := []byte(pricing.QuoteSecret)
+	switch {
+	case len(secret) > 0:
+	case cfg.Auth.JWTSecret != "":
+		mac := hmac.New(sha256.New, []byte(cfg.Auth.JWTSecret))
+		mac.Write([]byte("delivery-quote"))
+		secret = mac.Sum(nil)
+	default:
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return deliveryApp.PricingConfig{}, "", fmt.Errorf("failed to generate quote secret: %w", err)
+		}
+		warning = "No quote secret configured, quotes are only honoured by this instance"
+	}
+
+	return deliveryApp.PricingConfig{Model: model, QuoteTTL: pricing.QuoteTTL, Secret: secret}, warning, nil
+}
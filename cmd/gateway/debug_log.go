@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+// redactedValue replaces sensitive values in debug logs
+const redactedValue = "[REDACTED]"
+
+// builtinRedactKeys are always masked. Field and header names match when they
+// contain one, ignoring case and treating dashes as underscores.
+var builtinRedactKeys = []string{"password", "token", "secret", "authorization", "api_key", "apikey", "cookie"}
+
+var (
+	// jsonFieldPattern finds "key": value pairs in bodies that do not parse,
+	// such as truncated ones; a cut off string value is matched to its end
+	jsonFieldPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]*)`)
+	// formFieldPattern finds key=value pairs in form and plain text bodies
+	formFieldPattern = regexp.MustCompile(`([\w.\-\[\]]+)=([^&\s]*)`)
+)
+
+// bodyLogger selects requests for debug logging and redacts what it logs
+type bodyLogger struct {
+	routes       []string
+	apiKeyIDs    map[int]bool
+	sampleRate   float64
+	maxBodyBytes int
+	redactKeys   []string
+	sample       func() float64
+}
+
+// newBodyLogger builds a body logger, or returns nil when no route or API key
+// is selected
+func newBodyLogger(cfg config.DebugLogConfig) *bodyLogger {
+	if (len(cfg.Routes) == 0 && len(cfg.APIKeyIDs) == 0) || cfg.SampleRate <= 0 || cfg.MaxBodyBytes <= 0 {
+		return nil
+	}
+
+	l := &bodyLogger{
+		routes:       cfg.Routes,
+		apiKeyIDs:    make(map[int]bool, len(cfg.APIKeyIDs)),
+		sampleRate:   cfg.SampleRate,
+		maxBodyBytes: cfg.MaxBodyBytes,
+		sample:       rand.Float64,
+	}
+	for _, id := range cfg.APIKeyIDs {
+		l.apiKeyIDs[id] = true
+	}
+	for _, key := range append(append([]string(nil), builtinRedactKeys...), cfg.RedactKeys...) {
+		if key = normalizeKey(key); key != "" {
+			l.redactKeys = append(l.redactKeys, key)
+		}
+	}
+	return l
+}
+
+// selects reports whether the request is logged: it is on a debugged route or
+// made with a debugged API key, and it was sampled. Upgrades are never
+// logged, so tunnels keep their connection to themselves.
+func (l *bodyLogger) selects(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" {
+		return false
+	}
+
+	selected := false
+	for _, prefix := range l.routes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			selected = true
+			break
+		}
+	}
+	if claims, ok := authctx.ClaimsFrom(r.Context()); ok && claims.APIKeyID != 0 && l.apiKeyIDs[claims.APIKeyID] {
+		selected = true
+	}
+	return selected && l.sample() < l.sampleRate
+}
+
+// debugLogMiddleware logs the bodies of selected requests and their responses
+// once the response is written. Bodies are captured as they stream through,
+// up to the cap, so nothing larger is buffered and flushed responses are
+// still flushed.
+func (g *Gateway) debugLogMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g.bodyLogger == nil || !g.bodyLogger.selects(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reqBody := &cappedBuffer{max: g.bodyLogger.maxBodyBytes}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &teeReadCloser{ReadCloser: r.Body, capture: reqBody}
+		}
+		rec := &captureWriter{ResponseWriter: w, status: http.StatusOK, body: cappedBuffer{max: g.bodyLogger.maxBodyBytes}}
+
+		next.ServeHTTP(rec, r)
+
+		l := g.bodyLogger
+		fields := []zap.Field{
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.String("query", l.redactQuery(r.URL.RawQuery)),
+			zap.Int("status", rec.status),
+			zap.String("request_id", requestid.FromContext(r.Context())),
+			zap.String("trace_id", tracing.TraceID(r.Context())),
+			zap.Any("request_headers", l.redactHeader(r.Header)),
+			zap.String("request_body", l.redactBody(reqBody, r.Header)),
+			zap.Int64("request_bytes", reqBody.total),
+			zap.Bool("request_body_truncated", reqBody.truncated()),
+			zap.Any("response_headers", l.redactHeader(rec.Header())),
+			zap.String("response_body", l.redactBody(&rec.body, rec.Header())),
+			zap.Int64("response_bytes", rec.body.total),
+			zap.Bool("response_body_truncated", rec.body.truncated()),
+		}
+		if claims, ok := authctx.ClaimsFrom(r.Context()); ok {
+			fields = append(fields, zap.Int("user_id", claims.UserID))
+			if claims.APIKeyID != 0 {
+				fields = append(fields, zap.Int("api_key_id", claims.APIKeyID))
+			}
+		}
+		g.logger.WithFields(fields...).Info("Debug request captured")
+	}
+}
+
+// sensitive reports whether a field or header name is redacted
+func (l *bodyLogger) sensitive(name string) bool {
+	name = normalizeKey(name)
+	for _, key := range l.redactKeys {
+		if strings.Contains(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
+
+// redactHeader flattens headers for logging with sensitive ones masked
+func (l *bodyLogger) redactHeader(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if l.sensitive(name) {
+			out[name] = redactedValue
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// redactQuery masks sensitive query parameters, such as WebSocket tokens
+func (l *bodyLogger) redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return l.redactText(rawQuery)
+	}
+	for name := range values {
+		if l.sensitive(name) {
+			values[name] = []string{redactedValue}
+		}
+	}
+	return values.Encode()
+}
+
+// redactBody returns a captured body for logging. Complete JSON and form
+// bodies are parsed so nested values are masked too; anything else, such as a
+// truncated body, is masked field by field as text. Compressed and binary
+// bodies are described rather than logged.
+func (l *bodyLogger) redactBody(body *cappedBuffer, header http.Header) string {
+	if body.buf.Len() == 0 {
+		return ""
+	}
+	if encoding := header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return "[" + encoding + " encoded body not logged]"
+	}
+	contentType := header.Get("Content-Type")
+	if !textContent(contentType) {
+		return "[" + contentType + " body not logged]"
+	}
+
+	data := body.buf.Bytes()
+	if !body.truncated() {
+		if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+			return l.redactQuery(string(data))
+		}
+		var doc interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err == nil && !decoder.More() {
+			if redacted, err := json.Marshal(l.redactJSON(doc)); err == nil {
+				return string(redacted)
+			}
+		}
+	}
+	return l.redactText(string(data))
+}
+
+// redactJSON masks the values of sensitive keys anywhere in a decoded document
+func (l *bodyLogger) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if l.sensitive(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = l.redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = l.redactJSON(value)
+		}
+	}
+	return v
+}
+
+// redactText masks sensitive "key": value and key=value pairs in unparsed text
+func (l *bodyLogger) redactText(text string) string {
+	text = jsonFieldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := jsonFieldPattern.FindStringSubmatch(match)
+		if !l.sensitive(parts[1]) {
+			return match
+		}
+		return `"` + parts[1] + `"` + parts[2] + `"` + redactedValue + `"`
+	})
+	return formFieldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := formFieldPattern.FindStringSubmatch(match)
+		if !l.sensitive(parts[1]) {
+			return match
+		}
+		return parts[1] + "=" + redactedValue
+	})
+}
+
+// textContent reports whether a content type is readable in a log
+func textContent(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	contentType = strings.ToLower(contentType)
+	for _, text := range []string{"json", "text/", "xml", "x-www-form-urlencoded"} {
+		if strings.Contains(contentType, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// cappedBuffer keeps the first max bytes written to it and counts the rest
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	max   int
+	total int64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += int64(n)
+	if room := b.max - b.buf.Len(); room > 0 {
+		if n > room {
+			p = p[:room]
+		}
+		b.buf.Write(p)
+	}
+	return n, nil
+}
+
+func (b *cappedBuffer) truncated() bool {
+	return b.total > int64(b.buf.Len())
+}
+
+// teeReadCloser copies a request body into capture as the upstream reads it
+type teeReadCloser struct {
+	io.ReadCloser
+	capture *cappedBuffer
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		t.capture.Write(p[:n])
+	}
+	return n, err
+}
+
+// captureWriter copies a response into body as it is written
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        cappedBuffer
+}
+
+func (w *captureWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.body.Write(p[:n])
+	return n, err
+}
+
+// Flush keeps streamed responses flowing while they are captured
+func (w *captureWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
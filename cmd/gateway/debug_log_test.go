@@ -0,0 +1,187 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newDebugLogGateway logs every selected request into the returned observer
+func newDebugLogGateway(t *testing.T, cfg config.DebugLogConfig) (*Gateway, *observer.ObservedLogs) {
+	core, logs := observer.New(zap.InfoLevel)
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.logger = &logger.Logger{Logger: zap.New(core)}
+	g.bodyLogger = newBodyLogger(cfg)
+	if g.bodyLogger != nil {
+		g.bodyLogger.sample = func() float64 { return 0 }
+	}
+	return g, logs
+}
+
+// echoHandler reads the request body and answers with a JSON body of its own
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Set-Cookie", "session=abc")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write([]byte(`{"error":"invalid","refresh_token":"r-123","details":{"password":"hunter2"}}`))
+}
+
+func capturedFields(t *testing.T, logs *observer.ObservedLogs) map[string]interface{} {
+	t.Helper()
+	entries := logs.FilterMessage("Debug request captured").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 captured request, got %d", len(entries))
+	}
+	return entries[0].ContextMap()
+}
+
+func TestDebugLogMiddleware_RedactsCapturedBodies(t *testing.T) {
+	g, logs := newDebugLogGateway(t, config.DebugLogConfig{
+		Routes:       []string{"/api/delivery/"},
+		SampleRate:   1,
+		MaxBodyBytes: 1024,
+		RedactKeys:   []string{"phone"},
+	})
+
+	body := `{"pickup_location":"A","customer_phone":"555-0100","auth":{"access_token":"t-1"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/delivery/deliveries?token=ws-1&limit=5", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-jwt")
+	req.Header.Set("X-API-Key", "dt_live_key")
+	rec := httptest.NewRecorder()
+	g.debugLogMiddleware(echoHandler)(rec, req)
+
+	if !strings.Contains(rec.Body.String(), "hunter2") {
+		t.Fatalf("expected the client to get the unredacted response, got %s", rec.Body.String())
+	}
+
+	fields := capturedFields(t, logs)
+	logged := ""
+	for _, key := range []string{"query", "request_body", "response_body"} {
+		logged += fields[key].(string)
+	}
+	for _, name := range []string{"request_headers", "response_headers"} {
+		for _, value := range fields[name].(map[string]string) {
+			logged += value
+		}
+	}
+	for _, secret := range []string{"ws-1", "555-0100", "t-1", "secret-jwt", "dt_live_key", "r-123", "hunter2", "session=abc"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, logged)
+		}
+	}
+	for _, kept := range []string{`"pickup_location":"A"`, `"error":"invalid"`, "limit=5"} {
+		if !strings.Contains(logged, kept) {
+			t.Errorf("expected %q to be logged, got %s", kept, logged)
+		}
+	}
+	if fields["status"] != int64(http.StatusBadRequest) || fields["request_bytes"] != int64(len(body)) {
+		t.Errorf("unexpected status or size: %v %v", fields["status"], fields["request_bytes"])
+	}
+}
+
+func TestDebugLogMiddleware_CapsBodies(t *testing.T) {
+	g, logs := newDebugLogGateway(t, config.DebugLogConfig{Routes: []string{"/api/"}, SampleRate: 1, MaxBodyBytes: 16})
+
+	body := `{"note":"` + strings.Repeat("x", 100) + `","password":"hunter2"}`
+	var upstreamGot string
+	handler := g.debugLogMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		upstreamGot = string(data)
+		w.Write([]byte(`{"password":"` + strings.Repeat("p", 100) + `"}`))
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/delivery/deliveries", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if upstreamGot != body || !strings.HasSuffix(rec.Body.String(), `"}`) {
+		t.Fatal("expected bodies over the cap to pass through whole")
+	}
+
+	fields := capturedFields(t, logs)
+	if len(fields["request_body"].(string)) > 16 || fields["request_body_truncated"] != true {
+		t.Errorf("expected a truncated request body, got %q", fields["request_body"])
+	}
+	// A string cut off by the cap is still masked
+	if got := fields["response_body"].(string); got != `{"password":"[REDACTED]"` {
+		t.Errorf("expected the truncated password to be masked, got %q", got)
+	}
+}
+
+func TestDebugLogMiddleware_Selection(t *testing.T) {
+	cfg := config.DebugLogConfig{Routes: []string{"/api/analytics/"}, APIKeyIDs: []int{7}, SampleRate: 0.5, MaxBodyBytes: 64}
+
+	tests := []struct {
+		name   string
+		path   string
+		claims *domain.Claims
+		header http.Header
+		sample float64
+		want   bool
+	}{
+		{name: "debugged route", path: "/api/analytics/stats", sample: 0.2, want: true},
+		{name: "other route", path: "/api/delivery/deliveries", sample: 0.2},
+		{name: "debugged API key", path: "/api/delivery/deliveries", claims: &domain.Claims{UserID: 1, APIKeyID: 7}, sample: 0.2, want: true},
+		{name: "other API key", path: "/api/delivery/deliveries", claims: &domain.Claims{UserID: 1, APIKeyID: 8}, sample: 0.2},
+		{name: "not sampled", path: "/api/analytics/stats", sample: 0.7},
+		{name: "upgrade", path: "/api/analytics/stats", header: http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}}, sample: 0.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, logs := newDebugLogGateway(t, cfg)
+			g.bodyLogger.sample = func() float64 { return tt.sample }
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			if tt.claims != nil {
+				req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+			}
+			g.debugLogMiddleware(okHandler)(httptest.NewRecorder(), req)
+
+			if got := logs.FilterMessage("Debug request captured").Len() == 1; got != tt.want {
+				t.Errorf("expected captured %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDebugLogMiddleware_KeepsFlushing(t *testing.T) {
+	g, _ := newDebugLogGateway(t, config.DebugLogConfig{Routes: []string{"/api/"}, SampleRate: 1, MaxBodyBytes: 64})
+
+	rec := httptest.NewRecorder()
+	g.debugLogMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("expected the captured writer to flush, got %v", err)
+		}
+	})(rec, httptest.NewRequest(http.MethodGet, "/api/tracking/stream", nil))
+
+	if !rec.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+}
+
+func TestNewBodyLogger_DisabledWithoutSelection(t *testing.T) {
+	for _, cfg := range []config.DebugLogConfig{
+		{SampleRate: 1, MaxBodyBytes: 64},
+		{Routes: []string{"/api/"}, MaxBodyBytes: 64},
+		{APIKeyIDs: []int{1}, SampleRate: 1},
+	} {
+		if l := newBodyLogger(cfg); l != nil {
+			t.Errorf("expected no body logger for %+v", cfg)
+		}
+	}
+}
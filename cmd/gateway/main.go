@@ -1,35 +1,58 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
 	authAdapters "github.com/Keneke-Einar/delivertrack/pkg/auth/adapters"
 	authApp "github.com/Keneke-Einar/delivertrack/pkg/auth/app"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
 	authPorts "github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
 
+	"github.com/Keneke-Einar/delivertrack/migrations"
+	"github.com/Keneke-Einar/delivertrack/pkg/cache"
 	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	pkghttp "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/http/httpmiddleware"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
-	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
 
-	"github.com/didip/tollbooth"
-	"github.com/didip/tollbooth/limiter"
 	"go.uber.org/zap"
 )
 
 var version = "dev"
 
 type Gateway struct {
-	authService authPorts.AuthService
-	logger      *logger.Logger
+	authService   authPorts.AuthService
+	rateLimiter   *RateLimiter
+	upstreams     map[string]*upstream
+	responseCache *responseCache // nil unless a route is cached
+	bodyLogger    *bodyLogger    // nil unless debug logging is configured
+	logger        *logger.Logger
+	maxBodyBytes  int64  // outer bound on request bodies; zero means none
+	gatewaySecret string // sent with identity headers so services can trust them
+	wsConnections int64  // active WebSocket tunnels, updated atomically
+
+	allowQueryToken bool // also accept WebSocket tokens in the deprecated token query parameter
 }
 
 func main() {
@@ -40,16 +63,23 @@ func main() {
 
 	port := cfg.Service.Port
 	databaseURL := cfg.Database.URL
-	jwtSecret := cfg.Auth.JWTSecret
 
 	// Initialize logger
 	lg, err := logger.NewLogger(cfg.Logging, "gateway")
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	logger.SetGlobal(lg)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), "gateway", version)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize database for auth
-	db, err := postgres.New(databaseURL)
+	db, err := postgres.New(databaseURL, postgres.OptionsFromConfig(cfg.Database))
 	if err != nil {
 		lg.Fatal("Failed to connect to database", zap.Error(err))
 	}
@@ -57,26 +87,84 @@ func main() {
 
 	lg.Info("Database connection established")
 
+	// Apply pending schema migrations, or refuse to start against an outdated schema
+	applied, err := migrate.Ensure(context.Background(), db.DB, migrations.FS, cfg.Database.AutoMigrate)
+	if err != nil {
+		lg.Fatal("Database schema check failed, set DB_AUTO_MIGRATE=true to apply pending migrations", zap.Error(err))
+	}
+	for _, migration := range applied {
+		lg.Info("Applied database migration", zap.String("migration", migration.String()))
+	}
+
 	// Initialize auth service
 	userRepo := authAdapters.NewPostgresUserRepository(db.DB)
-	tokenService := authAdapters.NewJWTTokenService(jwtSecret, cfg.Auth.JWTExpiration)
+	tokenService, err := authAdapters.NewJWTTokenServiceFromConfig(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT keys: %v", err)
+	}
 	authService := authApp.NewAuthService(userRepo, tokenService)
+	loginAttempts, err := authAdapters.NewLoginAttemptStore(cfg.Auth.Lockout.Store, db.DB)
+	if err != nil {
+		log.Fatalf("Failed to create login attempt store: %v", err)
+	}
+	authService.SetLockout(loginAttempts, authApp.LockoutConfig{
+		MaxFailures:   cfg.Auth.Lockout.MaxFailures,
+		IPMaxFailures: cfg.Auth.Lockout.IPMaxFailures,
+		Window:        cfg.Auth.Lockout.Window,
+		Cooldown:      cfg.Auth.Lockout.Cooldown,
+	})
+	authService.SetAPIKeyRepository(authAdapters.NewPostgresAPIKeyRepository(db.DB))
+
+	// Audit log of account and other sensitive changes, written in the background
+	auditStore := audit.NewPostgresStore(db.DB)
+	auditWriter := audit.NewWriter(auditStore, audit.DefaultBufferSize, lg)
+	defer auditWriter.Close()
+	authService.SetAuditWriter(auditWriter)
+
+	trustedProxies, err := pkghttp.ParseTrustedProxies(cfg.Service.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
 
 	gateway := &Gateway{
-		authService: authService,
-		logger:      lg,
+		authService:   authService,
+		rateLimiter:   NewRateLimiter(cfg.RateLimit, trustedProxies),
+		upstreams:     make(map[string]*upstream),
+		logger:        lg,
+		maxBodyBytes:  cfg.Service.MaxBodyBytes,
+		gatewaySecret: cfg.Auth.GatewaySecret,
+		bodyLogger:    newBodyLogger(cfg.DebugLog),
+
+		allowQueryToken: cfg.Tracking.WSAllowQueryToken,
+	}
+	if gateway.bodyLogger != nil {
+		lg.Warn("Debug body logging enabled",
+			zap.Strings("routes", cfg.DebugLog.Routes),
+			zap.Ints("api_key_ids", cfg.DebugLog.APIKeyIDs),
+			zap.Float64("sample_rate", cfg.DebugLog.SampleRate))
 	}
 
-	// Setup rate limiter
-	limiter := tollbooth.NewLimiter(10, nil) // 10 requests per second
-	limiter.SetIPLookups([]string{"X-Real-IP", "X-Forwarded-For", "RemoteAddr"})
-	limiter.SetMethods([]string{"GET", "POST", "PUT", "DELETE"})
+	// Opt-in cache of GET responses, shared by replicas through Redis when configured
+	if len(cfg.ResponseCache.Routes) > 0 {
+		var store responseStore = newMemoryResponseStore(cfg.ResponseCache.MaxEntries)
+		if cfg.ResponseCache.Store == "redis" {
+			redisClient, err := cache.New(cfg.Redis.URL)
+			if err != nil {
+				log.Fatalf("Failed to configure Redis: %v", err)
+			}
+			defer redisClient.Close()
+			store = &redisResponseStore{redis: redisClient}
+		}
+		gateway.responseCache = newResponseCache(cfg.ResponseCache, store)
+		lg.Info("Response cache configured", zap.String("store", cfg.ResponseCache.Store), zap.Int("routes", len(cfg.ResponseCache.Routes)))
+	}
 
 	// Setup router
 	mux := http.NewServeMux()
 
 	// Health check
 	mux.HandleFunc("/health", gateway.healthHandler)
+	mux.HandleFunc("/metrics", gateway.metricsHandler)
 
 	// Proxy target URLs: prefer env vars (set in docker-compose), then config, then localhost fallback
 	deliveryURL := os.Getenv("GATEWAY_SERVICES_DELIVERY")
@@ -115,22 +203,55 @@ func main() {
 		zap.String("analytics", analyticsURL),
 	)
 
+	targets := map[string]string{
+		"delivery":     deliveryURL,
+		"tracking":     trackingURL,
+		"notification": notificationURL,
+		"analytics":    analyticsURL,
+	}
+	for name, targetURL := range targets {
+		u, err := newUpstream(name, targetURL, cfg.Upstream, lg)
+		if err != nil {
+			lg.Fatal("Failed to configure upstream", zap.String("service", name), zap.Error(err))
+		}
+		gateway.upstreams[name] = u
+	}
+	go gateway.monitorUpstreams(context.Background(), cfg.Upstream.HealthInterval)
+
 	// API routes
-	mux.Handle("/api/delivery/", gateway.authMiddleware(limiter, gateway.proxyHandler("delivery", deliveryURL)))
-	mux.Handle("/api/tracking/", gateway.authMiddleware(limiter, gateway.proxyHandler("tracking", trackingURL)))
-	mux.Handle("/api/notification/", gateway.authMiddleware(limiter, gateway.proxyHandler("notification", notificationURL)))
-	mux.Handle("/api/analytics/", gateway.authMiddleware(limiter, gateway.proxyHandler("analytics", analyticsURL)))
+	mux.Handle("/api/delivery/", gateway.authMiddleware(gateway.debugLogMiddleware(gateway.cacheMiddleware(gateway.proxyHandler("delivery")))))
+	trackingProxy := gateway.authMiddleware(gateway.debugLogMiddleware(gateway.cacheMiddleware(gateway.proxyHandler("tracking"))))
+	trackingWebSocketProxy := gateway.websocketProxyHandler("tracking")
+	mux.HandleFunc("/api/tracking/", func(w http.ResponseWriter, r *http.Request) {
+		// WebSocket upgrades authenticate via the subprotocol header and are tunneled
+		if strings.HasPrefix(r.URL.Path, "/api/tracking/ws/") && isWebSocketUpgrade(r) {
+			trackingWebSocketProxy(w, r)
+			return
+		}
+		trackingProxy(w, r)
+	})
+	mux.Handle("/api/notification/", gateway.authMiddleware(gateway.debugLogMiddleware(gateway.cacheMiddleware(gateway.proxyHandler("notification")))))
+	mux.Handle("/api/analytics/", gateway.authMiddleware(gateway.debugLogMiddleware(gateway.cacheMiddleware(gateway.proxyHandler("analytics")))))
+	mux.HandleFunc("/api/", gateway.notFoundHandler)
 
-	// Public geocoding routes (no auth required)
-	mux.Handle("/api/geocode/", gateway.geocodeProxyHandler(deliveryURL))
+	// Public geocoding and tracking-number routes (no auth required), served by the delivery service
+	mux.Handle("/api/geocode/", gateway.rateLimitMiddleware(gateway.publicDeliveryProxyHandler()))
+	mux.Handle("/api/track/", gateway.rateLimitMiddleware(gateway.publicDeliveryProxyHandler()))
 
 	// Auth routes (public)
 	authHandler := authAdapters.NewHTTPHandler(gateway.authService, cfg.Auth.JWTExpiration)
-	mux.HandleFunc("/login", authHandler.Login)
-	mux.HandleFunc("/register", authHandler.Register)
+	authHandler.SetTrustedProxies(trustedProxies)
+	mux.Handle("/login", gateway.rateLimitMiddleware(authHandler.Login))
+	mux.Handle("/register", gateway.rateLimitMiddleware(authHandler.Register))
 
-	// Wrap with logging and CORS
-	handler := gateway.loggingMiddleware(gateway.corsMiddleware(mux))
+	// Account routes for the signed-in user, and account activation, unlocking
+	// and API keys for admins
+	mux.Handle("/me", gateway.authMiddleware(authHandler.Me))
+	mux.Handle("/me/password", gateway.authMiddleware(authHandler.ChangePassword))
+	mux.Handle("/users/", gateway.authMiddleware(authHandler.Users))
+
+	// Wrap with tracing, logging, CORS and the body size limit
+	handler := tracing.HTTPHandler(pkghttp.RequestID(gateway.loggingMiddleware(gateway.corsMiddleware(gateway.bodyLimitMiddleware(mux)))), "gateway")
 
 	lg.Info("API Gateway starting", zap.String("version", version), zap.String("port", port))
 
@@ -139,116 +260,242 @@ func main() {
 	}
 }
 
-func (g *Gateway) proxyHandler(serviceName, targetURL string) http.HandlerFunc {
-	target, _ := url.Parse(targetURL)
-	proxy := httputil.NewSingleHostReverseProxy(target)
+// newReverseProxy creates a proxy to target that continues the request's trace
+// upstream. Handlers make the path relative to the service before proxying.
+func newReverseProxy(target *url.URL, transport http.RoundTripper) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			forwardedFor(pr)
+		},
+		Transport: tracing.HTTPTransport(transport),
+		ModifyResponse: func(resp *http.Response) error {
+			// Upstreams report the same trace and request ID; keep the single
+			// headers set by the gateway
+			resp.Header.Del(tracing.TraceIDHeader)
+			resp.Header.Del(requestid.Header)
+			return nil
+		},
+	}
+}
 
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Rewrite path: strip /api/servicename prefix
-		// e.g., /api/delivery/deliveries/ becomes /deliveries/
-		prefix := "/api/" + serviceName
-		r.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
-		r.URL.Host = target.Host
-		r.URL.Scheme = target.Scheme
-		r.Header.Set("X-Forwarded-Host", r.Header.Get("Host"))
+// forwardedFor sets X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto
+// on the outgoing request, appending the client to the chain of proxies in
+// front of the gateway
+func forwardedFor(pr *httputil.ProxyRequest) {
+	pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+	pr.SetXForwarded()
+}
 
-		proxy.ServeHTTP(w, r)
+// stripPathPrefix makes u's path relative to prefix. The prefix must end on a
+// segment boundary, so /api/delivery matches /api/delivery/deliveries but not
+// /api/deliveryextra, and the escaped form is kept so encoded segments reach
+// the service unchanged. It reports whether u is under prefix.
+func stripPathPrefix(u *url.URL, prefix string) bool {
+	rest, ok := strings.CutPrefix(u.EscapedPath(), prefix)
+	if !ok || (rest != "" && rest[0] != '/') {
+		return false
 	}
+	if rest == "" {
+		rest = "/"
+	}
+	path, err := url.PathUnescape(rest)
+	if err != nil {
+		return false
+	}
+	u.Path, u.RawPath = path, rest
+	return true
 }
 
-func (g *Gateway) geocodeProxyHandler(targetURL string) http.HandlerFunc {
-	target, _ := url.Parse(targetURL)
-	proxy := httputil.NewSingleHostReverseProxy(target)
+// notFoundHandler answers /api/ paths that match no service
+func (g *Gateway) notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, `{"error":"not_found","message":"No service matches this path"}`, http.StatusNotFound)
+}
+
+func (g *Gateway) proxyHandler(serviceName string) http.HandlerFunc {
+	u := g.upstreams[serviceName]
+	prefix := "/api/" + serviceName
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Rewrite path: strip /api/geocode prefix for geocoding routes
-		// e.g., /api/geocode/forward becomes /geocode/forward
-		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/api")
-		r.URL.Host = target.Host
-		r.URL.Scheme = target.Scheme
-		r.Header.Set("X-Forwarded-Host", r.Header.Get("Host"))
+		// Paths are relative to the service, e.g. /api/delivery/deliveries/
+		// becomes /deliveries/. The request is cloned so the gateway's own
+		// logs keep the original path.
+		out := r.Clone(r.Context())
+		if !stripPathPrefix(out.URL, prefix) {
+			g.notFoundHandler(w, r)
+			return
+		}
+
+		// Replace any identity headers the client sent with the verified ones
+		claims, _ := authctx.ClaimsFrom(r.Context())
+		identity.Forward(out.Header, claims, g.gatewaySecret)
 
-		proxy.ServeHTTP(w, r)
+		u.proxy.ServeHTTP(w, out)
 	}
 }
 
-func (g *Gateway) authMiddleware(lmt *limiter.Limiter, next http.HandlerFunc) http.HandlerFunc {
+func (g *Gateway) publicDeliveryProxyHandler() http.HandlerFunc {
+	u := g.upstreams["delivery"]
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Rate limiting
-		httpError := tollbooth.LimitByRequest(lmt, w, r)
-		if httpError != nil {
-			g.logger.WithFields(
-				zap.String("ip", r.RemoteAddr),
-				zap.String("path", r.URL.Path),
-				zap.String("error", "rate limited"),
-			).Warn("Request rate limited")
+		// Public delivery routes drop the /api prefix, e.g. /api/geocode/forward
+		// becomes /geocode/forward and /api/track/DT-000001Y becomes /track/DT-000001Y
+		out := r.Clone(r.Context())
+		if !stripPathPrefix(out.URL, "/api") {
+			g.notFoundHandler(w, r)
 			return
 		}
+		identity.Strip(out.Header)
+
+		u.proxy.ServeHTTP(w, out)
+	}
+}
 
-		// Authentication
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error":"unauthorized","message":"Authorization header required"}`, http.StatusUnauthorized)
+func (g *Gateway) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	// Per-caller rate limiting once authenticated: partner backends per API
+	// key, everyone else per user since many couriers share NATed IPs
+	authenticated := httpmiddleware.Auth(g.authService, httpmiddleware.WithCheck(
+		func(w http.ResponseWriter, r *http.Request, claims *authDomain.Claims) bool {
+			if claims.APIKeyID != 0 {
+				return g.allowAPIKey(w, r, claims.APIKeyID)
+			}
+			return g.allowUser(w, r, claims.UserID)
+		}))(next)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Route rate limiting by client IP
+		if !g.allowRoute(w, r) {
 			return
 		}
+		authenticated(w, r)
+	}
+}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid authorization header format"}`, http.StatusUnauthorized)
+// rateLimitMiddleware applies route rate limiting to public endpoints
+func (g *Gateway) rateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !g.allowRoute(w, r) {
 			return
 		}
+		next.ServeHTTP(w, r)
+	}
+}
 
-		token := parts[1]
+// allowRoute checks the route limit and writes a 429 when it is exceeded
+func (g *Gateway) allowRoute(w http.ResponseWriter, r *http.Request) bool {
+	return g.enforce(w, r, g.rateLimiter.CheckRoute(r))
+}
 
-		claims, err := g.authService.ValidateToken(r.Context(), token)
-		if err != nil {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid or expired token"}`, http.StatusUnauthorized)
-			return
-		}
+// allowUser checks the per-user limit and writes a 429 when it is exceeded
+func (g *Gateway) allowUser(w http.ResponseWriter, r *http.Request, userID int) bool {
+	return g.enforce(w, r, g.rateLimiter.CheckUser(userID))
+}
 
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "username", claims.Username)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		ctx = context.WithValue(ctx, "customer_id", claims.CustomerID)
-		ctx = context.WithValue(ctx, "courier_id", claims.CourierID)
+// allowAPIKey checks the per-key limit and writes a 429 when it is exceeded
+func (g *Gateway) allowAPIKey(w http.ResponseWriter, r *http.Request, keyID int) bool {
+	return g.enforce(w, r, g.rateLimiter.CheckAPIKey(keyID))
+}
+
+// enforce logs a limiter decision and rejects the request if it was denied
+func (g *Gateway) enforce(w http.ResponseWriter, r *http.Request, decision rateLimitDecision) bool {
+	fields := []zap.Field{
+		zap.String("bucket", decision.Bucket),
+		zap.String("key", decision.Key),
+		zap.Bool("allowed", decision.Allowed),
+		zap.String("path", r.URL.Path),
+		zap.String("trace_id", r.Header.Get("X-Trace-ID")),
+	}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+	if decision.Allowed {
+		g.logger.WithFields(fields...).Debug("Rate limit check passed")
+		return true
 	}
+
+	g.logger.WithFields(append(fields, zap.Int("retry_after", decision.RetryAfter))...).Warn("Request rate limited")
+	writeRateLimited(w, decision)
+	return false
 }
 
+// healthHandler reports the gateway and its upstreams. Status is "degraded"
+// while some upstreams are down or have an open circuit and "unavailable",
+// with a 503, once all of them are.
 func (g *Gateway) healthHandler(w http.ResponseWriter, r *http.Request) {
+	upstreams := make(map[string]upstreamStatus, len(g.upstreams))
+	down := 0
+	for name, u := range g.upstreams {
+		s := u.status()
+		upstreams[name] = s
+		if s.Status == healthDown || s.Circuit == resilience.StateOpen.String() {
+			down++
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	switch {
+	case len(upstreams) > 0 && down == len(upstreams):
+		status, code = "unavailable", http.StatusServiceUnavailable
+	case down > 0:
+		status = "degraded"
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"status":"ok","service":"gateway","version":"%s"}`, version)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    status,
+		"service":   "gateway",
+		"version":   version,
+		"upstreams": upstreams,
+	})
 }
 
-func (g *Gateway) loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Create trace context for incoming request
-		traceID := messaging.GenerateTraceID()
-		spanID := messaging.GenerateSpanID()
+// monitorUpstreams checks every upstream's health each interval until ctx ends
+func (g *Gateway) monitorUpstreams(ctx context.Context, interval time.Duration) {
+	client := &http.Client{}
+	check := func() {
+		var wg sync.WaitGroup
+		for _, u := range g.upstreams {
+			wg.Add(1)
+			go func(u *upstream) {
+				defer wg.Done()
+				u.checkHealth(ctx, client)
+			}(u)
+		}
+		wg.Wait()
+	}
 
-		traceCtx := &messaging.TraceContext{
-			TraceID:     traceID,
-			SpanID:      spanID,
-			ServiceName: "gateway",
-			Operation:   r.Method + " " + r.URL.Path,
+	check()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
 		}
+	}
+}
+
+func (g *Gateway) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"websocket_connections": %d}`, atomic.LoadInt64(&g.wsConnections))
+}
 
-		// Add trace context to request context
-		ctx := messaging.ContextWithTraceContext(r.Context(), traceCtx)
+func (g *Gateway) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
 
-		// Add trace headers to request for downstream services
-		r.Header.Set("X-Trace-ID", traceID)
-		r.Header.Set("X-Span-ID", spanID)
+		// Expose the request's trace ID to the logs of every handler downstream
+		traceID := tracing.TraceID(r.Context())
+		r.Header.Set(tracing.TraceIDHeader, traceID)
 
 		// Create a response writer wrapper to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
-		next.ServeHTTP(wrapped, r.WithContext(ctx))
+		next.ServeHTTP(wrapped, r)
 
 		g.logger.WithFields(
 			zap.String("method", r.Method),
@@ -258,6 +505,7 @@ func (g *Gateway) loggingMiddleware(next http.Handler) http.Handler {
 			zap.String("user_agent", r.Header.Get("User-Agent")),
 			zap.String("remote_addr", r.RemoteAddr),
 			zap.String("trace_id", traceID),
+			zap.String("request_id", requestid.FromContext(r.Context())),
 		).Info("Request processed")
 	})
 }
@@ -266,7 +514,8 @@ func (g *Gateway) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+requestid.Header)
+		w.Header().Set("Access-Control-Expose-Headers", requestid.Header+", "+tracing.TraceIDHeader+", "+cacheHeader+", Age")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -277,6 +526,33 @@ func (g *Gateway) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// bodyLimitMiddleware rejects request bodies over maxBodyBytes, upfront when
+// the declared length is over and once reading passes it otherwise
+func (g *Gateway) bodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.maxBodyBytes <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.ContentLength > g.maxBodyBytes {
+			writeBodyTooLarge(w, g.maxBodyBytes)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, g.maxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeBodyTooLarge sends a 413 naming the body size limit
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   "request_too_large",
+		"message": fmt.Sprintf("Request body too large, the limit is %d bytes", limit),
+	})
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -287,3 +563,13 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Hijack lets WebSocket tunnels take over the underlying connection
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	rw.statusCode = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
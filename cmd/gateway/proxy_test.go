@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+)
+
+// forwarded is the part of a proxied request the upstream saw
+type forwarded struct {
+	path    string
+	query   string
+	headers http.Header
+}
+
+func newRecordingUpstream(t *testing.T) (*httptest.Server, chan forwarded) {
+	received := make(chan forwarded, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- forwarded{path: r.URL.EscapedPath(), query: r.URL.RawQuery, headers: r.Header.Clone()}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestProxyHandler_Paths(t *testing.T) {
+	server, received := newRecordingUpstream(t)
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.upstreams = map[string]*upstream{"delivery": newTestUpstream(t, "delivery", server.URL)}
+	handler := g.proxyHandler("delivery")
+
+	tests := []struct {
+		name      string
+		path      string
+		wantPath  string
+		wantQuery string
+	}{
+		{name: "collection", path: "/api/delivery/deliveries", wantPath: "/deliveries"},
+		{name: "trailing slash", path: "/api/delivery/deliveries/", wantPath: "/deliveries/"},
+		{name: "service root", path: "/api/delivery", wantPath: "/"},
+		{name: "service root with slash", path: "/api/delivery/", wantPath: "/"},
+		{
+			name:      "query string",
+			path:      "/api/delivery/deliveries?status=pending&page=2&q=a%26b",
+			wantPath:  "/deliveries",
+			wantQuery: "status=pending&page=2&q=a%26b",
+		},
+		{name: "encoded slash", path: "/api/delivery/track/DT%2F000001", wantPath: "/track/DT%2F000001"},
+		{name: "encoded space", path: "/api/delivery/couriers/a%20b/route", wantPath: "/couriers/a%20b/route"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			handler(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+
+			got := <-received
+			if got.path != tt.wantPath {
+				t.Errorf("upstream path = %q, want %q", got.path, tt.wantPath)
+			}
+			if got.query != tt.wantQuery {
+				t.Errorf("upstream query = %q, want %q", got.query, tt.wantQuery)
+			}
+			if !strings.HasPrefix(req.URL.EscapedPath(), "/api/delivery") {
+				t.Errorf("expected the gateway's request to keep its path, got %q", req.URL.EscapedPath())
+			}
+		})
+	}
+}
+
+func TestProxyHandler_RejectsOtherPrefixes(t *testing.T) {
+	server, received := newRecordingUpstream(t)
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.upstreams = map[string]*upstream{"delivery": newTestUpstream(t, "delivery", server.URL)}
+
+	rec := httptest.NewRecorder()
+	g.proxyHandler("delivery")(rec, httptest.NewRequest(http.MethodGet, "/api/deliveryextra/foo", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	select {
+	case got := <-received:
+		t.Errorf("expected nothing proxied, upstream got %q", got.path)
+	default:
+	}
+}
+
+func TestProxyHandler_ForwardedHeaders(t *testing.T) {
+	server, received := newRecordingUpstream(t)
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.upstreams = map[string]*upstream{"delivery": newTestUpstream(t, "delivery", server.URL)}
+
+	req := httptest.NewRequest(http.MethodGet, "http://gateway.example.com/api/track/DT-000001Y", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Forwarded-Host", "spoofed.example.com")
+	rec := httptest.NewRecorder()
+	g.publicDeliveryProxyHandler()(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	got := <-received
+	if got.path != "/track/DT-000001Y" {
+		t.Errorf("upstream path = %q, want /track/DT-000001Y", got.path)
+	}
+	want := map[string]string{
+		"X-Forwarded-For":   "203.0.113.7, 10.0.0.1",
+		"X-Forwarded-Host":  "gateway.example.com",
+		"X-Forwarded-Proto": "http",
+	}
+	for header, value := range want {
+		if got.headers.Get(header) != value {
+			t.Errorf("%s = %q, want %q", header, got.headers.Get(header), value)
+		}
+	}
+}
+
+func TestStripPathPrefix(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   string
+		ok     bool
+	}{
+		{"/api/tracking/ws/deliveries/1", "/api/tracking", "/ws/deliveries/1", true},
+		{"/api/geocode/forward", "/api", "/geocode/forward", true},
+		{"/api/trackingx", "/api/tracking", "", false},
+		{"/other", "/api", "", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		ok := stripPathPrefix(req.URL, tt.prefix)
+		if ok != tt.ok || (ok && req.URL.EscapedPath() != tt.want) {
+			t.Errorf("stripPathPrefix(%q, %q) = %q, %v, want %q, %v", tt.path, tt.prefix, req.URL.EscapedPath(), ok, tt.want, tt.ok)
+		}
+	}
+}
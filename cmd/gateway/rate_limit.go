@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	pkghttp "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/didip/tollbooth"
+	"github.com/didip/tollbooth/limiter"
+)
+
+// routeLimiter is a token bucket limiter applied to paths under prefix
+type routeLimiter struct {
+	prefix  string
+	limiter *limiter.Limiter
+}
+
+// RateLimiter applies per-route limits keyed by client IP, per-user limits
+// keyed by the authenticated user ID and per-key limits keyed by API key ID.
+// Client IPs come from forwarding headers only behind a trusted proxy, so
+// rotating a spoofed X-Forwarded-For doesn't buy a fresh bucket.
+type RateLimiter struct {
+	routes       []routeLimiter
+	defaultRoute routeLimiter
+	user         *limiter.Limiter
+	apiKey       *limiter.Limiter
+	proxies      pkghttp.TrustedProxies
+}
+
+// rateLimitDecision describes the outcome of a limiter check
+type rateLimitDecision struct {
+	Allowed    bool
+	Bucket     string
+	Key        string
+	RetryAfter int
+}
+
+// NewRateLimiter builds a rate limiter from configuration, trusting the
+// forwarding headers of requests from proxies
+func NewRateLimiter(cfg config.RateLimitConfig, proxies pkghttp.TrustedProxies) *RateLimiter {
+	rl := &RateLimiter{
+		defaultRoute: routeLimiter{prefix: "", limiter: newLimiter(cfg.Default, 0)},
+		proxies:      proxies,
+	}
+
+	if cfg.PerUser > 0 {
+		rl.user = newLimiter(cfg.PerUser, 0)
+	}
+	if cfg.PerAPIKey > 0 {
+		rl.apiKey = newLimiter(cfg.PerAPIKey, 0)
+	}
+
+	for _, route := range cfg.Routes {
+		if route.Prefix == "" || route.Rate <= 0 {
+			continue
+		}
+		rl.routes = append(rl.routes, routeLimiter{prefix: route.Prefix, limiter: newLimiter(route.Rate, route.Burst)})
+	}
+
+	// Longest prefix wins
+	sort.SliceStable(rl.routes, func(i, j int) bool {
+		return len(rl.routes[i].prefix) > len(rl.routes[j].prefix)
+	})
+
+	return rl
+}
+
+// newLimiter creates a tollbooth limiter
+func newLimiter(rate float64, burst int) *limiter.Limiter {
+	lmt := tollbooth.NewLimiter(rate, nil)
+	if burst > 0 {
+		lmt.SetBurst(burst)
+	}
+	return lmt
+}
+
+// routeFor returns the limiter whose prefix best matches the path
+func (rl *RateLimiter) routeFor(path string) routeLimiter {
+	for _, route := range rl.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route
+		}
+	}
+	return rl.defaultRoute
+}
+
+// CheckRoute applies the route limit for the request, keyed by client IP
+func (rl *RateLimiter) CheckRoute(r *http.Request) rateLimitDecision {
+	route := rl.routeFor(r.URL.Path)
+	ip := rl.proxies.ClientIP(r)
+
+	bucket := route.prefix
+	if bucket == "" {
+		bucket = "default"
+	}
+
+	return check(route.limiter, "route:"+bucket, ip)
+}
+
+// CheckUser applies the per-user limit, keyed by user ID
+func (rl *RateLimiter) CheckUser(userID int) rateLimitDecision {
+	if rl.user == nil {
+		return rateLimitDecision{Allowed: true, Bucket: "user"}
+	}
+	return check(rl.user, "user", strconv.Itoa(userID))
+}
+
+// CheckAPIKey applies the per-key limit, keyed by API key ID, so a partner's
+// keys do not share one budget with the user owning them
+func (rl *RateLimiter) CheckAPIKey(keyID int) rateLimitDecision {
+	if rl.apiKey == nil {
+		return rateLimitDecision{Allowed: true, Bucket: "api_key"}
+	}
+	return check(rl.apiKey, "api_key", strconv.Itoa(keyID))
+}
+
+// check consumes a token from the bucket identified by bucket and key
+func check(lmt *limiter.Limiter, bucket, key string) rateLimitDecision {
+	decision := rateLimitDecision{Bucket: bucket, Key: key, Allowed: true}
+	if httpError := tollbooth.LimitByKeys(lmt, []string{bucket, key}); httpError != nil {
+		decision.Allowed = false
+		decision.RetryAfter = retryAfterSeconds(lmt.GetMax())
+	}
+	return decision
+}
+
+// retryAfterSeconds is the time until the bucket refills one token, rounded up
+func retryAfterSeconds(rate float64) int {
+	if rate <= 0 {
+		return 1
+	}
+	seconds := int(math.Ceil(1 / rate))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// writeRateLimited sends a 429 response with Retry-After and a JSON body
+func writeRateLimited(w http.ResponseWriter, decision rateLimitDecision) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(decision.RetryAfter))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       "rate_limited",
+		"message":     fmt.Sprintf("Rate limit exceeded, retry after %d seconds", decision.RetryAfter),
+		"retry_after": decision.RetryAfter,
+	})
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	pkghttp "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+// mockAuthService maps bearer tokens to user IDs and API keys to key IDs of user 1
+type mockAuthService struct {
+	users   map[string]int
+	apiKeys map[string]int
+}
+
+func (m *mockAuthService) Register(ctx context.Context, username, email, password, role string, orgID int, customerID, courierID *int) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) Authenticate(ctx context.Context, username, password string) (string, *domain.User, error) {
+	return "", nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.Claims, error) {
+	userID, ok := m.users[tokenString]
+	if !ok {
+		return nil, domain.ErrInvalidToken
+	}
+	return &domain.Claims{UserID: userID, Role: domain.RoleCourier}, nil
+}
+
+func (m *mockAuthService) GetUser(ctx context.Context, id int) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) UpdateEmail(ctx context.Context, id int, email string) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockAuthService) SetUserActive(ctx context.Context, id int, active bool) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) SetUserOrganization(ctx context.Context, id, orgID int) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) UnlockUser(ctx context.Context, id int) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockAuthService) IssueAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	return nil, "", errors.New("not implemented")
+}
+
+func (m *mockAuthService) ListAPIKeys(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockAuthService) ValidateAPIKey(ctx context.Context, key string) (*domain.Claims, error) {
+	keyID, ok := m.apiKeys[key]
+	if !ok {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return &domain.Claims{UserID: 1, Role: domain.RoleCustomer, APIKeyID: keyID}, nil
+}
+
+func newTestGateway(t *testing.T, cfg config.RateLimitConfig) *Gateway {
+	return &Gateway{
+		authService: &mockAuthService{
+			users:   map[string]int{"token-1": 1, "token-2": 2},
+			apiKeys: map[string]int{"key-1": 1, "key-2": 2},
+		},
+		rateLimiter: NewRateLimiter(cfg, nil),
+		logger:      &logger.Logger{Logger: zaptest.NewLogger(t)},
+	}
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func doRequest(handler http.HandlerFunc, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestRateLimiter_PerRouteBuckets(t *testing.T) {
+	g := newTestGateway(t, config.RateLimitConfig{
+		Default: 100,
+		Routes: []config.RouteRateLimit{
+			{Prefix: "/login", Rate: 1, Burst: 1},
+			{Prefix: "/api/tracking/locations", Rate: 100, Burst: 100},
+		},
+	})
+	handler := g.rateLimitMiddleware(okHandler)
+
+	if rec := doRequest(handler, "/login", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected first login to pass, got %d", rec.Code)
+	}
+
+	rec := doRequest(handler, "/login", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected second login to be limited, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After 1, got %q", rec.Header().Get("Retry-After"))
+	}
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON content type, got %q", rec.Header().Get("Content-Type"))
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body["error"] != "rate_limited" {
+		t.Errorf("expected rate_limited error, got %v", body["error"])
+	}
+
+	// Other routes have their own buckets
+	for i := 0; i < 10; i++ {
+		if rec := doRequest(handler, "/api/tracking/locations", ""); rec.Code != http.StatusOK {
+			t.Fatalf("expected location update %d to pass, got %d", i, rec.Code)
+		}
+	}
+}
+
+func TestRateLimiter_LongestPrefixWins(t *testing.T) {
+	rl := NewRateLimiter(config.RateLimitConfig{
+		Default: 10,
+		Routes: []config.RouteRateLimit{
+			{Prefix: "/api/tracking", Rate: 20},
+			{Prefix: "/api/tracking/locations", Rate: 50},
+		},
+	}, nil)
+
+	tests := []struct {
+		path   string
+		prefix string
+	}{
+		{"/api/tracking/locations", "/api/tracking/locations"},
+		{"/api/tracking/deliveries/1/track", "/api/tracking"},
+		{"/api/delivery/deliveries", ""},
+	}
+
+	for _, tt := range tests {
+		if got := rl.routeFor(tt.path).prefix; got != tt.prefix {
+			t.Errorf("path %s: expected prefix %q, got %q", tt.path, tt.prefix, got)
+		}
+	}
+}
+
+func TestRateLimiter_PerUserBuckets(t *testing.T) {
+	g := newTestGateway(t, config.RateLimitConfig{
+		Default: 100,
+		PerUser: 1,
+	})
+	handler := g.authMiddleware(okHandler)
+
+	// Both users share the same IP; only the per-user bucket should trip
+	if rec := doRequest(handler, "/api/delivery/deliveries", "token-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected user 1 first request to pass, got %d", rec.Code)
+	}
+	if rec := doRequest(handler, "/api/delivery/deliveries", "token-1"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected user 1 second request to be limited, got %d", rec.Code)
+	}
+	if rec := doRequest(handler, "/api/delivery/deliveries", "token-2"); rec.Code != http.StatusOK {
+		t.Fatalf("expected user 2 to have its own bucket, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiter_UnauthenticatedDoesNotConsumeUserBucket(t *testing.T) {
+	g := newTestGateway(t, config.RateLimitConfig{
+		Default: 100,
+		PerUser: 1,
+	})
+	handler := g.authMiddleware(okHandler)
+
+	if rec := doRequest(handler, "/api/delivery/deliveries", "bad-token"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for invalid token, got %d", rec.Code)
+	}
+	if rec := doRequest(handler, "/api/delivery/deliveries", "token-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected user 1 to pass, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiter_PerAPIKeyBuckets(t *testing.T) {
+	g := newTestGateway(t, config.RateLimitConfig{
+		Default:   100,
+		PerUser:   1,
+		PerAPIKey: 1,
+	})
+	handler := g.authMiddleware(okHandler)
+
+	doKeyRequest := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/delivery/deliveries", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set(domain.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	// Both keys belong to user 1, yet each has its own bucket apart from the user's
+	if code := doKeyRequest("key-1"); code != http.StatusOK {
+		t.Fatalf("expected key 1 first request to pass, got %d", code)
+	}
+	if code := doKeyRequest("key-1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected key 1 second request to be limited, got %d", code)
+	}
+	if code := doKeyRequest("key-2"); code != http.StatusOK {
+		t.Fatalf("expected key 2 to have its own bucket, got %d", code)
+	}
+	if rec := doRequest(handler, "/api/delivery/deliveries", "token-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected user 1's token to keep its own bucket, got %d", rec.Code)
+	}
+	if code := doKeyRequest("unknown-key"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown key, got %d", code)
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		rate     float64
+		expected int
+	}{
+		{10, 1},
+		{1, 1},
+		{0.5, 2},
+		{0.1, 10},
+		{0, 1},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfterSeconds(tt.rate); got != tt.expected {
+			t.Errorf("rate %v: expected %d, got %d", tt.rate, tt.expected, got)
+		}
+	}
+}
+
+func TestRateLimiter_ClientIPBehindTrustedProxy(t *testing.T) {
+	proxies, err := pkghttp.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("failed to parse proxies: %v", err)
+	}
+	rl := NewRateLimiter(config.RateLimitConfig{Default: 1}, nil)
+	behindProxy := NewRateLimiter(config.RateLimitConfig{Default: 1}, proxies)
+
+	request := func(remoteAddr, forwardedFor string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/delivery/deliveries", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		return req
+	}
+
+	// Without a trusted proxy a spoofed header doesn't buy a fresh bucket
+	if decision := rl.CheckRoute(request("203.0.113.7:1234", "198.51.100.1")); !decision.Allowed || decision.Key != "203.0.113.7" {
+		t.Fatalf("expected the first request to pass keyed by RemoteAddr, got %+v", decision)
+	}
+	if decision := rl.CheckRoute(request("203.0.113.7:1234", "198.51.100.2")); decision.Allowed {
+		t.Error("expected a rotated X-Forwarded-For to share the bucket")
+	}
+
+	// Behind a trusted proxy clients are told apart by the address it appended
+	for _, client := range []string{"198.51.100.1", "198.51.100.2"} {
+		decision := behindProxy.CheckRoute(request("10.0.0.5:1234", "192.0.2.99, "+client))
+		if !decision.Allowed || decision.Key != client {
+			t.Errorf("expected client %s to get its own bucket, got %+v", client, decision)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/cache"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	pkghttp "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Response cache settings
+const (
+	// responseCacheKeyPrefix namespaces cached responses. Keys continue with
+	// the route prefix, so a purge can drop a route's entries by prefix.
+	responseCacheKeyPrefix = "gateway:response:"
+	// maxCachedBodyBytes bounds the bodies kept; larger responses pass through uncached
+	maxCachedBodyBytes = 1 << 20
+	// cacheHeader tells clients whether a response came from the cache
+	cacheHeader = "X-Cache"
+)
+
+// cachedResponse is a stored upstream response
+type cachedResponse struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// responseStore keeps cached responses until their TTL passes
+type responseStore interface {
+	Get(ctx context.Context, key string) (*cachedResponse, bool, error)
+	Set(ctx context.Context, key string, resp *cachedResponse, ttl time.Duration) error
+}
+
+// cacheRoute is the caching rule for paths under prefix
+type cacheRoute struct {
+	prefix      string
+	ttl         time.Duration
+	uncacheable bool
+}
+
+// responseCache caches GET responses of configured routes per caller
+type responseCache struct {
+	routes []cacheRoute
+	store  responseStore
+}
+
+// newResponseCache builds a response cache over store, or returns nil when no
+// route caches anything
+func newResponseCache(cfg config.ResponseCacheConfig, store responseStore) *responseCache {
+	c := &responseCache{store: store}
+	cacheable := false
+	for _, route := range cfg.Routes {
+		if route.Prefix == "" || (route.TTL <= 0 && !route.Uncacheable) {
+			continue
+		}
+		c.routes = append(c.routes, cacheRoute{prefix: route.Prefix, ttl: route.TTL, uncacheable: route.Uncacheable})
+		cacheable = cacheable || !route.Uncacheable
+	}
+	if !cacheable {
+		return nil
+	}
+
+	// Longest prefix wins
+	sort.SliceStable(c.routes, func(i, j int) bool {
+		return len(c.routes[i].prefix) > len(c.routes[j].prefix)
+	})
+
+	return c
+}
+
+// routeFor returns the rule whose prefix best matches the path, if any
+func (c *responseCache) routeFor(path string) (cacheRoute, bool) {
+	for _, route := range c.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route, !route.uncacheable
+		}
+	}
+	return cacheRoute{}, false
+}
+
+// cacheKey identifies a response by route, caller, path and sorted query.
+// Callers are told apart by their customer or courier, falling back to the
+// user, so cached bodies are never shared across tenants.
+func cacheKey(route cacheRoute, r *http.Request) (string, bool) {
+	claims, ok := authctx.ClaimsFrom(r.Context())
+	if !ok {
+		return "", false
+	}
+
+	var caller string
+	switch {
+	case claims.CustomerID != nil:
+		caller = "customer:" + strconv.Itoa(*claims.CustomerID)
+	case claims.CourierID != nil:
+		caller = "courier:" + strconv.Itoa(*claims.CourierID)
+	default:
+		caller = "user:" + strconv.Itoa(claims.UserID)
+	}
+
+	// Encode sorts by parameter name
+	return fmt.Sprintf("%s%s|%s:%s|%s?%s", responseCacheKeyPrefix, route.prefix, claims.Role, caller,
+		r.URL.EscapedPath(), r.URL.Query().Encode()), true
+}
+
+// cacheMiddleware answers GET requests on cached routes from the cache and
+// stores successful responses. Requests with Cache-Control: no-cache skip
+// the lookup but refresh the entry; other methods pass straight through.
+func (g *Gateway) cacheMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g.responseCache == nil || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route, ok := g.responseCache.routeFor(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, ok := cacheKey(route, r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			cached, found, err := g.responseCache.store.Get(r.Context(), key)
+			if err != nil {
+				g.logger.WithFields(zap.String("path", r.URL.Path), zap.Error(err)).Warn("Response cache lookup failed")
+			}
+			if found {
+				writeCachedResponse(w, r, cached)
+				return
+			}
+		}
+
+		w.Header().Set(cacheHeader, "MISS")
+		recorder := newCacheRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		resp, ok := recorder.response()
+		if !ok {
+			return
+		}
+		if err := g.responseCache.store.Set(r.Context(), key, resp, route.ttl); err != nil {
+			g.logger.WithFields(zap.String("path", r.URL.Path), zap.Error(err)).Warn("Failed to cache response")
+		}
+	}
+}
+
+// writeCachedResponse replays a cached response with its age, or a 304 when
+// the request's If-None-Match lists its ETag
+func writeCachedResponse(w http.ResponseWriter, r *http.Request, cached *cachedResponse) {
+	for name, values := range cached.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(cacheHeader, "HIT")
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(cached.StoredAt).Seconds())))
+	if etag := cached.Header.Get("ETag"); etag != "" && pkghttp.ServeConditional(w, r, etag) {
+		return
+	}
+	w.WriteHeader(cached.Status)
+	w.Write(cached.Body)
+}
+
+// cacheRecorder passes a response through while keeping a copy of it
+type cacheRecorder struct {
+	http.ResponseWriter
+	before   http.Header // headers set before the upstream answered
+	header   http.Header // headers the upstream added
+	status   int
+	body     bytes.Buffer
+	tooLarge bool
+}
+
+func newCacheRecorder(w http.ResponseWriter) *cacheRecorder {
+	return &cacheRecorder{ResponseWriter: w, before: w.Header().Clone()}
+}
+
+func (rec *cacheRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+		// Only the upstream's headers are cached, not this request's IDs
+		rec.header = make(http.Header)
+		for name, values := range rec.Header() {
+			if name == cacheHeader {
+				continue
+			}
+			if before, ok := rec.before[name]; !ok || strings.Join(before, ",") != strings.Join(values, ",") {
+				rec.header[name] = append([]string(nil), values...)
+			}
+		}
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *cacheRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.tooLarge {
+		if rec.body.Len()+len(p) > maxCachedBodyBytes {
+			rec.tooLarge = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// response returns the recorded response if it may be cached: a complete 200
+// the upstream did not mark no-store or private
+func (rec *cacheRecorder) response() (*cachedResponse, bool) {
+	if rec.status != http.StatusOK || rec.tooLarge {
+		return nil, false
+	}
+	control := rec.header.Get("Cache-Control")
+	if strings.Contains(control, "no-store") || strings.Contains(control, "private") || rec.header.Get("Set-Cookie") != "" {
+		return nil, false
+	}
+	return &cachedResponse{
+		Status:   rec.status,
+		Header:   rec.header,
+		Body:     bytes.Clone(rec.body.Bytes()),
+		StoredAt: time.Now(),
+	}, true
+}
+
+// memoryResponseStore keeps up to maxEntries responses in process
+type memoryResponseStore struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int
+}
+
+type memoryEntry struct {
+	resp      *cachedResponse
+	expiresAt time.Time
+}
+
+func newMemoryResponseStore(maxEntries int) *memoryResponseStore {
+	return &memoryResponseStore{entries: make(map[string]memoryEntry), maxEntries: maxEntries}
+}
+
+func (s *memoryResponseStore) Get(ctx context.Context, key string) (*cachedResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return entry.resp, true, nil
+}
+
+// Set stores a response. A full store drops expired entries first, then the
+// one closest to expiring.
+func (s *memoryResponseStore) Set(ctx context.Context, key string, resp *cachedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := s.entries[key]; !exists && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		var soonest string
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			} else if soonest == "" || entry.expiresAt.Before(s.entries[soonest].expiresAt) {
+				soonest = k
+			}
+		}
+		if len(s.entries) >= s.maxEntries {
+			delete(s.entries, soonest)
+		}
+	}
+
+	s.entries[key] = memoryEntry{resp: resp, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// redisResponseStore keeps responses in Redis, shared by gateway replicas
+type redisResponseStore struct {
+	redis *cache.Redis
+}
+
+func (s *redisResponseStore) Get(ctx context.Context, key string) (*cachedResponse, bool, error) {
+	data, err := s.redis.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cached response: %w", err)
+	}
+
+	var resp cachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &resp, true, nil
+}
+
+func (s *redisResponseStore) Set(ctx context.Context, key string, resp *cachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	if err := s.redis.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to cache response: %w", err)
+	}
+	return nil
+}
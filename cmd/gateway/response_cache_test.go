@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+)
+
+// countingHandler answers with the number of requests it has served
+func countingHandler(hits *atomic.Int64, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(strconv.FormatInt(n, 10)))
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func cachedRequest(handler http.HandlerFunc, method, path string, claims *domain.Claims, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if claims != nil {
+		req = req.WithContext(authctx.WithClaims(req.Context(), claims))
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func newCachingGateway(t *testing.T) *Gateway {
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.responseCache = newResponseCache(config.ResponseCacheConfig{Routes: []config.ResponseCacheRoute{
+		{Prefix: "/api/delivery/deliveries", TTL: time.Minute},
+		{Prefix: "/api/delivery/deliveries/admin", Uncacheable: true},
+		{Prefix: "/api/analytics/stats", TTL: time.Minute},
+	}}, newMemoryResponseStore(100))
+	return g
+}
+
+func TestCacheMiddleware_HitAfterMiss(t *testing.T) {
+	g := newCachingGateway(t)
+	var hits atomic.Int64
+	handler := g.cacheMiddleware(countingHandler(&hits, http.StatusOK))
+	customer := &domain.Claims{UserID: 1, Role: domain.RoleCustomer, CustomerID: intPtr(5)}
+
+	first := cachedRequest(handler, http.MethodGet, "/api/delivery/deliveries?status=in_transit&limit=10", customer, nil)
+	if first.Header().Get(cacheHeader) != "MISS" || first.Body.String() != "1" {
+		t.Fatalf("expected a miss served by the upstream, got %q %q", first.Header().Get(cacheHeader), first.Body.String())
+	}
+
+	// The same query in another order is the same entry
+	second := cachedRequest(handler, http.MethodGet, "/api/delivery/deliveries?limit=10&status=in_transit", customer, nil)
+	if second.Header().Get(cacheHeader) != "HIT" || second.Body.String() != "1" || second.Code != http.StatusOK {
+		t.Fatalf("expected a cached hit, got %d %q %q", second.Code, second.Header().Get(cacheHeader), second.Body.String())
+	}
+	if second.Header().Get("Age") != "0" || second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected the cached headers with an age, got %v", second.Header())
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 upstream request, got %d", hits.Load())
+	}
+}
+
+func TestCacheMiddleware_KeyedByTenant(t *testing.T) {
+	g := newCachingGateway(t)
+	var hits atomic.Int64
+	handler := g.cacheMiddleware(countingHandler(&hits, http.StatusOK))
+
+	callers := []*domain.Claims{
+		{UserID: 1, Role: domain.RoleCustomer, CustomerID: intPtr(5)},
+		{UserID: 2, Role: domain.RoleCustomer, CustomerID: intPtr(6)},
+		{UserID: 3, Role: domain.RoleCourier, CourierID: intPtr(5)},
+		{UserID: 4, Role: domain.RoleAdmin},
+	}
+	for i, claims := range callers {
+		rec := cachedRequest(handler, http.MethodGet, "/api/analytics/stats/deliveries", claims, nil)
+		if rec.Header().Get(cacheHeader) != "MISS" || rec.Body.String() != strconv.Itoa(i+1) {
+			t.Errorf("caller %d: expected their own upstream response, got %q %q", i, rec.Header().Get(cacheHeader), rec.Body.String())
+		}
+	}
+}
+
+func TestCacheMiddleware_Bypass(t *testing.T) {
+	customer := &domain.Claims{UserID: 1, Role: domain.RoleCustomer, CustomerID: intPtr(5)}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		claims  *domain.Claims
+		header  http.Header
+		status  int
+		wantHit bool
+	}{
+		{name: "cached", method: http.MethodGet, path: "/api/delivery/deliveries", claims: customer, status: http.StatusOK, wantHit: true},
+		{name: "not GET", method: http.MethodPost, path: "/api/delivery/deliveries", claims: customer, status: http.StatusOK},
+		{name: "uncacheable route", method: http.MethodGet, path: "/api/delivery/deliveries/admin/stuck", claims: customer, status: http.StatusOK},
+		{name: "route without TTL", method: http.MethodGet, path: "/api/tracking/locations", claims: customer, status: http.StatusOK},
+		{name: "no-cache", method: http.MethodGet, path: "/api/delivery/deliveries", claims: customer, header: http.Header{"Cache-Control": {"no-cache"}}, status: http.StatusOK},
+		{name: "unauthenticated", method: http.MethodGet, path: "/api/delivery/deliveries", status: http.StatusOK},
+		{name: "error response", method: http.MethodGet, path: "/api/delivery/deliveries", claims: customer, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newCachingGateway(t)
+			var hits atomic.Int64
+			handler := g.cacheMiddleware(countingHandler(&hits, tt.status))
+
+			cachedRequest(handler, tt.method, tt.path, tt.claims, tt.header)
+			rec := cachedRequest(handler, tt.method, tt.path, tt.claims, tt.header)
+
+			if hit := rec.Header().Get(cacheHeader) == "HIT"; hit != tt.wantHit {
+				t.Errorf("expected hit %v, got X-Cache %q", tt.wantHit, rec.Header().Get(cacheHeader))
+			}
+			wantHits := int64(2)
+			if tt.wantHit {
+				wantHits = 1
+			}
+			if hits.Load() != wantHits {
+				t.Errorf("expected %d upstream requests, got %d", wantHits, hits.Load())
+			}
+		})
+	}
+}
+
+func TestCacheMiddleware_NoCacheRefreshesEntry(t *testing.T) {
+	g := newCachingGateway(t)
+	var hits atomic.Int64
+	handler := g.cacheMiddleware(countingHandler(&hits, http.StatusOK))
+	customer := &domain.Claims{UserID: 1, Role: domain.RoleCustomer, CustomerID: intPtr(5)}
+
+	cachedRequest(handler, http.MethodGet, "/api/delivery/deliveries", customer, nil)
+	cachedRequest(handler, http.MethodGet, "/api/delivery/deliveries", customer, http.Header{"Cache-Control": {"no-cache"}})
+
+	rec := cachedRequest(handler, http.MethodGet, "/api/delivery/deliveries", customer, nil)
+	if rec.Header().Get(cacheHeader) != "HIT" || rec.Body.String() != "2" {
+		t.Errorf("expected the refreshed response, got %q %q", rec.Header().Get(cacheHeader), rec.Body.String())
+	}
+}
+
+func TestCacheMiddleware_HitHonorsIfNoneMatch(t *testing.T) {
+	g := newCachingGateway(t)
+	var hits atomic.Int64
+	handler := g.cacheMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("ETag", `W/"1-1"`)
+		w.Write([]byte(`{"id":1}`))
+	})
+	customer := &domain.Claims{UserID: 1, Role: domain.RoleCustomer, CustomerID: intPtr(5)}
+
+	cachedRequest(handler, http.MethodGet, "/api/delivery/deliveries/1", customer, nil)
+	rec := cachedRequest(handler, http.MethodGet, "/api/delivery/deliveries/1", customer, http.Header{"If-None-Match": {`W/"1-1"`}})
+
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get(cacheHeader) != "HIT" {
+		t.Errorf("expected a cached 304, got %d %q %q", rec.Code, rec.Header().Get(cacheHeader), rec.Body.String())
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 upstream request, got %d", hits.Load())
+	}
+}
+
+func TestNewResponseCache_DisabledWithoutTTLs(t *testing.T) {
+	cfg := config.ResponseCacheConfig{Routes: []config.ResponseCacheRoute{
+		{Prefix: "/api/delivery"},
+		{Prefix: "/users/", Uncacheable: true},
+	}}
+	if c := newResponseCache(cfg, newMemoryResponseStore(10)); c != nil {
+		t.Errorf("expected no cache without a cached route, got %+v", c.routes)
+	}
+}
+
+func TestMemoryResponseStore(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryResponseStore(2)
+	resp := &cachedResponse{Status: http.StatusOK, StoredAt: time.Now()}
+
+	store.Set(ctx, "expired", resp, -time.Second)
+	if _, found, _ := store.Get(ctx, "expired"); found {
+		t.Error("expected an expired entry to be missed")
+	}
+
+	// A full store evicts the entry closest to expiring
+	store.Set(ctx, "short", resp, time.Minute)
+	store.Set(ctx, "long", resp, time.Hour)
+	store.Set(ctx, "new", resp, time.Hour)
+	if _, found, _ := store.Get(ctx, "short"); found {
+		t.Error("expected the soonest expiring entry to be evicted")
+	}
+	for _, key := range []string{"long", "new"} {
+		if _, found, _ := store.Get(ctx, key); !found {
+			t.Errorf("expected %q to be kept", key)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"go.uber.org/zap"
+)
+
+// healthCheckTimeout bounds a single /health probe
+const healthCheckTimeout = 2 * time.Second
+
+// Health states reported for an upstream
+const (
+	healthUnknown = "unknown"
+	healthUp      = "up"
+	healthDown    = "down"
+)
+
+// errUpstreamStatus marks responses that count as an upstream failure
+var errUpstreamStatus = errors.New("upstream responded with an availability error")
+
+// upstream is a proxied service. Requests pass through its circuit breaker so
+// a failing service is answered with a fast 503 instead of hanging clients;
+// health checks run independently of the breaker.
+type upstream struct {
+	name    string
+	target  *url.URL
+	breaker *resilience.CircuitBreaker
+	proxy   *httputil.ReverseProxy
+	logger  *logger.Logger
+
+	// retryAfter is the Retry-After hint sent while the circuit is open
+	retryAfter int
+
+	mu          sync.RWMutex
+	health      string
+	healthError string
+	lastCheck   time.Time
+}
+
+// newUpstream creates the proxy and circuit breaker for a service
+func newUpstream(name, targetURL string, cfg config.UpstreamConfig, lg *logger.Logger) (*upstream, error) {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s URL: %w", name, err)
+	}
+
+	u := &upstream{
+		name:       name,
+		target:     target,
+		breaker:    resilience.NewCircuitBreaker(name, cfg.FailureThreshold, cfg.ResetTimeout),
+		logger:     lg,
+		retryAfter: int(cfg.ResetTimeout.Seconds()),
+		health:     healthUnknown,
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ResponseHeaderTimeout: cfg.ResponseTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	u.proxy = newReverseProxy(target, &breakerTransport{breaker: u.breaker, base: transport})
+	u.proxy.ErrorHandler = u.handleProxyError
+
+	return u, nil
+}
+
+// handleProxyError answers requests the proxy could not complete
+func (u *upstream) handleProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, resilience.ErrCircuitOpen) || errors.Is(err, resilience.ErrHalfOpenLimit) {
+		u.writeUnavailable(w)
+		return
+	}
+	if r.Context().Err() != nil {
+		// Client went away; nobody is left to answer
+		return
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeBodyTooLarge(w, maxErr.Limit)
+		return
+	}
+
+	u.logger.WithContext(r.Context()).WithFields(
+		zap.String("service", u.name),
+		zap.String("path", r.URL.Path),
+		zap.Error(err),
+	).Warn("Upstream request failed")
+
+	status, code := http.StatusBadGateway, "bad_gateway"
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		status, code = http.StatusGatewayTimeout, "gateway_timeout"
+	}
+	writeUpstreamError(w, status, code, u.name)
+}
+
+// writeUnavailable fails a request fast while the circuit is open
+func (u *upstream) writeUnavailable(w http.ResponseWriter) {
+	if u.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(u.retryAfter))
+	}
+	writeUpstreamError(w, http.StatusServiceUnavailable, "service_unavailable", u.name)
+}
+
+// writeUpstreamError writes a JSON error naming the failing service
+func writeUpstreamError(w http.ResponseWriter, status int, code, service string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   code,
+		"service": service,
+	})
+}
+
+// checkHealth probes the service's /health endpoint and records the result
+func (u *upstream) checkHealth(ctx context.Context, client *http.Client) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	health, healthError := healthUp, ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.target.JoinPath("/health").String(), nil)
+	if err == nil {
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("health check returned %d", resp.StatusCode)
+			}
+		}
+	}
+	if err != nil {
+		health, healthError = healthDown, err.Error()
+	}
+
+	u.mu.Lock()
+	previous := u.health
+	u.health, u.healthError, u.lastCheck = health, healthError, time.Now()
+	u.mu.Unlock()
+
+	if previous != health {
+		u.logger.WithFields(
+			zap.String("service", u.name),
+			zap.String("health", health),
+			zap.String("error", healthError),
+		).Info("Upstream health changed")
+	}
+}
+
+// upstreamStatus is an upstream's entry in the gateway health report
+type upstreamStatus struct {
+	Status    string     `json:"status"`
+	Circuit   string     `json:"circuit"`
+	Error     string     `json:"error,omitempty"`
+	LastCheck *time.Time `json:"last_check,omitempty"`
+}
+
+// status reports the last health check and the circuit state
+func (u *upstream) status() upstreamStatus {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	s := upstreamStatus{
+		Status:  u.health,
+		Circuit: u.breaker.State().String(),
+		Error:   u.healthError,
+	}
+	if !u.lastCheck.IsZero() {
+		lastCheck := u.lastCheck
+		s.LastCheck = &lastCheck
+	}
+	return s
+}
+
+// breakerTransport sends requests through a circuit breaker. Transport errors,
+// timeouts and 502/503/504 responses count as failures; requests abandoned by
+// the client are ignored by the breaker, and those with a body over the
+// gateway's limit count as successes.
+type breakerTransport struct {
+	breaker *resilience.CircuitBreaker
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	var clientErr error
+	err := t.breaker.Call(req.Context(), func() error {
+		var err error
+		resp, err = t.base.RoundTrip(req)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				clientErr = err
+				return nil
+			}
+			return err
+		}
+		if isUnavailableStatus(resp.StatusCode) {
+			return errUpstreamStatus
+		}
+		return nil
+	})
+
+	// Availability errors are still relayed to the client as-is
+	if resp != nil {
+		return resp, nil
+	}
+	if err == nil {
+		err = clientErr
+	}
+	return nil, err
+}
+
+// isUnavailableStatus reports whether a status means the upstream could not serve the request
+func isUnavailableStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
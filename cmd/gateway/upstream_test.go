@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+func testUpstreamConfig() config.UpstreamConfig {
+	return config.UpstreamConfig{
+		FailureThreshold: 2,
+		ResetTimeout:     50 * time.Millisecond,
+		DialTimeout:      time.Second,
+		ResponseTimeout:  time.Second,
+	}
+}
+
+func newTestUpstream(t *testing.T, name, targetURL string) *upstream {
+	u, err := newUpstream(name, targetURL, testUpstreamConfig(), &logger.Logger{Logger: zaptest.NewLogger(t)})
+	if err != nil {
+		t.Fatalf("failed to create upstream: %v", err)
+	}
+	return u
+}
+
+func TestUpstream_CircuitOpensAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.upstreams = map[string]*upstream{"delivery": newTestUpstream(t, "delivery", server.URL)}
+	handler := g.proxyHandler("delivery")
+
+	// Failures up to the threshold are relayed from the upstream
+	for i := 0; i < 2; i++ {
+		if rec := doRequest(handler, "/api/delivery/deliveries", ""); rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected upstream 503 on request %d, got %d", i, rec.Code)
+		}
+	}
+
+	// The open circuit answers without reaching the upstream
+	rec := doRequest(handler, "/api/delivery/deliveries", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while open, got %d", rec.Code)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("expected the open circuit to skip the upstream, got %d hits", hits.Load())
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body["error"] != "service_unavailable" || body["service"] != "delivery" {
+		t.Errorf("unexpected body: %v", body)
+	}
+
+	// Half-open probes close the circuit again once the upstream recovers
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if rec := doRequest(handler, "/api/delivery/deliveries", ""); rec.Code != http.StatusOK {
+			t.Fatalf("expected probe %d to pass, got %d", i, rec.Code)
+		}
+	}
+	if !g.upstreams["delivery"].breaker.IsClosed() {
+		t.Errorf("expected circuit to close, got %s", g.upstreams["delivery"].breaker.State())
+	}
+}
+
+func TestGateway_HealthReportsUpstreams(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			t.Errorf("unexpected health path %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.upstreams = map[string]*upstream{
+		"delivery": newTestUpstream(t, "delivery", up.URL),
+		"tracking": newTestUpstream(t, "tracking", down.URL),
+	}
+	g.monitorUpstreams(context.Background(), 0)
+
+	rec := httptest.NewRecorder()
+	g.healthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 while degraded, got %d", rec.Code)
+	}
+
+	var body struct {
+		Status    string                    `json:"status"`
+		Upstreams map[string]upstreamStatus `json:"upstreams"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body.Status != "degraded" {
+		t.Errorf("expected degraded, got %s", body.Status)
+	}
+	if body.Upstreams["delivery"].Status != healthUp || body.Upstreams["tracking"].Status != healthDown {
+		t.Errorf("unexpected upstream statuses: %+v", body.Upstreams)
+	}
+
+	// With every upstream down the gateway itself reports unavailable
+	g.upstreams["delivery"].target = g.upstreams["tracking"].target
+	g.monitorUpstreams(context.Background(), 0)
+	rec = httptest.NewRecorder()
+	g.healthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 with all upstreams down, got %d", rec.Code)
+	}
+}
+
+func TestGateway_BodyLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.maxBodyBytes = 16
+	g.upstreams = map[string]*upstream{"delivery": newTestUpstream(t, "delivery", server.URL)}
+	handler := g.bodyLimitMiddleware(g.proxyHandler("delivery"))
+
+	send := func(body io.Reader) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/delivery/deliveries", body)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send(strings.NewReader(`{"notes": "ok"}`)); code != http.StatusOK {
+		t.Fatalf("expected a small body to pass, got %d", code)
+	}
+	if code := send(strings.NewReader(strings.Repeat("x", 64))); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for a declared oversized body, got %d", code)
+	}
+
+	// Without a declared length the limit trips while proxying, and the
+	// client's mistake does not count against the upstream
+	for i := 0; i < 3; i++ {
+		if code := send(io.MultiReader(strings.NewReader(strings.Repeat("x", 64)))); code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 for a streamed oversized body, got %d", code)
+		}
+	}
+	if !g.upstreams["delivery"].breaker.IsClosed() {
+		t.Errorf("expected the circuit to stay closed, got %s", g.upstreams["delivery"].breaker.State())
+	}
+}
+
+func TestGateway_ForwardsVerifiedIdentity(t *testing.T) {
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	g := newTestGateway(t, config.RateLimitConfig{Default: 1000})
+	g.gatewaySecret = "a-gateway-secret-of-at-least-32-chars"
+	g.upstreams = map[string]*upstream{"delivery": newTestUpstream(t, "delivery", server.URL)}
+
+	spoof := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer token-2")
+		req.Header.Set(identity.UserIDHeader, "1")
+		req.Header.Set(identity.UserRoleHeader, domain.RoleAdmin)
+		req.Header.Set(identity.CustomerIDHeader, "99")
+		req.Header.Set(identity.SecretHeader, "guessed")
+		return req
+	}
+
+	// Authenticated routes forward the identity from the validated token
+	rec := httptest.NewRecorder()
+	g.authMiddleware(g.proxyHandler("delivery"))(rec, spoof("/api/delivery/deliveries"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	h := <-received
+	if h.Get(identity.UserIDHeader) != "2" || h.Get(identity.UserRoleHeader) != domain.RoleCourier {
+		t.Errorf("expected user 2 as courier, got %q %q", h.Get(identity.UserIDHeader), h.Get(identity.UserRoleHeader))
+	}
+	if h.Get(identity.CustomerIDHeader) != "" {
+		t.Errorf("expected the spoofed customer ID stripped, got %q", h.Get(identity.CustomerIDHeader))
+	}
+	if h.Get(identity.SecretHeader) != g.gatewaySecret {
+		t.Errorf("expected the gateway secret, got %q", h.Get(identity.SecretHeader))
+	}
+
+	// Public routes carry no identity at all
+	rec = httptest.NewRecorder()
+	g.publicDeliveryProxyHandler()(rec, spoof("/api/track/DT-000001Y"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	h = <-received
+	for _, header := range []string{identity.UserIDHeader, identity.UserRoleHeader, identity.CustomerIDHeader, identity.SecretHeader} {
+		if h.Get(header) != "" {
+			t.Errorf("expected %s stripped from a public route, got %q", header, h.Get(header))
+		}
+	}
+}
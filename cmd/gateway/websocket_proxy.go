@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// websocketDialTimeout bounds how long the gateway waits to reach the upstream
+const websocketDialTimeout = 10 * time.Second
+
+// isWebSocketUpgrade reports whether the request asks for a WebSocket upgrade
+func isWebSocketUpgrade(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	for _, v := range strings.Split(r.Header.Get("Connection"), ",") {
+		if strings.EqualFold(strings.TrimSpace(v), "upgrade") {
+			return true
+		}
+	}
+	return false
+}
+
+// websocketBearerProtocol is offered ahead of the JWT in the
+// Sec-WebSocket-Protocol header, as in "bearer, <token>"
+const websocketBearerProtocol = "bearer"
+
+// websocketSubprotocols returns the subprotocols the client offered, in order
+func websocketSubprotocols(r *http.Request) []string {
+	var protocols []string
+	for _, v := range r.Header.Values("Sec-Websocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				protocols = append(protocols, p)
+			}
+		}
+	}
+	return protocols
+}
+
+// websocketToken extracts the JWT from the Sec-WebSocket-Protocol header, the
+// Authorization header or, when allowQuery is set, the deprecated token query
+// parameter. It reports whether the client offered the bearer subprotocol.
+func websocketToken(r *http.Request, allowQuery bool) (token string, subprotocol bool) {
+	if protocols := websocketSubprotocols(r); len(protocols) >= 2 && protocols[0] == websocketBearerProtocol {
+		return protocols[1], true
+	}
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		return parts[1], false
+	}
+	if !allowQuery {
+		return "", false
+	}
+	return r.URL.Query().Get("token"), false
+}
+
+// websocketProxyHandler validates the caller and tunnels a WebSocket upgrade
+// to the target service. Once the upstream accepts the upgrade, bytes are
+// copied in both directions until either side closes.
+func (g *Gateway) websocketProxyHandler(serviceName string) http.HandlerFunc {
+	u := g.upstreams[serviceName]
+	target := u.target
+	prefix := "/api/" + serviceName
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Rate limiting
+		if !g.allowRoute(w, r) {
+			return
+		}
+
+		// Authentication before upgrading
+		token, subprotocol := websocketToken(r, g.allowQueryToken)
+		if token == "" {
+			http.Error(w, `{"error":"unauthorized","message":"Token required in Sec-WebSocket-Protocol or Authorization header"}`, http.StatusUnauthorized)
+			return
+		}
+		claims, err := g.authService.ValidateToken(r.Context(), token)
+		if err != nil {
+			http.Error(w, `{"error":"unauthorized","message":"Invalid or expired token"}`, http.StatusUnauthorized)
+			return
+		}
+		if !g.allowUser(w, r, claims.UserID) {
+			return
+		}
+
+		// Dial failures count against the upstream's circuit like proxied requests
+		var upstreamConn net.Conn
+		err = u.breaker.Call(r.Context(), func() error {
+			var err error
+			upstreamConn, err = dialUpstream(target)
+			return err
+		})
+		if errors.Is(err, resilience.ErrCircuitOpen) || errors.Is(err, resilience.ErrHalfOpenLimit) {
+			u.writeUnavailable(w)
+			return
+		}
+		if err != nil {
+			g.logger.WithFields(
+				zap.String("service", serviceName),
+				zap.String("trace_id", r.Header.Get("X-Trace-ID")),
+				zap.String("request_id", requestid.FromContext(r.Context())),
+				zap.Error(err),
+			).Error("Failed to dial WebSocket upstream")
+			http.Error(w, `{"error":"bad_gateway","message":"Upstream unavailable"}`, http.StatusBadGateway)
+			return
+		}
+		defer upstreamConn.Close()
+
+		// Rewrite the request for the upstream. The upstream authenticates from
+		// the subprotocol header, so tokens from elsewhere are moved there and
+		// kept out of the upstream's access logs.
+		outReq := r.Clone(r.Context())
+		stripPathPrefix(outReq.URL, prefix)
+		query := outReq.URL.Query()
+		query.Del("token")
+		outReq.URL.RawQuery = query.Encode()
+		outReq.Header.Set("Sec-Websocket-Protocol", websocketBearerProtocol+", "+token)
+		outReq.Host = target.Host
+		outReq.RequestURI = ""
+		forwardedFor(&httputil.ProxyRequest{In: r, Out: outReq})
+		identity.Forward(outReq.Header, claims, g.gatewaySecret)
+		otel.GetTextMapPropagator().Inject(outReq.Context(), propagation.HeaderCarrier(outReq.Header))
+
+		if err := outReq.Write(upstreamConn); err != nil {
+			http.Error(w, `{"error":"bad_gateway","message":"Failed to forward upgrade request"}`, http.StatusBadGateway)
+			return
+		}
+
+		upstreamReader := bufio.NewReader(upstreamConn)
+		resp, err := http.ReadResponse(upstreamReader, outReq)
+		if err != nil {
+			http.Error(w, `{"error":"bad_gateway","message":"Invalid upstream response"}`, http.StatusBadGateway)
+			return
+		}
+
+		// Upstream refused the upgrade; relay its response as-is
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			defer resp.Body.Close()
+			for k, vv := range resp.Header {
+				for _, v := range vv {
+					w.Header().Add(k, v)
+				}
+			}
+			w.WriteHeader(resp.StatusCode)
+			io.Copy(w, resp.Body)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, `{"error":"internal_error","message":"WebSocket not supported"}`, http.StatusInternalServerError)
+			return
+		}
+		clientConn, clientBuf, err := hijacker.Hijack()
+		if err != nil {
+			g.logger.WithFields(zap.Error(err)).Error("Failed to hijack client connection")
+			return
+		}
+		defer clientConn.Close()
+
+		// Complete the handshake with the client, which must not be answered
+		// with a subprotocol it did not offer
+		if !subprotocol {
+			resp.Header.Del("Sec-Websocket-Protocol")
+		}
+		if _, err := fmt.Fprintf(clientBuf, "HTTP/1.1 %s\r\n", resp.Status); err != nil {
+			return
+		}
+		if err := resp.Header.Write(clientBuf); err != nil {
+			return
+		}
+		if _, err := clientBuf.WriteString("\r\n"); err != nil {
+			return
+		}
+		if err := clientBuf.Flush(); err != nil {
+			return
+		}
+
+		active := atomic.AddInt64(&g.wsConnections, 1)
+		defer atomic.AddInt64(&g.wsConnections, -1)
+
+		g.logger.WithFields(
+			zap.String("service", serviceName),
+			zap.String("path", outReq.URL.Path),
+			zap.Int("user_id", claims.UserID),
+			zap.Int64("active_connections", active),
+			zap.String("trace_id", r.Header.Get("X-Trace-ID")),
+			zap.String("request_id", requestid.FromContext(r.Context())),
+		).Info("WebSocket tunnel established")
+
+		tunnel(clientConn, clientBuf.Reader, upstreamConn, upstreamReader)
+
+		g.logger.WithFields(
+			zap.String("service", serviceName),
+			zap.String("path", outReq.URL.Path),
+			zap.Int("user_id", claims.UserID),
+			zap.String("trace_id", r.Header.Get("X-Trace-ID")),
+			zap.String("request_id", requestid.FromContext(r.Context())),
+		).Info("WebSocket tunnel closed")
+	}
+}
+
+// dialUpstream opens a TCP (or TLS for https targets) connection to the target
+func dialUpstream(target *url.URL) (net.Conn, error) {
+	host := target.Host
+	if target.Port() == "" {
+		if target.Scheme == "https" {
+			host = net.JoinHostPort(target.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(target.Hostname(), "80")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: websocketDialTimeout}
+	if target.Scheme == "https" {
+		return tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: target.Hostname()})
+	}
+	return dialer.Dial("tcp", host)
+}
+
+// tunnel copies data between the client and upstream connections. When either
+// direction ends, both connections are closed so the other side is released.
+func tunnel(clientConn net.Conn, clientReader io.Reader, upstreamConn net.Conn, upstreamReader io.Reader) {
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			clientConn.Close()
+			upstreamConn.Close()
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+		io.Copy(upstreamConn, clientReader)
+	}()
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+		io.Copy(clientConn, upstreamReader)
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebsocketToken(t *testing.T) {
+	tests := []struct {
+		name                string
+		target              string
+		headers             map[string]string
+		expectedToken       string
+		expectedSubprotocol bool
+		refuseQuery         bool
+	}{
+		{name: "subprotocol", target: "/ws", headers: map[string]string{"Sec-WebSocket-Protocol": "bearer, tok-1"}, expectedToken: "tok-1", expectedSubprotocol: true},
+		{name: "subprotocol before query", target: "/ws?token=tok-2", headers: map[string]string{"Sec-WebSocket-Protocol": "bearer,tok-1"}, expectedToken: "tok-1", expectedSubprotocol: true},
+		{name: "authorization header", target: "/ws", headers: map[string]string{"Authorization": "Bearer tok-3"}, expectedToken: "tok-3"},
+		{name: "query", target: "/ws?token=tok-2", expectedToken: "tok-2"},
+		{name: "query refused", target: "/ws?token=tok-2", refuseQuery: true},
+		{name: "authorization header with query refused", target: "/ws?token=tok-2", headers: map[string]string{"Authorization": "Bearer tok-3"}, expectedToken: "tok-3", refuseQuery: true},
+		{name: "other subprotocol", target: "/ws", headers: map[string]string{"Sec-WebSocket-Protocol": "graphql-ws"}},
+		{name: "none", target: "/ws"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			token, subprotocol := websocketToken(req, !tt.refuseQuery)
+			if token != tt.expectedToken || subprotocol != tt.expectedSubprotocol {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.expectedToken, tt.expectedSubprotocol, token, subprotocol)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/Keneke-Einar/delivertrack/migrations"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up                 apply pending migrations
+  down               revert the latest applied migration
+  status             list applied and pending migrations
+  dry-run            apply pending migrations in a transaction that is rolled back
+  baseline <version> record migrations up to version as applied without running them,
+                     for databases created before migrations were tracked
+
+The database is DATABASE_URL, or database.url of config/migrate/config.yaml.`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load("migrate")
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	databaseURL := config.GetEnv("DATABASE_URL", cfg.Database.URL)
+
+	db, err := postgres.New(databaseURL, postgres.OptionsFromConfig(cfg.Database))
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db.DB, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations("Applied", applied)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if reverted == nil {
+			fmt.Println("No migrations applied")
+			return
+		}
+		fmt.Printf("Reverted %s\n", reverted)
+	case "status":
+		applied, err := migrator.Applied(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migrations: %v", err)
+		}
+		for _, a := range applied {
+			fmt.Printf("applied  %03d_%s  %s\n", a.Version, a.Name, a.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migrations: %v", err)
+		}
+		for _, m := range pending {
+			fmt.Printf("pending  %s\n", m)
+		}
+	case "dry-run":
+		pending, err := migrator.DryRun(ctx)
+		if err != nil {
+			log.Fatalf("Dry run failed: %v", err)
+		}
+		printMigrations("Would apply", pending)
+	case "baseline":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		version, err := strconv.Atoi(os.Args[2])
+		if err != nil || version <= 0 {
+			log.Fatalf("Invalid version %q", os.Args[2])
+		}
+		recorded, err := migrator.Baseline(ctx, version)
+		if err != nil {
+			log.Fatalf("Baseline failed: %v", err)
+		}
+		printMigrations("Recorded", recorded)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func printMigrations(verb string, list []migrate.Migration) {
+	if len(list) == 0 {
+		fmt.Println("Schema is up to date")
+		return
+	}
+	for _, m := range list {
+		fmt.Printf("%s %s\n", verb, m)
+	}
+}
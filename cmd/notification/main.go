@@ -6,23 +6,36 @@ import (
 	"log"
 	"net"
 	"net/http"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	notificationAdapters "github.com/Keneke-Einar/delivertrack/internal/notification/adapters"
 	notificationApp "github.com/Keneke-Einar/delivertrack/internal/notification/app"
+	notificationPorts "github.com/Keneke-Einar/delivertrack/internal/notification/ports"
 	"go.uber.org/zap"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
 	authAdapters "github.com/Keneke-Einar/delivertrack/pkg/auth/adapters"
 	authApp "github.com/Keneke-Einar/delivertrack/pkg/auth/app"
-	authPorts "github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
 
+	"github.com/Keneke-Einar/delivertrack/migrations"
 	"github.com/Keneke-Einar/delivertrack/pkg/config"
 	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	healthcheck "github.com/Keneke-Einar/delivertrack/pkg/health"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/http/httpmiddleware"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
 
 	"github.com/Keneke-Einar/delivertrack/proto/notification"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -31,6 +44,10 @@ import (
 
 var version = "dev"
 
+// shutdownTimeout bounds how long in-flight HTTP requests may take to finish
+// once shutdown starts
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	cfg, err := config.Load("notification")
 	if err != nil {
@@ -39,16 +56,23 @@ func main() {
 
 	port := cfg.Service.Port
 	databaseURL := cfg.Database.URL
-	jwtSecret := cfg.Auth.JWTSecret
 
 	// Initialize logger
 	lg, err := logger.NewLogger(cfg.Logging, "notification")
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	logger.SetGlobal(lg)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), "notification-service", version)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize database
-	db, err := postgres.New(databaseURL)
+	db, err := postgres.New(databaseURL, postgres.OptionsFromConfig(cfg.Database))
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -56,13 +80,54 @@ func main() {
 
 	lg.Info("Database connection established")
 
+	// Apply pending schema migrations, or refuse to start against an outdated schema
+	applied, err := migrate.Ensure(context.Background(), db.DB, migrations.FS, cfg.Database.AutoMigrate)
+	if err != nil {
+		log.Fatalf("Database schema check failed, set DB_AUTO_MIGRATE=true to apply pending migrations: %v", err)
+	}
+	for _, migration := range applied {
+		lg.Info("Applied database migration", zap.String("migration", migration.String()))
+	}
+
 	// Wire up dependencies using layered architecture
 
 	// Auth layer
 	userRepo := authAdapters.NewPostgresUserRepository(db.DB)
-	tokenService := authAdapters.NewJWTTokenService(jwtSecret, cfg.Auth.JWTExpiration)
+	tokenService, err := authAdapters.NewJWTTokenServiceFromConfig(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT keys: %v", err)
+	}
 	authService := authApp.NewAuthService(userRepo, tokenService)
+	loginAttempts, err := authAdapters.NewLoginAttemptStore(cfg.Auth.Lockout.Store, db.DB)
+	if err != nil {
+		log.Fatalf("Failed to create login attempt store: %v", err)
+	}
+	authService.SetLockout(loginAttempts, authApp.LockoutConfig{
+		MaxFailures:   cfg.Auth.Lockout.MaxFailures,
+		IPMaxFailures: cfg.Auth.Lockout.IPMaxFailures,
+		Window:        cfg.Auth.Lockout.Window,
+		Cooldown:      cfg.Auth.Lockout.Cooldown,
+	})
+	authService.SetAPIKeyRepository(authAdapters.NewPostgresAPIKeyRepository(db.DB))
+
+	// Audit log of account and other sensitive changes, written in the background
+	auditStore := audit.NewPostgresStore(db.DB)
+	auditWriter := audit.NewWriter(auditStore, audit.DefaultBufferSize, lg)
+	defer auditWriter.Close()
+	authService.SetAuditWriter(auditWriter)
+
+	// Behind the gateway, trust the identity headers it forwards instead of
+	// validating every token again
+	var trustedGateway *identity.Verifier
+	if cfg.Auth.TrustGatewayHeaders {
+		trustedGateway = identity.NewVerifier(cfg.Auth.GatewaySecret)
+	}
 	authHandler := authAdapters.NewHTTPHandler(authService, cfg.Auth.JWTExpiration)
+	trustedProxies, err := httputil.ParseTrustedProxies(cfg.Service.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	authHandler.SetTrustedProxies(trustedProxies)
 
 	// Notification layer
 	notificationRepo := notificationAdapters.NewPostgresNotificationRepository(db.DB)
@@ -76,8 +141,66 @@ func main() {
 	defer consumer.Close()
 
 	notificationService := notificationApp.NewNotificationService(notificationRepo, consumer, lg)
+	notificationService.SetAuditWriter(auditWriter)
+	defer notificationService.Shutdown()
+
+	// Email channel: SMTP in deployed environments, logged only in development
+	var emailSender notificationPorts.EmailSender
+	switch cfg.Email.Driver {
+	case "smtp":
+		emailSender = notificationAdapters.NewSMTPEmailSender(notificationAdapters.SMTPConfig{
+			Host:     cfg.Email.SMTPHost,
+			Port:     cfg.Email.SMTPPort,
+			Username: cfg.Email.Username,
+			Password: cfg.Email.Password,
+			From:     cfg.Email.From,
+		})
+	case "noop":
+		emailSender = notificationAdapters.NewNoopEmailSender(lg)
+	default:
+		log.Fatalf("Unknown email driver %q", cfg.Email.Driver)
+	}
+	contacts := notificationAdapters.NewPostgresContactDirectory(db.DB)
+	notificationService.SetEmailChannel(emailSender, contacts, notificationApp.EmailConfig{
+		QueueSize:   cfg.Email.QueueSize,
+		Workers:     cfg.Email.Workers,
+		RetryDelay:  cfg.Email.RetryDelay,
+		SendTimeout: cfg.Email.SendTimeout,
+	})
+	lg.Info("Email channel enabled", zap.String("driver", cfg.Email.Driver))
+
+	// Push channel: FCM HTTP v1 in deployed environments, logged only in development
+	var pushSender notificationPorts.PushSender
+	switch cfg.Push.Driver {
+	case "fcm":
+		credentials, err := os.ReadFile(cfg.Push.FCMCredentialsFile)
+		if err != nil {
+			log.Fatalf("Failed to read FCM credentials: %v", err)
+		}
+		pushSender, err = notificationAdapters.NewFCMPushSender(notificationAdapters.FCMConfig{
+			ProjectID:   cfg.Push.FCMProjectID,
+			Credentials: credentials,
+		}, lg)
+		if err != nil {
+			log.Fatalf("Failed to create FCM push sender: %v", err)
+		}
+	case "noop":
+		pushSender = notificationAdapters.NewNoopPushSender(lg)
+	default:
+		log.Fatalf("Unknown push driver %q", cfg.Push.Driver)
+	}
+	notificationService.SetPushChannel(pushSender, notificationAdapters.NewPostgresDeviceRepository(db.DB), notificationApp.PushConfig{
+		MaxDevicesPerUser: cfg.Push.MaxDevicesPerUser,
+		SendTimeout:       cfg.Push.SendTimeout,
+		QueueSize:         cfg.Push.QueueSize,
+		Workers:           cfg.Push.Workers,
+	})
+	lg.Info("Push channel enabled", zap.String("driver", cfg.Push.Driver))
+
 	notificationHTTPHandler := notificationAdapters.NewHTTPHandler(notificationService)
+	notificationHTTPHandler.SetUserDirectory(contacts)
 	notificationGRPCHandler := notificationAdapters.NewGRPCHandler(notificationService)
+	notificationGRPCHandler.SetUserDirectory(contacts)
 
 	// Start event consumption
 	if err := notificationService.StartEventConsumption(); err != nil {
@@ -85,43 +208,86 @@ func main() {
 	}
 	lg.Info("Started consuming delivery and location events")
 
+	// Dead letter management: sample failed events and allow admins to retry them
+	if err := messaging.SetupDeadLetterExchange(rabbitMQURL); err != nil {
+		log.Fatalf("Failed to set up dead letter exchange: %v", err)
+	}
+	publisher, err := messaging.NewRabbitMQPublisher(rabbitMQURL, lg)
+	if err != nil {
+		log.Fatalf("Failed to create RabbitMQ publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	deadLetterStore, err := messaging.NewDeadLetterStore(cfg.RabbitMQ.DeadLetterStore, db.DB, cfg.RabbitMQ.DeadLetterSampleSize)
+	if err != nil {
+		log.Fatalf("Failed to create dead letter store: %v", err)
+	}
+	deadLetters := messaging.NewDeadLetterManager(deadLetterStore, publisher, cfg.RabbitMQ.DeadLetterMaxRetries, lg)
+	if err := consumer.ConsumeDeadLetters(messaging.DeadLetterQueue, deadLetters.Record); err != nil {
+		log.Fatalf("Failed to start dead letter consumption: %v", err)
+	}
+	deadLetterHTTPHandler := notificationAdapters.NewDeadLetterHTTPHandler(deadLetters)
+
+	// Readiness depends on the database and the consumer's broker connection;
+	// the publisher only retries dead letters
+	checker := healthcheck.NewChecker("notification", healthcheck.DefaultTimeout)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("rabbitmq", consumer.Check)
+	checker.AddOptional("rabbitmq_publisher", publisher.Check)
+
 	// Setup HTTP router
-	mux := http.NewServeMux()
+	mux := httputil.NewRouter()
+	protected := httpmiddleware.Auth(authService, httpmiddleware.WithTrustedGateway(trustedGateway))
+	admin := httpmiddleware.Auth(authService,
+		httpmiddleware.WithTrustedGateway(trustedGateway),
+		httpmiddleware.WithRequiredRoles(authDomain.RoleAdmin, authDomain.RoleSuperAdmin))
 
 	// Public routes
-	mux.HandleFunc("/health", healthHandler)
-	mux.HandleFunc("/", rootHandler)
-	mux.HandleFunc("/login", authHandler.Login)
-	mux.HandleFunc("/register", authHandler.Register)
+	mux.HandleFunc("GET /health/live", checker.LiveHandler)
+	mux.HandleFunc("GET /health/ready", checker.ReadyHandler)
+	mux.HandleFunc("GET /health", checker.ReadyHandler) // probed by the gateway
+	mux.HandleFunc("GET /{$}", rootHandler)
+	mux.HandleFunc("POST /login", authHandler.Login)
+	mux.HandleFunc("POST /register", authHandler.Register)
 
 	// Protected routes - notification endpoints
-	mux.HandleFunc("/notifications", authMiddleware(authService, notificationHTTPHandler.GetUserNotifications))
-	mux.HandleFunc("/notifications/", func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/notifications/")
-		if path == "" {
-			// POST /notifications
-			authMiddleware(authService, notificationHTTPHandler.SendNotification)(w, r)
-		} else {
-			// PUT /notifications/{id}/read
-			if strings.HasSuffix(path, "/read") {
-				authMiddleware(authService, notificationHTTPHandler.MarkAsRead)(w, r)
-			} else {
-				http.NotFound(w, r)
-			}
-		}
-	})
+	mux.HandleFunc("GET /notifications", protected(notificationHTTPHandler.GetUserNotifications))
+	mux.HandleFunc("POST /notifications", protected(notificationHTTPHandler.SendNotification))
+	mux.HandleFunc("GET /notifications/unread_count", protected(notificationHTTPHandler.GetUnreadCount))
+	mux.HandleFunc("PUT /notifications/read_all", protected(notificationHTTPHandler.MarkAllAsRead))
+	mux.HandleFunc("POST /notifications/mark-read", protected(notificationHTTPHandler.MarkAsRead))
+
+	mux.HandleFunc("GET /preferences", protected(notificationHTTPHandler.GetPreferences))
+	mux.HandleFunc("PUT /preferences", protected(notificationHTTPHandler.UpdatePreferences))
+
+	// Protected routes - push device registration
+	mux.HandleFunc("POST /devices", protected(notificationHTTPHandler.RegisterDevice))
+	mux.HandleFunc("DELETE /devices/{id}", protected(notificationHTTPHandler.DeleteDevice))
+
+	// Admin routes - dead letter management, super admins only
+	mux.HandleFunc("GET /admin/dead-letters", admin(deadLetterHTTPHandler.List))
+	mux.HandleFunc("POST /admin/dead-letters/{id}/retry", admin(deadLetterHTTPHandler.Retry))
 
 	// Start HTTP server in a goroutine
+	httpServer := &http.Server{
+		Addr:    ":" + port,
+		Handler: tracing.HTTPHandler(httputil.RequestID(mux), "notification-service"),
+	}
 	go func() {
 		lg.Info("Notification HTTP service starting",
 			zap.String("version", version),
 			zap.String("port", port))
 		lg.Info("HTTP endpoints available",
 			zap.Strings("endpoints", []string{
+				"GET /health/live", "GET /health/ready",
 				"POST /login", "POST /register",
-				"POST /notifications", "GET /notifications", "PUT /notifications/{id}/read"}))
+				"POST /notifications", "GET /notifications", "POST /notifications/mark-read",
+				"GET /notifications/unread_count", "PUT /notifications/read_all",
+				"GET /preferences", "PUT /preferences",
+				"POST /devices", "DELETE /devices/{id}",
+				"GET /admin/dead-letters", "POST /admin/dead-letters/{id}/retry"}))
 
-		if err := http.ListenAndServe(":"+port, mux); err != nil {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			lg.Fatal("Failed to start HTTP server", zap.Error(err))
 		}
 	}()
@@ -134,29 +300,38 @@ func main() {
 			zap.String("port", grpcPort), zap.Error(err))
 	}
 
-	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			grpcinterceptors.ErrorHandlingUnaryServerInterceptor(),
-			grpcinterceptors.LoggingUnaryServerInterceptor(lg),
-			grpcinterceptors.AuthUnaryServerInterceptor(authService),
-			grpcinterceptors.UnaryServerInterceptor(),
-		),
-		grpc.ChainStreamInterceptor(
-			grpcinterceptors.ErrorHandlingStreamServerInterceptor(),
-			grpcinterceptors.LoggingStreamServerInterceptor(lg),
-			grpcinterceptors.AuthStreamServerInterceptor(authService),
-			grpcinterceptors.StreamServerInterceptor(),
-		),
+	grpcServer := grpcinterceptors.NewServer(lg, authService, cfg.Logging.SlowRequestThreshold,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	)
 	notification.RegisterNotificationServiceServer(grpcServer, notificationGRPCHandler)
 
 	// Register health service
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	checker.SetGRPCServer(healthServer)
+	go checker.Run(context.Background(), healthcheck.DefaultInterval)
 
 	reflection.Register(grpcServer) // Enable reflection for debugging
 
+	// On SIGINT/SIGTERM stop taking events and drain HTTP and gRPC calls.
+	// Serve then returns and the deferred closes flush queued emails and
+	// audit entries before the process exits.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+
+		lg.Info("Shutting down notification service")
+		checker.Shutdown()
+		consumer.Close()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			lg.Error("Failed to drain HTTP requests", zap.Error(err))
+		}
+		grpcServer.GracefulStop()
+	}()
+
 	lg.Info("Notification gRPC service starting",
 		zap.String("version", version),
 		zap.String("port", grpcPort))
@@ -166,52 +341,8 @@ func main() {
 	}
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"status":"ok","service":"notification"}`)
-}
-
 func rootHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{"service":"notification","version":"%s"}`, version)
 }
-
-// authMiddleware validates JWT token and adds user info to context
-func authMiddleware(authService authPorts.AuthService, next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error":"unauthorized","message":"Authorization header required"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Check Bearer token format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid authorization header format"}`, http.StatusUnauthorized)
-			return
-		}
-
-		token := parts[1]
-
-		// Validate token
-		claims, err := authService.ValidateToken(r.Context(), token)
-		if err != nil {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid or expired token"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "username", claims.Username)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		ctx = context.WithValue(ctx, "customer_id", claims.CustomerID)
-		ctx = context.WithValue(ctx, "courier_id", claims.CourierID)
-
-		// Call next handler with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	}
-}
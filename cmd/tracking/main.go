@@ -2,30 +2,48 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	trackingAdapters "github.com/Keneke-Einar/delivertrack/internal/tracking/adapters"
 	trackingApp "github.com/Keneke-Einar/delivertrack/internal/tracking/app"
+	trackingDomain "github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 	"go.uber.org/zap"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
 	authAdapters "github.com/Keneke-Einar/delivertrack/pkg/auth/adapters"
 	authApp "github.com/Keneke-Einar/delivertrack/pkg/auth/app"
-	authPorts "github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
 
+	"github.com/Keneke-Einar/delivertrack/migrations"
+	"github.com/Keneke-Einar/delivertrack/pkg/cache"
 	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcclient"
 	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	healthcheck "github.com/Keneke-Einar/delivertrack/pkg/health"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/http/httpmiddleware"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"github.com/Keneke-Einar/delivertrack/pkg/mongodb"
 	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
 	"github.com/Keneke-Einar/delivertrack/pkg/websocket"
 
 	"github.com/Keneke-Einar/delivertrack/proto/delivery"
 	"github.com/Keneke-Einar/delivertrack/proto/tracking"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -43,16 +61,23 @@ func main() {
 	port := cfg.Service.Port
 	databaseURL := cfg.Database.URL
 	mongoURL := cfg.MongoDB.URL
-	jwtSecret := cfg.Auth.JWTSecret
 
 	// Initialize logger
 	lg, err := logger.NewLogger(cfg.Logging, "tracking")
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	logger.SetGlobal(lg)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), "tracking-service", version)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize PostgreSQL for auth
-	db, err := postgres.New(databaseURL)
+	db, err := postgres.New(databaseURL, postgres.OptionsFromConfig(cfg.Database))
 	if err != nil {
 		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
 	}
@@ -60,23 +85,44 @@ func main() {
 
 	lg.Info("PostgreSQL connection established")
 
+	// Apply pending schema migrations, or refuse to start against an outdated schema
+	applied, err := migrate.Ensure(context.Background(), db.DB, migrations.FS, cfg.Database.AutoMigrate)
+	if err != nil {
+		log.Fatalf("Database schema check failed, set DB_AUTO_MIGRATE=true to apply pending migrations: %v", err)
+	}
+	for _, migration := range applied {
+		lg.Info("Applied database migration", zap.String("migration", migration.String()))
+	}
+
 	// Initialize MongoDB for geospatial data
-	mongoClient, err := mongodb.New(mongoURL)
+	mongoClient, err := mongodb.New(mongoURL, mongodb.OptionsFromConfig(cfg.MongoDB))
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 	defer mongoClient.Close(context.Background())
 
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), 30*time.Second)
+	err = mongoClient.EnsureIndexes(indexCtx, cfg.MongoDB.LocationRetention)
+	cancelIndex()
+	if err != nil {
+		log.Fatalf("Failed to ensure MongoDB indexes: %v", err)
+	}
+
 	lg.Info("MongoDB connection established")
 
+	if cfg.MongoDB.BackfillBatchSize > 0 {
+		backfilled, err := mongoClient.BackfillLocationPoints(context.Background(), cfg.MongoDB.BackfillBatchSize)
+		if err != nil {
+			lg.Error("Failed to backfill GeoJSON courier locations", zap.Error(err))
+		} else if backfilled > 0 {
+			lg.Info("Backfilled GeoJSON courier locations", zap.Int64("documents", backfilled))
+		}
+	}
+
 	// Initialize gRPC clients for inter-service communication
-	deliveryConn, err := grpc.NewClient(cfg.Services.Delivery,
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(grpcinterceptors.UnaryClientInterceptor()),
-		grpc.WithStreamInterceptor(grpcinterceptors.StreamClientInterceptor()),
-	)
+	deliveryConn, err := grpcclient.New(context.Background(), "delivery", cfg.Services.Delivery, cfg.GRPC)
 	if err != nil {
-		log.Fatalf("Failed to connect to delivery service: %v", err)
+		lg.Fatal("Failed to connect to delivery service", zap.Error(err))
 	}
 	defer deliveryConn.Close()
 	deliveryClient := delivery.NewDeliveryServiceClient(deliveryConn)
@@ -85,9 +131,41 @@ func main() {
 
 	// Auth layer
 	userRepo := authAdapters.NewPostgresUserRepository(db.DB)
-	tokenService := authAdapters.NewJWTTokenService(jwtSecret, cfg.Auth.JWTExpiration)
+	tokenService, err := authAdapters.NewJWTTokenServiceFromConfig(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT keys: %v", err)
+	}
 	authService := authApp.NewAuthService(userRepo, tokenService)
+	loginAttempts, err := authAdapters.NewLoginAttemptStore(cfg.Auth.Lockout.Store, db.DB)
+	if err != nil {
+		log.Fatalf("Failed to create login attempt store: %v", err)
+	}
+	authService.SetLockout(loginAttempts, authApp.LockoutConfig{
+		MaxFailures:   cfg.Auth.Lockout.MaxFailures,
+		IPMaxFailures: cfg.Auth.Lockout.IPMaxFailures,
+		Window:        cfg.Auth.Lockout.Window,
+		Cooldown:      cfg.Auth.Lockout.Cooldown,
+	})
+	authService.SetAPIKeyRepository(authAdapters.NewPostgresAPIKeyRepository(db.DB))
+
+	// Audit log of account and other sensitive changes, written in the background
+	auditStore := audit.NewPostgresStore(db.DB)
+	auditWriter := audit.NewWriter(auditStore, audit.DefaultBufferSize, lg)
+	defer auditWriter.Close()
+	authService.SetAuditWriter(auditWriter)
+
+	// Behind the gateway, trust the identity headers it forwards instead of
+	// validating every token again
+	var trustedGateway *identity.Verifier
+	if cfg.Auth.TrustGatewayHeaders {
+		trustedGateway = identity.NewVerifier(cfg.Auth.GatewaySecret)
+	}
 	authHandler := authAdapters.NewHTTPHandler(authService, cfg.Auth.JWTExpiration)
+	trustedProxies, err := httputil.ParseTrustedProxies(cfg.Service.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	authHandler.SetTrustedProxies(trustedProxies)
 
 	// Tracking layer
 	trackingRepo := trackingAdapters.NewMongoDBLocationRepository(mongoClient)
@@ -100,75 +178,153 @@ func main() {
 	}
 	defer publisher.Close()
 
-	trackingService := trackingApp.NewTrackingService(trackingRepo, publisher, deliveryClient, authService, lg)
+	// Delivery events invalidate cached delivery owners
+	consumer, err := messaging.NewRabbitMQConsumer(rabbitMQURL, lg)
+	if err != nil {
+		log.Fatalf("Failed to create RabbitMQ consumer: %v", err)
+	}
+	defer consumer.Close()
+
+	// Readiness depends on the stores and the broker; the delivery service,
+	// Redis and delivery events only degrade features
+	checker := healthcheck.NewChecker("tracking", healthcheck.DefaultTimeout)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("mongodb", mongoClient.Ping)
+	checker.Add("rabbitmq", publisher.Check)
+	checker.AddOptional("delivery", healthcheck.GRPC(deliveryConn))
+	checker.AddOptional("delivery_events", consumer.Check)
+
+	// Geocoding for resolving addresses in track responses
+	geocodingSvc, err := geocoding.NewGeocodingService(geocoding.ConfigFromEnv(cfg.Geocoding), lg)
+	if err != nil {
+		log.Fatalf("Failed to initialize geocoding service: %v", err)
+	}
+
+	trackingService := trackingApp.NewTrackingService(trackingRepo, publisher, deliveryClient, authService, geocodingSvc, lg)
+	trackingService.SetZoneRepository(trackingAdapters.NewMongoDBZoneRepository(mongoClient))
+	trackingService.SetTrackSummaryRepository(trackingAdapters.NewMongoDBTrackSummaryRepository(mongoClient))
+	trackingService.SetJitterFilter(trackingDomain.JitterFilter{
+		MaxSpeedKmh:       cfg.Tracking.MaxSpeedKmh,
+		MaxAccuracyMeters: cfg.Tracking.MaxAccuracyMeters,
+	})
+	trackingService.SetCourierLiveness(trackingDomain.CourierLiveness{
+		ActiveWithin: cfg.Tracking.CourierActiveWindow,
+		OfflineAfter: cfg.Tracking.CourierOfflineAfter,
+	})
+	trackingService.SetETAUpdatePolicy(trackingDomain.ETAUpdatePolicy{
+		MinInterval: cfg.Tracking.ETAUpdateInterval,
+		MinChange:   cfg.Tracking.ETAChangeThreshold,
+	})
+
+	trackingService.SetDeliveryCircuitBreaker(resilience.NewCircuitBreakerWithConfig("delivery", cfg.CircuitBreakers["delivery"]))
+	trackingService.SetDeliveryLookupTimeout(cfg.Tracking.DeliveryTimeout)
+	trackingService.SetDeliveryCache(cfg.Tracking.DeliveryCacheTTL, cfg.Tracking.DeliveryCacheSize)
+	if err := trackingService.StartDeliveryEventConsumption(consumer); err != nil {
+		log.Fatalf("Failed to start delivery event consumption: %v", err)
+	}
+	if cfg.Tracking.FleetMapMaxCouriers > 0 {
+		trackingService.SetFleetMapLimit(cfg.Tracking.FleetMapMaxCouriers)
+	}
+
+	// Background jobs call the delivery service with a token for the service role
+	trackingService.SetServiceToken(func() (string, error) {
+		return tokenService.GenerateToken(&authDomain.User{Username: "tracking-service", Role: authDomain.RoleService})
+	})
+
+	// Cache the latest point per delivery; reads fall back to MongoDB while Redis is down
+	if cfg.Tracking.LocationCacheTTL > 0 {
+		redisClient, err := cache.New(cfg.Redis.URL)
+		if err != nil {
+			log.Fatalf("Failed to configure Redis: %v", err)
+		}
+		defer redisClient.Close()
+
+		pingCtx, cancelPing := context.WithTimeout(context.Background(), 2*time.Second)
+		if err := redisClient.Ping(pingCtx).Err(); err != nil {
+			lg.Warn("Redis unavailable, location reads will use MongoDB until it recovers", zap.Error(err))
+		} else {
+			lg.Info("Redis connection established")
+		}
+		cancelPing()
+		checker.AddOptional("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+
+		trackingService.SetLocationCache(trackingAdapters.NewRedisLocationCache(redisClient, cfg.Tracking.LocationCacheTTL))
+	}
 	trackingHTTPHandler := trackingAdapters.NewHTTPHandler(trackingService)
 	trackingGRPCHandler := trackingAdapters.NewGRPCHandler(trackingService)
 
 	// Initialize WebSocket hub
-	wsHub := websocket.NewHub(authService)
+	wsHub := websocket.NewHubWithConfig(authService, websocket.HubConfig{
+		BroadcastBuffer:       cfg.Tracking.WSBroadcastBuffer,
+		SendBuffer:            cfg.Tracking.WSSendBuffer,
+		ReadLimit:             cfg.Tracking.WSReadLimit,
+		PongTimeout:           cfg.Tracking.WSPongTimeout,
+		MaxConnections:        cfg.Tracking.WSMaxConnections,
+		MaxConnectionsPerUser: cfg.Tracking.WSMaxUserConnections,
+		AllowQueryToken:       cfg.Tracking.WSAllowQueryToken,
+	})
 	trackingService.SetWebSocketHub(wsHub)
 
 	// Start WebSocket hub in background
 	go wsHub.Run()
 
+	// Watch in-transit deliveries for couriers that stopped reporting
+	if cfg.Tracking.StaleCheckInterval > 0 {
+		trackingService.StartStaleCourierChecker(cfg.Tracking.StaleCheckInterval)
+	}
+
+	// Summarize and purge the tracks of deliveries finished beyond the retention window
+	if cfg.Tracking.RetentionInterval > 0 && cfg.Tracking.RetentionWindow > 0 {
+		trackingService.StartRetentionPurger(cfg.Tracking.RetentionInterval, cfg.Tracking.RetentionWindow)
+	}
+
 	// Setup HTTP router with middleware
-	mux := http.NewServeMux()
+	mux := httputil.NewRouter()
+	// Credentials are kept for the gRPC calls to the delivery service
+	protected := httpmiddleware.Auth(authService,
+		httpmiddleware.WithTrustedGateway(trustedGateway),
+		httpmiddleware.WithForwardedAuthorization())
 
 	// Public routes
-	mux.HandleFunc("/health", healthHandler)
-	mux.HandleFunc("/", rootHandler)
-	mux.HandleFunc("/login", authHandler.Login)
-	mux.HandleFunc("/register", authHandler.Register)
+	mux.HandleFunc("GET /health/live", checker.LiveHandler)
+	mux.HandleFunc("GET /health/ready", checker.ReadyHandler)
+	mux.HandleFunc("GET /health", checker.ReadyHandler) // probed by the gateway
+	mux.HandleFunc("GET /{$}", rootHandler)
+	mux.HandleFunc("POST /login", authHandler.Login)
+	mux.HandleFunc("POST /register", authHandler.Register)
 
 	// Protected routes - tracking endpoints
-	mux.HandleFunc("/locations", authMiddleware(authService, trackingHTTPHandler.RecordLocation))
+	mux.HandleFunc("POST /locations", protected(trackingHTTPHandler.RecordLocation))
 
 	// Delivery tracking routes
-	mux.HandleFunc("/deliveries/", func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/deliveries/")
-		parts := strings.Split(path, "/")
-
-		if len(parts) >= 2 {
-			switch parts[1] {
-			case "track":
-				// GET /deliveries/{id}/track
-				authMiddleware(authService, trackingHTTPHandler.GetDeliveryTrack)(w, r)
-			case "location":
-				// GET /deliveries/{id}/location
-				authMiddleware(authService, trackingHTTPHandler.GetCurrentLocation)(w, r)
-			case "eta":
-				// POST /deliveries/{id}/eta
-				authMiddleware(authService, trackingHTTPHandler.CalculateETA)(w, r)
-			default:
-				http.NotFound(w, r)
-			}
-		} else {
-			http.NotFound(w, r)
-		}
-	})
+	mux.HandleFunc("GET /deliveries/{id}/track", protected(trackingHTTPHandler.GetDeliveryTrack))
+	mux.HandleFunc("GET /deliveries/{id}/track/export", protected(trackingHTTPHandler.ExportDeliveryTrack))
+	mux.HandleFunc("DELETE /deliveries/{id}/track", protected(trackingHTTPHandler.EraseDeliveryTrack))
+	mux.HandleFunc("GET /deliveries/{id}/location", protected(trackingHTTPHandler.GetCurrentLocation))
+	mux.HandleFunc("POST /deliveries/{id}/eta", protected(trackingHTTPHandler.CalculateETA))
 
 	// Courier location routes
-	mux.HandleFunc("/couriers/", func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/couriers/")
-		parts := strings.Split(path, "/")
-
-		if len(parts) >= 2 && parts[1] == "location" {
-			// GET /couriers/{id}/location
-			authMiddleware(authService, trackingHTTPHandler.GetCourierLocation)(w, r)
-		} else {
-			http.NotFound(w, r)
-		}
-	})
+	mux.HandleFunc("GET /couriers/{id}/location", protected(trackingHTTPHandler.GetCourierLocation))
+	mux.HandleFunc("GET /couriers/{id}/status", protected(trackingHTTPHandler.GetCourierStatus))
+	mux.HandleFunc("GET /couriers/me/summary", protected(trackingHTTPHandler.GetCourierDailySummary))
+	mux.HandleFunc("GET /couriers/locations", protected(trackingHTTPHandler.GetFleetLocations))
 
 	// WebSocket routes
-	mux.HandleFunc("/ws/deliveries/", wsHub.HandleWebSocket)
-	mux.HandleFunc("/ws/notifications", wsHub.HandleCustomerWebSocket)
+	mux.HandleFunc("GET /ws/deliveries/{id}/track", wsHub.HandleWebSocket)
+	mux.HandleFunc("GET /ws/notifications", wsHub.HandleCustomerWebSocket)
 
 	// Metrics endpoint
-	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		connectionCount := wsHub.GetConnectionCount()
-		fmt.Fprintf(w, `{"websocket_connections": %d}`, connectionCount)
+		purgedTracks, purgedPoints := trackingService.PurgedTracks()
+		ownerCache, statusCache := trackingService.DeliveryCacheStats()
+		ownerCacheJSON, _ := json.Marshal(ownerCache)
+		statusCacheJSON, _ := json.Marshal(statusCache)
+		fmt.Fprintf(w, `{"websocket_connections": %d, "websocket_dropped_broadcasts": %d, "websocket_rejected_connections": %d, "purged_tracks": %d, "purged_points": %d, "audit_dropped": %d, "delivery_circuit_state": %q, "delivery_owner_cache": %s, "delivery_status_cache": %s}`,
+			connectionCount, wsHub.DroppedBroadcasts(), wsHub.RejectedConnections(), purgedTracks, purgedPoints, auditWriter.Dropped(), trackingService.DeliveryCircuitState(), ownerCacheJSON, statusCacheJSON)
 	})
 
 	// Wrap with CORS middleware
@@ -181,12 +337,16 @@ func main() {
 			zap.String("port", port))
 		lg.Info("HTTP endpoints available",
 			zap.Strings("endpoints", []string{
+				"GET /health/live", "GET /health/ready",
 				"POST /login", "POST /register",
 				"POST /locations", "GET /deliveries/{id}/track",
+				"GET /deliveries/{id}/track/export?format=geojson|gpx",
+				"DELETE /deliveries/{id}/track",
 				"GET /deliveries/{id}/location", "GET /couriers/{id}/location",
+				"GET /couriers/{id}/status", "GET /couriers/me/summary?date=YYYY-MM-DD", "GET /couriers/locations",
 				"GET /metrics", "WS /ws/deliveries/{id}/track", "WS /ws/notifications"}))
 
-		if err := http.ListenAndServe(":"+port, httpHandler); err != nil {
+		if err := http.ListenAndServe(":"+port, tracing.HTTPHandler(httputil.RequestID(httpHandler), "tracking-service")); err != nil {
 			lg.Fatal("Failed to start HTTP server", zap.Error(err))
 		}
 	}()
@@ -199,29 +359,31 @@ func main() {
 			zap.String("port", grpcPort), zap.Error(err))
 	}
 
-	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			grpcinterceptors.ErrorHandlingUnaryServerInterceptor(),
-			grpcinterceptors.LoggingUnaryServerInterceptor(lg),
-			grpcinterceptors.AuthUnaryServerInterceptor(authService),
-			grpcinterceptors.UnaryServerInterceptor(),
-		),
-		grpc.ChainStreamInterceptor(
-			grpcinterceptors.ErrorHandlingStreamServerInterceptor(),
-			grpcinterceptors.LoggingStreamServerInterceptor(lg),
-			grpcinterceptors.AuthStreamServerInterceptor(authService),
-			grpcinterceptors.StreamServerInterceptor(),
-		),
+	grpcServer := grpcinterceptors.NewServer(lg, authService, cfg.Logging.SlowRequestThreshold,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	)
 	tracking.RegisterTrackingServiceServer(grpcServer, trackingGRPCHandler)
 
 	// Register health service
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	checker.SetGRPCServer(healthServer)
+	go checker.Run(context.Background(), healthcheck.DefaultInterval)
 
 	reflection.Register(grpcServer) // Enable reflection for debugging
 
+	// Stop background jobs and drain gRPC calls on SIGINT/SIGTERM
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		<-sigCh
+
+		lg.Info("Shutting down tracking service")
+		checker.Shutdown()
+		trackingService.Shutdown()
+		grpcServer.GracefulStop()
+	}()
+
 	lg.Info("Tracking gRPC service starting",
 		zap.String("version", version),
 		zap.String("port", grpcPort))
@@ -231,57 +393,12 @@ func main() {
 	}
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"status":"ok","service":"tracking"}`)
-}
-
 func rootHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{"service":"tracking","version":"%s"}`, version)
 }
 
-// authMiddleware validates JWT token and adds user info to context
-func authMiddleware(authService authPorts.AuthService, next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error":"unauthorized","message":"Authorization header required"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Check Bearer token format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid authorization header format"}`, http.StatusUnauthorized)
-			return
-		}
-
-		token := parts[1]
-
-		// Validate token
-		claims, err := authService.ValidateToken(r.Context(), token)
-		if err != nil {
-			http.Error(w, `{"error":"unauthorized","message":"Invalid or expired token"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "username", claims.Username)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		ctx = context.WithValue(ctx, "customer_id", claims.CustomerID)
-		ctx = context.WithValue(ctx, "courier_id", claims.CourierID)
-		ctx = context.WithValue(ctx, "authorization", authHeader)
-
-		// Call next handler with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	}
-}
-
 // corsMiddleware adds CORS headers
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,164 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+)
+
+// PostgresCourierStatsRepository implements the CourierStatsRepository interface using PostgreSQL
+type PostgresCourierStatsRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCourierStatsRepository creates a new PostgreSQL courier stats repository
+func NewPostgresCourierStatsRepository(db *sql.DB) *PostgresCourierStatsRepository {
+	return &PostgresCourierStatsRepository{db: db}
+}
+
+// RecordPickup stores when a courier picked up a delivery, keeping the first pickup time
+func (r *PostgresCourierStatsRepository) RecordPickup(ctx context.Context, deliveryID, courierID int, at time.Time) error {
+	query := `
+		INSERT INTO courier_deliveries (delivery_id, courier_id, picked_up_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (delivery_id) DO UPDATE
+		SET courier_id = EXCLUDED.courier_id,
+			picked_up_at = COALESCE(courier_deliveries.picked_up_at, EXCLUDED.picked_up_at)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, deliveryID, courierID, at)
+	return err
+}
+
+// RecordOutcome marks a delivery as finished and increments the courier's daily aggregates
+func (r *PostgresCourierStatsRepository) RecordOutcome(ctx context.Context, orgID, deliveryID, courierID int, outcome domain.DeliveryOutcome, at time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO courier_deliveries (delivery_id, courier_id)
+		VALUES ($1, $2)
+		ON CONFLICT (delivery_id) DO NOTHING
+	`, deliveryID, courierID)
+	if err != nil {
+		return false, err
+	}
+
+	// Only the first outcome for a delivery counts, so redelivered events are no-ops
+	var pickedUpAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		UPDATE courier_deliveries
+		SET finished_at = $1, outcome = $2
+		WHERE delivery_id = $3 AND finished_at IS NULL
+		RETURNING picked_up_at
+	`, at, outcome, deliveryID).Scan(&pickedUpAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var completed, cancelled, timed int
+	var seconds float64
+	switch outcome {
+	case domain.DeliveryOutcomeCompleted:
+		completed = 1
+		if pickedUpAt.Valid && at.After(pickedUpAt.Time) {
+			timed = 1
+			seconds = at.Sub(pickedUpAt.Time).Seconds()
+		}
+	case domain.DeliveryOutcomeCancelled:
+		cancelled = 1
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO courier_daily_stats (org_id, courier_id, day, completed, cancelled, timed_deliveries, total_delivery_seconds)
+		VALUES ($7, $1, $2::date, $3, $4, $5, $6)
+		ON CONFLICT (org_id, courier_id, day) DO UPDATE
+		SET completed = courier_daily_stats.completed + EXCLUDED.completed,
+			cancelled = courier_daily_stats.cancelled + EXCLUDED.cancelled,
+			timed_deliveries = courier_daily_stats.timed_deliveries + EXCLUDED.timed_deliveries,
+			total_delivery_seconds = courier_daily_stats.total_delivery_seconds + EXCLUDED.total_delivery_seconds
+	`, courierID, at, completed, cancelled, timed, seconds, orgOrDefault(orgID))
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetStats sums a courier's daily aggregates from the given day onwards
+func (r *PostgresCourierStatsRepository) GetStats(ctx context.Context, courierID int, from time.Time) (*domain.CourierStats, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(completed), 0),
+			COALESCE(SUM(cancelled), 0),
+			COALESCE(SUM(timed_deliveries), 0),
+			COALESCE(SUM(total_delivery_seconds), 0)
+		FROM courier_daily_stats
+		WHERE courier_id = $1 AND day >= $2::date
+			AND ($3::int IS NULL OR org_id = $3)
+	`
+
+	var stats domain.CourierStats
+	err := r.db.QueryRowContext(ctx, query, courierID, from, orgScope(ctx)).Scan(
+		&stats.Completed,
+		&stats.Cancelled,
+		&stats.TimedDeliveries,
+		&stats.TotalDeliverySeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// ListStats sums every courier's daily aggregates for the days in [from, to)
+func (r *PostgresCourierStatsRepository) ListStats(ctx context.Context, from, to time.Time) ([]domain.CourierReportRow, error) {
+	query := `
+		SELECT
+			courier_id,
+			SUM(completed),
+			SUM(cancelled),
+			SUM(timed_deliveries),
+			SUM(total_delivery_seconds)
+		FROM courier_daily_stats
+		WHERE day >= $1::date AND day < $2::date
+			AND ($3::int IS NULL OR org_id = $3)
+		GROUP BY courier_id
+		ORDER BY courier_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, orgScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.CourierReportRow
+	for rows.Next() {
+		var row domain.CourierReportRow
+		if err := rows.Scan(
+			&row.CourierID,
+			&row.Completed,
+			&row.Cancelled,
+			&row.TimedDeliveries,
+			&row.TotalDeliverySeconds,
+		); err != nil {
+			return nil, err
+		}
+		stats = append(stats, row)
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,212 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+)
+
+// monthLayout formats a month's first day as a DATE literal, so the month
+// does not shift with the session time zone
+const monthLayout = "2006-01-02"
+
+// PostgresCustomerStatsRepository implements the CustomerStatsRepository interface using PostgreSQL
+type PostgresCustomerStatsRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCustomerStatsRepository creates a new PostgreSQL customer stats repository
+func NewPostgresCustomerStatsRepository(db *sql.DB) *PostgresCustomerStatsRepository {
+	return &PostgresCustomerStatsRepository{db: db}
+}
+
+// RecordCreated stores a delivery's creation and increments its month's created count
+func (r *PostgresCustomerStatsRepository) RecordCreated(ctx context.Context, orgID, deliveryID, customerID int, at time.Time, windowEnd *time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Only the first creation counts; an outcome seen earlier left the row without one
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO customer_deliveries (delivery_id, customer_id, created_at, window_end)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (delivery_id) DO UPDATE
+		SET created_at = EXCLUDED.created_at,
+			window_end = EXCLUDED.window_end
+		WHERE customer_deliveries.created_at IS NULL
+		RETURNING delivery_id
+	`, deliveryID, customerID, at, windowEnd).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO customer_monthly_stats (org_id, customer_id, month, created)
+		VALUES ($3, $1, $2::date, 1)
+		ON CONFLICT (org_id, customer_id, month) DO UPDATE
+		SET created = customer_monthly_stats.created + 1
+	`, customerID, domain.MonthStart(at).Format(monthLayout), orgOrDefault(orgID))
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RecordOutcome marks a delivery as finished and increments its month's rollups
+func (r *PostgresCustomerStatsRepository) RecordOutcome(ctx context.Context, outcome domain.CustomerOutcome) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO customer_deliveries (delivery_id, customer_id)
+		VALUES ($1, $2)
+		ON CONFLICT (delivery_id) DO NOTHING
+	`, outcome.DeliveryID, outcome.CustomerID)
+	if err != nil {
+		return false, err
+	}
+
+	// Only the first outcome for a delivery counts, so redelivered events are no-ops
+	var createdAt, windowEnd sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		UPDATE customer_deliveries
+		SET finished_at = $1, outcome = $2
+		WHERE delivery_id = $3 AND finished_at IS NULL
+		RETURNING created_at, window_end
+	`, outcome.At, outcome.Outcome, outcome.DeliveryID).Scan(&createdAt, &windowEnd)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if createdAt.Valid {
+		outcome.CreatedAt = &createdAt.Time
+	}
+	if windowEnd.Valid {
+		outcome.WindowEnd = &windowEnd.Time
+	}
+
+	month := domain.MonthStart(outcome.At).Format(monthLayout)
+	stats := outcome.Stats()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO customer_monthly_stats (org_id, customer_id, month, delivered, cancelled, timed_deliveries, total_delivery_seconds, scheduled_deliveries, on_time_deliveries)
+		VALUES ($9, $1, $2::date, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (org_id, customer_id, month) DO UPDATE
+		SET delivered = customer_monthly_stats.delivered + EXCLUDED.delivered,
+			cancelled = customer_monthly_stats.cancelled + EXCLUDED.cancelled,
+			timed_deliveries = customer_monthly_stats.timed_deliveries + EXCLUDED.timed_deliveries,
+			total_delivery_seconds = customer_monthly_stats.total_delivery_seconds + EXCLUDED.total_delivery_seconds,
+			scheduled_deliveries = customer_monthly_stats.scheduled_deliveries + EXCLUDED.scheduled_deliveries,
+			on_time_deliveries = customer_monthly_stats.on_time_deliveries + EXCLUDED.on_time_deliveries
+	`, outcome.CustomerID, month, stats.Delivered, stats.Cancelled, stats.TimedDeliveries, stats.TotalDeliverySeconds, stats.Scheduled, stats.OnTime, orgOrDefault(outcome.OrgID))
+	if err != nil {
+		return false, err
+	}
+
+	if stats.Delivered > 0 && outcome.Zone != "" {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO customer_monthly_zones (org_id, customer_id, month, zone, delivered)
+			VALUES ($4, $1, $2::date, $3, 1)
+			ON CONFLICT (org_id, customer_id, month, zone) DO UPDATE
+			SET delivered = customer_monthly_zones.delivered + 1
+		`, outcome.CustomerID, month, outcome.Zone, orgOrDefault(outcome.OrgID))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListMonths returns the customer's monthly rollups from the query's first to last month
+func (r *PostgresCustomerStatsRepository) ListMonths(ctx context.Context, q domain.CustomerAnalyticsQuery) ([]domain.CustomerMonthRow, error) {
+	query := `
+		SELECT
+			month,
+			created,
+			delivered,
+			cancelled,
+			timed_deliveries,
+			total_delivery_seconds,
+			scheduled_deliveries,
+			on_time_deliveries
+		FROM customer_monthly_stats
+		WHERE customer_id = $1 AND month >= $2::date AND month <= $3::date
+			AND ($4::int IS NULL OR org_id = $4)
+		ORDER BY month
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, q.CustomerID, domain.MonthStart(q.From).Format(monthLayout), domain.MonthStart(q.To).Format(monthLayout), orgScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var months []domain.CustomerMonthRow
+	for rows.Next() {
+		var row domain.CustomerMonthRow
+		if err := rows.Scan(
+			&row.Month,
+			&row.Created,
+			&row.Delivered,
+			&row.Cancelled,
+			&row.TimedDeliveries,
+			&row.TotalDeliverySeconds,
+			&row.Scheduled,
+			&row.OnTime,
+		); err != nil {
+			return nil, err
+		}
+		months = append(months, row)
+	}
+
+	return months, rows.Err()
+}
+
+// ListZones sums the customer's monthly zone rollups from the query's first to last month
+func (r *PostgresCustomerStatsRepository) ListZones(ctx context.Context, q domain.CustomerAnalyticsQuery) ([]domain.CustomerZoneRow, error) {
+	query := `
+		SELECT zone, SUM(delivered)
+		FROM customer_monthly_zones
+		WHERE customer_id = $1 AND month >= $2::date AND month <= $3::date
+			AND ($4::int IS NULL OR org_id = $4)
+		GROUP BY zone
+		ORDER BY SUM(delivered) DESC, zone
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, q.CustomerID, domain.MonthStart(q.From).Format(monthLayout), domain.MonthStart(q.To).Format(monthLayout), orgScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []domain.CustomerZoneRow
+	for rows.Next() {
+		var row domain.CustomerZoneRow
+		if err := rows.Scan(&row.Zone, &row.Delivered); err != nil {
+			return nil, err
+		}
+		zones = append(zones, row)
+	}
+
+	return zones, rows.Err()
+}
@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+)
+
+// FileReportStore implements the ReportStore interface on a local directory,
+// keeping each report as <id>.json metadata next to its <id>.report artifact
+type FileReportStore struct {
+	dir string
+}
+
+// NewFileReportStore creates a report store in dir, creating the directory if needed
+func NewFileReportStore(dir string) (*FileReportStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create report directory: %w", err)
+	}
+	return &FileReportStore{dir: dir}, nil
+}
+
+// path returns the file holding a report part, rejecting IDs that are not
+// hex so they cannot escape the directory
+func (s *FileReportStore) path(id, ext string) (string, error) {
+	if id == "" {
+		return "", domain.ErrReportNotFound
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", domain.ErrReportNotFound
+		}
+	}
+	return filepath.Join(s.dir, id+ext), nil
+}
+
+// writeFile replaces a file atomically so readers never see partial content
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Save creates or replaces a report's metadata
+func (s *FileReportStore) Save(ctx context.Context, report *domain.Report) error {
+	path, err := s.path(report.ID, ".json")
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// Get retrieves a report's metadata
+func (s *FileReportStore) Get(ctx context.Context, id string) (*domain.Report, error) {
+	path, err := s.path(id, ".json")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report domain.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode report %s: %w", id, err)
+	}
+	return &report, nil
+}
+
+// WriteArtifact stores a report's rendered output
+func (s *FileReportStore) WriteArtifact(ctx context.Context, id string, data []byte) error {
+	path, err := s.path(id, ".report")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// OpenArtifact opens a report's rendered output
+func (s *FileReportStore) OpenArtifact(ctx context.Context, id string) (io.ReadCloser, error) {
+	path, err := s.path(id, ".report")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// List returns the metadata of every report in the directory
+func (s *FileReportStore) List(ctx context.Context) ([]*domain.Report, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []*domain.Report
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		report, err := s.Get(ctx, id)
+		if errors.Is(err, domain.ErrReportNotFound) {
+			continue // not a report file, or deleted since listing
+		}
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// Delete removes a report's metadata and artifact
+func (s *FileReportStore) Delete(ctx context.Context, id string) error {
+	for _, ext := range []string{".report", ".json"} {
+		path, err := s.path(id, ext)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,11 +2,17 @@ package adapters
 
 import (
 	"context"
+	"errors"
+	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
 	analyticsProto "github.com/Keneke-Einar/delivertrack/proto/analytics"
+	commonProto "github.com/Keneke-Einar/delivertrack/proto/common"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -14,7 +20,8 @@ import (
 // GRPCHandler handles gRPC requests for analytics operations
 type GRPCHandler struct {
 	analyticsProto.UnimplementedAnalyticsServiceServer
-	service ports.AnalyticsService
+	service       ports.AnalyticsService
+	reportBaseURL string // prefixed to report download paths
 }
 
 // NewGRPCHandler creates a new gRPC handler
@@ -24,6 +31,11 @@ func NewGRPCHandler(service ports.AnalyticsService) *GRPCHandler {
 	}
 }
 
+// SetReportBaseURL sets the analytics HTTP address report download URLs point at
+func (h *GRPCHandler) SetReportBaseURL(baseURL string) {
+	h.reportBaseURL = strings.TrimSuffix(baseURL, "/")
+}
+
 // RecordEvent implements analytics.AnalyticsServiceServer
 func (h *GRPCHandler) RecordEvent(ctx context.Context, req *analyticsProto.RecordEventRequest) (*analyticsProto.RecordEventResponse, error) {
 	entityID, err := strconv.Atoi(req.EntityId)
@@ -39,6 +51,9 @@ func (h *GRPCHandler) RecordEvent(ctx context.Context, req *analyticsProto.Recor
 
 	metric, err := h.service.RecordMetric(ctx, domain.MetricType(req.EventType), entityID, req.EntityType, 1.0, metadata)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMetric) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid event: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to record event: %v", err)
 	}
 
@@ -60,13 +75,18 @@ func (h *GRPCHandler) GetDeliveryMetrics(ctx context.Context, req *analyticsProt
 		return nil, status.Errorf(codes.Internal, "failed to get delivery metrics: %v", err)
 	}
 
+	var successRate float64
+	if stats.TotalDeliveries > 0 {
+		successRate = float64(stats.CompletedDeliveries) / float64(stats.TotalDeliveries) * 100
+	}
+
 	return &analyticsProto.GetDeliveryMetricsResponse{
 		Metrics: &analyticsProto.DeliveryMetrics{
 			TotalDeliveries:     int32(stats.TotalDeliveries),
 			SuccessfulDeliveries: int32(stats.CompletedDeliveries),
 			FailedDeliveries:    int32(stats.CancelledDeliveries),
 			CancelledDeliveries: int32(stats.CancelledDeliveries),
-			SuccessRate:         float64(stats.CompletedDeliveries) / float64(stats.TotalDeliveries) * 100,
+			SuccessRate:         successRate,
 			AverageDeliveryTime: float64(stats.AverageDeliveryTime),
 			OnTimeDeliveries:    0,
 			OnTimeRate:          0,
@@ -82,14 +102,148 @@ func (h *GRPCHandler) BatchRecordEvents(ctx context.Context, req *analyticsProto
 
 // GetDriverPerformance implements analytics.AnalyticsServiceServer
 func (h *GRPCHandler) GetDriverPerformance(ctx context.Context, req *analyticsProto.GetDriverPerformanceRequest) (*analyticsProto.GetDriverPerformanceResponse, error) {
-	// TODO: Implement when service supports driver performance
-	return nil, status.Errorf(codes.Unimplemented, "method GetDriverPerformance not implemented")
+	courierID, err := strconv.Atoi(req.DriverId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid driver_id: %v", err)
+	}
+
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user claims")
+	}
+
+	// Couriers may only see their own performance
+	switch claims.Role {
+	case "admin", "super_admin":
+	case "courier":
+		if claims.CourierID == nil || *claims.CourierID != courierID {
+			return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+		}
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+	}
+
+	perf, err := h.service.GetCourierPerformance(ctx, courierID, periodForTimeRange(req.TimeRange))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get driver performance: %v", err)
+	}
+
+	total := perf.DeliveriesCompleted + perf.DeliveriesCancelled
+	var completionRate float64
+	if total > 0 {
+		completionRate = float64(perf.DeliveriesCompleted) / float64(total) * 100
+	}
+
+	return &analyticsProto.GetDriverPerformanceResponse{
+		Performance: &analyticsProto.DriverPerformance{
+			DriverId:            req.DriverId,
+			TotalDeliveries:     int32(total),
+			CompletedDeliveries: int32(perf.DeliveriesCompleted),
+			FailedDeliveries:    int32(perf.DeliveriesCancelled),
+			CompletionRate:      completionRate,
+			AverageDeliveryTime: perf.AverageDeliveryMinutes,
+		},
+	}, nil
+}
+
+// periodForTimeRange picks the smallest aggregation period covering a time range
+func periodForTimeRange(tr *commonProto.TimeRange) string {
+	if tr == nil || tr.StartTime <= 0 {
+		return "month"
+	}
+
+	end := time.Now()
+	if tr.EndTime > 0 {
+		end = time.Unix(tr.EndTime, 0)
+	}
+
+	switch span := end.Sub(time.Unix(tr.StartTime, 0)); {
+	case span <= 24*time.Hour:
+		return "day"
+	case span <= 7*24*time.Hour:
+		return "week"
+	default:
+		return "month"
+	}
 }
 
-// GetCustomerAnalytics implements analytics.AnalyticsServiceServer
+// GetCustomerAnalytics implements analytics.AnalyticsServiceServer. Customers
+// get their own analytics and admins name the customer; cancellations are
+// reported as failed deliveries, and the top destination zones as
+// frequent_locations carrying only a city.
 func (h *GRPCHandler) GetCustomerAnalytics(ctx context.Context, req *analyticsProto.GetCustomerAnalyticsRequest) (*analyticsProto.GetCustomerAnalyticsResponse, error) {
-	// TODO: Implement when service supports customer analytics
-	return nil, status.Errorf(codes.Unimplemented, "method GetCustomerAnalytics not implemented")
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user claims")
+	}
+
+	var q domain.CustomerAnalyticsQuery
+	if req.CustomerId != "" {
+		customerID, err := strconv.Atoi(req.CustomerId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid customer_id: %v", err)
+		}
+		q.CustomerID = customerID
+	}
+
+	// Customers may only see their own analytics
+	switch claims.Role {
+	case "admin", "super_admin":
+		if q.CustomerID == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "customer_id is required")
+		}
+	case "customer":
+		if claims.CustomerID == nil || (q.CustomerID != 0 && q.CustomerID != *claims.CustomerID) {
+			return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+		}
+		q.CustomerID = *claims.CustomerID
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+	}
+
+	// Customer analytics covers the last twelve months unless a range is given
+	q.To = time.Now()
+	if tr := req.TimeRange; tr != nil && tr.EndTime > 0 {
+		q.To = time.Unix(tr.EndTime, 0)
+	}
+	q.From = domain.DefaultCustomerFrom(q.To)
+	if tr := req.TimeRange; tr != nil && tr.StartTime > 0 {
+		q.From = time.Unix(tr.StartTime, 0)
+	}
+
+	analytics, err := h.service.GetCustomerAnalytics(ctx, q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeRange) || errors.Is(err, domain.ErrInvalidCustomer) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get customer analytics: %v", err)
+	}
+
+	resp := &analyticsProto.CustomerAnalytics{
+		CustomerId:           strconv.Itoa(analytics.CustomerID),
+		TotalDeliveries:      int32(analytics.Totals.Created),
+		SuccessfulDeliveries: int32(analytics.Totals.Delivered),
+		FailedDeliveries:     int32(analytics.Totals.Cancelled),
+		AverageDeliveryTime:  analytics.AverageDeliveryMinutes,
+		OnTimeRate:           analytics.OnTimePercentage,
+	}
+	for _, zone := range analytics.TopZones {
+		resp.FrequentLocations = append(resp.FrequentLocations, &commonProto.Location{City: zone.Zone})
+	}
+	for _, month := range analytics.Months {
+		start, _ := time.Parse("2006-01", month.Month)
+		resp.Monthly = append(resp.Monthly, &analyticsProto.TimeSeriesPoint{
+			Timestamp: start.Unix(),
+			Value:     float64(month.Delivered),
+			Metrics: map[string]float64{
+				"created":   float64(month.Created),
+				"delivered": float64(month.Delivered),
+				"cancelled": float64(month.Cancelled),
+			},
+		})
+	}
+
+	return &analyticsProto.GetCustomerAnalyticsResponse{Analytics: resp}, nil
 }
 
 // GetSystemMetrics implements analytics.AnalyticsServiceServer
@@ -98,20 +252,231 @@ func (h *GRPCHandler) GetSystemMetrics(ctx context.Context, req *analyticsProto.
 	return nil, status.Errorf(codes.Unimplemented, "method GetSystemMetrics not implemented")
 }
 
-// GenerateReport implements analytics.AnalyticsServiceServer
+// reportTypes maps the proto report types the service can generate
+var reportTypes = map[analyticsProto.ReportType]domain.ReportType{
+	analyticsProto.ReportType_REPORT_TYPE_DELIVERY_SUMMARY:   domain.ReportTypeDeliveriesSummary,
+	analyticsProto.ReportType_REPORT_TYPE_DRIVER_PERFORMANCE: domain.ReportTypeCourierPerformance,
+}
+
+// reportFormats maps the proto report formats the service can render
+var reportFormats = map[analyticsProto.ReportFormat]domain.ReportFormat{
+	analyticsProto.ReportFormat_REPORT_FORMAT_CSV:  domain.ReportFormatCSV,
+	analyticsProto.ReportFormat_REPORT_FORMAT_JSON: domain.ReportFormatJSON,
+}
+
+// GenerateReport implements analytics.AnalyticsServiceServer. Reports are
+// generated in the background: the download URL answers 409 until the
+// report is ready, and GeneratedAt is when it was requested.
 func (h *GRPCHandler) GenerateReport(ctx context.Context, req *analyticsProto.GenerateReportRequest) (*analyticsProto.GenerateReportResponse, error) {
-	// TODO: Implement when service supports report generation
-	return nil, status.Errorf(codes.Unimplemented, "method GenerateReport not implemented")
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user claims")
+	}
+
+	reportType, ok := reportTypes[req.Type]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported report type %s", req.Type)
+	}
+	format, ok := reportFormats[req.Format]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported report format %s", req.Format)
+	}
+
+	// Reports cover the last 30 days unless a range is given
+	to := time.Now()
+	from := to.Add(-30 * 24 * time.Hour)
+	if tr := req.TimeRange; tr != nil {
+		if tr.EndTime > 0 {
+			to = time.Unix(tr.EndTime, 0)
+		}
+		if tr.StartTime > 0 {
+			from = time.Unix(tr.StartTime, 0)
+		}
+	}
+	reportReq := domain.ReportRequest{Type: reportType, Format: format, From: from, To: to, RequestedBy: claims.UserID}
+
+	// Customers only get delivery summaries of their own deliveries
+	switch claims.Role {
+	case "admin", "super_admin":
+	case "customer":
+		if claims.CustomerID == nil || reportType != domain.ReportTypeDeliveriesSummary {
+			return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+		}
+		reportReq.CustomerID = claims.CustomerID
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+	}
+
+	report, err := h.service.GenerateReport(ctx, reportReq)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeRange) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		if errors.Is(err, domain.ErrReportsBusy) {
+			return nil, status.Errorf(codes.ResourceExhausted, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to generate report: %v", err)
+	}
+
+	return &analyticsProto.GenerateReportResponse{
+		ReportId:    report.ID,
+		DownloadUrl: h.reportBaseURL + report.DownloadPath(),
+		GeneratedAt: report.CreatedAt.Unix(),
+		ExpiresAt:   report.ExpiresAt.Unix(),
+	}, nil
 }
 
 // GetDashboard implements analytics.AnalyticsServiceServer
 func (h *GRPCHandler) GetDashboard(ctx context.Context, req *analyticsProto.GetDashboardRequest) (*analyticsProto.GetDashboardResponse, error) {
-	// TODO: Implement when service supports dashboard
-	return nil, status.Errorf(codes.Unimplemented, "method GetDashboard not implemented")
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user claims")
+	}
+
+	// Executive dashboards look at the last 30 days, everything else at the last day
+	now := time.Now()
+	q := domain.DashboardQuery{From: now.Add(-24 * time.Hour), To: now, Bucket: domain.BucketHour}
+	if req.Type == analyticsProto.DashboardType_DASHBOARD_TYPE_EXECUTIVE {
+		q.From = now.Add(-30 * 24 * time.Hour)
+		q.Bucket = domain.BucketDay
+	}
+
+	// Customers only see their own aggregate
+	switch claims.Role {
+	case "admin", "super_admin":
+	case "customer":
+		if claims.CustomerID == nil {
+			return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+		}
+		q.CustomerID = claims.CustomerID
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+	}
+
+	dashboard, err := h.service.GetDashboard(ctx, q)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get dashboard: %v", err)
+	}
+
+	totals := dashboard.Totals
+	var successRate float64
+	if finished := totals.Delivered + totals.Cancelled; finished > 0 {
+		successRate = float64(totals.Delivered) / float64(finished) * 100
+	}
+
+	return &analyticsProto.GetDashboardResponse{
+		Dashboard: &analyticsProto.Dashboard{
+			Title:       "Deliveries",
+			LastUpdated: now.Unix(),
+			DeliverySummary: &analyticsProto.DeliveryMetrics{
+				TotalDeliveries:      int32(totals.Created),
+				SuccessfulDeliveries: int32(totals.Delivered),
+				CancelledDeliveries:  int32(totals.Cancelled),
+				SuccessRate:          successRate,
+				OnTimeRate:           dashboard.OnTimePercentage,
+			},
+			Kpis: []*analyticsProto.KPI{
+				{Name: "on_time_rate", Value: dashboard.OnTimePercentage, Unit: "%"},
+				{Name: "success_rate", Value: successRate, Unit: "%"},
+			},
+			Charts: []*analyticsProto.Chart{
+				dashboardChart("Created", dashboard.Buckets, dashboard.Created),
+				dashboardChart("Delivered", dashboard.Buckets, dashboard.Delivered),
+				dashboardChart("Cancelled", dashboard.Buckets, dashboard.Cancelled),
+			},
+		},
+	}, nil
+}
+
+// dashboardChart converts one dashboard series into a line chart
+func dashboardChart(title string, buckets []time.Time, values []int) *analyticsProto.Chart {
+	points := make([]*analyticsProto.TimeSeriesPoint, len(buckets))
+	for i, bucket := range buckets {
+		points[i] = &analyticsProto.TimeSeriesPoint{Timestamp: bucket.Unix(), Value: float64(values[i])}
+	}
+	return &analyticsProto.Chart{Title: title, Type: analyticsProto.ChartType_CHART_TYPE_LINE, Data: points}
 }
 
-// GetRouteEfficiency implements analytics.AnalyticsServiceServer
+// GetRouteEfficiency implements analytics.AnalyticsServiceServer. Without a
+// driver_id admins get every courier's routes; only the distance fields and
+// delivery counts are filled in.
 func (h *GRPCHandler) GetRouteEfficiency(ctx context.Context, req *analyticsProto.GetRouteEfficiencyRequest) (*analyticsProto.GetRouteEfficiencyResponse, error) {
-	// TODO: Implement when service supports route efficiency
-	return nil, status.Errorf(codes.Unimplemented, "method GetRouteEfficiency not implemented")
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user claims")
+	}
+
+	// Route efficiency covers the last 30 days unless a range is given
+	q := domain.RouteEfficiencyQuery{To: time.Now()}
+	q.From = q.To.Add(-30 * 24 * time.Hour)
+	if tr := req.TimeRange; tr != nil {
+		if tr.EndTime > 0 {
+			q.To = time.Unix(tr.EndTime, 0)
+		}
+		if tr.StartTime > 0 {
+			q.From = time.Unix(tr.StartTime, 0)
+		}
+	}
+	if req.DriverId != "" {
+		courierID, err := strconv.Atoi(req.DriverId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid driver_id: %v", err)
+		}
+		q.CourierID = &courierID
+	}
+
+	// Couriers may only see their own routes
+	switch claims.Role {
+	case "admin", "super_admin":
+	case "courier":
+		if claims.CourierID == nil || (q.CourierID != nil && *q.CourierID != *claims.CourierID) {
+			return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+		}
+		q.CourierID = claims.CourierID
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "unauthorized access")
+	}
+
+	efficiency, err := h.service.GetRouteEfficiency(ctx, q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeRange) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get route efficiency: %v", err)
+	}
+
+	total := efficiency.Total
+	resp := &analyticsProto.RouteEfficiency{
+		TotalDistance:          total.ActualKm,
+		OptimalDistance:        total.StraightLineKm,
+		DistanceRatio:          total.Ratio,
+		MeasuredDeliveries:     int32(total.Measured),
+		SparseTrackDeliveries:  int32(total.SparseTracks),
+		UnmeasurableDeliveries: int32(total.Unmeasurable),
+	}
+	// A detour-free route scores 100
+	if total.Ratio > 0 {
+		resp.EfficiencyScore = math.Min(100, 100/total.Ratio)
+	}
+	for _, group := range efficiency.Couriers {
+		resp.Drivers = append(resp.Drivers, routeEfficiencyGroup(strconv.Itoa(group.CourierID), group))
+	}
+	for _, group := range efficiency.Zones {
+		resp.Zones = append(resp.Zones, routeEfficiencyGroup(group.Zone, group))
+	}
+
+	return &analyticsProto.GetRouteEfficiencyResponse{Efficiency: resp}, nil
+}
+
+// routeEfficiencyGroup converts one courier's or zone's route efficiency
+func routeEfficiencyGroup(key string, group domain.RouteEfficiencyGroup) *analyticsProto.RouteEfficiencyGroup {
+	return &analyticsProto.RouteEfficiencyGroup{
+		Key:                    key,
+		TotalDistance:          group.ActualKm,
+		OptimalDistance:        group.StraightLineKm,
+		DistanceRatio:          group.Ratio,
+		MeasuredDeliveries:     int32(group.Measured),
+		SparseTrackDeliveries:  int32(group.SparseTracks),
+		UnmeasurableDeliveries: int32(group.Unmeasurable),
+	}
 }
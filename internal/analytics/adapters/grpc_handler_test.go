@@ -0,0 +1,469 @@
+package adapters
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	analyticsProto "github.com/Keneke-Einar/delivertrack/proto/analytics"
+	"github.com/Keneke-Einar/delivertrack/proto/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tokens the contract tests' callers authenticate with
+const (
+	adminToken    = "admin-token"
+	customerToken = "customer-token" // customer 5
+	courierToken  = "courier-token"  // courier 7
+)
+
+// MockAnalyticsService answers with fixed figures and records the queries it receives
+type MockAnalyticsService struct {
+	mu             sync.Mutex
+	stats          domain.DeliveryStats
+	metrics        []*domain.Metric
+	dashboardQuery *domain.DashboardQuery
+	reportRequest  *domain.ReportRequest
+	routeQuery     *domain.RouteEfficiencyQuery
+	customerQuery  *domain.CustomerAnalyticsQuery
+}
+
+func (m *MockAnalyticsService) RecordMetric(ctx context.Context, metricType domain.MetricType, entityID int, entityType string, value float64, metadata map[string]interface{}) (*domain.Metric, error) {
+	metric, err := domain.NewMetric(metricType, entityID, entityType, value)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metrics = append(m.metrics, metric)
+	return metric, nil
+}
+
+func (m *MockAnalyticsService) GetDeliveryStats(ctx context.Context, period string) (*domain.DeliveryStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	return &stats, nil
+}
+
+func (m *MockAnalyticsService) GetMetricsByType(ctx context.Context, metricType domain.MetricType, limit int) ([]*domain.Metric, error) {
+	return nil, nil
+}
+
+func (m *MockAnalyticsService) GetCourierPerformance(ctx context.Context, courierID int, period string) (*domain.CourierPerformance, error) {
+	return &domain.CourierPerformance{
+		CourierID:              courierID,
+		Period:                 period,
+		DeliveriesCompleted:    3,
+		DeliveriesCancelled:    1,
+		AverageDeliveryMinutes: 42,
+	}, nil
+}
+
+func (m *MockAnalyticsService) GetDashboard(ctx context.Context, q domain.DashboardQuery) (*domain.Dashboard, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dashboardQuery = &q
+	return &domain.Dashboard{
+		From:             q.From,
+		To:               q.To,
+		Bucket:           q.Bucket,
+		Buckets:          []time.Time{q.From},
+		Created:          []int{4},
+		Delivered:        []int{3},
+		Cancelled:        []int{1},
+		Totals:           domain.DashboardTotals{Created: 4, Delivered: 3, Cancelled: 1},
+		OnTimePercentage: 50,
+	}, nil
+}
+
+func (m *MockAnalyticsService) GetRouteEfficiency(ctx context.Context, q domain.RouteEfficiencyQuery) (*domain.RouteEfficiency, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routeQuery = &q
+	return domain.NewRouteEfficiency(q, []domain.RouteStatsRow{
+		{CourierID: 7, Zone: "Berlin", RouteStats: domain.RouteStats{Measured: 2, ActualKm: 15, StraightLineKm: 10, SparseTracks: 1}},
+		{CourierID: 7, Zone: "Potsdam", RouteStats: domain.RouteStats{Measured: 1, ActualKm: 10, StraightLineKm: 10, Unmeasurable: 1}},
+	}), nil
+}
+
+func (m *MockAnalyticsService) GetCustomerAnalytics(ctx context.Context, q domain.CustomerAnalyticsQuery) (*domain.CustomerAnalytics, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.customerQuery = &q
+	return domain.NewCustomerAnalytics(q, []domain.CustomerMonthRow{
+		{Month: domain.MonthStart(q.To), CustomerMonthStats: domain.CustomerMonthStats{
+			Created: 4, Delivered: 3, Cancelled: 1, TimedDeliveries: 2, TotalDeliverySeconds: 2 * 45 * 60, Scheduled: 2, OnTime: 1,
+		}},
+	}, []domain.CustomerZoneRow{{Zone: "Potsdam", Delivered: 1}, {Zone: "Berlin", Delivered: 2}}), nil
+}
+
+func (m *MockAnalyticsService) GenerateReport(ctx context.Context, req domain.ReportRequest) (*domain.Report, error) {
+	report, err := domain.NewReport(req, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reportRequest = &req
+	return report, nil
+}
+
+func (m *MockAnalyticsService) GetReport(ctx context.Context, id string) (*domain.Report, error) {
+	return nil, domain.ErrReportNotFound
+}
+
+func (m *MockAnalyticsService) OpenReportArtifact(ctx context.Context, id string) (*domain.Report, io.ReadCloser, error) {
+	return nil, nil, domain.ErrReportNotFound
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// newAnalyticsClient serves the analytics gRPC handler over the production interceptor chain
+func newAnalyticsClient(t *testing.T, service *MockAnalyticsService) analyticsProto.AnalyticsServiceClient {
+	t.Helper()
+
+	authService := testsupport.NewAuthService()
+	authService.AddToken(adminToken, &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin})
+	authService.AddToken(customerToken, &authDomain.Claims{UserID: 2, Role: authDomain.RoleCustomer, CustomerID: intPtr(5)})
+	authService.AddToken(courierToken, &authDomain.Claims{UserID: 3, Role: authDomain.RoleCourier, CourierID: intPtr(7)})
+
+	handler := NewGRPCHandler(service)
+	handler.SetReportBaseURL("http://analytics.test/")
+	conn := testsupport.DialGRPCServer(t, authService, func(s *grpc.Server) {
+		analyticsProto.RegisterAnalyticsServiceServer(s, handler)
+	})
+	return analyticsProto.NewAnalyticsServiceClient(conn)
+}
+
+func as(token string) context.Context {
+	return testsupport.WithToken(context.Background(), token)
+}
+
+func expectCode(t *testing.T, err error, expected codes.Code) {
+	t.Helper()
+	if status.Code(err) != expected {
+		t.Fatalf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestAnalyticsGRPC_RequiresAuthentication(t *testing.T) {
+	client := newAnalyticsClient(t, &MockAnalyticsService{})
+
+	_, err := client.GetDashboard(context.Background(), &analyticsProto.GetDashboardRequest{})
+	expectCode(t, err, codes.Unauthenticated)
+
+	_, err = client.GetDashboard(as("unknown-token"), &analyticsProto.GetDashboardRequest{})
+	expectCode(t, err, codes.Unauthenticated)
+}
+
+func TestAnalyticsGRPC_RecordEvent(t *testing.T) {
+	service := &MockAnalyticsService{}
+	client := newAnalyticsClient(t, service)
+
+	resp, err := client.RecordEvent(as(adminToken), &analyticsProto.RecordEventRequest{
+		EventType:  string(domain.MetricTypeDeliveryCreated),
+		EntityId:   "12",
+		EntityType: "delivery",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.RecordedAt == 0 || len(service.metrics) != 1 || service.metrics[0].EntityID != 12 {
+		t.Errorf("unexpected response %+v or metrics %+v", resp, service.metrics)
+	}
+
+	_, err = client.RecordEvent(as(adminToken), &analyticsProto.RecordEventRequest{EntityId: "", EntityType: "delivery"})
+	expectCode(t, err, codes.InvalidArgument)
+
+	_, err = client.RecordEvent(as(adminToken), &analyticsProto.RecordEventRequest{EntityId: "12"})
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestAnalyticsGRPC_GetDeliveryMetrics(t *testing.T) {
+	t.Run("reports the success rate", func(t *testing.T) {
+		client := newAnalyticsClient(t, &MockAnalyticsService{stats: domain.DeliveryStats{TotalDeliveries: 4, CompletedDeliveries: 3, CancelledDeliveries: 1}})
+
+		resp, err := client.GetDeliveryMetrics(as(adminToken), &analyticsProto.GetDeliveryMetricsRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if m := resp.Metrics; m.TotalDeliveries != 4 || m.SuccessfulDeliveries != 3 || m.SuccessRate != 75 {
+			t.Errorf("unexpected metrics %+v", m)
+		}
+	})
+
+	t.Run("has no success rate without deliveries", func(t *testing.T) {
+		client := newAnalyticsClient(t, &MockAnalyticsService{})
+
+		resp, err := client.GetDeliveryMetrics(as(adminToken), &analyticsProto.GetDeliveryMetricsRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.IsNaN(resp.Metrics.SuccessRate) || resp.Metrics.SuccessRate != 0 {
+			t.Errorf("expected a zero success rate, got %v", resp.Metrics.SuccessRate)
+		}
+	})
+}
+
+func TestAnalyticsGRPC_GetDriverPerformance(t *testing.T) {
+	client := newAnalyticsClient(t, &MockAnalyticsService{})
+
+	resp, err := client.GetDriverPerformance(as(courierToken), &analyticsProto.GetDriverPerformanceRequest{DriverId: "7"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p := resp.Performance; p.DriverId != "7" || p.TotalDeliveries != 4 || p.CompletionRate != 75 || p.AverageDeliveryTime != 42 {
+		t.Errorf("unexpected performance %+v", p)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		driverID     string
+		expectedCode codes.Code
+	}{
+		{name: "another courier", token: courierToken, driverID: "8", expectedCode: codes.PermissionDenied},
+		{name: "customer", token: customerToken, driverID: "7", expectedCode: codes.PermissionDenied},
+		{name: "empty driver_id", token: adminToken, driverID: "", expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetDriverPerformance(as(tt.token), &analyticsProto.GetDriverPerformanceRequest{DriverId: tt.driverID})
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
+
+func TestAnalyticsGRPC_GetDashboard(t *testing.T) {
+	service := &MockAnalyticsService{}
+	client := newAnalyticsClient(t, service)
+
+	resp, err := client.GetDashboard(as(customerToken), &analyticsProto.GetDashboardRequest{Type: analyticsProto.DashboardType_DASHBOARD_TYPE_EXECUTIVE})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := service.dashboardQuery; q.CustomerID == nil || *q.CustomerID != 5 || q.Bucket != domain.BucketDay {
+		t.Errorf("expected a daily query scoped to customer 5, got %+v", q)
+	}
+	summary := resp.Dashboard.DeliverySummary
+	if summary.TotalDeliveries != 4 || summary.SuccessRate != 75 || summary.OnTimeRate != 50 || len(resp.Dashboard.Charts) != 3 {
+		t.Errorf("unexpected dashboard %+v", resp.Dashboard)
+	}
+
+	_, err = client.GetDashboard(as(courierToken), &analyticsProto.GetDashboardRequest{})
+	expectCode(t, err, codes.PermissionDenied)
+}
+
+func TestAnalyticsGRPC_GenerateReport(t *testing.T) {
+	service := &MockAnalyticsService{}
+	client := newAnalyticsClient(t, service)
+
+	resp, err := client.GenerateReport(as(customerToken), &analyticsProto.GenerateReportRequest{
+		Type:   analyticsProto.ReportType_REPORT_TYPE_DELIVERY_SUMMARY,
+		Format: analyticsProto.ReportFormat_REPORT_FORMAT_CSV,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ReportId == "" || resp.DownloadUrl != "http://analytics.test/reports/"+resp.ReportId || resp.ExpiresAt <= resp.GeneratedAt {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if req := service.reportRequest; req.CustomerID == nil || *req.CustomerID != 5 || req.RequestedBy != 2 {
+		t.Errorf("expected a report of customer 5's deliveries, got %+v", req)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name         string
+		token        string
+		req          *analyticsProto.GenerateReportRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "customer courier report",
+			token:        customerToken,
+			req:          &analyticsProto.GenerateReportRequest{Type: analyticsProto.ReportType_REPORT_TYPE_DRIVER_PERFORMANCE, Format: analyticsProto.ReportFormat_REPORT_FORMAT_CSV},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name:         "courier",
+			token:        courierToken,
+			req:          &analyticsProto.GenerateReportRequest{Type: analyticsProto.ReportType_REPORT_TYPE_DELIVERY_SUMMARY, Format: analyticsProto.ReportFormat_REPORT_FORMAT_CSV},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name:         "unsupported type",
+			token:        adminToken,
+			req:          &analyticsProto.GenerateReportRequest{Type: analyticsProto.ReportType_REPORT_TYPE_FINANCIAL, Format: analyticsProto.ReportFormat_REPORT_FORMAT_CSV},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "unsupported format",
+			token:        adminToken,
+			req:          &analyticsProto.GenerateReportRequest{Type: analyticsProto.ReportType_REPORT_TYPE_DELIVERY_SUMMARY, Format: analyticsProto.ReportFormat_REPORT_FORMAT_PDF},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:  "range ending before it starts",
+			token: adminToken,
+			req: &analyticsProto.GenerateReportRequest{
+				Type:      analyticsProto.ReportType_REPORT_TYPE_DELIVERY_SUMMARY,
+				Format:    analyticsProto.ReportFormat_REPORT_FORMAT_JSON,
+				TimeRange: &common.TimeRange{StartTime: now.Unix(), EndTime: now.Add(-time.Hour).Unix()},
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GenerateReport(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
+
+func TestAnalyticsGRPC_Unimplemented(t *testing.T) {
+	client := newAnalyticsClient(t, &MockAnalyticsService{})
+	ctx := as(adminToken)
+
+	_, err := client.BatchRecordEvents(ctx, &analyticsProto.BatchRecordEventsRequest{})
+	expectCode(t, err, codes.Unimplemented)
+
+	_, err = client.GetSystemMetrics(ctx, &analyticsProto.GetSystemMetricsRequest{})
+	expectCode(t, err, codes.Unimplemented)
+}
+
+func TestAnalyticsGRPC_GetRouteEfficiency(t *testing.T) {
+	service := &MockAnalyticsService{}
+	client := newAnalyticsClient(t, service)
+
+	resp, err := client.GetRouteEfficiency(as(courierToken), &analyticsProto.GetRouteEfficiencyRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := service.routeQuery; q.CourierID == nil || *q.CourierID != 7 {
+		t.Errorf("expected a query scoped to courier 7, got %+v", q)
+	}
+	e := resp.Efficiency
+	if e.TotalDistance != 25 || e.OptimalDistance != 20 || e.DistanceRatio != 1.25 || e.EfficiencyScore != 80 {
+		t.Errorf("unexpected distances %+v", e)
+	}
+	if e.MeasuredDeliveries != 3 || e.SparseTrackDeliveries != 1 || e.UnmeasurableDeliveries != 1 {
+		t.Errorf("unexpected delivery counts %+v", e)
+	}
+	if len(e.Drivers) != 1 || e.Drivers[0].Key != "7" || len(e.Zones) != 2 || e.Zones[0].Key != "Berlin" || e.Zones[0].DistanceRatio != 1.5 {
+		t.Errorf("unexpected groups %+v %+v", e.Drivers, e.Zones)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name         string
+		token        string
+		req          *analyticsProto.GetRouteEfficiencyRequest
+		expectedCode codes.Code
+	}{
+		{name: "another courier", token: courierToken, req: &analyticsProto.GetRouteEfficiencyRequest{DriverId: "8"}, expectedCode: codes.PermissionDenied},
+		{name: "customer", token: customerToken, req: &analyticsProto.GetRouteEfficiencyRequest{}, expectedCode: codes.PermissionDenied},
+		{name: "invalid driver_id", token: adminToken, req: &analyticsProto.GetRouteEfficiencyRequest{DriverId: "seven"}, expectedCode: codes.InvalidArgument},
+		{
+			name:         "range ending before it starts",
+			token:        adminToken,
+			req:          &analyticsProto.GetRouteEfficiencyRequest{TimeRange: &common.TimeRange{StartTime: now.Unix(), EndTime: now.Add(-time.Hour).Unix()}},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetRouteEfficiency(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+
+	// Admins see every courier without a driver_id
+	if _, err := client.GetRouteEfficiency(as(adminToken), &analyticsProto.GetRouteEfficiencyRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := service.routeQuery; q.CourierID != nil {
+		t.Errorf("expected an unscoped query, got courier %d", *q.CourierID)
+	}
+}
+
+func TestAnalyticsGRPC_GetCustomerAnalytics(t *testing.T) {
+	service := &MockAnalyticsService{}
+	client := newAnalyticsClient(t, service)
+
+	resp, err := client.GetCustomerAnalytics(as(customerToken), &analyticsProto.GetCustomerAnalyticsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := service.customerQuery
+	if q.CustomerID != 5 || !q.From.Equal(domain.DefaultCustomerFrom(q.To)) {
+		t.Errorf("expected the last twelve months of customer 5, got %+v", q)
+	}
+	a := resp.Analytics
+	if a.CustomerId != "5" || a.TotalDeliveries != 4 || a.SuccessfulDeliveries != 3 || a.FailedDeliveries != 1 {
+		t.Errorf("unexpected counts %+v", a)
+	}
+	if a.AverageDeliveryTime != 45 || a.OnTimeRate != 50 {
+		t.Errorf("expected 45 minutes and 50%% on time, got %v and %v", a.AverageDeliveryTime, a.OnTimeRate)
+	}
+	if len(a.Monthly) != domain.DefaultCustomerMonths || a.Monthly[len(a.Monthly)-1].Metrics["created"] != 4 {
+		t.Errorf("expected twelve months ending with this one, got %+v", a.Monthly)
+	}
+	if len(a.FrequentLocations) != 2 || a.FrequentLocations[0].City != "Berlin" {
+		t.Errorf("expected Berlin first, got %+v", a.FrequentLocations)
+	}
+
+	// A customer may name themselves, and admins any customer
+	if _, err := client.GetCustomerAnalytics(as(customerToken), &analyticsProto.GetCustomerAnalyticsRequest{CustomerId: "5"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetCustomerAnalytics(as(adminToken), &analyticsProto.GetCustomerAnalyticsRequest{CustomerId: "9"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := service.customerQuery; q.CustomerID != 9 {
+		t.Errorf("expected a query for customer 9, got %+v", q)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name         string
+		token        string
+		req          *analyticsProto.GetCustomerAnalyticsRequest
+		expectedCode codes.Code
+	}{
+		{name: "another customer", token: customerToken, req: &analyticsProto.GetCustomerAnalyticsRequest{CustomerId: "6"}, expectedCode: codes.PermissionDenied},
+		{name: "courier", token: courierToken, req: &analyticsProto.GetCustomerAnalyticsRequest{CustomerId: "5"}, expectedCode: codes.PermissionDenied},
+		{name: "admin without customer_id", token: adminToken, req: &analyticsProto.GetCustomerAnalyticsRequest{}, expectedCode: codes.InvalidArgument},
+		{name: "invalid customer_id", token: adminToken, req: &analyticsProto.GetCustomerAnalyticsRequest{CustomerId: "five"}, expectedCode: codes.InvalidArgument},
+		{
+			name:         "range ending before it starts",
+			token:        customerToken,
+			req:          &analyticsProto.GetCustomerAnalyticsRequest{TimeRange: &common.TimeRange{StartTime: now.Unix(), EndTime: now.Add(-time.Hour).Unix()}},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetCustomerAnalytics(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
@@ -2,10 +2,17 @@ package adapters
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
 )
 
@@ -78,3 +85,407 @@ func (h *HTTPHandler) GetDeliveryStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
+
+// GetCourierPerformance handles GET /stats/couriers/{id}
+func (h *HTTPHandler) GetCourierPerformance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract trace context
+	traceCtx := httputil.ExtractTraceContext(r, "analytics-service", "get_courier_performance_http")
+
+	courierID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/stats/couriers/"))
+	if err != nil || courierID <= 0 {
+		httputil.SendErrorResponse(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	// Couriers may only see their own performance
+	switch userCtx.Role {
+	case "admin", "super_admin":
+	case "courier":
+		if userCtx.CourierID == nil || *userCtx.CourierID != courierID {
+			httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+			return
+		}
+	default:
+		httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+		return
+	}
+
+	perf, err := h.service.GetCourierPerformance(traceCtx, courierID, r.URL.Query().Get("period"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPeriod) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to get courier performance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(perf)
+}
+
+// GetDashboard handles GET /stats/dashboard
+func (h *HTTPHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract trace context
+	traceCtx := httputil.ExtractTraceContext(r, "analytics-service", "get_dashboard_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	q := domain.DashboardQuery{To: time.Now(), Bucket: query.Get("bucket")}
+	if q.Bucket == "" {
+		q.Bucket = domain.BucketHour
+	}
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid to, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		q.To = parsed
+	}
+	q.From = q.To.Add(-24 * time.Hour)
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid from, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		q.From = parsed
+	}
+
+	// Customers only see their own aggregate
+	switch userCtx.Role {
+	case "admin", "super_admin":
+	case "customer":
+		if userCtx.CustomerID == nil {
+			httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+			return
+		}
+		q.CustomerID = userCtx.CustomerID
+	default:
+		httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+		return
+	}
+
+	dashboard, err := h.service.GetDashboard(traceCtx, q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeRange) || errors.Is(err, domain.ErrInvalidBucket) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to get dashboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboard)
+}
+
+// GetRouteEfficiency handles GET /stats/route-efficiency
+func (h *HTTPHandler) GetRouteEfficiency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract trace context
+	traceCtx := httputil.ExtractTraceContext(r, "analytics-service", "get_route_efficiency_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	q := domain.RouteEfficiencyQuery{To: time.Now()}
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid to, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		q.To = parsed
+	}
+	q.From = q.To.Add(-30 * 24 * time.Hour)
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid from, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		q.From = parsed
+	}
+	if courier := query.Get("courier_id"); courier != "" {
+		courierID, err := strconv.Atoi(courier)
+		if err != nil || courierID <= 0 {
+			httputil.SendErrorResponse(w, "Invalid courier ID", http.StatusBadRequest)
+			return
+		}
+		q.CourierID = &courierID
+	}
+
+	// Couriers may only see their own routes
+	switch userCtx.Role {
+	case "admin", "super_admin":
+	case "courier":
+		if userCtx.CourierID == nil || (q.CourierID != nil && *q.CourierID != *userCtx.CourierID) {
+			httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+			return
+		}
+		q.CourierID = userCtx.CourierID
+	default:
+		httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+		return
+	}
+
+	efficiency, err := h.service.GetRouteEfficiency(traceCtx, q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeRange) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to get route efficiency", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(efficiency)
+}
+
+// GetCustomerAnalytics handles GET /stats/me
+func (h *HTTPHandler) GetCustomerAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract trace context
+	traceCtx := httputil.ExtractTraceContext(r, "analytics-service", "get_customer_analytics_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	q := domain.CustomerAnalyticsQuery{To: time.Now()}
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid to, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		q.To = parsed
+	}
+	q.From = domain.DefaultCustomerFrom(q.To)
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid from, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		q.From = parsed
+	}
+	if customer := query.Get("customer_id"); customer != "" {
+		customerID, err := strconv.Atoi(customer)
+		if err != nil || customerID <= 0 {
+			httputil.SendErrorResponse(w, "Invalid customer ID", http.StatusBadRequest)
+			return
+		}
+		q.CustomerID = customerID
+	}
+
+	// Customers may only see their own analytics; admins name the customer
+	switch userCtx.Role {
+	case "admin", "super_admin":
+		if q.CustomerID == 0 {
+			httputil.SendErrorResponse(w, "customer_id is required", http.StatusBadRequest)
+			return
+		}
+	case "customer":
+		if userCtx.CustomerID == nil || (q.CustomerID != 0 && q.CustomerID != *userCtx.CustomerID) {
+			httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+			return
+		}
+		q.CustomerID = *userCtx.CustomerID
+	default:
+		httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+		return
+	}
+
+	analytics, err := h.service.GetCustomerAnalytics(traceCtx, q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeRange) || errors.Is(err, domain.ErrInvalidCustomer) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to get customer analytics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics)
+}
+
+// reportResponse is a report's status with where to poll and download it
+type reportResponse struct {
+	*domain.Report
+	StatusURL   string `json:"status_url"`
+	DownloadURL string `json:"download_url"`
+}
+
+func newReportResponse(report *domain.Report) reportResponse {
+	return reportResponse{
+		Report:      report,
+		StatusURL:   report.DownloadPath() + "/status",
+		DownloadURL: report.DownloadPath(),
+	}
+}
+
+// CreateReport handles POST /reports
+func (h *HTTPHandler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract trace context
+	traceCtx := httputil.ExtractTraceContext(r, "analytics-service", "create_report_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Type   domain.ReportType   `json:"type"`
+		Format domain.ReportFormat `json:"format"`
+		From   time.Time           `json:"from"`
+		To     time.Time           `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httputil.SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req := domain.ReportRequest{
+		Type:        body.Type,
+		Format:      body.Format,
+		From:        body.From,
+		To:          body.To,
+		RequestedBy: userCtx.UserID,
+	}
+
+	// Customers only get delivery summaries of their own deliveries
+	switch userCtx.Role {
+	case "admin", "super_admin":
+	case "customer":
+		if userCtx.CustomerID == nil || req.Type != domain.ReportTypeDeliveriesSummary {
+			httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+			return
+		}
+		req.CustomerID = userCtx.CustomerID
+	default:
+		httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+		return
+	}
+
+	report, err := h.service.GenerateReport(traceCtx, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidReportType) || errors.Is(err, domain.ErrInvalidReportFormat) || errors.Is(err, domain.ErrInvalidTimeRange) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrReportsBusy) {
+			w.Header().Set("Retry-After", "30")
+			httputil.SendErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to generate report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", report.DownloadPath()+"/status")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newReportResponse(report))
+}
+
+// GetReport handles GET /reports/{id} and GET /reports/{id}/status
+func (h *HTTPHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract trace context
+	traceCtx := httputil.ExtractTraceContext(r, "analytics-service", "get_report_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/reports/")
+	id, statusOnly := strings.CutSuffix(id, "/status")
+	if id == "" || strings.Contains(id, "/") {
+		httputil.SendErrorResponse(w, "Invalid report ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetReport(traceCtx, id)
+	if err == nil && !report.VisibleTo(userCtx.UserID, authctx.Organization(traceCtx), userCtx.Role) {
+		// Other users' reports are indistinguishable from missing ones
+		err = domain.ErrReportNotFound
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrReportNotFound) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to get report", http.StatusInternalServerError)
+		return
+	}
+
+	if statusOnly {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newReportResponse(report))
+		return
+	}
+
+	report, artifact, err := h.service.OpenReportArtifact(traceCtx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrReportNotFound):
+			httputil.SendErrorResponse(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, domain.ErrReportNotReady), errors.Is(err, domain.ErrReportFailed):
+			httputil.SendErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			httputil.SendErrorResponse(w, "Failed to get report", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer artifact.Close()
+
+	w.Header().Set("Content-Type", report.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, report.Type, report.ID, report.Format))
+	io.Copy(w, artifact)
+}
@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+func TestHTTPHandler_GetCustomerAnalytics(t *testing.T) {
+	admin := &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}
+	customer := &authDomain.Claims{UserID: 2, Role: authDomain.RoleCustomer, CustomerID: intPtr(5)}
+	courier := &authDomain.Claims{UserID: 3, Role: authDomain.RoleCourier, CourierID: intPtr(7)}
+
+	tests := []struct {
+		name             string
+		claims           *authDomain.Claims
+		query            string
+		expectedStatus   int
+		expectedCustomer int
+	}{
+		{name: "customer", claims: customer, expectedStatus: http.StatusOK, expectedCustomer: 5},
+		{name: "customer naming themselves", claims: customer, query: "?customer_id=5", expectedStatus: http.StatusOK, expectedCustomer: 5},
+		{name: "another customer", claims: customer, query: "?customer_id=6", expectedStatus: http.StatusForbidden},
+		{name: "admin", claims: admin, query: "?customer_id=6", expectedStatus: http.StatusOK, expectedCustomer: 6},
+		{name: "admin without customer_id", claims: admin, expectedStatus: http.StatusBadRequest},
+		{name: "courier", claims: courier, query: "?customer_id=5", expectedStatus: http.StatusForbidden},
+		{name: "unauthenticated", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid customer_id", claims: admin, query: "?customer_id=five", expectedStatus: http.StatusBadRequest},
+		{name: "invalid from", claims: customer, query: "?from=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "range ending before it starts", claims: customer, query: "?from=2026-03-01T00:00:00Z&to=2026-02-01T00:00:00Z", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &MockAnalyticsService{}
+			handler := NewHTTPHandler(service)
+
+			req := httptest.NewRequest(http.MethodGet, "/stats/me"+tt.query, nil)
+			if tt.claims != nil {
+				req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			handler.GetCustomerAnalytics(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if q := service.customerQuery; q == nil || q.CustomerID != tt.expectedCustomer {
+				t.Errorf("expected a query for customer %d, got %+v", tt.expectedCustomer, q)
+			}
+
+			var analytics domain.CustomerAnalytics
+			if err := json.NewDecoder(rec.Body).Decode(&analytics); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if analytics.CustomerID != tt.expectedCustomer || analytics.Totals.Created != 4 || len(analytics.TopZones) != 2 {
+				t.Errorf("unexpected analytics %+v", analytics)
+			}
+		})
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 )
 
 // PostgresMetricRepository implements the MetricRepository interface using PostgreSQL
@@ -18,6 +23,24 @@ func NewPostgresMetricRepository(db *sql.DB) *PostgresMetricRepository {
 	return &PostgresMetricRepository{db: db}
 }
 
+// orgOrDefault returns the organization rows are recorded under: orgID, or
+// the default one for events that predate organizations
+func orgOrDefault(orgID int) int {
+	if orgID == 0 {
+		return authDomain.DefaultOrgID
+	}
+	return orgID
+}
+
+// orgScope returns the organization the caller in ctx is confined to, or nil
+// when they may read every organization's analytics
+func orgScope(ctx context.Context) *int {
+	if orgID, ok := authctx.OrgScope(ctx); ok {
+		return &orgID
+	}
+	return nil
+}
+
 // Create stores a new metric
 func (r *PostgresMetricRepository) Create(ctx context.Context, metric *domain.Metric) error {
 	metadataJSON, err := json.Marshal(metric.Metadata)
@@ -26,8 +49,9 @@ func (r *PostgresMetricRepository) Create(ctx context.Context, metric *domain.Me
 	}
 
 	query := `
-		INSERT INTO metrics (type, entity_id, entity_type, value, metadata, timestamp, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO metrics (type, entity_id, entity_type, value, metadata, timestamp, created_at, source_event_id, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (source_event_id, type) DO NOTHING
 		RETURNING id
 	`
 
@@ -41,22 +65,88 @@ func (r *PostgresMetricRepository) Create(ctx context.Context, metric *domain.Me
 		metadataJSON,
 		metric.Timestamp,
 		metric.CreatedAt,
+		sql.NullString{String: metric.SourceEventID, Valid: metric.SourceEventID != ""},
+		orgOrDefault(metric.OrgID),
 	).Scan(&metric.ID)
 
+	// Nothing is returned when the event already recorded this metric
+	if err == sql.ErrNoRows {
+		return domain.ErrDuplicateMetric
+	}
+	return err
+}
+
+// metricBatchRows bounds the rows of one INSERT, keeping its 9 parameters
+// per row well under the PostgreSQL limit of 65535
+const metricBatchRows = 1000
+
+// CreateBatch stores metrics with multi-row inserts in a single transaction
+func (r *PostgresMetricRepository) CreateBatch(ctx context.Context, metrics []*domain.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(metrics); start += metricBatchRows {
+		end := min(start+metricBatchRows, len(metrics))
+		if err := insertMetrics(ctx, tx, metrics[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertMetrics writes one multi-row INSERT
+func insertMetrics(ctx context.Context, tx *sql.Tx, metrics []*domain.Metric) error {
+	var query strings.Builder
+	query.WriteString(`INSERT INTO metrics (type, entity_id, entity_type, value, metadata, timestamp, created_at, source_event_id, org_id) VALUES `)
+
+	args := make([]interface{}, 0, len(metrics)*9)
+	for i, metric := range metrics {
+		metadataJSON, err := json.Marshal(metric.Metadata)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9)
+		args = append(args,
+			metric.Type,
+			metric.EntityID,
+			metric.EntityType,
+			metric.Value,
+			metadataJSON,
+			metric.Timestamp,
+			metric.CreatedAt,
+			sql.NullString{String: metric.SourceEventID, Valid: metric.SourceEventID != ""},
+			orgOrDefault(metric.OrgID),
+		)
+	}
+	query.WriteString(` ON CONFLICT (source_event_id, type) DO NOTHING`)
+
+	_, err := tx.ExecContext(ctx, query.String(), args...)
 	return err
 }
 
 // GetByType retrieves metrics by type
 func (r *PostgresMetricRepository) GetByType(ctx context.Context, metricType domain.MetricType, limit int) ([]*domain.Metric, error) {
 	query := `
-		SELECT id, type, entity_id, entity_type, value, metadata, timestamp, created_at
+		SELECT id, org_id, type, entity_id, entity_type, value, metadata, timestamp, created_at
 		FROM metrics
-		WHERE type = $1
+		WHERE type = $1 AND ($3::int IS NULL OR org_id = $3)
 		ORDER BY timestamp DESC
 		LIMIT $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, metricType, limit)
+	rows, err := r.db.QueryContext(ctx, query, metricType, limit, orgScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +159,7 @@ func (r *PostgresMetricRepository) GetByType(ctx context.Context, metricType dom
 
 		err := rows.Scan(
 			&metric.ID,
+			&metric.OrgID,
 			&metric.Type,
 			&metric.EntityID,
 			&metric.EntityType,
@@ -97,16 +188,17 @@ func (r *PostgresMetricRepository) GetByType(ctx context.Context, metricType dom
 // GetByID retrieves a metric by ID
 func (r *PostgresMetricRepository) GetByID(ctx context.Context, id int) (*domain.Metric, error) {
 	query := `
-		SELECT id, type, entity_id, entity_type, value, metadata, timestamp, created_at
+		SELECT id, org_id, type, entity_id, entity_type, value, metadata, timestamp, created_at
 		FROM metrics
-		WHERE id = $1
+		WHERE id = $1 AND ($2::int IS NULL OR org_id = $2)
 	`
 
 	var metric domain.Metric
 	var metadataJSON []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id, orgScope(ctx)).Scan(
 		&metric.ID,
+		&metric.OrgID,
 		&metric.Type,
 		&metric.EntityID,
 		&metric.EntityType,
@@ -132,14 +224,14 @@ func (r *PostgresMetricRepository) GetByID(ctx context.Context, id int) (*domain
 // GetByEntityID retrieves metrics for a specific entity
 func (r *PostgresMetricRepository) GetByEntityID(ctx context.Context, entityID int, entityType string, limit int) ([]*domain.Metric, error) {
 	query := `
-		SELECT id, type, entity_id, entity_type, value, metadata, timestamp, created_at
+		SELECT id, org_id, type, entity_id, entity_type, value, metadata, timestamp, created_at
 		FROM metrics
-		WHERE entity_id = $1 AND entity_type = $2
+		WHERE entity_id = $1 AND entity_type = $2 AND ($4::int IS NULL OR org_id = $4)
 		ORDER BY timestamp DESC
 		LIMIT $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, entityID, entityType, limit)
+	rows, err := r.db.QueryContext(ctx, query, entityID, entityType, limit, orgScope(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -152,6 +244,7 @@ func (r *PostgresMetricRepository) GetByEntityID(ctx context.Context, entityID i
 
 		err := rows.Scan(
 			&metric.ID,
+			&metric.OrgID,
 			&metric.Type,
 			&metric.EntityID,
 			&metric.EntityType,
@@ -187,10 +280,11 @@ func (r *PostgresMetricRepository) GetDeliveryStats(ctx context.Context, period
 			COUNT(CASE WHEN type = 'delivery_cancelled' THEN 1 END) as cancelled_deliveries
 		FROM metrics
 		WHERE type IN ('delivery_created', 'delivery_completed', 'delivery_cancelled')
+			AND ($1::int IS NULL OR org_id = $1)
 	`
 
 	var stats domain.DeliveryStats
-	err := r.db.QueryRowContext(ctx, query).Scan(
+	err := r.db.QueryRowContext(ctx, query, orgScope(ctx)).Scan(
 		&stats.TotalDeliveries,
 		&stats.CompletedDeliveries,
 		&stats.CancelledDeliveries,
@@ -204,4 +298,77 @@ func (r *PostgresMetricRepository) GetDeliveryStats(ctx context.Context, period
 	stats.Period = period
 
 	return &stats, nil
-}
\ No newline at end of file
+}
+
+// dashboardFilter builds the shared WHERE clause for dashboard queries over
+// the caller's organization, numbering placeholders from first
+func dashboardFilter(ctx context.Context, q domain.DashboardQuery, first int) (string, []interface{}) {
+	where := fmt.Sprintf("timestamp >= $%d AND timestamp < $%d", first, first+1)
+	args := []interface{}{q.From.UTC(), q.To.UTC()}
+	if q.CustomerID != nil {
+		where += fmt.Sprintf(" AND metadata->>'customer_id' = $%d", first+len(args))
+		args = append(args, strconv.Itoa(*q.CustomerID))
+	}
+	if orgID := orgScope(ctx); orgID != nil {
+		where += fmt.Sprintf(" AND org_id = $%d", first+len(args))
+		args = append(args, *orgID)
+	}
+	return where, args
+}
+
+// GetDeliveryBuckets counts created, delivered and cancelled deliveries per time bucket
+func (r *PostgresMetricRepository) GetDeliveryBuckets(ctx context.Context, q domain.DashboardQuery) ([]domain.DashboardPoint, error) {
+	where, args := dashboardFilter(ctx, q, 2)
+	query := `
+		SELECT
+			date_trunc($1, timestamp) AS bucket,
+			COUNT(*) FILTER (WHERE type = 'delivery_created'),
+			COUNT(*) FILTER (WHERE type = 'delivery_completed'),
+			COUNT(*) FILTER (WHERE type = 'delivery_cancelled')
+		FROM metrics
+		WHERE type IN ('delivery_created', 'delivery_completed', 'delivery_cancelled')
+			AND ` + where + `
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{q.Bucket}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []domain.DashboardPoint
+	for rows.Next() {
+		var p domain.DashboardPoint
+		if err := rows.Scan(&p.Bucket, &p.Created, &p.Delivered, &p.Cancelled); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// GetOnTimeStats counts completed deliveries with a scheduled date and how many were on time
+func (r *PostgresMetricRepository) GetOnTimeStats(ctx context.Context, q domain.DashboardQuery) (*domain.OnTimeStats, error) {
+	where, args := dashboardFilter(ctx, q, 1)
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE metadata ? 'on_time'),
+			COUNT(*) FILTER (WHERE metadata->>'on_time' = 'true')
+		FROM metrics
+		WHERE type = 'delivery_completed'
+			AND ` + where
+
+	var stats domain.OnTimeStats
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&stats.Scheduled,
+		&stats.OnTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
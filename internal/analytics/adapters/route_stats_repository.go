@@ -0,0 +1,132 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+)
+
+// PostgresRouteStatsRepository implements the RouteStatsRepository interface using PostgreSQL
+type PostgresRouteStatsRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRouteStatsRepository creates a new PostgreSQL route stats repository
+func NewPostgresRouteStatsRepository(db *sql.DB) *PostgresRouteStatsRepository {
+	return &PostgresRouteStatsRepository{db: db}
+}
+
+// RecordTrackPoint counts a location towards a delivery's open track, keeping
+// the largest cumulative distance
+func (r *PostgresRouteStatsRepository) RecordTrackPoint(ctx context.Context, deliveryID, courierID int, cumulativeKm float64) error {
+	query := `
+		INSERT INTO delivery_tracks (delivery_id, courier_id, points, distance_km)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (delivery_id) DO UPDATE
+		SET points = delivery_tracks.points + 1,
+			distance_km = GREATEST(delivery_tracks.distance_km, EXCLUDED.distance_km)
+		WHERE delivery_tracks.completed_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, deliveryID, courierID, cumulativeKm)
+	return err
+}
+
+// RecordCompletion closes a delivery's track and increments its day's rollup
+func (r *PostgresRouteStatsRepository) RecordCompletion(ctx context.Context, route domain.RouteCompletion) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Deliveries no location was recorded for still get a track to close
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO delivery_tracks (delivery_id, courier_id)
+		VALUES ($1, $2)
+		ON CONFLICT (delivery_id) DO NOTHING
+	`, route.DeliveryID, route.CourierID)
+	if err != nil {
+		return false, err
+	}
+
+	// Only the first completion counts, so redelivered events are no-ops
+	err = tx.QueryRowContext(ctx, `
+		UPDATE delivery_tracks
+		SET completed_at = $1
+		WHERE delivery_id = $2 AND completed_at IS NULL
+		RETURNING points, distance_km
+	`, route.At, route.DeliveryID).Scan(&route.Points, &route.DistanceKm)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	stats := route.Stats()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO route_daily_stats (org_id, day, courier_id, zone, measured, actual_km, straight_line_km, sparse_tracks, unmeasurable)
+		VALUES ($9, $1::date, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (org_id, day, courier_id, zone) DO UPDATE
+		SET measured = route_daily_stats.measured + EXCLUDED.measured,
+			actual_km = route_daily_stats.actual_km + EXCLUDED.actual_km,
+			straight_line_km = route_daily_stats.straight_line_km + EXCLUDED.straight_line_km,
+			sparse_tracks = route_daily_stats.sparse_tracks + EXCLUDED.sparse_tracks,
+			unmeasurable = route_daily_stats.unmeasurable + EXCLUDED.unmeasurable
+	`, route.At, route.CourierID, route.Zone, stats.Measured, stats.ActualKm, stats.StraightLineKm, stats.SparseTracks, stats.Unmeasurable, orgOrDefault(route.OrgID))
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListRouteStats sums the daily rollups from the query's first to last day per courier and zone
+func (r *PostgresRouteStatsRepository) ListRouteStats(ctx context.Context, q domain.RouteEfficiencyQuery) ([]domain.RouteStatsRow, error) {
+	query := `
+		SELECT
+			courier_id,
+			zone,
+			SUM(measured),
+			SUM(actual_km),
+			SUM(straight_line_km),
+			SUM(sparse_tracks),
+			SUM(unmeasurable)
+		FROM route_daily_stats
+		WHERE day >= $1::date AND day <= $2::date
+			AND ($3::int IS NULL OR courier_id = $3)
+			AND ($4::int IS NULL OR org_id = $4)
+		GROUP BY courier_id, zone
+		ORDER BY courier_id, zone
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, q.From, q.To, q.CourierID, orgScope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.RouteStatsRow
+	for rows.Next() {
+		var row domain.RouteStatsRow
+		if err := rows.Scan(
+			&row.CourierID,
+			&row.Zone,
+			&row.Measured,
+			&row.ActualKm,
+			&row.StraightLineKm,
+			&row.SparseTracks,
+			&row.Unmeasurable,
+		); err != nil {
+			return nil, err
+		}
+		stats = append(stats, row)
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,222 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/ports"
+)
+
+// metricFlushTimeout bounds a single batch insert
+const metricFlushTimeout = 10 * time.Second
+
+// BatchConfig controls the write-behind buffer for event metrics
+type BatchConfig struct {
+	MaxRows       int           // flush as soon as this many metrics are buffered
+	FlushInterval time.Duration // flush buffered metrics at least this often
+}
+
+// DefaultBatchConfig flushes every 250ms or 500 metrics, whichever comes first
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxRows:       500,
+		FlushInterval: 250 * time.Millisecond,
+	}
+}
+
+// IngestionStats describes the event metric buffer
+type IngestionStats struct {
+	BufferDepth        int     `json:"buffer_depth"`
+	Flushes            int64   `json:"flushes"`
+	FailedFlushes      int64   `json:"failed_flushes"`
+	FlushedMetrics     int64   `json:"flushed_metrics"`
+	LastFlushLatencyMs float64 `json:"last_flush_latency_ms"`
+	AvgFlushLatencyMs  float64 `json:"avg_flush_latency_ms"`
+	MaxFlushLatencyMs  float64 `json:"max_flush_latency_ms"`
+}
+
+// pendingMetric is a buffered metric and where its flush outcome is reported
+type pendingMetric struct {
+	metric *domain.Metric
+	done   chan error
+}
+
+// metricBuffer accumulates event metrics and writes them in batches from a
+// single flush goroutine. Callers wait for the batch holding their metric,
+// so events are only acked once their metrics are stored.
+type metricBuffer struct {
+	repo   ports.MetricRepository
+	config BatchConfig
+
+	mu      sync.Mutex // guards pending, closed and stats
+	pending []pendingMetric
+	closed  bool
+	stats   IngestionStats
+	total   time.Duration // summed flush latency for the average
+
+	full    chan struct{} // signalled when pending reaches MaxRows
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newMetricBuffer(repo ports.MetricRepository, config BatchConfig) *metricBuffer {
+	b := &metricBuffer{
+		repo:    repo,
+		config:  config,
+		full:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add buffers a metric and returns the channel its flush outcome is sent on,
+// or nil once the buffer is closed
+func (b *metricBuffer) add(metric *domain.Metric) <-chan error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	b.pending = append(b.pending, pendingMetric{metric: metric, done: done})
+	if len(b.pending) >= b.config.MaxRows {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return done
+}
+
+func (b *metricBuffer) run() {
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.full:
+			b.flush()
+		case <-b.stop:
+			b.flush()
+			return
+		}
+	}
+}
+
+// flush writes everything buffered so far and reports the outcome to its callers
+func (b *metricBuffer) flush() {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	metrics := make([]*domain.Metric, len(batch))
+	for i, p := range batch {
+		metrics[i] = p.metric
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricFlushTimeout)
+	start := time.Now()
+	err := b.repo.CreateBatch(ctx, metrics)
+	latency := time.Since(start)
+	cancel()
+
+	b.mu.Lock()
+	b.stats.Flushes++
+	if err != nil {
+		b.stats.FailedFlushes++
+	} else {
+		b.stats.FlushedMetrics += int64(len(metrics))
+	}
+	b.total += latency
+	b.stats.LastFlushLatencyMs = durationMs(latency)
+	b.stats.AvgFlushLatencyMs = durationMs(b.total / time.Duration(b.stats.Flushes))
+	b.stats.MaxFlushLatencyMs = max(b.stats.MaxFlushLatencyMs, b.stats.LastFlushLatencyMs)
+	b.mu.Unlock()
+
+	for _, p := range batch {
+		p.done <- err
+	}
+}
+
+// close stops accepting metrics and flushes the ones still buffered
+func (b *metricBuffer) close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.stopped
+}
+
+func (b *metricBuffer) snapshot() IngestionStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.stats
+	stats.BufferDepth = len(b.pending)
+	return stats
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// SetBatching buffers metrics derived from consumed events and writes them in
+// batches. Zero config fields keep the defaults; call Shutdown to flush.
+func (s *AnalyticsService) SetBatching(config BatchConfig) {
+	defaults := DefaultBatchConfig()
+	if config.MaxRows <= 0 {
+		config.MaxRows = defaults.MaxRows
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+
+	s.buffer = newMetricBuffer(s.repo, config)
+}
+
+// Shutdown stops buffering and synchronously flushes buffered metrics, then
+// waits for running reports to finish
+func (s *AnalyticsService) Shutdown() {
+	if s.buffer != nil {
+		s.buffer.close()
+	}
+	if s.reports != nil {
+		s.reports.running.Wait()
+	}
+}
+
+// IngestionStats reports the event metric buffer; zero without batching
+func (s *AnalyticsService) IngestionStats() IngestionStats {
+	if s.buffer == nil {
+		return IngestionStats{}
+	}
+	return s.buffer.snapshot()
+}
+
+// writeEventMetric stores an event metric, through the buffer when batching
+// is enabled. It returns once the metric is stored.
+func (s *AnalyticsService) writeEventMetric(ctx context.Context, metric *domain.Metric) error {
+	if s.buffer != nil {
+		if done := s.buffer.add(metric); done != nil {
+			return <-done
+		}
+	}
+	return s.repo.Create(ctx, metric)
+}
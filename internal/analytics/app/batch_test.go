@@ -0,0 +1,135 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap/zaptest"
+)
+
+func newBatchingService(t *testing.T, metrics *MockMetricRepository, config BatchConfig) *AnalyticsService {
+	t.Helper()
+	service := NewAnalyticsService(metrics, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetBatching(config)
+	t.Cleanup(service.Shutdown)
+	return service
+}
+
+// pickupEvent records a single status change metric
+func pickupEvent(deliveryID int) messaging.Event {
+	event := statusEvent(fmt.Sprint(deliveryID), nil, "in_transit", time.Now())
+	event.ID = fmt.Sprintf("evt_%d", deliveryID)
+	return event
+}
+
+// handleConcurrently handles events in parallel, as the consumer does with concurrency
+func handleConcurrently(service *AnalyticsService, events []messaging.Event) []error {
+	errs := make([]error, len(events))
+	var wg sync.WaitGroup
+	for i, event := range events {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = service.handleDeliveryEvent(event)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestAnalyticsService_BatchFlushesWhenFull(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	service := newBatchingService(t, metrics, BatchConfig{MaxRows: 4, FlushInterval: time.Hour})
+
+	events := []messaging.Event{pickupEvent(1), pickupEvent(2), pickupEvent(3), pickupEvent(4)}
+	for _, err := range handleConcurrently(service, events) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(metrics.batches) != 1 || metrics.batches[0] != 4 {
+		t.Errorf("expected a single batch of 4, got %v", metrics.batches)
+	}
+	if stats := service.IngestionStats(); stats.Flushes != 1 || stats.FlushedMetrics != 4 || stats.BufferDepth != 0 {
+		t.Errorf("unexpected ingestion stats %+v", stats)
+	}
+}
+
+func TestAnalyticsService_BatchFlushesOnInterval(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	service := newBatchingService(t, metrics, BatchConfig{MaxRows: 100, FlushInterval: 10 * time.Millisecond})
+
+	if err := service.handleDeliveryEvent(pickupEvent(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics.metrics) != 1 || len(metrics.batches) != 1 {
+		t.Errorf("expected the metric to be flushed on the interval, got %d metrics in %v", len(metrics.metrics), metrics.batches)
+	}
+}
+
+func TestAnalyticsService_BatchFailureFailsEvents(t *testing.T) {
+	metrics := &MockMetricRepository{batchErr: errors.New("connection reset")}
+	service := newBatchingService(t, metrics, BatchConfig{MaxRows: 2, FlushInterval: time.Hour})
+
+	// Neither event is acked, so both are retried
+	for _, err := range handleConcurrently(service, []messaging.Event{pickupEvent(1), pickupEvent(2)}) {
+		if err == nil {
+			t.Error("expected the event to fail with its batch")
+		}
+	}
+	if stats := service.IngestionStats(); stats.FailedFlushes != 1 || stats.FlushedMetrics != 0 {
+		t.Errorf("unexpected ingestion stats %+v", stats)
+	}
+}
+
+func TestAnalyticsService_ShutdownFlushesBuffer(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	service := newBatchingService(t, metrics, BatchConfig{MaxRows: 100, FlushInterval: time.Hour})
+
+	handled := make(chan error, 1)
+	go func() { handled <- service.handleDeliveryEvent(pickupEvent(1)) }()
+	for deadline := time.Now().Add(5 * time.Second); service.IngestionStats().BufferDepth == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("metric was never buffered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	service.Shutdown()
+	if err := <-handled; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics.batches) != 1 || len(metrics.metrics) != 1 {
+		t.Errorf("expected shutdown to flush the buffered metric, got %v", metrics.batches)
+	}
+
+	// Events after shutdown are written directly
+	if err := service.handleDeliveryEvent(pickupEvent(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics.metrics) != 2 || len(metrics.batches) != 1 {
+		t.Errorf("expected a direct write after shutdown, got %d metrics in %v", len(metrics.metrics), metrics.batches)
+	}
+}
+
+func TestAnalyticsService_BatchSkipsRedeliveredMetrics(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	service := newBatchingService(t, metrics, BatchConfig{MaxRows: 2, FlushInterval: time.Hour})
+
+	// The same event twice in one batch is stored once
+	event := pickupEvent(1)
+	for _, err := range handleConcurrently(service, []messaging.Event{event, event}) {
+		if err != nil {
+			t.Fatalf("expected redeliveries to be acked, got %v", err)
+		}
+	}
+	if len(metrics.metrics) != 1 {
+		t.Errorf("expected one metric, got %d", len(metrics.metrics))
+	}
+}
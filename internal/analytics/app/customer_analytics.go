@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/ports"
+	"go.uber.org/zap"
+)
+
+// errCustomerStatsNotConfigured is returned when no customer stats repository was set
+var errCustomerStatsNotConfigured = errors.New("customer analytics is not configured")
+
+// SetCustomerStats enables customer analytics, rolling up each customer's
+// created and finished deliveries per month in repo
+func (s *AnalyticsService) SetCustomerStats(repo ports.CustomerStatsRepository) {
+	s.customerStats = repo
+}
+
+// GetCustomerAnalytics summarizes a customer's deliveries per month from the
+// monthly rollups, along with their average duration, on-time percentage
+// and most frequent destination zones
+func (s *AnalyticsService) GetCustomerAnalytics(ctx context.Context, q domain.CustomerAnalyticsQuery) (*domain.CustomerAnalytics, error) {
+	if s.customerStats == nil {
+		return nil, errCustomerStatsNotConfigured
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	months, err := s.customerStats.ListMonths(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer months: %w", err)
+	}
+
+	zones, err := s.customerStats.ListZones(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer zones: %w", err)
+	}
+
+	return domain.NewCustomerAnalytics(q, months, zones), nil
+}
+
+// recordCustomerCreated counts a created delivery towards its customer's month
+func (s *AnalyticsService) recordCustomerCreated(ctx context.Context, orgID, deliveryID, customerID int, at time.Time, windowEnd *time.Time) error {
+	recorded, err := s.customerStats.RecordCreated(ctx, orgID, deliveryID, customerID, at, windowEnd)
+	if err != nil {
+		return fmt.Errorf("failed to record customer delivery creation: %w", err)
+	}
+	if !recorded {
+		s.logger.InfoWithFields(ctx, "Customer delivery creation already recorded",
+			zap.Int("delivery_id", deliveryID))
+	}
+	return nil
+}
+
+// recordCustomerOutcome adds a finished delivery to its customer's month
+func (s *AnalyticsService) recordCustomerOutcome(ctx context.Context, outcome domain.CustomerOutcome) error {
+	recorded, err := s.customerStats.RecordOutcome(ctx, outcome)
+	if err != nil {
+		return fmt.Errorf("failed to record customer delivery outcome: %w", err)
+	}
+	if !recorded {
+		s.logger.InfoWithFields(ctx, "Customer delivery outcome already recorded",
+			zap.Int("delivery_id", outcome.DeliveryID))
+	}
+	return nil
+}
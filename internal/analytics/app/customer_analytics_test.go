@@ -0,0 +1,297 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap/zaptest"
+)
+
+type mockCustomerDelivery struct {
+	orgID     int
+	createdAt *time.Time
+	windowEnd *time.Time
+	finished  bool
+}
+
+type customerMonth struct {
+	customerID int
+	month      time.Time
+}
+
+type customerZone struct {
+	customerID int
+	zone       string
+}
+
+// MockCustomerStatsRepository keeps customer rollups in memory. Zones are
+// not split by month, so ListZones ignores the query's range.
+type MockCustomerStatsRepository struct {
+	deliveries map[int]*mockCustomerDelivery
+	months     map[customerMonth]*domain.CustomerMonthStats
+	zones      map[customerZone]int
+}
+
+func NewMockCustomerStatsRepository() *MockCustomerStatsRepository {
+	return &MockCustomerStatsRepository{
+		deliveries: make(map[int]*mockCustomerDelivery),
+		months:     make(map[customerMonth]*domain.CustomerMonthStats),
+		zones:      make(map[customerZone]int),
+	}
+}
+
+func (m *MockCustomerStatsRepository) delivery(deliveryID int) *mockCustomerDelivery {
+	d, ok := m.deliveries[deliveryID]
+	if !ok {
+		d = &mockCustomerDelivery{}
+		m.deliveries[deliveryID] = d
+	}
+	return d
+}
+
+func (m *MockCustomerStatsRepository) month(customerID int, at time.Time) *domain.CustomerMonthStats {
+	key := customerMonth{customerID: customerID, month: domain.MonthStart(at)}
+	stats, ok := m.months[key]
+	if !ok {
+		stats = &domain.CustomerMonthStats{}
+		m.months[key] = stats
+	}
+	return stats
+}
+
+func (m *MockCustomerStatsRepository) RecordCreated(ctx context.Context, orgID, deliveryID, customerID int, at time.Time, windowEnd *time.Time) (bool, error) {
+	d := m.delivery(deliveryID)
+	if d.createdAt != nil {
+		return false, nil
+	}
+	d.orgID, d.createdAt, d.windowEnd = orgID, &at, windowEnd
+	m.month(customerID, at).Created++
+	return true, nil
+}
+
+func (m *MockCustomerStatsRepository) RecordOutcome(ctx context.Context, outcome domain.CustomerOutcome) (bool, error) {
+	d := m.delivery(outcome.DeliveryID)
+	if d.finished {
+		return false, nil
+	}
+	d.finished = true
+	d.orgID = outcome.OrgID
+	if d.createdAt != nil {
+		outcome.CreatedAt = d.createdAt
+	}
+	if d.windowEnd != nil {
+		outcome.WindowEnd = d.windowEnd
+	}
+
+	stats := outcome.Stats()
+	month := m.month(outcome.CustomerID, outcome.At)
+	month.Delivered += stats.Delivered
+	month.Cancelled += stats.Cancelled
+	month.TimedDeliveries += stats.TimedDeliveries
+	month.TotalDeliverySeconds += stats.TotalDeliverySeconds
+	month.Scheduled += stats.Scheduled
+	month.OnTime += stats.OnTime
+	if stats.Delivered > 0 && outcome.Zone != "" {
+		m.zones[customerZone{customerID: outcome.CustomerID, zone: outcome.Zone}]++
+	}
+	return true, nil
+}
+
+func (m *MockCustomerStatsRepository) ListMonths(ctx context.Context, q domain.CustomerAnalyticsQuery) ([]domain.CustomerMonthRow, error) {
+	var rows []domain.CustomerMonthRow
+	for key, stats := range m.months {
+		if key.customerID != q.CustomerID || key.month.Before(domain.MonthStart(q.From)) || key.month.After(q.To) {
+			continue
+		}
+		rows = append(rows, domain.CustomerMonthRow{Month: key.month, CustomerMonthStats: *stats})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Month.Before(rows[j].Month) })
+	return rows, nil
+}
+
+func (m *MockCustomerStatsRepository) ListZones(ctx context.Context, q domain.CustomerAnalyticsQuery) ([]domain.CustomerZoneRow, error) {
+	var rows []domain.CustomerZoneRow
+	for key, delivered := range m.zones {
+		if key.customerID == q.CustomerID {
+			rows = append(rows, domain.CustomerZoneRow{Zone: key.zone, Delivered: delivered})
+		}
+	}
+	return rows, nil
+}
+
+func createdEvent(deliveryID int, at time.Time, scheduled map[string]interface{}) messaging.Event {
+	data := map[string]interface{}{
+		"delivery_id": float64(deliveryID),
+		"customer_id": float64(1),
+	}
+	for key, value := range scheduled {
+		data[key] = value
+	}
+	return messaging.Event{
+		Type:      messaging.EventTypeDeliveryCreated,
+		Source:    "delivery-service",
+		Timestamp: at.Unix(),
+		Data:      data,
+	}
+}
+
+func TestAnalyticsService_CustomerAnalyticsFromEvents(t *testing.T) {
+	customerStats := NewMockCustomerStatsRepository()
+	service := NewAnalyticsService(&MockMetricRepository{}, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetCustomerStats(customerStats)
+
+	// Two full months back, so every event is in the past
+	first := domain.MonthStart(time.Now()).AddDate(0, -2, 0)
+	second := first.AddDate(0, 1, 0)
+
+	created1 := first.AddDate(0, 0, 9).Add(10 * time.Hour)
+	created2 := first.AddDate(0, 0, 20)
+	delivered2 := second.AddDate(0, 0, 1)
+	delivered := func(deliveryID int, at time.Time, zone string) messaging.Event {
+		event := statusEvent("", float64(7), "delivered", at)
+		event.Data["delivery_id"] = float64(deliveryID)
+		event.Data["delivery_zone"] = zone
+		return event
+	}
+	cancelled := func(deliveryID int, at time.Time) messaging.Event {
+		event := statusEvent("", float64(7), "cancelled", at)
+		event.Data["delivery_id"] = float64(deliveryID)
+		return event
+	}
+
+	// Delivery 4 was created before the tracked months; its completion
+	// event's scheduled date is all that is known of its window
+	delivered4 := delivered(4, second.AddDate(0, 0, 4), "Potsdam")
+	delivered4.Data["scheduled_date"] = second.AddDate(0, 0, 4).Add(time.Hour).Format(time.RFC3339)
+
+	events := []messaging.Event{
+		// Delivery 1: delivered 90 minutes after creation, inside its window
+		createdEvent(1, created1, map[string]interface{}{
+			"scheduled_date": created1.Add(time.Hour).Format(time.RFC3339),
+			"scheduled_end":  created1.Add(2 * time.Hour).Format(time.RFC3339),
+		}),
+		delivered(1, created1.Add(90*time.Minute), "Berlin"),
+		// Redelivered creation and completion change nothing
+		createdEvent(1, created1, nil),
+		delivered(1, created1.Add(95*time.Minute), "Berlin"),
+		// Delivery 2: scheduled for a start only, delivered late the next month
+		createdEvent(2, created2, map[string]interface{}{
+			"scheduled_date": created2.Add(time.Hour).Format(time.RFC3339),
+		}),
+		{
+			Type:      messaging.EventTypeDeliveryConfirmed,
+			Timestamp: delivered2.Unix(),
+			Data: map[string]interface{}{
+				"delivery_id":    float64(2),
+				"customer_id":    float64(1),
+				"courier_id":     float64(7),
+				"delivered_date": delivered2.Format(time.RFC3339),
+				"delivery_zone":  "Berlin",
+			},
+		},
+		// Delivery 3: created and cancelled in the second month
+		createdEvent(3, second.AddDate(0, 0, 2), nil),
+		cancelled(3, second.AddDate(0, 0, 3)),
+		delivered4,
+		// Delivery 5: its cancellation arrives before its creation
+		cancelled(5, second.AddDate(0, 0, 6)),
+		createdEvent(5, second.AddDate(0, 0, 5), nil),
+	}
+	for _, event := range events {
+		if err := service.handleDeliveryEvent(event); err != nil {
+			t.Fatalf("unexpected error handling %s: %v", event.Type, err)
+		}
+	}
+
+	q := domain.CustomerAnalyticsQuery{CustomerID: 1, From: first.Add(time.Hour), To: time.Now()}
+	analytics, err := service.GetCustomerAnalytics(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Every month the range touches is listed, empty ones included
+	if len(analytics.Months) != 3 || analytics.Months[0].Month != first.Format("2006-01") {
+		t.Fatalf("expected three months from %s, got %+v", first.Format("2006-01"), analytics.Months)
+	}
+	tests := []struct {
+		month                  domain.CustomerMonth
+		expected               domain.CustomerMonth
+		expectedAverageMinutes float64
+	}{
+		{
+			month:                  analytics.Months[0],
+			expected:               domain.CustomerMonth{Created: 2, Delivered: 1, OnTimePercentage: 100},
+			expectedAverageMinutes: 90,
+		},
+		{
+			month:                  analytics.Months[1],
+			expected:               domain.CustomerMonth{Created: 2, Delivered: 2, Cancelled: 2, OnTimePercentage: 50},
+			expectedAverageMinutes: delivered2.Sub(created2).Minutes(),
+		},
+		{month: analytics.Months[2]},
+	}
+	for i, tt := range tests {
+		got := tt.month
+		if got.Created != tt.expected.Created || got.Delivered != tt.expected.Delivered || got.Cancelled != tt.expected.Cancelled {
+			t.Errorf("month %d: expected %+v, got %+v", i, tt.expected, got)
+		}
+		if got.OnTimePercentage != tt.expected.OnTimePercentage || got.AverageDeliveryMinutes != tt.expectedAverageMinutes {
+			t.Errorf("month %d: expected %v%% on time and %v minutes, got %+v", i, tt.expected.OnTimePercentage, tt.expectedAverageMinutes, got)
+		}
+	}
+
+	if totals := analytics.Totals; totals.Created != 4 || totals.Delivered != 3 || totals.Cancelled != 2 {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+	if expected := (90 + delivered2.Sub(created2).Minutes()) / 2; analytics.AverageDeliveryMinutes != expected {
+		t.Errorf("expected an average of %v minutes over timed deliveries, got %v", expected, analytics.AverageDeliveryMinutes)
+	}
+	if analytics.OnTimePercentage < 66.6 || analytics.OnTimePercentage > 66.7 {
+		t.Errorf("expected 2 of 3 scheduled deliveries on time, got %v%%", analytics.OnTimePercentage)
+	}
+	if len(analytics.TopZones) != 2 || analytics.TopZones[0] != (domain.CustomerZoneRow{Zone: "Berlin", Delivered: 2}) {
+		t.Errorf("expected Berlin then Potsdam, got %+v", analytics.TopZones)
+	}
+
+	// Other customers have nothing
+	other, err := service.GetCustomerAnalytics(context.Background(), domain.CustomerAnalyticsQuery{CustomerID: 2, From: q.From, To: q.To})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if other.Totals != (domain.DashboardTotals{}) || len(other.TopZones) != 0 {
+		t.Errorf("expected no deliveries for customer 2, got %+v", other)
+	}
+}
+
+func TestAnalyticsService_GetCustomerAnalyticsValidation(t *testing.T) {
+	service := NewAnalyticsService(&MockMetricRepository{}, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	now := time.Now()
+
+	if _, err := service.GetCustomerAnalytics(context.Background(), domain.CustomerAnalyticsQuery{CustomerID: 1, From: now.Add(-time.Hour), To: now}); !errors.Is(err, errCustomerStatsNotConfigured) {
+		t.Errorf("expected errCustomerStatsNotConfigured, got %v", err)
+	}
+
+	service.SetCustomerStats(NewMockCustomerStatsRepository())
+	tests := []struct {
+		name     string
+		q        domain.CustomerAnalyticsQuery
+		expected error
+	}{
+		{name: "missing customer", q: domain.CustomerAnalyticsQuery{From: now.Add(-time.Hour), To: now}, expected: domain.ErrInvalidCustomer},
+		{name: "reversed range", q: domain.CustomerAnalyticsQuery{CustomerID: 1, From: now, To: now.Add(-time.Hour)}, expected: domain.ErrInvalidTimeRange},
+		{name: "range over a year", q: domain.CustomerAnalyticsQuery{CustomerID: 1, From: now.AddDate(-2, 0, 0), To: now}, expected: domain.ErrInvalidTimeRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.GetCustomerAnalytics(context.Background(), tt.q); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
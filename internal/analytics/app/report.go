@@ -0,0 +1,398 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// errReportsNotConfigured is returned when no report store was set
+var errReportsNotConfigured = errors.New("report generation is not configured")
+
+// errReportInterrupted marks reports left pending by a previous process
+var errReportInterrupted = errors.New("report generation was interrupted by a restart")
+
+// ReportConfig controls background report generation
+type ReportConfig struct {
+	TTL           time.Duration // how long reports can be fetched after being requested
+	Timeout       time.Duration // bound on generating a single report
+	MaxConcurrent int           // reports generated at once; further requests are refused
+}
+
+// DefaultReportConfig keeps reports for a day and generates two at a time
+func DefaultReportConfig() ReportConfig {
+	return ReportConfig{
+		TTL:           24 * time.Hour,
+		Timeout:       5 * time.Minute,
+		MaxConcurrent: 2,
+	}
+}
+
+// reportGenerator runs report generation in the background
+type reportGenerator struct {
+	store   ports.ReportStore
+	config  ReportConfig
+	slots   chan struct{} // one token per running generation
+	running sync.WaitGroup
+}
+
+// SetReportStore enables report generation, storing reports in store. Zero
+// config fields keep the defaults; call Shutdown to wait for running reports.
+func (s *AnalyticsService) SetReportStore(store ports.ReportStore, config ReportConfig) {
+	defaults := DefaultReportConfig()
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = defaults.MaxConcurrent
+	}
+
+	s.reports = &reportGenerator{
+		store:  store,
+		config: config,
+		slots:  make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// GenerateReport stores a pending report and renders it in the background;
+// poll GetReport for its status. It returns domain.ErrReportsBusy when
+// MaxConcurrent reports are already being generated.
+func (s *AnalyticsService) GenerateReport(ctx context.Context, req domain.ReportRequest) (*domain.Report, error) {
+	if s.reports == nil {
+		return nil, errReportsNotConfigured
+	}
+
+	report, err := domain.NewReport(req, s.reports.config.TTL)
+	if err != nil {
+		return nil, err
+	}
+	report.OrgID = authctx.Organization(ctx)
+
+	// Take the slot before accepting the report so a burst of requests is
+	// refused instead of piling up goroutines waiting for one
+	select {
+	case s.reports.slots <- struct{}{}:
+	default:
+		return nil, domain.ErrReportsBusy
+	}
+	if err := s.reports.store.Save(ctx, report); err != nil {
+		<-s.reports.slots
+		return nil, fmt.Errorf("failed to save report: %w", err)
+	}
+
+	s.logger.InfoWithFields(ctx, "Report requested",
+		zap.String("report_id", report.ID),
+		zap.String("report_type", string(report.Type)),
+		zap.String("report_format", string(report.Format)))
+
+	// The caller gets a copy so the background generation can update its own
+	pending := *report
+	s.reports.running.Add(1)
+	go func() {
+		defer s.reports.running.Done()
+		defer func() { <-s.reports.slots }()
+		s.runReport(context.WithoutCancel(ctx), report)
+	}()
+
+	return &pending, nil
+}
+
+// GetReport retrieves a report's status; expired reports are removed and not found
+func (s *AnalyticsService) GetReport(ctx context.Context, id string) (*domain.Report, error) {
+	if s.reports == nil {
+		return nil, errReportsNotConfigured
+	}
+
+	report, err := s.reports.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report.Expired(time.Now()) {
+		if err := s.reports.store.Delete(ctx, id); err != nil {
+			s.logger.WarnWithFields(ctx, "Failed to delete expired report",
+				zap.String("report_id", id), zap.Error(err))
+		}
+		return nil, domain.ErrReportNotFound
+	}
+	return report, nil
+}
+
+// SweepReports fails the reports a previous process left pending, as nothing
+// will finish them, and removes expired ones. Call it once at startup, before
+// serving report requests; it returns how many reports it failed.
+func (s *AnalyticsService) SweepReports(ctx context.Context) (int, error) {
+	if s.reports == nil {
+		return 0, errReportsNotConfigured
+	}
+
+	reports, err := s.reports.store.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list reports: %w", err)
+	}
+
+	now := time.Now()
+	failed := 0
+	for _, report := range reports {
+		switch {
+		case report.Expired(now):
+			if err := s.reports.store.Delete(ctx, report.ID); err != nil {
+				return failed, fmt.Errorf("failed to delete expired report %s: %w", report.ID, err)
+			}
+		case report.Status == domain.ReportStatusPending:
+			report.MarkFailed(errReportInterrupted)
+			if err := s.reports.store.Save(ctx, report); err != nil {
+				return failed, fmt.Errorf("failed to fail interrupted report %s: %w", report.ID, err)
+			}
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// OpenReportArtifact opens a ready report's rendered output. It returns
+// domain.ErrReportNotReady while generating and domain.ErrReportFailed when
+// generation failed.
+func (s *AnalyticsService) OpenReportArtifact(ctx context.Context, id string) (*domain.Report, io.ReadCloser, error) {
+	report, err := s.GetReport(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch report.Status {
+	case domain.ReportStatusPending:
+		return nil, nil, domain.ErrReportNotReady
+	case domain.ReportStatusFailed:
+		return nil, nil, domain.ErrReportFailed
+	}
+
+	artifact, err := s.reports.store.OpenArtifact(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return report, artifact, nil
+}
+
+// runReport generates a report in the slot taken for it and records the outcome
+func (s *AnalyticsService) runReport(ctx context.Context, report *domain.Report) {
+	ctx = logger.WithContext(ctx, zap.String("report_id", report.ID))
+	start := time.Now()
+
+	genCtx, cancel := context.WithTimeout(ctx, s.reports.config.Timeout)
+	defer cancel()
+
+	if err := s.generateReport(genCtx, report); err != nil {
+		s.logger.ErrorWithFields(ctx, "Report generation failed", zap.Error(err))
+		report.MarkFailed(err)
+	} else {
+		s.logger.InfoWithFields(ctx, "Report ready",
+			zap.Duration("duration", time.Since(start)))
+		report.MarkReady()
+	}
+
+	if err := s.reports.store.Save(ctx, report); err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to record report status",
+			zap.String("report_status", string(report.Status)),
+			zap.Error(err))
+	}
+}
+
+// generateReport runs the report's aggregate queries and stores the rendered artifact
+func (s *AnalyticsService) generateReport(ctx context.Context, report *domain.Report) error {
+	var table *reportTable
+	var err error
+	switch report.Type {
+	case domain.ReportTypeDeliveriesSummary:
+		table, err = s.deliveriesSummaryTable(ctx, report)
+	case domain.ReportTypeCourierPerformance:
+		table, err = s.courierPerformanceTable(ctx, report)
+	default:
+		err = domain.ErrInvalidReportType
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := renderReport(report, table)
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	if err := s.reports.store.WriteArtifact(ctx, report.ID, data); err != nil {
+		return fmt.Errorf("failed to store report: %w", err)
+	}
+	return nil
+}
+
+// reportTable is a report's content: records for CSV, typed rows and a
+// summary for JSON
+type reportTable struct {
+	header  []string
+	records [][]string
+	rows    interface{}
+	summary interface{}
+}
+
+type deliveriesSummaryRow struct {
+	Day       string `json:"day"`
+	Created   int    `json:"created"`
+	Delivered int    `json:"delivered"`
+	Cancelled int    `json:"cancelled"`
+}
+
+type deliveriesSummary struct {
+	domain.DashboardTotals
+	OnTimePercentage float64 `json:"on_time_percentage"`
+}
+
+// deliveriesSummaryTable counts created, delivered and cancelled deliveries per day
+func (s *AnalyticsService) deliveriesSummaryTable(ctx context.Context, report *domain.Report) (*reportTable, error) {
+	q := domain.DashboardQuery{From: report.From, To: report.To, Bucket: domain.BucketDay, CustomerID: report.CustomerID}
+
+	points, err := s.repo.GetDeliveryBuckets(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery buckets: %w", err)
+	}
+	onTime, err := s.repo.GetOnTimeStats(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get on-time stats: %w", err)
+	}
+	dashboard := domain.NewDashboard(q, points, *onTime)
+
+	table := &reportTable{header: []string{"day", "created", "delivered", "cancelled"}}
+	rows := make([]deliveriesSummaryRow, len(dashboard.Buckets))
+	for i, bucket := range dashboard.Buckets {
+		rows[i] = deliveriesSummaryRow{
+			Day:       bucket.Format(time.DateOnly),
+			Created:   dashboard.Created[i],
+			Delivered: dashboard.Delivered[i],
+			Cancelled: dashboard.Cancelled[i],
+		}
+		table.records = append(table.records, []string{
+			rows[i].Day,
+			strconv.Itoa(rows[i].Created),
+			strconv.Itoa(rows[i].Delivered),
+			strconv.Itoa(rows[i].Cancelled),
+		})
+	}
+	table.rows = rows
+	table.summary = deliveriesSummary{DashboardTotals: dashboard.Totals, OnTimePercentage: dashboard.OnTimePercentage}
+	return table, nil
+}
+
+type courierPerformanceRow struct {
+	CourierID              int     `json:"courier_id"`
+	DeliveriesCompleted    int     `json:"deliveries_completed"`
+	DeliveriesCancelled    int     `json:"deliveries_cancelled"`
+	AverageDeliveryMinutes float64 `json:"average_delivery_minutes"`
+	CancellationRate       float64 `json:"cancellation_rate"`
+	DeliveriesPerDay       float64 `json:"deliveries_per_day"`
+}
+
+// courierPerformanceTable summarizes every courier over the whole days the report covers
+func (s *AnalyticsService) courierPerformanceTable(ctx context.Context, report *domain.Report) (*reportTable, error) {
+	from, to, days := reportDays(report.From, report.To)
+
+	stats, err := s.courierStats.ListStats(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list courier stats: %w", err)
+	}
+
+	table := &reportTable{header: []string{
+		"courier_id", "deliveries_completed", "deliveries_cancelled",
+		"average_delivery_minutes", "cancellation_rate", "deliveries_per_day",
+	}}
+	rows := make([]courierPerformanceRow, len(stats))
+	for i, stat := range stats {
+		perf := domain.NewCourierPerformance(stat.CourierID, "", from, days, stat.CourierStats)
+		rows[i] = courierPerformanceRow{
+			CourierID:              perf.CourierID,
+			DeliveriesCompleted:    perf.DeliveriesCompleted,
+			DeliveriesCancelled:    perf.DeliveriesCancelled,
+			AverageDeliveryMinutes: perf.AverageDeliveryMinutes,
+			CancellationRate:       perf.CancellationRate,
+			DeliveriesPerDay:       perf.DeliveriesPerDay,
+		}
+		table.records = append(table.records, []string{
+			strconv.Itoa(perf.CourierID),
+			strconv.Itoa(perf.DeliveriesCompleted),
+			strconv.Itoa(perf.DeliveriesCancelled),
+			formatFloat(perf.AverageDeliveryMinutes),
+			formatFloat(perf.CancellationRate),
+			formatFloat(perf.DeliveriesPerDay),
+		})
+	}
+	table.rows = rows
+	return table, nil
+}
+
+// reportDays widens [from, to) to whole UTC days, as courier aggregates are daily
+func reportDays(from, to time.Time) (time.Time, time.Time, int) {
+	day := 24 * time.Hour
+	start := from.UTC().Truncate(day)
+	end := to.UTC().Truncate(day)
+	if end.Before(to) {
+		end = end.Add(day)
+	}
+	return start, end, int(end.Sub(start) / day)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// reportDocument is the JSON rendering of a report
+type reportDocument struct {
+	ID          string            `json:"id"`
+	Type        domain.ReportType `json:"type"`
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Summary     interface{}       `json:"summary,omitempty"`
+	Rows        interface{}       `json:"rows"`
+}
+
+// renderReport renders a report's table in the report's format
+func renderReport(report *domain.Report, table *reportTable) ([]byte, error) {
+	var buf bytes.Buffer
+	switch report.Format {
+	case domain.ReportFormatCSV:
+		w := csv.NewWriter(&buf)
+		if err := w.Write(table.header); err != nil {
+			return nil, err
+		}
+		if err := w.WriteAll(table.records); err != nil {
+			return nil, err
+		}
+	case domain.ReportFormatJSON:
+		err := json.NewEncoder(&buf).Encode(reportDocument{
+			ID:          report.ID,
+			Type:        report.Type,
+			From:        report.From,
+			To:          report.To,
+			GeneratedAt: time.Now(),
+			Summary:     table.summary,
+			Rows:        table.rows,
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, domain.ErrInvalidReportFormat
+	}
+	return buf.Bytes(), nil
+}
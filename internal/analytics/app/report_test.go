@@ -0,0 +1,359 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+// memoryReportStore keeps reports in memory
+type memoryReportStore struct {
+	mu        sync.Mutex
+	reports   map[string]domain.Report
+	artifacts map[string][]byte
+	writeErr  error         // returned by WriteArtifact when set
+	release   chan struct{} // WriteArtifact waits for it when set
+}
+
+func newMemoryReportStore() *memoryReportStore {
+	return &memoryReportStore{reports: make(map[string]domain.Report), artifacts: make(map[string][]byte)}
+}
+
+func (m *memoryReportStore) Save(ctx context.Context, report *domain.Report) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reports[report.ID] = *report
+	return nil
+}
+
+func (m *memoryReportStore) Get(ctx context.Context, id string) (*domain.Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report, ok := m.reports[id]
+	if !ok {
+		return nil, domain.ErrReportNotFound
+	}
+	return &report, nil
+}
+
+func (m *memoryReportStore) WriteArtifact(ctx context.Context, id string, data []byte) error {
+	if m.release != nil {
+		<-m.release
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.writeErr != nil {
+		return m.writeErr
+	}
+	m.artifacts[id] = data
+	return nil
+}
+
+func (m *memoryReportStore) OpenArtifact(ctx context.Context, id string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.artifacts[id]
+	if !ok {
+		return nil, domain.ErrReportNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryReportStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.reports, id)
+	delete(m.artifacts, id)
+	return nil
+}
+
+func (m *memoryReportStore) List(ctx context.Context) ([]*domain.Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reports := make([]*domain.Report, 0, len(m.reports))
+	for _, report := range m.reports {
+		reports = append(reports, &report)
+	}
+	return reports, nil
+}
+
+func newReportService(t *testing.T, metrics *MockMetricRepository, courierStats *MockCourierStatsRepository, store *memoryReportStore, config ReportConfig) *AnalyticsService {
+	t.Helper()
+	service := NewAnalyticsService(metrics, courierStats, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetReportStore(store, config)
+	return service
+}
+
+// readArtifact reads a ready report's artifact
+func readArtifact(t *testing.T, service *AnalyticsService, id string) []byte {
+	t.Helper()
+	_, artifact, err := service.OpenReportArtifact(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unexpected error opening report: %v", err)
+	}
+	defer artifact.Close()
+	data, err := io.ReadAll(artifact)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	return data
+}
+
+func TestAnalyticsService_DeliveriesSummaryReportCSV(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	metrics := &MockMetricRepository{metrics: []*domain.Metric{
+		{Type: domain.MetricTypeDeliveryCreated, Timestamp: from.Add(2 * time.Hour), Metadata: map[string]interface{}{"customer_id": 1}},
+		{Type: domain.MetricTypeDeliveryCreated, Timestamp: from.Add(3 * time.Hour), Metadata: map[string]interface{}{"customer_id": 2}},
+		{Type: domain.MetricTypeDeliveryCompleted, Timestamp: from.Add(26 * time.Hour), Metadata: map[string]interface{}{"customer_id": 1}},
+	}}
+	store := newMemoryReportStore()
+	service := newReportService(t, metrics, NewMockCourierStatsRepository(), store, ReportConfig{})
+
+	report, err := service.GenerateReport(context.Background(), domain.ReportRequest{
+		Type:        domain.ReportTypeDeliveriesSummary,
+		Format:      domain.ReportFormatCSV,
+		From:        from,
+		To:          from.Add(3 * 24 * time.Hour),
+		RequestedBy: 5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Status != domain.ReportStatusPending || report.ID == "" {
+		t.Fatalf("expected a pending report with an ID, got %+v", report)
+	}
+	service.Shutdown()
+
+	status, err := service.GetReport(context.Background(), report.ID)
+	if err != nil || status.Status != domain.ReportStatusReady || status.CompletedAt == nil {
+		t.Fatalf("expected a ready report, got %+v (%v)", status, err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(readArtifact(t, service, report.ID))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	expected := [][]string{
+		{"day", "created", "delivered", "cancelled"},
+		{"2024-05-01", "2", "0", "0"},
+		{"2024-05-02", "0", "1", "0"},
+		{"2024-05-03", "0", "0", "0"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %v", len(expected), records)
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if records[i][j] != expected[i][j] {
+				t.Errorf("record %d: expected %v, got %v", i, expected[i], records[i])
+				break
+			}
+		}
+	}
+}
+
+func TestAnalyticsService_CourierPerformanceReportJSON(t *testing.T) {
+	courierStats := NewMockCourierStatsRepository()
+	courierStats.stats[7] = &domain.CourierStats{Completed: 6, Cancelled: 2, TimedDeliveries: 6, TotalDeliverySeconds: 6 * 1800}
+	courierStats.stats[3] = &domain.CourierStats{Completed: 1}
+	store := newMemoryReportStore()
+	service := newReportService(t, &MockMetricRepository{}, courierStats, store, ReportConfig{})
+
+	// A range ending mid-day covers that whole day
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	report, err := service.GenerateReport(context.Background(), domain.ReportRequest{
+		Type:   domain.ReportTypeCourierPerformance,
+		Format: domain.ReportFormatJSON,
+		From:   from,
+		To:     from.Add(2*24*time.Hour + time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.Shutdown()
+
+	var doc struct {
+		ID   string                  `json:"id"`
+		Type domain.ReportType       `json:"type"`
+		Rows []courierPerformanceRow `json:"rows"`
+	}
+	if err := json.Unmarshal(readArtifact(t, service, report.ID), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.ID != report.ID || doc.Type != domain.ReportTypeCourierPerformance || len(doc.Rows) != 2 {
+		t.Fatalf("unexpected report document %+v", doc)
+	}
+	if doc.Rows[0].CourierID != 3 || doc.Rows[1].CourierID != 7 {
+		t.Errorf("expected rows ordered by courier, got %+v", doc.Rows)
+	}
+	row := doc.Rows[1]
+	if row.AverageDeliveryMinutes != 30 || row.CancellationRate != 25 || row.DeliveriesPerDay != 2 {
+		t.Errorf("unexpected courier figures %+v", row)
+	}
+}
+
+func TestAnalyticsService_ReportPendingUntilGenerated(t *testing.T) {
+	store := newMemoryReportStore()
+	store.release = make(chan struct{})
+	service := newReportService(t, &MockMetricRepository{}, NewMockCourierStatsRepository(), store, ReportConfig{})
+	ctx := context.Background()
+
+	now := time.Now()
+	report, err := service.GenerateReport(ctx, domain.ReportRequest{
+		Type: domain.ReportTypeDeliveriesSummary, Format: domain.ReportFormatJSON, From: now.Add(-time.Hour), To: now,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := service.OpenReportArtifact(ctx, report.ID); !errors.Is(err, domain.ErrReportNotReady) {
+		t.Errorf("expected ErrReportNotReady, got %v", err)
+	}
+
+	close(store.release)
+	service.Shutdown()
+
+	if _, artifact, err := service.OpenReportArtifact(ctx, report.ID); err != nil {
+		t.Errorf("expected the report to be ready, got %v", err)
+	} else {
+		artifact.Close()
+	}
+}
+
+func TestAnalyticsService_ReportFailure(t *testing.T) {
+	store := newMemoryReportStore()
+	store.writeErr = errors.New("disk full")
+	service := newReportService(t, &MockMetricRepository{}, NewMockCourierStatsRepository(), store, ReportConfig{})
+	ctx := context.Background()
+
+	now := time.Now()
+	report, err := service.GenerateReport(ctx, domain.ReportRequest{
+		Type: domain.ReportTypeDeliveriesSummary, Format: domain.ReportFormatCSV, From: now.Add(-time.Hour), To: now,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.Shutdown()
+
+	status, err := service.GetReport(ctx, report.ID)
+	if err != nil || status.Status != domain.ReportStatusFailed || status.Error == "" {
+		t.Fatalf("expected a failed report, got %+v (%v)", status, err)
+	}
+	if _, _, err := service.OpenReportArtifact(ctx, report.ID); !errors.Is(err, domain.ErrReportFailed) {
+		t.Errorf("expected ErrReportFailed, got %v", err)
+	}
+}
+
+func TestAnalyticsService_GenerateReportRefusedWhenBusy(t *testing.T) {
+	store := newMemoryReportStore()
+	store.release = make(chan struct{})
+	service := newReportService(t, &MockMetricRepository{}, NewMockCourierStatsRepository(), store, ReportConfig{MaxConcurrent: 1})
+	ctx := context.Background()
+
+	now := time.Now()
+	req := domain.ReportRequest{
+		Type: domain.ReportTypeDeliveriesSummary, Format: domain.ReportFormatCSV, From: now.Add(-time.Hour), To: now,
+	}
+	if _, err := service.GenerateReport(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.GenerateReport(ctx, req); !errors.Is(err, domain.ErrReportsBusy) {
+		t.Errorf("expected ErrReportsBusy, got %v", err)
+	}
+	if len(store.reports) != 1 {
+		t.Errorf("expected the refused report not to be stored, got %d reports", len(store.reports))
+	}
+
+	close(store.release)
+	service.Shutdown()
+
+	if _, err := service.GenerateReport(ctx, req); err != nil {
+		t.Errorf("expected a free slot once the report finished, got %v", err)
+	}
+	service.Shutdown()
+}
+
+func TestAnalyticsService_SweepReports(t *testing.T) {
+	store := newMemoryReportStore()
+	service := newReportService(t, &MockMetricRepository{}, NewMockCourierStatsRepository(), store, ReportConfig{})
+	ctx := context.Background()
+
+	now := time.Now()
+	store.reports["aa"] = domain.Report{ID: "aa", Status: domain.ReportStatusPending, ExpiresAt: now.Add(time.Hour)}
+	store.reports["bb"] = domain.Report{ID: "bb", Status: domain.ReportStatusReady, ExpiresAt: now.Add(time.Hour)}
+	store.reports["cc"] = domain.Report{ID: "cc", Status: domain.ReportStatusPending, ExpiresAt: now.Add(-time.Hour)}
+
+	interrupted, err := service.SweepReports(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if interrupted != 1 {
+		t.Errorf("expected 1 interrupted report, got %d", interrupted)
+	}
+	if report := store.reports["aa"]; report.Status != domain.ReportStatusFailed || report.Error == "" {
+		t.Errorf("expected the pending report to be failed, got %+v", report)
+	}
+	if report := store.reports["bb"]; report.Status != domain.ReportStatusReady {
+		t.Errorf("expected the ready report to be kept, got %+v", report)
+	}
+	if _, ok := store.reports["cc"]; ok {
+		t.Errorf("expected the expired report to be deleted")
+	}
+}
+
+func TestAnalyticsService_ExpiredReportIsRemoved(t *testing.T) {
+	store := newMemoryReportStore()
+	service := newReportService(t, &MockMetricRepository{}, NewMockCourierStatsRepository(), store, ReportConfig{TTL: time.Nanosecond})
+	ctx := context.Background()
+
+	now := time.Now()
+	report, err := service.GenerateReport(ctx, domain.ReportRequest{
+		Type: domain.ReportTypeDeliveriesSummary, Format: domain.ReportFormatCSV, From: now.Add(-time.Hour), To: now,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.Shutdown()
+	time.Sleep(time.Millisecond)
+
+	if _, err := service.GetReport(ctx, report.ID); !errors.Is(err, domain.ErrReportNotFound) {
+		t.Errorf("expected ErrReportNotFound, got %v", err)
+	}
+	if len(store.reports) != 0 || len(store.artifacts) != 0 {
+		t.Errorf("expected the expired report to be deleted")
+	}
+}
+
+func TestAnalyticsService_GenerateReportValidation(t *testing.T) {
+	service := newReportService(t, &MockMetricRepository{}, NewMockCourierStatsRepository(), newMemoryReportStore(), ReportConfig{})
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		req      domain.ReportRequest
+		expected error
+	}{
+		{"unknown type", domain.ReportRequest{Type: "financial", Format: domain.ReportFormatCSV, From: now.Add(-time.Hour), To: now}, domain.ErrInvalidReportType},
+		{"unknown format", domain.ReportRequest{Type: domain.ReportTypeDeliveriesSummary, Format: "pdf", From: now.Add(-time.Hour), To: now}, domain.ErrInvalidReportFormat},
+		{"reversed range", domain.ReportRequest{Type: domain.ReportTypeDeliveriesSummary, Format: domain.ReportFormatCSV, From: now, To: now.Add(-time.Hour)}, domain.ErrInvalidTimeRange},
+		{"range too long", domain.ReportRequest{Type: domain.ReportTypeDeliveriesSummary, Format: domain.ReportFormatCSV, From: now.Add(-367 * 24 * time.Hour), To: now}, domain.ErrInvalidTimeRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.GenerateReport(context.Background(), tt.req); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
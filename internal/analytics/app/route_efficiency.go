@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// errRouteStatsNotConfigured is returned when no route stats repository was set
+var errRouteStatsNotConfigured = errors.New("route efficiency is not configured")
+
+// routeEndpoints are where a completed delivery was picked up and dropped off, if known
+type routeEndpoints struct {
+	pickup  *messaging.Coordinates
+	dropoff *messaging.Coordinates
+	zone    string
+}
+
+// SetRouteStats enables route efficiency, tracking deliveries from location
+// events and rolling up completed ones in repo
+func (s *AnalyticsService) SetRouteStats(repo ports.RouteStatsRepository) {
+	s.routeStats = repo
+}
+
+// GetRouteEfficiency compares completed deliveries' travelled distance with
+// the straight line from pickup to drop-off, per courier and per zone
+func (s *AnalyticsService) GetRouteEfficiency(ctx context.Context, q domain.RouteEfficiencyQuery) (*domain.RouteEfficiency, error) {
+	if s.routeStats == nil {
+		return nil, errRouteStatsNotConfigured
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.routeStats.ListRouteStats(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route stats: %w", err)
+	}
+
+	return domain.NewRouteEfficiency(q, rows), nil
+}
+
+// handleLocationUpdated adds a recorded location to its delivery's track.
+// A redelivered event counts its point twice; only the distance, which
+// matters for the ratio, is kept exact.
+func (s *AnalyticsService) handleLocationUpdated(ctx context.Context, event messaging.Event) error {
+	if s.routeStats == nil {
+		return nil
+	}
+
+	data, err := messaging.DecodeData[messaging.LocationRecordedEvent](event)
+	if err != nil {
+		return err
+	}
+
+	if err := s.routeStats.RecordTrackPoint(ctx, data.DeliveryID, data.CourierID, data.CumulativeDistanceKm); err != nil {
+		return fmt.Errorf("failed to record track point: %w", err)
+	}
+	return nil
+}
+
+// recordRoute adds a completed delivery's route to the daily rollups
+func (s *AnalyticsService) recordRoute(ctx context.Context, route domain.RouteCompletion, endpoints routeEndpoints) error {
+	route.Pickup = domainCoordinates(endpoints.pickup)
+	route.Dropoff = domainCoordinates(endpoints.dropoff)
+	route.Zone = endpoints.zone
+	if route.Zone == "" {
+		route.Zone = domain.UnknownZone
+	}
+
+	recorded, err := s.routeStats.RecordCompletion(ctx, route)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery route: %w", err)
+	}
+	if !recorded {
+		s.logger.InfoWithFields(ctx, "Delivery route already recorded",
+			zap.Int("delivery_id", route.DeliveryID))
+	}
+	return nil
+}
+
+// domainCoordinates converts an event point, keeping nil for unknown ones
+func domainCoordinates(coords *messaging.Coordinates) *domain.Coordinates {
+	if coords == nil {
+		return nil
+	}
+	return &domain.Coordinates{Latitude: coords.Latitude, Longitude: coords.Longitude}
+}
@@ -2,11 +2,13 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"go.uber.org/zap"
@@ -14,17 +16,23 @@ import (
 
 // AnalyticsService implements analytics use cases
 type AnalyticsService struct {
-	repo     ports.MetricRepository
-	consumer messaging.Consumer
-	logger   *logger.Logger
+	repo          ports.MetricRepository
+	courierStats  ports.CourierStatsRepository
+	routeStats    ports.RouteStatsRepository    // nil until SetRouteStats
+	customerStats ports.CustomerStatsRepository // nil until SetCustomerStats
+	consumer      messaging.Consumer
+	buffer        *metricBuffer    // nil until SetBatching
+	reports       *reportGenerator // nil until SetReportStore
+	logger        *logger.Logger
 }
 
 // NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(repo ports.MetricRepository, consumer messaging.Consumer, logger *logger.Logger) *AnalyticsService {
+func NewAnalyticsService(repo ports.MetricRepository, courierStats ports.CourierStatsRepository, consumer messaging.Consumer, logger *logger.Logger) *AnalyticsService {
 	return &AnalyticsService{
-		repo:     repo,
-		consumer: consumer,
-		logger:   logger,
+		repo:         repo,
+		courierStats: courierStats,
+		consumer:     consumer,
+		logger:       logger,
 	}
 }
 
@@ -36,6 +44,29 @@ func (s *AnalyticsService) RecordMetric(
 	entityType string,
 	value float64,
 	metadata map[string]interface{},
+) (*domain.Metric, error) {
+	metric, err := s.newMetric(ctx, metricType, entityID, entityType, value, metadata)
+	if err != nil {
+		return nil, err
+	}
+	metric.OrgID = authctx.Organization(ctx)
+
+	// Persist to repository
+	if err := s.repo.Create(ctx, metric); err != nil {
+		return nil, fmt.Errorf("failed to record metric: %w", err)
+	}
+
+	return metric, nil
+}
+
+// newMetric builds a validated metric, tagged with the event being handled if any
+func (s *AnalyticsService) newMetric(
+	ctx context.Context,
+	metricType domain.MetricType,
+	entityID int,
+	entityType string,
+	value float64,
+	metadata map[string]interface{},
 ) (*domain.Metric, error) {
 	s.logger.InfoWithFields(ctx, "Recording metric",
 		zap.String("metric_type", string(metricType)),
@@ -56,11 +87,7 @@ func (s *AnalyticsService) RecordMetric(
 	for key, val := range metadata {
 		metric.AddMetadata(key, val)
 	}
-
-	// Persist to repository
-	if err := s.repo.Create(ctx, metric); err != nil {
-		return nil, fmt.Errorf("failed to record metric: %w", err)
-	}
+	metric.SourceEventID = messaging.EventIDFromContext(ctx)
 
 	return metric, nil
 }
@@ -83,6 +110,44 @@ func (s *AnalyticsService) GetMetricsByType(ctx context.Context, metricType doma
 	return s.repo.GetByType(ctx, metricType, limit)
 }
 
+// GetCourierPerformance summarizes a courier's deliveries over a day, week or month
+func (s *AnalyticsService) GetCourierPerformance(ctx context.Context, courierID int, period string) (*domain.CourierPerformance, error) {
+	if period == "" {
+		period = "week" // default period
+	}
+
+	from, days, err := domain.PeriodStart(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.courierStats.GetStats(ctx, courierID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier stats: %w", err)
+	}
+
+	return domain.NewCourierPerformance(courierID, period, from, days, *stats), nil
+}
+
+// GetDashboard builds time-bucketed delivery series and totals
+func (s *AnalyticsService) GetDashboard(ctx context.Context, q domain.DashboardQuery) (*domain.Dashboard, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	points, err := s.repo.GetDeliveryBuckets(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery buckets: %w", err)
+	}
+
+	onTime, err := s.repo.GetOnTimeStats(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get on-time stats: %w", err)
+	}
+
+	return domain.NewDashboard(q, points, *onTime), nil
+}
+
 // StartEventConsumption starts consuming delivery events
 func (s *AnalyticsService) StartEventConsumption() error {
 	return s.consumer.Consume("analytics-delivery-events", s.handleDeliveryEvent)
@@ -90,13 +155,20 @@ func (s *AnalyticsService) StartEventConsumption() error {
 
 // handleDeliveryEvent processes incoming delivery events
 func (s *AnalyticsService) handleDeliveryEvent(event messaging.Event) error {
-	ctx := context.Background()
+	ctx := messaging.ContextWithTraceContext(context.Background(), event.TraceContext)
+	ctx = messaging.ContextWithEventID(ctx, event.ID)
 
 	switch event.Type {
-	case "delivery.created":
+	case messaging.EventTypeDeliveryCreated:
 		return s.handleDeliveryCreated(ctx, event)
-	case "delivery.status_changed":
+	case messaging.EventTypeDeliveryStatusChanged:
 		return s.handleDeliveryStatusChanged(ctx, event)
+	case messaging.EventTypeDeliveryConfirmed:
+		return s.handleDeliveryConfirmed(ctx, event)
+	case messaging.EventTypeDeliveryCancelled:
+		return s.handleDeliveryCancelled(ctx, event)
+	case messaging.EventTypeLocationUpdated:
+		return s.handleLocationUpdated(ctx, event)
 	default:
 		// Ignore unknown event types
 		return nil
@@ -105,29 +177,25 @@ func (s *AnalyticsService) handleDeliveryEvent(event messaging.Event) error {
 
 // handleDeliveryCreated processes delivery creation events
 func (s *AnalyticsService) handleDeliveryCreated(ctx context.Context, event messaging.Event) error {
-	deliveryIDStr, ok := event.Data["delivery_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid delivery_id in event data")
-	}
-
-	deliveryID, err := strconv.Atoi(deliveryIDStr)
+	data, err := messaging.DecodeData[messaging.DeliveryCreatedEvent](event)
 	if err != nil {
-		return fmt.Errorf("failed to parse delivery_id: %w", err)
-	}
-
-	customerIDStr, ok := event.Data["customer_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid customer_id in event data")
+		return err
 	}
 
-	customerID, err := strconv.Atoi(customerIDStr)
-	if err != nil {
-		return fmt.Errorf("failed to parse customer_id: %w", err)
+	// Rolled up on its own, like routes, so a retry after a later failure still adds it
+	if s.customerStats != nil {
+		windowEnd := data.ScheduledEnd
+		if windowEnd == nil {
+			windowEnd = data.ScheduledDate
+		}
+		if err := s.recordCustomerCreated(ctx, data.OrgID, data.DeliveryID, data.CustomerID, eventTime(event), windowEnd); err != nil {
+			return err
+		}
 	}
 
 	// Record delivery creation metric
-	_, err = s.RecordMetric(ctx, domain.MetricTypeDeliveryCreated, deliveryID, "delivery", 1.0, map[string]interface{}{
-		"customer_id": customerID,
+	err = s.recordEventMetric(ctx, data.OrgID, domain.MetricTypeDeliveryCreated, data.DeliveryID, "delivery", 1.0, map[string]interface{}{
+		"customer_id": data.CustomerID,
 		"source":      event.Source,
 	})
 	if err != nil {
@@ -135,9 +203,9 @@ func (s *AnalyticsService) handleDeliveryCreated(ctx context.Context, event mess
 	}
 
 	// Record customer activity metric
-	_, err = s.RecordMetric(ctx, domain.MetricTypeCustomerActivity, customerID, "customer", 1.0, map[string]interface{}{
+	err = s.recordEventMetric(ctx, data.OrgID, domain.MetricTypeCustomerActivity, data.CustomerID, "customer", 1.0, map[string]interface{}{
 		"activity_type": "delivery_created",
-		"delivery_id":   deliveryID,
+		"delivery_id":   data.DeliveryID,
 		"source":        event.Source,
 	})
 	if err != nil {
@@ -149,65 +217,179 @@ func (s *AnalyticsService) handleDeliveryCreated(ctx context.Context, event mess
 
 // handleDeliveryStatusChanged processes delivery status change events
 func (s *AnalyticsService) handleDeliveryStatusChanged(ctx context.Context, event messaging.Event) error {
-	deliveryIDStr, ok := event.Data["delivery_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid delivery_id in event data")
-	}
-
-	deliveryID, err := strconv.Atoi(deliveryIDStr)
+	data, err := messaging.DecodeData[messaging.DeliveryStatusChangedEvent](event)
 	if err != nil {
-		return fmt.Errorf("failed to parse delivery_id: %w", err)
+		return err
 	}
 
-	oldStatus, _ := event.Data["old_status"].(string)
-	newStatus, ok := event.Data["new_status"].(string)
-	if !ok {
-		return fmt.Errorf("invalid new_status in event data")
+	// Unassigned deliveries have no courier
+	var courierID int
+	if data.CourierID != nil {
+		courierID = *data.CourierID
 	}
 
-	customerIDStr, ok := event.Data["customer_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid customer_id in event data")
+	metadata := map[string]interface{}{
+		"old_status":  data.OldStatus,
+		"new_status":  data.NewStatus,
+		"customer_id": data.CustomerID,
+		"source":      event.Source,
+	}
+	if courierID > 0 {
+		metadata["courier_id"] = courierID
 	}
 
-	customerID, err := strconv.Atoi(customerIDStr)
+	// Record delivery status change metric
+	err = s.recordEventMetric(ctx, data.OrgID, domain.MetricTypeDeliveryStatusChanged, data.DeliveryID, "delivery", 1.0, metadata)
 	if err != nil {
-		return fmt.Errorf("failed to parse customer_id: %w", err)
+		return fmt.Errorf("failed to record delivery status change metric: %w", err)
 	}
 
-	courierIDStr, ok := event.Data["courier_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid courier_id in event data")
+	at := eventTime(event)
+	switch data.NewStatus {
+	case "in_transit":
+		if courierID > 0 {
+			if err := s.courierStats.RecordPickup(ctx, data.DeliveryID, courierID, at); err != nil {
+				return fmt.Errorf("failed to record courier pickup: %w", err)
+			}
+		}
+	case "delivered":
+		return s.recordDeliveryOutcome(ctx, data.OrgID, data.DeliveryID, data.CustomerID, courierID, domain.DeliveryOutcomeCompleted, at, data.ScheduledDate, event.Source,
+			routeEndpoints{pickup: data.Pickup, dropoff: data.Dropoff, zone: data.DeliveryZone})
+	case "cancelled":
+		return s.recordDeliveryOutcome(ctx, data.OrgID, data.DeliveryID, data.CustomerID, courierID, domain.DeliveryOutcomeCancelled, at, nil, event.Source, routeEndpoints{})
 	}
 
-	courierID, err := strconv.Atoi(courierIDStr)
+	return nil
+}
+
+// handleDeliveryConfirmed processes proof-of-delivery confirmation events
+func (s *AnalyticsService) handleDeliveryConfirmed(ctx context.Context, event messaging.Event) error {
+	data, err := messaging.DecodeData[messaging.DeliveryConfirmedEvent](event)
 	if err != nil {
-		return fmt.Errorf("failed to parse courier_id: %w", err)
+		return err
 	}
 
-	// Record delivery status change metric
-	_, err = s.RecordMetric(ctx, domain.MetricTypeDeliveryStatusChanged, deliveryID, "delivery", 1.0, map[string]interface{}{
-		"old_status":  oldStatus,
-		"new_status":  newStatus,
-		"customer_id": customerID,
-		"courier_id":  courierID,
-		"source":      event.Source,
-	})
+	at := eventTime(event)
+	if data.DeliveredDate != nil {
+		at = *data.DeliveredDate
+	}
+
+	return s.recordDeliveryOutcome(ctx, data.OrgID, data.DeliveryID, data.CustomerID, *data.CourierID, domain.DeliveryOutcomeCompleted, at,
+		data.ScheduledDate, event.Source, routeEndpoints{pickup: data.Pickup, dropoff: data.Dropoff, zone: data.DeliveryZone})
+}
+
+// handleDeliveryCancelled processes delivery cancellation events
+func (s *AnalyticsService) handleDeliveryCancelled(ctx context.Context, event messaging.Event) error {
+	data, err := messaging.DecodeData[messaging.DeliveryCancelledEvent](event)
 	if err != nil {
-		return fmt.Errorf("failed to record delivery status change metric: %w", err)
+		return err
+	}
+
+	var courierID int
+	if data.CourierID != nil {
+		courierID = *data.CourierID
+	}
+
+	at := eventTime(event)
+	if data.CancelledAt != nil {
+		at = *data.CancelledAt
+	}
+
+	return s.recordDeliveryOutcome(ctx, data.OrgID, data.DeliveryID, data.CustomerID, courierID, domain.DeliveryOutcomeCancelled, at, nil, event.Source, routeEndpoints{})
+}
+
+// recordDeliveryOutcome records a finished delivery once, updating the
+// customer's and courier's aggregates and, for completed deliveries, the
+// route rollups
+func (s *AnalyticsService) recordDeliveryOutcome(
+	ctx context.Context,
+	orgID, deliveryID, customerID, courierID int,
+	outcome domain.DeliveryOutcome,
+	at time.Time,
+	scheduled *time.Time,
+	source string,
+	endpoints routeEndpoints,
+) error {
+	// Routes are recorded once on their own, so a retry after a later failure still adds them
+	if outcome == domain.DeliveryOutcomeCompleted && courierID > 0 && s.routeStats != nil {
+		route := domain.RouteCompletion{OrgID: orgID, DeliveryID: deliveryID, CourierID: courierID, At: at}
+		if err := s.recordRoute(ctx, route, endpoints); err != nil {
+			return err
+		}
+	}
+	if s.customerStats != nil {
+		finished := domain.CustomerOutcome{OrgID: orgID, DeliveryID: deliveryID, CustomerID: customerID, Outcome: outcome, At: at, WindowEnd: scheduled, Zone: endpoints.zone}
+		if err := s.recordCustomerOutcome(ctx, finished); err != nil {
+			return err
+		}
 	}
 
-	// Record delivery completion if status is completed
-	if newStatus == "completed" {
-		_, err = s.RecordMetric(ctx, domain.MetricTypeDeliveryCompleted, deliveryID, "delivery", 1.0, map[string]interface{}{
-			"customer_id": customerID,
-			"courier_id":  courierID,
-			"source":      event.Source,
-		})
+	if courierID > 0 {
+		recorded, err := s.courierStats.RecordOutcome(ctx, orgID, deliveryID, courierID, outcome, at)
 		if err != nil {
-			return fmt.Errorf("failed to record delivery completion metric: %w", err)
+			return fmt.Errorf("failed to record courier delivery outcome: %w", err)
+		}
+		if !recorded {
+			// Already counted, e.g. status change followed by a confirmation
+			return nil
 		}
 	}
 
+	metricType := domain.MetricTypeDeliveryCompleted
+	if outcome == domain.DeliveryOutcomeCancelled {
+		metricType = domain.MetricTypeDeliveryCancelled
+	}
+
+	metadata := map[string]interface{}{
+		"customer_id": customerID,
+		"source":      source,
+	}
+	if courierID > 0 {
+		metadata["courier_id"] = courierID
+	}
+	if outcome == domain.DeliveryOutcomeCompleted && scheduled != nil {
+		metadata["on_time"] = !at.After(*scheduled)
+	}
+
+	if err := s.recordEventMetric(ctx, orgID, metricType, deliveryID, "delivery", 1.0, metadata); err != nil {
+		return fmt.Errorf("failed to record delivery %s metric: %w", outcome, err)
+	}
+
 	return nil
 }
+
+// recordEventMetric records a metric derived from the event being handled
+// under the event's organization, batched when enabled. A metric the event
+// already recorded counts as success, so redeliveries are acked.
+func (s *AnalyticsService) recordEventMetric(
+	ctx context.Context,
+	orgID int,
+	metricType domain.MetricType,
+	entityID int,
+	entityType string,
+	value float64,
+	metadata map[string]interface{},
+) error {
+	metric, err := s.newMetric(ctx, metricType, entityID, entityType, value, metadata)
+	if err != nil {
+		return err
+	}
+	metric.OrgID = orgID
+
+	err = s.writeEventMetric(ctx, metric)
+	if errors.Is(err, domain.ErrDuplicateMetric) {
+		s.logger.InfoWithFields(ctx, "Metric already recorded for event",
+			zap.String("metric_type", string(metricType)),
+			zap.String("event_id", messaging.EventIDFromContext(ctx)))
+		return nil
+	}
+	return err
+}
+
+// eventTime returns when an event was emitted, defaulting to now
+func eventTime(event messaging.Event) time.Time {
+	if event.Timestamp > 0 {
+		return time.Unix(event.Timestamp, 0)
+	}
+	return time.Now()
+}
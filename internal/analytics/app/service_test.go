@@ -0,0 +1,641 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap/zaptest"
+)
+
+// MockMetricRepository is a mock implementation of MetricRepository for testing
+type MockMetricRepository struct {
+	metrics  []*domain.Metric
+	batches  []int // sizes of the CreateBatch calls
+	batchErr error // returned by CreateBatch when set
+}
+
+func (m *MockMetricRepository) Create(ctx context.Context, metric *domain.Metric) error {
+	if metric.SourceEventID != "" {
+		for _, existing := range m.metrics {
+			if existing.SourceEventID == metric.SourceEventID && existing.Type == metric.Type {
+				return domain.ErrDuplicateMetric
+			}
+		}
+	}
+	metric.ID = len(m.metrics) + 1
+	m.metrics = append(m.metrics, metric)
+	return nil
+}
+
+func (m *MockMetricRepository) CreateBatch(ctx context.Context, metrics []*domain.Metric) error {
+	m.batches = append(m.batches, len(metrics))
+	if m.batchErr != nil {
+		return m.batchErr
+	}
+	for _, metric := range metrics {
+		if err := m.Create(ctx, metric); err != nil && !errors.Is(err, domain.ErrDuplicateMetric) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockMetricRepository) GetByID(ctx context.Context, id int) (*domain.Metric, error) {
+	if id <= 0 || id > len(m.metrics) {
+		return nil, domain.ErrAnalyticsNotFound
+	}
+	return m.metrics[id-1], nil
+}
+
+func (m *MockMetricRepository) GetByType(ctx context.Context, metricType domain.MetricType, limit int) ([]*domain.Metric, error) {
+	var metrics []*domain.Metric
+	for _, metric := range m.metrics {
+		if metric.Type == metricType {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+func (m *MockMetricRepository) GetByEntityID(ctx context.Context, entityID int, entityType string, limit int) ([]*domain.Metric, error) {
+	var metrics []*domain.Metric
+	for _, metric := range m.metrics {
+		if metric.EntityID == entityID && metric.EntityType == entityType {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+func (m *MockMetricRepository) GetDeliveryStats(ctx context.Context, period string) (*domain.DeliveryStats, error) {
+	return &domain.DeliveryStats{Period: period}, nil
+}
+
+func (m *MockMetricRepository) matchesDashboard(metric *domain.Metric, q domain.DashboardQuery) bool {
+	if metric.Timestamp.Before(q.From) || !metric.Timestamp.Before(q.To) {
+		return false
+	}
+	if q.CustomerID != nil {
+		customerID, ok := metric.Metadata["customer_id"].(int)
+		return ok && customerID == *q.CustomerID
+	}
+	return true
+}
+
+func (m *MockMetricRepository) GetDeliveryBuckets(ctx context.Context, q domain.DashboardQuery) ([]domain.DashboardPoint, error) {
+	step := time.Hour
+	if q.Bucket == domain.BucketDay {
+		step = 24 * time.Hour
+	}
+
+	byBucket := make(map[time.Time]*domain.DashboardPoint)
+	var points []domain.DashboardPoint
+	for _, metric := range m.metrics {
+		if !m.matchesDashboard(metric, q) {
+			continue
+		}
+		bucket := metric.Timestamp.UTC().Truncate(step)
+		point, ok := byBucket[bucket]
+		if !ok {
+			point = &domain.DashboardPoint{Bucket: bucket}
+			byBucket[bucket] = point
+		}
+		switch metric.Type {
+		case domain.MetricTypeDeliveryCreated:
+			point.Created++
+		case domain.MetricTypeDeliveryCompleted:
+			point.Delivered++
+		case domain.MetricTypeDeliveryCancelled:
+			point.Cancelled++
+		}
+	}
+	for _, point := range byBucket {
+		points = append(points, *point)
+	}
+	return points, nil
+}
+
+func (m *MockMetricRepository) GetOnTimeStats(ctx context.Context, q domain.DashboardQuery) (*domain.OnTimeStats, error) {
+	var stats domain.OnTimeStats
+	for _, metric := range m.metrics {
+		if metric.Type != domain.MetricTypeDeliveryCompleted || !m.matchesDashboard(metric, q) {
+			continue
+		}
+		onTime, ok := metric.Metadata["on_time"].(bool)
+		if !ok {
+			continue
+		}
+		stats.Scheduled++
+		if onTime {
+			stats.OnTime++
+		}
+	}
+	return &stats, nil
+}
+
+type mockCourierDelivery struct {
+	orgID      int
+	courierID  int
+	pickedUpAt *time.Time
+	finished   bool
+}
+
+// MockCourierStatsRepository keeps courier aggregates in memory
+type MockCourierStatsRepository struct {
+	deliveries map[int]*mockCourierDelivery
+	stats      map[int]*domain.CourierStats
+}
+
+func NewMockCourierStatsRepository() *MockCourierStatsRepository {
+	return &MockCourierStatsRepository{
+		deliveries: make(map[int]*mockCourierDelivery),
+		stats:      make(map[int]*domain.CourierStats),
+	}
+}
+
+func (m *MockCourierStatsRepository) delivery(deliveryID, courierID int) *mockCourierDelivery {
+	d, ok := m.deliveries[deliveryID]
+	if !ok {
+		d = &mockCourierDelivery{courierID: courierID}
+		m.deliveries[deliveryID] = d
+	}
+	return d
+}
+
+func (m *MockCourierStatsRepository) RecordPickup(ctx context.Context, deliveryID, courierID int, at time.Time) error {
+	d := m.delivery(deliveryID, courierID)
+	if d.pickedUpAt == nil {
+		d.pickedUpAt = &at
+	}
+	return nil
+}
+
+func (m *MockCourierStatsRepository) RecordOutcome(ctx context.Context, orgID, deliveryID, courierID int, outcome domain.DeliveryOutcome, at time.Time) (bool, error) {
+	d := m.delivery(deliveryID, courierID)
+	if d.finished {
+		return false, nil
+	}
+	d.finished = true
+	d.orgID = orgID
+
+	stats, ok := m.stats[courierID]
+	if !ok {
+		stats = &domain.CourierStats{}
+		m.stats[courierID] = stats
+	}
+
+	switch outcome {
+	case domain.DeliveryOutcomeCompleted:
+		stats.Completed++
+		if d.pickedUpAt != nil {
+			stats.TimedDeliveries++
+			stats.TotalDeliverySeconds += at.Sub(*d.pickedUpAt).Seconds()
+		}
+	case domain.DeliveryOutcomeCancelled:
+		stats.Cancelled++
+	}
+	return true, nil
+}
+
+func (m *MockCourierStatsRepository) GetStats(ctx context.Context, courierID int, from time.Time) (*domain.CourierStats, error) {
+	if stats, ok := m.stats[courierID]; ok {
+		copied := *stats
+		return &copied, nil
+	}
+	return &domain.CourierStats{}, nil
+}
+
+func (m *MockCourierStatsRepository) ListStats(ctx context.Context, from, to time.Time) ([]domain.CourierReportRow, error) {
+	var rows []domain.CourierReportRow
+	for courierID, stats := range m.stats {
+		rows = append(rows, domain.CourierReportRow{CourierID: courierID, CourierStats: *stats})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].CourierID < rows[j].CourierID })
+	return rows, nil
+}
+
+type mockTrack struct {
+	points     int
+	distanceKm float64
+	completed  bool
+}
+
+// MockRouteStatsRepository keeps delivery tracks and completed routes in memory
+type MockRouteStatsRepository struct {
+	tracks map[int]*mockTrack
+	routes []domain.RouteCompletion
+}
+
+func NewMockRouteStatsRepository() *MockRouteStatsRepository {
+	return &MockRouteStatsRepository{tracks: make(map[int]*mockTrack)}
+}
+
+func (m *MockRouteStatsRepository) track(deliveryID int) *mockTrack {
+	t, ok := m.tracks[deliveryID]
+	if !ok {
+		t = &mockTrack{}
+		m.tracks[deliveryID] = t
+	}
+	return t
+}
+
+func (m *MockRouteStatsRepository) RecordTrackPoint(ctx context.Context, deliveryID, courierID int, cumulativeKm float64) error {
+	t := m.track(deliveryID)
+	if !t.completed {
+		t.points++
+		t.distanceKm = math.Max(t.distanceKm, cumulativeKm)
+	}
+	return nil
+}
+
+func (m *MockRouteStatsRepository) RecordCompletion(ctx context.Context, route domain.RouteCompletion) (bool, error) {
+	t := m.track(route.DeliveryID)
+	if t.completed {
+		return false, nil
+	}
+	t.completed = true
+	route.Points, route.DistanceKm = t.points, t.distanceKm
+	m.routes = append(m.routes, route)
+	return true, nil
+}
+
+func (m *MockRouteStatsRepository) ListRouteStats(ctx context.Context, q domain.RouteEfficiencyQuery) ([]domain.RouteStatsRow, error) {
+	var rows []domain.RouteStatsRow
+	for _, route := range m.routes {
+		if route.At.Before(q.From) || route.At.After(q.To) || (q.CourierID != nil && route.CourierID != *q.CourierID) {
+			continue
+		}
+		rows = append(rows, domain.RouteStatsRow{CourierID: route.CourierID, Zone: route.Zone, RouteStats: route.Stats()})
+	}
+	return rows, nil
+}
+
+func statusEvent(deliveryID string, courierID interface{}, status string, at time.Time) messaging.Event {
+	return messaging.Event{
+		Type:      "delivery.status_changed",
+		Source:    "delivery-service",
+		Timestamp: at.Unix(),
+		Data: map[string]interface{}{
+			"delivery_id": deliveryID,
+			"customer_id": float64(1),
+			"courier_id":  courierID,
+			"new_status":  status,
+		},
+	}
+}
+
+func TestAnalyticsService_CourierPerformanceFromEvents(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	courierStats := NewMockCourierStatsRepository()
+	service := NewAnalyticsService(metrics, courierStats, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+
+	pickup := time.Now().Add(-2 * time.Hour)
+	events := []messaging.Event{
+		// Delivery 1: picked up and delivered 40 minutes later, then confirmed again
+		statusEvent("1", float64(7), "in_transit", pickup),
+		statusEvent("1", float64(7), "delivered", pickup.Add(40*time.Minute)),
+		{
+			Type:      "delivery.confirmed",
+			Timestamp: pickup.Add(41 * time.Minute).Unix(),
+			Data: map[string]interface{}{
+				"delivery_id": "1",
+				"customer_id": float64(1),
+				"courier_id":  float64(7),
+			},
+		},
+		// Delivery 2: confirmed 20 minutes after pickup
+		statusEvent("2", float64(7), "in_transit", pickup),
+		{
+			Type:      "delivery.confirmed",
+			Timestamp: pickup.Add(20 * time.Minute).Unix(),
+			Data: map[string]interface{}{
+				"delivery_id": "2",
+				"customer_id": float64(1),
+				"courier_id":  float64(7),
+			},
+		},
+		// Delivery 3: cancelled
+		statusEvent("3", "7", "cancelled", pickup),
+		// Unassigned delivery changes are not attributed to any courier
+		statusEvent("4", nil, "cancelled", pickup),
+	}
+
+	for _, event := range events {
+		if err := service.handleDeliveryEvent(event); err != nil {
+			t.Fatalf("unexpected error handling %s: %v", event.Type, err)
+		}
+	}
+
+	perf, err := service.GetCourierPerformance(context.Background(), 7, "week")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if perf.DeliveriesCompleted != 2 {
+		t.Errorf("expected 2 completed deliveries, got %d", perf.DeliveriesCompleted)
+	}
+	if perf.DeliveriesCancelled != 1 {
+		t.Errorf("expected 1 cancelled delivery, got %d", perf.DeliveriesCancelled)
+	}
+	if perf.AverageDeliveryMinutes != 30 {
+		t.Errorf("expected average delivery time of 30 minutes, got %f", perf.AverageDeliveryMinutes)
+	}
+	if perf.CancellationRate < 33.3 || perf.CancellationRate > 33.4 {
+		t.Errorf("expected cancellation rate of 33.3%%, got %f", perf.CancellationRate)
+	}
+	if perf.DeliveriesPerDay != 2.0/7.0 {
+		t.Errorf("expected %f deliveries per day, got %f", 2.0/7.0, perf.DeliveriesPerDay)
+	}
+
+	completed, _ := metrics.GetByType(context.Background(), domain.MetricTypeDeliveryCompleted, 0)
+	if len(completed) != 2 {
+		t.Errorf("expected duplicate confirmation to be ignored, got %d completion metrics", len(completed))
+	}
+}
+
+func TestAnalyticsService_DeliveryCancelledEvent(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	courierStats := NewMockCourierStatsRepository()
+	service := NewAnalyticsService(metrics, courierStats, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+
+	cancelledAt := time.Now().Add(-time.Hour)
+	event := messaging.Event{
+		Type:      messaging.EventTypeDeliveryCancelled,
+		Source:    "delivery-service",
+		Timestamp: cancelledAt.Unix(),
+		Data: map[string]interface{}{
+			"delivery_id":  float64(5),
+			"customer_id":  float64(1),
+			"courier_id":   float64(7),
+			"reason":       "Customer moved",
+			"cancelled_at": cancelledAt.Format(time.RFC3339),
+		},
+	}
+	// Redelivered events are only counted once
+	for i := 0; i < 2; i++ {
+		if err := service.handleDeliveryEvent(event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	perf, err := service.GetCourierPerformance(context.Background(), 7, "week")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perf.DeliveriesCancelled != 1 {
+		t.Errorf("expected 1 cancelled delivery, got %d", perf.DeliveriesCancelled)
+	}
+}
+
+func TestAnalyticsService_EventsRecordedUnderTheirOrganization(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	courierStats := NewMockCourierStatsRepository()
+	routeStats := NewMockRouteStatsRepository()
+	customerStats := NewMockCustomerStatsRepository()
+	service := NewAnalyticsService(metrics, courierStats, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetRouteStats(routeStats)
+	service.SetCustomerStats(customerStats)
+
+	delivered := statusEvent("1", float64(7), "delivered", time.Now())
+	delivered.Data["org_id"] = float64(2)
+	if err := service.handleDeliveryEvent(delivered); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, metric := range metrics.metrics {
+		if metric.OrgID != 2 {
+			t.Errorf("expected %s metric in organization 2, got %d", metric.Type, metric.OrgID)
+		}
+	}
+	if d := courierStats.deliveries[1]; d == nil || d.orgID != 2 {
+		t.Errorf("expected the courier outcome in organization 2, got %+v", d)
+	}
+	if len(routeStats.routes) != 1 || routeStats.routes[0].OrgID != 2 {
+		t.Errorf("expected the route in organization 2, got %+v", routeStats.routes)
+	}
+	if d := customerStats.deliveries[1]; d == nil || d.orgID != 2 {
+		t.Errorf("expected the customer outcome in organization 2, got %+v", d)
+	}
+}
+
+func TestAnalyticsService_RouteEfficiencyFromEvents(t *testing.T) {
+	routeStats := NewMockRouteStatsRepository()
+	service := NewAnalyticsService(&MockMetricRepository{}, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetRouteStats(routeStats)
+
+	at := time.Now().Add(-time.Hour)
+	location := func(deliveryID, courierID int, cumulativeKm float64) messaging.Event {
+		return messaging.Event{
+			Type: messaging.EventTypeLocationUpdated,
+			Data: map[string]interface{}{
+				"schema_version":         float64(messaging.SchemaVersionOf(messaging.EventTypeLocationUpdated)),
+				"delivery_id":            float64(deliveryID),
+				"courier_id":             float64(courierID),
+				"cumulative_distance_km": cumulativeKm,
+			},
+		}
+	}
+	delivered := func(deliveryID string, courierID float64, zone string, located bool) messaging.Event {
+		event := statusEvent(deliveryID, courierID, "delivered", at)
+		event.Data["delivery_zone"] = zone
+		if located {
+			// About 11.1 km apart
+			event.Data["pickup"] = map[string]interface{}{"latitude": 52.0, "longitude": 13.0}
+			event.Data["dropoff"] = map[string]interface{}{"latitude": 52.1, "longitude": 13.0}
+		}
+		return event
+	}
+
+	events := []messaging.Event{
+		// Delivery 1: three points, the last one arriving early
+		location(1, 7, 0), location(1, 7, 14), location(1, 7, 9),
+		delivered("1", 7, "Berlin", true),
+		// Redelivered completion and stray points afterwards change nothing
+		delivered("1", 7, "Berlin", true), location(1, 7, 30),
+		// Delivery 2: a single point is excluded
+		location(2, 7, 0),
+		delivered("2", 7, "Berlin", true),
+		// Delivery 3: tracked, but the drop-off was never geocoded
+		location(3, 8, 0), location(3, 8, 5),
+		delivered("3", 8, "", false),
+	}
+	for _, event := range events {
+		if err := service.handleDeliveryEvent(event); err != nil {
+			t.Fatalf("unexpected error handling %s: %v", event.Type, err)
+		}
+	}
+
+	q := domain.RouteEfficiencyQuery{From: at.Add(-time.Hour), To: time.Now()}
+	efficiency, err := service.GetRouteEfficiency(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	total := efficiency.Total
+	if total.Measured != 1 || total.SparseTracks != 1 || total.Unmeasurable != 1 {
+		t.Errorf("expected 1 measured, 1 sparse and 1 unmeasurable delivery, got %+v", total.RouteStats)
+	}
+	if total.ActualKm != 14 || total.Ratio < 1.25 || total.Ratio > 1.27 {
+		t.Errorf("expected 14 km travelled at a ratio of about 1.26, got %f km at %f", total.ActualKm, total.Ratio)
+	}
+	if len(efficiency.Couriers) != 2 || efficiency.Couriers[0].CourierID != 7 || efficiency.Couriers[0].SparseTracks != 1 {
+		t.Errorf("unexpected courier groups %+v", efficiency.Couriers)
+	}
+	if len(efficiency.Zones) != 2 || efficiency.Zones[0].Zone != "Berlin" || efficiency.Zones[1].Zone != domain.UnknownZone {
+		t.Errorf("unexpected zone groups %+v", efficiency.Zones)
+	}
+
+	courierID := 8
+	q.CourierID = &courierID
+	efficiency, err = service.GetRouteEfficiency(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if efficiency.Total.Unmeasurable != 1 || efficiency.Total.Measured != 0 || efficiency.Total.Ratio != 0 {
+		t.Errorf("expected only courier 8's unmeasurable delivery, got %+v", efficiency.Total)
+	}
+
+	if _, err := service.GetRouteEfficiency(context.Background(), domain.RouteEfficiencyQuery{From: q.To, To: q.From}); !errors.Is(err, domain.ErrInvalidTimeRange) {
+		t.Errorf("expected ErrInvalidTimeRange, got %v", err)
+	}
+}
+
+func TestAnalyticsService_GetCourierPerformanceInvalidPeriod(t *testing.T) {
+	service := NewAnalyticsService(&MockMetricRepository{}, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+
+	if _, err := service.GetCourierPerformance(context.Background(), 7, "year"); !errors.Is(err, domain.ErrInvalidPeriod) {
+		t.Errorf("expected ErrInvalidPeriod, got %v", err)
+	}
+}
+
+func TestAnalyticsService_GetDashboard(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	service := NewAnalyticsService(metrics, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	ctx := context.Background()
+
+	now := time.Now()
+	scheduled := now.Add(time.Hour).Format(time.RFC3339Nano)
+	late := now.Add(-time.Hour).Format(time.RFC3339Nano)
+	events := []messaging.Event{
+		{Type: "delivery.created", Data: map[string]interface{}{"delivery_id": "1", "customer_id": float64(1)}},
+		{Type: "delivery.created", Data: map[string]interface{}{"delivery_id": "2", "customer_id": float64(1)}},
+		{Type: "delivery.created", Data: map[string]interface{}{"delivery_id": "3", "customer_id": float64(2)}},
+		{
+			Type:      "delivery.confirmed",
+			Timestamp: now.Unix(),
+			Data:      map[string]interface{}{"delivery_id": "1", "customer_id": float64(1), "courier_id": float64(7), "scheduled_date": scheduled},
+		},
+		{
+			Type:      "delivery.confirmed",
+			Timestamp: now.Unix(),
+			Data:      map[string]interface{}{"delivery_id": "3", "customer_id": float64(2), "courier_id": float64(8), "scheduled_date": late},
+		},
+	}
+	for _, event := range events {
+		if err := service.handleDeliveryEvent(event); err != nil {
+			t.Fatalf("unexpected error handling %s: %v", event.Type, err)
+		}
+	}
+
+	q := domain.DashboardQuery{From: now.Add(-24 * time.Hour), To: now.Add(time.Hour), Bucket: domain.BucketHour}
+
+	global, err := service.GetDashboard(ctx, q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(global.Buckets) < 25 || len(global.Created) != len(global.Buckets) {
+		t.Errorf("expected a continuous hourly axis, got %d buckets and %d points", len(global.Buckets), len(global.Created))
+	}
+	if global.Totals.Created != 3 || global.Totals.Delivered != 2 {
+		t.Errorf("unexpected global totals: %+v", global.Totals)
+	}
+	if global.OnTimePercentage != 50 {
+		t.Errorf("expected 50%% on time, got %f", global.OnTimePercentage)
+	}
+
+	customerID := 1
+	q.CustomerID = &customerID
+	scoped, err := service.GetDashboard(ctx, q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if scoped.Totals.Created != 2 || scoped.Totals.Delivered != 1 {
+		t.Errorf("unexpected customer totals: %+v", scoped.Totals)
+	}
+	if scoped.OnTimePercentage != 100 {
+		t.Errorf("expected 100%% on time, got %f", scoped.OnTimePercentage)
+	}
+}
+
+func TestAnalyticsService_GetDashboardValidation(t *testing.T) {
+	service := NewAnalyticsService(&MockMetricRepository{}, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		query    domain.DashboardQuery
+		expected error
+	}{
+		{name: "unknown bucket", query: domain.DashboardQuery{From: now.Add(-time.Hour), To: now, Bucket: "minute"}, expected: domain.ErrInvalidBucket},
+		{name: "reversed range", query: domain.DashboardQuery{From: now, To: now.Add(-time.Hour), Bucket: domain.BucketHour}, expected: domain.ErrInvalidTimeRange},
+		{name: "range too long", query: domain.DashboardQuery{From: now.Add(-91 * 24 * time.Hour), To: now, Bucket: domain.BucketDay}, expected: domain.ErrInvalidTimeRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.GetDashboard(context.Background(), tt.query); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestAnalyticsService_RedeliveredEventRecordsMetricsOnce(t *testing.T) {
+	metrics := &MockMetricRepository{}
+	service := NewAnalyticsService(metrics, NewMockCourierStatsRepository(), nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+
+	created := messaging.Event{
+		ID:        "evt_1",
+		Type:      messaging.EventTypeDeliveryCreated,
+		Source:    "delivery-service",
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"delivery_id": float64(9),
+			"customer_id": float64(1),
+		},
+	}
+	// An unassigned delivery is not deduplicated by the courier aggregates
+	delivered := statusEvent("9", nil, "delivered", time.Now())
+	delivered.ID = "evt_2"
+
+	for _, event := range []messaging.Event{created, created, delivered, delivered} {
+		if err := service.handleDeliveryEvent(event); err != nil {
+			t.Fatalf("expected %s to be acked, got %v", event.Type, err)
+		}
+	}
+
+	counts := make(map[domain.MetricType]int)
+	for _, metric := range metrics.metrics {
+		counts[metric.Type]++
+	}
+	for _, metricType := range []domain.MetricType{
+		domain.MetricTypeDeliveryCreated,
+		domain.MetricTypeCustomerActivity,
+		domain.MetricTypeDeliveryStatusChanged,
+		domain.MetricTypeDeliveryCompleted,
+	} {
+		if counts[metricType] != 1 {
+			t.Errorf("expected one %s metric, got %d", metricType, counts[metricType])
+		}
+	}
+	if len(metrics.metrics) != 4 {
+		t.Errorf("expected 4 metrics, got %d", len(metrics.metrics))
+	}
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period, expected day, week or month")
+
+// DeliveryOutcome is how a courier's delivery finished
+type DeliveryOutcome string
+
+const (
+	DeliveryOutcomeCompleted DeliveryOutcome = "completed"
+	DeliveryOutcomeCancelled DeliveryOutcome = "cancelled"
+)
+
+// periodDays maps supported reporting periods to their length in days
+var periodDays = map[string]int{
+	"day":   1,
+	"week":  7,
+	"month": 30,
+}
+
+// PeriodStart returns the first day included in a reporting period ending today
+// and the number of days it covers
+func PeriodStart(period string, now time.Time) (time.Time, int, error) {
+	days, ok := periodDays[period]
+	if !ok {
+		return time.Time{}, 0, ErrInvalidPeriod
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return today.AddDate(0, 0, -(days - 1)), days, nil
+}
+
+// CourierStats holds the raw aggregates summed over a period
+type CourierStats struct {
+	Completed            int
+	Cancelled            int
+	TimedDeliveries      int
+	TotalDeliverySeconds float64
+}
+
+// CourierPerformance summarizes a courier's deliveries over a period
+type CourierPerformance struct {
+	CourierID              int       `json:"courier_id"`
+	Period                 string    `json:"period"`
+	From                   time.Time `json:"from"`
+	DeliveriesCompleted    int       `json:"deliveries_completed"`
+	DeliveriesCancelled    int       `json:"deliveries_cancelled"`
+	AverageDeliveryMinutes float64   `json:"average_delivery_minutes"`
+	CancellationRate       float64   `json:"cancellation_rate"` // percentage
+	DeliveriesPerDay       float64   `json:"deliveries_per_day"`
+}
+
+// NewCourierPerformance derives performance figures from period aggregates
+func NewCourierPerformance(courierID int, period string, from time.Time, days int, stats CourierStats) *CourierPerformance {
+	perf := &CourierPerformance{
+		CourierID:           courierID,
+		Period:              period,
+		From:                from,
+		DeliveriesCompleted: stats.Completed,
+		DeliveriesCancelled: stats.Cancelled,
+	}
+
+	if stats.TimedDeliveries > 0 {
+		perf.AverageDeliveryMinutes = stats.TotalDeliverySeconds / float64(stats.TimedDeliveries) / 60
+	}
+	if finished := stats.Completed + stats.Cancelled; finished > 0 {
+		perf.CancellationRate = float64(stats.Cancelled) / float64(finished) * 100
+	}
+	if days > 0 {
+		perf.DeliveriesPerDay = float64(stats.Completed) / float64(days)
+	}
+
+	return perf
+}
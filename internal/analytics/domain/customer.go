@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrInvalidCustomer = errors.New("invalid customer ID")
+
+// Customer analytics settings
+const (
+	// TopZonesLimit is how many destination zones customer analytics lists
+	TopZonesLimit = 5
+	// DefaultCustomerMonths is how many months customer analytics covers by default
+	DefaultCustomerMonths = 12
+)
+
+// MonthStart returns the first instant of t's month in UTC
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// DefaultCustomerFrom returns the start of the DefaultCustomerMonths months
+// ending with to's month
+func DefaultCustomerFrom(to time.Time) time.Time {
+	return MonthStart(to).AddDate(0, -(DefaultCustomerMonths - 1), 0)
+}
+
+// CustomerMonthStats holds one customer's raw aggregates for one month.
+// Creations count in the month the delivery was created, outcomes in the
+// month it finished.
+type CustomerMonthStats struct {
+	Created              int
+	Delivered            int
+	Cancelled            int
+	TimedDeliveries      int // delivered deliveries whose creation was seen
+	TotalDeliverySeconds float64
+	Scheduled            int // delivered deliveries with a scheduled window
+	OnTime               int
+}
+
+// add sums other into s
+func (s *CustomerMonthStats) add(other CustomerMonthStats) {
+	s.Created += other.Created
+	s.Delivered += other.Delivered
+	s.Cancelled += other.Cancelled
+	s.TimedDeliveries += other.TimedDeliveries
+	s.TotalDeliverySeconds += other.TotalDeliverySeconds
+	s.Scheduled += other.Scheduled
+	s.OnTime += other.OnTime
+}
+
+// CustomerOutcome is a finished delivery of a customer. CreatedAt and
+// WindowEnd come from the delivery's creation event when it was seen; a
+// completion event's scheduled date stands in for a missing window end.
+type CustomerOutcome struct {
+	OrgID      int
+	DeliveryID int
+	CustomerID int
+	Outcome    DeliveryOutcome
+	At         time.Time
+	CreatedAt  *time.Time
+	WindowEnd  *time.Time
+	Zone       string // drop-off city, empty when unknown
+}
+
+// Stats returns the outcome's contribution to the month it finished in.
+// Deliveries are timed from creation to drop-off and on time when dropped
+// off by the end of their scheduled window.
+func (o CustomerOutcome) Stats() CustomerMonthStats {
+	if o.Outcome == DeliveryOutcomeCancelled {
+		return CustomerMonthStats{Cancelled: 1}
+	}
+
+	stats := CustomerMonthStats{Delivered: 1}
+	if o.CreatedAt != nil && o.At.After(*o.CreatedAt) {
+		stats.TimedDeliveries = 1
+		stats.TotalDeliverySeconds = o.At.Sub(*o.CreatedAt).Seconds()
+	}
+	if o.WindowEnd != nil {
+		stats.Scheduled = 1
+		if !o.At.After(*o.WindowEnd) {
+			stats.OnTime = 1
+		}
+	}
+	return stats
+}
+
+// CustomerMonthRow is a customer's aggregates for the month starting at Month
+type CustomerMonthRow struct {
+	Month time.Time
+	CustomerMonthStats
+}
+
+// CustomerZoneRow counts a customer's delivered deliveries to one zone
+type CustomerZoneRow struct {
+	Zone      string `json:"zone"`
+	Delivered int    `json:"delivered"`
+}
+
+// CustomerAnalyticsQuery selects the months summarized for a customer.
+// Rollups are monthly, so every month the range touches is included.
+type CustomerAnalyticsQuery struct {
+	CustomerID int
+	From       time.Time
+	To         time.Time
+}
+
+// Validate checks the customer and that the range is ordered and at most MaxReportRange
+func (q CustomerAnalyticsQuery) Validate() error {
+	if q.CustomerID <= 0 {
+		return ErrInvalidCustomer
+	}
+	if q.From.IsZero() || q.To.IsZero() || !q.To.After(q.From) || q.To.Sub(q.From) > MaxReportRange {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// CustomerMonth summarizes a customer's deliveries in one month
+type CustomerMonth struct {
+	Month                  string  `json:"month"` // YYYY-MM
+	Created                int     `json:"created"`
+	Delivered              int     `json:"delivered"`
+	Cancelled              int     `json:"cancelled"`
+	AverageDeliveryMinutes float64 `json:"average_delivery_minutes"`
+	OnTimePercentage       float64 `json:"on_time_percentage"`
+}
+
+func newCustomerMonth(month time.Time, stats CustomerMonthStats) CustomerMonth {
+	return CustomerMonth{
+		Month:                  month.Format("2006-01"),
+		Created:                stats.Created,
+		Delivered:              stats.Delivered,
+		Cancelled:              stats.Cancelled,
+		AverageDeliveryMinutes: stats.averageDeliveryMinutes(),
+		OnTimePercentage:       stats.onTimePercentage(),
+	}
+}
+
+func (s CustomerMonthStats) averageDeliveryMinutes() float64 {
+	if s.TimedDeliveries == 0 {
+		return 0
+	}
+	return s.TotalDeliverySeconds / float64(s.TimedDeliveries) / 60
+}
+
+func (s CustomerMonthStats) onTimePercentage() float64 {
+	if s.Scheduled == 0 {
+		return 0
+	}
+	return float64(s.OnTime) / float64(s.Scheduled) * 100
+}
+
+// CustomerAnalytics summarizes a customer's deliveries per month and over
+// the whole range. On-time percentages only count deliveries that had a
+// scheduled window.
+type CustomerAnalytics struct {
+	CustomerID             int               `json:"customer_id"`
+	From                   time.Time         `json:"from"`
+	To                     time.Time         `json:"to"`
+	Months                 []CustomerMonth   `json:"months"`
+	Totals                 DashboardTotals   `json:"totals"`
+	AverageDeliveryMinutes float64           `json:"average_delivery_minutes"`
+	OnTimePercentage       float64           `json:"on_time_percentage"`
+	TopZones               []CustomerZoneRow `json:"top_zones"`
+}
+
+// NewCustomerAnalytics lays monthly rows out on a continuous month axis,
+// filling gaps with zeros, and keeps the TopZonesLimit zones delivered to
+// most, ties broken by name
+func NewCustomerAnalytics(q CustomerAnalyticsQuery, months []CustomerMonthRow, zones []CustomerZoneRow) *CustomerAnalytics {
+	byMonth := make(map[int64]CustomerMonthStats, len(months))
+	for _, row := range months {
+		byMonth[MonthStart(row.Month).Unix()] = row.CustomerMonthStats
+	}
+
+	analytics := &CustomerAnalytics{
+		CustomerID: q.CustomerID,
+		From:       q.From,
+		To:         q.To,
+		Months:     []CustomerMonth{},
+	}
+
+	var total CustomerMonthStats
+	for m := MonthStart(q.From); m.Before(q.To); m = m.AddDate(0, 1, 0) {
+		stats := byMonth[m.Unix()]
+		total.add(stats)
+		analytics.Months = append(analytics.Months, newCustomerMonth(m, stats))
+	}
+	analytics.Totals = DashboardTotals{Created: total.Created, Delivered: total.Delivered, Cancelled: total.Cancelled}
+	analytics.AverageDeliveryMinutes = total.averageDeliveryMinutes()
+	analytics.OnTimePercentage = total.onTimePercentage()
+
+	top := append([]CustomerZoneRow(nil), zones...)
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Delivered != top[j].Delivered {
+			return top[i].Delivered > top[j].Delivered
+		}
+		return top[i].Zone < top[j].Zone
+	})
+	if len(top) > TopZonesLimit {
+		top = top[:TopZonesLimit]
+	}
+	analytics.TopZones = append([]CustomerZoneRow{}, top...)
+
+	return analytics
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCustomerAnalytics_TopZones(t *testing.T) {
+	q := CustomerAnalyticsQuery{CustomerID: 1, From: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	zones := []CustomerZoneRow{
+		{Zone: "Hamburg", Delivered: 1},
+		{Zone: "Berlin", Delivered: 4},
+		{Zone: "Bremen", Delivered: 1},
+		{Zone: "Potsdam", Delivered: 4},
+		{Zone: "Munich", Delivered: 2},
+		{Zone: "Cologne", Delivered: 1},
+	}
+
+	analytics := NewCustomerAnalytics(q, nil, zones)
+
+	var got []string
+	for _, zone := range analytics.TopZones {
+		got = append(got, zone.Zone)
+	}
+	expected := []string{"Berlin", "Potsdam", "Munich", "Bremen", "Cologne"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+
+	// A range ending at a month's start leaves that month out
+	if len(analytics.Months) != 2 || analytics.Months[0].Month != "2026-01" || analytics.Months[1].Month != "2026-02" {
+		t.Errorf("expected January and February, got %+v", analytics.Months)
+	}
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	ErrInvalidBucket    = errors.New("invalid bucket, expected hour or day")
+)
+
+// MaxDashboardRange is the longest time range a dashboard can cover
+const MaxDashboardRange = 90 * 24 * time.Hour
+
+// Dashboard buckets supported by date_trunc
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
+
+// DashboardQuery selects the deliveries summarized by a dashboard. A nil
+// CustomerID means global numbers.
+type DashboardQuery struct {
+	From       time.Time
+	To         time.Time
+	Bucket     string
+	CustomerID *int
+}
+
+// Validate checks the bucket and that the range is ordered and at most MaxDashboardRange
+func (q DashboardQuery) Validate() error {
+	if q.Bucket != BucketHour && q.Bucket != BucketDay {
+		return ErrInvalidBucket
+	}
+	if q.From.IsZero() || q.To.IsZero() || !q.To.After(q.From) || q.To.Sub(q.From) > MaxDashboardRange {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// truncate rounds t down to the start of its bucket in UTC
+func (q DashboardQuery) truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if q.Bucket == BucketDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// step returns the length of one bucket
+func (q DashboardQuery) step() time.Duration {
+	if q.Bucket == BucketDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// DashboardPoint holds delivery counts for one time bucket
+type DashboardPoint struct {
+	Bucket    time.Time
+	Created   int
+	Delivered int
+	Cancelled int
+}
+
+// OnTimeStats counts delivered deliveries that had a scheduled date
+type OnTimeStats struct {
+	Scheduled int
+	OnTime    int
+}
+
+// DashboardTotals sums the series over the whole range
+type DashboardTotals struct {
+	Created   int `json:"created"`
+	Delivered int `json:"delivered"`
+	Cancelled int `json:"cancelled"`
+}
+
+// Dashboard holds chart-ready delivery series; all series share the Buckets axis
+type Dashboard struct {
+	From             time.Time       `json:"from"`
+	To               time.Time       `json:"to"`
+	Bucket           string          `json:"bucket"`
+	Buckets          []time.Time     `json:"buckets"`
+	Created          []int           `json:"created"`
+	Delivered        []int           `json:"delivered"`
+	Cancelled        []int           `json:"cancelled"`
+	Totals           DashboardTotals `json:"totals"`
+	OnTimePercentage float64         `json:"on_time_percentage"`
+}
+
+// NewDashboard lays points out on a continuous bucket axis, filling gaps with zeros
+func NewDashboard(q DashboardQuery, points []DashboardPoint, onTime OnTimeStats) *Dashboard {
+	byBucket := make(map[int64]DashboardPoint, len(points))
+	for _, p := range points {
+		byBucket[q.truncate(p.Bucket).Unix()] = p
+	}
+
+	d := &Dashboard{
+		From:   q.From,
+		To:     q.To,
+		Bucket: q.Bucket,
+	}
+
+	for b := q.truncate(q.From); b.Before(q.To); b = b.Add(q.step()) {
+		p := byBucket[b.Unix()]
+		d.Buckets = append(d.Buckets, b)
+		d.Created = append(d.Created, p.Created)
+		d.Delivered = append(d.Delivered, p.Delivered)
+		d.Cancelled = append(d.Cancelled, p.Cancelled)
+		d.Totals.Created += p.Created
+		d.Totals.Delivered += p.Delivered
+		d.Totals.Cancelled += p.Cancelled
+	}
+
+	if onTime.Scheduled > 0 {
+		d.OnTimePercentage = float64(onTime.OnTime) / float64(onTime.Scheduled) * 100
+	}
+
+	return d
+}
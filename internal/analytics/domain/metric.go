@@ -8,6 +8,10 @@ import (
 var (
 	ErrAnalyticsNotFound = errors.New("analytics data not found")
 	ErrInvalidMetric     = errors.New("invalid metric data")
+
+	// ErrDuplicateMetric is returned when the event being handled already
+	// recorded a metric of the same type, e.g. on redelivery
+	ErrDuplicateMetric = errors.New("metric already recorded for this event")
 )
 
 // MetricType represents the type of metric
@@ -26,6 +30,7 @@ const (
 // Metric represents an analytics metric
 type Metric struct {
 	ID         int
+	OrgID      int // organization of the event or caller the metric came from
 	Type       MetricType
 	EntityID   int
 	EntityType string
@@ -33,6 +38,8 @@ type Metric struct {
 	Metadata   map[string]interface{}
 	Timestamp  time.Time
 	CreatedAt  time.Time
+
+	SourceEventID string // event the metric was derived from; empty for direct records
 }
 
 // NewMetric creates a new metric with validation
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrReportNotFound      = errors.New("report not found")
+	ErrReportNotReady      = errors.New("report not ready")
+	ErrReportFailed        = errors.New("report generation failed")
+	ErrInvalidReportType   = errors.New("invalid report type, expected deliveries_summary or courier_performance")
+	ErrInvalidReportFormat = errors.New("invalid report format, expected csv or json")
+	ErrReportsBusy         = errors.New("too many reports are being generated, try again later")
+)
+
+// MaxReportRange is the longest time range a report can cover
+const MaxReportRange = 366 * 24 * time.Hour
+
+// ReportType selects the aggregates a report is built from
+type ReportType string
+
+const (
+	ReportTypeDeliveriesSummary  ReportType = "deliveries_summary"
+	ReportTypeCourierPerformance ReportType = "courier_performance"
+)
+
+// ReportFormat is how a report artifact is rendered
+type ReportFormat string
+
+const (
+	ReportFormatCSV  ReportFormat = "csv"
+	ReportFormatJSON ReportFormat = "json"
+)
+
+// ContentType returns the MIME type of artifacts in the format
+func (f ReportFormat) ContentType() string {
+	if f == ReportFormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// ReportStatus tracks asynchronous report generation
+type ReportStatus string
+
+const (
+	ReportStatusPending ReportStatus = "pending"
+	ReportStatusReady   ReportStatus = "ready"
+	ReportStatusFailed  ReportStatus = "failed"
+)
+
+// Report describes a generated (or generating) report artifact. A nil
+// CustomerID means global numbers.
+type Report struct {
+	ID          string       `json:"id"`
+	Type        ReportType   `json:"type"`
+	Format      ReportFormat `json:"format"`
+	From        time.Time    `json:"from"`
+	To          time.Time    `json:"to"`
+	CustomerID  *int         `json:"customer_id,omitempty"`
+	Status      ReportStatus `json:"status"`
+	Error       string       `json:"error,omitempty"`
+	CreatedBy   int          `json:"created_by"`
+	OrgID       int          `json:"org_id,omitempty"` // organization of the user who requested it
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+}
+
+// ReportRequest asks for a report over [From, To). A nil CustomerID means
+// global numbers.
+type ReportRequest struct {
+	Type        ReportType
+	Format      ReportFormat
+	From        time.Time
+	To          time.Time
+	CustomerID  *int
+	RequestedBy int
+}
+
+// Validate checks the type, the format and that the range is ordered and at most MaxReportRange
+func (r ReportRequest) Validate() error {
+	if r.Type != ReportTypeDeliveriesSummary && r.Type != ReportTypeCourierPerformance {
+		return ErrInvalidReportType
+	}
+	if r.Format != ReportFormatCSV && r.Format != ReportFormatJSON {
+		return ErrInvalidReportFormat
+	}
+	if r.From.IsZero() || r.To.IsZero() || !r.To.After(r.From) || r.To.Sub(r.From) > MaxReportRange {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// NewReport creates a pending report with a random ID that expires after ttl
+func NewReport(req ReportRequest, ttl time.Duration) (*Report, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Report{
+		ID:         hex.EncodeToString(id[:]),
+		Type:       req.Type,
+		Format:     req.Format,
+		From:       req.From,
+		To:         req.To,
+		CustomerID: req.CustomerID,
+		Status:     ReportStatusPending,
+		CreatedBy:  req.RequestedBy,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}, nil
+}
+
+// MarkReady records that the artifact was stored
+func (r *Report) MarkReady() {
+	now := time.Now()
+	r.Status = ReportStatusReady
+	r.Error = ""
+	r.CompletedAt = &now
+}
+
+// MarkFailed records why the artifact could not be generated
+func (r *Report) MarkFailed(err error) {
+	now := time.Now()
+	r.Status = ReportStatusFailed
+	r.Error = err.Error()
+	r.CompletedAt = &now
+}
+
+// Expired reports whether the report is past its expiry time
+func (r *Report) Expired(now time.Time) bool {
+	return now.After(r.ExpiresAt)
+}
+
+// VisibleTo reports whether a user of an organization may fetch the report:
+// super-admins see every report, admins their organization's and anyone else
+// only their own
+func (r *Report) VisibleTo(userID, orgID int, role string) bool {
+	switch role {
+	case "super_admin":
+		return true
+	case "admin":
+		return r.OrgID == orgID
+	}
+	return r.CreatedBy == userID
+}
+
+// DownloadPath is where the artifact is served over HTTP
+func (r *Report) DownloadPath() string {
+	return "/reports/" + r.ID
+}
+
+// CourierReportRow holds one courier's aggregates over a report range
+type CourierReportRow struct {
+	CourierID int
+	CourierStats
+}
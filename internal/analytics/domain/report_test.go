@@ -0,0 +1,27 @@
+package domain
+
+import "testing"
+
+func TestReport_VisibleTo(t *testing.T) {
+	report := &Report{CreatedBy: 5, OrgID: 2}
+	tests := []struct {
+		name     string
+		userID   int
+		orgID    int
+		role     string
+		expected bool
+	}{
+		{name: "requester", userID: 5, orgID: 2, role: "customer", expected: true},
+		{name: "other customer", userID: 6, orgID: 2, role: "customer"},
+		{name: "admin of the organization", userID: 1, orgID: 2, role: "admin", expected: true},
+		{name: "admin of another organization", userID: 1, orgID: 1, role: "admin"},
+		{name: "super-admin", userID: 1, orgID: 1, role: "super_admin", expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := report.VisibleTo(tt.userID, tt.orgID, tt.role); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+)
+
+// Route efficiency settings
+const (
+	// MinTrackPoints is the fewest recorded locations a track needs to be measured
+	MinTrackPoints = 2
+	// minStraightLineKm is the shortest pickup to drop-off distance a ratio is
+	// taken against; closer endpoints make any detour look enormous
+	minStraightLineKm = 0.05
+	// UnknownZone groups deliveries whose drop-off city is not known
+	UnknownZone = "unknown"
+)
+
+// Coordinates is a point in decimal degrees
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// RouteCompletion is a completed delivery's route: its endpoints from the
+// completion event and the track recorded from location events
+type RouteCompletion struct {
+	OrgID      int
+	DeliveryID int
+	CourierID  int
+	Zone       string
+	Pickup     *Coordinates
+	Dropoff    *Coordinates
+	Points     int
+	DistanceKm float64 // as travelled, summed by the tracking service
+	At         time.Time
+}
+
+// Stats returns the completion's contribution to its day's rollup. Tracks
+// with fewer than MinTrackPoints and deliveries without two distinct
+// endpoints are counted but not measured.
+func (c RouteCompletion) Stats() RouteStats {
+	if c.Points < MinTrackPoints {
+		return RouteStats{SparseTracks: 1}
+	}
+	if c.Pickup == nil || c.Dropoff == nil {
+		return RouteStats{Unmeasurable: 1}
+	}
+
+	straight := geo.HaversineKm(c.Pickup.Latitude, c.Pickup.Longitude, c.Dropoff.Latitude, c.Dropoff.Longitude)
+	if straight < minStraightLineKm {
+		return RouteStats{Unmeasurable: 1}
+	}
+	return RouteStats{Measured: 1, ActualKm: c.DistanceKm, StraightLineKm: straight}
+}
+
+// RouteStats holds the raw route aggregates summed over a period
+type RouteStats struct {
+	Measured       int     `json:"measured_deliveries"`
+	ActualKm       float64 `json:"actual_distance_km"`
+	StraightLineKm float64 `json:"straight_line_distance_km"`
+	SparseTracks   int     `json:"sparse_track_deliveries"` // fewer than MinTrackPoints recorded
+	Unmeasurable   int     `json:"unmeasurable_deliveries"` // pickup or drop-off not located
+}
+
+// add sums other into s
+func (s *RouteStats) add(other RouteStats) {
+	s.Measured += other.Measured
+	s.ActualKm += other.ActualKm
+	s.StraightLineKm += other.StraightLineKm
+	s.SparseTracks += other.SparseTracks
+	s.Unmeasurable += other.Unmeasurable
+}
+
+// RouteStatsRow is one courier's route aggregates in one zone
+type RouteStatsRow struct {
+	CourierID int
+	Zone      string
+	RouteStats
+}
+
+// RouteEfficiencyQuery selects the completed deliveries summarized. Rollups
+// are daily, so every day the range touches is included; a nil CourierID
+// means all couriers.
+type RouteEfficiencyQuery struct {
+	From      time.Time
+	To        time.Time
+	CourierID *int
+}
+
+// Validate checks that the range is ordered and at most MaxReportRange
+func (q RouteEfficiencyQuery) Validate() error {
+	if q.From.IsZero() || q.To.IsZero() || !q.To.After(q.From) || q.To.Sub(q.From) > MaxReportRange {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// RouteEfficiencyGroup summarizes the routes of one courier, one zone or all
+// deliveries. Ratio is actual over straight-line distance, so 1 is a
+// perfectly direct route; it is 0 when no delivery was measured.
+type RouteEfficiencyGroup struct {
+	CourierID int    `json:"courier_id,omitempty"`
+	Zone      string `json:"zone,omitempty"`
+	RouteStats
+	Ratio float64 `json:"distance_ratio"`
+}
+
+func newRouteEfficiencyGroup(courierID int, zone string, stats RouteStats) RouteEfficiencyGroup {
+	group := RouteEfficiencyGroup{CourierID: courierID, Zone: zone, RouteStats: stats}
+	if stats.StraightLineKm > 0 {
+		group.Ratio = stats.ActualKm / stats.StraightLineKm
+	}
+	return group
+}
+
+// RouteEfficiency summarizes completed deliveries' routes overall, per courier and per zone
+type RouteEfficiency struct {
+	From      time.Time              `json:"from"`
+	To        time.Time              `json:"to"`
+	CourierID *int                   `json:"courier_id,omitempty"`
+	Total     RouteEfficiencyGroup   `json:"total"`
+	Couriers  []RouteEfficiencyGroup `json:"couriers"`
+	Zones     []RouteEfficiencyGroup `json:"zones"`
+}
+
+// NewRouteEfficiency totals per courier and zone rows, ordering couriers by
+// ID and zones by name
+func NewRouteEfficiency(q RouteEfficiencyQuery, rows []RouteStatsRow) *RouteEfficiency {
+	var total RouteStats
+	couriers := make(map[int]RouteStats)
+	zones := make(map[string]RouteStats)
+	for _, row := range rows {
+		total.add(row.RouteStats)
+
+		courier := couriers[row.CourierID]
+		courier.add(row.RouteStats)
+		couriers[row.CourierID] = courier
+
+		zone := zones[row.Zone]
+		zone.add(row.RouteStats)
+		zones[row.Zone] = zone
+	}
+
+	efficiency := &RouteEfficiency{
+		From:      q.From,
+		To:        q.To,
+		CourierID: q.CourierID,
+		Total:     newRouteEfficiencyGroup(0, "", total),
+		Couriers:  make([]RouteEfficiencyGroup, 0, len(couriers)),
+		Zones:     make([]RouteEfficiencyGroup, 0, len(zones)),
+	}
+	for courierID, stats := range couriers {
+		efficiency.Couriers = append(efficiency.Couriers, newRouteEfficiencyGroup(courierID, "", stats))
+	}
+	for zone, stats := range zones {
+		efficiency.Zones = append(efficiency.Zones, newRouteEfficiencyGroup(0, zone, stats))
+	}
+	sort.Slice(efficiency.Couriers, func(i, j int) bool { return efficiency.Couriers[i].CourierID < efficiency.Couriers[j].CourierID })
+	sort.Slice(efficiency.Zones, func(i, j int) bool { return efficiency.Zones[i].Zone < efficiency.Zones[j].Zone })
+
+	return efficiency
+}
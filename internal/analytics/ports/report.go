@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+	"io"
+
+	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
+)
+
+// ReportStore keeps report metadata and rendered artifacts
+type ReportStore interface {
+	// Save creates or replaces a report's metadata
+	Save(ctx context.Context, report *domain.Report) error
+
+	// Get retrieves a report's metadata, returning domain.ErrReportNotFound when missing
+	Get(ctx context.Context, id string) (*domain.Report, error)
+
+	// WriteArtifact stores a report's rendered output
+	WriteArtifact(ctx context.Context, id string, data []byte) error
+
+	// OpenArtifact opens a report's rendered output, returning
+	// domain.ErrReportNotFound when missing
+	OpenArtifact(ctx context.Context, id string) (io.ReadCloser, error)
+
+	// Delete removes a report's metadata and artifact
+	Delete(ctx context.Context, id string) error
+
+	// List returns the metadata of every stored report
+	List(ctx context.Context) ([]*domain.Report, error)
+}
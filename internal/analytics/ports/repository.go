@@ -2,15 +2,24 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
 )
 
-// MetricRepository defines the analytics metric persistence operations
+// MetricRepository defines the analytics metric persistence operations. Like
+// the rollup repositories below, reads only see the organization of the caller
+// whose claims are in ctx, see authctx.OrgScope, and writes record the
+// organization they are given, the default one when 0.
 type MetricRepository interface {
-	// Create stores a new metric
+	// Create stores a new metric, returning domain.ErrDuplicateMetric when one
+	// of the same type exists for its source event
 	Create(ctx context.Context, metric *domain.Metric) error
 
+	// CreateBatch stores metrics with multi-row inserts, skipping those whose
+	// source event already recorded a metric of the same type. IDs are not set.
+	CreateBatch(ctx context.Context, metrics []*domain.Metric) error
+
 	// GetByID retrieves a metric by ID
 	GetByID(ctx context.Context, id int) (*domain.Metric, error)
 
@@ -22,4 +31,62 @@ type MetricRepository interface {
 
 	// GetDeliveryStats retrieves aggregated delivery statistics
 	GetDeliveryStats(ctx context.Context, period string) (*domain.DeliveryStats, error)
+
+	// GetDeliveryBuckets counts delivery events per date_trunc bucket
+	GetDeliveryBuckets(ctx context.Context, q domain.DashboardQuery) ([]domain.DashboardPoint, error)
+
+	// GetOnTimeStats counts completed deliveries that met their scheduled date
+	GetOnTimeStats(ctx context.Context, q domain.DashboardQuery) (*domain.OnTimeStats, error)
+}
+
+// CourierStatsRepository maintains incrementally updated per-courier aggregates
+type CourierStatsRepository interface {
+	// RecordPickup stores when a courier picked up a delivery
+	RecordPickup(ctx context.Context, deliveryID, courierID int, at time.Time) error
+
+	// RecordOutcome adds a finished delivery to the courier's aggregates in
+	// their organization. It returns false when the delivery's outcome was
+	// already recorded.
+	RecordOutcome(ctx context.Context, orgID, deliveryID, courierID int, outcome domain.DeliveryOutcome, at time.Time) (bool, error)
+
+	// GetStats sums a courier's aggregates from the given day onwards
+	GetStats(ctx context.Context, courierID int, from time.Time) (*domain.CourierStats, error)
+
+	// ListStats sums every courier's aggregates for the days in [from, to), ordered by courier
+	ListStats(ctx context.Context, from, to time.Time) ([]domain.CourierReportRow, error)
+}
+
+// RouteStatsRepository keeps delivery tracks and daily per-courier, per-zone route rollups
+type RouteStatsRepository interface {
+	// RecordTrackPoint adds a recorded location to a delivery's track. The largest
+	// cumulative distance seen is kept, so points arriving out of order don't
+	// shorten the track.
+	RecordTrackPoint(ctx context.Context, deliveryID, courierID int, cumulativeKm float64) error
+
+	// RecordCompletion closes a delivery's track and adds it to its day's rollup,
+	// filling in the recorded points and distance. It returns false when the
+	// delivery's completion was already recorded.
+	RecordCompletion(ctx context.Context, route domain.RouteCompletion) (bool, error)
+
+	// ListRouteStats sums the rollups of the days a query touches per courier and zone
+	ListRouteStats(ctx context.Context, q domain.RouteEfficiencyQuery) ([]domain.RouteStatsRow, error)
+}
+
+// CustomerStatsRepository keeps a timeline per delivery and monthly per-customer rollups
+type CustomerStatsRepository interface {
+	// RecordCreated stores when a customer's delivery was created and counts it
+	// towards that month in its organization. It returns false when the
+	// creation was already recorded.
+	RecordCreated(ctx context.Context, orgID, deliveryID, customerID int, at time.Time, windowEnd *time.Time) (bool, error)
+
+	// RecordOutcome adds a finished delivery to its month's rollup, filling in
+	// when it was created and its window end from the recorded creation. It
+	// returns false when the delivery's outcome was already recorded.
+	RecordOutcome(ctx context.Context, outcome domain.CustomerOutcome) (bool, error)
+
+	// ListMonths returns the customer's rollups for the months a query touches, ordered by month
+	ListMonths(ctx context.Context, q domain.CustomerAnalyticsQuery) ([]domain.CustomerMonthRow, error)
+
+	// ListZones sums the customer's delivered deliveries per zone over the months a query touches
+	ListZones(ctx context.Context, q domain.CustomerAnalyticsQuery) ([]domain.CustomerZoneRow, error)
 }
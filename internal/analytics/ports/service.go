@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"io"
 
 	"github.com/Keneke-Einar/delivertrack/internal/analytics/domain"
 )
@@ -16,4 +17,25 @@ type AnalyticsService interface {
 
 	// GetMetricsByType retrieves metrics by type
 	GetMetricsByType(ctx context.Context, metricType domain.MetricType, limit int) ([]*domain.Metric, error)
+
+	// GetCourierPerformance summarizes a courier's deliveries over a day, week or month
+	GetCourierPerformance(ctx context.Context, courierID int, period string) (*domain.CourierPerformance, error)
+
+	// GetDashboard builds time-bucketed delivery series and totals
+	GetDashboard(ctx context.Context, q domain.DashboardQuery) (*domain.Dashboard, error)
+
+	// GetCustomerAnalytics summarizes a customer's deliveries per month
+	GetCustomerAnalytics(ctx context.Context, q domain.CustomerAnalyticsQuery) (*domain.CustomerAnalytics, error)
+
+	// GetRouteEfficiency compares completed deliveries' travelled distance with the straight line
+	GetRouteEfficiency(ctx context.Context, q domain.RouteEfficiencyQuery) (*domain.RouteEfficiency, error)
+
+	// GenerateReport starts rendering a report in the background and returns it pending
+	GenerateReport(ctx context.Context, req domain.ReportRequest) (*domain.Report, error)
+
+	// GetReport retrieves a report's status
+	GetReport(ctx context.Context, id string) (*domain.Report, error)
+
+	// OpenReportArtifact opens a ready report's rendered output
+	OpenReportArtifact(ctx context.Context, id string) (*domain.Report, io.ReadCloser, error)
 }
@@ -0,0 +1,95 @@
+package adapters
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+// deliveryCSVColumns are the columns a bulk upload may carry, named like the JSON fields
+var deliveryCSVColumns = map[string]bool{
+	"customer_id":       true,
+	"courier_id":        true,
+	"pickup_location":   true,
+	"delivery_location": true,
+	"notes":             true,
+	"scheduled_date":    true,
+	"scheduled_end":     true,
+}
+
+// parseDeliveryCSV reads create-delivery rows from a CSV file whose header row
+// names the columns. Row numbers in errors match the bulk result indexes.
+func parseDeliveryCSV(r io.Reader) ([]ports.CreateDeliveryRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !deliveryCSVColumns[name] {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"pickup_location", "delivery_location"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var rows []ports.CreateDeliveryRequest
+	for index := 0; ; index++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := ports.CreateDeliveryRequest{
+			PickupLocation:   cell("pickup_location"),
+			DeliveryLocation: cell("delivery_location"),
+			Notes:            cell("notes"),
+		}
+		if v := cell("customer_id"); v != "" {
+			if row.CustomerID, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("row %d: invalid customer_id %q", index, v)
+			}
+		}
+		if v := cell("courier_id"); v != "" {
+			courierID, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid courier_id %q", index, v)
+			}
+			row.CourierID = &courierID
+		}
+		if v := cell("scheduled_date"); v != "" {
+			row.ScheduledDate = &v
+		}
+		if v := cell("scheduled_end"); v != "" {
+			row.ScheduledEnd = &v
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,117 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+)
+
+// CourierHTTPHandler handles HTTP requests for courier availability
+type CourierHTTPHandler struct {
+	service ports.CourierService
+}
+
+// NewCourierHTTPHandler creates a new courier HTTP handler
+func NewCourierHTTPHandler(service ports.CourierService) *CourierHTTPHandler {
+	return &CourierHTTPHandler{
+		service: service,
+	}
+}
+
+// UpdateCourierStatusRequest represents the request payload for a courier's availability
+type UpdateCourierStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// UpdateMyStatus handles PUT /couriers/me/status
+func (h *CourierHTTPHandler) UpdateMyStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req UpdateCourierStatusRequest
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	if userCtx.Role != "courier" {
+		httputil.SendErrorResponse(w, "Only couriers can set their status", http.StatusForbidden)
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "update_courier_status_http")
+
+	courier, err := h.service.UpdateCourierStatus(ctx, ports.UpdateCourierStatusRequest{
+		Status: req.Status,
+		AuthContext: ports.AuthContext{
+			Role:           userCtx.Role,
+			UserCustomerID: userCtx.CustomerID,
+			UserCourierID:  userCtx.CourierID,
+		},
+	})
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), courierErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(courier)
+}
+
+// ListCouriers handles GET /couriers?status=available
+func (h *CourierHTTPHandler) ListCouriers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "list_couriers_http")
+
+	couriers, err := h.service.ListCouriers(ctx, ports.ListCouriersRequest{
+		Status: r.URL.Query().Get("status"),
+		AuthContext: ports.AuthContext{
+			Role:           userCtx.Role,
+			UserCustomerID: userCtx.CustomerID,
+			UserCourierID:  userCtx.CourierID,
+		},
+	})
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), courierErrorStatus(err))
+		return
+	}
+	if couriers == nil {
+		couriers = []*domain.Courier{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(couriers)
+}
+
+// courierErrorStatus maps courier errors to HTTP status codes
+func courierErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrCourierNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidCourierStatus):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,118 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+)
+
+// PostgresCourierRepository implements the CourierRepository interface using PostgreSQL
+type PostgresCourierRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCourierRepository creates a new PostgreSQL courier repository
+func NewPostgresCourierRepository(db *sql.DB) *PostgresCourierRepository {
+	return &PostgresCourierRepository{db: db}
+}
+
+// GetByID retrieves a courier by their ID
+func (r *PostgresCourierRepository) GetByID(ctx context.Context, id int) (*domain.Courier, error) {
+	query := `
+		SELECT id, org_id, name, vehicle_type, phone, status, status_updated_at
+		FROM couriers
+		WHERE id = $1 AND ($2 = 0 OR org_id = $2)
+	`
+
+	var c domain.Courier
+	err := r.db.QueryRowContext(ctx, query, id, orgScope(ctx)).Scan(
+		&c.ID,
+		&c.OrgID,
+		&c.Name,
+		&c.VehicleType,
+		&c.Phone,
+		&c.Status,
+		&c.StatusUpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrCourierNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// List retrieves couriers, optionally only those with the given status
+func (r *PostgresCourierRepository) List(ctx context.Context, status string) ([]*domain.Courier, error) {
+	var rows *sql.Rows
+	var err error
+
+	if status != "" {
+		rows, err = r.db.QueryContext(ctx, `
+			SELECT id, org_id, name, vehicle_type, phone, status, status_updated_at
+			FROM couriers
+			WHERE status = $1 AND ($2 = 0 OR org_id = $2)
+			ORDER BY id
+		`, status, orgScope(ctx))
+	} else {
+		rows, err = r.db.QueryContext(ctx, `
+			SELECT id, org_id, name, vehicle_type, phone, status, status_updated_at
+			FROM couriers
+			WHERE $1 = 0 OR org_id = $1
+			ORDER BY id
+		`, orgScope(ctx))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var couriers []*domain.Courier
+	for rows.Next() {
+		var c domain.Courier
+		if err := rows.Scan(&c.ID, &c.OrgID, &c.Name, &c.VehicleType, &c.Phone, &c.Status, &c.StatusUpdatedAt); err != nil {
+			return nil, err
+		}
+		couriers = append(couriers, &c)
+	}
+
+	return couriers, rows.Err()
+}
+
+// UpdateStatus sets a courier's availability and when it changed
+func (r *PostgresCourierRepository) UpdateStatus(ctx context.Context, id int, status string, at time.Time) error {
+	query := `
+		UPDATE couriers
+		SET status = $1, status_updated_at = $2
+		WHERE id = $3
+		RETURNING id
+	`
+
+	var returnedID int
+	err := r.db.QueryRowContext(ctx, query, status, at, id).Scan(&returnedID)
+	if err == sql.ErrNoRows {
+		return domain.ErrCourierNotFound
+	}
+	return err
+}
+
+// ReleaseIfIdle makes a busy courier available again once none of their
+// deliveries are assigned or in transit. Couriers who went offline stay offline.
+func (r *PostgresCourierRepository) ReleaseIfIdle(ctx context.Context, id int, at time.Time) error {
+	query := `
+		UPDATE couriers
+		SET status = $1, status_updated_at = $2
+		WHERE id = $3 AND status = $4
+		  AND NOT EXISTS (
+		      SELECT 1 FROM deliveries WHERE courier_id = $3 AND status IN ($5, $6)
+		  )
+	`
+
+	_, err := r.db.ExecContext(ctx, query, domain.CourierAvailable, at, id, domain.CourierBusy,
+		domain.StatusAssigned, domain.StatusInTransit)
+	return err
+}
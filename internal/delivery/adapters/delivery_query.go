@@ -0,0 +1,136 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/lib/pq"
+)
+
+// orgScope returns the organization the caller in ctx is limited to, or 0
+// when they see every organization
+func orgScope(ctx context.Context) int {
+	orgID, _ := authctx.OrgScope(ctx)
+	return orgID
+}
+
+// deliveryColumns are the columns scanDeliveries reads, in order
+const deliveryColumns = `id, tracking_number, customer_id, courier_id, status, pickup_location, delivery_location,
+	scheduled_date, scheduled_end, delivered_date, late, notes, cancel_reason, cancel_reason_code, cancelled_at, created_at, updated_at, version,
+	pickup_line1, pickup_city, pickup_postal_code, pickup_country, pickup_latitude, pickup_longitude,
+	delivery_line1, delivery_city, delivery_postal_code, delivery_country, delivery_latitude, delivery_longitude,
+	quoted_price_cents, quoted_currency, org_id, confirmation_code`
+
+// listSortColumns whitelists the columns deliveries can be listed by
+var listSortColumns = map[string]string{
+	"":                       "created_at",
+	domain.SortCreatedAt:     "created_at",
+	domain.SortUpdatedAt:     "updated_at",
+	domain.SortScheduledDate: "scheduled_date",
+}
+
+// deliveryQuery composes a query over deliveries from optional filters,
+// numbering placeholders as conditions are added so every value stays a
+// parameter
+type deliveryQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// where adds a condition; each ? in it stands for arg
+func (q *deliveryQuery) where(condition string, arg interface{}) {
+	q.conditions = append(q.conditions, strings.ReplaceAll(condition, "?", q.param(arg)))
+}
+
+// param adds arg and returns its placeholder
+func (q *deliveryQuery) param(arg interface{}) string {
+	q.args = append(q.args, arg)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// whereClause returns the conditions ANDed into a WHERE clause, or nothing
+// without any
+func (q *deliveryQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.conditions, " AND ") + " "
+}
+
+// filterQuery adds a condition for each filter that is set; a new filter
+// field needs only a case here
+func filterQuery(filter ports.DeliveryFilter) *deliveryQuery {
+	q := &deliveryQuery{}
+	if filter.OrgID > 0 {
+		q.where("org_id = ?", filter.OrgID)
+	}
+	if len(filter.Statuses) > 0 {
+		q.where("status = ANY(?)", pq.Array(filter.Statuses))
+	}
+	if filter.CustomerID > 0 {
+		q.where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.CourierID > 0 {
+		q.where("courier_id = ?", filter.CourierID)
+	}
+	if filter.Late != nil {
+		q.where("late = ?", *filter.Late)
+	}
+	if filter.TrackingNumber != "" {
+		q.where("tracking_number = ?", filter.TrackingNumber)
+	}
+	if filter.From != nil {
+		q.where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q.where("created_at < ?", *filter.To)
+	}
+	if filter.DeliveredFrom != nil {
+		q.where("delivered_date >= ?", *filter.DeliveredFrom)
+	}
+	if filter.DeliveredTo != nil {
+		q.where("delivered_date < ?", *filter.DeliveredTo)
+	}
+	return q
+}
+
+// listQuery counts the deliveries a filter selects and reads its page of them
+type listQuery struct {
+	count     string
+	countArgs []interface{}
+	list      string
+	listArgs  []interface{}
+}
+
+// newListQuery builds the queries listing the deliveries a filter selects
+func newListQuery(filter ports.DeliveryFilter) (listQuery, error) {
+	column, ok := listSortColumns[filter.Order.Field]
+	if !ok {
+		return listQuery{}, domain.ErrInvalidSort
+	}
+
+	q := filterQuery(filter)
+	query := listQuery{
+		count:     "SELECT COUNT(*) FROM deliveries " + q.whereClause(),
+		countArgs: q.args,
+	}
+
+	direction := "DESC"
+	if filter.Order.Ascending {
+		direction = "ASC"
+	}
+	query.list = fmt.Sprintf("SELECT %s FROM deliveries %sORDER BY %s %s NULLS LAST, id %s",
+		deliveryColumns, q.whereClause(), column, direction, direction)
+	if filter.Limit > 0 {
+		query.list += " LIMIT " + q.param(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query.list += " OFFSET " + q.param(filter.Offset)
+	}
+	query.listArgs = q.args
+	return query, nil
+}
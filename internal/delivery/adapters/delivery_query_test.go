@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+func TestNewListQuery(t *testing.T) {
+	late := true
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    ports.DeliveryFilter
+		where     string
+		order     string
+		paging    string
+		countArgs int
+		listArgs  int
+	}{
+		{
+			name:  "no filters",
+			order: "ORDER BY created_at DESC NULLS LAST, id DESC",
+		},
+		{
+			name: "every filter",
+			filter: ports.DeliveryFilter{
+				Statuses:       []string{domain.StatusAssigned, domain.StatusInTransit},
+				CustomerID:     1,
+				CourierID:      7,
+				Late:           &late,
+				TrackingNumber: "DT-1",
+				From:           &from,
+				To:             &from,
+			},
+			where:     "WHERE status = ANY($1) AND customer_id = $2 AND courier_id = $3 AND late = $4 AND tracking_number = $5 AND created_at >= $6 AND created_at < $7 ",
+			order:     "ORDER BY created_at DESC NULLS LAST, id DESC",
+			countArgs: 7,
+			listArgs:  7,
+		},
+		{
+			name:      "paged by scheduled date",
+			filter:    ports.DeliveryFilter{CustomerID: 1, Order: domain.ListOrder{Field: domain.SortScheduledDate, Ascending: true}, Limit: 20, Offset: 40},
+			where:     "WHERE customer_id = $1 ",
+			order:     "ORDER BY scheduled_date ASC NULLS LAST, id ASC",
+			paging:    " LIMIT $2 OFFSET $3",
+			countArgs: 1,
+			listArgs:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := newListQuery(tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := "SELECT COUNT(*) FROM deliveries " + tt.where; query.count != want {
+				t.Errorf("count query:\n got %q\nwant %q", query.count, want)
+			}
+			if want := "FROM deliveries " + tt.where + tt.order + tt.paging; !strings.HasSuffix(query.list, want) {
+				t.Errorf("list query:\n got %q\nwant suffix %q", query.list, want)
+			}
+			if len(query.countArgs) != tt.countArgs || len(query.listArgs) != tt.listArgs {
+				t.Errorf("expected %d count and %d list args, got %d and %d", tt.countArgs, tt.listArgs, len(query.countArgs), len(query.listArgs))
+			}
+		})
+	}
+
+	// Sort fields are never interpolated from input
+	if _, err := newListQuery(ports.DeliveryFilter{Order: domain.ListOrder{Field: "id; DROP TABLE deliveries"}}); !errors.Is(err, domain.ErrInvalidSort) {
+		t.Errorf("expected ErrInvalidSort, got %v", err)
+	}
+}
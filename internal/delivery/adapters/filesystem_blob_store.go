@@ -0,0 +1,77 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemBlobStore implements BlobStore on the local filesystem
+type FilesystemBlobStore struct {
+	root string
+}
+
+// NewFilesystemBlobStore creates a blob store rooted at dir, creating it if needed
+func NewFilesystemBlobStore(dir string) (*FilesystemBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &FilesystemBlobStore{root: dir}, nil
+}
+
+// path resolves a key inside the root, rejecting keys that escape it
+func (s *FilesystemBlobStore) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put stores data under key, replacing any existing object
+func (s *FilesystemBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Write to a temp file first so readers never see a partial object
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves the data stored under key
+func (s *FilesystemBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+// Delete removes the object stored under key
+func (s *FilesystemBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
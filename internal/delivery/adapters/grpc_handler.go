@@ -2,14 +2,19 @@ package adapters
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
-	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
 	"github.com/Keneke-Einar/delivertrack/proto/common"
 	deliveryProto "github.com/Keneke-Einar/delivertrack/proto/delivery"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -33,25 +38,45 @@ func (h *GRPCHandler) CreateDelivery(ctx context.Context, req *deliveryProto.Cre
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid customer_id: %v", err)
 	}
+	if locationMissing(req.PickupLocation) || locationMissing(req.DeliveryLocation) {
+		return nil, status.Error(codes.InvalidArgument, "pickup_location and delivery_location addresses are required")
+	}
+
+	// Customers can only create their own deliveries
+	auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Role == "customer" && auth.UserCustomerID != nil && *auth.UserCustomerID != customerID {
+		return nil, status.Error(codes.PermissionDenied, "customers can only create their own deliveries")
+	}
 
 	// Map proto request to service request
 	serviceReq := ports.CreateDeliveryRequest{
 		CustomerID:       customerID,
-		PickupLocation:   req.PickupLocation.Address, // Assuming address is used
+		PickupLocation:   req.PickupLocation.Address,
 		DeliveryLocation: req.DeliveryLocation.Address,
+		PickupAddress:    requestAddress(req.PickupLocation),
+		DeliveryAddress:  requestAddress(req.DeliveryLocation),
 		Notes:            req.SpecialInstructions,
 	}
 
 	// Call service
 	delivery, err := h.service.CreateDelivery(ctx, serviceReq)
 	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidDeliveryData), errors.Is(err, domain.ErrInvalidScheduleWindow):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid delivery: %v", err)
+		case errors.Is(err, domain.ErrCourierUnavailable):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "failed to create delivery: %v", err)
 	}
 
 	// Map response
 	resp := &deliveryProto.CreateDeliveryResponse{
 		DeliveryId:     strconv.Itoa(delivery.ID),
-		TrackingNumber: "", // TODO: generate tracking number
+		TrackingNumber: delivery.TrackingNumber,
 		CreatedAt:      delivery.CreatedAt.Unix(),
 	}
 
@@ -65,33 +90,46 @@ func (h *GRPCHandler) GetDelivery(ctx context.Context, req *deliveryProto.GetDel
 		return nil, status.Errorf(codes.InvalidArgument, "invalid delivery_id: %v", err)
 	}
 
-	serviceReq := ports.GetDeliveryRequest{
-		ID: deliveryID,
+	auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Extract user claims from context
-	if claims, ok := ctx.Value(grpcinterceptors.UserClaimsContextKey).(*domain.Claims); ok {
-		serviceReq.Role = claims.Role
-		serviceReq.UserCustomerID = claims.CustomerID
-		serviceReq.UserCourierID = claims.CourierID
+	serviceReq := ports.GetDeliveryDetailsRequest{
+		ID:              deliveryID,
+		IncludeLocation: req.IncludeLocation,
+		IncludeETA:      req.IncludeEta,
+		AuthContext:     auth,
 	}
 
-	d, err := h.service.GetDelivery(ctx, serviceReq)
+	// Live tracking data is looked up on the caller's behalf
+	details, err := h.service.GetDeliveryDetails(forwardAuthorization(ctx), serviceReq)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "delivery not found: %v", err)
+		switch {
+		case errors.Is(err, domain.ErrDeliveryNotFound):
+			return nil, status.Error(codes.NotFound, "delivery not found")
+		case errors.Is(err, domain.ErrUnauthorized):
+			return nil, status.Error(codes.PermissionDenied, "not allowed to access this delivery")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get delivery: %v", err)
 	}
 
 	resp := &deliveryProto.GetDeliveryResponse{
-		Delivery: &deliveryProto.Delivery{
-			DeliveryId:       strconv.Itoa(d.ID),
-			CustomerId:       strconv.Itoa(d.CustomerID),
-			DriverId:         strconv.Itoa(*d.CourierID),
-			PickupLocation:   &common.Location{Address: d.PickupLocation},
-			DeliveryLocation: &common.Location{Address: d.DeliveryLocation},
-			Status:           deliveryProto.DeliveryStatus(deliveryProto.DeliveryStatus_value[d.Status]),
-			CreatedAt:        d.CreatedAt.Unix(),
-			UpdatedAt:        d.UpdatedAt.Unix(),
-		},
+		Delivery: protoDelivery(details.Delivery),
+		Partial:  details.Partial,
+	}
+	if loc := details.CurrentLocation; loc != nil {
+		resp.CurrentLocation = &deliveryProto.TrackedLocation{
+			Location:  &common.Location{Latitude: loc.Latitude, Longitude: loc.Longitude},
+			Timestamp: loc.Timestamp.Unix(),
+		}
+	}
+	if eta := details.ETA; eta != nil {
+		resp.Eta = &deliveryProto.DeliveryETA{
+			EtaSeconds:       eta.Seconds,
+			DistanceKm:       eta.DistanceKm,
+			EstimatedArrival: eta.EstimatedArrival.Unix(),
+		}
 	}
 
 	return resp, nil
@@ -104,53 +142,114 @@ func (h *GRPCHandler) UpdateDeliveryStatus(ctx context.Context, req *deliveryPro
 		return nil, status.Errorf(codes.InvalidArgument, "invalid delivery_id: %v", err)
 	}
 
+	if req.Status == deliveryProto.DeliveryStatus_DELIVERY_STATUS_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "status is required")
+	}
+
+	auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	serviceReq := ports.UpdateDeliveryStatusRequest{
-		ID:     deliveryID,
-		Status: req.Status.String(), // Convert enum to string
-		Notes:  req.Notes,
+		ID:              deliveryID,
+		Status:          domainStatus(req.Status),
+		Notes:           req.Notes,
+		ExpectedVersion: int(req.ExpectedVersion),
+		AuthContext:     auth,
 	}
 
-	err = h.service.UpdateDeliveryStatus(ctx, serviceReq)
+	d, err := h.service.UpdateDeliveryStatus(ctx, serviceReq)
 	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrConflict):
+			return nil, status.Error(codes.Aborted, err.Error())
+		case errors.Is(err, domain.ErrDeliveryNotFound):
+			return nil, status.Error(codes.NotFound, "delivery not found")
+		case errors.Is(err, domain.ErrUnauthorized):
+			return nil, status.Error(codes.PermissionDenied, "not allowed to update this delivery")
+		case errors.Is(err, domain.ErrInvalidStatus):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid status: %v", err)
+		case errors.Is(err, domain.ErrCancelByStatus), errors.Is(err, domain.ErrDeliverByStatus):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, domain.ErrCourierUnavailable), errors.Is(err, domain.ErrInvalidTransition):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "failed to update delivery status: %v", err)
 	}
 
-	return &deliveryProto.UpdateDeliveryStatusResponse{}, nil
+	return &deliveryProto.UpdateDeliveryStatusResponse{
+		Success:   true,
+		UpdatedAt: d.UpdatedAt.Unix(),
+		Version:   int32(d.Version),
+	}, nil
 }
 
 // ListDeliveries implements delivery.DeliveryServiceServer
 func (h *GRPCHandler) ListDeliveries(ctx context.Context, req *deliveryProto.ListDeliveriesRequest) (*deliveryProto.ListDeliveriesResponse, error) {
-	customerID, err := strconv.Atoi(req.CustomerId)
+	// An empty customer_id lists every customer the caller may see
+	var customerID int
+	if req.CustomerId != "" {
+		var err error
+		customerID, err = strconv.Atoi(req.CustomerId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid customer_id: %v", err)
+		}
+	}
+
+	// An empty driver_id lists deliveries regardless of courier
+	var courierID int
+	if req.DriverId != "" {
+		var err error
+		courierID, err = strconv.Atoi(req.DriverId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid driver_id: %v", err)
+		}
+	}
+
+	// Without pagination every delivery is listed on one page
+	page, pageSize := req.GetPagination().GetPage(), req.GetPagination().GetPageSize()
+	if page < 0 || pageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page and page_size must not be negative")
+	}
+	if pageSize > 0 {
+		page = max(page, 1)
+	}
+
+	auth, err := callerAuth(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid customer_id: %v", err)
+		return nil, err
 	}
 
 	serviceReq := ports.ListDeliveriesRequest{
-		Status:     req.Status.String(),
-		CustomerID: customerID,
+		Statuses:    domainStatuses(req.Status, req.Statuses),
+		CustomerID:  customerID,
+		CourierID:   courierID,
+		Sort:        req.Sort,
+		Order:       req.Order,
+		Limit:       int(pageSize),
+		Offset:      int(max(page-1, 0) * pageSize),
+		AuthContext: auth,
 	}
 
-	deliveries, err := h.service.ListDeliveries(ctx, serviceReq)
+	deliveries, total, err := h.service.ListDeliveries(ctx, serviceReq)
+	if errors.Is(err, domain.ErrInvalidSort) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid sort %q or order %q", req.Sort, req.Order)
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list deliveries: %v", err)
 	}
 
 	var deliveryProtos []*deliveryProto.Delivery
 	for _, d := range deliveries {
-		deliveryProtos = append(deliveryProtos, &deliveryProto.Delivery{
-			DeliveryId:       strconv.Itoa(d.ID),
-			CustomerId:       strconv.Itoa(d.CustomerID),
-			DriverId:         strconv.Itoa(*d.CourierID),
-			PickupLocation:   &common.Location{Address: d.PickupLocation},
-			DeliveryLocation: &common.Location{Address: d.DeliveryLocation},
-			Status:           deliveryProto.DeliveryStatus(deliveryProto.DeliveryStatus_value[d.Status]),
-			CreatedAt:        d.CreatedAt.Unix(),
-			UpdatedAt:        d.UpdatedAt.Unix(),
-		})
+		deliveryProtos = append(deliveryProtos, protoDelivery(d))
 	}
 
 	return &deliveryProto.ListDeliveriesResponse{
 		Deliveries: deliveryProtos,
+		TotalCount: int32(total),
+		Page:       page,
+		PageSize:   pageSize,
 	}, nil
 }
 
@@ -162,20 +261,139 @@ func (h *GRPCHandler) AssignDriver(ctx context.Context, req *deliveryProto.Assig
 
 // CancelDelivery implements delivery.DeliveryServiceServer
 func (h *GRPCHandler) CancelDelivery(ctx context.Context, req *deliveryProto.CancelDeliveryRequest) (*deliveryProto.CancelDeliveryResponse, error) {
-	// TODO: Implement when service supports it
-	return nil, status.Errorf(codes.Unimplemented, "method CancelDelivery not implemented")
+	deliveryID, err := strconv.Atoi(req.DeliveryId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid delivery_id: %v", err)
+	}
+
+	auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The proto message has no reason code yet, so gRPC cancellations carry only the reason
+	serviceReq := ports.CancelDeliveryRequest{
+		ID:          deliveryID,
+		Reason:      req.Reason,
+		AuthContext: auth,
+	}
+
+	delivery, err := h.service.CancelDelivery(ctx, serviceReq)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDeliveryNotFound):
+			return nil, status.Error(codes.NotFound, "delivery not found")
+		case errors.Is(err, domain.ErrUnauthorized):
+			return nil, status.Error(codes.PermissionDenied, "not allowed to cancel this delivery")
+		case errors.Is(err, domain.ErrInvalidCancellation):
+			return nil, status.Error(codes.InvalidArgument, "a cancellation reason is required")
+		case errors.Is(err, domain.ErrNotCancellable):
+			return nil, status.Error(codes.FailedPrecondition, "delivery can no longer be cancelled")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to cancel delivery: %v", err)
+	}
+
+	return &deliveryProto.CancelDeliveryResponse{
+		Success:     true,
+		CancelledAt: delivery.CancelledAt.Unix(),
+	}, nil
 }
 
 // GetDriverDeliveries implements delivery.DeliveryServiceServer
+// A non-zero date, in Unix seconds, keeps only deliveries delivered on that
+// UTC day.
 func (h *GRPCHandler) GetDriverDeliveries(ctx context.Context, req *deliveryProto.GetDriverDeliveriesRequest) (*deliveryProto.GetDriverDeliveriesResponse, error) {
-	// TODO: Implement when service supports it
-	return nil, status.Errorf(codes.Unimplemented, "method GetDriverDeliveries not implemented")
+	courierID, err := strconv.Atoi(req.DriverId)
+	if err != nil || courierID <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid driver_id: %q", req.DriverId)
+	}
+
+	auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listReq := ports.ListDeliveriesRequest{
+		Statuses:    domainStatuses(req.Status, nil),
+		CourierID:   courierID,
+		AuthContext: auth,
+	}
+	if req.Date != 0 {
+		dayStart := time.Unix(req.Date, 0).UTC().Truncate(24 * time.Hour)
+		dayEnd := dayStart.Add(24 * time.Hour)
+		listReq.DeliveredFrom, listReq.DeliveredTo = &dayStart, &dayEnd
+	}
+
+	deliveries, _, err := h.service.ListDeliveries(ctx, listReq)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list driver deliveries: %v", err)
+	}
+
+	resp := &deliveryProto.GetDriverDeliveriesResponse{}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, protoDelivery(d))
+	}
+	resp.TotalCount = int32(len(resp.Deliveries))
+	return resp, nil
 }
 
 // OptimizeRoute implements delivery.DeliveryServiceServer
 func (h *GRPCHandler) OptimizeRoute(ctx context.Context, req *deliveryProto.OptimizeRouteRequest) (*deliveryProto.OptimizeRouteResponse, error) {
-	// TODO: Implement when service supports it
-	return nil, status.Errorf(codes.Unimplemented, "method OptimizeRoute not implemented")
+	courierID, err := strconv.Atoi(req.DriverId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid driver_id: %v", err)
+	}
+
+	auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceReq := ports.OptimizeRouteRequest{
+		CourierID:   courierID,
+		AuthContext: auth,
+	}
+	for _, id := range req.DeliveryIds {
+		deliveryID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid delivery_id %q: %v", id, err)
+		}
+		serviceReq.DeliveryIDs = append(serviceReq.DeliveryIDs, deliveryID)
+	}
+	if loc := req.StartLocation; loc != nil && (loc.Latitude != 0 || loc.Longitude != 0) {
+		serviceReq.Start = &domain.CourierPosition{Latitude: loc.Latitude, Longitude: loc.Longitude}
+	}
+
+	// The courier's location is looked up in the tracking service on the caller's behalf
+	plan, err := h.service.OptimizeRoute(forwardAuthorization(ctx), serviceReq)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized):
+			return nil, status.Error(codes.PermissionDenied, "not allowed to plan this courier's route")
+		case errors.Is(err, domain.ErrCourierLocationUnknown):
+			return nil, status.Error(codes.FailedPrecondition, "courier location unknown; pass start_location")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to optimize route: %v", err)
+	}
+
+	resp := &deliveryProto.OptimizeRouteResponse{
+		TotalDistance:     plan.TotalDistance,
+		EstimatedDuration: plan.EstimatedDurationSeconds,
+	}
+	for _, stop := range plan.Stops {
+		resp.Route = append(resp.Route, &deliveryProto.RouteStop{
+			DeliveryId: strconv.Itoa(stop.DeliveryID),
+			Sequence:   int32(stop.Sequence),
+			Location: &common.Location{
+				Latitude:  stop.Latitude,
+				Longitude: stop.Longitude,
+			},
+			EstimatedArrival:     stop.EstimatedArrival.Unix(),
+			DistanceFromPrevious: stop.DistanceFromPrevious,
+		})
+	}
+
+	return resp, nil
 }
 
 // ConfirmDelivery implements delivery.DeliveryServiceServer
@@ -183,3 +401,140 @@ func (h *GRPCHandler) ConfirmDelivery(ctx context.Context, req *deliveryProto.Co
 	// TODO: Implement when service supports it
 	return nil, status.Errorf(codes.Unimplemented, "method ConfirmDelivery not implemented")
 }
+
+// callerAuth returns the authorization fields of the caller the auth
+// interceptor authenticated
+func callerAuth(ctx context.Context) (ports.AuthContext, error) {
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return ports.AuthContext{}, status.Error(codes.Unauthenticated, "missing user claims")
+	}
+	return ports.AuthContext{
+		Role:           claims.Role,
+		UserCustomerID: claims.CustomerID,
+		UserCourierID:  claims.CourierID,
+	}, nil
+}
+
+// forwardAuthorization carries the caller's authorization header into ctx,
+// so calls to other services are made on their behalf
+func forwardAuthorization(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if authHeaders := md.Get(grpcinterceptors.AuthorizationMetadataKey); len(authHeaders) > 0 {
+			return authctx.WithAuthorization(ctx, authHeaders[0])
+		}
+	}
+	return ctx
+}
+
+// locationMissing reports whether a request location has neither an address
+// nor coordinates
+func locationMissing(l *common.Location) bool {
+	return l.GetAddress() == "" && l.GetLatitude() == 0 && l.GetLongitude() == 0
+}
+
+// requestAddress maps a request location to a structured address. A location
+// with only its address text is left to the legacy free-text path, which
+// also understands "(lng,lat)" text. Coordinates count as given unless both
+// are zero, as proto3 cannot tell unset from zero.
+func requestAddress(l *common.Location) *ports.Address {
+	hasCoordinates := l.GetLatitude() != 0 || l.GetLongitude() != 0
+	if !hasCoordinates && l.GetCity() == "" && l.GetPostalCode() == "" && l.GetCountry() == "" {
+		return nil
+	}
+
+	address := &ports.Address{
+		Line1:      l.GetAddress(),
+		City:       l.GetCity(),
+		PostalCode: l.GetPostalCode(),
+		Country:    l.GetCountry(),
+	}
+	if hasCoordinates {
+		lat, lng := l.GetLatitude(), l.GetLongitude()
+		address.Latitude, address.Longitude = &lat, &lng
+	}
+	return address
+}
+
+// protoLocation maps one end of a delivery to its proto form. Coordinates
+// stay zero while unknown.
+func protoLocation(address domain.Address, location string, coords *domain.Coordinates) *common.Location {
+	l := &common.Location{
+		Address:    address.Line1,
+		City:       address.City,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+	if l.Address == "" {
+		l.Address = location
+	}
+	if coords != nil {
+		l.Latitude, l.Longitude = coords.Latitude, coords.Longitude
+	}
+	return l
+}
+
+// protoDelivery maps a delivery to its proto form
+func protoDelivery(d *domain.Delivery) *deliveryProto.Delivery {
+	p := &deliveryProto.Delivery{
+		DeliveryId:       strconv.Itoa(d.ID),
+		CustomerId:       strconv.Itoa(d.CustomerID),
+		DriverId:         driverID(d.CourierID),
+		TrackingNumber:   d.TrackingNumber,
+		PickupLocation:   pickupLocation(d),
+		DeliveryLocation: deliveryLocation(d),
+		Status:           protoStatus(d.Status),
+		CreatedAt:        d.CreatedAt.Unix(),
+		UpdatedAt:        d.UpdatedAt.Unix(),
+		Version:          int32(d.Version),
+	}
+	if d.DeliveredDate != nil {
+		p.ActualDelivery = d.DeliveredDate.Unix()
+	}
+	return p
+}
+
+// pickupLocation maps a delivery's pickup to its proto form
+func pickupLocation(d *domain.Delivery) *common.Location {
+	coords, _ := d.PickupCoordinates()
+	return protoLocation(d.PickupAddress, d.PickupLocation, coords)
+}
+
+// deliveryLocation maps a delivery's drop-off to its proto form
+func deliveryLocation(d *domain.Delivery) *common.Location {
+	coords, _ := d.DeliveryCoordinates()
+	return protoLocation(d.DeliveryAddress, d.DeliveryLocation, coords)
+}
+
+// driverID formats a delivery's courier, empty while unassigned
+func driverID(courierID *int) string {
+	if courierID == nil {
+		return ""
+	}
+	return strconv.Itoa(*courierID)
+}
+
+// protoStatus maps a domain status such as "in_transit" to its proto enum
+func protoStatus(s string) deliveryProto.DeliveryStatus {
+	return deliveryProto.DeliveryStatus(deliveryProto.DeliveryStatus_value["DELIVERY_STATUS_"+strings.ToUpper(s)])
+}
+
+// domainStatus maps a proto status to its domain form, empty when unspecified
+func domainStatus(s deliveryProto.DeliveryStatus) string {
+	if s == deliveryProto.DeliveryStatus_DELIVERY_STATUS_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(s.String(), "DELIVERY_STATUS_"))
+}
+
+// domainStatuses combines a request's single status and status list into the
+// statuses to filter on, none meaning all
+func domainStatuses(single deliveryProto.DeliveryStatus, list []deliveryProto.DeliveryStatus) []string {
+	var statuses []string
+	for _, s := range append([]deliveryProto.DeliveryStatus{single}, list...) {
+		if name := domainStatus(s); name != "" {
+			statuses = append(statuses, name)
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,480 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/app"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/proto/common"
+	deliveryProto "github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tokens the contract tests' callers authenticate with
+const (
+	adminToken         = "admin-token"
+	customerToken      = "customer-token"       // customer 1
+	otherCustomerToken = "other-customer-token" // customer 2
+	courierToken       = "courier-token"        // courier 7
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// newDeliveryClient serves the delivery gRPC handler over the production
+// interceptor chain, backed by the real service and an in-memory repository
+func newDeliveryClient(t *testing.T) (deliveryProto.DeliveryServiceClient, *memory.DeliveryRepository) {
+	t.Helper()
+
+	repo := memory.NewDeliveryRepository()
+	service := app.NewDeliveryService(repo, nil, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+
+	authService := testsupport.NewAuthService()
+	authService.AddToken(adminToken, &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin})
+	authService.AddToken(customerToken, &authDomain.Claims{UserID: 2, Role: authDomain.RoleCustomer, CustomerID: intPtr(1)})
+	authService.AddToken(otherCustomerToken, &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer, CustomerID: intPtr(2)})
+	authService.AddToken(courierToken, &authDomain.Claims{UserID: 4, Role: authDomain.RoleCourier, CourierID: intPtr(7)})
+
+	conn := testsupport.DialGRPCServer(t, authService, func(s *grpc.Server) {
+		deliveryProto.RegisterDeliveryServiceServer(s, NewGRPCHandler(service))
+	})
+	return deliveryProto.NewDeliveryServiceClient(conn), repo
+}
+
+func as(token string) context.Context {
+	return testsupport.WithToken(context.Background(), token)
+}
+
+func expectCode(t *testing.T, err error, expected codes.Code) {
+	t.Helper()
+	if status.Code(err) != expected {
+		t.Fatalf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestDeliveryGRPC_RequiresAuthentication(t *testing.T) {
+	client, _ := newDeliveryClient(t)
+	req := &deliveryProto.GetDeliveryRequest{DeliveryId: "1"}
+
+	_, err := client.GetDelivery(context.Background(), req)
+	expectCode(t, err, codes.Unauthenticated)
+
+	_, err = client.GetDelivery(as("unknown-token"), req)
+	expectCode(t, err, codes.Unauthenticated)
+}
+
+func TestDeliveryGRPC_CreateDelivery(t *testing.T) {
+	request := func(customerID string) *deliveryProto.CreateDeliveryRequest {
+		return &deliveryProto.CreateDeliveryRequest{
+			CustomerId:          customerID,
+			PickupLocation:      &common.Location{Address: "(-73.98,40.75)"},
+			DeliveryLocation:    &common.Location{Address: "(-73.99,40.76)"},
+			SpecialInstructions: "leave at door",
+		}
+	}
+
+	t.Run("creates the caller's delivery", func(t *testing.T) {
+		client, repo := newDeliveryClient(t)
+
+		resp, err := client.CreateDelivery(as(customerToken), request("1"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.DeliveryId != "1" || resp.TrackingNumber != domain.TrackingNumberFor(1) || resp.CreatedAt == 0 {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+		stored, err := repo.GetByID(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("expected the delivery to be stored: %v", err)
+		}
+		if stored.CustomerID != 1 || stored.Notes != "leave at door" || stored.Status != domain.StatusPending {
+			t.Errorf("unexpected stored delivery %+v", stored)
+		}
+	})
+
+	tests := []struct {
+		name         string
+		token        string
+		req          *deliveryProto.CreateDeliveryRequest
+		expectedCode codes.Code
+	}{
+		{name: "empty customer_id", token: adminToken, req: request(""), expectedCode: codes.InvalidArgument},
+		{name: "missing locations", token: adminToken, req: &deliveryProto.CreateDeliveryRequest{CustomerId: "1"}, expectedCode: codes.InvalidArgument},
+		{name: "another customer's delivery", token: otherCustomerToken, req: request("1"), expectedCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, repo := newDeliveryClient(t)
+
+			_, err := client.CreateDelivery(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+			if _, total, _ := repo.List(context.Background(), ports.DeliveryFilter{}); total != 0 {
+				t.Errorf("expected nothing stored, got %d deliveries", total)
+			}
+		})
+	}
+}
+
+func TestDeliveryGRPC_GetDelivery(t *testing.T) {
+	client, repo := newDeliveryClient(t)
+	repo.AddDelivery(&domain.Delivery{
+		ID:               1,
+		CustomerID:       1,
+		CourierID:        intPtr(7),
+		Status:           domain.StatusInTransit,
+		PickupLocation:   "Main St",
+		DeliveryLocation: "Oak Ave",
+	})
+
+	resp, err := client.GetDelivery(as(customerToken), &deliveryProto.GetDeliveryRequest{DeliveryId: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := resp.Delivery
+	if d.DeliveryId != "1" || d.CustomerId != "1" || d.DriverId != "7" || d.TrackingNumber != domain.TrackingNumberFor(1) {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+	if d.Status != deliveryProto.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT || d.PickupLocation.Address != "Main St" || d.DeliveryLocation.Address != "Oak Ave" {
+		t.Errorf("unexpected status or locations %+v", d)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		deliveryID   string
+		expectedCode codes.Code
+	}{
+		{name: "another customer", token: otherCustomerToken, deliveryID: "1", expectedCode: codes.PermissionDenied},
+		{name: "missing delivery", token: adminToken, deliveryID: "99", expectedCode: codes.NotFound},
+		{name: "non-numeric ID", token: adminToken, deliveryID: "abc", expectedCode: codes.InvalidArgument},
+		{name: "empty ID", token: adminToken, deliveryID: "", expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetDelivery(as(tt.token), &deliveryProto.GetDeliveryRequest{DeliveryId: tt.deliveryID})
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
+
+func TestDeliveryGRPC_GetDelivery_Coordinates(t *testing.T) {
+	client, _ := newDeliveryClient(t)
+
+	created, err := client.CreateDelivery(as(customerToken), &deliveryProto.CreateDeliveryRequest{
+		CustomerId:       "1",
+		PickupLocation:   &common.Location{Address: "(-73.98,40.75)"},
+		DeliveryLocation: &common.Location{Address: "5 Elm St", City: "New York", PostalCode: "10002", Country: "USA", Latitude: 40.71, Longitude: -73.99},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := client.GetDelivery(as(customerToken), &deliveryProto.GetDeliveryRequest{DeliveryId: created.DeliveryId})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pickup, dropoff := resp.Delivery.PickupLocation, resp.Delivery.DeliveryLocation
+	if pickup.Latitude != 40.75 || pickup.Longitude != -73.98 {
+		t.Errorf("expected pickup coordinates from the legacy text, got %+v", pickup)
+	}
+	if dropoff.Address != "5 Elm St" || dropoff.City != "New York" || dropoff.PostalCode != "10002" || dropoff.Latitude != 40.71 || dropoff.Longitude != -73.99 {
+		t.Errorf("unexpected drop-off location %+v", dropoff)
+	}
+}
+
+func TestDeliveryGRPC_UpdateDeliveryStatus(t *testing.T) {
+	client, repo := newDeliveryClient(t)
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, CourierID: intPtr(7), Status: domain.StatusAssigned})
+
+	resp, err := client.UpdateDeliveryStatus(as(courierToken), &deliveryProto.UpdateDeliveryStatusRequest{
+		DeliveryId:      "1",
+		Status:          deliveryProto.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT,
+		Notes:           "picked up",
+		ExpectedVersion: 1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Version != 2 {
+		t.Errorf("expected success at version 2, got %+v", resp)
+	}
+	stored, _ := repo.GetByID(context.Background(), 1)
+	if stored.Status != domain.StatusInTransit || stored.Notes != "picked up" {
+		t.Errorf("expected the status change to be stored, got %+v", stored)
+	}
+	if events := repo.OutboxEvents(); len(events) != 1 {
+		t.Errorf("expected one status changed event, got %d", len(events))
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		req          *deliveryProto.UpdateDeliveryStatusRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "another customer",
+			token:        otherCustomerToken,
+			req:          &deliveryProto.UpdateDeliveryStatusRequest{DeliveryId: "1", Status: deliveryProto.DeliveryStatus_DELIVERY_STATUS_DELIVERED},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name:         "missing delivery",
+			token:        adminToken,
+			req:          &deliveryProto.UpdateDeliveryStatusRequest{DeliveryId: "99", Status: deliveryProto.DeliveryStatus_DELIVERY_STATUS_DELIVERED},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "unspecified status",
+			token:        adminToken,
+			req:          &deliveryProto.UpdateDeliveryStatusRequest{DeliveryId: "1"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "non-numeric ID",
+			token:        adminToken,
+			req:          &deliveryProto.UpdateDeliveryStatusRequest{DeliveryId: "abc", Status: deliveryProto.DeliveryStatus_DELIVERY_STATUS_DELIVERED},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "stale version",
+			token:        adminToken,
+			req:          &deliveryProto.UpdateDeliveryStatusRequest{DeliveryId: "1", Status: deliveryProto.DeliveryStatus_DELIVERY_STATUS_DELIVERED, ExpectedVersion: 1},
+			expectedCode: codes.Aborted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.UpdateDeliveryStatus(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
+
+func TestDeliveryGRPC_ListDeliveries(t *testing.T) {
+	client, repo := newDeliveryClient(t)
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusPending})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CustomerID: 2, CourierID: intPtr(7), Status: domain.StatusAssigned})
+	repo.AddDelivery(&domain.Delivery{ID: 3, CustomerID: 1, Status: domain.StatusDelivered})
+
+	deliveryIDs := func(resp *deliveryProto.ListDeliveriesResponse) []string {
+		var ids []string
+		for _, d := range resp.Deliveries {
+			ids = append(ids, d.DeliveryId)
+		}
+		return ids
+	}
+
+	t.Run("customers see only their own deliveries", func(t *testing.T) {
+		resp, err := client.ListDeliveries(as(customerToken), &deliveryProto.ListDeliveriesRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, d := range resp.Deliveries {
+			if d.CustomerId != "1" {
+				t.Errorf("expected only customer 1's deliveries, got %v", deliveryIDs(resp))
+			}
+		}
+		if len(resp.Deliveries) != 2 {
+			t.Errorf("expected 2 deliveries, got %v", deliveryIDs(resp))
+		}
+	})
+
+	t.Run("filters by status and driver", func(t *testing.T) {
+		resp, err := client.ListDeliveries(as(adminToken), &deliveryProto.ListDeliveriesRequest{
+			Status:   deliveryProto.DeliveryStatus_DELIVERY_STATUS_ASSIGNED,
+			DriverId: "7",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Deliveries) != 1 || resp.Deliveries[0].DeliveryId != "2" || resp.Deliveries[0].DriverId != "7" {
+			t.Errorf("expected delivery 2 only, got %v", deliveryIDs(resp))
+		}
+	})
+
+	t.Run("filters by any of several statuses in order", func(t *testing.T) {
+		req := &deliveryProto.ListDeliveriesRequest{
+			Statuses: []deliveryProto.DeliveryStatus{
+				deliveryProto.DeliveryStatus_DELIVERY_STATUS_PENDING,
+				deliveryProto.DeliveryStatus_DELIVERY_STATUS_DELIVERED,
+			},
+		}
+		resp, err := client.ListDeliveries(as(adminToken), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids := deliveryIDs(resp); !slices.Equal(ids, []string{"3", "1"}) {
+			t.Errorf("expected deliveries 3 and 1, newest first, got %v", ids)
+		}
+
+		req.Order = "asc"
+		resp, err = client.ListDeliveries(as(adminToken), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids := deliveryIDs(resp); !slices.Equal(ids, []string{"1", "3"}) {
+			t.Errorf("expected deliveries 1 and 3, oldest first, got %v", ids)
+		}
+	})
+
+	t.Run("pages with the total count", func(t *testing.T) {
+		resp, err := client.ListDeliveries(as(adminToken), &deliveryProto.ListDeliveriesRequest{
+			Pagination: &common.Pagination{Page: 2, PageSize: 2},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids := deliveryIDs(resp); !slices.Equal(ids, []string{"1"}) || resp.TotalCount != 3 || resp.Page != 2 || resp.PageSize != 2 {
+			t.Errorf("expected delivery 1 on page 2 of 3 deliveries, got %v (%d total, page %d of size %d)",
+				ids, resp.TotalCount, resp.Page, resp.PageSize)
+		}
+
+		_, err = client.ListDeliveries(as(adminToken), &deliveryProto.ListDeliveriesRequest{
+			Pagination: &common.Pagination{Page: -1, PageSize: 2},
+		})
+		expectCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("rejects unknown sort fields", func(t *testing.T) {
+		_, err := client.ListDeliveries(as(adminToken), &deliveryProto.ListDeliveriesRequest{Sort: "customer_id"})
+		expectCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("rejects malformed IDs", func(t *testing.T) {
+		_, err := client.ListDeliveries(as(adminToken), &deliveryProto.ListDeliveriesRequest{CustomerId: "abc"})
+		expectCode(t, err, codes.InvalidArgument)
+
+		_, err = client.ListDeliveries(as(adminToken), &deliveryProto.ListDeliveriesRequest{DriverId: "abc"})
+		expectCode(t, err, codes.InvalidArgument)
+	})
+}
+
+func TestDeliveryGRPC_GetDriverDeliveries(t *testing.T) {
+	client, repo := newDeliveryClient(t)
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	delivered := func(at time.Time) *time.Time { return &at }
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, CourierID: intPtr(7), Status: domain.StatusDelivered, DeliveredDate: delivered(day.Add(9 * time.Hour))})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CustomerID: 1, CourierID: intPtr(7), Status: domain.StatusDelivered, DeliveredDate: delivered(day.Add(-time.Hour))})
+	repo.AddDelivery(&domain.Delivery{ID: 3, CustomerID: 1, CourierID: intPtr(7), Status: domain.StatusInTransit})
+	repo.AddDelivery(&domain.Delivery{ID: 4, CustomerID: 1, CourierID: intPtr(8), Status: domain.StatusDelivered, DeliveredDate: delivered(day.Add(10 * time.Hour))})
+
+	resp, err := client.GetDriverDeliveries(as(courierToken), &deliveryProto.GetDriverDeliveriesRequest{
+		DriverId: "7",
+		Status:   deliveryProto.DeliveryStatus_DELIVERY_STATUS_DELIVERED,
+		Date:     day.Add(15 * time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TotalCount != 1 || resp.Deliveries[0].DeliveryId != "1" || resp.Deliveries[0].ActualDelivery != day.Add(9*time.Hour).Unix() {
+		t.Errorf("expected only delivery 1 delivered that day, got %+v", resp.Deliveries)
+	}
+
+	resp, err = client.GetDriverDeliveries(as(adminToken), &deliveryProto.GetDriverDeliveriesRequest{DriverId: "7"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TotalCount != 3 {
+		t.Errorf("expected all 3 of courier 7's deliveries, got %d", resp.TotalCount)
+	}
+
+	_, err = client.GetDriverDeliveries(as(adminToken), &deliveryProto.GetDriverDeliveriesRequest{})
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestDeliveryGRPC_CancelDelivery(t *testing.T) {
+	client, repo := newDeliveryClient(t)
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusPending})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CustomerID: 1, Status: domain.StatusDelivered})
+
+	resp, err := client.CancelDelivery(as(customerToken), &deliveryProto.CancelDeliveryRequest{DeliveryId: "1", Reason: "ordered twice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.CancelledAt == 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	stored, _ := repo.GetByID(context.Background(), 1)
+	if stored.Status != domain.StatusCancelled {
+		t.Errorf("expected the delivery to be cancelled, got %s", stored.Status)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		req          *deliveryProto.CancelDeliveryRequest
+		expectedCode codes.Code
+	}{
+		{name: "another customer", token: otherCustomerToken, req: &deliveryProto.CancelDeliveryRequest{DeliveryId: "2", Reason: "changed my mind"}, expectedCode: codes.PermissionDenied},
+		{name: "missing delivery", token: adminToken, req: &deliveryProto.CancelDeliveryRequest{DeliveryId: "99", Reason: "changed my mind"}, expectedCode: codes.NotFound},
+		{name: "missing reason", token: customerToken, req: &deliveryProto.CancelDeliveryRequest{DeliveryId: "2"}, expectedCode: codes.InvalidArgument},
+		{name: "already delivered", token: customerToken, req: &deliveryProto.CancelDeliveryRequest{DeliveryId: "2", Reason: "changed my mind"}, expectedCode: codes.FailedPrecondition},
+		{name: "non-numeric ID", token: adminToken, req: &deliveryProto.CancelDeliveryRequest{DeliveryId: "abc", Reason: "changed my mind"}, expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.CancelDelivery(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
+
+func TestDeliveryGRPC_OptimizeRoute(t *testing.T) {
+	client, repo := newDeliveryClient(t)
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, CourierID: intPtr(7), Status: domain.StatusInTransit, DeliveryLocation: "(-73.99,40.76)"})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CustomerID: 1, CourierID: intPtr(7), Status: domain.StatusInTransit, DeliveryLocation: "(-73.98,40.75)"})
+	start := &common.Location{Latitude: 40.74, Longitude: -73.97}
+
+	resp, err := client.OptimizeRoute(as(courierToken), &deliveryProto.OptimizeRouteRequest{DriverId: "7", StartLocation: start})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Route) != 2 || resp.Route[0].DeliveryId != "2" || resp.Route[1].DeliveryId != "1" {
+		t.Fatalf("expected the nearer delivery 2 first, got %+v", resp.Route)
+	}
+	if resp.Route[0].Sequence != 1 || resp.TotalDistance <= 0 || resp.EstimatedDuration <= 0 {
+		t.Errorf("unexpected route totals: %+v", resp)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		req          *deliveryProto.OptimizeRouteRequest
+		expectedCode codes.Code
+	}{
+		{name: "another courier's route", token: customerToken, req: &deliveryProto.OptimizeRouteRequest{DriverId: "7", StartLocation: start}, expectedCode: codes.PermissionDenied},
+		{name: "unknown start", token: courierToken, req: &deliveryProto.OptimizeRouteRequest{DriverId: "7"}, expectedCode: codes.FailedPrecondition},
+		{name: "empty driver_id", token: courierToken, req: &deliveryProto.OptimizeRouteRequest{StartLocation: start}, expectedCode: codes.InvalidArgument},
+		{name: "malformed delivery ID", token: courierToken, req: &deliveryProto.OptimizeRouteRequest{DriverId: "7", DeliveryIds: []string{"x"}}, expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.OptimizeRoute(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
+
+func TestDeliveryGRPC_Unimplemented(t *testing.T) {
+	client, _ := newDeliveryClient(t)
+	ctx := as(adminToken)
+
+	_, err := client.AssignDriver(ctx, &deliveryProto.AssignDriverRequest{DeliveryId: "1", DriverId: "7"})
+	expectCode(t, err, codes.Unimplemented)
+
+	_, err = client.ConfirmDelivery(ctx, &deliveryProto.ConfirmDeliveryRequest{DeliveryId: "1"})
+	expectCode(t, err, codes.Unimplemented)
+}
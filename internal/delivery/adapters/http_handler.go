@@ -2,10 +2,14 @@ package adapters
 
 import (
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
 	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
 )
@@ -24,27 +28,38 @@ func NewHTTPHandler(service ports.DeliveryService) *HTTPHandler {
 
 // UpdateStatusRequest represents the request payload for updating delivery status
 type UpdateStatusRequest struct {
-	Status string `json:"status"`
-	Notes  string `json:"notes,omitempty"`
+	Status          string `json:"status"`
+	Notes           string `json:"notes,omitempty"`
+	ExpectedVersion int    `json:"expected_version,omitempty"` // If-Match takes precedence
+}
+
+// conflictResponse is sent with 409 when an update expected a stale version
+type conflictResponse struct {
+	Error          string `json:"error"`
+	Message        string `json:"message"`
+	CurrentVersion int    `json:"current_version"`
 }
 
 // CreateDelivery handles POST /deliveries
 func (h *HTTPHandler) CreateDelivery(w http.ResponseWriter, r *http.Request) {
 
 	var req ports.CreateDeliveryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httputil.SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
 		return
 	}
 
 	// Validate required fields
-	if req.CustomerID == 0 || req.PickupLocation == "" || req.DeliveryLocation == "" {
-		httputil.SendErrorResponse(w, "customer_id, pickup_location, and delivery_location are required", http.StatusBadRequest)
+	if req.CustomerID == 0 || !req.HasLocations() {
+		httputil.SendErrorResponse(w, "customer_id, pickup_location (or pickup_address), and delivery_location (or delivery_address) are required", http.StatusBadRequest)
 		return
 	}
 
 	// Get user context from auth middleware
-	userCtx := httputil.ExtractUserContext(r)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
 
 	// Authorization: customers can only create their own deliveries
 	if userCtx.Role == "customer" && userCtx.CustomerID != nil && *userCtx.CustomerID != req.CustomerID {
@@ -58,7 +73,21 @@ func (h *HTTPHandler) CreateDelivery(w http.ResponseWriter, r *http.Request) {
 	// Create delivery
 	delivery, err := h.service.CreateDelivery(ctx, req)
 	if err != nil {
-		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrInvalidScheduleWindow), errors.Is(err, domain.ErrQuoteInvalid), errors.Is(err, domain.ErrQuoteMismatch):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, domain.ErrCourierUnavailable):
+			statusCode = http.StatusConflict
+		case errors.Is(err, domain.ErrQuoteExpired):
+			statusCode = http.StatusGone
+		case errors.Is(err, domain.ErrSlotFull):
+			httputil.SendErrorCode(w, "slot_full", err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, domain.ErrSlotsUnavailable):
+			statusCode = http.StatusServiceUnavailable
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
 		return
 	}
 
@@ -67,30 +96,297 @@ func (h *HTTPHandler) CreateDelivery(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(delivery)
 }
 
-// GetDelivery handles GET /deliveries/:id
+// QuoteDelivery handles POST /deliveries/quote. It prices a delivery from its
+// pickup and drop-off and returns the quote with a token that POST
+// /deliveries honours as quote_token until expires_at.
+func (h *HTTPHandler) QuoteDelivery(w http.ResponseWriter, r *http.Request) {
+	var req ports.QuoteDeliveryRequest
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	if (req.PickupAddress == nil && req.PickupLocation == "") || (req.DeliveryAddress == nil && req.DeliveryLocation == "") {
+		httputil.SendErrorResponse(w, "pickup_location (or pickup_address) and delivery_location (or delivery_address) are required", http.StatusBadRequest)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+	req.AuthContext = ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "quote_delivery_http")
+
+	quote, err := h.service.QuoteDelivery(ctx, req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrInvalidDeliveryData), errors.Is(err, domain.ErrQuoteUnlocated):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, domain.ErrPricingUnavailable):
+			statusCode = http.StatusServiceUnavailable
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(quote)
+}
+
+// GetSlotAvailability handles GET /deliveries/slots?date=YYYY-MM-DD&zone=downtown.
+// It lists the two-hour windows the zone offers that day with the capacity
+// still free in each.
+func (h *HTTPHandler) GetSlotAvailability(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "get_slot_availability_http")
+
+	availability, err := h.service.GetSlotAvailability(ctx, ports.SlotAvailabilityRequest{
+		Date: r.URL.Query().Get("date"),
+		Zone: r.URL.Query().Get("zone"),
+		AuthContext: ports.AuthContext{
+			Role:           userCtx.Role,
+			UserCustomerID: userCtx.CustomerID,
+			UserCourierID:  userCtx.CourierID,
+		},
+	})
+	if err != nil {
+		sendSlotError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(availability)
+}
+
+// slotCapacitiesResponse is the body of the slot capacity admin endpoints
+type slotCapacitiesResponse struct {
+	Zone  string                `json:"zone"`
+	Slots []domain.SlotCapacity `json:"slots"`
+}
+
+// GetSlotCapacities handles GET /admin/slot-capacities/{zone}; super-admins
+// may pick another organization's with ?org_id=
+func (h *HTTPHandler) GetSlotCapacities(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "get_slot_capacities_http")
+
+	orgID := 0
+	if raw := r.URL.Query().Get("org_id"); raw != "" {
+		var err error
+		if orgID, err = strconv.Atoi(raw); err != nil || orgID <= 0 {
+			httputil.SendErrorResponse(w, "Invalid org_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	zone := r.PathValue("zone")
+	capacities, err := h.service.GetSlotCapacities(ctx, orgID, zone, ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	})
+	if err != nil {
+		sendSlotError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(slotCapacitiesResponse{Zone: zone, Slots: capacities})
+}
+
+// UpdateSlotCapacities handles PUT /admin/slot-capacities/{zone} with
+// {"slots":[{"start_hour":8,"capacity":20}]}, replacing every slot the zone
+// offers
+func (h *HTTPHandler) UpdateSlotCapacities(w http.ResponseWriter, r *http.Request) {
+	var req ports.UpdateSlotCapacitiesRequest
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+	req.Zone = r.PathValue("zone")
+	req.AuthContext = ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "update_slot_capacities_http")
+
+	capacities, err := h.service.UpdateSlotCapacities(ctx, req)
+	if err != nil {
+		sendSlotError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(slotCapacitiesResponse{Zone: req.Zone, Slots: capacities})
+}
+
+// sendSlotError maps an error from the slot endpoints to its status code
+func sendSlotError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		statusCode = http.StatusForbidden
+	case errors.Is(err, domain.ErrInvalidSlotRequest), errors.Is(err, domain.ErrInvalidSlotCapacity):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, domain.ErrSlotsUnavailable):
+		statusCode = http.StatusServiceUnavailable
+	}
+	httputil.SendErrorResponse(w, err.Error(), statusCode)
+}
+
+// maxBulkBodyBytes bounds bulk creation uploads
+const maxBulkBodyBytes = 5 << 20
+
+// BulkCreateDeliveries handles POST /deliveries/bulk with a JSON array of
+// create-delivery payloads, or a CSV file with a header row when the
+// Content-Type is text/csv
+func (h *HTTPHandler) BulkCreateDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rows []ports.CreateDeliveryRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		var err error
+		rows, err = parseDeliveryCSV(r.Body)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid CSV: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if err := httputil.DecodeJSONLimit(w, r, &rows, maxBulkBodyBytes); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	// Get user context from auth middleware
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	// Create trace context for request tracing
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "bulk_create_deliveries_http")
+
+	result, err := h.service.BulkCreateDeliveries(ctx, ports.BulkCreateDeliveriesRequest{
+		Deliveries: rows,
+		AuthContext: ports.AuthContext{
+			Role:           userCtx.Role,
+			UserCustomerID: userCtx.CustomerID,
+			UserCourierID:  userCtx.CourierID,
+		},
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrEmptyBatch):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, domain.ErrBatchTooLarge):
+			statusCode = http.StatusRequestEntityTooLarge
+		case errors.Is(err, domain.ErrUnauthorized):
+			statusCode = http.StatusForbidden
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// deliveryDetailsResponse is a delivery with the live fields asked for in
+// ?include=. Requested fields are null when unknown; fields not requested are
+// left out.
+type deliveryDetailsResponse struct {
+	*domain.Delivery
+	CurrentLocation json.RawMessage `json:"current_location,omitempty"`
+	ETA             json.RawMessage `json:"eta,omitempty"`
+	Partial         bool            `json:"partial"`
+}
+
+// includedField encodes v for a requested field, or nothing when it wasn't requested
+func includedField(requested bool, v interface{}) json.RawMessage {
+	if !requested {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
+
+// GetDelivery handles GET and HEAD /deliveries/:id?include=location,eta. The
+// weak ETag follows the delivery's version, so If-None-Match gets a 304 while
+// it is unchanged. With include, the latest location and ETA are looked up in
+// the tracking service and no ETag is sent, since they change on their own;
+// lookups that fail leave their field null with partial set.
 func (h *HTTPHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Extract ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/deliveries/")
-	id, err := strconv.Atoi(path)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
 		return
 	}
 
+	var includeLocation, includeETA bool
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+		case "location":
+			includeLocation = true
+		case "eta":
+			includeETA = true
+		default:
+			httputil.SendErrorResponse(w, "include must list location and/or eta", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Get user context
-	userCtx := httputil.ExtractUserContext(r)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
 
 	// Create trace context for request tracing
 	ctx := httputil.ExtractTraceContext(r, "delivery-service", "get_delivery_http")
 
 	// Get delivery
-	delivery, err := h.service.GetDelivery(ctx, ports.GetDeliveryRequest{
-		ID: id,
+	details, err := h.service.GetDeliveryDetails(ctx, ports.GetDeliveryDetailsRequest{
+		ID:              id,
+		IncludeLocation: includeLocation,
+		IncludeETA:      includeETA,
 		AuthContext: ports.AuthContext{
 			Role:           userCtx.Role,
 			UserCustomerID: userCtx.CustomerID,
@@ -108,15 +404,42 @@ func (h *HTTPHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	delivery := details.Delivery
 	w.Header().Set("Content-Type", "application/json")
+	if includeLocation || includeETA {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		json.NewEncoder(w).Encode(deliveryDetailsResponse{
+			Delivery:        delivery,
+			CurrentLocation: includedField(includeLocation, details.CurrentLocation),
+			ETA:             includedField(includeETA, details.ETA),
+			Partial:         details.Partial,
+		})
+		return
+	}
+
+	// Every update bumps the version and the update time
+	if httputil.ServeConditional(w, r, httputil.WeakETag(delivery.UpdatedAt, delivery.Version)) {
+		return
+	}
 	json.NewEncoder(w).Encode(delivery)
 }
 
-// ListDeliveries handles GET /deliveries
+// ListDeliveries handles GET /deliveries?status=assigned,in_transit&sort=scheduled_date&order=asc&limit=&offset=
+// status takes a comma-separated list; sort is created_at (default),
+// updated_at or scheduled_date, and order is asc or desc (default).
+// X-Total-Count is the number of deliveries on all pages.
 func (h *HTTPHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
 
 	// Get query parameters
-	status := r.URL.Query().Get("status")
+	var statuses []string
+	for _, status := range strings.Split(r.URL.Query().Get("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
 	customerIDParam := r.URL.Query().Get("customer_id")
 
 	var filterCustomerID int
@@ -129,31 +452,169 @@ func (h *HTTPHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var late *bool
+	if lateParam := r.URL.Query().Get("late"); lateParam != "" {
+		parsed, err := strconv.ParseBool(lateParam)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid late filter", http.StatusBadRequest)
+			return
+		}
+		late = &parsed
+	}
+
+	var limit, offset int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 0 {
+			httputil.SendErrorResponse(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if raw := r.URL.Query().Get("offset"); raw != "" {
+		var err error
+		if offset, err = strconv.Atoi(raw); err != nil || offset < 0 {
+			httputil.SendErrorResponse(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Get user context
-	userCtx := httputil.ExtractUserContext(r)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
 
 	// Create trace context for request tracing
 	ctx := httputil.ExtractTraceContext(r, "delivery-service", "list_deliveries_http")
 
 	// List deliveries
-	deliveries, err := h.service.ListDeliveries(ctx, ports.ListDeliveriesRequest{
-		Status:     status,
+	deliveries, total, err := h.service.ListDeliveries(ctx, ports.ListDeliveriesRequest{
+		Statuses:   statuses,
 		CustomerID: filterCustomerID,
+		Late:       late,
+		Sort:       r.URL.Query().Get("sort"),
+		Order:      r.URL.Query().Get("order"),
+		Limit:      limit,
+		Offset:     offset,
 		AuthContext: ports.AuthContext{
 			Role:           userCtx.Role,
 			UserCustomerID: userCtx.CustomerID,
 			UserCourierID:  userCtx.CourierID,
 		},
 	})
+	if errors.Is(err, domain.ErrInvalidSort) {
+		httputil.SendErrorResponse(w, "sort must be created_at, updated_at or scheduled_date and order asc or desc", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	json.NewEncoder(w).Encode(deliveries)
 }
 
+// SearchDeliveries handles GET /deliveries/search?tracking_number=..&pickup_contains=..&from=..&to=..
+// from and to bound the creation time and are RFC3339.
+func (h *HTTPHandler) SearchDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	req := ports.SearchDeliveriesRequest{
+		TrackingNumber: query.Get("tracking_number"),
+		PickupContains: query.Get("pickup_contains"),
+	}
+	var err error
+	if req.From, err = parseTimeParam(query.Get("from")); err != nil {
+		httputil.SendErrorResponse(w, "Invalid from, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+	if req.To, err = parseTimeParam(query.Get("to")); err != nil {
+		httputil.SendErrorResponse(w, "Invalid to, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	// Get user context
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	// Create trace context for request tracing
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "search_deliveries_http")
+
+	req.AuthContext = ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+
+	deliveries, err := h.service.SearchDeliveries(ctx, req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, domain.ErrInvalidTrackingNumber):
+			statusCode = http.StatusBadRequest
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*domain.Delivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// parseTimeParam parses an optional RFC3339 query parameter
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// TrackByNumber handles GET /track/:tracking_number. It is public and only
+// returns the delivery's progress and a rounded drop-off area.
+func (h *HTTPHandler) TrackByNumber(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trackingNumber := r.PathValue("number")
+
+	// Create trace context for request tracing
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "track_by_number_http")
+
+	view, err := h.service.TrackByNumber(ctx, trackingNumber)
+	if err != nil {
+		// Malformed and unknown numbers look the same to the public
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidTrackingNumber) || errors.Is(err, domain.ErrDeliveryNotFound) {
+			statusCode = http.StatusNotFound
+			err = domain.ErrDeliveryNotFound
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
 // UpdateDeliveryStatus handles PUT /deliveries/:id/status
 func (h *HTTPHandler) UpdateDeliveryStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
@@ -161,18 +622,15 @@ func (h *HTTPHandler) UpdateDeliveryStatus(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Extract ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/deliveries/")
-	path = strings.TrimSuffix(path, "/status")
-	id, err := strconv.Atoi(path)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
 		return
 	}
 
 	var req UpdateStatusRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httputil.SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
 		return
 	}
 
@@ -181,17 +639,27 @@ func (h *HTTPHandler) UpdateDeliveryStatus(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	version, err := expectedVersion(r, req.ExpectedVersion)
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Get user context
-	userCtx := httputil.ExtractUserContext(r)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
 
 	// Create trace context for request tracing
 	ctx := httputil.ExtractTraceContext(r, "delivery-service", "update_delivery_status_http")
 
 	// Update status
-	err = h.service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
-		ID:     id,
-		Status: req.Status,
-		Notes:  req.Notes,
+	delivery, err := h.service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
+		ID:              id,
+		Status:          req.Status,
+		Notes:           req.Notes,
+		ExpectedVersion: version,
 		AuthContext: ports.AuthContext{
 			Role:           userCtx.Role,
 			UserCustomerID: userCtx.CustomerID,
@@ -199,12 +667,214 @@ func (h *HTTPHandler) UpdateDeliveryStatus(w http.ResponseWriter, r *http.Reques
 		},
 	})
 	if err != nil {
+		var conflict *domain.ConflictError
+		if errors.As(err, &conflict) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(conflictResponse{
+				Error:          http.StatusText(http.StatusConflict),
+				Message:        "delivery was modified; refetch it and retry",
+				CurrentVersion: conflict.CurrentVersion,
+			})
+			return
+		}
+
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "unauthorized access" {
 			statusCode = http.StatusForbidden
 		} else if err.Error() == "delivery not found" {
 			statusCode = http.StatusNotFound
-		} else if err.Error() == "invalid delivery status" {
+		} else if err.Error() == "invalid delivery status" || errors.Is(err, domain.ErrCancelByStatus) || errors.Is(err, domain.ErrDeliverByStatus) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, domain.ErrCourierUnavailable) || errors.Is(err, domain.ErrInvalidTransition) {
+			statusCode = http.StatusConflict
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Status updated successfully", "version": delivery.Version})
+}
+
+// expectedVersion returns the version an update requires: the If-Match
+// header, such as 3 or "3", or else the body's expected_version. Zero means
+// neither was given.
+func expectedVersion(r *http.Request, bodyVersion int) (int, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if bodyVersion < 0 {
+			return 0, errors.New("expected_version must be positive")
+		}
+		return bodyVersion, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version <= 0 {
+		return 0, errors.New("If-Match must be a delivery version")
+	}
+	return version, nil
+}
+
+// maxConfirmationBodyBytes bounds proof-of-delivery uploads (base64 photo and signature)
+const maxConfirmationBodyBytes = 10 << 20
+
+// ConfirmDelivery handles POST /deliveries/:id/confirm
+func (h *HTTPHandler) ConfirmDelivery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	// Refuse other callers before reading a body of up to 10 MB
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	if userCtx.Role != "courier" {
+		httputil.SendErrorResponse(w, "Only couriers can confirm deliveries", http.StatusForbidden)
+		return
+	}
+
+	var req ports.ConfirmDeliveryRequest
+	if err := httputil.DecodeJSONLimit(w, r, &req, maxConfirmationBodyBytes); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	// Create trace context for request tracing
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "confirm_delivery_http")
+
+	req.ID = id
+	req.AuthContext = ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+
+	confirmation, err := h.service.ConfirmDelivery(ctx, req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized), errors.Is(err, domain.ErrWrongConfirmationCode):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, domain.ErrDeliveryNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, domain.ErrNotInTransit):
+			statusCode = http.StatusConflict
+		case errors.Is(err, domain.ErrInvalidConfirmation):
+			statusCode = http.StatusBadRequest
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(confirmation)
+}
+
+// GetConfirmationCode handles GET /deliveries/{id}/confirmation-code
+func (h *HTTPHandler) GetConfirmationCode(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "get_confirmation_code_http")
+	code, err := h.service.GetConfirmationCode(ctx, ports.GetDeliveryRequest{
+		ID: id,
+		AuthContext: ports.AuthContext{
+			Role:           userCtx.Role,
+			UserCustomerID: userCtx.CustomerID,
+			UserCourierID:  userCtx.CourierID,
+		},
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, domain.ErrDeliveryNotFound):
+			statusCode = http.StatusNotFound
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"confirmation_code": code})
+}
+
+// CancelDelivery handles POST /deliveries/:id/cancel
+func (h *HTTPHandler) CancelDelivery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ports.CancelDeliveryRequest
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		httputil.SendErrorResponse(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if req.ReasonCode != "" && !domain.IsValidCancelReasonCode(req.ReasonCode) {
+		httputil.SendErrorResponse(w, "reason_code must be one of customer_request, address_invalid, courier_unavailable, other", http.StatusBadRequest)
+		return
+	}
+
+	// Get user context
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	// Create trace context for request tracing
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "cancel_delivery_http")
+
+	req.ID = id
+	req.AuthContext = ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+
+	delivery, err := h.service.CancelDelivery(ctx, req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, domain.ErrDeliveryNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, domain.ErrNotCancellable):
+			statusCode = http.StatusConflict
+		case errors.Is(err, domain.ErrInvalidCancellation):
 			statusCode = http.StatusBadRequest
 		}
 		httputil.SendErrorResponse(w, err.Error(), statusCode)
@@ -213,6 +883,74 @@ func (h *HTTPHandler) UpdateDeliveryStatus(w http.ResponseWriter, r *http.Reques
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Status updated successfully"})
+	json.NewEncoder(w).Encode(delivery)
 }
 
+// GetCourierRoute handles GET /couriers/:id/route?delivery_ids=1,2&lat=..&lng=..
+// The route starts at the courier's last known location unless lat and lng are given.
+func (h *HTTPHandler) GetCourierRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	courierID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+
+	req := ports.OptimizeRouteRequest{CourierID: courierID}
+
+	query := r.URL.Query()
+	if ids := query.Get("delivery_ids"); ids != "" {
+		for _, raw := range strings.Split(ids, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(raw))
+			if err != nil {
+				httputil.SendErrorResponse(w, "Invalid delivery_ids", http.StatusBadRequest)
+				return
+			}
+			req.DeliveryIDs = append(req.DeliveryIDs, id)
+		}
+	}
+	if query.Get("lat") != "" || query.Get("lng") != "" {
+		lat, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+		lng, lngErr := strconv.ParseFloat(query.Get("lng"), 64)
+		if latErr != nil || lngErr != nil {
+			httputil.SendErrorResponse(w, "lat and lng must both be numbers", http.StatusBadRequest)
+			return
+		}
+		req.Start = &domain.CourierPosition{Latitude: lat, Longitude: lng}
+	}
+
+	// Get user context
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	// Create trace context for request tracing
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "optimize_route_http")
+
+	req.AuthContext = ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+
+	plan, err := h.service.OptimizeRoute(ctx, req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, domain.ErrCourierLocationUnknown):
+			statusCode = http.StatusConflict
+		}
+		httputil.SendErrorResponse(w, err.Error(), statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
@@ -0,0 +1,348 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/app"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+// update rewrites the golden files from the current responses:
+// go test ./internal/delivery/adapters -run Golden -update
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// assertGolden compares a JSON response with testdata/name, ignoring indentation
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	var got bytes.Buffer
+	if err := json.Indent(&got, bytes.TrimSpace(body), "", "  "); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, body)
+	}
+	got.WriteByte('\n')
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("response differs from %s; if the change is intended, rerun with -update\ngot:\n%s\nwant:\n%s", path, got.Bytes(), want)
+	}
+}
+
+// TestHTTPHandler_GetDelivery_Golden pins the JSON contract of GET
+// /deliveries/{id}: unset fields are null and set ones plain values, never
+// database wrapper objects
+func TestHTTPHandler_GetDelivery_Golden(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	handler := NewHTTPHandler(app.NewDeliveryService(repo, nil, nil, &logger.Logger{Logger: zaptest.NewLogger(t)}))
+
+	created := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	scheduled := time.Date(2026, 3, 3, 14, 0, 0, 0, time.UTC)
+	scheduledEnd := scheduled.Add(2 * time.Hour)
+
+	unassigned, err := domain.NewDelivery(1, "1 Main St, Springfield", "9 Elm St, Shelbyville")
+	if err != nil {
+		t.Fatalf("failed to build delivery: %v", err)
+	}
+	unassigned.ID = 1
+	unassigned.CreatedAt, unassigned.UpdatedAt = created, created
+
+	assigned, err := domain.NewDeliveryWithAddresses(2,
+		domain.Address{Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US", Coordinates: &domain.Coordinates{Latitude: 40.7128, Longitude: -74.006}},
+		domain.Address{Line1: "9 Elm St", City: "Shelbyville", Country: "US", Coordinates: &domain.Coordinates{Latitude: 40.7306, Longitude: -73.9352}})
+	if err != nil {
+		t.Fatalf("failed to build delivery: %v", err)
+	}
+	assigned.ID = 2
+	assigned.CourierID = intPtr(7)
+	assigned.Status = "in_transit"
+	assigned.ScheduledDate, assigned.ScheduledEnd = &scheduled, &scheduledEnd
+	assigned.Notes = "Leave at the door"
+	assigned.CreatedAt, assigned.UpdatedAt = created, created.Add(time.Hour)
+	assigned.Version = 3
+
+	tests := []struct {
+		name     string
+		delivery *domain.Delivery
+		golden   string
+	}{
+		{name: "unassigned", delivery: unassigned, golden: "get_delivery_unassigned.golden.json"},
+		{name: "assigned", delivery: assigned, golden: "get_delivery_assigned.golden.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.AddDelivery(tt.delivery)
+
+			req := httptest.NewRequest(http.MethodGet, "/deliveries/1", nil)
+			req.SetPathValue("id", strconv.Itoa(tt.delivery.ID))
+			req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}))
+			w := httptest.NewRecorder()
+			handler.GetDelivery(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			assertGolden(t, tt.golden, w.Body.Bytes())
+		})
+	}
+}
+
+// stubDeliveryTracker answers tracking lookups with fixed values, or fails them with err
+type stubDeliveryTracker struct {
+	location *domain.TrackedLocation
+	eta      *domain.DeliveryETA
+	err      error
+}
+
+func (s *stubDeliveryTracker) CurrentLocation(ctx context.Context, deliveryID int) (*domain.TrackedLocation, error) {
+	return s.location, s.err
+}
+
+func (s *stubDeliveryTracker) ETA(ctx context.Context, deliveryID int, destination domain.Coordinates) (*domain.DeliveryETA, error) {
+	return s.eta, s.err
+}
+
+func TestHTTPHandler_GetDelivery_Include(t *testing.T) {
+	reported := time.Date(2026, 3, 3, 14, 20, 0, 0, time.UTC)
+	tracker := &stubDeliveryTracker{
+		location: &domain.TrackedLocation{Latitude: 40.72, Longitude: -73.97, Timestamp: reported},
+		eta:      &domain.DeliveryETA{Seconds: 540, DistanceKm: 3.75, EstimatedArrival: reported.Add(9 * time.Minute)},
+	}
+
+	repo := memory.NewDeliveryRepository()
+	service := app.NewDeliveryService(repo, nil, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetDeliveryTracker(tracker, time.Second)
+	handler := NewHTTPHandler(service)
+
+	created := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	d, err := domain.NewDeliveryWithAddresses(1,
+		domain.Address{Line1: "1 Main St", City: "Springfield", Country: "US", Coordinates: &domain.Coordinates{Latitude: 40.7128, Longitude: -74.006}},
+		domain.Address{Line1: "9 Elm St", City: "Shelbyville", Country: "US", Coordinates: &domain.Coordinates{Latitude: 40.7306, Longitude: -73.9352}})
+	if err != nil {
+		t.Fatalf("failed to build delivery: %v", err)
+	}
+	d.ID = 1
+	d.CourierID = intPtr(7)
+	d.Status = "in_transit"
+	d.CreatedAt, d.UpdatedAt = created, created.Add(time.Hour)
+	repo.AddDelivery(d)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/deliveries/1"+query, nil)
+		req.SetPathValue("id", "1")
+		req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}))
+		w := httptest.NewRecorder()
+		handler.GetDelivery(w, req)
+		return w
+	}
+
+	w := get("?include=location,eta")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Error("expected no ETag on a response with live fields")
+	}
+	assertGolden(t, "get_delivery_include.golden.json", w.Body.Bytes())
+
+	t.Run("only location", func(t *testing.T) {
+		var body map[string]json.RawMessage
+		json.Unmarshal(get("?include=location").Body.Bytes(), &body)
+		if _, ok := body["eta"]; ok || body["current_location"] == nil {
+			t.Errorf("expected only the location, got %v", body)
+		}
+	})
+
+	t.Run("tracking failure", func(t *testing.T) {
+		tracker.err = errors.New("tracking unavailable")
+		defer func() { tracker.err = nil }()
+
+		w := get("?include=location,eta")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if string(body["current_location"]) != "null" || string(body["eta"]) != "null" || string(body["partial"]) != "true" {
+			t.Errorf("expected null fields marked partial, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("unknown include", func(t *testing.T) {
+		if w := get("?include=route"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+type stubZoneLocator struct{}
+
+func (stubZoneLocator) ZonesAt(ctx context.Context, point domain.Coordinates) ([]string, error) {
+	return []string{"downtown"}, nil
+}
+
+func TestHTTPHandler_QuoteDelivery(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	service := app.NewDeliveryService(repo, nil, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	handler := NewHTTPHandler(service)
+	customer := &authDomain.Claims{UserID: 1, Role: authDomain.RoleCustomer, CustomerID: intPtr(1)}
+
+	post := func(handle http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(authctx.WithClaims(req.Context(), customer))
+		w := httptest.NewRecorder()
+		handle(w, req)
+		return w
+	}
+	const locations = `"pickup_location":"(-74.006,40.7128)","delivery_location":"(-73.9352,40.7306)"`
+
+	if w := post(handler.QuoteDelivery, "/deliveries/quote", "{"+locations+"}"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d without pricing, got %d: %s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+	}
+
+	service.SetPricing(app.PricingConfig{
+		Model:  domain.PricingModel{Currency: "EUR", BaseFeeCents: 300, PerKmCents: 100},
+		Secret: []byte("quote-secret"),
+	}, stubZoneLocator{})
+
+	w := post(handler.QuoteDelivery, "/deliveries/quote", "{"+locations+"}")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Error("expected quotes not to be cached")
+	}
+	var quote domain.Quote
+	if err := json.Unmarshal(w.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to decode quote: %v", err)
+	}
+	if quote.Currency != "EUR" || quote.TotalCents <= 300 || quote.Token == "" || quote.ExpiresAt.IsZero() {
+		t.Errorf("unexpected quote: %s", w.Body.String())
+	}
+
+	t.Run("create with quote", func(t *testing.T) {
+		w := post(handler.CreateDelivery, "/deliveries", `{"customer_id":1,`+locations+`,"quote_token":"`+quote.Token+`"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var created domain.Delivery
+		json.Unmarshal(w.Body.Bytes(), &created)
+		if created.QuotedPriceCents == nil || *created.QuotedPriceCents != quote.TotalCents || created.QuotedCurrency != "EUR" {
+			t.Errorf("expected the quoted price stored, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("create with mismatched quote", func(t *testing.T) {
+		body := `{"customer_id":1,"pickup_location":"(-73.9857,40.7484)","delivery_location":"(-73.9352,40.7306)","quote_token":"` + quote.Token + `"}`
+		if w := post(handler.CreateDelivery, "/deliveries", body); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("missing locations", func(t *testing.T) {
+		if w := post(handler.QuoteDelivery, "/deliveries/quote", `{"pickup_location":"1 Main St"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestHTTPHandler_Slots(t *testing.T) {
+	service := app.NewDeliveryService(memory.NewDeliveryRepository(), nil, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetSlots(memory.NewSlotCapacityRepository(), stubZoneLocator{}, time.UTC)
+	handler := NewHTTPHandler(service)
+	admin := &authDomain.Claims{UserID: 9, Role: authDomain.RoleAdmin}
+	customer := &authDomain.Claims{UserID: 1, Role: authDomain.RoleCustomer, CustomerID: intPtr(1)}
+
+	serve := func(handle http.HandlerFunc, method, path, body string, claims *authDomain.Claims) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetPathValue("zone", "downtown")
+		req = req.WithContext(authctx.WithClaims(req.Context(), claims))
+		w := httptest.NewRecorder()
+		handle(w, req)
+		return w
+	}
+	const capacities = `{"slots":[{"start_hour":8,"capacity":1}]}`
+
+	if w := serve(handler.UpdateSlotCapacities, http.MethodPut, "/admin/slot-capacities/downtown", capacities, customer); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for customers, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := serve(handler.UpdateSlotCapacities, http.MethodPut, "/admin/slot-capacities/downtown", `{"slots":[{"start_hour":23,"capacity":1}]}`, admin); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a slot past midnight, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := serve(handler.UpdateSlotCapacities, http.MethodPut, "/admin/slot-capacities/downtown", capacities, admin); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := serve(handler.GetSlotCapacities, http.MethodGet, "/admin/slot-capacities/downtown", "", admin); w.Body.String() != `{"zone":"downtown","slots":[{"start_hour":8,"capacity":1}]}`+"\n" {
+		t.Errorf("expected the stored slots, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(handler.GetSlotCapacities, http.MethodGet, "/admin/slot-capacities/downtown?org_id=none", "", admin); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid org_id, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+	start := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 8, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	create := `{"customer_id":1,"pickup_location":"(-74.006,40.7128)","delivery_location":"(-73.9352,40.7306)","scheduled_date":"` + start + `"}`
+	if w := serve(handler.CreateDelivery, http.MethodPost, "/deliveries", create, customer); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	t.Run("availability", func(t *testing.T) {
+		w := serve(handler.GetSlotAvailability, http.MethodGet, "/deliveries/slots?zone=downtown&date="+tomorrow.Format("2006-01-02"), "", customer)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var availability domain.SlotAvailability
+		if err := json.Unmarshal(w.Body.Bytes(), &availability); err != nil {
+			t.Fatalf("failed to decode availability: %v", err)
+		}
+		if len(availability.Slots) != 1 || availability.Slots[0].Booked != 1 || availability.Slots[0].Available != 0 {
+			t.Errorf("expected the slot fully booked, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("bad date", func(t *testing.T) {
+		if w := serve(handler.GetSlotAvailability, http.MethodGet, "/deliveries/slots?zone=downtown&date=tomorrow", "", customer); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("slot full", func(t *testing.T) {
+		w := serve(handler.CreateDelivery, http.MethodPost, "/deliveries", create, customer)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		var body struct{ Error string }
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Error != "slot_full" {
+			t.Errorf("expected the slot_full error code, got %s", w.Body.String())
+		}
+	})
+}
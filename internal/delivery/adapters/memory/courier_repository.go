@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// CourierRepository implements ports.CourierRepository in memory
+type CourierRepository struct {
+	mu         sync.Mutex
+	couriers   map[int]*domain.Courier
+	deliveries *DeliveryRepository // nil if no courier is ever released
+}
+
+// NewCourierRepository creates an empty in-memory courier repository.
+// ReleaseIfIdle consults deliveries for the courier's open deliveries.
+func NewCourierRepository(deliveries *DeliveryRepository) *CourierRepository {
+	return &CourierRepository{
+		couriers:   make(map[int]*domain.Courier),
+		deliveries: deliveries,
+	}
+}
+
+// AddCourier stores a courier of the default organization with the given
+// availability
+func (r *CourierRepository) AddCourier(id int, status string) {
+	r.AddCourierInOrg(id, authDomain.DefaultOrgID, status)
+}
+
+// AddCourierInOrg stores a courier of an organization with the given
+// availability
+func (r *CourierRepository) AddCourierInOrg(id, orgID int, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.couriers[id] = &domain.Courier{ID: id, OrgID: orgID, Status: status, StatusUpdatedAt: time.Now()}
+}
+
+// GetByID retrieves a courier by their ID
+func (r *CourierRepository) GetByID(ctx context.Context, id int) (*domain.Courier, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	courier, ok := r.couriers[id]
+	if !ok || !visible(ctx, courier.OrgID) {
+		return nil, domain.ErrCourierNotFound
+	}
+	copied := *courier
+	return &copied, nil
+}
+
+// List retrieves couriers by ID, optionally only those with the given status
+func (r *CourierRepository) List(ctx context.Context, status string) ([]*domain.Courier, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var couriers []*domain.Courier
+	for _, c := range r.couriers {
+		if (status == "" || c.Status == status) && visible(ctx, c.OrgID) {
+			copied := *c
+			couriers = append(couriers, &copied)
+		}
+	}
+	sort.Slice(couriers, func(i, j int) bool { return couriers[i].ID < couriers[j].ID })
+	return couriers, nil
+}
+
+// UpdateStatus sets a courier's availability and when it changed
+func (r *CourierRepository) UpdateStatus(ctx context.Context, id int, status string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	courier, ok := r.couriers[id]
+	if !ok {
+		return domain.ErrCourierNotFound
+	}
+	courier.Status = status
+	courier.StatusUpdatedAt = at
+	return nil
+}
+
+// ReleaseIfIdle makes a busy courier available again once none of their
+// deliveries are assigned or in transit. Couriers who went offline stay offline.
+func (r *CourierRepository) ReleaseIfIdle(ctx context.Context, id int, at time.Time) error {
+	if r.deliveries != nil && r.deliveries.openDeliveryFor(id) {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	courier, ok := r.couriers[id]
+	if !ok || courier.Status != domain.CourierBusy {
+		return nil
+	}
+	courier.Status = domain.CourierAvailable
+	courier.StatusUpdatedAt = at
+	return nil
+}
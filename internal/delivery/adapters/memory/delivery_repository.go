@@ -0,0 +1,607 @@
+// Package memory provides in-memory implementations of the delivery
+// repositories for tests. They enforce the same constraints as the
+// PostgreSQL adapters so tests exercise the behavior production relies on.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// DeliveryRepository implements ports.DeliveryRepository in memory. Deliveries
+// are copied on the way in and out, so callers only see stored changes by
+// reading them back, as they would from PostgreSQL.
+type DeliveryRepository struct {
+	mu            sync.Mutex
+	deliveries    map[int]*domain.Delivery
+	outboxEvents  []*domain.OutboxEvent
+	confirmations []*domain.DeliveryConfirmation
+	slotZones     map[int]string // zone of each delivery booked into a slot
+	nextID        int
+	createErr     error
+	getByIDErr    error
+	updateErr     error
+}
+
+// NewDeliveryRepository creates an empty in-memory delivery repository
+func NewDeliveryRepository() *DeliveryRepository {
+	return &DeliveryRepository{
+		deliveries: make(map[int]*domain.Delivery),
+		slotZones:  make(map[int]string),
+		nextID:     1,
+	}
+}
+
+// AddDelivery stores a delivery as is, keeping its ID, in the default
+// organization unless its OrgID is set. Later deliveries are numbered after
+// the highest ID added.
+func (r *DeliveryRepository) AddDelivery(delivery *domain.Delivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := cloneDelivery(delivery)
+	if stored.OrgID == 0 {
+		stored.OrgID = authDomain.DefaultOrgID
+	}
+	if stored.TrackingNumber == "" {
+		stored.TrackingNumber = domain.TrackingNumberFor(stored.ID)
+	}
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+		stored.UpdatedAt = stored.CreatedAt
+	}
+	if stored.Version == 0 {
+		stored.Version = 1
+	}
+	r.deliveries[stored.ID] = stored
+	if stored.ID >= r.nextID {
+		r.nextID = stored.ID + 1
+	}
+}
+
+// SetCreateError makes every create fail with err; nil clears it
+func (r *DeliveryRepository) SetCreateError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.createErr = err
+}
+
+// SetGetByIDError makes GetByID fail with err; nil clears it
+func (r *DeliveryRepository) SetGetByIDError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.getByIDErr = err
+}
+
+// SetUpdateError makes every change to an existing delivery fail with err; nil clears it
+func (r *DeliveryRepository) SetUpdateError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updateErr = err
+}
+
+// OutboxEvents returns the events stored alongside delivery changes, oldest first
+func (r *DeliveryRepository) OutboxEvents() []*domain.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*domain.OutboxEvent(nil), r.outboxEvents...)
+}
+
+// Confirmations returns the stored proofs of delivery, oldest first
+func (r *DeliveryRepository) Confirmations() []*domain.DeliveryConfirmation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*domain.DeliveryConfirmation(nil), r.confirmations...)
+}
+
+// Create stores a new delivery, assigning its ID and tracking number
+func (r *DeliveryRepository) Create(ctx context.Context, delivery *domain.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(ctx, delivery)
+}
+
+// CreateWithOutbox stores a new delivery and the event built from it
+func (r *DeliveryRepository) CreateWithOutbox(ctx context.Context, delivery *domain.Delivery, buildEvent ports.OutboxEventBuilder) error {
+	return r.CreateBatchWithOutbox(ctx, []*domain.Delivery{delivery}, buildEvent)
+}
+
+// CreateBatchWithOutbox stores new deliveries and the events built from them,
+// storing none of them if any fails
+func (r *DeliveryRepository) CreateBatchWithOutbox(ctx context.Context, deliveries []*domain.Delivery, buildEvent ports.OutboxEventBuilder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createBatch(ctx, deliveries, buildEvent)
+}
+
+// CountBooked counts an organization's open deliveries booked into a zone's
+// slots whose scheduled start is in [start, end)
+func (r *DeliveryRepository) CountBooked(ctx context.Context, orgID int, zone string, start, end time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.countBooked(orgID, zone, start, end), nil
+}
+
+// CreateInSlotWithOutbox stores a new delivery booked into a slot and the
+// event built from it, returning domain.ErrSlotFull if the slot is full
+func (r *DeliveryRepository) CreateInSlotWithOutbox(ctx context.Context, delivery *domain.Delivery, slot domain.SlotBooking, buildEvent ports.OutboxEventBuilder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if booked := r.countBooked(slot.OrgID, slot.Zone, slot.Start, slot.End); booked >= slot.Capacity {
+		return fmt.Errorf("%w: %d of %d booked", domain.ErrSlotFull, booked, slot.Capacity)
+	}
+	if err := r.createBatch(ctx, []*domain.Delivery{delivery}, buildEvent); err != nil {
+		return err
+	}
+	r.slotZones[delivery.ID] = slot.Zone
+	return nil
+}
+
+// countBooked counts a slot's bookings; the caller holds the lock
+func (r *DeliveryRepository) countBooked(orgID int, zone string, start, end time.Time) int {
+	count := 0
+	for id, bookedZone := range r.slotZones {
+		d, ok := r.deliveries[id]
+		if !ok || bookedZone != zone || d.OrgID != orgID || d.ScheduledDate == nil || d.Status == domain.StatusCancelled {
+			continue
+		}
+		if !d.ScheduledDate.Before(start) && d.ScheduledDate.Before(end) {
+			count++
+		}
+	}
+	return count
+}
+
+// createBatch stores new deliveries and their events, or none of them; the
+// caller holds the lock
+func (r *DeliveryRepository) createBatch(ctx context.Context, deliveries []*domain.Delivery, buildEvent ports.OutboxEventBuilder) error {
+	nextID := r.nextID
+	var inserted []int
+	var events []*domain.OutboxEvent
+	rollback := func() {
+		for _, id := range inserted {
+			delete(r.deliveries, id)
+		}
+		r.nextID = nextID
+	}
+
+	for _, delivery := range deliveries {
+		if err := r.insert(ctx, delivery); err != nil {
+			rollback()
+			return err
+		}
+		inserted = append(inserted, delivery.ID)
+
+		event, err := buildEvent(delivery)
+		if err != nil {
+			rollback()
+			return err
+		}
+		events = append(events, event)
+	}
+
+	r.outboxEvents = append(r.outboxEvents, events...)
+	return nil
+}
+
+// GetByID retrieves a delivery by its ID
+func (r *DeliveryRepository) GetByID(ctx context.Context, id int) (*domain.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.getByIDErr != nil {
+		return nil, r.getByIDErr
+	}
+	delivery, ok := r.deliveries[id]
+	if !ok || !visible(ctx, delivery.OrgID) {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	return cloneDelivery(delivery), nil
+}
+
+// GetByTrackingNumber retrieves a delivery by its tracking number
+func (r *DeliveryRepository) GetByTrackingNumber(ctx context.Context, trackingNumber string) (*domain.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.deliveries {
+		if d.TrackingNumber == trackingNumber && visible(ctx, d.OrgID) {
+			return cloneDelivery(d), nil
+		}
+	}
+	return nil, domain.ErrDeliveryNotFound
+}
+
+// Search retrieves deliveries matching every criterion that is set, newest first
+func (r *DeliveryRepository) Search(ctx context.Context, criteria ports.DeliverySearch) ([]*domain.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := r.newestFirst(func(d *domain.Delivery) bool {
+		if !visible(ctx, d.OrgID) {
+			return false
+		}
+		if criteria.TrackingNumber != "" && d.TrackingNumber != criteria.TrackingNumber {
+			return false
+		}
+		if criteria.PickupContains != "" && !strings.Contains(strings.ToLower(d.PickupLocation), strings.ToLower(criteria.PickupContains)) {
+			return false
+		}
+		if criteria.From != nil && d.CreatedAt.Before(*criteria.From) {
+			return false
+		}
+		if criteria.To != nil && !d.CreatedAt.Before(*criteria.To) {
+			return false
+		}
+		if criteria.CustomerID > 0 && d.CustomerID != criteria.CustomerID {
+			return false
+		}
+		if c := criteria.ViewableByCourier; c != nil && d.Status != domain.StatusPending && (d.CourierID == nil || *d.CourierID != *c) {
+			return false
+		}
+		return true
+	})
+	if criteria.Limit > 0 && len(deliveries) > criteria.Limit {
+		deliveries = deliveries[:criteria.Limit]
+	}
+	return deliveries, nil
+}
+
+// List retrieves the page of deliveries a filter selects in its order, with
+// their total; deliveries without a scheduled date come last when sorting by it
+func (r *DeliveryRepository) List(ctx context.Context, filter ports.DeliveryFilter) ([]*domain.Delivery, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := r.newestFirst(func(d *domain.Delivery) bool {
+		switch {
+		case !visible(ctx, d.OrgID):
+			return false
+		case filter.OrgID > 0 && d.OrgID != filter.OrgID:
+			return false
+		case len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, d.Status):
+			return false
+		case filter.CustomerID > 0 && d.CustomerID != filter.CustomerID:
+			return false
+		case filter.CourierID > 0 && (d.CourierID == nil || *d.CourierID != filter.CourierID):
+			return false
+		case filter.Late != nil && d.Late != *filter.Late:
+			return false
+		case filter.TrackingNumber != "" && d.TrackingNumber != filter.TrackingNumber:
+			return false
+		case filter.From != nil && d.CreatedAt.Before(*filter.From):
+			return false
+		case filter.To != nil && !d.CreatedAt.Before(*filter.To):
+			return false
+		case filter.DeliveredFrom != nil && (d.DeliveredDate == nil || d.DeliveredDate.Before(*filter.DeliveredFrom)):
+			return false
+		case filter.DeliveredTo != nil && (d.DeliveredDate == nil || !d.DeliveredDate.Before(*filter.DeliveredTo)):
+			return false
+		}
+		return true
+	})
+
+	// key returns the sorted-by time, or nil for a missing scheduled date
+	key := func(d *domain.Delivery) *time.Time {
+		switch filter.Order.Field {
+		case domain.SortUpdatedAt:
+			return &d.UpdatedAt
+		case domain.SortScheduledDate:
+			return d.ScheduledDate
+		default:
+			return &d.CreatedAt
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		a, b := key(deliveries[i]), key(deliveries[j])
+		switch {
+		case (a == nil) != (b == nil):
+			return b == nil
+		case a != nil && !a.Equal(*b):
+			return a.Before(*b) == filter.Order.Ascending
+		default:
+			return (deliveries[i].ID < deliveries[j].ID) == filter.Order.Ascending
+		}
+	})
+
+	total := len(deliveries)
+	deliveries = deliveries[min(filter.Offset, total):]
+	if filter.Limit > 0 && len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+	return deliveries, total, nil
+}
+
+// UpdateStatus updates the status of a delivery, keeping its notes if none are given
+func (r *DeliveryRepository) UpdateStatus(ctx context.Context, id int, status, notes string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err := r.updateStatus(id, status, notes, 0)
+	return err
+}
+
+// UpdateStatusWithOutbox updates the status of a delivery and stores the event
+func (r *DeliveryRepository) UpdateStatusWithOutbox(ctx context.Context, id int, status, notes string, expectedVersion int, event *domain.OutboxEvent) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	version, err := r.updateStatus(id, status, notes, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	r.outboxEvents = append(r.outboxEvents, event)
+	return version, nil
+}
+
+// AssignCourier sets a delivery's courier; the status is left to the caller
+func (r *DeliveryRepository) AssignCourier(ctx context.Context, deliveryID, courierID, expectedVersion int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.forUpdate(deliveryID, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	stored.CourierID = &courierID
+	touch(stored)
+	return stored.Version, nil
+}
+
+// Update stores a delivery's editable fields
+func (r *DeliveryRepository) Update(ctx context.Context, delivery *domain.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.forUpdate(delivery.ID, delivery.Version)
+	if err != nil {
+		return err
+	}
+	updated := cloneDelivery(delivery)
+	stored.CustomerID = updated.CustomerID
+	stored.CourierID = updated.CourierID
+	stored.Status = updated.Status
+	stored.PickupLocation = updated.PickupLocation
+	stored.DeliveryLocation = updated.DeliveryLocation
+	stored.PickupAddress = updated.PickupAddress
+	stored.DeliveryAddress = updated.DeliveryAddress
+	stored.ScheduledDate = updated.ScheduledDate
+	stored.ScheduledEnd = updated.ScheduledEnd
+	stored.DeliveredDate = updated.DeliveredDate
+	stored.Late = updated.Late
+	stored.Notes = updated.Notes
+	touch(stored)
+	delivery.UpdatedAt = stored.UpdatedAt
+	delivery.Version = stored.Version
+	return nil
+}
+
+// ConfirmWithOutbox marks an in-transit delivery as delivered and stores its
+// proof of delivery and event, returning domain.ErrNotInTransit otherwise
+func (r *DeliveryRepository) ConfirmWithOutbox(ctx context.Context, delivery *domain.Delivery, confirmation *domain.DeliveryConfirmation, event *domain.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	stored, ok := r.deliveries[delivery.ID]
+	if !ok || stored.Status != domain.StatusInTransit {
+		return domain.ErrNotInTransit
+	}
+	stored.Status = delivery.Status
+	stored.DeliveredDate = cloneTime(delivery.DeliveredDate)
+	touch(stored)
+	delivery.Version = stored.Version
+
+	saved := *confirmation
+	saved.ID = len(r.confirmations) + 1
+	confirmation.ID = saved.ID
+	r.confirmations = append(r.confirmations, &saved)
+	r.outboxEvents = append(r.outboxEvents, event)
+	return nil
+}
+
+// CancelWithOutbox stores a delivery's cancellation and its event, returning
+// domain.ErrNotCancellable if its status changed since it was read
+func (r *DeliveryRepository) CancelWithOutbox(ctx context.Context, delivery *domain.Delivery, previousStatus string, event *domain.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	stored, ok := r.deliveries[delivery.ID]
+	if !ok || stored.Status != previousStatus {
+		return domain.ErrNotCancellable
+	}
+	stored.Status = delivery.Status
+	stored.CancelReason = delivery.CancelReason
+	stored.CancelReasonCode = delivery.CancelReasonCode
+	stored.CancelledAt = cloneTime(delivery.CancelledAt)
+	touch(stored)
+	delivery.Version = stored.Version
+	r.outboxEvents = append(r.outboxEvents, event)
+	return nil
+}
+
+// GetOverdue retrieves up to limit open deliveries whose scheduled window
+// ended before now and that are not flagged late yet, earliest window first
+func (r *DeliveryRepository) GetOverdue(ctx context.Context, now time.Time, limit int) ([]*domain.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var overdue []*domain.Delivery
+	for _, d := range r.deliveries {
+		if d.ScheduledEnd != nil && d.ScheduledEnd.Before(now) && !d.Late &&
+			d.Status != domain.StatusDelivered && d.Status != domain.StatusCancelled {
+			overdue = append(overdue, cloneDelivery(d))
+		}
+	}
+	sort.Slice(overdue, func(i, j int) bool {
+		if !overdue[i].ScheduledEnd.Equal(*overdue[j].ScheduledEnd) {
+			return overdue[i].ScheduledEnd.Before(*overdue[j].ScheduledEnd)
+		}
+		return overdue[i].ID < overdue[j].ID
+	})
+	if len(overdue) > limit {
+		overdue = overdue[:limit]
+	}
+	return overdue, nil
+}
+
+// MarkLateWithOutbox flags a delivery as late and stores the event, returning
+// domain.ErrNotOverdue if it is delivered, cancelled or already flagged
+func (r *DeliveryRepository) MarkLateWithOutbox(ctx context.Context, id int, event *domain.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	stored, ok := r.deliveries[id]
+	if !ok || stored.Late || stored.Status == domain.StatusDelivered || stored.Status == domain.StatusCancelled {
+		return domain.ErrNotOverdue
+	}
+	stored.Late = true
+	touch(stored)
+	r.outboxEvents = append(r.outboxEvents, event)
+	return nil
+}
+
+// openDeliveryFor reports whether any of a courier's deliveries are assigned or in transit
+func (r *DeliveryRepository) openDeliveryFor(courierID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.deliveries {
+		if d.CourierID != nil && *d.CourierID == courierID &&
+			(d.Status == domain.StatusAssigned || d.Status == domain.StatusInTransit) {
+			return true
+		}
+	}
+	return false
+}
+
+// insert stores a new delivery; the caller holds the lock
+func (r *DeliveryRepository) insert(ctx context.Context, delivery *domain.Delivery) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	if delivery.OrgID == 0 {
+		delivery.OrgID = authctx.Organization(ctx)
+	}
+	now := time.Now()
+	delivery.ID = r.nextID
+	delivery.TrackingNumber = domain.TrackingNumberFor(delivery.ID)
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	delivery.Version = 1
+	r.deliveries[delivery.ID] = cloneDelivery(delivery)
+	r.nextID++
+	return nil
+}
+
+// updateStatus changes a delivery's status, returning its new version; the
+// caller holds the lock
+func (r *DeliveryRepository) updateStatus(id int, status, notes string, expectedVersion int) (int, error) {
+	stored, err := r.forUpdate(id, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	stored.Status = status
+	if notes != "" {
+		stored.Notes = notes
+	}
+	touch(stored)
+	return stored.Version, nil
+}
+
+// forUpdate returns the stored delivery to change in place, checking a
+// non-zero expectedVersion against it; the caller holds the lock
+func (r *DeliveryRepository) forUpdate(id, expectedVersion int) (*domain.Delivery, error) {
+	if r.updateErr != nil {
+		return nil, r.updateErr
+	}
+	stored, ok := r.deliveries[id]
+	if !ok {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	if err := stored.CheckVersion(expectedVersion); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// touch records a change to a stored delivery
+func touch(stored *domain.Delivery) {
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+}
+
+// newestFirst returns copies of the deliveries matching keep, most recently
+// created first; the caller holds the lock
+func (r *DeliveryRepository) newestFirst(keep func(d *domain.Delivery) bool) []*domain.Delivery {
+	var deliveries []*domain.Delivery
+	for _, d := range r.deliveries {
+		if keep(d) {
+			deliveries = append(deliveries, cloneDelivery(d))
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	return deliveries
+}
+
+// cloneDelivery copies a delivery so neither copy sees the other's changes
+func cloneDelivery(d *domain.Delivery) *domain.Delivery {
+	copied := *d
+	if d.CourierID != nil {
+		courierID := *d.CourierID
+		copied.CourierID = &courierID
+	}
+	copied.ScheduledDate = cloneTime(d.ScheduledDate)
+	copied.ScheduledEnd = cloneTime(d.ScheduledEnd)
+	copied.DeliveredDate = cloneTime(d.DeliveredDate)
+	copied.CancelledAt = cloneTime(d.CancelledAt)
+	copied.PickupAddress.Coordinates = cloneCoordinates(d.PickupAddress.Coordinates)
+	copied.DeliveryAddress.Coordinates = cloneCoordinates(d.DeliveryAddress.Coordinates)
+	return &copied
+}
+
+func cloneCoordinates(c *domain.Coordinates) *domain.Coordinates {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	return &copied
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// visible reports whether the caller in ctx may read a record of an
+// organization, as the PostgreSQL adapters' org_id conditions do
+func visible(ctx context.Context, orgID int) bool {
+	scope, ok := authctx.OrgScope(ctx)
+	return !ok || scope == orgID
+}
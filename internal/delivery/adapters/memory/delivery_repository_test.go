@@ -0,0 +1,323 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+func TestDeliveryRepository_NotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+
+	if _, err := repo.GetByID(ctx, 1); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("GetByID: expected ErrDeliveryNotFound, got %v", err)
+	}
+	if _, err := repo.GetByTrackingNumber(ctx, domain.TrackingNumberFor(1)); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("GetByTrackingNumber: expected ErrDeliveryNotFound, got %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, 1, domain.StatusAssigned, ""); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("UpdateStatus: expected ErrDeliveryNotFound, got %v", err)
+	}
+	if _, err := repo.AssignCourier(ctx, 1, 2, 0); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("AssignCourier: expected ErrDeliveryNotFound, got %v", err)
+	}
+	if err := repo.Update(ctx, &domain.Delivery{ID: 1}); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("Update: expected ErrDeliveryNotFound, got %v", err)
+	}
+}
+
+func TestDeliveryRepository_CopiesDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+
+	d := &domain.Delivery{CustomerID: 1, Status: domain.StatusPending, Notes: "leave at door"}
+	if err := repo.Create(ctx, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.ID != 1 || d.TrackingNumber != domain.TrackingNumberFor(1) || d.CreatedAt.IsZero() {
+		t.Fatalf("expected the ID, tracking number and timestamps to be set, got %+v", d)
+	}
+
+	d.Status = domain.StatusCancelled
+	stored, _ := repo.GetByID(ctx, 1)
+	if stored.Status != domain.StatusPending {
+		t.Errorf("expected changes to the caller's copy not to be stored, got %s", stored.Status)
+	}
+
+	// Empty notes keep the stored ones, and assigning leaves the status alone
+	if err := repo.UpdateStatus(ctx, 1, domain.StatusAssigned, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.AssignCourier(ctx, 1, 7, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, _ = repo.GetByID(ctx, 1)
+	if stored.Notes != "leave at door" || stored.Status != domain.StatusAssigned || stored.CourierID == nil || *stored.CourierID != 7 {
+		t.Errorf("unexpected stored delivery %+v", stored)
+	}
+}
+
+func TestDeliveryRepository_ListsNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+	base := time.Now().Add(-time.Hour)
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusPending, PickupLocation: "Main St", CreatedAt: base})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CustomerID: 2, Status: domain.StatusPending, PickupLocation: "Oak Ave", CreatedAt: base.Add(time.Minute)})
+	repo.AddDelivery(&domain.Delivery{ID: 3, CustomerID: 1, Status: domain.StatusDelivered, PickupLocation: "main street", CreatedAt: base.Add(2 * time.Minute)})
+
+	pending, _, _ := repo.List(ctx, ports.DeliveryFilter{Statuses: []string{domain.StatusPending}})
+	if len(pending) != 2 || pending[0].ID != 2 || pending[1].ID != 1 {
+		t.Errorf("expected pending deliveries 2 then 1, got %v", ids(pending))
+	}
+	own, _, _ := repo.List(ctx, ports.DeliveryFilter{CustomerID: 1})
+	if len(own) != 2 || own[0].ID != 3 || own[1].ID != 1 {
+		t.Errorf("expected customer 1's deliveries 3 then 1, got %v", ids(own))
+	}
+	found, _ := repo.Search(ctx, ports.DeliverySearch{PickupContains: "MAIN", Limit: 1})
+	if len(found) != 1 || found[0].ID != 3 {
+		t.Errorf("expected the newest pickup match only, got %v", ids(found))
+	}
+}
+
+func TestDeliveryRepository_GuardsTransitions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusAssigned})
+	event := &domain.OutboxEvent{RoutingKey: "delivery.test"}
+
+	confirmed := &domain.Delivery{ID: 1, Status: domain.StatusDelivered}
+	err := repo.ConfirmWithOutbox(ctx, confirmed, &domain.DeliveryConfirmation{DeliveryID: 1}, event)
+	if !errors.Is(err, domain.ErrNotInTransit) {
+		t.Errorf("expected ErrNotInTransit, got %v", err)
+	}
+
+	cancelled := &domain.Delivery{ID: 1, Status: domain.StatusCancelled}
+	if err := repo.CancelWithOutbox(ctx, cancelled, domain.StatusPending, event); !errors.Is(err, domain.ErrNotCancellable) {
+		t.Errorf("expected ErrNotCancellable for a stale status, got %v", err)
+	}
+	if err := repo.CancelWithOutbox(ctx, cancelled, domain.StatusAssigned, event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.MarkLateWithOutbox(ctx, 1, event); !errors.Is(err, domain.ErrNotOverdue) {
+		t.Errorf("expected ErrNotOverdue for a cancelled delivery, got %v", err)
+	}
+
+	if events := repo.OutboxEvents(); len(events) != 1 {
+		t.Errorf("expected only the cancellation's event, got %d", len(events))
+	}
+}
+
+func TestDeliveryRepository_BatchIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+	failing := errors.New("bad event")
+
+	calls := 0
+	err := repo.CreateBatchWithOutbox(ctx, []*domain.Delivery{{CustomerID: 1}, {CustomerID: 1}}, func(d *domain.Delivery) (*domain.OutboxEvent, error) {
+		calls++
+		if calls == 2 {
+			return nil, failing
+		}
+		return &domain.OutboxEvent{}, nil
+	})
+	if !errors.Is(err, failing) {
+		t.Fatalf("expected the builder's error, got %v", err)
+	}
+	if _, total, _ := repo.List(ctx, ports.DeliveryFilter{}); total != 0 || len(repo.OutboxEvents()) != 0 {
+		t.Errorf("expected nothing stored, got %d deliveries", total)
+	}
+
+	d := &domain.Delivery{CustomerID: 1}
+	if err := repo.Create(ctx, d); err != nil || d.ID != 1 {
+		t.Errorf("expected IDs to restart after the rollback, got %d (%v)", d.ID, err)
+	}
+}
+
+func TestDeliveryRepository_GetOverdue(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+	now := time.Now()
+	ended := func(ago time.Duration) *time.Time {
+		at := now.Add(-ago)
+		return &at
+	}
+	repo.AddDelivery(&domain.Delivery{ID: 1, Status: domain.StatusInTransit, ScheduledEnd: ended(time.Minute)})
+	repo.AddDelivery(&domain.Delivery{ID: 2, Status: domain.StatusAssigned, ScheduledEnd: ended(time.Hour)})
+	repo.AddDelivery(&domain.Delivery{ID: 3, Status: domain.StatusDelivered, ScheduledEnd: ended(time.Hour)})
+	repo.AddDelivery(&domain.Delivery{ID: 4, Status: domain.StatusPending, ScheduledEnd: ended(time.Hour), Late: true})
+	repo.AddDelivery(&domain.Delivery{ID: 5, Status: domain.StatusPending, ScheduledEnd: ended(-time.Hour)})
+
+	overdue, _ := repo.GetOverdue(ctx, now, 10)
+	if len(overdue) != 2 || overdue[0].ID != 2 || overdue[1].ID != 1 {
+		t.Errorf("expected deliveries 2 then 1, got %v", ids(overdue))
+	}
+}
+
+func ids(deliveries []*domain.Delivery) []int {
+	result := make([]int, len(deliveries))
+	for i, d := range deliveries {
+		result[i] = d.ID
+	}
+	return result
+}
+
+func TestDeliveryRepository_ListOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	updated := []time.Time{base.Add(2 * time.Hour), base, base.Add(2 * time.Hour)}
+	for range updated {
+		if err := repo.Create(ctx, &domain.Delivery{CustomerID: 1, Status: domain.StatusPending}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for i, at := range updated {
+		repo.deliveries[i+1].UpdatedAt = at
+	}
+	if err := repo.Create(ctx, &domain.Delivery{CustomerID: 2, Status: domain.StatusPending}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		order    domain.ListOrder
+		expected []int
+	}{
+		{domain.ListOrder{Field: domain.SortUpdatedAt}, []int{3, 1, 2}},
+		{domain.ListOrder{Field: domain.SortUpdatedAt, Ascending: true}, []int{2, 1, 3}},
+	}
+	for _, tt := range tests {
+		deliveries, _, err := repo.List(ctx, ports.DeliveryFilter{CustomerID: 1, Order: tt.order})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var ids []int
+		for _, d := range deliveries {
+			ids = append(ids, d.ID)
+		}
+		if !slices.Equal(ids, tt.expected) {
+			t.Errorf("%+v: expected %v, got %v", tt.order, tt.expected, ids)
+		}
+	}
+}
+
+func TestDeliveryRepository_ListFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+	base := time.Now().Add(-time.Hour)
+	courier := 7
+	deliveredAt := base.Add(10 * time.Minute)
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusDelivered, CourierID: &courier, CreatedAt: base, DeliveredDate: &deliveredAt})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CustomerID: 1, Status: domain.StatusInTransit, CourierID: &courier, Late: true, CreatedAt: base.Add(time.Minute)})
+	repo.AddDelivery(&domain.Delivery{ID: 3, CustomerID: 2, Status: domain.StatusPending, CreatedAt: base.Add(2 * time.Minute)})
+	repo.AddDelivery(&domain.Delivery{ID: 4, CustomerID: 1, Status: domain.StatusPending, CreatedAt: base.Add(3 * time.Minute)})
+
+	late, notLate := true, false
+	from, to := base.Add(time.Minute), base.Add(3*time.Minute)
+	deliveredFrom, deliveredTo := base.Add(5*time.Minute), base.Add(20*time.Minute)
+	tests := []struct {
+		name     string
+		filter   ports.DeliveryFilter
+		expected []int
+		total    int
+	}{
+		{name: "everything", filter: ports.DeliveryFilter{}, expected: []int{4, 3, 2, 1}, total: 4},
+		{name: "courier", filter: ports.DeliveryFilter{CourierID: courier}, expected: []int{2, 1}, total: 2},
+		{name: "late", filter: ports.DeliveryFilter{Late: &late}, expected: []int{2}, total: 1},
+		{name: "not late for customer", filter: ports.DeliveryFilter{Late: &notLate, CustomerID: 1}, expected: []int{4, 1}, total: 2},
+		{name: "created range", filter: ports.DeliveryFilter{From: &from, To: &to}, expected: []int{3, 2}, total: 2},
+		{name: "delivered range", filter: ports.DeliveryFilter{DeliveredFrom: &deliveredFrom, DeliveredTo: &deliveredTo}, expected: []int{1}, total: 1},
+		{name: "first page", filter: ports.DeliveryFilter{Limit: 3}, expected: []int{4, 3, 2}, total: 4},
+		{name: "last page", filter: ports.DeliveryFilter{Limit: 3, Offset: 3}, expected: []int{1}, total: 4},
+		{name: "past the end", filter: ports.DeliveryFilter{Limit: 3, Offset: 6}, total: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries, total, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := ids(deliveries); !slices.Equal(got, tt.expected) || total != tt.total {
+				t.Errorf("expected %v of %d, got %v of %d", tt.expected, tt.total, got, total)
+			}
+		})
+	}
+}
+
+func TestDeliveryRepository_Versions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeliveryRepository()
+
+	d := &domain.Delivery{CustomerID: 1, Status: domain.StatusPending}
+	if err := repo.Create(ctx, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 1 {
+		t.Fatalf("expected a new delivery at version 1, got %d", d.Version)
+	}
+
+	if version, err := repo.AssignCourier(ctx, 1, 7, 1); err != nil || version != 2 {
+		t.Fatalf("expected version 2, got %d, %v", version, err)
+	}
+	var conflict *domain.ConflictError
+	if _, err := repo.UpdateStatusWithOutbox(ctx, 1, domain.StatusAssigned, "", 1, nil); !errors.As(err, &conflict) || conflict.CurrentVersion != 2 {
+		t.Fatalf("expected a conflict at version 2, got %v", err)
+	}
+
+	// Update is guarded by the delivery's own version
+	d.Notes = "stale"
+	if err := repo.Update(ctx, d); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	stored, _ := repo.GetByID(ctx, 1)
+	stored.Notes = "fresh"
+	if err := repo.Update(ctx, stored); err != nil || stored.Version != 3 {
+		t.Fatalf("expected version 3, got %d, %v", stored.Version, err)
+	}
+}
+
+func TestDeliveryRepository_ScopesByOrganization(t *testing.T) {
+	repo := NewDeliveryRepository()
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusPending})
+
+	orgAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin, OrgID: 2})
+	created := &domain.Delivery{CustomerID: 2, Status: domain.StatusPending}
+	if err := repo.Create(orgAdmin, created); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.OrgID != 2 {
+		t.Errorf("expected the delivery in the creator's organization, got %d", created.OrgID)
+	}
+
+	if _, err := repo.GetByID(orgAdmin, 1); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("expected another organization's delivery to be hidden, got %v", err)
+	}
+	if _, err := repo.GetByTrackingNumber(orgAdmin, domain.TrackingNumberFor(1)); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("expected another organization's tracking number to be hidden, got %v", err)
+	}
+	if deliveries, total, _ := repo.List(orgAdmin, ports.DeliveryFilter{}); total != 1 || deliveries[0].ID != created.ID {
+		t.Errorf("expected only the organization's delivery, got %d", total)
+	}
+	if found, _ := repo.Search(orgAdmin, ports.DeliverySearch{}); len(found) != 1 {
+		t.Errorf("expected search to see only the organization's delivery, got %d", len(found))
+	}
+
+	// Super-admins and background jobs see every organization
+	superAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{UserID: 2, Role: authDomain.RoleSuperAdmin})
+	for name, ctx := range map[string]context.Context{"super-admin": superAdmin, "no claims": context.Background()} {
+		if _, total, _ := repo.List(ctx, ports.DeliveryFilter{}); total != 2 {
+			t.Errorf("%s: expected both deliveries, got %d", name, total)
+		}
+	}
+	if _, total, _ := repo.List(superAdmin, ports.DeliveryFilter{OrgID: authDomain.DefaultOrgID}); total != 1 {
+		t.Errorf("expected the filter to select the default organization's delivery, got %d", total)
+	}
+}
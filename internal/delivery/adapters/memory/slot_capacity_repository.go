@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+)
+
+// slotZone identifies an organization's zone
+type slotZone struct {
+	orgID int
+	zone  string
+}
+
+// SlotCapacityRepository implements ports.SlotCapacityRepository in memory
+type SlotCapacityRepository struct {
+	mu         sync.Mutex
+	capacities map[slotZone][]domain.SlotCapacity
+}
+
+// NewSlotCapacityRepository creates an empty in-memory slot capacity repository
+func NewSlotCapacityRepository() *SlotCapacityRepository {
+	return &SlotCapacityRepository{capacities: make(map[slotZone][]domain.SlotCapacity)}
+}
+
+// ListCapacities retrieves an organization's slot capacities in a zone by start hour
+func (r *SlotCapacityRepository) ListCapacities(ctx context.Context, orgID int, zone string) ([]domain.SlotCapacity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.SlotCapacity{}, r.capacities[slotZone{orgID, zone}]...), nil
+}
+
+// ReplaceCapacities replaces all of an organization's slot capacities in a
+// zone; they are expected sorted by start hour, as
+// domain.ValidateSlotCapacities leaves them
+func (r *SlotCapacityRepository) ReplaceCapacities(ctx context.Context, orgID int, zone string, capacities []domain.SlotCapacity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := slotZone{orgID, zone}
+	if len(capacities) == 0 {
+		delete(r.capacities, key)
+		return nil
+	}
+	r.capacities[key] = append([]domain.SlotCapacity(nil), capacities...)
+	return nil
+}
@@ -0,0 +1,184 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/lib/pq"
+)
+
+// PostgresOutboxRepository implements the OutboxRepository interface using PostgreSQL
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresOutboxRepository creates a new PostgreSQL outbox repository
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+// insertOutboxEvent stores an outbox event using the given connection or transaction
+func insertOutboxEvent(ctx context.Context, q queryRower, event *domain.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_events (aggregate_id, exchange, routing_key, payload, status, attempts, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	return q.QueryRowContext(
+		ctx,
+		query,
+		event.AggregateID,
+		event.Exchange,
+		event.RoutingKey,
+		event.Payload,
+		event.Status,
+		event.Attempts,
+		event.NextAttemptAt,
+	).Scan(&event.ID, &event.CreatedAt)
+}
+
+// FetchPending claims pending events whose next attempt is due, oldest
+// first. Rows another dispatcher is claiming are skipped, and claimed ones
+// are pushed back by lease so that no other dispatcher publishes them while
+// they are attempted.
+func (r *PostgresOutboxRepository) FetchPending(ctx context.Context, limit int, lease time.Duration) ([]*domain.OutboxEvent, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM outbox_events
+			WHERE status = $1 AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE outbox_events o
+			SET next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond'
+			FROM due
+			WHERE o.id = due.id
+			RETURNING o.id, o.aggregate_id, o.exchange, o.routing_key, o.payload, o.status, o.attempts,
+			          o.last_error, o.next_attempt_at, o.sent_at, o.created_at
+		)
+		SELECT id, aggregate_id, exchange, routing_key, payload, status, attempts,
+		       last_error, next_attempt_at, sent_at, created_at
+		FROM claimed
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.OutboxStatusPending, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	return scanOutboxEvents(rows)
+}
+
+// ListPublished retrieves sent and dead events created in the filter's range, oldest first
+func (r *PostgresOutboxRepository) ListPublished(ctx context.Context, filter ports.OutboxRangeFilter) ([]*domain.OutboxEvent, error) {
+	query := `
+		SELECT id, aggregate_id, exchange, routing_key, payload, status, attempts,
+		       last_error, next_attempt_at, sent_at, created_at
+		FROM outbox_events
+		WHERE status IN ($1, $2) AND created_at >= $3 AND created_at < $4 AND id > $5
+		  AND (cardinality($6::text[]) = 0 OR routing_key = ANY($6))
+		ORDER BY id
+		LIMIT $7
+	`
+
+	routingKeys := filter.RoutingKeys
+	if routingKeys == nil {
+		routingKeys = []string{} // a NULL array would match nothing
+	}
+	rows, err := r.db.QueryContext(ctx, query, domain.OutboxStatusSent, domain.OutboxStatusDead,
+		filter.From, filter.To, filter.AfterID, pq.Array(routingKeys), filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanOutboxEvents(rows)
+}
+
+// scanOutboxEvents reads the outbox rows selected by FetchPending and ListPublished
+func scanOutboxEvents(rows *sql.Rows) ([]*domain.OutboxEvent, error) {
+	defer rows.Close()
+
+	var events []*domain.OutboxEvent
+	for rows.Next() {
+		var e domain.OutboxEvent
+		var lastError sql.NullString
+		var sentAt sql.NullTime
+
+		err := rows.Scan(
+			&e.ID,
+			&e.AggregateID,
+			&e.Exchange,
+			&e.RoutingKey,
+			&e.Payload,
+			&e.Status,
+			&e.Attempts,
+			&lastError,
+			&e.NextAttemptAt,
+			&sentAt,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if lastError.Valid {
+			e.LastError = lastError.String
+		}
+		if sentAt.Valid {
+			e.SentAt = &sentAt.Time
+		}
+
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkSent marks an event as successfully published
+func (r *PostgresOutboxRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	query := `
+		UPDATE outbox_events
+		SET status = $1, sent_at = $2, last_error = NULL
+		WHERE id = $3
+	`
+
+	_, err := r.db.ExecContext(ctx, query, domain.OutboxStatusSent, sentAt, id)
+	return err
+}
+
+// MarkFailed records a failed publish attempt
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, event *domain.OutboxEvent) error {
+	query := `
+		UPDATE outbox_events
+		SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4
+		WHERE id = $5
+	`
+
+	_, err := r.db.ExecContext(ctx, query, event.Status, event.Attempts, event.LastError, event.NextAttemptAt, event.ID)
+	return err
+}
+
+// Stats returns pending/dead counts and the age of the oldest pending event
+func (r *PostgresOutboxRepository) Stats(ctx context.Context) (*domain.OutboxStats, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'pending'),
+			COUNT(*) FILTER (WHERE status = 'dead'),
+			COALESCE(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - MIN(created_at) FILTER (WHERE status = 'pending'))), 0)
+		FROM outbox_events
+	`
+
+	var stats domain.OutboxStats
+	var oldestSeconds float64
+	if err := r.db.QueryRowContext(ctx, query).Scan(&stats.Pending, &stats.Dead, &oldestSeconds); err != nil {
+		return nil, err
+	}
+	stats.OldestPendingAge = time.Duration(oldestSeconds * float64(time.Second))
+
+	return &stats, nil
+}
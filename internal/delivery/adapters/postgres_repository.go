@@ -3,10 +3,20 @@ package adapters
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 )
 
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // PostgresDeliveryRepository implements the DeliveryRepository interface using PostgreSQL
 type PostgresDeliveryRepository struct {
 	db *sql.DB
@@ -19,10 +29,141 @@ func NewPostgresDeliveryRepository(db *sql.DB) *PostgresDeliveryRepository {
 
 // Create stores a new delivery
 func (r *PostgresDeliveryRepository) Create(ctx context.Context, delivery *domain.Delivery) error {
+	return insertDelivery(ctx, r.db, delivery)
+}
+
+// CreateWithOutbox stores a new delivery and its outbox event in a single transaction
+func (r *PostgresDeliveryRepository) CreateWithOutbox(ctx context.Context, delivery *domain.Delivery, buildEvent ports.OutboxEventBuilder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertDelivery(ctx, tx, delivery); err != nil {
+		return err
+	}
+
+	event, err := buildEvent(delivery)
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateBatchWithOutbox stores new deliveries and their outbox events in a single transaction
+func (r *PostgresDeliveryRepository) CreateBatchWithOutbox(ctx context.Context, deliveries []*domain.Delivery, buildEvent ports.OutboxEventBuilder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, delivery := range deliveries {
+		if err := insertDelivery(ctx, tx, delivery); err != nil {
+			return err
+		}
+
+		event, err := buildEvent(delivery)
+		if err != nil {
+			return err
+		}
+
+		if err := insertOutboxEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CountBooked counts an organization's open deliveries booked into a zone's
+// slots whose scheduled start is in [start, end)
+func (r *PostgresDeliveryRepository) CountBooked(ctx context.Context, orgID int, zone string, start, end time.Time) (int, error) {
+	return countBooked(ctx, r.db, orgID, zone, start, end)
+}
+
+// CreateInSlotWithOutbox stores a new delivery booked into a slot and its
+// outbox event in a single transaction. A transaction-scoped advisory lock on
+// the slot serializes concurrent bookings, so each sees the bookings
+// committed before it and the slot is never over-booked.
+func (r *PostgresDeliveryRepository) CreateInSlotWithOutbox(ctx context.Context, delivery *domain.Delivery, slot domain.SlotBooking, buildEvent ports.OutboxEventBuilder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	lockKey := fmt.Sprintf("delivery_slot:%d:%s:%d", slot.OrgID, slot.Zone, slot.Start.Unix())
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, lockKey); err != nil {
+		return err
+	}
+
+	booked, err := countBooked(ctx, tx, slot.OrgID, slot.Zone, slot.Start, slot.End)
+	if err != nil {
+		return err
+	}
+	if booked >= slot.Capacity {
+		return fmt.Errorf("%w: %d of %d booked", domain.ErrSlotFull, booked, slot.Capacity)
+	}
+
+	if err := insertDelivery(ctx, tx, delivery); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO delivery_slot_bookings (delivery_id, zone) VALUES ($1, $2)`, delivery.ID, slot.Zone); err != nil {
+		return err
+	}
+
+	event, err := buildEvent(delivery)
+	if err != nil {
+		return err
+	}
+	if err := insertOutboxEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// countBooked counts a slot's bookings using the given connection or transaction
+func countBooked(ctx context.Context, q queryRower, orgID int, zone string, start, end time.Time) (int, error) {
 	query := `
-		INSERT INTO deliveries (customer_id, courier_id, status, pickup_location, delivery_location, scheduled_date, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at, updated_at
+		SELECT COUNT(*)
+		FROM delivery_slot_bookings b
+		JOIN deliveries d ON d.id = b.delivery_id
+		WHERE b.zone = $1 AND d.scheduled_date >= $2 AND d.scheduled_date < $3 AND d.status <> 'cancelled'
+			AND d.org_id = $4
+	`
+
+	var count int
+	if err := q.QueryRowContext(ctx, query, zone, start, end, orgID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// insertDelivery inserts a delivery row using the given connection or transaction.
+// The ID is drawn first so the row is stored with its tracking number.
+func insertDelivery(ctx context.Context, q queryRower, delivery *domain.Delivery) error {
+	var id int
+	err := q.QueryRowContext(ctx, `SELECT nextval(pg_get_serial_sequence('deliveries', 'id'))`).Scan(&id)
+	if err != nil {
+		return err
+	}
+	trackingNumber := domain.TrackingNumberFor(id)
+
+	query := `
+		INSERT INTO deliveries (id, tracking_number, customer_id, courier_id, status, pickup_location, delivery_location, scheduled_date, scheduled_end, notes,
+		                        pickup_line1, pickup_city, pickup_postal_code, pickup_country, pickup_latitude, pickup_longitude,
+		                        delivery_line1, delivery_city, delivery_postal_code, delivery_country, delivery_latitude, delivery_longitude,
+		                        quoted_price_cents, quoted_currency, org_id, confirmation_code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
+		RETURNING created_at, updated_at, version
 	`
 
 	var courierID sql.NullInt64
@@ -35,52 +176,85 @@ func (r *PostgresDeliveryRepository) Create(ctx context.Context, delivery *domai
 		scheduledDate = sql.NullTime{Time: *delivery.ScheduledDate, Valid: true}
 	}
 
-	err := r.db.QueryRowContext(
-		ctx,
-		query,
+	var scheduledEnd sql.NullTime
+	if delivery.ScheduledEnd != nil {
+		scheduledEnd = sql.NullTime{Time: *delivery.ScheduledEnd, Valid: true}
+	}
+
+	args := []interface{}{
+		id,
+		trackingNumber,
 		delivery.CustomerID,
 		courierID,
 		delivery.Status,
 		delivery.PickupLocation,
 		delivery.DeliveryLocation,
 		scheduledDate,
+		scheduledEnd,
 		delivery.Notes,
-	).Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+	}
+	args = append(args, addressArgs(delivery.PickupAddress)...)
+	args = append(args, addressArgs(delivery.DeliveryAddress)...)
+	args = append(args, quoteArgs(delivery)...)
+	if delivery.OrgID == 0 {
+		delivery.OrgID = authctx.Organization(ctx)
+	}
+	args = append(args, delivery.OrgID, delivery.ConfirmationCode)
+	err = q.QueryRowContext(ctx, query, args...).Scan(&delivery.CreatedAt, &delivery.UpdatedAt, &delivery.Version)
 
 	if err != nil {
 		return err
 	}
 
+	delivery.ID = id
+	delivery.TrackingNumber = trackingNumber
 	return nil
 }
 
 // GetByID retrieves a delivery by its ID
 func (r *PostgresDeliveryRepository) GetByID(ctx context.Context, id int) (*domain.Delivery, error) {
 	query := `
-		SELECT id, customer_id, courier_id, status, pickup_location, delivery_location, 
-		       scheduled_date, delivered_date, notes, created_at, updated_at 
+		SELECT id, tracking_number, customer_id, courier_id, status, pickup_location, delivery_location, 
+		       scheduled_date, scheduled_end, delivered_date, late, notes, cancel_reason, cancel_reason_code, cancelled_at, created_at, updated_at, version, 
+		       pickup_line1, pickup_city, pickup_postal_code, pickup_country, pickup_latitude, pickup_longitude, 
+		       delivery_line1, delivery_city, delivery_postal_code, delivery_country, delivery_latitude, delivery_longitude, 
+		       quoted_price_cents, quoted_currency, org_id, confirmation_code 
 		FROM deliveries 
-		WHERE id = $1
+		WHERE id = $1 AND ($2 = 0 OR org_id = $2)
 	`
 
 	var d domain.Delivery
 	var courierID sql.NullInt64
-	var pickupLocation, deliveryLocation, notes sql.NullString
-	var scheduledDate, deliveredDate sql.NullTime
+	var pickupLocation, deliveryLocation, notes, cancelReason, cancelReasonCode sql.NullString
+	var scheduledDate, scheduledEnd, deliveredDate, cancelledAt sql.NullTime
+	var pickup, dropoff addressColumns
+	var quote quoteColumns
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	dest := []interface{}{
 		&d.ID,
+		&d.TrackingNumber,
 		&d.CustomerID,
 		&courierID,
 		&d.Status,
 		&pickupLocation,
 		&deliveryLocation,
 		&scheduledDate,
+		&scheduledEnd,
 		&deliveredDate,
+		&d.Late,
 		&notes,
+		&cancelReason,
+		&cancelReasonCode,
+		&cancelledAt,
 		&d.CreatedAt,
 		&d.UpdatedAt,
-	)
+		&d.Version,
+	}
+	dest = append(dest, pickup.dest()...)
+	dest = append(dest, dropoff.dest()...)
+	dest = append(dest, quote.dest()...)
+	dest = append(dest, &d.OrgID, &d.ConfirmationCode)
+	err := r.db.QueryRowContext(ctx, query, id, orgScope(ctx)).Scan(dest...)
 
 	if err == sql.ErrNoRows {
 		return nil, domain.ErrDeliveryNotFound
@@ -103,75 +277,81 @@ func (r *PostgresDeliveryRepository) GetByID(ctx context.Context, id int) (*doma
 	if scheduledDate.Valid {
 		d.ScheduledDate = &scheduledDate.Time
 	}
+	if scheduledEnd.Valid {
+		d.ScheduledEnd = &scheduledEnd.Time
+	}
 	if deliveredDate.Valid {
 		d.DeliveredDate = &deliveredDate.Time
 	}
 	if notes.Valid {
 		d.Notes = notes.String
 	}
+	if cancelReason.Valid {
+		d.CancelReason = cancelReason.String
+	}
+	if cancelReasonCode.Valid {
+		d.CancelReasonCode = cancelReasonCode.String
+	}
+	if cancelledAt.Valid {
+		d.CancelledAt = &cancelledAt.Time
+	}
+	d.PickupAddress = pickup.address()
+	d.DeliveryAddress = dropoff.address()
+	quote.apply(&d)
 
 	return &d, nil
 }
 
-// GetByStatus retrieves deliveries by status with optional customer filter
-func (r *PostgresDeliveryRepository) GetByStatus(ctx context.Context, status string, customerID int) ([]*domain.Delivery, error) {
-	var query string
-	var rows *sql.Rows
-	var err error
-
-	if customerID > 0 {
-		query = `
-			SELECT id, customer_id, courier_id, status, pickup_location, delivery_location, 
-			       scheduled_date, delivered_date, notes, created_at, updated_at 
-			FROM deliveries 
-			WHERE status = $1 AND customer_id = $2 
-			ORDER BY created_at DESC
-		`
-		rows, err = r.db.QueryContext(ctx, query, status, customerID)
-	} else {
-		query = `
-			SELECT id, customer_id, courier_id, status, pickup_location, delivery_location, 
-			       scheduled_date, delivered_date, notes, created_at, updated_at 
-			FROM deliveries 
-			WHERE status = $1 
-			ORDER BY created_at DESC
-		`
-		rows, err = r.db.QueryContext(ctx, query, status)
-	}
+// GetByTrackingNumber retrieves a delivery by its tracking number
+func (r *PostgresDeliveryRepository) GetByTrackingNumber(ctx context.Context, trackingNumber string) (*domain.Delivery, error) {
+	query := `
+		SELECT id, tracking_number, customer_id, courier_id, status, pickup_location, delivery_location, 
+		       scheduled_date, scheduled_end, delivered_date, late, notes, cancel_reason, cancel_reason_code, cancelled_at, created_at, updated_at, version, 
+		       pickup_line1, pickup_city, pickup_postal_code, pickup_country, pickup_latitude, pickup_longitude, 
+		       delivery_line1, delivery_city, delivery_postal_code, delivery_country, delivery_latitude, delivery_longitude, 
+		       quoted_price_cents, quoted_currency, org_id, confirmation_code 
+		FROM deliveries 
+		WHERE tracking_number = $1 AND ($2 = 0 OR org_id = $2)
+	`
 
+	rows, err := r.db.QueryContext(ctx, query, trackingNumber, orgScope(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return r.scanDeliveries(rows)
+	deliveries, err := r.scanDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	return deliveries[0], nil
 }
 
-// GetAll retrieves all deliveries with optional customer filter
-func (r *PostgresDeliveryRepository) GetAll(ctx context.Context, customerID int) ([]*domain.Delivery, error) {
-	var query string
-	var rows *sql.Rows
-	var err error
-
-	if customerID > 0 {
-		query = `
-			SELECT id, customer_id, courier_id, status, pickup_location, delivery_location, 
-			       scheduled_date, delivered_date, notes, created_at, updated_at 
-			FROM deliveries 
-			WHERE customer_id = $1 
-			ORDER BY created_at DESC
-		`
-		rows, err = r.db.QueryContext(ctx, query, customerID)
-	} else {
-		query = `
-			SELECT id, customer_id, courier_id, status, pickup_location, delivery_location, 
-			       scheduled_date, delivered_date, notes, created_at, updated_at 
-			FROM deliveries 
-			ORDER BY created_at DESC
-		`
-		rows, err = r.db.QueryContext(ctx, query)
+// Search retrieves deliveries matching every criterion that is set, newest first
+func (r *PostgresDeliveryRepository) Search(ctx context.Context, criteria ports.DeliverySearch) ([]*domain.Delivery, error) {
+	q := filterQuery(ports.DeliveryFilter{
+		OrgID:          orgScope(ctx),
+		TrackingNumber: criteria.TrackingNumber,
+		CustomerID:     criteria.CustomerID,
+		From:           criteria.From,
+		To:             criteria.To,
+	})
+	if criteria.PickupContains != "" {
+		q.where("pickup_location ILIKE ?", "%"+likeEscaper.Replace(criteria.PickupContains)+"%")
+	}
+	if criteria.ViewableByCourier != nil {
+		q.where("(courier_id = ? OR status = 'pending')", *criteria.ViewableByCourier)
 	}
 
+	query := "SELECT " + deliveryColumns + " FROM deliveries " + q.whereClause() + "ORDER BY created_at DESC"
+	if criteria.Limit > 0 {
+		query += " LIMIT " + q.param(criteria.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -180,50 +360,219 @@ func (r *PostgresDeliveryRepository) GetAll(ctx context.Context, customerID int)
 	return r.scanDeliveries(rows)
 }
 
+// likeEscaper escapes LIKE wildcards so user input only matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// List retrieves the page of deliveries a filter selects in its order, with
+// their total
+func (r *PostgresDeliveryRepository) List(ctx context.Context, filter ports.DeliveryFilter) ([]*domain.Delivery, int, error) {
+	if filter.OrgID == 0 {
+		filter.OrgID = orgScope(ctx)
+	}
+	query, err := newListQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query.count, query.countArgs...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query.list, query.listArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries, err := r.scanDeliveries(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
 // UpdateStatus updates the status of a delivery
 func (r *PostgresDeliveryRepository) UpdateStatus(ctx context.Context, id int, status, notes string) error {
+	_, err := updateDeliveryStatus(ctx, r.db, id, status, notes, 0)
+	return err
+}
+
+// UpdateStatusWithOutbox updates the status of a delivery and stores its outbox event in a single transaction
+func (r *PostgresDeliveryRepository) UpdateStatusWithOutbox(ctx context.Context, id int, status, notes string, expectedVersion int, event *domain.OutboxEvent) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	version, err := updateDeliveryStatus(ctx, tx, id, status, notes, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, event); err != nil {
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
+
+// updateDeliveryStatus updates a delivery status using the given connection or
+// transaction, returning its new version
+func updateDeliveryStatus(ctx context.Context, q queryRower, id int, status, notes string, expectedVersion int) (int, error) {
 	query := `
 		UPDATE deliveries 
-		SET status = $1, notes = COALESCE(NULLIF($2, ''), notes), updated_at = CURRENT_TIMESTAMP
-		WHERE id = $3
-		RETURNING id
+		SET status = $1, notes = COALESCE(NULLIF($2, ''), notes), updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $3 AND ($4 = 0 OR version = $4)
+		RETURNING version
 	`
 
-	var returnedID int
-	err := r.db.QueryRowContext(ctx, query, status, notes, id).Scan(&returnedID)
+	var version int
+	err := q.QueryRowContext(ctx, query, status, notes, id, expectedVersion).Scan(&version)
 	if err == sql.ErrNoRows {
+		return 0, notUpdated(ctx, q, id, expectedVersion)
+	}
+	return version, err
+}
+
+// notUpdated explains why an update of delivery id matched no row: it doesn't
+// exist, or its version is no longer expectedVersion
+func notUpdated(ctx context.Context, q queryRower, id, expectedVersion int) error {
+	if expectedVersion == 0 {
 		return domain.ErrDeliveryNotFound
 	}
-	return err
+
+	var version int
+	err := q.QueryRowContext(ctx, `SELECT version FROM deliveries WHERE id = $1`, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return domain.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return &domain.ConflictError{CurrentVersion: version}
 }
 
-// AssignCourier assigns a courier to a delivery
-func (r *PostgresDeliveryRepository) AssignCourier(ctx context.Context, deliveryID, courierID int) error {
-	query := `
+// ConfirmWithOutbox marks an in-transit delivery as delivered and stores its proof of
+// delivery and outbox event in a single transaction
+func (r *PostgresDeliveryRepository) ConfirmWithOutbox(ctx context.Context, delivery *domain.Delivery, confirmation *domain.DeliveryConfirmation, event *domain.OutboxEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Guard on the current status so concurrent confirmations can't both succeed
+	var deliveredDate sql.NullTime
+	if delivery.DeliveredDate != nil {
+		deliveredDate = sql.NullTime{Time: *delivery.DeliveredDate, Valid: true}
+	}
+	err = tx.QueryRowContext(ctx, `
 		UPDATE deliveries 
-		SET courier_id = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2
+		SET status = $1, delivered_date = $2, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $3 AND status = $4
+		RETURNING version
+	`, delivery.Status, deliveredDate, delivery.ID, domain.StatusInTransit).Scan(&delivery.Version)
+	if err == sql.ErrNoRows {
+		return domain.ErrNotInTransit
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO delivery_confirmations (delivery_id, courier_id, recipient_name, confirmation_code, photo_key, signature_key, notes, confirmed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id
+	`,
+		confirmation.DeliveryID,
+		confirmation.CourierID,
+		sql.NullString{String: confirmation.RecipientName, Valid: confirmation.RecipientName != ""},
+		sql.NullString{String: confirmation.ConfirmationCode, Valid: confirmation.ConfirmationCode != ""},
+		sql.NullString{String: confirmation.PhotoKey, Valid: confirmation.PhotoKey != ""},
+		sql.NullString{String: confirmation.SignatureKey, Valid: confirmation.SignatureKey != ""},
+		sql.NullString{String: confirmation.Notes, Valid: confirmation.Notes != ""},
+		confirmation.ConfirmedAt,
+	).Scan(&confirmation.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CancelWithOutbox stores a delivery's cancellation and its outbox event in a
+// single transaction. It returns domain.ErrNotCancellable if the delivery's
+// status changed since it was read.
+func (r *PostgresDeliveryRepository) CancelWithOutbox(ctx context.Context, delivery *domain.Delivery, previousStatus string, event *domain.OutboxEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE deliveries 
+		SET status = $1, cancel_reason = $2, cancel_reason_code = $3, cancelled_at = $4, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $5 AND status = $6
+		RETURNING version
+	`,
+		delivery.Status,
+		delivery.CancelReason,
+		sql.NullString{String: delivery.CancelReasonCode, Valid: delivery.CancelReasonCode != ""},
+		delivery.CancelledAt,
+		delivery.ID,
+		previousStatus,
+	).Scan(&delivery.Version)
+	if err == sql.ErrNoRows {
+		return domain.ErrNotCancellable
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AssignCourier assigns a courier to a delivery, returning its new version
+func (r *PostgresDeliveryRepository) AssignCourier(ctx context.Context, deliveryID, courierID, expectedVersion int) (int, error) {
+	query := `
+		UPDATE deliveries 
+		SET courier_id = $1, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $2 AND ($3 = 0 OR version = $3)
+		RETURNING version
 	`
 
-	var returnedID int
-	err := r.db.QueryRowContext(ctx, query, courierID, deliveryID).Scan(&returnedID)
+	var version int
+	err := r.db.QueryRowContext(ctx, query, courierID, deliveryID, expectedVersion).Scan(&version)
 	if err == sql.ErrNoRows {
-		return domain.ErrDeliveryNotFound
+		return 0, notUpdated(ctx, r.db, deliveryID, expectedVersion)
 	}
-	return err
+	return version, err
 }
 
-// Update updates a delivery
+// Update updates a delivery, guarded by its version unless that is zero
 func (r *PostgresDeliveryRepository) Update(ctx context.Context, delivery *domain.Delivery) error {
 	query := `
 		UPDATE deliveries 
 		SET customer_id = $1, courier_id = $2, status = $3, 
 		    pickup_location = $4, delivery_location = $5, 
-		    scheduled_date = $6, delivered_date = $7, notes = $8, 
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $9
-		RETURNING updated_at
+		    scheduled_date = $6, scheduled_end = $7, delivered_date = $8, 
+		    late = $9, notes = $10, 
+		    pickup_line1 = $12, pickup_city = $13, pickup_postal_code = $14, pickup_country = $15, pickup_latitude = $16, pickup_longitude = $17, 
+		    delivery_line1 = $18, delivery_city = $19, delivery_postal_code = $20, delivery_country = $21, delivery_latitude = $22, delivery_longitude = $23, 
+		    updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $11 AND ($24 = 0 OR version = $24)
+		RETURNING updated_at, version
 	`
 
 	var courierID sql.NullInt64
@@ -236,31 +585,96 @@ func (r *PostgresDeliveryRepository) Update(ctx context.Context, delivery *domai
 		scheduledDate = sql.NullTime{Time: *delivery.ScheduledDate, Valid: true}
 	}
 
+	var scheduledEnd sql.NullTime
+	if delivery.ScheduledEnd != nil {
+		scheduledEnd = sql.NullTime{Time: *delivery.ScheduledEnd, Valid: true}
+	}
+
 	var deliveredDate sql.NullTime
 	if delivery.DeliveredDate != nil {
 		deliveredDate = sql.NullTime{Time: *delivery.DeliveredDate, Valid: true}
 	}
 
-	err := r.db.QueryRowContext(
-		ctx,
-		query,
+	args := []interface{}{
 		delivery.CustomerID,
 		courierID,
 		delivery.Status,
 		delivery.PickupLocation,
 		delivery.DeliveryLocation,
 		scheduledDate,
+		scheduledEnd,
 		deliveredDate,
+		delivery.Late,
 		delivery.Notes,
 		delivery.ID,
-	).Scan(&delivery.UpdatedAt)
+	}
+	args = append(args, addressArgs(delivery.PickupAddress)...)
+	args = append(args, addressArgs(delivery.DeliveryAddress)...)
+	args = append(args, delivery.Version)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&delivery.UpdatedAt, &delivery.Version)
 
 	if err == sql.ErrNoRows {
-		return domain.ErrDeliveryNotFound
+		return notUpdated(ctx, r.db, delivery.ID, delivery.Version)
 	}
 	return err
 }
 
+// GetOverdue retrieves up to limit deliveries whose scheduled window ended
+// before now while they were still open and not yet flagged late
+func (r *PostgresDeliveryRepository) GetOverdue(ctx context.Context, now time.Time, limit int) ([]*domain.Delivery, error) {
+	// The predicate is spelled out to match the partial idx_deliveries_overdue index
+	query := `
+		SELECT id, tracking_number, customer_id, courier_id, status, pickup_location, delivery_location, 
+		       scheduled_date, scheduled_end, delivered_date, late, notes, cancel_reason, cancel_reason_code, cancelled_at, created_at, updated_at, version, 
+		       pickup_line1, pickup_city, pickup_postal_code, pickup_country, pickup_latitude, pickup_longitude, 
+		       delivery_line1, delivery_city, delivery_postal_code, delivery_country, delivery_latitude, delivery_longitude, 
+		       quoted_price_cents, quoted_currency, org_id, confirmation_code 
+		FROM deliveries 
+		WHERE scheduled_end < $1 AND late = FALSE AND status NOT IN ('delivered', 'cancelled') 
+		ORDER BY scheduled_end 
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanDeliveries(rows)
+}
+
+// MarkLateWithOutbox flags a delivery as late and stores its outbox event in a
+// single transaction. It returns domain.ErrNotOverdue if the delivery was
+// delivered, cancelled or already flagged in the meantime.
+func (r *PostgresDeliveryRepository) MarkLateWithOutbox(ctx context.Context, id int, event *domain.OutboxEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var returnedID int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE deliveries 
+		SET late = TRUE, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND NOT late AND status NOT IN ($2, $3)
+		RETURNING id
+	`, id, domain.StatusDelivered, domain.StatusCancelled).Scan(&returnedID)
+	if err == sql.ErrNoRows {
+		return domain.ErrNotOverdue
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // scanDeliveries is a helper to scan multiple delivery rows
 func (r *PostgresDeliveryRepository) scanDeliveries(rows *sql.Rows) ([]*domain.Delivery, error) {
 	var deliveries []*domain.Delivery
@@ -268,23 +682,36 @@ func (r *PostgresDeliveryRepository) scanDeliveries(rows *sql.Rows) ([]*domain.D
 	for rows.Next() {
 		var d domain.Delivery
 		var courierID sql.NullInt64
-		var pickupLocation, deliveryLocation, notes sql.NullString
-		var scheduledDate, deliveredDate sql.NullTime
+		var pickupLocation, deliveryLocation, notes, cancelReason, cancelReasonCode sql.NullString
+		var scheduledDate, scheduledEnd, deliveredDate, cancelledAt sql.NullTime
+		var pickup, dropoff addressColumns
+		var quote quoteColumns
 
-		err := rows.Scan(
+		dest := []interface{}{
 			&d.ID,
+			&d.TrackingNumber,
 			&d.CustomerID,
 			&courierID,
 			&d.Status,
 			&pickupLocation,
 			&deliveryLocation,
 			&scheduledDate,
+			&scheduledEnd,
 			&deliveredDate,
+			&d.Late,
 			&notes,
+			&cancelReason,
+			&cancelReasonCode,
+			&cancelledAt,
 			&d.CreatedAt,
 			&d.UpdatedAt,
-		)
-		if err != nil {
+			&d.Version,
+		}
+		dest = append(dest, pickup.dest()...)
+		dest = append(dest, dropoff.dest()...)
+		dest = append(dest, quote.dest()...)
+		dest = append(dest, &d.OrgID, &d.ConfirmationCode)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
 
@@ -302,15 +729,99 @@ func (r *PostgresDeliveryRepository) scanDeliveries(rows *sql.Rows) ([]*domain.D
 		if scheduledDate.Valid {
 			d.ScheduledDate = &scheduledDate.Time
 		}
+		if scheduledEnd.Valid {
+			d.ScheduledEnd = &scheduledEnd.Time
+		}
 		if deliveredDate.Valid {
 			d.DeliveredDate = &deliveredDate.Time
 		}
 		if notes.Valid {
 			d.Notes = notes.String
 		}
+		if cancelReason.Valid {
+			d.CancelReason = cancelReason.String
+		}
+		if cancelReasonCode.Valid {
+			d.CancelReasonCode = cancelReasonCode.String
+		}
+		if cancelledAt.Valid {
+			d.CancelledAt = &cancelledAt.Time
+		}
+		d.PickupAddress = pickup.address()
+		d.DeliveryAddress = dropoff.address()
+		quote.apply(&d)
 
 		deliveries = append(deliveries, &d)
 	}
 
 	return deliveries, rows.Err()
 }
+
+// addressColumns holds one end of a delivery as scanned from its line1,
+// city, postal code, country, latitude and longitude columns
+type addressColumns struct {
+	line1, city, postalCode, country sql.NullString
+	latitude, longitude              sql.NullFloat64
+}
+
+// dest returns the scan destinations in column order
+func (c *addressColumns) dest() []interface{} {
+	return []interface{}{&c.line1, &c.city, &c.postalCode, &c.country, &c.latitude, &c.longitude}
+}
+
+// address converts the scanned columns to a domain address
+func (c *addressColumns) address() domain.Address {
+	a := domain.Address{
+		Line1:      c.line1.String,
+		City:       c.city.String,
+		PostalCode: c.postalCode.String,
+		Country:    c.country.String,
+	}
+	if c.latitude.Valid && c.longitude.Valid {
+		a.Coordinates = &domain.Coordinates{Latitude: c.latitude.Float64, Longitude: c.longitude.Float64}
+	}
+	return a
+}
+
+// quoteColumns holds a delivery's quoted price as scanned from its
+// quoted_price_cents and quoted_currency columns
+type quoteColumns struct {
+	priceCents sql.NullInt64
+	currency   sql.NullString
+}
+
+// dest returns the scan destinations in column order
+func (c *quoteColumns) dest() []interface{} {
+	return []interface{}{&c.priceCents, &c.currency}
+}
+
+// apply sets the scanned quoted price on d
+func (c *quoteColumns) apply(d *domain.Delivery) {
+	if c.priceCents.Valid {
+		price := c.priceCents.Int64
+		d.QuotedPriceCents = &price
+	}
+	d.QuotedCurrency = c.currency.String
+}
+
+// quoteArgs returns a delivery's quoted price as query arguments in column order
+func quoteArgs(d *domain.Delivery) []interface{} {
+	var price sql.NullInt64
+	if d.QuotedPriceCents != nil {
+		price = sql.NullInt64{Int64: *d.QuotedPriceCents, Valid: true}
+	}
+	return []interface{}{price, sql.NullString{String: d.QuotedCurrency, Valid: d.QuotedCurrency != ""}}
+}
+
+// addressArgs returns an address as query arguments in column order
+func addressArgs(a domain.Address) []interface{} {
+	var latitude, longitude sql.NullFloat64
+	if a.Coordinates != nil {
+		latitude = sql.NullFloat64{Float64: a.Coordinates.Latitude, Valid: true}
+		longitude = sql.NullFloat64{Float64: a.Coordinates.Longitude, Valid: true}
+	}
+	nullable := func(s string) sql.NullString {
+		return sql.NullString{String: s, Valid: s != ""}
+	}
+	return []interface{}{nullable(a.Line1), nullable(a.City), nullable(a.PostalCode), nullable(a.Country), latitude, longitude}
+}
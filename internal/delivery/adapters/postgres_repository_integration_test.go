@@ -0,0 +1,107 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/migrations"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+)
+
+// Integration tests that require a real PostgreSQL database
+
+// TestIntegration_DeliveryList runs the generated list queries against the
+// migrated schema
+func TestIntegration_DeliveryList(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("DATABASE_URL not set, skipping integration test")
+	}
+
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := migrate.Ensure(ctx, db.DB, migrations.FS, true); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// A customer and courier of our own keep other rows out of the results
+	var customerID, courierID int
+	err = db.QueryRow(`INSERT INTO customers (name, address, contact) VALUES ('List Test', 'Main St', '555') RETURNING id`).Scan(&customerID)
+	if err != nil {
+		t.Fatalf("Failed to create customer: %v", err)
+	}
+	err = db.QueryRow(`INSERT INTO couriers (name, vehicle_type, phone) VALUES ('List Test', 'bike', '555') RETURNING id`).Scan(&courierID)
+	if err != nil {
+		t.Fatalf("Failed to create courier: %v", err)
+	}
+	defer db.Exec("DELETE FROM customers WHERE id = $1", customerID)
+	defer db.Exec("DELETE FROM couriers WHERE id = $1", courierID)
+
+	repo := NewPostgresDeliveryRepository(db.DB)
+	scheduled := time.Now().Add(24 * time.Hour)
+	seed := []*domain.Delivery{
+		{Status: domain.StatusPending, ScheduledDate: &scheduled},
+		{Status: domain.StatusAssigned, CourierID: &courierID},
+		{Status: domain.StatusInTransit, CourierID: &courierID},
+		{Status: domain.StatusPending},
+	}
+	var ids []int
+	for _, d := range seed {
+		d.CustomerID, d.PickupLocation, d.DeliveryLocation = customerID, "A", "B"
+		if err := repo.Create(ctx, d); err != nil {
+			t.Fatalf("Failed to create delivery: %v", err)
+		}
+		ids = append(ids, d.ID)
+	}
+	if _, err := db.Exec("UPDATE deliveries SET late = TRUE WHERE id = $1", ids[2]); err != nil {
+		t.Fatalf("Failed to flag delivery late: %v", err)
+	}
+
+	late := true
+	tests := []struct {
+		name     string
+		filter   ports.DeliveryFilter
+		expected []int
+		total    int
+	}{
+		{name: "customer", expected: []int{ids[3], ids[2], ids[1], ids[0]}, total: 4},
+		{name: "statuses", filter: ports.DeliveryFilter{Statuses: []string{domain.StatusAssigned, domain.StatusInTransit}}, expected: []int{ids[2], ids[1]}, total: 2},
+		{name: "courier", filter: ports.DeliveryFilter{CourierID: courierID, Order: domain.ListOrder{Ascending: true}}, expected: []int{ids[1], ids[2]}, total: 2},
+		{name: "late", filter: ports.DeliveryFilter{Late: &late}, expected: []int{ids[2]}, total: 1},
+		{name: "tracking number", filter: ports.DeliveryFilter{TrackingNumber: seed[1].TrackingNumber}, expected: []int{ids[1]}, total: 1},
+		{name: "scheduled first", filter: ports.DeliveryFilter{Order: domain.ListOrder{Field: domain.SortScheduledDate}, Limit: 1}, expected: []int{ids[0]}, total: 4},
+		{name: "second page", filter: ports.DeliveryFilter{Limit: 3, Offset: 3}, expected: []int{ids[0]}, total: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			filter.CustomerID = customerID
+			deliveries, total, err := repo.List(ctx, filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var got []int
+			for _, d := range deliveries {
+				got = append(got, d.ID)
+			}
+			if !slices.Equal(got, tt.expected) || total != tt.total {
+				t.Errorf("List() = %v of %d, want %v of %d", got, total, tt.expected, tt.total)
+			}
+		})
+	}
+}
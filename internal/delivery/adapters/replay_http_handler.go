@@ -0,0 +1,76 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+)
+
+// ReplayHTTPHandler handles the admin endpoint that republishes past events
+type ReplayHTTPHandler struct {
+	service ports.EventReplayService
+}
+
+// NewReplayHTTPHandler creates a new event replay HTTP handler
+func NewReplayHTTPHandler(service ports.EventReplayService) *ReplayHTTPHandler {
+	return &ReplayHTTPHandler{service: service}
+}
+
+// replayResponse is a replay's outcome, with the error that stopped it
+type replayResponse struct {
+	*domain.ReplayResult
+	Error string `json:"error,omitempty"`
+}
+
+// ReplayEvents handles POST /admin/events/replay. A replay stopped by a
+// broker or database failure answers 502 with what was published and where
+// to resume.
+func (h *ReplayHTTPHandler) ReplayEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ports.ReplayEventsRequest
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+	req.AuthContext = ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "replay_events_http")
+
+	result, err := h.service.ReplayEvents(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		httputil.SendErrorResponse(w, "Only admins can replay events", http.StatusForbidden)
+		return
+	case errors.Is(err, domain.ErrInvalidReplay):
+		httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrReplayInterrupted):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(replayResponse{ReplayResult: result, Error: err.Error()})
+		return
+	case err != nil:
+		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(replayResponse{ReplayResult: result})
+}
@@ -0,0 +1,67 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+)
+
+// PostgresSlotCapacityRepository implements the SlotCapacityRepository interface using PostgreSQL
+type PostgresSlotCapacityRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresSlotCapacityRepository creates a new PostgreSQL slot capacity repository
+func NewPostgresSlotCapacityRepository(db *sql.DB) *PostgresSlotCapacityRepository {
+	return &PostgresSlotCapacityRepository{db: db}
+}
+
+// ListCapacities retrieves an organization's slot capacities in a zone by start hour
+func (r *PostgresSlotCapacityRepository) ListCapacities(ctx context.Context, orgID int, zone string) ([]domain.SlotCapacity, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT start_hour, capacity
+		FROM slot_capacities
+		WHERE org_id = $1 AND zone = $2
+		ORDER BY start_hour
+	`, orgID, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	capacities := []domain.SlotCapacity{}
+	for rows.Next() {
+		var c domain.SlotCapacity
+		if err := rows.Scan(&c.StartHour, &c.Capacity); err != nil {
+			return nil, err
+		}
+		capacities = append(capacities, c)
+	}
+	return capacities, rows.Err()
+}
+
+// ReplaceCapacities replaces all of an organization's slot capacities in a
+// zone in a single transaction
+func (r *PostgresSlotCapacityRepository) ReplaceCapacities(ctx context.Context, orgID int, zone string, capacities []domain.SlotCapacity) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM slot_capacities WHERE org_id = $1 AND zone = $2`, orgID, zone); err != nil {
+		return err
+	}
+	for _, c := range capacities {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO slot_capacities (org_id, zone, start_hour, capacity)
+			VALUES ($1, $2, $3, $4)
+		`, orgID, zone, c.StartHour, c.Capacity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
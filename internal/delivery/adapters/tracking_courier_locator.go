@@ -0,0 +1,46 @@
+package adapters
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/proto/tracking"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TrackingCourierLocator looks up couriers' locations in the tracking service
+type TrackingCourierLocator struct {
+	client tracking.TrackingServiceClient
+}
+
+// NewTrackingCourierLocator creates a courier locator backed by the tracking service.
+// Calls forward the caller's token, so couriers may only locate themselves.
+func NewTrackingCourierLocator(client tracking.TrackingServiceClient) *TrackingCourierLocator {
+	return &TrackingCourierLocator{
+		client: client,
+	}
+}
+
+// Locate returns a courier's last reported position and average speed
+func (l *TrackingCourierLocator) Locate(ctx context.Context, courierID int) (*domain.CourierPosition, error) {
+	resp, err := l.client.GetCourierLocation(ctx, &tracking.GetCourierLocationRequest{
+		CourierId: strconv.Itoa(courierID),
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			return nil, domain.ErrCourierLocationUnknown
+		case codes.PermissionDenied:
+			return nil, domain.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	return &domain.CourierPosition{
+		Latitude:        resp.GetLocation().GetLatitude(),
+		Longitude:       resp.GetLocation().GetLongitude(),
+		AverageSpeedKmh: resp.AverageSpeed,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package adapters
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/proto/common"
+	"github.com/Keneke-Einar/delivertrack/proto/tracking"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TrackingDeliveryTracker reads deliveries' live tracking data from the tracking service
+type TrackingDeliveryTracker struct {
+	client tracking.TrackingServiceClient
+}
+
+// NewTrackingDeliveryTracker creates a delivery tracker backed by the tracking service.
+// Calls forward the caller's token, so the tracking service applies its own access rules.
+func NewTrackingDeliveryTracker(client tracking.TrackingServiceClient) *TrackingDeliveryTracker {
+	return &TrackingDeliveryTracker{
+		client: client,
+	}
+}
+
+// CurrentLocation returns a delivery's latest reported position, or nil if
+// none has been reported
+func (t *TrackingDeliveryTracker) CurrentLocation(ctx context.Context, deliveryID int) (*domain.TrackedLocation, error) {
+	resp, err := t.client.GetDeliveryLocation(ctx, &tracking.GetDeliveryLocationRequest{
+		DeliveryId: strconv.Itoa(deliveryID),
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &domain.TrackedLocation{
+		Latitude:  resp.GetLocation().GetLatitude(),
+		Longitude: resp.GetLocation().GetLongitude(),
+		Timestamp: time.Unix(resp.Timestamp, 0).UTC(),
+	}, nil
+}
+
+// ETA estimates when a delivery reaches destination, or returns nil if it has
+// no reported position to estimate from
+func (t *TrackingDeliveryTracker) ETA(ctx context.Context, deliveryID int, destination domain.Coordinates) (*domain.DeliveryETA, error) {
+	resp, err := t.client.GetDeliveryETA(ctx, &tracking.GetDeliveryETARequest{
+		DeliveryId:  strconv.Itoa(deliveryID),
+		Destination: &common.Location{Latitude: destination.Latitude, Longitude: destination.Longitude},
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &domain.DeliveryETA{
+		Seconds:          resp.EtaSeconds,
+		DistanceKm:       resp.DistanceKm,
+		EstimatedArrival: time.Now().UTC().Add(time.Duration(resp.EtaSeconds) * time.Second).Truncate(time.Second),
+	}, nil
+}
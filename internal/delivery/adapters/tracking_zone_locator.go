@@ -0,0 +1,33 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/proto/common"
+	"github.com/Keneke-Einar/delivertrack/proto/tracking"
+)
+
+// TrackingZoneLocator looks up delivery zones in the tracking service, which
+// keeps them in MongoDB
+type TrackingZoneLocator struct {
+	client tracking.TrackingServiceClient
+}
+
+// NewTrackingZoneLocator creates a zone locator backed by the tracking service
+func NewTrackingZoneLocator(client tracking.TrackingServiceClient) *TrackingZoneLocator {
+	return &TrackingZoneLocator{
+		client: client,
+	}
+}
+
+// ZonesAt returns the names of the active delivery zones containing the point
+func (l *TrackingZoneLocator) ZonesAt(ctx context.Context, point domain.Coordinates) ([]string, error) {
+	resp, err := l.client.FindZones(ctx, &tracking.FindZonesRequest{
+		Location: &common.Location{Latitude: point.Latitude, Longitude: point.Longitude},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Zones, nil
+}
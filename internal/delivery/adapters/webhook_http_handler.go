@@ -0,0 +1,278 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+)
+
+// WebhookHTTPHandler handles HTTP requests for webhook subscriptions
+type WebhookHTTPHandler struct {
+	service ports.WebhookService
+}
+
+// NewWebhookHTTPHandler creates a new webhook HTTP handler
+func NewWebhookHTTPHandler(service ports.WebhookService) *WebhookHTTPHandler {
+	return &WebhookHTTPHandler{
+		service: service,
+	}
+}
+
+// CreateWebhookRequest represents the request payload for registering a webhook
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+// UpdateWebhookRequest represents the request payload for changing a webhook; omitted fields are kept
+type UpdateWebhookRequest struct {
+	URL        *string   `json:"url"`
+	Secret     *string   `json:"secret"`
+	EventTypes *[]string `json:"event_types"`
+	Active     *bool     `json:"active"`
+}
+
+// CreateWebhook handles POST /webhooks
+func (h *WebhookHTTPHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "create_webhook_http")
+
+	sub, err := h.service.CreateWebhook(ctx, ports.CreateWebhookRequest{
+		URL:         req.URL,
+		Secret:      req.Secret,
+		EventTypes:  req.EventTypes,
+		AuthContext: webhookAuthContext(userCtx),
+	})
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// ListWebhooks handles GET /webhooks
+func (h *WebhookHTTPHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "list_webhooks_http")
+
+	subs, err := h.service.ListWebhooks(ctx, webhookAuthContext(userCtx))
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+	if subs == nil {
+		subs = []*domain.WebhookSubscription{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// GetWebhook handles GET /webhooks/{id}
+func (h *WebhookHTTPHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "get_webhook_http")
+
+	sub, err := h.service.GetWebhook(ctx, ports.WebhookRequest{ID: id, AuthContext: webhookAuthContext(userCtx)})
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// UpdateWebhook handles PUT /webhooks/{id}
+func (h *WebhookHTTPHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "update_webhook_http")
+
+	sub, err := h.service.UpdateWebhook(ctx, ports.UpdateWebhookRequest{
+		ID:          id,
+		URL:         req.URL,
+		Secret:      req.Secret,
+		EventTypes:  req.EventTypes,
+		Active:      req.Active,
+		AuthContext: webhookAuthContext(userCtx),
+	})
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// DeleteWebhook handles DELETE /webhooks/{id}
+func (h *WebhookHTTPHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "delete_webhook_http")
+
+	if err := h.service.DeleteWebhook(ctx, ports.WebhookRequest{ID: id, AuthContext: webhookAuthContext(userCtx)}); err != nil {
+		httputil.SendErrorResponse(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET /webhooks/{id}/deliveries?limit=50
+func (h *WebhookHTTPHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			httputil.SendErrorResponse(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "delivery-service", "list_webhook_deliveries_http")
+
+	deliveries, err := h.service.ListWebhookDeliveries(ctx, ports.ListWebhookDeliveriesRequest{
+		ID:          id,
+		Limit:       limit,
+		AuthContext: webhookAuthContext(userCtx),
+	})
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// webhookID parses the subscription ID from the path, responding 400 if it is invalid
+func webhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid webhook ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// webhookAuthContext builds the service auth context from the caller's identity
+func webhookAuthContext(userCtx httputil.UserContext) ports.AuthContext {
+	return ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+}
+
+// webhookErrorStatus maps webhook errors to HTTP status codes
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
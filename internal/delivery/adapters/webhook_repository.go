@@ -0,0 +1,350 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/lib/pq"
+)
+
+// PostgresWebhookRepository implements the WebhookRepository interface using PostgreSQL
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresWebhookRepository creates a new PostgreSQL webhook repository
+func NewPostgresWebhookRepository(db *sql.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+// CreateSubscription stores a new subscription
+func (r *PostgresWebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (customer_id, url, secret, event_types, active, created_at, updated_at, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		sub.CustomerID,
+		sub.URL,
+		sub.Secret,
+		pq.Array(eventTypesOrEmpty(sub.EventTypes)),
+		sub.Active,
+		sub.CreatedAt,
+		sub.UpdatedAt,
+		sub.OrgID,
+	).Scan(&sub.ID)
+}
+
+// GetSubscription retrieves a subscription by its ID
+func (r *PostgresWebhookRepository) GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error) {
+	query := `
+		SELECT id, customer_id, url, secret, event_types, active, created_at, updated_at, org_id
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	sub, err := scanWebhookSubscription(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrWebhookNotFound
+	}
+	return sub, err
+}
+
+// ListSubscriptions retrieves a customer's subscriptions, oldest first
+func (r *PostgresWebhookRepository) ListSubscriptions(ctx context.Context, customerID int) ([]*domain.WebhookSubscription, error) {
+	query := `
+		SELECT id, customer_id, url, secret, event_types, active, created_at, updated_at, org_id
+		FROM webhook_subscriptions
+		WHERE customer_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*domain.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+// UpdateSubscription stores a subscription's URL, secret, event types and active flag
+func (r *PostgresWebhookRepository) UpdateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $1, secret = $2, event_types = $3, active = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		sub.URL,
+		sub.Secret,
+		pq.Array(eventTypesOrEmpty(sub.EventTypes)),
+		sub.Active,
+		sub.ID,
+	).Scan(&sub.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return domain.ErrWebhookNotFound
+	}
+	return err
+}
+
+// DeleteSubscription removes a subscription; its deliveries cascade
+func (r *PostgresWebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// EnqueueDeliveries stores pending deliveries in a single transaction,
+// skipping events already queued for the same subscription
+func (r *PostgresWebhookRepository) EnqueueDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+	for _, d := range deliveries {
+		_, err := tx.ExecContext(ctx, query,
+			d.SubscriptionID,
+			d.EventID,
+			d.EventType,
+			d.Payload,
+			d.Status,
+			d.NextAttemptAt,
+			d.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FetchDueDeliveries claims pending deliveries whose next attempt is due,
+// oldest first. Rows another dispatcher is claiming are skipped, and claimed
+// ones are pushed back by lease so that no other dispatcher picks them up
+// while they are attempted.
+func (r *PostgresWebhookRepository) FetchDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id, next_attempt_at
+			FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries w
+			SET next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond'
+			FROM due
+			WHERE w.id = due.id
+			RETURNING w.id, w.subscription_id, w.event_id, w.event_type, w.payload, w.status,
+			          w.attempt_count, w.next_attempt_at, w.delivered_at, w.created_at,
+			          due.next_attempt_at AS due_at
+		)
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempt_count,
+		       next_attempt_at, delivered_at, created_at
+		FROM claimed
+		ORDER BY due_at, id
+	`
+
+	return r.queryDeliveries(ctx, query, domain.WebhookDeliveryPending, limit, lease.Milliseconds())
+}
+
+// SaveAttempt stores a delivery's status after its latest attempt together
+// with that attempt, in a single transaction
+func (r *PostgresWebhookRepository) SaveAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempt_count = $2, next_attempt_at = $3, delivered_at = $4
+		WHERE id = $5
+	`, delivery.Status, delivery.AttemptCount, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		return err
+	}
+
+	var statusCode sql.NullInt64
+	if attempt.StatusCode != 0 {
+		statusCode = sql.NullInt64{Int64: int64(attempt.StatusCode), Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_attempts (delivery_id, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+	`, delivery.ID, statusCode, attempt.Error, attempt.DurationMs, attempt.AttemptedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListDeliveries retrieves a subscription's most recent deliveries with their attempts
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempt_count,
+		       next_attempt_at, delivered_at, created_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	deliveries, err := r.queryDeliveries(ctx, query, subscriptionID, limit)
+	if err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+
+	ids := make([]int64, len(deliveries))
+	byID := make(map[int64]*domain.WebhookDelivery, len(deliveries))
+	for i, d := range deliveries {
+		ids[i] = d.ID
+		byID[d.ID] = d
+		d.Attempts = []domain.WebhookAttempt{}
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT delivery_id, status_code, error, duration_ms, attempted_at
+		FROM webhook_attempts
+		WHERE delivery_id = ANY($1)
+		ORDER BY attempted_at, id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deliveryID int64
+		var a domain.WebhookAttempt
+		var statusCode sql.NullInt64
+		var errMsg sql.NullString
+		if err := rows.Scan(&deliveryID, &statusCode, &errMsg, &a.DurationMs, &a.AttemptedAt); err != nil {
+			return nil, err
+		}
+		a.StatusCode = int(statusCode.Int64)
+		a.Error = errMsg.String
+
+		if d, ok := byID[deliveryID]; ok {
+			d.Attempts = append(d.Attempts, a)
+		}
+	}
+
+	return deliveries, rows.Err()
+}
+
+// queryDeliveries runs a query selecting webhook deliveries
+func (r *PostgresWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		var deliveredAt sql.NullTime
+
+		err := rows.Scan(
+			&d.ID,
+			&d.SubscriptionID,
+			&d.EventID,
+			&d.EventType,
+			&d.Payload,
+			&d.Status,
+			&d.AttemptCount,
+			&d.NextAttemptAt,
+			&deliveredAt,
+			&d.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+
+		deliveries = append(deliveries, &d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhookSubscription scans a subscription row
+func scanWebhookSubscription(row rowScanner) (*domain.WebhookSubscription, error) {
+	var sub domain.WebhookSubscription
+	var eventTypes pq.StringArray
+
+	err := row.Scan(
+		&sub.ID,
+		&sub.CustomerID,
+		&sub.URL,
+		&sub.Secret,
+		&eventTypes,
+		&sub.Active,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+		&sub.OrgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sub.EventTypes = []string(eventTypes)
+
+	return &sub, nil
+}
+
+// eventTypesOrEmpty stores an unfiltered subscription as an empty array rather than NULL
+func eventTypesOrEmpty(eventTypes []string) []string {
+	if eventTypes == nil {
+		return []string{}
+	}
+	return eventTypes
+}
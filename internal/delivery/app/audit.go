@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+)
+
+// Audited delivery actions
+const (
+	auditActionCreate       = "delivery.create"
+	auditActionStatusChange = "delivery.status_change"
+	auditActionAssign       = "delivery.assign"
+	auditActionCancel       = "delivery.cancel"
+	auditActionConfirm      = "delivery.confirm"
+)
+
+// SetAuditWriter records creations, status changes, assignments,
+// cancellations and confirmations to the audit log
+func (s *DeliveryService) SetAuditWriter(w *audit.Writer) {
+	s.audit = w
+}
+
+// deliverySnapshot is the state of a delivery kept in audit entries
+type deliverySnapshot struct {
+	Status           string     `json:"status"`
+	CustomerID       int        `json:"customer_id"`
+	CourierID        *int       `json:"courier_id,omitempty"`
+	PickupLocation   string     `json:"pickup_location"`
+	DeliveryLocation string     `json:"delivery_location"`
+	ScheduledDate    *time.Time `json:"scheduled_date,omitempty"`
+	ScheduledEnd     *time.Time `json:"scheduled_end,omitempty"`
+	Notes            string     `json:"notes,omitempty"`
+	CancelReason     string     `json:"cancel_reason,omitempty"`
+	CancelReasonCode string     `json:"cancel_reason_code,omitempty"`
+}
+
+// snapshotDelivery copies the audited fields of a delivery, so later changes
+// to it don't alter the snapshot
+func snapshotDelivery(d *domain.Delivery) *deliverySnapshot {
+	var courierID *int
+	if d.CourierID != nil {
+		id := *d.CourierID
+		courierID = &id
+	}
+	return &deliverySnapshot{
+		Status:           d.Status,
+		CustomerID:       d.CustomerID,
+		CourierID:        courierID,
+		PickupLocation:   d.PickupLocation,
+		DeliveryLocation: d.DeliveryLocation,
+		ScheduledDate:    d.ScheduledDate,
+		ScheduledEnd:     d.ScheduledEnd,
+		Notes:            d.Notes,
+		CancelReason:     d.CancelReason,
+		CancelReasonCode: d.CancelReasonCode,
+	}
+}
+
+// recordAudit queues an audit entry for a delivery; before is nil for creations
+func (s *DeliveryService) recordAudit(ctx context.Context, action string, deliveryID int, before *deliverySnapshot, after *domain.Delivery) {
+	if s.audit == nil {
+		return
+	}
+	s.audit.Record(ctx, after.OrgID, action, audit.EntityDelivery, strconv.Itoa(deliveryID), before, snapshotDelivery(after))
+}
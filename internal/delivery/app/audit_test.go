@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// memoryAuditStore keeps audit entries in memory
+type memoryAuditStore struct {
+	mu      sync.Mutex
+	entries []*audit.Entry
+}
+
+func (m *memoryAuditStore) Write(ctx context.Context, entry *audit.Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.ID = int64(len(m.entries) + 1)
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryAuditStore) ListByEntity(ctx context.Context, orgID int, entityType, entityID string, limit int) ([]*audit.Entry, error) {
+	return nil, nil
+}
+
+func TestDeliveryService_RecordsAudit(t *testing.T) {
+	customerID := 1
+	courierID := 3
+
+	repo := memory.NewDeliveryRepository()
+	couriers := memory.NewCourierRepository(repo)
+	couriers.AddCourier(courierID, domain.CourierAvailable)
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+	service.SetCourierRepository(couriers)
+
+	store := &memoryAuditStore{}
+	writer := audit.NewWriter(store, 10, createTestLogger(t))
+	service.SetAuditWriter(writer)
+
+	repo.AddDelivery(&domain.Delivery{
+		ID:               1,
+		CustomerID:       customerID,
+		Status:           domain.StatusPending,
+		PickupLocation:   "123 Main St",
+		DeliveryLocation: "456 Oak Ave",
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	})
+
+	courierCtx := authctx.WithClaims(context.Background(), &authDomain.Claims{UserID: 30, Role: "courier", CourierID: &courierID})
+	_, err := service.UpdateDeliveryStatus(courierCtx, ports.UpdateDeliveryStatusRequest{
+		ID:          1,
+		Status:      domain.StatusAssigned,
+		AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error assigning: %v", err)
+	}
+
+	customerCtx := authctx.WithClaims(context.Background(), &authDomain.Claims{UserID: 10, Role: "customer", CustomerID: &customerID})
+	_, err = service.CancelDelivery(customerCtx, ports.CancelDeliveryRequest{
+		ID:          1,
+		Reason:      "Ordered by mistake",
+		ReasonCode:  domain.CancelReasonCustomerRequest,
+		AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error cancelling: %v", err)
+	}
+
+	writer.Close()
+
+	if len(store.entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(store.entries))
+	}
+
+	tests := []struct {
+		action      string
+		actorUserID int
+		actorRole   string
+		before      string
+		after       string
+	}{
+		{auditActionAssign, 30, "courier", domain.StatusPending, domain.StatusAssigned},
+		{auditActionCancel, 10, "customer", domain.StatusAssigned, domain.StatusCancelled},
+	}
+	for i, tt := range tests {
+		entry := store.entries[i]
+		if entry.Action != tt.action || entry.EntityType != audit.EntityDelivery || entry.EntityID != "1" {
+			t.Errorf("entry %d: expected %s of delivery 1, got %s of %s %s", i, tt.action, entry.Action, entry.EntityType, entry.EntityID)
+		}
+		if entry.ActorUserID != tt.actorUserID || entry.ActorRole != tt.actorRole {
+			t.Errorf("entry %d: expected actor %d (%s), got %d (%s)", i, tt.actorUserID, tt.actorRole, entry.ActorUserID, entry.ActorRole)
+		}
+
+		var before, after deliverySnapshot
+		if err := json.Unmarshal(entry.Before, &before); err != nil {
+			t.Fatalf("entry %d: failed to decode before snapshot: %v", i, err)
+		}
+		if err := json.Unmarshal(entry.After, &after); err != nil {
+			t.Fatalf("entry %d: failed to decode after snapshot: %v", i, err)
+		}
+		if before.Status != tt.before || after.Status != tt.after {
+			t.Errorf("entry %d: expected %s -> %s, got %s -> %s", i, tt.before, tt.after, before.Status, after.Status)
+		}
+	}
+
+	var assignedBefore deliverySnapshot
+	json.Unmarshal(store.entries[0].Before, &assignedBefore)
+	if assignedBefore.CourierID != nil {
+		t.Error("expected the snapshot before assignment to have no courier")
+	}
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// BulkCreateConfig bounds bulk delivery creation
+type BulkCreateConfig struct {
+	MaxBatchSize   int // rows accepted per batch
+	GeocodeWorkers int // rows prepared concurrently; each geocodes its pickup and drop-off
+}
+
+// DefaultBulkCreateConfig returns a default bulk creation configuration
+func DefaultBulkCreateConfig() BulkCreateConfig {
+	return BulkCreateConfig{
+		MaxBatchSize:   500,
+		GeocodeWorkers: 8,
+	}
+}
+
+// SetBulkCreateConfig overrides the bulk creation limits; zero values keep the defaults
+func (s *DeliveryService) SetBulkCreateConfig(cfg BulkCreateConfig) {
+	defaults := DefaultBulkCreateConfig()
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaults.MaxBatchSize
+	}
+	if cfg.GeocodeWorkers <= 0 {
+		cfg.GeocodeWorkers = defaults.GeocodeWorkers
+	}
+	s.bulk = cfg
+}
+
+// BulkCreateDeliveries validates and geocodes every row, then stores the valid
+// ones together with their created events in a single transaction. Rows
+// scheduled into a zone's slot are booked one by one afterwards, as
+// CreateDelivery books them, so a full slot fails only its row. Invalid rows
+// are reported without failing the batch.
+func (s *DeliveryService) BulkCreateDeliveries(ctx context.Context, req ports.BulkCreateDeliveriesRequest) (*domain.BulkCreateResult, error) {
+	if len(req.Deliveries) == 0 {
+		return nil, domain.ErrEmptyBatch
+	}
+	if len(req.Deliveries) > s.bulk.MaxBatchSize {
+		return nil, fmt.Errorf("%w of %d", domain.ErrBatchTooLarge, s.bulk.MaxBatchSize)
+	}
+
+	rows := make([]ports.CreateDeliveryRequest, len(req.Deliveries))
+	copy(rows, req.Deliveries)
+
+	// Customers may only create their own deliveries; their rows default to them
+	if req.Role == "customer" {
+		if req.UserCustomerID == nil {
+			return nil, domain.ErrUnauthorized
+		}
+		for i := range rows {
+			if rows[i].CustomerID == 0 {
+				rows[i].CustomerID = *req.UserCustomerID
+			}
+		}
+	}
+
+	s.logger.InfoWithFields(ctx, "Creating deliveries in bulk",
+		zap.Int("rows", len(rows)))
+
+	result := &domain.BulkCreateResult{
+		Total: len(rows),
+		Rows:  make([]domain.BulkRowResult, len(rows)),
+	}
+	prepared := s.prepareBulkRows(ctx, req.AuthContext, rows)
+
+	var deliveries []*domain.Delivery
+	var createdRows, slottedRows []int
+	for i, p := range prepared {
+		result.Rows[i].Index = i
+		switch {
+		case p.err != nil:
+			result.Rows[i].Error = p.err.Error()
+			result.Failed++
+		case p.slot != nil:
+			slottedRows = append(slottedRows, i)
+		default:
+			deliveries = append(deliveries, p.delivery)
+			createdRows = append(createdRows, i)
+		}
+	}
+
+	if len(deliveries) > 0 {
+		if err := s.repo.CreateBatchWithOutbox(ctx, deliveries, s.createdEventBuilder(ctx)); err != nil {
+			s.logger.ErrorWithFields(ctx, "Failed to persist delivery batch",
+				zap.Int("rows", len(deliveries)), zap.Error(err))
+			return nil, fmt.Errorf("failed to create deliveries: %w", err)
+		}
+	}
+
+	// Each booking takes the slot's lock, so these rows cannot share the batch's transaction
+	for _, i := range slottedRows {
+		p := prepared[i]
+		err := s.repo.CreateInSlotWithOutbox(ctx, p.delivery, *p.slot, s.createdEventBuilder(ctx))
+		if err != nil {
+			if !errors.Is(err, domain.ErrSlotFull) {
+				s.logger.ErrorWithFields(ctx, "Failed to persist scheduled bulk row",
+					zap.Int("row", i), zap.Error(err))
+				err = fmt.Errorf("failed to create delivery: %w", err)
+			}
+			result.Rows[i].Error = err.Error()
+			result.Failed++
+			continue
+		}
+		deliveries = append(deliveries, p.delivery)
+		createdRows = append(createdRows, i)
+	}
+
+	for j, delivery := range deliveries {
+		row := &result.Rows[createdRows[j]]
+		row.Created = true
+		row.ID = delivery.ID
+		row.TrackingNumber = delivery.TrackingNumber
+		result.Created++
+		s.syncCourierStatus(ctx, delivery.CourierID, delivery.Status)
+		s.recordAudit(ctx, auditActionCreate, delivery.ID, nil, delivery)
+	}
+
+	s.logger.InfoWithFields(ctx, "Bulk delivery creation finished",
+		zap.Int("created", result.Created),
+		zap.Int("failed", result.Failed))
+
+	return result, nil
+}
+
+// preparedRow is a bulk row turned into a delivery and the slot it must be
+// booked into, or the reason it could not be
+type preparedRow struct {
+	delivery *domain.Delivery
+	slot     *domain.SlotBooking
+	err      error
+}
+
+// prepareBulkRows builds the deliveries of a batch on a bounded worker pool so
+// geocoding lookups run concurrently
+func (s *DeliveryService) prepareBulkRows(ctx context.Context, auth ports.AuthContext, rows []ports.CreateDeliveryRequest) []preparedRow {
+	prepared := make([]preparedRow, len(rows))
+
+	workers := s.bulk.GeocodeWorkers
+	if workers > len(rows) {
+		workers = len(rows)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				prepared[i] = s.prepareBulkRow(ctx, auth, i, rows[i])
+			}
+		}()
+	}
+	for i := range rows {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return prepared
+}
+
+// prepareBulkRow authorizes and builds a single row of a batch
+func (s *DeliveryService) prepareBulkRow(ctx context.Context, auth ports.AuthContext, index int, row ports.CreateDeliveryRequest) preparedRow {
+	ctx = logger.WithContext(ctx, zap.Int("row", index), zap.Int("customer_id", row.CustomerID))
+
+	// Reject incomplete rows before spending geocoding lookups on them
+	if row.CustomerID == 0 || !row.HasLocations() {
+		return preparedRow{err: fmt.Errorf("%w: customer_id, pickup_location and delivery_location are required", domain.ErrInvalidDeliveryData)}
+	}
+	if auth.Role == "customer" && row.CustomerID != *auth.UserCustomerID {
+		return preparedRow{err: fmt.Errorf("%w: customers can only create their own deliveries", domain.ErrUnauthorized)}
+	}
+
+	delivery, err := s.newDelivery(ctx, row)
+	if err != nil {
+		return preparedRow{err: err}
+	}
+	slot, err := s.bookingSlot(ctx, delivery)
+	if err != nil {
+		return preparedRow{err: err}
+	}
+	return preparedRow{delivery: delivery, slot: slot}
+}
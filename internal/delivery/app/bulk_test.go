@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// slowGeocodingService records how many lookups run at the same time
+type slowGeocodingService struct {
+	MockGeocodingService
+	delay time.Duration
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	calls       int
+}
+
+func (m *slowGeocodingService) ForwardGeocode(ctx context.Context, address string) (*geocoding.GeocodeResult, error) {
+	m.mu.Lock()
+	m.calls++
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.mu.Unlock()
+
+	time.Sleep(m.delay)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+	return m.MockGeocodingService.ForwardGeocode(ctx, address)
+}
+
+func TestDeliveryService_BulkCreateDeliveries(t *testing.T) {
+	customerID := 1
+	customer := ports.AuthContext{Role: "customer", UserCustomerID: &customerID}
+
+	t.Run("valid rows are created and invalid rows reported", func(t *testing.T) {
+		repo := memory.NewDeliveryRepository()
+		service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+
+		result, err := service.BulkCreateDeliveries(context.Background(), ports.BulkCreateDeliveriesRequest{
+			Deliveries: []ports.CreateDeliveryRequest{
+				{PickupLocation: "1 Dock Street", DeliveryLocation: "2 Mill Lane"},
+				{PickupLocation: "", DeliveryLocation: "2 Mill Lane"},
+				{CustomerID: 2, PickupLocation: "1 Dock Street", DeliveryLocation: "3 Mill Lane"},
+				{PickupLocation: "(-74.0,40.7)", DeliveryLocation: "(-73.9,40.8)", ScheduledEnd: future(-time.Hour)},
+				{CustomerID: customerID, PickupLocation: "(-74.0,40.7)", DeliveryLocation: "(-73.9,40.8)"},
+			},
+			AuthContext: customer,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Total != 5 || result.Created != 2 || result.Failed != 3 {
+			t.Errorf("expected 2 of 5 rows created, got %+v", result)
+		}
+		for _, index := range []int{0, 4} {
+			row := result.Rows[index]
+			if !row.Created || row.ID == 0 || row.TrackingNumber != domain.TrackingNumberFor(row.ID) {
+				t.Errorf("expected row %d to be created with a tracking number, got %+v", index, row)
+			}
+		}
+		for _, index := range []int{1, 2, 3} {
+			row := result.Rows[index]
+			if row.Created || row.Error == "" || row.Index != index {
+				t.Errorf("expected row %d to report an error, got %+v", index, row)
+			}
+		}
+
+		events := repo.OutboxEvents()
+		if len(events) != 2 {
+			t.Fatalf("expected one created event per delivery, got %d", len(events))
+		}
+		for _, event := range events {
+			if event.RoutingKey != messaging.EventTypeDeliveryCreated {
+				t.Errorf("expected delivery.created event, got %s", event.RoutingKey)
+			}
+		}
+	})
+
+	t.Run("geocoding runs on a bounded worker pool", func(t *testing.T) {
+		repo := memory.NewDeliveryRepository()
+		geocoder := &slowGeocodingService{delay: 20 * time.Millisecond}
+		service := NewDeliveryService(repo, geocoder, nil, createTestLogger(t))
+		service.SetBulkCreateConfig(BulkCreateConfig{GeocodeWorkers: 4})
+
+		rows := make([]ports.CreateDeliveryRequest, 12)
+		for i := range rows {
+			rows[i] = ports.CreateDeliveryRequest{CustomerID: customerID, PickupLocation: "1 Dock Street", DeliveryLocation: "2 Mill Lane"}
+		}
+
+		result, err := service.BulkCreateDeliveries(context.Background(), ports.BulkCreateDeliveriesRequest{
+			Deliveries:  rows,
+			AuthContext: ports.AuthContext{Role: "admin"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Created != len(rows) {
+			t.Errorf("expected all rows created, got %+v", result)
+		}
+		if geocoder.calls != 2*len(rows) {
+			t.Errorf("expected %d geocoding calls, got %d", 2*len(rows), geocoder.calls)
+		}
+		if geocoder.maxInFlight < 2 || geocoder.maxInFlight > 4 {
+			t.Errorf("expected between 2 and 4 concurrent lookups, got %d", geocoder.maxInFlight)
+		}
+	})
+
+	t.Run("scheduled rows are booked into their slots", func(t *testing.T) {
+		service, repo, _ := newSlotService(t, 2)
+
+		scheduled := func(at *string) ports.CreateDeliveryRequest {
+			return ports.CreateDeliveryRequest{CustomerID: customerID, PickupLocation: "1 Dock Street", DeliveryLocation: slotDropoff, ScheduledDate: at}
+		}
+		result, err := service.BulkCreateDeliveries(context.Background(), ports.BulkCreateDeliveriesRequest{
+			Deliveries: []ports.CreateDeliveryRequest{
+				scheduled(tomorrowAt(10, 0)),
+				scheduled(tomorrowAt(10, 30)),
+				scheduled(tomorrowAt(14, 0)),
+				scheduled(tomorrowAt(8, 0)),
+				scheduled(nil),
+			},
+			AuthContext: customer,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Created != 3 || result.Failed != 2 {
+			t.Errorf("expected 3 of 5 rows created, got %+v", result)
+		}
+		for _, index := range []int{1, 2} {
+			if row := result.Rows[index]; row.Created || row.Error == "" {
+				t.Errorf("expected row %d to be refused a slot, got %+v", index, row)
+			}
+		}
+		if events := repo.OutboxEvents(); len(events) != 3 {
+			t.Errorf("expected 3 deliveries stored, got %d", len(events))
+		}
+		if _, err := createScheduled(service, slotDropoff, tomorrowAt(10, 45)); !errors.Is(err, domain.ErrSlotFull) {
+			t.Errorf("expected the bulk booking to fill the 10:00 slot, got %v", err)
+		}
+	})
+
+	t.Run("batch limits", func(t *testing.T) {
+		service := NewDeliveryService(memory.NewDeliveryRepository(), &MockGeocodingService{}, nil, createTestLogger(t))
+		service.SetBulkCreateConfig(BulkCreateConfig{MaxBatchSize: 2})
+
+		row := ports.CreateDeliveryRequest{CustomerID: customerID, PickupLocation: "(-74.0,40.7)", DeliveryLocation: "(-73.9,40.8)"}
+		_, err := service.BulkCreateDeliveries(context.Background(), ports.BulkCreateDeliveriesRequest{
+			Deliveries:  []ports.CreateDeliveryRequest{row, row, row},
+			AuthContext: customer,
+		})
+		if !errors.Is(err, domain.ErrBatchTooLarge) {
+			t.Errorf("expected ErrBatchTooLarge, got %v", err)
+		}
+
+		_, err = service.BulkCreateDeliveries(context.Background(), ports.BulkCreateDeliveriesRequest{AuthContext: customer})
+		if !errors.Is(err, domain.ErrEmptyBatch) {
+			t.Errorf("expected ErrEmptyBatch, got %v", err)
+		}
+	})
+
+	t.Run("storage failure fails the whole batch", func(t *testing.T) {
+		repo := memory.NewDeliveryRepository()
+		repo.SetCreateError(errors.New("connection reset"))
+		service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+
+		_, err := service.BulkCreateDeliveries(context.Background(), ports.BulkCreateDeliveriesRequest{
+			Deliveries: []ports.CreateDeliveryRequest{
+				{CustomerID: customerID, PickupLocation: "(-74.0,40.7)", DeliveryLocation: "(-73.9,40.8)"},
+			},
+			AuthContext: customer,
+		})
+		if err == nil {
+			t.Error("expected an error when the batch cannot be stored")
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// CancelDelivery cancels a delivery on behalf of its customer or an admin.
+// The row is kept so cancelled deliveries stay visible in listings.
+func (s *DeliveryService) CancelDelivery(ctx context.Context, req ports.CancelDeliveryRequest) (*domain.Delivery, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.ID))
+
+	delivery, err := s.repo.GetByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Couriers hand deliveries back through status updates, not cancellation
+	switch req.Role {
+	case "admin", "super_admin":
+	case "customer":
+		if req.UserCustomerID == nil || *req.UserCustomerID != delivery.CustomerID {
+			return nil, domain.ErrUnauthorized
+		}
+	default:
+		return nil, domain.ErrUnauthorized
+	}
+
+	previousStatus := delivery.Status
+	before := snapshotDelivery(delivery)
+	if err := delivery.Cancel(req.Role, req.Reason, req.ReasonCode, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Persist the cancellation together with its cancelled event
+	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "delivery-service", "cancel_delivery")
+	event, err := messaging.NewDeliveryCancelledEvent(messaging.DeliveryCancelledEvent{
+		DeliveryID:      delivery.ID,
+		OrgID:           delivery.OrgID,
+		CustomerID:      delivery.CustomerID,
+		CourierID:       delivery.CourierID,
+		PreviousStatus:  previousStatus,
+		Reason:          delivery.CancelReason,
+		ReasonCode:      delivery.CancelReasonCode,
+		CancelledByRole: req.Role,
+		CancelledAt:     delivery.CancelledAt,
+	}, traceCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	outboxEvent, err := newOutboxEvent(ctx, delivery.ID, "delivery-events", messaging.EventTypeDeliveryCancelled, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CancelWithOutbox(ctx, delivery, previousStatus, outboxEvent); err != nil {
+		return nil, err
+	}
+	s.syncCourierStatus(ctx, delivery.CourierID, delivery.Status)
+	s.recordAudit(ctx, auditActionCancel, delivery.ID, before, delivery)
+
+	s.logger.InfoWithFields(ctx, "Delivery cancelled",
+		zap.String("previous_status", previousStatus),
+		zap.String("reason_code", delivery.CancelReasonCode),
+		zap.String("cancelled_by_role", req.Role))
+
+	return delivery, nil
+}
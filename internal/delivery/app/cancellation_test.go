@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+func TestDeliveryService_CancelDelivery(t *testing.T) {
+	customerID := 1
+	otherCustomerID := 2
+	courierID := 3
+
+	newDelivery := func(status string) *domain.Delivery {
+		d := &domain.Delivery{
+			ID:               1,
+			CustomerID:       customerID,
+			Status:           status,
+			PickupLocation:   "123 Main St",
+			DeliveryLocation: "456 Oak Ave",
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		if status != domain.StatusPending {
+			d.CourierID = &courierID
+		}
+		return d
+	}
+	customer := ports.AuthContext{Role: "customer", UserCustomerID: &customerID}
+	admin := ports.AuthContext{Role: "admin"}
+
+	t.Run("customer cancels an assigned delivery", func(t *testing.T) {
+		repo := memory.NewDeliveryRepository()
+		couriers := memory.NewCourierRepository(repo)
+		couriers.AddCourier(courierID, domain.CourierBusy)
+		service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+		service.SetCourierRepository(couriers)
+		repo.AddDelivery(newDelivery(domain.StatusAssigned))
+
+		delivery, err := service.CancelDelivery(context.Background(), ports.CancelDeliveryRequest{
+			ID:          1,
+			Reason:      "  Ordered by mistake ",
+			ReasonCode:  domain.CancelReasonCustomerRequest,
+			AuthContext: customer,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if delivery.Status != domain.StatusCancelled || delivery.CancelledAt == nil {
+			t.Errorf("expected cancelled delivery with timestamp, got %+v", delivery)
+		}
+		if delivery.CancelReason != "Ordered by mistake" || delivery.CancelReasonCode != domain.CancelReasonCustomerRequest {
+			t.Errorf("expected reason to be recorded, got %q (%q)", delivery.CancelReason, delivery.CancelReasonCode)
+		}
+
+		events := repo.OutboxEvents()
+		if len(events) != 1 || events[0].RoutingKey != messaging.EventTypeDeliveryCancelled {
+			t.Fatalf("expected one delivery.cancelled outbox event, got %+v", events)
+		}
+		var event messaging.Event
+		if err := json.Unmarshal(events[0].Payload, &event); err != nil {
+			t.Fatalf("failed to decode outbox payload: %v", err)
+		}
+		data, err := messaging.DecodeData[messaging.DeliveryCancelledEvent](event)
+		if err != nil {
+			t.Fatalf("failed to decode event data: %v", err)
+		}
+		if data.PreviousStatus != domain.StatusAssigned || data.Reason != "Ordered by mistake" || data.CancelledByRole != "customer" {
+			t.Errorf("unexpected event payload %+v", data)
+		}
+
+		if courier, _ := couriers.GetByID(context.Background(), courierID); courier.Status != domain.CourierAvailable {
+			t.Errorf("expected courier to be released, got %s", courier.Status)
+		}
+	})
+
+	t.Run("admin cancels an in-transit delivery", func(t *testing.T) {
+		repo := memory.NewDeliveryRepository()
+		service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+		repo.AddDelivery(newDelivery(domain.StatusInTransit))
+
+		delivery, err := service.CancelDelivery(context.Background(), ports.CancelDeliveryRequest{
+			ID:          1,
+			Reason:      "Address does not exist",
+			ReasonCode:  domain.CancelReasonAddressInvalid,
+			AuthContext: admin,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if delivery.Status != domain.StatusCancelled {
+			t.Errorf("expected cancelled status, got %s", delivery.Status)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		status      string
+		req         ports.CancelDeliveryRequest
+		expectedErr error
+	}{
+		{
+			name:        "customer cannot cancel in transit",
+			status:      domain.StatusInTransit,
+			req:         ports.CancelDeliveryRequest{ID: 1, Reason: "Changed my mind", AuthContext: customer},
+			expectedErr: domain.ErrNotCancellable,
+		},
+		{
+			name:        "delivered deliveries cannot be cancelled",
+			status:      domain.StatusDelivered,
+			req:         ports.CancelDeliveryRequest{ID: 1, Reason: "Too late", AuthContext: admin},
+			expectedErr: domain.ErrNotCancellable,
+		},
+		{
+			name:        "reason is required",
+			status:      domain.StatusPending,
+			req:         ports.CancelDeliveryRequest{ID: 1, Reason: "   ", AuthContext: customer},
+			expectedErr: domain.ErrInvalidCancellation,
+		},
+		{
+			name:        "unknown reason code",
+			status:      domain.StatusPending,
+			req:         ports.CancelDeliveryRequest{ID: 1, Reason: "Because", ReasonCode: "weather", AuthContext: customer},
+			expectedErr: domain.ErrInvalidCancellation,
+		},
+		{
+			name:   "other customers cannot cancel",
+			status: domain.StatusPending,
+			req: ports.CancelDeliveryRequest{ID: 1, Reason: "Not mine",
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &otherCustomerID}},
+			expectedErr: domain.ErrUnauthorized,
+		},
+		{
+			name:   "couriers cannot cancel",
+			status: domain.StatusAssigned,
+			req: ports.CancelDeliveryRequest{ID: 1, Reason: "Too far",
+				AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID}},
+			expectedErr: domain.ErrUnauthorized,
+		},
+		{
+			name:        "unknown delivery",
+			status:      domain.StatusPending,
+			req:         ports.CancelDeliveryRequest{ID: 99, Reason: "Gone", AuthContext: admin},
+			expectedErr: domain.ErrDeliveryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewDeliveryRepository()
+			service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+			repo.AddDelivery(newDelivery(tt.status))
+
+			_, err := service.CancelDelivery(context.Background(), tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if len(repo.OutboxEvents()) != 0 {
+				t.Errorf("expected no events for a rejected cancellation")
+			}
+		})
+	}
+}
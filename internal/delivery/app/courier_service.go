@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CourierService implements the courier availability use cases
+type CourierService struct {
+	repo   ports.CourierRepository
+	logger *logger.Logger
+}
+
+// NewCourierService creates a new courier service
+func NewCourierService(repo ports.CourierRepository, logger *logger.Logger) *CourierService {
+	return &CourierService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// UpdateCourierStatus sets the calling courier's availability
+func (s *CourierService) UpdateCourierStatus(ctx context.Context, req ports.UpdateCourierStatusRequest) (*domain.Courier, error) {
+	if req.Role != "courier" || req.UserCourierID == nil {
+		return nil, domain.ErrUnauthorized
+	}
+	if !domain.IsValidCourierStatus(req.Status) {
+		return nil, domain.ErrInvalidCourierStatus
+	}
+
+	courierID := *req.UserCourierID
+	ctx = logger.WithContext(ctx, zap.Int("courier_id", courierID))
+
+	if err := s.repo.UpdateStatus(ctx, courierID, req.Status, time.Now()); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithFields(ctx, "Courier status updated",
+		zap.String("status", req.Status))
+
+	return s.repo.GetByID(ctx, courierID)
+}
+
+// ListCouriers lists couriers with an optional status filter; admins only
+func (s *CourierService) ListCouriers(ctx context.Context, req ports.ListCouriersRequest) ([]*domain.Courier, error) {
+	if req.Role != "admin" && req.Role != "super_admin" {
+		return nil, domain.ErrUnauthorized
+	}
+	if req.Status != "" && !domain.IsValidCourierStatus(req.Status) {
+		return nil, domain.ErrInvalidCourierStatus
+	}
+
+	return s.repo.List(ctx, req.Status)
+}
+
+// SetCourierRepository lets delivery assignments check and update courier
+// availability. Without it couriers are assigned regardless of their status.
+func (s *DeliveryService) SetCourierRepository(repo ports.CourierRepository) {
+	s.couriers = repo
+}
+
+// requireCourierAvailable returns domain.ErrCourierUnavailable if a courier is off shift
+func (s *DeliveryService) requireCourierAvailable(ctx context.Context, courierID int) error {
+	if s.couriers == nil {
+		return nil
+	}
+
+	courier, err := s.couriers.GetByID(ctx, courierID)
+	if err != nil {
+		return err
+	}
+	if !courier.CanTakeDeliveries() {
+		return domain.ErrCourierUnavailable
+	}
+	return nil
+}
+
+// syncCourierStatus moves a delivery's courier to busy or available after the
+// delivery reached deliveryStatus. The delivery change is already committed,
+// so failures are only logged.
+func (s *DeliveryService) syncCourierStatus(ctx context.Context, courierID *int, deliveryStatus string) {
+	if s.couriers == nil || courierID == nil {
+		return
+	}
+
+	var err error
+	switch domain.CourierStatusForDelivery(deliveryStatus) {
+	case domain.CourierBusy:
+		err = s.couriers.UpdateStatus(ctx, *courierID, domain.CourierBusy, time.Now())
+	case domain.CourierAvailable:
+		err = s.couriers.ReleaseIfIdle(ctx, *courierID, time.Now())
+	default:
+		return
+	}
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to update courier status",
+			zap.Int("courier_id", *courierID), zap.String("delivery_status", deliveryStatus), zap.Error(err))
+	}
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+// storedCourier reads a courier back from the repository
+func storedCourier(t *testing.T, repo *memory.CourierRepository, id int) *domain.Courier {
+	t.Helper()
+	courier, err := repo.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to read courier %d: %v", id, err)
+	}
+	return courier
+}
+
+func TestCourierService_UpdateCourierStatus(t *testing.T) {
+	courierID := 2
+	customerID := 5
+
+	tests := []struct {
+		name        string
+		role        string
+		courierID   *int
+		status      string
+		expectedErr error
+	}{
+		{"courier goes offline", "courier", &courierID, domain.CourierOffline, nil},
+		{"courier becomes available", "courier", &courierID, domain.CourierAvailable, nil},
+		{"invalid status", "courier", &courierID, "on_break", domain.ErrInvalidCourierStatus},
+		{"courier without courier ID", "courier", nil, domain.CourierOffline, domain.ErrUnauthorized},
+		{"admin cannot set own status", "admin", nil, domain.CourierOffline, domain.ErrUnauthorized},
+		{"customer cannot set status", "customer", &customerID, domain.CourierOffline, domain.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewCourierRepository(nil)
+			repo.AddCourier(courierID, domain.CourierBusy)
+			service := NewCourierService(repo, createTestLogger(t))
+
+			courier, err := service.UpdateCourierStatus(context.Background(), ports.UpdateCourierStatusRequest{
+				Status:      tt.status,
+				AuthContext: ports.AuthContext{Role: tt.role, UserCourierID: tt.courierID},
+			})
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				if storedCourier(t, repo, courierID).Status != domain.CourierBusy {
+					t.Errorf("expected status to be unchanged, got %s", storedCourier(t, repo, courierID).Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if courier.Status != tt.status || courier.StatusUpdatedAt.IsZero() {
+				t.Errorf("expected status %s with a timestamp, got %+v", tt.status, courier)
+			}
+		})
+	}
+}
+
+func TestCourierService_ListCouriers(t *testing.T) {
+	repo := memory.NewCourierRepository(nil)
+	repo.AddCourier(1, domain.CourierAvailable)
+	repo.AddCourier(2, domain.CourierBusy)
+	repo.AddCourier(3, domain.CourierAvailable)
+	service := NewCourierService(repo, createTestLogger(t))
+
+	couriers, err := service.ListCouriers(context.Background(), ports.ListCouriersRequest{
+		Status:      domain.CourierAvailable,
+		AuthContext: ports.AuthContext{Role: "admin"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(couriers) != 2 {
+		t.Errorf("expected 2 available couriers, got %d", len(couriers))
+	}
+
+	courierID := 1
+	_, err = service.ListCouriers(context.Background(), ports.ListCouriersRequest{
+		AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+	})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for couriers, got %v", err)
+	}
+
+	_, err = service.ListCouriers(context.Background(), ports.ListCouriersRequest{
+		Status:      "sleeping",
+		AuthContext: ports.AuthContext{Role: "admin"},
+	})
+	if !errors.Is(err, domain.ErrInvalidCourierStatus) {
+		t.Errorf("expected ErrInvalidCourierStatus, got %v", err)
+	}
+}
+
+func TestDeliveryService_CourierAvailabilityFollowsDeliveries(t *testing.T) {
+	ctx := context.Background()
+	deliveryRepo := memory.NewDeliveryRepository()
+	courierRepo := memory.NewCourierRepository(deliveryRepo)
+	courierRepo.AddCourier(2, domain.CourierAvailable)
+	service := NewDeliveryService(deliveryRepo, &MockGeocodingService{}, nil, createTestLogger(t))
+	service.SetCourierRepository(courierRepo)
+
+	courierID := 2
+	admin := ports.AuthContext{Role: "admin"}
+	create := func() *domain.Delivery {
+		d, err := service.CreateDelivery(ctx, ports.CreateDeliveryRequest{
+			CustomerID:       1,
+			CourierID:        &courierID,
+			PickupLocation:   "123 Main St",
+			DeliveryLocation: "456 Oak Ave",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating delivery: %v", err)
+		}
+		return d
+	}
+	setStatus := func(id int, status string) {
+		_, err := service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{ID: id, Status: status, AuthContext: admin})
+		if err != nil {
+			t.Fatalf("unexpected error updating delivery %d to %s: %v", id, status, err)
+		}
+	}
+	expectStatus := func(expected string) {
+		t.Helper()
+		if got := storedCourier(t, courierRepo, courierID).Status; got != expected {
+			t.Errorf("expected courier %s, got %s", expected, got)
+		}
+	}
+
+	first := create()
+	expectStatus(domain.CourierBusy)
+
+	second := create()
+	setStatus(first.ID, domain.StatusInTransit)
+	setStatus(first.ID, domain.StatusDelivered)
+	expectStatus(domain.CourierBusy) // still has the second delivery
+
+	if _, err := service.CancelDelivery(ctx, ports.CancelDeliveryRequest{ID: second.ID, Reason: "no longer needed", AuthContext: admin}); err != nil {
+		t.Fatalf("unexpected error cancelling delivery %d: %v", second.ID, err)
+	}
+	expectStatus(domain.CourierAvailable)
+}
+
+func TestDeliveryService_RejectsOfflineCouriers(t *testing.T) {
+	ctx := context.Background()
+	deliveryRepo := memory.NewDeliveryRepository()
+	courierRepo := memory.NewCourierRepository(deliveryRepo)
+	courierRepo.AddCourier(2, domain.CourierOffline)
+	service := NewDeliveryService(deliveryRepo, &MockGeocodingService{}, nil, createTestLogger(t))
+	service.SetCourierRepository(courierRepo)
+
+	courierID := 2
+	_, err := service.CreateDelivery(ctx, ports.CreateDeliveryRequest{
+		CustomerID:       1,
+		CourierID:        &courierID,
+		PickupLocation:   "123 Main St",
+		DeliveryLocation: "456 Oak Ave",
+	})
+	if !errors.Is(err, domain.ErrCourierUnavailable) {
+		t.Errorf("expected ErrCourierUnavailable creating a delivery, got %v", err)
+	}
+
+	deliveryRepo.AddDelivery(&domain.Delivery{ID: 10, CustomerID: 1, Status: domain.StatusPending})
+	_, err = service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
+		ID:          10,
+		Status:      domain.StatusAssigned,
+		AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+	})
+	if !errors.Is(err, domain.ErrCourierUnavailable) {
+		t.Errorf("expected ErrCourierUnavailable self-assigning, got %v", err)
+	}
+	if storedDelivery(t, deliveryRepo, 10).CourierID != nil {
+		t.Error("expected delivery to stay unassigned")
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"go.uber.org/zap"
+)
+
+// DefaultTrackingLookupTimeout bounds each tracking service call made for a
+// delivery's details when no timeout is configured
+const DefaultTrackingLookupTimeout = 500 * time.Millisecond
+
+// SetDeliveryTracker lets delivery details embed the latest location and ETA
+// from the tracking service, giving each lookup at most timeout
+func (s *DeliveryService) SetDeliveryTracker(tracker ports.DeliveryTracker, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTrackingLookupTimeout
+	}
+	s.tracker = tracker
+	s.trackTimeout = timeout
+}
+
+// GetDeliveryDetails retrieves a delivery with the live tracking data asked
+// for. The lookups run concurrently on the caller's behalf; one that fails or
+// times out leaves its field nil and marks the details partial rather than
+// failing the read. A delivery without a reported location, or without
+// destination coordinates for an ETA, simply has no value.
+func (s *DeliveryService) GetDeliveryDetails(ctx context.Context, req ports.GetDeliveryDetailsRequest) (*domain.DeliveryDetails, error) {
+	delivery, err := s.GetDelivery(ctx, ports.GetDeliveryRequest{ID: req.ID, AuthContext: req.AuthContext})
+	if err != nil {
+		return nil, err
+	}
+
+	details := &domain.DeliveryDetails{Delivery: delivery}
+	if !req.IncludeLocation && !req.IncludeETA {
+		return details, nil
+	}
+	if s.tracker == nil {
+		details.Partial = true
+		return details, nil
+	}
+
+	var wg sync.WaitGroup
+	var locationErr, etaErr error
+	if req.IncludeLocation {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lookupCtx, cancel := context.WithTimeout(ctx, s.trackTimeout)
+			defer cancel()
+			location, err := s.tracker.CurrentLocation(lookupCtx, delivery.ID)
+			if err != nil {
+				locationErr = err
+				return
+			}
+			details.CurrentLocation = location
+		}()
+	}
+	if destination, ok := delivery.DeliveryCoordinates(); req.IncludeETA && ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lookupCtx, cancel := context.WithTimeout(ctx, s.trackTimeout)
+			defer cancel()
+			eta, err := s.tracker.ETA(lookupCtx, delivery.ID, *destination)
+			if err != nil {
+				etaErr = err
+				return
+			}
+			details.ETA = eta
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range []error{locationErr, etaErr} {
+		if err != nil {
+			details.Partial = true
+			s.logger.WarnWithFields(ctx, "Tracking lookup for delivery details failed",
+				zap.Int("delivery_id", delivery.ID), zap.Error(err))
+		}
+	}
+
+	return details, nil
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+// MockDeliveryTracker is a mock implementation of DeliveryTracker for testing.
+// A nil func blocks until the lookup times out.
+type MockDeliveryTracker struct {
+	calls    atomic.Int64
+	location func() (*domain.TrackedLocation, error)
+	eta      func(destination domain.Coordinates) (*domain.DeliveryETA, error)
+}
+
+func (m *MockDeliveryTracker) CurrentLocation(ctx context.Context, deliveryID int) (*domain.TrackedLocation, error) {
+	m.calls.Add(1)
+	if m.location == nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return m.location()
+}
+
+func (m *MockDeliveryTracker) ETA(ctx context.Context, deliveryID int, destination domain.Coordinates) (*domain.DeliveryETA, error) {
+	m.calls.Add(1)
+	if m.eta == nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return m.eta(destination)
+}
+
+func TestDeliveryService_GetDeliveryDetails(t *testing.T) {
+	customerID := 1
+	repo := memory.NewDeliveryRepository()
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: customerID, Status: domain.StatusInTransit,
+		DeliveryLocation: "(-73.935200,40.730600)"})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CustomerID: customerID, Status: domain.StatusInTransit,
+		DeliveryLocation: "9 Elm St"})
+	auth := ports.AuthContext{Role: "customer", UserCustomerID: &customerID}
+
+	location := &domain.TrackedLocation{Latitude: 40.7128, Longitude: -74.006, Timestamp: time.Now()}
+	found := func() (*domain.TrackedLocation, error) { return location, nil }
+	var gotDestination domain.Coordinates
+	estimate := func(destination domain.Coordinates) (*domain.DeliveryETA, error) {
+		gotDestination = destination
+		return &domain.DeliveryETA{Seconds: 600, DistanceKm: 4}, nil
+	}
+	unavailable := errors.New("tracking unavailable")
+
+	tests := []struct {
+		name         string
+		deliveryID   int
+		tracker      *MockDeliveryTracker
+		noTracker    bool
+		location     bool
+		eta          bool
+		wantLocation bool
+		wantETA      bool
+		wantPartial  bool
+		wantCalls    int64
+	}{
+		{name: "nothing requested", deliveryID: 1, tracker: &MockDeliveryTracker{}},
+		{name: "location and ETA", deliveryID: 1, tracker: &MockDeliveryTracker{location: found, eta: estimate},
+			location: true, eta: true, wantLocation: true, wantETA: true, wantCalls: 2},
+		{name: "failed lookup", deliveryID: 1, tracker: &MockDeliveryTracker{
+			location: func() (*domain.TrackedLocation, error) { return nil, unavailable }, eta: estimate,
+		}, location: true, eta: true, wantETA: true, wantPartial: true, wantCalls: 2},
+		{name: "timed out lookup", deliveryID: 1, tracker: &MockDeliveryTracker{location: found},
+			location: true, eta: true, wantLocation: true, wantPartial: true, wantCalls: 2},
+		{name: "no location reported", deliveryID: 1, tracker: &MockDeliveryTracker{
+			location: func() (*domain.TrackedLocation, error) { return nil, nil },
+		}, location: true, wantCalls: 1},
+		{name: "destination without coordinates", deliveryID: 2, tracker: &MockDeliveryTracker{eta: estimate}, eta: true},
+		{name: "no tracker", deliveryID: 1, noTracker: true, location: true, wantPartial: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+			if !tt.noTracker {
+				service.SetDeliveryTracker(tt.tracker, 20*time.Millisecond)
+			}
+
+			details, err := service.GetDeliveryDetails(context.Background(), ports.GetDeliveryDetailsRequest{
+				ID:              tt.deliveryID,
+				IncludeLocation: tt.location,
+				IncludeETA:      tt.eta,
+				AuthContext:     auth,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if details.Delivery == nil || details.Delivery.ID != tt.deliveryID {
+				t.Errorf("expected delivery %d, got %+v", tt.deliveryID, details.Delivery)
+			}
+			if (details.CurrentLocation != nil) != tt.wantLocation || (details.ETA != nil) != tt.wantETA {
+				t.Errorf("expected location %v and ETA %v, got %+v and %+v", tt.wantLocation, tt.wantETA, details.CurrentLocation, details.ETA)
+			}
+			if details.Partial != tt.wantPartial {
+				t.Errorf("expected partial %v, got %v", tt.wantPartial, details.Partial)
+			}
+			if tt.tracker != nil && tt.tracker.calls.Load() != tt.wantCalls {
+				t.Errorf("expected %d tracking lookups, got %d", tt.wantCalls, tt.tracker.calls.Load())
+			}
+		})
+	}
+
+	if gotDestination.Latitude != 40.7306 || gotDestination.Longitude != -73.9352 {
+		t.Errorf("expected the ETA to the delivery location, got %+v", gotDestination)
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		otherCustomerID := 2
+		service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+		tracker := &MockDeliveryTracker{location: found}
+		service.SetDeliveryTracker(tracker, time.Second)
+
+		_, err := service.GetDeliveryDetails(context.Background(), ports.GetDeliveryDetailsRequest{
+			ID: 1, IncludeLocation: true,
+			AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &otherCustomerID},
+		})
+		if !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("expected ErrUnauthorized, got %v", err)
+		}
+		if tracker.calls.Load() != 0 {
+			t.Error("expected no tracking lookup for an unauthorized caller")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// LateDetectorConfig holds late delivery detector configuration
+type LateDetectorConfig struct {
+	CheckInterval time.Duration
+	BatchSize     int
+}
+
+// DefaultLateDetectorConfig returns a default late delivery detector configuration
+func DefaultLateDetectorConfig() LateDetectorConfig {
+	return LateDetectorConfig{
+		CheckInterval: time.Minute,
+		BatchSize:     100,
+	}
+}
+
+// LateDeliveryDetector flags deliveries that are still open after their
+// scheduled window ended and emits a delivery.late event for each
+type LateDeliveryDetector struct {
+	repo   ports.DeliveryRepository
+	config LateDetectorConfig
+	logger *logger.Logger
+	now    func() time.Time
+}
+
+// NewLateDeliveryDetector creates a new late delivery detector
+func NewLateDeliveryDetector(repo ports.DeliveryRepository, config LateDetectorConfig, logger *logger.Logger) *LateDeliveryDetector {
+	return &LateDeliveryDetector{
+		repo:   repo,
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Run checks for late deliveries until the context is cancelled
+func (d *LateDeliveryDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DetectLate(ctx); err != nil && ctx.Err() == nil {
+			d.logger.ErrorWithFields(ctx, "Failed to detect late deliveries", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DetectLate flags one batch of overdue deliveries as late and returns how
+// many were flagged
+func (d *LateDeliveryDetector) DetectLate(ctx context.Context) (int, error) {
+	now := d.now()
+	deliveries, err := d.repo.GetOverdue(ctx, now, d.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get overdue deliveries: %w", err)
+	}
+
+	flagged := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return flagged, ctx.Err()
+		}
+		if !delivery.IsOverdue(now) {
+			continue
+		}
+
+		if err := d.markLate(ctx, delivery); err != nil {
+			if !errors.Is(err, domain.ErrNotOverdue) {
+				d.logger.ErrorWithFields(ctx, "Failed to flag late delivery",
+					zap.Int("delivery_id", delivery.ID), zap.Error(err))
+			}
+			continue
+		}
+		flagged++
+	}
+
+	return flagged, nil
+}
+
+// markLate flags a delivery as late together with its delivery.late event
+func (d *LateDeliveryDetector) markLate(ctx context.Context, delivery *domain.Delivery) error {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", delivery.ID))
+
+	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "delivery-service", "late_delivery_check")
+	event, err := messaging.NewDeliveryLateEvent(messaging.DeliveryLateEvent{
+		DeliveryID:    delivery.ID,
+		OrgID:         delivery.OrgID,
+		CustomerID:    delivery.CustomerID,
+		CourierID:     delivery.CourierID,
+		Status:        delivery.Status,
+		ScheduledDate: delivery.ScheduledDate,
+		ScheduledEnd:  delivery.ScheduledEnd,
+	}, traceCtx)
+	if err != nil {
+		return err
+	}
+
+	outboxEvent, err := newOutboxEvent(ctx, delivery.ID, "delivery-events", messaging.EventTypeDeliveryLate, event)
+	if err != nil {
+		return err
+	}
+
+	if err := d.repo.MarkLateWithOutbox(ctx, delivery.ID, outboxEvent); err != nil {
+		return err
+	}
+	delivery.Late = true
+
+	d.logger.WarnWithFields(ctx, "Delivery flagged late",
+		zap.String("status", delivery.Status),
+		zap.Timep("scheduled_end", delivery.ScheduledEnd))
+
+	return nil
+}
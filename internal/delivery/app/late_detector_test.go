@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+func newTestLateDetector(t *testing.T, repo *memory.DeliveryRepository, now time.Time) *LateDeliveryDetector {
+	detector := NewLateDeliveryDetector(repo, DefaultLateDetectorConfig(), createTestLogger(t))
+	detector.now = func() time.Time { return now }
+	return detector
+}
+
+func TestLateDeliveryDetector_DetectLate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	courierID := 2
+
+	repo := memory.NewDeliveryRepository()
+	for _, d := range []*domain.Delivery{
+		{ID: 1, CustomerID: 1, CourierID: &courierID, Status: domain.StatusInTransit, ScheduledEnd: &past},
+		{ID: 2, CustomerID: 1, Status: domain.StatusPending, ScheduledEnd: &past},
+		{ID: 3, CustomerID: 1, Status: domain.StatusInTransit, ScheduledEnd: &future},
+		{ID: 4, CustomerID: 1, Status: domain.StatusDelivered, ScheduledEnd: &past},
+		{ID: 5, CustomerID: 1, Status: domain.StatusCancelled, ScheduledEnd: &past},
+		{ID: 6, CustomerID: 1, Status: domain.StatusInTransit},
+		{ID: 7, CustomerID: 1, Status: domain.StatusInTransit, ScheduledEnd: &past, Late: true},
+	} {
+		repo.AddDelivery(d)
+	}
+	detector := newTestLateDetector(t, repo, now)
+
+	flagged, err := detector.DetectLate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flagged != 2 {
+		t.Errorf("expected 2 deliveries flagged, got %d", flagged)
+	}
+
+	for id, late := range map[int]bool{1: true, 2: true, 3: false, 4: false, 5: false, 6: false, 7: true} {
+		if storedDelivery(t, repo, id).Late != late {
+			t.Errorf("expected delivery %d late=%v, got %v", id, late, storedDelivery(t, repo, id).Late)
+		}
+	}
+
+	events := repo.OutboxEvents()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 outbox events, got %d", len(events))
+	}
+	if events[0].RoutingKey != messaging.EventTypeDeliveryLate || events[0].Exchange != "delivery-events" || events[0].AggregateID != 1 {
+		t.Errorf("unexpected outbox event: %+v", events[0])
+	}
+
+	var msg messaging.Event
+	if err := json.Unmarshal(events[0].Payload, &msg); err != nil {
+		t.Fatalf("failed to unmarshal outbox payload: %v", err)
+	}
+	data, err := messaging.DecodeData[messaging.DeliveryLateEvent](msg)
+	if err != nil {
+		t.Fatalf("failed to decode late event: %v", err)
+	}
+	if data.DeliveryID != 1 || data.CustomerID != 1 || data.CourierID == nil || *data.CourierID != courierID ||
+		data.ScheduledEnd == nil || !data.ScheduledEnd.Equal(past) {
+		t.Errorf("unexpected late event data: %+v", data)
+	}
+
+	// Deliveries are flagged only once
+	flagged, err = detector.DetectLate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flagged != 0 || len(repo.OutboxEvents()) != 2 {
+		t.Errorf("expected no new flags, got %d flagged and %d events", flagged, len(repo.OutboxEvents()))
+	}
+}
+
+func TestLateDeliveryDetector_RespectsBatchSize(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+
+	repo := memory.NewDeliveryRepository()
+	for id := 1; id <= 5; id++ {
+		repo.AddDelivery(&domain.Delivery{ID: id, CustomerID: 1, Status: domain.StatusAssigned, ScheduledEnd: &past})
+	}
+	detector := newTestLateDetector(t, repo, now)
+	detector.config.BatchSize = 2
+
+	flagged, err := detector.DetectLate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flagged != 2 {
+		t.Errorf("expected 2 deliveries flagged, got %d", flagged)
+	}
+}
+
+func TestLateDeliveryDetector_SkipsFailedUpdates(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+
+	repo := memory.NewDeliveryRepository()
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusInTransit, ScheduledEnd: &past})
+	repo.SetUpdateError(errors.New("database unavailable"))
+	detector := newTestLateDetector(t, repo, now)
+
+	flagged, err := detector.DetectLate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flagged != 0 || storedDelivery(t, repo, 1).Late || len(repo.OutboxEvents()) != 0 {
+		t.Errorf("expected failed update to leave delivery unflagged, got %d flagged", flagged)
+	}
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// OutboxDispatcherConfig holds outbox dispatcher configuration
+type OutboxDispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// Lease is how long a fetched batch stays hidden from other dispatchers
+	Lease time.Duration
+}
+
+// DefaultOutboxDispatcherConfig returns a default outbox dispatcher configuration
+func DefaultOutboxDispatcherConfig() OutboxDispatcherConfig {
+	return OutboxDispatcherConfig{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		InitialDelay: time.Second,
+		MaxDelay:     5 * time.Minute,
+		Multiplier:   2.0,
+		Lease:        time.Minute,
+	}
+}
+
+// OutboxMetrics is a snapshot of dispatcher counters and outbox lag
+type OutboxMetrics struct {
+	Published        int64   `json:"published"`
+	Failed           int64   `json:"failed"`
+	DeadLettered     int64   `json:"dead_lettered"`
+	Pending          int     `json:"pending"`
+	Dead             int     `json:"dead"`
+	LagSeconds       float64 `json:"lag_seconds"`
+	LastDispatchedAt *int64  `json:"last_dispatched_at,omitempty"`
+}
+
+// OutboxDispatcher publishes pending outbox events to the message broker
+type OutboxDispatcher struct {
+	repo      ports.OutboxRepository
+	publisher messaging.Publisher
+	webhooks  *WebhookService // nil until SetWebhooks
+	config    OutboxDispatcherConfig
+	logger    *logger.Logger
+
+	mu               sync.Mutex
+	published        int64
+	failed           int64
+	deadLettered     int64
+	lastDispatchedAt time.Time
+}
+
+// NewOutboxDispatcher creates a new outbox dispatcher
+func NewOutboxDispatcher(repo ports.OutboxRepository, publisher messaging.Publisher, config OutboxDispatcherConfig, logger *logger.Logger) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		repo:      repo,
+		publisher: publisher,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// SetWebhooks queues each event for the webhook subscriptions it matches
+// before publishing it; an event that cannot be queued is retried like a
+// failed publish
+func (d *OutboxDispatcher) SetWebhooks(webhooks *WebhookService) {
+	d.webhooks = webhooks
+}
+
+// Run polls the outbox until the context is cancelled
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchPending(ctx); err != nil && ctx.Err() == nil {
+			d.logger.ErrorWithFields(ctx, "Failed to dispatch outbox events", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchPending publishes one batch of due outbox events and returns how
+// many were published successfully
+func (d *OutboxDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	events, err := d.repo.FetchPending(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch pending outbox events: %w", err)
+	}
+
+	published := 0
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+
+		if err := d.dispatch(ctx, event); err != nil {
+			d.recordFailure(ctx, event, err)
+			continue
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// dispatch publishes a single outbox event and marks it sent
+func (d *OutboxDispatcher) dispatch(ctx context.Context, event *domain.OutboxEvent) error {
+	var msg messaging.Event
+	if err := json.Unmarshal(event.Payload, &msg); err != nil {
+		return fmt.Errorf("failed to unmarshal outbox payload: %w", err)
+	}
+
+	if msg.TraceContext != nil {
+		ctx = messaging.ContextWithTraceContext(ctx, msg.TraceContext)
+	}
+
+	if d.webhooks != nil {
+		if err := d.webhooks.Enqueue(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	if err := d.publisher.Publish(ctx, event.Exchange, event.RoutingKey, msg); err != nil {
+		return err
+	}
+
+	event.MarkSent()
+	if err := d.repo.MarkSent(ctx, event.ID, *event.SentAt); err != nil {
+		// The event reached the broker; it will be published again once
+		// its lease runs out, which consumers must tolerate
+		d.logger.ErrorWithFields(ctx, "Failed to mark outbox event as sent",
+			zap.Int64("outbox_id", event.ID), zap.Error(err))
+	}
+
+	d.mu.Lock()
+	d.published++
+	d.lastDispatchedAt = time.Now()
+	d.mu.Unlock()
+
+	return nil
+}
+
+// recordFailure schedules the next attempt for a failed event or moves it to the dead state
+func (d *OutboxDispatcher) recordFailure(ctx context.Context, event *domain.OutboxEvent, publishErr error) {
+	event.MarkFailed(publishErr, d.config.MaxAttempts, d.backoff(event.Attempts+1))
+
+	d.mu.Lock()
+	d.failed++
+	if event.IsDead() {
+		d.deadLettered++
+	}
+	d.mu.Unlock()
+
+	if event.IsDead() {
+		d.logger.ErrorWithFields(ctx, "Outbox event exceeded max publish attempts",
+			zap.Int64("outbox_id", event.ID),
+			zap.String("routing_key", event.RoutingKey),
+			zap.Int("attempts", event.Attempts),
+			zap.Error(publishErr))
+	} else {
+		d.logger.WarnWithFields(ctx, "Failed to publish outbox event, will retry",
+			zap.Int64("outbox_id", event.ID),
+			zap.String("routing_key", event.RoutingKey),
+			zap.Int("attempts", event.Attempts),
+			zap.Time("next_attempt_at", event.NextAttemptAt),
+			zap.Error(publishErr))
+	}
+
+	if err := d.repo.MarkFailed(ctx, event); err != nil {
+		d.logger.ErrorWithFields(ctx, "Failed to record outbox publish failure",
+			zap.Int64("outbox_id", event.ID), zap.Error(err))
+	}
+}
+
+// backoff returns the delay before the given attempt using exponential backoff
+func (d *OutboxDispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.InitialDelay
+	for i := 1; i < attempt; i++ {
+		delay = time.Duration(float64(delay) * d.config.Multiplier)
+		if delay > d.config.MaxDelay {
+			return d.config.MaxDelay
+		}
+	}
+	return delay
+}
+
+// Metrics returns dispatcher counters together with the current outbox lag
+func (d *OutboxDispatcher) Metrics(ctx context.Context) (*OutboxMetrics, error) {
+	stats, err := d.repo.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	metrics := &OutboxMetrics{
+		Published:    d.published,
+		Failed:       d.failed,
+		DeadLettered: d.deadLettered,
+		Pending:      stats.Pending,
+		Dead:         stats.Dead,
+		LagSeconds:   stats.OldestPendingAge.Seconds(),
+	}
+	if !d.lastDispatchedAt.IsZero() {
+		ts := d.lastDispatchedAt.Unix()
+		metrics.LastDispatchedAt = &ts
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxRepository for testing
+type MockOutboxRepository struct {
+	events map[int64]*domain.OutboxEvent
+	nextID int64
+}
+
+func NewMockOutboxRepository() *MockOutboxRepository {
+	return &MockOutboxRepository{
+		events: make(map[int64]*domain.OutboxEvent),
+		nextID: 1,
+	}
+}
+
+func (m *MockOutboxRepository) Add(event *domain.OutboxEvent) {
+	event.ID = m.nextID
+	m.events[m.nextID] = event
+	m.nextID++
+}
+
+func (m *MockOutboxRepository) FetchPending(ctx context.Context, limit int, lease time.Duration) ([]*domain.OutboxEvent, error) {
+	now := time.Now()
+	var pending []*domain.OutboxEvent
+	for id := int64(1); id < m.nextID && len(pending) < limit; id++ {
+		e, ok := m.events[id]
+		if ok && e.Status == domain.OutboxStatusPending && !e.NextAttemptAt.After(now) {
+			e.NextAttemptAt = now.Add(lease)
+			copied := *e
+			pending = append(pending, &copied)
+		}
+	}
+	return pending, nil
+}
+
+func (m *MockOutboxRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	e := m.events[id]
+	e.Status = domain.OutboxStatusSent
+	e.SentAt = &sentAt
+	return nil
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, event *domain.OutboxEvent) error {
+	copied := *event
+	m.events[event.ID] = &copied
+	return nil
+}
+
+func (m *MockOutboxRepository) Stats(ctx context.Context) (*domain.OutboxStats, error) {
+	stats := &domain.OutboxStats{}
+	for _, e := range m.events {
+		switch e.Status {
+		case domain.OutboxStatusPending:
+			stats.Pending++
+			if age := time.Since(e.CreatedAt); age > stats.OldestPendingAge {
+				stats.OldestPendingAge = age
+			}
+		case domain.OutboxStatusDead:
+			stats.Dead++
+		}
+	}
+	return stats, nil
+}
+
+func (m *MockOutboxRepository) ListPublished(ctx context.Context, filter ports.OutboxRangeFilter) ([]*domain.OutboxEvent, error) {
+	var events []*domain.OutboxEvent
+	for id := filter.AfterID + 1; id < m.nextID && len(events) < filter.Limit; id++ {
+		e, ok := m.events[id]
+		if !ok || e.Status == domain.OutboxStatusPending || e.CreatedAt.Before(filter.From) || !e.CreatedAt.Before(filter.To) {
+			continue
+		}
+		if len(filter.RoutingKeys) > 0 && !slices.Contains(filter.RoutingKeys, e.RoutingKey) {
+			continue
+		}
+		copied := *e
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// makeDue clears backoffs and leases so events are picked up by the next poll
+func (m *MockOutboxRepository) makeDue() {
+	for _, e := range m.events {
+		e.NextAttemptAt = time.Now().Add(-time.Second)
+	}
+}
+
+func newTestOutboxEvent(t *testing.T, deliveryID int) *domain.OutboxEvent {
+	event := messaging.NewEventWithTrace("delivery.created", "delivery-service", "create_delivery",
+		map[string]interface{}{"delivery_id": "1"}, nil)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	outboxEvent, err := domain.NewOutboxEvent(deliveryID, "delivery-events", "delivery.created", payload)
+	if err != nil {
+		t.Fatalf("failed to create outbox event: %v", err)
+	}
+	return outboxEvent
+}
+
+func TestOutboxDispatcher_PublishesPendingEvents(t *testing.T) {
+	repo := NewMockOutboxRepository()
+	repo.Add(newTestOutboxEvent(t, 1))
+	repo.Add(newTestOutboxEvent(t, 2))
+	publisher := testsupport.NewPublisher()
+
+	dispatcher := NewOutboxDispatcher(repo, publisher, DefaultOutboxDispatcherConfig(), createTestLogger(t))
+
+	published, err := dispatcher.DispatchPending(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if published != 2 {
+		t.Errorf("expected 2 published events, got %d", published)
+	}
+	if len(publisher.Events()) != 2 {
+		t.Errorf("expected publisher to receive 2 events, got %d", len(publisher.Events()))
+	}
+	for id, e := range repo.events {
+		if e.Status != domain.OutboxStatusSent {
+			t.Errorf("expected event %d to be sent, got %s", id, e.Status)
+		}
+	}
+}
+
+func TestOutboxDispatcher_SkipsClaimedEvents(t *testing.T) {
+	repo := NewMockOutboxRepository()
+	repo.Add(newTestOutboxEvent(t, 1))
+	publisher := testsupport.NewPublisher()
+	dispatcher := NewOutboxDispatcher(repo, publisher, DefaultOutboxDispatcherConfig(), createTestLogger(t))
+
+	// Another replica claims the event first
+	if claimed, _ := repo.FetchPending(context.Background(), 10, time.Minute); len(claimed) != 1 {
+		t.Fatalf("expected the event to be claimed, got %d", len(claimed))
+	}
+	if published, _ := dispatcher.DispatchPending(context.Background()); published != 0 {
+		t.Errorf("expected a claimed event not to be published again, got %d", published)
+	}
+
+	// The other replica died before publishing; the lease runs out
+	repo.makeDue()
+	if published, _ := dispatcher.DispatchPending(context.Background()); published != 1 {
+		t.Errorf("expected the event to be published once its lease ran out, got %d", published)
+	}
+	if len(publisher.Events()) != 1 {
+		t.Errorf("expected the event to be published once, got %d", len(publisher.Events()))
+	}
+}
+
+func TestOutboxDispatcher_RetriesFailedEvents(t *testing.T) {
+	repo := NewMockOutboxRepository()
+	repo.Add(newTestOutboxEvent(t, 1))
+	publisher := testsupport.NewPublisher()
+	publisher.SetError(errors.New("broker unavailable"))
+
+	dispatcher := NewOutboxDispatcher(repo, publisher, DefaultOutboxDispatcherConfig(), createTestLogger(t))
+
+	if _, err := dispatcher.DispatchPending(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := repo.events[1]
+	if e.Status != domain.OutboxStatusPending {
+		t.Fatalf("expected event to stay pending, got %s", e.Status)
+	}
+	if e.Attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", e.Attempts)
+	}
+	if e.LastError != "broker unavailable" {
+		t.Errorf("expected last error to be recorded, got %q", e.LastError)
+	}
+	if !e.NextAttemptAt.After(time.Now()) {
+		t.Error("expected next attempt to be scheduled in the future")
+	}
+
+	// Not due yet, so nothing is fetched
+	if published, _ := dispatcher.DispatchPending(context.Background()); published != 0 {
+		t.Errorf("expected backoff to delay retry, got %d published", published)
+	}
+
+	// Broker recovers
+	publisher.SetError(nil)
+	repo.makeDue()
+
+	published, err := dispatcher.DispatchPending(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if published != 1 {
+		t.Errorf("expected retried event to be published, got %d", published)
+	}
+	if repo.events[1].Status != domain.OutboxStatusSent {
+		t.Errorf("expected event to be sent, got %s", repo.events[1].Status)
+	}
+}
+
+func TestOutboxDispatcher_DeadAfterMaxAttempts(t *testing.T) {
+	repo := NewMockOutboxRepository()
+	repo.Add(newTestOutboxEvent(t, 1))
+	publisher := testsupport.NewPublisher()
+	publisher.SetError(errors.New("broker unavailable"))
+
+	config := DefaultOutboxDispatcherConfig()
+	config.MaxAttempts = 3
+	dispatcher := NewOutboxDispatcher(repo, publisher, config, createTestLogger(t))
+
+	for i := 0; i < config.MaxAttempts; i++ {
+		repo.makeDue()
+		if _, err := dispatcher.DispatchPending(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	e := repo.events[1]
+	if e.Status != domain.OutboxStatusDead {
+		t.Fatalf("expected event to be dead, got %s", e.Status)
+	}
+	if e.Attempts != config.MaxAttempts {
+		t.Errorf("expected %d attempts, got %d", config.MaxAttempts, e.Attempts)
+	}
+
+	// Dead events are not retried
+	publisher.SetError(nil)
+	repo.makeDue()
+	if published, _ := dispatcher.DispatchPending(context.Background()); published != 0 {
+		t.Errorf("expected dead event not to be published, got %d", published)
+	}
+
+	metrics, err := dispatcher.Metrics(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metrics.Dead != 1 || metrics.DeadLettered != 1 || metrics.Failed != int64(config.MaxAttempts) {
+		t.Errorf("unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestOutboxDispatcher_Backoff(t *testing.T) {
+	config := DefaultOutboxDispatcherConfig()
+	config.InitialDelay = time.Second
+	config.MaxDelay = 5 * time.Second
+	dispatcher := NewOutboxDispatcher(NewMockOutboxRepository(), testsupport.NewPublisher(), config, createTestLogger(t))
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := dispatcher.backoff(i + 1); got != want {
+			t.Errorf("attempt %d: expected backoff %v, got %v", i+1, want, got)
+		}
+	}
+}
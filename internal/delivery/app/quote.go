@@ -0,0 +1,217 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+	"go.uber.org/zap"
+)
+
+// Quote settings
+const (
+	// DefaultQuoteTTL is how long quotes are honoured when no TTL is configured
+	DefaultQuoteTTL = 15 * time.Minute
+	// quoteCoordinateTolerance is how far, in degrees, a delivery's locations
+	// may be from the quoted ones, about 10 m, so re-geocoding the same
+	// address still matches
+	quoteCoordinateTolerance = 1e-4
+)
+
+// PricingConfig configures delivery quotes
+type PricingConfig struct {
+	Model    domain.PricingModel
+	QuoteTTL time.Duration // how long a quote is honoured
+	Secret   []byte        // signs quote tokens; shared by every replica
+}
+
+// SetPricing enables delivery quotes, priced by cfg's model with zones looked
+// up through zones
+func (s *DeliveryService) SetPricing(cfg PricingConfig, zones ports.ZoneLocator) {
+	if cfg.QuoteTTL <= 0 {
+		cfg.QuoteTTL = DefaultQuoteTTL
+	}
+	s.pricing = &cfg
+	s.zones = zones
+}
+
+// quoteClaims is what a quote token binds: the price and the delivery it was
+// priced for
+type quoteClaims struct {
+	TotalCents    int64      `json:"p"`
+	Currency      string     `json:"c"`
+	Pickup        [2]float64 `json:"o"` // latitude, longitude
+	Dropoff       [2]float64 `json:"d"`
+	ScheduledDate int64      `json:"s,omitempty"` // unix seconds, 0 if unscheduled
+	ExpiresAt     int64      `json:"e"`
+}
+
+// QuoteDelivery prices a delivery before it is created. Addresses without
+// coordinates are geocoded, the zones of both ends are looked up, and the
+// price is signed into a token CreateDelivery honours until the quote
+// expires.
+func (s *DeliveryService) QuoteDelivery(ctx context.Context, req ports.QuoteDeliveryRequest) (*domain.Quote, error) {
+	if s.pricing == nil || s.zones == nil {
+		return nil, domain.ErrPricingUnavailable
+	}
+
+	pickup, err := s.quoteCoordinates(ctx, req.PickupAddress, req.PickupLocation)
+	if err != nil {
+		return nil, err
+	}
+	dropoff, err := s.quoteCoordinates(ctx, req.DeliveryAddress, req.DeliveryLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduled *time.Time
+	if req.ScheduledDate != nil && *req.ScheduledDate != "" {
+		parsed, err := time.Parse(time.RFC3339, *req.ScheduledDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid scheduled_date format: %v", domain.ErrInvalidDeliveryData, err)
+		}
+		scheduled = &parsed
+	}
+
+	pickupZones, err := s.zones.ZonesAt(ctx, *pickup)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to look up pickup zones: %v", domain.ErrPricingUnavailable, err)
+	}
+	dropoffZones, err := s.zones.ZonesAt(ctx, *dropoff)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to look up delivery zones: %v", domain.ErrPricingUnavailable, err)
+	}
+
+	price := s.pricing.Model.Price(domain.PriceRequest{
+		DistanceKm:    geo.HaversineKm(pickup.Latitude, pickup.Longitude, dropoff.Latitude, dropoff.Longitude),
+		PickupZones:   pickupZones,
+		DeliveryZones: dropoffZones,
+		ScheduledDate: scheduled,
+	})
+
+	expiresAt := time.Now().Add(s.pricing.QuoteTTL).Truncate(time.Second)
+	claims := quoteClaims{
+		TotalCents: price.TotalCents,
+		Currency:   price.Currency,
+		Pickup:     [2]float64{pickup.Latitude, pickup.Longitude},
+		Dropoff:    [2]float64{dropoff.Latitude, dropoff.Longitude},
+		ExpiresAt:  expiresAt.Unix(),
+	}
+	if scheduled != nil {
+		claims.ScheduledDate = scheduled.Unix()
+	}
+	token, err := s.signQuote(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithFields(ctx, "Delivery quoted",
+		zap.Int64("total_cents", price.TotalCents),
+		zap.String("pickup_zone", price.PickupZone),
+		zap.String("delivery_zone", price.DeliveryZone))
+
+	return &domain.Quote{Price: price, ExpiresAt: expiresAt.UTC(), Token: token}, nil
+}
+
+// quoteCoordinates resolves one end of a quote request to coordinates
+func (s *DeliveryService) quoteCoordinates(ctx context.Context, structured *ports.Address, location string) (*domain.Coordinates, error) {
+	address, err := requestAddress(structured, location)
+	if err != nil {
+		return nil, err
+	}
+	s.geocodeAddress(ctx, &address)
+	if address.Coordinates == nil {
+		return nil, domain.ErrQuoteUnlocated
+	}
+	return address.Coordinates, nil
+}
+
+// applyQuote stores a quoted price on a delivery being created, provided the
+// token is genuine, unexpired and was issued for the delivery's locations
+// and scheduled start
+func (s *DeliveryService) applyQuote(delivery *domain.Delivery, token string) error {
+	if s.pricing == nil {
+		return domain.ErrQuoteInvalid
+	}
+	claims, err := s.verifyQuote(token)
+	if err != nil {
+		return err
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return domain.ErrQuoteExpired
+	}
+
+	pickup, ok := delivery.PickupCoordinates()
+	if !ok || !nearCoordinates(*pickup, claims.Pickup) {
+		return domain.ErrQuoteMismatch
+	}
+	dropoff, ok := delivery.DeliveryCoordinates()
+	if !ok || !nearCoordinates(*dropoff, claims.Dropoff) {
+		return domain.ErrQuoteMismatch
+	}
+	var scheduled int64
+	if delivery.ScheduledDate != nil {
+		scheduled = delivery.ScheduledDate.Unix()
+	}
+	if scheduled != claims.ScheduledDate {
+		return domain.ErrQuoteMismatch
+	}
+
+	price := claims.TotalCents
+	delivery.QuotedPriceCents = &price
+	delivery.QuotedCurrency = claims.Currency
+	return nil
+}
+
+func nearCoordinates(c domain.Coordinates, quoted [2]float64) bool {
+	return math.Abs(c.Latitude-quoted[0]) <= quoteCoordinateTolerance &&
+		math.Abs(c.Longitude-quoted[1]) <= quoteCoordinateTolerance
+}
+
+// signQuote encodes claims as a token: the base64url JSON claims and their
+// HMAC-SHA256, joined by a dot
+func (s *DeliveryService) signQuote(claims quoteClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode quote: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.quoteMAC(encoded)), nil
+}
+
+// verifyQuote checks a token's signature and returns its claims
+func (s *DeliveryService) verifyQuote(token string) (*quoteClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, domain.ErrQuoteInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.quoteMAC(encoded)) {
+		return nil, domain.ErrQuoteInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, domain.ErrQuoteInvalid
+	}
+	var claims quoteClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, domain.ErrQuoteInvalid
+	}
+	return &claims, nil
+}
+
+func (s *DeliveryService) quoteMAC(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.pricing.Secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
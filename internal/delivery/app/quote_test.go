@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+// MockZoneLocator is a mock implementation of ZoneLocator for testing
+type MockZoneLocator struct {
+	zones map[domain.Coordinates][]string
+	err   error
+}
+
+func (m *MockZoneLocator) ZonesAt(ctx context.Context, point domain.Coordinates) ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.zones[point], nil
+}
+
+const (
+	quotePickup  = "(-74.006000,40.712800)"
+	quoteDropoff = "(-73.935200,40.730600)"
+)
+
+func newQuotingService(t *testing.T, zones *MockZoneLocator) (*DeliveryService, *memory.DeliveryRepository) {
+	repo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+	service.SetPricing(PricingConfig{
+		Model: domain.PricingModel{
+			Currency:     "USD",
+			BaseFeeCents: 500,
+			PerKmCents:   100,
+			ZonePairs:    []domain.ZonePairRate{{PickupZone: "downtown", DeliveryZone: domain.AnyZone, PerKmCents: 200}},
+		},
+		Secret: []byte("quote-secret"),
+	}, zones)
+	return service, repo
+}
+
+func TestDeliveryService_QuoteDelivery(t *testing.T) {
+	zones := &MockZoneLocator{zones: map[domain.Coordinates][]string{
+		{Latitude: 40.7128, Longitude: -74.006}: {"downtown"},
+	}}
+	service, _ := newQuotingService(t, zones)
+
+	quote, err := service.QuoteDelivery(context.Background(), ports.QuoteDeliveryRequest{
+		PickupLocation:   quotePickup,
+		DeliveryLocation: quoteDropoff,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if quote.PickupZone != "downtown" || quote.PerKmCents != 200 {
+		t.Errorf("expected the downtown rate, got %+v", quote.Price)
+	}
+	// About 6.3 km between the points
+	if quote.DistanceKm < 6 || quote.DistanceKm > 6.6 || quote.TotalCents != 500+quote.DistanceCents {
+		t.Errorf("unexpected distance or total: %+v", quote.Price)
+	}
+	if until := time.Until(quote.ExpiresAt); until < 14*time.Minute || until > DefaultQuoteTTL {
+		t.Errorf("expected the quote to expire in the default TTL, got %v", until)
+	}
+	if quote.Token == "" {
+		t.Error("expected a quote token")
+	}
+}
+
+func TestDeliveryService_QuoteDelivery_Errors(t *testing.T) {
+	badDate, latitude := "tomorrow", 40.7
+	tests := []struct {
+		name    string
+		zones   *MockZoneLocator
+		noPrice bool
+		req     ports.QuoteDeliveryRequest
+		wantErr error
+	}{
+		{name: "pricing not configured", noPrice: true,
+			req: ports.QuoteDeliveryRequest{PickupLocation: quotePickup, DeliveryLocation: quoteDropoff}, wantErr: domain.ErrPricingUnavailable},
+		{name: "zone lookup fails", zones: &MockZoneLocator{err: errors.New("tracking unavailable")},
+			req: ports.QuoteDeliveryRequest{PickupLocation: quotePickup, DeliveryLocation: quoteDropoff}, wantErr: domain.ErrPricingUnavailable},
+		{name: "bad scheduled date", zones: &MockZoneLocator{},
+			req: ports.QuoteDeliveryRequest{PickupLocation: quotePickup, DeliveryLocation: quoteDropoff, ScheduledDate: &badDate}, wantErr: domain.ErrInvalidDeliveryData},
+		{name: "half a coordinate", zones: &MockZoneLocator{},
+			req: ports.QuoteDeliveryRequest{PickupAddress: &ports.Address{Line1: "1 Main St", Latitude: &latitude}, DeliveryLocation: quoteDropoff}, wantErr: domain.ErrInvalidDeliveryData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newQuotingService(t, tt.zones)
+			if tt.noPrice {
+				service = NewDeliveryService(memory.NewDeliveryRepository(), &MockGeocodingService{}, nil, createTestLogger(t))
+			}
+			if _, err := service.QuoteDelivery(context.Background(), tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDeliveryService_QuoteDelivery_Unlocated(t *testing.T) {
+	// Without a geocoder, a free-text address has no coordinates to price by
+	service := NewDeliveryService(memory.NewDeliveryRepository(), nil, nil, createTestLogger(t))
+	service.SetPricing(PricingConfig{Secret: []byte("quote-secret")}, &MockZoneLocator{})
+
+	_, err := service.QuoteDelivery(context.Background(), ports.QuoteDeliveryRequest{
+		PickupLocation:   "123 Main St",
+		DeliveryLocation: quoteDropoff,
+	})
+	if !errors.Is(err, domain.ErrQuoteUnlocated) {
+		t.Errorf("expected ErrQuoteUnlocated, got %v", err)
+	}
+}
+
+func TestDeliveryService_CreateDelivery_QuoteToken(t *testing.T) {
+	scheduled := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second).Format(time.RFC3339)
+	service, _ := newQuotingService(t, &MockZoneLocator{})
+	quote, err := service.QuoteDelivery(context.Background(), ports.QuoteDeliveryRequest{
+		PickupLocation:   quotePickup,
+		DeliveryLocation: quoteDropoff,
+		ScheduledDate:    &scheduled,
+	})
+	if err != nil {
+		t.Fatalf("failed to quote: %v", err)
+	}
+
+	other, _ := newQuotingService(t, &MockZoneLocator{})
+	other.pricing.Secret = []byte("another-secret")
+	expired, err := service.signQuote(quoteClaims{
+		TotalCents: 100,
+		Currency:   "USD",
+		Pickup:     [2]float64{40.7128, -74.006},
+		Dropoff:    [2]float64{40.7306, -73.9352},
+		ExpiresAt:  time.Now().Add(-time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, signature, _ := strings.Cut(quote.Token, ".")
+	tampered := encoded[:len(encoded)-2] + "AA." + signature
+
+	tests := []struct {
+		name      string
+		service   *DeliveryService
+		token     string
+		dropoff   string
+		scheduled *string
+		wantErr   error
+	}{
+		{name: "quoted price stored", service: service, token: quote.Token, scheduled: &scheduled},
+		{name: "expired quote", service: service, token: expired, wantErr: domain.ErrQuoteExpired},
+		{name: "tampered quote", service: service, token: tampered, scheduled: &scheduled, wantErr: domain.ErrQuoteInvalid},
+		{name: "signed by another secret", service: other, token: quote.Token, scheduled: &scheduled, wantErr: domain.ErrQuoteInvalid},
+		{name: "malformed token", service: service, token: "not-a-quote", wantErr: domain.ErrQuoteInvalid},
+		{name: "different drop-off", service: service, token: quote.Token, dropoff: "(-73.985700,40.748400)", scheduled: &scheduled, wantErr: domain.ErrQuoteMismatch},
+		{name: "different scheduled start", service: service, token: quote.Token, scheduled: future(5 * time.Hour), wantErr: domain.ErrQuoteMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dropoff := quoteDropoff
+			if tt.dropoff != "" {
+				dropoff = tt.dropoff
+			}
+			delivery, err := tt.service.CreateDelivery(context.Background(), ports.CreateDeliveryRequest{
+				CustomerID:       1,
+				PickupLocation:   quotePickup,
+				DeliveryLocation: dropoff,
+				ScheduledDate:    tt.scheduled,
+				QuoteToken:       tt.token,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if delivery.QuotedPriceCents == nil || *delivery.QuotedPriceCents != quote.TotalCents || delivery.QuotedCurrency != "USD" {
+				t.Errorf("expected the quoted %d USD, got %v %q", quote.TotalCents, delivery.QuotedPriceCents, delivery.QuotedCurrency)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// replayBatchSize is how many outbox events a replay reads at a time
+const replayBatchSize = 500
+
+// auditActionReplay is the audited action of an event replay
+const auditActionReplay = "events.replay"
+
+// EventReplayService republishes past delivery events from the outbox, so
+// consumers can rebuild what they failed to process
+type EventReplayService struct {
+	repo      ports.OutboxRepository
+	publisher messaging.Publisher
+	audit     *audit.Writer // nil until SetAuditWriter
+	logger    *logger.Logger
+}
+
+// NewEventReplayService creates a new event replay service
+func NewEventReplayService(repo ports.OutboxRepository, publisher messaging.Publisher, logger *logger.Logger) *EventReplayService {
+	return &EventReplayService{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// SetAuditWriter records every replay, including refused ones, to the audit log
+func (s *EventReplayService) SetAuditWriter(w *audit.Writer) {
+	s.audit = w
+}
+
+// replayAudit is what the audit log keeps of a replay
+type replayAudit struct {
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	RoutingKey string     `json:"routing_key,omitempty"`
+	EventTypes []string   `json:"event_types,omitempty"`
+	Published  int        `json:"published"`
+	ResumeFrom *time.Time `json:"resume_from,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// ReplayEvents republishes the sent and dead outbox events created in the
+// request's range, oldest first. Each keeps its ID and timestamp and is
+// marked as replayed, so consumers that deduplicate by ID only process the
+// events they missed. Pending events are left to the dispatcher.
+func (s *EventReplayService) ReplayEvents(ctx context.Context, req ports.ReplayEventsRequest) (result *domain.ReplayResult, err error) {
+	result = &domain.ReplayResult{}
+	defer func() {
+		entry := replayAudit{
+			From:       req.From,
+			To:         req.To,
+			RoutingKey: req.RoutingKey,
+			EventTypes: req.EventTypes,
+			Published:  result.Published,
+			ResumeFrom: result.ResumeFrom,
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		s.audit.Record(ctx, authctx.Organization(ctx), auditActionReplay, audit.EntityEventReplay, req.EntityType, nil, entry)
+	}()
+
+	if req.AuthContext.Role != "admin" && req.AuthContext.Role != "super_admin" {
+		return result, domain.ErrUnauthorized
+	}
+	if err := validateReplay(req); err != nil {
+		return result, err
+	}
+
+	s.logger.InfoWithFields(ctx, "Replaying delivery events",
+		zap.Time("from", req.From),
+		zap.Time("to", req.To),
+		zap.String("routing_key", req.RoutingKey),
+		zap.Strings("event_types", req.EventTypes))
+
+	// Where a replay that fails part way should pick up
+	resumeFrom := req.From
+	filter := ports.OutboxRangeFilter{
+		From:        req.From,
+		To:          req.To,
+		RoutingKeys: req.EventTypes,
+		Limit:       replayBatchSize,
+	}
+	for {
+		events, err := s.repo.ListPublished(ctx, filter)
+		if err != nil {
+			result.ResumeFrom = &resumeFrom
+			return result, fmt.Errorf("%w: failed to read outbox events: %v", domain.ErrReplayInterrupted, err)
+		}
+
+		for _, event := range events {
+			filter.AfterID = event.ID
+			published, err := s.republish(ctx, event, req.RoutingKey)
+			if err != nil {
+				resume := event.CreatedAt
+				result.ResumeFrom = &resume
+				return result, fmt.Errorf("%w: failed to publish outbox event %d: %v", domain.ErrReplayInterrupted, event.ID, err)
+			}
+			if published {
+				result.Published++
+			}
+			resumeFrom = event.CreatedAt
+		}
+
+		if len(events) < filter.Limit {
+			break
+		}
+	}
+
+	s.logger.InfoWithFields(ctx, "Delivery events replayed", zap.Int("published", result.Published))
+	return result, nil
+}
+
+// republish publishes an outbox event again, marked as replayed, to routingKey
+// or its own. An event whose payload no longer decodes is skipped.
+func (s *EventReplayService) republish(ctx context.Context, event *domain.OutboxEvent, routingKey string) (bool, error) {
+	var msg messaging.Event
+	if err := json.Unmarshal(event.Payload, &msg); err != nil {
+		s.logger.WarnWithFields(ctx, "Skipping undecodable outbox event",
+			zap.Int64("outbox_id", event.ID), zap.Error(err))
+		return false, nil
+	}
+	msg.Replayed = true
+
+	if routingKey == "" {
+		routingKey = event.RoutingKey
+	}
+	if err := s.publisher.Publish(ctx, event.Exchange, routingKey, msg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// validateReplay checks a replay's entity type, range and routing key
+func validateReplay(req ports.ReplayEventsRequest) error {
+	switch {
+	case req.EntityType != domain.ReplayEntityDelivery:
+		return fmt.Errorf("%w: unsupported entity type %q", domain.ErrInvalidReplay, req.EntityType)
+	case req.From.IsZero() || req.To.IsZero():
+		return fmt.Errorf("%w: from and to are required", domain.ErrInvalidReplay)
+	case !req.To.After(req.From):
+		return fmt.Errorf("%w: to must be after from", domain.ErrInvalidReplay)
+	case req.To.Sub(req.From) > domain.MaxReplayWindow:
+		return fmt.Errorf("%w: range may not exceed %d days", domain.ErrInvalidReplay, int(domain.MaxReplayWindow.Hours()/24))
+	case strings.ContainsAny(req.RoutingKey, "*# "):
+		return fmt.Errorf("%w: routing key must not contain wildcards", domain.ErrInvalidReplay)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// addReplayableEvent stores a published outbox event created at createdAt
+func addReplayableEvent(t *testing.T, repo *MockOutboxRepository, routingKey, status string, createdAt time.Time) messaging.Event {
+	event := messaging.NewEventWithTrace(routingKey, "delivery-service", "test",
+		map[string]interface{}{"delivery_id": 1}, nil)
+	event.Timestamp = createdAt.Unix()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	outboxEvent, err := domain.NewOutboxEvent(1, "delivery-events", routingKey, payload)
+	if err != nil {
+		t.Fatalf("failed to create outbox event: %v", err)
+	}
+	outboxEvent.Status = status
+	outboxEvent.CreatedAt = createdAt
+	repo.Add(outboxEvent)
+	return event
+}
+
+func TestEventReplayService_ReplayEvents(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(6 * time.Hour)
+
+	repo := NewMockOutboxRepository()
+	before := addReplayableEvent(t, repo, "delivery.status_changed", domain.OutboxStatusSent, from.Add(-time.Minute))
+	changed := addReplayableEvent(t, repo, "delivery.status_changed", domain.OutboxStatusSent, from.Add(time.Hour))
+	addReplayableEvent(t, repo, "delivery.status_changed", domain.OutboxStatusPending, from.Add(2*time.Hour))
+	created := addReplayableEvent(t, repo, "delivery.created", domain.OutboxStatusDead, from.Add(3*time.Hour))
+	addReplayableEvent(t, repo, "delivery.status_changed", domain.OutboxStatusSent, to)
+
+	admin := ports.AuthContext{Role: "admin"}
+
+	t.Run("range", func(t *testing.T) {
+		publisher := testsupport.NewPublisher()
+		service := NewEventReplayService(repo, publisher, createTestLogger(t))
+
+		result, err := service.ReplayEvents(context.Background(), ports.ReplayEventsRequest{
+			EntityType: domain.ReplayEntityDelivery, From: from, To: to, AuthContext: admin,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		published := publisher.Published()
+		if result.Published != 2 || len(published) != 2 {
+			t.Fatalf("expected the sent and dead events in range, got %d: %+v", result.Published, published)
+		}
+		for i, want := range []messaging.Event{changed, created} {
+			got := published[i]
+			if got.Event.ID != want.ID || got.Event.Timestamp != want.Timestamp || !got.Event.Replayed {
+				t.Errorf("event %d: expected %s at %d marked replayed, got %+v", i, want.ID, want.Timestamp, got.Event)
+			}
+			if got.RoutingKey != want.Type || got.Exchange != "delivery-events" {
+				t.Errorf("event %d: expected its original routing, got %s %s", i, got.Exchange, got.RoutingKey)
+			}
+		}
+		if published[0].Event.ID == before.ID {
+			t.Error("expected events before the range to be left out")
+		}
+	})
+
+	t.Run("event types and target routing key", func(t *testing.T) {
+		publisher := testsupport.NewPublisher()
+		service := NewEventReplayService(repo, publisher, createTestLogger(t))
+
+		_, err := service.ReplayEvents(context.Background(), ports.ReplayEventsRequest{
+			EntityType: domain.ReplayEntityDelivery, From: from, To: to,
+			EventTypes: []string{"delivery.status_changed"}, RoutingKey: "replay.notification",
+			AuthContext: admin,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		published := publisher.Published()
+		if len(published) != 1 || published[0].Event.ID != changed.ID || published[0].RoutingKey != "replay.notification" {
+			t.Errorf("expected the status change sent to the target key, got %+v", published)
+		}
+	})
+
+	t.Run("publish failure", func(t *testing.T) {
+		publisher := testsupport.NewPublisher()
+		publisher.SetError(errors.New("broker unavailable"))
+		service := NewEventReplayService(repo, publisher, createTestLogger(t))
+
+		result, err := service.ReplayEvents(context.Background(), ports.ReplayEventsRequest{
+			EntityType: domain.ReplayEntityDelivery, From: from, To: to, AuthContext: admin,
+		})
+		if !errors.Is(err, domain.ErrReplayInterrupted) {
+			t.Fatalf("expected ErrReplayInterrupted, got %v", err)
+		}
+		if result.Published != 0 || result.ResumeFrom == nil || !result.ResumeFrom.Equal(from.Add(time.Hour)) {
+			t.Errorf("expected to resume from the first event, got %+v", result)
+		}
+	})
+}
+
+func TestEventReplayService_Rejects(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	admin := ports.AuthContext{Role: "admin"}
+
+	tests := []struct {
+		name    string
+		req     ports.ReplayEventsRequest
+		wantErr error
+	}{
+		{name: "not an admin", req: ports.ReplayEventsRequest{EntityType: "delivery", From: from, To: from.Add(time.Hour),
+			AuthContext: ports.AuthContext{Role: "customer"}}, wantErr: domain.ErrUnauthorized},
+		{name: "unknown entity type", req: ports.ReplayEventsRequest{EntityType: "courier", From: from, To: from.Add(time.Hour),
+			AuthContext: admin}, wantErr: domain.ErrInvalidReplay},
+		{name: "missing range", req: ports.ReplayEventsRequest{EntityType: "delivery", From: from, AuthContext: admin}, wantErr: domain.ErrInvalidReplay},
+		{name: "reversed range", req: ports.ReplayEventsRequest{EntityType: "delivery", From: from, To: from.Add(-time.Hour),
+			AuthContext: admin}, wantErr: domain.ErrInvalidReplay},
+		{name: "range too long", req: ports.ReplayEventsRequest{EntityType: "delivery", From: from, To: from.Add(domain.MaxReplayWindow + time.Hour),
+			AuthContext: admin}, wantErr: domain.ErrInvalidReplay},
+		{name: "wildcard routing key", req: ports.ReplayEventsRequest{EntityType: "delivery", From: from, To: from.Add(time.Hour),
+			RoutingKey: "delivery.*", AuthContext: admin}, wantErr: domain.ErrInvalidReplay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := testsupport.NewPublisher()
+			service := NewEventReplayService(NewMockOutboxRepository(), publisher, createTestLogger(t))
+
+			if _, err := service.ReplayEvents(context.Background(), tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if len(publisher.Events()) != 0 {
+				t.Error("expected nothing to be published")
+			}
+		})
+	}
+}
+
+func TestEventReplayService_RecordsAudit(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewMockOutboxRepository()
+	addReplayableEvent(t, repo, "delivery.created", domain.OutboxStatusSent, from.Add(time.Minute))
+
+	store := &memoryAuditStore{}
+	writer := audit.NewWriter(store, 10, createTestLogger(t))
+	service := NewEventReplayService(repo, testsupport.NewPublisher(), createTestLogger(t))
+	service.SetAuditWriter(writer)
+
+	adminCtx := authctx.WithClaims(context.Background(), &authDomain.Claims{UserID: 1, Role: "admin"})
+	service.ReplayEvents(adminCtx, ports.ReplayEventsRequest{
+		EntityType: "delivery", From: from, To: from.Add(time.Hour), AuthContext: ports.AuthContext{Role: "admin"},
+	})
+	customerCtx := authctx.WithClaims(context.Background(), &authDomain.Claims{UserID: 2, Role: "customer"})
+	service.ReplayEvents(customerCtx, ports.ReplayEventsRequest{
+		EntityType: "delivery", From: from, To: from.Add(time.Hour), AuthContext: ports.AuthContext{Role: "customer"},
+	})
+	writer.Close()
+
+	if len(store.entries) != 2 {
+		t.Fatalf("expected every replay to be audited, got %d entries", len(store.entries))
+	}
+	tests := []struct {
+		actorUserID int
+		published   int
+		err         string
+	}{
+		{actorUserID: 1, published: 1},
+		{actorUserID: 2, err: domain.ErrUnauthorized.Error()},
+	}
+	for i, tt := range tests {
+		entry := store.entries[i]
+		if entry.Action != auditActionReplay || entry.EntityType != audit.EntityEventReplay || entry.EntityID != "delivery" || entry.ActorUserID != tt.actorUserID {
+			t.Errorf("entry %d: unexpected %+v", i, entry)
+		}
+		var after replayAudit
+		if err := json.Unmarshal(entry.After, &after); err != nil {
+			t.Fatalf("entry %d: failed to decode snapshot: %v", i, err)
+		}
+		if after.Published != tt.published || after.Error != tt.err || !after.From.Equal(from) {
+			t.Errorf("entry %d: expected %d published and error %q, got %+v", i, tt.published, tt.err, after)
+		}
+	}
+}
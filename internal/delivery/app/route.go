@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// SetCourierLocator lets route planning start from a courier's last known
+// location. Without it callers have to pass a start position.
+func (s *DeliveryService) SetCourierLocator(locator ports.CourierLocator) {
+	s.locator = locator
+}
+
+// OptimizeRoute plans the stop order for a courier's assigned and in-transit
+// deliveries; admins may plan any courier's route, couriers only their own
+func (s *DeliveryService) OptimizeRoute(ctx context.Context, req ports.OptimizeRouteRequest) (*domain.RoutePlan, error) {
+	if req.Role != "admin" && req.Role != "super_admin" && (req.Role != "courier" || req.UserCourierID == nil || *req.UserCourierID != req.CourierID) {
+		return nil, domain.ErrUnauthorized
+	}
+
+	ctx = logger.WithContext(ctx, zap.Int("courier_id", req.CourierID))
+
+	deliveries, err := s.activeCourierDeliveries(ctx, req.CourierID, req.DeliveryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := s.routeStart(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stops []domain.RouteStop
+	var unrouted []int
+	for _, d := range deliveries {
+		coords, ok := d.NextStop()
+		if !ok {
+			unrouted = append(unrouted, d.ID)
+			continue
+		}
+		stops = append(stops, domain.RouteStop{DeliveryID: d.ID, Latitude: coords.Latitude, Longitude: coords.Longitude})
+	}
+
+	plan := PlanRoute(*start, stops, start.AverageSpeedKmh, time.Now())
+	plan.CourierID = req.CourierID
+	plan.Unrouted = unrouted
+
+	s.logger.InfoWithFields(ctx, "Route planned",
+		zap.Int("stops", len(plan.Stops)),
+		zap.Int("unrouted", len(unrouted)),
+		zap.Float64("total_distance_km", plan.TotalDistance))
+
+	return plan, nil
+}
+
+// activeCourierDeliveries returns the courier's assigned and in-transit
+// deliveries, limited to ids when given
+func (s *DeliveryService) activeCourierDeliveries(ctx context.Context, courierID int, ids []int) ([]*domain.Delivery, error) {
+	wanted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	deliveries, _, err := s.repo.List(ctx, ports.DeliveryFilter{
+		Statuses:  []string{domain.StatusAssigned, domain.StatusInTransit},
+		CourierID: courierID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var active []*domain.Delivery
+	for _, d := range deliveries {
+		if len(wanted) > 0 && !wanted[d.ID] {
+			continue
+		}
+		active = append(active, d)
+	}
+	return active, nil
+}
+
+// routeStart returns the requested start position, falling back to the
+// courier's last known location
+func (s *DeliveryService) routeStart(ctx context.Context, req ports.OptimizeRouteRequest) (*domain.CourierPosition, error) {
+	if req.Start != nil {
+		return req.Start, nil
+	}
+	if s.locator == nil {
+		return nil, domain.ErrCourierLocationUnknown
+	}
+	return s.locator.Locate(ctx, req.CourierID)
+}
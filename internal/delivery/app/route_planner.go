@@ -0,0 +1,114 @@
+package app
+
+import (
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+)
+
+// defaultCourierSpeedKmh is assumed when a courier hasn't reported speeds,
+// a typical urban delivery speed
+const defaultCourierSpeedKmh = 25.0
+
+// PlanRoute orders stops for a courier starting at start, departing at
+// departAt and moving at speedKmh. The order is built nearest-neighbor first
+// and then improved with 2-opt; the route is open, ending at the last stop.
+func PlanRoute(start domain.CourierPosition, stops []domain.RouteStop, speedKmh float64, departAt time.Time) *domain.RoutePlan {
+	if speedKmh <= 0 {
+		speedKmh = defaultCourierSpeedKmh
+	}
+
+	// points[0] is the start, points[i+1] is stops[i]
+	points := make([][2]float64, 0, len(stops)+1)
+	points = append(points, [2]float64{start.Latitude, start.Longitude})
+	for _, stop := range stops {
+		points = append(points, [2]float64{stop.Latitude, stop.Longitude})
+	}
+	dist := func(i, j int) float64 {
+		return geo.HaversineKm(points[i][0], points[i][1], points[j][0], points[j][1])
+	}
+
+	order := twoOpt(nearestNeighbor(len(points), dist), dist)
+
+	plan := &domain.RoutePlan{
+		Stops:           make([]domain.PlannedStop, 0, len(order)),
+		AverageSpeedKmh: speedKmh,
+	}
+	prev := 0
+	for i, p := range order {
+		leg := dist(prev, p)
+		plan.TotalDistance += leg
+		stop := stops[p-1]
+		plan.Stops = append(plan.Stops, domain.PlannedStop{
+			DeliveryID:           stop.DeliveryID,
+			Sequence:             i + 1,
+			Latitude:             stop.Latitude,
+			Longitude:            stop.Longitude,
+			DistanceFromPrevious: leg,
+			EstimatedArrival:     departAt.Add(travelTime(plan.TotalDistance, speedKmh)),
+		})
+		prev = p
+	}
+	plan.EstimatedDurationSeconds = int64(travelTime(plan.TotalDistance, speedKmh).Seconds())
+
+	return plan
+}
+
+// travelTime returns how long covering distanceKm takes at speedKmh
+func travelTime(distanceKm, speedKmh float64) time.Duration {
+	return time.Duration(distanceKm / speedKmh * float64(time.Hour))
+}
+
+// nearestNeighbor returns the points 1..n-1 in the order reached by always
+// moving to the closest unvisited point, starting from point 0
+func nearestNeighbor(n int, dist func(i, j int) float64) []int {
+	visited := make([]bool, n)
+	order := make([]int, 0, n-1)
+	current := 0
+	for len(order) < n-1 {
+		next := -1
+		for j := 1; j < n; j++ {
+			if !visited[j] && (next == -1 || dist(current, j) < dist(current, next)) {
+				next = j
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = next
+	}
+	return order
+}
+
+// twoOpt shortens an open path from point 0 through order by reversing
+// segments while that reduces its length
+func twoOpt(order []int, dist func(i, j int) float64) []int {
+	const epsilon = 1e-9
+	n := len(order)
+
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n-1; i++ {
+			before := 0
+			if i > 0 {
+				before = order[i-1]
+			}
+			for k := i + 1; k < n; k++ {
+				// Reversing order[i..k] swaps edges (before,i) and (k,after) for
+				// (before,k) and (i,after); an open path has no edge after the end
+				delta := dist(before, order[k]) - dist(before, order[i])
+				if k < n-1 {
+					after := order[k+1]
+					delta += dist(order[i], after) - dist(order[k], after)
+				}
+				if delta < -epsilon {
+					for l, r := i, k; l < r; l, r = l+1, r-1 {
+						order[l], order[r] = order[r], order[l]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+	return order
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+)
+
+// kmPerDegree is the length of one degree along the equator
+const kmPerDegree = geo.EarthRadiusKm * math.Pi / 180
+
+func stopIDs(plan *domain.RoutePlan) []int {
+	ids := make([]int, len(plan.Stops))
+	for i, stop := range plan.Stops {
+		ids[i] = stop.DeliveryID
+	}
+	return ids
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPlanRoute(t *testing.T) {
+	origin := domain.CourierPosition{}
+
+	// Stops along the equator, given in degrees of longitude and latitude
+	stop := func(id int, lng, lat float64) domain.RouteStop {
+		return domain.RouteStop{DeliveryID: id, Latitude: lat, Longitude: lng}
+	}
+
+	tests := []struct {
+		name          string
+		stops         []domain.RouteStop
+		expectedOrder []int
+		expectedKm    float64
+	}{
+		{
+			name:          "no stops",
+			stops:         nil,
+			expectedOrder: []int{},
+			expectedKm:    0,
+		},
+		{
+			name:          "single stop",
+			stops:         []domain.RouteStop{stop(7, 0.01, 0)},
+			expectedOrder: []int{7},
+			expectedKm:    0.01 * kmPerDegree,
+		},
+		{
+			name:          "collinear stops are visited outward",
+			stops:         []domain.RouteStop{stop(1, 0.03, 0), stop(2, 0.01, 0), stop(3, 0.02, 0)},
+			expectedOrder: []int{2, 3, 1},
+			expectedKm:    0.03 * kmPerDegree,
+		},
+		{
+			// Nearest-neighbor alone visits 3, 2, 1 and then doubles back to 4
+			name: "2-opt removes the backtrack left by nearest-neighbor",
+			stops: []domain.RouteStop{
+				stop(1, -0.02, -0.02),
+				stop(2, -0.02, -0.01),
+				stop(3, -0.02, 0),
+				stop(4, -0.02, 0.02),
+			},
+			expectedOrder: []int{1, 2, 3, 4},
+			expectedKm:    (2*math.Sqrt2 + 4) * 0.01 * kmPerDegree,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			departAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			plan := PlanRoute(origin, tt.stops, 30, departAt)
+
+			if got := stopIDs(plan); !equalIDs(got, tt.expectedOrder) {
+				t.Errorf("expected order %v, got %v", tt.expectedOrder, got)
+			}
+			if math.Abs(plan.TotalDistance-tt.expectedKm) > 0.01 {
+				t.Errorf("expected total distance %.3f km, got %.3f km", tt.expectedKm, plan.TotalDistance)
+			}
+
+			var legs float64
+			for i, s := range plan.Stops {
+				legs += s.DistanceFromPrevious
+				if s.Sequence != i+1 {
+					t.Errorf("expected stop %d to have sequence %d, got %d", s.DeliveryID, i+1, s.Sequence)
+				}
+				if !s.EstimatedArrival.After(departAt) {
+					t.Errorf("expected stop %d to arrive after departure, got %v", s.DeliveryID, s.EstimatedArrival)
+				}
+			}
+			if math.Abs(legs-plan.TotalDistance) > 1e-9 {
+				t.Errorf("expected legs to sum to total distance %.3f, got %.3f", plan.TotalDistance, legs)
+			}
+
+			expectedSeconds := int64(plan.TotalDistance / 30 * 3600)
+			if plan.EstimatedDurationSeconds != expectedSeconds {
+				t.Errorf("expected duration %ds, got %ds", expectedSeconds, plan.EstimatedDurationSeconds)
+			}
+		})
+	}
+}
+
+func TestPlanRoute_DefaultSpeed(t *testing.T) {
+	plan := PlanRoute(domain.CourierPosition{}, []domain.RouteStop{{DeliveryID: 1, Longitude: 0.1}}, 0, time.Now())
+
+	if plan.AverageSpeedKmh != defaultCourierSpeedKmh {
+		t.Errorf("expected default speed %.0f km/h, got %.0f", defaultCourierSpeedKmh, plan.AverageSpeedKmh)
+	}
+	expectedSeconds := int64(plan.TotalDistance / defaultCourierSpeedKmh * 3600)
+	if plan.EstimatedDurationSeconds != expectedSeconds {
+		t.Errorf("expected duration %ds, got %ds", expectedSeconds, plan.EstimatedDurationSeconds)
+	}
+}
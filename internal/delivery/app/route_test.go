@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+// MockCourierLocator is a mock implementation of CourierLocator for testing
+type MockCourierLocator struct {
+	positions map[int]*domain.CourierPosition
+}
+
+func (m *MockCourierLocator) Locate(ctx context.Context, courierID int) (*domain.CourierPosition, error) {
+	position, ok := m.positions[courierID]
+	if !ok {
+		return nil, domain.ErrCourierLocationUnknown
+	}
+	return position, nil
+}
+
+func TestDeliveryService_OptimizeRoute(t *testing.T) {
+	courierID := 2
+	otherCourierID := 3
+
+	repo := memory.NewDeliveryRepository()
+	repo.AddDelivery(&domain.Delivery{ID: 1, CourierID: &courierID, Status: domain.StatusAssigned,
+		PickupLocation: "(0.020000,0.000000)", DeliveryLocation: "(5.000000,5.000000)"})
+	repo.AddDelivery(&domain.Delivery{ID: 2, CourierID: &courierID, Status: domain.StatusInTransit,
+		PickupLocation: "(5.000000,5.000000)", DeliveryLocation: "(0.010000,0.000000)"})
+	repo.AddDelivery(&domain.Delivery{ID: 3, CourierID: &courierID, Status: domain.StatusInTransit,
+		DeliveryLocation: "456 Oak Ave"})
+	repo.AddDelivery(&domain.Delivery{ID: 4, CourierID: &courierID, Status: domain.StatusDelivered,
+		DeliveryLocation: "(0.005000,0.000000)"})
+	repo.AddDelivery(&domain.Delivery{ID: 5, CourierID: &otherCourierID, Status: domain.StatusAssigned,
+		PickupLocation: "(0.005000,0.000000)"})
+
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+	service.SetCourierLocator(&MockCourierLocator{positions: map[int]*domain.CourierPosition{
+		courierID: {AverageSpeedKmh: 20},
+	}})
+
+	t.Run("plans active deliveries from the courier's location", func(t *testing.T) {
+		plan, err := service.OptimizeRoute(context.Background(), ports.OptimizeRouteRequest{
+			CourierID:   courierID,
+			AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Delivery 2 is dropped off before delivery 1 is picked up
+		if got := stopIDs(plan); !equalIDs(got, []int{2, 1}) {
+			t.Errorf("expected stops [2 1], got %v", got)
+		}
+		if !equalIDs(plan.Unrouted, []int{3}) {
+			t.Errorf("expected delivery 3 to be unrouted, got %v", plan.Unrouted)
+		}
+		if plan.CourierID != courierID || plan.AverageSpeedKmh != 20 {
+			t.Errorf("expected courier %d at 20 km/h, got %+v", courierID, plan)
+		}
+	})
+
+	t.Run("limits the route to the requested deliveries", func(t *testing.T) {
+		plan, err := service.OptimizeRoute(context.Background(), ports.OptimizeRouteRequest{
+			CourierID:   courierID,
+			DeliveryIDs: []int{1, 5},
+			AuthContext: ports.AuthContext{Role: "admin"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := stopIDs(plan); !equalIDs(got, []int{1}) {
+			t.Errorf("expected stops [1], got %v", got)
+		}
+	})
+
+	t.Run("uses an explicit start position", func(t *testing.T) {
+		plan, err := service.OptimizeRoute(context.Background(), ports.OptimizeRouteRequest{
+			CourierID:   courierID,
+			Start:       &domain.CourierPosition{Longitude: 0.03},
+			AuthContext: ports.AuthContext{Role: "admin"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := stopIDs(plan); !equalIDs(got, []int{1, 2}) {
+			t.Errorf("expected stops [1 2], got %v", got)
+		}
+		if plan.AverageSpeedKmh != defaultCourierSpeedKmh {
+			t.Errorf("expected default speed, got %.0f km/h", plan.AverageSpeedKmh)
+		}
+	})
+
+	t.Run("unknown courier location", func(t *testing.T) {
+		_, err := service.OptimizeRoute(context.Background(), ports.OptimizeRouteRequest{
+			CourierID:   otherCourierID,
+			AuthContext: ports.AuthContext{Role: "admin"},
+		})
+		if !errors.Is(err, domain.ErrCourierLocationUnknown) {
+			t.Errorf("expected ErrCourierLocationUnknown, got %v", err)
+		}
+	})
+
+	t.Run("couriers cannot plan other couriers' routes", func(t *testing.T) {
+		_, err := service.OptimizeRoute(context.Background(), ports.OptimizeRouteRequest{
+			CourierID:   otherCourierID,
+			AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+		})
+		if !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("expected ErrUnauthorized, got %v", err)
+		}
+	})
+
+	t.Run("customers cannot plan routes", func(t *testing.T) {
+		customerID := 1
+		_, err := service.OptimizeRoute(context.Background(), ports.OptimizeRouteRequest{
+			CourierID:   courierID,
+			AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+		})
+		if !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("expected ErrUnauthorized, got %v", err)
+		}
+	})
+}
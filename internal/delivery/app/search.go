@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"math"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+// searchResultLimit caps the number of deliveries a search returns
+const searchResultLimit = 100
+
+// SearchDeliveries finds deliveries by tracking number, pickup location and
+// creation time. Customers only find their own deliveries and couriers the
+// ones they may view: assigned to them or still pending.
+func (s *DeliveryService) SearchDeliveries(ctx context.Context, req ports.SearchDeliveriesRequest) ([]*domain.Delivery, error) {
+	criteria := ports.DeliverySearch{
+		PickupContains: req.PickupContains,
+		From:           req.From,
+		To:             req.To,
+		Limit:          searchResultLimit,
+	}
+	if req.TrackingNumber != "" {
+		trackingNumber, err := domain.NormalizeTrackingNumber(req.TrackingNumber)
+		if err != nil {
+			return nil, err
+		}
+		criteria.TrackingNumber = trackingNumber
+	}
+
+	switch req.Role {
+	case "admin", "super_admin":
+	case "customer":
+		if req.UserCustomerID == nil {
+			return nil, domain.ErrUnauthorized
+		}
+		criteria.CustomerID = *req.UserCustomerID
+	case "courier":
+		if req.UserCourierID == nil {
+			return nil, domain.ErrUnauthorized
+		}
+		criteria.ViewableByCourier = req.UserCourierID
+	default:
+		return nil, domain.ErrUnauthorized
+	}
+
+	return s.repo.Search(ctx, criteria)
+}
+
+// TrackByNumber returns what anyone holding a tracking number may see about the
+// delivery. The drop-off is only given as a rounded area.
+func (s *DeliveryService) TrackByNumber(ctx context.Context, trackingNumber string) (*domain.TrackingView, error) {
+	trackingNumber, err := domain.NormalizeTrackingNumber(trackingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.GetByTrackingNumber(ctx, trackingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	view := delivery.TrackingView()
+	if coords, ok := delivery.DeliveryCoordinates(); ok {
+		view.Area = &domain.CoarseLocation{Latitude: coarsen(coords.Latitude), Longitude: coarsen(coords.Longitude)}
+	}
+	return view, nil
+}
+
+// coarsen rounds a coordinate to one decimal, about ten kilometers
+func coarsen(degrees float64) float64 {
+	return math.Round(degrees*10) / 10
+}
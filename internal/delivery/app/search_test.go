@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+func TestDeliveryService_SearchDeliveries(t *testing.T) {
+	customerID := 1
+	otherCustomerID := 2
+	courierID := 5
+	otherCourierID := 6
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := memory.NewDeliveryRepository()
+	add := func(id, customer int, courier *int, status, pickup string, createdAt time.Time) {
+		repo.AddDelivery(&domain.Delivery{
+			ID:             id,
+			TrackingNumber: domain.TrackingNumberFor(id),
+			CustomerID:     customer,
+			CourierID:      courier,
+			Status:         status,
+			PickupLocation: pickup,
+			CreatedAt:      createdAt,
+		})
+	}
+	add(1, customerID, &courierID, domain.StatusAssigned, "12 Harbour Road", base)
+	add(2, customerID, nil, domain.StatusPending, "3 Market Square", base.Add(24*time.Hour))
+	add(3, otherCustomerID, &otherCourierID, domain.StatusInTransit, "99 harbour road", base.Add(48*time.Hour))
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+
+	admin := ports.AuthContext{Role: "admin"}
+	from := base.Add(time.Hour)
+	to := base.Add(30 * time.Hour)
+
+	tests := []struct {
+		name        string
+		req         ports.SearchDeliveriesRequest
+		expectedIDs []int
+		expectedErr error
+	}{
+		{
+			name:        "tracking number is normalized",
+			req:         ports.SearchDeliveriesRequest{TrackingNumber: "dt" + domain.TrackingNumberFor(3)[3:], AuthContext: admin},
+			expectedIDs: []int{3},
+		},
+		{
+			name:        "pickup match ignores case",
+			req:         ports.SearchDeliveriesRequest{PickupContains: "HARBOUR", AuthContext: admin},
+			expectedIDs: []int{1, 3},
+		},
+		{
+			name:        "creation time range",
+			req:         ports.SearchDeliveriesRequest{From: &from, To: &to, AuthContext: admin},
+			expectedIDs: []int{2},
+		},
+		{
+			name: "customers only find their own deliveries",
+			req: ports.SearchDeliveriesRequest{PickupContains: "harbour",
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID}},
+			expectedIDs: []int{1},
+		},
+		{
+			name:        "couriers find assigned and pending deliveries",
+			req:         ports.SearchDeliveriesRequest{AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID}},
+			expectedIDs: []int{1, 2},
+		},
+		{
+			name:        "malformed tracking number",
+			req:         ports.SearchDeliveriesRequest{TrackingNumber: "DT-12345", AuthContext: admin},
+			expectedErr: domain.ErrInvalidTrackingNumber,
+		},
+		{
+			name:        "customer without customer ID",
+			req:         ports.SearchDeliveriesRequest{AuthContext: ports.AuthContext{Role: "customer"}},
+			expectedErr: domain.ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries, err := service.SearchDeliveries(context.Background(), tt.req)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			found := make(map[int]bool)
+			for _, d := range deliveries {
+				found[d.ID] = true
+			}
+			if len(found) != len(tt.expectedIDs) {
+				t.Errorf("expected deliveries %v, got %d results", tt.expectedIDs, len(deliveries))
+			}
+			for _, id := range tt.expectedIDs {
+				if !found[id] {
+					t.Errorf("expected delivery %d in results", id)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliveryService_TrackByNumber(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+
+	delivery, err := service.CreateDelivery(context.Background(), ports.CreateDeliveryRequest{
+		CustomerID:       1,
+		PickupLocation:   "(-74.006000,40.712800)",
+		DeliveryLocation: "(-73.985700,40.748400)",
+		Notes:            "Ring twice",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating delivery: %v", err)
+	}
+	if delivery.TrackingNumber != domain.TrackingNumberFor(delivery.ID) {
+		t.Fatalf("expected tracking number %s, got %q", domain.TrackingNumberFor(delivery.ID), delivery.TrackingNumber)
+	}
+
+	view, err := service.TrackByNumber(context.Background(), delivery.TrackingNumber)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if view.TrackingNumber != delivery.TrackingNumber || view.Status != domain.StatusPending {
+		t.Errorf("expected pending delivery %s, got %+v", delivery.TrackingNumber, view)
+	}
+	if view.Area == nil || view.Area.Latitude != 40.7 || view.Area.Longitude != -74 {
+		t.Errorf("expected drop-off area rounded to (40.7, -74), got %+v", view.Area)
+	}
+
+	if _, err := service.TrackByNumber(context.Background(), domain.TrackingNumberFor(delivery.ID+1)); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("expected ErrDeliveryNotFound for an unknown number, got %v", err)
+	}
+	if _, err := service.TrackByNumber(context.Background(), "not-a-number"); !errors.Is(err, domain.ErrInvalidTrackingNumber) {
+		t.Errorf("expected ErrInvalidTrackingNumber, got %v", err)
+	}
+}
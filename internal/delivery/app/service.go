@@ -2,148 +2,241 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"regexp"
+	"strings"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
-	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
-	"github.com/Keneke-Einar/delivertrack/proto/delivery"
 	"go.uber.org/zap"
 )
 
 // DeliveryService implements the delivery use cases
 type DeliveryService struct {
-	repo           ports.DeliveryRepository
-	publisher      messaging.Publisher
-	deliveryClient delivery.DeliveryServiceClient
-	deliveryCB     *resilience.CircuitBreaker
-	geocodingSvc   geocoding.GeocodingService
-	logger         *logger.Logger
+	repo         ports.DeliveryRepository
+	geocodingSvc geocoding.GeocodingService
+	blobStore    ports.BlobStore
+	couriers     ports.CourierRepository
+	locator      ports.CourierLocator
+	tracker      ports.DeliveryTracker
+	trackTimeout time.Duration
+	pricing      *PricingConfig // nil until SetPricing
+	zones        ports.ZoneLocator
+	slots        ports.SlotCapacityRepository // nil until SetSlots
+	slotLocation *time.Location
+	bulk         BulkCreateConfig
+	audit        *audit.Writer // nil until SetAuditWriter
+	logger       *logger.Logger
 }
 
 // NewDeliveryService creates a new delivery service
-func NewDeliveryService(repo ports.DeliveryRepository, publisher messaging.Publisher, deliveryClient delivery.DeliveryServiceClient, geocodingSvc geocoding.GeocodingService, logger *logger.Logger) *DeliveryService {
+func NewDeliveryService(repo ports.DeliveryRepository, geocodingSvc geocoding.GeocodingService, blobStore ports.BlobStore, logger *logger.Logger) *DeliveryService {
 	return &DeliveryService{
-		repo:           repo,
-		publisher:      publisher,
-		deliveryClient: deliveryClient,
-		deliveryCB:     resilience.NewCircuitBreaker("delivery", 3, 10*time.Second),
-		geocodingSvc:   geocodingSvc,
-		logger:         logger,
+		repo:         repo,
+		geocodingSvc: geocodingSvc,
+		blobStore:    blobStore,
+		bulk:         DefaultBulkCreateConfig(),
+		logger:       logger,
 	}
 }
 
-// geocodeLocation converts address to coordinates if needed
-func (s *DeliveryService) geocodeLocation(ctx context.Context, location string) (string, error) {
-	// Check if it's already coordinates (format: (lng,lat))
-	coordRegex := regexp.MustCompile(`^\s*\(\s*[-+]?\d*\.?\d+\s*,\s*[-+]?\d*\.?\d+\s*\)\s*$`)
-	if coordRegex.MatchString(location) {
-		return location, nil // Already coordinates
+// requestAddress resolves one end of a create request: the structured
+// address when given, otherwise the legacy free-text location
+func requestAddress(structured *ports.Address, location string) (domain.Address, error) {
+	if structured == nil {
+		return domain.AddressFromLocation(location), nil
 	}
 
-	// Try to geocode the address
-	if s.geocodingSvc != nil {
-		result, err := s.geocodingSvc.ForwardGeocode(ctx, location)
-		if err != nil {
-			s.logger.WarnWithFields(ctx, "Failed to geocode address, using as-is",
-				zap.String("address", location), zap.Error(err))
-			return location, nil // Keep original address if geocoding fails
-		}
+	address := domain.Address{
+		Line1:      strings.TrimSpace(structured.Line1),
+		City:       strings.TrimSpace(structured.City),
+		PostalCode: strings.TrimSpace(structured.PostalCode),
+		Country:    strings.TrimSpace(structured.Country),
+	}
+	switch {
+	case structured.Latitude != nil && structured.Longitude != nil:
+		address.Coordinates = &domain.Coordinates{Latitude: *structured.Latitude, Longitude: *structured.Longitude}
+	case structured.Latitude != nil || structured.Longitude != nil:
+		return domain.Address{}, fmt.Errorf("%w: latitude and longitude must be given together", domain.ErrInvalidDeliveryData)
+	}
+	return address, nil
+}
 
-		// Return coordinates in the expected format
-		coordStr := fmt.Sprintf("(%f,%f)", result.Longitude, result.Latitude)
-		s.logger.InfoWithFields(ctx, "Geocoded address to coordinates",
-			zap.String("address", location), zap.String("coordinates", coordStr))
-		return coordStr, nil
+// geocodeAddress looks up the coordinates of an address that has none,
+// filling in the city, postal code and country when they were left out. A
+// failed lookup leaves the address without coordinates.
+func (s *DeliveryService) geocodeAddress(ctx context.Context, address *domain.Address) {
+	if address.Coordinates != nil || s.geocodingSvc == nil || address.IsEmpty() {
+		return
 	}
 
-	// No geocoding service available, keep as-is
-	return location, nil
+	query := address.String()
+	result, err := s.geocodingSvc.ForwardGeocode(ctx, query)
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to geocode address, storing it without coordinates",
+			zap.String("address", query), zap.Error(err))
+		return
+	}
+
+	address.Coordinates = &domain.Coordinates{Latitude: result.Latitude, Longitude: result.Longitude}
+	if address.City == "" {
+		address.City = result.City
+	}
+	if address.PostalCode == "" {
+		address.PostalCode = result.ZipCode
+	}
+	if address.Country == "" {
+		address.Country = result.Country
+	}
+	s.logger.InfoWithFields(ctx, "Geocoded address to coordinates",
+		zap.String("address", query),
+		zap.Float64("latitude", result.Latitude),
+		zap.Float64("longitude", result.Longitude))
 }
 
 // CreateDelivery creates a new delivery
 func (s *DeliveryService) CreateDelivery(ctx context.Context, req ports.CreateDeliveryRequest) (*domain.Delivery, error) {
+	ctx = logger.WithContext(ctx, zap.Int("customer_id", req.CustomerID))
+
 	s.logger.InfoWithFields(ctx, "Creating new delivery",
-		zap.Int("customer_id", req.CustomerID),
 		zap.String("method", "CreateDelivery"))
 
-	// Geocode locations if they're addresses
-	pickupLocation, err := s.geocodeLocation(ctx, req.PickupLocation)
+	delivery, err := s.newDelivery(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	slot, err := s.bookingSlot(ctx, delivery)
 	if err != nil {
-		return nil, fmt.Errorf("failed to geocode pickup location: %w", err)
+		return nil, err
 	}
 
-	deliveryLocation, err := s.geocodeLocation(ctx, req.DeliveryLocation)
+	// Persist the delivery together with its created event so the event
+	// cannot be lost if the broker is unavailable
+	if slot != nil {
+		err = s.repo.CreateInSlotWithOutbox(ctx, delivery, *slot, s.createdEventBuilder(ctx))
+	} else {
+		err = s.repo.CreateWithOutbox(ctx, delivery, s.createdEventBuilder(ctx))
+	}
+	if errors.Is(err, domain.ErrSlotFull) {
+		s.logger.InfoWithFields(ctx, "Delivery slot is full",
+			zap.String("zone", slot.Zone),
+			zap.Time("slot_start", slot.Start))
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to geocode delivery location: %w", err)
+		s.logger.ErrorWithFields(ctx, "Failed to persist delivery",
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to create delivery: %w", err)
 	}
+	s.syncCourierStatus(ctx, delivery.CourierID, delivery.Status)
+	s.recordAudit(ctx, auditActionCreate, delivery.ID, nil, delivery)
+
+	s.logger.InfoWithFields(ctx, "Delivery created successfully",
+		zap.Int("delivery_id", delivery.ID),
+		zap.String("status", string(delivery.Status)))
+
+	return delivery, nil
+}
+
+// newDelivery geocodes and validates a create request into a delivery that is ready to be stored
+func (s *DeliveryService) newDelivery(ctx context.Context, req ports.CreateDeliveryRequest) (*domain.Delivery, error) {
+	pickup, err := requestAddress(req.PickupAddress, req.PickupLocation)
+	if err != nil {
+		return nil, err
+	}
+	dropoff, err := requestAddress(req.DeliveryAddress, req.DeliveryLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Geocode addresses given without coordinates
+	s.geocodeAddress(ctx, &pickup)
+	s.geocodeAddress(ctx, &dropoff)
 
 	// Create domain entity with validation
-	delivery, err := domain.NewDelivery(req.CustomerID, pickupLocation, deliveryLocation)
+	delivery, err := domain.NewDeliveryWithAddresses(req.CustomerID, pickup, dropoff)
 	if err != nil {
 		s.logger.ErrorWithFields(ctx, "Failed to create delivery domain entity",
-			zap.Int("customer_id", req.CustomerID),
 			zap.Error(err))
 		return nil, err
 	}
 
+	// Only callers not limited to an organization may create deliveries in
+	// another one; the repository stores the rest in the caller's
+	if req.OrgID > 0 {
+		if _, scoped := authctx.OrgScope(ctx); scoped {
+			return nil, domain.ErrUnauthorized
+		}
+		delivery.OrgID = req.OrgID
+	}
+
 	// Set optional fields
 	delivery.CourierID = req.CourierID
 	if req.CourierID != nil {
+		if err := s.requireCourierAvailable(ctx, *req.CourierID); err != nil {
+			return nil, err
+		}
 		delivery.Status = domain.StatusAssigned
 	}
 	delivery.Notes = req.Notes
 
+	var scheduledStart, scheduledEnd *time.Time
 	if req.ScheduledDate != nil && *req.ScheduledDate != "" {
 		parsedDate, err := time.Parse(time.RFC3339, *req.ScheduledDate)
 		if err != nil {
 			return nil, fmt.Errorf("invalid scheduled_date format: %w", err)
 		}
-		delivery.ScheduledDate = &parsedDate
+		scheduledStart = &parsedDate
+	}
+	if req.ScheduledEnd != nil && *req.ScheduledEnd != "" {
+		parsedDate, err := time.Parse(time.RFC3339, *req.ScheduledEnd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scheduled_end format: %w", err)
+		}
+		scheduledEnd = &parsedDate
+	}
+	if err := delivery.SetScheduleWindow(scheduledStart, scheduledEnd, time.Now()); err != nil {
+		return nil, err
 	}
 
-	// Persist to repository
-	if err := s.repo.Create(ctx, delivery); err != nil {
-		s.logger.ErrorWithFields(ctx, "Failed to persist delivery",
-			zap.Int("customer_id", req.CustomerID),
-			zap.Error(err))
-		return nil, fmt.Errorf("failed to create delivery: %w", err)
+	if req.QuoteToken != "" {
+		if err := s.applyQuote(delivery, req.QuoteToken); err != nil {
+			return nil, err
+		}
 	}
 
-	s.logger.InfoWithFields(ctx, "Delivery created successfully",
-		zap.Int("delivery_id", delivery.ID),
-		zap.Int("customer_id", req.CustomerID),
-		zap.String("status", string(delivery.Status)))
+	return delivery, nil
+}
 
-	// Publish delivery created event
+// createdEventBuilder builds the created outbox event for a delivery once it has its ID
+func (s *DeliveryService) createdEventBuilder(ctx context.Context) ports.OutboxEventBuilder {
 	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "delivery-service", "create_delivery")
-	event := messaging.NewEventWithTrace("delivery.created", "delivery-service", "create_delivery", map[string]interface{}{
-		"delivery_id":       fmt.Sprintf("%d", delivery.ID),
-		"customer_id":       delivery.CustomerID,
-		"courier_id":        delivery.CourierID,
-		"pickup_location":   delivery.PickupLocation,
-		"delivery_location": delivery.DeliveryLocation,
-		"status":           delivery.Status,
-		"scheduled_date":   delivery.ScheduledDate,
-		"notes":            delivery.Notes,
-	}, traceCtx)
-
-	// Publish event asynchronously with retry
-	go func() {
-		err := resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
-			return s.publisher.Publish(ctx, "delivery-events", "delivery.created", event)
-		})
+	return func(d *domain.Delivery) (*domain.OutboxEvent, error) {
+		event, err := messaging.NewDeliveryCreatedEvent(messaging.DeliveryCreatedEvent{
+			DeliveryID:       d.ID,
+			OrgID:            d.OrgID,
+			TrackingNumber:   d.TrackingNumber,
+			CustomerID:       d.CustomerID,
+			CourierID:        d.CourierID,
+			PickupLocation:   d.PickupLocation,
+			DeliveryLocation: d.DeliveryLocation,
+			Status:           d.Status,
+			ScheduledDate:    d.ScheduledDate,
+			ScheduledEnd:     d.ScheduledEnd,
+			Notes:            d.Notes,
+		}, traceCtx)
 		if err != nil {
-			fmt.Printf("Failed to publish delivery created event: %v\n", err)
+			return nil, err
 		}
-	}()
-
-	return delivery, nil
+		return newOutboxEvent(ctx, d.ID, "delivery-events", messaging.EventTypeDeliveryCreated, event)
+	}
 }
 
 // GetDelivery retrieves a delivery by ID with authorization
@@ -161,96 +254,275 @@ func (s *DeliveryService) GetDelivery(ctx context.Context, req ports.GetDelivery
 	return delivery, nil
 }
 
-// ListDeliveries lists deliveries with optional filters and authorization
-func (s *DeliveryService) ListDeliveries(ctx context.Context, req ports.ListDeliveriesRequest) ([]*domain.Delivery, error) {
-	// Apply authorization filters
-	filterCustomerID := req.CustomerID
-	if req.Role == "customer" && req.UserCustomerID != nil {
-		filterCustomerID = *req.UserCustomerID
+// ListDeliveries lists a page of deliveries with optional filters and
+// authorization, with the number of deliveries on all pages
+func (s *DeliveryService) ListDeliveries(ctx context.Context, req ports.ListDeliveriesRequest) ([]*domain.Delivery, int, error) {
+	order, err := domain.ParseListOrder(req.Sort, req.Order)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	var deliveries []*domain.Delivery
-	var err error
-
-	if req.Status != "" {
-		deliveries, err = s.repo.GetByStatus(ctx, req.Status, filterCustomerID)
-	} else {
-		deliveries, err = s.repo.GetAll(ctx, filterCustomerID)
+	filter := ports.DeliveryFilter{
+		Statuses:   req.Statuses,
+		CustomerID: req.CustomerID,
+		CourierID:  req.CourierID,
+		Late:       req.Late,
+		Order:      order,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+
+		DeliveredFrom: req.DeliveredFrom,
+		DeliveredTo:   req.DeliveredTo,
 	}
 
-	if err != nil {
-		return nil, err
+	// Apply authorization filters
+	if req.Role == "customer" && req.UserCustomerID != nil {
+		filter.CustomerID = *req.UserCustomerID
 	}
-
-	// Filter results based on authorization
 	if req.Role == "courier" && req.UserCourierID != nil {
-		filtered := make([]*domain.Delivery, 0)
-		for _, d := range deliveries {
-			if d.CourierID != nil && *d.CourierID == *req.UserCourierID {
-				filtered = append(filtered, d)
-			}
+		if req.CourierID > 0 && req.CourierID != *req.UserCourierID {
+			return []*domain.Delivery{}, 0, nil
 		}
-		return filtered, nil
+		filter.CourierID = *req.UserCourierID
 	}
 
-	return deliveries, nil
+	return s.repo.List(ctx, filter)
 }
 
-// UpdateDeliveryStatus updates a delivery status with authorization
-func (s *DeliveryService) UpdateDeliveryStatus(ctx context.Context, req ports.UpdateDeliveryStatusRequest) error {
+// UpdateDeliveryStatus updates a delivery status with authorization, returning
+// the updated delivery
+func (s *DeliveryService) UpdateDeliveryStatus(ctx context.Context, req ports.UpdateDeliveryStatusRequest) (*domain.Delivery, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.ID))
+
 	// Get delivery to check authorization
 	delivery, err := s.repo.GetByID(ctx, req.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Check authorization
-	if !delivery.CanBeModifiedBy(req.Role, req.UserCustomerID, req.UserCourierID) {
-		return domain.ErrUnauthorized
+	// Check authorization; only couriers and admins change a status, and a
+	// courier changes an unassigned delivery only by claiming it
+	if req.Role == "customer" {
+		return nil, domain.ErrUnauthorized
 	}
-
-	// If a courier is updating status to "assigned", assign them to the delivery
-	if req.Role == "courier" && req.UserCourierID != nil && req.Status == "assigned" && delivery.CourierID == nil {
+	claim := req.Status == domain.StatusAssigned && delivery.CanBeClaimedBy(req.Role, req.UserCourierID)
+	if !claim && !delivery.CanBeModifiedBy(req.Role, req.UserCustomerID, req.UserCourierID) {
+		return nil, domain.ErrUnauthorized
+	}
+	if err := delivery.CheckVersion(req.ExpectedVersion); err != nil {
+		return nil, err
+	}
+	// Admins may correct a status, everyone else only moves a delivery
+	// forward: assigned by claiming it and delivered by confirming it
+	if req.Role != "admin" && req.Role != "super_admin" {
+		if err := delivery.ValidateTransition(req.Status); err != nil {
+			return nil, err
+		}
+		if req.Status == domain.StatusAssigned && !claim {
+			return nil, fmt.Errorf("%w: couriers are assigned by claiming a pending delivery", domain.ErrInvalidTransition)
+		}
+		if req.Status == domain.StatusDelivered {
+			return nil, domain.ErrDeliverByStatus
+		}
+	}
+	before := snapshotDelivery(delivery)
+	oldStatus := delivery.Status
+	action := auditActionStatusChange
+	expectedVersion := req.ExpectedVersion
+
+	// A courier claiming the delivery is assigned to it
+	if claim {
+		if err := s.requireCourierAvailable(ctx, *req.UserCourierID); err != nil {
+			return nil, err
+		}
 		if err := delivery.AssignCourier(*req.UserCourierID); err != nil {
-			return err
+			return nil, err
 		}
 		// Update the repository with courier assignment
-		if err := s.repo.AssignCourier(ctx, req.ID, *req.UserCourierID); err != nil {
-			return err
+		version, err := s.repo.AssignCourier(ctx, req.ID, *req.UserCourierID, expectedVersion)
+		if err != nil {
+			return nil, err
 		}
+		if expectedVersion != 0 {
+			expectedVersion = version
+		}
+		action = auditActionAssign
 	} else {
 		// Validate and update status in domain entity
 		if err := delivery.UpdateStatus(req.Status); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// Persist the status update
-	if err := s.repo.UpdateStatus(ctx, req.ID, req.Status, req.Notes); err != nil {
-		return err
-	}
-
-	// Publish delivery status changed event
+	// Persist the status update together with its status changed event
 	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "delivery-service", "update_delivery_status")
-	event := messaging.NewEventWithTrace("delivery.status_changed", "delivery-service", "update_delivery_status", map[string]interface{}{
-		"delivery_id":     fmt.Sprintf("%d", req.ID),
-		"customer_id":     delivery.CustomerID,
-		"courier_id":      delivery.CourierID,
-		"old_status":      delivery.Status,
-		"new_status":      req.Status,
-		"notes":          req.Notes,
-		"updated_by_role": req.Role,
+	event, err := messaging.NewDeliveryStatusChangedEvent(messaging.DeliveryStatusChangedEvent{
+		DeliveryID:    req.ID,
+		OrgID:         delivery.OrgID,
+		CustomerID:    delivery.CustomerID,
+		CourierID:     delivery.CourierID,
+		OldStatus:     oldStatus,
+		NewStatus:     req.Status,
+		ScheduledDate: delivery.ScheduledDate,
+		Notes:         req.Notes,
+		UpdatedByRole: req.Role,
+		Pickup:        eventCoordinates(delivery.PickupCoordinates()),
+		Dropoff:       eventCoordinates(delivery.DeliveryCoordinates()),
+		DeliveryZone:  delivery.DeliveryAddress.City,
 	}, traceCtx)
+	if err != nil {
+		return nil, err
+	}
 
-	// Publish event asynchronously with retry
-	go func() {
-		err := resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
-			return s.publisher.Publish(ctx, "delivery-events", "delivery.status_changed", event)
-		})
-		if err != nil {
-			fmt.Printf("Failed to publish delivery status changed event: %v\n", err)
+	outboxEvent, err := newOutboxEvent(ctx, req.ID, "delivery-events", messaging.EventTypeDeliveryStatusChanged, event)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.repo.UpdateStatusWithOutbox(ctx, req.ID, req.Status, req.Notes, expectedVersion, outboxEvent)
+	if err != nil {
+		return nil, err
+	}
+	s.syncCourierStatus(ctx, delivery.CourierID, req.Status)
+
+	delivery.Status = req.Status
+	delivery.Version = version
+	if req.Notes != "" {
+		delivery.Notes = req.Notes
+	}
+	s.recordAudit(ctx, action, req.ID, before, delivery)
+
+	return delivery, nil
+}
+
+// GetConfirmationCode returns a delivery's confirmation code to its customer
+// or an admin. Couriers prove a handover with it, so they never see it.
+func (s *DeliveryService) GetConfirmationCode(ctx context.Context, req ports.GetDeliveryRequest) (string, error) {
+	if req.Role == "courier" {
+		return "", domain.ErrUnauthorized
+	}
+	delivery, err := s.GetDelivery(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return delivery.ConfirmationCode, nil
+}
+
+// ConfirmDelivery records proof of delivery from the assigned courier and marks the delivery as delivered
+func (s *DeliveryService) ConfirmDelivery(ctx context.Context, req ports.ConfirmDeliveryRequest) (*domain.DeliveryConfirmation, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.ID))
+
+	// Only the assigned courier can confirm a delivery
+	if req.Role != "courier" || req.UserCourierID == nil {
+		return nil, domain.ErrUnauthorized
+	}
+
+	delivery, err := s.repo.GetByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmation, err := domain.NewDeliveryConfirmation(req.ID, *req.UserCourierID, req.RecipientName, req.ConfirmationCode,
+		len(req.Photo) > 0, len(req.Signature) > 0)
+	if err != nil {
+		return nil, err
+	}
+	confirmation.Notes = req.Notes
+
+	before := snapshotDelivery(delivery)
+	if err := delivery.Confirm(*req.UserCourierID, req.ConfirmationCode); err != nil {
+		return nil, err
+	}
+
+	// Store images before the database write; they are removed again if it fails
+	var storedKeys []string
+	cleanup := func() {
+		for _, key := range storedKeys {
+			if err := s.blobStore.Delete(ctx, key); err != nil {
+				s.logger.WarnWithFields(ctx, "Failed to remove orphaned proof-of-delivery blob",
+					zap.String("key", key), zap.Error(err))
+			}
+		}
+	}
+
+	stamp := confirmation.ConfirmedAt.UnixNano()
+	if len(req.Photo) > 0 {
+		key := fmt.Sprintf("deliveries/%d/photo-%d", req.ID, stamp)
+		if err := s.blobStore.Put(ctx, key, req.Photo); err != nil {
+			return nil, fmt.Errorf("failed to store delivery photo: %w", err)
+		}
+		storedKeys = append(storedKeys, key)
+		confirmation.PhotoKey = key
+	}
+	if len(req.Signature) > 0 {
+		key := fmt.Sprintf("deliveries/%d/signature-%d", req.ID, stamp)
+		if err := s.blobStore.Put(ctx, key, req.Signature); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to store delivery signature: %w", err)
 		}
-	}()
+		storedKeys = append(storedKeys, key)
+		confirmation.SignatureKey = key
+	}
 
-	return nil
+	// Persist the confirmation together with its confirmed event
+	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "delivery-service", "confirm_delivery")
+	event, err := messaging.NewDeliveryConfirmedEvent(messaging.DeliveryConfirmedEvent{
+		DeliveryID:    req.ID,
+		OrgID:         delivery.OrgID,
+		CustomerID:    delivery.CustomerID,
+		CourierID:     delivery.CourierID,
+		RecipientName: confirmation.RecipientName,
+		HasPhoto:      confirmation.PhotoKey != "",
+		HasSignature:  confirmation.SignatureKey != "",
+		DeliveredDate: delivery.DeliveredDate,
+		ScheduledDate: delivery.ScheduledDate,
+		Pickup:        eventCoordinates(delivery.PickupCoordinates()),
+		Dropoff:       eventCoordinates(delivery.DeliveryCoordinates()),
+		DeliveryZone:  delivery.DeliveryAddress.City,
+	}, traceCtx)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	outboxEvent, err := newOutboxEvent(ctx, req.ID, "delivery-events", messaging.EventTypeDeliveryConfirmed, event)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	if err := s.repo.ConfirmWithOutbox(ctx, delivery, confirmation, outboxEvent); err != nil {
+		cleanup()
+		s.logger.ErrorWithFields(ctx, "Failed to persist delivery confirmation",
+			zap.Error(err))
+		return nil, err
+	}
+	s.syncCourierStatus(ctx, delivery.CourierID, delivery.Status)
+	s.recordAudit(ctx, auditActionConfirm, req.ID, before, delivery)
+
+	s.logger.InfoWithFields(ctx, "Delivery confirmed",
+		zap.Int("courier_id", *req.UserCourierID),
+		zap.Bool("has_photo", confirmation.PhotoKey != ""),
+		zap.Bool("has_signature", confirmation.SignatureKey != ""))
+
+	return confirmation, nil
+}
+
+// newOutboxEvent serializes a messaging event into an outbox row, recording
+// the request ID from ctx since the dispatcher publishes it later
+func newOutboxEvent(ctx context.Context, deliveryID int, exchange, routingKey string, event messaging.Event) (*domain.OutboxEvent, error) {
+	payload, err := json.Marshal(messaging.WithRequestID(ctx, event))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return domain.NewOutboxEvent(deliveryID, exchange, routingKey, payload)
+}
+
+// eventCoordinates converts a known address point for an event payload
+func eventCoordinates(coords *domain.Coordinates, ok bool) *messaging.Coordinates {
+	if !ok {
+		return nil
+	}
+	return &messaging.Coordinates{Latitude: coords.Latitude, Longitude: coords.Longitude}
 }
@@ -2,21 +2,21 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
 	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
-	"github.com/Keneke-Einar/delivertrack/proto/analytics"
-	"github.com/Keneke-Einar/delivertrack/proto/delivery"
-	"github.com/Keneke-Einar/delivertrack/proto/notification"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
 	"go.uber.org/zap/zaptest"
-	"google.golang.org/grpc"
 )
 
 // createTestLogger creates a test logger for unit tests
@@ -25,272 +25,14 @@ func createTestLogger(t *testing.T) *logger.Logger {
 	return &logger.Logger{Logger: zapLogger}
 }
 
-// MockDeliveryRepository is a mock implementation of DeliveryRepository for testing
-type MockDeliveryRepository struct {
-	deliveries map[int]*domain.Delivery
-	nextID     int
-	createErr  error
-	getByIDErr error
-	updateErr  error
-}
-
-func NewMockDeliveryRepository() *MockDeliveryRepository {
-	return &MockDeliveryRepository{
-		deliveries: make(map[int]*domain.Delivery),
-		nextID:     1,
-	}
-}
-
-func (m *MockDeliveryRepository) Create(ctx context.Context, delivery *domain.Delivery) error {
-	if m.createErr != nil {
-		return m.createErr
-	}
-	delivery.ID = m.nextID
-	m.deliveries[m.nextID] = delivery
-	m.nextID++
-	return nil
-}
-
-func (m *MockDeliveryRepository) GetByID(ctx context.Context, id int) (*domain.Delivery, error) {
-	if m.getByIDErr != nil {
-		return nil, m.getByIDErr
-	}
-	delivery, exists := m.deliveries[id]
-	if !exists {
-		return nil, domain.ErrDeliveryNotFound
-	}
-	return delivery, nil
-}
-
-func (m *MockDeliveryRepository) GetByStatus(ctx context.Context, status string, customerID int) ([]*domain.Delivery, error) {
-	var deliveries []*domain.Delivery
-	for _, d := range m.deliveries {
-		if d.Status == status && (customerID == 0 || d.CustomerID == customerID) {
-			deliveries = append(deliveries, d)
-		}
-	}
-	return deliveries, nil
-}
-
-func (m *MockDeliveryRepository) GetAll(ctx context.Context, customerID int) ([]*domain.Delivery, error) {
-	var deliveries []*domain.Delivery
-	for _, d := range m.deliveries {
-		if customerID == 0 || d.CustomerID == customerID {
-			deliveries = append(deliveries, d)
-		}
-	}
-	return deliveries, nil
-}
-
-func (m *MockDeliveryRepository) UpdateStatus(ctx context.Context, id int, status, notes string) error {
-	if m.updateErr != nil {
-		return m.updateErr
-	}
-	delivery, exists := m.deliveries[id]
-	if !exists {
-		return domain.ErrDeliveryNotFound
-	}
-	delivery.Status = status
-	delivery.Notes = notes
-	delivery.UpdatedAt = time.Now()
-	return nil
-}
-
-func (m *MockDeliveryRepository) AssignCourier(ctx context.Context, deliveryID, courierID int) error {
-	delivery, exists := m.deliveries[deliveryID]
-	if !exists {
-		return domain.ErrDeliveryNotFound
-	}
-	delivery.CourierID = &courierID
-	delivery.Status = "assigned"
-	delivery.UpdatedAt = time.Now()
-	return nil
-}
-
-func (m *MockDeliveryRepository) Update(ctx context.Context, delivery *domain.Delivery) error {
-	m.deliveries[delivery.ID] = delivery
-	return nil
-}
-
-func (m *MockDeliveryRepository) SetCreateError(err error) {
-	m.createErr = err
-}
-
-func (m *MockDeliveryRepository) SetGetByIDError(err error) {
-	m.getByIDErr = err
-}
-
-func (m *MockDeliveryRepository) SetUpdateError(err error) {
-	m.updateErr = err
-}
-
-func (m *MockDeliveryRepository) AddDelivery(delivery *domain.Delivery) {
-	m.deliveries[delivery.ID] = delivery
-	if delivery.ID >= m.nextID {
-		m.nextID = delivery.ID + 1
-	}
-}
-
-// MockPublisher is a mock implementation of messaging.Publisher for testing
-type MockPublisher struct {
-	publishedEvents []messaging.Event
-	publishErr      error
-}
-
-func NewMockPublisher() *MockPublisher {
-	return &MockPublisher{
-		publishedEvents: make([]messaging.Event, 0),
-	}
-}
-
-func (m *MockPublisher) Publish(ctx context.Context, exchange, routingKey string, event messaging.Event) error {
-	if m.publishErr != nil {
-		return m.publishErr
+// storedDelivery reads a delivery back from the repository
+func storedDelivery(t *testing.T, repo *memory.DeliveryRepository, id int) *domain.Delivery {
+	t.Helper()
+	delivery, err := repo.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to read delivery %d: %v", id, err)
 	}
-	m.publishedEvents = append(m.publishedEvents, event)
-	return nil
-}
-
-func (m *MockPublisher) Close() error {
-	return nil
-}
-
-func (m *MockPublisher) SetPublishError(err error) {
-	m.publishErr = err
-}
-
-func (m *MockPublisher) GetPublishedEvents() []messaging.Event {
-	return m.publishedEvents
-}
-
-// MockNotificationClient is a mock implementation of NotificationServiceClient for testing
-type MockNotificationClient struct{}
-
-func (m *MockNotificationClient) SendNotification(ctx context.Context, in *notification.SendNotificationRequest, opts ...grpc.CallOption) (*notification.SendNotificationResponse, error) {
-	return &notification.SendNotificationResponse{
-		NotificationId: "mock-notification-id",
-		Status:         notification.NotificationStatus_NOTIFICATION_STATUS_SENT,
-		SentAt:         time.Now().Unix(),
-	}, nil
-}
-
-func (m *MockNotificationClient) SendBulkNotifications(ctx context.Context, in *notification.SendBulkNotificationsRequest, opts ...grpc.CallOption) (*notification.SendBulkNotificationsResponse, error) {
-	return &notification.SendBulkNotificationsResponse{
-		SuccessCount: 1,
-		FailedCount:  0,
-	}, nil
-}
-
-func (m *MockNotificationClient) SendDeliveryUpdate(ctx context.Context, in *notification.SendDeliveryUpdateRequest, opts ...grpc.CallOption) (*notification.SendDeliveryUpdateResponse, error) {
-	return &notification.SendDeliveryUpdateResponse{
-		NotificationId: "mock-delivery-notification-id",
-		Success:        true,
-		SentAt:         time.Now().Unix(),
-	}, nil
-}
-
-func (m *MockNotificationClient) GetNotificationHistory(ctx context.Context, in *notification.GetNotificationHistoryRequest, opts ...grpc.CallOption) (*notification.GetNotificationHistoryResponse, error) {
-	return &notification.GetNotificationHistoryResponse{}, nil
-}
-
-func (m *MockNotificationClient) UpdatePreferences(ctx context.Context, in *notification.UpdatePreferencesRequest, opts ...grpc.CallOption) (*notification.UpdatePreferencesResponse, error) {
-	return &notification.UpdatePreferencesResponse{Success: true}, nil
-}
-
-func (m *MockNotificationClient) GetPreferences(ctx context.Context, in *notification.GetPreferencesRequest, opts ...grpc.CallOption) (*notification.GetPreferencesResponse, error) {
-	return &notification.GetPreferencesResponse{}, nil
-}
-
-func (m *MockNotificationClient) Subscribe(ctx context.Context, in *notification.SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[notification.Notification], error) {
-	return nil, nil
-}
-
-func (m *MockNotificationClient) MarkAsRead(ctx context.Context, in *notification.MarkAsReadRequest, opts ...grpc.CallOption) (*notification.MarkAsReadResponse, error) {
-	return &notification.MarkAsReadResponse{Success: true}, nil
-}
-
-// MockAnalyticsClient is a mock implementation of AnalyticsServiceClient for testing
-type MockAnalyticsClient struct{}
-
-func (m *MockAnalyticsClient) RecordEvent(ctx context.Context, in *analytics.RecordEventRequest, opts ...grpc.CallOption) (*analytics.RecordEventResponse, error) {
-	return &analytics.RecordEventResponse{
-		Success:    true,
-		RecordedAt: time.Now().Unix(),
-	}, nil
-}
-
-func (m *MockAnalyticsClient) BatchRecordEvents(ctx context.Context, in *analytics.BatchRecordEventsRequest, opts ...grpc.CallOption) (*analytics.BatchRecordEventsResponse, error) {
-	return &analytics.BatchRecordEventsResponse{
-		SuccessCount: 1,
-		FailedCount:  0,
-	}, nil
-}
-
-func (m *MockAnalyticsClient) GetDeliveryMetrics(ctx context.Context, in *analytics.GetDeliveryMetricsRequest, opts ...grpc.CallOption) (*analytics.GetDeliveryMetricsResponse, error) {
-	return &analytics.GetDeliveryMetricsResponse{}, nil
-}
-
-func (m *MockAnalyticsClient) GetDriverPerformance(ctx context.Context, in *analytics.GetDriverPerformanceRequest, opts ...grpc.CallOption) (*analytics.GetDriverPerformanceResponse, error) {
-	return &analytics.GetDriverPerformanceResponse{}, nil
-}
-
-func (m *MockAnalyticsClient) GetCustomerAnalytics(ctx context.Context, in *analytics.GetCustomerAnalyticsRequest, opts ...grpc.CallOption) (*analytics.GetCustomerAnalyticsResponse, error) {
-	return &analytics.GetCustomerAnalyticsResponse{}, nil
-}
-
-func (m *MockAnalyticsClient) GetSystemMetrics(ctx context.Context, in *analytics.GetSystemMetricsRequest, opts ...grpc.CallOption) (*analytics.GetSystemMetricsResponse, error) {
-	return &analytics.GetSystemMetricsResponse{}, nil
-}
-
-func (m *MockAnalyticsClient) GenerateReport(ctx context.Context, in *analytics.GenerateReportRequest, opts ...grpc.CallOption) (*analytics.GenerateReportResponse, error) {
-	return &analytics.GenerateReportResponse{}, nil
-}
-
-func (m *MockAnalyticsClient) GetDashboard(ctx context.Context, in *analytics.GetDashboardRequest, opts ...grpc.CallOption) (*analytics.GetDashboardResponse, error) {
-	return &analytics.GetDashboardResponse{}, nil
-}
-
-func (m *MockAnalyticsClient) GetRouteEfficiency(ctx context.Context, in *analytics.GetRouteEfficiencyRequest, opts ...grpc.CallOption) (*analytics.GetRouteEfficiencyResponse, error) {
-	return &analytics.GetRouteEfficiencyResponse{}, nil
-}
-
-// MockDeliveryClient is a mock implementation of DeliveryServiceClient for testing
-type MockDeliveryClient struct{}
-
-func (m *MockDeliveryClient) CreateDelivery(ctx context.Context, in *delivery.CreateDeliveryRequest, opts ...grpc.CallOption) (*delivery.CreateDeliveryResponse, error) {
-	return &delivery.CreateDeliveryResponse{}, nil
-}
-
-func (m *MockDeliveryClient) GetDelivery(ctx context.Context, in *delivery.GetDeliveryRequest, opts ...grpc.CallOption) (*delivery.GetDeliveryResponse, error) {
-	return &delivery.GetDeliveryResponse{}, nil
-}
-
-func (m *MockDeliveryClient) UpdateDeliveryStatus(ctx context.Context, in *delivery.UpdateDeliveryStatusRequest, opts ...grpc.CallOption) (*delivery.UpdateDeliveryStatusResponse, error) {
-	return &delivery.UpdateDeliveryStatusResponse{}, nil
-}
-
-func (m *MockDeliveryClient) AssignDriver(ctx context.Context, in *delivery.AssignDriverRequest, opts ...grpc.CallOption) (*delivery.AssignDriverResponse, error) {
-	return &delivery.AssignDriverResponse{}, nil
-}
-
-func (m *MockDeliveryClient) ListDeliveries(ctx context.Context, in *delivery.ListDeliveriesRequest, opts ...grpc.CallOption) (*delivery.ListDeliveriesResponse, error) {
-	return &delivery.ListDeliveriesResponse{}, nil
-}
-
-func (m *MockDeliveryClient) CancelDelivery(ctx context.Context, in *delivery.CancelDeliveryRequest, opts ...grpc.CallOption) (*delivery.CancelDeliveryResponse, error) {
-	return &delivery.CancelDeliveryResponse{}, nil
-}
-
-func (m *MockDeliveryClient) GetDriverDeliveries(ctx context.Context, in *delivery.GetDriverDeliveriesRequest, opts ...grpc.CallOption) (*delivery.GetDriverDeliveriesResponse, error) {
-	return &delivery.GetDriverDeliveriesResponse{}, nil
-}
-
-func (m *MockDeliveryClient) OptimizeRoute(ctx context.Context, in *delivery.OptimizeRouteRequest, opts ...grpc.CallOption) (*delivery.OptimizeRouteResponse, error) {
-	return &delivery.OptimizeRouteResponse{}, nil
-}
-
-func (m *MockDeliveryClient) ConfirmDelivery(ctx context.Context, in *delivery.ConfirmDeliveryRequest, opts ...grpc.CallOption) (*delivery.ConfirmDeliveryResponse, error) {
-	return &delivery.ConfirmDeliveryResponse{}, nil
+	return delivery
 }
 
 // MockGeocodingService is a mock implementation of GeocodingService for testing
@@ -329,6 +71,12 @@ func (m *MockGeocodingService) Autocomplete(ctx context.Context, query string) (
 	}, nil
 }
 
+// future formats a time d from now as RFC3339
+func future(d time.Duration) *string {
+	s := time.Now().Add(d).Format(time.RFC3339)
+	return &s
+}
+
 func TestDeliveryService_CreateDelivery(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -338,6 +86,7 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 		deliveryLoc      string
 		notes            string
 		scheduledDate    *string
+		scheduledEnd     *string
 		mockCreateErr    error
 		expectError      bool
 		expectedStatus   string
@@ -355,8 +104,8 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 			mockCreateErr:    nil,
 			expectError:      false,
 			expectedStatus:   domain.StatusPending,
-			expectedPickup:   "(-74.006000,40.712800)",
-			expectedDelivery: "(-74.006000,40.712800)",
+			expectedPickup:   "123 Main St, 10001 New York, USA",
+			expectedDelivery: "456 Oak Ave, 10001 New York, USA",
 		},
 		{
 			name:             "creation with courier",
@@ -369,8 +118,8 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 			mockCreateErr:    nil,
 			expectError:      false,
 			expectedStatus: domain.StatusAssigned,
-			expectedPickup:   "(-74.006000,40.712800)",
-			expectedDelivery: "(-74.006000,40.712800)",
+			expectedPickup:   "123 Main St, 10001 New York, USA",
+			expectedDelivery: "456 Oak Ave, 10001 New York, USA",
 		},
 		{
 			name:             "creation with scheduled date",
@@ -379,12 +128,12 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 			pickupLoc:        "123 Main St",
 			deliveryLoc:      "456 Oak Ave",
 			notes:            "",
-			scheduledDate:    func() *string { s := "2024-01-01T10:00:00Z"; return &s }(),
+			scheduledDate:    future(24 * time.Hour),
 			mockCreateErr:    nil,
 			expectError:      false,
 			expectedStatus:   domain.StatusPending,
-			expectedPickup:   "(-74.006000,40.712800)",
-			expectedDelivery: "(-74.006000,40.712800)",
+			expectedPickup:   "123 Main St, 10001 New York, USA",
+			expectedDelivery: "456 Oak Ave, 10001 New York, USA",
 		},
 		{
 			name:          "invalid customer ID",
@@ -430,17 +179,52 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 			mockCreateErr: nil,
 			expectError:   true,
 		},
+		{
+			name:             "creation with scheduled window",
+			customerID:       1,
+			pickupLoc:        "123 Main St",
+			deliveryLoc:      "456 Oak Ave",
+			scheduledDate:    future(24 * time.Hour),
+			scheduledEnd:     future(26 * time.Hour),
+			expectError:      false,
+			expectedStatus:   domain.StatusPending,
+			expectedPickup:   "123 Main St, 10001 New York, USA",
+			expectedDelivery: "456 Oak Ave, 10001 New York, USA",
+		},
+		{
+			name:          "scheduled date in the past",
+			customerID:    1,
+			pickupLoc:     "123 Main St",
+			deliveryLoc:   "456 Oak Ave",
+			scheduledDate: func() *string { s := "2024-01-01T10:00:00Z"; return &s }(),
+			expectError:   true,
+		},
+		{
+			name:          "window end before start",
+			customerID:    1,
+			pickupLoc:     "123 Main St",
+			deliveryLoc:   "456 Oak Ave",
+			scheduledDate: future(26 * time.Hour),
+			scheduledEnd:  future(24 * time.Hour),
+			expectError:   true,
+		},
+		{
+			name:         "invalid scheduled end format",
+			customerID:   1,
+			pickupLoc:    "123 Main St",
+			deliveryLoc:  "456 Oak Ave",
+			scheduledEnd: func() *string { s := "tomorrow"; return &s }(),
+			expectError:  true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := NewMockDeliveryRepository()
+			mockRepo := memory.NewDeliveryRepository()
 			mockRepo.SetCreateError(tt.mockCreateErr)
-			mockPublisher := NewMockPublisher()
-			mockDeliveryClient := &MockDeliveryClient{}
 			mockGeocodingSvc := &MockGeocodingService{}
 			testLogger := createTestLogger(t)
-			service := NewDeliveryService(mockRepo, mockPublisher, mockDeliveryClient, mockGeocodingSvc, testLogger)
+			service := NewDeliveryService(mockRepo, mockGeocodingSvc, nil, testLogger)
 
 			delivery, err := service.CreateDelivery(context.Background(), ports.CreateDeliveryRequest{
 				CustomerID:       tt.customerID,
@@ -449,6 +233,7 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 				DeliveryLocation: tt.deliveryLoc,
 				Notes:            tt.notes,
 				ScheduledDate:    tt.scheduledDate,
+				ScheduledEnd:     tt.scheduledEnd,
 			})
 
 			if tt.expectError {
@@ -484,6 +269,14 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 				t.Errorf("expected delivery location %s, got %s", tt.expectedDelivery, delivery.DeliveryLocation)
 			}
 
+			// Geocoding fills in the coordinates the route planner and ETA use
+			if coords, ok := delivery.PickupCoordinates(); !ok || coords.Latitude != 40.7128 || coords.Longitude != -74.006 {
+				t.Errorf("expected geocoded pickup coordinates, got %+v", coords)
+			}
+			if coords, ok := delivery.DeliveryCoordinates(); !ok || coords.Latitude != 40.7128 || coords.Longitude != -74.006 {
+				t.Errorf("expected geocoded delivery coordinates, got %+v", coords)
+			}
+
 			if tt.courierID != nil {
 				if delivery.CourierID == nil || *delivery.CourierID != *tt.courierID {
 					t.Errorf("expected courier ID %d, got %v", *tt.courierID, delivery.CourierID)
@@ -493,17 +286,89 @@ func TestDeliveryService_CreateDelivery(t *testing.T) {
 			if delivery.Notes != tt.notes {
 				t.Errorf("expected notes %s, got %s", tt.notes, delivery.Notes)
 			}
+
+			if (tt.scheduledEnd == nil) != (delivery.ScheduledEnd == nil) {
+				t.Errorf("expected scheduled end %v, got %v", tt.scheduledEnd, delivery.ScheduledEnd)
+			}
+
+			events := mockRepo.OutboxEvents()
+			if len(events) != 1 {
+				t.Fatalf("expected 1 outbox event, got %d", len(events))
+			}
+			if events[0].RoutingKey != "delivery.created" || events[0].AggregateID != delivery.ID {
+				t.Errorf("unexpected outbox event: %+v", events[0])
+			}
 		})
 	}
 }
 
+func TestDeliveryService_CreateDelivery_StructuredAddresses(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+
+	lat, lng := 52.52, 13.405
+	delivery, err := service.CreateDelivery(context.Background(), ports.CreateDeliveryRequest{
+		CustomerID:      1,
+		PickupAddress:   &ports.Address{Line1: "Unter den Linden 1", City: "Berlin", PostalCode: "10117", Country: "DE", Latitude: &lat, Longitude: &lng},
+		DeliveryAddress: &ports.Address{Line1: "456 Oak Ave"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Coordinates given by the caller are kept rather than geocoded
+	if delivery.PickupLocation != "Unter den Linden 1, 10117 Berlin, DE" {
+		t.Errorf("unexpected pickup location %q", delivery.PickupLocation)
+	}
+	if coords := delivery.PickupAddress.Coordinates; coords == nil || coords.Latitude != lat || coords.Longitude != lng {
+		t.Errorf("expected caller's pickup coordinates, got %+v", coords)
+	}
+	// Missing parts of the drop-off are filled in from the geocoder
+	if delivery.DeliveryAddress.City != "New York" || delivery.DeliveryAddress.Coordinates == nil {
+		t.Errorf("expected geocoded drop-off address, got %+v", delivery.DeliveryAddress)
+	}
+
+	_, err = service.CreateDelivery(context.Background(), ports.CreateDeliveryRequest{
+		CustomerID:      1,
+		PickupAddress:   &ports.Address{Line1: "Unter den Linden 1", Latitude: &lat},
+		DeliveryAddress: &ports.Address{Line1: "456 Oak Ave"},
+	})
+	if !errors.Is(err, domain.ErrInvalidDeliveryData) {
+		t.Errorf("expected ErrInvalidDeliveryData for a latitude without longitude, got %v", err)
+	}
+}
+
+func TestDeliveryService_CreateDelivery_RecordsRequestID(t *testing.T) {
+	mockRepo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(mockRepo, &MockGeocodingService{}, nil, createTestLogger(t))
+
+	ctx := requestid.WithID(context.Background(), "req-42")
+	if _, err := service.CreateDelivery(ctx, ports.CreateDeliveryRequest{
+		CustomerID:       1,
+		PickupLocation:   "123 Main St",
+		DeliveryLocation: "456 Oak Ave",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := mockRepo.OutboxEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 outbox event, got %d", len(events))
+	}
+	var event messaging.Event
+	if err := json.Unmarshal(events[0].Payload, &event); err != nil {
+		t.Fatalf("failed to decode outbox payload: %v", err)
+	}
+	if event.RequestID != "req-42" {
+		t.Errorf("expected the event to carry request ID req-42, got %q", event.RequestID)
+	}
+}
+
 func TestDeliveryService_GetDelivery(t *testing.T) {
-	mockRepo := NewMockDeliveryRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
+	mockRepo := memory.NewDeliveryRepository()
 	mockGeocodingSvc := &MockGeocodingService{}
 	testLogger := createTestLogger(t)
-	service := NewDeliveryService(mockRepo, mockPublisher, mockDeliveryClient, mockGeocodingSvc, testLogger)
+	service := NewDeliveryService(mockRepo, mockGeocodingSvc, nil, testLogger)
 
 	// Create a test delivery
 	delivery := &domain.Delivery{
@@ -637,12 +502,10 @@ func TestDeliveryService_GetDelivery(t *testing.T) {
 }
 
 func TestDeliveryService_ListDeliveries(t *testing.T) {
-	mockRepo := NewMockDeliveryRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
+	mockRepo := memory.NewDeliveryRepository()
 	mockGeocodingSvc := &MockGeocodingService{}
 	testLogger := createTestLogger(t)
-	service := NewDeliveryService(mockRepo, mockPublisher, mockDeliveryClient, mockGeocodingSvc, testLogger)
+	service := NewDeliveryService(mockRepo, mockGeocodingSvc, nil, testLogger)
 
 	// Create test deliveries
 	deliveries := []*domain.Delivery{
@@ -673,6 +536,7 @@ func TestDeliveryService_ListDeliveries(t *testing.T) {
 			Status:           domain.StatusDelivered,
 			PickupLocation:   "111 Oak St",
 			DeliveryLocation: "222 Maple Ave",
+			Late:             true,
 			CreatedAt:        time.Now(),
 			UpdatedAt:        time.Now(),
 		},
@@ -689,6 +553,8 @@ func TestDeliveryService_ListDeliveries(t *testing.T) {
 		role           string
 		userCustomerID *int
 		userCourierID  *int
+		late           *bool
+		courierID      int
 		expectedCount  int
 	}{
 		{
@@ -736,13 +602,58 @@ func TestDeliveryService_ListDeliveries(t *testing.T) {
 			userCourierID:  func() *int { i := 2; return &i }(),
 			expectedCount:  1,
 		},
+		{
+			name:          "admin list late deliveries",
+			role:          "admin",
+			late:          func() *bool { b := true; return &b }(),
+			expectedCount: 1,
+		},
+		{
+			name:          "admin list deliveries not late",
+			role:          "admin",
+			late:          func() *bool { b := false; return &b }(),
+			expectedCount: 2,
+		},
+		{
+			name:           "customer list late deliveries",
+			role:           "customer",
+			userCustomerID: func() *int { i := 1; return &i }(),
+			late:           func() *bool { b := true; return &b }(),
+			expectedCount:  0,
+		},
+		{
+			name:          "admin list by courier and status",
+			status:        domain.StatusPending,
+			role:          "admin",
+			courierID:     2,
+			expectedCount: 1,
+		},
+		{
+			name:          "admin list by unassigned courier",
+			role:          "admin",
+			courierID:     9,
+			expectedCount: 0,
+		},
+		{
+			name:          "courier list another courier's deliveries",
+			role:          "courier",
+			userCourierID: func() *int { i := 2; return &i }(),
+			courierID:     3,
+			expectedCount: 0,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := service.ListDeliveries(context.Background(), ports.ListDeliveriesRequest{
-				Status:     tt.status,
+			var statuses []string
+			if tt.status != "" {
+				statuses = []string{tt.status}
+			}
+			result, total, err := service.ListDeliveries(context.Background(), ports.ListDeliveriesRequest{
+				Statuses:   statuses,
 				CustomerID: tt.customerID,
+				Late:       tt.late,
+				CourierID:  tt.courierID,
 				AuthContext: ports.AuthContext{
 					Role:           tt.role,
 					UserCustomerID: tt.userCustomerID,
@@ -754,20 +665,226 @@ func TestDeliveryService_ListDeliveries(t *testing.T) {
 				return
 			}
 
-			if len(result) != tt.expectedCount {
-				t.Errorf("expected %d deliveries, got %d", tt.expectedCount, len(result))
+			if len(result) != tt.expectedCount || total != tt.expectedCount {
+				t.Errorf("expected %d deliveries, got %d of %d", tt.expectedCount, len(result), total)
 			}
 		})
 	}
 }
 
+func TestDeliveryService_ListDeliveries_SortAndStatuses(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		t := base.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+	seed := []*domain.Delivery{
+		{CustomerID: 1, Status: domain.StatusAssigned, ScheduledDate: at(5)},
+		{CustomerID: 1, Status: domain.StatusInTransit, ScheduledDate: at(2)},
+		{CustomerID: 1, Status: domain.StatusInTransit},
+		{CustomerID: 1, Status: domain.StatusPending, ScheduledDate: at(1)},
+	}
+	for _, d := range seed {
+		d.PickupLocation, d.DeliveryLocation = "A", "B"
+		if err := repo.Create(context.Background(), d); err != nil {
+			t.Fatalf("failed to seed delivery: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		statuses []string
+		sort     string
+		order    string
+		limit    int
+		offset   int
+		expected []int
+	}{
+		{name: "newest first by default", expected: []int{4, 3, 2, 1}},
+		{name: "second page", limit: 2, offset: 2, expected: []int{2, 1}},
+		{name: "assigned or in transit, scheduled soonest first", statuses: []string{domain.StatusAssigned, domain.StatusInTransit},
+			sort: domain.SortScheduledDate, order: domain.OrderAsc, expected: []int{2, 1, 3}},
+		{name: "unscheduled last when descending", sort: domain.SortScheduledDate, order: domain.OrderDesc, expected: []int{1, 2, 4, 3}},
+		{name: "oldest first", order: domain.OrderAsc, expected: []int{1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := service.ListDeliveries(context.Background(), ports.ListDeliveriesRequest{
+				Statuses:    tt.statuses,
+				Sort:        tt.sort,
+				Order:       tt.order,
+				Limit:       tt.limit,
+				Offset:      tt.offset,
+				AuthContext: ports.AuthContext{Role: "admin"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var ids []int
+			for _, d := range result {
+				ids = append(ids, d.ID)
+			}
+			if !slices.Equal(ids, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	for _, req := range []ports.ListDeliveriesRequest{{Sort: "customer_id"}, {Order: "up"}} {
+		req.Role = "admin"
+		if _, _, err := service.ListDeliveries(context.Background(), req); !errors.Is(err, domain.ErrInvalidSort) {
+			t.Errorf("expected ErrInvalidSort for sort %q order %q, got %v", req.Sort, req.Order, err)
+		}
+	}
+}
+
+func TestDeliveryService_UpdateDeliveryStatus_ExpectedVersion(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+	ctx := context.Background()
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusPending, PickupLocation: "A", DeliveryLocation: "B"})
+	admin := ports.AuthContext{Role: "admin"}
+
+	updated, err := service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
+		ID: 1, Status: domain.StatusInTransit, ExpectedVersion: 1, AuthContext: admin,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("expected version 2, got %d", updated.Version)
+	}
+
+	// A second writer still holding version 1 is told the current one
+	_, err = service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
+		ID: 1, Status: domain.StatusPending, ExpectedVersion: 1, AuthContext: admin,
+	})
+	var conflict *domain.ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if conflict.CurrentVersion != 2 {
+		t.Errorf("expected current version 2, got %d", conflict.CurrentVersion)
+	}
+
+	// Without an expected version the last write wins
+	updated, err = service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
+		ID: 1, Status: domain.StatusPending, AuthContext: admin,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Version != 3 || updated.Status != domain.StatusPending {
+		t.Errorf("expected a pending delivery at version 3, got %s at %d", updated.Status, updated.Version)
+	}
+
+	// A courier taking the delivery writes twice under one expected version
+	courierID := 7
+	updated, err = service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
+		ID: 1, Status: domain.StatusAssigned, ExpectedVersion: 3,
+		AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, _ := repo.GetByID(ctx, 1)
+	if updated.Version != 5 || stored.Version != 5 || stored.CourierID == nil || *stored.CourierID != courierID {
+		t.Errorf("expected the assignment stored at version 5, got %+v", stored)
+	}
+}
+
+func TestDeliveryService_UpdateDeliveryStatus_CourierClaim(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+	ctx := context.Background()
+	repo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: 1, Status: domain.StatusPending, PickupLocation: "A", DeliveryLocation: "B"})
+	courierID := 7
+	courier := ports.AuthContext{Role: "courier", UserCourierID: &courierID}
+
+	update := func(status string) error {
+		_, err := service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{ID: 1, Status: status, AuthContext: courier})
+		return err
+	}
+
+	// An unassigned delivery can only be claimed
+	if err := update(domain.StatusDelivered); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized before claiming, got %v", err)
+	}
+	if err := update(domain.StatusAssigned); err != nil {
+		t.Fatalf("failed to claim the delivery: %v", err)
+	}
+	if stored, _ := repo.GetByID(ctx, 1); stored.CourierID == nil || *stored.CourierID != courierID {
+		t.Fatalf("expected the delivery assigned to the claiming courier, got %+v", stored.CourierID)
+	}
+
+	// Once assigned, it moves forward one step at a time
+	if err := update(domain.StatusDelivered); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition skipping in_transit, got %v", err)
+	}
+	if err := update(domain.StatusInTransit); err != nil {
+		t.Fatalf("failed to pick up the delivery: %v", err)
+	}
+	if err := update(domain.StatusPending); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition moving back, got %v", err)
+	}
+
+	otherCourierID := 8
+	_, err := service.UpdateDeliveryStatus(ctx, ports.UpdateDeliveryStatusRequest{
+		ID: 1, Status: domain.StatusDelivered, AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &otherCourierID},
+	})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for another courier, got %v", err)
+	}
+}
+
+func TestDeliveryService_UpdateDeliveryStatus_EventCarriesRoute(t *testing.T) {
+	repo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+	courierID := 7
+	repo.AddDelivery(&domain.Delivery{
+		ID: 1, CustomerID: 1, CourierID: &courierID, Status: domain.StatusInTransit,
+		PickupLocation:   "(13.4,52.52)",
+		DeliveryLocation: "5 Elm St, Berlin",
+		DeliveryAddress:  domain.Address{Line1: "5 Elm St", City: "Berlin"},
+	})
+
+	_, err := service.UpdateDeliveryStatus(context.Background(), ports.UpdateDeliveryStatusRequest{
+		ID: 1, Status: domain.StatusDelivered, AuthContext: ports.AuthContext{Role: "admin"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := repo.OutboxEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 outbox event, got %d", len(events))
+	}
+	var event messaging.Event
+	if err := json.Unmarshal(events[0].Payload, &event); err != nil {
+		t.Fatalf("failed to decode outbox payload: %v", err)
+	}
+	data, err := messaging.DecodeData[messaging.DeliveryStatusChangedEvent](event)
+	if err != nil {
+		t.Fatalf("failed to decode event data: %v", err)
+	}
+	// The drop-off is not geocoded yet, so only the pickup point is known
+	if data.Pickup == nil || data.Pickup.Latitude != 52.52 || data.Dropoff != nil || data.DeliveryZone != "Berlin" {
+		t.Errorf("unexpected route endpoints %+v, %+v in zone %q", data.Pickup, data.Dropoff, data.DeliveryZone)
+	}
+	if data.OldStatus != domain.StatusInTransit || data.NewStatus != domain.StatusDelivered {
+		t.Errorf("expected in_transit -> delivered, got %s -> %s", data.OldStatus, data.NewStatus)
+	}
+}
+
 func TestDeliveryService_UpdateDeliveryStatus(t *testing.T) {
-	mockRepo := NewMockDeliveryRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
+	mockRepo := memory.NewDeliveryRepository()
 	mockGeocodingSvc := &MockGeocodingService{}
 	testLogger := createTestLogger(t)
-	service := NewDeliveryService(mockRepo, mockPublisher, mockDeliveryClient, mockGeocodingSvc, testLogger)
+	service := NewDeliveryService(mockRepo, mockGeocodingSvc, nil, testLogger)
 
 	// Create a test delivery
 	delivery := &domain.Delivery{
@@ -808,13 +925,26 @@ func TestDeliveryService_UpdateDeliveryStatus(t *testing.T) {
 		{
 			name:          "customer update own delivery",
 			id:            1,
-			status:        domain.StatusCancelled,
-			notes:         "Cancel delivery",
+			status:        domain.StatusAssigned,
+			notes:         "Customer update",
 			role:          "customer",
 			customerID:    func() *int { i := 1; return &i }(),
 			courierID:     nil,
 			mockUpdateErr: nil,
-			expectError:   false,
+			expectError:   true,
+			expectedErr:   domain.ErrUnauthorized,
+		},
+		{
+			name:          "cancelling through a status update",
+			id:            1,
+			status:        domain.StatusCancelled,
+			notes:         "Cancel delivery",
+			role:          "courier",
+			customerID:    nil,
+			courierID:     func() *int { i := 2; return &i }(),
+			mockUpdateErr: nil,
+			expectError:   true,
+			expectedErr:   domain.ErrCancelByStatus,
 		},
 		{
 			name:          "courier update assigned delivery",
@@ -827,6 +957,18 @@ func TestDeliveryService_UpdateDeliveryStatus(t *testing.T) {
 			mockUpdateErr: nil,
 			expectError:   false,
 		},
+		{
+			name:          "courier delivering without confirmation",
+			id:            1,
+			status:        domain.StatusDelivered,
+			notes:         "Dropped off",
+			role:          "courier",
+			customerID:    nil,
+			courierID:     func() *int { i := 2; return &i }(),
+			mockUpdateErr: nil,
+			expectError:   true,
+			expectedErr:   domain.ErrDeliverByStatus,
+		},
 		{
 			name:          "customer update other delivery",
 			id:            1,
@@ -880,7 +1022,7 @@ func TestDeliveryService_UpdateDeliveryStatus(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo.SetUpdateError(tt.mockUpdateErr)
 
-			err := service.UpdateDeliveryStatus(context.Background(), ports.UpdateDeliveryStatusRequest{
+			_, err := service.UpdateDeliveryStatus(context.Background(), ports.UpdateDeliveryStatusRequest{
 				ID:     tt.id,
 				Status: tt.status,
 				Notes:  tt.notes,
@@ -916,3 +1058,216 @@ func TestDeliveryService_UpdateDeliveryStatus(t *testing.T) {
 		})
 	}
 }
+
+// MockBlobStore is an in-memory implementation of BlobStore for testing
+type MockBlobStore struct {
+	blobs  map[string][]byte
+	putErr error
+}
+
+func NewMockBlobStore() *MockBlobStore {
+	return &MockBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	if m.putErr != nil {
+		return m.putErr
+	}
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *MockBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("blob %s not found", key)
+	}
+	return data, nil
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	delete(m.blobs, key)
+	return nil
+}
+
+func TestDeliveryService_ConfirmDelivery(t *testing.T) {
+	courierID := 2
+	otherCourierID := 3
+
+	newInTransit := func(id int) *domain.Delivery {
+		return &domain.Delivery{
+			ID:               id,
+			CustomerID:       1,
+			CourierID:        &courierID,
+			Status:           domain.StatusInTransit,
+			PickupLocation:   "123 Main St",
+			DeliveryLocation: "456 Oak Ave",
+			ConfirmationCode: "1234",
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+	}
+
+	t.Run("assigned courier confirms with photo and signature", func(t *testing.T) {
+		mockRepo := memory.NewDeliveryRepository()
+		blobs := NewMockBlobStore()
+		service := NewDeliveryService(mockRepo, &MockGeocodingService{}, blobs, createTestLogger(t))
+		mockRepo.AddDelivery(newInTransit(1))
+
+		confirmation, err := service.ConfirmDelivery(context.Background(), ports.ConfirmDeliveryRequest{
+			ID:            1,
+			Photo:         []byte("photo-bytes"),
+			Signature:     []byte("signature-bytes"),
+			RecipientName: "Jane Doe",
+			AuthContext:   ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if confirmation.PhotoKey == "" || confirmation.SignatureKey == "" {
+			t.Errorf("expected photo and signature keys, got %+v", confirmation)
+		}
+		if string(blobs.blobs[confirmation.PhotoKey]) != "photo-bytes" {
+			t.Error("expected photo to be stored in the blob store")
+		}
+		if string(blobs.blobs[confirmation.SignatureKey]) != "signature-bytes" {
+			t.Error("expected signature to be stored in the blob store")
+		}
+
+		delivered, _ := mockRepo.GetByID(context.Background(), 1)
+		if delivered.Status != domain.StatusDelivered {
+			t.Errorf("expected status %s, got %s", domain.StatusDelivered, delivered.Status)
+		}
+		if delivered.DeliveredDate == nil {
+			t.Error("expected delivered date to be set")
+		}
+
+		events := mockRepo.OutboxEvents()
+		if len(events) != 1 || events[0].RoutingKey != "delivery.confirmed" {
+			t.Errorf("expected a delivery.confirmed outbox event, got %+v", events)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		status      string
+		req         ports.ConfirmDeliveryRequest
+		expectedErr error
+	}{
+		{
+			name:   "other courier",
+			status: domain.StatusInTransit,
+			req: ports.ConfirmDeliveryRequest{
+				ConfirmationCode: "1234",
+				AuthContext:      ports.AuthContext{Role: "courier", UserCourierID: &otherCourierID},
+			},
+			expectedErr: domain.ErrUnauthorized,
+		},
+		{
+			name:   "customer cannot confirm",
+			status: domain.StatusInTransit,
+			req: ports.ConfirmDeliveryRequest{
+				ConfirmationCode: "1234",
+				AuthContext:      ports.AuthContext{Role: "customer", UserCustomerID: func() *int { i := 1; return &i }()},
+			},
+			expectedErr: domain.ErrUnauthorized,
+		},
+		{
+			name:   "not in transit",
+			status: domain.StatusAssigned,
+			req: ports.ConfirmDeliveryRequest{
+				ConfirmationCode: "1234",
+				AuthContext:      ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+			},
+			expectedErr: domain.ErrNotInTransit,
+		},
+		{
+			name:   "wrong confirmation code",
+			status: domain.StatusInTransit,
+			req: ports.ConfirmDeliveryRequest{
+				ConfirmationCode: "9999",
+				AuthContext:      ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+			},
+			expectedErr: domain.ErrWrongConfirmationCode,
+		},
+		{
+			name:   "missing proof",
+			status: domain.StatusInTransit,
+			req: ports.ConfirmDeliveryRequest{
+				RecipientName: "Jane Doe",
+				AuthContext:   ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+			},
+			expectedErr: domain.ErrInvalidConfirmation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := memory.NewDeliveryRepository()
+			blobs := NewMockBlobStore()
+			service := NewDeliveryService(mockRepo, &MockGeocodingService{}, blobs, createTestLogger(t))
+
+			d := newInTransit(1)
+			d.Status = tt.status
+			mockRepo.AddDelivery(d)
+
+			tt.req.ID = 1
+			_, err := service.ConfirmDelivery(context.Background(), tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if len(mockRepo.OutboxEvents()) != 0 {
+				t.Error("expected no outbox events on failure")
+			}
+			if d.Status != tt.status {
+				t.Errorf("expected status to remain %s, got %s", tt.status, d.Status)
+			}
+		})
+	}
+
+	t.Run("stored blobs are removed when persistence fails", func(t *testing.T) {
+		mockRepo := memory.NewDeliveryRepository()
+		blobs := NewMockBlobStore()
+		service := NewDeliveryService(mockRepo, &MockGeocodingService{}, blobs, createTestLogger(t))
+		mockRepo.AddDelivery(newInTransit(1))
+		mockRepo.SetUpdateError(errors.New("database unavailable"))
+
+		_, err := service.ConfirmDelivery(context.Background(), ports.ConfirmDeliveryRequest{
+			ID:          1,
+			Photo:       []byte("photo-bytes"),
+			AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+		})
+		if err == nil {
+			t.Fatal("expected error when persistence fails")
+		}
+		if len(blobs.blobs) != 0 {
+			t.Errorf("expected orphaned blobs to be removed, found %d", len(blobs.blobs))
+		}
+	})
+}
+
+func TestDeliveryService_GetConfirmationCode(t *testing.T) {
+	mockRepo := memory.NewDeliveryRepository()
+	service := NewDeliveryService(mockRepo, &MockGeocodingService{}, NewMockBlobStore(), createTestLogger(t))
+	customerID := 1
+	otherCustomerID := 2
+	courierID := 3
+	mockRepo.AddDelivery(&domain.Delivery{ID: 1, CustomerID: customerID, CourierID: &courierID, Status: domain.StatusInTransit, ConfirmationCode: "123456"})
+
+	code, err := service.GetConfirmationCode(context.Background(), ports.GetDeliveryRequest{
+		ID: 1, AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+	})
+	if err != nil || code != "123456" {
+		t.Errorf("expected the customer to get the code, got %q, %v", code, err)
+	}
+
+	for name, auth := range map[string]ports.AuthContext{
+		"assigned courier": {Role: "courier", UserCourierID: &courierID},
+		"other customer":   {Role: "customer", UserCustomerID: &otherCustomerID},
+	} {
+		if _, err := service.GetConfirmationCode(context.Background(), ports.GetDeliveryRequest{ID: 1, AuthContext: auth}); !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("%s: expected ErrUnauthorized, got %v", name, err)
+		}
+	}
+}
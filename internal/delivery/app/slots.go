@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"go.uber.org/zap"
+)
+
+// auditActionSlotCapacity is the audited action of a change to a zone's slots
+const auditActionSlotCapacity = "slot_capacity.update"
+
+// slotDateLayout is how slot availability requests give their day
+const slotDateLayout = "2006-01-02"
+
+// SetSlots limits scheduled deliveries to the capacity of the slots offered
+// in their drop-off zone. Zones are looked up through zones, which also
+// serves quotes, and slot windows are in location, UTC if nil.
+func (s *DeliveryService) SetSlots(capacities ports.SlotCapacityRepository, zones ports.ZoneLocator, location *time.Location) {
+	if location == nil {
+		location = time.UTC
+	}
+	s.slots = capacities
+	s.zones = zones
+	s.slotLocation = location
+}
+
+// GetSlotAvailability lists the slots a zone offers the caller's organization
+// on a day, each with its capacity less the organization's open deliveries
+// already scheduled in it. Slots that have started have nothing available.
+func (s *DeliveryService) GetSlotAvailability(ctx context.Context, req ports.SlotAvailabilityRequest) (*domain.SlotAvailability, error) {
+	if s.slots == nil {
+		return nil, domain.ErrSlotsUnavailable
+	}
+	zone := strings.TrimSpace(req.Zone)
+	if zone == "" {
+		return nil, fmt.Errorf("%w: zone is required", domain.ErrInvalidSlotRequest)
+	}
+	day, err := time.ParseInLocation(slotDateLayout, req.Date, s.slotLocation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", domain.ErrInvalidSlotRequest)
+	}
+
+	orgID := authctx.Organization(ctx)
+	capacities, err := s.slots.ListCapacities(ctx, orgID, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load slot capacities: %w", err)
+	}
+
+	now := time.Now()
+	slots := make([]domain.Slot, 0, len(capacities))
+	for _, c := range capacities {
+		start, end := c.Window(day)
+		booked, err := s.repo.CountBooked(ctx, orgID, zone, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count slot bookings: %w", err)
+		}
+
+		available := max(c.Capacity-booked, 0)
+		if !start.After(now) {
+			available = 0
+		}
+		slots = append(slots, domain.Slot{
+			Start:     start,
+			End:       end,
+			Capacity:  c.Capacity,
+			Booked:    booked,
+			Available: available,
+		})
+	}
+
+	return &domain.SlotAvailability{Date: day.Format(slotDateLayout), Zone: zone, Slots: slots}, nil
+}
+
+// slotOrganization resolves the organization whose slot capacities a caller
+// manages: its own, unless it is not limited to one and names another
+func slotOrganization(ctx context.Context, orgID int) (int, error) {
+	if orgID <= 0 {
+		return authctx.Organization(ctx), nil
+	}
+	if _, scoped := authctx.OrgScope(ctx); scoped {
+		return 0, domain.ErrUnauthorized
+	}
+	return orgID, nil
+}
+
+// GetSlotCapacities retrieves the slots a zone offers an organization and
+// their capacity; admins only. orgID 0 is the caller's organization.
+func (s *DeliveryService) GetSlotCapacities(ctx context.Context, orgID int, zone string, auth ports.AuthContext) ([]domain.SlotCapacity, error) {
+	if auth.Role != "admin" && auth.Role != "super_admin" {
+		return nil, domain.ErrUnauthorized
+	}
+	orgID, err := slotOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if s.slots == nil {
+		return nil, domain.ErrSlotsUnavailable
+	}
+	zone = strings.TrimSpace(zone)
+	if zone == "" {
+		return nil, fmt.Errorf("%w: zone is required", domain.ErrInvalidSlotRequest)
+	}
+	return s.slots.ListCapacities(ctx, orgID, zone)
+}
+
+// UpdateSlotCapacities replaces the slots a zone offers an organization and
+// their capacity; admins only. Deliveries already booked keep their slot even if it shrinks
+// below them.
+func (s *DeliveryService) UpdateSlotCapacities(ctx context.Context, req ports.UpdateSlotCapacitiesRequest) ([]domain.SlotCapacity, error) {
+	if req.Role != "admin" && req.Role != "super_admin" {
+		return nil, domain.ErrUnauthorized
+	}
+	orgID, err := slotOrganization(ctx, req.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if s.slots == nil {
+		return nil, domain.ErrSlotsUnavailable
+	}
+	zone := strings.TrimSpace(req.Zone)
+	if zone == "" {
+		return nil, fmt.Errorf("%w: zone is required", domain.ErrInvalidSlotRequest)
+	}
+	capacities := append([]domain.SlotCapacity{}, req.Slots...)
+	if err := domain.ValidateSlotCapacities(capacities); err != nil {
+		return nil, err
+	}
+
+	before, err := s.slots.ListCapacities(ctx, orgID, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load slot capacities: %w", err)
+	}
+	if err := s.slots.ReplaceCapacities(ctx, orgID, zone, capacities); err != nil {
+		return nil, fmt.Errorf("failed to store slot capacities: %w", err)
+	}
+	s.audit.Record(ctx, orgID, auditActionSlotCapacity, audit.EntitySlotCapacity, zone, before, capacities)
+
+	s.logger.InfoWithFields(ctx, "Slot capacities updated",
+		zap.Int("org_id", orgID),
+		zap.String("zone", zone),
+		zap.Int("slots", len(capacities)))
+
+	return capacities, nil
+}
+
+// bookingSlot returns the slot a scheduled delivery must be booked into: the
+// one containing its scheduled start in the first of its drop-off's zones
+// where its organization is offered slots. It is nil for unscheduled deliveries and drop-offs
+// outside every such zone, which are not limited. A zone that offers no slot
+// at the scheduled start has no capacity for it.
+func (s *DeliveryService) bookingSlot(ctx context.Context, delivery *domain.Delivery) (*domain.SlotBooking, error) {
+	if s.slots == nil || delivery.ScheduledDate == nil {
+		return nil, nil
+	}
+	dropoff, ok := delivery.DeliveryCoordinates()
+	if !ok {
+		s.logger.WarnWithFields(ctx, "Scheduled delivery has no drop-off coordinates, creating it without a slot")
+		return nil, nil
+	}
+
+	zones, err := s.zones.ZonesAt(ctx, *dropoff)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to look up delivery zones: %v", domain.ErrSlotsUnavailable, err)
+	}
+	orgID := delivery.OrgID
+	if orgID == 0 {
+		orgID = authctx.Organization(ctx)
+	}
+	for _, zone := range zones {
+		capacities, err := s.slots.ListCapacities(ctx, orgID, zone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to load slot capacities: %v", domain.ErrSlotsUnavailable, err)
+		}
+		if len(capacities) == 0 {
+			continue
+		}
+
+		slot, ok := domain.FindSlot(zone, capacities, *delivery.ScheduledDate, s.slotLocation)
+		if !ok {
+			return nil, fmt.Errorf("%w: zone %s offers no slot at %s", domain.ErrSlotFull, zone,
+				delivery.ScheduledDate.In(s.slotLocation).Format(time.RFC3339))
+		}
+		slot.OrgID = orgID
+		return &slot, nil
+	}
+	return nil, nil
+}
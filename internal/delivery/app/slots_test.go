@@ -0,0 +1,314 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// slotDropoff is in the downtown zone of newSlotService
+const slotDropoff = "(-73.935200,40.730600)"
+
+// newSlotService returns a service whose downtown zone offers 08:00 and 10:00
+// slots for capacity and one delivery
+func newSlotService(t *testing.T, capacity int) (*DeliveryService, *memory.DeliveryRepository, *MockZoneLocator) {
+	repo := memory.NewDeliveryRepository()
+	capacities := memory.NewSlotCapacityRepository()
+	capacities.ReplaceCapacities(context.Background(), 1, "downtown", []domain.SlotCapacity{
+		{StartHour: 8, Capacity: capacity},
+		{StartHour: 10, Capacity: 1},
+	})
+	zones := &MockZoneLocator{zones: map[domain.Coordinates][]string{
+		{Latitude: 40.7306, Longitude: -73.9352}: {"downtown"},
+	}}
+	service := NewDeliveryService(repo, &MockGeocodingService{}, nil, createTestLogger(t))
+	service.SetSlots(capacities, zones, time.UTC)
+	return service, repo, zones
+}
+
+// tomorrowAt formats the given time of tomorrow, in UTC, as RFC3339
+func tomorrowAt(hour, minute int) *string {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+	s := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), hour, minute, 0, 0, time.UTC).Format(time.RFC3339)
+	return &s
+}
+
+func createScheduled(service *DeliveryService, dropoff string, scheduled *string) (*domain.Delivery, error) {
+	return service.CreateDelivery(context.Background(), ports.CreateDeliveryRequest{
+		CustomerID:       1,
+		PickupLocation:   "123 Main St",
+		DeliveryLocation: dropoff,
+		ScheduledDate:    scheduled,
+	})
+}
+
+func TestDeliveryService_GetSlotAvailability(t *testing.T) {
+	service, _, _ := newSlotService(t, 2)
+	ctx := context.Background()
+
+	first, err := createScheduled(service, slotDropoff, tomorrowAt(8, 0))
+	if err != nil {
+		t.Fatalf("failed to book: %v", err)
+	}
+	if _, err := createScheduled(service, slotDropoff, tomorrowAt(9, 30)); err != nil {
+		t.Fatalf("failed to book: %v", err)
+	}
+	// Unscheduled deliveries and cancelled bookings take no capacity
+	if _, err := createScheduled(service, slotDropoff, nil); err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+	if _, err := service.CancelDelivery(ctx, ports.CancelDeliveryRequest{
+		ID: first.ID, Reason: "changed plans", AuthContext: ports.AuthContext{Role: "admin"},
+	}); err != nil {
+		t.Fatalf("failed to cancel: %v", err)
+	}
+
+	date := (*tomorrowAt(0, 0))[:10]
+	availability, err := service.GetSlotAvailability(ctx, ports.SlotAvailabilityRequest{Date: date, Zone: "downtown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if availability.Date != date || availability.Zone != "downtown" || len(availability.Slots) != 2 {
+		t.Fatalf("expected the zone's two slots that day, got %+v", availability)
+	}
+	morning := availability.Slots[0]
+	if morning.Start.Format(time.RFC3339) != *tomorrowAt(8, 0) || morning.End.Format(time.RFC3339) != *tomorrowAt(10, 0) {
+		t.Errorf("expected the 08:00-10:00 slot first, got %v-%v", morning.Start, morning.End)
+	}
+	if morning.Capacity != 2 || morning.Booked != 1 || morning.Available != 1 {
+		t.Errorf("expected one of two booked, got %+v", morning)
+	}
+	if later := availability.Slots[1]; later.Booked != 0 || later.Available != 1 {
+		t.Errorf("expected the 10:00 slot free, got %+v", later)
+	}
+
+	t.Run("started slots", func(t *testing.T) {
+		today := time.Now().UTC().Format(slotDateLayout)
+		service.UpdateSlotCapacities(ctx, ports.UpdateSlotCapacitiesRequest{
+			Zone: "uptown", Slots: []domain.SlotCapacity{{StartHour: 0, Capacity: 5}}, AuthContext: ports.AuthContext{Role: "admin"},
+		})
+		availability, err := service.GetSlotAvailability(ctx, ports.SlotAvailabilityRequest{Date: today, Zone: "uptown"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(availability.Slots) != 1 || availability.Slots[0].Available != 0 {
+			t.Errorf("expected a started slot to have nothing available, got %+v", availability.Slots)
+		}
+	})
+
+	t.Run("zone without slots", func(t *testing.T) {
+		availability, err := service.GetSlotAvailability(ctx, ports.SlotAvailabilityRequest{Date: date, Zone: "airport"})
+		if err != nil || len(availability.Slots) != 0 {
+			t.Errorf("expected no slots, got %+v, %v", availability, err)
+		}
+	})
+}
+
+func TestDeliveryService_GetSlotAvailability_Errors(t *testing.T) {
+	service, _, _ := newSlotService(t, 2)
+
+	tests := []struct {
+		name    string
+		service *DeliveryService
+		req     ports.SlotAvailabilityRequest
+		wantErr error
+	}{
+		{name: "slots not configured", service: NewDeliveryService(memory.NewDeliveryRepository(), nil, nil, createTestLogger(t)),
+			req: ports.SlotAvailabilityRequest{Date: "2026-10-14", Zone: "downtown"}, wantErr: domain.ErrSlotsUnavailable},
+		{name: "missing zone", service: service, req: ports.SlotAvailabilityRequest{Date: "2026-10-14"}, wantErr: domain.ErrInvalidSlotRequest},
+		{name: "bad date", service: service, req: ports.SlotAvailabilityRequest{Date: "14/10/2026", Zone: "downtown"}, wantErr: domain.ErrInvalidSlotRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.service.GetSlotAvailability(context.Background(), tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDeliveryService_CreateDelivery_Slots(t *testing.T) {
+	tests := []struct {
+		name      string
+		dropoff   string
+		scheduled *string
+		zoneErr   error
+		wantErr   error
+	}{
+		{name: "slot full", dropoff: slotDropoff, scheduled: tomorrowAt(10, 15), wantErr: domain.ErrSlotFull},
+		{name: "no slot at the scheduled start", dropoff: slotDropoff, scheduled: tomorrowAt(14, 0), wantErr: domain.ErrSlotFull},
+		{name: "slot with room", dropoff: slotDropoff, scheduled: tomorrowAt(8, 0)},
+		{name: "zone without slots", dropoff: "(-73.985700,40.748400)", scheduled: tomorrowAt(14, 0)},
+		{name: "zone lookup fails", dropoff: slotDropoff, scheduled: tomorrowAt(8, 0), zoneErr: errors.New("tracking unavailable"), wantErr: domain.ErrSlotsUnavailable},
+		{name: "unscheduled", dropoff: slotDropoff, zoneErr: errors.New("tracking unavailable")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, zones := newSlotService(t, 2)
+			if _, err := createScheduled(service, slotDropoff, tomorrowAt(10, 0)); err != nil {
+				t.Fatalf("failed to fill the 10:00 slot: %v", err)
+			}
+			zones.err = tt.zoneErr
+
+			_, err := createScheduled(service, tt.dropoff, tt.scheduled)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			wantStored := 2
+			if tt.wantErr != nil {
+				wantStored = 1
+			}
+			if events := repo.OutboxEvents(); len(events) != wantStored {
+				t.Errorf("expected %d deliveries created, got %d", wantStored, len(events))
+			}
+		})
+	}
+}
+
+func TestDeliveryService_CreateDelivery_SlotConcurrency(t *testing.T) {
+	service, repo, _ := newSlotService(t, 3)
+	scheduled := tomorrowAt(8, 30)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := createScheduled(service, slotDropoff, scheduled)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, domain.ErrSlotFull):
+			t.Errorf("expected ErrSlotFull, got %v", err)
+		}
+	}
+	if created != 3 || len(repo.OutboxEvents()) != 3 {
+		t.Errorf("expected exactly the slot's capacity of 3 created, got %d", created)
+	}
+}
+
+func TestDeliveryService_UpdateSlotCapacities(t *testing.T) {
+	service, _, _ := newSlotService(t, 2)
+	store := &memoryAuditStore{}
+	writer := audit.NewWriter(store, 10, createTestLogger(t))
+	service.SetAuditWriter(writer)
+	ctx := context.Background()
+	admin := ports.AuthContext{Role: "admin"}
+
+	if _, err := service.UpdateSlotCapacities(ctx, ports.UpdateSlotCapacitiesRequest{
+		Zone: "downtown", Slots: []domain.SlotCapacity{{StartHour: 8, Capacity: 9}}, AuthContext: ports.AuthContext{Role: "customer"},
+	}); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for customers, got %v", err)
+	}
+	if _, err := service.UpdateSlotCapacities(ctx, ports.UpdateSlotCapacitiesRequest{
+		Zone: "downtown", Slots: []domain.SlotCapacity{{StartHour: 8, Capacity: 9}, {StartHour: 9, Capacity: 1}}, AuthContext: admin,
+	}); !errors.Is(err, domain.ErrInvalidSlotCapacity) {
+		t.Errorf("expected ErrInvalidSlotCapacity for overlapping slots, got %v", err)
+	}
+
+	updated, err := service.UpdateSlotCapacities(ctx, ports.UpdateSlotCapacitiesRequest{
+		Zone: "downtown", Slots: []domain.SlotCapacity{{StartHour: 16, Capacity: 4}, {StartHour: 14, Capacity: 6}}, AuthContext: admin,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, err := service.GetSlotCapacities(ctx, 0, "downtown", admin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.SlotCapacity{{StartHour: 14, Capacity: 6}, {StartHour: 16, Capacity: 4}}
+	if len(updated) != 2 || len(stored) != 2 || stored[0] != want[0] || stored[1] != want[1] {
+		t.Errorf("expected %+v stored, got %+v", want, stored)
+	}
+	if _, err := service.GetSlotCapacities(ctx, 0, "downtown", ports.AuthContext{Role: "courier"}); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for couriers, got %v", err)
+	}
+
+	// The new slots take bookings and the old ones are gone
+	if _, err := createScheduled(service, slotDropoff, tomorrowAt(14, 0)); err != nil {
+		t.Errorf("expected the new slot to take bookings, got %v", err)
+	}
+	if _, err := createScheduled(service, slotDropoff, tomorrowAt(8, 0)); !errors.Is(err, domain.ErrSlotFull) {
+		t.Errorf("expected the removed slot to take none, got %v", err)
+	}
+
+	writer.Close()
+	var changes []*audit.Entry
+	for _, entry := range store.entries {
+		if entry.EntityType == audit.EntitySlotCapacity {
+			changes = append(changes, entry)
+		}
+	}
+	if len(changes) != 1 {
+		t.Fatalf("expected the change to be audited once, got %d entries", len(changes))
+	}
+	entry := changes[0]
+	if entry.Action != auditActionSlotCapacity || entry.EntityID != "downtown" {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+	var before, after []domain.SlotCapacity
+	json.Unmarshal(entry.Before, &before)
+	json.Unmarshal(entry.After, &after)
+	if len(before) != 2 || before[0].StartHour != 8 || len(after) != 2 || after[0] != want[0] {
+		t.Errorf("expected the old and new slots in the entry, got %s and %s", entry.Before, entry.After)
+	}
+}
+
+func TestDeliveryService_SlotCapacitiesPerOrganization(t *testing.T) {
+	service, _, _ := newSlotService(t, 2)
+	orgAdmin := ports.AuthContext{Role: "admin"}
+	otherOrg := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 2})
+
+	// Another organization sees none of the default one's slots and may not
+	// ask for them
+	stored, err := service.GetSlotCapacities(otherOrg, 0, "downtown", orgAdmin)
+	if err != nil || len(stored) != 0 {
+		t.Errorf("expected no slots for another organization, got %+v, %v", stored, err)
+	}
+	if _, err := service.GetSlotCapacities(otherOrg, authDomain.DefaultOrgID, "downtown", orgAdmin); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized picking another organization, got %v", err)
+	}
+	if _, err := service.UpdateSlotCapacities(otherOrg, ports.UpdateSlotCapacitiesRequest{
+		OrgID: authDomain.DefaultOrgID, Zone: "downtown", Slots: []domain.SlotCapacity{{StartHour: 8, Capacity: 9}}, AuthContext: orgAdmin,
+	}); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized changing another organization's slots, got %v", err)
+	}
+
+	// Its own slots leave the default organization's alone
+	if _, err := service.UpdateSlotCapacities(otherOrg, ports.UpdateSlotCapacitiesRequest{
+		Zone: "downtown", Slots: []domain.SlotCapacity{{StartHour: 14, Capacity: 1}}, AuthContext: orgAdmin,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	superAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "super_admin"})
+	stored, err = service.GetSlotCapacities(superAdmin, authDomain.DefaultOrgID, "downtown", ports.AuthContext{Role: "super_admin"})
+	if err != nil || len(stored) != 2 || stored[0].StartHour != 8 {
+		t.Errorf("expected the default organization's slots unchanged, got %+v, %v", stored, err)
+	}
+	stored, err = service.GetSlotCapacities(superAdmin, 2, "downtown", ports.AuthContext{Role: "super_admin"})
+	if err != nil || len(stored) != 1 || stored[0].StartHour != 14 {
+		t.Errorf("expected super-admins to read another organization's slots, got %+v, %v", stored, err)
+	}
+}
@@ -0,0 +1,207 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// Limits on listing a subscription's deliveries
+const (
+	defaultWebhookDeliveriesLimit = 50
+	maxWebhookDeliveriesLimit     = 200
+)
+
+// WebhookService implements the webhook subscription use cases and queues
+// delivery events for the subscriptions that match them
+type WebhookService struct {
+	repo   ports.WebhookRepository
+	logger *logger.Logger
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo ports.WebhookRepository, logger *logger.Logger) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// webhookPayload is the JSON body POSTed to subscriptions
+type webhookPayload struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Timestamp int64                  `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// CreateWebhook registers a subscription for the calling customer
+func (s *WebhookService) CreateWebhook(ctx context.Context, req ports.CreateWebhookRequest) (*domain.WebhookSubscription, error) {
+	if req.Role != "customer" || req.UserCustomerID == nil {
+		return nil, domain.ErrUnauthorized
+	}
+
+	sub, err := domain.NewWebhookSubscription(*req.UserCustomerID, req.URL, req.Secret, req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	sub.OrgID = authctx.Organization(ctx)
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithFields(ctx, "Webhook subscription created",
+		zap.Int64("webhook_id", sub.ID),
+		zap.Int("customer_id", sub.CustomerID))
+
+	return sub, nil
+}
+
+// ListWebhooks lists the calling customer's subscriptions
+func (s *WebhookService) ListWebhooks(ctx context.Context, auth ports.AuthContext) ([]*domain.WebhookSubscription, error) {
+	if auth.Role != "customer" || auth.UserCustomerID == nil {
+		return nil, domain.ErrUnauthorized
+	}
+	return s.repo.ListSubscriptions(ctx, *auth.UserCustomerID)
+}
+
+// GetWebhook retrieves a subscription the caller owns; admins may read any
+// of their organization's
+func (s *WebhookService) GetWebhook(ctx context.Context, req ports.WebhookRequest) (*domain.WebhookSubscription, error) {
+	return s.authorizedSubscription(ctx, req.ID, req.AuthContext)
+}
+
+// UpdateWebhook changes the fields set in the request on a subscription the caller owns
+func (s *WebhookService) UpdateWebhook(ctx context.Context, req ports.UpdateWebhookRequest) (*domain.WebhookSubscription, error) {
+	sub, err := s.authorizedSubscription(ctx, req.ID, req.AuthContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		sub.URL = *req.URL
+	}
+	if req.Secret != nil {
+		sub.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		sub.EventTypes = *req.EventTypes
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if err := sub.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// DeleteWebhook removes a subscription the caller owns, with its deliveries
+func (s *WebhookService) DeleteWebhook(ctx context.Context, req ports.WebhookRequest) error {
+	if _, err := s.authorizedSubscription(ctx, req.ID, req.AuthContext); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, req.ID)
+}
+
+// ListWebhookDeliveries lists a subscription's most recent deliveries and
+// their attempts, for debugging an endpoint
+func (s *WebhookService) ListWebhookDeliveries(ctx context.Context, req ports.ListWebhookDeliveriesRequest) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.authorizedSubscription(ctx, req.ID, req.AuthContext); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultWebhookDeliveriesLimit
+	}
+	if limit > maxWebhookDeliveriesLimit {
+		limit = maxWebhookDeliveriesLimit
+	}
+	return s.repo.ListDeliveries(ctx, req.ID, limit)
+}
+
+// authorizedSubscription fetches a subscription owned by the calling
+// customer, or any of their organization's for admins and any at all for
+// super-admins
+func (s *WebhookService) authorizedSubscription(ctx context.Context, id int64, auth ports.AuthContext) (*domain.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, scoped := authctx.OrgScope(ctx)
+	switch {
+	case auth.Role == "super_admin":
+	case auth.Role == "admin" && (!scoped || sub.OrgID == orgID):
+	case auth.Role == "customer" && auth.UserCustomerID != nil && *auth.UserCustomerID == sub.CustomerID:
+	default:
+		return nil, domain.ErrUnauthorized
+	}
+	return sub, nil
+}
+
+// Enqueue queues a delivery event for each of its customer's subscriptions
+// that match its type. Queuing is idempotent per event, so an event handed
+// over again after a failure is not sent twice.
+func (s *WebhookService) Enqueue(ctx context.Context, event messaging.Event) error {
+	customerID, ok := eventCustomerID(event)
+	if !ok {
+		return nil
+	}
+
+	subs, err := s.repo.ListSubscriptions(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	var deliveries []*domain.WebhookDelivery
+	var payload []byte
+	for _, sub := range subs {
+		if !sub.Matches(event.Type) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(webhookPayload{
+				ID:        event.ID,
+				Type:      event.Type,
+				Timestamp: event.Timestamp,
+				Data:      event.Data,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to marshal webhook payload: %w", err)
+			}
+		}
+		deliveries = append(deliveries, domain.NewWebhookDelivery(sub.ID, event.ID, event.Type, payload))
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := s.repo.EnqueueDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// eventCustomerID returns the customer a delivery event belongs to
+func eventCustomerID(event messaging.Event) (int, bool) {
+	switch v := event.Data["customer_id"].(type) {
+	case float64:
+		return int(v), v > 0
+	case int:
+		return v, v > 0
+	}
+	return 0, false
+}
@@ -0,0 +1,293 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/httpclient"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Headers set on webhook requests
+const (
+	WebhookSignatureHeader = "X-DeliverTrack-Signature"
+	WebhookEventHeader     = "X-DeliverTrack-Event"
+	WebhookDeliveryHeader  = "X-DeliverTrack-Delivery"
+)
+
+// WebhookDispatcherConfig holds webhook dispatcher configuration
+type WebhookDispatcherConfig struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	MaxAttempts    int
+	RequestTimeout time.Duration
+	InitialDelay   time.Duration
+	MaxDelay       time.Duration
+	Multiplier     float64
+
+	// AllowPrivateNetworks lets webhooks reach loopback, private and other
+	// non-public addresses, for local development only
+	AllowPrivateNetworks bool
+}
+
+// DefaultWebhookDispatcherConfig returns a default webhook dispatcher configuration
+func DefaultWebhookDispatcherConfig() WebhookDispatcherConfig {
+	return WebhookDispatcherConfig{
+		PollInterval:   time.Second,
+		BatchSize:      50,
+		MaxAttempts:    8,
+		RequestTimeout: 10 * time.Second,
+		InitialDelay:   10 * time.Second,
+		MaxDelay:       time.Hour,
+		Multiplier:     2.0,
+	}
+}
+
+// WebhookMetrics is a snapshot of webhook dispatcher counters
+type WebhookMetrics struct {
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	Dead      int64 `json:"dead"`
+}
+
+// WebhookDispatcher POSTs queued webhook deliveries to their subscriptions,
+// signing each body with the subscription's secret
+type WebhookDispatcher struct {
+	repo   ports.WebhookRepository
+	client *httpclient.Client
+	config WebhookDispatcherConfig
+	logger *logger.Logger
+
+	mu        sync.Mutex
+	succeeded int64
+	failed    int64
+	dead      int64
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher
+func NewWebhookDispatcher(repo ports.WebhookRepository, config WebhookDispatcherConfig, logger *logger.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo:   repo,
+		client: newWebhookHTTPClient(config, logger),
+		config: config,
+		logger: logger,
+	}
+}
+
+// errNonPublicAddress is returned when a webhook URL resolves to an address
+// the dispatcher must not reach
+var errNonPublicAddress = errors.New("webhook address is not public")
+
+// newWebhookHTTPClient creates the client webhooks are POSTed with. Merchants
+// choose the URLs, so unless private networks are allowed every connection's
+// resolved address is checked as it is dialled, which also covers DNS
+// rebinding, and redirects are returned rather than followed. Failed
+// attempts are retried by the dispatcher's own backoff, not the client.
+func newWebhookHTTPClient(config WebhookDispatcherConfig, logger *logger.Logger) *httpclient.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !domain.IsPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errNonPublicAddress, address)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // a proxy would be dialled instead of the merchant
+	transport.DialContext = dialer.DialContext
+
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return httpclient.New(client, httpclient.Config{Timeout: config.RequestTimeout}, logger)
+}
+
+// Run polls for due deliveries until the context is cancelled
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+			d.logger.ErrorWithFields(ctx, "Failed to dispatch webhook deliveries", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue attempts one batch of due deliveries and returns how many succeeded
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	// The batch is claimed for long enough to attempt every delivery in it
+	lease := time.Duration(d.config.BatchSize) * d.config.RequestTimeout
+	deliveries, err := d.repo.FetchDueDeliveries(ctx, d.config.BatchSize, lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch due webhook deliveries: %w", err)
+	}
+
+	// Subscriptions are looked up once per batch
+	subs := make(map[int64]*domain.WebhookSubscription)
+	succeeded := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return succeeded, ctx.Err()
+		}
+
+		sub, ok := subs[delivery.SubscriptionID]
+		if !ok {
+			sub, err = d.repo.GetSubscription(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, domain.ErrWebhookNotFound) {
+				d.logger.ErrorWithFields(ctx, "Failed to load webhook subscription",
+					zap.Int64("webhook_id", delivery.SubscriptionID), zap.Error(err))
+				continue
+			}
+			subs[delivery.SubscriptionID] = sub
+		}
+
+		if d.attempt(ctx, sub, delivery) {
+			succeeded++
+		}
+	}
+
+	return succeeded, nil
+}
+
+// attempt POSTs a delivery once and records the outcome, reporting whether it succeeded
+func (d *WebhookDispatcher) attempt(ctx context.Context, sub *domain.WebhookSubscription, delivery *domain.WebhookDelivery) bool {
+	var attempt domain.WebhookAttempt
+	retryable := false
+	switch {
+	case sub == nil || !sub.Active:
+		// Deliveries of removed or paused subscriptions are given up on
+		attempt = domain.WebhookAttempt{Error: "subscription inactive", AttemptedAt: time.Now()}
+	default:
+		attempt, retryable = d.post(ctx, sub, delivery)
+	}
+
+	delivery.RecordAttempt(attempt, retryable, d.config.MaxAttempts, d.backoff(delivery.AttemptCount+1))
+	succeeded := delivery.Status == domain.WebhookDeliverySucceeded
+
+	d.mu.Lock()
+	switch {
+	case succeeded:
+		d.succeeded++
+	case delivery.IsDead():
+		d.failed++
+		d.dead++
+	default:
+		d.failed++
+	}
+	d.mu.Unlock()
+
+	fields := []zap.Field{
+		zap.Int64("webhook_id", delivery.SubscriptionID),
+		zap.Int64("webhook_delivery_id", delivery.ID),
+		zap.String("event_type", delivery.EventType),
+		zap.Int("attempts", delivery.AttemptCount),
+		zap.Int("status_code", attempt.StatusCode),
+		zap.String("error", attempt.Error),
+	}
+	switch {
+	case delivery.IsDead():
+		d.logger.ErrorWithFields(ctx, "Webhook delivery gave up", fields...)
+	case !succeeded:
+		d.logger.WarnWithFields(ctx, "Webhook delivery failed, will retry",
+			append(fields, zap.Time("next_attempt_at", delivery.NextAttemptAt))...)
+	}
+
+	if err := d.repo.SaveAttempt(ctx, delivery, attempt); err != nil {
+		d.logger.ErrorWithFields(ctx, "Failed to record webhook attempt",
+			zap.Int64("webhook_delivery_id", delivery.ID), zap.Error(err))
+	}
+	return succeeded
+}
+
+// post sends a delivery's payload to the subscription's URL. Timeouts,
+// connection failures, 408, 429 and 5xx responses are retryable; other
+// non-2xx responses, redirects included, and non-public addresses are not.
+func (d *WebhookDispatcher) post(ctx context.Context, sub *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (domain.WebhookAttempt, bool) {
+	attempt := domain.WebhookAttempt{AttemptedAt: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(sub.Secret, delivery.Payload))
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = time.Since(attempt.AttemptedAt).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, !errors.Is(err, errNonPublicAddress)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = resp.Status
+	}
+	return attempt, retryableWebhookStatus(resp.StatusCode)
+}
+
+// retryableWebhookStatus reports whether a response asks to be tried again later
+func retryableWebhookStatus(code int) bool {
+	return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+// SignWebhookPayload returns the signature header value for a payload: the
+// hex HMAC-SHA256 of the body keyed with the subscription's secret
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the delay before the given attempt using exponential backoff
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.InitialDelay
+	for i := 1; i < attempt; i++ {
+		delay = time.Duration(float64(delay) * d.config.Multiplier)
+		if delay > d.config.MaxDelay {
+			return d.config.MaxDelay
+		}
+	}
+	return delay
+}
+
+// Metrics returns the dispatcher's counters
+func (d *WebhookDispatcher) Metrics() WebhookMetrics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return WebhookMetrics{Succeeded: d.succeeded, Failed: d.failed, Dead: d.dead}
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+)
+
+// newTestWebhook registers a subscription and queues one event for it. Test
+// servers listen on loopback, which subscriptions may not name, so the URL
+// is swapped in after registering.
+func newTestWebhook(t *testing.T, url string) (*MockWebhookRepository, *domain.WebhookSubscription) {
+	customerID := 1
+	repo := NewMockWebhookRepository()
+	service := NewWebhookService(repo, createTestLogger(t))
+
+	sub, err := service.CreateWebhook(context.Background(), ports.CreateWebhookRequest{
+		URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret,
+		AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.subs[sub.ID].URL = url
+	sub.URL = url
+	if err := service.Enqueue(context.Background(), newTestDeliveryEvent("delivery.created", customerID)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return repo, sub
+}
+
+// testDispatcherConfig is the default configuration, reaching the loopback test servers
+func testDispatcherConfig() WebhookDispatcherConfig {
+	config := DefaultWebhookDispatcherConfig()
+	config.AllowPrivateNetworks = true
+	return config
+}
+
+func TestWebhookDispatcher_SignsAndDelivers(t *testing.T) {
+	var gotSignature, gotEvent string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(WebhookSignatureHeader)
+		gotEvent = r.Header.Get(WebhookEventHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo, sub := newTestWebhook(t, server.URL)
+	dispatcher := NewWebhookDispatcher(repo, testDispatcherConfig(), createTestLogger(t))
+
+	succeeded, err := dispatcher.DispatchDue(context.Background())
+	if err != nil || succeeded != 1 {
+		t.Fatalf("expected 1 successful delivery, got %d (%v)", succeeded, err)
+	}
+
+	d := repo.delivery(sub.ID)
+	if gotSignature != SignWebhookPayload(testWebhookSecret, gotBody) || string(gotBody) != string(d.Payload) {
+		t.Errorf("expected the payload signed with the subscription secret, got %q", gotSignature)
+	}
+	if gotEvent != "delivery.created" {
+		t.Errorf("expected the event type header, got %q", gotEvent)
+	}
+	if d.Status != domain.WebhookDeliverySucceeded || d.DeliveredAt == nil {
+		t.Errorf("expected the delivery to succeed, got %s", d.Status)
+	}
+	if len(d.Attempts) != 1 || d.Attempts[0].StatusCode != http.StatusNoContent {
+		t.Errorf("expected one attempt recording 204, got %+v", d.Attempts)
+	}
+	if m := dispatcher.Metrics(); m.Succeeded != 1 || m.Failed != 0 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+func TestWebhookDispatcher_RetriesServerErrorsUntilDead(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo, sub := newTestWebhook(t, server.URL)
+	config := testDispatcherConfig()
+	config.MaxAttempts = 3
+	dispatcher := NewWebhookDispatcher(repo, config, createTestLogger(t))
+
+	if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := repo.delivery(sub.ID)
+	if d.Status != domain.WebhookDeliveryPending {
+		t.Fatalf("expected the delivery to stay pending, got %s", d.Status)
+	}
+	if delay := time.Until(d.NextAttemptAt); delay < config.InitialDelay-time.Second {
+		t.Errorf("expected the next attempt after backoff, got %s", delay)
+	}
+
+	// Not due yet
+	if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected no attempt before the backoff elapsed, got %d calls", calls.Load())
+	}
+
+	for i := 0; i < 2; i++ {
+		repo.makeDue()
+		if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	d = repo.delivery(sub.ID)
+	if !d.IsDead() || d.AttemptCount != 3 {
+		t.Errorf("expected the delivery to be dead after 3 attempts, got %s after %d", d.Status, d.AttemptCount)
+	}
+	if len(d.Attempts) != 3 || d.Attempts[2].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 3 recorded 503 attempts, got %+v", d.Attempts)
+	}
+	if m := dispatcher.Metrics(); m.Failed != 3 || m.Dead != 1 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+func TestWebhookDispatcher_ClientErrorIsNotRetried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	repo, sub := newTestWebhook(t, server.URL)
+	dispatcher := NewWebhookDispatcher(repo, testDispatcherConfig(), createTestLogger(t))
+
+	if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := repo.delivery(sub.ID); !d.IsDead() || d.AttemptCount != 1 {
+		t.Errorf("expected a 410 to give up straight away, got %s after %d", d.Status, d.AttemptCount)
+	}
+}
+
+func TestWebhookDispatcher_ThrottlingIsRetried(t *testing.T) {
+	for _, code := range []int{http.StatusRequestTimeout, http.StatusTooManyRequests} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(code)
+		}))
+
+		repo, sub := newTestWebhook(t, server.URL)
+		dispatcher := NewWebhookDispatcher(repo, testDispatcherConfig(), createTestLogger(t))
+		if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if d := repo.delivery(sub.ID); d.Status != domain.WebhookDeliveryPending || d.AttemptCount != 1 {
+			t.Errorf("expected a %d to be retried, got %s after %d", code, d.Status, d.AttemptCount)
+		}
+		server.Close()
+	}
+}
+
+func TestWebhookDispatcher_RedirectsAreNotFollowed(t *testing.T) {
+	var followed atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed.Store(true)
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	repo, sub := newTestWebhook(t, server.URL)
+	dispatcher := NewWebhookDispatcher(repo, testDispatcherConfig(), createTestLogger(t))
+	if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if followed.Load() {
+		t.Error("expected the redirect not to be followed")
+	}
+	if d := repo.delivery(sub.ID); !d.IsDead() || d.Attempts[0].StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("expected the redirect to give up, got %s with %+v", d.Status, d.Attempts)
+	}
+}
+
+func TestWebhookDispatcher_RefusesNonPublicAddresses(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	// A name the subscription was registered with, now resolving to loopback
+	repo, sub := newTestWebhook(t, server.URL)
+	dispatcher := NewWebhookDispatcher(repo, DefaultWebhookDispatcherConfig(), createTestLogger(t))
+	if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no request to reach the loopback server, got %d", calls.Load())
+	}
+	if d := repo.delivery(sub.ID); !d.IsDead() || d.Attempts[0].Error == "" {
+		t.Errorf("expected the delivery to give up, got %s with %+v", d.Status, d.Attempts)
+	}
+}
+
+func TestWebhookDispatcher_RetriesTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	repo, sub := newTestWebhook(t, server.URL)
+	config := testDispatcherConfig()
+	config.RequestTimeout = 50 * time.Millisecond
+	dispatcher := NewWebhookDispatcher(repo, config, createTestLogger(t))
+
+	if _, err := dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := repo.delivery(sub.ID)
+	if d.Status != domain.WebhookDeliveryPending || d.AttemptCount != 1 {
+		t.Errorf("expected a timeout to be retried, got %s after %d", d.Status, d.AttemptCount)
+	}
+	if d.Attempts[0].StatusCode != 0 || d.Attempts[0].Error == "" {
+		t.Errorf("expected the attempt to record the timeout, got %+v", d.Attempts[0])
+	}
+}
+
+func TestWebhookDispatcher_Backoff(t *testing.T) {
+	config := DefaultWebhookDispatcherConfig()
+	config.InitialDelay = time.Second
+	config.MaxDelay = 10 * time.Second
+	dispatcher := NewWebhookDispatcher(NewMockWebhookRepository(), config, createTestLogger(t))
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for i, expected := range want {
+		if got := dispatcher.backoff(i + 1); got != expected {
+			t.Errorf("attempt %d: expected %s, got %s", i+1, expected, got)
+		}
+	}
+}
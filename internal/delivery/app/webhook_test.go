@@ -0,0 +1,435 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/delivery/ports"
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// MockWebhookRepository is a mock implementation of WebhookRepository for testing
+type MockWebhookRepository struct {
+	mu             sync.Mutex
+	subs           map[int64]*domain.WebhookSubscription
+	deliveries     map[int64]*domain.WebhookDelivery
+	nextSubID      int64
+	nextDeliveryID int64
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{
+		subs:           make(map[int64]*domain.WebhookSubscription),
+		deliveries:     make(map[int64]*domain.WebhookDelivery),
+		nextSubID:      1,
+		nextDeliveryID: 1,
+	}
+}
+
+func (m *MockWebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub.ID = m.nextSubID
+	m.nextSubID++
+	copied := *sub
+	m.subs[sub.ID] = &copied
+	return nil
+}
+
+func (m *MockWebhookRepository) GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, domain.ErrWebhookNotFound
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (m *MockWebhookRepository) ListSubscriptions(ctx context.Context, customerID int) ([]*domain.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var subs []*domain.WebhookSubscription
+	for _, sub := range m.subs {
+		if sub.CustomerID == customerID {
+			copied := *sub
+			subs = append(subs, &copied)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs, nil
+}
+
+func (m *MockWebhookRepository) UpdateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.subs[sub.ID]; !ok {
+		return domain.ErrWebhookNotFound
+	}
+	copied := *sub
+	m.subs[sub.ID] = &copied
+	return nil
+}
+
+func (m *MockWebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.subs[id]; !ok {
+		return domain.ErrWebhookNotFound
+	}
+	delete(m.subs, id)
+	for deliveryID, d := range m.deliveries {
+		if d.SubscriptionID == id {
+			delete(m.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) EnqueueDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range deliveries {
+		duplicate := false
+		for _, existing := range m.deliveries {
+			if existing.SubscriptionID == d.SubscriptionID && existing.EventID == d.EventID {
+				duplicate = true
+			}
+		}
+		if duplicate {
+			continue
+		}
+		copied := *d
+		copied.ID = m.nextDeliveryID
+		m.deliveries[copied.ID] = &copied
+		m.nextDeliveryID++
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) FetchDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var due []*domain.WebhookDelivery
+	for id := int64(1); id < m.nextDeliveryID && len(due) < limit; id++ {
+		d, ok := m.deliveries[id]
+		if ok && d.Status == domain.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			d.NextAttemptAt = now.Add(lease)
+			copied := *d
+			copied.Attempts = nil
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func (m *MockWebhookRepository) SaveAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.deliveries[delivery.ID]
+	attempts := append(stored.Attempts, attempt)
+	copied := *delivery
+	copied.Attempts = attempts
+	m.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deliveries []*domain.WebhookDelivery
+	for id := m.nextDeliveryID - 1; id > 0 && len(deliveries) < limit; id-- {
+		if d, ok := m.deliveries[id]; ok && d.SubscriptionID == subscriptionID {
+			copied := *d
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	return deliveries, nil
+}
+
+// delivery returns the only queued delivery for a subscription, or nil
+func (m *MockWebhookRepository) delivery(subscriptionID int64) *domain.WebhookDelivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			return d
+		}
+	}
+	return nil
+}
+
+// makeDue clears the backoff so failed deliveries are picked up by the next poll
+func (m *MockWebhookRepository) makeDue() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		d.NextAttemptAt = time.Now().Add(-time.Second)
+	}
+}
+
+const testWebhookSecret = "0123456789abcdef"
+
+func newTestDeliveryEvent(eventType string, customerID int) messaging.Event {
+	return messaging.NewEventWithTrace(eventType, "delivery-service", "test",
+		map[string]interface{}{"delivery_id": 7, "customer_id": customerID}, nil)
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	customerID := 1
+	courierID := 2
+
+	tests := []struct {
+		name    string
+		req     ports.CreateWebhookRequest
+		wantErr error
+	}{
+		{
+			name: "customer with every event type",
+			req: ports.CreateWebhookRequest{
+				URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret,
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+		},
+		{
+			name: "customer with a filter",
+			req: ports.CreateWebhookRequest{
+				URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret,
+				EventTypes:  []string{"delivery.cancelled"},
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+		},
+		{
+			name: "courier",
+			req: ports.CreateWebhookRequest{
+				URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret,
+				AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID},
+			},
+			wantErr: domain.ErrUnauthorized,
+		},
+		{
+			name: "unsupported scheme",
+			req: ports.CreateWebhookRequest{
+				URL: "ftp://merchant.example.com/hooks", Secret: testWebhookSecret,
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+			wantErr: domain.ErrInvalidWebhook,
+		},
+		{
+			name: "short secret",
+			req: ports.CreateWebhookRequest{
+				URL: "https://merchant.example.com/hooks", Secret: "short",
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+			wantErr: domain.ErrInvalidWebhook,
+		},
+		{
+			name: "loopback address",
+			req: ports.CreateWebhookRequest{
+				URL: "http://127.0.0.1:8080/hooks", Secret: testWebhookSecret,
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+			wantErr: domain.ErrInvalidWebhook,
+		},
+		{
+			name: "cloud metadata address",
+			req: ports.CreateWebhookRequest{
+				URL: "http://169.254.169.254/latest/meta-data", Secret: testWebhookSecret,
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+			wantErr: domain.ErrInvalidWebhook,
+		},
+		{
+			name: "localhost",
+			req: ports.CreateWebhookRequest{
+				URL: "http://localhost/hooks", Secret: testWebhookSecret,
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+			wantErr: domain.ErrInvalidWebhook,
+		},
+		{
+			name: "unknown event type",
+			req: ports.CreateWebhookRequest{
+				URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret,
+				EventTypes:  []string{"location.updated"},
+				AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+			},
+			wantErr: domain.ErrInvalidWebhook,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewWebhookService(NewMockWebhookRepository(), createTestLogger(t))
+
+			sub, err := service.CreateWebhook(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if sub.ID == 0 || sub.CustomerID != customerID || !sub.Active {
+				t.Errorf("expected an active subscription for customer %d, got %+v", customerID, sub)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Ownership(t *testing.T) {
+	ownerID := 1
+	otherID := 2
+	owner := ports.AuthContext{Role: "customer", UserCustomerID: &ownerID}
+	other := ports.AuthContext{Role: "customer", UserCustomerID: &otherID}
+	admin := ports.AuthContext{Role: "admin"}
+
+	service := NewWebhookService(NewMockWebhookRepository(), createTestLogger(t))
+	sub, err := service.CreateWebhook(context.Background(), ports.CreateWebhookRequest{
+		URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret, AuthContext: owner,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := service.GetWebhook(context.Background(), ports.WebhookRequest{ID: sub.ID, AuthContext: other}); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected another customer to be refused, got %v", err)
+	}
+	if _, err := service.GetWebhook(context.Background(), ports.WebhookRequest{ID: sub.ID, AuthContext: admin}); err != nil {
+		t.Errorf("expected an admin to read any subscription, got %v", err)
+	}
+
+	// The subscription is in the owner's default organization
+	otherOrgAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 2})
+	if _, err := service.GetWebhook(otherOrgAdmin, ports.WebhookRequest{ID: sub.ID, AuthContext: admin}); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected another organization's admin to be refused, got %v", err)
+	}
+	orgAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: authDomain.DefaultOrgID})
+	if _, err := service.GetWebhook(orgAdmin, ports.WebhookRequest{ID: sub.ID, AuthContext: admin}); err != nil {
+		t.Errorf("expected the organization's admin to read the subscription, got %v", err)
+	}
+	superAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "super_admin"})
+	if _, err := service.GetWebhook(superAdmin, ports.WebhookRequest{ID: sub.ID, AuthContext: ports.AuthContext{Role: "super_admin"}}); err != nil {
+		t.Errorf("expected a super admin to read any subscription, got %v", err)
+	}
+
+	others, err := service.ListWebhooks(context.Background(), other)
+	if err != nil || len(others) != 0 {
+		t.Errorf("expected no subscriptions for another customer, got %d (%v)", len(others), err)
+	}
+
+	paused := false
+	updated, err := service.UpdateWebhook(context.Background(), ports.UpdateWebhookRequest{ID: sub.ID, Active: &paused, AuthContext: owner})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Active || updated.URL != sub.URL {
+		t.Errorf("expected only active to change, got %+v", updated)
+	}
+
+	if err := service.DeleteWebhook(context.Background(), ports.WebhookRequest{ID: sub.ID, AuthContext: other}); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected another customer's delete to be refused, got %v", err)
+	}
+	if err := service.DeleteWebhook(context.Background(), ports.WebhookRequest{ID: sub.ID, AuthContext: owner}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.GetWebhook(context.Background(), ports.WebhookRequest{ID: sub.ID, AuthContext: owner}); !errors.Is(err, domain.ErrWebhookNotFound) {
+		t.Errorf("expected the deleted subscription to be gone, got %v", err)
+	}
+}
+
+func TestWebhookService_Enqueue(t *testing.T) {
+	customerID := 1
+	otherID := 2
+	repo := NewMockWebhookRepository()
+	service := NewWebhookService(repo, createTestLogger(t))
+
+	create := func(customer *int, eventTypes []string) *domain.WebhookSubscription {
+		sub, err := service.CreateWebhook(context.Background(), ports.CreateWebhookRequest{
+			URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret, EventTypes: eventTypes,
+			AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: customer},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return sub
+	}
+	all := create(&customerID, nil)
+	cancelledOnly := create(&customerID, []string{"delivery.cancelled"})
+	otherCustomer := create(&otherID, nil)
+
+	event := newTestDeliveryEvent("delivery.status_changed", customerID)
+	// Round-trip as the outbox does, so customer_id is a JSON number
+	data, _ := json.Marshal(event)
+	var decoded messaging.Event
+	json.Unmarshal(data, &decoded)
+
+	// Handing the same event over twice queues it once
+	for i := 0; i < 2; i++ {
+		if err := service.Enqueue(context.Background(), decoded); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	d := repo.delivery(all.ID)
+	if d == nil {
+		t.Fatal("expected the unfiltered subscription to get the event")
+	}
+	if d.EventID != event.ID || d.EventType != "delivery.status_changed" || d.Status != domain.WebhookDeliveryPending {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(d.Payload, &payload); err != nil || payload.ID != event.ID || payload.Data["delivery_id"] != float64(7) {
+		t.Errorf("unexpected payload %s (%v)", d.Payload, err)
+	}
+	if repo.delivery(cancelledOnly.ID) != nil {
+		t.Error("expected the cancelled-only subscription not to get a status change")
+	}
+	if repo.delivery(otherCustomer.ID) != nil {
+		t.Error("expected another customer's subscription not to get the event")
+	}
+	if len(repo.deliveries) != 1 {
+		t.Errorf("expected 1 queued delivery, got %d", len(repo.deliveries))
+	}
+}
+
+func TestOutboxDispatcher_QueuesWebhooks(t *testing.T) {
+	customerID := 1
+	webhookRepo := NewMockWebhookRepository()
+	webhooks := NewWebhookService(webhookRepo, createTestLogger(t))
+	sub, err := webhooks.CreateWebhook(context.Background(), ports.CreateWebhookRequest{
+		URL: "https://merchant.example.com/hooks", Secret: testWebhookSecret,
+		AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	payload, _ := json.Marshal(newTestDeliveryEvent("delivery.created", customerID))
+	outboxEvent, err := domain.NewOutboxEvent(7, "delivery-events", "delivery.created", payload)
+	if err != nil {
+		t.Fatalf("failed to create outbox event: %v", err)
+	}
+	repo := NewMockOutboxRepository()
+	repo.Add(outboxEvent)
+
+	dispatcher := NewOutboxDispatcher(repo, testsupport.NewPublisher(), DefaultOutboxDispatcherConfig(), createTestLogger(t))
+	dispatcher.SetWebhooks(webhooks)
+
+	if published, err := dispatcher.DispatchPending(context.Background()); err != nil || published != 1 {
+		t.Fatalf("expected 1 published event, got %d (%v)", published, err)
+	}
+	if webhookRepo.delivery(sub.ID) == nil {
+		t.Error("expected the event to be queued for the subscription")
+	}
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Address is where a delivery is picked up or dropped off. Coordinates are
+// nil until the address has been geocoded.
+type Address struct {
+	Line1       string
+	City        string
+	PostalCode  string
+	Country     string
+	Coordinates *Coordinates
+}
+
+// Coordinates is a point in decimal degrees
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Valid reports whether the point lies within latitude and longitude bounds
+func (c Coordinates) Valid() bool {
+	return c.Latitude >= -90 && c.Latitude <= 90 && c.Longitude >= -180 && c.Longitude <= 180
+}
+
+// coordinatesPattern matches locations written as "(lng,lat)"
+var coordinatesPattern = regexp.MustCompile(`^\s*\(\s*([-+]?\d*\.?\d+)\s*,\s*([-+]?\d*\.?\d+)\s*\)\s*$`)
+
+// ParseCoordinates reads a location written as "(lng,lat)", the format
+// deliveries stored before addresses were structured
+func ParseCoordinates(location string) (*Coordinates, bool) {
+	m := coordinatesPattern.FindStringSubmatch(location)
+	if m == nil {
+		return nil, false
+	}
+	lng, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return nil, false
+	}
+	lat, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return nil, false
+	}
+	return &Coordinates{Latitude: lat, Longitude: lng}, true
+}
+
+// AddressFromLocation maps a free-text location into Line1. A location
+// written as "(lng,lat)" also sets the coordinates.
+func AddressFromLocation(location string) Address {
+	address := Address{Line1: strings.TrimSpace(location)}
+	if coords, ok := ParseCoordinates(location); ok {
+		address.Coordinates = coords
+	}
+	return address
+}
+
+// IsEmpty reports whether the address has neither text nor coordinates
+func (a Address) IsEmpty() bool {
+	return a.Line1 == "" && a.City == "" && a.PostalCode == "" && a.Country == "" && a.Coordinates == nil
+}
+
+// String formats the address on one line, e.g. "1 Main St, 10001 New York,
+// USA". An address known only by its coordinates is written as "(lng,lat)".
+func (a Address) String() string {
+	var parts []string
+	if a.Line1 != "" {
+		parts = append(parts, a.Line1)
+	}
+	if place := strings.TrimSpace(a.PostalCode + " " + a.City); place != "" {
+		parts = append(parts, place)
+	}
+	if a.Country != "" {
+		parts = append(parts, a.Country)
+	}
+	if len(parts) == 0 && a.Coordinates != nil {
+		return fmt.Sprintf("(%f,%f)", a.Coordinates.Longitude, a.Coordinates.Latitude)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validate rejects empty addresses and out of range coordinates
+func (a Address) validate() error {
+	if a.IsEmpty() {
+		return ErrInvalidDeliveryData
+	}
+	if a.Coordinates != nil && !a.Coordinates.Valid() {
+		return ErrInvalidDeliveryData
+	}
+	return nil
+}
+
+// locationCoordinates returns the address's coordinates, falling back to a
+// location still written as "(lng,lat)"
+func locationCoordinates(address Address, location string) (*Coordinates, bool) {
+	if address.Coordinates != nil {
+		return address.Coordinates, true
+	}
+	return ParseCoordinates(location)
+}
+
+// PickupCoordinates returns where the delivery is picked up, if known
+func (d *Delivery) PickupCoordinates() (*Coordinates, bool) {
+	return locationCoordinates(d.PickupAddress, d.PickupLocation)
+}
+
+// DeliveryCoordinates returns where the delivery is dropped off, if known
+func (d *Delivery) DeliveryCoordinates() (*Coordinates, bool) {
+	return locationCoordinates(d.DeliveryAddress, d.DeliveryLocation)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import "testing"
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		location string
+		lat, lng float64
+		ok       bool
+	}{
+		{"(-74.006000,40.712800)", 40.7128, -74.006, true},
+		{" ( 13.4, 52.52 ) ", 52.52, 13.4, true},
+		{"123 Main St", 0, 0, false},
+		{"(13.4)", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		coords, ok := ParseCoordinates(tt.location)
+		var lat, lng float64
+		if coords != nil {
+			lat, lng = coords.Latitude, coords.Longitude
+		}
+		if ok != tt.ok || lat != tt.lat || lng != tt.lng {
+			t.Errorf("ParseCoordinates(%q) = (%v, %v, %v), expected (%v, %v, %v)",
+				tt.location, lat, lng, ok, tt.lat, tt.lng, tt.ok)
+		}
+	}
+}
+
+func TestAddress_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  Address
+		expected string
+	}{
+		{"full address", Address{Line1: "1 Main St", City: "New York", PostalCode: "10001", Country: "USA"}, "1 Main St, 10001 New York, USA"},
+		{"line only", Address{Line1: "1 Main St"}, "1 Main St"},
+		{"city without postal code", Address{Line1: "1 Main St", City: "Berlin"}, "1 Main St, Berlin"},
+		{"coordinates only", Address{Coordinates: &Coordinates{Latitude: 40.7128, Longitude: -74.006}}, "(-74.006000,40.712800)"},
+		{"empty", Address{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.address.String(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAddressFromLocation(t *testing.T) {
+	address := AddressFromLocation(" 123 Main St ")
+	if address.Line1 != "123 Main St" || address.Coordinates != nil {
+		t.Errorf("unexpected address %+v", address)
+	}
+
+	address = AddressFromLocation("(-74.006,40.7128)")
+	if address.Coordinates == nil || address.Coordinates.Latitude != 40.7128 || address.Coordinates.Longitude != -74.006 {
+		t.Errorf("expected coordinates from legacy location, got %+v", address.Coordinates)
+	}
+}
+
+func TestNewDeliveryWithAddresses(t *testing.T) {
+	pickup := Address{Line1: "1 Main St", City: "New York", Coordinates: &Coordinates{Latitude: 40.7128, Longitude: -74.006}}
+	dropoff := Address{Line1: "5 Elm St", City: "New York"}
+
+	d, err := NewDeliveryWithAddresses(1, pickup, dropoff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.PickupLocation != "1 Main St, New York" || d.DeliveryLocation != "5 Elm St, New York" {
+		t.Errorf("unexpected locations %q, %q", d.PickupLocation, d.DeliveryLocation)
+	}
+	if coords, ok := d.PickupCoordinates(); !ok || coords.Latitude != 40.7128 {
+		t.Errorf("expected pickup coordinates, got %+v", coords)
+	}
+	if _, ok := d.DeliveryCoordinates(); ok {
+		t.Error("expected no delivery coordinates before geocoding")
+	}
+
+	invalid := []struct {
+		name            string
+		pickup, dropoff Address
+	}{
+		{"empty pickup", Address{}, dropoff},
+		{"empty dropoff", pickup, Address{}},
+		{"latitude out of range", Address{Line1: "x", Coordinates: &Coordinates{Latitude: 91}}, dropoff},
+		{"longitude out of range", pickup, Address{Line1: "x", Coordinates: &Coordinates{Longitude: -181}}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDeliveryWithAddresses(1, tt.pickup, tt.dropoff); err != ErrInvalidDeliveryData {
+				t.Errorf("expected ErrInvalidDeliveryData, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDelivery_CoordinatesFromLegacyLocation(t *testing.T) {
+	// Rows written before migration 022 only carry the location string
+	d := &Delivery{DeliveryLocation: "(13.4,52.52)"}
+	coords, ok := d.DeliveryCoordinates()
+	if !ok || coords.Latitude != 52.52 || coords.Longitude != 13.4 {
+		t.Errorf("expected coordinates parsed from location, got %+v", coords)
+	}
+}
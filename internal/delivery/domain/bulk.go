@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrEmptyBatch    = domainerr.New(codes.InvalidArgument, "batch contains no deliveries")
+	ErrBatchTooLarge = domainerr.New(codes.InvalidArgument, "batch exceeds the maximum size")
+)
+
+// BulkRowResult reports the outcome of one row of a bulk creation batch
+type BulkRowResult struct {
+	Index          int    `json:"index"`
+	Created        bool   `json:"created"`
+	ID             int    `json:"id,omitempty"`
+	TrackingNumber string `json:"tracking_number,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// BulkCreateResult summarizes a bulk creation batch; Rows is in input order
+type BulkCreateResult struct {
+	Total   int             `json:"total"`
+	Created int             `json:"created"`
+	Failed  int             `json:"failed"`
+	Rows    []BulkRowResult `json:"rows"`
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrInvalidCancellation = domainerr.New(codes.InvalidArgument, "invalid cancellation")
+	ErrNotCancellable      = domainerr.New(codes.FailedPrecondition, "delivery can no longer be cancelled")
+	ErrCancelByStatus      = domainerr.New(codes.InvalidArgument, "deliveries are cancelled with CancelDelivery, which records a reason")
+)
+
+// Cancellation reason codes
+const (
+	CancelReasonCustomerRequest    = "customer_request"
+	CancelReasonAddressInvalid     = "address_invalid"
+	CancelReasonCourierUnavailable = "courier_unavailable"
+	CancelReasonOther              = "other"
+)
+
+// IsValidCancelReasonCode checks if a cancellation reason code is known
+func IsValidCancelReasonCode(code string) bool {
+	switch code {
+	case CancelReasonCustomerRequest, CancelReasonAddressInvalid, CancelReasonCourierUnavailable, CancelReasonOther:
+		return true
+	}
+	return false
+}
+
+// Cancel cancels the delivery with a required free-text reason and an
+// optional reason code. Pending and assigned deliveries may be cancelled by
+// their customer or an admin; once in transit only admins may cancel.
+func (d *Delivery) Cancel(role, reason, reasonCode string, now time.Time) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || (reasonCode != "" && !IsValidCancelReasonCode(reasonCode)) {
+		return ErrInvalidCancellation
+	}
+
+	switch d.Status {
+	case StatusPending, StatusAssigned:
+	case StatusInTransit:
+		if role != "admin" && role != "super_admin" {
+			return ErrNotCancellable
+		}
+	default:
+		return ErrNotCancellable
+	}
+
+	d.Status = StatusCancelled
+	d.CancelReason = reason
+	d.CancelReasonCode = reasonCode
+	d.CancelledAt = &now
+	d.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrInvalidConfirmation   = domainerr.New(codes.InvalidArgument, "invalid delivery confirmation")
+	ErrNotInTransit          = domainerr.New(codes.FailedPrecondition, "delivery is not in transit")
+	ErrWrongConfirmationCode = domainerr.New(codes.PermissionDenied, "confirmation code does not match")
+	ErrDeliverByStatus       = domainerr.New(codes.InvalidArgument, "deliveries are delivered with ConfirmDelivery, which records proof of delivery")
+)
+
+// NewConfirmationCode returns a random six-digit code for a new delivery
+func NewConfirmationCode() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		panic(fmt.Sprintf("failed to generate confirmation code: %v", err))
+	}
+	return fmt.Sprintf("%06d", n.Int64())
+}
+
+// DeliveryConfirmation is the proof of delivery captured by the courier.
+// Photo and signature images are stored in a blob store and referenced by key.
+type DeliveryConfirmation struct {
+	ID               int       `json:"id"`
+	DeliveryID       int       `json:"delivery_id"`
+	CourierID        int       `json:"courier_id"`
+	RecipientName    string    `json:"recipient_name,omitempty"`
+	ConfirmationCode string    `json:"confirmation_code,omitempty"`
+	PhotoKey         string    `json:"photo_key,omitempty"`
+	SignatureKey     string    `json:"signature_key,omitempty"`
+	Notes            string    `json:"notes,omitempty"`
+	ConfirmedAt      time.Time `json:"confirmed_at"`
+}
+
+// NewDeliveryConfirmation creates a confirmation with validation. At least one
+// form of proof (photo, signature or confirmation code) is required.
+func NewDeliveryConfirmation(deliveryID, courierID int, recipientName, confirmationCode string, hasPhoto, hasSignature bool) (*DeliveryConfirmation, error) {
+	if deliveryID <= 0 || courierID <= 0 {
+		return nil, ErrInvalidConfirmation
+	}
+	if !hasPhoto && !hasSignature && confirmationCode == "" {
+		return nil, ErrInvalidConfirmation
+	}
+
+	return &DeliveryConfirmation{
+		DeliveryID:       deliveryID,
+		CourierID:        courierID,
+		RecipientName:    recipientName,
+		ConfirmationCode: confirmationCode,
+		ConfirmedAt:      time.Now(),
+	}, nil
+}
+
+// Confirm marks the delivery as delivered by its assigned courier. A
+// confirmation code, when given, must be the delivery's.
+func (d *Delivery) Confirm(courierID int, confirmationCode string) error {
+	if d.CourierID == nil || *d.CourierID != courierID {
+		return ErrUnauthorized
+	}
+	if d.Status != StatusInTransit {
+		return ErrNotInTransit
+	}
+	if confirmationCode != "" && (d.ConfirmationCode == "" ||
+		subtle.ConstantTimeCompare([]byte(confirmationCode), []byte(d.ConfirmationCode)) != 1) {
+		return ErrWrongConfirmationCode
+	}
+
+	return d.UpdateStatus(StatusDelivered)
+}
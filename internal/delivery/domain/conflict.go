@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrConflict is matched by ConflictError, returned when a delivery was
+// changed since the version the caller expected
+var ErrConflict = domainerr.New(codes.Aborted, "delivery was modified concurrently")
+
+// ConflictError reports the delivery's current version so the caller can
+// refetch it and retry
+type ConflictError struct {
+	CurrentVersion int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s, current version is %d", ErrConflict, e.CurrentVersion)
+}
+
+// Is makes errors.Is(err, ErrConflict) match
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// GRPCCode implements domainerr.Coder
+func (e *ConflictError) GRPCCode() codes.Code {
+	return codes.Aborted
+}
+
+// CheckVersion returns a ConflictError unless expected is zero or the
+// delivery's version
+func (d *Delivery) CheckVersion(expected int) error {
+	if expected != 0 && expected != d.Version {
+		return &ConflictError{CurrentVersion: d.Version}
+	}
+	return nil
+}
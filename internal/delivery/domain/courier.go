@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrCourierNotFound      = domainerr.New(codes.NotFound, "courier not found")
+	ErrInvalidCourierStatus = domainerr.New(codes.InvalidArgument, "invalid courier status")
+	ErrCourierUnavailable   = domainerr.New(codes.FailedPrecondition, "courier is offline")
+)
+
+// Courier availability constants
+const (
+	CourierAvailable = "available"
+	CourierBusy      = "busy"
+	CourierOffline   = "offline"
+)
+
+// Courier is a courier and whether they can take deliveries
+type Courier struct {
+	ID              int       `json:"id"`
+	OrgID           int       `json:"org_id"`
+	Name            string    `json:"name"`
+	VehicleType     string    `json:"vehicle_type"`
+	Phone           string    `json:"phone"`
+	Status          string    `json:"status"`
+	StatusUpdatedAt time.Time `json:"status_updated_at"`
+}
+
+// IsValidCourierStatus checks if a courier availability status is valid
+func IsValidCourierStatus(status string) bool {
+	return status == CourierAvailable || status == CourierBusy || status == CourierOffline
+}
+
+// CanTakeDeliveries reports whether deliveries may be assigned to the courier.
+// Busy couriers may stack deliveries; offline couriers are off shift.
+func (c *Courier) CanTakeDeliveries() bool {
+	return c.Status != CourierOffline
+}
+
+// CourierStatusForDelivery returns the availability a delivery status puts its
+// courier in: busy while it is assigned or in transit, available once it is
+// delivered or cancelled, and empty if it doesn't change availability
+func CourierStatusForDelivery(deliveryStatus string) string {
+	switch deliveryStatus {
+	case StatusAssigned, StatusInTransit:
+		return CourierBusy
+	case StatusDelivered, StatusCancelled:
+		return CourierAvailable
+	}
+	return ""
+}
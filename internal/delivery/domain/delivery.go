@@ -2,15 +2,21 @@ package domain
 
 import (
 	"database/sql"
-	"errors"
 	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
 )
 
 var (
-	ErrDeliveryNotFound     = errors.New("delivery not found")
-	ErrInvalidStatus        = errors.New("invalid delivery status")
-	ErrUnauthorized         = errors.New("unauthorized access")
-	ErrInvalidDeliveryData  = errors.New("invalid delivery data")
+	ErrDeliveryNotFound      = domainerr.New(codes.NotFound, "delivery not found")
+	ErrInvalidStatus         = domainerr.New(codes.InvalidArgument, "invalid delivery status")
+	ErrUnauthorized          = domainerr.New(codes.PermissionDenied, "unauthorized access")
+	ErrInvalidDeliveryData   = domainerr.New(codes.InvalidArgument, "invalid delivery data")
+	ErrInvalidScheduleWindow = domainerr.New(codes.InvalidArgument, "invalid schedule window")
+	ErrNotOverdue            = domainerr.New(codes.FailedPrecondition, "delivery is not overdue")
+
+	ErrInvalidTransition = domainerr.New(codes.FailedPrecondition, "delivery cannot move to this status")
 )
 
 // Status constants
@@ -25,32 +31,60 @@ const (
 // Delivery represents the core delivery entity
 type Delivery struct {
 	ID               int
+	OrgID            int    // organization the delivery belongs to
+	TrackingNumber   string // derived from the ID, see TrackingNumberFor
 	CustomerID       int
 	CourierID        *int
 	Status           string
-	PickupLocation   string
-	DeliveryLocation string
-	ScheduledDate    *time.Time
+	PickupLocation   string // PickupAddress on one line, see Address.String
+	DeliveryLocation string // DeliveryAddress on one line
+	PickupAddress    Address
+	DeliveryAddress  Address
+	ScheduledDate    *time.Time // start of the scheduled window
+	ScheduledEnd     *time.Time // end of the scheduled window
 	DeliveredDate    *time.Time
+	Late             bool // still undelivered when the window ended
 	Notes            string
+	CancelReason     string
+	CancelReasonCode string // one of the CancelReason constants, empty if not given
+	CancelledAt      *time.Time
+	QuotedPriceCents *int64 // price honoured from a quote, in the currency's minor unit
+	QuotedCurrency   string
+	ConfirmationCode string `json:"-"` // given to the courier by the customer on handover, see Confirm
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
+	Version          int // bumped by every update, see CheckVersion
 }
 
-// NewDelivery creates a new delivery with validation
+// NewDelivery creates a new delivery with validation from free-text
+// locations, see AddressFromLocation
 func NewDelivery(customerID int, pickupLocation, deliveryLocation string) (*Delivery, error) {
-	if customerID <= 0 {
+	if pickupLocation == "" || deliveryLocation == "" {
 		return nil, ErrInvalidDeliveryData
 	}
-	if pickupLocation == "" || deliveryLocation == "" {
+	return NewDeliveryWithAddresses(customerID, AddressFromLocation(pickupLocation), AddressFromLocation(deliveryLocation))
+}
+
+// NewDeliveryWithAddresses creates a new delivery with validation
+func NewDeliveryWithAddresses(customerID int, pickup, dropoff Address) (*Delivery, error) {
+	if customerID <= 0 {
 		return nil, ErrInvalidDeliveryData
 	}
+	if err := pickup.validate(); err != nil {
+		return nil, err
+	}
+	if err := dropoff.validate(); err != nil {
+		return nil, err
+	}
 
 	return &Delivery{
 		CustomerID:       customerID,
 		Status:           StatusPending,
-		PickupLocation:   pickupLocation,
-		DeliveryLocation: deliveryLocation,
+		PickupLocation:   pickup.String(),
+		DeliveryLocation: dropoff.String(),
+		PickupAddress:    pickup,
+		DeliveryAddress:  dropoff,
+		ConfirmationCode: NewConfirmationCode(),
 		CreatedAt:        time.Now(),
 		UpdatedAt:        time.Now(),
 	}, nil
@@ -61,6 +95,9 @@ func (d *Delivery) UpdateStatus(newStatus string) error {
 	if !isValidStatus(newStatus) {
 		return ErrInvalidStatus
 	}
+	if newStatus == StatusCancelled {
+		return ErrCancelByStatus
+	}
 
 	d.Status = newStatus
 	d.UpdatedAt = time.Now()
@@ -73,6 +110,57 @@ func (d *Delivery) UpdateStatus(newStatus string) error {
 	return nil
 }
 
+// statusTransitions is the next status of a delivery in each status;
+// delivered and cancelled deliveries have none
+var statusTransitions = map[string]string{
+	StatusPending:   StatusAssigned,
+	StatusAssigned:  StatusInTransit,
+	StatusInTransit: StatusDelivered,
+}
+
+// ValidateTransition checks that the delivery may move to newStatus: the next
+// status in its lifecycle, or the one it already has
+func (d *Delivery) ValidateTransition(newStatus string) error {
+	if !isValidStatus(newStatus) {
+		return ErrInvalidStatus
+	}
+	if newStatus == StatusCancelled {
+		return ErrCancelByStatus
+	}
+	if newStatus != d.Status && statusTransitions[d.Status] != newStatus {
+		return ErrInvalidTransition
+	}
+	return nil
+}
+
+// SetScheduleWindow sets the window the delivery is scheduled for. Either
+// bound may be omitted, but given bounds must lie after now and the end must
+// come after the start.
+func (d *Delivery) SetScheduleWindow(start, end *time.Time, now time.Time) error {
+	if start != nil && !start.After(now) {
+		return ErrInvalidScheduleWindow
+	}
+	if end != nil && !end.After(now) {
+		return ErrInvalidScheduleWindow
+	}
+	if start != nil && end != nil && !end.After(*start) {
+		return ErrInvalidScheduleWindow
+	}
+
+	d.ScheduledDate = start
+	d.ScheduledEnd = end
+	return nil
+}
+
+// IsOverdue reports whether the delivery's window has ended without it being
+// delivered or cancelled and it hasn't been flagged late yet
+func (d *Delivery) IsOverdue(now time.Time) bool {
+	if d.Late || d.ScheduledEnd == nil || !now.After(*d.ScheduledEnd) {
+		return false
+	}
+	return d.Status != StatusDelivered && d.Status != StatusCancelled
+}
+
 // AssignCourier assigns a courier to the delivery
 func (d *Delivery) AssignCourier(courierID int) error {
 	if courierID <= 0 {
@@ -89,7 +177,7 @@ func (d *Delivery) AssignCourier(courierID int) error {
 // CanBeViewedBy checks if a user can view (read) this delivery.
 // More permissive than CanBeModifiedBy: couriers can view any active delivery.
 func (d *Delivery) CanBeViewedBy(role string, customerID *int, courierID *int) bool {
-	if role == "admin" {
+	if role == "admin" || role == "super_admin" {
 		return true
 	}
 	if role == "customer" && customerID != nil && *customerID == d.CustomerID {
@@ -112,7 +200,7 @@ func (d *Delivery) CanBeViewedBy(role string, customerID *int, courierID *int) b
 
 // CanBeModifiedBy checks if a user can modify this delivery
 func (d *Delivery) CanBeModifiedBy(role string, customerID *int, courierID *int) bool {
-	if role == "admin" {
+	if role == "admin" || role == "super_admin" {
 		return true
 	}
 
@@ -120,24 +208,21 @@ func (d *Delivery) CanBeModifiedBy(role string, customerID *int, courierID *int)
 		return true
 	}
 
-	if role == "courier" {
-		// Couriers can view/modify deliveries assigned to them
-		if courierID != nil && d.CourierID != nil && *courierID == *d.CourierID {
-			return true
-		}
-		// Couriers can also view pending deliveries (available for assignment)
-		if d.Status == StatusPending {
-			return true
-		}
-		// For testing: couriers can modify assigned and in_transit deliveries
-		if d.Status == StatusAssigned || d.Status == StatusInTransit {
-			return true
-		}
+	// Couriers can modify deliveries assigned to them; unassigned ones they
+	// may only claim, see CanBeClaimedBy
+	if role == "courier" && courierID != nil && d.CourierID != nil {
+		return *courierID == *d.CourierID
 	}
 
 	return false
 }
 
+// CanBeClaimedBy checks if a courier can assign themselves this delivery:
+// it is pending and no one is assigned to it yet
+func (d *Delivery) CanBeClaimedBy(role string, courierID *int) bool {
+	return role == "courier" && courierID != nil && d.CourierID == nil && d.Status == StatusPending
+}
+
 // isValidStatus checks if a status is valid
 func isValidStatus(status string) bool {
 	validStatuses := []string{
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -114,6 +115,10 @@ func TestNewDelivery(t *testing.T) {
 				t.Errorf("expected empty notes, got %s", delivery.Notes)
 			}
 
+			if len(delivery.ConfirmationCode) != 6 {
+				t.Errorf("expected a six-digit confirmation code, got %q", delivery.ConfirmationCode)
+			}
+
 			if delivery.CreatedAt.IsZero() {
 				t.Error("expected created at to be set")
 			}
@@ -157,9 +162,10 @@ func TestDelivery_UpdateStatus(t *testing.T) {
 			expectError: false,
 		},
 		{
-			name:        "valid status change to cancelled",
+			name:        "cancelled must go through Cancel",
 			newStatus:   StatusCancelled,
-			expectError: false,
+			expectError: true,
+			expectedErr: ErrCancelByStatus,
 		},
 		{
 			name:        "invalid status",
@@ -368,6 +374,64 @@ func TestDelivery_CanBeModifiedBy(t *testing.T) {
 	}
 }
 
+func TestDelivery_CanBeModifiedBy_Unclaimed(t *testing.T) {
+	courierID, otherCourierID := 2, 3
+	tests := []struct {
+		name      string
+		status    string
+		courierID *int
+		claimable bool
+	}{
+		{"courier can claim pending delivery", StatusPending, nil, true},
+		{"courier cannot claim unclaimed in transit delivery", StatusInTransit, nil, false},
+		{"courier cannot claim unclaimed cancelled delivery", StatusCancelled, nil, false},
+		{"courier cannot claim another courier's delivery", StatusPending, &otherCourierID, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := &Delivery{ID: 1, CustomerID: 1, Status: tt.status, CourierID: tt.courierID}
+			if result := delivery.CanBeClaimedBy("courier", &courierID); result != tt.claimable {
+				t.Errorf("expected %v, got %v", tt.claimable, result)
+			}
+			// Claiming is the only change a courier may make to a delivery not assigned to them
+			if delivery.CanBeModifiedBy("courier", nil, &courierID) {
+				t.Error("expected the courier to be refused other changes")
+			}
+			if delivery.CanBeClaimedBy("courier", nil) || delivery.CanBeClaimedBy("customer", &courierID) {
+				t.Error("expected only a courier with an ID to claim")
+			}
+		})
+	}
+}
+
+func TestDelivery_ValidateTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  error
+	}{
+		{StatusPending, StatusAssigned, nil},
+		{StatusAssigned, StatusInTransit, nil},
+		{StatusInTransit, StatusDelivered, nil},
+		{StatusAssigned, StatusAssigned, nil},
+		{StatusPending, StatusDelivered, ErrInvalidTransition},
+		{StatusInTransit, StatusPending, ErrInvalidTransition},
+		{StatusDelivered, StatusInTransit, ErrInvalidTransition},
+		{StatusCancelled, StatusAssigned, ErrInvalidTransition},
+		{StatusAssigned, StatusCancelled, ErrCancelByStatus},
+		{StatusPending, "lost", ErrInvalidStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			delivery := &Delivery{Status: tt.from}
+			if err := delivery.ValidateTransition(tt.to); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestIsValidStatus(t *testing.T) {
 	validStatuses := []string{
 		StatusPending,
@@ -388,11 +452,8 @@ func TestIsValidStatus(t *testing.T) {
 
 	for _, status := range validStatuses {
 		t.Run("valid_"+status, func(t *testing.T) {
-			// We can't test the private function directly, so we'll test it through UpdateStatus
-			delivery := &Delivery{Status: StatusPending}
-			err := delivery.UpdateStatus(status)
-			if err != nil {
-				t.Errorf("expected status %s to be valid, but got error: %v", status, err)
+			if !isValidStatus(status) {
+				t.Errorf("expected status %s to be valid", status)
 			}
 		})
 	}
@@ -406,4 +467,74 @@ func TestIsValidStatus(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestDelivery_SetScheduleWindow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	tests := []struct {
+		name        string
+		start       *time.Time
+		end         *time.Time
+		expectError bool
+	}{
+		{"no window", nil, nil, false},
+		{"start only", at(time.Hour), nil, false},
+		{"end only", nil, at(time.Hour), false},
+		{"future window", at(time.Hour), at(3 * time.Hour), false},
+		{"start in the past", at(-time.Hour), at(time.Hour), true},
+		{"end in the past", nil, at(-time.Minute), true},
+		{"end before start", at(3 * time.Hour), at(time.Hour), true},
+		{"end equal to start", at(time.Hour), at(time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := &Delivery{Status: StatusPending}
+			err := delivery.SetScheduleWindow(tt.start, tt.end, now)
+			if tt.expectError {
+				if err != ErrInvalidScheduleWindow {
+					t.Errorf("expected ErrInvalidScheduleWindow, got %v", err)
+				}
+				if delivery.ScheduledDate != nil || delivery.ScheduledEnd != nil {
+					t.Error("expected window to be left unset on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if delivery.ScheduledDate != tt.start || delivery.ScheduledEnd != tt.end {
+				t.Errorf("expected window %v-%v, got %v-%v", tt.start, tt.end, delivery.ScheduledDate, delivery.ScheduledEnd)
+			}
+		})
+	}
+}
+
+func TestDelivery_IsOverdue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		delivery Delivery
+		expected bool
+	}{
+		{"no window end", Delivery{Status: StatusInTransit}, false},
+		{"window still open", Delivery{Status: StatusInTransit, ScheduledEnd: &future}, false},
+		{"window ended in transit", Delivery{Status: StatusInTransit, ScheduledEnd: &past}, true},
+		{"window ended while pending", Delivery{Status: StatusPending, ScheduledEnd: &past}, true},
+		{"already flagged late", Delivery{Status: StatusInTransit, ScheduledEnd: &past, Late: true}, false},
+		{"delivered", Delivery{Status: StatusDelivered, ScheduledEnd: &past}, false},
+		{"cancelled", Delivery{Status: StatusCancelled, ScheduledEnd: &past}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.delivery.IsOverdue(now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
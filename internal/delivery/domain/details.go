@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// TrackedLocation is a delivery's latest position reported to the tracking service
+type TrackedLocation struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DeliveryETA estimates when a delivery reaches its destination
+type DeliveryETA struct {
+	Seconds          int64     `json:"seconds"`
+	DistanceKm       float64   `json:"distance_km"`
+	EstimatedArrival time.Time `json:"estimated_arrival"`
+}
+
+// DeliveryDetails is a delivery with the live tracking data asked for. A
+// requested field the tracking service couldn't supply in time is left nil
+// and Partial is set.
+type DeliveryDetails struct {
+	Delivery        *Delivery
+	CurrentLocation *TrackedLocation
+	ETA             *DeliveryETA
+	Partial         bool
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrInvalidSort = domainerr.New(codes.InvalidArgument, "invalid sort field or order")
+)
+
+// Fields deliveries can be listed by
+const (
+	SortCreatedAt     = "created_at"
+	SortUpdatedAt     = "updated_at"
+	SortScheduledDate = "scheduled_date"
+)
+
+// Listing orders
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// ListOrder is how listed deliveries are ordered
+type ListOrder struct {
+	Field     string // one of the Sort constants
+	Ascending bool
+}
+
+// ParseListOrder validates a sort field and order; empty values select
+// created_at, newest first. Deliveries without a scheduled date come last
+// either way when sorting by it.
+func ParseListOrder(field, order string) (ListOrder, error) {
+	listOrder := ListOrder{Field: SortCreatedAt}
+	switch field {
+	case "":
+	case SortCreatedAt, SortUpdatedAt, SortScheduledDate:
+		listOrder.Field = field
+	default:
+		return ListOrder{}, ErrInvalidSort
+	}
+
+	switch order {
+	case "", OrderDesc:
+	case OrderAsc:
+		listOrder.Ascending = true
+	default:
+		return ListOrder{}, ErrInvalidSort
+	}
+	return listOrder, nil
+}
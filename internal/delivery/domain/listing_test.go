@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseListOrder(t *testing.T) {
+	tests := []struct {
+		field, order string
+		expected     ListOrder
+		err          error
+	}{
+		{"", "", ListOrder{Field: SortCreatedAt}, nil},
+		{SortUpdatedAt, OrderAsc, ListOrder{Field: SortUpdatedAt, Ascending: true}, nil},
+		{SortScheduledDate, OrderDesc, ListOrder{Field: SortScheduledDate}, nil},
+		{"customer_id", "", ListOrder{}, ErrInvalidSort},
+		{"", "up", ListOrder{}, ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseListOrder(tt.field, tt.order)
+		if got != tt.expected || !errors.Is(err, tt.err) {
+			t.Errorf("ParseListOrder(%q, %q) = (%+v, %v), expected (%+v, %v)", tt.field, tt.order, got, err, tt.expected, tt.err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrInvalidOutboxEvent = errors.New("invalid outbox event")
+	ErrInvalidReplay      = domainerr.New(codes.InvalidArgument, "invalid event replay")
+	ErrReplayInterrupted  = domainerr.New(codes.Unavailable, "event replay interrupted")
+)
+
+// ReplayEntityDelivery is the entity type whose events can be replayed: every
+// outbox event belongs to a delivery
+const ReplayEntityDelivery = "delivery"
+
+// MaxReplayWindow bounds the time range a single replay may cover
+const MaxReplayWindow = 31 * 24 * time.Hour
+
+// Outbox status constants
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// OutboxEvent is an integration event persisted alongside the delivery
+// mutation that produced it, waiting to be published to the message broker
+type OutboxEvent struct {
+	ID            int64
+	AggregateID   int
+	Exchange      string
+	RoutingKey    string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+	CreatedAt     time.Time
+}
+
+// OutboxStats summarizes the state of the outbox for monitoring
+type OutboxStats struct {
+	Pending          int
+	Dead             int
+	OldestPendingAge time.Duration
+}
+
+// ReplayResult is the outcome of republishing outbox events. When a publish
+// fails the replay stops, and ResumeFrom is where to replay from next.
+type ReplayResult struct {
+	Published  int        `json:"published"`
+	ResumeFrom *time.Time `json:"resume_from,omitempty"`
+}
+
+// NewOutboxEvent creates a pending outbox event with validation
+func NewOutboxEvent(aggregateID int, exchange, routingKey string, payload []byte) (*OutboxEvent, error) {
+	if exchange == "" || routingKey == "" || len(payload) == 0 {
+		return nil, ErrInvalidOutboxEvent
+	}
+
+	now := time.Now()
+	return &OutboxEvent{
+		AggregateID:   aggregateID,
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		Payload:       payload,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// MarkSent records a successful publish
+func (e *OutboxEvent) MarkSent() {
+	now := time.Now()
+	e.Status = OutboxStatusSent
+	e.SentAt = &now
+	e.LastError = ""
+}
+
+// MarkFailed records a failed publish attempt. The event is scheduled for
+// another attempt after backoff, or moved to the dead state once maxAttempts
+// has been reached.
+func (e *OutboxEvent) MarkFailed(err error, maxAttempts int, backoff time.Duration) {
+	e.Attempts++
+	if err != nil {
+		e.LastError = err.Error()
+	}
+
+	if e.Attempts >= maxAttempts {
+		e.Status = OutboxStatusDead
+		return
+	}
+
+	e.NextAttemptAt = time.Now().Add(backoff)
+}
+
+// IsDead reports whether the event has exhausted its publish attempts
+func (e *OutboxEvent) IsDead() bool {
+	return e.Status == OutboxStatusDead
+}
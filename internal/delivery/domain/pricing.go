@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrQuoteInvalid       = domainerr.New(codes.InvalidArgument, "invalid quote token")
+	ErrQuoteExpired       = domainerr.New(codes.FailedPrecondition, "quote has expired")
+	ErrQuoteMismatch      = domainerr.New(codes.InvalidArgument, "quote does not match the delivery")
+	ErrQuoteUnlocated     = domainerr.New(codes.InvalidArgument, "pickup and delivery locations must be resolvable to coordinates")
+	ErrPricingUnavailable = domainerr.New(codes.Unavailable, "pricing is unavailable")
+)
+
+// AnyZone matches every zone, including points outside all zones, in a zone pair rate
+const AnyZone = "*"
+
+// PricingModel prices deliveries from a base fee, a per-km rate chosen by the
+// pickup and delivery zones, and surcharges for scheduled times. Amounts are
+// in the currency's minor unit.
+type PricingModel struct {
+	Currency     string
+	BaseFeeCents int64
+	PerKmCents   int64 // rate when no zone pair rate matches
+	ZonePairs    []ZonePairRate
+	Surcharges   []ScheduledSurcharge
+	Location     *time.Location // scheduled times are matched in this zone, UTC if nil
+}
+
+// ZonePairRate is the per-km rate between a pickup zone and a delivery zone.
+// Either zone may be AnyZone.
+type ZonePairRate struct {
+	PickupZone   string
+	DeliveryZone string
+	PerKmCents   int64
+}
+
+// ScheduledSurcharge adds Percent of the price to deliveries scheduled to
+// start between StartHour and EndHour on Weekdays. A window whose end is not
+// after its start runs past midnight; no weekdays means every day.
+type ScheduledSurcharge struct {
+	Name      string
+	Weekdays  []time.Weekday
+	StartHour int
+	EndHour   int
+	Percent   float64
+}
+
+// PriceRequest is what a delivery is priced by
+type PriceRequest struct {
+	DistanceKm    float64
+	PickupZones   []string   // active zones containing the pickup
+	DeliveryZones []string   // active zones containing the drop-off
+	ScheduledDate *time.Time // start of the scheduled window, nil if unscheduled
+}
+
+// Price is a priced delivery with the parts of its price
+type Price struct {
+	TotalCents     int64    `json:"total_cents"`
+	Currency       string   `json:"currency"`
+	BaseFeeCents   int64    `json:"base_fee_cents"`
+	DistanceCents  int64    `json:"distance_cents"`
+	SurchargeCents int64    `json:"surcharge_cents"`
+	DistanceKm     float64  `json:"distance_km"`
+	PerKmCents     int64    `json:"per_km_cents"`
+	PickupZone     string   `json:"pickup_zone,omitempty"`   // zone of the matched rate, empty for the default rate
+	DeliveryZone   string   `json:"delivery_zone,omitempty"` // zone of the matched rate, empty for the default rate
+	Surcharges     []string `json:"surcharges,omitempty"`    // names of the applied surcharges
+}
+
+// Price computes the price of a delivery. The first zone pair rate matching
+// one of the pickup zones and one of the delivery zones sets the per-km rate,
+// and every surcharge whose window contains the scheduled start applies.
+func (m PricingModel) Price(req PriceRequest) Price {
+	price := Price{
+		Currency:     m.Currency,
+		BaseFeeCents: m.BaseFeeCents,
+		DistanceKm:   math.Round(req.DistanceKm*100) / 100,
+		PerKmCents:   m.PerKmCents,
+	}
+	if rate, pickupZone, deliveryZone, ok := m.zonePairRate(req.PickupZones, req.DeliveryZones); ok {
+		price.PerKmCents = rate.PerKmCents
+		price.PickupZone, price.DeliveryZone = pickupZone, deliveryZone
+	}
+	price.DistanceCents = int64(math.Round(req.DistanceKm * float64(price.PerKmCents)))
+	subtotal := price.BaseFeeCents + price.DistanceCents
+
+	if req.ScheduledDate != nil {
+		var percent float64
+		for _, surcharge := range m.Surcharges {
+			if surcharge.applies(req.ScheduledDate.In(m.location())) {
+				percent += surcharge.Percent
+				price.Surcharges = append(price.Surcharges, surcharge.Name)
+			}
+		}
+		price.SurchargeCents = int64(math.Round(float64(subtotal) * percent / 100))
+	}
+
+	price.TotalCents = subtotal + price.SurchargeCents
+	return price
+}
+
+// zonePairRate returns the first rate matching the zones and the zones it matched
+func (m PricingModel) zonePairRate(pickupZones, deliveryZones []string) (ZonePairRate, string, string, bool) {
+	for _, rate := range m.ZonePairs {
+		pickupZone, ok := matchZone(rate.PickupZone, pickupZones)
+		if !ok {
+			continue
+		}
+		deliveryZone, ok := matchZone(rate.DeliveryZone, deliveryZones)
+		if !ok {
+			continue
+		}
+		return rate, pickupZone, deliveryZone, true
+	}
+	return ZonePairRate{}, "", "", false
+}
+
+// matchZone reports whether a rate's zone matches one of a point's zones,
+// returning the zone matched
+func matchZone(rateZone string, zones []string) (string, bool) {
+	if rateZone == AnyZone {
+		return rateZone, true
+	}
+	for _, zone := range zones {
+		if zone == rateZone {
+			return zone, true
+		}
+	}
+	return "", false
+}
+
+func (m PricingModel) location() *time.Location {
+	if m.Location == nil {
+		return time.UTC
+	}
+	return m.Location
+}
+
+// applies reports whether the surcharge window contains t
+func (s ScheduledSurcharge) applies(t time.Time) bool {
+	if len(s.Weekdays) > 0 {
+		matched := false
+		for _, day := range s.Weekdays {
+			if day == t.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	hour := t.Hour()
+	if s.EndHour > s.StartHour {
+		return hour >= s.StartHour && hour < s.EndHour
+	}
+	return hour >= s.StartHour || hour < s.EndHour
+}
+
+// Quote is a price offered for a delivery that CreateDelivery honours until
+// it expires
+type Quote struct {
+	Price
+	ExpiresAt time.Time `json:"expires_at"`
+	Token     string    `json:"quote_token"`
+}
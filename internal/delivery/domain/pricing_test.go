@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPricingModel_Price(t *testing.T) {
+	model := PricingModel{
+		Currency:     "USD",
+		BaseFeeCents: 500,
+		PerKmCents:   100,
+		ZonePairs: []ZonePairRate{
+			{PickupZone: "downtown", DeliveryZone: "downtown", PerKmCents: 80},
+			{PickupZone: "downtown", DeliveryZone: AnyZone, PerKmCents: 150},
+			{PickupZone: AnyZone, DeliveryZone: "airport", PerKmCents: 200},
+		},
+		Surcharges: []ScheduledSurcharge{
+			{Name: "night", StartHour: 22, EndHour: 6, Percent: 20},
+			{Name: "weekend", Weekdays: []time.Weekday{time.Saturday, time.Sunday}, StartHour: 0, EndHour: 0, Percent: 10},
+			{Name: "rush", Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, StartHour: 17, EndHour: 19, Percent: 15},
+		},
+	}
+	at := func(value string) *time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &parsed
+	}
+
+	tests := []struct {
+		name           string
+		req            PriceRequest
+		wantTotal      int64
+		wantPerKm      int64
+		wantZones      [2]string
+		wantSurcharges []string
+	}{
+		{
+			name:      "default rate outside every zone",
+			req:       PriceRequest{DistanceKm: 10},
+			wantTotal: 1500,
+			wantPerKm: 100,
+		},
+		{
+			name:      "exact zone pair",
+			req:       PriceRequest{DistanceKm: 10, PickupZones: []string{"downtown"}, DeliveryZones: []string{"downtown"}},
+			wantTotal: 1300,
+			wantPerKm: 80,
+			wantZones: [2]string{"downtown", "downtown"},
+		},
+		{
+			name:      "wildcard delivery zone",
+			req:       PriceRequest{DistanceKm: 10, PickupZones: []string{"downtown"}},
+			wantTotal: 2000,
+			wantPerKm: 150,
+			wantZones: [2]string{"downtown", AnyZone},
+		},
+		{
+			name:      "first matching pair wins",
+			req:       PriceRequest{DistanceKm: 10, PickupZones: []string{"downtown"}, DeliveryZones: []string{"airport"}},
+			wantTotal: 2000,
+			wantPerKm: 150,
+			wantZones: [2]string{"downtown", AnyZone},
+		},
+		{
+			name:      "any of several zones matches",
+			req:       PriceRequest{DistanceKm: 10, PickupZones: []string{"suburbs"}, DeliveryZones: []string{"north", "airport"}},
+			wantTotal: 2500,
+			wantPerKm: 200,
+			wantZones: [2]string{AnyZone, "airport"},
+		},
+		{
+			name:      "distance is rounded to the cent",
+			req:       PriceRequest{DistanceKm: 2.345},
+			wantTotal: 735,
+			wantPerKm: 100,
+		},
+		{
+			name:           "surcharge within its window",
+			req:            PriceRequest{DistanceKm: 10, ScheduledDate: at("2026-10-14T17:30:00Z")},
+			wantTotal:      1725,
+			wantPerKm:      100,
+			wantSurcharges: []string{"rush"},
+		},
+		{
+			name:      "window end is exclusive",
+			req:       PriceRequest{DistanceKm: 10, ScheduledDate: at("2026-10-14T19:00:00Z")},
+			wantTotal: 1500,
+			wantPerKm: 100,
+		},
+		{
+			name:           "overnight window after midnight",
+			req:            PriceRequest{DistanceKm: 10, ScheduledDate: at("2026-10-14T03:00:00Z")},
+			wantTotal:      1800,
+			wantPerKm:      100,
+			wantSurcharges: []string{"night"},
+		},
+		{
+			name:           "weekday filter and summed percents",
+			req:            PriceRequest{DistanceKm: 10, ScheduledDate: at("2026-10-17T23:00:00Z")},
+			wantTotal:      1950,
+			wantPerKm:      100,
+			wantSurcharges: []string{"night", "weekend"},
+		},
+		{
+			name:      "unscheduled deliveries pay no surcharge",
+			req:       PriceRequest{DistanceKm: 10},
+			wantTotal: 1500,
+			wantPerKm: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := model.Price(tt.req)
+
+			if price.TotalCents != tt.wantTotal {
+				t.Errorf("expected total %d, got %d (%+v)", tt.wantTotal, price.TotalCents, price)
+			}
+			if price.TotalCents != price.BaseFeeCents+price.DistanceCents+price.SurchargeCents {
+				t.Errorf("expected the parts to add up to the total, got %+v", price)
+			}
+			if price.PerKmCents != tt.wantPerKm {
+				t.Errorf("expected per-km rate %d, got %d", tt.wantPerKm, price.PerKmCents)
+			}
+			if zones := [2]string{price.PickupZone, price.DeliveryZone}; zones != tt.wantZones {
+				t.Errorf("expected zones %v, got %v", tt.wantZones, zones)
+			}
+			if !reflect.DeepEqual(price.Surcharges, tt.wantSurcharges) {
+				t.Errorf("expected surcharges %v, got %v", tt.wantSurcharges, price.Surcharges)
+			}
+			if price.Currency != "USD" {
+				t.Errorf("expected USD, got %q", price.Currency)
+			}
+		})
+	}
+}
+
+func TestPricingModel_PriceInLocation(t *testing.T) {
+	location := time.FixedZone("UTC+3", 3*60*60)
+	model := PricingModel{
+		BaseFeeCents: 1000,
+		Surcharges:   []ScheduledSurcharge{{Name: "night", StartHour: 22, EndHour: 6, Percent: 50}},
+		Location:     location,
+	}
+
+	// 20:00 UTC is 23:00 in the model's zone
+	scheduled := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+	price := model.Price(PriceRequest{ScheduledDate: &scheduled})
+
+	if price.SurchargeCents != 500 || price.TotalCents != 1500 {
+		t.Errorf("expected the night surcharge in the model's zone, got %+v", price)
+	}
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrCourierLocationUnknown = domainerr.New(codes.FailedPrecondition, "courier location unknown")
+)
+
+// RouteStop is a place a courier has to visit for one of their deliveries
+type RouteStop struct {
+	DeliveryID int
+	Latitude   float64
+	Longitude  float64
+}
+
+// CourierPosition is where a courier is and how fast they have been moving
+type CourierPosition struct {
+	Latitude        float64
+	Longitude       float64
+	AverageSpeedKmh float64 // 0 if unknown
+}
+
+// PlannedStop is a route stop in visiting order
+type PlannedStop struct {
+	DeliveryID           int       `json:"delivery_id"`
+	Sequence             int       `json:"sequence"`
+	Latitude             float64   `json:"latitude"`
+	Longitude            float64   `json:"longitude"`
+	DistanceFromPrevious float64   `json:"distance_from_previous_km"`
+	EstimatedArrival     time.Time `json:"estimated_arrival"`
+}
+
+// RoutePlan is an ordered stop list for a courier's active deliveries
+type RoutePlan struct {
+	CourierID                int           `json:"courier_id"`
+	Stops                    []PlannedStop `json:"stops"`
+	TotalDistance            float64       `json:"total_distance_km"`
+	EstimatedDurationSeconds int64         `json:"estimated_duration_seconds"`
+	AverageSpeedKmh          float64       `json:"average_speed_kmh"`
+	Unrouted                 []int         `json:"unrouted_delivery_ids,omitempty"` // deliveries without coordinates
+}
+
+// NextStop returns where a courier has to go next for a delivery: the
+// pickup while it is assigned, the drop-off once it is in transit. It
+// reports false when there is no next stop or its coordinates are unknown.
+func (d *Delivery) NextStop() (*Coordinates, bool) {
+	switch d.Status {
+	case StatusAssigned:
+		return d.PickupCoordinates()
+	case StatusInTransit:
+		return d.DeliveryCoordinates()
+	}
+	return nil, false
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrSlotFull            = domainerr.New(codes.ResourceExhausted, "delivery slot is full")
+	ErrSlotsUnavailable    = domainerr.New(codes.Unavailable, "slot availability is unavailable")
+	ErrInvalidSlotRequest  = domainerr.New(codes.InvalidArgument, "invalid slot request")
+	ErrInvalidSlotCapacity = domainerr.New(codes.InvalidArgument, "invalid slot capacity")
+)
+
+// SlotLength is the length of the delivery windows customers book
+const SlotLength = 2 * time.Hour
+
+// SlotCapacity is how many deliveries a zone takes in the slot starting at
+// StartHour each day
+type SlotCapacity struct {
+	StartHour int `json:"start_hour"`
+	Capacity  int `json:"capacity"`
+}
+
+// Window returns the slot's window on the day of date, in date's location
+func (c SlotCapacity) Window(date time.Time) (start, end time.Time) {
+	start = time.Date(date.Year(), date.Month(), date.Day(), c.StartHour, 0, 0, 0, date.Location())
+	end = time.Date(date.Year(), date.Month(), date.Day(), c.StartHour+int(SlotLength/time.Hour), 0, 0, 0, date.Location())
+	return start, end
+}
+
+// ValidateSlotCapacities checks that every slot starts on the hour within the
+// day, has a non-negative capacity and does not overlap another, and sorts
+// them by start hour
+func ValidateSlotCapacities(capacities []SlotCapacity) error {
+	sort.Slice(capacities, func(i, j int) bool { return capacities[i].StartHour < capacities[j].StartHour })
+
+	lastStart := 24 - int(SlotLength/time.Hour)
+	for i, c := range capacities {
+		if c.StartHour < 0 || c.StartHour > lastStart {
+			return fmt.Errorf("%w: start_hour must be between 0 and %d", ErrInvalidSlotCapacity, lastStart)
+		}
+		if c.Capacity < 0 {
+			return fmt.Errorf("%w: capacity must not be negative", ErrInvalidSlotCapacity)
+		}
+		if i > 0 && c.StartHour-capacities[i-1].StartHour < int(SlotLength/time.Hour) {
+			return fmt.Errorf("%w: slots starting at %d and %d overlap", ErrInvalidSlotCapacity, capacities[i-1].StartHour, c.StartHour)
+		}
+	}
+	return nil
+}
+
+// Slot is one window of a day in a zone and how much of it is still free
+type Slot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Capacity  int       `json:"capacity"`
+	Booked    int       `json:"booked"`
+	Available int       `json:"available"` // zero once the slot is full or has started
+}
+
+// SlotAvailability is the slots a zone offers on a day
+type SlotAvailability struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Zone  string `json:"zone"`
+	Slots []Slot `json:"slots"`
+}
+
+// SlotBooking is the slot a scheduled delivery is booked into
+type SlotBooking struct {
+	OrgID    int // organization whose capacity the slot counts against
+	Zone     string
+	Start    time.Time
+	End      time.Time
+	Capacity int
+}
+
+// FindSlot returns the booking of the slot among capacities whose window
+// contains at, matched in location
+func FindSlot(zone string, capacities []SlotCapacity, at time.Time, location *time.Location) (SlotBooking, bool) {
+	local := at.In(location)
+	for _, c := range capacities {
+		start, end := c.Window(local)
+		if !at.Before(start) && at.Before(end) {
+			return SlotBooking{Zone: zone, Start: start, End: end, Capacity: c.Capacity}, true
+		}
+	}
+	return SlotBooking{}, false
+}
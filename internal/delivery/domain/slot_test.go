@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateSlotCapacities(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacities []SlotCapacity
+		wantErr    bool
+	}{
+		{name: "no slots", capacities: nil},
+		{name: "adjacent slots", capacities: []SlotCapacity{{StartHour: 10, Capacity: 5}, {StartHour: 8, Capacity: 0}}},
+		{name: "last slot of the day", capacities: []SlotCapacity{{StartHour: 22, Capacity: 1}}},
+		{name: "past the end of the day", capacities: []SlotCapacity{{StartHour: 23, Capacity: 1}}, wantErr: true},
+		{name: "negative start hour", capacities: []SlotCapacity{{StartHour: -1, Capacity: 1}}, wantErr: true},
+		{name: "negative capacity", capacities: []SlotCapacity{{StartHour: 8, Capacity: -1}}, wantErr: true},
+		{name: "overlapping slots", capacities: []SlotCapacity{{StartHour: 8, Capacity: 5}, {StartHour: 9, Capacity: 5}}, wantErr: true},
+		{name: "duplicate slots", capacities: []SlotCapacity{{StartHour: 8, Capacity: 5}, {StartHour: 8, Capacity: 2}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSlotCapacities(tt.capacities)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSlotCapacity) {
+				t.Errorf("expected ErrInvalidSlotCapacity, got %v", err)
+			}
+			for i := 1; i < len(tt.capacities) && err == nil; i++ {
+				if tt.capacities[i-1].StartHour > tt.capacities[i].StartHour {
+					t.Errorf("expected slots sorted by start hour, got %+v", tt.capacities)
+				}
+			}
+		})
+	}
+}
+
+func TestFindSlot(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	capacities := []SlotCapacity{{StartHour: 8, Capacity: 4}, {StartHour: 10, Capacity: 2}}
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		wantFound bool
+		wantStart time.Time
+	}{
+		{name: "start of a slot", at: time.Date(2026, 10, 14, 6, 0, 0, 0, time.UTC), wantFound: true,
+			wantStart: time.Date(2026, 10, 14, 8, 0, 0, 0, location)},
+		{name: "within a slot", at: time.Date(2026, 10, 14, 9, 45, 0, 0, time.UTC), wantFound: true,
+			wantStart: time.Date(2026, 10, 14, 10, 0, 0, 0, location)},
+		{name: "slot end is exclusive", at: time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)},
+		{name: "before every slot", at: time.Date(2026, 10, 14, 5, 59, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, ok := FindSlot("downtown", capacities, tt.at, location)
+			if ok != tt.wantFound {
+				t.Fatalf("expected found %v, got %v", tt.wantFound, ok)
+			}
+			if !ok {
+				return
+			}
+			if !slot.Start.Equal(tt.wantStart) || !slot.End.Equal(tt.wantStart.Add(SlotLength)) || slot.Zone != "downtown" {
+				t.Errorf("expected the slot from %v, got %+v", tt.wantStart, slot)
+			}
+		})
+	}
+}
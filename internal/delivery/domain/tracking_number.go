@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrInvalidTrackingNumber = domainerr.New(codes.InvalidArgument, "invalid tracking number")
+)
+
+const (
+	trackingNumberPrefix = "DT-"
+	trackingNumberDigits = 6 // base32 digits before padding grows, ~1 billion IDs
+
+	// trackingAlphabet is Crockford's base32: no I, L, O or U, so numbers read
+	// out over the phone aren't mistaken for each other
+	trackingAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// TrackingNumberFor returns the tracking number for a delivery ID: "DT-", the
+// ID in base32 padded to six digits, and a Luhn mod 32 check character that
+// catches single mistyped characters and most swapped neighbours.
+func TrackingNumberFor(id int) string {
+	var digits []byte
+	for n := id; n > 0; n /= 32 {
+		digits = append([]byte{trackingAlphabet[n%32]}, digits...)
+	}
+	for len(digits) < trackingNumberDigits {
+		digits = append([]byte{'0'}, digits...)
+	}
+
+	return trackingNumberPrefix + string(digits) + string(trackingAlphabet[trackingCheck(string(digits))])
+}
+
+// NormalizeTrackingNumber returns the canonical form of a tracking number as
+// typed by a customer: case-insensitive, with or without the prefix and
+// hyphens, and with O, I and L read as 0, 1 and 1.
+func NormalizeTrackingNumber(s string) (string, error) {
+	s = strings.NewReplacer("-", "", " ", "", "O", "0", "I", "1", "L", "1").Replace(strings.ToUpper(s))
+	if strings.HasPrefix(s, "DT") && len(s) > trackingNumberDigits+1 {
+		s = s[2:]
+	}
+
+	if len(s) < trackingNumberDigits+1 {
+		return "", ErrInvalidTrackingNumber
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(trackingAlphabet, s[i]) < 0 {
+			return "", ErrInvalidTrackingNumber
+		}
+	}
+
+	digits, check := s[:len(s)-1], s[len(s)-1]
+	if trackingAlphabet[trackingCheck(digits)] != check {
+		return "", ErrInvalidTrackingNumber
+	}
+	return trackingNumberPrefix + s, nil
+}
+
+// trackingCheck returns the Luhn mod 32 check value for base32 digits
+func trackingCheck(digits string) int {
+	factor, sum := 2, 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(trackingAlphabet, digits[i])
+		sum += addend/32 + addend%32
+		factor = 3 - factor
+	}
+	return (32 - sum%32) % 32
+}
+
+// CoarseLocation is a location rounded to about ten kilometers
+type CoarseLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// TrackingView is what anyone holding a tracking number may see about a
+// delivery: its progress, without addresses, couriers or customer details
+type TrackingView struct {
+	TrackingNumber string          `json:"tracking_number"`
+	Status         string          `json:"status"`
+	Late           bool            `json:"late"`
+	ScheduledEnd   *time.Time      `json:"scheduled_end,omitempty"`
+	DeliveredDate  *time.Time      `json:"delivered_date,omitempty"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Area           *CoarseLocation `json:"area,omitempty"` // around the drop-off, when it was geocoded
+}
+
+// TrackingView returns the redacted public view of the delivery
+func (d *Delivery) TrackingView() *TrackingView {
+	return &TrackingView{
+		TrackingNumber: d.TrackingNumber,
+		Status:         d.Status,
+		Late:           d.Late,
+		ScheduledEnd:   d.ScheduledEnd,
+		DeliveredDate:  d.DeliveredDate,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
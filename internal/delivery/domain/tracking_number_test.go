@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTrackingNumberFor(t *testing.T) {
+	tests := []struct {
+		id       int
+		expected string
+	}{
+		{1, "DT-000001Y"},
+		{32, "DT-000010Z"},
+		{1234567, "DT-015NM7C"},
+	}
+
+	for _, tt := range tests {
+		if got := TrackingNumberFor(tt.id); got != tt.expected {
+			t.Errorf("TrackingNumberFor(%d) = %s, expected %s", tt.id, got, tt.expected)
+		}
+	}
+
+	seen := make(map[string]int)
+	for id := 1; id <= 5000; id++ {
+		number := TrackingNumberFor(id)
+		if other, ok := seen[number]; ok {
+			t.Fatalf("IDs %d and %d share tracking number %s", other, id, number)
+		}
+		seen[number] = id
+
+		normalized, err := NormalizeTrackingNumber(number)
+		if err != nil || normalized != number {
+			t.Fatalf("expected %s to normalize to itself, got %s, %v", number, normalized, err)
+		}
+	}
+}
+
+func TestNormalizeTrackingNumber(t *testing.T) {
+	number := TrackingNumberFor(1234567)
+
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr error
+	}{
+		{"canonical", number, nil},
+		{"lowercase", "dt-015nm7c", nil},
+		{"without prefix", "015NM7C", nil},
+		{"with spaces", " DT 015NM 7C ", nil},
+		{"mistyped character", "DT-015NN7C", ErrInvalidTrackingNumber},
+		{"swapped neighbours", "DT-01N5M7C", ErrInvalidTrackingNumber},
+		{"not base32", "DT-015NM7U", ErrInvalidTrackingNumber},
+		{"too short", "DT-01", ErrInvalidTrackingNumber},
+		{"empty", "", ErrInvalidTrackingNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTrackingNumber(tt.input)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %s, %v", tt.expectedErr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != number {
+				t.Errorf("expected %s, got %s", number, got)
+			}
+		})
+	}
+
+	// Crockford's look-alikes are read as digits
+	if got, err := NormalizeTrackingNumber("DT-OOOOOIY"); err != nil || got != "DT-000001Y" {
+		t.Errorf("expected DT-000001Y, got %s, %v", got, err)
+	}
+}
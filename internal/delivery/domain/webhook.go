@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrWebhookNotFound = domainerr.New(codes.NotFound, "webhook not found")
+	ErrInvalidWebhook  = domainerr.New(codes.InvalidArgument, "invalid webhook")
+)
+
+// MinWebhookSecretLength is the shortest signing secret a subscription accepts
+const MinWebhookSecretLength = 16
+
+// Webhook delivery status constants
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookEventTypes are the delivery events a subscription can receive
+var WebhookEventTypes = []string{
+	"delivery.created",
+	"delivery.status_changed",
+	"delivery.confirmed",
+	"delivery.late",
+	"delivery.cancelled",
+}
+
+// WebhookSubscription is a merchant endpoint notified of their deliveries' events
+type WebhookSubscription struct {
+	ID         int64     `json:"id"`
+	CustomerID int       `json:"customer_id"`
+	OrgID      int       `json:"-"` // organization of the customer
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"event_types"` // empty receives every event type
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// NewWebhookSubscription creates an active subscription with validation
+func NewWebhookSubscription(customerID int, rawURL, secret string, eventTypes []string) (*WebhookSubscription, error) {
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	now := time.Now()
+	sub := &WebhookSubscription{
+		CustomerID: customerID,
+		URL:        rawURL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := sub.Validate(); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Validate checks the subscription's URL, secret and event types
+func (s *WebhookSubscription) Validate() error {
+	if s.CustomerID <= 0 {
+		return ErrInvalidWebhook
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhook
+	}
+	if !isPublicHost(u.Hostname()) {
+		return ErrInvalidWebhook
+	}
+	if len(s.Secret) < MinWebhookSecretLength {
+		return ErrInvalidWebhook
+	}
+	for _, t := range s.EventTypes {
+		if !isWebhookEventType(t) {
+			return ErrInvalidWebhook
+		}
+	}
+	return nil
+}
+
+// nonPublicPrefixes are the special-purpose ranges, beyond those the netip
+// predicates cover, that webhooks must not reach
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which maps onto IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+}
+
+// IsPublicAddress reports whether webhooks may be sent to addr: it must not
+// be loopback, private, link-local (which holds cloud metadata endpoints),
+// multicast or otherwise special-purpose
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPublicHost rejects webhook hosts that are known not to be public without
+// resolving them: non-public IP literals and localhost names. Names that
+// resolve to non-public addresses are refused when dialled.
+func isPublicHost(host string) bool {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return IsPublicAddress(addr)
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	// Shorthand IPv4 forms such as "127.1" may be read as addresses
+	return !isNumericHost(host)
+}
+
+// isNumericHost reports whether host is made of digits and dots only, which
+// resolvers may read as an IPv4 address in a shorthand or octal form
+func isNumericHost(host string) bool {
+	for _, c := range host {
+		if (c < '0' || c > '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether the subscription receives events of eventType
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	if !s.Active || !isWebhookEventType(eventType) {
+		return false
+	}
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebhookEventType reports whether subscriptions may filter on eventType
+func isWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent, or waiting to be sent, to a subscription
+type WebhookDelivery struct {
+	ID             int64            `json:"id"`
+	SubscriptionID int64            `json:"subscription_id"`
+	EventID        string           `json:"event_id"`
+	EventType      string           `json:"event_type"`
+	Payload        []byte           `json:"-"`
+	Status         string           `json:"status"`
+	AttemptCount   int              `json:"attempt_count"`
+	NextAttemptAt  time.Time        `json:"next_attempt_at"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	Attempts       []WebhookAttempt `json:"attempts"`
+}
+
+// WebhookAttempt records one POST of a delivery to its subscription's URL
+type WebhookAttempt struct {
+	StatusCode  int       `json:"status_code,omitempty"` // 0 when no response was received
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// NewWebhookDelivery creates a pending delivery of an event to a subscription
+func NewWebhookDelivery(subscriptionID int64, eventID, eventType string, payload []byte) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
+
+// RecordAttempt adds an attempt to the delivery and moves it on: a 2xx
+// response succeeds it, a retryable failure schedules another attempt after
+// backoff, and anything else, or reaching maxAttempts, leaves it dead.
+func (d *WebhookDelivery) RecordAttempt(attempt WebhookAttempt, retryable bool, maxAttempts int, backoff time.Duration) {
+	d.AttemptCount++
+	d.Attempts = append(d.Attempts, attempt)
+
+	switch {
+	case attempt.StatusCode >= 200 && attempt.StatusCode < 300:
+		d.Status = WebhookDeliverySucceeded
+		d.DeliveredAt = &attempt.AttemptedAt
+	case !retryable || d.AttemptCount >= maxAttempts:
+		d.Status = WebhookDeliveryDead
+	default:
+		d.NextAttemptAt = attempt.AttemptedAt.Add(backoff)
+	}
+}
+
+// IsDead reports whether the delivery will not be attempted again
+func (d *WebhookDelivery) IsDead() bool {
+	return d.Status == WebhookDeliveryDead
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := IsPublicAddress(netip.MustParseAddr(tt.addr)); got != tt.public {
+				t.Errorf("expected %v, got %v", tt.public, got)
+			}
+		})
+	}
+}
+
+func TestWebhookSubscription_ValidateHost(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://merchant.example.com/hooks", true},
+		{"https://93.184.216.34/hooks", true},
+		{"http://127.0.0.1/hooks", false},
+		{"http://[::1]:8080/hooks", false},
+		{"http://localhost:8080/hooks", false},
+		{"http://api.localhost/hooks", false},
+		{"http://127.1/hooks", false},
+		{"http://10.0.0.5/hooks", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			sub := WebhookSubscription{CustomerID: 1, URL: tt.url, Secret: "0123456789abcdef"}
+			if err := sub.Validate(); (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
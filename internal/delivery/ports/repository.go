@@ -2,30 +2,236 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
 )
 
-// DeliveryRepository defines the interface for delivery data persistence
+// DeliveryRepository defines the interface for delivery data persistence.
+// Reads only see the organization of the caller whose claims are in ctx, see
+// authctx.OrgScope; super-admins and callers without claims see every
+// organization.
 type DeliveryRepository interface {
-	// Create stores a new delivery
+	// Create stores a new delivery, in the caller's organization unless its
+	// OrgID is set
 	Create(ctx context.Context, delivery *domain.Delivery) error
 
 	// GetByID retrieves a delivery by its ID
 	GetByID(ctx context.Context, id int) (*domain.Delivery, error)
 
-	// GetByStatus retrieves deliveries by status with optional customer filter
-	GetByStatus(ctx context.Context, status string, customerID int) ([]*domain.Delivery, error)
+	// GetByTrackingNumber retrieves a delivery by its tracking number
+	GetByTrackingNumber(ctx context.Context, trackingNumber string) (*domain.Delivery, error)
 
-	// GetAll retrieves all deliveries with optional customer filter
-	GetAll(ctx context.Context, customerID int) ([]*domain.Delivery, error)
+	// Search retrieves deliveries matching all set criteria, newest first
+	Search(ctx context.Context, criteria DeliverySearch) ([]*domain.Delivery, error)
+
+	// List retrieves the page of deliveries a filter selects in its order,
+	// with the number of deliveries it selects across all pages
+	List(ctx context.Context, filter DeliveryFilter) ([]*domain.Delivery, int, error)
 
 	// UpdateStatus updates the status of a delivery
 	UpdateStatus(ctx context.Context, id int, status, notes string) error
 
-	// AssignCourier assigns a courier to a delivery
-	AssignCourier(ctx context.Context, deliveryID, courierID int) error
+	// AssignCourier assigns a courier to a delivery, returning its new version.
+	// A non-zero expectedVersion must match the stored one, or a
+	// *domain.ConflictError is returned.
+	AssignCourier(ctx context.Context, deliveryID, courierID, expectedVersion int) (int, error)
 
-	// Update updates a delivery
+	// Update updates a delivery, returning a *domain.ConflictError if its
+	// non-zero Version is no longer the stored one, and sets its new Version
 	Update(ctx context.Context, delivery *domain.Delivery) error
+
+	// CreateWithOutbox stores a new delivery and the event built from it in a single transaction
+	CreateWithOutbox(ctx context.Context, delivery *domain.Delivery, buildEvent OutboxEventBuilder) error
+
+	// CreateBatchWithOutbox stores new deliveries and the events built from them in a single transaction
+	CreateBatchWithOutbox(ctx context.Context, deliveries []*domain.Delivery, buildEvent OutboxEventBuilder) error
+
+	// UpdateStatusWithOutbox updates the status of a delivery and stores the event in a single
+	// transaction, returning its new version. expectedVersion is checked as in AssignCourier.
+	UpdateStatusWithOutbox(ctx context.Context, id int, status, notes string, expectedVersion int, event *domain.OutboxEvent) (int, error)
+
+	// CancelWithOutbox stores a cancellation made on a delivery read with
+	// previousStatus, together with its event, in a single transaction
+	CancelWithOutbox(ctx context.Context, delivery *domain.Delivery, previousStatus string, event *domain.OutboxEvent) error
+
+	// GetOverdue retrieves up to limit open deliveries whose scheduled window
+	// ended before now and that are not flagged late yet
+	GetOverdue(ctx context.Context, now time.Time, limit int) ([]*domain.Delivery, error)
+
+	// MarkLateWithOutbox flags an overdue delivery as late and stores the event in a
+	// single transaction, returning domain.ErrNotOverdue if it no longer is
+	MarkLateWithOutbox(ctx context.Context, id int, event *domain.OutboxEvent) error
+
+	// ConfirmWithOutbox marks an in-transit delivery as delivered, stores its proof of
+	// delivery and the confirmed event in a single transaction
+	ConfirmWithOutbox(ctx context.Context, delivery *domain.Delivery, confirmation *domain.DeliveryConfirmation, event *domain.OutboxEvent) error
+
+	// CountBooked counts an organization's deliveries booked into a zone's
+	// slots that are scheduled to start in [start, end) and not cancelled
+	CountBooked(ctx context.Context, orgID int, zone string, start, end time.Time) (int, error)
+
+	// CreateInSlotWithOutbox stores a new delivery booked into a slot and the
+	// event built from it in a single transaction, returning
+	// domain.ErrSlotFull if the slot already holds its capacity. Concurrent
+	// bookings of the same slot are serialized.
+	CreateInSlotWithOutbox(ctx context.Context, delivery *domain.Delivery, slot domain.SlotBooking, buildEvent OutboxEventBuilder) error
+}
+
+// SlotCapacityRepository defines the interface for per-zone slot capacity
+// persistence. Each organization sets its own capacities for a zone.
+type SlotCapacityRepository interface {
+	// ListCapacities retrieves an organization's slot capacities in a zone by
+	// start hour. Its bookings in a zone without any are not limited.
+	ListCapacities(ctx context.Context, orgID int, zone string) ([]domain.SlotCapacity, error)
+
+	// ReplaceCapacities replaces all of an organization's slot capacities in a zone
+	ReplaceCapacities(ctx context.Context, orgID int, zone string, capacities []domain.SlotCapacity) error
+}
+
+// CourierRepository defines the interface for courier availability
+// persistence. Reads are scoped to the caller's organization like
+// DeliveryRepository's.
+type CourierRepository interface {
+	// GetByID retrieves a courier by their ID
+	GetByID(ctx context.Context, id int) (*domain.Courier, error)
+
+	// List retrieves couriers, optionally only those with the given status
+	List(ctx context.Context, status string) ([]*domain.Courier, error)
+
+	// UpdateStatus sets a courier's availability and when it changed
+	UpdateStatus(ctx context.Context, id int, status string, at time.Time) error
+
+	// ReleaseIfIdle makes a busy courier available again once none of their
+	// deliveries are assigned or in transit
+	ReleaseIfIdle(ctx context.Context, id int, at time.Time) error
+}
+
+// DeliverySearch holds delivery search criteria; zero values match everything
+type DeliverySearch struct {
+	TrackingNumber    string
+	PickupContains    string     // case-insensitive substring of the pickup location
+	From              *time.Time // created at or after
+	To                *time.Time // created before
+	CustomerID        int
+	ViewableByCourier *int // only deliveries assigned to this courier or still pending
+	Limit             int
+}
+
+// DeliveryFilter selects deliveries to list; zero values match everything
+type DeliveryFilter struct {
+	OrgID          int      // in this organization; the caller's when 0
+	Statuses       []string // any of these
+	CustomerID     int
+	CourierID      int   // assigned to this courier
+	Late           *bool // flagged late (true) or not (false)
+	TrackingNumber string
+	From           *time.Time       // created at or after
+	To             *time.Time       // created before
+	DeliveredFrom  *time.Time       // delivered at or after
+	DeliveredTo    *time.Time       // delivered before
+	Order          domain.ListOrder // newest first when zero; ties are broken by ID in the same direction
+	Limit          int              // all of them when 0
+	Offset         int
+}
+
+// OutboxEventBuilder builds an outbox event from a persisted delivery, once
+// database-generated fields such as the ID are known
+type OutboxEventBuilder func(delivery *domain.Delivery) (*domain.OutboxEvent, error)
+
+// OutboxRepository defines the interface for outbox event persistence
+type OutboxRepository interface {
+	// FetchPending claims up to limit pending events that are due for a
+	// publish attempt, hiding them from other callers for lease
+	FetchPending(ctx context.Context, limit int, lease time.Duration) ([]*domain.OutboxEvent, error)
+
+	// MarkSent marks an event as successfully published
+	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+
+	// MarkFailed records a failed publish attempt, including the next attempt time or dead state
+	MarkFailed(ctx context.Context, event *domain.OutboxEvent) error
+
+	// Stats returns pending/dead counts and the age of the oldest pending event
+	Stats(ctx context.Context) (*domain.OutboxStats, error)
+
+	// ListPublished retrieves sent and dead events in the filter's range,
+	// oldest first
+	ListPublished(ctx context.Context, filter OutboxRangeFilter) ([]*domain.OutboxEvent, error)
+}
+
+// OutboxRangeFilter selects outbox events by creation time, paged by ID
+type OutboxRangeFilter struct {
+	From        time.Time // created at or after
+	To          time.Time // created before
+	RoutingKeys []string  // any when empty
+	AfterID     int64
+	Limit       int
+}
+
+// BlobStore defines the interface for binary object storage such as proof-of-delivery images
+type BlobStore interface {
+	// Put stores data under key, replacing any existing object
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get retrieves the data stored under key
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes the object stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// CourierLocator defines the interface for looking up where a courier is
+type CourierLocator interface {
+	// Locate returns a courier's last known position, or
+	// domain.ErrCourierLocationUnknown if they haven't reported one
+	Locate(ctx context.Context, courierID int) (*domain.CourierPosition, error)
+}
+
+// ZoneLocator defines the interface for looking up the delivery zones a point is in
+type ZoneLocator interface {
+	// ZonesAt returns the names of the active delivery zones containing the point
+	ZonesAt(ctx context.Context, point domain.Coordinates) ([]string, error)
+}
+
+// DeliveryTracker defines the interface for reading a delivery's live
+// tracking data
+type DeliveryTracker interface {
+	// CurrentLocation returns a delivery's latest reported position
+	CurrentLocation(ctx context.Context, deliveryID int) (*domain.TrackedLocation, error)
+
+	// ETA estimates when a delivery reaches destination from its latest position
+	ETA(ctx context.Context, deliveryID int, destination domain.Coordinates) (*domain.DeliveryETA, error)
+}
+
+// WebhookRepository defines the interface for webhook subscription and delivery persistence
+type WebhookRepository interface {
+	// CreateSubscription stores a new subscription, setting its ID
+	CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+
+	// GetSubscription retrieves a subscription by its ID
+	GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error)
+
+	// ListSubscriptions retrieves a customer's subscriptions, oldest first
+	ListSubscriptions(ctx context.Context, customerID int) ([]*domain.WebhookSubscription, error)
+
+	// UpdateSubscription stores a subscription's URL, secret, event types and active flag
+	UpdateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+
+	// DeleteSubscription removes a subscription and its deliveries
+	DeleteSubscription(ctx context.Context, id int64) error
+
+	// EnqueueDeliveries stores pending deliveries, skipping any whose event
+	// was already queued for the same subscription
+	EnqueueDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error
+
+	// FetchDueDeliveries claims up to limit pending deliveries whose next
+	// attempt is due, hiding them from other callers for lease
+	FetchDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error)
+
+	// SaveAttempt stores a delivery's status after its latest attempt, together with that attempt
+	SaveAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) error
+
+	// ListDeliveries retrieves up to limit of a subscription's deliveries with
+	// their attempts, newest first
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error)
 }
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
 )
@@ -15,12 +16,66 @@ type AuthContext struct {
 
 // CreateDeliveryRequest for creating a new delivery
 type CreateDeliveryRequest struct {
-	CustomerID       int     `json:"customer_id"`
-	CourierID        *int    `json:"courier_id,omitempty"`
-	PickupLocation   string  `json:"pickup_location"`   // Can be coordinates "(lng,lat)" or address
-	DeliveryLocation string  `json:"delivery_location"` // Can be coordinates "(lng,lat)" or address
-	Notes            string  `json:"notes,omitempty"`
-	ScheduledDate    *string `json:"scheduled_date,omitempty"`
+	CustomerID       int      `json:"customer_id"`
+	CourierID        *int     `json:"courier_id,omitempty"`
+	PickupLocation   string   `json:"pickup_location,omitempty"`   // Legacy free-text address or "(lng,lat)", used when PickupAddress is not set
+	DeliveryLocation string   `json:"delivery_location,omitempty"` // Legacy free-text address or "(lng,lat)", used when DeliveryAddress is not set
+	PickupAddress    *Address `json:"pickup_address,omitempty"`
+	DeliveryAddress  *Address `json:"delivery_address,omitempty"`
+	Notes            string   `json:"notes,omitempty"`
+	ScheduledDate    *string  `json:"scheduled_date,omitempty"` // window start, RFC3339
+	ScheduledEnd     *string  `json:"scheduled_end,omitempty"`  // window end, RFC3339
+	QuoteToken       string   `json:"quote_token,omitempty"`    // honours the quoted price until the quote expires
+	OrgID            int      `json:"org_id,omitempty"`         // super-admins only; the caller's organization when 0
+}
+
+// HasLocations reports whether both the pickup and the drop-off are given,
+// as structured addresses or legacy locations
+func (r CreateDeliveryRequest) HasLocations() bool {
+	return (r.PickupAddress != nil || r.PickupLocation != "") && (r.DeliveryAddress != nil || r.DeliveryLocation != "")
+}
+
+// Address is a structured pickup or drop-off address. Addresses without
+// coordinates are geocoded; latitude and longitude must be given together.
+type Address struct {
+	Line1      string   `json:"line1"`
+	City       string   `json:"city,omitempty"`
+	PostalCode string   `json:"postal_code,omitempty"`
+	Country    string   `json:"country,omitempty"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
+}
+
+// QuoteDeliveryRequest for pricing a delivery before it is created. Locations
+// are given as for CreateDeliveryRequest and must resolve to coordinates.
+type QuoteDeliveryRequest struct {
+	PickupLocation   string   `json:"pickup_location,omitempty"`
+	DeliveryLocation string   `json:"delivery_location,omitempty"`
+	PickupAddress    *Address `json:"pickup_address,omitempty"`
+	DeliveryAddress  *Address `json:"delivery_address,omitempty"`
+	ScheduledDate    *string  `json:"scheduled_date,omitempty"` // window start, RFC3339
+	AuthContext
+}
+
+// SlotAvailabilityRequest for listing the delivery slots a zone offers on a day
+type SlotAvailabilityRequest struct {
+	Date string `json:"date"` // YYYY-MM-DD, in the slot timezone
+	Zone string `json:"zone"`
+	AuthContext
+}
+
+// UpdateSlotCapacitiesRequest for replacing the slots a zone offers and their capacity
+type UpdateSlotCapacitiesRequest struct {
+	OrgID int                   `json:"org_id,omitempty"` // super-admins only; the caller's organization when 0
+	Zone  string                `json:"zone"`
+	Slots []domain.SlotCapacity `json:"slots"` // no slots lifts the zone's limits
+	AuthContext
+}
+
+// BulkCreateDeliveriesRequest for creating a batch of deliveries at once
+type BulkCreateDeliveriesRequest struct {
+	Deliveries []CreateDeliveryRequest `json:"deliveries"`
+	AuthContext // Embedded for auth
 }
 
 // GetDeliveryRequest for retrieving a delivery
@@ -29,18 +84,85 @@ type GetDeliveryRequest struct {
 	AuthContext // Embedded for auth
 }
 
+// GetDeliveryDetailsRequest for retrieving a delivery with live tracking data
+type GetDeliveryDetailsRequest struct {
+	ID              int  `json:"id"`
+	IncludeLocation bool `json:"include_location"`
+	IncludeETA      bool `json:"include_eta"`
+	AuthContext
+}
+
 // ListDeliveriesRequest for listing deliveries
 type ListDeliveriesRequest struct {
-	Status     string `json:"status,omitempty"`
-	CustomerID int    `json:"customer_id"`
+	Statuses   []string `json:"statuses,omitempty"` // deliveries in any of these statuses; empty matches all
+	CustomerID int      `json:"customer_id"`
+	CourierID  int      `json:"courier_id,omitempty"` // only deliveries assigned to this courier when > 0
+	Late       *bool    `json:"late,omitempty"`       // only late (true) or not late (false) deliveries
+	Sort       string   `json:"sort,omitempty"`       // created_at (default), updated_at or scheduled_date
+	Order      string   `json:"order,omitempty"`      // asc or desc (default)
+	Limit      int      `json:"limit,omitempty"`      // page size; all deliveries when 0
+	Offset     int      `json:"offset,omitempty"`
+
+	DeliveredFrom *time.Time `json:"delivered_from,omitempty"` // only deliveries delivered at or after
+	DeliveredTo   *time.Time `json:"delivered_to,omitempty"`   // only deliveries delivered before
+
+	AuthContext // Embedded for auth
+}
+
+// SearchDeliveriesRequest for searching deliveries; unset filters match everything
+type SearchDeliveriesRequest struct {
+	TrackingNumber string     `json:"tracking_number,omitempty"`
+	PickupContains string     `json:"pickup_contains,omitempty"`
+	From           *time.Time `json:"from,omitempty"` // created at or after
+	To             *time.Time `json:"to,omitempty"`   // created before
 	AuthContext // Embedded for auth
 }
 
 // UpdateDeliveryStatusRequest for updating status
 type UpdateDeliveryStatusRequest struct {
-	ID     int    `json:"id"`
-	Status string `json:"status"`
-	Notes  string `json:"notes,omitempty"`
+	ID              int    `json:"id"`
+	Status          string `json:"status"`
+	Notes           string `json:"notes,omitempty"`
+	ExpectedVersion int    `json:"expected_version,omitempty"` // zero skips the version check
+	AuthContext // Embedded for auth
+}
+
+// ConfirmDeliveryRequest for capturing proof of delivery
+type ConfirmDeliveryRequest struct {
+	ID               int    `json:"id"`
+	Photo            []byte `json:"photo,omitempty"`     // base64 in JSON
+	Signature        []byte `json:"signature,omitempty"` // base64 in JSON
+	RecipientName    string `json:"recipient_name,omitempty"`
+	ConfirmationCode string `json:"confirmation_code,omitempty"` // must be the one the delivery's customer was given
+	Notes            string `json:"notes,omitempty"`
+	AuthContext // Embedded for auth
+}
+
+// CancelDeliveryRequest for cancelling a delivery without deleting it
+type CancelDeliveryRequest struct {
+	ID         int    `json:"id"`
+	Reason     string `json:"reason"`
+	ReasonCode string `json:"reason_code,omitempty"` // customer_request, address_invalid, courier_unavailable or other
+	AuthContext // Embedded for auth
+}
+
+// UpdateCourierStatusRequest for a courier reporting their availability
+type UpdateCourierStatusRequest struct {
+	Status string `json:"status"` // available, busy or offline
+	AuthContext // Embedded for auth
+}
+
+// ListCouriersRequest for listing couriers
+type ListCouriersRequest struct {
+	Status string `json:"status,omitempty"`
+	AuthContext // Embedded for auth
+}
+
+// OptimizeRouteRequest for planning the stop order of a courier's active deliveries
+type OptimizeRouteRequest struct {
+	CourierID   int                     `json:"courier_id"`
+	DeliveryIDs []int                   `json:"delivery_ids,omitempty"` // all active deliveries when empty
+	Start       *domain.CourierPosition `json:"start,omitempty"`        // courier's last known location when nil
 	AuthContext // Embedded for auth
 }
 
@@ -49,12 +171,131 @@ type DeliveryService interface {
 	// CreateDelivery creates a new delivery
 	CreateDelivery(ctx context.Context, req CreateDeliveryRequest) (*domain.Delivery, error)
 
+	// BulkCreateDeliveries creates the valid rows of a batch in one transaction and reports each row's outcome
+	BulkCreateDeliveries(ctx context.Context, req BulkCreateDeliveriesRequest) (*domain.BulkCreateResult, error)
+
 	// GetDelivery retrieves a delivery by ID
 	GetDelivery(ctx context.Context, req GetDeliveryRequest) (*domain.Delivery, error)
 
-	// ListDeliveries lists deliveries with optional filters
-	ListDeliveries(ctx context.Context, req ListDeliveriesRequest) ([]*domain.Delivery, error)
+	// QuoteDelivery prices a delivery and signs a quote CreateDelivery honours until it expires
+	QuoteDelivery(ctx context.Context, req QuoteDeliveryRequest) (*domain.Quote, error)
+
+	// GetSlotAvailability lists a zone's slots on a day with the capacity
+	// still free in each
+	GetSlotAvailability(ctx context.Context, req SlotAvailabilityRequest) (*domain.SlotAvailability, error)
+
+	// GetSlotCapacities retrieves the slots a zone offers an organization and
+	// their capacity; orgID 0 is the caller's organization
+	GetSlotCapacities(ctx context.Context, orgID int, zone string, auth AuthContext) ([]domain.SlotCapacity, error)
+
+	// UpdateSlotCapacities replaces the slots a zone offers and their capacity
+	UpdateSlotCapacities(ctx context.Context, req UpdateSlotCapacitiesRequest) ([]domain.SlotCapacity, error)
+
+	// GetDeliveryDetails retrieves a delivery with its latest location and ETA as requested
+	GetDeliveryDetails(ctx context.Context, req GetDeliveryDetailsRequest) (*domain.DeliveryDetails, error)
+
+	// ListDeliveries lists a page of deliveries with optional filters, and
+	// how many there are on all pages
+	ListDeliveries(ctx context.Context, req ListDeliveriesRequest) ([]*domain.Delivery, int, error)
+
+	// SearchDeliveries finds the deliveries the caller may see that match the request
+	SearchDeliveries(ctx context.Context, req SearchDeliveriesRequest) ([]*domain.Delivery, error)
+
+	// TrackByNumber returns the public view of a delivery; it needs no authorization
+	TrackByNumber(ctx context.Context, trackingNumber string) (*domain.TrackingView, error)
+
+	// UpdateDeliveryStatus updates a delivery status, returning a
+	// *domain.ConflictError if a non-zero ExpectedVersion is stale
+	UpdateDeliveryStatus(ctx context.Context, req UpdateDeliveryStatusRequest) (*domain.Delivery, error)
+
+	// GetConfirmationCode returns the code a delivery's customer hands the
+	// courier on delivery; couriers may not read it
+	GetConfirmationCode(ctx context.Context, req GetDeliveryRequest) (string, error)
+
+	// ConfirmDelivery records proof of delivery and marks the delivery as delivered
+	ConfirmDelivery(ctx context.Context, req ConfirmDeliveryRequest) (*domain.DeliveryConfirmation, error)
+
+	// CancelDelivery cancels a delivery and records the reason
+	CancelDelivery(ctx context.Context, req CancelDeliveryRequest) (*domain.Delivery, error)
+
+	// OptimizeRoute orders a courier's active deliveries into a stop list
+	OptimizeRoute(ctx context.Context, req OptimizeRouteRequest) (*domain.RoutePlan, error)
+}
+
+// CourierService defines the interface for courier availability operations
+type CourierService interface {
+	// UpdateCourierStatus sets the calling courier's availability
+	UpdateCourierStatus(ctx context.Context, req UpdateCourierStatusRequest) (*domain.Courier, error)
+
+	// ListCouriers lists couriers with an optional status filter
+	ListCouriers(ctx context.Context, req ListCouriersRequest) ([]*domain.Courier, error)
+}
+
+// CreateWebhookRequest for registering a webhook subscription
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types,omitempty"` // every event type when empty
+	AuthContext // Embedded for auth
+}
+
+// UpdateWebhookRequest for changing a webhook subscription; nil fields are left unchanged
+type UpdateWebhookRequest struct {
+	ID         int64     `json:"id"`
+	URL        *string   `json:"url,omitempty"`
+	Secret     *string   `json:"secret,omitempty"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+	Active     *bool     `json:"active,omitempty"`
+	AuthContext // Embedded for auth
+}
+
+// WebhookRequest identifies a webhook subscription the caller acts on
+type WebhookRequest struct {
+	ID int64 `json:"id"`
+	AuthContext // Embedded for auth
+}
+
+// ListWebhookDeliveriesRequest for inspecting a subscription's recent deliveries
+type ListWebhookDeliveriesRequest struct {
+	ID    int64 `json:"id"`
+	Limit int   `json:"limit,omitempty"`
+	AuthContext // Embedded for auth
+}
+
+// ReplayEventsRequest selects the delivery events to republish. Events are
+// published to RoutingKey, or their original routing key when it is empty.
+type ReplayEventsRequest struct {
+	EntityType  string      `json:"entity_type"`
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	RoutingKey  string      `json:"routing_key,omitempty"`
+	EventTypes  []string    `json:"event_types,omitempty"` // original routing keys, all when empty
+	AuthContext AuthContext `json:"-"`
+}
+
+// EventReplayService defines the interface for republishing past events
+type EventReplayService interface {
+	// ReplayEvents republishes the events of a time range, marked as replayed
+	ReplayEvents(ctx context.Context, req ReplayEventsRequest) (*domain.ReplayResult, error)
+}
+
+// WebhookService defines the interface for webhook subscription operations
+type WebhookService interface {
+	// CreateWebhook registers a subscription for the calling customer
+	CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*domain.WebhookSubscription, error)
+
+	// ListWebhooks lists the calling customer's subscriptions
+	ListWebhooks(ctx context.Context, auth AuthContext) ([]*domain.WebhookSubscription, error)
+
+	// GetWebhook retrieves a subscription the caller owns
+	GetWebhook(ctx context.Context, req WebhookRequest) (*domain.WebhookSubscription, error)
+
+	// UpdateWebhook changes a subscription the caller owns
+	UpdateWebhook(ctx context.Context, req UpdateWebhookRequest) (*domain.WebhookSubscription, error)
+
+	// DeleteWebhook removes a subscription the caller owns
+	DeleteWebhook(ctx context.Context, req WebhookRequest) error
 
-	// UpdateDeliveryStatus updates a delivery status
-	UpdateDeliveryStatus(ctx context.Context, req UpdateDeliveryStatusRequest) error
+	// ListWebhookDeliveries lists a subscription's recent deliveries and their attempts
+	ListWebhookDeliveries(ctx context.Context, req ListWebhookDeliveriesRequest) ([]*domain.WebhookDelivery, error)
 }
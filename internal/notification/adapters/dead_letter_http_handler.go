@@ -0,0 +1,116 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// defaultDeadLetterLimit is how many dead letters are listed when no limit is given
+const defaultDeadLetterLimit = 20
+
+// DeadLetterHTTPHandler handles super-admin HTTP requests for dead-lettered events
+type DeadLetterHTTPHandler struct {
+	service ports.DeadLetterService
+}
+
+// NewDeadLetterHTTPHandler creates a new dead letter HTTP handler
+func NewDeadLetterHTTPHandler(service ports.DeadLetterService) *DeadLetterHTTPHandler {
+	return &DeadLetterHTTPHandler{service: service}
+}
+
+// requireSuperAdmin rejects callers that are not super-admins. Dead letters
+// hold every organization's events and aren't recorded with one, so admins
+// confined to an organization may neither read nor retry them.
+func requireSuperAdmin(w http.ResponseWriter, r *http.Request) bool {
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return false
+	}
+	if _, scoped := authctx.OrgScope(r.Context()); scoped || (userCtx.Role != "admin" && userCtx.Role != "super_admin") {
+		httputil.SendErrorResponse(w, "unauthorized access", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// List handles GET /admin/dead-letters?limit=
+func (h *DeadLetterHTTPHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !requireSuperAdmin(w, r) {
+		return
+	}
+
+	limit := defaultDeadLetterLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			httputil.SendErrorResponse(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	stats, err := h.service.Stats(r.Context())
+	if err != nil {
+		httputil.SendErrorResponse(w, "Failed to count dead letters", http.StatusInternalServerError)
+		return
+	}
+	letters, err := h.service.List(r.Context(), limit)
+	if err != nil {
+		httputil.SendErrorResponse(w, "Failed to list dead letters", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stats":        stats,
+		"dead_letters": letters,
+	})
+}
+
+// Retry handles POST /admin/dead-letters/{id}/retry
+func (h *DeadLetterHTTPHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "retry_dead_letter_http")
+
+	if !requireSuperAdmin(w, r) {
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		httputil.SendErrorResponse(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return
+	}
+
+	letter, err := h.service.Retry(traceCtx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, messaging.ErrDeadLetterNotFound):
+			httputil.SendErrorResponse(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, messaging.ErrDeadLetterExhausted), errors.Is(err, messaging.ErrDeadLetterRetried):
+			httputil.SendErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			httputil.SendErrorResponse(w, "Failed to retry dead letter", http.StatusBadGateway)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letter)
+}
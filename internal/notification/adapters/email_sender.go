@@ -0,0 +1,162 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// SMTPConfig holds the mail server an SMTPEmailSender relays through
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // empty skips authentication
+	Password string
+	From     string // sender, e.g. "DeliverTrack <no-reply@example.com>"
+}
+
+// SMTPEmailSender implements the EmailSender interface over SMTP, upgrading
+// to TLS with STARTTLS whenever the server offers it
+type SMTPEmailSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPEmailSender creates a new SMTP email sender
+func NewSMTPEmailSender(config SMTPConfig) *SMTPEmailSender {
+	return &SMTPEmailSender{config: config}
+}
+
+// Send delivers an email as a multipart text and HTML message
+func (s *SMTPEmailSender) Send(ctx context.Context, message ports.EmailMessage) error {
+	from, err := mail.ParseAddress(s.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	body, err := buildMIMEMessage(s.config.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet mail server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate with mail server: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("mail server rejected sender: %w", err)
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return fmt.Errorf("mail server rejected recipient: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail server rejected message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// buildMIMEMessage renders an email as multipart/alternative with the text
+// part first, so clients that can show HTML prefer it
+func buildMIMEMessage(from string, message ports.EmailMessage, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", message.TextBody},
+		{"text/html; charset=utf-8", message.HTMLBody},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", message.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// NoopEmailSender logs emails instead of sending them, for development
+type NoopEmailSender struct {
+	logger *logger.Logger
+}
+
+// NewNoopEmailSender creates an email sender that only logs
+func NewNoopEmailSender(logger *logger.Logger) *NoopEmailSender {
+	return &NoopEmailSender{logger: logger}
+}
+
+// Send logs the email and reports success
+func (s *NoopEmailSender) Send(ctx context.Context, message ports.EmailMessage) error {
+	s.logger.InfoWithFields(ctx, "Email not sent, noop sender configured",
+		zap.String("to", message.To),
+		zap.String("subject", message.Subject))
+	return nil
+}
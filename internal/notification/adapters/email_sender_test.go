@@ -0,0 +1,173 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+)
+
+var testEmail = ports.EmailMessage{
+	To:       "ada@example.com",
+	Subject:  "Your delivery DT-000010 was delivered",
+	TextBody: "Your delivery was delivered.",
+	HTMLBody: "<p>Your delivery was <strong>delivered</strong>.</p>",
+}
+
+// readMIMEParts parses a message built by buildMIMEMessage into its headers and part bodies
+func readMIMEParts(t *testing.T, raw []byte) (*mail.Message, map[string]string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(part)
+		parts[contentType] = string(body)
+	}
+	return msg, parts
+}
+
+func TestBuildMIMEMessage(t *testing.T) {
+	raw, err := buildMIMEMessage("DeliverTrack <no-reply@example.com>", testEmail, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, parts := readMIMEParts(t, raw)
+	if msg.Header.Get("To") != testEmail.To || msg.Header.Get("Subject") != testEmail.Subject {
+		t.Errorf("unexpected headers: %v", msg.Header)
+	}
+	if parts["text/plain"] != testEmail.TextBody {
+		t.Errorf("unexpected text part %q", parts["text/plain"])
+	}
+	if parts["text/html"] != testEmail.HTMLBody {
+		t.Errorf("unexpected HTML part %q", parts["text/html"])
+	}
+}
+
+// fakeSMTPServer accepts one message and hands its envelope and data to received
+func fakeSMTPServer(t *testing.T, received chan<- []string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 fake ESMTP")
+
+		var envelope []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); verb {
+			case "EHLO", "HELO":
+				reply("250 fake")
+			case "MAIL", "RCPT":
+				envelope = append(envelope, line)
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					dataLine, err := r.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				received <- append(envelope, data.String())
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestSMTPEmailSender_Send(t *testing.T) {
+	received := make(chan []string, 1)
+	addr := fakeSMTPServer(t, received)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+
+	sender := NewSMTPEmailSender(SMTPConfig{Host: host, Port: portNum, From: "DeliverTrack <no-reply@example.com>"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := sender.Send(ctx, testEmail); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got[0] != "MAIL FROM:<no-reply@example.com>" || got[1] != "RCPT TO:<ada@example.com>" {
+			t.Errorf("unexpected envelope %v", got[:2])
+		}
+		_, parts := readMIMEParts(t, []byte(got[2]))
+		if parts["text/plain"] != testEmail.TextBody {
+			t.Errorf("unexpected text part %q", parts["text/plain"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mail server received nothing")
+	}
+}
+
+func TestSMTPEmailSender_SendUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+
+	sender := NewSMTPEmailSender(SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "no-reply@example.com"})
+	if err := sender.Send(context.Background(), testEmail); err == nil {
+		t.Error("expected an error when the mail server is unreachable")
+	}
+}
@@ -0,0 +1,270 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/httpclient"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	fcmDefaultEndpoint = "https://fcm.googleapis.com"
+	fcmDefaultTokenURI = "https://oauth2.googleapis.com/token"
+	fcmMessagingScope  = "https://www.googleapis.com/auth/firebase.messaging"
+
+	// fcmTokenRefreshMargin renews access tokens this long before they expire
+	fcmTokenRefreshMargin = time.Minute
+)
+
+// FCMConfig holds the Firebase project an FCMPushSender sends through
+type FCMConfig struct {
+	ProjectID   string          // defaults to the service account's project
+	Credentials []byte          // service account key file contents
+	Endpoint    string          // defaults to https://fcm.googleapis.com
+	HTTPClient  httpclient.Doer // sends each attempt; defaults to a plain *http.Client
+}
+
+// fcmServiceAccount is the part of a Google service account key file FCM needs
+type fcmServiceAccount struct {
+	ProjectID    string `json:"project_id"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// FCMPushSender implements the PushSender interface with the FCM HTTP v1 API.
+// Access tokens are obtained with the service account's signed JWT and
+// cached until shortly before they expire. Requests are retried on 429 and
+// unavailable responses, as FCM asks.
+type FCMPushSender struct {
+	projectID string
+	endpoint  string
+	client    *httpclient.Client
+	account   fcmServiceAccount
+	key       *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMPushSender creates a new FCM push sender from a service account key
+func NewFCMPushSender(config FCMConfig, logger *logger.Logger) (*FCMPushSender, error) {
+	var account fcmServiceAccount
+	if err := json.Unmarshal(config.Credentials, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse FCM credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("FCM credentials missing client_email or private_key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM private key: %w", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = fcmDefaultTokenURI
+	}
+
+	sender := &FCMPushSender{
+		projectID: config.ProjectID,
+		endpoint:  strings.TrimSuffix(config.Endpoint, "/"),
+		client:    httpclient.New(config.HTTPClient, httpclient.DefaultConfig(), logger),
+		account:   account,
+		key:       key,
+	}
+	if sender.projectID == "" {
+		sender.projectID = account.ProjectID
+	}
+	if sender.projectID == "" {
+		return nil, errors.New("FCM project ID not configured")
+	}
+	if sender.endpoint == "" {
+		sender.endpoint = fcmDefaultEndpoint
+	}
+	return sender, nil
+}
+
+// fcmMessage is the FCM v1 messages:send request body
+type fcmMessage struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data,omitempty"`
+	} `json:"message"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// fcmErrorResponse is the error body returned by FCM v1
+type fcmErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// unregistered reports whether FCM rejected the token as no longer valid.
+// Only the UNREGISTERED code counts: a bare 404 also covers a wrong project.
+func (e fcmErrorResponse) unregistered() bool {
+	for _, detail := range e.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return true
+		}
+	}
+	return false
+}
+
+// Send delivers a push notification to one device through FCM
+func (s *FCMPushSender) Send(ctx context.Context, device *domain.Device, message ports.PushMessage) error {
+	var body fcmMessage
+	body.Message.Token = device.Token
+	body.Message.Notification = fcmNotification{Title: message.Title, Body: message.Body}
+	body.Message.Data = message.Data
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode FCM message: %w", err)
+	}
+
+	accessToken, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	sendURL := fmt.Sprintf("%s/v1/projects/%s/messages:send", s.endpoint, url.PathEscape(s.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	// A retried send may reach the device twice, which beats not at all
+	resp, err := s.client.Do(httpclient.MarkRetryable(req))
+	if err != nil {
+		return fmt.Errorf("FCM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var fcmErr fcmErrorResponse
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&fcmErr)
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Force a fresh access token on the next send
+		s.mu.Lock()
+		s.accessToken = ""
+		s.mu.Unlock()
+	}
+	if fcmErr.unregistered() {
+		return fmt.Errorf("%w: %s", domain.ErrPushTokenUnregistered, fcmErr.Error.Message)
+	}
+	return fmt.Errorf("FCM returned %d %s: %s", resp.StatusCode, fcmErr.Error.Status, fcmErr.Error.Message)
+}
+
+// token returns a cached access token, exchanging a fresh signed JWT when it is about to expire
+func (s *FCMPushSender) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.accessToken != "" && now.Add(fcmTokenRefreshMargin).Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.account.ClientEmail,
+		"scope": fcmMessagingScope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if s.account.PrivateKeyID != "" {
+		assertion.Header["kid"] = s.account.PrivateKeyID
+	}
+	signed, err := assertion.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign FCM token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(httpclient.MarkRetryable(req))
+	if err != nil {
+		return "", fmt.Errorf("FCM token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return "", fmt.Errorf("FCM token request returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode FCM token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("FCM token response missing access_token")
+	}
+
+	s.accessToken = token.AccessToken
+	s.expiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
+
+// NoopPushSender logs push notifications instead of sending them, for development
+type NoopPushSender struct {
+	logger *logger.Logger
+}
+
+// NewNoopPushSender creates a push sender that only logs
+func NewNoopPushSender(logger *logger.Logger) *NoopPushSender {
+	return &NoopPushSender{logger: logger}
+}
+
+// Send logs the push notification and reports success
+func (s *NoopPushSender) Send(ctx context.Context, device *domain.Device, message ports.PushMessage) error {
+	s.logger.InfoWithFields(ctx, "Push not sent, noop sender configured",
+		zap.Int("device_id", device.ID),
+		zap.String("platform", string(device.Platform)),
+		zap.String("title", message.Title))
+	return nil
+}
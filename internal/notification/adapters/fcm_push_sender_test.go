@@ -0,0 +1,170 @@
+package adapters
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeFCM serves the OAuth token exchange and FCM v1 messages:send, rejecting
+// the token "gone" as unregistered
+type fakeFCM struct {
+	key         *rsa.PrivateKey
+	tokenCalls  atomic.Int32
+	unavailable atomic.Int32 // sends answered 503 before succeeding
+	lastMessage fcmMessage
+	server      *httptest.Server
+}
+
+func newFakeFCM(t *testing.T) *fakeFCM {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	fake := &fakeFCM{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		fake.tokenCalls.Add(1)
+		assertion := r.FormValue("assertion")
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(assertion, claims, func(*jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		if err != nil || claims["scope"] != fcmMessagingScope || claims["iss"] != "push@test-project.iam.gserviceaccount.com" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-1", "expires_in": 3600})
+	})
+	mux.HandleFunc("/v1/projects/test-project/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if fake.unavailable.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&fake.lastMessage)
+		if fake.lastMessage.Message.Token == "gone" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND",
+				"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+			return
+		}
+		w.Write([]byte(`{"name":"projects/test-project/messages/1"}`))
+	})
+	fake.server = httptest.NewServer(mux)
+	t.Cleanup(fake.server.Close)
+
+	return fake
+}
+
+// credentials returns a service account key file pointing at the fake token endpoint
+func (f *fakeFCM) credentials(t *testing.T) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(f.key)
+	if err != nil {
+		t.Fatalf("failed to encode key: %v", err)
+	}
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "test-project",
+		"client_email": "push@test-project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    f.server.URL + "/token",
+	})
+	return credentials
+}
+
+func TestFCMPushSender_Send(t *testing.T) {
+	fake := newFakeFCM(t)
+	sender, err := NewFCMPushSender(FCMConfig{Credentials: fake.credentials(t), Endpoint: fake.server.URL}, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	device := &domain.Device{ID: 1, Platform: domain.DevicePlatformFCM, Token: "device-token"}
+	message := ports.PushMessage{Title: "Delivery Created", Body: "On its way", Data: map[string]string{"delivery_id": "10"}}
+	for i := 0; i < 2; i++ {
+		if err := sender.Send(context.Background(), device, message); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	got := fake.lastMessage.Message
+	if got.Token != "device-token" || got.Notification.Title != "Delivery Created" || got.Data["delivery_id"] != "10" {
+		t.Errorf("unexpected FCM message %+v", got)
+	}
+	// The access token is cached between sends
+	if calls := fake.tokenCalls.Load(); calls != 1 {
+		t.Errorf("expected 1 token exchange, got %d", calls)
+	}
+}
+
+func TestFCMPushSender_RetriesUnavailable(t *testing.T) {
+	fake := newFakeFCM(t)
+	fake.unavailable.Store(1)
+	sender, err := NewFCMPushSender(FCMConfig{Credentials: fake.credentials(t), Endpoint: fake.server.URL}, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	device := &domain.Device{ID: 1, Platform: domain.DevicePlatformFCM, Token: "device-token"}
+	if err := sender.Send(context.Background(), device, ports.PushMessage{Title: "t", Body: "b"}); err != nil {
+		t.Fatalf("expected the send to be retried, got %v", err)
+	}
+	if got := fake.lastMessage.Message.Token; got != "device-token" {
+		t.Errorf("expected the retried message to arrive, got token %q", got)
+	}
+}
+
+func TestFCMPushSender_SendUnregistered(t *testing.T) {
+	fake := newFakeFCM(t)
+	sender, err := NewFCMPushSender(FCMConfig{Credentials: fake.credentials(t), Endpoint: fake.server.URL}, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = sender.Send(context.Background(), &domain.Device{ID: 1, Token: "gone"}, ports.PushMessage{Title: "t", Body: "b"})
+	if !errors.Is(err, domain.ErrPushTokenUnregistered) {
+		t.Errorf("expected an unregistered token error, got %v", err)
+	}
+}
+
+func TestNewFCMPushSender_InvalidCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials string
+	}{
+		{"not JSON", `service account`},
+		{"missing key", `{"project_id":"p","client_email":"push@p.iam.gserviceaccount.com"}`},
+		{"malformed key", `{"project_id":"p","client_email":"push@p.iam.gserviceaccount.com","private_key":"nope"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFCMPushSender(FCMConfig{Credentials: []byte(tt.credentials)}, &logger.Logger{Logger: zaptest.NewLogger(t)}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -2,10 +2,16 @@ package adapters
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
 	notificationProto "github.com/Keneke-Einar/delivertrack/proto/notification"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -15,6 +21,7 @@ import (
 type GRPCHandler struct {
 	notificationProto.UnimplementedNotificationServiceServer
 	service ports.NotificationService
+	users   ports.UserDirectory
 }
 
 // NewGRPCHandler creates a new gRPC handler
@@ -24,18 +31,27 @@ func NewGRPCHandler(service ports.NotificationService) *GRPCHandler {
 	}
 }
 
+// SetUserDirectory sets where the organizations of recipients are looked up;
+// without it admins confined to an organization reach no other user
+func (h *GRPCHandler) SetUserDirectory(users ports.UserDirectory) {
+	h.users = users
+}
+
 // SendNotification implements notification.NotificationServiceServer
 func (h *GRPCHandler) SendNotification(ctx context.Context, req *notificationProto.SendNotificationRequest) (*notificationProto.SendNotificationResponse, error) {
 	recipientID, err := strconv.Atoi(req.RecipientId)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid recipient_id: %v", err)
 	}
+	if err := h.authorizeRecipient(ctx, recipientID); err != nil {
+		return nil, err
+	}
 
-	// Map proto NotificationType to domain (assuming channel for simplicity)
-	notifType := domain.NotificationType(req.Channel.String())
-
-	notif, err := h.service.SendNotification(ctx, recipientID, notifType, req.Subject, req.Message, req.RecipientId)
+	notif, err := h.send(ctx, recipientID, req)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidNotification) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid notification: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to send notification: %v", err)
 	}
 
@@ -48,55 +64,152 @@ func (h *GRPCHandler) SendNotification(ctx context.Context, req *notificationPro
 	return resp, nil
 }
 
+// channelTypes maps the proto channels notifications can be sent over
+var channelTypes = map[notificationProto.NotificationChannel]domain.NotificationType{
+	notificationProto.NotificationChannel_CHANNEL_EMAIL: domain.NotificationTypeEmail,
+	notificationProto.NotificationChannel_CHANNEL_SMS:   domain.NotificationTypeSMS,
+	notificationProto.NotificationChannel_CHANNEL_PUSH:  domain.NotificationTypePush,
+}
+
+// send delivers a single proto notification request to recipientID
+func (h *GRPCHandler) send(ctx context.Context, recipientID int, req *notificationProto.SendNotificationRequest) (*domain.Notification, error) {
+	notifType, ok := channelTypes[req.Channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported channel %s", domain.ErrInvalidNotification, req.Channel)
+	}
+
+	return h.service.SendNotification(ctx, recipientID, notifType, req.Subject, req.Message, req.RecipientId)
+}
+
+// protoChannel maps a notification's type back to its channel, in-app for
+// types without one of their own
+func protoChannel(t domain.NotificationType) notificationProto.NotificationChannel {
+	for channel, notifType := range channelTypes {
+		if notifType == t {
+			return channel
+		}
+	}
+	return notificationProto.NotificationChannel_CHANNEL_IN_APP
+}
+
+// authorizeRecipient checks that the caller may notify recipientID or read
+// their notifications; only admins and internal services may reach other
+// users, and admins only those of their organization
+func (h *GRPCHandler) authorizeRecipient(ctx context.Context, recipientID int) error {
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing user claims")
+	}
+	if !domain.CanSendTo(claims.Role, claims.UserID, recipientID) {
+		return status.Error(codes.PermissionDenied, domain.ErrForbiddenRecipient.Error())
+	}
+	err := recipientInScope(ctx, h.users, claims.UserID, recipientID)
+	if errors.Is(err, domain.ErrForbiddenRecipient) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to look up recipient: %v", err)
+	}
+	return nil
+}
+
+// recipientInScope checks that a caller confined to an organization only
+// reaches users of that organization, failing closed without a directory
+func recipientInScope(ctx context.Context, users ports.UserDirectory, callerID, recipientID int) error {
+	orgID, scoped := authctx.OrgScope(ctx)
+	if !scoped || recipientID == callerID {
+		return nil
+	}
+	if users == nil {
+		return domain.ErrForbiddenRecipient
+	}
+
+	recipientOrg, err := users.UserOrganization(ctx, recipientID)
+	if errors.Is(err, domain.ErrRecipientNotFound) || (err == nil && recipientOrg != orgID) {
+		return domain.ErrForbiddenRecipient
+	}
+	return err
+}
+
 // GetNotificationHistory implements notification.NotificationServiceServer
 func (h *GRPCHandler) GetNotificationHistory(ctx context.Context, req *notificationProto.GetNotificationHistoryRequest) (*notificationProto.GetNotificationHistoryResponse, error) {
 	recipientID, err := strconv.Atoi(req.RecipientId)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid recipient_id: %v", err)
 	}
+	if err := h.authorizeRecipient(ctx, recipientID); err != nil {
+		return nil, err
+	}
 
-	limit := 10
+	filter := domain.NotificationFilter{
+		UserID:     recipientID,
+		UnreadOnly: req.UnreadOnly,
+		Limit:      10,
+	}
 	if req.Pagination != nil && req.Pagination.PageSize > 0 {
-		limit = int(req.Pagination.PageSize)
+		filter.Limit = int(req.Pagination.PageSize)
+		if req.Pagination.Page > 1 {
+			filter.Offset = int(req.Pagination.Page-1) * filter.Limit
+		}
+	}
+	if req.TimeRange != nil && req.TimeRange.StartTime > 0 {
+		since := time.Unix(req.TimeRange.StartTime, 0)
+		filter.Since = &since
 	}
 
-	notifications, err := h.service.GetUserNotifications(ctx, recipientID, limit)
+	notifications, err := h.service.ListNotifications(ctx, filter)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get notification history: %v", err)
 	}
 
+	unreadCount, err := h.service.GetUnreadCount(ctx, recipientID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get unread count: %v", err)
+	}
+
 	var notifProtos []*notificationProto.Notification
 	for _, n := range notifications {
-		status := notificationProto.NotificationStatus_NOTIFICATION_STATUS_UNSPECIFIED
-		switch n.Status {
-		case "pending":
-			status = notificationProto.NotificationStatus_NOTIFICATION_STATUS_PENDING
-		case "sent":
-			status = notificationProto.NotificationStatus_NOTIFICATION_STATUS_SENT
-		case "failed":
-			status = notificationProto.NotificationStatus_NOTIFICATION_STATUS_FAILED
-		}
-
-		notifProtos = append(notifProtos, &notificationProto.Notification{
-			NotificationId: strconv.Itoa(n.ID),
-			RecipientId:    strconv.Itoa(n.UserID),
-			Type:           notificationProto.NotificationType_NOTIFICATION_TYPE_SYSTEM_ALERT, // default
-			Channel:        notificationProto.NotificationChannel_CHANNEL_EMAIL, // default
-			Subject:        n.Subject,
-			Message:        n.Message,
-			Status:         status,
-			Read:           false,
-			CreatedAt:      n.CreatedAt.Unix(),
-			SentAt:         n.CreatedAt.Unix(),
-			ReadAt:         0,
-		})
+		notifProtos = append(notifProtos, notificationToProto(n))
 	}
 
 	return &notificationProto.GetNotificationHistoryResponse{
 		Notifications: notifProtos,
+		UnreadCount:   int32(unreadCount),
 	}, nil
 }
 
+// notificationToProto converts a domain notification to its protobuf form
+func notificationToProto(n *domain.Notification) *notificationProto.Notification {
+	status := notificationProto.NotificationStatus_NOTIFICATION_STATUS_UNSPECIFIED
+	switch n.Status {
+	case "pending":
+		status = notificationProto.NotificationStatus_NOTIFICATION_STATUS_PENDING
+	case "sent":
+		status = notificationProto.NotificationStatus_NOTIFICATION_STATUS_SENT
+	case "failed":
+		status = notificationProto.NotificationStatus_NOTIFICATION_STATUS_FAILED
+	}
+
+	var readAt int64
+	if n.ReadAt != nil {
+		readAt = n.ReadAt.Unix()
+	}
+
+	return &notificationProto.Notification{
+		NotificationId: strconv.Itoa(n.ID),
+		RecipientId:    strconv.Itoa(n.UserID),
+		Type:           notificationProto.NotificationType_NOTIFICATION_TYPE_SYSTEM_ALERT, // default
+		Channel:        protoChannel(n.Type),
+		Subject:        n.Subject,
+		Message:        n.Message,
+		Status:         status,
+		Read:           n.IsRead(),
+		CreatedAt:      n.CreatedAt.Unix(),
+		SentAt:         n.CreatedAt.Unix(),
+		ReadAt:         readAt,
+	}
+}
+
 // MarkAsRead implements notification.NotificationServiceServer
 func (h *GRPCHandler) MarkAsRead(ctx context.Context, req *notificationProto.MarkAsReadRequest) (*notificationProto.MarkAsReadResponse, error) {
 	notificationID, err := strconv.Atoi(req.NotificationId)
@@ -104,6 +217,18 @@ func (h *GRPCHandler) MarkAsRead(ctx context.Context, req *notificationProto.Mar
 		return nil, status.Errorf(codes.InvalidArgument, "invalid notification_id: %v", err)
 	}
 
+	// Only the recipient, admins and services may mark a notification read
+	notif, err := h.service.GetNotificationByID(ctx, notificationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotificationNotFound) {
+			return nil, status.Error(codes.NotFound, "notification not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get notification: %v", err)
+	}
+	if err := h.authorizeRecipient(ctx, notif.UserID); err != nil {
+		return nil, err
+	}
+
 	err = h.service.MarkAsRead(ctx, notificationID)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to mark as read: %v", err)
@@ -112,28 +237,163 @@ func (h *GRPCHandler) MarkAsRead(ctx context.Context, req *notificationProto.Mar
 	return &notificationProto.MarkAsReadResponse{}, nil
 }
 
-// SendBulkNotifications implements notification.NotificationServiceServer
+// SendBulkNotifications implements notification.NotificationServiceServer. The
+// whole batch is rejected if any recipient is not allowed; otherwise each
+// notification is sent independently and reported in the results.
 func (h *GRPCHandler) SendBulkNotifications(ctx context.Context, req *notificationProto.SendBulkNotificationsRequest) (*notificationProto.SendBulkNotificationsResponse, error) {
-	// TODO: Implement when service supports bulk
-	return nil, status.Errorf(codes.Unimplemented, "method SendBulkNotifications not implemented")
+	recipientIDs := make([]int, len(req.Notifications))
+	for i, n := range req.Notifications {
+		recipientID, err := strconv.Atoi(n.RecipientId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid recipient_id at index %d: %v", i, err)
+		}
+		if err := h.authorizeRecipient(ctx, recipientID); err != nil {
+			return nil, err
+		}
+		recipientIDs[i] = recipientID
+	}
+
+	resp := &notificationProto.SendBulkNotificationsResponse{}
+	for i, n := range req.Notifications {
+		notif, err := h.send(ctx, recipientIDs[i], n)
+		if err != nil {
+			resp.FailedCount++
+			resp.Results = append(resp.Results, &notificationProto.NotificationResult{ErrorMessage: err.Error()})
+			continue
+		}
+		resp.SuccessCount++
+		resp.Results = append(resp.Results, &notificationProto.NotificationResult{
+			NotificationId: strconv.Itoa(notif.ID),
+			Success:        true,
+		})
+	}
+
+	return resp, nil
 }
 
 // UpdatePreferences implements notification.NotificationServiceServer
 func (h *GRPCHandler) UpdatePreferences(ctx context.Context, req *notificationProto.UpdatePreferencesRequest) (*notificationProto.UpdatePreferencesResponse, error) {
-	// TODO: Implement when service supports preferences
-	return nil, status.Errorf(codes.Unimplemented, "method UpdatePreferences not implemented")
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	if err := h.authorizeRecipient(ctx, userID); err != nil {
+		return nil, err
+	}
+	if req.Preferences == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "preferences are required")
+	}
+
+	prefs := &domain.NotificationPreferences{
+		UserID:       userID,
+		InAppEnabled: req.Preferences.InAppEnabled,
+		EmailEnabled: req.Preferences.EmailEnabled,
+		SMSEnabled:   req.Preferences.SmsEnabled,
+		PushEnabled:  req.Preferences.PushEnabled,
+		EventTypes:   req.Preferences.NotificationTypes,
+	}
+
+	if err := h.service.UpdatePreferences(ctx, prefs); err != nil {
+		if errors.Is(err, domain.ErrInvalidPreferences) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid preferences: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update preferences: %v", err)
+	}
+
+	return &notificationProto.UpdatePreferencesResponse{
+		Success:   true,
+		UpdatedAt: prefs.UpdatedAt.Unix(),
+	}, nil
 }
 
 // GetPreferences implements notification.NotificationServiceServer
 func (h *GRPCHandler) GetPreferences(ctx context.Context, req *notificationProto.GetPreferencesRequest) (*notificationProto.GetPreferencesResponse, error) {
-	// TODO: Implement when service supports preferences
-	return nil, status.Errorf(codes.Unimplemented, "method GetPreferences not implemented")
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	if err := h.authorizeRecipient(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	prefs, err := h.service.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get preferences: %v", err)
+	}
+
+	return &notificationProto.GetPreferencesResponse{
+		Preferences: &notificationProto.NotificationPreferences{
+			EmailEnabled:      prefs.EmailEnabled,
+			SmsEnabled:        prefs.SMSEnabled,
+			PushEnabled:       prefs.PushEnabled,
+			InAppEnabled:      prefs.InAppEnabled,
+			NotificationTypes: prefs.EventTypes,
+		},
+	}, nil
 }
 
 // Subscribe implements notification.NotificationServiceServer
+// It replays the user's unread notifications, then streams new ones until
+// the client disconnects. A subscriber too slow to keep up is dropped with
+// ResourceExhausted so it never holds up delivery to others.
 func (h *GRPCHandler) Subscribe(req *notificationProto.SubscribeRequest, stream notificationProto.NotificationService_SubscribeServer) error {
-	// TODO: Implement streaming
-	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+	ctx := stream.Context()
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing user claims")
+	}
+
+	userID := claims.UserID
+	if req.UserId != "" {
+		id, err := strconv.Atoi(req.UserId)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+		}
+		if err := h.authorizeRecipient(ctx, id); err != nil {
+			return err
+		}
+		userID = id
+	}
+
+	sub, unread, err := h.service.Subscribe(ctx, userID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	wanted := func(n *notificationProto.Notification) bool {
+		return len(req.Types) == 0 || slices.Contains(req.Types, n.Type)
+	}
+
+	// a notification created while subscribing can be both replayed and
+	// published, so remember what was replayed
+	replayed := make(map[int]bool, len(unread))
+	for _, n := range unread {
+		replayed[n.ID] = true
+		if msg := notificationToProto(n); wanted(msg) {
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-sub.Dropped():
+			return status.Error(codes.ResourceExhausted, "subscriber fell too far behind")
+		case n := <-sub.Notifications():
+			if replayed[n.ID] {
+				continue
+			}
+			if msg := notificationToProto(n); wanted(msg) {
+				if err := stream.Send(msg); err != nil {
+					return err
+				}
+			}
+		}
+	}
 }
 
 // SendDeliveryUpdate implements notification.NotificationServiceServer
@@ -0,0 +1,461 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/app"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	notificationProto "github.com/Keneke-Einar/delivertrack/proto/notification"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// claimsContext stores claims the way the auth interceptor does
+func claimsContext(claims *authDomain.Claims) context.Context {
+	ctx := context.WithValue(context.Background(), grpcinterceptors.UserClaimsContextKey, claims)
+	return authctx.WithClaims(ctx, claims)
+}
+
+func notificationRequest(recipientID string) *notificationProto.SendNotificationRequest {
+	return &notificationProto.SendNotificationRequest{
+		RecipientId: recipientID,
+		Channel:     notificationProto.NotificationChannel_CHANNEL_EMAIL,
+		Subject:     "Hello",
+		Message:     "Your parcel is on its way",
+	}
+}
+
+func TestGRPCHandler_SendNotification_Authorization(t *testing.T) {
+	tests := []struct {
+		name         string
+		claims       *authDomain.Claims
+		recipientID  string
+		expectedCode codes.Code
+	}{
+		{name: "admin to another user", claims: &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}, recipientID: "2", expectedCode: codes.OK},
+		{name: "admin to another organization's user", claims: &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}, recipientID: "5", expectedCode: codes.PermissionDenied},
+		{name: "admin to an unknown user", claims: &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}, recipientID: "99", expectedCode: codes.PermissionDenied},
+		{name: "super admin to another organization's user", claims: &authDomain.Claims{UserID: 1, Role: authDomain.RoleSuperAdmin}, recipientID: "5", expectedCode: codes.OK},
+		{name: "service to another user", claims: &authDomain.Claims{Role: authDomain.RoleService}, recipientID: "2", expectedCode: codes.OK},
+		{name: "customer to self", claims: &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer}, recipientID: "3", expectedCode: codes.OK},
+		{name: "customer to another user", claims: &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer}, recipientID: "2", expectedCode: codes.PermissionDenied},
+		{name: "courier to self", claims: &authDomain.Claims{UserID: 4, Role: authDomain.RoleCourier}, recipientID: "4", expectedCode: codes.OK},
+		{name: "courier to another user", claims: &authDomain.Claims{UserID: 4, Role: authDomain.RoleCourier}, recipientID: "2", expectedCode: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockNotificationService{}
+			handler := NewGRPCHandler(mockService)
+			handler.SetUserDirectory(testUsers)
+
+			_, err := handler.SendNotification(claimsContext(tt.claims), notificationRequest(tt.recipientID))
+			if status.Code(err) != tt.expectedCode {
+				t.Errorf("expected code %v, got %v", tt.expectedCode, err)
+			}
+			if tt.expectedCode != codes.OK && len(mockService.sent) != 0 {
+				t.Errorf("expected no notification to be sent, got %v", mockService.sent)
+			}
+		})
+	}
+}
+
+func TestGRPCHandler_SendNotification_MissingClaims(t *testing.T) {
+	handler := NewGRPCHandler(&MockNotificationService{})
+
+	_, err := handler.SendNotification(context.Background(), notificationRequest("2"))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestGRPCHandler_SendBulkNotifications(t *testing.T) {
+	req := &notificationProto.SendBulkNotificationsRequest{
+		Notifications: []*notificationProto.SendNotificationRequest{notificationRequest("3"), notificationRequest("2")},
+	}
+
+	t.Run("rejects the batch when any recipient is not allowed", func(t *testing.T) {
+		mockService := &MockNotificationService{}
+		handler := NewGRPCHandler(mockService)
+
+		_, err := handler.SendBulkNotifications(claimsContext(&authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer}), req)
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("expected PermissionDenied, got %v", err)
+		}
+		if len(mockService.sent) != 0 {
+			t.Errorf("expected no notification to be sent, got %v", mockService.sent)
+		}
+	})
+
+	t.Run("sends every notification for admins", func(t *testing.T) {
+		mockService := &MockNotificationService{}
+		handler := NewGRPCHandler(mockService)
+		handler.SetUserDirectory(testUsers)
+
+		resp, err := handler.SendBulkNotifications(claimsContext(&authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}), req)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if resp.SuccessCount != 2 || resp.FailedCount != 0 || len(resp.Results) != 2 {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+}
+
+// Tokens the contract tests' callers authenticate with
+const (
+	adminToken    = "admin-token"    // user 1
+	customerToken = "customer-token" // user 3
+	serviceToken  = "service-token"
+)
+
+// newNotificationClient serves the notification gRPC handler over the
+// production interceptor chain, backed by the real service and an in-memory
+// repository
+func newNotificationClient(t *testing.T) (notificationProto.NotificationServiceClient, *memory.NotificationRepository) {
+	t.Helper()
+
+	repo := memory.NewNotificationRepository()
+	service := app.NewNotificationService(repo, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+
+	authService := testsupport.NewAuthService()
+	authService.AddToken(adminToken, &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin})
+	authService.AddToken(customerToken, &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer})
+	authService.AddToken(serviceToken, &authDomain.Claims{Role: authDomain.RoleService})
+
+	handler := NewGRPCHandler(service)
+	handler.SetUserDirectory(testUsers)
+	conn := testsupport.DialGRPCServer(t, authService, func(s *grpc.Server) {
+		notificationProto.RegisterNotificationServiceServer(s, handler)
+	})
+	return notificationProto.NewNotificationServiceClient(conn), repo
+}
+
+func as(token string) context.Context {
+	return testsupport.WithToken(context.Background(), token)
+}
+
+func expectCode(t *testing.T, err error, expected codes.Code) {
+	t.Helper()
+	if status.Code(err) != expected {
+		t.Fatalf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestNotificationGRPC_RequiresAuthentication(t *testing.T) {
+	client, _ := newNotificationClient(t)
+
+	_, err := client.SendNotification(context.Background(), notificationRequest("3"))
+	expectCode(t, err, codes.Unauthenticated)
+
+	_, err = client.SendNotification(as("unknown-token"), notificationRequest("3"))
+	expectCode(t, err, codes.Unauthenticated)
+}
+
+func TestNotificationGRPC_SendNotification(t *testing.T) {
+	client, repo := newNotificationClient(t)
+
+	resp, err := client.SendNotification(as(serviceToken), notificationRequest("3"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.NotificationId != "1" || resp.Status != notificationProto.NotificationStatus_NOTIFICATION_STATUS_SENT || resp.SentAt == 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	stored := repo.All()
+	if len(stored) != 1 || stored[0].UserID != 3 || stored[0].Type != domain.NotificationTypeEmail || stored[0].Subject != "Hello" {
+		t.Fatalf("unexpected stored notifications %+v", stored)
+	}
+
+	invalid := func(mutate func(*notificationProto.SendNotificationRequest)) *notificationProto.SendNotificationRequest {
+		req := notificationRequest("3")
+		mutate(req)
+		return req
+	}
+	tests := []struct {
+		name         string
+		token        string
+		req          *notificationProto.SendNotificationRequest
+		expectedCode codes.Code
+	}{
+		{name: "another user", token: customerToken, req: notificationRequest("2"), expectedCode: codes.PermissionDenied},
+		{name: "empty recipient_id", token: adminToken, req: notificationRequest(""), expectedCode: codes.InvalidArgument},
+		{name: "missing message", token: adminToken, req: invalid(func(r *notificationProto.SendNotificationRequest) { r.Message = "" }), expectedCode: codes.InvalidArgument},
+		{
+			name:  "unsupported channel",
+			token: adminToken,
+			req: invalid(func(r *notificationProto.SendNotificationRequest) {
+				r.Channel = notificationProto.NotificationChannel_CHANNEL_WEBHOOK
+			}),
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SendNotification(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+	if n := len(repo.All()); n != 1 {
+		t.Errorf("expected rejected notifications not to be stored, got %d", n)
+	}
+}
+
+func TestNotificationGRPC_SendBulkNotifications(t *testing.T) {
+	client, repo := newNotificationClient(t)
+	unsupported := notificationRequest("2")
+	unsupported.Channel = notificationProto.NotificationChannel_CHANNEL_UNSPECIFIED
+
+	resp, err := client.SendBulkNotifications(as(adminToken), &notificationProto.SendBulkNotificationsRequest{
+		Notifications: []*notificationProto.SendNotificationRequest{notificationRequest("3"), unsupported},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.SuccessCount != 1 || resp.FailedCount != 1 || !resp.Results[0].Success || resp.Results[1].ErrorMessage == "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if n := len(repo.All()); n != 1 {
+		t.Errorf("expected 1 stored notification, got %d", n)
+	}
+
+	_, err = client.SendBulkNotifications(as(adminToken), &notificationProto.SendBulkNotificationsRequest{
+		Notifications: []*notificationProto.SendNotificationRequest{notificationRequest("x")},
+	})
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestNotificationGRPC_History(t *testing.T) {
+	client, _ := newNotificationClient(t)
+	for i := 0; i < 2; i++ {
+		if _, err := client.SendNotification(as(adminToken), notificationRequest("3")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	_, err := client.MarkAsRead(as(customerToken), &notificationProto.MarkAsReadRequest{NotificationId: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := client.GetNotificationHistory(as(customerToken), &notificationProto.GetNotificationHistoryRequest{RecipientId: "3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Notifications) != 2 || resp.UnreadCount != 1 {
+		t.Fatalf("expected 2 notifications with 1 unread, got %d with %d unread", len(resp.Notifications), resp.UnreadCount)
+	}
+	for _, n := range resp.Notifications {
+		if n.RecipientId != "3" || n.Channel != notificationProto.NotificationChannel_CHANNEL_EMAIL || n.Read != (n.NotificationId == "1") {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	}
+
+	unread, err := client.GetNotificationHistory(as(customerToken), &notificationProto.GetNotificationHistoryRequest{RecipientId: "3", UnreadOnly: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(unread.Notifications) != 1 || unread.Notifications[0].NotificationId != "2" {
+		t.Errorf("expected only notification 2, got %+v", unread.Notifications)
+	}
+
+	_, err = client.GetNotificationHistory(as(customerToken), &notificationProto.GetNotificationHistoryRequest{RecipientId: "2"})
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = client.GetNotificationHistory(as(customerToken), &notificationProto.GetNotificationHistoryRequest{})
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestNotificationGRPC_MarkAsRead(t *testing.T) {
+	client, repo := newNotificationClient(t)
+	if _, err := client.SendNotification(as(adminToken), notificationRequest("2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		notificationID string
+		expectedCode   codes.Code
+	}{
+		{name: "another user's notification", notificationID: "1", expectedCode: codes.PermissionDenied},
+		{name: "missing notification", notificationID: "99", expectedCode: codes.NotFound},
+		{name: "non-numeric ID", notificationID: "abc", expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.MarkAsRead(as(customerToken), &notificationProto.MarkAsReadRequest{NotificationId: tt.notificationID})
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+	if stored := repo.All(); stored[0].IsRead() {
+		t.Error("expected the notification to stay unread")
+	}
+}
+
+func TestNotificationGRPC_Preferences(t *testing.T) {
+	client, _ := newNotificationClient(t)
+
+	defaults, err := client.GetPreferences(as(customerToken), &notificationProto.GetPreferencesRequest{UserId: "3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !defaults.Preferences.EmailEnabled || !defaults.Preferences.InAppEnabled {
+		t.Errorf("expected the default preferences, got %+v", defaults.Preferences)
+	}
+
+	updated, err := client.UpdatePreferences(as(customerToken), &notificationProto.UpdatePreferencesRequest{
+		UserId: "3",
+		Preferences: &notificationProto.NotificationPreferences{
+			InAppEnabled:      true,
+			NotificationTypes: map[string]bool{domain.EventTypeLocationUpdates: false},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated.Success || updated.UpdatedAt == 0 {
+		t.Errorf("unexpected response: %+v", updated)
+	}
+
+	stored, err := client.GetPreferences(as(adminToken), &notificationProto.GetPreferencesRequest{UserId: "3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.Preferences.EmailEnabled || stored.Preferences.NotificationTypes[domain.EventTypeLocationUpdates] {
+		t.Errorf("expected the updated preferences, got %+v", stored.Preferences)
+	}
+
+	tests := []struct {
+		name         string
+		req          *notificationProto.UpdatePreferencesRequest
+		expectedCode codes.Code
+	}{
+		{name: "another user", req: &notificationProto.UpdatePreferencesRequest{UserId: "2", Preferences: &notificationProto.NotificationPreferences{}}, expectedCode: codes.PermissionDenied},
+		{name: "missing preferences", req: &notificationProto.UpdatePreferencesRequest{UserId: "3"}, expectedCode: codes.InvalidArgument},
+		{
+			name:         "unknown event type",
+			req:          &notificationProto.UpdatePreferencesRequest{UserId: "3", Preferences: &notificationProto.NotificationPreferences{NotificationTypes: map[string]bool{"weather": true}}},
+			expectedCode: codes.InvalidArgument,
+		},
+		{name: "empty user_id", req: &notificationProto.UpdatePreferencesRequest{Preferences: &notificationProto.NotificationPreferences{}}, expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.UpdatePreferences(as(customerToken), tt.req)
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+
+	_, err = client.GetPreferences(as(customerToken), &notificationProto.GetPreferencesRequest{UserId: "2"})
+	expectCode(t, err, codes.PermissionDenied)
+}
+
+func TestNotificationGRPC_Unimplemented(t *testing.T) {
+	client, _ := newNotificationClient(t)
+
+	_, err := client.SendDeliveryUpdate(as(adminToken), &notificationProto.SendDeliveryUpdateRequest{})
+	expectCode(t, err, codes.Unimplemented)
+}
+
+func recvNotification(t *testing.T, stream notificationProto.NotificationService_SubscribeClient) *notificationProto.Notification {
+	t.Helper()
+	n, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return n
+}
+
+func TestNotificationGRPC_Subscribe(t *testing.T) {
+	client, _ := newNotificationClient(t)
+
+	unread, err := client.SendNotification(as(serviceToken), notificationRequest("3"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// two concurrent subscribers for the same user each get the unread
+	// notification replayed, then every new one
+	ctx, cancel := context.WithCancel(as(customerToken))
+	defer cancel()
+	var streams []notificationProto.NotificationService_SubscribeClient
+	for i := 0; i < 2; i++ {
+		stream, err := client.Subscribe(ctx, &notificationProto.SubscribeRequest{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n := recvNotification(t, stream); n.NotificationId != unread.NotificationId {
+			t.Fatalf("expected notification %s replayed, got %+v", unread.NotificationId, n)
+		}
+		streams = append(streams, stream)
+	}
+
+	if _, err := client.SendNotification(as(serviceToken), notificationRequest("2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created, err := client.SendNotification(as(serviceToken), notificationRequest("3"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := stream.Recv()
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if n.NotificationId != created.NotificationId || n.RecipientId != "3" || n.Message != "Your parcel is on its way" {
+				t.Errorf("expected notification %s, got %+v", created.NotificationId, n)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cancel()
+	for _, stream := range streams {
+		_, err := stream.Recv()
+		expectCode(t, err, codes.Canceled)
+	}
+}
+
+func TestNotificationGRPC_SubscribeAuthorization(t *testing.T) {
+	client, _ := newNotificationClient(t)
+
+	tests := []struct {
+		name         string
+		token        string
+		userID       string
+		expectedCode codes.Code
+	}{
+		{name: "no token", userID: "3", expectedCode: codes.Unauthenticated},
+		{name: "another user", token: customerToken, userID: "2", expectedCode: codes.PermissionDenied},
+		{name: "invalid user_id", token: customerToken, userID: "abc", expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = as(tt.token)
+			}
+			stream, err := client.Subscribe(ctx, &notificationProto.SubscribeRequest{UserId: tt.userID})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
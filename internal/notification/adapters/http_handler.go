@@ -2,7 +2,11 @@ package adapters
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
@@ -12,6 +16,7 @@ import (
 // HTTPHandler handles HTTP requests for notification operations
 type HTTPHandler struct {
 	service ports.NotificationService
+	users   ports.UserDirectory
 }
 
 // NewHTTPHandler creates a new HTTP handler
@@ -21,6 +26,12 @@ func NewHTTPHandler(service ports.NotificationService) *HTTPHandler {
 	}
 }
 
+// SetUserDirectory sets where the organizations of recipients are looked up;
+// without it admins confined to an organization reach no other user
+func (h *HTTPHandler) SetUserDirectory(users ports.UserDirectory) {
+	h.users = users
+}
+
 // SendNotification handles POST /notifications/send
 func (h *HTTPHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -29,6 +40,10 @@ func (h *HTTPHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Extract user and trace context
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
 	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "send_notification_http")
 
 	var req struct {
@@ -39,8 +54,23 @@ func (h *HTTPHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
 		Recipient string `json:"recipient"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httputil.SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	// Only admins and internal services may address other users, admins only
+	// those of their organization
+	if !domain.CanSendTo(userCtx.Role, userCtx.UserID, req.UserID) {
+		httputil.SendErrorResponse(w, domain.ErrForbiddenRecipient.Error(), http.StatusForbidden)
+		return
+	}
+	if err := recipientInScope(traceCtx, h.users, userCtx.UserID, req.UserID); err != nil {
+		if errors.Is(err, domain.ErrForbiddenRecipient) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to look up recipient", http.StatusInternalServerError)
 		return
 	}
 
@@ -54,6 +84,48 @@ func (h *HTTPHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(notification)
 }
 
+// parseNotificationFilter reads unread_only, type, since, limit and offset query params
+func parseNotificationFilter(r *http.Request) (domain.NotificationFilter, error) {
+	query := r.URL.Query()
+	filter := domain.NotificationFilter{
+		Type: domain.NotificationType(query.Get("type")),
+	}
+
+	if v := query.Get("unread_only"); v != "" {
+		unreadOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid unread_only")
+		}
+		filter.UnreadOnly = unreadOnly
+	}
+
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since, expected RFC3339 timestamp")
+		}
+		filter.Since = &since
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return filter, fmt.Errorf("invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, fmt.Errorf("invalid offset")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
 // GetNotifications handles GET /notifications
 func (h *HTTPHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -65,19 +137,20 @@ func (h *HTTPHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
 	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "get_notifications_http")
 
 	// Get user ID from context (set by auth middleware)
-	userIDValue := r.Context().Value("user_id")
-	if userIDValue == nil {
-		httputil.SendErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
 		return
 	}
+	userID := userCtx.UserID
 
-	userID, ok := userIDValue.(int)
-	if !ok {
-		httputil.SendErrorResponse(w, "Invalid user context", http.StatusInternalServerError)
+	filter, err := parseNotificationFilter(r)
+	if err != nil {
+		httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	filter.UserID = userID
 
-	notifications, err := h.service.GetUserNotifications(traceCtx, userID, 10)
+	notifications, err := h.service.ListNotifications(traceCtx, filter)
 	if err != nil {
 		httputil.SendErrorResponse(w, "Failed to get notifications", http.StatusInternalServerError)
 		return
@@ -106,8 +179,8 @@ func (h *HTTPHandler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
 		NotificationID int `json:"notification_id"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httputil.SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
 		return
 	}
 
@@ -119,3 +192,187 @@ func (h *HTTPHandler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// GetUnreadCount handles GET /notifications/unread_count
+func (h *HTTPHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract user and trace context
+	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "get_unread_count_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.service.GetUnreadCount(traceCtx, userCtx.UserID)
+	if err != nil {
+		httputil.SendErrorResponse(w, "Failed to get unread count", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"unread_count": count})
+}
+
+// MarkAllAsRead handles PUT /notifications/read_all
+func (h *HTTPHandler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract user and trace context
+	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "mark_all_as_read_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.service.MarkAllAsRead(traceCtx, userCtx.UserID)
+	if err != nil {
+		httputil.SendErrorResponse(w, "Failed to mark notifications as read", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"marked_read": count})
+}
+
+// GetPreferences handles GET /preferences
+func (h *HTTPHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract user and trace context
+	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "get_preferences_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(traceCtx, userCtx.UserID)
+	if err != nil {
+		httputil.SendErrorResponse(w, "Failed to get preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// UpdatePreferences handles PUT /preferences
+func (h *HTTPHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract user and trace context
+	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "update_preferences_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	var prefs domain.NotificationPreferences
+	if err := httputil.DecodeJSON(w, r, &prefs); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+	// Users can only change their own preferences
+	prefs.UserID = userCtx.UserID
+
+	if err := h.service.UpdatePreferences(traceCtx, &prefs); err != nil {
+		if errors.Is(err, domain.ErrInvalidPreferences) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to update preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// RegisterDevice handles POST /devices
+func (h *HTTPHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract user and trace context
+	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "register_device_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Platform string `json:"platform"`
+		Token    string `json:"token"`
+	}
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
+		return
+	}
+
+	// Devices are always registered for the caller
+	device, err := h.service.RegisterDevice(traceCtx, userCtx.UserID, domain.DevicePlatform(req.Platform), req.Token)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidDevice) {
+			httputil.SendErrorResponse(w, "platform must be fcm, apns or webpush and token is required", http.StatusBadRequest)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to register device", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(device)
+}
+
+// DeleteDevice handles DELETE /devices/{id}
+func (h *HTTPHandler) DeleteDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract user and trace context
+	traceCtx := httputil.ExtractTraceContext(r, "notification-service", "delete_device_http")
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	deviceID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || deviceID <= 0 {
+		httputil.SendErrorResponse(w, "Invalid device ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteDevice(traceCtx, userCtx.UserID, deviceID); err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			httputil.SendErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		httputil.SendErrorResponse(w, "Failed to delete device", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
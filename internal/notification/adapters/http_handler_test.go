@@ -0,0 +1,329 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// MockNotificationService is a mock implementation of NotificationService for testing
+type MockNotificationService struct {
+	sendNotificationFunc func(ctx context.Context, userID int, notifType domain.NotificationType, subject, message, recipient string) (*domain.Notification, error)
+	sent                 []int
+	devices              map[int]*domain.Device
+}
+
+func (m *MockNotificationService) SendNotification(ctx context.Context, userID int, notifType domain.NotificationType, subject, message, recipient string) (*domain.Notification, error) {
+	m.sent = append(m.sent, userID)
+	if m.sendNotificationFunc != nil {
+		return m.sendNotificationFunc(ctx, userID, notifType, subject, message, recipient)
+	}
+	return &domain.Notification{ID: len(m.sent), UserID: userID, Type: notifType, CreatedAt: time.Now()}, nil
+}
+
+func (m *MockNotificationService) GetNotificationByID(ctx context.Context, id int) (*domain.Notification, error) {
+	return &domain.Notification{ID: id}, nil
+}
+
+func (m *MockNotificationService) GetUserNotifications(ctx context.Context, userID int, limit int) ([]*domain.Notification, error) {
+	return []*domain.Notification{}, nil
+}
+
+func (m *MockNotificationService) ListNotifications(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
+	return []*domain.Notification{}, nil
+}
+
+func (m *MockNotificationService) GetUnreadCount(ctx context.Context, userID int) (int, error) {
+	return 0, nil
+}
+
+func (m *MockNotificationService) MarkAsRead(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *MockNotificationService) MarkAllAsRead(ctx context.Context, userID int) (int, error) {
+	return 0, nil
+}
+
+func (m *MockNotificationService) GetPreferences(ctx context.Context, userID int) (*domain.NotificationPreferences, error) {
+	return &domain.NotificationPreferences{UserID: userID}, nil
+}
+
+func (m *MockNotificationService) UpdatePreferences(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	return nil
+}
+
+func (m *MockNotificationService) RegisterDevice(ctx context.Context, userID int, platform domain.DevicePlatform, token string) (*domain.Device, error) {
+	device, err := domain.NewDevice(userID, platform, token)
+	if err != nil {
+		return nil, err
+	}
+	if m.devices == nil {
+		m.devices = make(map[int]*domain.Device)
+	}
+	device.ID = len(m.devices) + 1
+	m.devices[device.ID] = device
+	return device, nil
+}
+
+func (m *MockNotificationService) DeleteDevice(ctx context.Context, userID, deviceID int) error {
+	device, ok := m.devices[deviceID]
+	if !ok || device.UserID != userID {
+		return domain.ErrDeviceNotFound
+	}
+	delete(m.devices, deviceID)
+	return nil
+}
+
+func (m *MockNotificationService) Subscribe(ctx context.Context, userID int) (ports.Subscription, []*domain.Notification, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
+// stubUserDirectory maps user IDs to their organization
+type stubUserDirectory map[int]int
+
+func (d stubUserDirectory) UserOrganization(ctx context.Context, userID int) (int, error) {
+	orgID, ok := d[userID]
+	if !ok {
+		return 0, domain.ErrRecipientNotFound
+	}
+	return orgID, nil
+}
+
+// testUsers puts users 1 to 4 in the default organization and 5 in another
+var testUsers = stubUserDirectory{1: 1, 2: 1, 3: 1, 4: 1, 5: 2}
+
+func TestHTTPHandler_SendNotification_Authorization(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *authDomain.Claims
+		recipientID    int
+		expectedStatus int
+	}{
+		{name: "admin to another user", claims: &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}, recipientID: 2, expectedStatus: http.StatusOK},
+		{name: "admin to another organization's user", claims: &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin}, recipientID: 5, expectedStatus: http.StatusForbidden},
+		{name: "super admin to another organization's user", claims: &authDomain.Claims{UserID: 1, Role: authDomain.RoleSuperAdmin}, recipientID: 5, expectedStatus: http.StatusOK},
+		{name: "service to another user", claims: &authDomain.Claims{UserID: 0, Role: authDomain.RoleService}, recipientID: 2, expectedStatus: http.StatusOK},
+		{name: "customer to self", claims: &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer}, recipientID: 3, expectedStatus: http.StatusOK},
+		{name: "customer to another user", claims: &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer}, recipientID: 2, expectedStatus: http.StatusForbidden},
+		{name: "courier to self", claims: &authDomain.Claims{UserID: 4, Role: authDomain.RoleCourier}, recipientID: 4, expectedStatus: http.StatusOK},
+		{name: "courier to another user", claims: &authDomain.Claims{UserID: 4, Role: authDomain.RoleCourier}, recipientID: 2, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockNotificationService{}
+			handler := NewHTTPHandler(mockService)
+			handler.SetUserDirectory(testUsers)
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"user_id":   tt.recipientID,
+				"type":      "email",
+				"subject":   "Hello",
+				"message":   "Your parcel is on its way",
+				"recipient": "user@example.com",
+			})
+			req := httptest.NewRequest("POST", "/notifications", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+
+			w := httptest.NewRecorder()
+			handler.SendNotification(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusForbidden && len(mockService.sent) != 0 {
+				t.Errorf("expected no notification to be sent, got %v", mockService.sent)
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_SendNotification_MissingClaims(t *testing.T) {
+	mockService := &MockNotificationService{}
+	handler := NewHTTPHandler(mockService)
+
+	body, _ := json.Marshal(map[string]interface{}{"user_id": 2, "type": "email", "subject": "Hi", "message": "Hi", "recipient": "a@b.c"})
+	req := httptest.NewRequest("POST", "/notifications", bytes.NewReader(body))
+
+	// No claims in context
+	w := httptest.NewRecorder()
+	handler.SendNotification(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if len(mockService.sent) != 0 {
+		t.Errorf("expected no notification to be sent, got %v", mockService.sent)
+	}
+}
+
+func TestHTTPHandler_RegisterDevice(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "fcm token", body: `{"platform":"fcm","token":"fcm-token"}`, expectedStatus: http.StatusCreated},
+		{name: "webpush token", body: `{"platform":"webpush","token":"https://push.example.com/sub/1"}`, expectedStatus: http.StatusCreated},
+		{name: "unknown platform", body: `{"platform":"pager","token":"abc"}`, expectedStatus: http.StatusBadRequest},
+		{name: "missing token", body: `{"platform":"apns"}`, expectedStatus: http.StatusBadRequest},
+		{name: "malformed body", body: `{`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockNotificationService{}
+			handler := NewHTTPHandler(mockService)
+
+			req := httptest.NewRequest("POST", "/devices", bytes.NewReader([]byte(tt.body)))
+			req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer}))
+
+			w := httptest.NewRecorder()
+			handler.RegisterDevice(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var device domain.Device
+			if err := json.NewDecoder(w.Body).Decode(&device); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			// The device belongs to the caller from the claims
+			if device.ID == 0 || device.UserID != 3 || !device.Active {
+				t.Errorf("unexpected device %+v", device)
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_RegisterDevice_MissingClaims(t *testing.T) {
+	mockService := &MockNotificationService{}
+	handler := NewHTTPHandler(mockService)
+
+	req := httptest.NewRequest("POST", "/devices", bytes.NewReader([]byte(`{"platform":"fcm","token":"t"}`)))
+	w := httptest.NewRecorder()
+	handler.RegisterDevice(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if len(mockService.devices) != 0 {
+		t.Errorf("expected no device to be registered, got %v", mockService.devices)
+	}
+}
+
+func TestHTTPHandler_DeleteDevice(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "own device", id: "1", userID: 3, expectedStatus: http.StatusNoContent},
+		{name: "another user's device", id: "1", userID: 4, expectedStatus: http.StatusNotFound},
+		{name: "unknown device", id: "99", userID: 3, expectedStatus: http.StatusNotFound},
+		{name: "invalid ID", id: "abc", userID: 3, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockNotificationService{}
+			if _, err := mockService.RegisterDevice(context.Background(), 3, domain.DevicePlatformFCM, "fcm-token"); err != nil {
+				t.Fatalf("failed to register device: %v", err)
+			}
+			handler := NewHTTPHandler(mockService)
+
+			req := httptest.NewRequest("DELETE", "/devices/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{UserID: tt.userID, Role: authDomain.RoleCustomer}))
+
+			w := httptest.NewRecorder()
+			handler.DeleteDevice(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if deleted := len(mockService.devices) == 0; deleted != (tt.expectedStatus == http.StatusNoContent) {
+				t.Errorf("unexpected devices after delete: %v", mockService.devices)
+			}
+		})
+	}
+}
+
+// stubDeadLetterService returns one pending dead letter and counts retries
+type stubDeadLetterService struct {
+	retried []int
+}
+
+func (s *stubDeadLetterService) List(ctx context.Context, limit int) ([]messaging.DeadLetter, error) {
+	return []messaging.DeadLetter{{ID: 1, RoutingKey: "delivery.created", Status: messaging.DeadLetterStatusPending}}, nil
+}
+
+func (s *stubDeadLetterService) Stats(ctx context.Context) (messaging.DeadLetterStats, error) {
+	return messaging.DeadLetterStats{Total: 1, Pending: 1}, nil
+}
+
+func (s *stubDeadLetterService) Retry(ctx context.Context, id int) (*messaging.DeadLetter, error) {
+	s.retried = append(s.retried, id)
+	return &messaging.DeadLetter{ID: id, Status: messaging.DeadLetterStatusRetried}, nil
+}
+
+func TestDeadLetterHTTPHandler_SuperAdminsOnly(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *authDomain.Claims
+		expectedStatus int
+	}{
+		{name: "super admin", claims: &authDomain.Claims{UserID: 1, Role: "super_admin"}, expectedStatus: http.StatusOK},
+		{name: "organization admin", claims: &authDomain.Claims{UserID: 2, Role: "admin", OrgID: 2}, expectedStatus: http.StatusForbidden},
+		{name: "customer", claims: &authDomain.Claims{UserID: 3, Role: "customer"}, expectedStatus: http.StatusForbidden},
+		{name: "anonymous", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubDeadLetterService{}
+			handler := NewDeadLetterHTTPHandler(service)
+
+			list := httptest.NewRequest(http.MethodGet, "/admin/dead-letters", nil)
+			retry := httptest.NewRequest(http.MethodPost, "/admin/dead-letters/1/retry", nil)
+			retry.SetPathValue("id", "1")
+			if tt.claims != nil {
+				list = list.WithContext(authctx.WithClaims(list.Context(), tt.claims))
+				retry = retry.WithContext(authctx.WithClaims(retry.Context(), tt.claims))
+			}
+
+			w := httptest.NewRecorder()
+			handler.List(w, list)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("list: expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			w = httptest.NewRecorder()
+			handler.Retry(w, retry)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("retry: expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if retried := len(service.retried) == 1; retried != (tt.expectedStatus == http.StatusOK) {
+				t.Errorf("expected a retry only for super admins, got %v", service.retried)
+			}
+		})
+	}
+}
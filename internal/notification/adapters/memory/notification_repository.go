@@ -0,0 +1,293 @@
+// Package memory provides an in-memory notification repository for tests
+// that enforces the same constraints as the PostgreSQL adapter.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+)
+
+// NotificationRepository implements ports.NotificationRepository in memory.
+// Notifications and preferences are copied on the way in and out.
+type NotificationRepository struct {
+	mu            sync.Mutex
+	notifications map[int]*domain.Notification
+	preferences   map[int]*domain.NotificationPreferences
+	customerUsers map[int]int
+	nextID        int
+	createErr     error
+}
+
+// NewNotificationRepository creates an empty in-memory notification repository
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{
+		notifications: make(map[int]*domain.Notification),
+		preferences:   make(map[int]*domain.NotificationPreferences),
+		customerUsers: make(map[int]int),
+		nextID:        1,
+	}
+}
+
+// SetCreateError makes Create fail with err; nil clears it
+func (r *NotificationRepository) SetCreateError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.createErr = err
+}
+
+// SetCustomerUser makes userID the active user account of customerID, whose
+// preferences GetCustomerPreferences returns
+func (r *NotificationRepository) SetCustomerUser(customerID, userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.customerUsers[customerID] = userID
+}
+
+// All returns every stored notification in the order they were created
+func (r *NotificationRepository) All() []*domain.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var notifications []*domain.Notification
+	for _, n := range r.notifications {
+		notifications = append(notifications, cloneNotification(n))
+	}
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID < notifications[j].ID })
+	return notifications
+}
+
+// Create stores a new notification, returning domain.ErrDuplicateNotification
+// when one of the same type exists for its source event
+func (r *NotificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.createErr != nil {
+		return r.createErr
+	}
+	if notification.SourceEventID != "" {
+		for _, existing := range r.notifications {
+			if existing.SourceEventID == notification.SourceEventID && existing.Type == notification.Type {
+				return domain.ErrDuplicateNotification
+			}
+		}
+	}
+	notification.ID = r.nextID
+	r.nextID++
+	r.notifications[notification.ID] = cloneNotification(notification)
+	return nil
+}
+
+// GetByID retrieves a notification by ID
+func (r *NotificationRepository) GetByID(ctx context.Context, id int) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, ok := r.notifications[id]
+	if !ok {
+		return nil, domain.ErrNotificationNotFound
+	}
+	return cloneNotification(notification), nil
+}
+
+// GetByUserID retrieves up to limit of a user's notifications, newest first
+func (r *NotificationRepository) GetByUserID(ctx context.Context, userID int, limit int) ([]*domain.Notification, error) {
+	return r.List(ctx, domain.NotificationFilter{UserID: userID, Limit: limit})
+}
+
+// List retrieves a user's notifications matching the filter, newest first.
+// As in SQL, the limit is always applied, so a zero limit returns nothing.
+func (r *NotificationRepository) List(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := make([]*domain.Notification, 0)
+	for _, n := range r.notifications {
+		if n.UserID != filter.UserID {
+			continue
+		}
+		if filter.UnreadOnly && n.IsRead() {
+			continue
+		}
+		if filter.Type != "" && n.Type != filter.Type {
+			continue
+		}
+		if filter.Since != nil && n.CreatedAt.Before(*filter.Since) {
+			continue
+		}
+		matched = append(matched, cloneNotification(n))
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	if filter.Offset >= len(matched) {
+		return []*domain.Notification{}, nil
+	}
+	matched = matched[filter.Offset:]
+	if len(matched) > filter.Limit {
+		matched = matched[:max(filter.Limit, 0)]
+	}
+	return matched, nil
+}
+
+// CountUnread counts a user's unread notifications
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, n := range r.notifications {
+		if n.UserID == userID && !n.IsRead() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MarkAllAsRead marks all of a user's notifications as read, returning how many changed
+func (r *NotificationRepository) MarkAllAsRead(ctx context.Context, userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for _, n := range r.notifications {
+		if n.UserID == userID && !n.IsRead() {
+			readAt := now
+			n.ReadAt = &readAt
+			n.UpdatedAt = now
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Update stores a notification's fields other than its email outcome and source
+// event. Like an SQL UPDATE, it does nothing if the notification doesn't exist.
+func (r *NotificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.notifications[notification.ID]
+	if !ok {
+		return nil
+	}
+	updated := cloneNotification(notification)
+	updated.CreatedAt = stored.CreatedAt
+	updated.EmailStatus = stored.EmailStatus
+	updated.EmailError = stored.EmailError
+	updated.SourceEventID = stored.SourceEventID
+	r.notifications[notification.ID] = updated
+	return nil
+}
+
+// UpdateEmailStatus stores an email notification's delivery outcome
+// without touching its read state
+func (r *NotificationRepository) UpdateEmailStatus(ctx context.Context, notification *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.notifications[notification.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = notification.Status
+	stored.SentAt = cloneTime(notification.SentAt)
+	stored.EmailStatus = notification.EmailStatus
+	stored.EmailError = notification.EmailError
+	stored.UpdatedAt = notification.UpdatedAt
+	return nil
+}
+
+// Delete deletes a notification
+func (r *NotificationRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.notifications, id)
+	return nil
+}
+
+// GetPreferences retrieves a user's notification preferences, returning
+// domain.ErrPreferencesNotFound if none are stored
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID int) (*domain.NotificationPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prefs, ok := r.preferences[userID]
+	if !ok {
+		return nil, domain.ErrPreferencesNotFound
+	}
+	return clonePreferences(prefs), nil
+}
+
+// GetCustomerPreferences retrieves the preferences of the user account set
+// for the customer with SetCustomerUser, returning
+// domain.ErrPreferencesNotFound if it has none
+func (r *NotificationRepository) GetCustomerPreferences(ctx context.Context, customerID int) (*domain.NotificationPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userID, ok := r.customerUsers[customerID]
+	if !ok {
+		return nil, domain.ErrPreferencesNotFound
+	}
+	prefs, ok := r.preferences[userID]
+	if !ok {
+		return nil, domain.ErrPreferencesNotFound
+	}
+	return clonePreferences(prefs), nil
+}
+
+// SavePreferences creates or replaces a user's notification preferences
+func (r *NotificationRepository) SavePreferences(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := clonePreferences(prefs)
+	if stored.EventTypes == nil {
+		stored.EventTypes = map[string]bool{}
+	}
+	r.preferences[prefs.UserID] = stored
+	return nil
+}
+
+// cloneNotification copies a notification so neither copy sees the other's changes
+func cloneNotification(n *domain.Notification) *domain.Notification {
+	copied := *n
+	if n.DeliveryID != nil {
+		deliveryID := *n.DeliveryID
+		copied.DeliveryID = &deliveryID
+	}
+	copied.SentAt = cloneTime(n.SentAt)
+	copied.ReadAt = cloneTime(n.ReadAt)
+	return &copied
+}
+
+// clonePreferences copies preferences, including their event type map
+func clonePreferences(p *domain.NotificationPreferences) *domain.NotificationPreferences {
+	copied := *p
+	if p.EventTypes != nil {
+		copied.EventTypes = make(map[string]bool, len(p.EventTypes))
+		for k, v := range p.EventTypes {
+			copied.EventTypes[k] = v
+		}
+	}
+	return &copied
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
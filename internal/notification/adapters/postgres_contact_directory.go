@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+)
+
+// PostgresContactDirectory implements the ContactDirectory and UserDirectory
+// interfaces using PostgreSQL
+type PostgresContactDirectory struct {
+	db *sql.DB
+}
+
+// NewPostgresContactDirectory creates a new PostgreSQL contact directory
+func NewPostgresContactDirectory(db *sql.DB) *PostgresContactDirectory {
+	return &PostgresContactDirectory{db: db}
+}
+
+// CustomerEmail returns the email of the customer's active user account,
+// falling back to the address on the customer record
+func (d *PostgresContactDirectory) CustomerEmail(ctx context.Context, customerID int) (string, error) {
+	query := `
+		SELECT COALESCE(
+			(SELECT email FROM users WHERE customer_id = $1 AND active ORDER BY id LIMIT 1),
+			(SELECT NULLIF(email, '') FROM customers WHERE id = $1)
+		)
+	`
+
+	var email sql.NullString
+	if err := d.db.QueryRowContext(ctx, query, customerID).Scan(&email); err != nil {
+		return "", err
+	}
+	if !email.Valid {
+		return "", domain.ErrNoEmailAddress
+	}
+
+	return email.String, nil
+}
+
+// UserOrganization returns the organization of a user
+func (d *PostgresContactDirectory) UserOrganization(ctx context.Context, userID int) (int, error) {
+	var orgID int
+	err := d.db.QueryRowContext(ctx, `SELECT org_id FROM users WHERE id = $1`, userID).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrRecipientNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return orgID, nil
+}
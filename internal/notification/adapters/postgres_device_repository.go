@@ -0,0 +1,140 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+)
+
+// PostgresDeviceRepository implements the DeviceRepository interface using PostgreSQL
+type PostgresDeviceRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresDeviceRepository creates a new PostgreSQL device repository
+func NewPostgresDeviceRepository(db *sql.DB) *PostgresDeviceRepository {
+	return &PostgresDeviceRepository{db: db}
+}
+
+const deviceColumns = `id, user_id, platform, token, active, created_at, updated_at`
+
+// scanDevice reads a row selected with deviceColumns
+func scanDevice(row rowScanner) (*domain.Device, error) {
+	var device domain.Device
+	err := row.Scan(
+		&device.ID,
+		&device.UserID,
+		&device.Platform,
+		&device.Token,
+		&device.Active,
+		&device.CreatedAt,
+		&device.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// Save registers a device. Registering a token the user already has
+// reactivates it and keeps its original ID and creation time.
+func (r *PostgresDeviceRepository) Save(ctx context.Context, device *domain.Device) error {
+	query := `
+		INSERT INTO devices (user_id, platform, token, active, created_at, updated_at)
+		VALUES ($1, $2, $3, TRUE, $4, $5)
+		ON CONFLICT (user_id, token) DO UPDATE SET
+			platform = EXCLUDED.platform,
+			active = TRUE,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+
+	device.Active = true
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		device.UserID,
+		device.Platform,
+		device.Token,
+		device.CreatedAt,
+		device.UpdatedAt,
+	).Scan(&device.ID, &device.CreatedAt)
+}
+
+// GetByID retrieves a device by ID
+func (r *PostgresDeviceRepository) GetByID(ctx context.Context, id int) (*domain.Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices WHERE id = $1`
+
+	device, err := scanDevice(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// ListActiveByUser retrieves a user's active devices, most recently registered first
+func (r *PostgresDeviceRepository) ListActiveByUser(ctx context.Context, userID int) ([]*domain.Device, error) {
+	query := `SELECT ` + deviceColumns + `
+		FROM devices
+		WHERE user_id = $1 AND active
+		ORDER BY updated_at DESC, id DESC
+	`
+	return r.list(ctx, query, userID)
+}
+
+// ListActiveByCustomer retrieves the active devices of a customer's active user accounts
+func (r *PostgresDeviceRepository) ListActiveByCustomer(ctx context.Context, customerID int) ([]*domain.Device, error) {
+	query := `
+		SELECT d.id, d.user_id, d.platform, d.token, d.active, d.created_at, d.updated_at
+		FROM devices d
+		JOIN users u ON u.id = d.user_id
+		WHERE u.customer_id = $1 AND u.active AND d.active
+		ORDER BY d.id
+	`
+	return r.list(ctx, query, customerID)
+}
+
+func (r *PostgresDeviceRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.Device, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*domain.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
+
+// Deactivate stops pushes to a device without forgetting it
+func (r *PostgresDeviceRepository) Deactivate(ctx context.Context, id int) error {
+	query := `UPDATE devices SET active = FALSE, updated_at = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, time.Now())
+	return err
+}
+
+// Delete removes a device registration
+func (r *PostgresDeviceRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM devices WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return domain.ErrDeviceNotFound
+	}
+	return nil
+}
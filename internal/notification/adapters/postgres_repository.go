@@ -3,6 +3,9 @@ package adapters
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
@@ -21,8 +24,9 @@ func NewPostgresNotificationRepository(db *sql.DB) *PostgresNotificationReposito
 // Create stores a new notification
 func (r *PostgresNotificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
 	query := `
-		INSERT INTO notifications (user_id, delivery_id, type, status, subject, message, recipient, sent_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO notifications (user_id, delivery_id, type, status, subject, message, recipient, sent_at, created_at, updated_at, email_status, source_event_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (source_event_id, type) DO NOTHING
 		RETURNING id
 	`
 
@@ -49,24 +53,33 @@ func (r *PostgresNotificationRepository) Create(ctx context.Context, notificatio
 		sentAt,
 		notification.CreatedAt,
 		notification.UpdatedAt,
+		nullEmailStatus(notification.EmailStatus),
+		sql.NullString{String: notification.SourceEventID, Valid: notification.SourceEventID != ""},
 	).Scan(&notification.ID)
 
+	// Nothing is returned when the event already created this notification
+	if err == sql.ErrNoRows {
+		return domain.ErrDuplicateNotification
+	}
 	return err
 }
 
-// GetByID retrieves a notification by ID
-func (r *PostgresNotificationRepository) GetByID(ctx context.Context, id int) (*domain.Notification, error) {
-	query := `
-		SELECT id, user_id, delivery_id, type, status, subject, message, recipient, sent_at, created_at, updated_at
-		FROM notifications
-		WHERE id = $1
-	`
+// notificationColumns lists the columns scanned by scanNotification
+const notificationColumns = `id, user_id, delivery_id, type, status, subject, message, recipient, sent_at, read_at, created_at, updated_at, email_status, email_error, source_event_id`
 
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanNotification scans a notification selected with notificationColumns
+func scanNotification(row rowScanner) (*domain.Notification, error) {
 	var notification domain.Notification
 	var deliveryID sql.NullInt64
-	var sentAt sql.NullTime
+	var sentAt, readAt sql.NullTime
+	var emailStatus, emailError, sourceEventID sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&notification.ID,
 		&notification.UserID,
 		&deliveryID,
@@ -76,14 +89,21 @@ func (r *PostgresNotificationRepository) GetByID(ctx context.Context, id int) (*
 		&notification.Message,
 		&notification.Recipient,
 		&sentAt,
+		&readAt,
 		&notification.CreatedAt,
 		&notification.UpdatedAt,
+		&emailStatus,
+		&emailError,
+		&sourceEventID,
 	)
-
 	if err != nil {
 		return nil, err
 	}
 
+	notification.EmailStatus = domain.EmailStatus(emailStatus.String)
+	notification.EmailError = emailError.String
+	notification.SourceEventID = sourceEventID.String
+
 	if deliveryID.Valid {
 		dID := int(deliveryID.Int64)
 		notification.DeliveryID = &dID
@@ -93,20 +113,62 @@ func (r *PostgresNotificationRepository) GetByID(ctx context.Context, id int) (*
 		notification.SentAt = &sentAt.Time
 	}
 
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+
 	return &notification, nil
 }
 
+// GetByID retrieves a notification by ID
+func (r *PostgresNotificationRepository) GetByID(ctx context.Context, id int) (*domain.Notification, error) {
+	query := `SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE id = $1
+	`
+
+	notification, err := scanNotification(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
+
 // GetByUserID retrieves notifications for a user
 func (r *PostgresNotificationRepository) GetByUserID(ctx context.Context, userID int, limit int) ([]*domain.Notification, error) {
-	query := `
-		SELECT id, user_id, delivery_id, type, status, subject, message, recipient, sent_at, created_at, updated_at
+	return r.List(ctx, domain.NotificationFilter{UserID: userID, Limit: limit})
+}
+
+// List retrieves a user's notifications matching the filter, newest first
+func (r *PostgresNotificationRepository) List(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{filter.UserID}
+
+	if filter.UnreadOnly {
+		conditions = append(conditions, "read_at IS NULL")
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`SELECT %s
 		FROM notifications
-		WHERE user_id = $1
+		WHERE %s
 		ORDER BY created_at DESC
-		LIMIT $2
-	`
+		LIMIT $%d OFFSET $%d
+	`, notificationColumns, strings.Join(conditions, " AND "), len(args)-1, len(args))
 
-	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -114,40 +176,40 @@ func (r *PostgresNotificationRepository) GetByUserID(ctx context.Context, userID
 
 	notifications := make([]*domain.Notification, 0)
 	for rows.Next() {
-		var notification domain.Notification
-		var deliveryID sql.NullInt64
-		var sentAt sql.NullTime
-
-		err := rows.Scan(
-			&notification.ID,
-			&notification.UserID,
-			&deliveryID,
-			&notification.Type,
-			&notification.Status,
-			&notification.Subject,
-			&notification.Message,
-			&notification.Recipient,
-			&sentAt,
-			&notification.CreatedAt,
-			&notification.UpdatedAt,
-		)
+		notification, err := scanNotification(rows)
 		if err != nil {
 			return nil, err
 		}
+		notifications = append(notifications, notification)
+	}
 
-		if deliveryID.Valid {
-			dID := int(deliveryID.Int64)
-			notification.DeliveryID = &dID
-		}
+	return notifications, rows.Err()
+}
 
-		if sentAt.Valid {
-			notification.SentAt = &sentAt.Time
-		}
+// CountUnread counts a user's unread notifications
+func (r *PostgresNotificationRepository) CountUnread(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
 
-		notifications = append(notifications, &notification)
+// MarkAllAsRead marks every unread notification of a user as read and returns how many changed
+func (r *PostgresNotificationRepository) MarkAllAsRead(ctx context.Context, userID int) (int, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = $1, updated_at = $1
+		WHERE user_id = $2 AND read_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return 0, err
 	}
 
-	return notifications, nil
+	affected, err := result.RowsAffected()
+	return int(affected), err
 }
 
 // UpdateStatus updates the status of a notification
@@ -166,8 +228,8 @@ func (r *PostgresNotificationRepository) UpdateStatus(ctx context.Context, id in
 func (r *PostgresNotificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
 	query := `
 		UPDATE notifications
-		SET user_id = $1, delivery_id = $2, type = $3, status = $4, subject = $5, message = $6, recipient = $7, sent_at = $8, updated_at = $9
-		WHERE id = $10
+		SET user_id = $1, delivery_id = $2, type = $3, status = $4, subject = $5, message = $6, recipient = $7, sent_at = $8, read_at = $9, updated_at = $10
+		WHERE id = $11
 	`
 
 	var deliveryID sql.NullInt64
@@ -180,6 +242,11 @@ func (r *PostgresNotificationRepository) Update(ctx context.Context, notificatio
 		sentAt = sql.NullTime{Time: *notification.SentAt, Valid: true}
 	}
 
+	var readAt sql.NullTime
+	if notification.ReadAt != nil {
+		readAt = sql.NullTime{Time: *notification.ReadAt, Valid: true}
+	}
+
 	_, err := r.db.ExecContext(
 		ctx,
 		query,
@@ -191,6 +258,7 @@ func (r *PostgresNotificationRepository) Update(ctx context.Context, notificatio
 		notification.Message,
 		notification.Recipient,
 		sentAt,
+		readAt,
 		notification.UpdatedAt,
 		notification.ID,
 	)
@@ -198,9 +266,136 @@ func (r *PostgresNotificationRepository) Update(ctx context.Context, notificatio
 	return err
 }
 
+// UpdateEmailStatus stores an email notification's delivery outcome
+func (r *PostgresNotificationRepository) UpdateEmailStatus(ctx context.Context, notification *domain.Notification) error {
+	query := `
+		UPDATE notifications
+		SET status = $1, sent_at = $2, email_status = $3, email_error = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	var sentAt sql.NullTime
+	if notification.SentAt != nil {
+		sentAt = sql.NullTime{Time: *notification.SentAt, Valid: true}
+	}
+
+	var emailError sql.NullString
+	if notification.EmailError != "" {
+		emailError = sql.NullString{String: notification.EmailError, Valid: true}
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		notification.Status,
+		sentAt,
+		nullEmailStatus(notification.EmailStatus),
+		emailError,
+		notification.UpdatedAt,
+		notification.ID,
+	)
+
+	return err
+}
+
+// nullEmailStatus stores an empty email status as NULL
+func nullEmailStatus(status domain.EmailStatus) sql.NullString {
+	return sql.NullString{String: string(status), Valid: status != ""}
+}
+
 // Delete deletes a notification
 func (r *PostgresNotificationRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM notifications WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
-}
\ No newline at end of file
+}
+
+// GetPreferences retrieves a user's notification preferences
+func (r *PostgresNotificationRepository) GetPreferences(ctx context.Context, userID int) (*domain.NotificationPreferences, error) {
+	query := `
+		SELECT user_id, in_app_enabled, email_enabled, sms_enabled, push_enabled, event_types, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+
+	return r.scanPreferences(ctx, query, userID)
+}
+
+// GetCustomerPreferences retrieves the preferences of the customer's active
+// user account, the same one CustomerEmail addresses
+func (r *PostgresNotificationRepository) GetCustomerPreferences(ctx context.Context, customerID int) (*domain.NotificationPreferences, error) {
+	query := `
+		SELECT user_id, in_app_enabled, email_enabled, sms_enabled, push_enabled, event_types, updated_at
+		FROM notification_preferences
+		WHERE user_id = (SELECT id FROM users WHERE customer_id = $1 AND active ORDER BY id LIMIT 1)
+	`
+
+	return r.scanPreferences(ctx, query, customerID)
+}
+
+// scanPreferences runs a query for a single preferences row
+func (r *PostgresNotificationRepository) scanPreferences(ctx context.Context, query string, args ...interface{}) (*domain.NotificationPreferences, error) {
+	var prefs domain.NotificationPreferences
+	var eventTypes []byte
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&prefs.UserID,
+		&prefs.InAppEnabled,
+		&prefs.EmailEnabled,
+		&prefs.SMSEnabled,
+		&prefs.PushEnabled,
+		&eventTypes,
+		&prefs.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrPreferencesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefs.EventTypes = make(map[string]bool)
+	if err := json.Unmarshal(eventTypes, &prefs.EventTypes); err != nil {
+		return nil, fmt.Errorf("failed to decode event types: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+// SavePreferences creates or replaces a user's notification preferences
+func (r *PostgresNotificationRepository) SavePreferences(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, in_app_enabled, email_enabled, sms_enabled, push_enabled, event_types, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE
+		SET in_app_enabled = EXCLUDED.in_app_enabled,
+			email_enabled = EXCLUDED.email_enabled,
+			sms_enabled = EXCLUDED.sms_enabled,
+			push_enabled = EXCLUDED.push_enabled,
+			event_types = EXCLUDED.event_types,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	eventTypes := prefs.EventTypes
+	if eventTypes == nil {
+		eventTypes = map[string]bool{}
+	}
+	encoded, err := json.Marshal(eventTypes)
+	if err != nil {
+		return fmt.Errorf("failed to encode event types: %w", err)
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		prefs.UserID,
+		prefs.InAppEnabled,
+		prefs.EmailEnabled,
+		prefs.SMSEnabled,
+		prefs.PushEnabled,
+		encoded,
+		prefs.UpdatedAt,
+	)
+
+	return err
+}
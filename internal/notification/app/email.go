@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// errEmailQueueFull is recorded on emails dropped because the send queue had no room
+var errEmailQueueFull = errors.New("email queue full")
+
+// EmailConfig controls the email send queue
+type EmailConfig struct {
+	QueueSize   int           // emails waiting to be sent; emails beyond it fail immediately
+	Workers     int           // concurrent sends
+	RetryDelay  time.Duration // wait before retrying a failed send once
+	SendTimeout time.Duration // bound on a single send attempt
+}
+
+// DefaultEmailConfig queues up to 100 emails for two workers
+func DefaultEmailConfig() EmailConfig {
+	return EmailConfig{
+		QueueSize:   100,
+		Workers:     2,
+		RetryDelay:  5 * time.Second,
+		SendTimeout: 30 * time.Second,
+	}
+}
+
+// emailJob is a queued email and the notification row recording its outcome
+type emailJob struct {
+	ctx          context.Context
+	notification *domain.Notification
+	message      ports.EmailMessage
+}
+
+// emailQueue hands emails to a fixed set of send workers
+type emailQueue struct {
+	sender   ports.EmailSender
+	contacts ports.ContactDirectory
+	config   EmailConfig
+
+	jobs    chan emailJob
+	mu      sync.RWMutex // guards closed against sends on the closed jobs channel
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// enqueue queues a job without blocking, reporting false when the queue is full or closed
+func (q *emailQueue) enqueue(job emailJob) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+
+	select {
+	case q.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops accepting emails and waits for the queued ones to be sent
+func (q *emailQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+
+	q.workers.Wait()
+}
+
+// SetEmailChannel enables email notifications sent through sender to the
+// addresses in contacts and starts the send workers. Zero config fields keep
+// the defaults; call Shutdown to drain the queue.
+func (s *NotificationService) SetEmailChannel(sender ports.EmailSender, contacts ports.ContactDirectory, config EmailConfig) {
+	defaults := DefaultEmailConfig()
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = defaults.SendTimeout
+	}
+
+	queue := &emailQueue{
+		sender:   sender,
+		contacts: contacts,
+		config:   config,
+		jobs:     make(chan emailJob, config.QueueSize),
+	}
+	for i := 0; i < config.Workers; i++ {
+		queue.workers.Add(1)
+		go func() {
+			defer queue.workers.Done()
+			for job := range queue.jobs {
+				s.deliverEmail(queue, job)
+			}
+		}()
+	}
+	s.email = queue
+}
+
+// Shutdown stops accepting emails and pushes and waits for the queued ones
+// to be sent
+func (s *NotificationService) Shutdown() {
+	if s.email != nil {
+		s.email.close()
+	}
+	if s.push != nil {
+		s.push.close()
+	}
+}
+
+// queueEmail renders an event email and queues it for the customer unless
+// they opted out of email or of the event type. Problems are logged and
+// recorded on the notification row rather than returned, so a slow or
+// failing mail server never holds up the event.
+func (s *NotificationService) queueEmail(ctx context.Context, customerID int, eventType, templateName string, data emailData) {
+	if s.email == nil || s.skipReplayed(ctx, "email") {
+		return
+	}
+	ctx = logger.WithContext(ctx, zap.Int("recipient_user_id", customerID))
+
+	prefs, err := s.customerPreferences(ctx, customerID)
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to get preferences for email notification", zap.Error(err))
+		return
+	}
+	if !prefs.Allows(domain.NotificationTypeEmail, eventType) {
+		s.logger.InfoWithFields(ctx, "Email suppressed by user preferences",
+			zap.String("event_type", eventType))
+		return
+	}
+
+	address, err := s.email.contacts.CustomerEmail(ctx, customerID)
+	if errors.Is(err, domain.ErrNoEmailAddress) {
+		s.logger.InfoWithFields(ctx, "Email skipped, no address on file")
+		return
+	}
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to look up email address", zap.Error(err))
+		return
+	}
+
+	message, err := renderEmail(templateName, address, data)
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to render email", zap.Error(err))
+		return
+	}
+
+	notification, err := domain.NewNotification(customerID, domain.NotificationTypeEmail, message.Subject, message.TextBody, address)
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to create email notification", zap.Error(err))
+		return
+	}
+	deliveryID := data.DeliveryID
+	notification.DeliveryID = &deliveryID
+	notification.SourceEventID = messaging.EventIDFromContext(ctx)
+	notification.MarkEmailQueued()
+	err = s.repo.Create(ctx, notification)
+	if errors.Is(err, domain.ErrDuplicateNotification) {
+		s.logger.InfoWithFields(ctx, "Email already queued for event")
+		return
+	}
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to store email notification", zap.Error(err))
+		return
+	}
+
+	job := emailJob{ctx: context.WithoutCancel(ctx), notification: notification, message: message}
+	if !s.email.enqueue(job) {
+		s.logger.WarnWithFields(ctx, "Email dropped, send queue full",
+			zap.Int("notification_id", notification.ID))
+		notification.MarkEmailFailed(errEmailQueueFull)
+		s.recordEmailStatus(ctx, notification)
+	}
+}
+
+// deliverEmail sends a queued email, retrying once after the retry delay,
+// and records the outcome on its notification row
+func (s *NotificationService) deliverEmail(queue *emailQueue, job emailJob) {
+	ctx := logger.WithContext(job.ctx, zap.Int("notification_id", job.notification.ID))
+
+	send := func() error {
+		sendCtx, cancel := context.WithTimeout(ctx, queue.config.SendTimeout)
+		defer cancel()
+		return queue.sender.Send(sendCtx, job.message)
+	}
+
+	err := send()
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Email send failed, retrying", zap.Error(err))
+		time.Sleep(queue.config.RetryDelay)
+		err = send()
+	}
+
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Email send failed", zap.Error(err))
+		job.notification.MarkEmailFailed(err)
+	} else {
+		job.notification.MarkEmailSent()
+	}
+	s.recordEmailStatus(ctx, job.notification)
+}
+
+// recordEmailStatus stores an email's outcome, logging when that fails
+func (s *NotificationService) recordEmailStatus(ctx context.Context, notification *domain.Notification) {
+	if err := s.repo.UpdateEmailStatus(ctx, notification); err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to record email status",
+			zap.String("email_status", string(notification.EmailStatus)),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+)
+
+// Email templates rendered for delivery events
+const (
+	emailTemplateStatusChanged = "status_changed"
+	emailTemplateDelivered     = "delivered"
+)
+
+// emailData is what the email templates can show
+type emailData struct {
+	DeliveryID     int
+	Status         string
+	PreviousStatus string
+}
+
+// newEmailData describes a status change with statuses spelled out for people
+func newEmailData(deliveryID int, status, previousStatus string) emailData {
+	return emailData{
+		DeliveryID:     deliveryID,
+		Status:         statusLabel(status),
+		PreviousStatus: statusLabel(previousStatus),
+	}
+}
+
+// statusLabel turns a status such as in_transit into "in transit"
+func statusLabel(status string) string {
+	return strings.ReplaceAll(status, "_", " ")
+}
+
+// emailTemplate renders one kind of email as a subject, a text body and an HTML body
+type emailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+func mustEmailTemplate(name, subject, text, html string) *emailTemplate {
+	return &emailTemplate{
+		subject: texttemplate.Must(texttemplate.New(name + "_subject").Parse(subject)),
+		text:    texttemplate.Must(texttemplate.New(name + "_text").Parse(text)),
+		html:    htmltemplate.Must(htmltemplate.New(name + "_html").Parse(html)),
+	}
+}
+
+// emailLayout wraps the HTML body of every email
+const emailLayout = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222;">
+%s
+<p style="color: #888; font-size: 12px;">You are receiving this because email notifications are on in your DeliverTrack preferences.</p>
+</body>
+</html>
+`
+
+var emailTemplates = map[string]*emailTemplate{
+	emailTemplateStatusChanged: mustEmailTemplate(emailTemplateStatusChanged,
+		`Delivery #{{.DeliveryID}} is now {{.Status}}`,
+		`Hello,
+
+Your delivery #{{.DeliveryID}} is now {{.Status}}{{if .PreviousStatus}} (previously {{.PreviousStatus}}){{end}}.
+
+- DeliverTrack
+`,
+		fmt.Sprintf(emailLayout, `<p>Hello,</p>
+<p>Your delivery <strong>#{{.DeliveryID}}</strong> is now <strong>{{.Status}}</strong>{{if .PreviousStatus}} (previously {{.PreviousStatus}}){{end}}.</p>
+<p>- DeliverTrack</p>`)),
+
+	emailTemplateDelivered: mustEmailTemplate(emailTemplateDelivered,
+		`Delivery #{{.DeliveryID}} has arrived`,
+		`Hello,
+
+Your delivery #{{.DeliveryID}} has been delivered.
+
+- DeliverTrack
+`,
+		fmt.Sprintf(emailLayout, `<p>Hello,</p>
+<p>Your delivery <strong>#{{.DeliveryID}}</strong> has been delivered.</p>
+<p>- DeliverTrack</p>`)),
+}
+
+// renderEmail renders the named template into an email addressed to to
+func renderEmail(name, to string, data emailData) (ports.EmailMessage, error) {
+	tmpl, ok := emailTemplates[name]
+	if !ok {
+		return ports.EmailMessage{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return ports.EmailMessage{}, fmt.Errorf("failed to render %s email subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return ports.EmailMessage{}, fmt.Errorf("failed to render %s email text: %w", name, err)
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return ports.EmailMessage{}, fmt.Errorf("failed to render %s email HTML: %w", name, err)
+	}
+
+	return ports.EmailMessage{
+		To:       to,
+		Subject:  subject.String(),
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
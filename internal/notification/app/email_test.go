@@ -0,0 +1,269 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// mockEmailSender records sent emails and fails its first `failures` sends
+type mockEmailSender struct {
+	mu       sync.Mutex
+	sent     []ports.EmailMessage
+	calls    int
+	failures int
+	started  chan struct{} // signalled at the start of each send when set
+	release  chan struct{} // each send waits for it when set
+}
+
+func (m *mockEmailSender) Send(ctx context.Context, message ports.EmailMessage) error {
+	if m.started != nil {
+		m.started <- struct{}{}
+	}
+	if m.release != nil {
+		<-m.release
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.calls <= m.failures {
+		return errors.New("connection refused")
+	}
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+// mockContactDirectory maps customer IDs to email addresses
+type mockContactDirectory map[int]string
+
+func (m mockContactDirectory) CustomerEmail(ctx context.Context, customerID int) (string, error) {
+	address, ok := m[customerID]
+	if !ok {
+		return "", domain.ErrNoEmailAddress
+	}
+	return address, nil
+}
+
+var testContacts = mockContactDirectory{3: "ada@example.com"}
+
+func statusEvent(newStatus string) messaging.Event {
+	return messaging.Event{
+		Type: messaging.EventTypeDeliveryStatusChanged,
+		Data: map[string]interface{}{
+			"customer_id": 3,
+			"delivery_id": 10,
+			"old_status":  "in_transit",
+			"new_status":  newStatus,
+		},
+	}
+}
+
+// emailNotifications returns the stored email notifications
+func emailNotifications(repo *memory.NotificationRepository) []*domain.Notification {
+	var emails []*domain.Notification
+	for _, n := range repo.All() {
+		if n.Type == domain.NotificationTypeEmail {
+			emails = append(emails, n)
+		}
+	}
+	return emails
+}
+
+func TestNotificationService_EmailOnStatusChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		subject string
+		body    string
+	}{
+		{"delivered", "delivered", "Delivery #10 has arrived", "has been delivered"},
+		{"status change", "out_for_delivery", "Delivery #10 is now out for delivery", "(previously in transit)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewNotificationRepository()
+			service := newTestService(t, repo)
+			sender := &mockEmailSender{}
+			service.SetEmailChannel(sender, testContacts, EmailConfig{})
+
+			if err := service.handleEvent(statusEvent(tt.status)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			service.Shutdown()
+
+			if len(sender.sent) != 1 {
+				t.Fatalf("expected 1 email, got %d", len(sender.sent))
+			}
+			message := sender.sent[0]
+			if message.To != "ada@example.com" || message.Subject != tt.subject {
+				t.Errorf("unexpected email %q to %q", message.Subject, message.To)
+			}
+			if !strings.Contains(message.TextBody, tt.body) || !strings.Contains(message.HTMLBody, "<strong>#10</strong>") {
+				t.Errorf("unexpected email bodies:\n%s\n%s", message.TextBody, message.HTMLBody)
+			}
+
+			// The in-app notification is kept alongside the email
+			if len(repo.All()) != 2 {
+				t.Fatalf("expected in-app and email notifications, got %d", len(repo.All()))
+			}
+			emails := emailNotifications(repo)
+			if len(emails) != 1 || emails[0].EmailStatus != domain.EmailStatusSent || emails[0].Status != domain.NotificationStatusSent {
+				t.Errorf("expected a sent email notification, got %+v", emails)
+			}
+			if emails[0].DeliveryID == nil || *emails[0].DeliveryID != 10 {
+				t.Errorf("expected the email to reference delivery 10, got %v", emails[0].DeliveryID)
+			}
+		})
+	}
+}
+
+func TestNotificationService_EmailHonorsPreferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		prefs *domain.NotificationPreferences
+	}{
+		{"email channel disabled", &domain.NotificationPreferences{UserID: 3, InAppEnabled: true, EmailEnabled: false, EventTypes: map[string]bool{}}},
+		{"status updates disabled", &domain.NotificationPreferences{UserID: 3, InAppEnabled: true, EmailEnabled: true, EventTypes: map[string]bool{domain.EventTypeStatusUpdates: false}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewNotificationRepository()
+			repo.SetCustomerUser(3, 3)
+			service := newTestService(t, repo)
+			sender := &mockEmailSender{}
+			service.SetEmailChannel(sender, testContacts, EmailConfig{})
+			if err := service.UpdatePreferences(context.Background(), tt.prefs); err != nil {
+				t.Fatalf("unexpected error saving preferences: %v", err)
+			}
+
+			if err := service.handleEvent(statusEvent("delivered")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			service.Shutdown()
+
+			if len(sender.sent) != 0 || len(emailNotifications(repo)) != 0 {
+				t.Errorf("expected no email, got %d sent", len(sender.sent))
+			}
+		})
+	}
+}
+
+func TestNotificationService_EmailWithoutAddress(t *testing.T) {
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+	sender := &mockEmailSender{}
+	service.SetEmailChannel(sender, mockContactDirectory{}, EmailConfig{})
+
+	if err := service.handleEvent(statusEvent("delivered")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.Shutdown()
+
+	if len(sender.sent) != 0 || len(emailNotifications(repo)) != 0 {
+		t.Errorf("expected no email without an address, got %d sent", len(sender.sent))
+	}
+	if len(repo.All()) != 1 {
+		t.Errorf("expected the in-app notification, got %d notifications", len(repo.All()))
+	}
+}
+
+func TestNotificationService_EmailRetriesOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		status   domain.EmailStatus
+	}{
+		{"retry succeeds", 1, domain.EmailStatusSent},
+		{"retry fails", 2, domain.EmailStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewNotificationRepository()
+			service := newTestService(t, repo)
+			sender := &mockEmailSender{failures: tt.failures}
+			service.SetEmailChannel(sender, testContacts, EmailConfig{RetryDelay: time.Millisecond})
+
+			if err := service.handleEvent(statusEvent("delivered")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			service.Shutdown()
+
+			if sender.calls != 2 {
+				t.Errorf("expected 2 send attempts, got %d", sender.calls)
+			}
+			emails := emailNotifications(repo)
+			if len(emails) != 1 || emails[0].EmailStatus != tt.status {
+				t.Fatalf("expected email status %s, got %+v", tt.status, emails)
+			}
+			if tt.status == domain.EmailStatusFailed && (emails[0].EmailError != "connection refused" || emails[0].Status != domain.NotificationStatusFailed) {
+				t.Errorf("expected the failure to be recorded, got %+v", emails[0])
+			}
+		})
+	}
+}
+
+func TestNotificationService_EmailQueueFull(t *testing.T) {
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+	sender := &mockEmailSender{started: make(chan struct{}, 3), release: make(chan struct{})}
+	service.SetEmailChannel(sender, testContacts, EmailConfig{QueueSize: 1, Workers: 1})
+
+	// The first email occupies the worker, the second fills the queue
+	if err := service.handleEvent(statusEvent("delivered")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-sender.started
+	if err := service.handleEvent(statusEvent("delivered")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The third is dropped without blocking the event
+	if err := service.handleEvent(statusEvent("delivered")); err != nil {
+		t.Fatalf("expected the event to be acked, got %v", err)
+	}
+
+	close(sender.release)
+	service.Shutdown()
+
+	var sent, dropped int
+	for _, n := range emailNotifications(repo) {
+		switch {
+		case n.EmailStatus == domain.EmailStatusSent:
+			sent++
+		case n.EmailStatus == domain.EmailStatusFailed && n.EmailError == errEmailQueueFull.Error():
+			dropped++
+		}
+	}
+	if sent != 2 || dropped != 1 {
+		t.Errorf("expected 2 sent and 1 dropped email, got %d sent and %d dropped", sent, dropped)
+	}
+}
+
+func TestRenderEmail_EscapesHTML(t *testing.T) {
+	message, err := renderEmail(emailTemplateStatusChanged, "ada@example.com", newEmailData(10, "<b>lost</b>", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(message.HTMLBody, "<b>lost</b>") || !strings.Contains(message.HTMLBody, "&lt;b&gt;lost&lt;/b&gt;") {
+		t.Errorf("expected the status to be escaped in HTML, got %s", message.HTMLBody)
+	}
+	if !strings.Contains(message.TextBody, "<b>lost</b>") {
+		t.Errorf("expected the text body to be unescaped, got %s", message.TextBody)
+	}
+	if strings.Contains(message.TextBody, "previously") {
+		t.Errorf("expected no previous status without one, got %s", message.TextBody)
+	}
+}
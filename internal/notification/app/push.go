@@ -0,0 +1,320 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+var (
+	// errPushNotConfigured is returned by device endpoints when SetPushChannel was never called
+	errPushNotConfigured = errors.New("push notifications are not configured")
+	// errPushQueueFull is logged for pushes dropped because the send queue had no room
+	errPushQueueFull = errors.New("push queue full")
+)
+
+// PushConfig controls push notification fan-out
+type PushConfig struct {
+	MaxDevicesPerUser int           // active devices kept per user; older registrations are deactivated
+	SendTimeout       time.Duration // bound on a single device send
+	QueueSize         int           // pushes waiting to be sent; pushes beyond it fail immediately
+	Workers           int           // concurrent fan-outs
+}
+
+// DefaultPushConfig keeps up to 10 devices per user and queues up to 100
+// pushes for four workers
+func DefaultPushConfig() PushConfig {
+	return PushConfig{
+		MaxDevicesPerUser: 10,
+		SendTimeout:       10 * time.Second,
+		QueueSize:         100,
+		Workers:           4,
+	}
+}
+
+// pushJob is a queued fan-out and the notification row recording its outcome
+type pushJob struct {
+	ctx          context.Context
+	notification *domain.Notification
+	devices      []*domain.Device
+	message      ports.PushMessage
+}
+
+// pushChannel sends push notifications to registered devices from a fixed
+// set of send workers, so a slow provider never holds up event handling
+type pushChannel struct {
+	sender  ports.PushSender
+	devices ports.DeviceRepository
+	config  PushConfig
+
+	jobs    chan pushJob
+	mu      sync.RWMutex // guards closed against sends on the closed jobs channel
+	closed  bool
+	pending sync.WaitGroup // queued and in-flight jobs
+	workers sync.WaitGroup
+}
+
+// enqueue queues a job without blocking, reporting false when the queue is full or closed
+func (p *pushChannel) enqueue(job pushJob) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+
+	p.pending.Add(1)
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		p.pending.Done()
+		return false
+	}
+}
+
+// close stops accepting pushes and waits for the queued ones to be sent
+func (p *pushChannel) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.jobs)
+	p.mu.Unlock()
+
+	p.workers.Wait()
+}
+
+// SetPushChannel enables device registration and push notifications sent
+// through sender and starts the send workers. Zero config fields keep the
+// defaults; call Shutdown to drain the queue.
+func (s *NotificationService) SetPushChannel(sender ports.PushSender, devices ports.DeviceRepository, config PushConfig) {
+	defaults := DefaultPushConfig()
+	if config.MaxDevicesPerUser <= 0 {
+		config.MaxDevicesPerUser = defaults.MaxDevicesPerUser
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = defaults.SendTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+
+	channel := &pushChannel{
+		sender:  sender,
+		devices: devices,
+		config:  config,
+		jobs:    make(chan pushJob, config.QueueSize),
+	}
+	for i := 0; i < config.Workers; i++ {
+		channel.workers.Add(1)
+		go func() {
+			defer channel.workers.Done()
+			for job := range channel.jobs {
+				s.deliverPush(channel, job)
+				channel.pending.Done()
+			}
+		}()
+	}
+	s.push = channel
+}
+
+// RegisterDevice registers a device to receive the user's push notifications.
+// Registering a known token reactivates it; beyond the per-user cap the
+// least recently registered devices are deactivated.
+func (s *NotificationService) RegisterDevice(ctx context.Context, userID int, platform domain.DevicePlatform, token string) (*domain.Device, error) {
+	if s.push == nil {
+		return nil, errPushNotConfigured
+	}
+
+	device, err := domain.NewDevice(userID, platform, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.push.devices.Save(ctx, device); err != nil {
+		return nil, err
+	}
+
+	active, err := s.push.devices.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, stale := range excessDevices(active, device.ID, s.push.config.MaxDevicesPerUser) {
+		if err := s.push.devices.Deactivate(ctx, stale.ID); err != nil {
+			return nil, err
+		}
+		s.logger.InfoWithFields(ctx, "Deactivated device over the per-user limit",
+			zap.Int("device_id", stale.ID),
+			zap.Int("user_id", userID))
+	}
+
+	return device, nil
+}
+
+// excessDevices returns the devices beyond the first max, never including the
+// one just registered. devices are ordered most recently registered first.
+func excessDevices(devices []*domain.Device, registeredID, max int) []*domain.Device {
+	var excess []*domain.Device
+	kept := 1 // the registered device always stays
+	for _, device := range devices {
+		if device.ID == registeredID {
+			continue
+		}
+		if kept < max {
+			kept++
+			continue
+		}
+		excess = append(excess, device)
+	}
+	return excess
+}
+
+// DeleteDevice removes one of the user's devices
+func (s *NotificationService) DeleteDevice(ctx context.Context, userID, deviceID int) error {
+	if s.push == nil {
+		return errPushNotConfigured
+	}
+
+	device, err := s.push.devices.GetByID(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	// Other users' devices are reported missing so IDs can't be probed
+	if device.UserID != userID {
+		return domain.ErrDeviceNotFound
+	}
+
+	return s.push.devices.Delete(ctx, deviceID)
+}
+
+// pushToCustomer queues a push notification to every active device of the
+// customer unless they opted out of push or of the event type. Each fan-out is
+// recorded as a push notification row, so a redelivered event is not pushed
+// again. Sends happen on the push workers; failures are logged and recorded
+// on the row so a slow or flaky provider never holds up the event.
+func (s *NotificationService) pushToCustomer(ctx context.Context, customerID int, eventType string, deliveryID int, title, body string) {
+	if s.push == nil || s.skipReplayed(ctx, "push") {
+		return
+	}
+	ctx = logger.WithContext(ctx, zap.Int("recipient_user_id", customerID))
+
+	prefs, err := s.customerPreferences(ctx, customerID)
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to get preferences for push notification", zap.Error(err))
+		return
+	}
+	if !prefs.Allows(domain.NotificationTypePush, eventType) {
+		s.logger.InfoWithFields(ctx, "Push suppressed by user preferences",
+			zap.String("event_type", eventType))
+		return
+	}
+
+	devices, err := s.push.devices.ListActiveByCustomer(ctx, customerID)
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to list devices for push notification", zap.Error(err))
+		return
+	}
+	if len(devices) == 0 {
+		return
+	}
+
+	notification, err := domain.NewNotification(customerID, domain.NotificationTypePush, title, body, fmt.Sprintf("customer_%d", customerID))
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to create push notification", zap.Error(err))
+		return
+	}
+	notification.DeliveryID = &deliveryID
+	notification.SourceEventID = messaging.EventIDFromContext(ctx)
+	err = s.repo.Create(ctx, notification)
+	if errors.Is(err, domain.ErrDuplicateNotification) {
+		s.logger.InfoWithFields(ctx, "Push already sent for event")
+		return
+	}
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to store push notification", zap.Error(err))
+		return
+	}
+
+	message := ports.PushMessage{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"event_type":  eventType,
+			"delivery_id": strconv.Itoa(deliveryID),
+		},
+	}
+	job := pushJob{ctx: context.WithoutCancel(ctx), notification: notification, devices: devices, message: message}
+	if !s.push.enqueue(job) {
+		s.logger.WarnWithFields(ctx, "Push dropped, send queue full",
+			zap.Int("notification_id", notification.ID),
+			zap.Error(errPushQueueFull))
+		notification.MarkAsFailed()
+		s.recordPushStatus(ctx, notification)
+	}
+}
+
+// deliverPush fans a queued push out to its devices and records the outcome
+// on its notification row. Devices whose token the provider rejects are
+// deactivated.
+func (s *NotificationService) deliverPush(channel *pushChannel, job pushJob) {
+	ctx := logger.WithContext(job.ctx, zap.Int("notification_id", job.notification.ID))
+
+	delivered := false
+	for _, device := range job.devices {
+		if s.pushToDevice(ctx, channel, device, job.message) {
+			delivered = true
+		}
+	}
+
+	if delivered {
+		job.notification.MarkAsSent()
+	} else {
+		job.notification.MarkAsFailed()
+	}
+	s.recordPushStatus(ctx, job.notification)
+}
+
+// recordPushStatus stores a push's outcome, logging when that fails
+func (s *NotificationService) recordPushStatus(ctx context.Context, notification *domain.Notification) {
+	if err := s.repo.Update(ctx, notification); err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to record push status", zap.Error(err))
+	}
+}
+
+// pushToDevice sends one push notification, deactivating unregistered tokens.
+// It reports whether the provider accepted the notification.
+func (s *NotificationService) pushToDevice(ctx context.Context, channel *pushChannel, device *domain.Device, message ports.PushMessage) bool {
+	ctx = logger.WithContext(ctx, zap.Int("device_id", device.ID))
+
+	sendCtx, cancel := context.WithTimeout(ctx, channel.config.SendTimeout)
+	err := channel.sender.Send(sendCtx, device, message)
+	cancel()
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrPushTokenUnregistered):
+		s.logger.InfoWithFields(ctx, "Push token unregistered, deactivating device", zap.Error(err))
+		if err := channel.devices.Deactivate(ctx, device.ID); err != nil {
+			s.logger.ErrorWithFields(ctx, "Failed to deactivate device", zap.Error(err))
+		}
+	default:
+		s.logger.WarnWithFields(ctx, "Push send failed",
+			zap.String("platform", string(device.Platform)),
+			zap.Error(err))
+	}
+	return false
+}
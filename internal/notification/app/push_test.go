@@ -0,0 +1,410 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+)
+
+// fakePushSender records pushes and rejects the tokens in unregistered
+type fakePushSender struct {
+	mu           sync.Mutex
+	sent         map[string][]ports.PushMessage // by token
+	unregistered map[string]bool
+	err          error // returned for every other token when set
+}
+
+func (f *fakePushSender) Send(ctx context.Context, device *domain.Device, message ports.PushMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.unregistered[device.Token] {
+		return fmt.Errorf("%w: requested entity was not found", domain.ErrPushTokenUnregistered)
+	}
+	if f.err != nil {
+		return f.err
+	}
+	if f.sent == nil {
+		f.sent = make(map[string][]ports.PushMessage)
+	}
+	f.sent[device.Token] = append(f.sent[device.Token], message)
+	return nil
+}
+
+// mockDeviceRepository stores devices in memory; customers maps user IDs to customer IDs
+type mockDeviceRepository struct {
+	devices   map[int]*domain.Device
+	customers map[int]int
+	nextID    int
+	clock     time.Time // advanced on every save so registrations are ordered
+}
+
+func newMockDeviceRepository() *mockDeviceRepository {
+	return &mockDeviceRepository{
+		devices:   make(map[int]*domain.Device),
+		customers: map[int]int{7: 3, 8: 3},
+		clock:     time.Now(),
+	}
+}
+
+func (m *mockDeviceRepository) Save(ctx context.Context, device *domain.Device) error {
+	m.clock = m.clock.Add(time.Second)
+	for _, existing := range m.devices {
+		if existing.UserID == device.UserID && existing.Token == device.Token {
+			existing.Platform = device.Platform
+			existing.Active = true
+			existing.UpdatedAt = m.clock
+			device.ID = existing.ID
+			device.CreatedAt = existing.CreatedAt
+			device.UpdatedAt = m.clock
+			return nil
+		}
+	}
+	m.nextID++
+	device.ID = m.nextID
+	device.UpdatedAt = m.clock
+	stored := *device
+	m.devices[device.ID] = &stored
+	return nil
+}
+
+func (m *mockDeviceRepository) GetByID(ctx context.Context, id int) (*domain.Device, error) {
+	device, ok := m.devices[id]
+	if !ok {
+		return nil, domain.ErrDeviceNotFound
+	}
+	return device, nil
+}
+
+func (m *mockDeviceRepository) active(match func(*domain.Device) bool) []*domain.Device {
+	var devices []*domain.Device
+	for _, device := range m.devices {
+		if device.Active && match(device) {
+			devices = append(devices, device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].UpdatedAt.After(devices[j].UpdatedAt) })
+	return devices
+}
+
+func (m *mockDeviceRepository) ListActiveByUser(ctx context.Context, userID int) ([]*domain.Device, error) {
+	return m.active(func(d *domain.Device) bool { return d.UserID == userID }), nil
+}
+
+func (m *mockDeviceRepository) ListActiveByCustomer(ctx context.Context, customerID int) ([]*domain.Device, error) {
+	return m.active(func(d *domain.Device) bool { return m.customers[d.UserID] == customerID }), nil
+}
+
+func (m *mockDeviceRepository) Deactivate(ctx context.Context, id int) error {
+	if device, ok := m.devices[id]; ok {
+		device.Active = false
+	}
+	return nil
+}
+
+func (m *mockDeviceRepository) Delete(ctx context.Context, id int) error {
+	if _, ok := m.devices[id]; !ok {
+		return domain.ErrDeviceNotFound
+	}
+	delete(m.devices, id)
+	return nil
+}
+
+func newPushTestService(t *testing.T, sender *fakePushSender, config PushConfig) (*NotificationService, *mockDeviceRepository, *memory.NotificationRepository) {
+	t.Helper()
+	repo := memory.NewNotificationRepository()
+	devices := newMockDeviceRepository()
+	service := newTestService(t, repo)
+	service.SetPushChannel(sender, devices, config)
+	return service, devices, repo
+}
+
+// waitForPushes blocks until the push workers sent everything queued so far
+func waitForPushes(service *NotificationService) {
+	service.push.pending.Wait()
+}
+
+func registerDevice(t *testing.T, service *NotificationService, userID int, token string) *domain.Device {
+	t.Helper()
+	device, err := service.RegisterDevice(context.Background(), userID, domain.DevicePlatformFCM, token)
+	if err != nil {
+		t.Fatalf("failed to register device: %v", err)
+	}
+	return device
+}
+
+func TestNotificationService_RegisterDevice(t *testing.T) {
+	service, devices, _ := newPushTestService(t, &fakePushSender{}, PushConfig{})
+
+	first := registerDevice(t, service, 7, "phone")
+	again := registerDevice(t, service, 7, "phone")
+	if again.ID != first.ID || len(devices.devices) != 1 {
+		t.Errorf("expected re-registering a token to reuse device %d, got %d (%d stored)", first.ID, again.ID, len(devices.devices))
+	}
+
+	// Another user may hold the same token, e.g. after signing in on a shared tablet
+	if other := registerDevice(t, service, 8, "phone"); other.ID == first.ID {
+		t.Error("expected a separate device for another user")
+	}
+
+	if _, err := service.RegisterDevice(context.Background(), 7, "pager", "token"); !errors.Is(err, domain.ErrInvalidDevice) {
+		t.Errorf("expected ErrInvalidDevice for an unknown platform, got %v", err)
+	}
+}
+
+func TestNotificationService_RegisterDeviceCapsDevicesPerUser(t *testing.T) {
+	service, devices, _ := newPushTestService(t, &fakePushSender{}, PushConfig{MaxDevicesPerUser: 2})
+
+	oldest := registerDevice(t, service, 7, "old-phone")
+	registerDevice(t, service, 7, "tablet")
+	registerDevice(t, service, 7, "new-phone")
+
+	active, _ := devices.ListActiveByUser(context.Background(), 7)
+	if len(active) != 2 {
+		t.Fatalf("expected 2 active devices, got %d", len(active))
+	}
+	if devices.devices[oldest.ID].Active {
+		t.Error("expected the least recently registered device to be deactivated")
+	}
+
+	// Re-registering the deactivated device brings it back and evicts the next oldest
+	registerDevice(t, service, 7, "old-phone")
+	if !devices.devices[oldest.ID].Active {
+		t.Error("expected re-registration to reactivate the device")
+	}
+	if active, _ := devices.ListActiveByUser(context.Background(), 7); len(active) != 2 || active[1].Token != "new-phone" {
+		t.Errorf("expected the tablet to be evicted, got %+v", active)
+	}
+}
+
+func TestNotificationService_DeleteDevice(t *testing.T) {
+	service, devices, _ := newPushTestService(t, &fakePushSender{}, PushConfig{})
+	device := registerDevice(t, service, 7, "phone")
+
+	if err := service.DeleteDevice(context.Background(), 8, device.ID); !errors.Is(err, domain.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound for another user's device, got %v", err)
+	}
+	if err := service.DeleteDevice(context.Background(), 7, device.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(devices.devices) != 0 {
+		t.Errorf("expected the device to be deleted, got %v", devices.devices)
+	}
+}
+
+func TestNotificationService_PushFansOutToCustomerDevices(t *testing.T) {
+	sender := &fakePushSender{unregistered: map[string]bool{"uninstalled": true}}
+	service, devices, _ := newPushTestService(t, sender, PushConfig{})
+	registerDevice(t, service, 7, "phone")
+	registerDevice(t, service, 8, "laptop") // a second account of customer 3
+	gone := registerDevice(t, service, 7, "uninstalled")
+	registerDevice(t, service, 9, "stranger") // not one of customer 3's accounts
+
+	if err := service.handleEvent(statusEvent("out_for_delivery")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForPushes(service)
+
+	for _, token := range []string{"phone", "laptop"} {
+		pushes := sender.sent[token]
+		if len(pushes) != 1 || pushes[0].Title != "Delivery Status Update" || pushes[0].Data["delivery_id"] != "10" {
+			t.Errorf("expected one status push to %s, got %+v", token, pushes)
+		}
+	}
+	if len(sender.sent["stranger"]) != 0 {
+		t.Error("expected no push to devices of other customers")
+	}
+	if devices.devices[gone.ID].Active {
+		t.Error("expected the unregistered device to be deactivated")
+	}
+
+	// Deactivated devices are skipped from then on
+	if err := service.handleEvent(statusEvent("delivered")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForPushes(service)
+	if len(sender.sent["phone"]) != 2 || len(sender.sent["uninstalled"]) != 0 {
+		t.Errorf("unexpected pushes %v", sender.sent)
+	}
+}
+
+func TestNotificationService_PushHonorsPreferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		prefs *domain.NotificationPreferences
+	}{
+		{"push channel disabled", &domain.NotificationPreferences{UserID: 7, InAppEnabled: true, PushEnabled: false, EventTypes: map[string]bool{}}},
+		{"status updates disabled", &domain.NotificationPreferences{UserID: 7, InAppEnabled: true, PushEnabled: true, EventTypes: map[string]bool{domain.EventTypeStatusUpdates: false}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakePushSender{}
+			service, _, repo := newPushTestService(t, sender, PushConfig{})
+			repo.SetCustomerUser(3, 7)
+			registerDevice(t, service, 7, "phone")
+			if err := service.UpdatePreferences(context.Background(), tt.prefs); err != nil {
+				t.Fatalf("unexpected error saving preferences: %v", err)
+			}
+
+			if err := service.handleEvent(statusEvent("delivered")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			waitForPushes(service)
+			if len(sender.sent) != 0 {
+				t.Errorf("expected no push, got %v", sender.sent)
+			}
+		})
+	}
+}
+
+func TestNotificationService_PushFailureDoesNotFailEvent(t *testing.T) {
+	sender := &fakePushSender{err: errors.New("503 service unavailable")}
+	service, devices, repo := newPushTestService(t, sender, PushConfig{})
+	device := registerDevice(t, service, 7, "phone")
+
+	if err := service.handleEvent(statusEvent("delivered")); err != nil {
+		t.Fatalf("expected the event to be acked, got %v", err)
+	}
+	waitForPushes(service)
+	if !devices.devices[device.ID].Active {
+		t.Error("expected transient failures to keep the device active")
+	}
+	if len(repo.All()) != 2 {
+		t.Fatalf("expected in-app and push notifications, got %d", len(repo.All()))
+	}
+	for _, n := range repo.All() {
+		if n.Type == domain.NotificationTypePush && n.Status != domain.NotificationStatusFailed {
+			t.Errorf("expected the push to be recorded as failed, got %s", n.Status)
+		}
+	}
+}
+
+// blockingPushSender holds every send until release is closed
+type blockingPushSender struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingPushSender) Send(ctx context.Context, device *domain.Device, message ports.PushMessage) error {
+	b.started <- struct{}{}
+	<-b.release
+	return nil
+}
+
+func TestNotificationService_PushDoesNotBlockEvents(t *testing.T) {
+	sender := &blockingPushSender{started: make(chan struct{}, 3), release: make(chan struct{})}
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+	service.SetPushChannel(sender, newMockDeviceRepository(), PushConfig{QueueSize: 1, Workers: 1})
+	registerDevice(t, service, 7, "phone")
+
+	// The worker holds the first push and the second fills the queue; the
+	// third is dropped, and none of them hold up the event handler
+	for i, status := range []string{"picked_up", "in_transit", "delivered"} {
+		event := statusEvent(status)
+		event.ID = fmt.Sprintf("evt_%d", i)
+		if err := service.handleEvent(event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if i == 0 {
+			<-sender.started
+		}
+	}
+	close(sender.release)
+	service.Shutdown()
+
+	statuses := make(map[domain.NotificationStatus]int)
+	for _, n := range repo.All() {
+		if n.Type == domain.NotificationTypePush {
+			statuses[n.Status]++
+		}
+	}
+	if statuses[domain.NotificationStatusSent] != 2 || statuses[domain.NotificationStatusFailed] != 1 {
+		t.Errorf("expected two pushes sent and one dropped, got %v", statuses)
+	}
+}
+
+func TestNotificationService_RedeliveredEventIsHandledOnce(t *testing.T) {
+	sender := &fakePushSender{}
+	service, _, repo := newPushTestService(t, sender, PushConfig{})
+	emails := &mockEmailSender{}
+	service.SetEmailChannel(emails, testContacts, EmailConfig{})
+	registerDevice(t, service, 7, "phone")
+
+	event := statusEvent("delivered")
+	event.ID = "evt_1"
+	for i := 0; i < 2; i++ {
+		if err := service.handleEvent(event); err != nil {
+			t.Fatalf("expected delivery %d to be acked, got %v", i+1, err)
+		}
+	}
+	waitForPushes(service)
+
+	counts := make(map[domain.NotificationType]int)
+	for _, n := range repo.All() {
+		counts[n.Type]++
+	}
+	if len(repo.All()) != 3 || counts[domain.NotificationTypeDeliveryUpdate] != 1 ||
+		counts[domain.NotificationTypeEmail] != 1 || counts[domain.NotificationTypePush] != 1 {
+		t.Errorf("expected one notification per channel, got %v", counts)
+	}
+	if len(sender.sent["phone"]) != 1 {
+		t.Errorf("expected one push, got %d", len(sender.sent["phone"]))
+	}
+
+	// A different event for the same delivery is a new notification
+	event.ID = "evt_2"
+	if err := service.handleEvent(event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.Shutdown()
+	if len(sender.sent["phone"]) != 2 || len(emails.sent) != 2 {
+		t.Errorf("expected one push and one email per event, got %d pushes and %d emails", len(sender.sent["phone"]), len(emails.sent))
+	}
+}
+
+func TestNotificationService_ReplayedEventOnlyBackfills(t *testing.T) {
+	sender := &fakePushSender{}
+	service, _, repo := newPushTestService(t, sender, PushConfig{})
+	emails := &mockEmailSender{}
+	service.SetEmailChannel(emails, testContacts, EmailConfig{})
+	registerDevice(t, service, 7, "phone")
+	sub := service.subscribers.subscribe(3)
+	defer sub.Close()
+
+	occurredAt := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	event := statusEvent("delivered")
+	event.ID = "evt_missed"
+	event.Timestamp = occurredAt.Unix()
+	event.Replayed = true
+	for i := 0; i < 2; i++ {
+		if err := service.handleEvent(event); err != nil {
+			t.Fatalf("expected replay %d to be acked, got %v", i+1, err)
+		}
+	}
+	service.Shutdown()
+
+	notifications := repo.All()
+	if len(notifications) != 1 || notifications[0].Type != domain.NotificationTypeDeliveryUpdate {
+		t.Fatalf("expected only the in-app notification to be backfilled, got %+v", notifications)
+	}
+	if !notifications[0].CreatedAt.Equal(occurredAt) || notifications[0].SourceEventID != "evt_missed" {
+		t.Errorf("expected the notification as of the original event, got %v %q", notifications[0].CreatedAt, notifications[0].SourceEventID)
+	}
+	if len(sender.sent["phone"]) != 0 || len(emails.sent) != 0 {
+		t.Errorf("expected no push or email for a replay, got %d pushes and %d emails", len(sender.sent["phone"]), len(emails.sent))
+	}
+	select {
+	case n := <-sub.Notifications():
+		t.Errorf("expected live subscribers not to be sent a replay, got %+v", n)
+	default:
+	}
+}
@@ -2,32 +2,51 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/notification/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"go.uber.org/zap"
 )
 
+// maxListLimit caps the page size of notification listings
+const maxListLimit = 200
+
 // NotificationService implements notification use cases
 type NotificationService struct {
 	repo     ports.NotificationRepository
 	consumer messaging.Consumer
+	email    *emailQueue   // nil until SetEmailChannel
+	push     *pushChannel  // nil until SetPushChannel
+	audit    *audit.Writer // nil until SetAuditWriter
 	logger   *logger.Logger
+
+	subscribers *subscriberRegistry
 }
 
 // NewNotificationService creates a new notification service
 func NewNotificationService(repo ports.NotificationRepository, consumer messaging.Consumer, logger *logger.Logger) *NotificationService {
 	return &NotificationService{
-		repo:     repo,
-		consumer: consumer,
-		logger:   logger,
+		repo:        repo,
+		consumer:    consumer,
+		logger:      logger,
+		subscribers: newSubscriberRegistry(),
 	}
 }
 
+// SetAuditWriter records notification preference changes to the audit log
+func (s *NotificationService) SetAuditWriter(w *audit.Writer) {
+	s.audit = w
+}
+
 // SendNotification sends a notification to a user
 func (s *NotificationService) SendNotification(
 	ctx context.Context,
@@ -35,8 +54,10 @@ func (s *NotificationService) SendNotification(
 	notifType domain.NotificationType,
 	subject, message, recipient string,
 ) (*domain.Notification, error) {
+	// The recipient is not necessarily the caller, whose user_id the request logger carries
+	ctx = logger.WithContext(ctx, zap.Int("recipient_user_id", userID))
+
 	s.logger.InfoWithFields(ctx, "Sending notification",
-		zap.Int("user_id", userID),
 		zap.String("type", string(notifType)),
 		zap.String("recipient", recipient))
 
@@ -44,10 +65,15 @@ func (s *NotificationService) SendNotification(
 	notification, err := domain.NewNotification(userID, notifType, subject, message, recipient)
 	if err != nil {
 		s.logger.ErrorWithFields(ctx, "Failed to create notification domain entity",
-			zap.Int("user_id", userID),
 			zap.Error(err))
 		return nil, err
 	}
+	notification.SourceEventID = messaging.EventIDFromContext(ctx)
+	// A replay backfills the notification as of the original event
+	occurredAt, replayed := messaging.ReplayFromContext(ctx)
+	if replayed && occurredAt.Unix() > 0 {
+		notification.CreatedAt = occurredAt
+	}
 
 	// Persist to repository
 	if err := s.repo.Create(ctx, notification); err != nil {
@@ -60,10 +86,29 @@ func (s *NotificationService) SendNotification(
 	if err := s.repo.Update(ctx, notification); err != nil {
 		return nil, fmt.Errorf("failed to update notification status: %w", err)
 	}
+	if !replayed {
+		s.subscribers.publish(notification)
+	}
 
 	return notification, nil
 }
 
+// Subscribe registers for the notifications created for userID from now on
+// and returns the user's unread notifications, oldest first. Registering
+// before listing means none is missed, though one may be in both.
+func (s *NotificationService) Subscribe(ctx context.Context, userID int) (ports.Subscription, []*domain.Notification, error) {
+	sub := s.subscribers.subscribe(userID)
+
+	unread, err := s.repo.List(ctx, domain.NotificationFilter{UserID: userID, UnreadOnly: true, Limit: maxListLimit})
+	if err != nil {
+		sub.Close()
+		return nil, nil, fmt.Errorf("failed to list unread notifications: %w", err)
+	}
+	slices.Reverse(unread)
+
+	return sub, unread, nil
+}
+
 // GetNotificationByID retrieves a notification by ID
 func (s *NotificationService) GetNotificationByID(ctx context.Context, id int) (*domain.Notification, error) {
 	return s.repo.GetByID(ctx, id)
@@ -78,6 +123,26 @@ func (s *NotificationService) GetUserNotifications(ctx context.Context, userID i
 	return s.repo.GetByUserID(ctx, userID, limit)
 }
 
+// ListNotifications retrieves a user's notifications matching the filter
+func (s *NotificationService) ListNotifications(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50 // default limit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.List(ctx, filter)
+}
+
+// GetUnreadCount counts a user's unread notifications
+func (s *NotificationService) GetUnreadCount(ctx context.Context, userID int) (int, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
 // MarkAsRead marks a notification as read
 func (s *NotificationService) MarkAsRead(ctx context.Context, id int) error {
 	notification, err := s.repo.GetByID(ctx, id)
@@ -85,10 +150,123 @@ func (s *NotificationService) MarkAsRead(ctx context.Context, id int) error {
 		return err
 	}
 
-	notification.MarkAsSent()
+	notification.MarkAsRead()
 	return s.repo.Update(ctx, notification)
 }
 
+// MarkAllAsRead marks all of a user's notifications as read
+func (s *NotificationService) MarkAllAsRead(ctx context.Context, userID int) (int, error) {
+	ctx = logger.WithContext(ctx, zap.Int("user_id", userID))
+
+	count, err := s.repo.MarkAllAsRead(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	s.logger.InfoWithFields(ctx, "Marked all notifications as read",
+		zap.Int("count", count))
+
+	return count, nil
+}
+
+// GetPreferences retrieves a user's notification preferences, falling back to defaults
+func (s *NotificationService) GetPreferences(ctx context.Context, userID int) (*domain.NotificationPreferences, error) {
+	prefs, err := s.repo.GetPreferences(ctx, userID)
+	if errors.Is(err, domain.ErrPreferencesNotFound) {
+		return domain.DefaultPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// customerPreferences retrieves the preferences of a customer's user account,
+// falling back to defaults. Events name the customer, while preferences are
+// saved by the user, whose ID differs.
+func (s *NotificationService) customerPreferences(ctx context.Context, customerID int) (*domain.NotificationPreferences, error) {
+	prefs, err := s.repo.GetCustomerPreferences(ctx, customerID)
+	if errors.Is(err, domain.ErrPreferencesNotFound) {
+		return domain.DefaultPreferences(0), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreferences stores a user's notification preferences
+func (s *NotificationService) UpdatePreferences(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	if err := prefs.Validate(); err != nil {
+		return err
+	}
+
+	// Users who never saved preferences have no previous state to record
+	var before *domain.NotificationPreferences
+	if s.audit != nil {
+		if previous, err := s.repo.GetPreferences(ctx, prefs.UserID); err == nil {
+			before = previous
+		}
+	}
+
+	prefs.UpdatedAt = time.Now()
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	s.audit.Record(ctx, authctx.Organization(ctx), "notification_preferences.update", audit.EntityNotificationPreferences, strconv.Itoa(prefs.UserID), before, prefs)
+
+	s.logger.InfoWithFields(logger.WithContext(ctx, zap.Int("user_id", prefs.UserID)), "Notification preferences updated")
+
+	return nil
+}
+
+// sendIfAllowed sends an event-driven notification unless the recipient
+// customer opted out. Preferences are read on every event so changes apply
+// immediately.
+func (s *NotificationService) sendIfAllowed(
+	ctx context.Context,
+	customerID int,
+	eventType string,
+	notifType domain.NotificationType,
+	subject, message, recipient string,
+) error {
+	ctx = logger.WithContext(ctx, zap.Int("recipient_user_id", customerID))
+
+	prefs, err := s.customerPreferences(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	if !prefs.Allows(notifType, eventType) {
+		s.logger.InfoWithFields(ctx, "Notification suppressed by user preferences",
+			zap.String("event_type", eventType),
+			zap.String("type", string(notifType)))
+		return nil
+	}
+
+	_, err = s.SendNotification(ctx, customerID, notifType, subject, message, recipient)
+	if errors.Is(err, domain.ErrDuplicateNotification) {
+		// Redelivered event: the notification exists, let the other channels catch up
+		s.logger.InfoWithFields(ctx, "Notification already sent for event",
+			zap.String("event_type", eventType))
+		return nil
+	}
+	return err
+}
+
+// skipReplayed reports whether a channel is skipped because the event being
+// handled is a replay: its rows are backfilled, but customers are not pushed
+// or mailed about what happened in the past
+func (s *NotificationService) skipReplayed(ctx context.Context, channel string) bool {
+	if _, replayed := messaging.ReplayFromContext(ctx); !replayed {
+		return false
+	}
+	s.logger.InfoWithFields(ctx, "Skipping channel for replayed event", zap.String("channel", channel))
+	return true
+}
+
 // StartEventConsumption starts consuming delivery and location events
 func (s *NotificationService) StartEventConsumption() error {
 	return s.consumer.Consume("notification-events", s.handleEvent)
@@ -96,14 +274,25 @@ func (s *NotificationService) StartEventConsumption() error {
 
 // handleEvent processes incoming events
 func (s *NotificationService) handleEvent(event messaging.Event) error {
-	ctx := context.Background()
+	ctx := messaging.ContextWithTraceContext(context.Background(), event.TraceContext)
+	ctx = logger.WithContext(ctx, zap.String("event_id", event.ID))
+	ctx = messaging.ContextWithEventID(ctx, event.ID)
+	if event.Replayed {
+		ctx = messaging.ContextWithReplay(ctx, time.Unix(event.Timestamp, 0))
+	}
 
 	switch event.Type {
-	case "delivery.created":
+	case messaging.EventTypeDeliveryCreated:
 		return s.handleDeliveryCreated(ctx, event)
-	case "delivery.status_changed":
+	case messaging.EventTypeDeliveryStatusChanged:
 		return s.handleDeliveryStatusChanged(ctx, event)
-	case "location.updated":
+	case messaging.EventTypeDeliveryLate:
+		return s.handleDeliveryLate(ctx, event)
+	case messaging.EventTypeDeliveryCancelled:
+		return s.handleDeliveryCancelled(ctx, event)
+	case messaging.EventTypeDeliveryETAUpdated:
+		return s.handleDeliveryETAUpdated(ctx, event)
+	case messaging.EventTypeLocationUpdated:
 		return s.handleLocationUpdated(ctx, event)
 	default:
 		// Ignore unknown event types
@@ -113,80 +302,193 @@ func (s *NotificationService) handleEvent(event messaging.Event) error {
 
 // handleDeliveryCreated processes delivery creation events
 func (s *NotificationService) handleDeliveryCreated(ctx context.Context, event messaging.Event) error {
-	customerIDStr, ok := event.Data["customer_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid customer_id in event data")
-	}
-
-	customerID, err := strconv.Atoi(customerIDStr)
+	data, err := messaging.DecodeData[messaging.DeliveryCreatedEvent](event)
 	if err != nil {
-		return fmt.Errorf("failed to parse customer_id: %w", err)
+		return err
 	}
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", data.DeliveryID))
 
-	deliveryIDStr, ok := event.Data["delivery_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid delivery_id in event data")
+	// Customers quote the tracking number to support, older events only carry the ID
+	reference := strconv.Itoa(data.DeliveryID)
+	if data.TrackingNumber != "" {
+		reference = data.TrackingNumber
 	}
 
 	// Send notification to customer about delivery creation
-	_, err = s.SendNotification(
+	subject := "Delivery Created"
+	message := fmt.Sprintf("Your delivery %s has been created and is being processed.", reference)
+	err = s.sendIfAllowed(
 		ctx,
-		customerID,
+		data.CustomerID,
+		domain.EventTypeDeliveryCreated,
 		domain.NotificationTypeDeliveryUpdate,
-		"Delivery Created",
-		fmt.Sprintf("Your delivery %s has been created and is being processed.", deliveryIDStr),
-		fmt.Sprintf("customer_%d", customerID),
+		subject,
+		message,
+		fmt.Sprintf("customer_%d", data.CustomerID),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to send delivery created notification: %w", err)
 	}
 
+	s.pushToCustomer(ctx, data.CustomerID, domain.EventTypeDeliveryCreated, data.DeliveryID, subject, message)
+
 	return nil
 }
 
 // handleDeliveryStatusChanged processes delivery status change events
 func (s *NotificationService) handleDeliveryStatusChanged(ctx context.Context, event messaging.Event) error {
-	customerIDStr, ok := event.Data["customer_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid customer_id in event data")
+	data, err := messaging.DecodeData[messaging.DeliveryStatusChangedEvent](event)
+	if err != nil {
+		return err
 	}
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", data.DeliveryID))
 
-	customerID, err := strconv.Atoi(customerIDStr)
+	// Send notification to customer about status change
+	subject := "Delivery Status Update"
+	message := fmt.Sprintf("Your delivery %d status has been updated to: %s", data.DeliveryID, data.NewStatus)
+	err = s.sendIfAllowed(
+		ctx,
+		data.CustomerID,
+		domain.EventTypeStatusUpdates,
+		domain.NotificationTypeDeliveryUpdate,
+		subject,
+		message,
+		fmt.Sprintf("customer_%d", data.CustomerID),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to parse customer_id: %w", err)
+		return fmt.Errorf("failed to send delivery status notification: %w", err)
 	}
 
-	deliveryIDStr, ok := event.Data["delivery_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid delivery_id in event data")
+	s.pushToCustomer(ctx, data.CustomerID, domain.EventTypeStatusUpdates, data.DeliveryID, subject, message)
+
+	emailTemplate := emailTemplateStatusChanged
+	if data.NewStatus == "delivered" {
+		emailTemplate = emailTemplateDelivered
 	}
+	s.queueEmail(ctx, data.CustomerID, domain.EventTypeStatusUpdates, emailTemplate,
+		newEmailData(data.DeliveryID, data.NewStatus, data.OldStatus))
 
-	newStatus, ok := event.Data["new_status"].(string)
-	if !ok {
-		return fmt.Errorf("invalid new_status in event data")
+	return nil
+}
+
+// handleDeliveryLate processes events for deliveries still open after their scheduled window
+func (s *NotificationService) handleDeliveryLate(ctx context.Context, event messaging.Event) error {
+	data, err := messaging.DecodeData[messaging.DeliveryLateEvent](event)
+	if err != nil {
+		return err
 	}
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", data.DeliveryID))
 
-	// Send notification to customer about status change
-	_, err = s.SendNotification(
+	// Let the customer know their delivery missed its window
+	subject := "Delivery Running Late"
+	message := fmt.Sprintf("Your delivery %d is running late and missed its scheduled window.", data.DeliveryID)
+	err = s.sendIfAllowed(
 		ctx,
-		customerID,
+		data.CustomerID,
+		domain.EventTypeStatusUpdates,
 		domain.NotificationTypeDeliveryUpdate,
-		"Delivery Status Update",
-		fmt.Sprintf("Your delivery %s status has been updated to: %s", deliveryIDStr, newStatus),
-		fmt.Sprintf("customer_%d", customerID),
+		subject,
+		message,
+		fmt.Sprintf("customer_%d", data.CustomerID),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to send delivery status notification: %w", err)
+		return fmt.Errorf("failed to send delivery late notification: %w", err)
 	}
 
+	s.pushToCustomer(ctx, data.CustomerID, domain.EventTypeStatusUpdates, data.DeliveryID, subject, message)
+
 	return nil
 }
 
-// handleLocationUpdated processes location update events
+// handleDeliveryCancelled processes delivery cancellation events
+func (s *NotificationService) handleDeliveryCancelled(ctx context.Context, event messaging.Event) error {
+	data, err := messaging.DecodeData[messaging.DeliveryCancelledEvent](event)
+	if err != nil {
+		return err
+	}
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", data.DeliveryID))
+
+	// Tell the customer why their delivery was cancelled
+	subject := "Delivery Cancelled"
+	message := fmt.Sprintf("Your delivery %d has been cancelled. Reason: %s", data.DeliveryID, data.Reason)
+	err = s.sendIfAllowed(
+		ctx,
+		data.CustomerID,
+		domain.EventTypeStatusUpdates,
+		domain.NotificationTypeDeliveryUpdate,
+		subject,
+		message,
+		fmt.Sprintf("customer_%d", data.CustomerID),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send delivery cancelled notification: %w", err)
+	}
+
+	s.pushToCustomer(ctx, data.CustomerID, domain.EventTypeStatusUpdates, data.DeliveryID, subject, message)
+
+	return nil
+}
+
+// handleDeliveryETAUpdated processes the throttled ETA updates pushed to customers
+func (s *NotificationService) handleDeliveryETAUpdated(ctx context.Context, event messaging.Event) error {
+	data, err := messaging.DecodeData[messaging.DeliveryETAUpdatedEvent](event)
+	if err != nil {
+		return err
+	}
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", data.DeliveryID))
+
+	minutes := (data.ETASeconds + 59) / 60
+	subject := "Delivery ETA Updated"
+	message := fmt.Sprintf("Your delivery %d is about %d minutes away (%.1f km remaining).", data.DeliveryID, minutes, data.DistanceRemainingKm)
+	err = s.sendIfAllowed(
+		ctx,
+		data.CustomerID,
+		domain.EventTypeETAUpdates,
+		domain.NotificationTypeDeliveryUpdate,
+		subject,
+		message,
+		fmt.Sprintf("customer_%d", data.CustomerID),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send delivery ETA notification: %w", err)
+	}
+
+	s.pushToCustomer(ctx, data.CustomerID, domain.EventTypeETAUpdates, data.DeliveryID, subject, message)
+
+	return nil
+}
+
+// handleLocationUpdated tells the customer once per delivery that the courier
+// is on the way. The event carries the customer, so no lookup is needed;
+// events from producers older than schema version 2 don't and are skipped.
 func (s *NotificationService) handleLocationUpdated(ctx context.Context, event messaging.Event) error {
-	// For location updates, we could send notifications to customers
-	// For now, we'll skip this to avoid spam, but the infrastructure is in place
-	// TODO: Implement location-based notifications (e.g., "Courier is nearby")
+	data, err := messaging.DecodeData[messaging.LocationRecordedEvent](event)
+	if err != nil {
+		return err
+	}
+	if data.CustomerID == 0 || data.DeliveryStatus != "in_transit" {
+		return nil
+	}
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", data.DeliveryID))
+	// Every location is its own event; keying on the delivery sends this only once
+	ctx = messaging.ContextWithEventID(ctx, fmt.Sprintf("courier-on-the-way:%d", data.DeliveryID))
+
+	subject := "Courier On The Way"
+	message := fmt.Sprintf("The courier is on the way with your delivery %d.", data.DeliveryID)
+	err = s.sendIfAllowed(
+		ctx,
+		data.CustomerID,
+		domain.EventTypeLocationUpdates,
+		domain.NotificationTypeDeliveryUpdate,
+		subject,
+		message,
+		fmt.Sprintf("customer_%d", data.CustomerID),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send courier on the way notification: %w", err)
+	}
+
+	s.pushToCustomer(ctx, data.CustomerID, domain.EventTypeLocationUpdates, data.DeliveryID, subject, message)
 
 	return nil
 }
@@ -0,0 +1,467 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestService(t *testing.T, repo *memory.NotificationRepository) *NotificationService {
+	return NewNotificationService(repo, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+}
+
+func statusChangedEvent(customerID string) messaging.Event {
+	return messaging.Event{
+		Type: "delivery.status_changed",
+		Data: map[string]interface{}{
+			"customer_id": customerID,
+			"delivery_id": "10",
+			"new_status":  "in_transit",
+		},
+	}
+}
+
+func TestNotificationService_GetPreferencesDefaults(t *testing.T) {
+	service := newTestService(t, memory.NewNotificationRepository())
+
+	prefs, err := service.GetPreferences(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prefs.UserID != 7 || !prefs.InAppEnabled || !prefs.EventEnabled(domain.EventTypeStatusUpdates) {
+		t.Errorf("expected default preferences, got %+v", prefs)
+	}
+}
+
+func TestNotificationService_UpdatePreferencesRejectsUnknownEventType(t *testing.T) {
+	service := newTestService(t, memory.NewNotificationRepository())
+
+	err := service.UpdatePreferences(context.Background(), &domain.NotificationPreferences{
+		UserID:     7,
+		EventTypes: map[string]bool{"weather": true},
+	})
+	if !errors.Is(err, domain.ErrInvalidPreferences) {
+		t.Errorf("expected ErrInvalidPreferences, got %v", err)
+	}
+}
+
+func TestNotificationService_HandleEventHonorsPreferences(t *testing.T) {
+	tests := []struct {
+		name          string
+		prefs         *domain.NotificationPreferences
+		expectedCount int
+	}{
+		{
+			name:          "defaults send status updates",
+			prefs:         nil,
+			expectedCount: 1,
+		},
+		{
+			name: "status updates disabled",
+			prefs: &domain.NotificationPreferences{
+				UserID:       1,
+				InAppEnabled: true,
+				EventTypes:   map[string]bool{domain.EventTypeStatusUpdates: false},
+			},
+			expectedCount: 0,
+		},
+		{
+			name: "in-app channel disabled",
+			prefs: &domain.NotificationPreferences{
+				UserID:       1,
+				InAppEnabled: false,
+				EventTypes:   map[string]bool{},
+			},
+			expectedCount: 0,
+		},
+		{
+			name: "only eta updates disabled",
+			prefs: &domain.NotificationPreferences{
+				UserID:       1,
+				InAppEnabled: true,
+				EventTypes:   map[string]bool{domain.EventTypeETAUpdates: false},
+			},
+			expectedCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewNotificationRepository()
+			repo.SetCustomerUser(1, 1)
+			service := newTestService(t, repo)
+			if tt.prefs != nil {
+				if err := service.UpdatePreferences(context.Background(), tt.prefs); err != nil {
+					t.Fatalf("unexpected error saving preferences: %v", err)
+				}
+			}
+
+			if err := service.handleEvent(statusChangedEvent("1")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(repo.All()) != tt.expectedCount {
+				t.Errorf("expected %d notifications, got %d", tt.expectedCount, len(repo.All()))
+			}
+		})
+	}
+}
+
+func TestNotificationService_PreferenceChangesApplyImmediately(t *testing.T) {
+	repo := memory.NewNotificationRepository()
+	repo.SetCustomerUser(1, 1)
+	service := newTestService(t, repo)
+
+	if err := service.handleEvent(statusChangedEvent("1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prefs := domain.DefaultPreferences(1)
+	prefs.EventTypes[domain.EventTypeStatusUpdates] = false
+	if err := service.UpdatePreferences(context.Background(), prefs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := service.handleEvent(statusChangedEvent("1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.All()) != 1 {
+		t.Errorf("expected the second event to be suppressed, got %d notifications", len(repo.All()))
+	}
+}
+
+func TestNotificationService_HandleEventUsesCustomersUserPreferences(t *testing.T) {
+	repo := memory.NewNotificationRepository()
+	repo.SetCustomerUser(1, 21)
+	service := newTestService(t, repo)
+
+	// User 1 is someone else, whose opt-out does not apply to customer 1
+	optOut := domain.DefaultPreferences(1)
+	optOut.InAppEnabled = false
+	if err := service.UpdatePreferences(context.Background(), optOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.handleEvent(statusChangedEvent("1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.All()) != 1 {
+		t.Fatalf("expected another user's preferences to be ignored, got %d notifications", len(repo.All()))
+	}
+
+	// Customer 1's own user opting out suppresses their notifications
+	optOut.UserID = 21
+	if err := service.UpdatePreferences(context.Background(), optOut); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.handleEvent(statusChangedEvent("1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.All()) != 1 {
+		t.Errorf("expected the customer's user's opt-out to apply, got %d notifications", len(repo.All()))
+	}
+}
+
+func TestNotificationService_HandleDeliveryLate(t *testing.T) {
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+
+	err := service.handleEvent(messaging.Event{
+		Type: messaging.EventTypeDeliveryLate,
+		Data: map[string]interface{}{
+			"customer_id":   "3",
+			"delivery_id":   "10",
+			"status":        "in_transit",
+			"scheduled_end": "2024-05-01T12:00:00Z",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.All()) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(repo.All()))
+	}
+	for _, n := range repo.All() {
+		if n.UserID != 3 || n.Subject != "Delivery Running Late" || n.Recipient != "customer_3" {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	}
+}
+
+func TestNotificationService_HandleDeliveryCancelled(t *testing.T) {
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+
+	err := service.handleEvent(messaging.Event{
+		Type: messaging.EventTypeDeliveryCancelled,
+		Data: map[string]interface{}{
+			"customer_id":     "3",
+			"delivery_id":     "10",
+			"previous_status": "pending",
+			"reason":          "Ordered twice",
+			"reason_code":     "customer_request",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.All()) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(repo.All()))
+	}
+	for _, n := range repo.All() {
+		if n.UserID != 3 || n.Subject != "Delivery Cancelled" || !strings.Contains(n.Message, "Ordered twice") {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	}
+}
+
+func TestNotificationService_HandleDeliveryETAUpdated(t *testing.T) {
+	etaEvent := messaging.Event{
+		Type: messaging.EventTypeDeliveryETAUpdated,
+		Data: map[string]interface{}{
+			"customer_id":           3,
+			"delivery_id":           10,
+			"courier_id":            7,
+			"eta_seconds":           750,
+			"estimated_arrival":     "2024-05-01T12:12:30Z",
+			"distance_remaining_km": 5.2,
+		},
+	}
+
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+	if err := service.handleEvent(etaEvent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.All()) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(repo.All()))
+	}
+	for _, n := range repo.All() {
+		if n.UserID != 3 || n.Subject != "Delivery ETA Updated" || !strings.Contains(n.Message, "about 13 minutes away") {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	}
+
+	// Customers who turned off ETA updates get nothing stored
+	repo = memory.NewNotificationRepository()
+	repo.SetCustomerUser(3, 3)
+	service = newTestService(t, repo)
+	err := service.UpdatePreferences(context.Background(), &domain.NotificationPreferences{
+		UserID:       3,
+		InAppEnabled: true,
+		EventTypes:   map[string]bool{domain.EventTypeETAUpdates: false},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error saving preferences: %v", err)
+	}
+	if err := service.handleEvent(etaEvent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.All()) != 0 {
+		t.Errorf("expected no notifications with ETA updates disabled, got %d", len(repo.All()))
+	}
+}
+
+func TestNotificationService_ReadState(t *testing.T) {
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.SendNotification(ctx, 1, domain.NotificationTypeEmail, "Subject", "Message", "user@example.com"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := service.SendNotification(ctx, 1, domain.NotificationTypeSMS, "Subject", "Message", "+15550100"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.SendNotification(ctx, 2, domain.NotificationTypeEmail, "Subject", "Message", "other@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := service.MarkAsRead(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	count, err := service.GetUnreadCount(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 unread notifications, got %d", count)
+	}
+
+	tests := []struct {
+		name          string
+		filter        domain.NotificationFilter
+		expectedCount int
+	}{
+		{name: "all", filter: domain.NotificationFilter{UserID: 1}, expectedCount: 4},
+		{name: "unread only", filter: domain.NotificationFilter{UserID: 1, UnreadOnly: true}, expectedCount: 3},
+		{name: "by type", filter: domain.NotificationFilter{UserID: 1, Type: domain.NotificationTypeSMS}, expectedCount: 1},
+		{name: "paginated", filter: domain.NotificationFilter{UserID: 1, Limit: 2, Offset: 3}, expectedCount: 1},
+		{name: "since future", filter: domain.NotificationFilter{UserID: 1, Since: func() *time.Time { t := time.Now().Add(time.Hour); return &t }()}, expectedCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifications, err := service.ListNotifications(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(notifications) != tt.expectedCount {
+				t.Errorf("expected %d notifications, got %d", tt.expectedCount, len(notifications))
+			}
+		})
+	}
+
+	marked, err := service.MarkAllAsRead(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if marked != 3 {
+		t.Errorf("expected 3 notifications marked read, got %d", marked)
+	}
+	if count, _ := service.GetUnreadCount(ctx, 1); count != 0 {
+		t.Errorf("expected no unread notifications, got %d", count)
+	}
+	if count, _ := service.GetUnreadCount(ctx, 2); count != 1 {
+		t.Errorf("expected other user's notifications to stay unread, got %d", count)
+	}
+}
+
+func TestNotificationService_Subscribe(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t, memory.NewNotificationRepository())
+
+	first, err := service.SendNotification(ctx, 7, domain.NotificationTypeEmail, "First", "one", "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.SendNotification(ctx, 7, domain.NotificationTypeEmail, "Second", "two", "user@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.MarkAsRead(ctx, first.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sub, unread, err := service.Subscribe(ctx, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(unread) != 1 || unread[0].Subject != "Second" {
+		t.Fatalf("expected the one unread notification replayed, got %+v", unread)
+	}
+
+	if _, err := service.SendNotification(ctx, 8, domain.NotificationTypeEmail, "Other", "user", "user@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.SendNotification(ctx, 7, domain.NotificationTypeEmail, "Third", "three", "user@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case n := <-sub.Notifications():
+		if n.Subject != "Third" {
+			t.Errorf("expected only user 7's notification, got %+v", n)
+		}
+	default:
+		t.Fatal("expected the new notification to be published")
+	}
+
+	sub.Close()
+	sub.Close()
+	if got := service.subscribers.count(7); got != 0 {
+		t.Errorf("expected no subscribers after Close, got %d", got)
+	}
+}
+
+func TestNotificationService_SubscribeDropsSlowSubscribers(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t, memory.NewNotificationRepository())
+
+	slow, _, err := service.Subscribe(ctx, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer slow.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i <= subscriberBuffer; i++ {
+			if _, err := service.SendNotification(ctx, 7, domain.NotificationTypeEmail, "Update", "news", "user@example.com"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing blocked on a slow subscriber")
+	}
+
+	select {
+	case <-slow.Dropped():
+	default:
+		t.Fatal("expected the slow subscriber to be dropped")
+	}
+	if got := service.subscribers.count(7); got != 0 {
+		t.Errorf("expected the dropped subscriber to be removed, got %d", got)
+	}
+	if got := len(slow.Notifications()); got != subscriberBuffer {
+		t.Errorf("expected %d buffered notifications, got %d", subscriberBuffer, got)
+	}
+}
+
+func TestNotificationService_HandleLocationUpdated(t *testing.T) {
+	locationEvent := func(id, status string, customerID int) messaging.Event {
+		return messaging.Event{
+			ID:   id,
+			Type: messaging.EventTypeLocationUpdated,
+			Data: map[string]interface{}{
+				"delivery_id":     10,
+				"courier_id":      7,
+				"latitude":        40.71,
+				"longitude":       -74.0,
+				"delivery_status": status,
+				"customer_id":     customerID,
+			},
+		}
+	}
+
+	repo := memory.NewNotificationRepository()
+	service := newTestService(t, repo)
+	events := []messaging.Event{
+		locationEvent("loc_1", "picked_up", 3),
+		locationEvent("loc_2", "in_transit", 3),
+		locationEvent("loc_3", "in_transit", 3),
+		locationEvent("loc_4", "in_transit", 0), // older producer without customer
+	}
+	for _, event := range events {
+		if err := service.handleEvent(event); err != nil {
+			t.Fatalf("unexpected error for %s: %v", event.ID, err)
+		}
+	}
+
+	// Only the first in-transit location notifies, and only once per delivery
+	if len(repo.All()) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(repo.All()))
+	}
+	for _, n := range repo.All() {
+		if n.UserID != 3 || n.Subject != "Courier On The Way" || !strings.Contains(n.Message, "delivery 10") {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	}
+}
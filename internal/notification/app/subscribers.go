@@ -0,0 +1,99 @@
+package app
+
+import (
+	"sync"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+)
+
+// subscriberBuffer is how many notifications a subscriber may fall behind
+// before it is dropped
+const subscriberBuffer = 64
+
+// subscriberRegistry fans new notifications out to their recipient's live
+// subscribers. Publishing never blocks: a subscriber whose buffer is full is
+// removed and told through its dropped channel.
+type subscriberRegistry struct {
+	mu     sync.Mutex
+	byUser map[int]map[*subscription]struct{}
+}
+
+func newSubscriberRegistry() *subscriberRegistry {
+	return &subscriberRegistry{byUser: make(map[int]map[*subscription]struct{})}
+}
+
+// subscribe registers a subscriber for userID's notifications
+func (r *subscriberRegistry) subscribe(userID int) *subscription {
+	sub := &subscription{
+		registry:      r,
+		userID:        userID,
+		notifications: make(chan *domain.Notification, subscriberBuffer),
+		dropped:       make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.byUser[userID] == nil {
+		r.byUser[userID] = make(map[*subscription]struct{})
+	}
+	r.byUser[userID][sub] = struct{}{}
+	return sub
+}
+
+// publish hands a copy of n to each of its recipient's subscribers, dropping
+// those that are too far behind
+func (r *subscriberRegistry) publish(n *domain.Notification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for sub := range r.byUser[n.UserID] {
+		copied := *n
+		select {
+		case sub.notifications <- &copied:
+		default:
+			r.removeLocked(sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+// remove unregisters sub; removing it twice is a no-op
+func (r *subscriberRegistry) remove(sub *subscription) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(sub)
+}
+
+func (r *subscriberRegistry) removeLocked(sub *subscription) {
+	subs := r.byUser[sub.userID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(r.byUser, sub.userID)
+	}
+}
+
+// count returns how many subscribers userID has
+func (r *subscriberRegistry) count(userID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.byUser[userID])
+}
+
+// subscription implements ports.Subscription
+type subscription struct {
+	registry      *subscriberRegistry
+	userID        int
+	notifications chan *domain.Notification
+	dropped       chan struct{}
+}
+
+func (s *subscription) Notifications() <-chan *domain.Notification {
+	return s.notifications
+}
+
+func (s *subscription) Dropped() <-chan struct{} {
+	return s.dropped
+}
+
+func (s *subscription) Close() {
+	s.registry.remove(s)
+}
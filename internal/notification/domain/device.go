@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+	ErrInvalidDevice  = errors.New("invalid device registration")
+
+	// ErrPushTokenUnregistered is returned by push senders when the provider
+	// no longer accepts a device token, e.g. because the app was uninstalled
+	ErrPushTokenUnregistered = errors.New("push token unregistered")
+)
+
+// maxDeviceTokenLength bounds stored tokens; FCM and APNs tokens are far shorter
+const maxDeviceTokenLength = 4096
+
+// DevicePlatform is the push service a device token was issued by
+type DevicePlatform string
+
+const (
+	DevicePlatformFCM     DevicePlatform = "fcm"
+	DevicePlatformAPNS    DevicePlatform = "apns"
+	DevicePlatformWebPush DevicePlatform = "webpush"
+)
+
+// Valid reports whether the platform is a supported one
+func (p DevicePlatform) Valid() bool {
+	switch p {
+	case DevicePlatformFCM, DevicePlatformAPNS, DevicePlatformWebPush:
+		return true
+	}
+	return false
+}
+
+// Device is a courier or customer app installation that receives push notifications
+type Device struct {
+	ID        int            `json:"id"`
+	UserID    int            `json:"user_id"`
+	Platform  DevicePlatform `json:"platform"`
+	Token     string         `json:"token"`
+	Active    bool           `json:"active"` // false once the provider rejected the token or the user has too many devices
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// NewDevice creates a new active device registration with validation
+func NewDevice(userID int, platform DevicePlatform, token string) (*Device, error) {
+	if userID <= 0 || !platform.Valid() {
+		return nil, ErrInvalidDevice
+	}
+	if token == "" || len(token) > maxDeviceTokenLength {
+		return nil, ErrInvalidDevice
+	}
+
+	now := time.Now()
+	return &Device{
+		UserID:    userID,
+		Platform:  platform,
+		Token:     token,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
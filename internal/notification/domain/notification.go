@@ -8,6 +8,13 @@ import (
 var (
 	ErrNotificationNotFound = errors.New("notification not found")
 	ErrInvalidNotification  = errors.New("invalid notification data")
+	ErrForbiddenRecipient   = errors.New("not allowed to notify this user")
+	ErrRecipientNotFound    = errors.New("recipient not found")
+	ErrNoEmailAddress       = errors.New("no email address on file")
+
+	// ErrDuplicateNotification is returned when a notification of the same type
+	// was already created for the event being handled, e.g. on redelivery
+	ErrDuplicateNotification = errors.New("notification already created for this event")
 )
 
 // NotificationType represents the type of notification
@@ -29,6 +36,15 @@ const (
 	NotificationStatusFailed  NotificationStatus = "failed"
 )
 
+// EmailStatus tracks delivery of an email notification; empty for other channels
+type EmailStatus string
+
+const (
+	EmailStatusQueued EmailStatus = "queued"
+	EmailStatusSent   EmailStatus = "sent"
+	EmailStatusFailed EmailStatus = "failed"
+)
+
 // Notification represents a notification entity
 type Notification struct {
 	ID         int
@@ -40,8 +56,24 @@ type Notification struct {
 	Message    string
 	Recipient  string
 	SentAt     *time.Time
+	ReadAt     *time.Time
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+
+	EmailStatus EmailStatus
+	EmailError  string // why the last email attempt failed
+
+	SourceEventID string // event that caused the notification; empty for direct sends
+}
+
+// CanSendTo checks if a caller's role may address a notification to
+// recipientID. Admins and internal services may notify anyone, admins only
+// within their organization; other users only themselves.
+func CanSendTo(role string, callerID, recipientID int) bool {
+	if role == "admin" || role == "super_admin" || role == "service" {
+		return true
+	}
+	return callerID > 0 && callerID == recipientID
 }
 
 // NewNotification creates a new notification with validation
@@ -54,7 +86,8 @@ func NewNotification(userID int, notifType NotificationType, subject, message, r
 		return nil, ErrInvalidNotification
 	}
 
-	if notifType != NotificationTypeEmail && notifType != NotificationTypeSMS && notifType != NotificationTypePush {
+	if notifType != NotificationTypeEmail && notifType != NotificationTypeSMS && notifType != NotificationTypePush &&
+		notifType != NotificationTypeDeliveryUpdate {
 		return nil, ErrInvalidNotification
 	}
 
@@ -83,3 +116,49 @@ func (n *Notification) MarkAsFailed() {
 	n.Status = NotificationStatusFailed
 	n.UpdatedAt = time.Now()
 }
+
+// MarkEmailQueued records that the email is waiting in the send queue
+func (n *Notification) MarkEmailQueued() {
+	n.EmailStatus = EmailStatusQueued
+	n.EmailError = ""
+	n.UpdatedAt = time.Now()
+}
+
+// MarkEmailSent records that the email was handed to the mail server
+func (n *Notification) MarkEmailSent() {
+	n.MarkAsSent()
+	n.EmailStatus = EmailStatusSent
+	n.EmailError = ""
+}
+
+// MarkEmailFailed records that the email could not be sent and why
+func (n *Notification) MarkEmailFailed(reason error) {
+	n.MarkAsFailed()
+	n.EmailStatus = EmailStatusFailed
+	n.EmailError = reason.Error()
+}
+
+// MarkAsRead records when the user read the notification; reading twice keeps the first time
+func (n *Notification) MarkAsRead() {
+	if n.ReadAt != nil {
+		return
+	}
+	now := time.Now()
+	n.ReadAt = &now
+	n.UpdatedAt = now
+}
+
+// IsRead reports whether the user has read the notification
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+// NotificationFilter narrows a user's notification listing
+type NotificationFilter struct {
+	UserID     int
+	UnreadOnly bool
+	Type       NotificationType
+	Since      *time.Time
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrPreferencesNotFound = errors.New("notification preferences not found")
+	ErrInvalidPreferences  = errors.New("invalid notification preferences")
+)
+
+// Event types that users can toggle individually
+const (
+	EventTypeDeliveryCreated = "delivery_created"
+	EventTypeStatusUpdates   = "status_updates"
+	EventTypeETAUpdates      = "eta_updates"
+	EventTypeLocationUpdates = "location_updates"
+)
+
+var knownEventTypes = map[string]bool{
+	EventTypeDeliveryCreated: true,
+	EventTypeStatusUpdates:   true,
+	EventTypeETAUpdates:      true,
+	EventTypeLocationUpdates: true,
+}
+
+// NotificationPreferences holds a user's channel and event type settings.
+// Event types missing from EventTypes are enabled.
+type NotificationPreferences struct {
+	UserID       int             `json:"user_id"`
+	InAppEnabled bool            `json:"in_app_enabled"`
+	EmailEnabled bool            `json:"email_enabled"`
+	SMSEnabled   bool            `json:"sms_enabled"`
+	PushEnabled  bool            `json:"push_enabled"`
+	EventTypes   map[string]bool `json:"event_types"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// DefaultPreferences returns the preferences used for users that never saved any.
+// Push is on because it only reaches devices the user registered.
+func DefaultPreferences(userID int) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:       userID,
+		InAppEnabled: true,
+		EmailEnabled: true,
+		PushEnabled:  true,
+		EventTypes:   map[string]bool{},
+	}
+}
+
+// Validate checks the preferences belong to a user and only toggle known event types
+func (p *NotificationPreferences) Validate() error {
+	if p.UserID <= 0 {
+		return ErrInvalidPreferences
+	}
+	for eventType := range p.EventTypes {
+		if !knownEventTypes[eventType] {
+			return ErrInvalidPreferences
+		}
+	}
+	return nil
+}
+
+// EventEnabled reports whether the user wants notifications for an event type
+func (p *NotificationPreferences) EventEnabled(eventType string) bool {
+	enabled, ok := p.EventTypes[eventType]
+	return !ok || enabled
+}
+
+// ChannelEnabled reports whether the user accepts notifications of the given type.
+// Delivery updates are shown in-app.
+func (p *NotificationPreferences) ChannelEnabled(notifType NotificationType) bool {
+	switch notifType {
+	case NotificationTypeEmail:
+		return p.EmailEnabled
+	case NotificationTypeSMS:
+		return p.SMSEnabled
+	case NotificationTypePush:
+		return p.PushEnabled
+	case NotificationTypeDeliveryUpdate:
+		return p.InAppEnabled
+	default:
+		return false
+	}
+}
+
+// Allows reports whether a notification of the given type for an event type should be sent
+func (p *NotificationPreferences) Allows(notifType NotificationType, eventType string) bool {
+	return p.ChannelEnabled(notifType) && p.EventEnabled(eventType)
+}
@@ -0,0 +1,23 @@
+package ports
+
+import "context"
+
+// EmailMessage is a rendered email ready to send
+type EmailMessage struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// EmailSender delivers rendered emails
+type EmailSender interface {
+	// Send delivers an email, returning once the mail server accepted it
+	Send(ctx context.Context, message EmailMessage) error
+}
+
+// ContactDirectory resolves where a customer's notifications are sent
+type ContactDirectory interface {
+	// CustomerEmail returns a customer's email address, or domain.ErrNoEmailAddress
+	CustomerEmail(ctx context.Context, customerID int) (string, error)
+}
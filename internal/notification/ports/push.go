@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+)
+
+// PushMessage is a push notification ready to send to a device
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string // passed to the app alongside the notification
+}
+
+// PushSender delivers push notifications to devices
+type PushSender interface {
+	// Send delivers a push notification to one device, returning an error
+	// wrapping domain.ErrPushTokenUnregistered when the provider no longer
+	// accepts the device's token
+	Send(ctx context.Context, device *domain.Device, message PushMessage) error
+}
+
+// DeviceRepository defines the push device persistence operations
+type DeviceRepository interface {
+	// Save registers a device, reactivating and returning the existing row
+	// when the user already registered the same token
+	Save(ctx context.Context, device *domain.Device) error
+
+	// GetByID retrieves a device by ID, returning domain.ErrDeviceNotFound if missing
+	GetByID(ctx context.Context, id int) (*domain.Device, error)
+
+	// ListActiveByUser retrieves a user's active devices, most recently registered first
+	ListActiveByUser(ctx context.Context, userID int) ([]*domain.Device, error)
+
+	// ListActiveByCustomer retrieves the active devices of a customer's user accounts
+	ListActiveByCustomer(ctx context.Context, customerID int) ([]*domain.Device, error)
+
+	// Deactivate stops pushes to a device without forgetting it
+	Deactivate(ctx context.Context, id int) error
+
+	// Delete removes a device registration
+	Delete(ctx context.Context, id int) error
+}
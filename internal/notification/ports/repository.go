@@ -8,7 +8,8 @@ import (
 
 // NotificationRepository defines the notification persistence operations
 type NotificationRepository interface {
-	// Create stores a new notification
+	// Create stores a new notification, returning domain.ErrDuplicateNotification
+	// when one of the same type exists for its source event
 	Create(ctx context.Context, notification *domain.Notification) error
 
 	// GetByID retrieves a notification by ID
@@ -17,9 +18,39 @@ type NotificationRepository interface {
 	// GetByUserID retrieves all notifications for a user
 	GetByUserID(ctx context.Context, userID int, limit int) ([]*domain.Notification, error)
 
+	// List retrieves a user's notifications matching the filter, newest first
+	List(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error)
+
+	// CountUnread counts a user's unread notifications
+	CountUnread(ctx context.Context, userID int) (int, error)
+
+	// MarkAllAsRead marks all of a user's notifications as read, returning how many changed
+	MarkAllAsRead(ctx context.Context, userID int) (int, error)
+
 	// Update updates a notification's status
 	Update(ctx context.Context, notification *domain.Notification) error
 
+	// UpdateEmailStatus stores an email notification's delivery outcome
+	// without touching its read state
+	UpdateEmailStatus(ctx context.Context, notification *domain.Notification) error
+
 	// Delete deletes a notification
 	Delete(ctx context.Context, id int) error
+
+	// GetPreferences retrieves a user's notification preferences, returning
+	// domain.ErrPreferencesNotFound if none are stored
+	GetPreferences(ctx context.Context, userID int) (*domain.NotificationPreferences, error)
+
+	// GetCustomerPreferences retrieves the preferences of a customer's active
+	// user account, returning domain.ErrPreferencesNotFound if it stored none
+	GetCustomerPreferences(ctx context.Context, customerID int) (*domain.NotificationPreferences, error)
+
+	// SavePreferences creates or replaces a user's notification preferences
+	SavePreferences(ctx context.Context, prefs *domain.NotificationPreferences) error
+}
+
+// UserDirectory looks up the users notifications are addressed to
+type UserDirectory interface {
+	// UserOrganization returns a user's organization, or domain.ErrRecipientNotFound
+	UserOrganization(ctx context.Context, userID int) (int, error)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Keneke-Einar/delivertrack/internal/notification/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 )
 
 // NotificationService defines the notification use cases
@@ -17,6 +18,57 @@ type NotificationService interface {
 	// GetUserNotifications retrieves all notifications for a user
 	GetUserNotifications(ctx context.Context, userID int, limit int) ([]*domain.Notification, error)
 
+	// ListNotifications retrieves a user's notifications matching the filter
+	ListNotifications(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error)
+
+	// GetUnreadCount counts a user's unread notifications
+	GetUnreadCount(ctx context.Context, userID int) (int, error)
+
 	// MarkAsRead marks a notification as read
 	MarkAsRead(ctx context.Context, id int) error
+
+	// MarkAllAsRead marks all of a user's notifications as read
+	MarkAllAsRead(ctx context.Context, userID int) (int, error)
+
+	// GetPreferences retrieves a user's notification preferences, falling back to defaults
+	GetPreferences(ctx context.Context, userID int) (*domain.NotificationPreferences, error)
+
+	// UpdatePreferences stores a user's notification preferences
+	UpdatePreferences(ctx context.Context, prefs *domain.NotificationPreferences) error
+
+	// RegisterDevice registers a device to receive the user's push notifications
+	RegisterDevice(ctx context.Context, userID int, platform domain.DevicePlatform, token string) (*domain.Device, error)
+
+	// DeleteDevice removes one of the user's devices, returning
+	// domain.ErrDeviceNotFound for devices of other users
+	DeleteDevice(ctx context.Context, userID, deviceID int) error
+
+	// Subscribe registers for the notifications created for a user from now
+	// on and returns the user's unread notifications, oldest first. A
+	// notification created while subscribing may be in both.
+	Subscribe(ctx context.Context, userID int) (Subscription, []*domain.Notification, error)
+}
+
+// Subscription delivers a user's new notifications until it is closed
+type Subscription interface {
+	// Notifications receives each notification created for the user
+	Notifications() <-chan *domain.Notification
+
+	// Dropped is closed when the subscriber fell too far behind and was removed
+	Dropped() <-chan struct{}
+
+	// Close unregisters the subscription
+	Close()
+}
+
+// DeadLetterService inspects and retries dead-lettered events
+type DeadLetterService interface {
+	// List returns up to limit recent dead letters, most recent first
+	List(ctx context.Context, limit int) ([]messaging.DeadLetter, error)
+
+	// Stats counts the stored dead letters
+	Stats(ctx context.Context) (messaging.DeadLetterStats, error)
+
+	// Retry republishes a dead letter to its original exchange and routing key
+	Retry(ctx context.Context, id int) (*messaging.DeadLetter, error)
 }
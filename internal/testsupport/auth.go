@@ -0,0 +1,112 @@
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// errNotSupported is returned by the account methods AuthService doesn't stub
+var errNotSupported = errors.New("not supported by the test auth service")
+
+// AuthService implements ports.AuthService for request authentication only:
+// it validates the tokens and API keys tests register. It is safe for
+// concurrent use.
+type AuthService struct {
+	mu      sync.Mutex
+	tokens  map[string]*domain.Claims
+	apiKeys map[string]*domain.Claims
+}
+
+// NewAuthService creates an auth service that accepts no credentials
+func NewAuthService() *AuthService {
+	return &AuthService{
+		tokens:  make(map[string]*domain.Claims),
+		apiKeys: make(map[string]*domain.Claims),
+	}
+}
+
+// AddToken makes ValidateToken accept token with claims
+func (a *AuthService) AddToken(token string, claims *domain.Claims) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens[token] = claims
+}
+
+// AddAPIKey makes ValidateAPIKey accept key with claims
+func (a *AuthService) AddAPIKey(key string, claims *domain.Claims) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.apiKeys[key] = claims
+}
+
+// ValidateToken returns the claims added for token, or domain.ErrInvalidToken
+func (a *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.Claims, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	claims, ok := a.tokens[tokenString]
+	if !ok {
+		return nil, domain.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ValidateAPIKey returns the claims added for key, or domain.ErrInvalidAPIKey
+func (a *AuthService) ValidateAPIKey(ctx context.Context, key string) (*domain.Claims, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	claims, ok := a.apiKeys[key]
+	if !ok {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return claims, nil
+}
+
+func (a *AuthService) Register(ctx context.Context, username, email, password, role string, orgID int, customerID, courierID *int) (*domain.User, error) {
+	return nil, errNotSupported
+}
+
+func (a *AuthService) Authenticate(ctx context.Context, username, password string) (string, *domain.User, error) {
+	return "", nil, errNotSupported
+}
+
+func (a *AuthService) GetUser(ctx context.Context, id int) (*domain.User, error) {
+	return nil, errNotSupported
+}
+
+func (a *AuthService) UpdateEmail(ctx context.Context, id int, email string) (*domain.User, error) {
+	return nil, errNotSupported
+}
+
+func (a *AuthService) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	return errNotSupported
+}
+
+func (a *AuthService) SetUserActive(ctx context.Context, id int, active bool) (*domain.User, error) {
+	return nil, errNotSupported
+}
+
+func (a *AuthService) SetUserOrganization(ctx context.Context, id, orgID int) (*domain.User, error) {
+	return nil, errNotSupported
+}
+
+func (a *AuthService) UnlockUser(ctx context.Context, id int) error {
+	return errNotSupported
+}
+
+func (a *AuthService) IssueAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	return nil, "", errNotSupported
+}
+
+func (a *AuthService) ListAPIKeys(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	return nil, errNotSupported
+}
+
+func (a *AuthService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	return errNotSupported
+}
@@ -0,0 +1,313 @@
+package testsupport
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/proto/common"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"github.com/Keneke-Einar/delivertrack/proto/notification"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// stub counts a client's calls and holds the errors set for its methods
+type stub struct {
+	mu    sync.Mutex
+	calls map[string]int
+	errs  map[string]error
+}
+
+// SetError makes calls to the named method, e.g. "GetDelivery", fail with err; nil clears it
+func (s *stub) SetError(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.errs == nil {
+		s.errs = make(map[string]error)
+	}
+	s.errs[method] = err
+}
+
+// Calls returns how many times the named method was called
+func (s *stub) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// call records a call to method and returns the error set for it; the caller holds the lock
+func (s *stub) call(method string) error {
+	if s.calls == nil {
+		s.calls = make(map[string]int)
+	}
+	s.calls[method]++
+	return s.errs[method]
+}
+
+// DeliveryClient implements delivery.DeliveryServiceClient with configurable
+// responses. GetDelivery returns the configured delivery under the requested
+// ID; the list methods filter the configured deliveries like the delivery
+// service does. Other methods report success.
+type DeliveryClient struct {
+	stub
+	delivery   *delivery.Delivery
+	deliveries []*delivery.Delivery
+}
+
+// NewDeliveryClient creates a client whose deliveries belong to customer 1,
+// are assigned to courier 1 and are in transit
+func NewDeliveryClient() *DeliveryClient {
+	return &DeliveryClient{
+		delivery: &delivery.Delivery{
+			CustomerId:       "1",
+			DriverId:         "1",
+			TrackingNumber:   "DT-TEST",
+			PickupLocation:   &common.Location{Latitude: 40.7128, Longitude: -74.0060},
+			DeliveryLocation: &common.Location{Latitude: 40.7589, Longitude: -73.9851},
+			Status:           delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT,
+			CreatedAt:        1234567890,
+			UpdatedAt:        1234567890,
+		},
+	}
+}
+
+// SetDelivery sets what GetDelivery returns; its ID is replaced by the requested one
+func (c *DeliveryClient) SetDelivery(d *delivery.Delivery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delivery = proto.Clone(d).(*delivery.Delivery)
+}
+
+// SetStatus changes the status of the delivery GetDelivery returns
+func (c *DeliveryClient) SetStatus(s delivery.DeliveryStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delivery.Status = s
+}
+
+// SetDeliveries sets the deliveries ListDeliveries and GetDriverDeliveries choose from
+func (c *DeliveryClient) SetDeliveries(deliveries []*delivery.Delivery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deliveries = deliveries
+}
+
+func (c *DeliveryClient) CreateDelivery(ctx context.Context, in *delivery.CreateDeliveryRequest, opts ...grpc.CallOption) (*delivery.CreateDeliveryResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("CreateDelivery"); err != nil {
+		return nil, err
+	}
+	return &delivery.CreateDeliveryResponse{DeliveryId: "1", TrackingNumber: "DT-TEST", CreatedAt: time.Now().Unix()}, nil
+}
+
+func (c *DeliveryClient) GetDelivery(ctx context.Context, in *delivery.GetDeliveryRequest, opts ...grpc.CallOption) (*delivery.GetDeliveryResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetDelivery"); err != nil {
+		return nil, err
+	}
+	d := proto.Clone(c.delivery).(*delivery.Delivery)
+	d.DeliveryId = in.DeliveryId
+	return &delivery.GetDeliveryResponse{Delivery: d}, nil
+}
+
+func (c *DeliveryClient) UpdateDeliveryStatus(ctx context.Context, in *delivery.UpdateDeliveryStatusRequest, opts ...grpc.CallOption) (*delivery.UpdateDeliveryStatusResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("UpdateDeliveryStatus"); err != nil {
+		return nil, err
+	}
+	return &delivery.UpdateDeliveryStatusResponse{Success: true, UpdatedAt: time.Now().Unix()}, nil
+}
+
+func (c *DeliveryClient) AssignDriver(ctx context.Context, in *delivery.AssignDriverRequest, opts ...grpc.CallOption) (*delivery.AssignDriverResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("AssignDriver"); err != nil {
+		return nil, err
+	}
+	return &delivery.AssignDriverResponse{Success: true, AssignedAt: time.Now().Unix()}, nil
+}
+
+// ListDeliveries returns the configured deliveries matching the request's status, driver and customer
+func (c *DeliveryClient) ListDeliveries(ctx context.Context, in *delivery.ListDeliveriesRequest, opts ...grpc.CallOption) (*delivery.ListDeliveriesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("ListDeliveries"); err != nil {
+		return nil, err
+	}
+	matched := c.matching(append([]delivery.DeliveryStatus{in.Status}, in.Statuses...), in.DriverId, in.CustomerId)
+	return &delivery.ListDeliveriesResponse{Deliveries: matched, TotalCount: int32(len(matched))}, nil
+}
+
+func (c *DeliveryClient) CancelDelivery(ctx context.Context, in *delivery.CancelDeliveryRequest, opts ...grpc.CallOption) (*delivery.CancelDeliveryResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("CancelDelivery"); err != nil {
+		return nil, err
+	}
+	return &delivery.CancelDeliveryResponse{Success: true, CancelledAt: time.Now().Unix()}, nil
+}
+
+// GetDriverDeliveries returns the configured deliveries of the requested
+// driver, optionally by status and by the UTC day they were delivered on
+func (c *DeliveryClient) GetDriverDeliveries(ctx context.Context, in *delivery.GetDriverDeliveriesRequest, opts ...grpc.CallOption) (*delivery.GetDriverDeliveriesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetDriverDeliveries"); err != nil {
+		return nil, err
+	}
+	matched := c.matching([]delivery.DeliveryStatus{in.Status}, in.DriverId, "")
+	if in.Date != 0 {
+		day := time.Unix(in.Date, 0).UTC().Truncate(24 * time.Hour)
+		onDay := matched[:0]
+		for _, d := range matched {
+			if d.ActualDelivery >= day.Unix() && d.ActualDelivery < day.Add(24*time.Hour).Unix() {
+				onDay = append(onDay, d)
+			}
+		}
+		matched = onDay
+	}
+	return &delivery.GetDriverDeliveriesResponse{Deliveries: matched, TotalCount: int32(len(matched))}, nil
+}
+
+func (c *DeliveryClient) OptimizeRoute(ctx context.Context, in *delivery.OptimizeRouteRequest, opts ...grpc.CallOption) (*delivery.OptimizeRouteResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("OptimizeRoute"); err != nil {
+		return nil, err
+	}
+	return &delivery.OptimizeRouteResponse{}, nil
+}
+
+func (c *DeliveryClient) ConfirmDelivery(ctx context.Context, in *delivery.ConfirmDeliveryRequest, opts ...grpc.CallOption) (*delivery.ConfirmDeliveryResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("ConfirmDelivery"); err != nil {
+		return nil, err
+	}
+	return &delivery.ConfirmDeliveryResponse{Success: true, ConfirmedAt: time.Now().Unix()}, nil
+}
+
+// matching filters the configured deliveries, ignoring unset criteria; the caller holds the lock
+func (c *DeliveryClient) matching(statuses []delivery.DeliveryStatus, driverID, customerID string) []*delivery.Delivery {
+	statuses = slices.DeleteFunc(statuses, func(s delivery.DeliveryStatus) bool {
+		return s == delivery.DeliveryStatus_DELIVERY_STATUS_UNSPECIFIED
+	})
+	var matched []*delivery.Delivery
+	for _, d := range c.deliveries {
+		if len(statuses) > 0 && !slices.Contains(statuses, d.Status) {
+			continue
+		}
+		if driverID != "" && d.DriverId != driverID {
+			continue
+		}
+		if customerID != "" && d.CustomerId != customerID {
+			continue
+		}
+		matched = append(matched, proto.Clone(d).(*delivery.Delivery))
+	}
+	return matched
+}
+
+// NotificationClient implements notification.NotificationServiceClient,
+// recording the notifications it is asked to send and reporting success
+type NotificationClient struct {
+	stub
+	sent []*notification.SendNotificationRequest
+}
+
+// NewNotificationClient creates a notification client that accepts every call
+func NewNotificationClient() *NotificationClient {
+	return &NotificationClient{}
+}
+
+// Sent returns the SendNotification requests received, oldest first
+func (c *NotificationClient) Sent() []*notification.SendNotificationRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*notification.SendNotificationRequest(nil), c.sent...)
+}
+
+func (c *NotificationClient) SendNotification(ctx context.Context, in *notification.SendNotificationRequest, opts ...grpc.CallOption) (*notification.SendNotificationResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("SendNotification"); err != nil {
+		return nil, err
+	}
+	c.sent = append(c.sent, in)
+	return &notification.SendNotificationResponse{
+		NotificationId: "1",
+		Status:         notification.NotificationStatus_NOTIFICATION_STATUS_SENT,
+		SentAt:         time.Now().Unix(),
+	}, nil
+}
+
+func (c *NotificationClient) SendBulkNotifications(ctx context.Context, in *notification.SendBulkNotificationsRequest, opts ...grpc.CallOption) (*notification.SendBulkNotificationsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("SendBulkNotifications"); err != nil {
+		return nil, err
+	}
+	return &notification.SendBulkNotificationsResponse{SuccessCount: int32(len(in.Notifications))}, nil
+}
+
+func (c *NotificationClient) GetNotificationHistory(ctx context.Context, in *notification.GetNotificationHistoryRequest, opts ...grpc.CallOption) (*notification.GetNotificationHistoryResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetNotificationHistory"); err != nil {
+		return nil, err
+	}
+	return &notification.GetNotificationHistoryResponse{}, nil
+}
+
+func (c *NotificationClient) UpdatePreferences(ctx context.Context, in *notification.UpdatePreferencesRequest, opts ...grpc.CallOption) (*notification.UpdatePreferencesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("UpdatePreferences"); err != nil {
+		return nil, err
+	}
+	return &notification.UpdatePreferencesResponse{Success: true}, nil
+}
+
+func (c *NotificationClient) GetPreferences(ctx context.Context, in *notification.GetPreferencesRequest, opts ...grpc.CallOption) (*notification.GetPreferencesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetPreferences"); err != nil {
+		return nil, err
+	}
+	return &notification.GetPreferencesResponse{}, nil
+}
+
+// Subscribe is not supported by the stub
+func (c *NotificationClient) Subscribe(ctx context.Context, in *notification.SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[notification.Notification], error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("Subscribe"); err != nil {
+		return nil, err
+	}
+	return nil, status.Error(codes.Unimplemented, "subscribe is not stubbed")
+}
+
+func (c *NotificationClient) MarkAsRead(ctx context.Context, in *notification.MarkAsReadRequest, opts ...grpc.CallOption) (*notification.MarkAsReadResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("MarkAsRead"); err != nil {
+		return nil, err
+	}
+	return &notification.MarkAsReadResponse{Success: true}, nil
+}
+
+func (c *NotificationClient) SendDeliveryUpdate(ctx context.Context, in *notification.SendDeliveryUpdateRequest, opts ...grpc.CallOption) (*notification.SendDeliveryUpdateResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("SendDeliveryUpdate"); err != nil {
+		return nil, err
+	}
+	return &notification.SendDeliveryUpdateResponse{NotificationId: "1", Success: true, SentAt: time.Now().Unix()}, nil
+}
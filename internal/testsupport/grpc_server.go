@@ -0,0 +1,50 @@
+package testsupport
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufconnSize is the in-memory listener's buffer size
+const bufconnSize = 1 << 20
+
+// DialGRPCServer serves the handlers register adds over an in-memory
+// listener, behind the interceptor chain the services run in production, and
+// returns a client connection to it. Both are closed when the test ends.
+func DialGRPCServer(t *testing.T, authService ports.AuthService, register func(*grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(bufconnSize)
+	// Streams may still log as the server stops, after the test's logger is gone
+	server := grpcinterceptors.NewServer(&logger.Logger{Logger: zap.NewNop()}, authService, 0)
+	register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial the in-memory gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// WithToken returns a context that sends token as the call's bearer authorization
+func WithToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, grpcinterceptors.AuthorizationMetadataKey, "Bearer "+token)
+}
@@ -0,0 +1,76 @@
+// Package testsupport provides test doubles shared by the services' tests: a
+// recording messaging.Publisher, stub gRPC clients whose responses and errors
+// tests configure, a token-table AuthService, and an in-memory gRPC server
+// running the production interceptor chain. In-memory repositories live next
+// to the real adapters, in each service's adapters/memory package.
+package testsupport
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+)
+
+// PublishedEvent is an event together with where it was published
+type PublishedEvent struct {
+	Exchange   string
+	RoutingKey string
+	Event      messaging.Event
+}
+
+// Publisher implements messaging.Publisher by recording what is published.
+// It is safe for concurrent use.
+type Publisher struct {
+	mu        sync.Mutex
+	published []PublishedEvent
+	err       error
+}
+
+// NewPublisher creates a publisher that records every event
+func NewPublisher() *Publisher {
+	return &Publisher{}
+}
+
+// Publish records the event, or fails with the error set by SetError
+func (p *Publisher) Publish(ctx context.Context, exchange, routingKey string, event messaging.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, PublishedEvent{Exchange: exchange, RoutingKey: routingKey, Event: event})
+	return nil
+}
+
+// Close does nothing
+func (p *Publisher) Close() error {
+	return nil
+}
+
+// SetError makes Publish fail with err; nil clears it
+func (p *Publisher) SetError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Events returns the published events, oldest first
+func (p *Publisher) Events() []messaging.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := make([]messaging.Event, len(p.published))
+	for i, published := range p.published {
+		events[i] = published.Event
+	}
+	return events
+}
+
+// Published returns the published events with their exchange and routing key, oldest first
+func (p *Publisher) Published() []PublishedEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PublishedEvent(nil), p.published...)
+}
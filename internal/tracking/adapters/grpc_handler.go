@@ -2,12 +2,19 @@ package adapters
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"time"
 
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
 	"github.com/Keneke-Einar/delivertrack/proto/common"
 	trackingProto "github.com/Keneke-Einar/delivertrack/proto/tracking"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -43,20 +50,40 @@ func (h *GRPCHandler) UpdateLocation(ctx context.Context, req *trackingProto.Upd
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid tracking_number: %v", err)
 	}
+	if req.Location == nil {
+		return nil, status.Error(codes.InvalidArgument, "location is required")
+	}
+
+	// The request has no courier field: couriers record their own locations
+	ctx, auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Role != "courier" || auth.UserCourierID == nil {
+		return nil, status.Error(codes.PermissionDenied, "only couriers can record locations")
+	}
 
 	serviceReq := ports.RecordLocationRequest{
 		DeliveryID: deliveryID,
-		CourierID:  0, // TODO: get from context or something
+		CourierID:  *auth.UserCourierID,
 		Latitude:   req.Location.Latitude,
 		Longitude:  req.Location.Longitude,
-		Accuracy:   nil,
-		Speed:      nil,
-		Heading:    nil,
-		Altitude:   nil,
 	}
 
 	location, err := h.service.RecordLocation(ctx, serviceReq)
 	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidLocation):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid location: %v", err)
+		case errors.Is(err, domain.ErrDeliveryClosed):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, domain.ErrUnauthorized):
+			return nil, status.Error(codes.PermissionDenied, "delivery is not assigned to this courier")
+		case errors.Is(err, domain.ErrLocationJitter):
+			return &trackingProto.UpdateLocationResponse{Success: false}, nil
+		case errors.Is(err, domain.ErrDeliveryUnavailable):
+			return nil, status.Error(codes.Unavailable, "delivery service is unavailable")
+		}
 		return nil, status.Errorf(codes.Internal, "failed to record location: %v", err)
 	}
 
@@ -81,20 +108,31 @@ func (h *GRPCHandler) GetTrackingHistory(ctx context.Context, req *trackingProto
 		return nil, status.Errorf(codes.InvalidArgument, "invalid tracking_number: %v", err)
 	}
 
+	ctx, auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	serviceReq := ports.GetDeliveryTrackRequest{
-		DeliveryID: deliveryID,
-		Limit:      100, // Default limit
+		DeliveryID:  deliveryID,
+		Limit:       100, // Default limit
+		AuthContext: auth,
 	}
 
-	locations, err := h.service.GetDeliveryTrack(ctx, serviceReq)
+	locations, _, err := h.service.GetDeliveryTrack(ctx, serviceReq)
 	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			return nil, status.Error(codes.PermissionDenied, "not allowed to access this delivery")
+		}
 		return nil, status.Errorf(codes.Internal, "failed to get tracking history: %v", err)
 	}
 
-	var locationUpdates []*trackingProto.LocationUpdate
+	// Recorded locations are the only tracking events so far
+	events := make([]*trackingProto.TrackingEvent, 0, len(locations))
 	for _, loc := range locations {
-		locationUpdates = append(locationUpdates, &trackingProto.LocationUpdate{
-			TrackingNumber: req.TrackingNumber,
+		events = append(events, &trackingProto.TrackingEvent{
+			EventId:   strconv.Itoa(loc.ID),
+			EventType: "location_update",
 			Location: &common.Location{
 				Latitude:  loc.Latitude,
 				Longitude: loc.Longitude,
@@ -104,7 +142,7 @@ func (h *GRPCHandler) GetTrackingHistory(ctx context.Context, req *trackingProto
 	}
 
 	return &trackingProto.GetTrackingHistoryResponse{
-		Events: []*trackingProto.TrackingEvent{}, // TODO: map locations to events
+		Events: events,
 	}, nil
 }
 
@@ -114,8 +152,264 @@ func (h *GRPCHandler) StreamLocation(req *trackingProto.StreamLocationRequest, s
 	return status.Errorf(codes.Unimplemented, "method StreamLocation not implemented")
 }
 
+// TrackDelivery implements tracking.TrackingServiceServer
+func (h *GRPCHandler) TrackDelivery(req *trackingProto.TrackDeliveryRequest, stream trackingProto.TrackingService_TrackDeliveryServer) error {
+	deliveryID, err := strconv.Atoi(req.DeliveryId)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid delivery_id: %v", err)
+	}
+
+	ctx, auth, err := callerAuth(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	serviceReq := ports.TrackDeliveryRequest{
+		DeliveryID:  deliveryID,
+		AuthContext: auth,
+	}
+
+	err = h.service.TrackDelivery(ctx, serviceReq, func(loc *domain.Location) error {
+		update := &trackingProto.LocationUpdate{
+			TrackingNumber: req.DeliveryId,
+			Location: &common.Location{
+				Latitude:  loc.Latitude,
+				Longitude: loc.Longitude,
+			},
+			Timestamp: loc.Timestamp.Unix(),
+		}
+		if loc.Speed != nil {
+			update.Speed = *loc.Speed
+		}
+		if loc.Heading != nil {
+			update.Bearing = *loc.Heading
+		}
+		return stream.Send(update)
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			return status.Error(codes.PermissionDenied, "not allowed to track this delivery")
+		}
+		if status.Code(err) == codes.NotFound {
+			return status.Error(codes.NotFound, "delivery not found")
+		}
+		return status.Errorf(codes.Internal, "failed to track delivery: %v", err)
+	}
+
+	return nil
+}
+
+// GetCourierStatus implements tracking.TrackingServiceServer
+func (h *GRPCHandler) GetCourierStatus(ctx context.Context, req *trackingProto.GetCourierStatusRequest) (*trackingProto.GetCourierStatusResponse, error) {
+	courierID, err := strconv.Atoi(req.CourierId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid courier_id: %v", err)
+	}
+
+	ctx, auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeat, err := h.service.GetCourierStatus(ctx, ports.GetCourierStatusRequest{
+		CourierID:   courierID,
+		AuthContext: auth,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			return nil, status.Error(codes.PermissionDenied, "not allowed to access this courier")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get courier status: %v", err)
+	}
+
+	resp := &trackingProto.GetCourierStatusResponse{
+		CourierId:             req.CourierId,
+		Status:                string(heartbeat.Status),
+		StatusDurationSeconds: int64(heartbeat.StatusDuration(time.Now()).Seconds()),
+	}
+	if heartbeat.LastSeenAt != nil {
+		resp.LastSeenAt = heartbeat.LastSeenAt.Unix()
+	}
+	if heartbeat.StatusSince != nil {
+		resp.StatusSince = heartbeat.StatusSince.Unix()
+	}
+	return resp, nil
+}
+
+// GetCourierLocation implements tracking.TrackingServiceServer. Admins and
+// services may read any courier, couriers only themselves.
+func (h *GRPCHandler) GetCourierLocation(ctx context.Context, req *trackingProto.GetCourierLocationRequest) (*trackingProto.GetCourierLocationResponse, error) {
+	courierID, err := strconv.Atoi(req.CourierId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid courier_id: %v", err)
+	}
+
+	ctx, auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Role != "admin" && auth.Role != "super_admin" && auth.Role != authDomain.RoleService &&
+		(auth.Role != "courier" || auth.UserCourierID == nil || *auth.UserCourierID != courierID) {
+		return nil, status.Error(codes.PermissionDenied, "not allowed to access this courier")
+	}
+
+	serviceReq := ports.GetCourierLocationRequest{CourierID: courierID, AuthContext: auth}
+	location, err := h.service.GetCourierLocation(ctx, serviceReq)
+	if err != nil {
+		if errors.Is(err, domain.ErrLocationNotFound) {
+			return nil, status.Error(codes.NotFound, "courier has not reported a location")
+		}
+		if errors.Is(err, domain.ErrCourierNotActive) {
+			return nil, status.Error(codes.NotFound, "courier_not_active: courier has no active delivery")
+		}
+		if errors.Is(err, domain.ErrUnauthorized) {
+			return nil, status.Error(codes.PermissionDenied, "not allowed to access this courier")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get courier location: %v", err)
+	}
+
+	speed, err := h.service.GetCourierAverageSpeed(ctx, serviceReq)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get courier speed: %v", err)
+	}
+
+	return &trackingProto.GetCourierLocationResponse{
+		CourierId: req.CourierId,
+		Location: &common.Location{
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+		},
+		Timestamp:    location.Timestamp.Unix(),
+		AverageSpeed: speed,
+	}, nil
+}
+
+// GetDeliveryLocation implements tracking.TrackingServiceServer
+func (h *GRPCHandler) GetDeliveryLocation(ctx context.Context, req *trackingProto.GetDeliveryLocationRequest) (*trackingProto.GetDeliveryLocationResponse, error) {
+	deliveryID, err := strconv.Atoi(req.DeliveryId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid delivery_id: %v", err)
+	}
+
+	ctx, auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	location, _, err := h.service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{DeliveryID: deliveryID, AuthContext: auth})
+	if err != nil {
+		return nil, deliveryReadError(err, "failed to get delivery location")
+	}
+
+	return &trackingProto.GetDeliveryLocationResponse{
+		DeliveryId: req.DeliveryId,
+		CourierId:  strconv.Itoa(location.CourierID),
+		Location: &common.Location{
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+		},
+		Timestamp: location.Timestamp.Unix(),
+	}, nil
+}
+
+// GetDeliveryETA implements tracking.TrackingServiceServer
+func (h *GRPCHandler) GetDeliveryETA(ctx context.Context, req *trackingProto.GetDeliveryETARequest) (*trackingProto.GetDeliveryETAResponse, error) {
+	deliveryID, err := strconv.Atoi(req.DeliveryId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid delivery_id: %v", err)
+	}
+	if req.Destination == nil {
+		return nil, status.Error(codes.InvalidArgument, "destination is required")
+	}
+
+	ctx, auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	eta, err := h.service.CalculateETAToDestination(ctx, ports.CalculateETAToDestinationRequest{
+		DeliveryID:  deliveryID,
+		DestLat:     req.Destination.Latitude,
+		DestLng:     req.Destination.Longitude,
+		AuthContext: auth,
+	})
+	if err != nil {
+		return nil, deliveryReadError(err, "failed to calculate delivery ETA")
+	}
+
+	return &trackingProto.GetDeliveryETAResponse{
+		DeliveryId:   req.DeliveryId,
+		EtaSeconds:   int64(eta.ETA.Seconds()),
+		DistanceKm:   eta.DistanceKm,
+		AverageSpeed: eta.AverageSpeed,
+	}, nil
+}
+
+// FindZones implements tracking.TrackingServiceServer
+func (h *GRPCHandler) FindZones(ctx context.Context, req *trackingProto.FindZonesRequest) (*trackingProto.FindZonesResponse, error) {
+	if req.Location == nil {
+		return nil, status.Error(codes.InvalidArgument, "location is required")
+	}
+
+	ctx, auth, err := callerAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zones, err := h.service.FindZones(ctx, ports.FindZonesRequest{
+		Latitude:    req.Location.Latitude,
+		Longitude:   req.Location.Longitude,
+		AuthContext: auth,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidLocation):
+			return nil, status.Error(codes.InvalidArgument, "location is out of range")
+		case errors.Is(err, domain.ErrZonesUnavailable):
+			return nil, status.Error(codes.Unavailable, "delivery zones are not configured")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to find zones: %v", err)
+	}
+
+	return &trackingProto.FindZonesResponse{Zones: zones}, nil
+}
+
+// deliveryReadError maps a failed read of a delivery's tracking data to a status
+func deliveryReadError(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		return status.Error(codes.PermissionDenied, "not allowed to access this delivery")
+	case errors.Is(err, domain.ErrLocationNotFound):
+		return status.Error(codes.NotFound, "delivery has no reported location")
+	case errors.Is(err, domain.ErrDeliveryUnavailable):
+		return status.Error(codes.Unavailable, "delivery service is unavailable")
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
+
 // BatchUpdateLocations implements tracking.TrackingServiceServer
 func (h *GRPCHandler) BatchUpdateLocations(ctx context.Context, req *trackingProto.BatchUpdateLocationsRequest) (*trackingProto.BatchUpdateLocationsResponse, error) {
 	// TODO: Implement batch updates
 	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateLocations not implemented")
 }
+
+// callerAuth returns the caller's authorization fields and a context that
+// forwards their token to delivery service lookups
+func callerAuth(ctx context.Context) (context.Context, ports.AuthContext, error) {
+	claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+	if !ok {
+		return ctx, ports.AuthContext{}, status.Error(codes.Unauthenticated, "missing user claims")
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if authHeaders := md.Get(grpcinterceptors.AuthorizationMetadataKey); len(authHeaders) > 0 {
+			ctx = authctx.WithAuthorization(ctx, authHeaders[0])
+		}
+	}
+
+	return ctx, ports.AuthContext{
+		Role:           claims.Role,
+		UserCustomerID: claims.CustomerID,
+		UserCourierID:  claims.CourierID,
+	}, nil
+}
@@ -0,0 +1,381 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/app"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/proto/common"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	trackingProto "github.com/Keneke-Einar/delivertrack/proto/tracking"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tokens the contract tests' callers authenticate with. The stub delivery
+// client's deliveries belong to customer 1 and courier 1.
+const (
+	adminToken         = "admin-token"
+	customerToken      = "customer-token"       // customer 1
+	otherCustomerToken = "other-customer-token" // customer 2
+	courierToken       = "courier-token"        // courier 1
+	otherCourierToken  = "other-courier-token"  // courier 2
+)
+
+// trackingFixture is a tracking gRPC client served by the real service over
+// the production interceptor chain, with the service's collaborators
+type trackingFixture struct {
+	client     trackingProto.TrackingServiceClient
+	repo       *memory.LocationRepository
+	deliveries *testsupport.DeliveryClient
+}
+
+func newTrackingFixture(t *testing.T) *trackingFixture {
+	t.Helper()
+
+	authService := testsupport.NewAuthService()
+	authService.AddToken(adminToken, &authDomain.Claims{UserID: 1, Role: authDomain.RoleAdmin})
+	authService.AddToken(customerToken, &authDomain.Claims{UserID: 2, Role: authDomain.RoleCustomer, CustomerID: intPtr(1)})
+	authService.AddToken(otherCustomerToken, &authDomain.Claims{UserID: 3, Role: authDomain.RoleCustomer, CustomerID: intPtr(2)})
+	authService.AddToken(courierToken, &authDomain.Claims{UserID: 4, Role: authDomain.RoleCourier, CourierID: intPtr(1)})
+	authService.AddToken(otherCourierToken, &authDomain.Claims{UserID: 5, Role: authDomain.RoleCourier, CourierID: intPtr(2)})
+
+	repo := memory.NewLocationRepository()
+	deliveries := testsupport.NewDeliveryClient()
+	service := app.NewTrackingService(repo, testsupport.NewPublisher(), deliveries, authService, nil, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	t.Cleanup(service.Shutdown)
+
+	conn := testsupport.DialGRPCServer(t, authService, func(s *grpc.Server) {
+		trackingProto.RegisterTrackingServiceServer(s, NewGRPCHandler(service))
+	})
+	return &trackingFixture{client: trackingProto.NewTrackingServiceClient(conn), repo: repo, deliveries: deliveries}
+}
+
+// addLocation stores a point courier 1 reported for delivery 1 at
+func (f *trackingFixture) addLocation(t *testing.T, at time.Time) *domain.Location {
+	t.Helper()
+	location := &domain.Location{DeliveryID: 1, CourierID: 1, Latitude: 40.75, Longitude: -73.98, Timestamp: at}
+	if err := f.repo.Create(context.Background(), location); err != nil {
+		t.Fatalf("failed to store location: %v", err)
+	}
+	return location
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func as(token string) context.Context {
+	return testsupport.WithToken(context.Background(), token)
+}
+
+func expectCode(t *testing.T, err error, expected codes.Code) {
+	t.Helper()
+	if status.Code(err) != expected {
+		t.Fatalf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestTrackingGRPC_RequiresAuthentication(t *testing.T) {
+	f := newTrackingFixture(t)
+
+	_, err := f.client.GetCourierStatus(context.Background(), &trackingProto.GetCourierStatusRequest{CourierId: "1"})
+	expectCode(t, err, codes.Unauthenticated)
+
+	stream, err := f.client.TrackDelivery(as("unknown-token"), &trackingProto.TrackDeliveryRequest{DeliveryId: "1"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	expectCode(t, err, codes.Unauthenticated)
+}
+
+func TestTrackingGRPC_UpdateLocation(t *testing.T) {
+	point := &common.Location{Latitude: 40.75, Longitude: -73.98}
+
+	t.Run("records the calling courier's location", func(t *testing.T) {
+		f := newTrackingFixture(t)
+
+		resp, err := f.client.UpdateLocation(as(courierToken), &trackingProto.UpdateLocationRequest{TrackingNumber: "1", Location: point})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success || resp.UpdatedAt == 0 {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+		latest, err := f.repo.GetLatestByDeliveryID(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("expected the location to be stored: %v", err)
+		}
+		if latest.CourierID != 1 {
+			t.Errorf("expected the location to be recorded for courier 1, got %d", latest.CourierID)
+		}
+	})
+
+	t.Run("refuses finished deliveries", func(t *testing.T) {
+		f := newTrackingFixture(t)
+		f.deliveries.SetStatus(delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED)
+
+		_, err := f.client.UpdateLocation(as(courierToken), &trackingProto.UpdateLocationRequest{TrackingNumber: "1", Location: point})
+		expectCode(t, err, codes.FailedPrecondition)
+	})
+
+	t.Run("refuses deliveries without a courier", func(t *testing.T) {
+		f := newTrackingFixture(t)
+		f.deliveries.SetDelivery(&delivery.Delivery{CustomerId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_PENDING})
+
+		_, err := f.client.UpdateLocation(as(courierToken), &trackingProto.UpdateLocationRequest{TrackingNumber: "1", Location: point})
+		expectCode(t, err, codes.PermissionDenied)
+		if n := f.repo.Count(1); n != 0 {
+			t.Errorf("expected nothing stored, got %d locations", n)
+		}
+	})
+
+	tests := []struct {
+		name         string
+		token        string
+		req          *trackingProto.UpdateLocationRequest
+		expectedCode codes.Code
+	}{
+		{name: "not a courier", token: adminToken, req: &trackingProto.UpdateLocationRequest{TrackingNumber: "1", Location: point}, expectedCode: codes.PermissionDenied},
+		{name: "courier not assigned", token: otherCourierToken, req: &trackingProto.UpdateLocationRequest{TrackingNumber: "1", Location: point}, expectedCode: codes.PermissionDenied},
+		{name: "empty tracking number", token: courierToken, req: &trackingProto.UpdateLocationRequest{Location: point}, expectedCode: codes.InvalidArgument},
+		{name: "missing location", token: courierToken, req: &trackingProto.UpdateLocationRequest{TrackingNumber: "1"}, expectedCode: codes.InvalidArgument},
+		{name: "out of range", token: courierToken, req: &trackingProto.UpdateLocationRequest{TrackingNumber: "1", Location: &common.Location{Latitude: 95, Longitude: 10}}, expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTrackingFixture(t)
+
+			_, err := f.client.UpdateLocation(as(tt.token), tt.req)
+			expectCode(t, err, tt.expectedCode)
+			if n := f.repo.Count(1); n != 0 {
+				t.Errorf("expected nothing stored, got %d locations", n)
+			}
+		})
+	}
+}
+
+func TestTrackingGRPC_GetTrackingHistory(t *testing.T) {
+	f := newTrackingFixture(t)
+	now := time.Now().Truncate(time.Second)
+	first := f.addLocation(t, now.Add(-2*time.Minute))
+	second := f.addLocation(t, now.Add(-time.Minute))
+
+	resp, err := f.client.GetTrackingHistory(as(customerToken), &trackingProto.GetTrackingHistoryRequest{TrackingNumber: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(resp.Events))
+	}
+	for i, expected := range []*domain.Location{second, first} {
+		event := resp.Events[i]
+		if event.EventType != "location_update" || event.Timestamp != expected.Timestamp.Unix() || event.Location.Latitude != expected.Latitude {
+			t.Errorf("event %d: unexpected %+v", i, event)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		trackingNumber string
+		expectedCode   codes.Code
+	}{
+		{name: "another customer", token: otherCustomerToken, trackingNumber: "1", expectedCode: codes.PermissionDenied},
+		{name: "another courier", token: otherCourierToken, trackingNumber: "1", expectedCode: codes.PermissionDenied},
+		{name: "non-numeric tracking number", token: adminToken, trackingNumber: "abc", expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.client.GetTrackingHistory(as(tt.token), &trackingProto.GetTrackingHistoryRequest{TrackingNumber: tt.trackingNumber})
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+}
+
+func TestTrackingGRPC_TrackDelivery(t *testing.T) {
+	// Streams for finished deliveries end after the last known point
+	receive := func(f *trackingFixture, token, deliveryID string) ([]*trackingProto.LocationUpdate, error) {
+		stream, err := f.client.TrackDelivery(as(token), &trackingProto.TrackDeliveryRequest{DeliveryId: deliveryID})
+		if err != nil {
+			return nil, err
+		}
+		var updates []*trackingProto.LocationUpdate
+		for {
+			update, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return updates, nil
+			}
+			if err != nil {
+				return updates, err
+			}
+			updates = append(updates, update)
+		}
+	}
+
+	f := newTrackingFixture(t)
+	f.deliveries.SetStatus(delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED)
+	f.addLocation(t, time.Now().Add(-2*time.Minute))
+	latest := f.addLocation(t, time.Now().Add(-time.Minute))
+
+	updates, err := receive(f, customerToken, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(updates) != 1 || updates[0].TrackingNumber != "1" || updates[0].Timestamp != latest.Timestamp.Unix() {
+		t.Errorf("expected only the latest point, got %+v", updates)
+	}
+
+	_, err = receive(f, otherCustomerToken, "1")
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = receive(f, customerToken, "abc")
+	expectCode(t, err, codes.InvalidArgument)
+
+	f.deliveries.SetError("GetDelivery", status.Error(codes.NotFound, "delivery not found"))
+	_, err = receive(f, adminToken, "99")
+	expectCode(t, err, codes.NotFound)
+}
+
+func TestTrackingGRPC_GetCourierStatus(t *testing.T) {
+	f := newTrackingFixture(t)
+	f.addLocation(t, time.Now().Add(-10*time.Second))
+
+	resp, err := f.client.GetCourierStatus(as(courierToken), &trackingProto.GetCourierStatusRequest{CourierId: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.CourierId != "1" || resp.Status != string(domain.CourierStatusActive) || resp.LastSeenAt == 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	_, err = f.client.GetCourierStatus(as(otherCourierToken), &trackingProto.GetCourierStatusRequest{CourierId: "1"})
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = f.client.GetCourierStatus(as(adminToken), &trackingProto.GetCourierStatusRequest{})
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestTrackingGRPC_GetCourierLocation(t *testing.T) {
+	f := newTrackingFixture(t)
+	f.deliveries.SetDeliveries([]*delivery.Delivery{
+		{DeliveryId: "1", CustomerId: "1", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT},
+	})
+	latest := f.addLocation(t, time.Now().Add(-10*time.Second))
+
+	resp, err := f.client.GetCourierLocation(as(courierToken), &trackingProto.GetCourierLocationRequest{CourierId: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.CourierId != "1" || resp.Timestamp != latest.Timestamp.Unix() || resp.Location.Longitude != latest.Longitude {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		courierID    string
+		expectedCode codes.Code
+	}{
+		{name: "another courier", token: otherCourierToken, courierID: "1", expectedCode: codes.PermissionDenied},
+		{name: "customer", token: customerToken, courierID: "1", expectedCode: codes.PermissionDenied},
+		{name: "no reported location", token: adminToken, courierID: "3", expectedCode: codes.NotFound},
+		{name: "empty courier_id", token: adminToken, courierID: "", expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.client.GetCourierLocation(as(tt.token), &trackingProto.GetCourierLocationRequest{CourierId: tt.courierID})
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+
+	t.Run("courier without an active delivery", func(t *testing.T) {
+		f.deliveries.SetDeliveries(nil)
+		_, err := f.client.GetCourierLocation(as(courierToken), &trackingProto.GetCourierLocationRequest{CourierId: "1"})
+		expectCode(t, err, codes.NotFound)
+	})
+}
+
+func TestTrackingGRPC_GetDeliveryLocationAndETA(t *testing.T) {
+	f := newTrackingFixture(t)
+	f.deliveries.SetDeliveries([]*delivery.Delivery{
+		{DeliveryId: "1", CustomerId: "1", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT},
+		{DeliveryId: "2", CustomerId: "1", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT},
+	})
+	latest := f.addLocation(t, time.Now().Add(-10*time.Second))
+
+	location, err := f.client.GetDeliveryLocation(as(customerToken), &trackingProto.GetDeliveryLocationRequest{DeliveryId: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location.CourierId != "1" || location.Timestamp != latest.Timestamp.Unix() || location.Location.Latitude != latest.Latitude {
+		t.Errorf("unexpected location: %+v", location)
+	}
+
+	destination := &common.Location{Latitude: latest.Latitude + 0.1, Longitude: latest.Longitude}
+	eta, err := f.client.GetDeliveryETA(as(customerToken), &trackingProto.GetDeliveryETARequest{DeliveryId: "1", Destination: destination})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eta.EtaSeconds <= 0 || eta.DistanceKm <= 0 || eta.AverageSpeed <= 0 {
+		t.Errorf("unexpected ETA: %+v", eta)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		deliveryID   string
+		expectedCode codes.Code
+	}{
+		{name: "another customer", token: otherCustomerToken, deliveryID: "1", expectedCode: codes.PermissionDenied},
+		{name: "no reported location", token: customerToken, deliveryID: "2", expectedCode: codes.NotFound},
+		{name: "invalid delivery_id", token: customerToken, deliveryID: "abc", expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.client.GetDeliveryLocation(as(tt.token), &trackingProto.GetDeliveryLocationRequest{DeliveryId: tt.deliveryID})
+			expectCode(t, err, tt.expectedCode)
+
+			_, err = f.client.GetDeliveryETA(as(tt.token), &trackingProto.GetDeliveryETARequest{DeliveryId: tt.deliveryID, Destination: destination})
+			expectCode(t, err, tt.expectedCode)
+		})
+	}
+
+	t.Run("missing destination", func(t *testing.T) {
+		_, err := f.client.GetDeliveryETA(as(customerToken), &trackingProto.GetDeliveryETARequest{DeliveryId: "1"})
+		expectCode(t, err, codes.InvalidArgument)
+	})
+}
+
+func TestTrackingGRPC_Unimplemented(t *testing.T) {
+	f := newTrackingFixture(t)
+	ctx := as(adminToken)
+
+	_, err := f.client.CreateTracking(ctx, &trackingProto.CreateTrackingRequest{})
+	expectCode(t, err, codes.Unimplemented)
+
+	_, err = f.client.GetTracking(ctx, &trackingProto.GetTrackingRequest{})
+	expectCode(t, err, codes.Unimplemented)
+
+	_, err = f.client.AddTrackingEvent(ctx, &trackingProto.AddTrackingEventRequest{})
+	expectCode(t, err, codes.Unimplemented)
+
+	_, err = f.client.BatchUpdateLocations(ctx, &trackingProto.BatchUpdateLocationsRequest{})
+	expectCode(t, err, codes.Unimplemented)
+
+	stream, err := f.client.StreamLocation(ctx, &trackingProto.StreamLocationRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	expectCode(t, err, codes.Unimplemented)
+}
@@ -2,14 +2,17 @@ package adapters
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
-	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
 	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
-	"google.golang.org/grpc/metadata"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
 )
 
 // HTTPHandler handles HTTP requests for tracking operations
@@ -32,8 +35,8 @@ func (h *HTTPHandler) RecordLocation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req ports.RecordLocationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httputil.SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
 		return
 	}
 
@@ -44,15 +47,22 @@ func (h *HTTPHandler) RecordLocation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user context from auth middleware
-	userCtx := httputil.ExtractUserContext(r)
-
-	// Authorization: only couriers can record locations, and only their own
-	if userCtx.Role != "courier" {
-		httputil.SendErrorResponse(w, "Only couriers can record locations", http.StatusForbidden)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
 		return
 	}
 
-	if userCtx.CourierID == nil || *userCtx.CourierID != req.CourierID {
+	// Authorization: only couriers can record locations, and only their own.
+	// Admins may record test points for any courier with admin_override.
+	switch {
+	case (userCtx.Role == "admin" || userCtx.Role == "super_admin") && req.AdminOverride:
+	case userCtx.Role != "courier":
+		httputil.SendErrorResponse(w, "Only couriers can record locations", http.StatusForbidden)
+		return
+	case req.AdminOverride:
+		httputil.SendErrorResponse(w, "Only admins can override courier assignment", http.StatusForbidden)
+		return
+	case userCtx.CourierID == nil || *userCtx.CourierID != req.CourierID:
 		httputil.SendErrorResponse(w, "Couriers can only record their own locations", http.StatusForbidden)
 		return
 	}
@@ -63,31 +73,50 @@ func (h *HTTPHandler) RecordLocation(w http.ResponseWriter, r *http.Request) {
 	// Record location
 	location, err := h.service.RecordLocation(ctx, req)
 	if err != nil {
-		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, domain.ErrInvalidLocation):
+			httputil.SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrDeliveryClosed):
+			httputil.SendErrorResponse(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, domain.ErrUnauthorized):
+			httputil.SendErrorResponse(w, "Delivery is not assigned to this courier", http.StatusForbidden)
+		case errors.Is(err, domain.ErrLocationJitter):
+			// The point was plausible input but is not stored or broadcast
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"discarded": true,
+				"reason":    err.Error(),
+			})
+		default:
+			sendServiceError(w, err)
+		}
 		return
 	}
 
+	// A re-uploaded point is answered with its stored copy
+	status := http.StatusCreated
+	if location.Duplicate {
+		status = http.StatusOK
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(location)
 }
 
-// GetDeliveryTrack handles GET /deliveries/{id}/track
+// GetDeliveryTrack handles GET /deliveries/{id}/track?from=&to=&order=asc|desc&simplify=
+// from and to are RFC3339 and optional; points come newest first unless order=asc.
+// simplify drops points within that many meters of the simplified track.
+// HEAD is answered too; the weak ETag follows the point count, the latest
+// point and the query shaping the response, so If-None-Match gets a 304 until
+// a location is recorded or different parameters are asked for.
 func (h *HTTPHandler) GetDeliveryTrack(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Extract delivery ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/deliveries/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "track" {
-		httputil.SendErrorResponse(w, "Invalid path", http.StatusBadRequest)
-		return
-	}
-
-	deliveryID, err := strconv.Atoi(parts[0])
+	deliveryID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
 		return
@@ -102,87 +131,213 @@ func (h *HTTPHandler) GetDeliveryTrack(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get user context
-	userCtx := httputil.ExtractUserContext(r)
+	query := r.URL.Query()
+	from, err := parseTimeQuery(query.Get("from"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "from must be an RFC3339 timestamp", http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeQuery(query.Get("to"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "to must be an RFC3339 timestamp", http.StatusBadRequest)
+		return
+	}
 
-	// Authorization: customers can only track their own deliveries, couriers can track assigned deliveries
-	if userCtx.Role == "customer" && userCtx.CustomerID != nil {
-		// In a real implementation, we'd check if the delivery belongs to this customer
-		// For now, we'll allow it
-	} else if userCtx.Role == "courier" && userCtx.CourierID != nil {
-		// In a real implementation, we'd check if the courier is assigned to this delivery
-		// For now, we'll allow it
+	var oldestFirst bool
+	switch order := query.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		oldestFirst = true
+	default:
+		httputil.SendErrorResponse(w, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	var simplifyMeters float64
+	if simplifyStr := query.Get("simplify"); simplifyStr != "" {
+		simplifyMeters, err = strconv.ParseFloat(simplifyStr, 64)
+		if err != nil || simplifyMeters < 0 || math.IsInf(simplifyMeters, 0) || math.IsNaN(simplifyMeters) {
+			httputil.SendErrorResponse(w, "simplify must be a non-negative tolerance in meters", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Optional reverse geocoding of the latest point (and every Nth point)
+	resolveAddresses, _ := strconv.ParseBool(r.URL.Query().Get("resolve_addresses"))
+	addressEvery := 0
+	if everyStr := r.URL.Query().Get("address_every"); everyStr != "" {
+		if n, err := strconv.Atoi(everyStr); err == nil && n > 0 {
+			addressEvery = n
+		}
+	}
+
+	// Get user context
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
 	}
 
 	// Create trace context for request tracing
 	ctx := httputil.ExtractTraceContext(r, "tracking-service", "get_delivery_track_http")
 
-	// Get delivery track
-	locations, err := h.service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{
-		DeliveryID: deliveryID,
-		Limit:      limit,
+	// Answer HEAD and unchanged tracks from the point count and latest
+	// timestamp alone, before the track is loaded
+	version, err := h.service.GetTrackVersion(ctx, ports.GetTrackVersionRequest{
+		DeliveryID:  deliveryID,
+		AuthContext: authContext(userCtx),
 	})
 	if err != nil {
-		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		sendReadError(w, err)
 		return
 	}
-
 	w.Header().Set("Content-Type", "application/json")
+	etag := httputil.WeakETag(version.LatestAt, version.Points,
+		timeOrZero(from), timeOrZero(to), strconv.FormatBool(oldestFirst), limit,
+		strconv.FormatFloat(simplifyMeters, 'g', -1, 64), strconv.FormatBool(resolveAddresses), addressEvery)
+	if httputil.ServeConditional(w, r, etag) {
+		return
+	}
+
+	// Get delivery track; customers may only read their own deliveries and
+	// couriers the ones assigned to them
+	locations, originalCount, err := h.service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{
+		DeliveryID:       deliveryID,
+		Limit:            limit,
+		From:             from,
+		To:               to,
+		OldestFirst:      oldestFirst,
+		SimplifyMeters:   simplifyMeters,
+		ResolveAddresses: resolveAddresses,
+		AddressEvery:     addressEvery,
+		AuthContext:      authContext(userCtx),
+	})
+	if err != nil {
+		sendReadError(w, err)
+		return
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"delivery_id": deliveryID,
-		"locations":   locations,
+		"delivery_id":          deliveryID,
+		"locations":            locations,
+		"point_count":          len(locations),
+		"original_point_count": originalCount,
 	})
 }
 
-// GetCurrentLocation handles GET /deliveries/{id}/location
-func (h *HTTPHandler) GetCurrentLocation(w http.ResponseWriter, r *http.Request) {
+// ExportDeliveryTrack handles GET /deliveries/{id}/track/export?format=geojson|gpx&from=&to=
+// The track is streamed oldest point first; from and to are RFC3339 and optional.
+func (h *HTTPHandler) ExportDeliveryTrack(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Extract delivery ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/deliveries/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "location" {
-		httputil.SendErrorResponse(w, "Invalid path", http.StatusBadRequest)
+	deliveryID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
 		return
 	}
 
-	deliveryID, err := strconv.Atoi(parts[0])
+	query := r.URL.Query()
+	req := ports.ExportDeliveryTrackRequest{DeliveryID: deliveryID}
+	if req.From, err = parseTimeQuery(query.Get("from")); err != nil {
+		httputil.SendErrorResponse(w, "from must be an RFC3339 timestamp", http.StatusBadRequest)
+		return
+	}
+	if req.To, err = parseTimeQuery(query.Get("to")); err != nil {
+		httputil.SendErrorResponse(w, "to must be an RFC3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	var writer trackWriter
+	var contentType, extension string
+	switch format := query.Get("format"); format {
+	case "", "geojson":
+		writer = newGeoJSONTrackWriter(w, deliveryID)
+		contentType, extension = "application/geo+json", "geojson"
+	case "gpx":
+		writer = newGPXTrackWriter(w, deliveryID)
+		contentType, extension = "application/gpx+xml", "gpx"
+	default:
+		httputil.SendErrorResponse(w, "format must be geojson or gpx", http.StatusBadRequest)
+		return
+	}
+
+	// Get user context
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+	req.AuthContext = authContext(userCtx)
+
+	// Create trace context for request tracing
+	ctx := httputil.ExtractTraceContext(r, "tracking-service", "export_delivery_track_http")
+
+	// Headers go out with the first point so authorization failures can still be reported
+	headersSent := false
+	sendHeaders := func() {
+		if headersSent {
+			return
+		}
+		headersSent = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="delivery-%d-track.%s"`, deliveryID, extension))
+	}
+
+	// Same authorization as GetDeliveryTrack
+	err = h.service.ExportDeliveryTrack(ctx, req, func(location *domain.Location) error {
+		sendHeaders()
+		return writer.WritePoint(location)
+	})
+	if err != nil {
+		if !headersSent {
+			sendReadError(w, err)
+		}
+		// Part of the document may already be out; leaving it unterminated marks it as broken
+		return
+	}
+
+	sendHeaders()
+	writer.Close()
+}
+
+// GetCurrentLocation handles GET /deliveries/{id}/location
+func (h *HTTPHandler) GetCurrentLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
 		return
 	}
 
 	// Get user context
-	userCtx := httputil.ExtractUserContext(r)
-
-	// Authorization: customers can only track their own deliveries, couriers can track assigned deliveries
-	if userCtx.Role == "customer" && userCtx.CustomerID != nil {
-		// In a real implementation, we'd check if the delivery belongs to this customer
-	} else if userCtx.Role == "courier" && userCtx.CourierID != nil {
-		// In a real implementation, we'd check if the courier is assigned to this delivery
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
 	}
 
 	// Create trace context for request tracing
 	ctx := httputil.ExtractTraceContext(r, "tracking-service", "get_current_location_http")
 
-	// Add authorization metadata for gRPC calls
-	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
-		md := metadata.Pairs(grpcinterceptors.AuthorizationMetadataKey, authHeader)
-		ctx = metadata.NewOutgoingContext(ctx, md)
-	}
-
 	// Get current location
-	location, err := h.service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{
-		DeliveryID: deliveryID,
+	location, cached, err := h.service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{
+		DeliveryID:  deliveryID,
+		AuthContext: authContext(userCtx),
 	})
 	if err != nil {
-		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		sendReadError(w, err)
 		return
 	}
 
+	if cached {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(location)
 }
@@ -194,22 +349,17 @@ func (h *HTTPHandler) GetCourierLocation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Extract courier ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/couriers/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "location" {
-		httputil.SendErrorResponse(w, "Invalid path", http.StatusBadRequest)
-		return
-	}
-
-	courierID, err := strconv.Atoi(parts[0])
+	courierID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		httputil.SendErrorResponse(w, "Invalid courier ID", http.StatusBadRequest)
 		return
 	}
 
 	// Get user context
-	userCtx := httputil.ExtractUserContext(r)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
 
 	// Authorization: couriers can only access their own location, admins can access any
 	if userCtx.Role == "courier" && userCtx.CourierID != nil && *userCtx.CourierID != courierID {
@@ -220,12 +370,22 @@ func (h *HTTPHandler) GetCourierLocation(w http.ResponseWriter, r *http.Request)
 	// Create trace context for request tracing
 	ctx := httputil.ExtractTraceContext(r, "tracking-service", "get_courier_location_http")
 
-	// Get courier location
+	// Get courier location; outside admins, only couriers on an active delivery are visible
 	location, err := h.service.GetCourierLocation(ctx, ports.GetCourierLocationRequest{
-		CourierID: courierID,
+		CourierID:   courierID,
+		AuthContext: authContext(userCtx),
 	})
 	if err != nil {
-		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, domain.ErrCourierNotActive):
+			httputil.SendErrorCode(w, "courier_not_active", "Courier has no active delivery", http.StatusNotFound)
+		case errors.Is(err, domain.ErrLocationNotFound):
+			httputil.SendErrorResponse(w, "Courier has not reported a location", http.StatusNotFound)
+		case errors.Is(err, domain.ErrUnauthorized):
+			httputil.SendErrorResponse(w, "Not allowed to access this courier", http.StatusForbidden)
+		default:
+			sendServiceError(w, err)
+		}
 		return
 	}
 
@@ -233,6 +393,275 @@ func (h *HTTPHandler) GetCourierLocation(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(location)
 }
 
+// courierStatusResponse is a courier's heartbeat with how long they have held their status
+type courierStatusResponse struct {
+	*domain.CourierHeartbeat
+	StatusDurationSeconds int64 `json:"status_duration_seconds"`
+}
+
+// GetCourierStatus handles GET /couriers/{id}/status
+func (h *HTTPHandler) GetCourierStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	courierID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "tracking-service", "get_courier_status_http")
+
+	heartbeat, err := h.service.GetCourierStatus(ctx, ports.GetCourierStatusRequest{
+		CourierID:   courierID,
+		AuthContext: authContext(userCtx),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			httputil.SendErrorResponse(w, "Not allowed to access this courier", http.StatusForbidden)
+			return
+		}
+		sendServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(courierStatusResponse{
+		CourierHeartbeat:      heartbeat,
+		StatusDurationSeconds: int64(heartbeat.StatusDuration(time.Now()).Seconds()),
+	})
+}
+
+// courierDaySummaryResponse is a courier's day summary with its durations in seconds
+type courierDaySummaryResponse struct {
+	*domain.CourierDaySummary
+	ActiveTimeSeconds         int64 `json:"active_time_seconds"`
+	AveragePerDeliverySeconds int64 `json:"average_per_delivery_seconds"`
+}
+
+// GetCourierDailySummary handles GET /couriers/me/summary?date=YYYY-MM-DD.
+// The date defaults to today (UTC); admins name the courier with courier_id.
+func (h *HTTPHandler) GetCourierDailySummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	var courierID int
+	switch {
+	case userCtx.Role == "admin" || userCtx.Role == "super_admin":
+		id, err := strconv.Atoi(r.URL.Query().Get("courier_id"))
+		if err != nil || id <= 0 {
+			httputil.SendErrorResponse(w, "courier_id is required for admins", http.StatusBadRequest)
+			return
+		}
+		courierID = id
+	case userCtx.Role == "courier" && userCtx.CourierID != nil:
+		courierID = *userCtx.CourierID
+	default:
+		httputil.SendErrorResponse(w, "Only couriers have a daily summary", http.StatusForbidden)
+		return
+	}
+
+	date := time.Now().UTC()
+	if value := r.URL.Query().Get("date"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "tracking-service", "get_courier_daily_summary_http")
+
+	summary, err := h.service.GetCourierDailySummary(ctx, ports.GetCourierDailySummaryRequest{
+		CourierID:   courierID,
+		Date:        date,
+		AuthContext: authContext(userCtx),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnauthorized):
+			httputil.SendErrorResponse(w, "Not allowed to access this courier", http.StatusForbidden)
+		case errors.Is(err, domain.ErrFutureSummaryDate):
+			httputil.SendErrorResponse(w, "date must not be in the future", http.StatusBadRequest)
+		default:
+			sendServiceError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(courierDaySummaryResponse{
+		CourierDaySummary:         summary,
+		ActiveTimeSeconds:         int64(summary.ActiveTime.Seconds()),
+		AveragePerDeliverySeconds: int64(summary.AveragePerDelivery.Seconds()),
+	})
+}
+
+// fleetFeatureCollection is the fleet map as a GeoJSON FeatureCollection
+// with one Point feature per courier
+type fleetFeatureCollection struct {
+	Type      string         `json:"type"`
+	Features  []fleetFeature `json:"features"`
+	Truncated bool           `json:"truncated"` // couriers were left out to stay within the limit
+}
+
+type fleetFeature struct {
+	Type       string          `json:"type"`
+	Geometry   fleetGeometry   `json:"geometry"`
+	Properties fleetProperties `json:"properties"`
+}
+
+type fleetGeometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // longitude, latitude
+}
+
+type fleetProperties struct {
+	CourierID  int                    `json:"courier_id"`
+	Status     domain.CourierStatus   `json:"status"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Speed      *float64               `json:"speed,omitempty"`
+	Heading    *float64               `json:"heading,omitempty"`
+	Deliveries []domain.FleetDelivery `json:"deliveries"`
+}
+
+// GetFleetLocations handles GET /couriers/locations?bbox=minLng,minLat,maxLng,maxLat&status=active|all
+func (h *HTTPHandler) GetFleetLocations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	req := ports.GetFleetLocationsRequest{AuthContext: authContext(userCtx)}
+	if value := r.URL.Query().Get("bbox"); value != "" {
+		box, err := domain.ParseBoundingBox(value)
+		if err != nil {
+			httputil.SendErrorResponse(w, "Invalid bbox, expected minLng,minLat,maxLng,maxLat", http.StatusBadRequest)
+			return
+		}
+		req.Box = box
+	}
+	switch r.URL.Query().Get("status") {
+	case "", "active":
+		req.ActiveOnly = true
+	case "all":
+	default:
+		httputil.SendErrorResponse(w, "Invalid status, expected active or all", http.StatusBadRequest)
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "tracking-service", "get_fleet_locations_http")
+
+	fleet, truncated, err := h.service.GetFleetLocations(ctx, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			httputil.SendErrorResponse(w, "Only admins can view the fleet map", http.StatusForbidden)
+			return
+		}
+		sendServiceError(w, err)
+		return
+	}
+
+	resp := fleetFeatureCollection{Type: "FeatureCollection", Features: make([]fleetFeature, 0, len(fleet)), Truncated: truncated}
+	for _, courier := range fleet {
+		deliveries := courier.Deliveries
+		if deliveries == nil {
+			deliveries = []domain.FleetDelivery{}
+		}
+		resp.Features = append(resp.Features, fleetFeature{
+			Type:     "Feature",
+			Geometry: fleetGeometry{Type: "Point", Coordinates: [2]float64{courier.Location.Longitude, courier.Location.Latitude}},
+			Properties: fleetProperties{
+				CourierID:  courier.Location.CourierID,
+				Status:     courier.Status,
+				Timestamp:  courier.Location.Timestamp,
+				Speed:      courier.Location.Speed,
+				Heading:    courier.Location.Heading,
+				Deliveries: deliveries,
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// trackSummaryResponse is a purged track's summary with its duration in seconds
+type trackSummaryResponse struct {
+	*domain.TrackSummary
+	DurationSeconds int64 `json:"duration_seconds"`
+}
+
+// eraseTrackResponse reports what an erasure request deleted
+type eraseTrackResponse struct {
+	DeliveryID    int                   `json:"delivery_id"`
+	PointsDeleted int64                 `json:"points_deleted"`
+	Summary       *trackSummaryResponse `json:"summary"` // null if the delivery had no points
+}
+
+// EraseDeliveryTrack handles DELETE /deliveries/{id}/track
+func (h *HTTPHandler) EraseDeliveryTrack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "tracking-service", "erase_delivery_track_http")
+
+	summary, deleted, err := h.service.EraseDeliveryTrack(ctx, ports.EraseDeliveryTrackRequest{
+		DeliveryID:  deliveryID,
+		UserID:      userCtx.UserID,
+		AuthContext: authContext(userCtx),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			httputil.SendErrorResponse(w, "Only admins can erase delivery tracks", http.StatusForbidden)
+			return
+		}
+		sendServiceError(w, err)
+		return
+	}
+
+	resp := eraseTrackResponse{DeliveryID: deliveryID, PointsDeleted: deleted}
+	if summary != nil {
+		resp.Summary = &trackSummaryResponse{TrackSummary: summary, DurationSeconds: int64(summary.Duration.Seconds())}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // CalculateETA handles POST /deliveries/{id}/eta
 func (h *HTTPHandler) CalculateETA(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -241,18 +670,13 @@ func (h *HTTPHandler) CalculateETA(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Extract user and trace context
-	userCtx := httputil.ExtractUserContext(r)
-	traceCtx := httputil.ExtractTraceContext(r, "tracking-service", "calculate_eta_http")
-
-	// Extract delivery ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/deliveries/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 || parts[1] != "eta" {
-		httputil.SendErrorResponse(w, "Invalid path", http.StatusBadRequest)
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
 		return
 	}
+	traceCtx := httputil.ExtractTraceContext(r, "tracking-service", "calculate_eta_http")
 
-	deliveryID, err := strconv.Atoi(parts[0])
+	deliveryID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		httputil.SendErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest)
 		return
@@ -263,8 +687,8 @@ func (h *HTTPHandler) CalculateETA(w http.ResponseWriter, r *http.Request) {
 		DestLng float64 `json:"dest_lng"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httputil.SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if err := httputil.DecodeJSON(w, r, &req); err != nil {
+		httputil.SendBodyError(w, err)
 		return
 	}
 
@@ -274,24 +698,95 @@ func (h *HTTPHandler) CalculateETA(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Authorization: customers can calculate ETA for their deliveries, couriers for assigned deliveries
-	if userCtx.Role == "customer" && userCtx.CustomerID != nil {
-		// In a real implementation, we'd check if the delivery belongs to this customer
-	} else if userCtx.Role == "courier" && userCtx.CourierID != nil {
-		// In a real implementation, we'd check if the courier is assigned to this delivery
-	}
-
 	// Calculate ETA
 	eta, err := h.service.CalculateETAToDestination(traceCtx, ports.CalculateETAToDestinationRequest{
-		DeliveryID: deliveryID,
-		DestLat:    req.DestLat,
-		DestLng:    req.DestLng,
+		DeliveryID:  deliveryID,
+		DestLat:     req.DestLat,
+		DestLng:     req.DestLng,
+		AuthContext: authContext(userCtx),
 	})
 	if err != nil {
-		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		sendReadError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(eta)
 }
+
+// parseTimeQuery parses an optional RFC3339 query value; empty yields nil
+func parseTimeQuery(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// timeOrZero returns an optional time, or the zero time when unset
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// authContext carries the caller's role and identities into service requests
+func authContext(userCtx httputil.UserContext) ports.AuthContext {
+	return ports.AuthContext{
+		Role:           userCtx.Role,
+		UserCustomerID: userCtx.CustomerID,
+		UserCourierID:  userCtx.CourierID,
+	}
+}
+
+// unavailableResponse is sent while a dependency is unavailable
+type unavailableResponse struct {
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+	RetryAfter int64  `json:"retry_after,omitempty"` // seconds, while the circuit is open
+}
+
+// sendServiceError answers a failure the caller cannot fix: 503 while a
+// dependency is unavailable, with a retry hint while its circuit is open, and
+// 500 otherwise. Failed delivery lookups answer delivery_unavailable.
+func sendServiceError(w http.ResponseWriter, err error) {
+	var open *resilience.OpenError
+	isOpen := errors.As(err, &open)
+
+	resp := unavailableResponse{Error: "service_unavailable"}
+	switch {
+	case errors.Is(err, domain.ErrDeliveryUnavailable):
+		resp = unavailableResponse{Error: "delivery_unavailable", Message: "The delivery service is unavailable"}
+	case isOpen:
+		resp.Message = fmt.Sprintf("The %s service is unavailable", open.Breaker)
+	default:
+		httputil.SendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if isOpen {
+		resp.RetryAfter = max(int64(math.Ceil(open.RetryAfter.Seconds())), 1)
+		w.Header().Set("Retry-After", strconv.FormatInt(resp.RetryAfter, 10))
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// sendReadError maps delivery read failures to responses, refusing callers
+// the delivery does not belong to
+func sendReadError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrUnauthorized) {
+		httputil.SendErrorResponse(w, "Not allowed to access this delivery", http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidTimeRange) {
+		httputil.SendErrorResponse(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+	sendServiceError(w, err)
+}
@@ -4,22 +4,41 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/app"
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // MockTrackingService is a mock implementation of TrackingService for testing
 type MockTrackingService struct {
 	recordLocationFunc         func(ctx context.Context, req ports.RecordLocationRequest) (*domain.Location, error)
 	getDeliveryTrackFunc       func(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, error)
-	getCurrentLocationFunc     func(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, error)
+	exportDeliveryTrackFunc    func(ctx context.Context, req ports.ExportDeliveryTrackRequest, emit func(*domain.Location) error) error
+	getCurrentLocationFunc     func(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, bool, error)
 	getCourierLocationFunc     func(ctx context.Context, req ports.GetCourierLocationRequest) (*domain.Location, error)
 	calculateETAFunc           func(ctx context.Context, req ports.CalculateETAToDestinationRequest) (*ports.CalculateETAResponse, error)
+	getCourierStatusFunc       func(ctx context.Context, req ports.GetCourierStatusRequest) (*domain.CourierHeartbeat, error)
+	eraseDeliveryTrackFunc     func(ctx context.Context, req ports.EraseDeliveryTrackRequest) (*domain.TrackSummary, int64, error)
+	getDailySummaryFunc        func(ctx context.Context, req ports.GetCourierDailySummaryRequest) (*domain.CourierDaySummary, error)
+	getFleetLocationsFunc      func(ctx context.Context, req ports.GetFleetLocationsRequest) ([]domain.FleetCourier, bool, error)
+	getTrackVersionFunc        func(ctx context.Context, req ports.GetTrackVersionRequest) (domain.TrackVersion, error)
+	findZonesFunc              func(ctx context.Context, req ports.FindZonesRequest) ([]string, error)
 }
 
 func (m *MockTrackingService) RecordLocation(ctx context.Context, req ports.RecordLocationRequest) (*domain.Location, error) {
@@ -29,18 +48,40 @@ func (m *MockTrackingService) RecordLocation(ctx context.Context, req ports.Reco
 	return &domain.Location{}, nil
 }
 
-func (m *MockTrackingService) GetDeliveryTrack(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, error) {
+func (m *MockTrackingService) GetDeliveryTrack(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, int, error) {
 	if m.getDeliveryTrackFunc != nil {
-		return m.getDeliveryTrackFunc(ctx, req)
+		locations, err := m.getDeliveryTrackFunc(ctx, req)
+		return locations, len(locations), err
 	}
-	return []*domain.Location{}, nil
+	return []*domain.Location{}, 0, nil
 }
 
-func (m *MockTrackingService) GetCurrentLocation(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, error) {
+func (m *MockTrackingService) GetTrackVersion(ctx context.Context, req ports.GetTrackVersionRequest) (domain.TrackVersion, error) {
+	if m.getTrackVersionFunc != nil {
+		return m.getTrackVersionFunc(ctx, req)
+	}
+	return domain.TrackVersion{}, nil
+}
+
+func (m *MockTrackingService) ExportDeliveryTrack(ctx context.Context, req ports.ExportDeliveryTrackRequest, emit func(*domain.Location) error) error {
+	if m.exportDeliveryTrackFunc != nil {
+		return m.exportDeliveryTrackFunc(ctx, req, emit)
+	}
+	return nil
+}
+
+func (m *MockTrackingService) EraseDeliveryTrack(ctx context.Context, req ports.EraseDeliveryTrackRequest) (*domain.TrackSummary, int64, error) {
+	if m.eraseDeliveryTrackFunc != nil {
+		return m.eraseDeliveryTrackFunc(ctx, req)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockTrackingService) GetCurrentLocation(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, bool, error) {
 	if m.getCurrentLocationFunc != nil {
 		return m.getCurrentLocationFunc(ctx, req)
 	}
-	return &domain.Location{}, nil
+	return &domain.Location{}, false, nil
 }
 
 func (m *MockTrackingService) GetCourierLocation(ctx context.Context, req ports.GetCourierLocationRequest) (*domain.Location, error) {
@@ -50,6 +91,42 @@ func (m *MockTrackingService) GetCourierLocation(ctx context.Context, req ports.
 	return &domain.Location{}, nil
 }
 
+func (m *MockTrackingService) GetCourierAverageSpeed(ctx context.Context, req ports.GetCourierLocationRequest) (float64, error) {
+	return 0, nil
+}
+
+func (m *MockTrackingService) GetCourierStatus(ctx context.Context, req ports.GetCourierStatusRequest) (*domain.CourierHeartbeat, error) {
+	if m.getCourierStatusFunc != nil {
+		return m.getCourierStatusFunc(ctx, req)
+	}
+	return &domain.CourierHeartbeat{CourierID: req.CourierID, Status: domain.CourierStatusOffline}, nil
+}
+
+func (m *MockTrackingService) GetCourierDailySummary(ctx context.Context, req ports.GetCourierDailySummaryRequest) (*domain.CourierDaySummary, error) {
+	if m.getDailySummaryFunc != nil {
+		return m.getDailySummaryFunc(ctx, req)
+	}
+	return &domain.CourierDaySummary{CourierID: req.CourierID, Date: req.Date.Format(time.DateOnly)}, nil
+}
+
+func (m *MockTrackingService) GetFleetLocations(ctx context.Context, req ports.GetFleetLocationsRequest) ([]domain.FleetCourier, bool, error) {
+	if m.getFleetLocationsFunc != nil {
+		return m.getFleetLocationsFunc(ctx, req)
+	}
+	return nil, false, nil
+}
+
+func (m *MockTrackingService) TrackDelivery(ctx context.Context, req ports.TrackDeliveryRequest, send func(*domain.Location) error) error {
+	return nil
+}
+
+func (m *MockTrackingService) FindZones(ctx context.Context, req ports.FindZonesRequest) ([]string, error) {
+	if m.findZonesFunc != nil {
+		return m.findZonesFunc(ctx, req)
+	}
+	return nil, nil
+}
+
 func (m *MockTrackingService) CalculateETAToDestination(ctx context.Context, req ports.CalculateETAToDestinationRequest) (*ports.CalculateETAResponse, error) {
 	if m.calculateETAFunc != nil {
 		return m.calculateETAFunc(ctx, req)
@@ -57,6 +134,12 @@ func (m *MockTrackingService) CalculateETAToDestination(ctx context.Context, req
 	return &ports.CalculateETAResponse{}, nil
 }
 
+// withPathID sets the {id} path value the router extracts in the service
+func withPathID(req *http.Request, id string) *http.Request {
+	req.SetPathValue("id", id)
+	return req
+}
+
 func TestHTTPHandler_RecordLocation(t *testing.T) {
 	mockService := &MockTrackingService{
 		recordLocationFunc: func(ctx context.Context, req ports.RecordLocationRequest) (*domain.Location, error) {
@@ -86,8 +169,7 @@ func TestHTTPHandler_RecordLocation(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add auth context
-	ctx := context.WithValue(req.Context(), "role", "courier")
-	ctx = context.WithValue(ctx, "courier_id", &[]int{1}[0])
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "courier", CourierID: &[]int{1}[0]})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -107,6 +189,74 @@ func TestHTTPHandler_RecordLocation(t *testing.T) {
 	}
 }
 
+func TestHTTPHandler_RecordLocation_Duplicate(t *testing.T) {
+	mockService := &MockTrackingService{
+		recordLocationFunc: func(ctx context.Context, req ports.RecordLocationRequest) (*domain.Location, error) {
+			return &domain.Location{
+				DeliveryID:    req.DeliveryID,
+				CourierID:     req.CourierID,
+				Latitude:      req.Latitude,
+				Longitude:     req.Longitude,
+				ClientPointID: req.ClientPointID,
+				Duplicate:     true,
+			}, nil
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+
+	body := `{"delivery_id":1,"courier_id":1,"latitude":40.7128,"longitude":-74.0060,"client_point_id":"7f9c24e8-3b12-4a6d-9e5f-1c2b3a4d5e6f","recorded_at":"2026-01-02T03:04:05Z"}`
+	req := httptest.NewRequest("POST", "/locations", bytes.NewReader([]byte(body)))
+	req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "courier", CourierID: &[]int{1}[0]}))
+
+	w := httptest.NewRecorder()
+	handler.RecordLocation(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d for a re-upload, got %d", http.StatusOK, w.Code)
+	}
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response["duplicate"] != true || response["client_point_id"] != "7f9c24e8-3b12-4a6d-9e5f-1c2b3a4d5e6f" {
+		t.Errorf("expected the stored point marked as a duplicate, got %v", response)
+	}
+}
+
+func TestHTTPHandler_RecordLocation_Rejected(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "invalid location", err: domain.ErrInvalidLocation, expectedStatus: http.StatusBadRequest},
+		{name: "delivery closed", err: fmt.Errorf("%w: delivery is delivered", domain.ErrDeliveryClosed), expectedStatus: http.StatusConflict},
+		{name: "jitter", err: fmt.Errorf("%w: implied speed 900 km/h exceeds 200 km/h", domain.ErrLocationJitter), expectedStatus: http.StatusAccepted},
+		{name: "courier not assigned", err: domain.ErrUnauthorized, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHTTPHandler(&MockTrackingService{
+				recordLocationFunc: func(ctx context.Context, req ports.RecordLocationRequest) (*domain.Location, error) {
+					return nil, tt.err
+				},
+			})
+
+			body, _ := json.Marshal(ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060})
+			req := httptest.NewRequest("POST", "/locations", bytes.NewReader(body))
+			req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "courier", CourierID: &[]int{1}[0]}))
+
+			w := httptest.NewRecorder()
+			handler.RecordLocation(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
 func TestHTTPHandler_RecordLocation_Unauthorized(t *testing.T) {
 	mockService := &MockTrackingService{}
 	handler := NewHTTPHandler(mockService)
@@ -123,7 +273,7 @@ func TestHTTPHandler_RecordLocation_Unauthorized(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add customer role (not authorized)
-	ctx := context.WithValue(req.Context(), "role", "customer")
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "customer"})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -134,6 +284,30 @@ func TestHTTPHandler_RecordLocation_Unauthorized(t *testing.T) {
 	}
 }
 
+func TestHTTPHandler_RecordLocation_MissingClaims(t *testing.T) {
+	mockService := &MockTrackingService{}
+	handler := NewHTTPHandler(mockService)
+
+	reqBody := ports.RecordLocationRequest{
+		DeliveryID: 1,
+		CourierID:  1,
+		Latitude:   40.7128,
+		Longitude:  -74.0060,
+	}
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest("POST", "/locations", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	// No claims in context
+	w := httptest.NewRecorder()
+	handler.RecordLocation(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
 func TestHTTPHandler_RecordLocation_WrongCourier(t *testing.T) {
 	mockService := &MockTrackingService{}
 	handler := NewHTTPHandler(mockService)
@@ -150,8 +324,7 @@ func TestHTTPHandler_RecordLocation_WrongCourier(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add auth context for courier ID 1
-	ctx := context.WithValue(req.Context(), "role", "courier")
-	ctx = context.WithValue(ctx, "courier_id", &[]int{1}[0])
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "courier", CourierID: &[]int{1}[0]})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -162,6 +335,50 @@ func TestHTTPHandler_RecordLocation_WrongCourier(t *testing.T) {
 	}
 }
 
+func TestHTTPHandler_RecordLocation_AdminOverride(t *testing.T) {
+	courierID := 1
+	tests := []struct {
+		name           string
+		claims         *authDomain.Claims
+		override       bool
+		expectedStatus int
+	}{
+		{name: "admin with override", claims: &authDomain.Claims{Role: "admin"}, override: true, expectedStatus: http.StatusCreated},
+		{name: "admin without override", claims: &authDomain.Claims{Role: "admin"}, expectedStatus: http.StatusForbidden},
+		{name: "courier with override", claims: &authDomain.Claims{Role: "courier", CourierID: &courierID}, override: true, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded *ports.RecordLocationRequest
+			handler := NewHTTPHandler(&MockTrackingService{
+				recordLocationFunc: func(ctx context.Context, req ports.RecordLocationRequest) (*domain.Location, error) {
+					recorded = &req
+					return &domain.Location{DeliveryID: req.DeliveryID, CourierID: req.CourierID}, nil
+				},
+			})
+
+			// Courier 9 is not the caller
+			body, _ := json.Marshal(ports.RecordLocationRequest{DeliveryID: 1, CourierID: 9, Latitude: 40.7128, Longitude: -74.0060, AdminOverride: tt.override})
+			req := httptest.NewRequest("POST", "/locations", bytes.NewReader(body))
+			req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+
+			w := httptest.NewRecorder()
+			handler.RecordLocation(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusCreated && (recorded == nil || !recorded.AdminOverride) {
+				t.Errorf("expected the override to reach the service, got %+v", recorded)
+			}
+			if tt.expectedStatus != http.StatusCreated && recorded != nil {
+				t.Error("expected the service not to be called")
+			}
+		})
+	}
+}
+
 func TestHTTPHandler_GetDeliveryTrack(t *testing.T) {
 	mockService := &MockTrackingService{
 		getDeliveryTrackFunc: func(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, error) {
@@ -181,10 +398,10 @@ func TestHTTPHandler_GetDeliveryTrack(t *testing.T) {
 
 	handler := NewHTTPHandler(mockService)
 
-	req := httptest.NewRequest("GET", "/deliveries/1/track", nil)
+	req := withPathID(httptest.NewRequest("GET", "/deliveries/1/track", nil), "1")
 
 	// Add auth context
-	ctx := context.WithValue(req.Context(), "role", "customer")
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "customer"})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -195,8 +412,10 @@ func TestHTTPHandler_GetDeliveryTrack(t *testing.T) {
 	}
 
 	var response struct {
-		DeliveryID int                    `json:"delivery_id"`
-		Locations  []*domain.Location     `json:"locations"`
+		DeliveryID         int                `json:"delivery_id"`
+		Locations          []*domain.Location `json:"locations"`
+		PointCount         int                `json:"point_count"`
+		OriginalPointCount int                `json:"original_point_count"`
 	}
 
 	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
@@ -210,11 +429,346 @@ func TestHTTPHandler_GetDeliveryTrack(t *testing.T) {
 	if len(response.Locations) != 1 {
 		t.Errorf("expected 1 location, got %d", len(response.Locations))
 	}
+
+	if response.PointCount != 1 || response.OriginalPointCount != 1 {
+		t.Errorf("expected point counts of 1, got %d of %d", response.PointCount, response.OriginalPointCount)
+	}
+}
+
+func TestHTTPHandler_GetDeliveryTrack_Conditional(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	service := app.NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), testsupport.NewAuthService(), nil,
+		&logger.Logger{Logger: zaptest.NewLogger(t)})
+	service.SetJitterFilter(domain.JitterFilter{})
+	handler := NewHTTPHandler(service)
+	ctx := context.Background()
+
+	record := func(latitude float64) {
+		t.Helper()
+		if _, err := service.RecordLocation(ctx, ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: latitude, Longitude: -74.0060}); err != nil {
+			t.Fatalf("failed to record location: %v", err)
+		}
+	}
+	getTrack := func(method, ifNoneMatch string, query ...string) *httptest.ResponseRecorder {
+		target := "/deliveries/1/track"
+		if len(query) > 0 {
+			target += "?" + query[0]
+		}
+		req := withPathID(httptest.NewRequest(method, target, nil), "1")
+		req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "admin"}))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.GetDeliveryTrack(w, req)
+		return w
+	}
+
+	record(40.7128)
+	first := getTrack(http.MethodGet, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected a 200 with an ETag, got %d %q", first.Code, etag)
+	}
+
+	unchanged := getTrack(http.MethodGet, etag)
+	if unchanged.Code != http.StatusNotModified || unchanged.Body.Len() != 0 {
+		t.Errorf("expected a 304 without a body while nothing changed, got %d %q", unchanged.Code, unchanged.Body.String())
+	}
+
+	head := getTrack(http.MethodHead, "")
+	if head.Code != http.StatusOK || head.Body.Len() != 0 || head.Header().Get("ETag") != etag {
+		t.Errorf("expected HEAD to answer the headers only, got %d %q %q", head.Code, head.Header().Get("ETag"), head.Body.String())
+	}
+
+	// The query shapes the body, so another one is not answered from the tag
+	for _, query := range []string{"order=asc", "simplify=25", "from=2020-01-01T00:00:00Z", "limit=1"} {
+		other := getTrack(http.MethodGet, etag, query)
+		if other.Code != http.StatusOK || other.Header().Get("ETag") == etag {
+			t.Errorf("expected a 200 with another ETag for %s, got %d %q", query, other.Code, other.Header().Get("ETag"))
+		}
+	}
+
+	// A new location changes the tag, so the old one gets the fresh track
+	record(40.7228)
+	fresh := getTrack(http.MethodGet, etag)
+	if fresh.Code != http.StatusOK || fresh.Header().Get("ETag") == etag {
+		t.Fatalf("expected a fresh 200 after a new location, got %d %q", fresh.Code, fresh.Header().Get("ETag"))
+	}
+	var response struct {
+		PointCount int `json:"point_count"`
+	}
+	if err := json.NewDecoder(fresh.Body).Decode(&response); err != nil || response.PointCount != 2 {
+		t.Errorf("expected both points, got %d (%v)", response.PointCount, err)
+	}
+}
+
+func TestHTTPHandler_GetDeliveryTrack_Window(t *testing.T) {
+	var got ports.GetDeliveryTrackRequest
+	mockService := &MockTrackingService{
+		getDeliveryTrackFunc: func(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, error) {
+			got = req
+			if req.From != nil && req.To != nil && req.To.Before(*req.From) {
+				return nil, domain.ErrInvalidTimeRange
+			}
+			return []*domain.Location{}, nil
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{name: "window oldest first", query: "?from=2024-01-01T10:00:00Z&to=2024-01-01T12:00:00Z&order=asc", expectedStatus: http.StatusOK},
+		{name: "newest first", query: "?order=desc", expectedStatus: http.StatusOK},
+		{name: "unparsable from", query: "?from=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "unparsable to", query: "?to=2024-01-01", expectedStatus: http.StatusBadRequest},
+		{name: "unknown order", query: "?order=random", expectedStatus: http.StatusBadRequest},
+		{name: "simplified", query: "?simplify=2.5", expectedStatus: http.StatusOK},
+		{name: "negative simplify", query: "?simplify=-1", expectedStatus: http.StatusBadRequest},
+		{name: "unparsable simplify", query: "?simplify=NaN", expectedStatus: http.StatusBadRequest},
+		{name: "to before from", query: "?from=2024-01-01T12:00:00Z&to=2024-01-01T10:00:00Z", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ports.GetDeliveryTrackRequest{}
+			req := withPathID(httptest.NewRequest("GET", "/deliveries/1/track"+tt.query, nil), "1")
+			req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "admin"}))
+
+			w := httptest.NewRecorder()
+			handler.GetDeliveryTrack(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The first case's window reaches the service
+	req := withPathID(httptest.NewRequest("GET", "/deliveries/1/track"+tests[0].query, nil), "1")
+	req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "admin"}))
+	handler.GetDeliveryTrack(httptest.NewRecorder(), req)
+	wantFrom := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	wantTo := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got.From == nil || !got.From.Equal(wantFrom) || got.To == nil || !got.To.Equal(wantTo) || !got.OldestFirst {
+		t.Errorf("expected the window and order to be passed through, got %+v", got)
+	}
+}
+
+func TestHTTPHandler_GetDeliveryTrack_ResolveAddresses(t *testing.T) {
+	var got ports.GetDeliveryTrackRequest
+	mockService := &MockTrackingService{
+		getDeliveryTrackFunc: func(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, error) {
+			got = req
+			return []*domain.Location{{ID: 1, DeliveryID: req.DeliveryID, Address: "123 Main St"}}, nil
+		},
+	}
+
+	handler := NewHTTPHandler(mockService)
+
+	req := withPathID(httptest.NewRequest("GET", "/deliveries/1/track?resolve_addresses=true&address_every=10", nil), "1")
+	req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "customer"}))
+
+	w := httptest.NewRecorder()
+	handler.GetDeliveryTrack(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !got.ResolveAddresses || got.AddressEvery != 10 {
+		t.Errorf("expected address resolution options to be passed through, got %+v", got)
+	}
+
+	var response struct {
+		Locations []map[string]interface{} `json:"locations"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Locations) != 1 || response.Locations[0]["address"] != "123 Main St" {
+		t.Errorf("expected address in response, got %+v", response.Locations)
+	}
+}
+
+func TestHTTPHandler_DeliveryReads_Forbidden(t *testing.T) {
+	customerID := 7
+	var gotAuth []ports.AuthContext
+	mockService := &MockTrackingService{
+		getDeliveryTrackFunc: func(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, error) {
+			gotAuth = append(gotAuth, req.AuthContext)
+			return nil, domain.ErrUnauthorized
+		},
+		getCurrentLocationFunc: func(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, bool, error) {
+			gotAuth = append(gotAuth, req.AuthContext)
+			return nil, false, domain.ErrUnauthorized
+		},
+		calculateETAFunc: func(ctx context.Context, req ports.CalculateETAToDestinationRequest) (*ports.CalculateETAResponse, error) {
+			gotAuth = append(gotAuth, req.AuthContext)
+			return nil, domain.ErrUnauthorized
+		},
+		exportDeliveryTrackFunc: func(ctx context.Context, req ports.ExportDeliveryTrackRequest, emit func(*domain.Location) error) error {
+			gotAuth = append(gotAuth, req.AuthContext)
+			return domain.ErrUnauthorized
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+
+	requests := []struct {
+		name  string
+		serve func(w http.ResponseWriter, r *http.Request)
+		req   *http.Request
+	}{
+		{name: "track", serve: handler.GetDeliveryTrack, req: withPathID(httptest.NewRequest("GET", "/deliveries/1/track", nil), "1")},
+		{name: "location", serve: handler.GetCurrentLocation, req: withPathID(httptest.NewRequest("GET", "/deliveries/1/location", nil), "1")},
+		{name: "export", serve: handler.ExportDeliveryTrack, req: withPathID(httptest.NewRequest("GET", "/deliveries/1/track/export?format=gpx", nil), "1")},
+		{name: "eta", serve: handler.CalculateETA, req: withPathID(httptest.NewRequest("POST", "/deliveries/1/eta", bytes.NewReader([]byte(`{"dest_lat":40.7589,"dest_lng":-73.9851}`))), "1")},
+	}
+
+	for _, tt := range requests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req.WithContext(authctx.WithClaims(tt.req.Context(), &authDomain.Claims{Role: "customer", CustomerID: &customerID}))
+
+			w := httptest.NewRecorder()
+			tt.serve(w, req)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+			}
+		})
+	}
+
+	// The caller's identity is passed on for the ownership check
+	for _, auth := range gotAuth {
+		if auth.Role != "customer" || auth.UserCustomerID == nil || *auth.UserCustomerID != customerID {
+			t.Errorf("expected caller auth context, got %+v", auth)
+		}
+	}
+	if len(gotAuth) != len(requests) {
+		t.Errorf("expected %d service calls, got %d", len(requests), len(gotAuth))
+	}
+}
+
+func TestHTTPHandler_ExportDeliveryTrack(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	speed := 36.0
+	track := []*domain.Location{
+		{DeliveryID: 1, Latitude: 40.70, Longitude: -74.00, Timestamp: start, Speed: &speed},
+		{DeliveryID: 1, Latitude: 40.71, Longitude: -74.01, Timestamp: start.Add(time.Minute)},
+		{DeliveryID: 1, Latitude: 40.72, Longitude: -74.02, Timestamp: start.Add(2 * time.Minute), Speed: &speed},
+	}
+
+	var got ports.ExportDeliveryTrackRequest
+	mockService := &MockTrackingService{
+		exportDeliveryTrackFunc: func(ctx context.Context, req ports.ExportDeliveryTrackRequest, emit func(*domain.Location) error) error {
+			got = req
+			for _, loc := range track {
+				if err := emit(loc); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		req := withPathID(httptest.NewRequest("GET", target, nil), "1")
+		req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "admin"}))
+		w := httptest.NewRecorder()
+		handler.ExportDeliveryTrack(w, req)
+		return w
+	}
+
+	t.Run("geojson", func(t *testing.T) {
+		w := serve("/deliveries/1/track/export?from=2024-05-01T08:00:00Z&to=2024-05-01T10:00:00Z")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/geo+json" {
+			t.Errorf("expected GeoJSON content type, got %q", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="delivery-1-track.geojson"` {
+			t.Errorf("unexpected Content-Disposition %q", cd)
+		}
+		if got.From == nil || !got.From.Equal(start.Add(-time.Hour)) || got.To == nil || !got.To.Equal(start.Add(time.Hour)) {
+			t.Errorf("expected time window to be passed on, got %+v", got)
+		}
+
+		var collection struct {
+			Type     string `json:"type"`
+			Features []struct {
+				Properties map[string]interface{} `json:"properties"`
+				Geometry   struct {
+					Type        string          `json:"type"`
+					Coordinates json.RawMessage `json:"coordinates"`
+				} `json:"geometry"`
+			} `json:"features"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+			t.Fatalf("invalid GeoJSON: %v\n%s", err, w.Body.String())
+		}
+		if collection.Type != "FeatureCollection" || len(collection.Features) != 3 {
+			t.Fatalf("expected a collection of 3 features, got %+v", collection)
+		}
+		var line [][]float64
+		json.Unmarshal(collection.Features[0].Geometry.Coordinates, &line)
+		if collection.Features[0].Geometry.Type != "LineString" || len(line) != 3 || line[0][0] != -74.00 || line[0][1] != 40.70 {
+			t.Errorf("expected a 3 point LineString in lng,lat order, got %s", collection.Features[0].Geometry.Coordinates)
+		}
+		end := collection.Features[2]
+		if end.Geometry.Type != "Point" || end.Properties["point"] != "end" ||
+			end.Properties["timestamp"] != "2024-05-01T09:02:00Z" || end.Properties["speed_kmh"] != 36.0 {
+			t.Errorf("unexpected end feature %+v", end)
+		}
+	})
+
+	t.Run("gpx", func(t *testing.T) {
+		w := serve("/deliveries/1/track/export?format=gpx")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/gpx+xml" {
+			t.Errorf("expected GPX content type, got %q", ct)
+		}
+
+		var doc struct {
+			XMLName xml.Name `xml:"gpx"`
+			Points  []struct {
+				Lat   float64 `xml:"lat,attr"`
+				Lon   float64 `xml:"lon,attr"`
+				Time  string  `xml:"time"`
+				Speed string  `xml:"extensions>TrackPointExtension>speed"`
+			} `xml:"trk>trkseg>trkpt"`
+		}
+		if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid GPX: %v\n%s", err, w.Body.String())
+		}
+		if len(doc.Points) != 3 || doc.Points[0].Lat != 40.70 || doc.Points[0].Time != "2024-05-01T09:00:00Z" {
+			t.Fatalf("unexpected track points %+v", doc.Points)
+		}
+		if doc.Points[0].Speed != "10.00" || doc.Points[1].Speed != "" {
+			t.Errorf("expected speed in m/s only where reported, got %+v", doc.Points)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, target := range []string{
+			"/deliveries/1/track/export?format=kml",
+			"/deliveries/1/track/export?from=yesterday",
+		} {
+			if w := serve(target); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
 }
 
 func TestHTTPHandler_GetCurrentLocation(t *testing.T) {
 	mockService := &MockTrackingService{
-		getCurrentLocationFunc: func(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, error) {
+		getCurrentLocationFunc: func(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, bool, error) {
 			return &domain.Location{
 				ID:         1,
 				DeliveryID: req.DeliveryID,
@@ -223,16 +777,16 @@ func TestHTTPHandler_GetCurrentLocation(t *testing.T) {
 				Longitude:  -74.0060,
 				Timestamp:  time.Now(),
 				CreatedAt:  time.Now(),
-			}, nil
+			}, true, nil
 		},
 	}
 
 	handler := NewHTTPHandler(mockService)
 
-	req := httptest.NewRequest("GET", "/deliveries/1/location", nil)
+	req := withPathID(httptest.NewRequest("GET", "/deliveries/1/location", nil), "1")
 
 	// Add auth context
-	ctx := context.WithValue(req.Context(), "role", "customer")
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "customer"})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -241,6 +795,9 @@ func TestHTTPHandler_GetCurrentLocation(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
+	if w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected X-Cache HIT, got %q", w.Header().Get("X-Cache"))
+	}
 
 	var response domain.Location
 	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
@@ -268,11 +825,10 @@ func TestHTTPHandler_GetCourierLocation(t *testing.T) {
 
 	handler := NewHTTPHandler(mockService)
 
-	req := httptest.NewRequest("GET", "/couriers/1/location", nil)
+	req := withPathID(httptest.NewRequest("GET", "/couriers/1/location", nil), "1")
 
 	// Add auth context
-	ctx := context.WithValue(req.Context(), "role", "courier")
-	ctx = context.WithValue(ctx, "courier_id", &[]int{1}[0])
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "courier", CourierID: &[]int{1}[0]})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -292,6 +848,370 @@ func TestHTTPHandler_GetCourierLocation(t *testing.T) {
 	}
 }
 
+func TestHTTPHandler_GetCourierLocation_NotActive(t *testing.T) {
+	handler := NewHTTPHandler(&MockTrackingService{
+		getCourierLocationFunc: func(ctx context.Context, req ports.GetCourierLocationRequest) (*domain.Location, error) {
+			if req.Role != "customer" {
+				t.Errorf("expected the caller's role to reach the service, got %q", req.Role)
+			}
+			return nil, domain.ErrCourierNotActive
+		},
+	})
+
+	req := withPathID(httptest.NewRequest("GET", "/couriers/1/location", nil), "1")
+	req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "customer"}))
+
+	w := httptest.NewRecorder()
+	handler.GetCourierLocation(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	var response struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Error != "courier_not_active" {
+		t.Errorf("expected error code courier_not_active, got %q", response.Error)
+	}
+}
+
+func TestHTTPHandler_GetCourierStatus(t *testing.T) {
+	lastSeen := time.Now().Add(-10 * time.Minute)
+	since := lastSeen.Add(5 * time.Minute)
+	mockService := &MockTrackingService{
+		getCourierStatusFunc: func(ctx context.Context, req ports.GetCourierStatusRequest) (*domain.CourierHeartbeat, error) {
+			if req.Role != "admin" {
+				return nil, domain.ErrUnauthorized
+			}
+			return &domain.CourierHeartbeat{
+				CourierID:   req.CourierID,
+				Status:      domain.CourierStatusStale,
+				LastSeenAt:  &lastSeen,
+				StatusSince: &since,
+			}, nil
+		},
+	}
+
+	handler := NewHTTPHandler(mockService)
+
+	tests := []struct {
+		name           string
+		claims         *authDomain.Claims
+		expectedStatus int
+	}{
+		{name: "admin", claims: &authDomain.Claims{Role: "admin"}, expectedStatus: http.StatusOK},
+		{name: "customer", claims: &authDomain.Claims{Role: "customer", CustomerID: &[]int{1}[0]}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withPathID(httptest.NewRequest("GET", "/couriers/7/status", nil), "7")
+			req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+
+			w := httptest.NewRecorder()
+			handler.GetCourierStatus(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				CourierID             int        `json:"courier_id"`
+				Status                string     `json:"status"`
+				LastSeenAt            *time.Time `json:"last_seen_at"`
+				StatusDurationSeconds int64      `json:"status_duration_seconds"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.CourierID != 7 || response.Status != "stale" || response.LastSeenAt == nil {
+				t.Errorf("unexpected response: %+v", response)
+			}
+			if response.StatusDurationSeconds < 299 || response.StatusDurationSeconds > 301 {
+				t.Errorf("expected about 300 seconds in status, got %d", response.StatusDurationSeconds)
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_CircuitOpen(t *testing.T) {
+	mockService := &MockTrackingService{
+		getCourierStatusFunc: func(ctx context.Context, req ports.GetCourierStatusRequest) (*domain.CourierHeartbeat, error) {
+			return nil, fmt.Errorf("failed to list deliveries: %w", &resilience.OpenError{Breaker: "delivery", RetryAfter: 2500 * time.Millisecond})
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+
+	req := withPathID(httptest.NewRequest("GET", "/couriers/7/status", nil), "7")
+	req = req.WithContext(authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "admin"}))
+	w := httptest.NewRecorder()
+	handler.GetCourierStatus(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "3" {
+		t.Errorf("expected Retry-After 3, got %q", got)
+	}
+	var response struct {
+		Error      string `json:"error"`
+		RetryAfter int    `json:"retry_after"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Error != "service_unavailable" || response.RetryAfter != 3 {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestHTTPHandler_GetCourierDailySummary(t *testing.T) {
+	courierID := 7
+	var got ports.GetCourierDailySummaryRequest
+	mockService := &MockTrackingService{
+		getDailySummaryFunc: func(ctx context.Context, req ports.GetCourierDailySummaryRequest) (*domain.CourierDaySummary, error) {
+			got = req
+			if req.Date.Year() == 2999 {
+				return nil, domain.ErrFutureSummaryDate
+			}
+			return &domain.CourierDaySummary{
+				CourierID:           req.CourierID,
+				Date:                req.Date.Format(time.DateOnly),
+				DeliveriesCompleted: 3,
+				DistanceKm:          42.5,
+				ActiveTime:          3 * time.Hour,
+				AveragePerDelivery:  time.Hour,
+			}, nil
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+
+	tests := []struct {
+		name           string
+		query          string
+		claims         *authDomain.Claims
+		expectedStatus int
+		expectedID     int
+	}{
+		{name: "courier's own day", query: "?date=2024-03-05", claims: &authDomain.Claims{UserID: 1, Role: "courier", CourierID: &courierID}, expectedStatus: http.StatusOK, expectedID: 7},
+		{name: "courier ignores courier_id", query: "?date=2024-03-05&courier_id=9", claims: &authDomain.Claims{UserID: 1, Role: "courier", CourierID: &courierID}, expectedStatus: http.StatusOK, expectedID: 7},
+		{name: "admin names a courier", query: "?date=2024-03-05&courier_id=9", claims: &authDomain.Claims{UserID: 2, Role: "admin"}, expectedStatus: http.StatusOK, expectedID: 9},
+		{name: "admin without courier_id", query: "?date=2024-03-05", claims: &authDomain.Claims{UserID: 2, Role: "admin"}, expectedStatus: http.StatusBadRequest},
+		{name: "customer", query: "?date=2024-03-05", claims: &authDomain.Claims{UserID: 3, Role: "customer"}, expectedStatus: http.StatusForbidden},
+		{name: "invalid date", query: "?date=05/03/2024", claims: &authDomain.Claims{UserID: 1, Role: "courier", CourierID: &courierID}, expectedStatus: http.StatusBadRequest},
+		{name: "future date", query: "?date=2999-01-01", claims: &authDomain.Claims{UserID: 1, Role: "courier", CourierID: &courierID}, expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/couriers/me/summary"+tt.query, nil)
+			req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+			w := httptest.NewRecorder()
+			handler.GetCourierDailySummary(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if got.CourierID != tt.expectedID {
+				t.Errorf("expected courier %d, got %d", tt.expectedID, got.CourierID)
+			}
+
+			var body map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body["date"] != "2024-03-05" || body["deliveries_completed"] != float64(3) ||
+				body["active_time_seconds"] != float64(10800) || body["average_per_delivery_seconds"] != float64(3600) {
+				t.Errorf("unexpected response %v", body)
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_GetFleetLocations(t *testing.T) {
+	seen := time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)
+	var got ports.GetFleetLocationsRequest
+	mockService := &MockTrackingService{
+		getFleetLocationsFunc: func(ctx context.Context, req ports.GetFleetLocationsRequest) ([]domain.FleetCourier, bool, error) {
+			got = req
+			if req.Role != "admin" {
+				return nil, false, domain.ErrUnauthorized
+			}
+			return []domain.FleetCourier{
+				{
+					Location:   &domain.Location{CourierID: 7, Latitude: 40.7128, Longitude: -74.006, Timestamp: seen},
+					Status:     domain.CourierStatusActive,
+					Deliveries: []domain.FleetDelivery{{DeliveryID: 3, Status: "in_transit"}},
+				},
+				{Location: &domain.Location{CourierID: 8, Latitude: 40.8, Longitude: -73.95, Timestamp: seen}, Status: domain.CourierStatusStale},
+			}, true, nil
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+	admin := &authDomain.Claims{UserID: 1, Role: "admin"}
+
+	tests := []struct {
+		name           string
+		query          string
+		claims         *authDomain.Claims
+		expectedStatus int
+	}{
+		{name: "defaults to active couriers anywhere", query: "", claims: admin, expectedStatus: http.StatusOK},
+		{name: "invalid bbox", query: "?bbox=-74.1,40.6", claims: admin, expectedStatus: http.StatusBadRequest},
+		{name: "invalid status", query: "?status=idle", claims: admin, expectedStatus: http.StatusBadRequest},
+		{name: "courier", query: "", claims: &authDomain.Claims{UserID: 2, Role: "courier"}, expectedStatus: http.StatusForbidden},
+		{name: "bounding box and all couriers", query: "?bbox=-74.1,40.6,-73.9,40.9&status=all", claims: admin, expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/couriers/locations"+tt.query, nil)
+			req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+			w := httptest.NewRecorder()
+			handler.GetFleetLocations(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The last successful request asked for all couriers inside the box
+	if got.ActiveOnly || got.Box == nil || got.Box.MinLongitude != -74.1 || got.Box.MaxLatitude != 40.9 {
+		t.Errorf("unexpected request %+v", got)
+	}
+
+	req := httptest.NewRequest("GET", "/couriers/locations", nil)
+	req = req.WithContext(authctx.WithClaims(req.Context(), admin))
+	w := httptest.NewRecorder()
+	handler.GetFleetLocations(w, req)
+
+	if !got.ActiveOnly {
+		t.Error("expected active couriers by default")
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/geo+json" {
+		t.Errorf("expected GeoJSON content type, got %q", ct)
+	}
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties struct {
+				CourierID  int                    `json:"courier_id"`
+				Status     string                 `json:"status"`
+				Deliveries []domain.FleetDelivery `json:"deliveries"`
+			} `json:"properties"`
+		} `json:"features"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&collection); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 || !collection.Truncated {
+		t.Fatalf("unexpected collection %+v", collection)
+	}
+	first := collection.Features[0]
+	if first.Type != "Feature" || first.Geometry.Type != "Point" || first.Geometry.Coordinates[0] != -74.006 || first.Geometry.Coordinates[1] != 40.7128 {
+		t.Errorf("expected a [lng, lat] point feature, got %+v", first)
+	}
+	if first.Properties.CourierID != 7 || first.Properties.Status != "active" || len(first.Properties.Deliveries) != 1 {
+		t.Errorf("unexpected properties %+v", first.Properties)
+	}
+	if second := collection.Features[1].Properties; second.Deliveries == nil || len(second.Deliveries) != 0 {
+		t.Errorf("expected an empty deliveries list, got %+v", second.Deliveries)
+	}
+}
+
+func TestHTTPHandler_EraseDeliveryTrack(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	mockService := &MockTrackingService{
+		eraseDeliveryTrackFunc: func(ctx context.Context, req ports.EraseDeliveryTrackRequest) (*domain.TrackSummary, int64, error) {
+			if req.Role != "admin" {
+				return nil, 0, domain.ErrUnauthorized
+			}
+			if req.UserID != 3 {
+				t.Errorf("expected the requesting user 3, got %d", req.UserID)
+			}
+			if req.DeliveryID == 2 {
+				return nil, 0, nil
+			}
+			return &domain.TrackSummary{
+				DeliveryID: req.DeliveryID,
+				Start:      domain.TrackPoint{Timestamp: start},
+				End:        domain.TrackPoint{Timestamp: start.Add(25 * time.Minute)},
+				PointCount: 40,
+				Duration:   25 * time.Minute,
+				Reason:     domain.PurgeReasonErasure,
+			}, 40, nil
+		},
+	}
+
+	handler := NewHTTPHandler(mockService)
+
+	tests := []struct {
+		name           string
+		id             string
+		claims         *authDomain.Claims
+		expectedStatus int
+		expectedPoints int64
+		expectSummary  bool
+	}{
+		{name: "admin", id: "1", claims: &authDomain.Claims{UserID: 3, Role: "admin"}, expectedStatus: http.StatusOK, expectedPoints: 40, expectSummary: true},
+		{name: "no points", id: "2", claims: &authDomain.Claims{UserID: 3, Role: "admin"}, expectedStatus: http.StatusOK},
+		{name: "customer", id: "1", claims: &authDomain.Claims{UserID: 3, Role: "customer", CustomerID: &[]int{1}[0]}, expectedStatus: http.StatusForbidden},
+		{name: "invalid ID", id: "abc", claims: &authDomain.Claims{UserID: 3, Role: "admin"}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withPathID(httptest.NewRequest("DELETE", "/deliveries/"+tt.id+"/track", nil), tt.id)
+			req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+
+			w := httptest.NewRecorder()
+			handler.EraseDeliveryTrack(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				PointsDeleted int64 `json:"points_deleted"`
+				Summary       *struct {
+					PointCount      int    `json:"point_count"`
+					Reason          string `json:"reason"`
+					DurationSeconds int64  `json:"duration_seconds"`
+				} `json:"summary"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.PointsDeleted != tt.expectedPoints {
+				t.Errorf("expected %d points deleted, got %d", tt.expectedPoints, response.PointsDeleted)
+			}
+			if (response.Summary != nil) != tt.expectSummary {
+				t.Fatalf("expected summary %v, got %+v", tt.expectSummary, response.Summary)
+			}
+			if tt.expectSummary && (response.Summary.DurationSeconds != 1500 || response.Summary.Reason != "erasure_request") {
+				t.Errorf("unexpected summary: %+v", response.Summary)
+			}
+		})
+	}
+}
+
 func TestHTTPHandler_CalculateETA(t *testing.T) {
 	mockService := &MockTrackingService{
 		calculateETAFunc: func(ctx context.Context, req ports.CalculateETAToDestinationRequest) (*ports.CalculateETAResponse, error) {
@@ -314,11 +1234,11 @@ func TestHTTPHandler_CalculateETA(t *testing.T) {
 	}
 
 	body, _ := json.Marshal(reqBody)
-	req := httptest.NewRequest("POST", "/deliveries/1/eta", bytes.NewReader(body))
+	req := withPathID(httptest.NewRequest("POST", "/deliveries/1/eta", bytes.NewReader(body)), "1")
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add auth context
-	ctx := context.WithValue(req.Context(), "role", "customer")
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "customer"})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -342,6 +1262,59 @@ func TestHTTPHandler_CalculateETA(t *testing.T) {
 	}
 }
 
+func TestHTTPHandler_DeliveryUnavailable(t *testing.T) {
+	unavailable := fmt.Errorf("%w: %w", domain.ErrDeliveryUnavailable, status.Error(codes.DeadlineExceeded, "context deadline exceeded"))
+	mockService := &MockTrackingService{
+		getCurrentLocationFunc: func(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, bool, error) {
+			return &domain.Location{DeliveryID: req.DeliveryID, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060,
+				Timestamp: time.Now(), DeliveryContext: domain.DeliveryContextUnavailable}, false, nil
+		},
+		calculateETAFunc: func(ctx context.Context, req ports.CalculateETAToDestinationRequest) (*ports.CalculateETAResponse, error) {
+			return nil, unavailable
+		},
+	}
+	handler := NewHTTPHandler(mockService)
+	claims := &authDomain.Claims{Role: "customer"}
+
+	// Current locations are still served, marked as lacking delivery context
+	req := withPathID(httptest.NewRequest("GET", "/deliveries/1/location", nil), "1")
+	req = req.WithContext(authctx.WithClaims(req.Context(), claims))
+	w := httptest.NewRecorder()
+	handler.GetCurrentLocation(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var location map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&location); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if location["delivery_context"] != "unavailable" {
+		t.Errorf("expected delivery_context unavailable, got %v", location["delivery_context"])
+	}
+
+	// ETAs are refused
+	body := bytes.NewReader([]byte(`{"dest_lat":40.7589,"dest_lng":-73.9851}`))
+	req = withPathID(httptest.NewRequest("POST", "/deliveries/1/eta", body), "1")
+	req = req.WithContext(authctx.WithClaims(req.Context(), claims))
+	w = httptest.NewRecorder()
+	handler.CalculateETA(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "" {
+		t.Errorf("expected no Retry-After without an open circuit, got %q", got)
+	}
+	var response unavailableResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Error != "delivery_unavailable" {
+		t.Errorf("expected error delivery_unavailable, got %+v", response)
+	}
+}
+
 func TestHTTPHandler_InvalidJSON(t *testing.T) {
 	mockService := &MockTrackingService{}
 	handler := NewHTTPHandler(mockService)
@@ -350,8 +1323,7 @@ func TestHTTPHandler_InvalidJSON(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add auth context
-	ctx := context.WithValue(req.Context(), "role", "courier")
-	ctx = context.WithValue(ctx, "courier_id", &[]int{1}[0])
+	ctx := authctx.WithClaims(req.Context(), &authDomain.Claims{Role: "courier", CourierID: &[]int{1}[0]})
 	req = req.WithContext(ctx)
 
 	w := httptest.NewRecorder()
@@ -0,0 +1,324 @@
+// Package memory provides an in-memory location repository for tests that
+// orders, windows and limits history the way the MongoDB adapter does.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+)
+
+// courierHistoryWindow is how far back GetByCourierID looks, as in MongoDB
+const courierHistoryWindow = 24 * time.Hour
+
+// LocationRepository implements ports.LocationRepository in memory.
+// Locations are copied on the way in and out.
+type LocationRepository struct {
+	mu        sync.Mutex
+	locations []*domain.Location // in insertion order
+	nextID    int
+	createErr error
+}
+
+// NewLocationRepository creates an empty in-memory location repository
+func NewLocationRepository() *LocationRepository {
+	return &LocationRepository{nextID: 1}
+}
+
+// SetCreateError makes Create fail with err; nil clears it
+func (r *LocationRepository) SetCreateError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.createErr = err
+}
+
+// Count returns how many locations are stored for a delivery
+func (r *LocationRepository) Count(deliveryID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.matching(func(l *domain.Location) bool { return l.DeliveryID == deliveryID }))
+}
+
+// Create stores a new location, assigning its ID. Like the MongoDB unique
+// index, a courier's second location with the same ClientPointID fails with
+// domain.ErrDuplicateLocation.
+func (r *LocationRepository) Create(ctx context.Context, location *domain.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.createErr != nil {
+		return r.createErr
+	}
+	if location.ClientPointID != "" && r.byClientPointID(location.CourierID, location.ClientPointID) != nil {
+		return domain.ErrDuplicateLocation
+	}
+	location.ID = r.nextID
+	r.nextID++
+	if location.CreatedAt.IsZero() {
+		location.CreatedAt = time.Now()
+	}
+	stored := cloneLocation(location)
+	stored.Address = "" // resolved on request, not persisted
+	stored.Duplicate = false
+	r.locations = append(r.locations, stored)
+	return nil
+}
+
+// GetByClientPointID retrieves the location a courier stored under a client point ID
+func (r *LocationRepository) GetByClientPointID(ctx context.Context, courierID int, clientPointID string) (*domain.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l := r.byClientPointID(courierID, clientPointID); l != nil {
+		return cloneLocation(l), nil
+	}
+	return nil, domain.ErrLocationNotFound
+}
+
+// byClientPointID finds a stored location by courier and client point ID;
+// the caller holds the lock
+func (r *LocationRepository) byClientPointID(courierID int, clientPointID string) *domain.Location {
+	for _, l := range r.locations {
+		if l.CourierID == courierID && l.ClientPointID == clientPointID {
+			return l
+		}
+	}
+	return nil
+}
+
+// GetByDeliveryID retrieves the most recent of a delivery's locations in the query window
+func (r *LocationRepository) GetByDeliveryID(ctx context.Context, deliveryID int, query ports.LocationQuery) ([]*domain.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	locations := r.matching(func(l *domain.Location) bool {
+		return l.DeliveryID == deliveryID && inWindow(l, query.From, query.To)
+	})
+	newestFirst(locations)
+	locations = limited(locations, query.Limit)
+	if query.OldestFirst {
+		oldestFirst(locations)
+	}
+	return locations, nil
+}
+
+// StreamByDeliveryID passes a delivery's locations in the window to fn oldest first
+func (r *LocationRepository) StreamByDeliveryID(ctx context.Context, deliveryID int, from, to *time.Time, fn func(*domain.Location) error) error {
+	r.mu.Lock()
+	locations := r.matching(func(l *domain.Location) bool {
+		return l.DeliveryID == deliveryID && inWindow(l, from, to)
+	})
+	r.mu.Unlock()
+
+	oldestFirst(locations)
+	for _, location := range locations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLatestByDeliveryID retrieves the latest location for a delivery
+func (r *LocationRepository) GetLatestByDeliveryID(ctx context.Context, deliveryID int) (*domain.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return latest(r.matching(func(l *domain.Location) bool { return l.DeliveryID == deliveryID }))
+}
+
+// GetTrackVersion counts a delivery's locations and finds the latest timestamp
+func (r *LocationRepository) GetTrackVersion(ctx context.Context, deliveryID int) (domain.TrackVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var version domain.TrackVersion
+	for _, l := range r.locations {
+		if l.DeliveryID != deliveryID {
+			continue
+		}
+		version.Points++
+		if l.Timestamp.After(version.LatestAt) {
+			version.LatestAt = l.Timestamp
+		}
+	}
+	return version, nil
+}
+
+// GetByCourierID retrieves up to limit of a courier's locations from the
+// last 24 hours, newest first; a limit of zero or less returns all of them
+func (r *LocationRepository) GetByCourierID(ctx context.Context, courierID int, limit int) ([]*domain.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	since := time.Now().Add(-courierHistoryWindow)
+	locations := r.matching(func(l *domain.Location) bool {
+		return l.CourierID == courierID && !l.Timestamp.Before(since)
+	})
+	newestFirst(locations)
+	return limited(locations, limit), nil
+}
+
+// StreamByCourierID passes a courier's locations in the window to fn oldest first
+func (r *LocationRepository) StreamByCourierID(ctx context.Context, courierID int, from, to time.Time, fn func(*domain.Location) error) error {
+	r.mu.Lock()
+	locations := r.matching(func(l *domain.Location) bool {
+		return l.CourierID == courierID && inWindow(l, &from, &to)
+	})
+	r.mu.Unlock()
+
+	oldestFirst(locations)
+	for _, location := range locations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLatestByCourierID retrieves the latest location for a courier
+func (r *LocationRepository) GetLatestByCourierID(ctx context.Context, courierID int) (*domain.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return latest(r.matching(func(l *domain.Location) bool { return l.CourierID == courierID }))
+}
+
+// GetLatestPerCourier retrieves the latest location of each courier matching
+// query, ordered by courier ID; a limit of zero or less returns all of them
+func (r *LocationRepository) GetLatestPerCourier(ctx context.Context, query ports.FleetQuery) ([]*domain.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var wanted map[int]bool
+	if query.CourierIDs != nil {
+		wanted = make(map[int]bool, len(query.CourierIDs))
+		for _, id := range query.CourierIDs {
+			wanted[id] = true
+		}
+	}
+
+	locations := r.matching(func(l *domain.Location) bool {
+		return !l.Timestamp.Before(query.Since) && (wanted == nil || wanted[l.CourierID]) &&
+			(query.OrgID == 0 || l.OrgID == query.OrgID)
+	})
+	newestFirst(locations)
+
+	seen := make(map[int]bool)
+	var latestPerCourier []*domain.Location
+	for _, l := range locations {
+		if seen[l.CourierID] {
+			continue
+		}
+		seen[l.CourierID] = true
+		if query.Box == nil || query.Box.Contains(l.Latitude, l.Longitude) {
+			latestPerCourier = append(latestPerCourier, l)
+		}
+	}
+	sort.Slice(latestPerCourier, func(i, j int) bool { return latestPerCourier[i].CourierID < latestPerCourier[j].CourierID })
+	return limited(latestPerCourier, query.Limit), nil
+}
+
+// DeleteByDeliveryID removes all of a delivery's locations and returns how many there were
+func (r *LocationRepository) DeleteByDeliveryID(ctx context.Context, deliveryID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.locations[:0]
+	var deleted int64
+	for _, l := range r.locations {
+		if l.DeliveryID == deliveryID {
+			deleted++
+			continue
+		}
+		kept = append(kept, l)
+	}
+	r.locations = kept
+	return deleted, nil
+}
+
+// matching returns copies of the stored locations keep accepts, in insertion
+// order; the caller holds the lock
+func (r *LocationRepository) matching(keep func(l *domain.Location) bool) []*domain.Location {
+	var locations []*domain.Location
+	for _, l := range r.locations {
+		if keep(l) {
+			locations = append(locations, cloneLocation(l))
+		}
+	}
+	return locations
+}
+
+// inWindow reports whether a location is at or after from and before to
+func inWindow(l *domain.Location, from, to *time.Time) bool {
+	return (from == nil || !l.Timestamp.Before(*from)) && (to == nil || l.Timestamp.Before(*to))
+}
+
+// latest returns the location with the latest timestamp, or domain.ErrLocationNotFound
+func latest(locations []*domain.Location) (*domain.Location, error) {
+	if len(locations) == 0 {
+		return nil, domain.ErrLocationNotFound
+	}
+	newestFirst(locations)
+	return locations[0], nil
+}
+
+// limited keeps the first limit locations; a limit of zero or less keeps them all
+func limited(locations []*domain.Location, limit int) []*domain.Location {
+	if limit > 0 && len(locations) > limit {
+		return locations[:limit]
+	}
+	return locations
+}
+
+// newestFirst sorts by timestamp descending, later inserts first on ties
+func newestFirst(locations []*domain.Location) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		if !locations[i].Timestamp.Equal(locations[j].Timestamp) {
+			return locations[i].Timestamp.After(locations[j].Timestamp)
+		}
+		return locations[i].ID > locations[j].ID
+	})
+}
+
+// oldestFirst sorts by timestamp ascending, earlier inserts first on ties
+func oldestFirst(locations []*domain.Location) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		if !locations[i].Timestamp.Equal(locations[j].Timestamp) {
+			return locations[i].Timestamp.Before(locations[j].Timestamp)
+		}
+		return locations[i].ID < locations[j].ID
+	})
+}
+
+// cloneLocation copies a location so neither copy sees the other's changes
+func cloneLocation(l *domain.Location) *domain.Location {
+	copied := *l
+	copied.Accuracy = cloneFloat(l.Accuracy)
+	copied.Speed = cloneFloat(l.Speed)
+	copied.Heading = cloneFloat(l.Heading)
+	copied.Altitude = cloneFloat(l.Altitude)
+	if l.RecordedAt != nil {
+		recordedAt := *l.RecordedAt
+		copied.RecordedAt = &recordedAt
+	}
+	return &copied
+}
+
+func cloneFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	copied := *f
+	return &copied
+}
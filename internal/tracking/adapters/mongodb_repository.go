@@ -2,11 +2,16 @@ package adapters
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 	"github.com/Keneke-Einar/delivertrack/pkg/mongodb"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // MongoDBLocationRepository implements LocationRepository using MongoDB
@@ -21,21 +26,19 @@ func NewMongoDBLocationRepository(mongoDB *mongodb.MongoDB) *MongoDBLocationRepo
 	}
 }
 
-// Create stores a new location
+// Create stores a new location; the unique client point index turns a
+// re-upload into domain.ErrDuplicateLocation
 func (r *MongoDBLocationRepository) Create(ctx context.Context, location *domain.Location) error {
 	courierLocation := &mongodb.CourierLocation{
-		CourierID:  int64(location.CourierID),
-		DeliveryID: int64(location.DeliveryID),
-		Location: mongodb.GeoJSON{
-			Type: "Point",
-			Coordinates: []interface{}{
-				location.Longitude, // MongoDB uses [longitude, latitude]
-				location.Latitude,
-			},
-		},
-		Timestamp: time.Now(),
-		CreatedAt: time.Now(),
+		CourierID:     int64(location.CourierID),
+		DeliveryID:    int64(location.DeliveryID),
+		OrgID:         int64(location.OrgID),
+		Timestamp:     location.Timestamp,
+		DistanceKm:    location.DistanceKm,
+		ClientPointID: location.ClientPointID,
+		RecordedAt:    location.RecordedAt,
 	}
+	courierLocation.SetPoint(location.Longitude, location.Latitude)
 
 	// Set optional fields if provided
 	if location.Accuracy != nil {
@@ -51,70 +54,73 @@ func (r *MongoDBLocationRepository) Create(ctx context.Context, location *domain
 		courierLocation.Altitude = *location.Altitude
 	}
 
-	return r.mongoDB.InsertCourierLocation(ctx, courierLocation)
+	err := r.mongoDB.InsertCourierLocation(ctx, courierLocation)
+	if errors.Is(err, mongodb.ErrDuplicateClientPoint) {
+		return domain.ErrDuplicateLocation
+	}
+	if err != nil {
+		return err
+	}
+	location.CreatedAt = courierLocation.CreatedAt
+	return nil
 }
 
-// GetByDeliveryID retrieves locations for a delivery
-func (r *MongoDBLocationRepository) GetByDeliveryID(ctx context.Context, deliveryID int, limit int) ([]*domain.Location, error) {
-	// Note: MongoDB stores courier locations, not delivery locations directly
-	// We need to get courier locations and filter by delivery context
-	// This is a simplified implementation - in practice, we'd need to join with delivery data
+// GetByClientPointID retrieves the location a courier stored under a client point ID
+func (r *MongoDBLocationRepository) GetByClientPointID(ctx context.Context, courierID int, clientPointID string) (*domain.Location, error) {
+	courierLocation, err := r.mongoDB.GetCourierLocationByClientPointID(ctx, int64(courierID), clientPointID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainLocation(courierLocation)
+}
 
-	// For now, we'll get recent courier locations (assuming deliveryID maps to courierID for simplicity)
-	// In a real implementation, we'd need to track which courier is assigned to which delivery
-	courierLocations, err := r.mongoDB.GetLocationHistoryByDeliveryID(ctx, int64(deliveryID), time.Now().Add(-24*time.Hour), int64(limit))
+// GetByDeliveryID retrieves the most recent of a delivery's locations in the query window
+func (r *MongoDBLocationRepository) GetByDeliveryID(ctx context.Context, deliveryID int, query ports.LocationQuery) ([]*domain.Location, error) {
+	courierLocations, err := r.mongoDB.GetLocationsByDeliveryID(ctx, int64(deliveryID), query.From, query.To, int64(query.Limit))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get location history for delivery: %w", err)
 	}
 
-	locations := make([]*domain.Location, len(courierLocations))
-	for i, cl := range courierLocations {
-		coords := cl.Location.Coordinates.([]interface{})
-		longitude := coords[0].(float64)
-		latitude := coords[1].(float64)
-
-		location := &domain.Location{
-			DeliveryID: deliveryID,
-			CourierID:  int(cl.CourierID),
-			Latitude:   latitude,
-			Longitude:  longitude,
-			Timestamp:  cl.Timestamp,
-			CreatedAt:  cl.CreatedAt,
-			Accuracy:   &cl.Accuracy,
-			Speed:      &cl.Speed,
-			Heading:    &cl.Heading,
-			Altitude:   &cl.Altitude,
-		}
-		locations[i] = location
+	// The store returns newest first so the limit keeps the latest points
+	if query.OldestFirst {
+		slices.Reverse(courierLocations)
 	}
 
-	return locations, nil
+	return toDomainLocations(courierLocations)
+}
+
+// StreamByDeliveryID passes a delivery's locations to fn oldest first
+func (r *MongoDBLocationRepository) StreamByDeliveryID(ctx context.Context, deliveryID int, from, to *time.Time, fn func(*domain.Location) error) error {
+	return r.mongoDB.StreamLocationsByDeliveryID(ctx, int64(deliveryID), from, to, func(cl *mongodb.CourierLocation) error {
+		location, err := toDomainLocation(cl)
+		if err != nil {
+			return err
+		}
+		return fn(location)
+	})
 }
 
 // GetLatestByDeliveryID retrieves the latest location for a delivery
 func (r *MongoDBLocationRepository) GetLatestByDeliveryID(ctx context.Context, deliveryID int) (*domain.Location, error) {
-	// Similar to GetByDeliveryID, simplified implementation
 	courierLocation, err := r.mongoDB.GetLatestLocationByDeliveryID(ctx, int64(deliveryID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest location for delivery: %w", err)
 	}
 
-	coords := courierLocation.Location.Coordinates.([]interface{})
-	longitude := coords[0].(float64)
-	latitude := coords[1].(float64)
+	return toDomainLocation(courierLocation)
+}
 
-	return &domain.Location{
-		DeliveryID: deliveryID,
-		CourierID:  int(courierLocation.CourierID),
-		Latitude:   latitude,
-		Longitude:  longitude,
-		Timestamp:  courierLocation.Timestamp,
-		CreatedAt:  courierLocation.CreatedAt,
-		Accuracy:   &courierLocation.Accuracy,
-		Speed:      &courierLocation.Speed,
-		Heading:    &courierLocation.Heading,
-		Altitude:   &courierLocation.Altitude,
-	}, nil
+// GetTrackVersion counts a delivery's locations and finds the latest timestamp
+func (r *MongoDBLocationRepository) GetTrackVersion(ctx context.Context, deliveryID int) (domain.TrackVersion, error) {
+	points, latestAt, err := r.mongoDB.GetDeliveryTrackVersion(ctx, int64(deliveryID))
+	if err != nil {
+		return domain.TrackVersion{}, fmt.Errorf("failed to get track version for delivery: %w", err)
+	}
+	return domain.TrackVersion{Points: points, LatestAt: latestAt}, nil
 }
 
 // GetByCourierID retrieves locations for a courier
@@ -124,49 +130,113 @@ func (r *MongoDBLocationRepository) GetByCourierID(ctx context.Context, courierI
 		return nil, fmt.Errorf("failed to get courier location history: %w", err)
 	}
 
-	locations := make([]*domain.Location, len(courierLocations))
-	for i, cl := range courierLocations {
-		coords := cl.Location.Coordinates.([]interface{})
-		longitude := coords[0].(float64)
-		latitude := coords[1].(float64)
-
-		location := &domain.Location{
-			CourierID:  courierID,
-			Latitude:   latitude,
-			Longitude:  longitude,
-			Timestamp:  cl.Timestamp,
-			CreatedAt:  cl.CreatedAt,
-			Accuracy:   &cl.Accuracy,
-			Speed:      &cl.Speed,
-			Heading:    &cl.Heading,
-			Altitude:   &cl.Altitude,
-		}
-		locations[i] = location
-	}
+	return toDomainLocations(courierLocations)
+}
 
-	return locations, nil
+// StreamByCourierID passes a courier's locations in the window to fn oldest first
+func (r *MongoDBLocationRepository) StreamByCourierID(ctx context.Context, courierID int, from, to time.Time, fn func(*domain.Location) error) error {
+	return r.mongoDB.StreamCourierLocations(ctx, int64(courierID), &from, &to, func(cl *mongodb.CourierLocation) error {
+		location, err := toDomainLocation(cl)
+		if err != nil {
+			return err
+		}
+		return fn(location)
+	})
 }
 
 // GetLatestByCourierID retrieves the latest location for a courier
 func (r *MongoDBLocationRepository) GetLatestByCourierID(ctx context.Context, courierID int) (*domain.Location, error) {
 	courierLocation, err := r.mongoDB.GetLatestCourierLocation(ctx, int64(courierID))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrLocationNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest courier location: %w", err)
 	}
 
-	coords := courierLocation.Location.Coordinates.([]interface{})
-	longitude := coords[0].(float64)
-	latitude := coords[1].(float64)
+	return toDomainLocation(courierLocation)
+}
+
+// GetLatestPerCourier retrieves each matching courier's latest location in one aggregation
+func (r *MongoDBLocationRepository) GetLatestPerCourier(ctx context.Context, query ports.FleetQuery) ([]*domain.Location, error) {
+	var courierIDs []int64
+	if query.CourierIDs != nil {
+		courierIDs = make([]int64, len(query.CourierIDs))
+		for i, id := range query.CourierIDs {
+			courierIDs[i] = int64(id)
+		}
+	}
+	var orgIDs []int64
+	if query.OrgID != 0 {
+		orgIDs = []int64{int64(query.OrgID)}
+		// Points stored before organizations belong to the default one
+		if query.OrgID == authDomain.DefaultOrgID {
+			orgIDs = append(orgIDs, 0)
+		}
+	}
+	var box *mongodb.BoundingBox
+	if query.Box != nil {
+		box = &mongodb.BoundingBox{
+			MinLongitude: query.Box.MinLongitude,
+			MinLatitude:  query.Box.MinLatitude,
+			MaxLongitude: query.Box.MaxLongitude,
+			MaxLatitude:  query.Box.MaxLatitude,
+		}
+	}
+
+	courierLocations, err := r.mongoDB.GetLatestCourierLocations(ctx, query.Since, courierIDs, orgIDs, box, int64(query.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest courier locations: %w", err)
+	}
+
+	return toDomainLocations(courierLocations)
+}
+
+// DeleteByDeliveryID removes all of a delivery's locations
+func (r *MongoDBLocationRepository) DeleteByDeliveryID(ctx context.Context, deliveryID int) (int64, error) {
+	return r.mongoDB.DeleteLocationsByDeliveryID(ctx, int64(deliveryID))
+}
+
+// toDomainLocations maps stored courier locations to domain locations
+func toDomainLocations(courierLocations []mongodb.CourierLocation) ([]*domain.Location, error) {
+	locations := make([]*domain.Location, len(courierLocations))
+	for i := range courierLocations {
+		location, err := toDomainLocation(&courierLocations[i])
+		if err != nil {
+			return nil, err
+		}
+		locations[i] = location
+	}
+	return locations, nil
+}
+
+// toDomainLocation maps a stored courier location to a domain location,
+// reading the position from its GeoJSON point
+func toDomainLocation(cl *mongodb.CourierLocation) (*domain.Location, error) {
+	latitude, longitude, err := cl.Point()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored location: %w", err)
+	}
+
+	orgID := int(cl.OrgID)
+	if orgID == 0 {
+		orgID = authDomain.DefaultOrgID
+	}
 
 	return &domain.Location{
-		CourierID:  int(courierLocation.CourierID),
-		Latitude:   latitude,
-		Longitude:  longitude,
-		Timestamp:  courierLocation.Timestamp,
-		CreatedAt:  courierLocation.CreatedAt,
-		Accuracy:   &courierLocation.Accuracy,
-		Speed:      &courierLocation.Speed,
-		Heading:    &courierLocation.Heading,
-		Altitude:   &courierLocation.Altitude,
+		DeliveryID:    int(cl.DeliveryID),
+		CourierID:     int(cl.CourierID),
+		OrgID:         orgID,
+		Latitude:      latitude,
+		Longitude:     longitude,
+		Timestamp:     cl.Timestamp,
+		CreatedAt:     cl.CreatedAt,
+		Accuracy:      &cl.Accuracy,
+		Speed:         &cl.Speed,
+		Heading:       &cl.Heading,
+		Altitude:      &cl.Altitude,
+		DistanceKm:    cl.DistanceKm,
+		ClientPointID: cl.ClientPointID,
+		RecordedAt:    cl.RecordedAt,
 	}, nil
-}
\ No newline at end of file
+}
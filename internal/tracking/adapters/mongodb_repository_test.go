@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
 	"github.com/Keneke-Einar/delivertrack/pkg/mongodb"
 )
 
@@ -18,7 +19,7 @@ func TestMongoDBLocationRepository_Integration(t *testing.T) {
 	}
 
 	// Connect to MongoDB
-	mongoClient, err := mongodb.New(mongoURL)
+	mongoClient, err := mongodb.New(mongoURL, mongodb.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
@@ -70,7 +71,7 @@ func TestMongoDBLocationRepository_Integration(t *testing.T) {
 	}
 
 	// Test GetByDeliveryID
-	locations, err := repo.GetByDeliveryID(ctx, 1, 10)
+	locations, err := repo.GetByDeliveryID(ctx, 1, ports.LocationQuery{Limit: 10})
 	if err != nil {
 		t.Fatalf("Failed to get locations by delivery ID: %v", err)
 	}
@@ -123,7 +124,7 @@ func TestMongoDBLocationRepository_Integration(t *testing.T) {
 	}
 
 	// Get all locations
-	allLocations, err := repo.GetByDeliveryID(ctx, 1, 10)
+	allLocations, err := repo.GetByDeliveryID(ctx, 1, ports.LocationQuery{Limit: 10})
 	if err != nil {
 		t.Fatalf("Failed to get all locations: %v", err)
 	}
@@ -133,7 +134,7 @@ func TestMongoDBLocationRepository_Integration(t *testing.T) {
 	}
 
 	// Test limit
-	limitedLocations, err := repo.GetByDeliveryID(ctx, 1, 1)
+	limitedLocations, err := repo.GetByDeliveryID(ctx, 1, ports.LocationQuery{Limit: 1})
 	if err != nil {
 		t.Fatalf("Failed to get limited locations: %v", err)
 	}
@@ -161,7 +162,7 @@ func TestMongoDBLocationRepository_GetByDeliveryID_Limit(t *testing.T) {
 	}
 
 	// Connect to MongoDB
-	mongoClient, err := mongodb.New(mongoURL)
+	mongoClient, err := mongodb.New(mongoURL, mongodb.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
@@ -186,7 +187,7 @@ func TestMongoDBLocationRepository_GetByDeliveryID_Limit(t *testing.T) {
 	}
 
 	// Test limit
-	limited, err := repo.GetByDeliveryID(ctx, 2, 3)
+	limited, err := repo.GetByDeliveryID(ctx, 2, ports.LocationQuery{Limit: 3})
 	if err != nil {
 		t.Fatalf("Failed to get limited locations: %v", err)
 	}
@@ -196,7 +197,7 @@ func TestMongoDBLocationRepository_GetByDeliveryID_Limit(t *testing.T) {
 	}
 
 	// Test unlimited (should return all)
-	all, err := repo.GetByDeliveryID(ctx, 2, 100)
+	all, err := repo.GetByDeliveryID(ctx, 2, ports.LocationQuery{Limit: 100})
 	if err != nil {
 		t.Fatalf("Failed to get all locations: %v", err)
 	}
@@ -204,4 +205,105 @@ func TestMongoDBLocationRepository_GetByDeliveryID_Limit(t *testing.T) {
 	if len(all) != 5 {
 		t.Errorf("Expected 5 locations without limit, got %d", len(all))
 	}
-}
\ No newline at end of file
+}
+func TestMongoDBLocationRepository_GetByDeliveryID_Window(t *testing.T) {
+	// Skip if no MongoDB URL is provided
+	mongoURL := os.Getenv("MONGO_URL")
+	if mongoURL == "" {
+		t.Skip("MONGO_URL not set, skipping integration test")
+	}
+
+	mongoClient, err := mongodb.New(mongoURL, mongodb.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer mongoClient.Close(context.Background())
+
+	repo := NewMongoDBLocationRepository(mongoClient)
+	ctx := context.Background()
+
+	// One point a minute for five minutes
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	for i := 0; i < 5; i++ {
+		location, err := domain.NewLocation(3, 1, 40.0+float64(i), -74.0)
+		if err != nil {
+			t.Fatalf("Failed to create location %d: %v", i, err)
+		}
+		location.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(ctx, location); err != nil {
+			t.Fatalf("Failed to create location %d: %v", i, err)
+		}
+	}
+
+	from := start.Add(time.Minute)
+	to := start.Add(4 * time.Minute)
+	window, err := repo.GetByDeliveryID(ctx, 3, ports.LocationQuery{From: &from, To: &to, Limit: 2, OldestFirst: true})
+	if err != nil {
+		t.Fatalf("Failed to get windowed locations: %v", err)
+	}
+
+	// Minutes 1-3 are in the window and the limit keeps the latest two, oldest first
+	if len(window) != 2 || window[0].Latitude != 42.0 || window[1].Latitude != 43.0 {
+		t.Errorf("Expected latitudes [42 43], got %v", window)
+	}
+}
+
+func TestMongoDBLocationRepository_DeleteByDeliveryID(t *testing.T) {
+	// Skip if no MongoDB URL is provided
+	mongoURL := os.Getenv("MONGO_URL")
+	if mongoURL == "" {
+		t.Skip("MONGO_URL not set, skipping integration test")
+	}
+
+	mongoClient, err := mongodb.New(mongoURL, mongodb.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer mongoClient.Close(context.Background())
+
+	repo := NewMongoDBLocationRepository(mongoClient)
+	summaries := NewMongoDBTrackSummaryRepository(mongoClient)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		location, err := domain.NewLocation(4, 1, 40.0+float64(i), -74.0)
+		if err != nil {
+			t.Fatalf("Failed to create location %d: %v", i, err)
+		}
+		if err := repo.Create(ctx, location); err != nil {
+			t.Fatalf("Failed to create location %d: %v", i, err)
+		}
+	}
+
+	summary := &domain.TrackSummary{DeliveryID: 4, CourierID: 1, PointCount: 3, Reason: domain.PurgeReasonErasure, PurgedAt: time.Now()}
+	if err := summaries.SaveSummary(ctx, summary); err != nil {
+		t.Fatalf("Failed to save summary: %v", err)
+	}
+	// Saving again replaces the first summary
+	summary.PointCount = 4
+	if err := summaries.SaveSummary(ctx, summary); err != nil {
+		t.Fatalf("Failed to replace summary: %v", err)
+	}
+	stored, err := mongoClient.GetTrackSummary(ctx, 4)
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err)
+	}
+	if stored.PointCount != 4 {
+		t.Errorf("Expected the replaced summary, got %d points", stored.PointCount)
+	}
+
+	deleted, err := repo.DeleteByDeliveryID(ctx, 4)
+	if err != nil {
+		t.Fatalf("Failed to delete locations: %v", err)
+	}
+	if deleted < 3 {
+		t.Errorf("Expected at least 3 deleted locations, got %d", deleted)
+	}
+	remaining, err := repo.GetByDeliveryID(ctx, 4, ports.LocationQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get locations: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("Expected no locations left, got %d", len(remaining))
+	}
+}
@@ -0,0 +1,72 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLocationCache implements LocationCache using Redis
+type RedisLocationCache struct {
+	redis *cache.Redis
+	ttl   time.Duration
+}
+
+// NewRedisLocationCache creates a cache whose entries expire after ttl
+func NewRedisLocationCache(client *cache.Redis, ttl time.Duration) *RedisLocationCache {
+	return &RedisLocationCache{
+		redis: client,
+		ttl:   ttl,
+	}
+}
+
+// latestLocationKey returns the key holding a delivery's latest location
+func latestLocationKey(deliveryID int) string {
+	return fmt.Sprintf("delivery:%d:latest", deliveryID)
+}
+
+// GetLatest returns the cached latest location for a delivery
+func (c *RedisLocationCache) GetLatest(ctx context.Context, deliveryID int) (*domain.Location, bool, error) {
+	data, err := c.redis.Get(ctx, latestLocationKey(deliveryID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cached location: %w", err)
+	}
+
+	var location domain.Location
+	if err := json.Unmarshal(data, &location); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached location: %w", err)
+	}
+
+	return &location, true, nil
+}
+
+// SetLatest caches a location as the latest for its delivery
+func (c *RedisLocationCache) SetLatest(ctx context.Context, location *domain.Location) error {
+	data, err := json.Marshal(location)
+	if err != nil {
+		return fmt.Errorf("failed to encode location: %w", err)
+	}
+
+	if err := c.redis.Set(ctx, latestLocationKey(location.DeliveryID), data, c.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to cache location: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteLatest drops a delivery's cached latest location
+func (c *RedisLocationCache) DeleteLatest(ctx context.Context, deliveryID int) error {
+	if err := c.redis.Del(ctx, latestLocationKey(deliveryID)).Err(); err != nil {
+		return fmt.Errorf("failed to delete cached location: %w", err)
+	}
+	return nil
+}
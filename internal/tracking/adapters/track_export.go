@@ -0,0 +1,166 @@
+package adapters
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+)
+
+// trackWriter streams a delivery track in an export format, one point at a time
+type trackWriter interface {
+	// WritePoint appends the next point; points arrive oldest first
+	WritePoint(location *domain.Location) error
+
+	// Close writes what follows the last point and flushes the output
+	Close() error
+}
+
+// geoJSONTrackWriter writes a FeatureCollection holding the route as a
+// LineString followed by start and end Point features. Coordinates are
+// written as they arrive; only the first and latest points are kept.
+type geoJSONTrackWriter struct {
+	w          *bufio.Writer
+	deliveryID int
+	first      *domain.Location
+	last       *domain.Location
+	count      int
+}
+
+func newGeoJSONTrackWriter(w io.Writer, deliveryID int) *geoJSONTrackWriter {
+	return &geoJSONTrackWriter{w: bufio.NewWriter(w), deliveryID: deliveryID}
+}
+
+func (g *geoJSONTrackWriter) WritePoint(location *domain.Location) error {
+	g.count++
+	switch g.count {
+	case 1:
+		// A LineString needs two positions, so hold the first until the second arrives
+		g.first = location
+	case 2:
+		fmt.Fprintf(g.w, `{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"delivery_id":%d},"geometry":{"type":"LineString","coordinates":[`, g.deliveryID)
+		g.writePosition(g.first)
+		g.w.WriteByte(',')
+		g.writePosition(location)
+	default:
+		g.w.WriteByte(',')
+		g.writePosition(location)
+	}
+	g.last = location
+	return nil
+}
+
+func (g *geoJSONTrackWriter) Close() error {
+	switch g.count {
+	case 0:
+		g.w.WriteString(`{"type":"FeatureCollection","features":[]}`)
+	case 1:
+		g.w.WriteString(`{"type":"FeatureCollection","features":[`)
+		if err := g.writePointFeature("start", g.first); err != nil {
+			return err
+		}
+		g.w.WriteByte(',')
+		if err := g.writePointFeature("end", g.last); err != nil {
+			return err
+		}
+		g.w.WriteString(`]}`)
+	default:
+		g.w.WriteString(`]}},`)
+		if err := g.writePointFeature("start", g.first); err != nil {
+			return err
+		}
+		g.w.WriteByte(',')
+		if err := g.writePointFeature("end", g.last); err != nil {
+			return err
+		}
+		g.w.WriteString(`]}`)
+	}
+	return g.w.Flush()
+}
+
+// writePosition writes a GeoJSON [longitude, latitude] position
+func (g *geoJSONTrackWriter) writePosition(location *domain.Location) {
+	g.w.WriteByte('[')
+	g.w.WriteString(strconv.FormatFloat(location.Longitude, 'f', -1, 64))
+	g.w.WriteByte(',')
+	g.w.WriteString(strconv.FormatFloat(location.Latitude, 'f', -1, 64))
+	g.w.WriteByte(']')
+}
+
+func (g *geoJSONTrackWriter) writePointFeature(role string, location *domain.Location) error {
+	properties := map[string]interface{}{
+		"delivery_id": g.deliveryID,
+		"point":       role,
+		"timestamp":   location.Timestamp.UTC().Format(time.RFC3339),
+	}
+	if location.Speed != nil {
+		properties["speed_kmh"] = *location.Speed
+	}
+
+	feature, err := json.Marshal(map[string]interface{}{
+		"type":       "Feature",
+		"properties": properties,
+		"geometry": map[string]interface{}{
+			"type":        "Point",
+			"coordinates": []float64{location.Longitude, location.Latitude},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = g.w.Write(feature)
+	return err
+}
+
+// gpxTrackWriter writes a GPX 1.1 document with a single track segment.
+// Speeds go into Garmin TrackPointExtension v2 elements, in m/s as that schema requires.
+type gpxTrackWriter struct {
+	w          *bufio.Writer
+	deliveryID int
+	started    bool
+}
+
+func newGPXTrackWriter(w io.Writer, deliveryID int) *gpxTrackWriter {
+	return &gpxTrackWriter{w: bufio.NewWriter(w), deliveryID: deliveryID}
+}
+
+func (g *gpxTrackWriter) start() {
+	g.started = true
+	g.w.WriteString(xml.Header)
+	g.w.WriteString(`<gpx version="1.1" creator="DeliverTrack" xmlns="http://www.topografix.com/GPX/1/1" ` +
+		`xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v2">` + "\n")
+	fmt.Fprintf(g.w, "<trk><name>Delivery %d</name><trkseg>\n", g.deliveryID)
+}
+
+func (g *gpxTrackWriter) WritePoint(location *domain.Location) error {
+	if !g.started {
+		g.start()
+	}
+
+	fmt.Fprintf(g.w, `<trkpt lat="%s" lon="%s">`,
+		strconv.FormatFloat(location.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(location.Longitude, 'f', -1, 64))
+	if location.Altitude != nil {
+		fmt.Fprintf(g.w, "<ele>%s</ele>", strconv.FormatFloat(*location.Altitude, 'f', -1, 64))
+	}
+	fmt.Fprintf(g.w, "<time>%s</time>", location.Timestamp.UTC().Format(time.RFC3339))
+	if location.Speed != nil {
+		fmt.Fprintf(g.w, "<extensions><gpxtpx:TrackPointExtension><gpxtpx:speed>%s</gpxtpx:speed></gpxtpx:TrackPointExtension></extensions>",
+			strconv.FormatFloat(*location.Speed/3.6, 'f', 2, 64))
+	}
+	g.w.WriteString("</trkpt>\n")
+	return nil
+}
+
+func (g *gpxTrackWriter) Close() error {
+	if !g.started {
+		g.start()
+	}
+	g.w.WriteString("</trkseg></trk>\n</gpx>\n")
+	return g.w.Flush()
+}
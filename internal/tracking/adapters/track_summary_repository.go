@@ -0,0 +1,37 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/mongodb"
+)
+
+// MongoDBTrackSummaryRepository implements TrackSummaryRepository using MongoDB
+type MongoDBTrackSummaryRepository struct {
+	mongoDB *mongodb.MongoDB
+}
+
+// NewMongoDBTrackSummaryRepository creates a new MongoDB track summary repository
+func NewMongoDBTrackSummaryRepository(mongoDB *mongodb.MongoDB) *MongoDBTrackSummaryRepository {
+	return &MongoDBTrackSummaryRepository{
+		mongoDB: mongoDB,
+	}
+}
+
+// SaveSummary stores a delivery's summary, replacing any earlier one
+func (r *MongoDBTrackSummaryRepository) SaveSummary(ctx context.Context, summary *domain.TrackSummary) error {
+	return r.mongoDB.UpsertTrackSummary(ctx, &mongodb.TrackSummary{
+		DeliveryID:      int64(summary.DeliveryID),
+		CourierID:       int64(summary.CourierID),
+		Start:           mongodb.NewPoint(summary.Start.Longitude, summary.Start.Latitude),
+		StartedAt:       summary.Start.Timestamp,
+		End:             mongodb.NewPoint(summary.End.Longitude, summary.End.Latitude),
+		EndedAt:         summary.End.Timestamp,
+		PointCount:      int64(summary.PointCount),
+		DistanceKm:      summary.DistanceKm,
+		DurationSeconds: summary.Duration.Seconds(),
+		Reason:          string(summary.Reason),
+		PurgedAt:        summary.PurgedAt,
+	})
+}
@@ -0,0 +1,34 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/mongodb"
+)
+
+// MongoDBZoneRepository implements ZoneRepository using MongoDB delivery zones
+type MongoDBZoneRepository struct {
+	mongoDB *mongodb.MongoDB
+}
+
+// NewMongoDBZoneRepository creates a new MongoDB zone repository
+func NewMongoDBZoneRepository(mongoDB *mongodb.MongoDB) *MongoDBZoneRepository {
+	return &MongoDBZoneRepository{
+		mongoDB: mongoDB,
+	}
+}
+
+// FindZonesContainingPoint returns the names of active zones containing the point
+func (r *MongoDBZoneRepository) FindZonesContainingPoint(ctx context.Context, latitude, longitude float64) ([]string, error) {
+	zones, err := r.mongoDB.FindZonesContainingPoint(ctx, longitude, latitude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find zones for point: %w", err)
+	}
+
+	names := make([]string, len(zones))
+	for i, zone := range zones {
+		names[i] = zone.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,308 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"go.uber.org/zap"
+)
+
+// Courier liveness settings
+const (
+	// courierHistoryLimit is how many recent points are read to date an active run
+	courierHistoryLimit = 100
+	// staleCheckTimeout bounds one scan of in-transit deliveries
+	staleCheckTimeout = 30 * time.Second
+)
+
+// SetCourierLiveness replaces the thresholds used to derive courier status
+func (s *TrackingService) SetCourierLiveness(liveness domain.CourierLiveness) {
+	s.liveness = liveness
+}
+
+// SetServiceToken sets where background jobs get the token they present to
+// the delivery service, typically one minted for the service role
+func (s *TrackingService) SetServiceToken(token func() (string, error)) {
+	s.serviceToken = token
+}
+
+// authorizeCourier checks that the caller may read a courier's locations,
+// returning domain.ErrUnauthorized if not: super-admins any courier, admins
+// those whose latest location was recorded in their organization, or who
+// have none yet, and couriers only themselves
+func (s *TrackingService) authorizeCourier(ctx context.Context, role string, userCourierID *int, courierID int) error {
+	switch role {
+	case "super_admin":
+		return nil
+	case "admin":
+		orgID, scoped := authctx.OrgScope(ctx)
+		if !scoped {
+			return nil
+		}
+		latest, err := s.repo.GetLatestByCourierID(ctx, courierID)
+		if errors.Is(err, domain.ErrLocationNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get latest courier location: %w", err)
+		}
+		if latest.OrgID != orgID {
+			return domain.ErrUnauthorized
+		}
+		return nil
+	case "courier":
+		if userCourierID != nil && *userCourierID == courierID {
+			return nil
+		}
+	}
+	return domain.ErrUnauthorized
+}
+
+// GetCourierStatus reports when a courier last sent a location and whether
+// they are active, stale or offline. Admins may read their organization's
+// couriers, couriers only themselves.
+func (s *TrackingService) GetCourierStatus(ctx context.Context, req ports.GetCourierStatusRequest) (*domain.CourierHeartbeat, error) {
+	if err := s.authorizeCourier(ctx, req.Role, req.UserCourierID, req.CourierID); err != nil {
+		return nil, err
+	}
+
+	locations, err := s.repo.GetByCourierID(ctx, req.CourierID, courierHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier locations: %w", err)
+	}
+
+	seen := make([]time.Time, 0, len(locations))
+	for _, loc := range locations {
+		seen = append(seen, loc.Timestamp)
+	}
+	sort.Slice(seen, func(i, j int) bool { return seen[i].After(seen[j]) })
+
+	// Recent history has a cutoff; older couriers are dated by their last point
+	if len(seen) == 0 {
+		latest, err := s.repo.GetLatestByCourierID(ctx, req.CourierID)
+		if err != nil && !errors.Is(err, domain.ErrLocationNotFound) {
+			return nil, fmt.Errorf("failed to get latest courier location: %w", err)
+		}
+		if latest != nil {
+			seen = append(seen, latest.Timestamp)
+		}
+	}
+
+	heartbeat := s.liveness.Heartbeat(req.CourierID, seen, time.Now())
+	return &heartbeat, nil
+}
+
+// GetCourierAverageSpeed averages the speeds a courier reported with their
+// recent points, ignoring points without one; 0 means unknown
+func (s *TrackingService) GetCourierAverageSpeed(ctx context.Context, req ports.GetCourierLocationRequest) (float64, error) {
+	locations, err := s.repo.GetByCourierID(ctx, req.CourierID, courierHistoryLimit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get courier locations: %w", err)
+	}
+
+	var total float64
+	var count int
+	for _, loc := range locations {
+		if loc.Speed != nil && *loc.Speed > 0 {
+			total += *loc.Speed
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return total / float64(count), nil
+}
+
+// StartStaleCourierChecker scans in-transit deliveries every interval for
+// couriers that stopped reporting, until Shutdown is called
+func (s *TrackingService) StartStaleCourierChecker(interval time.Duration) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.backgroundCtx.Done():
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(s.backgroundCtx, staleCheckTimeout)
+				s.checkStaleCouriers(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Shutdown stops background jobs and waits for them to finish
+func (s *TrackingService) Shutdown() {
+	s.stopBackground()
+	s.background.Wait()
+}
+
+// checkStaleCouriers alerts once per silence for every in-transit delivery
+// whose courier hasn't reported within the active window. Alerts are only
+// tracked by the checker goroutine.
+func (s *TrackingService) checkStaleCouriers(ctx context.Context) {
+	if s.serviceToken != nil {
+		token, err := s.serviceToken()
+		if err != nil {
+			s.logger.ErrorWithFields(ctx, "Failed to get service token for stale courier check", zap.Error(err))
+			return
+		}
+		ctx = authctx.WithAuthorization(ctx, "Bearer "+token)
+	}
+
+	var resp *delivery.ListDeliveriesResponse
+	err := s.deliveryCB.Call(ctx, func() error {
+		var err error
+		resp, err = s.deliveryClient.ListDeliveries(ctx, &delivery.ListDeliveriesRequest{
+			Status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT,
+		})
+		return err
+	})
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to list in-transit deliveries for stale courier check", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	inTransit := make(map[int]bool, len(resp.Deliveries))
+	for _, d := range resp.Deliveries {
+		deliveryID, err := strconv.Atoi(d.DeliveryId)
+		if err != nil {
+			continue
+		}
+		courierID, err := strconv.Atoi(d.DriverId)
+		if err != nil {
+			continue // unassigned
+		}
+		inTransit[deliveryID] = true
+
+		var lastSeen time.Time
+		latest, err := s.repo.GetLatestByCourierID(ctx, courierID)
+		if err != nil && !errors.Is(err, domain.ErrLocationNotFound) {
+			s.logger.WarnWithFields(ctx, "Failed to get latest courier location for stale courier check",
+				zap.Int("courier_id", courierID), zap.Error(err))
+			continue
+		}
+		if latest != nil {
+			lastSeen = latest.Timestamp
+		}
+
+		if s.liveness.IsActive(lastSeen, now) {
+			delete(s.staleAlerts, deliveryID)
+			continue
+		}
+		if alerted, ok := s.staleAlerts[deliveryID]; ok && alerted.Equal(lastSeen) {
+			continue
+		}
+		s.staleAlerts[deliveryID] = lastSeen
+
+		customerID, _ := strconv.Atoi(d.CustomerId)
+		s.alertStaleCourier(ctx, deliveryID, courierID, customerID, lastSeen)
+	}
+
+	for deliveryID := range s.staleAlerts {
+		if !inTransit[deliveryID] {
+			delete(s.staleAlerts, deliveryID)
+		}
+	}
+}
+
+// activeDeliveryStatuses are the delivery statuses during which a courier's
+// location is visible to customers and other couriers
+var activeDeliveryStatuses = []delivery.DeliveryStatus{
+	delivery.DeliveryStatus_DELIVERY_STATUS_ASSIGNED,
+	delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT,
+}
+
+// courierHasActiveDelivery reports whether a courier has at least one assigned
+// or in-transit delivery. The lookup runs as the service when a token source
+// is configured, so the answer doesn't depend on which deliveries the caller may see.
+func (s *TrackingService) courierHasActiveDelivery(ctx context.Context, courierID int) (bool, error) {
+	if s.serviceToken != nil {
+		token, err := s.serviceToken()
+		if err != nil {
+			return false, fmt.Errorf("failed to get service token: %w", err)
+		}
+		ctx = authctx.WithAuthorization(ctx, "Bearer "+token)
+	}
+
+	driverID := strconv.Itoa(courierID)
+	for _, st := range activeDeliveryStatuses {
+		var resp *delivery.ListDeliveriesResponse
+		err := s.deliveryCB.Call(ctx, func() error {
+			var err error
+			resp, err = s.deliveryClient.ListDeliveries(ctx, &delivery.ListDeliveriesRequest{
+				Status:   st,
+				DriverId: driverID,
+			})
+			return err
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to list courier deliveries: %w", err)
+		}
+		for _, d := range resp.Deliveries {
+			if d.DriverId == driverID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// alertStaleCourier publishes a courier.stale event and tells the customer
+// their delivery's courier has gone quiet
+func (s *TrackingService) alertStaleCourier(ctx context.Context, deliveryID, courierID, customerID int, lastSeen time.Time) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", deliveryID), zap.Int("courier_id", courierID))
+
+	var lastSeenAt *time.Time
+	if !lastSeen.IsZero() {
+		lastSeenAt = &lastSeen
+	}
+
+	s.logger.WarnWithFields(ctx, "Courier of in-transit delivery stopped reporting",
+		zap.Timep("last_seen_at", lastSeenAt))
+
+	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "tracking-service", "stale_courier_check")
+	event, err := messaging.NewCourierStaleEvent(messaging.CourierStaleEvent{
+		DeliveryID: deliveryID,
+		CourierID:  courierID,
+		CustomerID: customerID,
+		LastSeenAt: lastSeenAt,
+	}, traceCtx)
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to build courier stale event", zap.Error(err))
+	} else {
+		err = resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
+			return s.publisher.Publish(ctx, "tracking-events", messaging.EventTypeCourierStale, event)
+		})
+		if err != nil {
+			s.logger.ErrorWithFields(ctx, "Failed to publish courier stale event", zap.Error(err))
+		}
+	}
+
+	if s.wsHub != nil && customerID > 0 {
+		s.wsHub.BroadcastCustomerNotification(customerID, "courier_stale",
+			fmt.Sprintf("We haven't heard from the courier of delivery #%d for a while", deliveryID),
+			map[string]interface{}{
+				"delivery_id":  deliveryID,
+				"last_seen_at": lastSeenAt,
+			})
+	}
+}
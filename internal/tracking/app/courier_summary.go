@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+)
+
+// daySummaryCacheSize bounds how many finished days' summaries are kept
+const daySummaryCacheSize = 1024
+
+// daySummaryKey identifies one courier's day as seen from an organization,
+// 0 for callers who see every organization's deliveries
+type daySummaryKey struct {
+	courierID int
+	date      string
+	orgID     int
+}
+
+// daySummaryCache keeps the summaries of days that have ended, whose
+// deliveries and locations no longer change
+type daySummaryCache struct {
+	mu      sync.Mutex
+	entries map[daySummaryKey]domain.CourierDaySummary
+}
+
+func newDaySummaryCache() *daySummaryCache {
+	return &daySummaryCache{entries: make(map[daySummaryKey]domain.CourierDaySummary)}
+}
+
+func (c *daySummaryCache) get(key daySummaryKey) (domain.CourierDaySummary, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary, ok := c.entries[key]
+	return summary, ok
+}
+
+// put caches a summary, evicting an arbitrary one when full
+func (c *daySummaryCache) put(key daySummaryKey, summary domain.CourierDaySummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= daySummaryCacheSize {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = summary
+}
+
+// GetCourierDailySummary reports how many deliveries a courier completed on a
+// UTC day, how far they drove and how long they were active. Couriers may
+// read only their own days, admins those of their organization's couriers.
+// Days that have ended are computed once and then served from memory.
+func (s *TrackingService) GetCourierDailySummary(ctx context.Context, req ports.GetCourierDailySummaryRequest) (*domain.CourierDaySummary, error) {
+	if err := s.authorizeCourier(ctx, req.Role, req.UserCourierID, req.CourierID); err != nil {
+		return nil, err
+	}
+
+	dayStart := time.Date(req.Date.Year(), req.Date.Month(), req.Date.Day(), 0, 0, 0, 0, time.UTC)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	now := time.Now()
+	if dayStart.After(now) {
+		return nil, domain.ErrFutureSummaryDate
+	}
+
+	orgID, _ := authctx.OrgScope(ctx)
+	key := daySummaryKey{courierID: req.CourierID, date: dayStart.Format(time.DateOnly), orgID: orgID}
+	if summary, ok := s.daySummaries.get(key); ok {
+		return &summary, nil
+	}
+
+	var resp *delivery.GetDriverDeliveriesResponse
+	err := s.deliveryCB.Call(ctx, func() error {
+		var err error
+		resp, err = s.deliveryClient.GetDriverDeliveries(ctx, &delivery.GetDriverDeliveriesRequest{
+			DriverId: strconv.Itoa(req.CourierID),
+			Status:   delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED,
+			Date:     dayStart.Unix(),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier deliveries: %w", err)
+	}
+
+	var summarizer domain.CourierDaySummarizer
+	err = s.repo.StreamByCourierID(ctx, req.CourierID, dayStart, dayEnd, func(loc *domain.Location) error {
+		summarizer.Add(loc)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read courier locations: %w", err)
+	}
+
+	summary := summarizer.Summary(req.CourierID, dayStart, len(resp.Deliveries))
+	if !dayEnd.After(now) {
+		s.daySummaries.put(key, summary)
+	}
+	return &summary, nil
+}
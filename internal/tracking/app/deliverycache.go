@@ -0,0 +1,230 @@
+package app
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"go.uber.org/zap"
+)
+
+// DefaultDeliveryCacheSize is how many deliveries a delivery cache holds
+// before the least recently used one is evicted
+const DefaultDeliveryCacheSize = 10000
+
+// DeliveryCacheStats counts a delivery cache's lookups and evictions
+type DeliveryCacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`        // lookups of missing or expired entries
+	Invalidations int64 `json:"invalidations"` // entries evicted by delivery events
+	Entries       int   `json:"entries"`
+}
+
+// deliveryCache remembers who deliveries belong to, and their status, so
+// polling clients and recorded locations don't cost a delivery service call
+// each. get serves entries for ttl; lastKnown serves them however old, for
+// reads made while the delivery service is unavailable. Past maxEntries the
+// least recently used entry is evicted, and delivery events invalidate an
+// entry once they reach the instance after its courier or status changes.
+type deliveryCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[int]*list.Element // values are *deliveryCacheEntry
+	order      *list.List            // most recently used first
+	now        func() time.Time
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+}
+
+type deliveryCacheEntry struct {
+	deliveryID int
+	owner      deliveryOwner
+}
+
+// newDeliveryCache creates a cache that serves owners for ttl and holds at
+// most maxEntries, DefaultDeliveryCacheSize when zero
+func newDeliveryCache(ttl time.Duration, maxEntries int) *deliveryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultDeliveryCacheSize
+	}
+	return &deliveryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[int]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// get returns the cached owner of a delivery while it is fresh
+func (c *deliveryCache) get(deliveryID int) (deliveryOwner, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[deliveryID]
+	if !ok {
+		c.misses.Add(1)
+		return deliveryOwner{}, false
+	}
+	owner := elem.Value.(*deliveryCacheEntry).owner
+	if c.now().Sub(owner.fetchedAt) > c.ttl {
+		c.misses.Add(1)
+		return deliveryOwner{}, false
+	}
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	return owner, true
+}
+
+// lastKnown returns the cached owner of a delivery however old. Expired
+// entries are kept until they are replaced, invalidated or evicted.
+func (c *deliveryCache) lastKnown(deliveryID int) (deliveryOwner, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[deliveryID]
+	if !ok {
+		return deliveryOwner{}, false
+	}
+	return elem.Value.(*deliveryCacheEntry).owner, true
+}
+
+// put caches the owner of a delivery, evicting the least recently used
+// entry when the cache is full
+func (c *deliveryCache) put(deliveryID int, owner deliveryOwner) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	owner.fetchedAt = c.now()
+	if elem, ok := c.entries[deliveryID]; ok {
+		elem.Value.(*deliveryCacheEntry).owner = owner
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if len(c.entries) >= c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*deliveryCacheEntry).deliveryID)
+	}
+	c.entries[deliveryID] = c.order.PushFront(&deliveryCacheEntry{deliveryID: deliveryID, owner: owner})
+}
+
+// invalidate drops a delivery's entry, reporting whether there was one
+func (c *deliveryCache) invalidate(deliveryID int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[deliveryID]
+	if !ok {
+		return false
+	}
+	c.order.Remove(elem)
+	delete(c.entries, deliveryID)
+	c.invalidations.Add(1)
+	return true
+}
+
+// stats returns the cache's counters and size
+func (c *deliveryCache) stats() DeliveryCacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return DeliveryCacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+		Entries:       entries,
+	}
+}
+
+// deliveryEventsExchange is where the delivery service publishes delivery
+// events. Every tracking instance caches owners, so each binds its own queue.
+const deliveryEventsExchange = "delivery-events"
+
+// deliveryCacheEvents are the delivery events that change who a delivery
+// belongs to; assignments arrive as status changes to assigned
+var deliveryCacheEvents = []string{
+	messaging.EventTypeDeliveryStatusChanged,
+	messaging.EventTypeDeliveryConfirmed,
+	messaging.EventTypeDeliveryCancelled,
+}
+
+// SetDeliveryCache sizes the delivery owner cache: owners are reused for ttl,
+// deliveryOwnerTTL when zero, and at most maxEntries deliveries are cached
+func (s *TrackingService) SetDeliveryCache(ttl time.Duration, maxEntries int) {
+	if ttl <= 0 {
+		ttl = deliveryOwnerTTL
+	}
+	s.owners = newDeliveryCache(ttl, maxEntries)
+	s.statuses = newDeliveryCache(min(deliveryContextTTL, ttl), maxEntries)
+}
+
+// DeliveryCacheStats returns the counters of the owner cache used for
+// authorization and of the short-lived status cache used for recorded
+// locations
+func (s *TrackingService) DeliveryCacheStats() (owners, statuses DeliveryCacheStats) {
+	return s.owners.stats(), s.statuses.stats()
+}
+
+// StartDeliveryEventConsumption subscribes this instance to delivery events
+// so cached owners are invalidated when a delivery is assigned, changes
+// status or is cancelled, instead of serving stale authorization decisions
+// for their TTL. Events published while the subscription is down are
+// missed; the TTL still bounds how long those entries are served.
+func (s *TrackingService) StartDeliveryEventConsumption(subscriber messaging.Subscriber) error {
+	return subscriber.Subscribe(deliveryEventsExchange, deliveryCacheEvents, s.handleDeliveryEvent)
+}
+
+// handleDeliveryEvent invalidates the cached owner of the delivery an event
+// is about and has its open trackers authorized again in the background, so
+// a courier taken off it stops receiving its locations. Assignments arrive
+// as status changes to assigned.
+func (s *TrackingService) handleDeliveryEvent(event messaging.Event) error {
+	var deliveryID int
+	switch event.Type {
+	case messaging.EventTypeDeliveryStatusChanged:
+		data, err := messaging.DecodeData[messaging.DeliveryStatusChangedEvent](event)
+		if err != nil {
+			return err
+		}
+		deliveryID = data.DeliveryID
+	case messaging.EventTypeDeliveryConfirmed:
+		data, err := messaging.DecodeData[messaging.DeliveryConfirmedEvent](event)
+		if err != nil {
+			return err
+		}
+		deliveryID = data.DeliveryID
+	case messaging.EventTypeDeliveryCancelled:
+		data, err := messaging.DecodeData[messaging.DeliveryCancelledEvent](event)
+		if err != nil {
+			return err
+		}
+		deliveryID = data.DeliveryID
+	default:
+		// Other events don't change who a delivery belongs to
+		return nil
+	}
+
+	s.invalidateDelivery(deliveryID)
+	if s.wsHub != nil {
+		go s.wsHub.ReauthorizeDelivery(deliveryID)
+	}
+	ctx := messaging.ContextWithTraceContext(context.Background(), event.TraceContext)
+	s.logger.DebugWithFields(ctx, "Invalidated cached delivery owner",
+		zap.Int("delivery_id", deliveryID), zap.String("event_type", event.Type))
+	return nil
+}
+
+// invalidateDelivery drops a delivery from the owner and status caches
+func (s *TrackingService) invalidateDelivery(deliveryID int) {
+	s.owners.invalidate(deliveryID)
+	s.statuses.invalidate(deliveryID)
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/adapters/memory"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+)
+
+func TestDeliveryCache_TTL(t *testing.T) {
+	cache := newDeliveryCache(time.Minute, 10)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	if _, ok := cache.get(1); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	cache.put(1, deliveryOwner{customerID: "5", courierID: "7"})
+	if owner, ok := cache.get(1); !ok || owner.courierID != "7" {
+		t.Errorf("expected a hit for courier 7, got %+v, %v", owner, ok)
+	}
+
+	// Expired entries miss but are still known for degraded reads
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.get(1); ok {
+		t.Error("expected an expired entry to miss")
+	}
+	if owner, ok := cache.lastKnown(1); !ok || owner.courierID != "7" {
+		t.Errorf("expected the expired entry to be last known, got %+v, %v", owner, ok)
+	}
+
+	stats := cache.stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Entries != 1 {
+		t.Errorf("expected 1 hit, 2 misses and 1 entry, got %+v", stats)
+	}
+}
+
+func TestDeliveryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newDeliveryCache(time.Minute, 2)
+	cache.put(1, deliveryOwner{customerID: "1"})
+	cache.put(2, deliveryOwner{customerID: "2"})
+	cache.get(1) // 2 becomes the least recently used
+	cache.put(3, deliveryOwner{customerID: "3"})
+
+	if _, ok := cache.lastKnown(2); ok {
+		t.Error("expected delivery 2 to be evicted")
+	}
+	for _, id := range []int{1, 3} {
+		if _, ok := cache.lastKnown(id); !ok {
+			t.Errorf("expected delivery %d to be cached", id)
+		}
+	}
+	if stats := cache.stats(); stats.Entries != 2 {
+		t.Errorf("expected 2 entries, got %d", stats.Entries)
+	}
+}
+
+func TestDeliveryCache_Invalidate(t *testing.T) {
+	cache := newDeliveryCache(time.Minute, 10)
+	cache.put(1, deliveryOwner{customerID: "1"})
+
+	if !cache.invalidate(1) {
+		t.Error("expected the cached delivery to be invalidated")
+	}
+	if cache.invalidate(1) {
+		t.Error("expected nothing left to invalidate")
+	}
+	if _, ok := cache.lastKnown(1); ok {
+		t.Error("expected an invalidated entry to be gone, not just expired")
+	}
+	if stats := cache.stats(); stats.Invalidations != 1 || stats.Entries != 0 {
+		t.Errorf("expected 1 invalidation and no entries, got %+v", stats)
+	}
+}
+
+func TestTrackingService_DeliveryEventsInvalidateOwner(t *testing.T) {
+	courierID := 1
+	reassigned := 2
+	tests := []struct {
+		name  string
+		event func() (messaging.Event, error)
+	}{
+		{
+			name: "status changed",
+			event: func() (messaging.Event, error) {
+				return messaging.NewDeliveryStatusChangedEvent(messaging.DeliveryStatusChangedEvent{
+					DeliveryID: 1, CustomerID: 1, CourierID: &reassigned, OldStatus: "assigned", NewStatus: "assigned",
+				}, nil)
+			},
+		},
+		{
+			name: "cancelled",
+			event: func() (messaging.Event, error) {
+				return messaging.NewDeliveryCancelledEvent(messaging.DeliveryCancelledEvent{
+					DeliveryID: 1, CustomerID: 1, CourierID: &courierID, Reason: "customer request",
+				}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveryClient := ownerDeliveryClient("1", "1", nil)
+			service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+			courier := ports.AuthContext{Role: "courier", UserCourierID: &courierID}
+			if err := service.authorizeDelivery(context.Background(), 1, courier); err != nil {
+				t.Fatalf("expected the assigned courier to be allowed, got %v", err)
+			}
+
+			// Dispatch moves the delivery to another courier
+			deliveryClient.SetDelivery(&delivery.Delivery{CustomerId: "1", DriverId: "2", Status: delivery.DeliveryStatus_DELIVERY_STATUS_ASSIGNED})
+			event, err := tt.event()
+			if err != nil {
+				t.Fatalf("failed to build event: %v", err)
+			}
+			if err := service.handleDeliveryEvent(event); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The next check asks the delivery service again instead of waiting out the TTL
+			if err := service.authorizeDelivery(context.Background(), 1, courier); !errors.Is(err, domain.ErrUnauthorized) {
+				t.Errorf("expected the previous courier to be refused, got %v", err)
+			}
+			if calls := deliveryClient.Calls("GetDelivery"); calls != 2 {
+				t.Errorf("expected 2 delivery lookups, got %d", calls)
+			}
+			if owners, _ := service.DeliveryCacheStats(); owners.Invalidations != 1 {
+				t.Errorf("expected 1 invalidation, got %+v", owners)
+			}
+		})
+	}
+}
+
+func TestTrackingService_DeliveryEventsIgnoreOtherTypes(t *testing.T) {
+	deliveryClient := ownerDeliveryClient("1", "1", nil)
+	service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	service.owners.put(1, deliveryOwner{customerID: "1", courierID: "1"})
+
+	event, err := messaging.NewDeliveryCreatedEvent(messaging.DeliveryCreatedEvent{DeliveryID: 1, CustomerID: 1, PickupLocation: "A", DeliveryLocation: "B"}, nil)
+	if err != nil {
+		t.Fatalf("failed to build event: %v", err)
+	}
+	if err := service.handleDeliveryEvent(event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := service.owners.get(1); !ok {
+		t.Error("expected a created event to leave the cache alone")
+	}
+}
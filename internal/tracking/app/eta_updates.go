@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"github.com/Keneke-Einar/delivertrack/proto/common"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"go.uber.org/zap"
+)
+
+// ETA push settings
+const (
+	// etaUpdateTimeout bounds fetching the delivery and pushing its ETA for one point
+	etaUpdateTimeout = 10 * time.Second
+	// etaStateTTL is how long a delivery's last push is remembered without new points
+	etaStateTTL = time.Hour
+)
+
+// etaPush is the last ETA pushed for a delivery
+type etaPush struct {
+	eta      time.Duration
+	pushedAt time.Time
+}
+
+// etaThrottle remembers the last ETA pushed per delivery. Points for the same
+// delivery are recorded concurrently, so all state is guarded by mu.
+type etaThrottle struct {
+	mu      sync.Mutex
+	policy  domain.ETAUpdatePolicy
+	pushed  map[int]etaPush
+	sweptAt time.Time
+	now     func() time.Time
+}
+
+// newETAThrottle creates a throttle applying policy
+func newETAThrottle(policy domain.ETAUpdatePolicy) *etaThrottle {
+	return &etaThrottle{
+		policy: policy,
+		pushed: make(map[int]etaPush),
+		now:    time.Now,
+	}
+}
+
+// setPolicy replaces the policy applied to later pushes
+func (t *etaThrottle) setPolicy(policy domain.ETAUpdatePolicy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.policy = policy
+}
+
+// claim reports whether eta should be pushed for the delivery and, when it
+// should, records it as pushed so concurrent points don't push twice
+func (t *etaThrottle) claim(deliveryID int, eta time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	if last, ok := t.pushed[deliveryID]; ok && !t.policy.Due(last.eta, last.pushedAt, eta, now) {
+		return false
+	}
+	t.pushed[deliveryID] = etaPush{eta: eta, pushedAt: now}
+	return true
+}
+
+// forget drops a delivery's push state once it will see no further movement
+func (t *etaThrottle) forget(deliveryID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pushed, deliveryID)
+}
+
+// sweep drops deliveries that stopped reporting without reaching a terminal
+// status. It runs at most once per etaStateTTL; callers hold mu.
+func (t *etaThrottle) sweep(now time.Time) {
+	if now.Sub(t.sweptAt) < etaStateTTL {
+		return
+	}
+	t.sweptAt = now
+	for deliveryID, last := range t.pushed {
+		if now.Sub(last.pushedAt) >= etaStateTTL {
+			delete(t.pushed, deliveryID)
+		}
+	}
+}
+
+// SetETAUpdatePolicy replaces the throttle applied to ETA pushes
+func (s *TrackingService) SetETAUpdatePolicy(policy domain.ETAUpdatePolicy) {
+	s.etaUpdates.setPolicy(policy)
+}
+
+// hasCoordinates reports whether a delivery's drop-off was geocoded; the
+// delivery service leaves the coordinates of addresses it could not geocode
+// at zero
+func hasCoordinates(l *common.Location) bool {
+	return l != nil && (l.Latitude != 0 || l.Longitude != 0)
+}
+
+// pushETAUpdate recomputes the delivery's ETA from a newly recorded location
+// and, when the throttle allows, pushes it to the customer over the hub and
+// publishes a delivery.eta_updated event for the notification service
+func (s *TrackingService) pushETAUpdate(ctx context.Context, location *domain.Location, d *delivery.Delivery) {
+	if isTerminalDeliveryStatus(d.Status) {
+		s.etaUpdates.forget(location.DeliveryID)
+		return
+	}
+	customerID, err := strconv.Atoi(d.CustomerId)
+	if err != nil || !hasCoordinates(d.DeliveryLocation) {
+		return
+	}
+
+	distanceKm := geo.HaversineKm(
+		location.Latitude, location.Longitude,
+		d.DeliveryLocation.Latitude, d.DeliveryLocation.Longitude,
+	)
+	eta := domain.EstimateETA(distanceKm)
+	if !s.etaUpdates.claim(location.DeliveryID, eta) {
+		return
+	}
+	estimatedArrival := location.Timestamp.Add(eta).UTC()
+
+	s.logger.InfoWithFields(ctx, "Pushing delivery ETA update",
+		zap.Duration("eta", eta),
+		zap.Float64("distance_remaining_km", distanceKm))
+
+	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "tracking-service", "eta_update")
+	event, err := messaging.NewDeliveryETAUpdatedEvent(messaging.DeliveryETAUpdatedEvent{
+		DeliveryID:          location.DeliveryID,
+		CourierID:           location.CourierID,
+		CustomerID:          customerID,
+		ETASeconds:          int64(eta / time.Second),
+		EstimatedArrival:    estimatedArrival,
+		DistanceRemainingKm: distanceKm,
+	}, traceCtx)
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to build ETA update event", zap.Error(err))
+	} else {
+		err = resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
+			return s.publisher.Publish(ctx, "tracking-events", messaging.EventTypeDeliveryETAUpdated, event)
+		})
+		if err != nil {
+			s.logger.ErrorWithFields(ctx, "Failed to publish ETA update event", zap.Error(err))
+		}
+	}
+
+	if s.wsHub != nil {
+		s.wsHub.BroadcastCustomerNotification(customerID, "eta_update",
+			fmt.Sprintf("Your delivery #%d is about %d minutes away", location.DeliveryID, int(math.Ceil(eta.Minutes()))),
+			map[string]interface{}{
+				"delivery_id":           location.DeliveryID,
+				"eta_seconds":           int64(eta / time.Second),
+				"estimated_arrival":     estimatedArrival,
+				"distance_remaining_km": distanceKm,
+			})
+	}
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+)
+
+// Fleet map settings
+const (
+	// DefaultFleetMapLimit caps how many couriers one fleet map response holds
+	DefaultFleetMapLimit = 500
+	// fleetLocationWindow is how recently a courier must have reported to be on the map
+	fleetLocationWindow = time.Hour
+)
+
+// SetFleetMapLimit caps how many couriers a fleet map response holds
+func (s *TrackingService) SetFleetMapLimit(limit int) {
+	s.fleetMapLimit = limit
+}
+
+// GetFleetLocations returns the latest location of every courier who reported
+// within the last hour, with their liveness and assigned or in-transit
+// deliveries, for the dispatch map. With ActiveOnly only couriers that have
+// such a delivery are included. Admins see their organization's couriers,
+// super-admins every courier. The second result reports
+// whether couriers were left out to stay within the fleet map limit.
+func (s *TrackingService) GetFleetLocations(ctx context.Context, req ports.GetFleetLocationsRequest) ([]domain.FleetCourier, bool, error) {
+	if req.Role != "admin" && req.Role != "super_admin" {
+		return nil, false, domain.ErrUnauthorized
+	}
+
+	deliveries, err := s.activeDeliveriesByCourier(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	query := ports.FleetQuery{
+		Since: now.Add(-fleetLocationWindow),
+		Box:   req.Box,
+		Limit: s.fleetMapLimit + 1,
+	}
+	if orgID, scoped := authctx.OrgScope(ctx); scoped {
+		query.OrgID = orgID
+	}
+	if req.ActiveOnly {
+		query.CourierIDs = make([]int, 0, len(deliveries))
+		for courierID := range deliveries {
+			query.CourierIDs = append(query.CourierIDs, courierID)
+		}
+		if len(query.CourierIDs) == 0 {
+			return []domain.FleetCourier{}, false, nil
+		}
+	}
+
+	locations, err := s.repo.GetLatestPerCourier(ctx, query)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get courier locations: %w", err)
+	}
+
+	truncated := len(locations) > s.fleetMapLimit
+	if truncated {
+		locations = locations[:s.fleetMapLimit]
+	}
+
+	fleet := make([]domain.FleetCourier, 0, len(locations))
+	for _, loc := range locations {
+		fleet = append(fleet, domain.FleetCourier{
+			Location:   loc,
+			Status:     s.liveness.Heartbeat(loc.CourierID, []time.Time{loc.Timestamp}, now).Status,
+			Deliveries: deliveries[loc.CourierID],
+		})
+	}
+	return fleet, truncated, nil
+}
+
+// activeDeliveriesByCourier lists assigned and in-transit deliveries grouped
+// by courier, one delivery service call per status
+func (s *TrackingService) activeDeliveriesByCourier(ctx context.Context) (map[int][]domain.FleetDelivery, error) {
+	byCourier := make(map[int][]domain.FleetDelivery)
+	for _, st := range activeDeliveryStatuses {
+		var resp *delivery.ListDeliveriesResponse
+		err := s.deliveryCB.Call(ctx, func() error {
+			var err error
+			resp, err = s.deliveryClient.ListDeliveries(ctx, &delivery.ListDeliveriesRequest{Status: st})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list active deliveries: %w", err)
+		}
+
+		for _, d := range resp.Deliveries {
+			courierID, err := strconv.Atoi(d.DriverId)
+			if err != nil {
+				continue // unassigned
+			}
+			deliveryID, err := strconv.Atoi(d.DeliveryId)
+			if err != nil {
+				continue
+			}
+			byCourier[courierID] = append(byCourier[courierID], domain.FleetDelivery{
+				DeliveryID: deliveryID,
+				Status:     deliveryStatusName(d.Status),
+			})
+		}
+	}
+	return byCourier, nil
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// deliveryOwnerTTL is how long a delivery's customer and courier are reused
+// before asking the delivery service again. Delivery events invalidate them
+// sooner; a missed event delays a courier reassignment by at most this long.
+const deliveryOwnerTTL = 30 * time.Second
+
+// deliveryContextTTL is how long a delivery's status is reused for incoming
+// locations; status changes whose event was missed reach location events
+// after at most this long
+const deliveryContextTTL = 5 * time.Second
+
+// deliveryOwner is who a delivery belongs to, and its status, as reported by
+// the delivery service
+type deliveryOwner struct {
+	customerID string
+	courierID  string // empty while unassigned
+	status     delivery.DeliveryStatus
+	fetchedAt  time.Time
+}
+
+// ownerOf extracts the owner of a delivery
+func ownerOf(d *delivery.Delivery) deliveryOwner {
+	return deliveryOwner{customerID: d.CustomerId, courierID: d.DriverId, status: d.Status}
+}
+
+// allows lets admins read any delivery, customers their own and couriers the
+// deliveries assigned to them. Owners don't record the delivery's
+// organization, so org-admins may only be checked against an owner just
+// fetched with their authorization.
+func (o deliveryOwner) allows(auth ports.AuthContext) bool {
+	switch auth.Role {
+	case "admin", "super_admin":
+		return true
+	case "customer":
+		return auth.UserCustomerID != nil && o.customerID == strconv.Itoa(*auth.UserCustomerID)
+	case "courier":
+		return auth.UserCourierID != nil && o.courierID != "" && o.courierID == strconv.Itoa(*auth.UserCourierID)
+	}
+	return false
+}
+
+// deliveryContext returns a delivery's owner and status for a recorded
+// location. A courier reporting every few seconds costs one delivery service
+// call per deliveryContextTTL; the answer also refreshes the owner cache.
+func (s *TrackingService) deliveryContext(ctx context.Context, deliveryID int) (deliveryOwner, error) {
+	if owner, ok := s.statuses.get(deliveryID); ok {
+		return owner, nil
+	}
+
+	d, err := s.getDelivery(ctx, deliveryID)
+	if err != nil {
+		return deliveryOwner{}, err
+	}
+	owner := ownerOf(d)
+	s.statuses.put(deliveryID, owner)
+	s.owners.put(deliveryID, owner)
+	return owner, nil
+}
+
+// isHiddenDelivery reports whether a delivery lookup failed because the
+// delivery doesn't exist or the caller may not see it
+func isHiddenDelivery(err error) bool {
+	code := status.Code(err)
+	return code == codes.PermissionDenied || code == codes.NotFound
+}
+
+// authorizeDelivery checks that the caller may read a delivery's locations,
+// returning domain.ErrUnauthorized if not. The lookup runs with the caller's
+// authorization, so deliveries the delivery service hides from them are
+// refused as well.
+func (s *TrackingService) authorizeDelivery(ctx context.Context, deliveryID int, auth ports.AuthContext) error {
+	switch auth.Role {
+	case "super_admin":
+		return nil
+	case "admin":
+		// Admins may read their own organization's deliveries. Cached owners
+		// don't record the organization, so ask the delivery service, which
+		// hides other organizations' deliveries from them.
+		if _, err := s.getDelivery(ctx, deliveryID); err != nil {
+			if isHiddenDelivery(err) {
+				return domain.ErrUnauthorized
+			}
+			return err
+		}
+		return nil
+	}
+
+	owner, ok := s.owners.get(deliveryID)
+	if !ok {
+		d, err := s.getDelivery(ctx, deliveryID)
+		if err != nil {
+			if isHiddenDelivery(err) {
+				return domain.ErrUnauthorized
+			}
+			return err
+		}
+		owner = ownerOf(d)
+		s.owners.put(deliveryID, owner)
+	}
+
+	if !owner.allows(auth) {
+		return domain.ErrUnauthorized
+	}
+	return nil
+}
+
+// CanTrackDelivery reports whether the holder of claims may watch a delivery's
+// live locations; it is the WebSocket hub's authorizer
+func (s *TrackingService) CanTrackDelivery(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+	err := s.authorizeDelivery(ctx, deliveryID, ports.AuthContext{
+		Role:           claims.Role,
+		UserCustomerID: claims.CustomerID,
+		UserCourierID:  claims.CourierID,
+	})
+	if errors.Is(err, domain.ErrUnauthorized) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
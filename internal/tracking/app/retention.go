@@ -0,0 +1,218 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"go.uber.org/zap"
+)
+
+// retentionPurgeTimeout bounds one retention run over finished deliveries
+const retentionPurgeTimeout = 10 * time.Minute
+
+// errNoSummaryRepository is returned when a track would be purged without
+// anywhere to keep its summary
+var errNoSummaryRepository = errors.New("track summary repository not configured")
+
+// purgedDeliveryStatuses are the statuses whose tracks the retention job purges
+var purgedDeliveryStatuses = []delivery.DeliveryStatus{
+	delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED,
+	delivery.DeliveryStatus_DELIVERY_STATUS_CANCELLED,
+}
+
+// SetTrackSummaryRepository sets where summaries of purged tracks are kept;
+// tracks are only purged once it is set
+func (s *TrackingService) SetTrackSummaryRepository(repo ports.TrackSummaryRepository) {
+	s.summaryRepo = repo
+}
+
+// PurgedTracks returns how many delivery tracks were purged and how many
+// points they held, by the retention job and erasure requests together
+func (s *TrackingService) PurgedTracks() (deliveries, points int64) {
+	return s.purgedTracks.Load(), s.purgedPoints.Load()
+}
+
+// StartRetentionPurger purges the tracks of deliveries delivered or cancelled
+// more than window ago every interval, until Shutdown is called
+func (s *TrackingService) StartRetentionPurger(interval, window time.Duration) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.backgroundCtx.Done():
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(s.backgroundCtx, retentionPurgeTimeout)
+				s.purgeExpiredTracks(ctx, time.Now().Add(-window))
+				cancel()
+			}
+		}
+	}()
+}
+
+// purgeExpiredTracks summarizes and deletes the tracks of deliveries that
+// finished before cutoff. Deliveries already purged have no points left and
+// are skipped, so runs can overlap the same deliveries safely.
+func (s *TrackingService) purgeExpiredTracks(ctx context.Context, cutoff time.Time) {
+	if s.serviceToken != nil {
+		token, err := s.serviceToken()
+		if err != nil {
+			s.logger.ErrorWithFields(ctx, "Failed to get service token for retention purge", zap.Error(err))
+			return
+		}
+		ctx = authctx.WithAuthorization(ctx, "Bearer "+token)
+	}
+
+	var deliveries, points, failures int
+	for _, st := range purgedDeliveryStatuses {
+		var resp *delivery.ListDeliveriesResponse
+		err := s.deliveryCB.Call(ctx, func() error {
+			var err error
+			resp, err = s.deliveryClient.ListDeliveries(ctx, &delivery.ListDeliveriesRequest{Status: st})
+			return err
+		})
+		if err != nil {
+			s.logger.WarnWithFields(ctx, "Failed to list finished deliveries for retention purge",
+				zap.String("status", deliveryStatusName(st)), zap.Error(err))
+			continue
+		}
+
+		for _, d := range resp.Deliveries {
+			// Deliveries without an update time are kept rather than guessed at
+			if d.UpdatedAt == 0 || !time.Unix(d.UpdatedAt, 0).Before(cutoff) {
+				continue
+			}
+			deliveryID, err := strconv.Atoi(d.DeliveryId)
+			if err != nil {
+				continue
+			}
+
+			summary, deleted, err := s.purgeTrack(ctx, deliveryID, domain.PurgeReasonRetention)
+			if err != nil {
+				failures++
+				s.logger.WarnWithFields(ctx, "Failed to purge delivery track",
+					zap.Int("delivery_id", deliveryID), zap.Error(err))
+				continue
+			}
+			if summary != nil {
+				deliveries++
+				points += int(deleted)
+			}
+		}
+	}
+
+	s.logger.InfoWithFields(ctx, "Retention purge finished",
+		zap.Time("cutoff", cutoff),
+		zap.Int("deliveries_purged", deliveries),
+		zap.Int("points_purged", points),
+		zap.Int("failures", failures))
+}
+
+// EraseDeliveryTrack summarizes a delivery's track, deletes its raw points
+// and publishes a delivery.track_erased audit event. Only admins may erase,
+// and org-admins only their own organization's deliveries.
+func (s *TrackingService) EraseDeliveryTrack(ctx context.Context, req ports.EraseDeliveryTrackRequest) (*domain.TrackSummary, int64, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.DeliveryID))
+
+	if req.Role != "admin" && req.Role != "super_admin" {
+		return nil, 0, domain.ErrUnauthorized
+	}
+	// The delivery service hides other organizations' deliveries from the
+	// caller whose authorization it is asked with
+	if _, scoped := authctx.OrgScope(ctx); scoped {
+		if _, err := s.getDelivery(ctx, req.DeliveryID); err != nil {
+			if isHiddenDelivery(err) {
+				return nil, 0, domain.ErrUnauthorized
+			}
+			return nil, 0, err
+		}
+	}
+
+	summary, deleted, err := s.purgeTrack(ctx, req.DeliveryID, domain.PurgeReasonErasure)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.logger.InfoWithFields(ctx, "Delivery track erased on request",
+		zap.Int("requested_by", req.UserID), zap.Int64("points_deleted", deleted))
+
+	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "tracking-service", "erase_delivery_track")
+	event, err := messaging.NewTrackErasedEvent(messaging.TrackErasedEvent{
+		DeliveryID:    req.DeliveryID,
+		RequestedBy:   req.UserID,
+		PointsDeleted: deleted,
+		Reason:        string(domain.PurgeReasonErasure),
+	}, traceCtx)
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to build track erased event", zap.Error(err))
+		return summary, deleted, nil
+	}
+	err = resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
+		return s.publisher.Publish(ctx, "tracking-events", messaging.EventTypeTrackErased, event)
+	})
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to publish track erased event", zap.Error(err))
+	}
+
+	return summary, deleted, nil
+}
+
+// purgeTrack stores a summary of a delivery's track, then deletes its points
+// and cached latest location. A delivery without points returns a nil
+// summary. The summary is written first so a failed delete loses nothing and
+// the next attempt replaces it.
+func (s *TrackingService) purgeTrack(ctx context.Context, deliveryID int, reason domain.PurgeReason) (*domain.TrackSummary, int64, error) {
+	if s.summaryRepo == nil {
+		return nil, 0, errNoSummaryRepository
+	}
+
+	var summarizer domain.TrackSummarizer
+	err := s.repo.StreamByDeliveryID(ctx, deliveryID, nil, nil, func(loc *domain.Location) error {
+		summarizer.Add(loc)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read delivery track: %w", err)
+	}
+
+	summary, ok := summarizer.Summary()
+	if !ok {
+		return nil, 0, nil
+	}
+	summary.Reason = reason
+	summary.PurgedAt = time.Now()
+
+	if err := s.summaryRepo.SaveSummary(ctx, &summary); err != nil {
+		return nil, 0, fmt.Errorf("failed to save track summary: %w", err)
+	}
+
+	deleted, err := s.repo.DeleteByDeliveryID(ctx, deliveryID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to delete delivery track: %w", err)
+	}
+
+	if s.locationCache != nil {
+		if err := s.locationCache.DeleteLatest(ctx, deliveryID); err != nil {
+			s.logger.WarnWithFields(ctx, "Failed to drop cached location of purged track", zap.Error(err))
+		}
+	}
+
+	s.purgedTracks.Add(1)
+	s.purgedPoints.Add(deleted)
+	return &summary, deleted, nil
+}
@@ -2,13 +2,21 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"	
 	"strconv"	
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 	authPorts "github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
 	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
@@ -17,6 +25,22 @@ import (
 	"go.uber.org/zap"
 )
 
+// Address resolution limits for track responses
+const (
+	addressResolveWorkers = 4
+	addressResolveTimeout = 3 * time.Second
+)
+
+// defaultTrackWindow is how far back a delivery track goes when no start is given
+const defaultTrackWindow = 24 * time.Hour
+
+// trackStatusInterval is how often live tracking streams recheck the delivery status
+const trackStatusInterval = 30 * time.Second
+
+// DefaultDeliveryLookupTimeout bounds each delivery service lookup when no
+// timeout is configured
+const DefaultDeliveryLookupTimeout = 500 * time.Millisecond
+
 // TrackingService implements tracking use cases
 type TrackingService struct {
 	repo           ports.LocationRepository
@@ -24,23 +48,122 @@ type TrackingService struct {
 	publisher      messaging.Publisher
 	deliveryClient delivery.DeliveryServiceClient
 	deliveryCB     *resilience.CircuitBreaker
+	lookupTimeout  time.Duration // bound on each delivery service lookup
+	owners         *deliveryCache
+	statuses       *deliveryCache // owners with their status, kept briefly for recorded locations
+	geocodingSvc   geocoding.GeocodingService
+	zoneRepo       ports.ZoneRepository
+	locationCache  ports.LocationCache
+	subscriptions  *locationBroker
+	statusInterval time.Duration
+	zoneTracker    *zoneTracker
+	etaUpdates     *etaThrottle
+	jitterFilter   domain.JitterFilter
+	discarded      atomic.Int64
+	summaryRepo    ports.TrackSummaryRepository
+	purgedTracks   atomic.Int64
+	purgedPoints   atomic.Int64
+	liveness       domain.CourierLiveness
+	serviceToken   func() (string, error)
+	staleAlerts    map[int]time.Time // delivery ID to the last-seen time already alerted on
+	daySummaries   *daySummaryCache
+	fleetMapLimit  int
+	backgroundCtx  context.Context
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
 	logger         *logger.Logger
 }
 
 // NewTrackingService creates a new tracking service
-func NewTrackingService(repo ports.LocationRepository, publisher messaging.Publisher, deliveryClient delivery.DeliveryServiceClient, authService authPorts.AuthService, logger *logger.Logger) *TrackingService {
-	return &TrackingService{
+func NewTrackingService(repo ports.LocationRepository, publisher messaging.Publisher, deliveryClient delivery.DeliveryServiceClient, authService authPorts.AuthService, geocodingSvc geocoding.GeocodingService, logger *logger.Logger) *TrackingService {
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	s := &TrackingService{
 		repo:           repo,
 		wsHub:          websocket.NewHub(authService),
 		publisher:      publisher,
 		deliveryClient: deliveryClient,
 		deliveryCB:     resilience.NewCircuitBreaker("delivery", 3, 10*time.Second),
+		lookupTimeout:  DefaultDeliveryLookupTimeout,
+		owners:         newDeliveryCache(deliveryOwnerTTL, DefaultDeliveryCacheSize),
+		statuses:       newDeliveryCache(deliveryContextTTL, DefaultDeliveryCacheSize),
+		geocodingSvc:   geocodingSvc,
+		zoneTracker:    newZoneTracker(zoneCacheTTL),
+		etaUpdates:     newETAThrottle(domain.DefaultETAUpdatePolicy()),
+		subscriptions:  newLocationBroker(),
+		statusInterval: trackStatusInterval,
+		jitterFilter:   domain.DefaultJitterFilter(),
+		liveness:       domain.DefaultCourierLiveness(),
+		staleAlerts:    make(map[int]time.Time),
+		daySummaries:   newDaySummaryCache(),
+		fleetMapLimit:  DefaultFleetMapLimit,
+		backgroundCtx:  backgroundCtx,
+		stopBackground: stopBackground,
 		logger:         logger,
 	}
+	s.wsHub.SetAuthorizer(s.CanTrackDelivery)
+	s.deliveryCB.OnStateChange(s.logCircuitStateChange)
+	return s
+}
+
+// SetDeliveryCircuitBreaker replaces the breaker guarding delivery service calls
+func (s *TrackingService) SetDeliveryCircuitBreaker(cb *resilience.CircuitBreaker) {
+	cb.OnStateChange(s.logCircuitStateChange)
+	s.deliveryCB = cb
+}
+
+// SetDeliveryLookupTimeout gives each delivery service lookup at most
+// timeout, so a hanging delivery service can't hold up tracking requests
+func (s *TrackingService) SetDeliveryLookupTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultDeliveryLookupTimeout
+	}
+	s.lookupTimeout = timeout
+}
+
+// DeliveryCircuitState returns the state of the breaker guarding delivery service calls
+func (s *TrackingService) DeliveryCircuitState() resilience.CircuitBreakerState {
+	return s.deliveryCB.State()
+}
+
+// logCircuitStateChange logs a breaker transition; an opening circuit is
+// why delivery lookups, ETAs and authorization checks start failing
+func (s *TrackingService) logCircuitStateChange(name string, from, to resilience.CircuitBreakerState) {
+	fields := []zap.Field{zap.String("breaker", name), zap.Stringer("from", from), zap.Stringer("to", to)}
+	if to == resilience.StateOpen {
+		s.logger.WarnWithFields(context.Background(), "Circuit breaker opened", fields...)
+		return
+	}
+	s.logger.InfoWithFields(context.Background(), "Circuit breaker state changed", fields...)
+}
+
+// SetZoneRepository enables geofence entry/exit detection against delivery zones
+func (s *TrackingService) SetZoneRepository(repo ports.ZoneRepository) {
+	s.zoneRepo = repo
+}
+
+// SetLocationCache enables caching of each delivery's latest location
+func (s *TrackingService) SetLocationCache(cache ports.LocationCache) {
+	s.locationCache = cache
+}
+
+// SetJitterFilter replaces the thresholds used to discard noisy GPS points
+func (s *TrackingService) SetJitterFilter(filter domain.JitterFilter) {
+	s.jitterFilter = filter
+}
+
+// DiscardedLocations returns how many points were discarded as GPS jitter
+func (s *TrackingService) DiscardedLocations() int64 {
+	return s.discarded.Load()
 }
 
-// SetWebSocketHub sets the WebSocket hub for broadcasting location updates
+// SetWebSocketHub sets the WebSocket hub for broadcasting location updates,
+// lets it admit delivery trackers through CanTrackDelivery and answers its
+// resuming trackers with each delivery's latest location
 func (s *TrackingService) SetWebSocketHub(hub *websocket.Hub) {
+	if hub != nil {
+		hub.SetAuthorizer(s.CanTrackDelivery)
+		hub.SetLocationSnapshot(s.latestLocation)
+	}
 	s.wsHub = hub
 }
 
@@ -51,9 +174,9 @@ func (s *TrackingService) GetWebSocketHub() *websocket.Hub {
 
 // RecordLocation records a new location point
 func (s *TrackingService) RecordLocation(ctx context.Context, req ports.RecordLocationRequest) (*domain.Location, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.DeliveryID), zap.Int("courier_id", req.CourierID))
+
 	s.logger.InfoWithFields(ctx, "Recording location update",
-		zap.Int("delivery_id", req.DeliveryID),
-		zap.Int("courier_id", req.CourierID),
 		zap.Float64("latitude", req.Latitude),
 		zap.Float64("longitude", req.Longitude))
 
@@ -61,118 +184,536 @@ func (s *TrackingService) RecordLocation(ctx context.Context, req ports.RecordLo
 	location, err := domain.NewLocation(req.DeliveryID, req.CourierID, req.Latitude, req.Longitude)
 	if err != nil {
 		s.logger.ErrorWithFields(ctx, "Failed to create location domain entity",
-			zap.Int("delivery_id", req.DeliveryID),
 			zap.Error(err))
 		return nil, err
 	}
 
+	// Points belong to the organization of whoever recorded them
+	location.OrgID = authctx.Organization(ctx)
+
 	// Set optional fields
 	location.SetOptionalFields(req.Accuracy, req.Speed, req.Heading, req.Altitude)
+	if err := location.ValidateOptionalFields(); err != nil {
+		return nil, err
+	}
+	if err := location.SetClientPoint(req.ClientPointID, req.RecordedAt, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// A re-uploaded point gets its stored copy back, even once the delivery
+	// has closed, so the courier app can clear its buffer
+	if location.ClientPointID != "" {
+		existing, err := s.repo.GetByClientPointID(ctx, req.CourierID, location.ClientPointID)
+		if err == nil {
+			return s.duplicateLocation(ctx, existing), nil
+		}
+		if !errors.Is(err, domain.ErrLocationNotFound) {
+			// The unique index still catches the duplicate on insert
+			s.logger.WarnWithFields(ctx, "Failed to look up client point", zap.Error(err))
+		}
+	}
+
+	// Points are only taken from the delivery's assigned courier, and points for
+	// finished deliveries are refused so the courier app stops sending. If the
+	// delivery service can't be reached the point is refused as unavailable,
+	// as its courier can't be checked; the app uploads it again later under
+	// the same client point ID.
+	owner, err := s.deliveryContext(ctx, req.DeliveryID)
+	if err != nil {
+		if isHiddenDelivery(err) {
+			return nil, domain.ErrUnauthorized
+		}
+		s.logger.WarnWithFields(ctx, "Failed to check delivery status for location update", zap.Error(err))
+		return nil, fmt.Errorf("%w: %w", domain.ErrDeliveryUnavailable, err)
+	}
+	if !req.AdminOverride && owner.courierID != strconv.Itoa(req.CourierID) {
+		s.logger.WarnWithFields(ctx, "Rejected location from unassigned courier",
+			zap.String("assigned_courier_id", owner.courierID))
+		return nil, domain.ErrUnauthorized
+	}
+	if isTerminalDeliveryStatus(owner.status) {
+		return nil, fmt.Errorf("%w: delivery is %s", domain.ErrDeliveryClosed, deliveryStatusName(owner.status))
+	}
+
+	// Discard points implying an impossible jump from the last accepted point
+	last, err := s.repo.GetLatestByCourierID(ctx, req.CourierID)
+	if err != nil {
+		last = nil
+	}
+	if err := s.jitterFilter.Check(last, location); err != nil {
+		discarded := s.discarded.Add(1)
+		s.logger.WarnWithFields(ctx, "Discarded location update",
+			zap.Int64("discarded_total", discarded),
+			zap.Error(err))
+		return nil, err
+	}
+
+	// Without cached zone state, the courier's prior point is needed to detect transitions
+	var previous *domain.Location
+	if s.zoneRepo != nil && !s.zoneTracker.known(req.CourierID) {
+		previous = last
+	}
+
+	// Measure from the delivery's previous point, usually the courier's last one
+	trackPrevious := last
+	if trackPrevious == nil || trackPrevious.DeliveryID != req.DeliveryID {
+		if trackPrevious, err = s.repo.GetLatestByDeliveryID(ctx, req.DeliveryID); err != nil {
+			trackPrevious = nil
+		}
+	}
+	late := location.RecordedAt != nil && trackPrevious != nil && location.Timestamp.Before(trackPrevious.Timestamp)
+	var step float64
+	if late {
+		// Measuring back to an older position would count the distance twice
+		location.DistanceKm = trackPrevious.DistanceKm
+	} else {
+		step = location.ContinueTrack(trackPrevious)
+	}
 
 	// Persist to repository
 	if err := s.repo.Create(ctx, location); err != nil {
+		if errors.Is(err, domain.ErrDuplicateLocation) {
+			// Another upload of the point was stored since the lookup above
+			existing, getErr := s.repo.GetByClientPointID(ctx, req.CourierID, location.ClientPointID)
+			if getErr != nil {
+				return nil, fmt.Errorf("failed to read duplicate location: %w", getErr)
+			}
+			return s.duplicateLocation(ctx, existing), nil
+		}
 		return nil, fmt.Errorf("failed to record location: %w", err)
 	}
 
-	// Broadcast location update to WebSocket clients
-	if s.wsHub != nil {
-		go s.wsHub.BroadcastLocation(req.DeliveryID, location)
-	}
+	// Live consumers only follow the courier's current position; a point
+	// uploaded behind newer ones only fills in the stored track
+	if !late {
+		if s.locationCache != nil {
+			if err := s.locationCache.SetLatest(ctx, location); err != nil {
+				s.logger.WarnWithFields(ctx, "Failed to cache latest location",
+					zap.Error(err))
+			}
+		}
 
-	// Update delivery ETA asynchronously
-	go func() {
-		// Get delivery details to calculate ETA
-		deliveryReq := &delivery.GetDeliveryRequest{
-			DeliveryId: fmt.Sprintf("%d", req.DeliveryID),
+		// Detect geofence entry/exit asynchronously
+		if s.zoneRepo != nil {
+			go func() {
+				zoneCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), zoneCheckTimeout)
+				defer cancel()
+				s.checkZoneTransitions(zoneCtx, location, previous)
+			}()
 		}
-		deliveryResp, err := s.deliveryClient.GetDelivery(ctx, deliveryReq)
-		if err != nil {
-			fmt.Printf("Failed to get delivery for ETA calculation: %v\n", err)
-			return
+
+		// Push to live tracking streams
+		s.subscriptions.publish(location)
+
+		// Broadcast location update to WebSocket clients
+		if s.wsHub != nil {
+			// Never blocks; the hub counts and logs what it drops
+			s.wsHub.BroadcastLocation(req.DeliveryID, location)
 		}
 
-		// Send customer notification about location update
-		if s.wsHub != nil && deliveryResp.Delivery.CustomerId != "" {
-			customerID, err := strconv.Atoi(deliveryResp.Delivery.CustomerId)
-			if err == nil {
-				go s.wsHub.BroadcastCustomerNotification(customerID, "location_update", 
-					fmt.Sprintf("Your delivery #%d location has been updated", req.DeliveryID),
-					map[string]interface{}{
-						"delivery_id": req.DeliveryID,
-						"latitude":    location.Latitude,
-						"longitude":   location.Longitude,
-					})
+		// Notify the customer and push a throttled ETA update asynchronously
+		go func() {
+			etaCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), etaUpdateTimeout)
+			defer cancel()
+
+			d, err := s.getDelivery(etaCtx, req.DeliveryID)
+			if err != nil {
+				s.logger.WarnWithFields(etaCtx, "Failed to get delivery for ETA calculation", zap.Error(err))
+				return
 			}
-		}
 
-		// Calculate ETA to delivery location
-		destLat := deliveryResp.Delivery.DeliveryLocation.Latitude
-		destLng := deliveryResp.Delivery.DeliveryLocation.Longitude
-		
-		// Simple ETA calculation (can be improved)
-		distanceKm := calculateHaversineDistance(
-			location.Latitude, location.Longitude,
-			destLat, destLng,
-		)
-		averageSpeed := 25.0 // km/h
-		etaHours := distanceKm / averageSpeed
-		etaSeconds := int64(etaHours * 3600)
-		// etaTimestamp := time.Now().Unix() + etaSeconds
-
-		// For now, just log the ETA. In a real implementation, you might update the delivery record
-		fmt.Printf("Calculated ETA for delivery %d: %d seconds (%f km at %f km/h)\n", 
-			req.DeliveryID, etaSeconds, distanceKm, averageSpeed)
-	}()
+			// Send customer notification about location update
+			if s.wsHub != nil && d.CustomerId != "" {
+				customerID, err := strconv.Atoi(d.CustomerId)
+				if err == nil {
+					s.wsHub.BroadcastCustomerNotification(customerID, "location_update", 
+						fmt.Sprintf("Your delivery #%d location has been updated", req.DeliveryID),
+						map[string]interface{}{
+							"delivery_id": req.DeliveryID,
+							"latitude":    location.Latitude,
+							"longitude":   location.Longitude,
+						})
+				}
+			}
+
+			s.pushETAUpdate(etaCtx, location, d)
+		}()
+	}
 
 	// Send location update notification asynchronously via event publishing
 	go func() {
 		traceCtx := messaging.ExtractTraceContextFromContext(ctx, "tracking-service", "record_location")
-		event := messaging.NewEventWithTrace("location.updated", "tracking-service", "record_location", map[string]interface{}{
-			"delivery_id": fmt.Sprintf("%d", req.DeliveryID),
-			"courier_id":  fmt.Sprintf("%d", req.CourierID),
-			"latitude":    location.Latitude,
-			"longitude":   location.Longitude,
-			"accuracy":    location.Accuracy,
-			"speed":       location.Speed,
-			"heading":     location.Heading,
-			"altitude":    location.Altitude,
-		}, traceCtx)
+		data := messaging.LocationRecordedEvent{
+			DeliveryID:             req.DeliveryID,
+			CourierID:              req.CourierID,
+			Latitude:               location.Latitude,
+			Longitude:              location.Longitude,
+			Accuracy:               location.Accuracy,
+			Speed:                  location.Speed,
+			Heading:                location.Heading,
+			Altitude:               location.Altitude,
+			DistanceFromPreviousKm: step,
+			CumulativeDistanceKm:   location.DistanceKm,
+		}
+		if owner.status != delivery.DeliveryStatus_DELIVERY_STATUS_UNSPECIFIED {
+			data.DeliveryStatus = deliveryStatusName(owner.status)
+		}
+		data.CustomerID, _ = strconv.Atoi(owner.customerID)
+		if courierID, err := strconv.Atoi(owner.courierID); err == nil {
+			data.AssignedCourierID = &courierID
+		}
+		event, err := messaging.NewLocationRecordedEvent(data, traceCtx)
+		if err != nil {
+			s.logger.ErrorWithFields(ctx, "Failed to build location update event", zap.Error(err))
+			return
+		}
 
 		// Publish event asynchronously with retry
-		err := resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
-			return s.publisher.Publish(ctx, "tracking-events", "location.updated", event)
+		err = resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
+			return s.publisher.Publish(ctx, "tracking-events", messaging.EventTypeLocationUpdated, event)
 		})
 		if err != nil {
-			fmt.Printf("Failed to publish location update event: %v\n", err)
+			s.logger.ErrorWithFields(ctx, "Failed to publish location update event", zap.Error(err))
 		}
 	}()
 
 	return location, nil
 }
 
+// duplicateLocation marks the stored copy of a re-uploaded point; nothing
+// is broadcast or published for it again
+func (s *TrackingService) duplicateLocation(ctx context.Context, existing *domain.Location) *domain.Location {
+	s.logger.InfoWithFields(ctx, "Ignored duplicate location upload",
+		zap.String("client_point_id", existing.ClientPointID))
+	existing.Duplicate = true
+	return existing
+}
+
 // GetDeliveryTrack retrieves the tracking history for a delivery
-func (s *TrackingService) GetDeliveryTrack(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, error) {
-	limit := req.Limit
-	if limit <= 0 {
-		limit = 100 // default limit
+func (s *TrackingService) GetDeliveryTrack(ctx context.Context, req ports.GetDeliveryTrackRequest) ([]*domain.Location, int, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.DeliveryID))
+
+	if err := s.authorizeDelivery(ctx, req.DeliveryID, req.AuthContext); err != nil {
+		return nil, 0, err
+	}
+
+	if req.From != nil && req.To != nil && req.To.Before(*req.From) {
+		return nil, 0, domain.ErrInvalidTimeRange
+	}
+
+	query := ports.LocationQuery{From: req.From, To: req.To, Limit: req.Limit, OldestFirst: req.OldestFirst}
+	if query.Limit <= 0 {
+		query.Limit = 100 // default limit
+	}
+	if query.From == nil {
+		end := time.Now()
+		if query.To != nil {
+			end = *query.To
+		}
+		from := end.Add(-defaultTrackWindow)
+		query.From = &from
+	}
+
+	locations, err := s.repo.GetByDeliveryID(ctx, req.DeliveryID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Simplify first so only the points returned are reverse geocoded
+	fetched := len(locations)
+	locations = domain.SimplifyTrack(locations, req.SimplifyMeters)
+
+	if req.ResolveAddresses {
+		s.resolveAddresses(ctx, locations, req.AddressEvery)
+	}
+
+	return locations, fetched, nil
+}
+
+// GetTrackVersion returns the state of a delivery's track for conditional reads
+func (s *TrackingService) GetTrackVersion(ctx context.Context, req ports.GetTrackVersionRequest) (domain.TrackVersion, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.DeliveryID))
+
+	if err := s.authorizeDelivery(ctx, req.DeliveryID, req.AuthContext); err != nil {
+		return domain.TrackVersion{}, err
+	}
+
+	return s.repo.GetTrackVersion(ctx, req.DeliveryID)
+}
+
+// ExportDeliveryTrack streams a delivery's locations in the time window to emit, oldest first
+func (s *TrackingService) ExportDeliveryTrack(ctx context.Context, req ports.ExportDeliveryTrackRequest, emit func(*domain.Location) error) error {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.DeliveryID))
+
+	if err := s.authorizeDelivery(ctx, req.DeliveryID, req.AuthContext); err != nil {
+		return err
+	}
+
+	return s.repo.StreamByDeliveryID(ctx, req.DeliveryID, req.From, req.To, emit)
+}
+
+// resolveAddresses reverse geocodes the most recent location and, when every > 0,
+// every Nth location. Lookups run on a bounded worker pool; failed or slow lookups
+// leave the address empty rather than failing the request.
+func (s *TrackingService) resolveAddresses(ctx context.Context, locations []*domain.Location, every int) {
+	if s.geocodingSvc == nil || len(locations) == 0 {
+		return
+	}
+
+	// Repositories differ in ordering, so pick the latest point by timestamp
+	latest := 0
+	for i, loc := range locations {
+		if loc.Timestamp.After(locations[latest].Timestamp) {
+			latest = i
+		}
+	}
+
+	targets := make(chan *domain.Location, len(locations))
+	for i, loc := range locations {
+		if i == latest || (every > 0 && i%every == 0) {
+			targets <- loc
+		}
+	}
+	close(targets)
+
+	workers := addressResolveWorkers
+	if len(targets) < workers {
+		workers = len(targets)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for loc := range targets {
+				lookupCtx, cancel := context.WithTimeout(ctx, addressResolveTimeout)
+				result, err := s.geocodingSvc.ReverseGeocode(lookupCtx, loc.Latitude, loc.Longitude)
+				cancel()
+				if err != nil {
+					s.logger.WarnWithFields(ctx, "Failed to resolve location address",
+						zap.Float64("latitude", loc.Latitude),
+						zap.Float64("longitude", loc.Longitude),
+						zap.Error(err))
+					continue
+				}
+				loc.Address = result.Address
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// GetCurrentLocation retrieves the current location for a delivery, reporting
+// whether it was served from the cache. Cache failures fall back to the repository.
+// While the delivery service is unavailable the location is still served,
+// marked with domain.DeliveryContextUnavailable, to callers the delivery was
+// last known to belong to, other than org-admins, and to the courier who
+// reported it.
+func (s *TrackingService) GetCurrentLocation(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, bool, error) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.DeliveryID))
+
+	err := s.authorizeDelivery(ctx, req.DeliveryID, req.AuthContext)
+	if err == nil {
+		return s.currentLocation(ctx, req.DeliveryID)
+	}
+	if !errors.Is(err, domain.ErrDeliveryUnavailable) {
+		return nil, false, err
+	}
+
+	s.logger.WarnWithFields(ctx, "Serving current location without the delivery service", zap.Error(err))
+	location, cached, locErr := s.currentLocation(ctx, req.DeliveryID)
+	if locErr != nil {
+		return nil, false, locErr
+	}
+	owner, known := s.owners.lastKnown(req.DeliveryID)
+	reporter := req.Role == "courier" && req.UserCourierID != nil && *req.UserCourierID == location.CourierID
+	if !reporter && (!known || req.Role == "admin" || !owner.allows(req.AuthContext)) {
+		return nil, false, err
+	}
+
+	degraded := *location
+	degraded.DeliveryContext = domain.DeliveryContextUnavailable
+	return &degraded, cached, nil
+}
+
+// latestLocation returns a delivery's latest location for the WebSocket
+// hub, or nil when none has been reported. The hub only asks about
+// deliveries its trackers were authorized for.
+func (s *TrackingService) latestLocation(ctx context.Context, deliveryID int) (*domain.Location, error) {
+	location, _, err := s.currentLocation(ctx, deliveryID)
+	if errors.Is(err, domain.ErrLocationNotFound) {
+		return nil, nil
+	}
+	return location, err
+}
+
+// currentLocation reads a delivery's latest location through the location cache
+func (s *TrackingService) currentLocation(ctx context.Context, deliveryID int) (*domain.Location, bool, error) {
+	if s.locationCache != nil {
+		location, found, err := s.locationCache.GetLatest(ctx, deliveryID)
+		if err != nil {
+			s.logger.WarnWithFields(ctx, "Failed to read cached location",
+				zap.Error(err))
+		} else if found {
+			return location, true, nil
+		}
+	}
+
+	location, err := s.repo.GetLatestByDeliveryID(ctx, deliveryID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if s.locationCache != nil {
+		if err := s.locationCache.SetLatest(ctx, location); err != nil {
+			s.logger.WarnWithFields(ctx, "Failed to cache latest location",
+				zap.Error(err))
+		}
+	}
+
+	return location, false, nil
+}
+
+// TrackDelivery streams a delivery's locations to send: the last known point,
+// then each newly recorded one. It returns when ctx ends, send fails or the
+// delivery reaches a terminal status.
+func (s *TrackingService) TrackDelivery(ctx context.Context, req ports.TrackDeliveryRequest, send func(*domain.Location) error) error {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", req.DeliveryID))
+
+	d, err := s.getDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		return err
+	}
+	s.owners.put(req.DeliveryID, ownerOf(d))
+	if !ownerOf(d).allows(req.AuthContext) {
+		return domain.ErrUnauthorized
+	}
+
+	// Subscribe before reading the last point so no update is missed in between
+	sub := s.subscriptions.subscribe(req.DeliveryID)
+	defer s.subscriptions.unsubscribe(sub)
+
+	var lastSent time.Time
+	latest, _, err := s.currentLocation(ctx, req.DeliveryID)
+	if err == nil {
+		if err := send(latest); err != nil {
+			return err
+		}
+		lastSent = latest.Timestamp
 	}
 
-	return s.repo.GetByDeliveryID(ctx, req.DeliveryID, limit)
+	if isTerminalDeliveryStatus(d.Status) {
+		return nil
+	}
+
+	ticker := time.NewTicker(s.statusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case location := <-sub.updates:
+			if !location.Timestamp.After(lastSent) {
+				continue
+			}
+			if err := send(location); err != nil {
+				return err
+			}
+			lastSent = location.Timestamp
+		case <-ticker.C:
+			d, err := s.getDelivery(ctx, req.DeliveryID)
+			if err != nil {
+				s.logger.WarnWithFields(ctx, "Failed to check delivery status for live tracking",
+					zap.Error(err))
+				continue
+			}
+			if isTerminalDeliveryStatus(d.Status) {
+				return nil
+			}
+		}
+	}
+}
+
+// getDelivery fetches a delivery from the delivery service, giving up after
+// the lookup timeout. Failures other than the delivery being missing or
+// hidden from the caller are domain.ErrDeliveryUnavailable.
+func (s *TrackingService) getDelivery(ctx context.Context, deliveryID int) (*delivery.Delivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.lookupTimeout)
+	defer cancel()
+
+	var resp *delivery.GetDeliveryResponse
+	err := s.deliveryCB.Call(ctx, func() error {
+		var err error
+		resp, err = s.deliveryClient.GetDelivery(ctx, &delivery.GetDeliveryRequest{
+			DeliveryId: strconv.Itoa(deliveryID),
+		})
+		return err
+	})
+	if err != nil {
+		if isHiddenDelivery(err) {
+			return nil, fmt.Errorf("failed to get delivery: %w", err)
+		}
+		return nil, fmt.Errorf("%w: %w", domain.ErrDeliveryUnavailable, err)
+	}
+	if resp.Delivery == nil {
+		return nil, fmt.Errorf("delivery %d not found", deliveryID)
+	}
+	return resp.Delivery, nil
 }
 
-// GetCurrentLocation retrieves the current location for a delivery
-func (s *TrackingService) GetCurrentLocation(ctx context.Context, req ports.GetCurrentLocationRequest) (*domain.Location, error) {
+// deliveryStatusName returns the lower-case name of a delivery status, e.g. "in_transit"
+func deliveryStatusName(status delivery.DeliveryStatus) string {
+	return strings.ToLower(strings.TrimPrefix(status.String(), "DELIVERY_STATUS_"))
+}
 
-	return s.repo.GetLatestByDeliveryID(ctx, req.DeliveryID)
+// isTerminalDeliveryStatus reports whether a delivery will see no further movement
+func isTerminalDeliveryStatus(status delivery.DeliveryStatus) bool {
+	switch status {
+	case delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED,
+		delivery.DeliveryStatus_DELIVERY_STATUS_FAILED,
+		delivery.DeliveryStatus_DELIVERY_STATUS_CANCELLED,
+		delivery.DeliveryStatus_DELIVERY_STATUS_RETURNED:
+		return true
+	}
+	return false
 }
 
-// GetCourierLocation retrieves the current location for a courier
+// GetCourierLocation retrieves the current location for a courier. Outside
+// admins and services, couriers are only visible while they have an assigned
+// or in-transit delivery, returning domain.ErrCourierNotActive otherwise.
+// Admins confined to an organization only see couriers whose location was
+// recorded in it, getting domain.ErrUnauthorized for others.
 func (s *TrackingService) GetCourierLocation(ctx context.Context, req ports.GetCourierLocationRequest) (*domain.Location, error) {
-	return s.repo.GetLatestByCourierID(ctx, req.CourierID)
+	if req.Role != "admin" && req.Role != "super_admin" && req.Role != authDomain.RoleService {
+		active, err := s.courierHasActiveDelivery(ctx, req.CourierID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, domain.ErrCourierNotActive
+		}
+	}
+
+	location, err := s.repo.GetLatestByCourierID(ctx, req.CourierID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role == "admin" {
+		if orgID, scoped := authctx.OrgScope(ctx); scoped && location.OrgID != orgID {
+			return nil, domain.ErrUnauthorized
+		}
+	}
+	return location, nil
 }
 
 // CalculateETAToDestination calculates ETA from current location to destination
 func (s *TrackingService) CalculateETAToDestination(ctx context.Context, req ports.CalculateETAToDestinationRequest) (*ports.CalculateETAResponse, error) {
+	if err := s.authorizeDelivery(ctx, req.DeliveryID, req.AuthContext); err != nil {
+		return nil, err
+	}
+
 	// Get current location
 	currentLocation, err := s.repo.GetLatestByDeliveryID(ctx, req.DeliveryID)
 	if err != nil {
@@ -180,7 +721,7 @@ func (s *TrackingService) CalculateETAToDestination(ctx context.Context, req por
 	}
 
 	// Calculate distance using Haversine formula
-	distanceKm := calculateHaversineDistance(
+	distanceKm := geo.HaversineKm(
 		currentLocation.Latitude, currentLocation.Longitude,
 		req.DestLat, req.DestLng,
 	)
@@ -198,20 +739,3 @@ func (s *TrackingService) CalculateETAToDestination(ctx context.Context, req por
 		AverageSpeed: averageSpeed,
 	}, nil
 }
-
-// calculateHaversineDistance calculates the approximate distance between two points using simplified formula
-func calculateHaversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
-	// Simplified distance calculation (approximate km)
-	// 1 degree latitude = 111 km
-	// 1 degree longitude = 111 km * cos(latitude)
-	latDiff := lat2 - lat1
-	lngDiff := lng2 - lng1
-
-	// Average latitude for longitude correction
-	avgLat := (lat1 + lat2) / 2
-
-	latKm := latDiff * 111.0
-	lngKm := lngDiff * 111.0 * (3.141592653589793 * avgLat / 180) // cos approximation
-
-	return (latKm*latKm + lngKm*lngKm)
-}
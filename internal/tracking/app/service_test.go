@@ -3,260 +3,50 @@ package app
 import (
 	"context"
 	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/adapters/memory"
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
 	"github.com/Keneke-Einar/delivertrack/proto/common"
 	"github.com/Keneke-Einar/delivertrack/proto/delivery"
-	"github.com/Keneke-Einar/delivertrack/proto/notification"
 	"go.uber.org/zap/zaptest"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// adminAuth is a caller allowed to read any delivery
+var adminAuth = ports.AuthContext{Role: "admin"}
+
 // createTestLogger creates a test logger for unit tests
 func createTestLogger(t *testing.T) *logger.Logger {
 	zapLogger := zaptest.NewLogger(t)
 	return &logger.Logger{Logger: zapLogger}
 }
 
-// MockLocationRepository is a mock implementation of LocationRepository for testing
-type MockLocationRepository struct {
-	locations map[int][]*domain.Location
-	nextID    int
-}
-
-func NewMockLocationRepository() *MockLocationRepository {
-	return &MockLocationRepository{
-		locations: make(map[int][]*domain.Location),
-		nextID:    1,
-	}
-}
-
-func (m *MockLocationRepository) Create(ctx context.Context, location *domain.Location) error {
-	location.ID = m.nextID
-	m.nextID++
-
-	// Store by delivery ID
-	if m.locations[location.DeliveryID] == nil {
-		m.locations[location.DeliveryID] = make([]*domain.Location, 0)
-	}
-	m.locations[location.DeliveryID] = append(m.locations[location.DeliveryID], location)
-
-	return nil
-}
-
-func (m *MockLocationRepository) GetByDeliveryID(ctx context.Context, deliveryID int, limit int) ([]*domain.Location, error) {
-	locations := m.locations[deliveryID]
-
-	// Return most recent locations up to limit
-	if len(locations) <= limit {
-		return locations, nil
-	}
-
-	return locations[len(locations)-limit:], nil
-}
-
-func (m *MockLocationRepository) GetLatestByDeliveryID(ctx context.Context, deliveryID int) (*domain.Location, error) {
-	locations := m.locations[deliveryID]
-	if len(locations) == 0 {
-		return nil, errors.New("location not found")
-	}
-
-	return locations[len(locations)-1], nil
-}
-
-func (m *MockLocationRepository) GetByCourierID(ctx context.Context, courierID int, limit int) ([]*domain.Location, error) {
-	// For simplicity, return locations where courier ID matches
-	var result []*domain.Location
-	for _, locations := range m.locations {
-		for _, loc := range locations {
-			if loc.CourierID == courierID {
-				result = append(result, loc)
-			}
-		}
-	}
-
-	// Return most recent up to limit
-	if len(result) <= limit {
-		return result, nil
-	}
-
-	return result[len(result)-limit:], nil
-}
-
-func (m *MockLocationRepository) GetLatestByCourierID(ctx context.Context, courierID int) (*domain.Location, error) {
-	var latest *domain.Location
-	for _, locations := range m.locations {
-		for _, loc := range locations {
-			if loc.CourierID == courierID {
-				if latest == nil || loc.Timestamp.After(latest.Timestamp) {
-					latest = loc
-				}
-			}
-		}
-	}
-
-	if latest == nil {
-		return nil, errors.New("location not found")
-	}
-
-	return latest, nil
-}
-
-// MockPublisher is a mock implementation of messaging.Publisher for testing
-type MockPublisher struct {
-	publishedEvents []messaging.Event
-	publishErr      error
-}
-
-func NewMockPublisher() *MockPublisher {
-	return &MockPublisher{
-		publishedEvents: make([]messaging.Event, 0),
-	}
-}
-
-func (m *MockPublisher) Publish(ctx context.Context, exchange, routingKey string, event messaging.Event) error {
-	if m.publishErr != nil {
-		return m.publishErr
-	}
-	m.publishedEvents = append(m.publishedEvents, event)
-	return nil
-}
-
-func (m *MockPublisher) Close() error {
-	return nil
-}
-
-func (m *MockPublisher) SetPublishError(err error) {
-	m.publishErr = err
-}
-
-// MockDeliveryClient is a mock implementation of DeliveryServiceClient for testing
-type MockDeliveryClient struct{}
-
-func (m *MockDeliveryClient) CreateDelivery(ctx context.Context, in *delivery.CreateDeliveryRequest, opts ...grpc.CallOption) (*delivery.CreateDeliveryResponse, error) {
-	return &delivery.CreateDeliveryResponse{
-		DeliveryId:     "mock-delivery-id",
-		TrackingNumber: "mock-tracking-number",
-		CreatedAt:      1234567890,
-	}, nil
-}
-
-func (m *MockDeliveryClient) GetDelivery(ctx context.Context, in *delivery.GetDeliveryRequest, opts ...grpc.CallOption) (*delivery.GetDeliveryResponse, error) {
-	return &delivery.GetDeliveryResponse{
-		Delivery: &delivery.Delivery{
-			DeliveryId:       "mock-delivery-id",
-			CustomerId:       "1",
-			DriverId:         "1",
-			TrackingNumber:   "mock-tracking-number",
-			PickupLocation:   &common.Location{Latitude: 40.7128, Longitude: -74.0060},
-			DeliveryLocation: &common.Location{Latitude: 40.7589, Longitude: -73.9851},
-			Status:           delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT,
-			CreatedAt:        1234567890,
-			UpdatedAt:        1234567890,
-		},
-	}, nil
-}
-
-func (m *MockDeliveryClient) UpdateDeliveryStatus(ctx context.Context, in *delivery.UpdateDeliveryStatusRequest, opts ...grpc.CallOption) (*delivery.UpdateDeliveryStatusResponse, error) {
-	return &delivery.UpdateDeliveryStatusResponse{
-		Success:   true,
-		UpdatedAt: 1234567890,
-	}, nil
-}
-
-func (m *MockDeliveryClient) AssignDriver(ctx context.Context, in *delivery.AssignDriverRequest, opts ...grpc.CallOption) (*delivery.AssignDriverResponse, error) {
-	return &delivery.AssignDriverResponse{
-		Success:    true,
-		AssignedAt: 1234567890,
-	}, nil
-}
-
-func (m *MockDeliveryClient) ListDeliveries(ctx context.Context, in *delivery.ListDeliveriesRequest, opts ...grpc.CallOption) (*delivery.ListDeliveriesResponse, error) {
-	return &delivery.ListDeliveriesResponse{}, nil
-}
-
-func (m *MockDeliveryClient) CancelDelivery(ctx context.Context, in *delivery.CancelDeliveryRequest, opts ...grpc.CallOption) (*delivery.CancelDeliveryResponse, error) {
-	return &delivery.CancelDeliveryResponse{
-		Success:     true,
-		CancelledAt: 1234567890,
-	}, nil
-}
-
-func (m *MockDeliveryClient) GetDriverDeliveries(ctx context.Context, in *delivery.GetDriverDeliveriesRequest, opts ...grpc.CallOption) (*delivery.GetDriverDeliveriesResponse, error) {
-	return &delivery.GetDriverDeliveriesResponse{}, nil
-}
-
-func (m *MockDeliveryClient) OptimizeRoute(ctx context.Context, in *delivery.OptimizeRouteRequest, opts ...grpc.CallOption) (*delivery.OptimizeRouteResponse, error) {
-	return &delivery.OptimizeRouteResponse{}, nil
-}
-
-func (m *MockDeliveryClient) ConfirmDelivery(ctx context.Context, in *delivery.ConfirmDeliveryRequest, opts ...grpc.CallOption) (*delivery.ConfirmDeliveryResponse, error) {
-	return &delivery.ConfirmDeliveryResponse{
-		Success:     true,
-		ConfirmedAt: 1234567890,
-	}, nil
-}
-
-// MockNotificationClient is a mock implementation of NotificationServiceClient for testing
-type MockNotificationClient struct{}
-
-func (m *MockNotificationClient) SendNotification(ctx context.Context, in *notification.SendNotificationRequest, opts ...grpc.CallOption) (*notification.SendNotificationResponse, error) {
-	return &notification.SendNotificationResponse{
-		NotificationId: "mock-notification-id",
-		Status:         notification.NotificationStatus_NOTIFICATION_STATUS_SENT,
-		SentAt:         1234567890,
-	}, nil
-}
-
-func (m *MockNotificationClient) SendBulkNotifications(ctx context.Context, in *notification.SendBulkNotificationsRequest, opts ...grpc.CallOption) (*notification.SendBulkNotificationsResponse, error) {
-	return &notification.SendBulkNotificationsResponse{
-		SuccessCount: 1,
-		FailedCount:  0,
-	}, nil
-}
-
-func (m *MockNotificationClient) SendDeliveryUpdate(ctx context.Context, in *notification.SendDeliveryUpdateRequest, opts ...grpc.CallOption) (*notification.SendDeliveryUpdateResponse, error) {
-	return &notification.SendDeliveryUpdateResponse{
-		NotificationId: "mock-delivery-notification-id",
-		Success:        true,
-		SentAt:         1234567890,
-	}, nil
-}
-
-func (m *MockNotificationClient) GetNotificationHistory(ctx context.Context, in *notification.GetNotificationHistoryRequest, opts ...grpc.CallOption) (*notification.GetNotificationHistoryResponse, error) {
-	return &notification.GetNotificationHistoryResponse{}, nil
-}
-
-func (m *MockNotificationClient) UpdatePreferences(ctx context.Context, in *notification.UpdatePreferencesRequest, opts ...grpc.CallOption) (*notification.UpdatePreferencesResponse, error) {
-	return &notification.UpdatePreferencesResponse{Success: true}, nil
-}
-
-func (m *MockNotificationClient) GetPreferences(ctx context.Context, in *notification.GetPreferencesRequest, opts ...grpc.CallOption) (*notification.GetPreferencesResponse, error) {
-	return &notification.GetPreferencesResponse{}, nil
-}
-
-func (m *MockNotificationClient) Subscribe(ctx context.Context, in *notification.SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[notification.Notification], error) {
-	return nil, nil
-}
-
-func (m *MockNotificationClient) MarkAsRead(ctx context.Context, in *notification.MarkAsReadRequest, opts ...grpc.CallOption) (*notification.MarkAsReadResponse, error) {
-	return &notification.MarkAsReadResponse{Success: true}, nil
-}
-
 // MockAuthService is a mock implementation of AuthService for testing
 type MockAuthService struct{}
 
-func (m *MockAuthService) Register(ctx context.Context, username, email, password, role string, customerID, courierID *int) (*authDomain.User, error) {
+func (m *MockAuthService) Register(ctx context.Context, username, email, password, role string, orgID int, customerID, courierID *int) (*authDomain.User, error) {
 	return &authDomain.User{
 		ID:         1,
 		Username:   username,
 		Email:      email,
 		Role:       role,
+		OrgID:      orgID,
 		CustomerID: customerID,
 		CourierID:  courierID,
 	}, nil
@@ -288,13 +78,49 @@ func (m *MockAuthService) GetUser(ctx context.Context, id int) (*authDomain.User
 	}, nil
 }
 
+func (m *MockAuthService) UpdateEmail(ctx context.Context, id int, email string) (*authDomain.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	return nil
+}
+
+func (m *MockAuthService) SetUserActive(ctx context.Context, id int, active bool) (*authDomain.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) SetUserOrganization(ctx context.Context, id, orgID int) (*authDomain.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) UnlockUser(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *MockAuthService) IssueAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (*authDomain.APIKey, string, error) {
+	return nil, "", nil
+}
+
+func (m *MockAuthService) ListAPIKeys(ctx context.Context, userID int) ([]*authDomain.APIKey, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	return nil
+}
+
+func (m *MockAuthService) ValidateAPIKey(ctx context.Context, key string) (*authDomain.Claims, error) {
+	return nil, nil
+}
+
 func TestTrackingService_RecordLocation(t *testing.T) {
-	repo := NewMockLocationRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
+	repo := memory.NewLocationRepository()
+	mockPublisher := testsupport.NewPublisher()
+	mockDeliveryClient := testsupport.NewDeliveryClient()
 	mockAuthService := &MockAuthService{}
 	testLogger := createTestLogger(t)
-	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, testLogger)
+	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, nil, testLogger)
 
 	req := ports.RecordLocationRequest{
 		DeliveryID: 1,
@@ -347,15 +173,26 @@ func TestTrackingService_RecordLocation(t *testing.T) {
 	if location.Altitude == nil || *location.Altitude != *req.Altitude {
 		t.Errorf("expected altitude %f, got %v", *req.Altitude, location.Altitude)
 	}
+
+	// Points are recorded in the courier's organization
+	courierCtx := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "courier", OrgID: 2})
+	req.Latitude += 0.0001
+	location, err = service.RecordLocation(courierCtx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location.OrgID != 2 {
+		t.Errorf("expected organization 2, got %d", location.OrgID)
+	}
 }
 
 func TestTrackingService_RecordLocation_InvalidData(t *testing.T) {
-	repo := NewMockLocationRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
+	repo := memory.NewLocationRepository()
+	mockPublisher := testsupport.NewPublisher()
+	mockDeliveryClient := testsupport.NewDeliveryClient()
 	mockAuthService := &MockAuthService{}
 	testLogger := createTestLogger(t)
-	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, testLogger)
+	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, nil, testLogger)
 
 	req := ports.RecordLocationRequest{
 		DeliveryID: 0, // Invalid
@@ -370,187 +207,1747 @@ func TestTrackingService_RecordLocation_InvalidData(t *testing.T) {
 	}
 }
 
-func TestTrackingService_GetDeliveryTrack(t *testing.T) {
-	repo := NewMockLocationRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
-	mockAuthService := &MockAuthService{}
-	testLogger := createTestLogger(t)
-	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, testLogger)
-
-	// Add some test locations
+func TestTrackingService_RecordLocation_DiscardsJitter(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
 	ctx := context.Background()
-	for i := 1; i <= 5; i++ {
-		req := ports.RecordLocationRequest{
-			DeliveryID: 1,
-			CourierID:  1,
-			Latitude:   40.7128 + float64(i),
-			Longitude:  -74.0060 + float64(i),
-		}
-		_, err := service.RecordLocation(ctx, req)
-		if err != nil {
-			t.Fatalf("failed to record location: %v", err)
-		}
-	}
 
-	// Get track with limit
-	trackReq := ports.GetDeliveryTrackRequest{
-		DeliveryID: 1,
-		Limit:      3,
+	first := ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060}
+	if _, err := service.RecordLocation(ctx, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	locations, err := service.GetDeliveryTrack(ctx, trackReq)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	// About 50 km north within a second of the previous point
+	teleport := ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 41.1628, Longitude: -74.0060}
+	if _, err := service.RecordLocation(ctx, teleport); !errors.Is(err, domain.ErrLocationJitter) {
+		t.Fatalf("expected ErrLocationJitter, got %v", err)
 	}
 
-	if len(locations) != 3 {
-		t.Errorf("expected 3 locations, got %d", len(locations))
+	if repo.Count(1) != 1 {
+		t.Errorf("expected the discarded point not to be stored, got %d locations", repo.Count(1))
+	}
+	if service.DiscardedLocations() != 1 {
+		t.Errorf("expected 1 discarded location, got %d", service.DiscardedLocations())
 	}
 
-	// Verify they're the most recent (should be in chronological order)
-	// The mock returns the last 3 locations: latitudes 43.7128, 44.7128, 45.7128
-	expectedLats := []float64{43.7128, 44.7128, 45.7128}
-	for i, loc := range locations {
-		if loc.Latitude != expectedLats[i] {
-			t.Errorf("expected latitude %f at index %d, got %f", expectedLats[i], i, loc.Latitude)
-		}
+	negativeSpeed := -1.0
+	invalid := ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, Speed: &negativeSpeed}
+	if _, err := service.RecordLocation(ctx, invalid); !errors.Is(err, domain.ErrInvalidLocation) {
+		t.Errorf("expected ErrInvalidLocation for negative speed, got %v", err)
 	}
 }
 
-func TestTrackingService_GetCurrentLocation(t *testing.T) {
-	repo := NewMockLocationRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
-	mockAuthService := &MockAuthService{}
-	testLogger := createTestLogger(t)
-	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, testLogger)
-
+func TestTrackingService_RecordLocation_ClientPointID(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
 	ctx := context.Background()
 
-	// Add a location
+	recordedAt := time.Now().Add(-5 * time.Minute).Truncate(time.Second)
 	req := ports.RecordLocationRequest{
-		DeliveryID: 1,
-		CourierID:  1,
-		Latitude:   40.7128,
-		Longitude:  -74.0060,
+		DeliveryID:    1,
+		CourierID:     1,
+		Latitude:      40.7128,
+		Longitude:     -74.0060,
+		ClientPointID: "7F9C24E8-3B12-4A6D-9E5F-1C2B3A4D5E6F",
+		RecordedAt:    &recordedAt,
 	}
-	_, err := service.RecordLocation(ctx, req)
+	first, err := service.RecordLocation(ctx, req)
 	if err != nil {
-		t.Fatalf("failed to record location: %v", err)
+		t.Fatalf("unexpected error: %v", err)
 	}
-
-	// Get current location
-	currentReq := ports.GetCurrentLocationRequest{
-		DeliveryID: 1,
+	if first.Duplicate || first.ClientPointID != "7f9c24e8-3b12-4a6d-9e5f-1c2b3a4d5e6f" {
+		t.Errorf("expected a new point with the canonical client point ID, got %+v", first)
+	}
+	if !first.Timestamp.Equal(recordedAt) || first.RecordedAt == nil || !first.CreatedAt.After(recordedAt) {
+		t.Errorf("expected the client's timestamp and the server's, got timestamp %v recorded %v created %v", first.Timestamp, first.RecordedAt, first.CreatedAt)
 	}
 
-	location, err := service.GetCurrentLocation(ctx, currentReq)
+	// The same point uploaded again, spelled differently, after the courier moved on
+	req.ClientPointID = "7f9c24e8-3b12-4a6d-9e5f-1c2b3a4d5e6f"
+	req.Latitude = 40.7130
+	again, err := service.RecordLocation(ctx, req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if !again.Duplicate || again.ID != first.ID || again.Latitude != first.Latitude {
+		t.Errorf("expected the stored point back as a duplicate, got %+v", again)
+	}
+	if repo.Count(1) != 1 {
+		t.Errorf("expected one stored point, got %d", repo.Count(1))
+	}
 
-	if location == nil {
-		t.Fatal("expected location, got nil")
+	// Another courier's point with the same ID is its own
+	service.SetJitterFilter(domain.JitterFilter{})
+	other := req
+	other.CourierID = 2
+	other.AdminOverride = true
+	if loc, err := service.RecordLocation(ctx, other); err != nil || loc.Duplicate {
+		t.Errorf("expected another courier's point to be stored, got %+v, %v", loc, err)
 	}
 
-	if location.Latitude != 40.7128 {
-		t.Errorf("expected latitude 40.7128, got %f", location.Latitude)
+	for name, bad := range map[string]ports.RecordLocationRequest{
+		"not a UUID": {DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, ClientPointID: "point-1"},
+		"future":     {DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, RecordedAt: &[]time.Time{time.Now().Add(time.Hour)}[0]},
+	} {
+		if _, err := service.RecordLocation(ctx, bad); !errors.Is(err, domain.ErrInvalidLocation) {
+			t.Errorf("%s: expected ErrInvalidLocation, got %v", name, err)
+		}
 	}
 }
 
-func TestTrackingService_GetCourierLocation(t *testing.T) {
-	repo := NewMockLocationRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
-	mockAuthService := &MockAuthService{}
-	testLogger := createTestLogger(t)
-	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, testLogger)
-
+func TestTrackingService_RecordLocation_LatePoint(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	cache := NewMockLocationCache()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	service.SetLocationCache(cache)
 	ctx := context.Background()
 
-	// Add locations for different deliveries but same courier
-	for i := 1; i <= 3; i++ {
-		req := ports.RecordLocationRequest{
-			DeliveryID: i,
-			CourierID:  1,
-			Latitude:   40.7128 + float64(i),
-			Longitude:  -74.0060 + float64(i),
-		}
-		_, err := service.RecordLocation(ctx, req)
-		if err != nil {
-			t.Fatalf("failed to record location: %v", err)
-		}
+	live, err := service.RecordLocation(ctx, ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// Get courier location
-	courierReq := ports.GetCourierLocationRequest{
-		CourierID: 1,
+	// A point from a dead zone ten minutes ago arrives after the live one
+	recordedAt := time.Now().Add(-10 * time.Minute)
+	late, err := service.RecordLocation(ctx, ports.RecordLocationRequest{
+		DeliveryID: 1, CourierID: 1, Latitude: 40.7028, Longitude: -74.0060,
+		ClientPointID: "0b6c2f4e-8d1a-4c3b-9f7e-5a6b7c8d9e0f", RecordedAt: &recordedAt,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if late.DistanceKm != live.DistanceKm {
+		t.Errorf("expected the late point to add no distance, got %f km after %f km", late.DistanceKm, live.DistanceKm)
 	}
 
-	location, err := service.GetCourierLocation(ctx, courierReq)
+	// The track is ordered by when points were taken
+	track, _, err := service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{DeliveryID: 1, OldestFirst: true, AuthContext: adminAuth})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-
-	if location == nil {
-		t.Fatal("expected location, got nil")
+	if len(track) != 2 || track[0].Latitude != 40.7028 || track[1].Latitude != 40.7128 {
+		t.Errorf("expected the late point first, got %+v", track)
 	}
 
-	// Should be the latest (highest latitude)
-	if location.Latitude != 40.7128+3 {
-		t.Errorf("expected latest latitude 43.7128, got %f", location.Latitude)
+	// The current location is still the live one
+	if current, found, _ := cache.GetLatest(ctx, 1); !found || current.Latitude != 40.7128 {
+		t.Errorf("expected the cache to keep the live point, got %+v", current)
 	}
 }
 
-func TestTrackingService_CalculateETAToDestination(t *testing.T) {
-	repo := NewMockLocationRepository()
-	mockPublisher := NewMockPublisher()
-	mockDeliveryClient := &MockDeliveryClient{}
-	mockAuthService := &MockAuthService{}
-	testLogger := createTestLogger(t)
-	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, testLogger)
+func TestTrackingService_RecordLocation_TerminalDelivery(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   delivery.DeliveryStatus
+		expected error
+	}{
+		{name: "in transit", status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT},
+		{name: "delivered", status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, expected: domain.ErrDeliveryClosed},
+		{name: "cancelled", status: delivery.DeliveryStatus_DELIVERY_STATUS_CANCELLED, expected: domain.ErrDeliveryClosed},
+	}
 
-	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewLocationRepository()
+			deliveryClient := statusDeliveryClient(tt.status)
+			service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
 
-	// Add current location
-	req := ports.RecordLocationRequest{
-		DeliveryID: 1,
-		CourierID:  1,
-		Latitude:   40.7128, // NYC
-		Longitude:  -74.0060,
-	}
-	_, err := service.RecordLocation(ctx, req)
-	if err != nil {
-		t.Fatalf("failed to record location: %v", err)
+			_, err := service.RecordLocation(context.Background(), ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060})
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if stored := repo.Count(1); (tt.expected == nil) != (stored == 1) {
+				t.Errorf("expected the point stored only for an open delivery, got %d stored", stored)
+			}
+		})
 	}
 
-	// Calculate ETA to destination
-	etaReq := ports.CalculateETAToDestinationRequest{
-		DeliveryID: 1,
-		DestLat:    40.7589, // Times Square (about 5km away)
-		DestLng:    -73.9851,
+	// Points can't be checked while the delivery service is unreachable, so
+	// they are refused until the app uploads them again
+	repo := memory.NewLocationRepository()
+	deliveryClient := ownerDeliveryClient("1", "1", status.Error(codes.Unavailable, "delivery service down"))
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	if _, err := service.RecordLocation(context.Background(), ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060}); !errors.Is(err, domain.ErrDeliveryUnavailable) {
+		t.Errorf("expected ErrDeliveryUnavailable, got %v", err)
 	}
-
-	eta, err := service.CalculateETAToDestination(ctx, etaReq)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if stored := repo.Count(1); stored != 0 {
+		t.Errorf("expected the point not to be stored, got %d stored", stored)
 	}
+}
 
-	if eta == nil {
-		t.Fatal("expected ETA response, got nil")
+func TestTrackingService_RecordLocation_AssignedCourier(t *testing.T) {
+	tests := []struct {
+		name     string
+		driverID string
+		err      error
+		req      ports.RecordLocationRequest
+		expected error
+	}{
+		{name: "assigned courier", driverID: "1", req: ports.RecordLocationRequest{CourierID: 1}},
+		{name: "other courier", driverID: "9", req: ports.RecordLocationRequest{CourierID: 1}, expected: domain.ErrUnauthorized},
+		{name: "no courier yet", req: ports.RecordLocationRequest{CourierID: 1}, expected: domain.ErrUnauthorized},
+		{name: "hidden delivery", driverID: "1", err: status.Error(codes.PermissionDenied, "not your delivery"), req: ports.RecordLocationRequest{CourierID: 1}, expected: domain.ErrUnauthorized},
+		{name: "admin override", driverID: "9", req: ports.RecordLocationRequest{CourierID: 1, AdminOverride: true}},
 	}
 
-	if eta.DistanceKm <= 0 {
-		t.Errorf("expected positive distance, got %f", eta.DistanceKm)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewLocationRepository()
+			deliveryClient := ownerDeliveryClient("3", tt.driverID, tt.err)
+			service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+			req := tt.req
+			req.DeliveryID, req.Latitude, req.Longitude = 1, 40.7128, -74.0060
+			_, err := service.RecordLocation(context.Background(), req)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if stored := repo.Count(1); (tt.expected == nil) != (stored == 1) {
+				t.Errorf("expected the point stored only when allowed, got %d stored", stored)
+			}
+		})
 	}
+}
 
-	if eta.AverageSpeed != 25.0 {
-		t.Errorf("expected average speed 25.0, got %f", eta.AverageSpeed)
+func TestTrackingService_RecordLocation_EnrichesEvent(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	publisher := testsupport.NewPublisher()
+	deliveryClient := ownerDeliveryClient("3", "1", nil)
+	service := NewTrackingService(repo, publisher, deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	// Three points about 33 m apart heading north
+	for i := 0; i < 3; i++ {
+		req := ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128 + 0.0003*float64(i), Longitude: -74.0060}
+		if _, err := service.RecordLocation(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 	}
 
-	if eta.ETA <= 0 {
-		t.Errorf("expected positive ETA duration, got %v", eta.ETA)
+	// Events are published in the background
+	var recorded []messaging.LocationRecordedEvent
+	deadline := time.Now().Add(time.Second)
+	for len(recorded) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		recorded = recorded[:0]
+		for _, event := range publisher.Events() {
+			data, err := messaging.DecodeData[messaging.LocationRecordedEvent](event)
+			if err != nil {
+				t.Fatalf("failed to decode event: %v", err)
+			}
+			recorded = append(recorded, data)
+		}
+	}
+	if len(recorded) != 3 {
+		t.Fatalf("expected 3 location events, got %d", len(recorded))
+	}
+
+	var longest messaging.LocationRecordedEvent
+	for _, data := range recorded {
+		if data.SchemaVersion != messaging.SchemaVersionOf(messaging.EventTypeLocationUpdated) || data.DeliveryStatus != "in_transit" || data.CustomerID != 3 ||
+			data.AssignedCourierID == nil || *data.AssignedCourierID != 1 {
+			t.Errorf("expected delivery context in event, got %+v", data)
+		}
+		if data.CumulativeDistanceKm > longest.CumulativeDistanceKm {
+			longest = data
+		}
+	}
+	if math.Abs(longest.CumulativeDistanceKm-0.0667) > 0.001 || math.Abs(longest.DistanceFromPreviousKm-0.0334) > 0.001 {
+		t.Errorf("expected about 67 m travelled in 33 m steps, got %.4f km and %.4f km",
+			longest.CumulativeDistanceKm, longest.DistanceFromPreviousKm)
+	}
+
+	// The running total is stored with the point
+	latest, err := repo.GetLatestByDeliveryID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latest.DistanceKm != longest.CumulativeDistanceKm {
+		t.Errorf("expected stored distance %.4f km, got %.4f km", longest.CumulativeDistanceKm, latest.DistanceKm)
+	}
+}
+
+func TestTrackingService_GetDeliveryTrack(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	mockPublisher := testsupport.NewPublisher()
+	mockDeliveryClient := testsupport.NewDeliveryClient()
+	mockAuthService := &MockAuthService{}
+	testLogger := createTestLogger(t)
+	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, nil, testLogger)
+
+	// Points are a degree apart, far beyond any plausible courier speed
+	service.SetJitterFilter(domain.JitterFilter{})
+
+	// Add some test locations
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		req := ports.RecordLocationRequest{
+			DeliveryID: 1,
+			CourierID:  1,
+			Latitude:   40.7128 + float64(i),
+			Longitude:  -74.0060 + float64(i),
+		}
+		_, err := service.RecordLocation(ctx, req)
+		if err != nil {
+			t.Fatalf("failed to record location: %v", err)
+		}
+	}
+
+	// Get track with limit
+	trackReq := ports.GetDeliveryTrackRequest{
+		DeliveryID:  1,
+		Limit:       3,
+		OldestFirst: true,
+		AuthContext: adminAuth,
+	}
+
+	locations, _, err := service.GetDeliveryTrack(ctx, trackReq)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(locations) != 3 {
+		t.Errorf("expected 3 locations, got %d", len(locations))
+	}
+
+	// Verify they're the most recent (should be in chronological order)
+	// The mock returns the last 3 locations: latitudes 43.7128, 44.7128, 45.7128
+	expectedLats := []float64{43.7128, 44.7128, 45.7128}
+	for i, loc := range locations {
+		if loc.Latitude != expectedLats[i] {
+			t.Errorf("expected latitude %f at index %d, got %f", expectedLats[i], i, loc.Latitude)
+		}
+	}
+}
+
+func TestTrackingService_GetDeliveryTrack_Window(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	ctx := context.Background()
+
+	// One point an hour, the oldest two days ago
+	now := time.Now().Truncate(time.Hour)
+	for i := 48; i >= 0; i-- {
+		location, err := domain.NewLocation(1, 1, 40.0, -74.0)
+		if err != nil {
+			t.Fatalf("failed to create location: %v", err)
+		}
+		location.Timestamp = now.Add(-time.Duration(i) * time.Hour)
+		repo.Create(ctx, location)
+	}
+
+	hoursAgo := func(h int) *time.Time {
+		t := now.Add(-time.Duration(h) * time.Hour)
+		return &t
+	}
+
+	tests := []struct {
+		name      string
+		req       ports.GetDeliveryTrackRequest
+		wantFirst *time.Time
+		wantLast  *time.Time
+		wantCount int
+	}{
+		{
+			name:      "defaults to the last day, newest first",
+			req:       ports.GetDeliveryTrackRequest{DeliveryID: 1},
+			wantFirst: hoursAgo(0),
+			wantLast:  hoursAgo(23),
+			wantCount: 24,
+		},
+		{
+			name:      "window oldest first",
+			req:       ports.GetDeliveryTrackRequest{DeliveryID: 1, From: hoursAgo(40), To: hoursAgo(30), OldestFirst: true},
+			wantFirst: hoursAgo(40),
+			wantLast:  hoursAgo(31),
+			wantCount: 10,
+		},
+		{
+			name:      "day before an end",
+			req:       ports.GetDeliveryTrackRequest{DeliveryID: 1, To: hoursAgo(24)},
+			wantFirst: hoursAgo(25),
+			wantLast:  hoursAgo(48),
+			wantCount: 24,
+		},
+		{
+			name:      "limit keeps the latest points in the window",
+			req:       ports.GetDeliveryTrackRequest{DeliveryID: 1, From: hoursAgo(40), To: hoursAgo(30), Limit: 3, OldestFirst: true},
+			wantFirst: hoursAgo(33),
+			wantLast:  hoursAgo(31),
+			wantCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.AuthContext = adminAuth
+			locations, _, err := service.GetDeliveryTrack(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(locations) != tt.wantCount {
+				t.Fatalf("expected %d locations, got %d", tt.wantCount, len(locations))
+			}
+			if first := locations[0].Timestamp; !first.Equal(*tt.wantFirst) {
+				t.Errorf("first point at %v, want %v", first, *tt.wantFirst)
+			}
+			if last := locations[len(locations)-1].Timestamp; !last.Equal(*tt.wantLast) {
+				t.Errorf("last point at %v, want %v", last, *tt.wantLast)
+			}
+		})
+	}
+
+	_, _, err := service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{DeliveryID: 1, From: hoursAgo(1), To: hoursAgo(2), AuthContext: adminAuth})
+	if !errors.Is(err, domain.ErrInvalidTimeRange) {
+		t.Errorf("expected ErrInvalidTimeRange for to before from, got %v", err)
+	}
+}
+
+func TestTrackingService_GetDeliveryTrack_Simplify(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	ctx := context.Background()
+
+	// Ten points about 11 m apart in a straight line north
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		location, _ := domain.NewLocation(1, 1, 40.0+float64(i)*0.0001, -74.0)
+		location.Timestamp = base.Add(time.Duration(i) * time.Second)
+		repo.Create(ctx, location)
+	}
+
+	locations, fetched, err := service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{DeliveryID: 1, SimplifyMeters: 5, OldestFirst: true, AuthContext: adminAuth})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fetched != 10 {
+		t.Errorf("expected 10 fetched points, got %d", fetched)
+	}
+	if len(locations) != 2 || !locations[0].Timestamp.Equal(base) || !locations[1].Timestamp.Equal(base.Add(9*time.Second)) {
+		t.Errorf("expected only the first and last points, got %d", len(locations))
+	}
+}
+
+// MockGeocodingService resolves coordinates to fixed addresses and fails for latitudes in failLats
+type MockGeocodingService struct {
+	mu       sync.Mutex
+	calls    int
+	failLats map[float64]bool
+}
+
+func (m *MockGeocodingService) ForwardGeocode(ctx context.Context, address string) (*geocoding.GeocodeResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockGeocodingService) ReverseGeocode(ctx context.Context, lat, lng float64) (*geocoding.ReverseGeocodeResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.failLats[lat] {
+		return nil, errors.New("geocoding unavailable")
+	}
+	return &geocoding.ReverseGeocodeResult{Address: "Main St"}, nil
+}
+
+func (m *MockGeocodingService) Autocomplete(ctx context.Context, query string) ([]geocoding.AutocompleteResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestTrackingService_GetDeliveryTrack_ResolveAddresses(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	geocoder := &MockGeocodingService{failLats: map[float64]bool{42.0: true}}
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, geocoder, createTestLogger(t))
+
+	ctx := context.Background()
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		location, _ := domain.NewLocation(1, 1, 40.0+float64(i), -74.0)
+		location.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		repo.Create(ctx, location)
+	}
+
+	t.Run("without flag addresses are not resolved", func(t *testing.T) {
+		locations, _, err := service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{DeliveryID: 1, AuthContext: adminAuth})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, loc := range locations {
+			if loc.Address != "" {
+				t.Errorf("expected no address, got %q", loc.Address)
+			}
+		}
+		if geocoder.calls != 0 {
+			t.Errorf("expected no geocoding calls, got %d", geocoder.calls)
+		}
+	})
+
+	t.Run("latest and every Nth point are resolved", func(t *testing.T) {
+		locations, _, err := service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{
+			DeliveryID:       1,
+			ResolveAddresses: true,
+			AddressEvery:     2,
+			AuthContext:      adminAuth,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Indices 0, 2 and 4 (latest) are resolved; index 2 (lat 42) fails and is omitted
+		expected := []string{"Main St", "", "", "", "Main St"}
+		for i, loc := range locations {
+			if loc.Address != expected[i] {
+				t.Errorf("index %d: expected address %q, got %q", i, expected[i], loc.Address)
+			}
+		}
+		if geocoder.calls != 3 {
+			t.Errorf("expected 3 geocoding calls, got %d", geocoder.calls)
+		}
+	})
+}
+
+// MockZoneRepository returns zones for points by latitude and counts lookups
+type MockZoneRepository struct {
+	mu      sync.Mutex
+	zones   map[float64][]string
+	lookups int
+}
+
+func (m *MockZoneRepository) FindZonesContainingPoint(ctx context.Context, latitude, longitude float64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups++
+	return m.zones[latitude], nil
+}
+
+func TestTrackingService_CheckZoneTransitions(t *testing.T) {
+	zones := &MockZoneRepository{zones: map[float64][]string{
+		40.0: {"suburbs"},
+		41.0: {"downtown"},
+	}}
+	publisher := testsupport.NewPublisher()
+	service := NewTrackingService(memory.NewLocationRepository(), publisher, testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	service.SetWebSocketHub(nil)
+	service.SetZoneRepository(zones)
+
+	now := time.Now()
+	service.zoneTracker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	previous, _ := domain.NewLocation(1, 7, 40.0, -74.0)
+	current, _ := domain.NewLocation(1, 7, 41.0, -74.0)
+
+	// Cold start compares against the courier's previous point
+	service.checkZoneTransitions(ctx, current, previous)
+
+	if len(publisher.Events()) != 2 {
+		t.Fatalf("expected 2 zone events, got %d", len(publisher.Events()))
+	}
+	exited, entered := publisher.Events()[0], publisher.Events()[1]
+	if exited.Type != "courier.zone_exited" || exited.Data["zone_name"] != "suburbs" {
+		t.Errorf("unexpected exit event: %+v", exited)
+	}
+	enteredData, err := messaging.DecodeData[messaging.ZoneTransitionEvent](entered)
+	if err != nil {
+		t.Fatalf("failed to decode entry event: %v", err)
+	}
+	if entered.Type != "courier.zone_entered" || enteredData.ZoneName != "downtown" || enteredData.CourierID != 7 {
+		t.Errorf("unexpected entry event: %+v", entered)
+	}
+
+	// Within the TTL the cached zones are reused without a geo query
+	lookups := zones.lookups
+	back, _ := domain.NewLocation(1, 7, 40.0, -74.0)
+	service.checkZoneTransitions(ctx, back, nil)
+	if zones.lookups != lookups {
+		t.Errorf("expected cached zones to skip lookup, got %d new lookups", zones.lookups-lookups)
+	}
+
+	// After the TTL the new point is compared with the cached zones
+	now = now.Add(zoneCacheTTL + time.Second)
+	service.checkZoneTransitions(ctx, back, nil)
+	if len(publisher.Events()) != 4 {
+		t.Fatalf("expected 4 zone events, got %d", len(publisher.Events()))
+	}
+	if publisher.Events()[3].Type != "courier.zone_entered" || publisher.Events()[3].Data["zone_name"] != "suburbs" {
+		t.Errorf("unexpected entry event: %+v", publisher.Events()[3])
+	}
+
+	// Staying in the same zones publishes nothing
+	now = now.Add(zoneCacheTTL + time.Second)
+	service.checkZoneTransitions(ctx, back, nil)
+	if len(publisher.Events()) != 4 {
+		t.Errorf("expected no new events, got %d", len(publisher.Events()))
+	}
+}
+
+func TestZoneTracker_EvictsIdleCouriers(t *testing.T) {
+	tracker := newZoneTracker(zoneCacheTTL)
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	tracker.claim(1)
+	tracker.set(1, []string{"downtown"})
+	tracker.claim(2)
+
+	// Courier 2 keeps sending points; courier 1 goes offline
+	for i := 0; i < 6; i++ {
+		now = now.Add(zoneIdleTTL / 3)
+		tracker.claim(2)
+	}
+	if tracker.known(1) {
+		t.Error("expected the idle courier to be unknown")
+	}
+	if !tracker.known(2) {
+		t.Error("expected the active courier to be known")
+	}
+	tracker.mu.Lock()
+	entries := len(tracker.couriers)
+	tracker.mu.Unlock()
+	if entries != 1 {
+		t.Errorf("expected the idle courier to be evicted, got %d entries", entries)
+	}
+
+	// A returning courier starts over from their previous point
+	if previous, known, due := tracker.claim(1); known || !due || previous != nil {
+		t.Errorf("expected a fresh claim, got %v, %v, %v", previous, known, due)
+	}
+}
+
+func TestTrackingService_GetCurrentLocation(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	mockPublisher := testsupport.NewPublisher()
+	mockDeliveryClient := testsupport.NewDeliveryClient()
+	mockAuthService := &MockAuthService{}
+	testLogger := createTestLogger(t)
+	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, nil, testLogger)
+
+	ctx := context.Background()
+
+	// Add a location
+	req := ports.RecordLocationRequest{
+		DeliveryID: 1,
+		CourierID:  1,
+		Latitude:   40.7128,
+		Longitude:  -74.0060,
+	}
+	_, err := service.RecordLocation(ctx, req)
+	if err != nil {
+		t.Fatalf("failed to record location: %v", err)
+	}
+
+	// Get current location
+	currentReq := ports.GetCurrentLocationRequest{
+		DeliveryID:  1,
+		AuthContext: adminAuth,
+	}
+
+	location, cached, err := service.GetCurrentLocation(ctx, currentReq)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cached {
+		t.Error("expected no cache hit without a location cache")
+	}
+
+	if location == nil {
+		t.Fatal("expected location, got nil")
+	}
+
+	if location.Latitude != 40.7128 {
+		t.Errorf("expected latitude 40.7128, got %f", location.Latitude)
+	}
+}
+
+// MockLocationCache is an in-memory LocationCache
+type MockLocationCache struct {
+	mu        sync.Mutex
+	locations map[int]*domain.Location
+	err       error
+}
+
+func NewMockLocationCache() *MockLocationCache {
+	return &MockLocationCache{locations: make(map[int]*domain.Location)}
+}
+
+func (m *MockLocationCache) GetLatest(ctx context.Context, deliveryID int) (*domain.Location, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, false, m.err
+	}
+	location, ok := m.locations[deliveryID]
+	return location, ok, nil
+}
+
+func (m *MockLocationCache) SetLatest(ctx context.Context, location *domain.Location) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.locations[location.DeliveryID] = location
+	return nil
+}
+
+func (m *MockLocationCache) DeleteLatest(ctx context.Context, deliveryID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.locations, deliveryID)
+	return nil
+}
+
+func TestTrackingService_GetCurrentLocation_Cache(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	cache := NewMockLocationCache()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	service.SetLocationCache(cache)
+
+	ctx := context.Background()
+
+	// A point stored before the cache existed is a miss, then cached
+	repo.Create(ctx, &domain.Location{DeliveryID: 2, CourierID: 2, Latitude: 51.5, Longitude: -0.12, Timestamp: time.Now()})
+	location, cached, err := service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{DeliveryID: 2, AuthContext: adminAuth})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cached || location.Latitude != 51.5 {
+		t.Errorf("expected a miss served from the repository, got cached=%v %+v", cached, location)
+	}
+	if _, found, _ := cache.GetLatest(ctx, 2); !found {
+		t.Error("expected the miss to populate the cache")
+	}
+
+	// Recording writes through to the cache
+	_, err = service.RecordLocation(ctx, ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060})
+	if err != nil {
+		t.Fatalf("failed to record location: %v", err)
+	}
+	location, cached, err = service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{DeliveryID: 1, AuthContext: adminAuth})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cached || location.Latitude != 40.7128 {
+		t.Errorf("expected a cache hit for the recorded point, got cached=%v %+v", cached, location)
+	}
+
+	// An unavailable cache falls back to the repository without an error
+	cache.err = errors.New("connection refused")
+	location, cached, err = service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{DeliveryID: 1, AuthContext: adminAuth})
+	if err != nil {
+		t.Fatalf("expected cache failures to be hidden, got %v", err)
+	}
+	if cached || location.Latitude != 40.7128 {
+		t.Errorf("expected a repository read, got cached=%v %+v", cached, location)
+	}
+}
+
+// statusDeliveryClient serves a delivery whose status can change during a test
+func statusDeliveryClient(status delivery.DeliveryStatus) *testsupport.DeliveryClient {
+	client := testsupport.NewDeliveryClient()
+	client.SetStatus(status)
+	return client
+}
+
+func TestTrackingService_TrackDelivery(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	deliveryClient := statusDeliveryClient(delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT)
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	service.statusInterval = 10 * time.Millisecond
+
+	ctx := context.Background()
+	record := func(lat float64) {
+		t.Helper()
+		if _, err := service.RecordLocation(ctx, ports.RecordLocationRequest{DeliveryID: 1, CourierID: 1, Latitude: lat, Longitude: -74.0060}); err != nil {
+			t.Fatalf("failed to record location: %v", err)
+		}
+	}
+	record(40.7128)
+
+	customerID := 1
+	received := make(chan *domain.Location, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- service.TrackDelivery(ctx, ports.TrackDeliveryRequest{
+			DeliveryID:  1,
+			AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &customerID},
+		}, func(loc *domain.Location) error {
+			received <- loc
+			return nil
+		})
+	}()
+
+	expect := func(lat float64) {
+		t.Helper()
+		select {
+		case loc := <-received:
+			if loc.Latitude != lat {
+				t.Errorf("expected latitude %f, got %f", lat, loc.Latitude)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for location %f", lat)
+		}
+	}
+
+	// The last known point is sent first, then new points as they are recorded
+	expect(40.7128)
+	time.Sleep(5 * time.Millisecond) // keep the timestamps apart
+	record(40.7130)
+	expect(40.7130)
+
+	// The stream ends once the delivery is finished
+	deliveryClient.SetStatus(delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to end for a delivered delivery")
+	}
+	if n := service.subscriptions.count(1); n != 0 {
+		t.Errorf("expected subscriptions to be cleaned up, got %d", n)
+	}
+}
+
+func TestTrackingService_TrackDelivery_Authorization(t *testing.T) {
+	deliveryClient := statusDeliveryClient(delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED)
+	service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	own, other := 1, 2
+	tests := []struct {
+		name     string
+		auth     ports.AuthContext
+		expected error
+	}{
+		{name: "admin", auth: ports.AuthContext{Role: "admin"}},
+		{name: "owning customer", auth: ports.AuthContext{Role: "customer", UserCustomerID: &own}},
+		{name: "assigned courier", auth: ports.AuthContext{Role: "courier", UserCourierID: &own}},
+		{name: "other customer", auth: ports.AuthContext{Role: "customer", UserCustomerID: &other}, expected: domain.ErrUnauthorized},
+		{name: "unassigned courier", auth: ports.AuthContext{Role: "courier", UserCourierID: &other}, expected: domain.ErrUnauthorized},
+		{name: "customer without ID", auth: ports.AuthContext{Role: "customer"}, expected: domain.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Delivered deliveries end the stream right away for allowed callers
+			err := service.TrackDelivery(context.Background(), ports.TrackDeliveryRequest{DeliveryID: 1, AuthContext: tt.auth},
+				func(*domain.Location) error { return nil })
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// ownerDeliveryClient serves a delivery with a fixed owner, or fails with err
+func ownerDeliveryClient(customerID, courierID string, err error) *testsupport.DeliveryClient {
+	client := testsupport.NewDeliveryClient()
+	client.SetDelivery(&delivery.Delivery{
+		CustomerId: customerID,
+		DriverId:   courierID,
+		Status:     delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT,
+	})
+	client.SetError("GetDelivery", err)
+	return client
+}
+
+func TestTrackingService_ReadAuthorization(t *testing.T) {
+	own, other := 1, 2
+	tests := []struct {
+		name      string
+		courierID string
+		auth      ports.AuthContext
+		expected  error
+	}{
+		{name: "admin", courierID: "1", auth: adminAuth},
+		{name: "owning customer", courierID: "1", auth: ports.AuthContext{Role: "customer", UserCustomerID: &own}},
+		{name: "other customer", courierID: "1", auth: ports.AuthContext{Role: "customer", UserCustomerID: &other}, expected: domain.ErrUnauthorized},
+		{name: "assigned courier", courierID: "1", auth: ports.AuthContext{Role: "courier", UserCourierID: &own}},
+		{name: "courier of another delivery", courierID: "1", auth: ports.AuthContext{Role: "courier", UserCourierID: &other}, expected: domain.ErrUnauthorized},
+		{name: "courier on unassigned delivery", courierID: "", auth: ports.AuthContext{Role: "courier", UserCourierID: &own}, expected: domain.ErrUnauthorized},
+		{name: "no role", courierID: "1", auth: ports.AuthContext{}, expected: domain.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewLocationRepository()
+			repo.Create(context.Background(), &domain.Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, Timestamp: time.Now()})
+			deliveryClient := ownerDeliveryClient("1", tt.courierID, nil)
+			service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+			ctx := context.Background()
+
+			_, _, err := service.GetDeliveryTrack(ctx, ports.GetDeliveryTrackRequest{DeliveryID: 1, AuthContext: tt.auth})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("GetDeliveryTrack: expected %v, got %v", tt.expected, err)
+			}
+			_, _, err = service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{DeliveryID: 1, AuthContext: tt.auth})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("GetCurrentLocation: expected %v, got %v", tt.expected, err)
+			}
+			_, err = service.CalculateETAToDestination(ctx, ports.CalculateETAToDestinationRequest{DeliveryID: 1, DestLat: 40.7589, DestLng: -73.9851, AuthContext: tt.auth})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("CalculateETAToDestination: expected %v, got %v", tt.expected, err)
+			}
+			err = service.ExportDeliveryTrack(ctx, ports.ExportDeliveryTrackRequest{DeliveryID: 1, AuthContext: tt.auth},
+				func(*domain.Location) error { return nil })
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("ExportDeliveryTrack: expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestTrackingService_ExportDeliveryTrack(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		repo.Create(context.Background(), &domain.Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7, Longitude: -74.0,
+			Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+
+	from, to := start.Add(time.Minute), start.Add(3*time.Minute)
+	var got []time.Time
+	err := service.ExportDeliveryTrack(context.Background(), ports.ExportDeliveryTrackRequest{
+		DeliveryID: 1, From: &from, To: &to, AuthContext: adminAuth,
+	}, func(loc *domain.Location) error {
+		got = append(got, loc.Timestamp)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || !got[0].Equal(from) || !got[1].Equal(start.Add(2*time.Minute)) {
+		t.Errorf("expected the points from 09:01 up to but excluding 09:03, got %v", got)
+	}
+
+	// An emit error stops the stream
+	stop := errors.New("client went away")
+	calls := 0
+	err = service.ExportDeliveryTrack(context.Background(), ports.ExportDeliveryTrackRequest{DeliveryID: 1, AuthContext: adminAuth},
+		func(*domain.Location) error { calls++; return stop })
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected the stream to stop at the first error, got %v after %d points", err, calls)
+	}
+}
+
+func TestTrackingService_ReadAuthorization_CachesOwner(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	repo.Create(context.Background(), &domain.Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, Timestamp: time.Now()})
+	deliveryClient := ownerDeliveryClient("1", "1", nil)
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	own := 1
+	req := ports.GetCurrentLocationRequest{DeliveryID: 1, AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &own}}
+	for i := 0; i < 3; i++ {
+		if _, _, err := service.GetCurrentLocation(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls := deliveryClient.Calls("GetDelivery"); calls != 1 {
+		t.Errorf("expected 1 delivery lookup while cached, got %d", calls)
+	}
+
+	// An expired entry is looked up again
+	service.owners.now = func() time.Time { return time.Now().Add(2 * deliveryOwnerTTL) }
+	if _, _, err := service.GetCurrentLocation(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls := deliveryClient.Calls("GetDelivery"); calls != 2 {
+		t.Errorf("expected a fresh lookup after expiry, got %d calls", calls)
+	}
+}
+
+func TestTrackingService_ReadAuthorization_HiddenDelivery(t *testing.T) {
+	// The delivery service refuses lookups for deliveries the caller cannot see
+	deliveryClient := ownerDeliveryClient("1", "1", status.Error(codes.NotFound, "delivery not found"))
+	service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	other := 2
+	_, _, err := service.GetDeliveryTrack(context.Background(), ports.GetDeliveryTrackRequest{
+		DeliveryID:  1,
+		AuthContext: ports.AuthContext{Role: "customer", UserCustomerID: &other},
+	})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected %v, got %v", domain.ErrUnauthorized, err)
+	}
+}
+
+func TestTrackingService_CanTrackDelivery(t *testing.T) {
+	assigned, other := 1, 2
+	deliveryClient := ownerDeliveryClient("1", "1", nil)
+	service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	if ok, err := service.CanTrackDelivery(context.Background(), &authDomain.Claims{Role: "courier", CourierID: &assigned}, 1); !ok || err != nil {
+		t.Errorf("expected assigned courier to be allowed, got %v, %v", ok, err)
+	}
+	if ok, err := service.CanTrackDelivery(context.Background(), &authDomain.Claims{Role: "courier", CourierID: &other}, 1); ok || err != nil {
+		t.Errorf("expected other courier to be denied, got %v, %v", ok, err)
+	}
+
+	// Lookup failures are reported so the hub can fail closed
+	failing := ownerDeliveryClient("1", "1", status.Error(codes.Unavailable, "delivery service down"))
+	service = NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), failing, &MockAuthService{}, nil, createTestLogger(t))
+	if ok, err := service.CanTrackDelivery(context.Background(), &authDomain.Claims{Role: "courier", CourierID: &assigned}, 1); ok || err == nil {
+		t.Errorf("expected lookup error, got %v, %v", ok, err)
+	}
+}
+
+func TestTrackingService_GetCourierLocation(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	mockPublisher := testsupport.NewPublisher()
+	mockDeliveryClient := testsupport.NewDeliveryClient()
+	mockAuthService := &MockAuthService{}
+	testLogger := createTestLogger(t)
+	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, nil, testLogger)
+
+	// Points are a degree apart, far beyond any plausible courier speed
+	service.SetJitterFilter(domain.JitterFilter{})
+
+	ctx := context.Background()
+
+	// Add locations for different deliveries but same courier
+	for i := 1; i <= 3; i++ {
+		req := ports.RecordLocationRequest{
+			DeliveryID: i,
+			CourierID:  1,
+			Latitude:   40.7128 + float64(i),
+			Longitude:  -74.0060 + float64(i),
+		}
+		_, err := service.RecordLocation(ctx, req)
+		if err != nil {
+			t.Fatalf("failed to record location: %v", err)
+		}
+	}
+
+	// Get courier location
+	courierReq := ports.GetCourierLocationRequest{
+		CourierID:   1,
+		AuthContext: adminAuth,
+	}
+
+	location, err := service.GetCourierLocation(ctx, courierReq)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if location == nil {
+		t.Fatal("expected location, got nil")
+	}
+
+	// Should be the latest (highest latitude)
+	if location.Latitude != 40.7128+3 {
+		t.Errorf("expected latest latitude 43.7128, got %f", location.Latitude)
+	}
+}
+
+func TestTrackingService_GetCourierLocationPerOrganization(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	repo.Create(context.Background(), &domain.Location{DeliveryID: 1, CourierID: 1, OrgID: 2, Latitude: 40.7128, Longitude: -74.0060, Timestamp: time.Now()})
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	req := ports.GetCourierLocationRequest{CourierID: 1, AuthContext: adminAuth}
+
+	if _, err := service.GetCourierLocation(authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 2}), req); err != nil {
+		t.Errorf("expected the organization's admin to see its courier, got %v", err)
+	}
+	if _, err := service.GetCourierLocation(authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 3}), req); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for another organization's admin, got %v", err)
+	}
+	superAdmin := ports.GetCourierLocationRequest{CourierID: 1, AuthContext: ports.AuthContext{Role: "super_admin"}}
+	if _, err := service.GetCourierLocation(authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "super_admin"}), superAdmin); err != nil {
+		t.Errorf("expected super-admins to see every courier, got %v", err)
+	}
+}
+
+// courierDeliveriesClient lists the given deliveries
+func courierDeliveriesClient(deliveries []*delivery.Delivery) *testsupport.DeliveryClient {
+	client := testsupport.NewDeliveryClient()
+	client.SetDeliveries(deliveries)
+	return client
+}
+
+func TestTrackingService_GetCourierLocation_Privacy(t *testing.T) {
+	customerID := 5
+	customer := ports.AuthContext{Role: "customer", UserCustomerID: &customerID}
+
+	tests := []struct {
+		name     string
+		status   delivery.DeliveryStatus
+		auth     ports.AuthContext
+		expected error
+	}{
+		{name: "assigned delivery", status: delivery.DeliveryStatus_DELIVERY_STATUS_ASSIGNED, auth: customer},
+		{name: "in-transit delivery", status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT, auth: customer},
+		{name: "only finished deliveries", status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, auth: customer, expected: domain.ErrCourierNotActive},
+		{name: "admin sees inactive couriers", status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, auth: adminAuth},
+		{name: "services see inactive couriers", status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, auth: ports.AuthContext{Role: authDomain.RoleService}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewLocationRepository()
+			repo.Create(context.Background(), &domain.Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, Timestamp: time.Now()})
+			deliveryClient := courierDeliveriesClient([]*delivery.Delivery{
+				{DeliveryId: "1", DriverId: "1", Status: tt.status},
+				{DeliveryId: "2", DriverId: "2", Status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT},
+			})
+			service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+			location, err := service.GetCourierLocation(context.Background(), ports.GetCourierLocationRequest{CourierID: 1, AuthContext: tt.auth})
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if tt.expected == nil && (location == nil || location.CourierID != 1) {
+				t.Errorf("expected courier 1's location, got %+v", location)
+			}
+		})
+	}
+}
+
+func TestTrackingService_GetCourierAverageSpeed(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	ctx := context.Background()
+	req := ports.GetCourierLocationRequest{CourierID: 1}
+
+	speed, err := service.GetCourierAverageSpeed(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if speed != 0 {
+		t.Errorf("expected unknown speed 0 without points, got %f", speed)
+	}
+
+	// Points without a speed don't count towards the average
+	for _, s := range []float64{20, 0, 40} {
+		location, err := domain.NewLocation(1, 1, 40.7128, -74.0060)
+		if err != nil {
+			t.Fatalf("failed to create location: %v", err)
+		}
+		if s > 0 {
+			reported := s
+			location.Speed = &reported
+		}
+		repo.Create(ctx, location)
+	}
+
+	speed, err = service.GetCourierAverageSpeed(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if speed != 30 {
+		t.Errorf("expected average speed 30, got %f", speed)
+	}
+}
+
+// pointAt stores a point for a courier recorded at the given time
+func pointAt(t *testing.T, repo *memory.LocationRepository, deliveryID, courierID int, at time.Time) {
+	location, err := domain.NewLocation(deliveryID, courierID, 40.7128, -74.0060)
+	if err != nil {
+		t.Fatalf("failed to create location: %v", err)
+	}
+	location.Timestamp = at
+	repo.Create(context.Background(), location)
+}
+
+func TestTrackingService_GetCourierStatus(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	service.SetCourierLiveness(domain.CourierLiveness{ActiveWithin: 5 * time.Minute, OfflineAfter: 30 * time.Minute})
+
+	now := time.Now()
+	pointAt(t, repo, 1, 1, now.Add(-4*time.Minute))
+	pointAt(t, repo, 1, 1, now.Add(-time.Minute))
+	pointAt(t, repo, 2, 2, now.Add(-10*time.Minute))
+
+	self, other := 1, 2
+	tests := []struct {
+		name      string
+		courierID int
+		auth      ports.AuthContext
+		status    domain.CourierStatus
+		expected  error
+	}{
+		{name: "admin reads an active courier", courierID: 1, auth: adminAuth, status: domain.CourierStatusActive},
+		{name: "admin reads a stale courier", courierID: 2, auth: adminAuth, status: domain.CourierStatusStale},
+		{name: "admin reads a courier that never reported", courierID: 3, auth: adminAuth, status: domain.CourierStatusOffline},
+		{name: "courier reads themselves", courierID: 1, auth: ports.AuthContext{Role: "courier", UserCourierID: &self}, status: domain.CourierStatusActive},
+		{name: "courier reads another courier", courierID: 2, auth: ports.AuthContext{Role: "courier", UserCourierID: &self}, expected: domain.ErrUnauthorized},
+		{name: "customer", courierID: 2, auth: ports.AuthContext{Role: "customer", UserCustomerID: &other}, expected: domain.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heartbeat, err := service.GetCourierStatus(context.Background(), ports.GetCourierStatusRequest{CourierID: tt.courierID, AuthContext: tt.auth})
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if err != nil {
+				return
+			}
+			if heartbeat.Status != tt.status {
+				t.Errorf("expected status %s, got %s", tt.status, heartbeat.Status)
+			}
+		})
+	}
+
+	// An active courier's status dates from the start of their run of points
+	heartbeat, _ := service.GetCourierStatus(context.Background(), ports.GetCourierStatusRequest{CourierID: 1, AuthContext: adminAuth})
+	if heartbeat.StatusSince == nil || !heartbeat.StatusSince.Equal(now.Add(-4*time.Minute)) {
+		t.Errorf("expected active since the first point, got %v", heartbeat.StatusSince)
+	}
+
+	// Org-admins only read couriers whose latest point is in their organization
+	location, _ := domain.NewLocation(5, 5, 40.7128, -74.0060)
+	location.OrgID = 2
+	repo.Create(context.Background(), location)
+	req := ports.GetCourierStatusRequest{CourierID: 5, AuthContext: adminAuth}
+	if _, err := service.GetCourierStatus(authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 2}), req); err != nil {
+		t.Errorf("expected the organization's admin to read its courier, got %v", err)
+	}
+	if _, err := service.GetCourierStatus(authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 3}), req); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for another organization's admin, got %v", err)
+	}
+	superAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "super_admin"})
+	if _, err := service.GetCourierStatus(superAdmin, ports.GetCourierStatusRequest{CourierID: 5, AuthContext: ports.AuthContext{Role: "super_admin"}}); err != nil {
+		t.Errorf("expected a super admin to read any courier, got %v", err)
+	}
+}
+
+func TestTrackingService_GetCourierDailySummary(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	deliveryClient := testsupport.NewDeliveryClient()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	deliveryClient.SetDeliveries([]*delivery.Delivery{
+		{DeliveryId: "1", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, ActualDelivery: day.Add(10 * time.Hour).Unix()},
+		{DeliveryId: "2", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, ActualDelivery: day.Add(11 * time.Hour).Unix()},
+		{DeliveryId: "3", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, ActualDelivery: day.Add(-time.Hour).Unix()},
+		{DeliveryId: "4", DriverId: "2", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, ActualDelivery: day.Add(10 * time.Hour).Unix()},
+	})
+	// 20 minutes of work, a 45 minute break, then 10 more; points outside the day are ignored
+	pointAt(t, repo, 1, 1, day.Add(9*time.Hour))
+	pointAt(t, repo, 1, 1, day.Add(9*time.Hour+20*time.Minute))
+	pointAt(t, repo, 2, 1, day.Add(10*time.Hour+5*time.Minute))
+	pointAt(t, repo, 2, 1, day.Add(10*time.Hour+15*time.Minute))
+	pointAt(t, repo, 3, 1, day.Add(-10*time.Minute))
+	pointAt(t, repo, 4, 2, day.Add(9*time.Hour+5*time.Minute))
+
+	self, other := 1, 2
+	tests := []struct {
+		name      string
+		courierID int
+		auth      ports.AuthContext
+		expected  error
+	}{
+		{name: "courier reads themselves", courierID: 1, auth: ports.AuthContext{Role: "courier", UserCourierID: &self}},
+		{name: "admin reads any courier", courierID: 1, auth: adminAuth},
+		{name: "courier reads another courier", courierID: 2, auth: ports.AuthContext{Role: "courier", UserCourierID: &self}, expected: domain.ErrUnauthorized},
+		{name: "customer", courierID: 1, auth: ports.AuthContext{Role: "customer", UserCustomerID: &other}, expected: domain.ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := service.GetCourierDailySummary(context.Background(), ports.GetCourierDailySummaryRequest{
+				CourierID: tt.courierID, Date: day.Add(12 * time.Hour), AuthContext: tt.auth,
+			})
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if err != nil {
+				return
+			}
+			if summary.DeliveriesCompleted != 2 || summary.PointCount != 4 {
+				t.Errorf("expected 2 deliveries and 4 points, got %+v", summary)
+			}
+			if summary.ActiveTime != 30*time.Minute || summary.AveragePerDelivery != 15*time.Minute {
+				t.Errorf("expected 30m active and 15m per delivery, got %s and %s", summary.ActiveTime, summary.AveragePerDelivery)
+			}
+		})
+	}
+
+	// The finished day was computed once and then cached
+	if calls := deliveryClient.Calls("GetDriverDeliveries"); calls != 1 {
+		t.Errorf("expected one delivery service call for a finished day, got %d", calls)
+	}
+
+	// Today is still changing, so it is recomputed each time
+	today := ports.GetCourierDailySummaryRequest{CourierID: 1, Date: time.Now(), AuthContext: adminAuth}
+	service.GetCourierDailySummary(context.Background(), today)
+	service.GetCourierDailySummary(context.Background(), today)
+	if calls := deliveryClient.Calls("GetDriverDeliveries"); calls != 3 {
+		t.Errorf("expected today to be recomputed, got %d calls", calls)
+	}
+
+	_, err := service.GetCourierDailySummary(context.Background(), ports.GetCourierDailySummaryRequest{
+		CourierID: 1, Date: time.Now().AddDate(0, 0, 2), AuthContext: adminAuth,
+	})
+	if !errors.Is(err, domain.ErrFutureSummaryDate) {
+		t.Errorf("expected ErrFutureSummaryDate, got %v", err)
+	}
+
+	// Courier 1 didn't report in organization 2, so its admin may not read them
+	orgAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 2})
+	_, err = service.GetCourierDailySummary(orgAdmin, ports.GetCourierDailySummaryRequest{
+		CourierID: 1, Date: day.Add(12 * time.Hour), AuthContext: adminAuth,
+	})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for another organization's admin, got %v", err)
+	}
+}
+
+func TestTrackingService_GetFleetLocations(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	deliveryClient := testsupport.NewDeliveryClient()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	deliveryClient.SetDeliveries([]*delivery.Delivery{
+		{DeliveryId: "10", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT},
+		{DeliveryId: "11", DriverId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_ASSIGNED},
+		{DeliveryId: "12", DriverId: "2", Status: delivery.DeliveryStatus_DELIVERY_STATUS_ASSIGNED},
+		{DeliveryId: "13", DriverId: "4", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED},
+		{DeliveryId: "14", Status: delivery.DeliveryStatus_DELIVERY_STATUS_ASSIGNED},
+	})
+
+	now := time.Now()
+	at := func(courierID int, lat, lng float64, ts time.Time) {
+		t.Helper()
+		location, err := domain.NewLocation(courierID*10, courierID, lat, lng)
+		if err != nil {
+			t.Fatalf("failed to create location: %v", err)
+		}
+		location.Timestamp = ts
+		repo.Create(context.Background(), location)
+	}
+	// Courier 1 moved out of Manhattan into London; courier 2 is stale in Manhattan
+	at(1, 40.7128, -74.006, now.Add(-3*time.Minute))
+	at(1, 51.5, -0.12, now.Add(-time.Minute))
+	at(2, 40.75, -73.99, now.Add(-10*time.Minute))
+	at(3, 40.76, -73.98, now.Add(-time.Minute)) // no active delivery
+	at(4, 40.77, -73.97, now.Add(-2*time.Hour)) // too long ago
+	manhattan := &domain.BoundingBox{MinLongitude: -74.1, MinLatitude: 40.6, MaxLongitude: -73.9, MaxLatitude: 40.9}
+
+	ids := func(fleet []domain.FleetCourier) []int {
+		var got []int
+		for _, c := range fleet {
+			got = append(got, c.Location.CourierID)
+		}
+		return got
+	}
+
+	fleet, truncated, err := service.GetFleetLocations(context.Background(), ports.GetFleetLocationsRequest{ActiveOnly: true, AuthContext: adminAuth})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(fleet); truncated || len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("expected active couriers 1 and 2, got %v (truncated %v)", got, truncated)
+	}
+	if fleet[0].Location.Latitude != 51.5 || fleet[0].Status != domain.CourierStatusActive || len(fleet[0].Deliveries) != 2 {
+		t.Errorf("expected courier 1's latest point and both deliveries, got %+v", fleet[0])
+	}
+	if fleet[1].Status != domain.CourierStatusStale || fleet[1].Deliveries[0] != (domain.FleetDelivery{DeliveryID: 12, Status: "assigned"}) {
+		t.Errorf("unexpected courier 2 %+v", fleet[1])
+	}
+
+	// The box applies to each courier's latest point, so courier 1 has left it
+	fleet, _, err = service.GetFleetLocations(context.Background(), ports.GetFleetLocationsRequest{Box: manhattan, AuthContext: adminAuth})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(fleet); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("expected couriers 2 and 3 in the box, got %v", got)
+	}
+
+	service.SetFleetMapLimit(1)
+	fleet, truncated, _ = service.GetFleetLocations(context.Background(), ports.GetFleetLocationsRequest{AuthContext: adminAuth})
+	if len(fleet) != 1 || !truncated {
+		t.Errorf("expected one courier and truncated, got %d (truncated %v)", len(fleet), truncated)
+	}
+
+	// Org-admins only see couriers who reported in their organization
+	service.SetFleetMapLimit(DefaultFleetMapLimit)
+	other, _ := domain.NewLocation(50, 5, 40.78, -73.96)
+	other.OrgID = 2
+	repo.Create(context.Background(), other)
+	orgAdmin := authctx.WithClaims(context.Background(), &authDomain.Claims{Role: "admin", OrgID: 2})
+	fleet, _, err = service.GetFleetLocations(orgAdmin, ports.GetFleetLocationsRequest{AuthContext: adminAuth})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(fleet); len(got) != 1 || got[0] != 5 {
+		t.Errorf("expected only organization 2's courier 5, got %v", got)
+	}
+	fleet, _, _ = service.GetFleetLocations(context.Background(), ports.GetFleetLocationsRequest{AuthContext: adminAuth})
+	if got := ids(fleet); len(got) != 4 {
+		t.Errorf("expected every organization's couriers unscoped, got %v", got)
+	}
+
+	courierID := 1
+	_, _, err = service.GetFleetLocations(context.Background(), ports.GetFleetLocationsRequest{AuthContext: ports.AuthContext{Role: "courier", UserCourierID: &courierID}})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized for a courier, got %v", err)
+	}
+}
+
+func TestTrackingService_DeliveryCircuitBreaker(t *testing.T) {
+	deliveryClient := testsupport.NewDeliveryClient()
+	service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	service.SetDeliveryCircuitBreaker(resilience.NewCircuitBreakerWithConfig("delivery", config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute}))
+
+	deliveryClient.SetError("ListDeliveries", errors.New("connection refused"))
+	req := ports.GetFleetLocationsRequest{AuthContext: adminAuth}
+	if _, _, err := service.GetFleetLocations(context.Background(), req); err == nil {
+		t.Fatal("expected the delivery service failure")
+	}
+	if state := service.DeliveryCircuitState(); state != resilience.StateOpen {
+		t.Fatalf("expected the circuit open, got %s", state)
+	}
+
+	_, _, err := service.GetFleetLocations(context.Background(), req)
+	var open *resilience.OpenError
+	if !errors.As(err, &open) || open.Breaker != "delivery" {
+		t.Errorf("expected an open circuit error, got %v", err)
+	}
+	if calls := deliveryClient.Calls("ListDeliveries"); calls != 1 {
+		t.Errorf("expected the open circuit to skip the delivery service, got %d calls", calls)
+	}
+}
+
+// inTransitDeliveryClient lists fixed in-transit deliveries and records the
+// authorization each listing was made with
+type inTransitDeliveryClient struct {
+	*testsupport.DeliveryClient
+	deliveries     []*delivery.Delivery
+	authorizations chan string
+}
+
+func (m *inTransitDeliveryClient) ListDeliveries(ctx context.Context, in *delivery.ListDeliveriesRequest, opts ...grpc.CallOption) (*delivery.ListDeliveriesResponse, error) {
+	if in.Status != delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected status %v", in.Status)
+	}
+	select {
+	case m.authorizations <- authctx.AuthorizationFrom(ctx):
+	default:
+	}
+	return &delivery.ListDeliveriesResponse{Deliveries: m.deliveries}, nil
+}
+
+func TestTrackingService_CheckStaleCouriers(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	publisher := testsupport.NewPublisher()
+	deliveryClient := &inTransitDeliveryClient{
+		DeliveryClient: testsupport.NewDeliveryClient(),
+		deliveries: []*delivery.Delivery{
+			{DeliveryId: "10", CustomerId: "5", DriverId: "1"},
+			{DeliveryId: "11", CustomerId: "6", DriverId: "2"},
+			{DeliveryId: "12", CustomerId: "7"}, // unassigned
+		},
+		authorizations: make(chan string, 1),
+	}
+	service := NewTrackingService(repo, publisher, deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	service.SetWebSocketHub(nil)
+	service.SetCourierLiveness(domain.CourierLiveness{ActiveWithin: 5 * time.Minute, OfflineAfter: 30 * time.Minute})
+	service.SetServiceToken(func() (string, error) { return "service-token", nil })
+
+	now := time.Now()
+	pointAt(t, repo, 10, 1, now.Add(-20*time.Minute))
+	pointAt(t, repo, 11, 2, now.Add(-time.Minute))
+
+	staleEvents := func() []messaging.Event {
+		var events []messaging.Event
+		for _, event := range publisher.Events() {
+			if event.Type == messaging.EventTypeCourierStale {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	service.checkStaleCouriers(context.Background())
+
+	if auth := <-deliveryClient.authorizations; auth != "Bearer service-token" {
+		t.Errorf("expected the service token to be presented, got %q", auth)
+	}
+	events := staleEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 stale courier event, got %d", len(events))
+	}
+	data, err := messaging.DecodeData[messaging.CourierStaleEvent](events[0])
+	if err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if data.DeliveryID != 10 || data.CourierID != 1 || data.CustomerID != 5 || data.LastSeenAt == nil {
+		t.Errorf("unexpected event payload: %+v", data)
+	}
+
+	// The same silence is only reported once
+	service.checkStaleCouriers(context.Background())
+	if len(staleEvents()) != 1 {
+		t.Errorf("expected no repeat alert, got %d events", len(staleEvents()))
+	}
+
+	// A later point that is still too old starts a new silence
+	pointAt(t, repo, 10, 1, now.Add(-10*time.Minute))
+	service.checkStaleCouriers(context.Background())
+	if len(staleEvents()) != 2 {
+		t.Errorf("expected a second alert after the courier reported again, got %d events", len(staleEvents()))
+	}
+}
+
+func TestTrackingService_StaleCourierChecker_Shutdown(t *testing.T) {
+	deliveryClient := &inTransitDeliveryClient{DeliveryClient: testsupport.NewDeliveryClient(), authorizations: make(chan string, 1)}
+	service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+
+	service.StartStaleCourierChecker(time.Millisecond)
+
+	select {
+	case <-deliveryClient.authorizations:
+	case <-time.After(time.Second):
+		t.Fatal("expected the checker to scan in-transit deliveries")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		service.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Shutdown to stop the checker")
+	}
+}
+
+// MockTrackSummaryRepository keeps track summaries by delivery ID
+type MockTrackSummaryRepository struct {
+	summaries map[int]*domain.TrackSummary
+	err       error
+}
+
+func NewMockTrackSummaryRepository() *MockTrackSummaryRepository {
+	return &MockTrackSummaryRepository{summaries: make(map[int]*domain.TrackSummary)}
+}
+
+func (m *MockTrackSummaryRepository) SaveSummary(ctx context.Context, summary *domain.TrackSummary) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.summaries[summary.DeliveryID] = summary
+	return nil
+}
+
+// recordTrack stores points for a delivery one minute apart, 100 m north each
+func recordTrack(repo *memory.LocationRepository, deliveryID, points int, start time.Time) {
+	for i := 0; i < points; i++ {
+		repo.Create(context.Background(), &domain.Location{
+			DeliveryID: deliveryID,
+			CourierID:  1,
+			Latitude:   40.0 + float64(i)*0.0009,
+			Longitude:  -74.0,
+			Timestamp:  start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+}
+
+func TestTrackingService_PurgeExpiredTracks(t *testing.T) {
+	now := time.Now()
+	cutoff := now.Add(-7 * 24 * time.Hour)
+	old := cutoff.Add(-time.Hour).Unix()
+	recent := cutoff.Add(time.Hour).Unix()
+
+	repo := memory.NewLocationRepository()
+	for id := 1; id <= 5; id++ {
+		recordTrack(repo, id, 3, time.Unix(old, 0).Add(-time.Hour))
+	}
+	deliveryClient := courierDeliveriesClient([]*delivery.Delivery{
+		{DeliveryId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, UpdatedAt: old},
+		{DeliveryId: "2", Status: delivery.DeliveryStatus_DELIVERY_STATUS_CANCELLED, UpdatedAt: old},
+		{DeliveryId: "3", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, UpdatedAt: recent},
+		{DeliveryId: "4", Status: delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT, UpdatedAt: old},
+		{DeliveryId: "5", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED},
+	})
+	summaries := NewMockTrackSummaryRepository()
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	service.SetTrackSummaryRepository(summaries)
+
+	service.purgeExpiredTracks(context.Background(), cutoff)
+
+	for id, purged := range map[int]bool{1: true, 2: true, 3: false, 4: false, 5: false} {
+		if got := repo.Count(id) == 0; got != purged {
+			t.Errorf("delivery %d: expected purged %v, got %v", id, purged, got)
+		}
+		if _, ok := summaries.summaries[id]; ok != purged {
+			t.Errorf("delivery %d: expected a summary %v, got %v", id, purged, ok)
+		}
+	}
+
+	summary := summaries.summaries[1]
+	if summary.PointCount != 3 || summary.Duration != 2*time.Minute || summary.Reason != domain.PurgeReasonRetention {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.DistanceKm < 0.19 || summary.DistanceKm > 0.21 {
+		t.Errorf("expected about 0.2 km, got %.3f", summary.DistanceKm)
+	}
+	if tracks, points := service.PurgedTracks(); tracks != 2 || points != 6 {
+		t.Errorf("expected 2 tracks and 6 points purged, got %d and %d", tracks, points)
+	}
+
+	// A second run finds nothing left and keeps the summaries
+	service.purgeExpiredTracks(context.Background(), cutoff)
+	if tracks, _ := service.PurgedTracks(); tracks != 2 {
+		t.Errorf("expected no further purges, got %d tracks", tracks)
+	}
+	if summaries.summaries[1] != summary {
+		t.Error("expected the first summary to be kept")
+	}
+}
+
+func TestTrackingService_PurgeExpiredTracks_KeepsPointsWhenSummaryFails(t *testing.T) {
+	cutoff := time.Now()
+	repo := memory.NewLocationRepository()
+	recordTrack(repo, 1, 3, cutoff.Add(-2*time.Hour))
+	deliveryClient := courierDeliveriesClient([]*delivery.Delivery{
+		{DeliveryId: "1", Status: delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED, UpdatedAt: cutoff.Add(-time.Hour).Unix()},
+	})
+	summaries := NewMockTrackSummaryRepository()
+	summaries.err = errors.New("mongo unavailable")
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	service.SetTrackSummaryRepository(summaries)
+
+	service.purgeExpiredTracks(context.Background(), cutoff)
+
+	if repo.Count(1) != 3 {
+		t.Errorf("expected the points to be kept, got %d", repo.Count(1))
+	}
+}
+
+func TestTrackingService_EraseDeliveryTrack(t *testing.T) {
+	customerID := 1
+	tests := []struct {
+		name          string
+		auth          ports.AuthContext
+		points        int
+		orgID         int  // the caller is this organization's admin
+		hidden        bool // the delivery service hides the delivery from the caller
+		expectedErr   error
+		expectSummary bool
+	}{
+		{name: "admin", auth: adminAuth, points: 4, expectSummary: true},
+		{name: "no points", auth: adminAuth},
+		{name: "organization's admin", auth: adminAuth, orgID: 2, points: 4, expectSummary: true},
+		{name: "another organization's admin", auth: adminAuth, orgID: 3, hidden: true, points: 4, expectedErr: domain.ErrUnauthorized},
+		{name: "customer", auth: ports.AuthContext{Role: "customer", UserCustomerID: &customerID}, points: 4, expectedErr: domain.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewLocationRepository()
+			recordTrack(repo, 1, tt.points, time.Now().Add(-time.Hour))
+			cache := NewMockLocationCache()
+			cache.SetLatest(context.Background(), &domain.Location{DeliveryID: 1})
+			publisher := testsupport.NewPublisher()
+			summaries := NewMockTrackSummaryRepository()
+			deliveryClient := testsupport.NewDeliveryClient()
+			if tt.hidden {
+				deliveryClient.SetError("GetDelivery", status.Error(codes.NotFound, "delivery not found"))
+			}
+			service := NewTrackingService(repo, publisher, deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+			service.SetTrackSummaryRepository(summaries)
+			service.SetLocationCache(cache)
+
+			ctx := context.Background()
+			if tt.orgID != 0 {
+				ctx = authctx.WithClaims(ctx, &authDomain.Claims{Role: "admin", OrgID: tt.orgID})
+			}
+			summary, deleted, err := service.EraseDeliveryTrack(ctx, ports.EraseDeliveryTrackRequest{
+				DeliveryID:  1,
+				UserID:      9,
+				AuthContext: tt.auth,
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr != nil {
+				if repo.Count(1) != tt.points || len(publisher.Events()) != 0 {
+					t.Error("expected nothing erased or published")
+				}
+				return
+			}
+
+			if (summary != nil) != tt.expectSummary || deleted != int64(tt.points) {
+				t.Fatalf("expected summary %v and %d deleted, got %+v and %d", tt.expectSummary, tt.points, summary, deleted)
+			}
+			if repo.Count(1) != 0 {
+				t.Error("expected the points to be deleted")
+			}
+			if tt.expectSummary {
+				if summaries.summaries[1] == nil || summaries.summaries[1].Reason != domain.PurgeReasonErasure {
+					t.Errorf("expected an erasure summary, got %+v", summaries.summaries[1])
+				}
+				if _, found, _ := cache.GetLatest(context.Background(), 1); found {
+					t.Error("expected the cached location to be dropped")
+				}
+			}
+
+			if len(publisher.Events()) != 1 {
+				t.Fatalf("expected one audit event, got %d", len(publisher.Events()))
+			}
+			event := publisher.Events()[0]
+			if event.Type != messaging.EventTypeTrackErased || event.Data["requested_by"] != float64(9) || event.Data["points_deleted"] != float64(tt.points) {
+				t.Errorf("unexpected audit event: %+v", event)
+			}
+		})
+	}
+}
+
+func TestTrackingService_CalculateETAToDestination(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	mockPublisher := testsupport.NewPublisher()
+	mockDeliveryClient := testsupport.NewDeliveryClient()
+	mockAuthService := &MockAuthService{}
+	testLogger := createTestLogger(t)
+	service := NewTrackingService(repo, mockPublisher, mockDeliveryClient, mockAuthService, nil, testLogger)
+
+	ctx := context.Background()
+
+	// Add current location
+	req := ports.RecordLocationRequest{
+		DeliveryID: 1,
+		CourierID:  1,
+		Latitude:   40.7128, // NYC
+		Longitude:  -74.0060,
+	}
+	_, err := service.RecordLocation(ctx, req)
+	if err != nil {
+		t.Fatalf("failed to record location: %v", err)
+	}
+
+	// Calculate ETA to destination
+	etaReq := ports.CalculateETAToDestinationRequest{
+		DeliveryID:  1,
+		DestLat:     40.7589, // Times Square (about 5km away)
+		DestLng:     -73.9851,
+		AuthContext: adminAuth,
+	}
+
+	eta, err := service.CalculateETAToDestination(ctx, etaReq)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if eta == nil {
+		t.Fatal("expected ETA response, got nil")
+	}
+
+	if eta.DistanceKm <= 0 {
+		t.Errorf("expected positive distance, got %f", eta.DistanceKm)
+	}
+
+	if eta.AverageSpeed != 25.0 {
+		t.Errorf("expected average speed 25.0, got %f", eta.AverageSpeed)
+	}
+
+	if eta.ETA <= 0 {
+		t.Errorf("expected positive ETA duration, got %v", eta.ETA)
 	}
 
 	// ETA should be distance / speed
@@ -562,3 +1959,254 @@ func TestTrackingService_CalculateETAToDestination(t *testing.T) {
 			expectedETAMinutes, actualETAMinutes)
 	}
 }
+
+func TestTrackingService_PushETAUpdate(t *testing.T) {
+	publisher := testsupport.NewPublisher()
+	service := NewTrackingService(memory.NewLocationRepository(), publisher, testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	service.SetWebSocketHub(nil)
+	service.SetETAUpdatePolicy(domain.ETAUpdatePolicy{MinInterval: time.Minute, MinChange: 2 * time.Minute})
+
+	now := time.Now()
+	service.etaUpdates.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	d := &delivery.Delivery{
+		CustomerId:       "3",
+		DeliveryLocation: &common.Location{Latitude: 40.7589, Longitude: -73.9851},
+		Status:           delivery.DeliveryStatus_DELIVERY_STATUS_IN_TRANSIT,
+	}
+	far, _ := domain.NewLocation(1, 7, 40.7128, -74.0060)
+
+	// The first point for a delivery always pushes
+	service.pushETAUpdate(ctx, far, d)
+	if len(publisher.Events()) != 1 {
+		t.Fatalf("expected 1 ETA event, got %d", len(publisher.Events()))
+	}
+	event := publisher.Events()[0]
+	data, err := messaging.DecodeData[messaging.DeliveryETAUpdatedEvent](event)
+	if err != nil {
+		t.Fatalf("failed to decode ETA event: %v", err)
+	}
+	if event.Type != messaging.EventTypeDeliveryETAUpdated || data.DeliveryID != 1 || data.CustomerID != 3 || data.CourierID != 7 {
+		t.Errorf("unexpected ETA event: %+v", data)
+	}
+	if data.DistanceRemainingKm < 5 || data.DistanceRemainingKm > 6 {
+		t.Errorf("expected about 5.4 km remaining, got %f", data.DistanceRemainingKm)
+	}
+	if want := int64(domain.EstimateETA(data.DistanceRemainingKm) / time.Second); data.ETASeconds != want {
+		t.Errorf("expected ETA of %d seconds, got %d", want, data.ETASeconds)
+	}
+	if !data.EstimatedArrival.Equal(far.Timestamp.Add(time.Duration(data.ETASeconds) * time.Second)) {
+		t.Errorf("unexpected estimated arrival %v", data.EstimatedArrival)
+	}
+
+	// A small change within the interval is throttled
+	now = now.Add(10 * time.Second)
+	service.pushETAUpdate(ctx, far, d)
+	if len(publisher.Events()) != 1 {
+		t.Errorf("expected the ETA push to be throttled, got %d events", len(publisher.Events()))
+	}
+
+	// A large change pushes before the interval is up
+	near, _ := domain.NewLocation(1, 7, 40.7500, -73.9880)
+	service.pushETAUpdate(ctx, near, d)
+	if len(publisher.Events()) != 2 {
+		t.Errorf("expected a large ETA change to push, got %d events", len(publisher.Events()))
+	}
+
+	// Once the interval is up an unchanged ETA is pushed again
+	now = now.Add(time.Minute)
+	service.pushETAUpdate(ctx, near, d)
+	if len(publisher.Events()) != 3 {
+		t.Errorf("expected a push after the interval, got %d events", len(publisher.Events()))
+	}
+
+	// A drop-off that was never geocoded gives no distance to estimate from
+	ungeocoded := &delivery.Delivery{CustomerId: "3", DeliveryLocation: &common.Location{Address: "456 Oak Ave"}, Status: d.Status}
+	now = now.Add(time.Hour)
+	service.pushETAUpdate(ctx, near, ungeocoded)
+	if len(publisher.Events()) != 3 {
+		t.Errorf("expected no push without drop-off coordinates, got %d events", len(publisher.Events()))
+	}
+
+	// Terminal deliveries push nothing and drop their state
+	d.Status = delivery.DeliveryStatus_DELIVERY_STATUS_DELIVERED
+	service.pushETAUpdate(ctx, near, d)
+	if len(publisher.Events()) != 3 {
+		t.Errorf("expected no push for a delivered delivery, got %d events", len(publisher.Events()))
+	}
+	if len(service.etaUpdates.pushed) != 0 {
+		t.Errorf("expected delivered delivery state to be dropped, got %v", service.etaUpdates.pushed)
+	}
+}
+
+func TestETAThrottle_ConcurrentClaims(t *testing.T) {
+	throttle := newETAThrottle(domain.ETAUpdatePolicy{MinInterval: time.Minute, MinChange: 2 * time.Minute})
+
+	var wg sync.WaitGroup
+	var claimed atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(deliveryID int) {
+			defer wg.Done()
+			if throttle.claim(deliveryID, 10*time.Minute) {
+				claimed.Add(1)
+			}
+		}(1 + i%2)
+	}
+	wg.Wait()
+
+	if claimed.Load() != 2 {
+		t.Errorf("expected one push per delivery, got %d", claimed.Load())
+	}
+}
+
+func TestETAThrottle_SweepsIdleDeliveries(t *testing.T) {
+	throttle := newETAThrottle(domain.DefaultETAUpdatePolicy())
+	now := time.Now()
+	throttle.now = func() time.Time { return now }
+
+	throttle.claim(1, 10*time.Minute)
+	now = now.Add(etaStateTTL)
+	throttle.claim(2, 10*time.Minute)
+
+	if _, ok := throttle.pushed[1]; ok {
+		t.Error("expected an idle delivery to be swept")
+	}
+	if _, ok := throttle.pushed[2]; !ok {
+		t.Error("expected the active delivery to be kept")
+	}
+}
+
+func TestTrackingService_FindZones(t *testing.T) {
+	service := NewTrackingService(memory.NewLocationRepository(), testsupport.NewPublisher(), testsupport.NewDeliveryClient(), &MockAuthService{}, nil, createTestLogger(t))
+	customerID := 1
+	auth := ports.AuthContext{Role: "customer", UserCustomerID: &customerID}
+
+	if _, err := service.FindZones(context.Background(), ports.FindZonesRequest{Latitude: 40, AuthContext: auth}); !errors.Is(err, domain.ErrZonesUnavailable) {
+		t.Errorf("expected ErrZonesUnavailable without a zone repository, got %v", err)
+	}
+
+	service.SetZoneRepository(&MockZoneRepository{zones: map[float64][]string{40.0: {"downtown", "metro"}}})
+	zones, err := service.FindZones(context.Background(), ports.FindZonesRequest{Latitude: 40, Longitude: -73, AuthContext: auth})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(zones) != 2 || zones[0] != "downtown" {
+		t.Errorf("expected the zones containing the point, got %v", zones)
+	}
+
+	if _, err := service.FindZones(context.Background(), ports.FindZonesRequest{Latitude: 91, AuthContext: auth}); !errors.Is(err, domain.ErrInvalidLocation) {
+		t.Errorf("expected ErrInvalidLocation, got %v", err)
+	}
+}
+
+// slowDeliveryClient answers delivery lookups after delay, or fails them once
+// the caller's deadline passes
+type slowDeliveryClient struct {
+	*testsupport.DeliveryClient
+	delay time.Duration
+}
+
+func (m *slowDeliveryClient) GetDelivery(ctx context.Context, in *delivery.GetDeliveryRequest, opts ...grpc.CallOption) (*delivery.GetDeliveryResponse, error) {
+	select {
+	case <-time.After(m.delay):
+		return m.DeliveryClient.GetDelivery(ctx, in, opts...)
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func TestTrackingService_DeliveryLookupDeadline(t *testing.T) {
+	own, other := 1, 2
+	tests := []struct {
+		name      string
+		auth      ports.AuthContext
+		lastOwner *deliveryOwner
+		expected  error
+	}{
+		{name: "super-admin", auth: ports.AuthContext{Role: "super_admin"}},
+		{name: "admin", auth: adminAuth, expected: domain.ErrDeliveryUnavailable},
+		{name: "courier who reported the location", auth: ports.AuthContext{Role: "courier", UserCourierID: &own}},
+		{name: "other courier", auth: ports.AuthContext{Role: "courier", UserCourierID: &other}, expected: domain.ErrDeliveryUnavailable},
+		{name: "customer without a known owner", auth: ports.AuthContext{Role: "customer", UserCustomerID: &own}, expected: domain.ErrDeliveryUnavailable},
+		{name: "last known owning customer", auth: ports.AuthContext{Role: "customer", UserCustomerID: &own},
+			lastOwner: &deliveryOwner{customerID: "1", courierID: "1"}},
+		{name: "customer not the last known owner", auth: ports.AuthContext{Role: "customer", UserCustomerID: &other},
+			lastOwner: &deliveryOwner{customerID: "1", courierID: "1"}, expected: domain.ErrDeliveryUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewLocationRepository()
+			repo.Create(context.Background(), &domain.Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, Timestamp: time.Now()})
+			deliveryClient := &slowDeliveryClient{DeliveryClient: ownerDeliveryClient("1", "1", nil), delay: time.Second}
+			service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+			service.SetDeliveryLookupTimeout(20 * time.Millisecond)
+			if tt.lastOwner != nil {
+				// Cached long enough ago to have gone stale
+				service.owners.put(1, *tt.lastOwner)
+				service.owners.now = func() time.Time { return time.Now().Add(time.Hour) }
+			}
+			ctx := context.Background()
+
+			start := time.Now()
+			location, _, err := service.GetCurrentLocation(ctx, ports.GetCurrentLocationRequest{DeliveryID: 1, AuthContext: tt.auth})
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("expected the lookup to give up at its deadline, took %v", elapsed)
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("GetCurrentLocation: expected %v, got %v", tt.expected, err)
+			}
+			if err == nil {
+				want := domain.DeliveryContextUnavailable
+				if tt.auth.Role == "super_admin" {
+					want = "" // super-admins are never looked up
+				}
+				if location.DeliveryContext != want {
+					t.Errorf("expected delivery context %q, got %q", want, location.DeliveryContext)
+				}
+			}
+
+			if tt.auth.Role == "super_admin" {
+				return
+			}
+			_, err = service.CalculateETAToDestination(ctx, ports.CalculateETAToDestinationRequest{DeliveryID: 1, DestLat: 40.7589, DestLng: -73.9851, AuthContext: tt.auth})
+			// ETAs are refused rather than computed for a delivery that can't be checked
+			if !errors.Is(err, domain.ErrDeliveryUnavailable) {
+				t.Errorf("CalculateETAToDestination: expected %v, got %v", domain.ErrDeliveryUnavailable, err)
+			}
+		})
+	}
+}
+
+func TestTrackingService_DeliveryLookupDeadlineOpensCircuit(t *testing.T) {
+	repo := memory.NewLocationRepository()
+	deliveryClient := &slowDeliveryClient{DeliveryClient: ownerDeliveryClient("1", "1", nil), delay: time.Second}
+	service := NewTrackingService(repo, testsupport.NewPublisher(), deliveryClient, &MockAuthService{}, nil, createTestLogger(t))
+	service.SetDeliveryLookupTimeout(10 * time.Millisecond)
+	service.SetDeliveryCircuitBreaker(resilience.NewCircuitBreaker("delivery", 2, time.Minute))
+	own := 1
+	auth := ports.AuthContext{Role: "customer", UserCustomerID: &own}
+
+	for i := 0; i < 2; i++ {
+		_, err := service.CalculateETAToDestination(context.Background(), ports.CalculateETAToDestinationRequest{DeliveryID: 1, DestLat: 1, DestLng: 1, AuthContext: auth})
+		if !errors.Is(err, domain.ErrDeliveryUnavailable) || status.Code(err) != codes.DeadlineExceeded {
+			t.Fatalf("expected a timed out lookup, got %v", err)
+		}
+	}
+	if service.DeliveryCircuitState() != resilience.StateOpen {
+		t.Fatalf("expected timed out lookups to open the circuit, got %v", service.DeliveryCircuitState())
+	}
+
+	// Once open, lookups fail without waiting for the delivery service
+	start := time.Now()
+	_, err := service.CalculateETAToDestination(context.Background(), ports.CalculateETAToDestinationRequest{DeliveryID: 1, DestLat: 1, DestLng: 1, AuthContext: auth})
+	var open *resilience.OpenError
+	if !errors.Is(err, domain.ErrDeliveryUnavailable) || !errors.As(err, &open) {
+		t.Errorf("expected an open circuit error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected an open circuit to fail fast, took %v", elapsed)
+	}
+}
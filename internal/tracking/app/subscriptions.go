@@ -0,0 +1,74 @@
+package app
+
+import (
+	"sync"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+)
+
+// subscriptionBuffer is how many undelivered updates a subscriber may lag behind
+// before further updates are dropped for it
+const subscriptionBuffer = 16
+
+// locationSubscription receives the locations recorded for one delivery
+type locationSubscription struct {
+	deliveryID int
+	updates    chan *domain.Location
+}
+
+// locationBroker fans recorded locations out to per-delivery subscribers
+type locationBroker struct {
+	mu          sync.Mutex
+	subscribers map[int]map[*locationSubscription]struct{}
+}
+
+func newLocationBroker() *locationBroker {
+	return &locationBroker{subscribers: make(map[int]map[*locationSubscription]struct{})}
+}
+
+// subscribe registers a subscriber for a delivery's locations
+func (b *locationBroker) subscribe(deliveryID int) *locationSubscription {
+	sub := &locationSubscription{
+		deliveryID: deliveryID,
+		updates:    make(chan *domain.Location, subscriptionBuffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[deliveryID] == nil {
+		b.subscribers[deliveryID] = make(map[*locationSubscription]struct{})
+	}
+	b.subscribers[deliveryID][sub] = struct{}{}
+	return sub
+}
+
+// unsubscribe removes a subscriber; its channel is left open so a concurrent
+// publish never sends on a closed channel
+func (b *locationBroker) unsubscribe(sub *locationSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers[sub.deliveryID], sub)
+	if len(b.subscribers[sub.deliveryID]) == 0 {
+		delete(b.subscribers, sub.deliveryID)
+	}
+}
+
+// publish hands a location to every subscriber of its delivery without blocking
+func (b *locationBroker) publish(location *domain.Location) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers[location.DeliveryID] {
+		select {
+		case sub.updates <- location:
+		default:
+			// Slow subscriber; it catches up with the next point
+		}
+	}
+}
+
+// count returns the number of subscribers for a delivery
+func (b *locationBroker) count(deliveryID int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[deliveryID])
+}
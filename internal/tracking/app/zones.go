@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/pkg/resilience"
+	"go.uber.org/zap"
+)
+
+// Zone transition settings
+const (
+	// zoneCacheTTL is how long a courier's zones are reused before re-querying
+	zoneCacheTTL = 15 * time.Second
+	// zoneCheckTimeout bounds the geo queries and publishes for one point
+	zoneCheckTimeout = 10 * time.Second
+	// zoneIdleTTL is how long a courier who sends no points keeps their zone
+	// state, so finished and offline couriers don't pile up
+	zoneIdleTTL = 30 * time.Minute
+)
+
+// courierZones is the last known set of zones for a courier
+type courierZones struct {
+	zones     []string
+	checkedAt time.Time
+	seenAt    time.Time // when the courier last sent a point
+}
+
+// zoneTracker remembers which zones each courier was last seen in. Couriers
+// idle for longer than idleTTL are forgotten, as if never seen.
+type zoneTracker struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	idleTTL   time.Duration
+	couriers  map[int]*courierZones
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// newZoneTracker creates a tracker that caches zone lookups for ttl
+func newZoneTracker(ttl time.Duration) *zoneTracker {
+	return &zoneTracker{
+		ttl:      ttl,
+		idleTTL:  zoneIdleTTL,
+		couriers: make(map[int]*courierZones),
+		now:      time.Now,
+	}
+}
+
+// idle reports whether entry belongs to a courier not seen for idleTTL
+func (t *zoneTracker) idle(entry *courierZones, now time.Time) bool {
+	return now.Sub(entry.seenAt) > t.idleTTL
+}
+
+// sweep drops idle couriers, at most once per idleTTL. The caller must hold mu.
+func (t *zoneTracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.idleTTL {
+		return
+	}
+	t.lastSweep = now
+	for courierID, entry := range t.couriers {
+		if t.idle(entry, now) {
+			delete(t.couriers, courierID)
+		}
+	}
+}
+
+// known reports whether the courier has tracked zone state
+func (t *zoneTracker) known(courierID int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.couriers[courierID]
+	return ok && !t.idle(entry, t.now())
+}
+
+// claim returns the courier's previous zones when a new lookup is due. It
+// returns due=false while the cached zones are still fresh; otherwise it marks
+// the courier as checked so concurrent points don't query twice.
+func (t *zoneTracker) claim(courierID int) (previous []string, known, due bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+	entry, ok := t.couriers[courierID]
+	if ok && t.idle(entry, now) {
+		ok = false
+	}
+	if ok && now.Sub(entry.checkedAt) < t.ttl {
+		entry.seenAt = now
+		return nil, true, false
+	}
+	if !ok {
+		entry = &courierZones{}
+		t.couriers[courierID] = entry
+	}
+	entry.checkedAt = now
+	entry.seenAt = now
+	return entry.zones, ok, true
+}
+
+// set stores the zones containing the courier's latest point
+func (t *zoneTracker) set(courierID int, zones []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.couriers[courierID]
+	if !ok {
+		now := t.now()
+		entry = &courierZones{checkedAt: now, seenAt: now}
+		t.couriers[courierID] = entry
+	}
+	entry.zones = zones
+}
+
+// checkZoneTransitions compares the zones containing the new point with the
+// courier's previous zones and publishes entry/exit events. previous is the
+// courier's prior point, used only when no zone state is cached yet.
+func (s *TrackingService) checkZoneTransitions(ctx context.Context, location *domain.Location, previous *domain.Location) {
+	ctx = logger.WithContext(ctx, zap.Int("delivery_id", location.DeliveryID), zap.Int("courier_id", location.CourierID))
+
+	prevZones, known, due := s.zoneTracker.claim(location.CourierID)
+	if !due {
+		return
+	}
+
+	if !known && previous != nil {
+		zones, err := s.zoneRepo.FindZonesContainingPoint(ctx, previous.Latitude, previous.Longitude)
+		if err != nil {
+			s.logger.WarnWithFields(ctx, "Failed to look up zones for previous location", zap.Error(err))
+		}
+		prevZones = zones
+	}
+
+	zones, err := s.zoneRepo.FindZonesContainingPoint(ctx, location.Latitude, location.Longitude)
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to look up zones for location", zap.Error(err))
+		return
+	}
+	s.zoneTracker.set(location.CourierID, zones)
+
+	transition := domain.DiffZones(prevZones, zones)
+	if transition.IsEmpty() {
+		return
+	}
+
+	for _, zone := range transition.Exited {
+		s.publishZoneEvent(ctx, messaging.EventTypeZoneExited, location, zone)
+	}
+	for _, zone := range transition.Entered {
+		s.publishZoneEvent(ctx, messaging.EventTypeZoneEntered, location, zone)
+	}
+
+	if len(transition.Entered) > 0 {
+		s.notifyDestinationZoneEntered(ctx, location, transition.Entered)
+	}
+}
+
+// publishZoneEvent publishes a zone transition event with retry
+func (s *TrackingService) publishZoneEvent(ctx context.Context, eventType string, location *domain.Location, zone string) {
+	s.logger.InfoWithFields(ctx, "Courier zone transition",
+		zap.String("event_type", eventType),
+		zap.String("zone", zone))
+
+	traceCtx := messaging.ExtractTraceContextFromContext(ctx, "tracking-service", "zone_transition")
+	event, err := messaging.NewZoneTransitionEvent(eventType, messaging.ZoneTransitionEvent{
+		DeliveryID: location.DeliveryID,
+		CourierID:  location.CourierID,
+		ZoneName:   zone,
+		Latitude:   location.Latitude,
+		Longitude:  location.Longitude,
+	}, traceCtx)
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to build zone transition event",
+			zap.String("event_type", eventType), zap.String("zone", zone), zap.Error(err))
+		return
+	}
+
+	err = resilience.Retry(ctx, resilience.DefaultRetryConfig(), func() error {
+		return s.publisher.Publish(ctx, "tracking-events", eventType, event)
+	})
+	if err != nil {
+		s.logger.ErrorWithFields(ctx, "Failed to publish zone transition event",
+			zap.String("event_type", eventType), zap.String("zone", zone), zap.Error(err))
+	}
+}
+
+// notifyDestinationZoneEntered tells the customer their courier is nearby when
+// one of the entered zones contains the delivery destination
+func (s *TrackingService) notifyDestinationZoneEntered(ctx context.Context, location *domain.Location, entered []string) {
+	if s.wsHub == nil {
+		return
+	}
+
+	d, err := s.getDelivery(ctx, location.DeliveryID)
+	if err != nil || d.DeliveryLocation == nil {
+		s.logger.WarnWithFields(ctx, "Failed to get delivery for zone notification", zap.Error(err))
+		return
+	}
+
+	customerID, err := strconv.Atoi(d.CustomerId)
+	if err != nil {
+		return
+	}
+
+	dest := d.DeliveryLocation
+	destZones, err := s.zoneRepo.FindZonesContainingPoint(ctx, dest.Latitude, dest.Longitude)
+	if err != nil {
+		s.logger.WarnWithFields(ctx, "Failed to look up destination zones", zap.Error(err))
+		return
+	}
+
+	for _, zone := range entered {
+		for _, destZone := range destZones {
+			if zone != destZone {
+				continue
+			}
+			s.wsHub.BroadcastCustomerNotification(customerID, "courier_nearby",
+				fmt.Sprintf("Your courier is nearby with delivery #%d", location.DeliveryID),
+				map[string]interface{}{
+					"delivery_id": location.DeliveryID,
+					"zone_name":   zone,
+					"latitude":    location.Latitude,
+					"longitude":   location.Longitude,
+				})
+			return
+		}
+	}
+}
+
+// FindZones returns the names of the active delivery zones containing a
+// point. Zones are not customer data, so any authenticated caller may look
+// them up.
+func (s *TrackingService) FindZones(ctx context.Context, req ports.FindZonesRequest) ([]string, error) {
+	if s.zoneRepo == nil {
+		return nil, domain.ErrZonesUnavailable
+	}
+	if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+		return nil, domain.ErrInvalidLocation
+	}
+
+	zones, err := s.zoneRepo.FindZonesContainingPoint(ctx, req.Latitude, req.Longitude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find zones: %w", err)
+	}
+	return zones, nil
+}
@@ -0,0 +1,81 @@
+package domain
+
+import "time"
+
+// CourierStatus is a courier's liveness derived from their reported locations
+type CourierStatus string
+
+const (
+	CourierStatusActive  CourierStatus = "active"
+	CourierStatusStale   CourierStatus = "stale"
+	CourierStatusOffline CourierStatus = "offline"
+)
+
+// CourierLiveness holds the thresholds for deriving a courier's status. A
+// courier is active while their last point is at most ActiveWithin old,
+// offline once it is older than OfflineAfter, and stale in between.
+type CourierLiveness struct {
+	ActiveWithin time.Duration
+	OfflineAfter time.Duration
+}
+
+// DefaultCourierLiveness treats five minutes of silence as stale and thirty as offline
+func DefaultCourierLiveness() CourierLiveness {
+	return CourierLiveness{ActiveWithin: 5 * time.Minute, OfflineAfter: 30 * time.Minute}
+}
+
+// CourierHeartbeat is a courier's derived status and since when they have held it
+type CourierHeartbeat struct {
+	CourierID   int           `json:"courier_id"`
+	Status      CourierStatus `json:"status"`
+	LastSeenAt  *time.Time    `json:"last_seen_at"` // nil if the courier never reported
+	StatusSince *time.Time    `json:"status_since"` // nil when unknown
+}
+
+// StatusDuration returns how long the courier has held their status, zero when unknown
+func (h CourierHeartbeat) StatusDuration(now time.Time) time.Duration {
+	if h.StatusSince == nil || now.Before(*h.StatusSince) {
+		return 0
+	}
+	return now.Sub(*h.StatusSince)
+}
+
+// IsActive reports whether a point seen at lastSeen still counts as a heartbeat at now
+func (l CourierLiveness) IsActive(lastSeen, now time.Time) bool {
+	return !lastSeen.IsZero() && now.Sub(lastSeen) <= l.ActiveWithin
+}
+
+// Heartbeat derives a courier's status from the timestamps of their recent
+// points, newest first. An active courier's status dates from the start of
+// their current run of points without a gap longer than ActiveWithin, bounded
+// by the oldest point given.
+func (l CourierLiveness) Heartbeat(courierID int, seen []time.Time, now time.Time) CourierHeartbeat {
+	heartbeat := CourierHeartbeat{CourierID: courierID, Status: CourierStatusOffline}
+	if len(seen) == 0 {
+		return heartbeat
+	}
+
+	lastSeen := seen[0]
+	heartbeat.LastSeenAt = &lastSeen
+
+	var since time.Time
+	switch {
+	case l.IsActive(lastSeen, now):
+		heartbeat.Status = CourierStatusActive
+		since = lastSeen
+		for _, t := range seen[1:] {
+			if since.Sub(t) > l.ActiveWithin {
+				break
+			}
+			since = t
+		}
+	case now.Sub(lastSeen) <= l.OfflineAfter:
+		heartbeat.Status = CourierStatusStale
+		since = lastSeen.Add(l.ActiveWithin)
+	default:
+		since = lastSeen.Add(l.OfflineAfter)
+	}
+	heartbeat.StatusSince = &since
+
+	return heartbeat
+}
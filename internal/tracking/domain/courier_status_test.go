@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCourierLiveness_Heartbeat(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	liveness := CourierLiveness{ActiveWithin: 5 * time.Minute, OfflineAfter: 30 * time.Minute}
+
+	tests := []struct {
+		name     string
+		seen     []time.Time
+		status   CourierStatus
+		duration time.Duration
+	}{
+		{"never reported", nil, CourierStatusOffline, 0},
+		{"active since the start of the current run", []time.Time{ago(time.Minute), ago(4 * time.Minute), ago(8 * time.Minute), ago(20 * time.Minute)}, CourierStatusActive, 8 * time.Minute},
+		{"active with a single point", []time.Time{ago(2 * time.Minute)}, CourierStatusActive, 2 * time.Minute},
+		{"stale after the active window", []time.Time{ago(12 * time.Minute)}, CourierStatusStale, 7 * time.Minute},
+		{"offline after the offline threshold", []time.Time{ago(45 * time.Minute)}, CourierStatusOffline, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heartbeat := liveness.Heartbeat(7, tt.seen, now)
+			if heartbeat.CourierID != 7 {
+				t.Errorf("expected courier 7, got %d", heartbeat.CourierID)
+			}
+			if heartbeat.Status != tt.status {
+				t.Errorf("expected status %s, got %s", tt.status, heartbeat.Status)
+			}
+			if got := heartbeat.StatusDuration(now); got != tt.duration {
+				t.Errorf("expected status duration %v, got %v", tt.duration, got)
+			}
+			if len(tt.seen) > 0 && (heartbeat.LastSeenAt == nil || !heartbeat.LastSeenAt.Equal(tt.seen[0])) {
+				t.Errorf("expected last seen %v, got %v", tt.seen[0], heartbeat.LastSeenAt)
+			}
+			if len(tt.seen) == 0 && (heartbeat.LastSeenAt != nil || heartbeat.StatusSince != nil) {
+				t.Errorf("expected unknown times for a courier that never reported, got %+v", heartbeat)
+			}
+		})
+	}
+}
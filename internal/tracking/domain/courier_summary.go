@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+)
+
+// ActiveGapThreshold is the longest silence between two of a courier's points
+// still counted as active time; longer gaps are breaks
+const ActiveGapThreshold = 30 * time.Minute
+
+// CourierDaySummary is a courier's end-of-day view of one UTC calendar day
+type CourierDaySummary struct {
+	CourierID           int           `json:"courier_id"`
+	Date                string        `json:"date"` // YYYY-MM-DD
+	DeliveriesCompleted int           `json:"deliveries_completed"`
+	DistanceKm          float64       `json:"distance_km"`
+	PointCount          int           `json:"point_count"`
+	FirstSeenAt         *time.Time    `json:"first_seen_at,omitempty"`
+	LastSeenAt          *time.Time    `json:"last_seen_at,omitempty"`
+	ActiveTime          time.Duration `json:"-"`
+	AveragePerDelivery  time.Duration `json:"-"` // active time per completed delivery, 0 without any
+}
+
+// CourierDaySummarizer folds a courier's points for a day, passed to Add
+// oldest first, into distance and active time without holding them in memory
+type CourierDaySummarizer struct {
+	distanceKm float64
+	active     time.Duration
+	count      int
+	first      *Location
+	last       *Location
+}
+
+// Add folds the next point of the day into the summary
+func (s *CourierDaySummarizer) Add(loc *Location) {
+	if s.last == nil {
+		s.first = loc
+	} else {
+		s.distanceKm += geo.HaversineKm(s.last.Latitude, s.last.Longitude, loc.Latitude, loc.Longitude)
+		if gap := loc.Timestamp.Sub(s.last.Timestamp); gap <= ActiveGapThreshold {
+			s.active += gap
+		}
+	}
+	s.count++
+	s.last = loc
+}
+
+// Summary returns the day's summary for a courier who completed deliveries
+// that day
+func (s *CourierDaySummarizer) Summary(courierID int, day time.Time, deliveries int) CourierDaySummary {
+	summary := CourierDaySummary{
+		CourierID:           courierID,
+		Date:                day.Format(time.DateOnly),
+		DeliveriesCompleted: deliveries,
+		DistanceKm:          s.distanceKm,
+		PointCount:          s.count,
+		ActiveTime:          s.active,
+	}
+	if s.first != nil {
+		first, last := s.first.Timestamp, s.last.Timestamp
+		summary.FirstSeenAt, summary.LastSeenAt = &first, &last
+	}
+	if deliveries > 0 {
+		summary.AveragePerDelivery = s.active / time.Duration(deliveries)
+	}
+	return summary
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCourierDaySummarizer(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := day.Add(9 * time.Hour)
+	// Four points 100 m apart a minute apart, an hour's break, then two more
+	var offsets [][2]float64
+	for i := 0; i < 6; i++ {
+		offsets = append(offsets, [2]float64{float64(i) * 100, 0})
+	}
+	track := syntheticTrack(offsets)
+	for i, loc := range track {
+		loc.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if i >= 4 {
+			loc.Timestamp = loc.Timestamp.Add(time.Hour)
+		}
+	}
+
+	var summarizer CourierDaySummarizer
+	for _, loc := range track {
+		summarizer.Add(loc)
+	}
+	summary := summarizer.Summary(1, day, 2)
+
+	if summary.Date != "2024-01-01" || summary.CourierID != 1 || summary.DeliveriesCompleted != 2 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if summary.PointCount != 6 {
+		t.Errorf("expected 6 points, got %d", summary.PointCount)
+	}
+	// Distance across the break still counts, time during it doesn't
+	if math.Abs(summary.DistanceKm-0.5) > 0.01 {
+		t.Errorf("expected about 0.5 km, got %.3f", summary.DistanceKm)
+	}
+	if summary.ActiveTime != 4*time.Minute {
+		t.Errorf("expected 4m active, got %s", summary.ActiveTime)
+	}
+	if summary.AveragePerDelivery != 2*time.Minute {
+		t.Errorf("expected 2m per delivery, got %s", summary.AveragePerDelivery)
+	}
+	if !summary.FirstSeenAt.Equal(start) || !summary.LastSeenAt.Equal(track[5].Timestamp) {
+		t.Errorf("unexpected first and last seen %v, %v", summary.FirstSeenAt, summary.LastSeenAt)
+	}
+}
+
+func TestCourierDaySummarizer_Empty(t *testing.T) {
+	var summarizer CourierDaySummarizer
+	summary := summarizer.Summary(1, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0)
+
+	if summary.PointCount != 0 || summary.DistanceKm != 0 || summary.ActiveTime != 0 || summary.AveragePerDelivery != 0 {
+		t.Errorf("expected an empty summary, got %+v", summary)
+	}
+	if summary.FirstSeenAt != nil || summary.LastSeenAt != nil {
+		t.Error("expected no first or last seen time without points")
+	}
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// AverageCourierSpeedKmh is the typical urban delivery speed used to estimate arrival times
+const AverageCourierSpeedKmh = 25.0
+
+// EstimateETA returns the time needed to cover distanceKm at the average courier speed
+func EstimateETA(distanceKm float64) time.Duration {
+	eta := time.Duration(distanceKm / AverageCourierSpeedKmh * float64(time.Hour))
+	return eta.Round(time.Second)
+}
+
+// ETAUpdatePolicy throttles ETA pushes for a delivery. A new ETA is pushed at
+// most once per MinInterval, or sooner when it differs from the last pushed
+// ETA by more than MinChange. A zero MinChange never pushes early.
+type ETAUpdatePolicy struct {
+	MinInterval time.Duration
+	MinChange   time.Duration
+}
+
+// DefaultETAUpdatePolicy pushes at most once a minute unless the ETA moves by more than two minutes
+func DefaultETAUpdatePolicy() ETAUpdatePolicy {
+	return ETAUpdatePolicy{MinInterval: time.Minute, MinChange: 2 * time.Minute}
+}
+
+// Due reports whether eta should be pushed at now, given the ETA last pushed at pushedAt
+func (p ETAUpdatePolicy) Due(lastETA time.Duration, pushedAt time.Time, eta time.Duration, now time.Time) bool {
+	if now.Sub(pushedAt) >= p.MinInterval {
+		return true
+	}
+	change := eta - lastETA
+	if change < 0 {
+		change = -change
+	}
+	return p.MinChange > 0 && change > p.MinChange
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateETA(t *testing.T) {
+	if eta := EstimateETA(12.5); eta != 30*time.Minute {
+		t.Errorf("expected 30m for 12.5 km, got %v", eta)
+	}
+	if eta := EstimateETA(0); eta != 0 {
+		t.Errorf("expected zero ETA at the destination, got %v", eta)
+	}
+}
+
+func TestETAUpdatePolicy_Due(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	policy := ETAUpdatePolicy{MinInterval: time.Minute, MinChange: 2 * time.Minute}
+
+	tests := []struct {
+		name     string
+		lastETA  time.Duration
+		pushedAt time.Time
+		eta      time.Duration
+		due      bool
+	}{
+		{"within the interval with a small change", 10 * time.Minute, now.Add(-30 * time.Second), 9 * time.Minute, false},
+		{"within the interval at the change threshold", 10 * time.Minute, now.Add(-30 * time.Second), 8 * time.Minute, false},
+		{"within the interval with a large drop", 10 * time.Minute, now.Add(-30 * time.Second), 7 * time.Minute, true},
+		{"within the interval with a large rise", 10 * time.Minute, now.Add(-30 * time.Second), 13 * time.Minute, true},
+		{"after the interval", 10 * time.Minute, now.Add(-time.Minute), 10 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if due := policy.Due(tt.lastETA, tt.pushedAt, tt.eta, now); due != tt.due {
+				t.Errorf("expected due=%v, got %v", tt.due, due)
+			}
+		})
+	}
+
+	intervalOnly := ETAUpdatePolicy{MinInterval: time.Minute}
+	if intervalOnly.Due(10*time.Minute, now.Add(-30*time.Second), time.Minute, now) {
+		t.Error("expected a zero MinChange to never push early")
+	}
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoundingBox is a rectangle of longitudes and latitudes, as used in map
+// viewports; boxes crossing the antimeridian are not supported
+type BoundingBox struct {
+	MinLongitude float64
+	MinLatitude  float64
+	MaxLongitude float64
+	MaxLatitude  float64
+}
+
+// ParseBoundingBox reads a box written "minLng,minLat,maxLng,maxLat"
+func ParseBoundingBox(value string) (*BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("%w: bbox must be minLng,minLat,maxLng,maxLat", ErrInvalidLocation)
+	}
+	var coords [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bbox must be minLng,minLat,maxLng,maxLat", ErrInvalidLocation)
+		}
+		coords[i] = f
+	}
+
+	box := &BoundingBox{MinLongitude: coords[0], MinLatitude: coords[1], MaxLongitude: coords[2], MaxLatitude: coords[3]}
+	if box.MinLongitude < -180 || box.MaxLongitude > 180 || box.MinLatitude < -90 || box.MaxLatitude > 90 {
+		return nil, fmt.Errorf("%w: bbox is out of range", ErrInvalidLocation)
+	}
+	if box.MinLongitude >= box.MaxLongitude || box.MinLatitude >= box.MaxLatitude {
+		return nil, fmt.Errorf("%w: bbox minimums must be below its maximums", ErrInvalidLocation)
+	}
+	return box, nil
+}
+
+// Contains reports whether a point lies inside the box or on its edge
+func (b BoundingBox) Contains(latitude, longitude float64) bool {
+	return latitude >= b.MinLatitude && latitude <= b.MaxLatitude &&
+		longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+}
+
+// FleetDelivery is a delivery a courier on the fleet map is working on
+type FleetDelivery struct {
+	DeliveryID int    `json:"delivery_id"`
+	Status     string `json:"status"`
+}
+
+// FleetCourier is one courier on the dispatch fleet map: their latest
+// location, liveness and active deliveries
+type FleetCourier struct {
+	Location   *Location
+	Status     CourierStatus
+	Deliveries []FleetDelivery
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := ParseBoundingBox("-74.1, 40.6,-73.9,40.9")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *box != (BoundingBox{MinLongitude: -74.1, MinLatitude: 40.6, MaxLongitude: -73.9, MaxLatitude: 40.9}) {
+		t.Errorf("unexpected box %+v", box)
+	}
+	if !box.Contains(40.7128, -74.006) || box.Contains(51.5, -0.12) {
+		t.Error("unexpected Contains result")
+	}
+
+	for _, value := range []string{"", "1,2,3", "a,2,3,4", "-74,40,-75,41", "-74,41,-73,40", "-190,40,-73,41", "-74,-91,-73,41"} {
+		if _, err := ParseBoundingBox(value); !errors.Is(err, ErrInvalidLocation) {
+			t.Errorf("ParseBoundingBox(%q): expected ErrInvalidLocation, got %v", value, err)
+		}
+	}
+}
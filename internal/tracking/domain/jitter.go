@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+	"google.golang.org/grpc/codes"
+)
+
+var ErrLocationJitter = domainerr.New(codes.FailedPrecondition, "location discarded as GPS jitter")
+
+// JitterFilter discards points that are too inaccurate or imply the courier
+// moved implausibly fast since their previous point
+type JitterFilter struct {
+	MaxSpeedKmh       float64
+	MaxAccuracyMeters float64
+}
+
+// DefaultJitterFilter allows up to 200 km/h and 100 m accuracy
+func DefaultJitterFilter() JitterFilter {
+	return JitterFilter{MaxSpeedKmh: 200, MaxAccuracyMeters: 100}
+}
+
+// Check returns ErrLocationJitter when current should be discarded. previous
+// is the courier's last accepted point and may be nil. A zero threshold
+// disables that check.
+func (f JitterFilter) Check(previous, current *Location) error {
+	if f.MaxAccuracyMeters > 0 && current.Accuracy != nil && *current.Accuracy > f.MaxAccuracyMeters {
+		return fmt.Errorf("%w: accuracy %.0f m exceeds %.0f m", ErrLocationJitter, *current.Accuracy, f.MaxAccuracyMeters)
+	}
+
+	if f.MaxSpeedKmh <= 0 || previous == nil {
+		return nil
+	}
+
+	// Buffered points uploaded late may predate previous. Points within the
+	// same second are treated as one second apart.
+	elapsed := current.Timestamp.Sub(previous.Timestamp)
+	if elapsed < 0 {
+		elapsed = -elapsed
+	}
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+
+	distanceKm := geo.HaversineKm(previous.Latitude, previous.Longitude, current.Latitude, current.Longitude)
+	if speed := distanceKm / elapsed.Hours(); speed > f.MaxSpeedKmh {
+		return fmt.Errorf("%w: implied speed %.0f km/h exceeds %.0f km/h", ErrLocationJitter, speed, f.MaxSpeedKmh)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJitterFilter_Check(t *testing.T) {
+	start := time.Now()
+	at := func(lat, lng float64, offset time.Duration, accuracy *float64) *Location {
+		return &Location{DeliveryID: 1, CourierID: 1, Latitude: lat, Longitude: lng, Timestamp: start.Add(offset), Accuracy: accuracy}
+	}
+	accurate := 15.0
+	inaccurate := 250.0
+	previous := at(40.7128, -74.0060, 0, nil)
+
+	tests := []struct {
+		name     string
+		filter   JitterFilter
+		previous *Location
+		current  *Location
+		discard  bool
+	}{
+		{
+			name:     "first point",
+			filter:   DefaultJitterFilter(),
+			previous: nil,
+			current:  at(40.7128, -74.0060, 0, &accurate),
+			discard:  false,
+		},
+		{
+			name:     "normal movement",
+			filter:   DefaultJitterFilter(),
+			previous: previous,
+			current:  at(40.7228, -74.0060, time.Minute, &accurate), // ~1.1 km in a minute
+			discard:  false,
+		},
+		{
+			name:     "teleport of 50 km in two seconds",
+			filter:   DefaultJitterFilter(),
+			previous: previous,
+			current:  at(41.1628, -74.0060, 2*time.Second, &accurate),
+			discard:  true,
+		},
+		{
+			name:     "just under the speed limit",
+			filter:   JitterFilter{MaxSpeedKmh: 200},
+			previous: previous,
+			current:  at(40.7128+199.0/111.195, -74.0060, time.Hour, nil),
+			discard:  false,
+		},
+		{
+			name:     "just over the speed limit",
+			filter:   JitterFilter{MaxSpeedKmh: 200},
+			previous: previous,
+			current:  at(40.7128+201.0/111.195, -74.0060, time.Hour, nil),
+			discard:  true,
+		},
+		{
+			name:     "same timestamp small move",
+			filter:   DefaultJitterFilter(),
+			previous: previous,
+			current:  at(40.71285, -74.0060, 0, nil), // ~5 m
+			discard:  false,
+		},
+		{
+			name:     "buffered point uploaded after a later one",
+			filter:   DefaultJitterFilter(),
+			previous: previous,
+			current:  at(40.7028, -74.0060, -time.Minute, nil), // ~1.1 km a minute earlier
+			discard:  false,
+		},
+		{
+			name:     "buffered point implying a teleport",
+			filter:   DefaultJitterFilter(),
+			previous: previous,
+			current:  at(41.1628, -74.0060, -2*time.Second, nil),
+			discard:  true,
+		},
+		{
+			name:     "poor accuracy",
+			filter:   DefaultJitterFilter(),
+			previous: nil,
+			current:  at(40.7128, -74.0060, 0, &inaccurate),
+			discard:  true,
+		},
+		{
+			name:     "disabled filter",
+			filter:   JitterFilter{},
+			previous: previous,
+			current:  at(41.1628, -74.0060, 2*time.Second, &inaccurate),
+			discard:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Check(tt.previous, tt.current)
+			if tt.discard && !errors.Is(err, ErrLocationJitter) {
+				t.Errorf("expected ErrLocationJitter, got %v", err)
+			}
+			if !tt.discard && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
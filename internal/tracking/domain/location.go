@@ -1,31 +1,66 @@
 package domain
 
 import (
-	"errors"
+	"fmt"
 	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
 )
 
 var (
-	ErrLocationNotFound    = errors.New("location not found")
-	ErrInvalidLocation     = errors.New("invalid location data")
-	ErrUnauthorized        = errors.New("unauthorized access")
+	ErrLocationNotFound    = domainerr.New(codes.NotFound, "location not found")
+	ErrInvalidLocation     = domainerr.New(codes.InvalidArgument, "invalid location data")
+	ErrUnauthorized        = domainerr.New(codes.PermissionDenied, "unauthorized access")
+	ErrInvalidTimeRange    = domainerr.New(codes.InvalidArgument, "time range ends before it starts")
+	ErrCourierNotActive    = domainerr.New(codes.FailedPrecondition, "courier has no active delivery")
+	ErrDeliveryClosed      = domainerr.New(codes.FailedPrecondition, "delivery is no longer accepting locations")
+	ErrFutureSummaryDate   = domainerr.New(codes.InvalidArgument, "summary date is in the future")
+	ErrZonesUnavailable    = domainerr.New(codes.Unavailable, "delivery zones are not configured")
+	ErrDeliveryUnavailable = domainerr.New(codes.Unavailable, "delivery service is unavailable")
+	ErrDuplicateLocation   = domainerr.New(codes.AlreadyExists, "location already recorded")
 )
 
+// MaxRecordedAtSkew is how far ahead of the server's clock a client's
+// recorded_at may be before the point is refused
+const MaxRecordedAtSkew = time.Minute
+
 // Location represents a tracking location point
 type Location struct {
-	ID          int
-	DeliveryID  int
-	CourierID   int
-	Latitude    float64
-	Longitude   float64
-	Accuracy    *float64
-	Speed       *float64
-	Heading     *float64
-	Altitude    *float64
-	Timestamp   time.Time
-	CreatedAt   time.Time
+	ID         int
+	DeliveryID int
+	CourierID  int
+	OrgID      int // organization of the courier who recorded it
+	Latitude   float64
+	Longitude  float64
+	Accuracy   *float64
+	Speed      *float64
+	Heading    *float64
+	Altitude   *float64
+	DistanceKm float64 // travelled along the delivery's track up to this point
+	// Timestamp orders the track: the client's RecordedAt when it sent one,
+	// otherwise when the server received the point
+	Timestamp time.Time
+	CreatedAt time.Time // when the server stored the point
+	// ClientPointID is the courier app's ID for the point; together with
+	// CourierID it identifies re-uploads of the same point
+	ClientPointID string     `json:"client_point_id,omitempty"`
+	RecordedAt    *time.Time `json:"recorded_at,omitempty"` // when the courier app took the point
+	// Duplicate is set when RecordLocation returned an earlier upload of the
+	// point instead of storing it again. Set on request, not persisted.
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Address   string `json:"address,omitempty"` // Resolved on request, not persisted
+	// DeliveryContext is DeliveryContextUnavailable on reads served without
+	// the delivery service. Set on request, not persisted.
+	DeliveryContext string `json:"delivery_context,omitempty"`
 }
 
+// DeliveryContextUnavailable marks a location read while the delivery
+// service could not be reached
+const DeliveryContextUnavailable = "unavailable"
+
 // NewLocation creates a new location with validation
 func NewLocation(deliveryID, courierID int, latitude, longitude float64) (*Location, error) {
 	if deliveryID <= 0 || courierID <= 0 {
@@ -40,6 +75,11 @@ func NewLocation(deliveryID, courierID int, latitude, longitude float64) (*Locat
 		return nil, ErrInvalidLocation
 	}
 
+	// 0,0 is what receivers report without a fix
+	if latitude == 0 && longitude == 0 {
+		return nil, ErrInvalidLocation
+	}
+
 	return &Location{
 		DeliveryID: deliveryID,
 		CourierID:  courierID,
@@ -58,6 +98,52 @@ func (l *Location) SetOptionalFields(accuracy, speed, heading, altitude *float64
 	l.Altitude = altitude
 }
 
+// SetClientPoint sets the courier app's ID for the point and when it was
+// taken, either of which may be empty. The ID must be a UUID and is stored
+// in canonical form; recordedAt may not be more than MaxRecordedAtSkew
+// after now and becomes the point's Timestamp.
+func (l *Location) SetClientPoint(clientPointID string, recordedAt *time.Time, now time.Time) error {
+	if clientPointID != "" {
+		id, err := uuid.Parse(clientPointID)
+		if err != nil {
+			return fmt.Errorf("%w: client_point_id must be a UUID", ErrInvalidLocation)
+		}
+		l.ClientPointID = id.String()
+	}
+	if recordedAt != nil {
+		if recordedAt.After(now.Add(MaxRecordedAtSkew)) {
+			return fmt.Errorf("%w: recorded_at is in the future", ErrInvalidLocation)
+		}
+		at := recordedAt.UTC()
+		l.RecordedAt = &at
+		l.Timestamp = at
+	}
+	return nil
+}
+
+// ValidateOptionalFields rejects negative accuracy or speed
+func (l *Location) ValidateOptionalFields() error {
+	if l.Accuracy != nil && *l.Accuracy < 0 {
+		return ErrInvalidLocation
+	}
+	if l.Speed != nil && *l.Speed < 0 {
+		return ErrInvalidLocation
+	}
+	return nil
+}
+
+// ContinueTrack measures the point from the delivery's previous one, if any,
+// carrying the distance travelled so far into DistanceKm. It returns the
+// length of the step.
+func (l *Location) ContinueTrack(previous *Location) float64 {
+	if previous == nil || previous.DeliveryID != l.DeliveryID {
+		return 0
+	}
+	step := geo.HaversineKm(previous.Latitude, previous.Longitude, l.Latitude, l.Longitude)
+	l.DistanceKm = previous.DistanceKm + step
+	return step
+}
+
 // IsValid checks if the location data is valid
 func (l *Location) IsValid() bool {
 	return l.DeliveryID > 0 &&
@@ -65,3 +151,10 @@ func (l *Location) IsValid() bool {
 		l.Latitude >= -90 && l.Latitude <= 90 &&
 		l.Longitude >= -180 && l.Longitude <= 180
 }
+
+// TrackVersion identifies the state of a delivery's track: it changes
+// whenever a point is recorded or the track is erased
+type TrackVersion struct {
+	Points   int64
+	LatestAt time.Time // zero while the track is empty
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+	"math"
 	"testing"
 	"time"
 )
@@ -70,6 +72,38 @@ func TestNewLocation(t *testing.T) {
 			longitude:   181.0,
 			expectError: true,
 		},
+		{
+			name:        "upper boundaries",
+			deliveryID:  1,
+			courierID:   1,
+			latitude:    90.0,
+			longitude:   180.0,
+			expectError: false,
+		},
+		{
+			name:        "lower boundaries",
+			deliveryID:  1,
+			courierID:   1,
+			latitude:    -90.0,
+			longitude:   -180.0,
+			expectError: false,
+		},
+		{
+			name:        "null island",
+			deliveryID:  1,
+			courierID:   1,
+			latitude:    0,
+			longitude:   0,
+			expectError: true,
+		},
+		{
+			name:        "equator is valid",
+			deliveryID:  1,
+			courierID:   1,
+			latitude:    0,
+			longitude:   32.5,
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -137,6 +171,97 @@ func TestSetOptionalFields(t *testing.T) {
 	}
 }
 
+func TestValidateOptionalFields(t *testing.T) {
+	zero := 0.0
+	positive := 5.0
+	negative := -0.1
+
+	tests := []struct {
+		name        string
+		accuracy    *float64
+		speed       *float64
+		expectError bool
+	}{
+		{name: "unset", expectError: false},
+		{name: "zero", accuracy: &zero, speed: &zero, expectError: false},
+		{name: "positive", accuracy: &positive, speed: &positive, expectError: false},
+		{name: "negative accuracy", accuracy: &negative, expectError: true},
+		{name: "negative speed", speed: &negative, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := NewLocation(1, 1, 40.7128, -74.0060)
+			if err != nil {
+				t.Fatalf("failed to create location: %v", err)
+			}
+			location.SetOptionalFields(tt.accuracy, tt.speed, nil, nil)
+
+			err = location.ValidateOptionalFields()
+			if tt.expectError && !errors.Is(err, ErrInvalidLocation) {
+				t.Errorf("expected ErrInvalidLocation, got %v", err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetClientPoint(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	skewed := now.Add(MaxRecordedAtSkew / 2)
+	future := now.Add(2 * MaxRecordedAtSkew)
+
+	tests := []struct {
+		name          string
+		clientPointID string
+		recordedAt    *time.Time
+		expectedID    string
+		expectError   bool
+	}{
+		{name: "unset"},
+		{name: "canonical UUID", clientPointID: "7f9c24e8-3b12-4a6d-9e5f-1c2b3a4d5e6f", expectedID: "7f9c24e8-3b12-4a6d-9e5f-1c2b3a4d5e6f"},
+		{name: "upper case UUID", clientPointID: "7F9C24E8-3B12-4A6D-9E5F-1C2B3A4D5E6F", expectedID: "7f9c24e8-3b12-4a6d-9e5f-1c2b3a4d5e6f"},
+		{name: "not a UUID", clientPointID: "point-1", expectError: true},
+		{name: "recorded earlier", recordedAt: &earlier},
+		{name: "client clock slightly ahead", recordedAt: &skewed},
+		{name: "recorded in the future", recordedAt: &future, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := NewLocation(1, 1, 40.7128, -74.0060)
+			if err != nil {
+				t.Fatalf("failed to create location: %v", err)
+			}
+			received := location.Timestamp
+
+			err = location.SetClientPoint(tt.clientPointID, tt.recordedAt, now)
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidLocation) {
+					t.Errorf("expected ErrInvalidLocation, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if location.ClientPointID != tt.expectedID {
+				t.Errorf("expected client point ID %q, got %q", tt.expectedID, location.ClientPointID)
+			}
+			switch {
+			case tt.recordedAt == nil && (location.RecordedAt != nil || !location.Timestamp.Equal(received)):
+				t.Errorf("expected the received time to be kept, got %v", location.Timestamp)
+			case tt.recordedAt != nil && (location.RecordedAt == nil || !location.Timestamp.Equal(*tt.recordedAt)):
+				t.Errorf("expected the timestamp %v, got %v", *tt.recordedAt, location.Timestamp)
+			}
+		})
+	}
+}
+
 func TestIsValid(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -225,4 +350,24 @@ func TestIsValid(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestContinueTrack(t *testing.T) {
+	previous := &Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, DistanceKm: 2}
+	current := &Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7218, Longitude: -74.0060}
+
+	step := current.ContinueTrack(previous)
+	if math.Abs(step-1.0) > 0.01 {
+		t.Errorf("expected a step of about 1 km, got %.3f km", step)
+	}
+	if current.DistanceKm != previous.DistanceKm+step {
+		t.Errorf("expected cumulative distance %.3f km, got %.3f km", previous.DistanceKm+step, current.DistanceKm)
+	}
+
+	// The first point of a delivery, or one after another delivery's, starts at zero
+	for _, previous := range []*Location{nil, {DeliveryID: 2, CourierID: 1, Latitude: 40.7128, Longitude: -74.0060, DistanceKm: 5}} {
+		fresh := &Location{DeliveryID: 1, CourierID: 1, Latitude: 40.7218, Longitude: -74.0060}
+		if step := fresh.ContinueTrack(previous); step != 0 || fresh.DistanceKm != 0 {
+			t.Errorf("expected a new track from %+v, got step %.3f km and total %.3f km", previous, step, fresh.DistanceKm)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"math"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+)
+
+// SimplifyTrack reduces a track with the Ramer–Douglas–Peucker algorithm,
+// dropping points that lie within toleranceMeters of the line through the
+// points kept around them. The first and last points are always kept and the
+// result is in the same order as the input. A tolerance <= 0 or a track of
+// fewer than three points is returned unchanged.
+func SimplifyTrack(locations []*Location, toleranceMeters float64) []*Location {
+	if toleranceMeters <= 0 || len(locations) < 3 {
+		return locations
+	}
+
+	// Project onto a plane around the first point; accurate to well under a
+	// meter over the distances a single delivery covers
+	originLat := locations[0].Latitude * math.Pi / 180
+	metersPerDegree := geo.EarthRadiusKm * 1000 * math.Pi / 180
+	xs := make([]float64, len(locations))
+	ys := make([]float64, len(locations))
+	for i, loc := range locations {
+		xs[i] = (loc.Longitude - locations[0].Longitude) * metersPerDegree * math.Cos(originLat)
+		ys[i] = (loc.Latitude - locations[0].Latitude) * metersPerDegree
+	}
+
+	keep := make([]bool, len(locations))
+	keep[0], keep[len(locations)-1] = true, true
+
+	// Iterative so tracks of tens of thousands of points cannot exhaust the stack
+	type span struct{ first, last int }
+	stack := []span{{0, len(locations) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		farthest, maxDistance := -1, toleranceMeters
+		for i := s.first + 1; i < s.last; i++ {
+			if d := segmentDistance(xs[i], ys[i], xs[s.first], ys[s.first], xs[s.last], ys[s.last]); d > maxDistance {
+				farthest, maxDistance = i, d
+			}
+		}
+		if farthest < 0 {
+			continue
+		}
+
+		keep[farthest] = true
+		stack = append(stack, span{s.first, farthest}, span{farthest, s.last})
+	}
+
+	simplified := make([]*Location, 0, len(locations))
+	for i, loc := range locations {
+		if keep[i] {
+			simplified = append(simplified, loc)
+		}
+	}
+	return simplified
+}
+
+// segmentDistance returns the distance from point p to the segment a-b
+func segmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return math.Hypot(px-ax, py-ay)
+	}
+
+	// Position of the projection along the segment, clamped to its ends
+	t := ((px-ax)*dx + (py-ay)*dy) / lengthSquared
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(px-(ax+t*dx), py-(ay+t*dy))
+}
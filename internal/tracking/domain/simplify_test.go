@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+)
+
+// metersToDegrees converts a north-south distance to degrees of latitude
+func metersToDegrees(meters float64) float64 {
+	return meters / (geo.EarthRadiusKm * 1000 * math.Pi / 180)
+}
+
+// syntheticTrack builds a track one second per point from latitude/longitude offsets in meters
+func syntheticTrack(offsets [][2]float64) []*Location {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	track := make([]*Location, len(offsets))
+	for i, o := range offsets {
+		track[i] = &Location{
+			DeliveryID: 1,
+			CourierID:  1,
+			Latitude:   40.0 + metersToDegrees(o[0]),
+			Longitude:  -74.0 + metersToDegrees(o[1])/math.Cos(40.0*math.Pi/180),
+			Timestamp:  start.Add(time.Duration(i) * time.Second),
+		}
+	}
+	return track
+}
+
+func TestSimplifyTrack(t *testing.T) {
+	// 10 km due north with a meter of sideways wobble
+	var straight [][2]float64
+	for i := 0; i <= 5000; i++ {
+		straight = append(straight, [2]float64{float64(i) * 2, math.Sin(float64(i))})
+	}
+
+	// 20 m either side of a line north every 100 m
+	var zigzag [][2]float64
+	for i := 0; i <= 20; i++ {
+		zigzag = append(zigzag, [2]float64{float64(i) * 100, float64(20 * (1 - 2*(i%2)))})
+	}
+
+	// 1 km north, then 1 km east
+	var corner [][2]float64
+	for i := 0; i <= 100; i++ {
+		corner = append(corner, [2]float64{float64(i) * 10, 0})
+	}
+	for i := 1; i <= 100; i++ {
+		corner = append(corner, [2]float64{1000, float64(i) * 10})
+	}
+
+	tests := []struct {
+		name      string
+		offsets   [][2]float64
+		tolerance float64
+		want      int
+	}{
+		{name: "wobble within tolerance", offsets: straight, tolerance: 5, want: 2},
+		{name: "zigzag above tolerance", offsets: zigzag, tolerance: 5, want: len(zigzag)},
+		{name: "corner", offsets: corner, tolerance: 5, want: 3},
+		{name: "zero tolerance", offsets: corner, tolerance: 0, want: len(corner)},
+		{name: "two points", offsets: [][2]float64{{0, 0}, {100, 0}}, tolerance: 5, want: 2},
+		{name: "doubling back", offsets: [][2]float64{{0, 0}, {500, 0}, {1000, 0}, {500, 0}, {0, 1}}, tolerance: 5, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := syntheticTrack(tt.offsets)
+			simplified := SimplifyTrack(track, tt.tolerance)
+
+			if len(simplified) != tt.want {
+				t.Fatalf("expected %d points, got %d", tt.want, len(simplified))
+			}
+			if simplified[0] != track[0] || simplified[len(simplified)-1] != track[len(track)-1] {
+				t.Error("expected the first and last points to be kept")
+			}
+			for i := 1; i < len(simplified); i++ {
+				if !simplified[i].Timestamp.After(simplified[i-1].Timestamp) {
+					t.Fatalf("point %d is out of order", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSimplifyTrack_KeepsCorner(t *testing.T) {
+	track := syntheticTrack([][2]float64{{0, 0}, {250, 0}, {500, 0}, {500, 250}, {500, 500}})
+	simplified := SimplifyTrack(track, 10)
+
+	if len(simplified) != 3 || simplified[1] != track[2] {
+		t.Errorf("expected the start, corner and end, got %d points", len(simplified))
+	}
+	if len(track) != 5 {
+		t.Error("expected the input track to be left unchanged")
+	}
+}
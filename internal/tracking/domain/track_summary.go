@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/geo"
+)
+
+// PurgeReason records why a delivery's raw track was deleted
+type PurgeReason string
+
+const (
+	PurgeReasonRetention PurgeReason = "retention"
+	PurgeReasonErasure   PurgeReason = "erasure_request"
+)
+
+// TrackPoint is a position kept in a track summary
+type TrackPoint struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TrackSummary is what remains of a delivery's track once its raw points are purged
+type TrackSummary struct {
+	DeliveryID int           `json:"delivery_id"`
+	CourierID  int           `json:"courier_id"`
+	Start      TrackPoint    `json:"start"`
+	End        TrackPoint    `json:"end"`
+	PointCount int           `json:"point_count"`
+	DistanceKm float64       `json:"distance_km"`
+	Duration   time.Duration `json:"-"`
+	Reason     PurgeReason   `json:"reason"`
+	PurgedAt   time.Time     `json:"purged_at"`
+}
+
+// TrackSummarizer builds a TrackSummary from a delivery's points passed to
+// Add oldest first, without holding the whole track in memory
+type TrackSummarizer struct {
+	summary TrackSummary
+	last    *Location
+}
+
+// Add folds the next point of the track into the summary
+func (t *TrackSummarizer) Add(loc *Location) {
+	point := TrackPoint{Latitude: loc.Latitude, Longitude: loc.Longitude, Timestamp: loc.Timestamp}
+	if t.last == nil {
+		t.summary.DeliveryID = loc.DeliveryID
+		t.summary.CourierID = loc.CourierID
+		t.summary.Start = point
+	} else {
+		t.summary.DistanceKm += geo.HaversineKm(t.last.Latitude, t.last.Longitude, loc.Latitude, loc.Longitude)
+	}
+	t.summary.End = point
+	t.summary.PointCount++
+	t.last = loc
+}
+
+// Summary returns the summary of the points added so far, or false if there were none
+func (t *TrackSummarizer) Summary() (TrackSummary, bool) {
+	if t.last == nil {
+		return TrackSummary{}, false
+	}
+	summary := t.summary
+	summary.Duration = summary.End.Timestamp.Sub(summary.Start.Timestamp)
+	return summary, true
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrackSummarizer(t *testing.T) {
+	// 1 km north, then 1 km east
+	var offsets [][2]float64
+	for i := 0; i <= 10; i++ {
+		offsets = append(offsets, [2]float64{float64(i) * 100, 0})
+	}
+	for i := 1; i <= 10; i++ {
+		offsets = append(offsets, [2]float64{1000, float64(i) * 100})
+	}
+	track := syntheticTrack(offsets)
+
+	var summarizer TrackSummarizer
+	for _, loc := range track {
+		summarizer.Add(loc)
+	}
+	summary, ok := summarizer.Summary()
+	if !ok {
+		t.Fatal("expected a summary")
+	}
+
+	if summary.DeliveryID != 1 || summary.CourierID != 1 {
+		t.Errorf("expected delivery 1 and courier 1, got %d and %d", summary.DeliveryID, summary.CourierID)
+	}
+	if summary.PointCount != len(track) {
+		t.Errorf("expected %d points, got %d", len(track), summary.PointCount)
+	}
+	if math.Abs(summary.DistanceKm-2) > 0.01 {
+		t.Errorf("expected about 2 km, got %.3f", summary.DistanceKm)
+	}
+	if summary.Duration != time.Duration(len(track)-1)*time.Second {
+		t.Errorf("expected %ds, got %s", len(track)-1, summary.Duration)
+	}
+	first, last := track[0], track[len(track)-1]
+	if summary.Start.Latitude != first.Latitude || !summary.Start.Timestamp.Equal(first.Timestamp) {
+		t.Errorf("expected the start to be the first point, got %+v", summary.Start)
+	}
+	if summary.End.Longitude != last.Longitude || !summary.End.Timestamp.Equal(last.Timestamp) {
+		t.Errorf("expected the end to be the last point, got %+v", summary.End)
+	}
+}
+
+func TestTrackSummarizer_Empty(t *testing.T) {
+	var summarizer TrackSummarizer
+	if _, ok := summarizer.Summary(); ok {
+		t.Error("expected no summary without points")
+	}
+}
@@ -0,0 +1,37 @@
+package domain
+
+// ZoneTransition describes the zones a courier entered and left between two points
+type ZoneTransition struct {
+	Entered []string
+	Exited  []string
+}
+
+// DiffZones compares the zones containing a courier's previous and current points
+func DiffZones(previous, current []string) ZoneTransition {
+	prev := make(map[string]bool, len(previous))
+	for _, name := range previous {
+		prev[name] = true
+	}
+	curr := make(map[string]bool, len(current))
+	for _, name := range current {
+		curr[name] = true
+	}
+
+	var transition ZoneTransition
+	for _, name := range current {
+		if !prev[name] {
+			transition.Entered = append(transition.Entered, name)
+		}
+	}
+	for _, name := range previous {
+		if !curr[name] {
+			transition.Exited = append(transition.Exited, name)
+		}
+	}
+	return transition
+}
+
+// IsEmpty reports whether the courier stayed within the same zones
+func (t ZoneTransition) IsEmpty() bool {
+	return len(t.Entered) == 0 && len(t.Exited) == 0
+}
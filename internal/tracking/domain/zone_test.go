@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffZones(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		current  []string
+		entered  []string
+		exited   []string
+	}{
+		{"no zones", nil, nil, nil, nil},
+		{"same zones", []string{"downtown"}, []string{"downtown"}, nil, nil},
+		{"entered zone", nil, []string{"downtown"}, []string{"downtown"}, nil},
+		{"exited zone", []string{"downtown"}, nil, nil, []string{"downtown"}},
+		{"moved between zones", []string{"downtown", "city"}, []string{"city", "harbor"}, []string{"harbor"}, []string{"downtown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transition := DiffZones(tt.previous, tt.current)
+			if !reflect.DeepEqual(transition.Entered, tt.entered) {
+				t.Errorf("expected entered %v, got %v", tt.entered, transition.Entered)
+			}
+			if !reflect.DeepEqual(transition.Exited, tt.exited) {
+				t.Errorf("expected exited %v, got %v", tt.exited, transition.Exited)
+			}
+			if transition.IsEmpty() != (tt.entered == nil && tt.exited == nil) {
+				t.Errorf("unexpected IsEmpty %v", transition.IsEmpty())
+			}
+		})
+	}
+}
@@ -2,24 +2,89 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 )
 
 // LocationRepository defines the interface for location data persistence
 type LocationRepository interface {
-	// Create stores a new location
+	// Create stores a new location. A location whose courier already stored
+	// one with the same ClientPointID fails with domain.ErrDuplicateLocation.
 	Create(ctx context.Context, location *domain.Location) error
 
-	// GetByDeliveryID retrieves locations for a delivery
-	GetByDeliveryID(ctx context.Context, deliveryID int, limit int) ([]*domain.Location, error)
+	// GetByClientPointID retrieves the location a courier stored under a client point ID
+	GetByClientPointID(ctx context.Context, courierID int, clientPointID string) (*domain.Location, error)
+
+	// GetByDeliveryID retrieves the most recent of a delivery's locations matching query
+	GetByDeliveryID(ctx context.Context, deliveryID int, query LocationQuery) ([]*domain.Location, error)
+
+	// StreamByDeliveryID passes a delivery's locations to fn oldest first, optionally
+	// only those at or after from and before to, stopping at the first error fn returns
+	StreamByDeliveryID(ctx context.Context, deliveryID int, from, to *time.Time, fn func(*domain.Location) error) error
 
 	// GetLatestByDeliveryID retrieves the latest location for a delivery
 	GetLatestByDeliveryID(ctx context.Context, deliveryID int) (*domain.Location, error)
 
+	// GetTrackVersion counts a delivery's locations and finds the latest timestamp among them
+	GetTrackVersion(ctx context.Context, deliveryID int) (domain.TrackVersion, error)
+
 	// GetByCourierID retrieves locations for a courier
 	GetByCourierID(ctx context.Context, courierID int, limit int) ([]*domain.Location, error)
 
+	// StreamByCourierID passes a courier's locations at or after from and before
+	// to, oldest first, stopping at the first error fn returns
+	StreamByCourierID(ctx context.Context, courierID int, from, to time.Time, fn func(*domain.Location) error) error
+
 	// GetLatestByCourierID retrieves the latest location for a courier
 	GetLatestByCourierID(ctx context.Context, courierID int) (*domain.Location, error)
+
+	// GetLatestPerCourier retrieves the latest location of each courier matching query, ordered by courier ID
+	GetLatestPerCourier(ctx context.Context, query FleetQuery) ([]*domain.Location, error)
+
+	// DeleteByDeliveryID removes all of a delivery's locations and returns how many there were
+	DeleteByDeliveryID(ctx context.Context, deliveryID int) (int64, error)
+}
+
+// LocationQuery selects part of a delivery's location history. Limit keeps the
+// most recent points in the window; OldestFirst only changes the order they are returned in.
+type LocationQuery struct {
+	From        *time.Time // at or after; open when nil
+	To          *time.Time // before; open when nil
+	Limit       int
+	OldestFirst bool
+}
+
+// FleetQuery selects couriers for the fleet map by their latest location
+type FleetQuery struct {
+	Since      time.Time           // only couriers who reported at or after this
+	CourierIDs []int               // only these couriers; nil for all
+	Box        *domain.BoundingBox // only couriers whose latest location is inside; nil for anywhere
+	OrgID      int                 // only locations recorded in this organization; 0 for every one
+	Limit      int
+}
+
+// TrackSummaryRepository defines the interface for storing the summaries
+// kept when a delivery's track is purged
+type TrackSummaryRepository interface {
+	// SaveSummary stores a delivery's summary, replacing any earlier one
+	SaveSummary(ctx context.Context, summary *domain.TrackSummary) error
+}
+
+// ZoneRepository defines the interface for delivery zone lookups
+type ZoneRepository interface {
+	// FindZonesContainingPoint returns the names of active zones containing the point
+	FindZonesContainingPoint(ctx context.Context, latitude, longitude float64) ([]string, error)
+}
+
+// LocationCache defines the interface for caching the latest location of a delivery
+type LocationCache interface {
+	// GetLatest returns the cached location, or found=false on a miss
+	GetLatest(ctx context.Context, deliveryID int) (location *domain.Location, found bool, err error)
+
+	// SetLatest caches a location as the latest for its delivery
+	SetLatest(ctx context.Context, location *domain.Location) error
+
+	// DeleteLatest drops a delivery's cached location
+	DeleteLatest(ctx context.Context, deliveryID int) error
 }
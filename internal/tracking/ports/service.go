@@ -24,12 +24,49 @@ type RecordLocationRequest struct {
 	Speed      *float64 `json:"speed,omitempty"`
 	Heading    *float64 `json:"heading,omitempty"`
 	Altitude   *float64 `json:"altitude,omitempty"`
+	// ClientPointID is the courier app's UUID for a point it may upload more
+	// than once; a point already stored under it is returned instead
+	ClientPointID string `json:"client_point_id,omitempty"`
+	// RecordedAt is when the courier app took the point, for points buffered
+	// offline and uploaded later; when the server received it if nil
+	RecordedAt *time.Time `json:"recorded_at,omitempty"`
+	// AdminOverride skips the check that CourierID is assigned to the
+	// delivery, for test points recorded by admins
+	AdminOverride bool `json:"admin_override,omitempty"`
 }
 
 // GetDeliveryTrackRequest for retrieving delivery track
 type GetDeliveryTrackRequest struct {
+	DeliveryID       int        `json:"delivery_id"`
+	Limit            int        `json:"limit,omitempty"`
+	From             *time.Time `json:"from,omitempty"`              // at or after; the 24 hours before To when nil
+	To               *time.Time `json:"to,omitempty"`                // before; open when nil
+	OldestFirst      bool       `json:"oldest_first,omitempty"`      // newest first by default
+	SimplifyMeters   float64    `json:"simplify_meters,omitempty"`   // Drop points within this distance of the simplified line when > 0
+	ResolveAddresses bool       `json:"resolve_addresses,omitempty"` // Reverse geocode the latest point
+	AddressEvery     int        `json:"address_every,omitempty"`     // Also resolve every Nth point when > 0
+	AuthContext
+}
+
+// ExportDeliveryTrackRequest for exporting a delivery's full track
+type ExportDeliveryTrackRequest struct {
+	DeliveryID int        `json:"delivery_id"`
+	From       *time.Time `json:"from,omitempty"` // at or after; open when nil
+	To         *time.Time `json:"to,omitempty"`   // before; open when nil
+	AuthContext
+}
+
+// GetTrackVersionRequest for checking whether a delivery's track changed
+type GetTrackVersionRequest struct {
+	DeliveryID int `json:"delivery_id"`
+	AuthContext
+}
+
+// EraseDeliveryTrackRequest for deleting a delivery's raw track on request
+type EraseDeliveryTrackRequest struct {
 	DeliveryID int `json:"delivery_id"`
-	Limit      int `json:"limit,omitempty"`
+	UserID     int `json:"user_id"` // who asked, for the audit event
+	AuthContext
 }
 
 // GetCurrentLocationRequest for retrieving current location
@@ -38,9 +75,43 @@ type GetCurrentLocationRequest struct {
 	AuthContext // Embedded for auth
 }
 
+// TrackDeliveryRequest for streaming a delivery's locations
+type TrackDeliveryRequest struct {
+	DeliveryID int `json:"delivery_id"`
+	AuthContext
+}
+
 // GetCourierLocationRequest for retrieving courier location
 type GetCourierLocationRequest struct {
 	CourierID int `json:"courier_id"`
+	AuthContext
+}
+
+// FindZonesRequest for looking up the delivery zones containing a point
+type FindZonesRequest struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	AuthContext
+}
+
+// GetCourierStatusRequest for retrieving a courier's last-seen status
+type GetCourierStatusRequest struct {
+	CourierID int `json:"courier_id"`
+	AuthContext
+}
+
+// GetCourierDailySummaryRequest for a courier's end-of-day summary
+type GetCourierDailySummaryRequest struct {
+	CourierID int       `json:"courier_id"`
+	Date      time.Time `json:"date"` // the UTC calendar day containing this time
+	AuthContext
+}
+
+// GetFleetLocationsRequest for the dispatch fleet map
+type GetFleetLocationsRequest struct {
+	Box        *domain.BoundingBox `json:"bbox,omitempty"`    // only couriers inside; nil for anywhere
+	ActiveOnly bool                `json:"active,omitempty"` // only couriers with an assigned or in-transit delivery
+	AuthContext
 }
 
 // CalculateETAToDestinationRequest for calculating ETA to destination
@@ -48,6 +119,7 @@ type CalculateETAToDestinationRequest struct {
 	DeliveryID  int     `json:"delivery_id"`
 	DestLat     float64 `json:"dest_lat"`
 	DestLng     float64 `json:"dest_lng"`
+	AuthContext
 }
 
 // CalculateETAResponse for ETA calculation response
@@ -59,18 +131,50 @@ type CalculateETAResponse struct {
 
 // TrackingService defines the interface for tracking business operations
 type TrackingService interface {
-	// RecordLocation records a new location point
+	// RecordLocation records a new location point, or returns the stored one
+	// with Duplicate set when its client point ID was recorded before
 	RecordLocation(ctx context.Context, req RecordLocationRequest) (*domain.Location, error)
 
-	// GetDeliveryTrack retrieves the tracking history for a delivery
-	GetDeliveryTrack(ctx context.Context, req GetDeliveryTrackRequest) ([]*domain.Location, error)
+	// GetDeliveryTrack retrieves the tracking history for a delivery and how many points it had before simplification
+	GetDeliveryTrack(ctx context.Context, req GetDeliveryTrackRequest) ([]*domain.Location, int, error)
+
+	// GetTrackVersion returns how many points a delivery's track has and when the latest was recorded,
+	// without loading the track
+	GetTrackVersion(ctx context.Context, req GetTrackVersionRequest) (domain.TrackVersion, error)
+
+	// ExportDeliveryTrack passes a delivery's locations in the time window to emit, oldest first
+	ExportDeliveryTrack(ctx context.Context, req ExportDeliveryTrackRequest, emit func(*domain.Location) error) error
 
-	// GetCurrentLocation retrieves the current location for a delivery
-	GetCurrentLocation(ctx context.Context, req GetCurrentLocationRequest) (*domain.Location, error)
+	// EraseDeliveryTrack summarizes a delivery's track and deletes its raw points,
+	// returning the summary (nil if there were no points) and how many were deleted; admins only
+	EraseDeliveryTrack(ctx context.Context, req EraseDeliveryTrackRequest) (*domain.TrackSummary, int64, error)
 
-	// GetCourierLocation retrieves the current location for a courier
+	// GetCurrentLocation retrieves the current location for a delivery and whether it came from the cache
+	GetCurrentLocation(ctx context.Context, req GetCurrentLocationRequest) (*domain.Location, bool, error)
+
+	// GetCourierLocation retrieves the current location for a courier; only admins and services
+	// see couriers without an assigned or in-transit delivery
 	GetCourierLocation(ctx context.Context, req GetCourierLocationRequest) (*domain.Location, error)
 
+	// GetCourierAverageSpeed returns a courier's average reported speed in km/h over their recent points, 0 if unknown
+	GetCourierAverageSpeed(ctx context.Context, req GetCourierLocationRequest) (float64, error)
+
+	// GetCourierStatus reports when a courier last sent a location and whether they are active, stale or offline
+	GetCourierStatus(ctx context.Context, req GetCourierStatusRequest) (*domain.CourierHeartbeat, error)
+
+	// GetCourierDailySummary reports a courier's completed deliveries, distance and active time for a UTC day
+	GetCourierDailySummary(ctx context.Context, req GetCourierDailySummaryRequest) (*domain.CourierDaySummary, error)
+
+	// GetFleetLocations returns every recently reporting courier's latest location, liveness and
+	// active deliveries, and whether the list was capped; admins only
+	GetFleetLocations(ctx context.Context, req GetFleetLocationsRequest) ([]domain.FleetCourier, bool, error)
+
+	// TrackDelivery streams a delivery's last known and subsequent locations to send
+	TrackDelivery(ctx context.Context, req TrackDeliveryRequest, send func(*domain.Location) error) error
+
 	// CalculateETAToDestination calculates ETA from current location to destination
 	CalculateETAToDestination(ctx context.Context, req CalculateETAToDestinationRequest) (*CalculateETAResponse, error)
+
+	// FindZones returns the names of the active delivery zones containing a point
+	FindZones(ctx context.Context, req FindZonesRequest) ([]string, error)
 }
@@ -0,0 +1,2 @@
+-- Drop outbox table
+DROP TABLE IF EXISTS outbox_events;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Drop proof-of-delivery table
+DROP TABLE IF EXISTS delivery_confirmations;
@@ -0,0 +1,15 @@
+-- Create proof-of-delivery table; photo and signature blobs live in the blob store
+CREATE TABLE IF NOT EXISTS delivery_confirmations (
+    id SERIAL PRIMARY KEY,
+    delivery_id INTEGER NOT NULL UNIQUE,
+    courier_id INTEGER NOT NULL,
+    recipient_name VARCHAR(255),
+    confirmation_code VARCHAR(64),
+    photo_key TEXT,
+    signature_key TEXT,
+    notes TEXT,
+    confirmed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (delivery_id) REFERENCES deliveries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_confirmations_courier_id ON delivery_confirmations(courier_id);
@@ -0,0 +1,5 @@
+-- Drop notification read state
+DROP INDEX IF EXISTS idx_notifications_user_unread;
+DROP INDEX IF EXISTS idx_notifications_user_type;
+DROP INDEX IF EXISTS idx_notifications_user_created_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS read_at;
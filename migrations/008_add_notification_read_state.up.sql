@@ -0,0 +1,9 @@
+-- Track when a notification was read
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS read_at TIMESTAMP;
+
+-- Listing is always per user, newest first, optionally by type
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created_at ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_type ON notifications(user_id, type);
+
+-- Unread badge counts and unread-only listing only touch unread rows
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id, created_at DESC) WHERE read_at IS NULL;
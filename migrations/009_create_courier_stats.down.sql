@@ -0,0 +1,3 @@
+-- Drop courier performance aggregates
+DROP TABLE IF EXISTS courier_daily_stats;
+DROP TABLE IF EXISTS courier_deliveries;
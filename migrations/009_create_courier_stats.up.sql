@@ -0,0 +1,19 @@
+-- Per-delivery courier timeline; finished_at makes outcome recording idempotent
+CREATE TABLE IF NOT EXISTS courier_deliveries (
+    delivery_id INTEGER PRIMARY KEY,
+    courier_id INTEGER NOT NULL,
+    picked_up_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    outcome VARCHAR(20) CHECK (outcome IN ('completed', 'cancelled'))
+);
+
+-- Daily per-courier aggregates, incremented as delivery events arrive
+CREATE TABLE IF NOT EXISTS courier_daily_stats (
+    courier_id INTEGER NOT NULL,
+    day DATE NOT NULL,
+    completed INTEGER NOT NULL DEFAULT 0,
+    cancelled INTEGER NOT NULL DEFAULT 0,
+    timed_deliveries INTEGER NOT NULL DEFAULT 0,
+    total_delivery_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (courier_id, day)
+);
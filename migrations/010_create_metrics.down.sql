@@ -0,0 +1,2 @@
+-- Drop analytics metrics table
+DROP TABLE IF EXISTS metrics;
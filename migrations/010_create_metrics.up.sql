@@ -0,0 +1,17 @@
+-- Create analytics metrics table
+CREATE TABLE IF NOT EXISTS metrics (
+    id SERIAL PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    metadata JSONB,
+    timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_type_timestamp ON metrics(type, timestamp);
+CREATE INDEX IF NOT EXISTS idx_metrics_entity ON metrics(entity_type, entity_id);
+
+-- Customer dashboards filter delivery metrics by the customer in their metadata
+CREATE INDEX IF NOT EXISTS idx_metrics_customer_timestamp ON metrics((metadata->>'customer_id'), timestamp);
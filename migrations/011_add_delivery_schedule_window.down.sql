@@ -0,0 +1,4 @@
+-- Drop the delivery window end and late flag
+DROP INDEX IF EXISTS idx_deliveries_overdue;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS late;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS scheduled_end;
@@ -0,0 +1,8 @@
+-- The scheduled date starts the delivery window; scheduled_end closes it
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS scheduled_end TIMESTAMP;
+
+-- Set once a delivery is still open after its window closed
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS late BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The late-delivery job only scans open deliveries that are not flagged yet
+CREATE INDEX IF NOT EXISTS idx_deliveries_overdue ON deliveries(scheduled_end) WHERE late = FALSE AND status NOT IN ('delivered', 'cancelled');
@@ -0,0 +1,4 @@
+-- Drop the courier status timestamp and constraint
+ALTER TABLE couriers DROP COLUMN IF EXISTS status_updated_at;
+ALTER TABLE couriers DROP CONSTRAINT IF EXISTS couriers_status_check;
+ALTER TABLE couriers ALTER COLUMN status DROP NOT NULL;
@@ -0,0 +1,5 @@
+-- Couriers report availability themselves; status_updated_at records when it last changed
+UPDATE couriers SET status = 'available' WHERE status IS NULL OR status NOT IN ('available', 'busy', 'offline');
+ALTER TABLE couriers ALTER COLUMN status SET NOT NULL;
+ALTER TABLE couriers ADD CONSTRAINT couriers_status_check CHECK (status IN ('available', 'busy', 'offline'));
+ALTER TABLE couriers ADD COLUMN IF NOT EXISTS status_updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,3 @@
+-- Drop delivery tracking numbers
+DROP INDEX IF EXISTS idx_deliveries_tracking_number;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS tracking_number;
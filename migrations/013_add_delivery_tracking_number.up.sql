@@ -0,0 +1,40 @@
+-- Human-friendly tracking numbers customers quote to support, derived from the ID
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS tracking_number VARCHAR(20);
+
+-- Backfill existing deliveries the way the delivery service numbers new ones:
+-- "DT-", the ID in Crockford base32 padded to six digits, and a Luhn mod 32 check character
+DO $$
+DECLARE
+    alphabet CONSTANT TEXT := '0123456789ABCDEFGHJKMNPQRSTVWXYZ';
+    r RECORD;
+    n BIGINT;
+    digits TEXT;
+    factor INT;
+    total INT;
+    addend INT;
+BEGIN
+    FOR r IN SELECT id FROM deliveries WHERE tracking_number IS NULL LOOP
+        n := r.id;
+        digits := '';
+        WHILE n > 0 LOOP
+            digits := substr(alphabet, (n % 32)::INT + 1, 1) || digits;
+            n := n / 32;
+        END LOOP;
+        digits := repeat('0', greatest(0, 6 - length(digits))) || digits;
+
+        factor := 2;
+        total := 0;
+        FOR i IN REVERSE length(digits)..1 LOOP
+            addend := factor * (strpos(alphabet, substr(digits, i, 1)) - 1);
+            total := total + addend / 32 + addend % 32;
+            factor := 3 - factor;
+        END LOOP;
+
+        UPDATE deliveries
+        SET tracking_number = 'DT-' || digits || substr(alphabet, (32 - total % 32) % 32 + 1, 1)
+        WHERE id = r.id;
+    END LOOP;
+END $$;
+
+ALTER TABLE deliveries ALTER COLUMN tracking_number SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deliveries_tracking_number ON deliveries(tracking_number);
@@ -0,0 +1,5 @@
+-- Drop delivery cancellation details
+ALTER TABLE deliveries DROP CONSTRAINT IF EXISTS deliveries_cancel_reason_code_check;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS cancel_reason_code;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS cancel_reason;
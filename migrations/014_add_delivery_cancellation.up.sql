@@ -0,0 +1,8 @@
+-- Why and when a delivery was cancelled; cancelled deliveries are kept, not deleted
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS cancel_reason TEXT;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS cancel_reason_code VARCHAR(32);
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP;
+
+ALTER TABLE deliveries DROP CONSTRAINT IF EXISTS deliveries_cancel_reason_code_check;
+ALTER TABLE deliveries ADD CONSTRAINT deliveries_cancel_reason_code_check
+    CHECK (cancel_reason_code IN ('customer_request', 'address_invalid', 'courier_unavailable', 'other'));
@@ -0,0 +1,4 @@
+-- Drop email delivery tracking
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_email_status_check;
+ALTER TABLE notifications DROP COLUMN IF EXISTS email_error;
+ALTER TABLE notifications DROP COLUMN IF EXISTS email_status;
@@ -0,0 +1,7 @@
+-- Track delivery of email notifications; NULL for other channels
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_status VARCHAR(20);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_error TEXT;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_email_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_email_status_check
+    CHECK (email_status IN ('queued', 'sent', 'failed'));
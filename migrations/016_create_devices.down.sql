@@ -0,0 +1,2 @@
+-- Drop push notification devices
+DROP TABLE IF EXISTS devices;
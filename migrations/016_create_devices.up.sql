@@ -0,0 +1,14 @@
+-- Create push notification devices; a user registers each app install's token once
+CREATE TABLE IF NOT EXISTS devices (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    platform VARCHAR(16) NOT NULL CHECK (platform IN ('fcm', 'apns', 'webpush')),
+    token TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user_active ON devices(user_id) WHERE active;
@@ -0,0 +1,6 @@
+-- Drop event deduplication
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_source_event_type_key;
+ALTER TABLE metrics DROP COLUMN IF EXISTS source_event_id;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_source_event_type_key;
+ALTER TABLE notifications DROP COLUMN IF EXISTS source_event_id;
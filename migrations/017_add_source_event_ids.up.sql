@@ -0,0 +1,9 @@
+-- Remember which event produced each notification and metric so redelivered
+-- events are recognised; direct sends and records leave it NULL
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS source_event_id VARCHAR(64);
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_source_event_type_key;
+ALTER TABLE notifications ADD CONSTRAINT notifications_source_event_type_key UNIQUE (source_event_id, type);
+
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS source_event_id VARCHAR(64);
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_source_event_type_key;
+ALTER TABLE metrics ADD CONSTRAINT metrics_source_event_type_key UNIQUE (source_event_id, type);
@@ -0,0 +1,10 @@
+-- Track failed logins per username and source IP for lockout
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(255) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure TIMESTAMP WITH TIME ZONE,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_expires_at ON login_attempts(expires_at);
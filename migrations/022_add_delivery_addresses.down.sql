@@ -0,0 +1,13 @@
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS pickup_line1,
+    DROP COLUMN IF EXISTS pickup_city,
+    DROP COLUMN IF EXISTS pickup_postal_code,
+    DROP COLUMN IF EXISTS pickup_country,
+    DROP COLUMN IF EXISTS pickup_latitude,
+    DROP COLUMN IF EXISTS pickup_longitude,
+    DROP COLUMN IF EXISTS delivery_line1,
+    DROP COLUMN IF EXISTS delivery_city,
+    DROP COLUMN IF EXISTS delivery_postal_code,
+    DROP COLUMN IF EXISTS delivery_country,
+    DROP COLUMN IF EXISTS delivery_latitude,
+    DROP COLUMN IF EXISTS delivery_longitude;
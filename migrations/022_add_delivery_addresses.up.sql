@@ -0,0 +1,34 @@
+-- Structured pickup and drop-off addresses. pickup_location and
+-- delivery_location keep the address on one line for older readers.
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS pickup_line1 TEXT,
+    ADD COLUMN IF NOT EXISTS pickup_city VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS pickup_postal_code VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS pickup_country VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS pickup_latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS pickup_longitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS delivery_line1 TEXT,
+    ADD COLUMN IF NOT EXISTS delivery_city VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS delivery_postal_code VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS delivery_country VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS delivery_latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS delivery_longitude DOUBLE PRECISION;
+
+-- Backfill: existing locations become line1. Deliveries geocoded before this
+-- migration stored "(lng,lat)" instead of the address, which is copied into
+-- the coordinate columns. Free-text locations that were never geocoded stay
+-- without coordinates until the delivery is recreated.
+UPDATE deliveries SET pickup_line1 = pickup_location WHERE pickup_line1 IS NULL;
+UPDATE deliveries SET delivery_line1 = delivery_location WHERE delivery_line1 IS NULL;
+
+UPDATE deliveries
+SET pickup_longitude = (regexp_match(pickup_location, '^\s*\(\s*([-+]?\d*\.?\d+)\s*,\s*([-+]?\d*\.?\d+)\s*\)\s*$'))[1]::DOUBLE PRECISION,
+    pickup_latitude = (regexp_match(pickup_location, '^\s*\(\s*([-+]?\d*\.?\d+)\s*,\s*([-+]?\d*\.?\d+)\s*\)\s*$'))[2]::DOUBLE PRECISION
+WHERE pickup_latitude IS NULL
+  AND pickup_location ~ '^\s*\(\s*([-+]?\d*\.?\d+)\s*,\s*([-+]?\d*\.?\d+)\s*\)\s*$';
+
+UPDATE deliveries
+SET delivery_longitude = (regexp_match(delivery_location, '^\s*\(\s*([-+]?\d*\.?\d+)\s*,\s*([-+]?\d*\.?\d+)\s*\)\s*$'))[1]::DOUBLE PRECISION,
+    delivery_latitude = (regexp_match(delivery_location, '^\s*\(\s*([-+]?\d*\.?\d+)\s*,\s*([-+]?\d*\.?\d+)\s*\)\s*$'))[2]::DOUBLE PRECISION
+WHERE delivery_latitude IS NULL
+  AND delivery_location ~ '^\s*\(\s*([-+]?\d*\.?\d+)\s*,\s*([-+]?\d*\.?\d+)\s*\)\s*$';
@@ -0,0 +1,2 @@
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,4 @@
+-- Row version for optimistic concurrency: every update bumps it, and writers
+-- that read a delivery can require it to be unchanged
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- Drop route efficiency rollups
+DROP TABLE IF EXISTS route_daily_stats;
+DROP TABLE IF EXISTS delivery_tracks;
@@ -0,0 +1,22 @@
+-- Per-delivery track summary from location events; completed_at makes
+-- completion recording idempotent
+CREATE TABLE IF NOT EXISTS delivery_tracks (
+    delivery_id INTEGER PRIMARY KEY,
+    courier_id INTEGER NOT NULL,
+    points INTEGER NOT NULL DEFAULT 0,
+    distance_km DOUBLE PRECISION NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP
+);
+
+-- Daily route efficiency rollups per courier and drop-off zone
+CREATE TABLE IF NOT EXISTS route_daily_stats (
+    day DATE NOT NULL,
+    courier_id INTEGER NOT NULL,
+    zone VARCHAR(255) NOT NULL,
+    measured INTEGER NOT NULL DEFAULT 0,
+    actual_km DOUBLE PRECISION NOT NULL DEFAULT 0,
+    straight_line_km DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sparse_tracks INTEGER NOT NULL DEFAULT 0,
+    unmeasurable INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, courier_id, zone)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS quoted_currency,
+    DROP COLUMN IF EXISTS quoted_price_cents;
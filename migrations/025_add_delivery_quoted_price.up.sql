@@ -0,0 +1,5 @@
+-- Price honoured from a quote when the delivery was created, in the
+-- currency's minor unit; NULL for deliveries created without one
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS quoted_price_cents BIGINT,
+    ADD COLUMN IF NOT EXISTS quoted_currency VARCHAR(3);
@@ -0,0 +1,2 @@
+-- Admin replays read published events by creation time
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events(created_at);
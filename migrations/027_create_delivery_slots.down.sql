@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS delivery_slot_bookings;
+DROP TABLE IF EXISTS slot_capacities;
@@ -0,0 +1,18 @@
+-- Deliveries each zone takes in the two-hour slot starting at start_hour,
+-- edited by admins; zones without rows are not limited
+CREATE TABLE IF NOT EXISTS slot_capacities (
+    zone VARCHAR(100) NOT NULL,
+    start_hour SMALLINT NOT NULL CHECK (start_hour BETWEEN 0 AND 22),
+    capacity INTEGER NOT NULL CHECK (capacity >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (zone, start_hour)
+);
+
+-- The zone a scheduled delivery was booked in, resolved from its drop-off
+-- when it was created; its slot is found by its scheduled_date
+CREATE TABLE IF NOT EXISTS delivery_slot_bookings (
+    delivery_id INTEGER PRIMARY KEY REFERENCES deliveries(id) ON DELETE CASCADE,
+    zone VARCHAR(100) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_slot_bookings_zone ON delivery_slot_bookings(zone);
@@ -0,0 +1,4 @@
+-- Drop customer rollups
+DROP TABLE IF EXISTS customer_monthly_zones;
+DROP TABLE IF EXISTS customer_monthly_stats;
+DROP TABLE IF EXISTS customer_deliveries;
@@ -0,0 +1,33 @@
+-- Per-delivery customer timeline; created_at and finished_at make creation
+-- and outcome recording idempotent
+CREATE TABLE IF NOT EXISTS customer_deliveries (
+    delivery_id INTEGER PRIMARY KEY,
+    customer_id INTEGER NOT NULL,
+    created_at TIMESTAMP,
+    window_end TIMESTAMP,
+    finished_at TIMESTAMP,
+    outcome VARCHAR(20) CHECK (outcome IN ('completed', 'cancelled'))
+);
+
+-- Monthly per-customer aggregates, incremented as delivery events arrive
+CREATE TABLE IF NOT EXISTS customer_monthly_stats (
+    customer_id INTEGER NOT NULL,
+    month DATE NOT NULL,
+    created INTEGER NOT NULL DEFAULT 0,
+    delivered INTEGER NOT NULL DEFAULT 0,
+    cancelled INTEGER NOT NULL DEFAULT 0,
+    timed_deliveries INTEGER NOT NULL DEFAULT 0,
+    total_delivery_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    scheduled_deliveries INTEGER NOT NULL DEFAULT 0,
+    on_time_deliveries INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (customer_id, month)
+);
+
+-- Monthly delivered deliveries per customer and drop-off zone
+CREATE TABLE IF NOT EXISTS customer_monthly_zones (
+    customer_id INTEGER NOT NULL,
+    month DATE NOT NULL,
+    zone VARCHAR(255) NOT NULL,
+    delivered INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (customer_id, month, zone)
+);
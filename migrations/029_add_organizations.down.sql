@@ -0,0 +1,34 @@
+-- A courier's or customer's rollups are all in their own organization, so
+-- the original keys stay unique
+ALTER TABLE customer_monthly_zones DROP CONSTRAINT customer_monthly_zones_pkey;
+ALTER TABLE customer_monthly_zones ADD PRIMARY KEY (customer_id, month, zone);
+ALTER TABLE customer_monthly_zones DROP COLUMN IF EXISTS org_id;
+
+ALTER TABLE customer_monthly_stats DROP CONSTRAINT customer_monthly_stats_pkey;
+ALTER TABLE customer_monthly_stats ADD PRIMARY KEY (customer_id, month);
+ALTER TABLE customer_monthly_stats DROP COLUMN IF EXISTS org_id;
+
+ALTER TABLE route_daily_stats DROP CONSTRAINT route_daily_stats_pkey;
+ALTER TABLE route_daily_stats ADD PRIMARY KEY (day, courier_id, zone);
+ALTER TABLE route_daily_stats DROP COLUMN IF EXISTS org_id;
+
+ALTER TABLE courier_daily_stats DROP CONSTRAINT courier_daily_stats_pkey;
+ALTER TABLE courier_daily_stats ADD PRIMARY KEY (courier_id, day);
+ALTER TABLE courier_daily_stats DROP COLUMN IF EXISTS org_id;
+
+DROP INDEX IF EXISTS idx_metrics_org_type_timestamp;
+ALTER TABLE metrics DROP COLUMN IF EXISTS org_id;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+UPDATE users SET role = 'admin' WHERE role = 'super_admin';
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('customer', 'courier', 'admin'));
+
+DROP INDEX IF EXISTS idx_deliveries_org_id_created_at;
+DROP INDEX IF EXISTS idx_couriers_org_id_status;
+DROP INDEX IF EXISTS idx_users_org_id;
+
+ALTER TABLE deliveries DROP COLUMN IF EXISTS org_id;
+ALTER TABLE couriers DROP COLUMN IF EXISTS org_id;
+ALTER TABLE users DROP COLUMN IF EXISTS org_id;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,43 @@
+-- Organizations partition users, couriers, deliveries and the analytics
+-- rollups; rows from before organizations existed belong to the default one
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO organizations (id, name) VALUES (1, 'default') ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('organizations', 'id'), (SELECT MAX(id) FROM organizations));
+
+-- The default keeps writers that predate organizations in the default one
+ALTER TABLE users ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE couriers ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+CREATE INDEX IF NOT EXISTS idx_users_org_id ON users(org_id);
+CREATE INDEX IF NOT EXISTS idx_couriers_org_id_status ON couriers(org_id, status);
+CREATE INDEX IF NOT EXISTS idx_deliveries_org_id_created_at ON deliveries(org_id, created_at);
+
+-- Super-admins administer every organization
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('customer', 'courier', 'admin', 'super_admin'));
+
+-- Analytics rows carry the organization of the delivery events they count
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_metrics_org_type_timestamp ON metrics(org_id, type, timestamp);
+
+ALTER TABLE courier_daily_stats ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE courier_daily_stats DROP CONSTRAINT courier_daily_stats_pkey;
+ALTER TABLE courier_daily_stats ADD PRIMARY KEY (org_id, courier_id, day);
+
+ALTER TABLE route_daily_stats ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE route_daily_stats DROP CONSTRAINT route_daily_stats_pkey;
+ALTER TABLE route_daily_stats ADD PRIMARY KEY (org_id, day, courier_id, zone);
+
+ALTER TABLE customer_monthly_stats ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE customer_monthly_stats DROP CONSTRAINT customer_monthly_stats_pkey;
+ALTER TABLE customer_monthly_stats ADD PRIMARY KEY (org_id, customer_id, month);
+
+ALTER TABLE customer_monthly_zones ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE customer_monthly_zones DROP CONSTRAINT customer_monthly_zones_pkey;
+ALTER TABLE customer_monthly_zones ADD PRIMARY KEY (org_id, customer_id, month, zone);
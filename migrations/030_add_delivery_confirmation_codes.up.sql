@@ -0,0 +1,7 @@
+-- The code a customer hands the courier to prove the delivery reached them;
+-- existing deliveries get one as well
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS confirmation_code VARCHAR(6) NOT NULL DEFAULT '';
+
+UPDATE deliveries
+SET confirmation_code = lpad(floor(random() * 1000000)::int::text, 6, '0')
+WHERE confirmation_code = '';
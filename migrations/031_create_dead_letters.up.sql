@@ -0,0 +1,19 @@
+-- Dead-lettered events are stored before the dead letter queue message is
+-- acknowledged, so they survive restarts until retried. The body is kept as
+-- received, which may not be valid JSON.
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id SERIAL PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL DEFAULT '',
+    event_type VARCHAR(100) NOT NULL DEFAULT '',
+    exchange VARCHAR(255) NOT NULL DEFAULT '',
+    routing_key VARCHAR(255) NOT NULL DEFAULT '',
+    queue VARCHAR(255) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'retried', 'failed')),
+    body BYTEA,
+    dead_lettered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_status ON dead_letters(status);
@@ -0,0 +1,3 @@
+-- Audit entries are read by the admins of the organization their entity
+-- belongs to; entries from before organizations belong to the default one
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1;
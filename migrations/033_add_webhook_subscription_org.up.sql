@@ -0,0 +1,7 @@
+-- Subscriptions belong to their customer's organization, whose admins may
+-- manage them
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+UPDATE webhook_subscriptions s SET org_id = u.org_id
+FROM users u
+WHERE u.customer_id = s.customer_id AND u.org_id <> s.org_id;
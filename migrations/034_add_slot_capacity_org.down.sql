@@ -0,0 +1,5 @@
+DELETE FROM slot_capacities WHERE org_id <> 1;
+
+ALTER TABLE slot_capacities DROP CONSTRAINT IF EXISTS slot_capacities_pkey;
+ALTER TABLE slot_capacities ADD PRIMARY KEY (zone, start_hour);
+ALTER TABLE slot_capacities DROP COLUMN IF EXISTS org_id;
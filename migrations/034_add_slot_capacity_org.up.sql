@@ -0,0 +1,6 @@
+-- Each organization sets the slots its deliveries take in a zone; existing
+-- capacities stay with the default organization
+ALTER TABLE slot_capacities ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+ALTER TABLE slot_capacities DROP CONSTRAINT IF EXISTS slot_capacities_pkey;
+ALTER TABLE slot_capacities ADD PRIMARY KEY (org_id, zone, start_hour);
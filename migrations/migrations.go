@@ -0,0 +1,10 @@
+// Package migrations embeds the PostgreSQL schema migrations applied by
+// pkg/postgres/migrate
+package migrations
+
+import "embed"
+
+// FS holds the versioned up and down migrations
+//
+//go:embed *.sql
+var FS embed.FS
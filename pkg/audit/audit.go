@@ -0,0 +1,201 @@
+// Package audit records who changed what in the services' sensitive
+// mutations. Entries are written in the background so auditing never slows
+// down or fails the operation it records.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+// Entity types recorded in the audit log
+const (
+	EntityDelivery                = "delivery"
+	EntityUser                    = "user"
+	EntityNotificationPreferences = "notification_preferences"
+	EntityEventReplay             = "event_replay"  // identified by the replayed entity type
+	EntitySlotCapacity            = "slot_capacity" // identified by the delivery zone
+)
+
+// ActorAnonymous is the role recorded for unauthenticated actions such as self-registration
+const ActorAnonymous = "anonymous"
+
+// Writer settings
+const (
+	// DefaultBufferSize is how many entries may wait to be written before new ones are dropped
+	DefaultBufferSize = 1000
+	// writeTimeout bounds storing a single entry
+	writeTimeout = 5 * time.Second
+)
+
+// ErrInvalidQuery is returned for audit reads without an entity type and ID
+var ErrInvalidQuery = errors.New("entity type and ID are required")
+
+// Entry is one recorded mutation. Before and After are JSON snapshots of the
+// entity, either of which is empty when it did not exist.
+type Entry struct {
+	ID          int64           `json:"id"`
+	OrgID       int             `json:"org_id"`        // organization the entity belongs to
+	ActorUserID int             `json:"actor_user_id"` // 0 for anonymous actors and services
+	ActorRole   string          `json:"actor_role"`
+	Action      string          `json:"action"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    string          `json:"entity_id"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	TraceID     string          `json:"trace_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Store persists audit entries
+type Store interface {
+	// Write stores an entry, setting its ID
+	Write(ctx context.Context, entry *Entry) error
+
+	// ListByEntity retrieves up to limit of an entity's entries, newest first,
+	// only those recorded in orgID unless it is 0
+	ListByEntity(ctx context.Context, orgID int, entityType, entityID string, limit int) ([]*Entry, error)
+}
+
+// Writer records entries to a Store from a single background goroutine. When
+// its buffer is full, or the store fails, entries are dropped and counted
+// rather than holding up the caller. A nil Writer records nothing.
+type Writer struct {
+	store   Store
+	entries chan *Entry
+	dropped atomic.Int64
+	mu      sync.RWMutex // guards closed against Record sending on a closed channel
+	closed  bool
+	done    chan struct{}
+	logger  *logger.Logger
+}
+
+// NewWriter starts a writer holding up to bufferSize pending entries;
+// bufferSize <= 0 uses DefaultBufferSize. Close stops it.
+func NewWriter(store Store, bufferSize int, logger *logger.Logger) *Writer {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	w := &Writer{
+		store:   store,
+		entries: make(chan *Entry, bufferSize),
+		done:    make(chan struct{}),
+		logger:  logger,
+	}
+	go w.run()
+	return w
+}
+
+// Record queues an entry for action on an entity of organization orgID, the
+// default one when 0. The actor comes from the claims in ctx, anonymous when
+// there are none, and the snapshots are encoded immediately so later changes
+// to before and after are not recorded.
+func (w *Writer) Record(ctx context.Context, orgID int, action, entityType, entityID string, before, after interface{}) {
+	if w == nil {
+		return
+	}
+	if orgID == 0 {
+		orgID = authDomain.DefaultOrgID
+	}
+
+	entry := &Entry{
+		OrgID:      orgID,
+		ActorRole:  ActorAnonymous,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		TraceID:    tracing.TraceID(ctx),
+		CreatedAt:  time.Now(),
+	}
+	if claims, ok := authctx.ClaimsFrom(ctx); ok {
+		entry.ActorUserID = claims.UserID
+		entry.ActorRole = claims.Role
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err == nil {
+		entry.After, err = snapshot(after)
+	}
+	if err != nil {
+		w.dropped.Add(1)
+		w.logger.WarnWithFields(ctx, "Failed to encode audit snapshot",
+			zap.String("action", action), zap.Error(err))
+		return
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return
+	}
+	select {
+	case w.entries <- entry:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many entries were not stored
+func (w *Writer) Dropped() int64 {
+	if w == nil {
+		return 0
+	}
+	return w.dropped.Load()
+}
+
+// Close stops accepting entries and waits for the queued ones to be written
+func (w *Writer) Close() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+// run writes queued entries until the queue is closed and drained
+func (w *Writer) run() {
+	defer close(w.done)
+	for entry := range w.entries {
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		if err := w.store.Write(ctx, entry); err != nil {
+			w.dropped.Add(1)
+			w.logger.WarnWithFields(ctx, "Failed to write audit entry",
+				zap.String("action", entry.Action),
+				zap.String("entity_type", entry.EntityType),
+				zap.String("entity_id", entry.EntityID),
+				zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// snapshot encodes an entity as JSON, nil for a nil entity
+func snapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+	return data, nil
+}
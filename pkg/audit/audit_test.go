@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+// memoryStore keeps entries in memory; block holds writes until it is closed
+type memoryStore struct {
+	mu      sync.Mutex
+	entries []*Entry
+	err     error
+	block   chan struct{}
+}
+
+func (s *memoryStore) Write(ctx context.Context, entry *Entry) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	entry.ID = int64(len(s.entries) + 1)
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memoryStore) ListByEntity(ctx context.Context, orgID int, entityType, entityID string, limit int) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []*Entry
+	for i := len(s.entries) - 1; i >= 0 && len(matched) < limit; i-- {
+		if e := s.entries[i]; e.EntityType == entityType && e.EntityID == entityID && (orgID == 0 || e.OrgID == orgID) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, nil
+}
+
+func testLogger(t *testing.T) *logger.Logger {
+	return &logger.Logger{Logger: zaptest.NewLogger(t)}
+}
+
+func TestWriter_Record(t *testing.T) {
+	store := &memoryStore{}
+	w := NewWriter(store, 10, testLogger(t))
+
+	type delivery struct {
+		Status string `json:"status"`
+	}
+	before := &delivery{Status: "pending"}
+	after := &delivery{Status: "cancelled"}
+
+	ctx := authctx.WithClaims(context.Background(), &authDomain.Claims{UserID: 7, Role: "admin"})
+	w.Record(ctx, 2, "delivery.cancel", EntityDelivery, "12", before, after)
+	after.Status = "changed later"
+	w.Record(context.Background(), 0, "user.register", EntityUser, "3", nil, map[string]string{"username": "new"})
+	w.Close()
+
+	if len(store.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(store.entries))
+	}
+	cancel := store.entries[0]
+	if cancel.ActorUserID != 7 || cancel.ActorRole != "admin" || cancel.EntityID != "12" || cancel.OrgID != 2 {
+		t.Errorf("unexpected entry: %+v", cancel)
+	}
+	if string(cancel.Before) != `{"status":"pending"}` || string(cancel.After) != `{"status":"cancelled"}` {
+		t.Errorf("expected snapshots taken at record time, got %s and %s", cancel.Before, cancel.After)
+	}
+	if cancel.CreatedAt.IsZero() {
+		t.Error("expected a timestamp")
+	}
+
+	register := store.entries[1]
+	if register.ActorUserID != 0 || register.ActorRole != ActorAnonymous || register.Before != nil || register.OrgID != authDomain.DefaultOrgID {
+		t.Errorf("expected an anonymous entry in the default organization without a before snapshot, got %+v", register)
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("expected nothing dropped, got %d", w.Dropped())
+	}
+}
+
+func TestWriter_DropsWhenFull(t *testing.T) {
+	store := &memoryStore{block: make(chan struct{})}
+	w := NewWriter(store, 2, testLogger(t))
+
+	// One entry is held by the blocked store, two fill the buffer and the rest are dropped
+	for i := 0; i < 6; i++ {
+		w.Record(context.Background(), 0, "delivery.create", EntityDelivery, "1", nil, nil)
+	}
+	close(store.block)
+	w.Close()
+
+	if got := w.Dropped() + int64(len(store.entries)); got != 6 {
+		t.Errorf("expected every entry written or dropped, got %d", got)
+	}
+	if w.Dropped() < 3 {
+		t.Errorf("expected at least 3 dropped entries, got %d", w.Dropped())
+	}
+}
+
+func TestWriter_CountsFailedWrites(t *testing.T) {
+	store := &memoryStore{err: errors.New("database unavailable")}
+	w := NewWriter(store, 10, testLogger(t))
+
+	w.Record(context.Background(), 0, "delivery.create", EntityDelivery, "1", nil, nil)
+	w.Close()
+	w.Record(context.Background(), 0, "delivery.create", EntityDelivery, "2", nil, nil)
+
+	if w.Dropped() != 2 {
+		t.Errorf("expected the failed and the late entry dropped, got %d", w.Dropped())
+	}
+}
+
+func TestWriter_Nil(t *testing.T) {
+	var w *Writer
+	w.Record(context.Background(), 0, "delivery.create", EntityDelivery, "1", nil, nil)
+	w.Close()
+	if w.Dropped() != 0 {
+		t.Error("expected a nil writer to record nothing")
+	}
+}
+
+func TestHTTPHandler_ListEntries(t *testing.T) {
+	store := &memoryStore{}
+	for _, id := range []string{"1", "2", "1"} {
+		store.Write(context.Background(), &Entry{OrgID: 1, Action: "delivery.create", EntityType: EntityDelivery, EntityID: id})
+	}
+	store.Write(context.Background(), &Entry{OrgID: 2, Action: "delivery.update_status", EntityType: EntityDelivery, EntityID: "1"})
+	handler := NewHTTPHandler(store)
+
+	tests := []struct {
+		name           string
+		query          string
+		claims         *authDomain.Claims
+		expectedStatus int
+		expectedCount  int
+	}{
+		{name: "admin", query: "entity=delivery&id=1", claims: &authDomain.Claims{Role: "admin"}, expectedStatus: http.StatusOK, expectedCount: 2},
+		{name: "another organization's admin", query: "entity=delivery&id=1", claims: &authDomain.Claims{Role: "admin", OrgID: 2}, expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "super admin", query: "entity=delivery&id=1", claims: &authDomain.Claims{Role: "super_admin"}, expectedStatus: http.StatusOK, expectedCount: 3},
+		{name: "limit", query: "entity=delivery&id=1&limit=1", claims: &authDomain.Claims{Role: "admin"}, expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "missing id", query: "entity=delivery", claims: &authDomain.Claims{Role: "admin"}, expectedStatus: http.StatusBadRequest},
+		{name: "invalid limit", query: "entity=delivery&id=1&limit=0", claims: &authDomain.Claims{Role: "admin"}, expectedStatus: http.StatusBadRequest},
+		{name: "customer", query: "entity=delivery&id=1", claims: &authDomain.Claims{Role: "customer"}, expectedStatus: http.StatusForbidden},
+		{name: "anonymous", query: "entity=delivery&id=1", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit?"+tt.query, nil)
+			if tt.claims != nil {
+				req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+			}
+			w := httptest.NewRecorder()
+			handler.ListEntries(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var response struct {
+				Entries []*Entry `json:"entries"`
+				Count   int      `json:"count"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Count != tt.expectedCount || len(response.Entries) != tt.expectedCount {
+				t.Errorf("expected %d entries, got %d", tt.expectedCount, response.Count)
+			}
+		})
+	}
+}
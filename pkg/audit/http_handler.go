@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+)
+
+// Audit read limits
+const (
+	defaultListLimit = 100
+	maxListLimit     = 500
+)
+
+// HTTPHandler serves audit entries to admins, each their own organization's
+// and super-admins every organization's
+type HTTPHandler struct {
+	store Store
+}
+
+// NewHTTPHandler creates a new audit HTTP handler
+func NewHTTPHandler(store Store) *HTTPHandler {
+	return &HTTPHandler{store: store}
+}
+
+// listResponse is an entity's audit entries, newest first
+type listResponse struct {
+	Entries []*Entry `json:"entries"`
+	Count   int      `json:"count"`
+}
+
+// ListEntries handles GET /admin/audit?entity=delivery&id=123&limit=100
+func (h *HTTPHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userCtx, ok := httputil.RequireUserContext(w, r)
+	if !ok {
+		return
+	}
+	if userCtx.Role != "admin" && userCtx.Role != "super_admin" {
+		httputil.SendErrorResponse(w, "Only admins can read the audit log", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	entityType, entityID := query.Get("entity"), query.Get("id")
+	if entityType == "" || entityID == "" {
+		httputil.SendErrorResponse(w, ErrInvalidQuery.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultListLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.SendErrorResponse(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxListLimit)
+	}
+
+	ctx := httputil.ExtractTraceContext(r, "audit-log", "list_audit_entries_http")
+	orgID, _ := authctx.OrgScope(ctx)
+	entries, err := h.store.ListByEntity(ctx, orgID, entityType, entityID, limit)
+	if err != nil {
+		httputil.SendErrorResponse(w, "Failed to read audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Entries: entries, Count: len(entries)})
+}
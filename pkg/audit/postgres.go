@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PostgresStore implements Store using the audit_log table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgreSQL audit store
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Write stores an entry, setting its ID
+func (s *PostgresStore) Write(ctx context.Context, entry *Entry) error {
+	query := `
+		INSERT INTO audit_log (actor_user_id, actor_role, action, entity_type, entity_id,
+			before_snapshot, after_snapshot, trace_id, created_at, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10)
+		RETURNING id
+	`
+
+	err := s.db.QueryRowContext(ctx, query,
+		entry.ActorUserID, entry.ActorRole, entry.Action, entry.EntityType, entry.EntityID,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.TraceID, entry.CreatedAt, entry.OrgID,
+	).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListByEntity retrieves up to limit of an entity's entries, newest first,
+// only those of orgID unless it is 0
+func (s *PostgresStore) ListByEntity(ctx context.Context, orgID int, entityType, entityID string, limit int) ([]*Entry, error) {
+	query := `
+		SELECT id, org_id, actor_user_id, actor_role, action, entity_type, entity_id,
+			before_snapshot, after_snapshot, COALESCE(trace_id, ''), created_at
+		FROM audit_log
+		WHERE entity_type = $1 AND entity_id = $2 AND ($4 = 0 OR org_id = $4)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, entityType, entityID, limit, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+	for rows.Next() {
+		var entry Entry
+		var before, after []byte
+		if err := rows.Scan(&entry.ID, &entry.OrgID, &entry.ActorUserID, &entry.ActorRole, &entry.Action,
+			&entry.EntityType, &entry.EntityID, &before, &after, &entry.TraceID, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Before, entry.After = before, after
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// nullJSON stores an empty snapshot as NULL
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
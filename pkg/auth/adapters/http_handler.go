@@ -2,17 +2,24 @@ package adapters
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	pkghttp "github.com/Keneke-Einar/delivertrack/pkg/http"
 )
 
 // HTTPHandler handles HTTP requests for authentication operations
 type HTTPHandler struct {
-	authService   ports.AuthService
-	tokenDuration time.Duration
+	authService    ports.AuthService
+	tokenDuration  time.Duration
+	trustedProxies pkghttp.TrustedProxies
 }
 
 // NewHTTPHandler creates a new HTTP handler for authentication
@@ -23,6 +30,12 @@ func NewHTTPHandler(authService ports.AuthService, tokenDuration time.Duration)
 	}
 }
 
+// SetTrustedProxies sets the proxies whose forwarding headers are believed
+// when taking a login's source address; without them it is RemoteAddr
+func (h *HTTPHandler) SetTrustedProxies(proxies pkghttp.TrustedProxies) {
+	h.trustedProxies = proxies
+}
+
 // LoginRequest represents a login request payload
 type LoginRequest struct {
 	Username string `json:"username"`
@@ -36,12 +49,14 @@ type LoginResponse struct {
 	ExpiresIn int64              `json:"expires_in"` // seconds
 }
 
-// RegisterRequest represents a registration request payload
+// RegisterRequest represents a registration request payload; a missing
+// org_id registers into the default organization
 type RegisterRequest struct {
 	Username   string `json:"username"`
 	Email      string `json:"email"`
 	Password   string `json:"password"`
 	Role       string `json:"role"`
+	OrgID      int    `json:"org_id,omitempty"`
 	CustomerID *int   `json:"customer_id,omitempty"`
 	CourierID  *int   `json:"courier_id,omitempty"`
 }
@@ -52,6 +67,26 @@ type ErrorResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// IssueAPIKeyRequest represents an API key request payload; a missing
+// expires_at never expires
+type IssueAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// IssueAPIKeyResponse is the issued key with its plaintext, shown only once
+type IssueAPIKeyResponse struct {
+	*domain.APIKey
+	Key string `json:"key"`
+}
+
+// LockedResponse is sent while too many failed logins block a login
+type LockedResponse struct {
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+	RetryAfter int64  `json:"retry_after"` // seconds
+}
+
 // Login handles POST /login
 func (h *HTTPHandler) Login(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -71,8 +106,22 @@ func (h *HTTPHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Authenticate user
-	token, user, err := h.authService.Authenticate(r.Context(), req.Username, req.Password)
+	// Authenticate user, throttled per username and source IP
+	ctx := authctx.WithClientIP(r.Context(), h.trustedProxies.ClientIP(r))
+	token, user, err := h.authService.Authenticate(ctx, req.Username, req.Password)
+	var locked *domain.LockedError
+	if errors.As(err, &locked) {
+		retryAfter := int64(math.Ceil(locked.RetryAfter.Seconds()))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(LockedResponse{
+			Error:      http.StatusText(http.StatusTooManyRequests),
+			Message:    domain.ErrAccountLocked.Error(),
+			RetryAfter: retryAfter,
+		})
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, "Invalid credentials", http.StatusUnauthorized)
 		return
@@ -109,13 +158,27 @@ func (h *HTTPHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The route is public, so an admin registering someone else sends their token
+	ctx := r.Context()
+	if _, ok := authctx.ClaimsFrom(ctx); !ok {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			claims, err := h.authService.ValidateToken(ctx, token)
+			if err != nil {
+				sendErrorResponse(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			ctx = authctx.WithClaims(ctx, claims)
+		}
+	}
+
 	// Create user
 	user, err := h.authService.Register(
-		r.Context(),
+		ctx,
 		req.Username,
 		req.Email,
 		req.Password,
 		req.Role,
+		req.OrgID,
 		req.CustomerID,
 		req.CourierID,
 	)
@@ -123,7 +186,9 @@ func (h *HTTPHandler) Register(w http.ResponseWriter, r *http.Request) {
 		statusCode := http.StatusInternalServerError
 		if err == domain.ErrUserExists {
 			statusCode = http.StatusConflict
-		} else if err == domain.ErrInvalidRole || err == domain.ErrInvalidUserData {
+		} else if err == domain.ErrForbidden {
+			statusCode = http.StatusForbidden
+		} else if err == domain.ErrInvalidRole || err == domain.ErrInvalidUserData || errors.Is(err, domain.ErrOrgNotFound) {
 			statusCode = http.StatusBadRequest
 		}
 		sendErrorResponse(w, err.Error(), statusCode)
@@ -136,6 +201,298 @@ func (h *HTTPHandler) Register(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user.ToPublicUser())
 }
 
+// UpdateProfileRequest represents a profile update payload
+type UpdateProfileRequest struct {
+	Email string `json:"email"`
+}
+
+// ChangePasswordRequest represents a password change payload
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// SetActiveRequest represents an account activation payload
+type SetActiveRequest struct {
+	Active *bool `json:"active"`
+}
+
+// SetOrganizationRequest represents a payload moving a user to another organization
+type SetOrganizationRequest struct {
+	OrgID int `json:"org_id"`
+}
+
+// Me handles GET /me and PUT /me
+func (h *HTTPHandler) Me(w http.ResponseWriter, r *http.Request) {
+	claims, ok := authctx.ClaimsFrom(r.Context())
+	if !ok {
+		sendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var user *domain.User
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		user, err = h.authService.GetUser(r.Context(), claims.UserID)
+	case http.MethodPut:
+		var req UpdateProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		user, err = h.authService.UpdateEmail(r.Context(), claims.UserID, req.Email)
+	default:
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		sendUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.ToPublicUser())
+}
+
+// ChangePassword handles PUT /me/password
+func (h *HTTPHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := authctx.ClaimsFrom(r.Context())
+	if !ok {
+		sendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		sendErrorResponse(w, "Current and new password are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.ChangePassword(r.Context(), claims.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+		sendUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Users handles the admin account routes PUT /users/{id}/active,
+// POST /users/{id}/unlock, PUT /users/{id}/organization and the API key
+// routes under /users/{id}/api-keys. Admins only manage the users of their
+// own organization, and of those neither super-admins nor other admins, who
+// would otherwise hand them a key acting with more authority than their own;
+// super-admins manage everyone.
+func (h *HTTPHandler) Users(w http.ResponseWriter, r *http.Request) {
+	claims, ok := authctx.ClaimsFrom(r.Context())
+	if !ok {
+		sendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if !domain.IsAdminRole(claims.Role) {
+		sendErrorResponse(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+		return
+	}
+
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		sendErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Users of other organizations are not found, as if they did not exist
+	if orgID, scoped := claims.OrgScope(); scoped {
+		user, err := h.authService.GetUser(r.Context(), id)
+		if err != nil {
+			sendUserError(w, err)
+			return
+		}
+		if user.OrgID != orgID {
+			sendUserError(w, domain.ErrUserNotFound)
+			return
+		}
+		if user.Role == domain.RoleSuperAdmin || (domain.IsAdminRole(user.Role) && user.ID != claims.UserID) {
+			sendErrorResponse(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	action, keyIDStr, hasKeyID := strings.Cut(action, "/")
+
+	// A leaked key must not be able to mint or revoke keys
+	if action == "api-keys" && claims.APIKeyID != 0 {
+		sendErrorResponse(w, "API keys cannot be managed with an API key", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case action == "active" && !hasKeyID:
+		h.setUserActive(w, r, claims, id)
+	case action == "unlock" && !hasKeyID:
+		h.unlockUser(w, r, id)
+	case action == "organization" && !hasKeyID:
+		h.setUserOrganization(w, r, claims, id)
+	case action == "api-keys" && !hasKeyID:
+		h.apiKeys(w, r, id)
+	case action == "api-keys":
+		keyID, err := strconv.Atoi(keyIDStr)
+		if err != nil || keyID <= 0 {
+			sendErrorResponse(w, "Invalid API key ID", http.StatusBadRequest)
+			return
+		}
+		h.revokeAPIKey(w, r, id, keyID)
+	default:
+		sendErrorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// setUserActive handles PUT /users/{id}/active
+func (h *HTTPHandler) setUserActive(w http.ResponseWriter, r *http.Request, claims *domain.Claims, id int) {
+	if r.Method != http.MethodPut {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SetActiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Active == nil {
+		sendErrorResponse(w, "Invalid request body, expected {\"active\": true|false}", http.StatusBadRequest)
+		return
+	}
+
+	// Admins cannot lock themselves out
+	if id == claims.UserID && !*req.Active {
+		sendErrorResponse(w, "Cannot deactivate your own account", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.authService.SetUserActive(r.Context(), id, *req.Active)
+	if err != nil {
+		sendUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.ToPublicUser())
+}
+
+// setUserOrganization handles PUT /users/{id}/organization, which only
+// super-admins may call
+func (h *HTTPHandler) setUserOrganization(w http.ResponseWriter, r *http.Request, claims *domain.Claims, id int) {
+	if r.Method != http.MethodPut {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if claims.Role != domain.RoleSuperAdmin {
+		sendErrorResponse(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+		return
+	}
+
+	var req SetOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.OrgID <= 0 {
+		sendErrorResponse(w, "Invalid request body, expected {\"org_id\": <id>}", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.authService.SetUserOrganization(r.Context(), id, req.OrgID)
+	if err != nil {
+		sendUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.ToPublicUser())
+}
+
+// unlockUser handles POST /users/{id}/unlock
+func (h *HTTPHandler) unlockUser(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.authService.UnlockUser(r.Context(), id); err != nil {
+		sendUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiKeys handles POST /users/{id}/api-keys, which issues a key, and
+// GET /users/{id}/api-keys, which lists them
+func (h *HTTPHandler) apiKeys(w http.ResponseWriter, r *http.Request, userID int) {
+	switch r.Method {
+	case http.MethodPost:
+		var req IssueAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		key, plaintext, err := h.authService.IssueAPIKey(r.Context(), userID, req.Name, req.ExpiresAt)
+		if err != nil {
+			sendUserError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(IssueAPIKeyResponse{APIKey: key, Key: plaintext})
+	case http.MethodGet:
+		keys, err := h.authService.ListAPIKeys(r.Context(), userID)
+		if err != nil {
+			sendUserError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(keys)
+	default:
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// revokeAPIKey handles DELETE /users/{id}/api-keys/{keyID}
+func (h *HTTPHandler) revokeAPIKey(w http.ResponseWriter, r *http.Request, userID, keyID int) {
+	if r.Method != http.MethodDelete {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.authService.RevokeAPIKey(r.Context(), userID, keyID); err != nil {
+		sendUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sendUserError maps account errors to HTTP status codes, hiding unexpected ones
+func sendUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrAPIKeyNotFound):
+		sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrEmailTaken), errors.Is(err, domain.ErrUserInactive):
+		sendErrorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidUserData), errors.Is(err, domain.ErrWeakPassword), errors.Is(err, domain.ErrOrgNotFound):
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		sendErrorResponse(w, "Current password is incorrect", http.StatusForbidden)
+	default:
+		sendErrorResponse(w, "Failed to update account", http.StatusInternalServerError)
+	}
+}
+
 // sendErrorResponse sends a JSON error response
 func sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
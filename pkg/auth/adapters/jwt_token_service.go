@@ -1,40 +1,178 @@
 package adapters
 
 import (
+	"crypto/rsa"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTTokenService implements the TokenService interface using JWT
+// DefaultJWTKeyID is the ID of the key built from the single configured
+// secret. Tokens without a kid header, issued before key IDs existed, are
+// validated against it when it is in the key set.
+const DefaultJWTKeyID = "default"
+
+// JWTKey is a key tokens are signed or validated with. A key without a
+// signing key, such as an RS256 public key, only validates.
+type JWTKey struct {
+	ID        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey creates an HS256 key from a shared secret
+func NewHMACKey(id, secret string) JWTKey {
+	return JWTKey{ID: id, method: jwt.SigningMethodHS256, signKey: []byte(secret), verifyKey: []byte(secret)}
+}
+
+// NewRSAKey creates an RS256 key from PEM encoded keys. The private key may be
+// empty on services that only validate tokens; the public key may be empty
+// when it can be derived from the private key.
+func NewRSAKey(id, privateKeyPEM, publicKeyPEM string) (JWTKey, error) {
+	key := JWTKey{ID: id, method: jwt.SigningMethodRS256}
+
+	if privateKeyPEM != "" {
+		private, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("jwt key %q: invalid private key: %w", id, err)
+		}
+		key.signKey = private
+		key.verifyKey = &private.PublicKey
+	}
+	if publicKeyPEM != "" {
+		public, err := jwt.ParseRSAPublicKeyFromPEM([]byte(publicKeyPEM))
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("jwt key %q: invalid public key: %w", id, err)
+		}
+		if private, ok := key.signKey.(*rsa.PrivateKey); ok && !private.PublicKey.Equal(public) {
+			return JWTKey{}, fmt.Errorf("jwt key %q: public key does not match private key", id)
+		}
+		key.verifyKey = public
+	}
+	if key.verifyKey == nil {
+		return JWTKey{}, fmt.Errorf("jwt key %q: a private or public key is required", id)
+	}
+
+	return key, nil
+}
+
+// JWTTokenService implements the TokenService interface using JWT. Tokens are
+// signed with the current key and carry its ID in the kid header; any key in
+// the set validates, so tokens of a previous key stay valid during rotation.
 type JWTTokenService struct {
-	secret        []byte
+	keys          map[string]JWTKey
+	current       JWTKey
 	tokenDuration time.Duration
 }
 
-// NewJWTTokenService creates a new JWT token service
+// NewJWTTokenService creates a new JWT token service signing with a single
+// HS256 secret
 func NewJWTTokenService(secret string, tokenDuration time.Duration) *JWTTokenService {
+	key := NewHMACKey(DefaultJWTKeyID, secret)
 	return &JWTTokenService{
-		secret:        []byte(secret),
+		keys:          map[string]JWTKey{key.ID: key},
+		current:       key,
 		tokenDuration: tokenDuration,
 	}
 }
 
+// NewJWTTokenServiceWithKeys creates a JWT token service that validates with
+// any of keys. currentKeyID names the key new tokens are signed with; it may
+// be empty on services that never issue tokens.
+func NewJWTTokenServiceWithKeys(keys []JWTKey, currentKeyID string, tokenDuration time.Duration) (*JWTTokenService, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one jwt key is required")
+	}
+
+	s := &JWTTokenService{keys: make(map[string]JWTKey, len(keys)), tokenDuration: tokenDuration}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("jwt keys must have an id")
+		}
+		if _, ok := s.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
+		s.keys[key.ID] = key
+	}
+
+	if currentKeyID != "" {
+		current, ok := s.keys[currentKeyID]
+		if !ok {
+			return nil, fmt.Errorf("jwt signing key %q is not in the key set", currentKeyID)
+		}
+		if current.signKey == nil {
+			return nil, fmt.Errorf("jwt signing key %q has no private key", currentKeyID)
+		}
+		s.current = current
+	}
+
+	return s, nil
+}
+
+// NewJWTTokenServiceFromConfig creates a JWT token service from the auth
+// configuration: the jwt_keys set when one is configured, signing with
+// jwt_signing_key, and otherwise the single jwt_secret
+func NewJWTTokenServiceFromConfig(cfg config.AuthConfig) (*JWTTokenService, error) {
+	if len(cfg.JWTKeys) == 0 {
+		return NewJWTTokenService(cfg.JWTSecret, cfg.JWTExpiration), nil
+	}
+
+	keys := make([]JWTKey, 0, len(cfg.JWTKeys))
+	for _, kc := range cfg.JWTKeys {
+		switch kc.Algorithm {
+		case "", "HS256":
+			if kc.Secret == "" {
+				return nil, fmt.Errorf("jwt key %q: secret is required for HS256", kc.ID)
+			}
+			keys = append(keys, NewHMACKey(kc.ID, kc.Secret))
+		case "RS256":
+			key, err := NewRSAKey(kc.ID, kc.PrivateKey, kc.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("jwt key %q: unsupported algorithm %q, expected HS256 or RS256", kc.ID, kc.Algorithm)
+		}
+	}
+
+	// Without an explicit signing key, sign with the first key able to
+	signingKey := cfg.JWTSigningKey
+	if signingKey == "" {
+		for _, key := range keys {
+			if key.signKey != nil {
+				signingKey = key.ID
+				break
+			}
+		}
+	}
+
+	return NewJWTTokenServiceWithKeys(keys, signingKey, cfg.JWTExpiration)
+}
+
 // JWTClaims extends jwt.RegisteredClaims with custom fields
 type JWTClaims struct {
 	UserID     int    `json:"user_id"`
 	Username   string `json:"username"`
 	Email      string `json:"email"`
 	Role       string `json:"role"`
+	OrgID      int    `json:"org_id,omitempty"`
 	CustomerID *int   `json:"customer_id,omitempty"`
 	CourierID  *int   `json:"courier_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token for a user
+// GenerateToken creates a new JWT token for a user, signed with the current key
 func (s *JWTTokenService) GenerateToken(user *domain.User) (string, error) {
+	if s.current.signKey == nil {
+		return "", errors.New("failed to sign token: no jwt signing key configured")
+	}
+
 	now := time.Now()
 	expiresAt := now.Add(s.tokenDuration)
 
@@ -43,6 +181,7 @@ func (s *JWTTokenService) GenerateToken(user *domain.User) (string, error) {
 		Username:   user.Username,
 		Email:      user.Email,
 		Role:       user.Role,
+		OrgID:      user.OrgID,
 		CustomerID: user.CustomerID,
 		CourierID:  user.CourierID,
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -51,8 +190,9 @@ func (s *JWTTokenService) GenerateToken(user *domain.User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.secret)
+	token := jwt.NewWithClaims(s.current.method, claims)
+	token.Header["kid"] = s.current.ID
+	tokenString, err := token.SignedString(s.current.signKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -60,15 +200,31 @@ func (s *JWTTokenService) GenerateToken(user *domain.User) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func (s *JWTTokenService) ValidateToken(tokenString string) (*domain.Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.secret, nil
-	})
+// keyFor returns the validation key named by a token's kid header, rejecting
+// unknown kids and tokens signed with another algorithm than their key's
+func (s *JWTTokenService) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = DefaultJWTKeyID
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
 
+// ValidateToken validates a JWT token against the key named by its kid and
+// returns the claims
+func (s *JWTTokenService) ValidateToken(tokenString string) (*domain.Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.keyFor)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, domain.ErrExpiredToken
+	}
 	if err != nil {
 		return nil, domain.ErrInvalidToken
 	}
@@ -88,6 +244,7 @@ func (s *JWTTokenService) ValidateToken(tokenString string) (*domain.Claims, err
 		Username:   claims.Username,
 		Email:      claims.Email,
 		Role:       claims.Role,
+		OrgID:      claims.OrgID,
 		CustomerID: claims.CustomerID,
 		CourierID:  claims.CourierID,
 	}, nil
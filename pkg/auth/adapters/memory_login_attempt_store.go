@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// memoryLoginAttempt is a key's attempts and when they stop mattering
+type memoryLoginAttempt struct {
+	attempts domain.LoginAttempts
+	expires  time.Time
+}
+
+// MemoryLoginAttemptStore implements the LoginAttemptStore interface in
+// process memory; lockouts are lost on restart
+type MemoryLoginAttemptStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryLoginAttempt
+	lastPurge time.Time
+}
+
+// NewMemoryLoginAttemptStore creates an empty in-memory login attempt store
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{entries: make(map[string]memoryLoginAttempt)}
+}
+
+// Get returns a key's attempts unless they expired
+func (s *MemoryLoginAttemptStore) Get(ctx context.Context, key string) (domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !time.Now().Before(entry.expires) {
+		return domain.LoginAttempts{}, nil
+	}
+	return entry.attempts, nil
+}
+
+// RecordFailure counts a failed login
+func (s *MemoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, policy domain.LockoutPolicy, now time.Time) (domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPurge) >= loginAttemptPurgeInterval {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastPurge = now
+	}
+
+	attempts := s.entries[key].attempts
+	attempts.RecordFailure(now, policy)
+	s.entries[key] = memoryLoginAttempt{attempts: attempts, expires: attemptsExpiry(attempts, policy)}
+	return attempts, nil
+}
+
+// Reset forgets a key's failures
+func (s *MemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,127 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// PostgresAPIKeyRepository implements the APIKeyRepository interface using PostgreSQL
+type PostgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new PostgreSQL API key repository
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, created_at, expires_at, revoked_at, last_used_at`
+
+// Create stores a new key, setting its ID
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, key.CreatedAt, key.ExpiresAt,
+	).Scan(&key.ID)
+}
+
+// GetByPrefix retrieves a key by its lookup prefix
+func (r *PostgresAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, prefix))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// ListByUser retrieves a user's keys, newest first
+func (r *PostgresAPIKeyRepository) ListByUser(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Revoke marks a user's key as revoked; revoking it again keeps the first time
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, userID, id int, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2 AND user_id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, at, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed records when a key was last used
+func (r *PostgresAPIKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// scanAPIKey reads a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var expiresAt, revokedAt, lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.CreatedAt,
+		&expiresAt,
+		&revokedAt,
+		&lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &key, nil
+}
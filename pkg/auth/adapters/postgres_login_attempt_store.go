@@ -0,0 +1,156 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+)
+
+// loginAttemptPurgeInterval is how often stores drop entries that no longer
+// affect lockout
+const loginAttemptPurgeInterval = 10 * time.Minute
+
+// NewLoginAttemptStore creates the login attempt store named by kind:
+// "postgres" (the default) keeps lockouts across restarts, "memory" keeps
+// them per process
+func NewLoginAttemptStore(kind string, db *sql.DB) (ports.LoginAttemptStore, error) {
+	switch kind {
+	case "", "postgres":
+		return NewPostgresLoginAttemptStore(db), nil
+	case "memory":
+		return NewMemoryLoginAttemptStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown login attempt store %q, expected postgres or memory", kind)
+	}
+}
+
+// attemptsExpiry is when recorded attempts stop mattering: once the window
+// has passed and any lock is over
+func attemptsExpiry(attempts domain.LoginAttempts, policy domain.LockoutPolicy) time.Time {
+	expires := attempts.LastFailure.Add(policy.Window)
+	if attempts.LockedUntil.After(expires) {
+		expires = attempts.LockedUntil
+	}
+	return expires
+}
+
+// PostgresLoginAttemptStore implements the LoginAttemptStore interface using PostgreSQL
+type PostgresLoginAttemptStore struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// NewPostgresLoginAttemptStore creates a new PostgreSQL login attempt store
+func NewPostgresLoginAttemptStore(db *sql.DB) *PostgresLoginAttemptStore {
+	return &PostgresLoginAttemptStore{db: db}
+}
+
+// Get returns a key's attempts unless they expired
+func (s *PostgresLoginAttemptStore) Get(ctx context.Context, key string) (domain.LoginAttempts, error) {
+	query := `
+		SELECT failures, last_failure, locked_until
+		FROM login_attempts
+		WHERE key = $1 AND expires_at > $2
+	`
+
+	attempts, err := scanLoginAttempts(s.db.QueryRowContext(ctx, query, key, time.Now()))
+	if err == sql.ErrNoRows {
+		return domain.LoginAttempts{}, nil
+	}
+	return attempts, err
+}
+
+// RecordFailure counts a failed login, locking the row so concurrent
+// failures for the same key are all counted
+func (s *PostgresLoginAttemptStore) RecordFailure(ctx context.Context, key string, policy domain.LockoutPolicy, now time.Time) (domain.LoginAttempts, error) {
+	s.purgeExpired(ctx, now)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.LoginAttempts{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO login_attempts (key, failures, expires_at)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (key) DO NOTHING
+	`, key, now)
+	if err != nil {
+		return domain.LoginAttempts{}, err
+	}
+
+	attempts, err := scanLoginAttempts(tx.QueryRowContext(ctx, `
+		SELECT failures, last_failure, locked_until
+		FROM login_attempts
+		WHERE key = $1
+		FOR UPDATE
+	`, key))
+	if err != nil {
+		return domain.LoginAttempts{}, err
+	}
+
+	attempts.RecordFailure(now, policy)
+
+	var lockedUntil sql.NullTime
+	if !attempts.LockedUntil.IsZero() {
+		lockedUntil = sql.NullTime{Time: attempts.LockedUntil, Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE login_attempts
+		SET failures = $2, last_failure = $3, locked_until = $4, expires_at = $5
+		WHERE key = $1
+	`, key, attempts.Failures, attempts.LastFailure, lockedUntil, attemptsExpiry(attempts, policy))
+	if err != nil {
+		return domain.LoginAttempts{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.LoginAttempts{}, err
+	}
+	return attempts, nil
+}
+
+// Reset forgets a key's failures
+func (s *PostgresLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, key)
+	return err
+}
+
+// purgeExpired deletes expired rows at most once per purge interval, so
+// guesses at random usernames do not grow the table without bound
+func (s *PostgresLoginAttemptStore) purgeExpired(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastPurge) < loginAttemptPurgeInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPurge = now
+	s.mu.Unlock()
+
+	// Best effort: the next purge catches anything missed
+	s.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE expires_at <= $1`, now)
+}
+
+func scanLoginAttempts(row rowScanner) (domain.LoginAttempts, error) {
+	var attempts domain.LoginAttempts
+	var lastFailure, lockedUntil sql.NullTime
+	if err := row.Scan(&attempts.Failures, &lastFailure, &lockedUntil); err != nil {
+		return domain.LoginAttempts{}, err
+	}
+	attempts.LastFailure = lastFailure.Time
+	attempts.LockedUntil = lockedUntil.Time
+	return attempts, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
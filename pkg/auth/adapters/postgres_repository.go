@@ -3,8 +3,17 @@ package adapters
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/lib/pq"
+)
+
+// PostgreSQL error codes for constraint violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
 )
 
 // PostgresUserRepository implements the UserRepository interface using PostgreSQL
@@ -40,18 +49,18 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User)
 	if user.Role == "courier" && user.CourierID == nil {
 		var courID int
 		err := tx.QueryRowContext(ctx,
-			`INSERT INTO couriers (name, vehicle_type, phone) VALUES ($1, $2, $3) RETURNING id`,
-			user.Username, "bicycle", "N/A",
+			`INSERT INTO couriers (name, vehicle_type, phone, org_id) VALUES ($1, $2, $3, $4) RETURNING id`,
+			user.Username, "bicycle", "N/A", user.OrgID,
 		).Scan(&courID)
 		if err != nil {
-			return err
+			return orgViolation(err)
 		}
 		user.CourierID = &courID
 	}
 
 	query := `
-		INSERT INTO users (username, email, password_hash, role, customer_id, courier_id, active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (username, email, password_hash, role, customer_id, courier_id, active, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -73,9 +82,10 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User)
 		customerID,
 		courierID,
 		user.Active,
+		user.OrgID,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
-		return err
+		return orgViolation(err)
 	}
 
 	return tx.Commit()
@@ -84,7 +94,7 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User)
 // GetByID retrieves a user by ID
 func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, customer_id, courier_id, active, created_at, updated_at
+		SELECT id, username, email, password_hash, role, org_id, customer_id, courier_id, active, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -98,6 +108,7 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (*domain.U
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
+		&user.OrgID,
 		&customerID,
 		&courierID,
 		&user.Active,
@@ -127,7 +138,7 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (*domain.U
 // GetByUsername retrieves a user by username
 func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, customer_id, courier_id, active, created_at, updated_at
+		SELECT id, username, email, password_hash, role, org_id, customer_id, courier_id, active, created_at, updated_at
 		FROM users
 		WHERE username = $1
 	`
@@ -141,6 +152,7 @@ func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username str
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
+		&user.OrgID,
 		&customerID,
 		&courierID,
 		&user.Active,
@@ -170,7 +182,7 @@ func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username str
 // GetByEmail retrieves a user by email
 func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, customer_id, courier_id, active, created_at, updated_at
+		SELECT id, username, email, password_hash, role, org_id, customer_id, courier_id, active, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -184,6 +196,7 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
+		&user.OrgID,
 		&customerID,
 		&courierID,
 		&user.Active,
@@ -215,8 +228,8 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User)
 	query := `
 		UPDATE users
 		SET username = $1, email = $2, password_hash = $3, role = $4, 
-		    customer_id = $5, courier_id = $6, active = $7, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $8
+		    customer_id = $5, courier_id = $6, active = $7, org_id = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9
 		RETURNING updated_at
 	`
 
@@ -238,15 +251,108 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User)
 		customerID,
 		courierID,
 		user.Active,
+		user.OrgID,
 		user.ID,
 	).Scan(&user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return domain.ErrUserNotFound
 	}
+	return orgViolation(err)
+}
+
+// UpdateEmail changes a user's email
+func (r *PostgresUserRepository) UpdateEmail(ctx context.Context, id int, email string) error {
+	query := `UPDATE users SET email = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, email, id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+// UpdatePassword replaces a user's password hash
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, passwordHash, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+// SetActive activates or deactivates a user
+func (r *PostgresUserRepository) SetActive(ctx context.Context, id int, active bool) error {
+	query := `UPDATE users SET active = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, active, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+// SetOrganization moves a user, and the courier profile linked to it, to
+// another organization
+func (r *PostgresUserRepository) SetOrganization(ctx context.Context, id, orgID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var courierID sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		`UPDATE users SET org_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING courier_id`,
+		orgID, id,
+	).Scan(&courierID)
+	if err == sql.ErrNoRows {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return orgViolation(err)
+	}
+
+	if courierID.Valid {
+		if _, err := tx.ExecContext(ctx, `UPDATE couriers SET org_id = $1 WHERE id = $2`, orgID, courierID.Int64); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// orgViolation returns domain.ErrOrgNotFound when err is a write naming an
+// organization that does not exist
+func orgViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation && strings.Contains(pqErr.Constraint, "org_id") {
+		return domain.ErrOrgNotFound
+	}
 	return err
 }
 
+// requireRow returns domain.ErrUserNotFound when a statement matched no user
+func requireRow(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
 // Delete deletes a user
 func (r *PostgresUserRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM users WHERE id = $1`
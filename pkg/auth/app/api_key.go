@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+)
+
+// errAPIKeysNotConfigured is returned when no API key repository was set
+var errAPIKeysNotConfigured = errors.New("api keys are not configured")
+
+// lastUsedResolution bounds how often a key's last-used time is written, so
+// busy keys do not cost a write per request
+const lastUsedResolution = time.Minute
+
+// SetAPIKeyRepository enables API key authentication with keys stored in repo
+func (s *AuthService) SetAPIKeyRepository(repo ports.APIKeyRepository) {
+	s.apiKeys = repo
+}
+
+// IssueAPIKey creates an API key acting as an active user. The plaintext is
+// returned once; only its hash is stored.
+func (s *AuthService) IssueAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	if s.apiKeys == nil {
+		return nil, "", errAPIKeysNotConfigured
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !user.IsActive() {
+		return nil, "", domain.ErrUserInactive
+	}
+
+	key, plaintext, err := domain.NewAPIKey(user.ID, name, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.apiKeys.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return key, plaintext, nil
+}
+
+// ListAPIKeys retrieves a user's API keys, including revoked and expired ones
+func (s *AuthService) ListAPIKeys(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	if s.apiKeys == nil {
+		return nil, errAPIKeysNotConfigured
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.apiKeys.ListByUser(ctx, userID)
+}
+
+// RevokeAPIKey revokes one of a user's API keys; it is rejected from then on
+func (s *AuthService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	if s.apiKeys == nil {
+		return errAPIKeysNotConfigured
+	}
+	return s.apiKeys.Revoke(ctx, userID, id, time.Now())
+}
+
+// ValidateAPIKey resolves an API key to the claims of the user owning it, so
+// the request passes the same role and ownership checks as that user's tokens
+func (s *AuthService) ValidateAPIKey(ctx context.Context, plaintext string) (*domain.Claims, error) {
+	if s.apiKeys == nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	prefix, err := domain.ParseAPIKeyPrefix(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.apiKeys.GetByPrefix(ctx, prefix)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+
+	now := time.Now()
+	if err := key.Verify(plaintext, now); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrUserInactive
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.apiKeys.TouchLastUsed(ctx, key.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to record api key use: %w", err)
+		}
+	}
+
+	return key.ClaimsFor(user), nil
+}
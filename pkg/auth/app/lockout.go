@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+)
+
+// LockoutConfig controls brute-force protection on login
+type LockoutConfig struct {
+	MaxFailures   int           // consecutive failures that lock a username
+	IPMaxFailures int           // consecutive failures that lock a source IP
+	Window        time.Duration // failures further apart than this start a new count
+	Cooldown      time.Duration // how long a locked username or IP waits
+}
+
+// DefaultLockoutConfig locks a username after 5 failures and an IP after 20,
+// both for 15 minutes
+func DefaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		MaxFailures:   5,
+		IPMaxFailures: 20,
+		Window:        15 * time.Minute,
+		Cooldown:      15 * time.Minute,
+	}
+}
+
+// loginLockout counts failed logins per username and per source IP
+type loginLockout struct {
+	store      ports.LoginAttemptStore
+	userPolicy domain.LockoutPolicy
+	ipPolicy   domain.LockoutPolicy
+}
+
+// lockoutKey is a throttled username or source IP and the policy it is held to
+type lockoutKey struct {
+	key    string
+	policy domain.LockoutPolicy
+}
+
+// SetLockout enables login throttling with attempts tracked in store. Zero
+// config fields keep the defaults.
+func (s *AuthService) SetLockout(store ports.LoginAttemptStore, config LockoutConfig) {
+	defaults := DefaultLockoutConfig()
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = defaults.MaxFailures
+	}
+	if config.IPMaxFailures <= 0 {
+		config.IPMaxFailures = defaults.IPMaxFailures
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaults.Cooldown
+	}
+
+	s.lockout = &loginLockout{
+		store:      store,
+		userPolicy: domain.LockoutPolicy{MaxFailures: config.MaxFailures, Window: config.Window, Cooldown: config.Cooldown},
+		ipPolicy:   domain.LockoutPolicy{MaxFailures: config.IPMaxFailures, Window: config.Window, Cooldown: config.Cooldown},
+	}
+}
+
+// userLockoutKey is the attempt key of a username, which is matched case-insensitively
+func userLockoutKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+// lockoutKeys returns the keys a login for username is throttled on
+func (l *loginLockout) lockoutKeys(ctx context.Context, username string) []lockoutKey {
+	keys := []lockoutKey{{key: userLockoutKey(username), policy: l.userPolicy}}
+	if ip := authctx.ClientIPFrom(ctx); ip != "" {
+		keys = append(keys, lockoutKey{key: "ip:" + ip, policy: l.ipPolicy})
+	}
+	return keys
+}
+
+// check returns a LockedError while any of the keys is locked
+func (l *loginLockout) check(ctx context.Context, keys []lockoutKey) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, k := range keys {
+		attempts, err := l.store.Get(ctx, k.key)
+		if err != nil {
+			return fmt.Errorf("failed to check login attempts: %w", err)
+		}
+		retryAfter = max(retryAfter, attempts.LockedFor(now))
+	}
+	if retryAfter > 0 {
+		return &domain.LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// recordFailure counts a failed login on every key. It returns a LockedError
+// if that locked one of them and domain.ErrInvalidCredentials otherwise.
+func (l *loginLockout) recordFailure(ctx context.Context, keys []lockoutKey) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, k := range keys {
+		attempts, err := l.store.RecordFailure(ctx, k.key, k.policy, now)
+		if err != nil {
+			return fmt.Errorf("failed to record login attempt: %w", err)
+		}
+		retryAfter = max(retryAfter, attempts.LockedFor(now))
+	}
+	if retryAfter > 0 {
+		return &domain.LockedError{RetryAfter: retryAfter}
+	}
+	return domain.ErrInvalidCredentials
+}
+
+// reset forgets the failed logins counted on every key
+func (l *loginLockout) reset(ctx context.Context, keys []lockoutKey) error {
+	for _, k := range keys {
+		if err := l.store.Reset(ctx, k.key); err != nil {
+			return fmt.Errorf("failed to reset login attempts: %w", err)
+		}
+	}
+	return nil
+}
+
+// UnlockUser lifts a username's lockout and forgets its failed logins
+func (s *AuthService) UnlockUser(ctx context.Context, id int) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.lockout == nil {
+		return nil
+	}
+	return s.lockout.store.Reset(ctx, userLockoutKey(user.Username))
+}
@@ -2,8 +2,12 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/audit"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
 )
@@ -12,6 +16,9 @@ import (
 type AuthService struct {
 	userRepo     ports.UserRepository
 	tokenService ports.TokenService
+	lockout      *loginLockout          // nil until SetLockout
+	apiKeys      ports.APIKeyRepository // nil until SetAPIKeyRepository
+	audit        *audit.Writer          // nil until SetAuditWriter
 }
 
 // NewAuthService creates a new authentication service
@@ -22,12 +29,37 @@ func NewAuthService(userRepo ports.UserRepository, tokenService ports.TokenServi
 	}
 }
 
-// Register creates a new user account
+// SetAuditWriter records registrations, deactivations and reactivations to the audit log
+func (s *AuthService) SetAuditWriter(w *audit.Writer) {
+	s.audit = w
+}
+
+// Register creates a new user account in an organization, the default one
+// when orgID is zero. Anyone may sign up as a customer or courier of the
+// default organization, whatever orgID they ask for. Admins in ctx choose
+// the organization and may register admins: an organization's admin only
+// into their own, a super-admin into any.
 func (s *AuthService) Register(
 	ctx context.Context,
 	username, email, password, role string,
+	orgID int,
 	customerID, courierID *int,
 ) (*domain.User, error) {
+	claims, _ := authctx.ClaimsFrom(ctx)
+	switch {
+	case claims == nil || (claims.Role != domain.RoleAdmin && claims.Role != domain.RoleSuperAdmin):
+		if role == domain.RoleAdmin {
+			return nil, domain.ErrForbidden
+		}
+		orgID = 0
+	case claims.Role == domain.RoleAdmin:
+		scope, _ := claims.OrgScope()
+		if orgID != 0 && orgID != scope {
+			return nil, domain.ErrForbidden
+		}
+		orgID = scope
+	}
+
 	// Check if user already exists
 	existingUser, err := s.userRepo.GetByUsername(ctx, username)
 	if err == nil && existingUser != nil {
@@ -40,7 +72,7 @@ func (s *AuthService) Register(
 	}
 
 	// Create new user (with validation)
-	user, err := domain.NewUser(username, email, password, role, customerID, courierID)
+	user, err := domain.NewUser(username, email, password, role, orgID, customerID, courierID)
 	if err != nil {
 		return nil, err
 	}
@@ -49,16 +81,33 @@ func (s *AuthService) Register(
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.audit.Record(ctx, user.OrgID, "user.register", audit.EntityUser, strconv.Itoa(user.ID), nil, user.ToPublicUser())
 
 	return user, nil
 }
 
-// Authenticate validates credentials and returns a token and user
+// Authenticate validates credentials and returns a token and user. With
+// lockout enabled, a locked username or source IP gets a domain.LockedError
+// whether or not the username exists.
 func (s *AuthService) Authenticate(ctx context.Context, username, password string) (string, *domain.User, error) {
+	var lockoutKeys []lockoutKey
+	if s.lockout != nil {
+		lockoutKeys = s.lockout.lockoutKeys(ctx, username)
+		if err := s.lockout.check(ctx, lockoutKeys); err != nil {
+			return "", nil, err
+		}
+	}
+	invalidCredentials := func() error {
+		if s.lockout == nil {
+			return domain.ErrInvalidCredentials
+		}
+		return s.lockout.recordFailure(ctx, lockoutKeys)
+	}
+
 	// Retrieve user
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		return "", nil, domain.ErrInvalidCredentials
+		return "", nil, invalidCredentials()
 	}
 
 	// Check if user is active
@@ -68,7 +117,14 @@ func (s *AuthService) Authenticate(ctx context.Context, username, password strin
 
 	// Verify password
 	if err := user.VerifyPassword(password); err != nil {
-		return "", nil, domain.ErrInvalidCredentials
+		return "", nil, invalidCredentials()
+	}
+
+	// A successful login starts the username's and the address's counts over
+	if s.lockout != nil {
+		if err := s.lockout.reset(ctx, lockoutKeys); err != nil {
+			return "", nil, err
+		}
 	}
 
 	// Generate token
@@ -80,12 +136,125 @@ func (s *AuthService) Authenticate(ctx context.Context, username, password strin
 	return token, user, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT token and returns the claims. Tokens of
+// deactivated or deleted users are rejected even before they expire.
 func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.Claims, error) {
-	return s.tokenService.ValidateToken(tokenString)
+	claims, err := s.tokenService.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Internal service tokens do not belong to a user
+	if claims.Role == domain.RoleService {
+		return claims, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrUserInactive
+	}
+
+	return claims, nil
 }
 
 // GetUser retrieves a user by ID
 func (s *AuthService) GetUser(ctx context.Context, id int) (*domain.User, error) {
 	return s.userRepo.GetByID(ctx, id)
 }
+
+// UpdateEmail changes a user's email, rejecting addresses used by another user
+func (s *AuthService) UpdateEmail(ctx context.Context, id int, email string) (*domain.User, error) {
+	if err := domain.ValidateEmail(email); err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil && existingUser.ID != id {
+		return nil, domain.ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+
+	// The repository still guards against a concurrent update taking the address
+	if err := s.userRepo.UpdateEmail(ctx, id, email); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(ctx, id)
+}
+
+// ChangePassword replaces a user's password once the current one is verified
+func (s *AuthService) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := user.VerifyPassword(currentPassword); err != nil {
+		return domain.ErrInvalidCredentials
+	}
+	if err := user.SetPassword(newPassword); err != nil {
+		return err
+	}
+
+	return s.userRepo.UpdatePassword(ctx, id, user.PasswordHash)
+}
+
+// SetUserActive deactivates or reactivates a user account
+func (s *AuthService) SetUserActive(ctx context.Context, id int, active bool) (*domain.User, error) {
+	var before *domain.PublicUser
+	if s.audit != nil {
+		if user, err := s.userRepo.GetByID(ctx, id); err == nil {
+			before = user.ToPublicUser()
+		}
+	}
+
+	if err := s.userRepo.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	action := "user.deactivate"
+	if active {
+		action = "user.reactivate"
+	}
+	s.audit.Record(ctx, user.OrgID, action, audit.EntityUser, strconv.Itoa(id), before, user.ToPublicUser())
+
+	return user, nil
+}
+
+// SetUserOrganization moves a user, and their courier profile, to another
+// organization. Their current tokens keep the old organization until they
+// log in again.
+func (s *AuthService) SetUserOrganization(ctx context.Context, id, orgID int) (*domain.User, error) {
+	if orgID <= 0 {
+		return nil, domain.ErrOrgNotFound
+	}
+
+	before, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetOrganization(ctx, id, orgID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, user.OrgID, "user.set_organization", audit.EntityUser, strconv.Itoa(id), before.ToPublicUser(), user.ToPublicUser())
+
+	return user, nil
+}
@@ -1,16 +1,32 @@
 package auth_test
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/adapters"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/app"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func TestPasswordValidation(t *testing.T) {
 	password := "mysecretpassword"
 
 	// Create a user with hashed password
-	user, err := domain.NewUser("testuser", "test@example.com", password, domain.RoleCustomer, nil, nil)
+	user, err := domain.NewUser("testuser", "test@example.com", password, domain.RoleCustomer, 0, nil, nil)
 	if err != nil {
 		t.Fatalf("NewUser failed: %v", err)
 	}
@@ -42,7 +58,7 @@ func TestIsValidRole(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		_, err := domain.NewUser("test", "test@example.com", "password", tt.role, nil, nil)
+		_, err := domain.NewUser("test", "test@example.com", "password", tt.role, 0, nil, nil)
 		if (err == nil) != tt.valid {
 			t.Errorf("NewUser with role %q validation = %v, want %v", tt.role, err == nil, tt.valid)
 		}
@@ -69,10 +85,732 @@ func TestUserValidation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := domain.NewUser(tt.username, tt.email, tt.password, tt.role, nil, nil)
+			_, err := domain.NewUser(tt.username, tt.email, tt.password, tt.role, 0, nil, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewUser() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestValidatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"password123", true},
+		{"pässwörd1", true},
+		{"short1", false},
+		{"passwordonly", false},
+		{"1234567890", false},
+	}
+
+	for _, tt := range tests {
+		err := domain.ValidatePasswordStrength(tt.password)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidatePasswordStrength(%q) = %v, want valid %v", tt.password, err, tt.valid)
+		}
+	}
+}
+
+// memoryUserRepository keeps users in memory
+type memoryUserRepository struct {
+	users map[int]domain.User
+}
+
+func (m *memoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	user.ID = len(m.users) + 1
+	m.users[user.ID] = *user
+	return nil
+}
+
+func (m *memoryUserRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (m *memoryUserRepository) find(match func(domain.User) bool) (*domain.User, error) {
+	for _, user := range m.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (m *memoryUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return m.find(func(u domain.User) bool { return u.Username == username })
+}
+
+func (m *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return m.find(func(u domain.User) bool { return u.Email == email })
+}
+
+func (m *memoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	m.users[user.ID] = *user
+	return nil
+}
+
+// modify applies change to a stored user
+func (m *memoryUserRepository) modify(id int, change func(*domain.User)) error {
+	user, ok := m.users[id]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	change(&user)
+	m.users[id] = user
+	return nil
+}
+
+func (m *memoryUserRepository) UpdateEmail(ctx context.Context, id int, email string) error {
+	return m.modify(id, func(u *domain.User) { u.Email = email })
+}
+
+func (m *memoryUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	return m.modify(id, func(u *domain.User) { u.PasswordHash = passwordHash })
+}
+
+func (m *memoryUserRepository) SetActive(ctx context.Context, id int, active bool) error {
+	return m.modify(id, func(u *domain.User) { u.Active = active })
+}
+
+func (m *memoryUserRepository) SetOrganization(ctx context.Context, id, orgID int) error {
+	return m.modify(id, func(u *domain.User) { u.OrgID = orgID })
+}
+
+func (m *memoryUserRepository) Delete(ctx context.Context, id int) error {
+	delete(m.users, id)
+	return nil
+}
+
+func newTestAuthService(t *testing.T) *app.AuthService {
+	t.Helper()
+	service := app.NewAuthService(&memoryUserRepository{users: make(map[int]domain.User)}, adapters.NewJWTTokenService("test-secret", time.Hour))
+	for _, username := range []string{"ada", "grace"} {
+		if _, err := service.Register(context.Background(), username, username+"@example.com", "password123", domain.RoleCustomer, 0, nil, nil); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	return service
+}
+
+func TestAuthService_UpdateEmail(t *testing.T) {
+	service := newTestAuthService(t)
+	ctx := context.Background()
+
+	user, err := service.UpdateEmail(ctx, 1, "ada@lovelace.dev")
+	if err != nil || user.Email != "ada@lovelace.dev" {
+		t.Fatalf("UpdateEmail = %+v, %v", user, err)
+	}
+	if _, err := service.UpdateEmail(ctx, 1, "ada@lovelace.dev"); err != nil {
+		t.Errorf("keeping the same email should succeed, got %v", err)
+	}
+	if _, err := service.UpdateEmail(ctx, 1, "grace@example.com"); !errors.Is(err, domain.ErrEmailTaken) {
+		t.Errorf("expected ErrEmailTaken, got %v", err)
+	}
+	if _, err := service.UpdateEmail(ctx, 1, "not an email"); !errors.Is(err, domain.ErrInvalidUserData) {
+		t.Errorf("expected ErrInvalidUserData, got %v", err)
+	}
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	service := newTestAuthService(t)
+	ctx := context.Background()
+
+	if err := service.ChangePassword(ctx, 1, "wrongpassword1", "newpassword1"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if err := service.ChangePassword(ctx, 1, "password123", "weak"); !errors.Is(err, domain.ErrWeakPassword) {
+		t.Errorf("expected ErrWeakPassword, got %v", err)
+	}
+	if err := service.ChangePassword(ctx, 1, "password123", "newpassword1"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+
+	if _, _, err := service.Authenticate(ctx, "ada", "password123"); err == nil {
+		t.Error("the old password should no longer work")
+	}
+	if _, _, err := service.Authenticate(ctx, "ada", "newpassword1"); err != nil {
+		t.Errorf("the new password should work, got %v", err)
+	}
+}
+
+func TestAuthService_DeactivatedUserTokensFail(t *testing.T) {
+	service := newTestAuthService(t)
+	ctx := context.Background()
+
+	token, _, err := service.Authenticate(ctx, "ada", "password123")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if _, err := service.ValidateToken(ctx, token); err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+
+	if _, err := service.SetUserActive(ctx, 1, false); err != nil {
+		t.Fatalf("SetUserActive failed: %v", err)
+	}
+	if _, err := service.ValidateToken(ctx, token); !errors.Is(err, domain.ErrUserInactive) {
+		t.Errorf("expected ErrUserInactive, got %v", err)
+	}
+
+	user, err := service.SetUserActive(ctx, 1, true)
+	if err != nil || !user.Active {
+		t.Fatalf("SetUserActive = %+v, %v", user, err)
+	}
+	if _, err := service.ValidateToken(ctx, token); err != nil {
+		t.Errorf("reactivated user's token should validate, got %v", err)
+	}
+}
+
+func TestAuthService_Organizations(t *testing.T) {
+	service := newTestAuthService(t)
+	ctx := context.Background()
+
+	superAdmin := authctx.WithClaims(ctx, &domain.Claims{UserID: 99, Role: domain.RoleSuperAdmin})
+	user, err := service.Register(superAdmin, "linus", "linus@example.com", "password123", domain.RoleCourier, 2, nil, nil)
+	if err != nil || user.OrgID != 2 {
+		t.Fatalf("Register = %+v, %v", user, err)
+	}
+	if _, err := service.Register(superAdmin, "root", "root@example.com", "password123", domain.RoleSuperAdmin, 0, nil, nil); !errors.Is(err, domain.ErrInvalidRole) {
+		t.Errorf("expected super-admins to be refused at registration, got %v", err)
+	}
+
+	// Existing users registered without an organization are in the default one
+	token, _, err := service.Authenticate(ctx, "ada", "password123")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	claims, err := service.ValidateToken(ctx, token)
+	if err != nil || claims.OrgID != domain.DefaultOrgID {
+		t.Fatalf("expected claims in the default organization, got %+v, %v", claims, err)
+	}
+
+	moved, err := service.SetUserOrganization(ctx, 1, 2)
+	if err != nil || moved.OrgID != 2 {
+		t.Fatalf("SetUserOrganization = %+v, %v", moved, err)
+	}
+	if _, err := service.SetUserOrganization(ctx, 1, 0); !errors.Is(err, domain.ErrOrgNotFound) {
+		t.Errorf("expected ErrOrgNotFound, got %v", err)
+	}
+}
+
+func TestAuthService_RegisterRestrictions(t *testing.T) {
+	service := newTestAuthService(t)
+	ctx := context.Background()
+	orgAdmin := authctx.WithClaims(ctx, &domain.Claims{UserID: 98, Role: domain.RoleAdmin, OrgID: 2})
+	courier := authctx.WithClaims(ctx, &domain.Claims{UserID: 97, Role: domain.RoleCourier, OrgID: 2})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		role    string
+		orgID   int
+		wantOrg int
+		wantErr error
+	}{
+		{name: "anonymous sign-up ignores the organization", ctx: ctx, role: domain.RoleCustomer, orgID: 2, wantOrg: domain.DefaultOrgID},
+		{name: "anonymous admin", ctx: ctx, role: domain.RoleAdmin, wantErr: domain.ErrForbidden},
+		{name: "non-admin caller", ctx: courier, role: domain.RoleCourier, orgID: 2, wantOrg: domain.DefaultOrgID},
+		{name: "non-admin caller registering an admin", ctx: courier, role: domain.RoleAdmin, wantErr: domain.ErrForbidden},
+		{name: "org admin into their organization", ctx: orgAdmin, role: domain.RoleAdmin, wantOrg: 2},
+		{name: "org admin into another organization", ctx: orgAdmin, role: domain.RoleCourier, orgID: 3, wantErr: domain.ErrForbidden},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username := fmt.Sprintf("user%d", i)
+			user, err := service.Register(tt.ctx, username, username+"@example.com", "password123", tt.role, tt.orgID, nil, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && user.OrgID != tt.wantOrg {
+				t.Errorf("expected organization %d, got %d", tt.wantOrg, user.OrgID)
+			}
+		})
+	}
+}
+
+func newLockoutAuthService(t *testing.T, config app.LockoutConfig) *app.AuthService {
+	t.Helper()
+	service := newTestAuthService(t)
+	service.SetLockout(adapters.NewMemoryLoginAttemptStore(), config)
+	return service
+}
+
+// failLogins makes n failed login attempts for username
+func failLogins(ctx context.Context, service *app.AuthService, username string, n int) error {
+	var err error
+	for i := 0; i < n; i++ {
+		_, _, err = service.Authenticate(ctx, username, "wrongpassword1")
+	}
+	return err
+}
+
+func TestAuthService_LockoutThreshold(t *testing.T) {
+	service := newLockoutAuthService(t, app.LockoutConfig{MaxFailures: 3})
+	ctx := context.Background()
+
+	if err := failLogins(ctx, service, "ada", 2); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials below the threshold, got %v", err)
+	}
+
+	err := failLogins(ctx, service, "ada", 1)
+	var locked *domain.LockedError
+	if !errors.As(err, &locked) || locked.RetryAfter <= 0 {
+		t.Fatalf("expected a LockedError with a retry delay, got %v", err)
+	}
+
+	// Even the right password is refused while locked
+	if _, _, err := service.Authenticate(ctx, "ada", "password123"); !errors.Is(err, domain.ErrAccountLocked) {
+		t.Errorf("expected ErrAccountLocked, got %v", err)
+	}
+
+	// Unknown usernames lock the same way
+	if err := failLogins(ctx, service, "nobody", 3); !errors.Is(err, domain.ErrAccountLocked) {
+		t.Errorf("expected unknown usernames to lock, got %v", err)
+	}
+
+	// Other users are unaffected
+	if _, _, err := service.Authenticate(ctx, "grace", "password123"); err != nil {
+		t.Errorf("expected grace to log in, got %v", err)
+	}
+}
+
+func TestAuthService_LockoutCooldownExpires(t *testing.T) {
+	service := newLockoutAuthService(t, app.LockoutConfig{MaxFailures: 2, Cooldown: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	if err := failLogins(ctx, service, "ada", 2); !errors.Is(err, domain.ErrAccountLocked) {
+		t.Fatalf("expected ErrAccountLocked, got %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// After the cooldown the count starts over
+	if err := failLogins(ctx, service, "ada", 1); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials after the cooldown, got %v", err)
+	}
+	if _, _, err := service.Authenticate(ctx, "ada", "password123"); err != nil {
+		t.Errorf("expected ada to log in after the cooldown, got %v", err)
+	}
+}
+
+func TestAuthService_LockoutResetOnSuccess(t *testing.T) {
+	service := newLockoutAuthService(t, app.LockoutConfig{MaxFailures: 3})
+	ctx := context.Background()
+
+	failLogins(ctx, service, "ada", 2)
+	if _, _, err := service.Authenticate(ctx, "ada", "password123"); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+
+	// The earlier failures no longer count towards the threshold
+	if err := failLogins(ctx, service, "ada", 2); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials after the reset, got %v", err)
+	}
+}
+
+func TestAuthService_LockoutPerSourceIP(t *testing.T) {
+	service := newLockoutAuthService(t, app.LockoutConfig{MaxFailures: 10, IPMaxFailures: 3})
+	ctx := authctx.WithClientIP(context.Background(), "203.0.113.7")
+
+	// Spreading guesses over usernames still locks the address
+	for _, username := range []string{"ada", "grace"} {
+		failLogins(ctx, service, username, 1)
+	}
+	if err := failLogins(ctx, service, "nobody", 1); !errors.Is(err, domain.ErrAccountLocked) {
+		t.Fatalf("expected the IP to be locked, got %v", err)
+	}
+
+	// The usernames themselves are not locked from elsewhere
+	if _, _, err := service.Authenticate(context.Background(), "ada", "password123"); err != nil {
+		t.Errorf("expected ada to log in from another address, got %v", err)
+	}
+}
+
+func TestAuthService_LockoutPerSourceIPResetOnSuccess(t *testing.T) {
+	service := newLockoutAuthService(t, app.LockoutConfig{MaxFailures: 10, IPMaxFailures: 3})
+	ctx := authctx.WithClientIP(context.Background(), "203.0.113.7")
+
+	failLogins(ctx, service, "nobody", 2)
+	if _, _, err := service.Authenticate(ctx, "ada", "password123"); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+
+	// The address's earlier failures no longer count either
+	if err := failLogins(ctx, service, "nobody", 2); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials after the reset, got %v", err)
+	}
+}
+
+func TestAuthService_UnlockUser(t *testing.T) {
+	service := newLockoutAuthService(t, app.LockoutConfig{MaxFailures: 2})
+	ctx := context.Background()
+
+	failLogins(ctx, service, "ADA", 2)
+	if err := service.UnlockUser(ctx, 1); err != nil {
+		t.Fatalf("UnlockUser failed: %v", err)
+	}
+	if _, _, err := service.Authenticate(ctx, "ada", "password123"); err != nil {
+		t.Errorf("expected ada to log in once unlocked, got %v", err)
+	}
+}
+
+// memoryAPIKeyRepository keeps API keys in memory
+type memoryAPIKeyRepository struct {
+	keys []domain.APIKey
+}
+
+func (m *memoryAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	key.ID = len(m.keys) + 1
+	m.keys = append(m.keys, *key)
+	return nil
+}
+
+func (m *memoryAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	for _, key := range m.keys {
+		if key.Prefix == prefix {
+			return &key, nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (m *memoryAPIKeyRepository) ListByUser(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		if m.keys[i].UserID == userID {
+			key := m.keys[i]
+			keys = append(keys, &key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryAPIKeyRepository) Revoke(ctx context.Context, userID, id int, at time.Time) error {
+	for i := range m.keys {
+		if m.keys[i].ID == id && m.keys[i].UserID == userID {
+			m.keys[i].RevokedAt = &at
+			return nil
+		}
+	}
+	return domain.ErrAPIKeyNotFound
+}
+
+func (m *memoryAPIKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	m.keys[id-1].LastUsedAt = &at
+	return nil
+}
+
+func newAPIKeyAuthService(t *testing.T) (*app.AuthService, *memoryAPIKeyRepository) {
+	t.Helper()
+	service := newTestAuthService(t)
+	repo := &memoryAPIKeyRepository{}
+	service.SetAPIKeyRepository(repo)
+	return service, repo
+}
+
+func TestAuthService_APIKeyActsAsOwner(t *testing.T) {
+	service, repo := newAPIKeyAuthService(t)
+	ctx := context.Background()
+
+	key, plaintext, err := service.IssueAPIKey(ctx, 2, "Partner backend", nil)
+	if err != nil {
+		t.Fatalf("IssueAPIKey failed: %v", err)
+	}
+	if repo.keys[0].KeyHash == plaintext || repo.keys[0].KeyHash != domain.HashAPIKey(plaintext) {
+		t.Errorf("expected only the key's hash to be stored")
+	}
+
+	claims, err := service.ValidateAPIKey(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("ValidateAPIKey failed: %v", err)
+	}
+	if claims.UserID != 2 || claims.Username != "grace" || claims.Role != domain.RoleCustomer || claims.APIKeyID != key.ID {
+		t.Errorf("expected grace's claims tagged with the key, got %+v", claims)
+	}
+	if repo.keys[0].LastUsedAt == nil {
+		t.Errorf("expected the key's last use to be recorded")
+	}
+
+	// The same prefix with a different secret is rejected
+	prefix, _ := domain.ParseAPIKeyPrefix(plaintext)
+	if _, err := service.ValidateAPIKey(ctx, "dtk_"+prefix+"_forged"); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for a forged key, got %v", err)
+	}
+	if _, err := service.ValidateAPIKey(ctx, "not-a-key"); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for a malformed key, got %v", err)
+	}
+	if got, err := domain.ParseAPIKeyPrefix("dtk_" + prefix + "_se_cr_et"); err != nil || got != prefix {
+		t.Errorf("expected the prefix of a key whose secret has underscores, got %q, %v", got, err)
+	}
+}
+
+func TestAuthService_APIKeyRevocationAndExpiry(t *testing.T) {
+	service, repo := newAPIKeyAuthService(t)
+	ctx := context.Background()
+
+	key, plaintext, err := service.IssueAPIKey(ctx, 1, "Revoked", nil)
+	if err != nil {
+		t.Fatalf("IssueAPIKey failed: %v", err)
+	}
+	if err := service.RevokeAPIKey(ctx, 2, key.ID); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("expected another user's key to be not found, got %v", err)
+	}
+	if err := service.RevokeAPIKey(ctx, 1, key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+	if _, err := service.ValidateAPIKey(ctx, plaintext); !errors.Is(err, domain.ErrAPIKeyRevoked) {
+		t.Errorf("expected ErrAPIKeyRevoked, got %v", err)
+	}
+
+	soon := time.Now().Add(time.Hour)
+	_, plaintext, err = service.IssueAPIKey(ctx, 1, "Expiring", &soon)
+	if err != nil {
+		t.Fatalf("IssueAPIKey failed: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	repo.keys[1].ExpiresAt = &past
+	if _, err := service.ValidateAPIKey(ctx, plaintext); !errors.Is(err, domain.ErrAPIKeyExpired) {
+		t.Errorf("expected ErrAPIKeyExpired, got %v", err)
+	}
+	if _, _, err := service.IssueAPIKey(ctx, 1, "Already expired", &past); !errors.Is(err, domain.ErrInvalidUserData) {
+		t.Errorf("expected a past expiry to be rejected, got %v", err)
+	}
+
+	keys, err := service.ListAPIKeys(ctx, 1)
+	if err != nil || len(keys) != 2 || keys[0].Name != "Expiring" {
+		t.Errorf("expected both keys newest first, got %+v (%v)", keys, err)
+	}
+}
+
+func TestAuthService_APIKeyOfDeactivatedUser(t *testing.T) {
+	service, _ := newAPIKeyAuthService(t)
+	ctx := context.Background()
+
+	_, plaintext, err := service.IssueAPIKey(ctx, 1, "Partner backend", nil)
+	if err != nil {
+		t.Fatalf("IssueAPIKey failed: %v", err)
+	}
+	if _, err := service.SetUserActive(ctx, 1, false); err != nil {
+		t.Fatalf("SetUserActive failed: %v", err)
+	}
+	if _, err := service.ValidateAPIKey(ctx, plaintext); !errors.Is(err, domain.ErrUserInactive) {
+		t.Errorf("expected ErrUserInactive, got %v", err)
+	}
+	if _, _, err := service.IssueAPIKey(ctx, 1, "Another", nil); !errors.Is(err, domain.ErrUserInactive) {
+		t.Errorf("expected no keys for a deactivated user, got %v", err)
+	}
+}
+
+// tokenKeyID returns the kid header of a token without validating it
+func tokenKeyID(t *testing.T, tokenString string) string {
+	t.Helper()
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &adapters.JWTClaims{})
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
+func TestJWTTokenService_KeyRotation(t *testing.T) {
+	user := &domain.User{ID: 1, Username: "ada", Role: domain.RoleCustomer}
+
+	before, err := adapters.NewJWTTokenServiceFromConfig(config.AuthConfig{
+		JWTExpiration: time.Hour,
+		JWTKeys:       []config.JWTKeyConfig{{ID: "2026-09", Secret: "old-secret"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create token service: %v", err)
+	}
+	oldToken, err := before.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	// Rotation adds the new key and signs with it, keeping the old one
+	after, err := adapters.NewJWTTokenServiceFromConfig(config.AuthConfig{
+		JWTExpiration: time.Hour,
+		JWTKeys: []config.JWTKeyConfig{
+			{ID: "2026-09", Secret: "old-secret"},
+			{ID: "2026-10", Algorithm: "HS256", Secret: "new-secret"},
+		},
+		JWTSigningKey: "2026-10",
+	})
+	if err != nil {
+		t.Fatalf("failed to create token service: %v", err)
+	}
+	newToken, err := after.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	if kid := tokenKeyID(t, newToken); kid != "2026-10" {
+		t.Errorf("expected new tokens to use the new key, got kid %q", kid)
+	}
+	if claims, err := after.ValidateToken(oldToken); err != nil || claims.UserID != 1 {
+		t.Errorf("expected the old token to stay valid, got %+v, %v", claims, err)
+	}
+	if _, err := after.ValidateToken(newToken); err != nil {
+		t.Errorf("expected the new token to be valid, got %v", err)
+	}
+	if _, err := before.ValidateToken(newToken); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for an unknown kid, got %v", err)
+	}
+}
+
+func TestJWTTokenService_LegacyTokensUseDefaultKey(t *testing.T) {
+	// Tokens issued before key IDs carry no kid
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, adapters.JWTClaims{
+		UserID:           1,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	service := adapters.NewJWTTokenService("test-secret", time.Hour)
+	if _, err := service.ValidateToken(legacy); err != nil {
+		t.Errorf("expected a token without kid to validate against the default key, got %v", err)
+	}
+
+	rotated, err := adapters.NewJWTTokenServiceWithKeys([]adapters.JWTKey{
+		adapters.NewHMACKey("2026-10", "new-secret"),
+	}, "2026-10", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create token service: %v", err)
+	}
+	if _, err := rotated.ValidateToken(legacy); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken once the default key is retired, got %v", err)
+	}
+}
+
+func TestJWTTokenService_ExpiredToken(t *testing.T) {
+	service := adapters.NewJWTTokenService("test-secret", -time.Minute)
+	token, err := service.GenerateToken(&domain.User{ID: 1})
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if _, err := service.ValidateToken(token); !errors.Is(err, domain.ErrExpiredToken) {
+		t.Errorf("expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestJWTTokenService_RS256PublicKeyValidation(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+	privatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}))
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+
+	issuer, err := adapters.NewJWTTokenServiceFromConfig(config.AuthConfig{
+		JWTExpiration: time.Hour,
+		JWTKeys:       []config.JWTKeyConfig{{ID: "rsa-1", Algorithm: "RS256", PrivateKey: privatePEM}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create issuing service: %v", err)
+	}
+	token, err := issuer.GenerateToken(&domain.User{ID: 4, Role: domain.RoleCourier})
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	// A read-only service holds just the public key
+	validator, err := adapters.NewJWTTokenServiceFromConfig(config.AuthConfig{
+		JWTKeys: []config.JWTKeyConfig{{ID: "rsa-1", Algorithm: "RS256", PublicKey: publicPEM}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create validating service: %v", err)
+	}
+	if claims, err := validator.ValidateToken(token); err != nil || claims.UserID != 4 {
+		t.Errorf("expected the public key to validate the token, got %+v, %v", claims, err)
+	}
+	if _, err := validator.GenerateToken(&domain.User{ID: 4}); err == nil {
+		t.Errorf("expected a public-key-only service to refuse signing")
+	}
+
+	// An HS256 token forged with the public key as secret is rejected
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, adapters.JWTClaims{UserID: 1}).SignedString([]byte(publicPEM))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	if _, err := validator.ValidateToken(forged); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for an algorithm mismatch, got %v", err)
+	}
+}
+
+func TestJWTTokenService_InvalidKeySets(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AuthConfig
+	}{
+		{"missing secret", config.AuthConfig{JWTKeys: []config.JWTKeyConfig{{ID: "a"}}}},
+		{"missing id", config.AuthConfig{JWTKeys: []config.JWTKeyConfig{{Secret: "s"}}}},
+		{"duplicate id", config.AuthConfig{JWTKeys: []config.JWTKeyConfig{{ID: "a", Secret: "s"}, {ID: "a", Secret: "t"}}}},
+		{"unknown signing key", config.AuthConfig{JWTKeys: []config.JWTKeyConfig{{ID: "a", Secret: "s"}}, JWTSigningKey: "b"}},
+		{"unknown algorithm", config.AuthConfig{JWTKeys: []config.JWTKeyConfig{{ID: "a", Algorithm: "none"}}}},
+		{"invalid pem", config.AuthConfig{JWTKeys: []config.JWTKeyConfig{{ID: "a", Algorithm: "RS256", PublicKey: "not a key"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := adapters.NewJWTTokenServiceFromConfig(tt.cfg); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_UsersConfinesOrgAdmins(t *testing.T) {
+	users := &memoryUserRepository{users: make(map[int]domain.User)}
+	service := app.NewAuthService(users, adapters.NewJWTTokenService("test-secret", time.Hour))
+	service.SetAPIKeyRepository(&memoryAPIKeyRepository{})
+	for id, role := range map[int]string{1: domain.RoleCustomer, 2: domain.RoleSuperAdmin, 3: domain.RoleAdmin, 4: domain.RoleAdmin} {
+		users.users[id] = domain.User{ID: id, Username: fmt.Sprintf("user%d", id), Role: role, OrgID: domain.DefaultOrgID, Active: true}
+	}
+	handler := adapters.NewHTTPHandler(service, time.Hour)
+
+	orgAdmin := &domain.Claims{UserID: 4, Role: domain.RoleAdmin, OrgID: domain.DefaultOrgID}
+	superAdmin := &domain.Claims{UserID: 2, Role: domain.RoleSuperAdmin, OrgID: domain.DefaultOrgID}
+	tests := []struct {
+		name   string
+		claims *domain.Claims
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{name: "key for a super-admin", claims: orgAdmin, method: http.MethodPost, path: "/users/2/api-keys", body: `{"name":"ops"}`, want: http.StatusForbidden},
+		{name: "deactivating a super-admin", claims: orgAdmin, method: http.MethodPut, path: "/users/2/active", body: `{"active":false}`, want: http.StatusForbidden},
+		{name: "unlocking a super-admin", claims: orgAdmin, method: http.MethodPost, path: "/users/2/unlock", want: http.StatusForbidden},
+		{name: "key for another admin", claims: orgAdmin, method: http.MethodPost, path: "/users/3/api-keys", body: `{"name":"ops"}`, want: http.StatusForbidden},
+		{name: "deactivating another admin", claims: orgAdmin, method: http.MethodPut, path: "/users/3/active", body: `{"active":false}`, want: http.StatusForbidden},
+		{name: "key for themselves", claims: orgAdmin, method: http.MethodPost, path: "/users/4/api-keys", body: `{"name":"ops"}`, want: http.StatusCreated},
+		{name: "key for a customer", claims: orgAdmin, method: http.MethodPost, path: "/users/1/api-keys", body: `{"name":"ops"}`, want: http.StatusCreated},
+		{name: "super-admin deactivating an admin", claims: superAdmin, method: http.MethodPut, path: "/users/3/active", body: `{"active":false}`, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req = req.WithContext(authctx.WithClaims(req.Context(), tt.claims))
+			w := httptest.NewRecorder()
+			handler.Users(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+	if !users.users[2].Active {
+		t.Errorf("expected the super-admin to stay active")
+	}
+}
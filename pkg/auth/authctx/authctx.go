@@ -0,0 +1,110 @@
+// Package authctx stores authenticated user claims in a request context
+// under unexported keys, so values cannot collide with other packages and
+// readers never need raw type assertions.
+package authctx
+
+import (
+	"context"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// contextKey is unexported to prevent collisions with keys from other packages
+type contextKey int
+
+const (
+	claimsKey contextKey = iota
+	authorizationKey
+	clientIPKey
+)
+
+// WithClaims returns a copy of ctx carrying the validated token claims
+func WithClaims(ctx context.Context, claims *domain.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFrom returns the claims stored in ctx, if any
+func ClaimsFrom(ctx context.Context) (*domain.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*domain.Claims)
+	return claims, ok && claims != nil
+}
+
+// UserIDFrom returns the authenticated user ID
+func UserIDFrom(ctx context.Context) (int, bool) {
+	claims, ok := ClaimsFrom(ctx)
+	if !ok {
+		return 0, false
+	}
+	return claims.UserID, true
+}
+
+// RoleFrom returns the authenticated user's role
+func RoleFrom(ctx context.Context) (string, bool) {
+	claims, ok := ClaimsFrom(ctx)
+	if !ok {
+		return "", false
+	}
+	return claims.Role, true
+}
+
+// CustomerIDFrom returns the customer ID linked to the user, or nil
+func CustomerIDFrom(ctx context.Context) *int {
+	claims, ok := ClaimsFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return claims.CustomerID
+}
+
+// CourierIDFrom returns the courier ID linked to the user, or nil
+func CourierIDFrom(ctx context.Context) *int {
+	claims, ok := ClaimsFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return claims.CourierID
+}
+
+// OrgScope returns the organization repositories confine the caller's reads
+// and writes to. Super-admins, internal services and contexts without claims,
+// such as background jobs and public endpoints, are not confined.
+func OrgScope(ctx context.Context) (int, bool) {
+	claims, ok := ClaimsFrom(ctx)
+	if !ok {
+		return 0, false
+	}
+	return claims.OrgScope()
+}
+
+// Organization returns the organization the caller creates records in: the
+// one they are confined to, or the default one when they aren't
+func Organization(ctx context.Context) int {
+	if orgID, ok := OrgScope(ctx); ok {
+		return orgID
+	}
+	return domain.DefaultOrgID
+}
+
+// WithAuthorization returns a copy of ctx carrying the raw Authorization
+// header, so it can be forwarded on outgoing gRPC calls
+func WithAuthorization(ctx context.Context, authHeader string) context.Context {
+	return context.WithValue(ctx, authorizationKey, authHeader)
+}
+
+// AuthorizationFrom returns the raw Authorization header stored in ctx
+func AuthorizationFrom(ctx context.Context) string {
+	authHeader, _ := ctx.Value(authorizationKey).(string)
+	return authHeader
+}
+
+// WithClientIP returns a copy of ctx carrying the caller's source IP, so
+// login throttling can count failures per address
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIPFrom returns the source IP stored in ctx, or "" if none
+func ClientIPFrom(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
@@ -0,0 +1,108 @@
+package authctx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+func TestWithClaims(t *testing.T) {
+	customerID := 5
+	claims := &domain.Claims{
+		UserID:     42,
+		Username:   "testuser",
+		Role:       domain.RoleCustomer,
+		CustomerID: &customerID,
+	}
+
+	ctx := authctx.WithClaims(context.Background(), claims)
+
+	got, ok := authctx.ClaimsFrom(ctx)
+	if !ok || got != claims {
+		t.Fatalf("expected claims %+v, got %+v", claims, got)
+	}
+
+	if userID, ok := authctx.UserIDFrom(ctx); !ok || userID != 42 {
+		t.Errorf("expected user ID 42, got %d", userID)
+	}
+	if role, ok := authctx.RoleFrom(ctx); !ok || role != domain.RoleCustomer {
+		t.Errorf("expected role %s, got %s", domain.RoleCustomer, role)
+	}
+	if id := authctx.CustomerIDFrom(ctx); id == nil || *id != customerID {
+		t.Errorf("expected customer ID %d, got %v", customerID, id)
+	}
+	if id := authctx.CourierIDFrom(ctx); id != nil {
+		t.Errorf("expected nil courier ID, got %v", *id)
+	}
+}
+
+func TestClaimsFrom_Missing(t *testing.T) {
+	ctx := context.Background()
+
+	if _, ok := authctx.ClaimsFrom(ctx); ok {
+		t.Error("expected no claims in empty context")
+	}
+	if _, ok := authctx.RoleFrom(ctx); ok {
+		t.Error("expected no role in empty context")
+	}
+	if id := authctx.CourierIDFrom(ctx); id != nil {
+		t.Error("expected nil courier ID in empty context")
+	}
+
+	// String keys from other packages must not collide
+	ctx = context.WithValue(ctx, "role", "admin")
+	if _, ok := authctx.RoleFrom(ctx); ok {
+		t.Error("expected string key not to be read as claims")
+	}
+
+	// A nil claims pointer is treated as missing
+	ctx = authctx.WithClaims(context.Background(), nil)
+	if _, ok := authctx.ClaimsFrom(ctx); ok {
+		t.Error("expected nil claims to be treated as missing")
+	}
+}
+
+func TestOrgScope(t *testing.T) {
+	tests := []struct {
+		name        string
+		claims      *domain.Claims
+		expectedOrg int
+		expectedOK  bool
+	}{
+		{name: "org admin", claims: &domain.Claims{UserID: 1, Role: domain.RoleAdmin, OrgID: 2}, expectedOrg: 2, expectedOK: true},
+		{name: "token from before organizations", claims: &domain.Claims{UserID: 2, Role: domain.RoleCustomer}, expectedOrg: domain.DefaultOrgID, expectedOK: true},
+		{name: "super-admin", claims: &domain.Claims{UserID: 3, Role: domain.RoleSuperAdmin, OrgID: 2}},
+		{name: "internal service", claims: &domain.Claims{Role: domain.RoleService}},
+		{name: "no claims"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := authctx.WithClaims(context.Background(), tt.claims)
+			orgID, ok := authctx.OrgScope(ctx)
+			if orgID != tt.expectedOrg || ok != tt.expectedOK {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tt.expectedOrg, tt.expectedOK, orgID, ok)
+			}
+
+			// Callers that aren't confined create records in the default organization
+			expected := tt.expectedOrg
+			if !tt.expectedOK {
+				expected = domain.DefaultOrgID
+			}
+			if got := authctx.Organization(ctx); got != expected {
+				t.Errorf("expected records in organization %d, got %d", expected, got)
+			}
+		})
+	}
+}
+
+func TestWithAuthorization(t *testing.T) {
+	ctx := authctx.WithAuthorization(context.Background(), "Bearer token")
+	if got := authctx.AuthorizationFrom(ctx); got != "Bearer token" {
+		t.Errorf("expected authorization header, got %q", got)
+	}
+	if got := authctx.AuthorizationFrom(context.Background()); got != "" {
+		t.Errorf("expected empty authorization header, got %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ErrAccountLocked is matched by LockedError, returned while too many failed
+// logins keep a username or source IP locked
+var ErrAccountLocked = errors.New("too many failed login attempts")
+
+// LockedError reports how long until login may be retried. It is the same
+// for existing and unknown usernames.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrAccountLocked, e.RetryAfter.Round(time.Second))
+}
+
+// Is makes errors.Is(err, ErrAccountLocked) match
+func (e *LockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// GRPCCode implements domainerr.Coder
+func (e *LockedError) GRPCCode() codes.Code {
+	return codes.ResourceExhausted
+}
+
+// LockoutPolicy decides when failed logins lock a key
+type LockoutPolicy struct {
+	MaxFailures int           // consecutive failures that lock the key
+	Window      time.Duration // failures further apart than this start a new count
+	Cooldown    time.Duration // how long the key stays locked
+}
+
+// LoginAttempts tracks consecutive failed logins for a username or source IP
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// LockedFor returns how long the key remains locked, zero when it is not
+func (a LoginAttempts) LockedFor(now time.Time) time.Duration {
+	if now.Before(a.LockedUntil) {
+		return a.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// RecordFailure counts a failed login at now, locking the key once the
+// policy's MaxFailures is reached. An expired lock or a failure outside the
+// window starts a new count.
+func (a *LoginAttempts) RecordFailure(now time.Time, policy LockoutPolicy) {
+	lockExpired := !a.LockedUntil.IsZero() && !now.Before(a.LockedUntil)
+	if lockExpired || now.Sub(a.LastFailure) > policy.Window {
+		a.Failures = 0
+		a.LockedUntil = time.Time{}
+	}
+
+	a.Failures++
+	a.LastFailure = now
+	if a.Failures >= policy.MaxFailures {
+		a.LockedUntil = now.Add(policy.Cooldown)
+	}
+}
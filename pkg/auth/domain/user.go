@@ -1,11 +1,16 @@
 package domain
 
 import (
-	"errors"
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
 )
 
 // Role constants
@@ -13,20 +18,37 @@ const (
 	RoleCustomer = "customer"
 	RoleCourier  = "courier"
 	RoleAdmin    = "admin"
+	// RoleSuperAdmin administers every organization, where RoleAdmin is
+	// confined to its own. It is never assigned by registration.
+	RoleSuperAdmin = "super_admin"
+	// RoleService is carried by tokens issued to internal service callers and
+	// is never assigned to registered users
+	RoleService = "service"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrExpiredToken       = errors.New("token has expired")
-	ErrUnauthorized       = errors.New("unauthorized")
-	ErrForbidden          = errors.New("forbidden: insufficient permissions")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserExists         = errors.New("user already exists")
-	ErrInvalidRole        = errors.New("invalid role")
-	ErrInvalidUserData    = errors.New("invalid user data")
+	ErrInvalidCredentials = domainerr.New(codes.Unauthenticated, "invalid credentials")
+	ErrInvalidToken       = domainerr.New(codes.Unauthenticated, "invalid token")
+	ErrExpiredToken       = domainerr.New(codes.Unauthenticated, "token has expired")
+	ErrUnauthorized       = domainerr.New(codes.PermissionDenied, "unauthorized")
+	ErrForbidden          = domainerr.New(codes.PermissionDenied, "forbidden: insufficient permissions")
+	ErrUserNotFound       = domainerr.New(codes.InvalidArgument, "user not found")
+	ErrUserExists         = domainerr.New(codes.AlreadyExists, "user already exists")
+	ErrInvalidRole        = domainerr.New(codes.InvalidArgument, "invalid role")
+	ErrInvalidUserData    = domainerr.New(codes.InvalidArgument, "invalid user data")
+	ErrEmailTaken         = domainerr.New(codes.AlreadyExists, "email already in use")
+	ErrWeakPassword       = domainerr.New(codes.InvalidArgument, "password must be at least 8 characters and contain a letter and a digit")
+	ErrUserInactive       = domainerr.New(codes.PermissionDenied, "user account is deactivated")
+	ErrOrgNotFound        = domainerr.New(codes.InvalidArgument, "organization not found")
 )
 
+// DefaultOrgID is the organization rows from before organizations existed
+// were backfilled into. Tokens without an organization belong to it.
+const DefaultOrgID = 1
+
+// MinPasswordLength is the shortest password accepted by ValidatePasswordStrength
+const MinPasswordLength = 8
+
 // User represents an authenticated user in the domain
 type User struct {
 	ID           int
@@ -34,6 +56,7 @@ type User struct {
 	Email        string
 	PasswordHash string
 	Role         string
+	OrgID        int
 	CustomerID   *int
 	CourierID    *int
 	Active       bool
@@ -41,17 +64,21 @@ type User struct {
 	UpdatedAt    time.Time
 }
 
-// NewUser creates a new user with validation
-func NewUser(username, email, password, role string, customerID, courierID *int) (*User, error) {
+// NewUser creates a new user in an organization with validation;
+// DefaultOrgID is used when orgID is zero
+func NewUser(username, email, password, role string, orgID int, customerID, courierID *int) (*User, error) {
 	// Validate required fields
-	if username == "" || email == "" || password == "" || role == "" {
+	if username == "" || email == "" || password == "" || role == "" || orgID < 0 {
 		return nil, ErrInvalidUserData
 	}
 
-	// Validate role
-	if !IsValidRole(role) {
+	// Validate role; super-admins are promoted, not registered
+	if !IsValidRole(role) || role == RoleSuperAdmin {
 		return nil, ErrInvalidRole
 	}
+	if orgID == 0 {
+		orgID = DefaultOrgID
+	}
 
 	// Hash password
 	hash, err := HashPassword(password)
@@ -64,6 +91,7 @@ func NewUser(username, email, password, role string, customerID, courierID *int)
 		Email:        email,
 		PasswordHash: hash,
 		Role:         role,
+		OrgID:        orgID,
 		CustomerID:   customerID,
 		CourierID:    courierID,
 		Active:       true,
@@ -77,6 +105,50 @@ func (u *User) VerifyPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
 }
 
+// SetPassword replaces the user's password after checking its strength
+func (u *User) SetPassword(password string) error {
+	if err := ValidatePasswordStrength(password); err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = hash
+	return nil
+}
+
+// ValidatePasswordStrength checks that a password has at least
+// MinPasswordLength characters including a letter and a digit
+func ValidatePasswordStrength(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return ErrWeakPassword
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// ValidateEmail checks that an email address is plausible
+func ValidateEmail(email string) error {
+	if _, err := mail.ParseAddress(email); err != nil || strings.ContainsAny(email, "<> ") {
+		return ErrInvalidUserData
+	}
+	return nil
+}
+
 // IsActive checks if the user account is active
 func (u *User) IsActive() bool {
 	return u.Active
@@ -90,7 +162,7 @@ func (u *User) HasRole(role string) bool {
 // CanAccessResource checks if user can access a resource based on role and ownership
 func (u *User) CanAccessResource(requiredRole string, resourceCustomerID, resourceCourierID *int) bool {
 	// Admin can access everything
-	if u.Role == RoleAdmin {
+	if IsAdminRole(u.Role) {
 		return true
 	}
 
@@ -123,17 +195,44 @@ func HashPassword(password string) (string, error) {
 
 // IsValidRole checks if a role is valid
 func IsValidRole(role string) bool {
-	return role == RoleCustomer || role == RoleCourier || role == RoleAdmin
+	return role == RoleCustomer || role == RoleCourier || role == RoleAdmin || role == RoleSuperAdmin
+}
+
+// IsAdminRole checks if a role has admin rights: within its organization for
+// RoleAdmin, in every organization for RoleSuperAdmin
+func IsAdminRole(role string) bool {
+	return role == RoleAdmin || role == RoleSuperAdmin
 }
 
-// Claims represents JWT claims for authorization
+// Claims represents JWT claims for authorization. APIKeyID is set when the
+// request was authenticated with an API key rather than a token.
 type Claims struct {
 	UserID     int    `json:"user_id"`
 	Username   string `json:"username"`
 	Email      string `json:"email"`
 	Role       string `json:"role"`
+	OrgID      int    `json:"org_id,omitempty"`
 	CustomerID *int   `json:"customer_id,omitempty"`
 	CourierID  *int   `json:"courier_id,omitempty"`
+	APIKeyID   int    `json:"api_key_id,omitempty"`
+}
+
+// Organization returns the caller's organization, DefaultOrgID for tokens
+// issued before organizations existed
+func (c *Claims) Organization() int {
+	if c.OrgID == 0 {
+		return DefaultOrgID
+	}
+	return c.OrgID
+}
+
+// OrgScope returns the organization the caller's data is confined to.
+// Super-admins and internal services are not confined and get false.
+func (c *Claims) OrgScope() (int, bool) {
+	if c.Role == RoleSuperAdmin || c.Role == RoleService {
+		return 0, false
+	}
+	return c.Organization(), true
 }
 
 // ToPublicUser returns a user without sensitive information
@@ -143,6 +242,7 @@ func (u *User) ToPublicUser() *PublicUser {
 		Username:   u.Username,
 		Email:      u.Email,
 		Role:       u.Role,
+		OrgID:      u.OrgID,
 		CustomerID: u.CustomerID,
 		CourierID:  u.CourierID,
 		Active:     u.Active,
@@ -157,6 +257,7 @@ type PublicUser struct {
 	Username   string    `json:"username"`
 	Email      string    `json:"email"`
 	Role       string    `json:"role"`
+	OrgID      int       `json:"org_id"`
 	CustomerID *int      `json:"customer_id,omitempty"`
 	CourierID  *int      `json:"courier_id,omitempty"`
 	Active     bool      `json:"active"`
@@ -0,0 +1,166 @@
+package identity_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
+)
+
+const secret = "a-gateway-secret-of-at-least-32-chars"
+
+func TestForward_ReplacesClientHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-User-ID", "1")
+	h.Set("X-User-Role", domain.RoleAdmin)
+	h.Set("X-User-Email", "admin@example.com")
+	h.Set(identity.CustomerIDHeader, "99")
+	h.Set(identity.OrgIDHeader, "3")
+	h.Set(identity.SecretHeader, "guessed")
+	h.Set("Authorization", "Bearer token")
+
+	courierID := 7
+	identity.Forward(h, &domain.Claims{UserID: 42, Role: domain.RoleCourier, CourierID: &courierID}, secret)
+
+	want := map[string]string{
+		identity.UserIDHeader:     "42",
+		identity.UserRoleHeader:   domain.RoleCourier,
+		identity.CourierIDHeader:  "7",
+		identity.CustomerIDHeader: "",
+		identity.OrgIDHeader:      "",
+		identity.SecretHeader:     secret,
+		"X-User-Email":            "",
+		"Authorization":           "Bearer token",
+	}
+	for header, value := range want {
+		if got := h.Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestForward_WithoutSecretOrClaims(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-User-ID", "1")
+	h.Set(identity.SecretHeader, "guessed")
+
+	identity.Forward(h, &domain.Claims{UserID: 42, Role: domain.RoleCustomer}, "")
+	if h.Get(identity.SecretHeader) != "" {
+		t.Error("expected no gateway secret when none is configured")
+	}
+	if h.Get(identity.UserIDHeader) != "42" {
+		t.Errorf("X-User-ID = %q, want 42", h.Get(identity.UserIDHeader))
+	}
+
+	identity.Forward(h, nil, secret)
+	if len(h) != 0 {
+		t.Errorf("expected every identity header stripped for an anonymous request, got %v", h)
+	}
+}
+
+func TestVerifier_Claims(t *testing.T) {
+	customerID := 5
+	forwarded := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/deliveries", nil)
+		identity.Forward(r.Header, &domain.Claims{UserID: 42, Role: domain.RoleCustomer, OrgID: 2, CustomerID: &customerID}, secret)
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		verifier   *identity.Verifier
+		request    func() *http.Request
+		wantClaims bool
+		wantErr    error
+	}{
+		{
+			name:       "forwarded by the gateway",
+			verifier:   identity.NewVerifier(secret),
+			request:    forwarded,
+			wantClaims: true,
+		},
+		{
+			name:     "trust disabled",
+			verifier: nil,
+			request:  forwarded,
+		},
+		{
+			name:     "direct request",
+			verifier: identity.NewVerifier(secret),
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/deliveries", nil)
+				r.Header.Set(identity.UserIDHeader, "1")
+				r.Header.Set(identity.UserRoleHeader, domain.RoleAdmin)
+				return r
+			},
+		},
+		{
+			name:     "wrong secret",
+			verifier: identity.NewVerifier("another-secret-of-at-least-32-chars"),
+			request:  forwarded,
+			wantErr:  identity.ErrUntrusted,
+		},
+		{
+			name:     "unknown role",
+			verifier: identity.NewVerifier(secret),
+			request: func() *http.Request {
+				r := forwarded()
+				r.Header.Set(identity.UserRoleHeader, "superuser")
+				return r
+			},
+			wantErr: identity.ErrInvalidHeaders,
+		},
+		{
+			name:     "malformed customer ID",
+			verifier: identity.NewVerifier(secret),
+			request: func() *http.Request {
+				r := forwarded()
+				r.Header.Set(identity.CustomerIDHeader, "five")
+				return r
+			},
+			wantErr: identity.ErrInvalidHeaders,
+		},
+		{
+			name:     "malformed org ID",
+			verifier: identity.NewVerifier(secret),
+			request: func() *http.Request {
+				r := forwarded()
+				r.Header.Set(identity.OrgIDHeader, "-1")
+				return r
+			},
+			wantErr: identity.ErrInvalidHeaders,
+		},
+		{
+			name:     "missing user ID",
+			verifier: identity.NewVerifier(secret),
+			request: func() *http.Request {
+				r := forwarded()
+				r.Header.Del(identity.UserIDHeader)
+				return r
+			},
+			wantErr: identity.ErrInvalidHeaders,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.verifier.Claims(tt.request())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Claims() error = %v, want %v", err, tt.wantErr)
+			}
+			if !tt.wantClaims {
+				if claims != nil {
+					t.Errorf("Claims() = %+v, want nil", claims)
+				}
+				return
+			}
+			if claims == nil || claims.UserID != 42 || claims.Role != domain.RoleCustomer || claims.OrgID != 2 ||
+				claims.CustomerID == nil || *claims.CustomerID != customerID || claims.CourierID != nil {
+				t.Errorf("Claims() = %+v, want the forwarded customer", claims)
+			}
+		})
+	}
+}
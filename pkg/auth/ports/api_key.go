@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// APIKeyRepository defines the interface for API key persistence
+type APIKeyRepository interface {
+	// Create stores a new key, setting its ID
+	Create(ctx context.Context, key *domain.APIKey) error
+
+	// GetByPrefix retrieves a key by its lookup prefix
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+
+	// ListByUser retrieves a user's keys, newest first
+	ListByUser(ctx context.Context, userID int) ([]*domain.APIKey, error)
+
+	// Revoke marks a user's key as revoked, returning domain.ErrAPIKeyNotFound
+	// when the user has no such key
+	Revoke(ctx context.Context, userID, id int, at time.Time) error
+
+	// TouchLastUsed records when a key was last used
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+)
+
+// LoginAttemptStore tracks failed logins per key, a username or a source IP
+type LoginAttemptStore interface {
+	// Get returns a key's attempts, the zero value when none are recorded
+	Get(ctx context.Context, key string) (domain.LoginAttempts, error)
+
+	// RecordFailure atomically counts a failed login for key under policy
+	// and returns the updated attempts
+	RecordFailure(ctx context.Context, key string, policy domain.LockoutPolicy, now time.Time) (domain.LoginAttempts, error)
+
+	// Reset forgets a key's failures and lifts its lock
+	Reset(ctx context.Context, key string) error
+}
@@ -23,6 +23,20 @@ type UserRepository interface {
 	// Update updates a user
 	Update(ctx context.Context, user *domain.User) error
 
+	// UpdateEmail changes a user's email, returning domain.ErrEmailTaken when
+	// another user has it
+	UpdateEmail(ctx context.Context, id int, email string) error
+
+	// UpdatePassword replaces a user's password hash
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+
+	// SetActive activates or deactivates a user
+	SetActive(ctx context.Context, id int, active bool) error
+
+	// SetOrganization moves a user to another organization, returning
+	// domain.ErrOrgNotFound when it does not exist
+	SetOrganization(ctx context.Context, id, orgID int) error
+
 	// Delete deletes a user
 	Delete(ctx context.Context, id int) error
 }
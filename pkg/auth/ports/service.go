@@ -2,23 +2,57 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 )
 
 // AuthService defines the interface for authentication operations
 type AuthService interface {
-	// Register creates a new user account
-	Register(ctx context.Context, username, email, password, role string, customerID, courierID *int) (*domain.User, error)
+	// Register creates a new user account in an organization, the default
+	// one when orgID is zero. Only an admin caller in ctx may choose the
+	// organization or register an admin.
+	Register(ctx context.Context, username, email, password, role string, orgID int, customerID, courierID *int) (*domain.User, error)
 
-	// Authenticate validates credentials and returns a token and user
+	// Authenticate validates credentials and returns a token and user,
+	// returning a domain.LockedError after too many failed attempts
 	Authenticate(ctx context.Context, username, password string) (token string, user *domain.User, err error)
 
-	// ValidateToken validates a JWT token and returns the claims
+	// ValidateToken validates a JWT token and returns the claims, failing for
+	// deactivated users
 	ValidateToken(ctx context.Context, tokenString string) (*domain.Claims, error)
 
 	// GetUser retrieves a user by ID
 	GetUser(ctx context.Context, id int) (*domain.User, error)
+
+	// UpdateEmail changes a user's email
+	UpdateEmail(ctx context.Context, id int, email string) (*domain.User, error)
+
+	// ChangePassword replaces a user's password once the current one is verified
+	ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error
+
+	// SetUserActive deactivates or reactivates a user account
+	SetUserActive(ctx context.Context, id int, active bool) (*domain.User, error)
+
+	// SetUserOrganization moves a user to another organization
+	SetUserOrganization(ctx context.Context, id, orgID int) (*domain.User, error)
+
+	// UnlockUser lifts a user's login lockout
+	UnlockUser(ctx context.Context, id int) error
+
+	// IssueAPIKey creates an API key acting as a user and returns it with its
+	// plaintext, which cannot be retrieved again
+	IssueAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (*domain.APIKey, string, error)
+
+	// ListAPIKeys retrieves a user's API keys
+	ListAPIKeys(ctx context.Context, userID int) ([]*domain.APIKey, error)
+
+	// RevokeAPIKey revokes one of a user's API keys
+	RevokeAPIKey(ctx context.Context, userID, id int) error
+
+	// ValidateAPIKey validates an API key and returns the claims of the user
+	// owning it, failing for revoked or expired keys and deactivated users
+	ValidateAPIKey(ctx context.Context, key string) (*domain.Claims, error)
 }
 
 // TokenService defines the interface for JWT token operations
@@ -1,3 +1,41 @@
 package cache
 
-// Redis client
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Timeouts are kept short so a slow or unreachable Redis degrades callers
+// to their fallback instead of stalling requests
+const (
+	dialTimeout  = 500 * time.Millisecond
+	readTimeout  = 200 * time.Millisecond
+	writeTimeout = 200 * time.Millisecond
+)
+
+// Redis wraps a Redis client
+type Redis struct {
+	*redis.Client
+}
+
+// New creates a Redis client for a redis:// URL or a plain host:port address.
+// It does not connect; use Ping to check availability.
+func New(redisURL string) (*Redis, error) {
+	opts := &redis.Options{Addr: redisURL}
+	if strings.Contains(redisURL, "://") {
+		parsed, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		opts = parsed
+	}
+
+	opts.DialTimeout = dialTimeout
+	opts.ReadTimeout = readTimeout
+	opts.WriteTimeout = writeTimeout
+
+	return &Redis{redis.NewClient(opts)}, nil
+}
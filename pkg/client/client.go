@@ -0,0 +1,429 @@
+// Package client is a Go SDK for the DeliverTrack HTTP API served by the
+// gateway. It signs requests with a bearer token or API key, logs in again
+// when the token is about to expire or is rejected, decodes error responses
+// into *Error and pages through list endpoints.
+//
+//	c, err := client.New(client.Config{BaseURL: "http://localhost:8084"})
+//	if err != nil { ... }
+//	if _, err := c.Login(ctx, "alice", "secret"); err != nil { ... }
+//	d, err := c.GetDelivery(ctx, 42)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader carries a partner backend's API key in place of a token
+const APIKeyHeader = "X-API-Key"
+
+// refreshMargin is how long before its expiry a token is replaced
+const refreshMargin = 30 * time.Second
+
+// Config holds where the API is and how requests are sent
+type Config struct {
+	BaseURL    string        // gateway URL, e.g. http://localhost:8084
+	Timeout    time.Duration // bound on each request; zero leaves it to the context
+	HTTPClient *http.Client  // sends requests; http.DefaultClient when nil
+	APIKey     string        // authenticates as a partner backend instead of logging in
+	PageSize   int           // deliveries fetched per page when listing; DefaultPageSize when zero
+
+	// Bounds on the wait between WebSocket reconnects, doubled after each
+	// failed attempt; DefaultReconnectBackoff and DefaultMaxReconnectBackoff when zero
+	ReconnectBackoff    time.Duration
+	MaxReconnectBackoff time.Duration
+}
+
+// Defaults for unset Config fields
+const (
+	DefaultPageSize            = 50
+	DefaultReconnectBackoff    = 500 * time.Millisecond
+	DefaultMaxReconnectBackoff = 30 * time.Second
+)
+
+// Client calls the DeliverTrack API. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+	config  Config
+
+	// refreshMu serializes logins replacing the token, so requests that
+	// find it expiring or rejected at the same time share one login
+	refreshMu sync.Mutex
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time // zero when unknown
+	username  string    // credentials kept to log in again once the token expires
+	password  string
+}
+
+// New creates a client for the API at config.BaseURL
+func New(config Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(config.BaseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", config.BaseURL)
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = DefaultPageSize
+	}
+	if config.ReconnectBackoff <= 0 {
+		config.ReconnectBackoff = DefaultReconnectBackoff
+	}
+	if config.MaxReconnectBackoff <= 0 {
+		config.MaxReconnectBackoff = DefaultMaxReconnectBackoff
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: base, http: httpClient, config: config}, nil
+}
+
+// Error is an error response from the API. Code is the machine-readable
+// error, such as "slot_full", or the status text where the endpoint has none.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RetryAfter time.Duration // set with 429 and 503 responses that give one
+	// CurrentVersion is the delivery's version when an update expected a stale one
+	CurrentVersion int
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("delivertrack: %d %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("delivertrack: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// ErrorCode returns the machine-readable code of an API error, or "" when
+// err is not one
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// errorBody is every error response's shape; retry_after and
+// current_version only come with some of them
+type errorBody struct {
+	Error          string `json:"error"`
+	Message        string `json:"message"`
+	RetryAfter     int64  `json:"retry_after"`
+	CurrentVersion int    `json:"current_version"`
+}
+
+// User is the signed-in account
+type User struct {
+	ID         int       `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	OrgID      int       `json:"org_id"`
+	CustomerID *int      `json:"customer_id,omitempty"`
+	CourierID  *int      `json:"courier_id,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Session is the result of a login
+type Session struct {
+	Token     string
+	User      *User
+	ExpiresAt time.Time
+}
+
+// RegisterRequest creates an account; customers need CustomerID and couriers CourierID
+type RegisterRequest struct {
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	Role       string `json:"role"`             // customer, courier or admin
+	OrgID      int    `json:"org_id,omitempty"` // the default organization when 0
+	CustomerID *int   `json:"customer_id,omitempty"`
+	CourierID  *int   `json:"courier_id,omitempty"`
+}
+
+// Register creates an account. It does not sign in; call Login after.
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*User, error) {
+	var user User
+	if _, err := c.send(ctx, http.MethodPost, "/register", nil, req, &user, false); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login signs in and authenticates the client's further requests with the
+// issued token. The credentials are kept to log in again before the token
+// expires, or when the API rejects it.
+func (c *Client) Login(ctx context.Context, username, password string) (*Session, error) {
+	session, err := c.login(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.username, c.password = username, password
+	c.mu.Unlock()
+	return session, nil
+}
+
+// Refresh replaces the token by logging in again with the credentials given
+// to Login. The API issues no refresh tokens, so a client given its token
+// through SetToken cannot refresh.
+func (c *Client) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.relogin(ctx)
+}
+
+// refreshRejected replaces a token the API rejected, unless another request
+// already replaced it
+func (c *Client) refreshRejected(ctx context.Context, rejected string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if c.Token() != rejected {
+		return nil
+	}
+	return c.relogin(ctx)
+}
+
+// relogin logs in again with the credentials given to Login; callers hold refreshMu
+func (c *Client) relogin(ctx context.Context) error {
+	c.mu.Lock()
+	username, password := c.username, c.password
+	c.mu.Unlock()
+	if username == "" {
+		return errors.New("delivertrack: no credentials to refresh the token with; call Login first")
+	}
+
+	_, err := c.login(ctx, username, password)
+	return err
+}
+
+// SetToken authenticates the client's further requests with a token obtained
+// elsewhere. The client cannot refresh it.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.expiresAt = time.Time{}
+	c.username, c.password = "", ""
+}
+
+// Token returns the token requests are authenticated with, "" before login
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+func (c *Client) login(ctx context.Context, username, password string) (*Session, error) {
+	var resp struct {
+		Token     string `json:"token"`
+		User      *User  `json:"user"`
+		ExpiresIn int64  `json:"expires_in"` // seconds
+	}
+	body := map[string]string{"username": username, "password": password}
+	if _, err := c.send(ctx, http.MethodPost, "/login", nil, body, &resp, false); err != nil {
+		return nil, err
+	}
+
+	session := &Session{Token: resp.Token, User: resp.User}
+	if resp.ExpiresIn > 0 {
+		session.ExpiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	c.mu.Lock()
+	c.token, c.expiresAt = session.Token, session.ExpiresAt
+	c.mu.Unlock()
+	return session, nil
+}
+
+// credentials returns the token to authenticate with, logging in again first
+// when it is about to expire
+func (c *Client) credentials(ctx context.Context) (string, error) {
+	token, expiring := c.currentToken()
+	if !expiring {
+		return token, nil
+	}
+
+	// Look again once it is our turn, a request ahead may have logged in already
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if token, expiring = c.currentToken(); !expiring {
+		return token, nil
+	}
+	if err := c.relogin(ctx); err != nil {
+		return "", err
+	}
+	return c.Token(), nil
+}
+
+// currentToken returns the token and whether it is about to expire and can be refreshed
+func (c *Client) currentToken() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiring := c.username != "" && !c.expiresAt.IsZero() && time.Until(c.expiresAt) < refreshMargin
+	return c.token, expiring
+}
+
+// do sends an authenticated request, decoding a JSON response into out when
+// it is non-nil. A rejected token is replaced once and the request resent.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	_, err := c.send(ctx, method, path, query, in, out, true)
+	return err
+}
+
+// send is do for requests that may go unauthenticated, also returning the
+// response headers
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, in, out any, authenticate bool) (http.Header, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	refreshed := false
+	for {
+		sent := c.Token()
+		resp, err := c.roundTrip(ctx, method, path, query, body, authenticate)
+		if err != nil {
+			return nil, err
+		}
+
+		// Tokens can be rejected before their expiry, e.g. after a restart with a new key
+		if resp.StatusCode == http.StatusUnauthorized && authenticate && !refreshed && c.canRefresh() {
+			resp.Body.Close()
+			if err := c.refreshRejected(ctx, sent); err != nil {
+				return nil, err
+			}
+			refreshed = true
+			continue
+		}
+		return resp.Header, decodeResponse(resp, out)
+	}
+}
+
+func (c *Client) roundTrip(ctx context.Context, method, path string, query url.Values, body []byte, authenticate bool) (*http.Response, error) {
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint(path, query), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if authenticate {
+		if c.config.APIKey != "" {
+			req.Header.Set(APIKeyHeader, c.config.APIKey)
+		} else {
+			token, err := c.credentials(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the body while the request's timeout still allows it
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+func (c *Client) canRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username != "" && c.config.APIKey == ""
+}
+
+// endpoint resolves an API path against the base URL
+func (c *Client) endpoint(path string, query url.Values) string {
+	u := *c.baseURL
+	u.Path = strings.TrimRight(u.Path, "/") + path
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// decodeResponse decodes a successful response into out, or an error
+// response into *Error
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	var body errorBody
+	if data, _ := io.ReadAll(resp.Body); json.Unmarshal(data, &body) == nil {
+		apiErr.Code = body.Error
+		apiErr.Message = body.Message
+		apiErr.CurrentVersion = body.CurrentVersion
+		if body.RetryAfter > 0 {
+			apiErr.RetryAfter = time.Duration(body.RetryAfter) * time.Second
+		}
+	} else {
+		// Some errors, such as the gateway's plain-text ones, aren't JSON
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	if apiErr.Code == "" {
+		apiErr.Code = http.StatusText(resp.StatusCode)
+	}
+	if seconds, err := strconv.ParseInt(resp.Header.Get("Retry-After"), 10, 64); err == nil && apiErr.RetryAfter == 0 {
+		apiErr.RetryAfter = time.Duration(min(seconds, math.MaxInt32)) * time.Second
+	}
+	return apiErr
+}
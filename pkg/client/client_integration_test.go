@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Integration tests that require a running stack, e.g. docker compose up,
+// with DELIVERTRACK_URL pointing at its gateway
+
+// TestIntegration_DeliveryLifecycle walks a delivery from creation to
+// assignment through the gateway
+func TestIntegration_DeliveryLifecycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	baseURL := os.Getenv("DELIVERTRACK_URL")
+	if baseURL == "" {
+		t.Skip("DELIVERTRACK_URL not set, skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	customers, err := New(Config{BaseURL: baseURL, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	customer, err := customers.Register(ctx, RegisterRequest{
+		Username: "sdk_customer_" + suffix,
+		Email:    "sdk_customer_" + suffix + "@example.com",
+		Password: "CustomerPass123!",
+		Role:     "customer",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register customer: %v", err)
+	}
+	if customer.CustomerID == nil {
+		t.Fatal("Expected registration to create a customer profile")
+	}
+	if _, err := customers.Login(ctx, customer.Username, "CustomerPass123!"); err != nil {
+		t.Fatalf("Failed to log in as customer: %v", err)
+	}
+
+	delivery, err := customers.CreateDelivery(ctx, CreateDeliveryRequest{
+		CustomerID:       *customer.CustomerID,
+		PickupLocation:   "(-122.4194,37.7749)",
+		DeliveryLocation: "(-122.4089,37.7849)",
+		Notes:            "SDK integration test",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create delivery: %v", err)
+	}
+	if delivery.Status != StatusPending {
+		t.Errorf("Expected a pending delivery, got %q", delivery.Status)
+	}
+
+	found := false
+	for d, err := range customers.ListDeliveries(ctx, ListOptions{CustomerID: *customer.CustomerID}) {
+		if err != nil {
+			t.Fatalf("Failed to list deliveries: %v", err)
+		}
+		found = found || d.ID == delivery.ID
+	}
+	if !found {
+		t.Errorf("Expected delivery %d in the customer's listing", delivery.ID)
+	}
+
+	// Admins cannot sign themselves up, so a courier takes the delivery
+	couriers, err := New(Config{BaseURL: baseURL, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	courier, err := couriers.Register(ctx, RegisterRequest{
+		Username: "sdk_courier_" + suffix,
+		Email:    "sdk_courier_" + suffix + "@example.com",
+		Password: "CourierPass123!",
+		Role:     "courier",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register courier: %v", err)
+	}
+	if _, err := couriers.Login(ctx, courier.Username, "CourierPass123!"); err != nil {
+		t.Fatalf("Failed to log in as courier: %v", err)
+	}
+	if err := couriers.do(ctx, http.MethodPut, "/api/delivery/couriers/me/status", nil, map[string]string{"status": "available"}, nil); err != nil {
+		t.Fatalf("Failed to make the courier available: %v", err)
+	}
+
+	version, err := couriers.UpdateStatus(ctx, delivery.ID, UpdateStatusRequest{
+		Status:          StatusAssigned,
+		ExpectedVersion: delivery.Version,
+	})
+	if err != nil {
+		t.Fatalf("Failed to assign delivery: %v", err)
+	}
+	if version <= delivery.Version {
+		t.Errorf("Expected the version to advance past %d, got %d", delivery.Version, version)
+	}
+
+	// The customer's copy is now stale
+	_, err = couriers.UpdateStatus(ctx, delivery.ID, UpdateStatusRequest{
+		Status:          StatusInTransit,
+		ExpectedVersion: delivery.Version,
+	})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.CurrentVersion != version {
+		t.Errorf("Expected a version conflict at version %d, got %v", version, err)
+	}
+
+	got, err := customers.GetDelivery(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("Failed to get delivery: %v", err)
+	}
+	if got.Status != StatusAssigned || got.Version != version {
+		t.Errorf("Expected an assigned delivery at version %d, got %q at %d", version, got.Status, got.Version)
+	}
+}
@@ -0,0 +1,382 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeGateway issues numbered tokens from /login, each valid until the next
+// login, and serves handler for requests carrying the current one
+type fakeGateway struct {
+	logins    atomic.Int32
+	expiresIn int64
+	handler   http.HandlerFunc
+}
+
+func (g *fakeGateway) token() string {
+	return "token-" + strconv.Itoa(int(g.logins.Load()))
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/login" {
+		var creds map[string]string
+		json.NewDecoder(r.Body).Decode(&creds)
+		if creds["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","message":"invalid credentials"}`))
+			return
+		}
+		g.logins.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"token":      g.token(),
+			"user":       map[string]any{"id": 1, "username": creds["username"], "role": "admin"},
+			"expires_in": g.expiresIn,
+		})
+		return
+	}
+
+	authorized := r.Header.Get("Authorization") == "Bearer "+g.token() ||
+		(r.Header.Get(APIKeyHeader) == "partner-key" && r.Header.Get("Authorization") == "")
+	if !authorized && r.Header.Get("Sec-WebSocket-Protocol") != "bearer, "+g.token() {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"unauthorized","message":"Invalid or expired token"}`))
+		return
+	}
+	g.handler(w, r)
+}
+
+func newTestClient(t *testing.T, gateway *fakeGateway) *Client {
+	t.Helper()
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+
+	c, err := New(Config{BaseURL: server.URL, PageSize: 2, ReconnectBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return c
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	for _, base := range []string{"", "localhost:8084", "://bad"} {
+		if _, err := New(Config{BaseURL: base}); err == nil {
+			t.Errorf("expected an error for base URL %q", base)
+		}
+	}
+}
+
+func TestClient_Login(t *testing.T) {
+	gateway := &fakeGateway{expiresIn: 3600, handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ID":42,"TrackingNumber":"DT-42","Status":"pending","Version":3}`))
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+
+	if _, err := c.GetDelivery(ctx, 42); ErrorCode(err) != "unauthorized" {
+		t.Fatalf("expected an unauthorized error before login, got %v", err)
+	}
+
+	if _, err := c.Login(ctx, "alice", "wrong"); ErrorCode(err) != "Unauthorized" {
+		t.Fatalf("expected the login to be rejected, got %v", err)
+	}
+
+	session, err := c.Login(ctx, "alice", "secret")
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	if session.Token != "token-1" || session.User.Username != "alice" || time.Until(session.ExpiresAt) < time.Hour-time.Minute {
+		t.Errorf("unexpected session: %+v", session)
+	}
+
+	d, err := c.GetDelivery(ctx, 42)
+	if err != nil {
+		t.Fatalf("failed to get delivery: %v", err)
+	}
+	if d.ID != 42 || d.TrackingNumber != "DT-42" || d.Status != StatusPending || d.Version != 3 {
+		t.Errorf("unexpected delivery: %+v", d)
+	}
+}
+
+func TestClient_RefreshesRejectedToken(t *testing.T) {
+	gateway := &fakeGateway{expiresIn: 3600, handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ID":1}`))
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+
+	if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	// Another login, e.g. from a restarted gateway, invalidates the client's token
+	gateway.logins.Add(1)
+
+	if _, err := c.GetDelivery(ctx, 1); err != nil {
+		t.Fatalf("expected the request to succeed after logging in again, got %v", err)
+	}
+	if c.Token() != "token-3" {
+		t.Errorf("expected the client to hold a new token, got %q", c.Token())
+	}
+
+	// A token set by the caller cannot be replaced
+	c.SetToken("stale")
+	if _, err := c.GetDelivery(ctx, 1); ErrorCode(err) != "unauthorized" {
+		t.Errorf("expected an unauthorized error, got %v", err)
+	}
+	if err := c.Refresh(ctx); err == nil {
+		t.Error("expected refresh without credentials to fail")
+	}
+}
+
+func TestClient_RefreshesExpiringToken(t *testing.T) {
+	gateway := &fakeGateway{expiresIn: 10, handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ID":1}`))
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+
+	if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	if _, err := c.GetDelivery(ctx, 1); err != nil {
+		t.Fatalf("failed to get delivery: %v", err)
+	}
+	// The token expires within the refresh margin, so it is replaced before use
+	if got := gateway.logins.Load(); got != 2 {
+		t.Errorf("expected a second login before the request, got %d logins", got)
+	}
+}
+
+func TestClient_ConcurrentRefreshesShareOneLogin(t *testing.T) {
+	gateway := &fakeGateway{expiresIn: 3600, handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ID":1}`))
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+
+	if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	getConcurrently := func() {
+		t.Helper()
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.GetDelivery(ctx, 1)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Errorf("expected every request to succeed, got %v", err)
+			}
+		}
+	}
+
+	// Each login invalidates the previous token, so racing logins would reject each other
+	gateway.logins.Add(1)
+	getConcurrently()
+	if got := gateway.logins.Load(); got != 3 {
+		t.Errorf("expected one login for the rejected token, got %d logins", got-2)
+	}
+
+	c.mu.Lock()
+	c.expiresAt = time.Now().Add(time.Second)
+	c.mu.Unlock()
+	getConcurrently()
+	if got := gateway.logins.Load(); got != 4 {
+		t.Errorf("expected one login for the expiring token, got %d logins", got-3)
+	}
+}
+
+func TestClient_APIKey(t *testing.T) {
+	gateway := &fakeGateway{handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ID":7}`))
+	}}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, APIKey: "partner-key"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	d, err := c.GetDelivery(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("failed to get delivery: %v", err)
+	}
+	if d.ID != 7 {
+		t.Errorf("unexpected delivery: %+v", d)
+	}
+}
+
+func TestClient_DecodesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		wantErr Error
+	}{
+		{
+			name:    "machine-readable code",
+			status:  http.StatusConflict,
+			body:    `{"error":"slot_full","message":"The slot is fully booked"}`,
+			wantErr: Error{StatusCode: http.StatusConflict, Code: "slot_full", Message: "The slot is fully booked"},
+		},
+		{
+			name:    "version conflict",
+			status:  http.StatusConflict,
+			body:    `{"error":"Conflict","message":"delivery was modified","current_version":5}`,
+			wantErr: Error{StatusCode: http.StatusConflict, Code: "Conflict", Message: "delivery was modified", CurrentVersion: 5},
+		},
+		{
+			name:    "retry after in the body",
+			status:  http.StatusServiceUnavailable,
+			body:    `{"error":"service_unavailable","message":"circuit open","retry_after":12}`,
+			wantErr: Error{StatusCode: http.StatusServiceUnavailable, Code: "service_unavailable", Message: "circuit open", RetryAfter: 12 * time.Second},
+		},
+		{
+			name:    "retry after header",
+			status:  http.StatusTooManyRequests,
+			header:  map[string]string{"Retry-After": "3"},
+			body:    "rate limit exceeded\n",
+			wantErr: Error{StatusCode: http.StatusTooManyRequests, Code: "Too Many Requests", Message: "rate limit exceeded", RetryAfter: 3 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := &fakeGateway{handler: func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.header {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}}
+			c := newTestClient(t, gateway)
+			if _, err := c.Login(context.Background(), "alice", "secret"); err != nil {
+				t.Fatalf("failed to log in: %v", err)
+			}
+
+			_, err := c.GetDelivery(context.Background(), 1)
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			if *apiErr != tt.wantErr {
+				t.Errorf("expected %+v, got %+v", tt.wantErr, *apiErr)
+			}
+		})
+	}
+}
+
+func TestClient_ListDeliveries(t *testing.T) {
+	const total = 5
+	gateway := &fakeGateway{handler: func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("status") != "pending,assigned" || query.Get("late") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		offset, _ := strconv.Atoi(query.Get("offset"))
+
+		page := []map[string]int{}
+		for id := offset + 1; id <= min(offset+limit, total); id++ {
+			page = append(page, map[string]int{"ID": id})
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		json.NewEncoder(w).Encode(page)
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+	if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	late := true
+	opts := ListOptions{Statuses: []string{StatusPending, StatusAssigned}, Late: &late}
+
+	var ids []int
+	for d, err := range c.ListDeliveries(ctx, opts) {
+		if err != nil {
+			t.Fatalf("failed to list deliveries: %v", err)
+		}
+		ids = append(ids, d.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Errorf("expected every page to be fetched, got %v", ids)
+	}
+
+	// Stopping early fetches no more pages
+	for d := range c.ListDeliveries(ctx, opts) {
+		if d.ID == 1 {
+			break
+		}
+	}
+
+	page, err := c.ListDeliveriesPage(ctx, opts, 2, 4)
+	if err != nil {
+		t.Fatalf("failed to list a page: %v", err)
+	}
+	if page.Total != total || len(page.Deliveries) != 1 || page.Deliveries[0].ID != 5 {
+		t.Errorf("unexpected last page: total %d, %d deliveries", page.Total, len(page.Deliveries))
+	}
+
+	for _, err := range c.ListDeliveries(ctx, ListOptions{}) {
+		if ErrorCode(err) != "Bad Request" {
+			t.Errorf("expected the error to be yielded, got %v", err)
+		}
+	}
+}
+
+func TestClient_UpdateStatus(t *testing.T) {
+	gateway := &fakeGateway{handler: func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateStatusRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Method != http.MethodPut || r.URL.Path != "/api/delivery/deliveries/9/status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.ExpectedVersion != 2 {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"Conflict","message":"delivery was modified","current_version":2}`))
+			return
+		}
+		w.Write([]byte(`{"message":"Delivery status updated successfully","version":3}`))
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+	if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	version, err := c.UpdateStatus(ctx, 9, UpdateStatusRequest{Status: StatusDelivered, ExpectedVersion: 2})
+	if err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if version != 3 {
+		t.Errorf("expected version 3, got %d", version)
+	}
+
+	_, err = c.UpdateStatus(ctx, 9, UpdateStatusRequest{Status: StatusDelivered, ExpectedVersion: 1})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.CurrentVersion != 2 {
+		t.Errorf("expected a version conflict at version 2, got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusAssigned  = "assigned"
+	StatusInTransit = "in_transit"
+	StatusDelivered = "delivered"
+	StatusCancelled = "cancelled"
+)
+
+// Delivery is a delivery as the API returns it
+type Delivery struct {
+	ID               int        `json:"ID"`
+	TrackingNumber   string     `json:"TrackingNumber"`
+	CustomerID       int        `json:"CustomerID"`
+	CourierID        *int       `json:"CourierID"`
+	Status           string     `json:"Status"`
+	PickupLocation   string     `json:"PickupLocation"`
+	DeliveryLocation string     `json:"DeliveryLocation"`
+	PickupAddress    *Address   `json:"PickupAddress"`
+	DeliveryAddress  *Address   `json:"DeliveryAddress"`
+	ScheduledDate    *time.Time `json:"ScheduledDate"`
+	ScheduledEnd     *time.Time `json:"ScheduledEnd"`
+	DeliveredDate    *time.Time `json:"DeliveredDate"`
+	Late             bool       `json:"Late"`
+	Notes            string     `json:"Notes"`
+	CancelReason     string     `json:"CancelReason"`
+	CancelReasonCode string     `json:"CancelReasonCode"`
+	CancelledAt      *time.Time `json:"CancelledAt"`
+	QuotedPriceCents *int64     `json:"QuotedPriceCents"`
+	QuotedCurrency   string     `json:"QuotedCurrency"`
+	CreatedAt        time.Time  `json:"CreatedAt"`
+	UpdatedAt        time.Time  `json:"UpdatedAt"`
+	Version          int        `json:"Version"`
+}
+
+// Address is a delivery's structured pickup or drop-off address
+type Address struct {
+	Line1       string       `json:"Line1"`
+	City        string       `json:"City"`
+	PostalCode  string       `json:"PostalCode"`
+	Country     string       `json:"Country"`
+	Coordinates *Coordinates `json:"Coordinates"`
+}
+
+// Coordinates is a point in decimal degrees
+type Coordinates struct {
+	Latitude  float64 `json:"Latitude"`
+	Longitude float64 `json:"Longitude"`
+}
+
+// AddressInput is a pickup or drop-off address for a new delivery. Addresses
+// without coordinates are geocoded; latitude and longitude go together.
+type AddressInput struct {
+	Line1      string   `json:"line1"`
+	City       string   `json:"city,omitempty"`
+	PostalCode string   `json:"postal_code,omitempty"`
+	Country    string   `json:"country,omitempty"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
+}
+
+// CreateDeliveryRequest creates a delivery. Each of the pickup and drop-off
+// is given as an address or, for older integrations, a free-text location.
+type CreateDeliveryRequest struct {
+	CustomerID       int           `json:"customer_id"`
+	CourierID        *int          `json:"courier_id,omitempty"`
+	PickupLocation   string        `json:"pickup_location,omitempty"`
+	DeliveryLocation string        `json:"delivery_location,omitempty"`
+	PickupAddress    *AddressInput `json:"pickup_address,omitempty"`
+	DeliveryAddress  *AddressInput `json:"delivery_address,omitempty"`
+	Notes            string        `json:"notes,omitempty"`
+	ScheduledDate    *time.Time    `json:"scheduled_date,omitempty"` // window start
+	ScheduledEnd     *time.Time    `json:"scheduled_end,omitempty"`  // window end
+	QuoteToken       string        `json:"quote_token,omitempty"`    // honours a quoted price
+}
+
+// CreateDelivery creates a delivery
+func (c *Client) CreateDelivery(ctx context.Context, req CreateDeliveryRequest) (*Delivery, error) {
+	var d Delivery
+	if err := c.do(ctx, http.MethodPost, "/api/delivery/deliveries", nil, req, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetDelivery retrieves a delivery
+func (c *Client) GetDelivery(ctx context.Context, id int) (*Delivery, error) {
+	var d Delivery
+	if err := c.do(ctx, http.MethodGet, "/api/delivery/deliveries/"+strconv.Itoa(id), nil, nil, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetConfirmationCode returns the code the customer gives the courier to
+// confirm a delivery. Couriers cannot read it.
+func (c *Client) GetConfirmationCode(ctx context.Context, id int) (string, error) {
+	var resp struct {
+		ConfirmationCode string `json:"confirmation_code"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/delivery/deliveries/"+strconv.Itoa(id)+"/confirmation-code", nil, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.ConfirmationCode, nil
+}
+
+// ListOptions filters and orders a delivery listing; zero values are unset
+type ListOptions struct {
+	Statuses   []string
+	CustomerID int
+	Late       *bool
+	Sort       string // created_at (default), updated_at or scheduled_date
+	Order      string // asc or desc (default)
+}
+
+// Page is one page of a delivery listing
+type Page struct {
+	Deliveries []*Delivery
+	Total      int // deliveries on all pages
+}
+
+// ListDeliveriesPage retrieves the limit deliveries after offset
+func (c *Client) ListDeliveriesPage(ctx context.Context, opts ListOptions, limit, offset int) (*Page, error) {
+	query := url.Values{}
+	if len(opts.Statuses) > 0 {
+		query.Set("status", strings.Join(opts.Statuses, ","))
+	}
+	if opts.CustomerID != 0 {
+		query.Set("customer_id", strconv.Itoa(opts.CustomerID))
+	}
+	if opts.Late != nil {
+		query.Set("late", strconv.FormatBool(*opts.Late))
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if opts.Order != "" {
+		query.Set("order", opts.Order)
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+
+	var page Page
+	header, err := c.send(ctx, http.MethodGet, "/api/delivery/deliveries", query, nil, &page.Deliveries, true)
+	if err != nil {
+		return nil, err
+	}
+	page.Total, _ = strconv.Atoi(header.Get("X-Total-Count"))
+	return &page, nil
+}
+
+// ListDeliveries iterates over every delivery matching opts, fetching a page
+// of Config.PageSize at a time. Iteration stops at the first error, which
+// is yielded with a nil delivery.
+//
+//	for d, err := range c.ListDeliveries(ctx, client.ListOptions{Statuses: []string{client.StatusInTransit}}) {
+//		if err != nil { ... }
+//	}
+func (c *Client) ListDeliveries(ctx context.Context, opts ListOptions) iter.Seq2[*Delivery, error] {
+	return func(yield func(*Delivery, error) bool) {
+		for offset := 0; ; {
+			page, err := c.ListDeliveriesPage(ctx, opts, c.config.PageSize, offset)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, d := range page.Deliveries {
+				if !yield(d, nil) {
+					return
+				}
+			}
+
+			offset += len(page.Deliveries)
+			if len(page.Deliveries) < c.config.PageSize || offset >= page.Total {
+				return
+			}
+		}
+	}
+}
+
+// UpdateStatusRequest moves a delivery to a new status. A non-zero
+// ExpectedVersion fails the update with a 409 *Error carrying the current
+// version when the delivery has changed since it was read.
+type UpdateStatusRequest struct {
+	Status          string `json:"status"`
+	Notes           string `json:"notes,omitempty"`
+	ExpectedVersion int    `json:"expected_version,omitempty"`
+}
+
+// UpdateStatus changes a delivery's status, returning its new version
+func (c *Client) UpdateStatus(ctx context.Context, id int, req UpdateStatusRequest) (int, error) {
+	var resp struct {
+		Version int `json:"version"`
+	}
+	if err := c.do(ctx, http.MethodPut, "/api/delivery/deliveries/"+strconv.Itoa(id)+"/status", nil, req, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
@@ -0,0 +1,296 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrLocationDiscarded is returned by RecordLocation for a point the API
+// accepted but dropped as GPS jitter; it is neither stored nor broadcast
+var ErrLocationDiscarded = errors.New("delivertrack: location discarded")
+
+// Location is a reported courier position on a delivery
+type Location struct {
+	ID         int       `json:"ID"`
+	DeliveryID int       `json:"DeliveryID"`
+	CourierID  int       `json:"CourierID"`
+	Latitude   float64   `json:"Latitude"`
+	Longitude  float64   `json:"Longitude"`
+	Accuracy   *float64  `json:"Accuracy"`
+	Speed      *float64  `json:"Speed"`
+	Heading    *float64  `json:"Heading"`
+	Altitude   *float64  `json:"Altitude"`
+	DistanceKm float64   `json:"DistanceKm"` // travelled along the delivery's track up to this point
+	Timestamp  time.Time `json:"Timestamp"`
+	CreatedAt  time.Time `json:"CreatedAt"`
+	// ClientPointID and RecordedAt are echoed from the RecordLocationRequest that stored the point
+	ClientPointID string     `json:"client_point_id,omitempty"`
+	RecordedAt    *time.Time `json:"recorded_at,omitempty"`
+	// Duplicate is set when RecordLocation returned a point stored by an earlier upload
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Address   string `json:"address,omitempty"` // only when addresses were asked for
+	// DeliveryContext is "unavailable" on reads served while the delivery service was down
+	DeliveryContext string `json:"delivery_context,omitempty"`
+}
+
+// RecordLocationRequest reports a courier's position on a delivery
+type RecordLocationRequest struct {
+	DeliveryID int      `json:"delivery_id"`
+	CourierID  int      `json:"courier_id"`
+	Latitude   float64  `json:"latitude"`
+	Longitude  float64  `json:"longitude"`
+	Accuracy   *float64 `json:"accuracy,omitempty"` // meters
+	Speed      *float64 `json:"speed,omitempty"`
+	Heading    *float64 `json:"heading,omitempty"`
+	Altitude   *float64 `json:"altitude,omitempty"`
+	// ClientPointID is a UUID the courier app chooses for the point, so that
+	// uploading it again returns the stored point instead of a second copy
+	ClientPointID string `json:"client_point_id,omitempty"`
+	// RecordedAt is when the point was taken, for points buffered offline;
+	// the track is ordered by it
+	RecordedAt *time.Time `json:"recorded_at,omitempty"`
+}
+
+// RecordLocation reports a courier's position, returning the stored point.
+// A point whose ClientPointID was recorded before is returned as stored then,
+// with Duplicate set, and may be cleared from the app's buffer either way.
+func (c *Client) RecordLocation(ctx context.Context, req RecordLocationRequest) (*Location, error) {
+	var resp struct {
+		Location
+		Discarded bool   `json:"discarded"`
+		Reason    string `json:"reason"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/tracking/locations", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Discarded {
+		return nil, fmt.Errorf("%w: %s", ErrLocationDiscarded, resp.Reason)
+	}
+	return &resp.Location, nil
+}
+
+// TrackOptions selects the points of a delivery's track; zero values are unset
+type TrackOptions struct {
+	From, To       time.Time // the API defaults to the 24 hours before To
+	Limit          int       // the API defaults to 100
+	OldestFirst    bool
+	SimplifyMeters float64
+}
+
+// Track is a delivery's recorded locations
+type Track struct {
+	DeliveryID         int         `json:"delivery_id"`
+	Locations          []*Location `json:"locations"`
+	PointCount         int         `json:"point_count"`
+	OriginalPointCount int         `json:"original_point_count"` // before simplification
+}
+
+// GetTrack retrieves a delivery's recorded locations
+func (c *Client) GetTrack(ctx context.Context, deliveryID int, opts TrackOptions) (*Track, error) {
+	query := url.Values{}
+	if !opts.From.IsZero() {
+		query.Set("from", opts.From.Format(time.RFC3339))
+	}
+	if !opts.To.IsZero() {
+		query.Set("to", opts.To.Format(time.RFC3339))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.OldestFirst {
+		query.Set("order", "asc")
+	}
+	if opts.SimplifyMeters > 0 {
+		query.Set("simplify", strconv.FormatFloat(opts.SimplifyMeters, 'f', -1, 64))
+	}
+
+	var track Track
+	if err := c.do(ctx, http.MethodGet, "/api/tracking/deliveries/"+strconv.Itoa(deliveryID)+"/track", query, nil, &track); err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+// trackEnvelope wraps every message the tracking WebSocket sends; Seq
+// counts the messages sent on one connection
+type trackEnvelope struct {
+	Type    string          `json:"type"`
+	Seq     uint64          `json:"seq"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// trackLocation is the payload of a "location" envelope
+type trackLocation struct {
+	DeliveryID int       `json:"delivery_id"`
+	Location   *Location `json:"location"`
+}
+
+// trackResumed is the payload of a "resumed" envelope, answering a resume
+// action with the latest location of the tracked delivery
+type trackResumed struct {
+	Locations []trackLocation `json:"locations"`
+}
+
+// trackState is what a subscription remembers across reconnects
+type trackState struct {
+	connected bool   // a connection was read from before
+	lastSeq   uint64 // last sequence number read on the previous connection
+	lastID    int    // ID of the last location passed to fn
+}
+
+// SubscribeTrack calls fn with each location broadcast for a delivery until
+// ctx ends, returning ctx's error, or fn fails, returning its error. Dropped
+// connections are redialled with backoff and resumed: the server answers
+// with the delivery's latest location, passed to fn unless it was the last
+// one seen. Other points recorded while disconnected are not replayed, so
+// callers that need every point should fill the gap with GetTrack. The
+// subscription needs a token, logging in again when it is rejected; refused
+// subscriptions return their *Error.
+func (c *Client) SubscribeTrack(ctx context.Context, deliveryID int, fn func(*Location) error) error {
+	if c.config.APIKey != "" {
+		return errors.New("delivertrack: track subscriptions need a token; API keys cannot subscribe")
+	}
+
+	backoff := c.config.ReconnectBackoff
+	refreshed := false
+	var state trackState
+	for {
+		conn, err := c.dialTrack(ctx, deliveryID)
+		var apiErr *Error
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && !refreshed && c.canRefresh():
+			if err := c.Refresh(ctx); err != nil {
+				return err
+			}
+			refreshed = true
+			continue
+		case errors.As(err, &apiErr) && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests:
+			return err
+		case err == nil:
+			backoff, refreshed = c.config.ReconnectBackoff, false
+			if err := readTrack(ctx, conn, &state, fn); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, c.config.MaxReconnectBackoff)
+	}
+}
+
+// dialTrack opens the tracking WebSocket for a delivery. A refused handshake
+// is returned as *Error.
+func (c *Client) dialTrack(ctx context.Context, deliveryID int) (*websocket.Conn, error) {
+	token, err := c.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *c.baseURL
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path += "/api/tracking/ws/deliveries/" + strconv.Itoa(deliveryID) + "/track"
+
+	// The token goes in the subprotocol header, which unlike the query string
+	// stays out of access logs
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = []string{"bearer", token}
+	if c.config.Timeout > 0 {
+		dialer.HandshakeTimeout = c.config.Timeout
+	}
+	conn, resp, err := dialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		if resp != nil {
+			return nil, decodeError(resp)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// readTrack passes the location updates read from conn to fn until the
+// connection drops, returning nil, or fn fails or ctx ends. A connection
+// following another one is first resumed from state.
+func readTrack(ctx context.Context, conn *websocket.Conn, state *trackState, fn func(*Location) error) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	defer conn.Close()
+
+	if state.connected {
+		resume := map[string]any{"action": "resume", "last_seq": state.lastSeq}
+		if err := conn.WriteJSON(resume); err != nil {
+			return ctx.Err()
+		}
+	}
+	state.connected, state.lastSeq = true, 0
+
+	deliver := func(loc *Location) error {
+		if loc == nil {
+			return nil
+		}
+		state.lastID = loc.ID
+		return fn(loc)
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return ctx.Err()
+		}
+
+		var env trackEnvelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			continue
+		}
+		state.lastSeq = env.Seq
+
+		switch env.Type {
+		case "location":
+			var msg trackLocation
+			if json.Unmarshal(env.Payload, &msg) != nil {
+				continue
+			}
+			if err := deliver(msg.Location); err != nil {
+				return err
+			}
+		case "resumed":
+			var msg trackResumed
+			if json.Unmarshal(env.Payload, &msg) != nil {
+				continue
+			}
+			for _, snapshot := range msg.Locations {
+				if snapshot.Location == nil || snapshot.Location.ID == state.lastID {
+					continue
+				}
+				if err := deliver(snapshot.Location); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClient_RecordLocation(t *testing.T) {
+	gateway := &fakeGateway{handler: func(w http.ResponseWriter, r *http.Request) {
+		var req RecordLocationRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Latitude == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"discarded":true,"reason":"within the accuracy radius of the last point"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ID":11,"DeliveryID":3,"CourierID":4,"Latitude":40.7,"Longitude":-74}`))
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+	if _, err := c.Login(ctx, "courier", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	loc, err := c.RecordLocation(ctx, RecordLocationRequest{DeliveryID: 3, CourierID: 4, Latitude: 40.7, Longitude: -74})
+	if err != nil {
+		t.Fatalf("failed to record location: %v", err)
+	}
+	if loc.ID != 11 || loc.DeliveryID != 3 || loc.Latitude != 40.7 {
+		t.Errorf("unexpected location: %+v", loc)
+	}
+
+	if _, err := c.RecordLocation(ctx, RecordLocationRequest{DeliveryID: 3, CourierID: 4}); !errors.Is(err, ErrLocationDiscarded) {
+		t.Errorf("expected ErrLocationDiscarded, got %v", err)
+	}
+}
+
+func TestClient_GetTrack(t *testing.T) {
+	from := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	gateway := &fakeGateway{handler: func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/api/tracking/deliveries/3/track" || query.Get("from") != "2026-03-01T08:00:00Z" ||
+			query.Get("order") != "asc" || query.Get("limit") != "20" || query.Get("simplify") != "5.5" || query.Has("to") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"delivery_id":3,"locations":[{"ID":1},{"ID":2}],"point_count":2,"original_point_count":8}`))
+	}}
+	c := newTestClient(t, gateway)
+	ctx := context.Background()
+	if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	track, err := c.GetTrack(ctx, 3, TrackOptions{From: from, Limit: 20, OldestFirst: true, SimplifyMeters: 5.5})
+	if err != nil {
+		t.Fatalf("failed to get track: %v", err)
+	}
+	if track.DeliveryID != 3 || len(track.Locations) != 2 || track.OriginalPointCount != 8 {
+		t.Errorf("unexpected track: %+v", track)
+	}
+}
+
+// envelope wraps a fake tracking WebSocket message as the server sends it
+func envelope(msgType string, seq int, payload any) map[string]any {
+	return map[string]any{"v": 1, "type": msgType, "seq": seq, "sent_at": time.Now().UTC(), "payload": payload}
+}
+
+func TestClient_SubscribeTrack(t *testing.T) {
+	var connections atomic.Int32
+	var resumedFrom sync.Map
+	upgrader := websocket.Upgrader{}
+	gateway := &fakeGateway{handler: func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tracking/ws/deliveries/3/track":
+		case "/api/tracking/ws/deliveries/4/track":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"forbidden","message":"Access denied"}`))
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Each connection sends one location and drops, so the client must
+		// reconnect and resume; the first snapshot is the location already
+		// seen, the second one was recorded while disconnected
+		n := connections.Add(1)
+		if n == 1 {
+			conn.WriteJSON(envelope("subscribed", 1, map[string]any{"delivery_id": 3}))
+		} else {
+			var resume struct {
+				Action  string `json:"action"`
+				LastSeq int    `json:"last_seq"`
+			}
+			if err := conn.ReadJSON(&resume); err != nil || resume.Action != "resume" {
+				return
+			}
+			resumedFrom.Store(n, resume.LastSeq)
+			snapshot := map[string]any{"ID": n - 1, "DeliveryID": 3}
+			if n == 3 {
+				snapshot["ID"] = 25
+			}
+			conn.WriteJSON(envelope("resumed", 1, map[string]any{
+				"last_seq":   resume.LastSeq,
+				"resume_gap": true,
+				"locations":  []any{map[string]any{"delivery_id": 3, "location": snapshot}},
+			}))
+		}
+		conn.WriteJSON(envelope("location", 2, map[string]any{"delivery_id": 3, "location": map[string]any{"ID": n, "DeliveryID": 3}}))
+	}}
+	c := newTestClient(t, gateway)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	// The first dial is rejected, so the client logs in again
+	gateway.logins.Add(1)
+
+	stop := errors.New("stop")
+	var ids []int
+	err := c.SubscribeTrack(ctx, 3, func(loc *Location) error {
+		ids = append(ids, loc.ID)
+		if len(ids) == 4 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the callback's error, got %v", err)
+	}
+	if fmt.Sprint(ids) != "[1 2 25 3]" {
+		t.Errorf("expected a location from each of 3 connections and the new snapshot, got %v", ids)
+	}
+	for _, n := range []int32{2, 3} {
+		if seq, _ := resumedFrom.Load(n); seq != 2 {
+			t.Errorf("expected connection %d to resume from seq 2, got %v", n, seq)
+		}
+	}
+
+	err = c.SubscribeTrack(ctx, 4, func(*Location) error { return nil })
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a forbidden subscription to fail at once, got %v", err)
+	}
+
+	if err := c.SubscribeTrack(ctx, 5, func(*Location) error { return nil }); ErrorCode(err) != "Not Found" {
+		t.Errorf("expected a missing delivery to fail at once, got %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MinJWTSecretLength is the shortest HS256 secret accepted in strict mode
+const MinJWTSecretLength = 32
+
+// defaultJWTSecret is the placeholder secret set by setDefaults and the
+// development compose file
+const defaultJWTSecret = "your-super-secret-jwt-key-change-in-production"
+
+// Problem is an invalid configuration value. Soft problems are safe enough for
+// local development and are only warnings when CONFIG_STRICT=false.
+type Problem struct {
+	Field   string
+	Message string
+	Soft    bool
+}
+
+func (p Problem) String() string {
+	return p.Field + ": " + p.Message
+}
+
+// ValidationError lists every problem found in a service's configuration
+type ValidationError struct {
+	Service  string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid %s configuration (%d problem(s)):", e.Service, len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.String())
+		if p.Soft {
+			b.WriteString(" (set CONFIG_STRICT=false to allow this in local development)")
+		}
+	}
+	return b.String()
+}
+
+// dependencies are the backing services a service connects to at startup
+type dependencies struct {
+	database bool
+	mongodb  bool
+	rabbitmq bool
+	auth     bool
+}
+
+var serviceDependencies = map[string]dependencies{
+	"delivery":     {database: true, rabbitmq: true, auth: true},
+	"tracking":     {database: true, mongodb: true, rabbitmq: true, auth: true},
+	"notification": {database: true, rabbitmq: true, auth: true},
+	"analytics":    {database: true, rabbitmq: true, auth: true},
+	"gateway":      {database: true, auth: true},
+	"migrate":      {database: true},
+}
+
+// Validate checks the settings serviceName needs and returns a
+// *ValidationError listing every problem, so all of them can be fixed at once
+func (c *Config) Validate(serviceName string) error {
+	v := &validator{}
+	deps := serviceDependencies[serviceName]
+
+	v.port("service.port", c.Service.Port)
+	for _, proxy := range c.Service.TrustedProxies {
+		v.trustedProxy("service.trusted_proxies", proxy)
+	}
+
+	if deps.database {
+		v.postgresURL("database.url", c.Database.URL)
+		if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+			v.add("database.max_open_conns", "pool sizes cannot be negative")
+		}
+	}
+	if deps.mongodb {
+		v.url("mongodb.url", c.MongoDB.URL, "mongodb", "mongodb+srv")
+		if c.MongoDB.ReadPreference != "" && !validReadPreferences[strings.ToLower(c.MongoDB.ReadPreference)] {
+			v.add("mongodb.read_preference", fmt.Sprintf("%q is not one of primary, primaryPreferred, secondary, secondaryPreferred or nearest", c.MongoDB.ReadPreference))
+		}
+	}
+	if deps.rabbitmq {
+		v.url("rabbitmq.url", c.RabbitMQ.URL, "amqp", "amqps")
+	}
+	if deps.auth {
+		v.auth(c.Auth)
+	}
+
+	// The gateway proxies to HTTP URLs; other services dial gRPC host:port addresses
+	services := []struct{ field, value string }{
+		{"services.delivery", c.Services.Delivery},
+		{"services.tracking", c.Services.Tracking},
+		{"services.notification", c.Services.Notification},
+		{"services.analytics", c.Services.Analytics},
+	}
+	for _, s := range services {
+		switch serviceName {
+		case "gateway":
+			// Non-HTTP values fall back to localhost in the gateway
+			if strings.HasPrefix(s.value, "http") {
+				v.url(s.field, s.value, "http", "https")
+			}
+		case "delivery", "tracking", "notification", "analytics":
+			v.hostPort(s.field, s.value)
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Service: serviceName, Problems: v.problems}
+}
+
+var validReadPreferences = map[string]bool{
+	"primary": true, "primarypreferred": true, "secondary": true, "secondarypreferred": true, "nearest": true,
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(field, message string) {
+	v.problems = append(v.problems, Problem{Field: field, Message: message})
+}
+
+func (v *validator) addSoft(field, message string) {
+	v.problems = append(v.problems, Problem{Field: field, Message: message, Soft: true})
+}
+
+func (v *validator) trustedProxy(field, value string) {
+	if _, _, err := net.ParseCIDR(value); err == nil || net.ParseIP(value) != nil {
+		return
+	}
+	v.add(field, fmt.Sprintf("%q is not a CIDR or IP address", value))
+}
+
+func (v *validator) port(field, value string) {
+	if value == "" {
+		v.add(field, "is required")
+		return
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		v.add(field, fmt.Sprintf("%q is not a port number between 1 and 65535", value))
+	}
+}
+
+func (v *validator) hostPort(field, value string) {
+	if value == "" {
+		v.add(field, "is required")
+		return
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil || host == "" {
+		v.add(field, fmt.Sprintf("%q is not a host:port address", value))
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		v.add(field, fmt.Sprintf("%q has an invalid port", value))
+	}
+}
+
+// url checks that value is an absolute URL with a host and one of schemes
+func (v *validator) url(field, value string, schemes ...string) {
+	if value == "" {
+		v.add(field, "is required")
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add(field, fmt.Sprintf("is not a valid URL: %v", redact(err)))
+		return
+	}
+	if !contains(schemes, u.Scheme) {
+		v.add(field, fmt.Sprintf("scheme %q is not one of %s", u.Scheme, strings.Join(schemes, ", ")))
+		return
+	}
+	if u.Host == "" {
+		v.add(field, "has no host")
+	}
+}
+
+// postgresURL accepts postgres:// URLs and key=value connection strings
+func (v *validator) postgresURL(field, value string) {
+	if value != "" && !strings.Contains(value, "://") {
+		for _, pair := range strings.Fields(value) {
+			if !strings.Contains(pair, "=") {
+				v.add(field, "is neither a postgres:// URL nor a key=value connection string")
+				return
+			}
+		}
+		return
+	}
+	v.url(field, value, "postgres", "postgresql")
+}
+
+func (v *validator) auth(cfg AuthConfig) {
+	if len(cfg.JWTKeys) == 0 {
+		switch {
+		case cfg.JWTSecret == "":
+			v.add("auth.jwt_secret", "is required unless auth.jwt_keys is set")
+		case len(cfg.JWTSecret) < MinJWTSecretLength:
+			v.addSoft("auth.jwt_secret", fmt.Sprintf("must be at least %d characters, got %d", MinJWTSecretLength, len(cfg.JWTSecret)))
+		case cfg.JWTSecret == defaultJWTSecret:
+			v.addSoft("auth.jwt_secret", "is the development placeholder")
+		}
+	}
+
+	for i, key := range cfg.JWTKeys {
+		field := fmt.Sprintf("auth.jwt_keys[%d]", i)
+		if key.ID == "" {
+			v.add(field+".id", "is required")
+		}
+		if (key.Algorithm == "" || key.Algorithm == "HS256") && key.Secret != "" && len(key.Secret) < MinJWTSecretLength {
+			v.addSoft(field+".secret", fmt.Sprintf("must be at least %d characters, got %d", MinJWTSecretLength, len(key.Secret)))
+		}
+	}
+
+	if cfg.TrustGatewayHeaders && cfg.GatewaySecret == "" {
+		v.add("auth.gateway_secret", "is required when auth.trust_gateway_headers is set")
+	}
+	if cfg.GatewaySecret != "" && len(cfg.GatewaySecret) < MinJWTSecretLength {
+		v.addSoft("auth.gateway_secret", fmt.Sprintf("must be at least %d characters, got %d", MinJWTSecretLength, len(cfg.GatewaySecret)))
+	}
+
+	if cfg.JWTExpiration <= 0 {
+		v.add("auth.jwt_expiration", "must be positive")
+	}
+}
+
+// split separates soft problems from the rest
+func (e *ValidationError) split() (soft []Problem, rest *ValidationError) {
+	rest = &ValidationError{Service: e.Service}
+	for _, p := range e.Problems {
+		if p.Soft {
+			soft = append(soft, p)
+		} else {
+			rest.Problems = append(rest.Problems, p)
+		}
+	}
+	return soft, rest
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// redact drops the URL from a parse error, which may carry credentials
+func redact(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+// Package domainerr provides sentinel errors that carry the gRPC status code
+// they map to, so transport adapters can convert domain errors without
+// knowing every domain package.
+package domainerr
+
+import "google.golang.org/grpc/codes"
+
+// Coder is implemented by errors that know their gRPC status code
+type Coder interface {
+	GRPCCode() codes.Code
+}
+
+// codedError is a sentinel error with a gRPC status code
+type codedError struct {
+	text string
+	code codes.Code
+}
+
+// New returns an error with the given text that maps to code. Like
+// errors.New, each call returns a distinct error, so it can be matched with
+// errors.Is.
+func New(code codes.Code, text string) error {
+	return &codedError{text: text, code: code}
+}
+
+func (e *codedError) Error() string {
+	return e.text
+}
+
+// GRPCCode implements Coder
+func (e *codedError) GRPCCode() codes.Code {
+	return e.code
+}
@@ -0,0 +1,19 @@
+// Package geo provides the geographic calculations shared by the services
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean Earth radius used for distance calculations
+const EarthRadiusKm = 6371.0
+
+// HaversineKm returns the great-circle distance between two points in kilometers
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,18 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// New York to Los Angeles is roughly 3936 km
+	distance := HaversineKm(40.7128, -74.0060, 34.0522, -118.2437)
+	if math.Abs(distance-3936) > 10 {
+		t.Errorf("expected about 3936 km, got %f", distance)
+	}
+
+	if distance := HaversineKm(40.7128, -74.0060, 40.7128, -74.0060); distance != 0 {
+		t.Errorf("expected 0 km for the same point, got %f", distance)
+	}
+}
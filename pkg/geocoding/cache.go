@@ -0,0 +1,118 @@
+package geocoding
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStats holds geocoding cache counters
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Size   int   `json:"size"`
+}
+
+// cacheEntry is a cached value with its expiry time
+type cacheEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// lruCache is a size-bounded, TTL-aware LRU cache safe for concurrent use
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List
+	hits     int64
+	misses   int64
+	now      func() time.Time
+}
+
+// newLRUCache creates a cache holding up to capacity entries for ttl each
+func newLRUCache(capacity int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *lruCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		c.misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return entry.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+func (c *lruCache) Set(key string, value interface{}) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.value = value
+		entry.expiresAt = c.now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&cacheEntry{key: key, value: value, expiresAt: c.now().Add(c.ttl)})
+	c.items[key] = elem
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Stats returns a snapshot of cache counters
+func (c *lruCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{Hits: c.hits, Misses: c.misses, Size: c.order.Len()}
+}
+
+// removeElement removes an element; callers must hold the lock
+func (c *lruCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*cacheEntry).key)
+}
+
+// normalizeAddress builds a cache key that ignores case and extra whitespace
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.Join(strings.Fields(address), " "))
+}
+
+// coordinateKey rounds coordinates to ~11m so nearby lookups share a cache entry
+func coordinateKey(lat, lng float64) string {
+	return fmt.Sprintf("%.4f,%.4f", lat, lng)
+}
@@ -0,0 +1,85 @@
+package geocoding
+
+import (
+	"context"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// FallbackGeocodingService tries a primary service and falls back to a
+// secondary one when the primary errors or, for geocoding, finds no match
+type FallbackGeocodingService struct {
+	primary   GeocodingService
+	secondary GeocodingService
+	logger    *logger.Logger
+}
+
+// NewFallbackGeocodingService creates a composite geocoding service
+func NewFallbackGeocodingService(primary, secondary GeocodingService, logger *logger.Logger) *FallbackGeocodingService {
+	return &FallbackGeocodingService{
+		primary:   primary,
+		secondary: secondary,
+		logger:    logger,
+	}
+}
+
+// ForwardGeocode converts address to coordinates
+func (s *FallbackGeocodingService) ForwardGeocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	result, err := s.primary.ForwardGeocode(ctx, address)
+	if err == nil && result != nil {
+		return result, nil
+	}
+
+	s.logFallback(ctx, "forward", err)
+	return s.secondary.ForwardGeocode(ctx, address)
+}
+
+// ReverseGeocode converts coordinates to address
+func (s *FallbackGeocodingService) ReverseGeocode(ctx context.Context, lat, lng float64) (*ReverseGeocodeResult, error) {
+	result, err := s.primary.ReverseGeocode(ctx, lat, lng)
+	if err == nil && result != nil {
+		return result, nil
+	}
+
+	s.logFallback(ctx, "reverse", err)
+	return s.secondary.ReverseGeocode(ctx, lat, lng)
+}
+
+// Autocomplete provides address suggestions. No suggestions is a valid
+// answer, given for queries too short to complete among others, so only a
+// primary error falls back.
+func (s *FallbackGeocodingService) Autocomplete(ctx context.Context, query string) ([]AutocompleteResult, error) {
+	results, err := s.primary.Autocomplete(ctx, query)
+	if err == nil {
+		return results, nil
+	}
+
+	s.logFallback(ctx, "autocomplete", err)
+	return s.secondary.Autocomplete(ctx, query)
+}
+
+// CacheStats sums cache counters of both services where available
+func (s *FallbackGeocodingService) CacheStats() CacheStats {
+	var stats CacheStats
+	for _, svc := range []GeocodingService{s.primary, s.secondary} {
+		if c, ok := svc.(interface{ CacheStats() CacheStats }); ok {
+			st := c.CacheStats()
+			stats.Hits += st.Hits
+			stats.Misses += st.Misses
+			stats.Size += st.Size
+		}
+	}
+	return stats
+}
+
+// logFallback records why the secondary service is being used
+func (s *FallbackGeocodingService) logFallback(ctx context.Context, operation string, err error) {
+	fields := []zap.Field{zap.String("operation", operation)}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	} else {
+		fields = append(fields, zap.String("reason", "no results"))
+	}
+	s.logger.WarnWithFields(ctx, "Primary geocoding provider failed, using fallback", fields...)
+}
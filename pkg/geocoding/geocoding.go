@@ -2,14 +2,26 @@ package geocoding
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"net/url"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/httpclient"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrNoResults is returned when a provider finds nothing for a query
+	ErrNoResults = errors.New("no geocoding results")
+	// ErrUnknownProvider is returned for an unsupported provider name
+	ErrUnknownProvider = errors.New("unknown geocoding provider")
 )
 
 // GeocodingService interface for address ↔ coordinate conversion
@@ -19,6 +31,24 @@ type GeocodingService interface {
 	Autocomplete(ctx context.Context, query string) ([]AutocompleteResult, error)
 }
 
+// Provider builds requests for and parses responses from a specific geocoding API
+type Provider interface {
+	// Name identifies the provider in logs and metrics
+	Name() string
+
+	// DefaultRequestsPerSecond is the provider's usage policy limit
+	DefaultRequestsPerSecond() float64
+
+	ForwardRequest(ctx context.Context, address string) (*http.Request, error)
+	ParseForward(body []byte) (*GeocodeResult, error)
+
+	ReverseRequest(ctx context.Context, lat, lng float64) (*http.Request, error)
+	ParseReverse(body []byte) (*ReverseGeocodeResult, error)
+
+	AutocompleteRequest(ctx context.Context, query string) (*http.Request, error)
+	ParseAutocomplete(body []byte) ([]AutocompleteResult, error)
+}
+
 // GeocodeResult represents a geocoding response
 type GeocodeResult struct {
 	Latitude  float64 `json:"latitude"`
@@ -45,246 +75,290 @@ type AutocompleteResult struct {
 	Description string `json:"description"`
 }
 
-// NominatimResponse represents the response from Nominatim API
-type NominatimResponse struct {
-	PlaceID     int              `json:"place_id"`
-	License     string           `json:"licence"`
-	OSMType     string           `json:"osm_type"`
-	OSMID       int              `json:"osm_id"`
-	Lat         string           `json:"lat"`
-	Lon         string           `json:"lon"`
-	Class       string           `json:"class"`
-	Type        string           `json:"type"`
-	PlaceRank   int              `json:"place_rank"`
-	Importance  float64          `json:"importance"`
-	Addresstype string           `json:"addresstype"`
-	Name        string           `json:"name"`
-	DisplayName string           `json:"display_name"`
-	Address     NominatimAddress `json:"address"`
-	Boundingbox []string         `json:"boundingbox"`
+// Default service settings
+const (
+	defaultCacheSize = 10000
+	defaultCacheTTL  = 24 * time.Hour
+
+	// minAutocompleteLength is the shortest query sent upstream for suggestions
+	minAutocompleteLength = 3
+)
+
+// HTTPGeocodingService implements GeocodingService on top of a Provider,
+// adding response caching, client-side rate limiting and retries of
+// rate-limited or unavailable responses
+type HTTPGeocodingService struct {
+	client   *httpclient.Client
+	provider Provider
+	cache    *lruCache
+	logger   *logger.Logger
 }
 
-// NominatimAddress represents the address part of Nominatim response
-type NominatimAddress struct {
-	HouseNumber string `json:"house_number"`
-	Road        string `json:"road"`
-	Suburb      string `json:"suburb"`
-	City        string `json:"city"`
-	State       string `json:"state"`
-	Postcode    string `json:"postcode"`
-	Country     string `json:"country"`
-	CountryCode string `json:"country_code"`
+// rateLimitedDoer queues each attempt, retries included, behind the limiter
+// to respect the provider's usage policy
+type rateLimitedDoer struct {
+	doer    httpclient.Doer
+	limiter *rate.Limiter
 }
 
-// HTTPGeocodingService implements GeocodingService using external APIs
-type HTTPGeocodingService struct {
-	client *http.Client
-	logger *logger.Logger
+func (d *rateLimitedDoer) Do(req *http.Request) (*http.Response, error) {
+	if err := d.limiter.Wait(req.Context()); err != nil {
+		return nil, fmt.Errorf("rate limiter wait: %w", err)
+	}
+	return d.doer.Do(req)
 }
 
 // NewHTTPGeocodingService creates a geocoding service using free APIs
 func NewHTTPGeocodingService(logger *logger.Logger) *HTTPGeocodingService {
+	return NewHTTPGeocodingServiceWithConfig(config.GeocodingConfig{}, logger)
+}
+
+// NewHTTPGeocodingServiceWithConfig creates a Nominatim geocoding service with
+// custom endpoint, cache and rate limit settings. Zero values fall back to defaults.
+func NewHTTPGeocodingServiceWithConfig(cfg config.GeocodingConfig, logger *logger.Logger) *HTTPGeocodingService {
+	return NewProviderGeocodingService(NewNominatimProvider(cfg.BaseURL), cfg, logger)
+}
+
+// NewProviderGeocodingService creates a geocoding service backed by the given provider
+func NewProviderGeocodingService(provider Provider, cfg config.GeocodingConfig, logger *logger.Logger) *HTTPGeocodingService {
+	cacheSize := cfg.CacheSize
+	if cacheSize == 0 {
+		cacheSize = defaultCacheSize
+	}
+	cacheTTL := cfg.CacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = defaultCacheTTL
+	}
+	rps := cfg.RequestsPerSecond
+	if rps <= 0 {
+		rps = provider.DefaultRequestsPerSecond()
+	}
+
+	retry := httpclient.DefaultConfig()
+	switch {
+	case cfg.MaxRetries < 0:
+		retry.MaxRetries = 0
+	case cfg.MaxRetries > 0:
+		retry.MaxRetries = cfg.MaxRetries
+	}
+	if cfg.RequestTimeout > 0 {
+		retry.AttemptTimeout = cfg.RequestTimeout
+	}
+	limited := &rateLimitedDoer{doer: &http.Client{}, limiter: rate.NewLimiter(rate.Limit(rps), 1)}
+
 	return &HTTPGeocodingService{
-		client: &http.Client{Timeout: 10 * time.Second},
-		logger: logger,
+		client:   httpclient.New(limited, retry, logger),
+		provider: provider,
+		cache:    newLRUCache(cacheSize, cacheTTL),
+		logger:   logger,
 	}
 }
 
-// ForwardGeocode converts address to coordinates using Nominatim (OpenStreetMap)
-func (s *HTTPGeocodingService) ForwardGeocode(ctx context.Context, address string) (*GeocodeResult, error) {
-	if address == "" {
-		return nil, fmt.Errorf("address cannot be empty")
+// NewGeocodingService creates the geocoding service selected by cfg.Provider,
+// wrapped with a fallback provider when cfg.FallbackProvider is set
+func NewGeocodingService(cfg config.GeocodingConfig, logger *logger.Logger) (GeocodingService, error) {
+	primary, err := newProvider(cfg.Provider, cfg)
+	if err != nil {
+		return nil, err
 	}
+	primarySvc := NewProviderGeocodingService(primary, cfg, logger)
 
-	// Build Nominatim API URL
-	baseURL := "https://nominatim.openstreetmap.org/search"
-	params := url.Values{}
-	params.Add("format", "json")
-	params.Add("q", address)
-	params.Add("limit", "1")
-	params.Add("addressdetails", "1")
+	if cfg.FallbackProvider == "" || cfg.FallbackProvider == cfg.Provider {
+		return primarySvc, nil
+	}
 
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+	// The fallback has its own base URL and rate limit defaults
+	fallbackCfg := cfg
+	fallbackCfg.BaseURL = ""
+	fallbackCfg.RequestsPerSecond = 0
+	secondary, err := newProvider(cfg.FallbackProvider, fallbackCfg)
+	if err != nil {
+		return nil, err
+	}
 
-	s.logger.InfoWithFields(ctx, "Geocoding address",
-		zap.String("address", address), zap.String("url", fullURL))
+	return NewFallbackGeocodingService(primarySvc, NewProviderGeocodingService(secondary, fallbackCfg, logger), logger), nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// ConfigFromEnv overrides provider selection and credentials from environment
+// variables (set in docker-compose), since viper does not map nested keys
+func ConfigFromEnv(cfg config.GeocodingConfig) config.GeocodingConfig {
+	if provider := os.Getenv("GEOCODING_PROVIDER"); provider != "" {
+		cfg.Provider = provider
+	}
+	if fallback := os.Getenv("GEOCODING_FALLBACK_PROVIDER"); fallback != "" {
+		cfg.FallbackProvider = fallback
+	}
+	if token := os.Getenv("MAPBOX_ACCESS_TOKEN"); token != "" {
+		cfg.MapboxToken = token
 	}
+	if key := os.Getenv("GOOGLE_MAPS_API_KEY"); key != "" {
+		cfg.GoogleAPIKey = key
+	}
+	return cfg
+}
 
+// newProvider builds a provider by name
+func newProvider(name string, cfg config.GeocodingConfig) (Provider, error) {
+	switch strings.ToLower(name) {
+	case "", "nominatim":
+		return NewNominatimProvider(cfg.BaseURL), nil
+	case "mapbox":
+		if cfg.MapboxToken == "" {
+			return nil, fmt.Errorf("mapbox provider requires an access token")
+		}
+		return NewMapboxProvider(cfg.BaseURL, cfg.MapboxToken), nil
+	case "google":
+		if cfg.GoogleAPIKey == "" {
+			return nil, fmt.Errorf("google provider requires an API key")
+		}
+		return NewGoogleProvider(cfg.BaseURL, cfg.GoogleAPIKey), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+}
+
+// CacheStats returns cache hit/miss counters
+func (s *HTTPGeocodingService) CacheStats() CacheStats {
+	return s.cache.Stats()
+}
+
+// fetch performs the request, retrying transient failures, and returns the body
+func (s *HTTPGeocodingService) fetch(ctx context.Context, req *http.Request) ([]byte, error) {
 	// Set User-Agent as required by Nominatim
 	req.Header.Set("User-Agent", "DeliverTrack/1.0")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call geocoding API: %w", err)
+		return nil, fmt.Errorf("failed to call %s API: %w", s.provider.Name(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("geocoding API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("%s API returned status %d", s.provider.Name(), resp.StatusCode)
 	}
 
-	var results []NominatimResponse
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return nil, fmt.Errorf("failed to decode geocoding response: %w", err)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", s.provider.Name(), err)
 	}
 
-	if len(results) == 0 {
-		return nil, fmt.Errorf("no results found for address: %s", address)
+	return body, nil
+}
+
+// ForwardGeocode converts address to coordinates
+func (s *HTTPGeocodingService) ForwardGeocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address cannot be empty")
 	}
 
-	result := results[0]
+	cacheKey := "forward:" + normalizeAddress(address)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		result := *cached.(*GeocodeResult)
+		return &result, nil
+	}
 
-	// Parse latitude and longitude
-	lat, err := parseFloat(result.Lat)
+	req, err := s.provider.ForwardRequest(ctx, address)
 	if err != nil {
-		return nil, fmt.Errorf("invalid latitude in response: %s", result.Lat)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	lng, err := parseFloat(result.Lon)
+	s.logger.InfoWithFields(ctx, "Geocoding address",
+		zap.String("provider", s.provider.Name()), zap.String("address", address))
+
+	body, err := s.fetch(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("invalid longitude in response: %s", result.Lon)
+		return nil, fmt.Errorf("geocoding failed: %w", err)
 	}
 
-	// Extract address components
-	addr := result.Address
-	geocodeResult := &GeocodeResult{
-		Latitude:  lat,
-		Longitude: lng,
-		Address:   result.DisplayName,
-		City:      addr.City,
-		State:     addr.State,
-		Country:   addr.Country,
-		ZipCode:   addr.Postcode,
+	geocodeResult, err := s.provider.ParseForward(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to geocode address %s: %w", address, err)
 	}
 
 	s.logger.InfoWithFields(ctx, "Successfully geocoded address",
+		zap.String("provider", s.provider.Name()),
 		zap.String("address", address),
-		zap.Float64("lat", lat),
-		zap.Float64("lng", lng),
-		zap.String("display_name", result.DisplayName))
+		zap.Float64("lat", geocodeResult.Latitude),
+		zap.Float64("lng", geocodeResult.Longitude),
+		zap.String("display_name", geocodeResult.Address))
 
-	return geocodeResult, nil
-}
+	cached := *geocodeResult
+	s.cache.Set(cacheKey, &cached)
 
-// parseFloat safely parses a string to float64
-func parseFloat(s string) (float64, error) {
-	var f float64
-	_, err := fmt.Sscanf(s, "%f", &f)
-	return f, err
+	return geocodeResult, nil
 }
 
-// ReverseGeocode converts coordinates to address using Nominatim
+// ReverseGeocode converts coordinates to address
 func (s *HTTPGeocodingService) ReverseGeocode(ctx context.Context, lat, lng float64) (*ReverseGeocodeResult, error) {
-	// Build Nominatim reverse API URL
-	baseURL := "https://nominatim.openstreetmap.org/reverse"
-	params := url.Values{}
-	params.Add("format", "json")
-	params.Add("lat", fmt.Sprintf("%.6f", lat))
-	params.Add("lon", fmt.Sprintf("%.6f", lng))
-	params.Add("addressdetails", "1")
-
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
-
-	s.logger.InfoWithFields(ctx, "Reverse geocoding coordinates",
-		zap.Float64("lat", lat), zap.Float64("lng", lng), zap.String("url", fullURL))
+	cacheKey := "reverse:" + coordinateKey(lat, lng)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		result := *cached.(*ReverseGeocodeResult)
+		return &result, nil
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	req, err := s.provider.ReverseRequest(ctx, lat, lng)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "DeliverTrack/1.0")
+	s.logger.InfoWithFields(ctx, "Reverse geocoding coordinates",
+		zap.String("provider", s.provider.Name()), zap.Float64("lat", lat), zap.Float64("lng", lng))
 
-	resp, err := s.client.Do(req)
+	body, err := s.fetch(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call reverse geocoding API: %w", err)
+		return nil, fmt.Errorf("reverse geocoding failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("reverse geocoding API returned status %d", resp.StatusCode)
-	}
-
-	var result NominatimResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode reverse geocoding response: %w", err)
-	}
-
-	if result.DisplayName == "" {
-		return nil, fmt.Errorf("no address found for coordinates: %f, %f", lat, lng)
-	}
-
-	// Extract address components
-	addr := result.Address
-	reverseResult := &ReverseGeocodeResult{
-		Address: result.DisplayName,
-		City:    addr.City,
-		State:   addr.State,
-		Country: addr.Country,
-		ZipCode: addr.Postcode,
+	reverseResult, err := s.provider.ParseReverse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reverse geocode coordinates %f, %f: %w", lat, lng, err)
 	}
 
 	s.logger.InfoWithFields(ctx, "Successfully reverse geocoded coordinates",
-		zap.Float64("lat", lat), zap.Float64("lng", lng), zap.String("address", result.DisplayName))
+		zap.String("provider", s.provider.Name()),
+		zap.Float64("lat", lat), zap.Float64("lng", lng), zap.String("address", reverseResult.Address))
+
+	cached := *reverseResult
+	s.cache.Set(cacheKey, &cached)
 
 	return reverseResult, nil
 }
 
-// Autocomplete provides address suggestions using Nominatim search
+// Autocomplete provides address suggestions
 func (s *HTTPGeocodingService) Autocomplete(ctx context.Context, query string) ([]AutocompleteResult, error) {
-	if query == "" {
+	// Short queries match too broadly to be useful and would burn rate limit
+	if len([]rune(strings.TrimSpace(query))) < minAutocompleteLength {
 		return []AutocompleteResult{}, nil
 	}
 
-	// Build Nominatim search API URL for suggestions
-	baseURL := "https://nominatim.openstreetmap.org/search"
-	params := url.Values{}
-	params.Add("format", "json")
-	params.Add("q", query)
-	params.Add("limit", "5") // Get up to 5 suggestions
-	params.Add("addressdetails", "1")
-
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
-
-	s.logger.InfoWithFields(ctx, "Getting address suggestions",
-		zap.String("query", query), zap.String("url", fullURL))
+	cacheKey := "autocomplete:" + normalizeAddress(query)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		return append([]AutocompleteResult(nil), cached.([]AutocompleteResult)...), nil
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	req, err := s.provider.AutocompleteRequest(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "DeliverTrack/1.0")
+	s.logger.InfoWithFields(ctx, "Getting address suggestions",
+		zap.String("provider", s.provider.Name()), zap.String("query", query))
 
-	resp, err := s.client.Do(req)
+	body, err := s.fetch(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call autocomplete API: %w", err)
+		return nil, fmt.Errorf("autocomplete failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("autocomplete API returned status %d", resp.StatusCode)
-	}
-
-	var results []NominatimResponse
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return nil, fmt.Errorf("failed to decode autocomplete response: %w", err)
-	}
-
-	var suggestions []AutocompleteResult
-	for _, result := range results {
-		suggestions = append(suggestions, AutocompleteResult{
-			Text:        result.DisplayName,
-			Description: fmt.Sprintf("%s (%s)", result.Addresstype, result.Type),
-		})
+	suggestions, err := s.provider.ParseAutocomplete(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse suggestions: %w", err)
 	}
 
 	s.logger.InfoWithFields(ctx, "Got address suggestions",
+		zap.String("provider", s.provider.Name()),
 		zap.String("query", query), zap.Int("count", len(suggestions)))
 
+	s.cache.Set(cacheKey, append([]AutocompleteResult(nil), suggestions...))
+
 	return suggestions, nil
 }
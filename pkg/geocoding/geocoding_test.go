@@ -0,0 +1,248 @@
+package geocoding
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+// newNominatimServer returns a fake Nominatim server and a counter of requests it served
+func newNominatimServer(t *testing.T) (*httptest.Server, *int64) {
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("expected User-Agent header")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/search":
+			if r.URL.Query().Get("q") == "nowhere" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"lat":"40.7128","lon":"-74.0060","display_name":"New York, NY","type":"city","addresstype":"city","address":{"city":"New York","state":"NY","country":"USA","postcode":"10001"}}]`))
+		case "/reverse":
+			w.Write([]byte(`{"display_name":"123 Main St, New York, NY","address":{"city":"New York","state":"NY","country":"USA","postcode":"10001"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestService(t *testing.T, baseURL string, rps float64) *HTTPGeocodingService {
+	return NewHTTPGeocodingServiceWithConfig(config.GeocodingConfig{
+		BaseURL:           baseURL,
+		CacheSize:         100,
+		CacheTTL:          time.Hour,
+		RequestsPerSecond: rps,
+	}, &logger.Logger{Logger: zaptest.NewLogger(t)})
+}
+
+func TestForwardGeocode_CachesNormalizedAddress(t *testing.T) {
+	server, calls := newNominatimServer(t)
+	svc := newTestService(t, server.URL, 1000)
+
+	first, err := svc.ForwardGeocode(context.Background(), "New York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Latitude != 40.7128 || first.Longitude != -74.0060 {
+		t.Errorf("unexpected coordinates: %+v", first)
+	}
+
+	second, err := svc.ForwardGeocode(context.Background(), "  new   YORK ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *second != *first {
+		t.Errorf("expected cached result %+v, got %+v", first, second)
+	}
+
+	if got := atomic.LoadInt64(calls); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+
+	stats := svc.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Size != 1 {
+		t.Errorf("unexpected cache stats: %+v", stats)
+	}
+}
+
+func TestForwardGeocode_DoesNotCacheErrors(t *testing.T) {
+	server, calls := newNominatimServer(t)
+	svc := newTestService(t, server.URL, 1000)
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.ForwardGeocode(context.Background(), "nowhere"); err == nil {
+			t.Fatal("expected error for address without results")
+		}
+	}
+
+	if got := atomic.LoadInt64(calls); got != 2 {
+		t.Errorf("expected failed lookups to hit upstream each time, got %d calls", got)
+	}
+}
+
+func TestReverseGeocode_CachesRoundedCoordinates(t *testing.T) {
+	server, calls := newNominatimServer(t)
+	svc := newTestService(t, server.URL, 1000)
+
+	if _, err := svc.ReverseGeocode(context.Background(), 40.712801, -74.006001); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := svc.ReverseGeocode(context.Background(), 40.712812, -74.006012)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.City != "New York" {
+		t.Errorf("expected city New York, got %s", result.City)
+	}
+
+	if got := atomic.LoadInt64(calls); got != 1 {
+		t.Errorf("expected nearby coordinates to share a cache entry, got %d calls", got)
+	}
+
+	// A different location misses the cache
+	if _, err := svc.ReverseGeocode(context.Background(), 41.0, -74.0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt64(calls); got != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", got)
+	}
+}
+
+func TestAutocomplete_ShortQueryShortCircuits(t *testing.T) {
+	server, calls := newNominatimServer(t)
+	svc := newTestService(t, server.URL, 1000)
+
+	for _, query := range []string{"", "n", "ny", "  ny  "} {
+		results, err := svc.Autocomplete(context.Background(), query)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", query, err)
+		}
+		if len(results) != 0 {
+			t.Errorf("expected no results for %q, got %d", query, len(results))
+		}
+	}
+
+	if got := atomic.LoadInt64(calls); got != 0 {
+		t.Errorf("expected no upstream calls, got %d", got)
+	}
+
+	results, err := svc.Autocomplete(context.Background(), "new")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 suggestion, got %d", len(results))
+	}
+	if got := atomic.LoadInt64(calls); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+}
+
+func TestHTTPGeocodingService_RateLimitsUpstream(t *testing.T) {
+	server, calls := newNominatimServer(t)
+	svc := newTestService(t, server.URL, 10) // one request every 100ms
+
+	start := time.Now()
+	for _, address := range []string{"address one", "address two", "address three"} {
+		if _, err := svc.ForwardGeocode(context.Background(), address); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	if got := atomic.LoadInt64(calls); got != 3 {
+		t.Errorf("expected 3 upstream calls, got %d", got)
+	}
+	// First request is immediate, the next two wait ~100ms each
+	if elapsed < 180*time.Millisecond {
+		t.Errorf("expected requests to be spaced by the limiter, took %v", elapsed)
+	}
+}
+
+func TestHTTPGeocodingService_RateLimitRespectsContext(t *testing.T) {
+	server, _ := newNominatimServer(t)
+	svc := newTestService(t, server.URL, 0.01)
+
+	// Consume the only token
+	if _, err := svc.ForwardGeocode(context.Background(), "first"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := svc.ForwardGeocode(ctx, "second"); err == nil {
+		t.Error("expected error when context expires while waiting for the limiter")
+	}
+}
+
+func TestHTTPGeocodingService_RetriesUnavailableUpstream(t *testing.T) {
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"lat":"40.7128","lon":"-74.0060","display_name":"New York, NY"}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc := newTestService(t, server.URL, 1000)
+	result, err := svc.ForwardGeocode(context.Background(), "New York")
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if result.Latitude != 40.7128 || atomic.LoadInt64(&calls) != 2 {
+		t.Errorf("expected a result after 2 calls, got %+v after %d", result, calls)
+	}
+
+	// Retries can be turned off
+	atomic.StoreInt64(&calls, 0)
+	svc = NewHTTPGeocodingServiceWithConfig(config.GeocodingConfig{BaseURL: server.URL, MaxRetries: -1, RequestsPerSecond: 1000},
+		&logger.Logger{Logger: zaptest.NewLogger(t)})
+	if _, err := svc.ForwardGeocode(context.Background(), "New York"); err == nil {
+		t.Error("expected the 503 to fail without retries")
+	}
+}
+
+func TestLRUCache_EvictionAndTTL(t *testing.T) {
+	cache := newLRUCache(2, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a") // a is now most recently used
+	cache.Set("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if v, ok := cache.Get("a"); !ok || v.(int) != 1 {
+		t.Error("expected a to remain cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get("c"); ok {
+		t.Error("expected entry to expire after TTL")
+	}
+	if cache.Stats().Size != 1 {
+		t.Errorf("expected expired entry to be removed, size %d", cache.Stats().Size)
+	}
+}
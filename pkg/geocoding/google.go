@@ -0,0 +1,207 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultGoogleURL is the Google Maps Platform endpoint
+const defaultGoogleURL = "https://maps.googleapis.com"
+
+// Google API status values
+const (
+	googleStatusOK          = "OK"
+	googleStatusZeroResults = "ZERO_RESULTS"
+)
+
+// googleGeocodeResponse represents a Google Geocoding API response
+type googleGeocodeResponse struct {
+	Status       string         `json:"status"`
+	ErrorMessage string         `json:"error_message"`
+	Results      []googleResult `json:"results"`
+}
+
+// googleResult is a single geocoding result
+type googleResult struct {
+	FormattedAddress  string                   `json:"formatted_address"`
+	AddressComponents []googleAddressComponent `json:"address_components"`
+	Geometry          struct {
+		Location struct {
+			Lat float64 `json:"lat"`
+			Lng float64 `json:"lng"`
+		} `json:"location"`
+	} `json:"geometry"`
+}
+
+// googleAddressComponent is a typed piece of a formatted address
+type googleAddressComponent struct {
+	LongName  string   `json:"long_name"`
+	ShortName string   `json:"short_name"`
+	Types     []string `json:"types"`
+}
+
+// googleAutocompleteResponse represents a Places Autocomplete API response
+type googleAutocompleteResponse struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	Predictions  []struct {
+		Description          string `json:"description"`
+		StructuredFormatting struct {
+			MainText      string `json:"main_text"`
+			SecondaryText string `json:"secondary_text"`
+		} `json:"structured_formatting"`
+	} `json:"predictions"`
+}
+
+// GoogleProvider talks to the Google Geocoding and Places APIs
+type GoogleProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewGoogleProvider creates a Google provider; an empty baseURL uses the public endpoint
+func NewGoogleProvider(baseURL, apiKey string) *GoogleProvider {
+	if baseURL == "" {
+		baseURL = defaultGoogleURL
+	}
+	return &GoogleProvider{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey}
+}
+
+// Name returns the provider name
+func (p *GoogleProvider) Name() string {
+	return "google"
+}
+
+// DefaultRequestsPerSecond matches the Google Geocoding API default quota
+func (p *GoogleProvider) DefaultRequestsPerSecond() float64 {
+	return 50
+}
+
+// request builds a request against a Google Maps API path
+func (p *GoogleProvider) request(ctx context.Context, path string, params url.Values) (*http.Request, error) {
+	params.Set("key", p.apiKey)
+	return http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s?%s", p.baseURL, path, params.Encode()), nil)
+}
+
+// ForwardRequest builds a Google geocoding request
+func (p *GoogleProvider) ForwardRequest(ctx context.Context, address string) (*http.Request, error) {
+	return p.request(ctx, "/maps/api/geocode/json", url.Values{"address": {address}})
+}
+
+// ParseForward parses a Google geocoding response
+func (p *GoogleProvider) ParseForward(body []byte) (*GeocodeResult, error) {
+	result, err := parseGoogleResult(body)
+	if err != nil {
+		return nil, err
+	}
+
+	city, state, country, zip := result.components()
+	return &GeocodeResult{
+		Latitude:  result.Geometry.Location.Lat,
+		Longitude: result.Geometry.Location.Lng,
+		Address:   result.FormattedAddress,
+		City:      city,
+		State:     state,
+		Country:   country,
+		ZipCode:   zip,
+	}, nil
+}
+
+// ReverseRequest builds a Google reverse geocoding request
+func (p *GoogleProvider) ReverseRequest(ctx context.Context, lat, lng float64) (*http.Request, error) {
+	return p.request(ctx, "/maps/api/geocode/json", url.Values{"latlng": {fmt.Sprintf("%.6f,%.6f", lat, lng)}})
+}
+
+// ParseReverse parses a Google reverse geocoding response
+func (p *GoogleProvider) ParseReverse(body []byte) (*ReverseGeocodeResult, error) {
+	result, err := parseGoogleResult(body)
+	if err != nil {
+		return nil, err
+	}
+
+	city, state, country, zip := result.components()
+	return &ReverseGeocodeResult{
+		Address: result.FormattedAddress,
+		City:    city,
+		State:   state,
+		Country: country,
+		ZipCode: zip,
+	}, nil
+}
+
+// AutocompleteRequest builds a Places Autocomplete request
+func (p *GoogleProvider) AutocompleteRequest(ctx context.Context, query string) (*http.Request, error) {
+	return p.request(ctx, "/maps/api/place/autocomplete/json", url.Values{"input": {query}, "types": {"address"}})
+}
+
+// ParseAutocomplete parses Places Autocomplete predictions into suggestions
+func (p *GoogleProvider) ParseAutocomplete(body []byte) ([]AutocompleteResult, error) {
+	var resp googleAutocompleteResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.Status != googleStatusOK && resp.Status != googleStatusZeroResults {
+		return nil, googleStatusError(resp.Status, resp.ErrorMessage)
+	}
+
+	suggestions := []AutocompleteResult{}
+	for _, prediction := range resp.Predictions {
+		suggestions = append(suggestions, AutocompleteResult{
+			Text:        prediction.Description,
+			Description: prediction.StructuredFormatting.SecondaryText,
+		})
+	}
+	return suggestions, nil
+}
+
+// parseGoogleResult decodes a geocoding response and returns its best match
+func parseGoogleResult(body []byte) (*googleResult, error) {
+	var resp googleGeocodeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	switch resp.Status {
+	case googleStatusOK:
+	case googleStatusZeroResults:
+		return nil, ErrNoResults
+	default:
+		return nil, googleStatusError(resp.Status, resp.ErrorMessage)
+	}
+
+	if len(resp.Results) == 0 {
+		return nil, ErrNoResults
+	}
+	return &resp.Results[0], nil
+}
+
+// googleStatusError reports a non-OK API status such as REQUEST_DENIED
+func googleStatusError(status, message string) error {
+	if message != "" {
+		return fmt.Errorf("google API returned status %s: %s", status, message)
+	}
+	return fmt.Errorf("google API returned status %s", status)
+}
+
+// components extracts city, state, country and postcode from address components
+func (r *googleResult) components() (city, state, country, zip string) {
+	for _, c := range r.AddressComponents {
+		for _, t := range c.Types {
+			switch t {
+			case "locality":
+				city = c.LongName
+			case "administrative_area_level_1":
+				state = c.ShortName
+			case "country":
+				country = c.LongName
+			case "postal_code":
+				zip = c.LongName
+			}
+		}
+	}
+	return city, state, country, zip
+}
@@ -0,0 +1,165 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultMapboxURL is the Mapbox API endpoint
+const defaultMapboxURL = "https://api.mapbox.com"
+
+// mapboxResponse represents a Mapbox Geocoding API v5 response
+type mapboxResponse struct {
+	Features []mapboxFeature `json:"features"`
+}
+
+// mapboxFeature is a single place in a Mapbox response
+type mapboxFeature struct {
+	ID        string          `json:"id"`
+	PlaceType []string        `json:"place_type"`
+	Text      string          `json:"text"`
+	PlaceName string          `json:"place_name"`
+	Center    []float64       `json:"center"` // [lng, lat]
+	Context   []mapboxContext `json:"context"`
+}
+
+// mapboxContext is a parent region of a feature, e.g. "place.123" or "postcode.456"
+type mapboxContext struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// MapboxProvider talks to the Mapbox Geocoding API
+type MapboxProvider struct {
+	baseURL     string
+	accessToken string
+}
+
+// NewMapboxProvider creates a Mapbox provider; an empty baseURL uses the public endpoint
+func NewMapboxProvider(baseURL, accessToken string) *MapboxProvider {
+	if baseURL == "" {
+		baseURL = defaultMapboxURL
+	}
+	return &MapboxProvider{baseURL: strings.TrimRight(baseURL, "/"), accessToken: accessToken}
+}
+
+// Name returns the provider name
+func (p *MapboxProvider) Name() string {
+	return "mapbox"
+}
+
+// DefaultRequestsPerSecond stays well below the Mapbox per-minute quota
+func (p *MapboxProvider) DefaultRequestsPerSecond() float64 {
+	return 10
+}
+
+// placesRequest builds a request against the mapbox.places endpoint
+func (p *MapboxProvider) placesRequest(ctx context.Context, query string, params url.Values) (*http.Request, error) {
+	params.Set("access_token", p.accessToken)
+	fullURL := fmt.Sprintf("%s/geocoding/v5/mapbox.places/%s.json?%s", p.baseURL, url.PathEscape(query), params.Encode())
+	return http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+}
+
+// ForwardRequest builds a Mapbox forward geocoding request
+func (p *MapboxProvider) ForwardRequest(ctx context.Context, address string) (*http.Request, error) {
+	return p.placesRequest(ctx, address, url.Values{"limit": {"1"}})
+}
+
+// ParseForward parses a Mapbox forward geocoding response
+func (p *MapboxProvider) ParseForward(body []byte) (*GeocodeResult, error) {
+	feature, err := parseMapboxFeature(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(feature.Center) != 2 {
+		return nil, fmt.Errorf("invalid center in response: %v", feature.Center)
+	}
+
+	city, state, country, zip := feature.components()
+	return &GeocodeResult{
+		Latitude:  feature.Center[1],
+		Longitude: feature.Center[0],
+		Address:   feature.PlaceName,
+		City:      city,
+		State:     state,
+		Country:   country,
+		ZipCode:   zip,
+	}, nil
+}
+
+// ReverseRequest builds a Mapbox reverse geocoding request
+func (p *MapboxProvider) ReverseRequest(ctx context.Context, lat, lng float64) (*http.Request, error) {
+	return p.placesRequest(ctx, fmt.Sprintf("%.6f,%.6f", lng, lat), url.Values{"limit": {"1"}})
+}
+
+// ParseReverse parses a Mapbox reverse geocoding response
+func (p *MapboxProvider) ParseReverse(body []byte) (*ReverseGeocodeResult, error) {
+	feature, err := parseMapboxFeature(body)
+	if err != nil {
+		return nil, err
+	}
+
+	city, state, country, zip := feature.components()
+	return &ReverseGeocodeResult{
+		Address: feature.PlaceName,
+		City:    city,
+		State:   state,
+		Country: country,
+		ZipCode: zip,
+	}, nil
+}
+
+// AutocompleteRequest builds a Mapbox autocomplete request
+func (p *MapboxProvider) AutocompleteRequest(ctx context.Context, query string) (*http.Request, error) {
+	return p.placesRequest(ctx, query, url.Values{"autocomplete": {"true"}, "limit": {"5"}})
+}
+
+// ParseAutocomplete parses Mapbox features into suggestions
+func (p *MapboxProvider) ParseAutocomplete(body []byte) ([]AutocompleteResult, error) {
+	var resp mapboxResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	suggestions := []AutocompleteResult{}
+	for _, feature := range resp.Features {
+		suggestions = append(suggestions, AutocompleteResult{
+			Text:        feature.PlaceName,
+			Description: strings.Join(feature.PlaceType, ", "),
+		})
+	}
+	return suggestions, nil
+}
+
+// parseMapboxFeature decodes a response and returns its best match
+func parseMapboxFeature(body []byte) (*mapboxFeature, error) {
+	var resp mapboxResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(resp.Features) == 0 || resp.Features[0].PlaceName == "" {
+		return nil, ErrNoResults
+	}
+	return &resp.Features[0], nil
+}
+
+// components extracts city, state, country and postcode from the feature context
+func (f *mapboxFeature) components() (city, state, country, zip string) {
+	for _, c := range f.Context {
+		switch strings.SplitN(c.ID, ".", 2)[0] {
+		case "place":
+			city = c.Text
+		case "region":
+			state = c.Text
+		case "country":
+			country = c.Text
+		case "postcode":
+			zip = c.Text
+		}
+	}
+	return city, state, country, zip
+}
@@ -0,0 +1,181 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultNominatimURL is the public OpenStreetMap Nominatim endpoint
+const defaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+// NominatimResponse represents the response from Nominatim API
+type NominatimResponse struct {
+	PlaceID     int              `json:"place_id"`
+	License     string           `json:"licence"`
+	OSMType     string           `json:"osm_type"`
+	OSMID       int              `json:"osm_id"`
+	Lat         string           `json:"lat"`
+	Lon         string           `json:"lon"`
+	Class       string           `json:"class"`
+	Type        string           `json:"type"`
+	PlaceRank   int              `json:"place_rank"`
+	Importance  float64          `json:"importance"`
+	Addresstype string           `json:"addresstype"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"display_name"`
+	Address     NominatimAddress `json:"address"`
+	Boundingbox []string         `json:"boundingbox"`
+}
+
+// NominatimAddress represents the address part of Nominatim response
+type NominatimAddress struct {
+	HouseNumber string `json:"house_number"`
+	Road        string `json:"road"`
+	Suburb      string `json:"suburb"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	Postcode    string `json:"postcode"`
+	Country     string `json:"country"`
+	CountryCode string `json:"country_code"`
+}
+
+// NominatimProvider talks to OpenStreetMap Nominatim
+type NominatimProvider struct {
+	baseURL string
+}
+
+// NewNominatimProvider creates a Nominatim provider; an empty baseURL uses the public endpoint
+func NewNominatimProvider(baseURL string) *NominatimProvider {
+	if baseURL == "" {
+		baseURL = defaultNominatimURL
+	}
+	return &NominatimProvider{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Name returns the provider name
+func (p *NominatimProvider) Name() string {
+	return "nominatim"
+}
+
+// DefaultRequestsPerSecond follows the Nominatim usage policy of one request per second
+func (p *NominatimProvider) DefaultRequestsPerSecond() float64 {
+	return 1
+}
+
+// ForwardRequest builds a Nominatim search request
+func (p *NominatimProvider) ForwardRequest(ctx context.Context, address string) (*http.Request, error) {
+	params := url.Values{}
+	params.Add("format", "json")
+	params.Add("q", address)
+	params.Add("limit", "1")
+	params.Add("addressdetails", "1")
+
+	return http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/search?%s", p.baseURL, params.Encode()), nil)
+}
+
+// ParseForward parses a Nominatim search response
+func (p *NominatimProvider) ParseForward(body []byte) (*GeocodeResult, error) {
+	var results []NominatimResponse
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+
+	result := results[0]
+
+	// Parse latitude and longitude
+	lat, err := parseFloat(result.Lat)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude in response: %s", result.Lat)
+	}
+
+	lng, err := parseFloat(result.Lon)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude in response: %s", result.Lon)
+	}
+
+	addr := result.Address
+	return &GeocodeResult{
+		Latitude:  lat,
+		Longitude: lng,
+		Address:   result.DisplayName,
+		City:      addr.City,
+		State:     addr.State,
+		Country:   addr.Country,
+		ZipCode:   addr.Postcode,
+	}, nil
+}
+
+// ReverseRequest builds a Nominatim reverse request
+func (p *NominatimProvider) ReverseRequest(ctx context.Context, lat, lng float64) (*http.Request, error) {
+	params := url.Values{}
+	params.Add("format", "json")
+	params.Add("lat", fmt.Sprintf("%.6f", lat))
+	params.Add("lon", fmt.Sprintf("%.6f", lng))
+	params.Add("addressdetails", "1")
+
+	return http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/reverse?%s", p.baseURL, params.Encode()), nil)
+}
+
+// ParseReverse parses a Nominatim reverse response
+func (p *NominatimProvider) ParseReverse(body []byte) (*ReverseGeocodeResult, error) {
+	var result NominatimResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if result.DisplayName == "" {
+		return nil, ErrNoResults
+	}
+
+	addr := result.Address
+	return &ReverseGeocodeResult{
+		Address: result.DisplayName,
+		City:    addr.City,
+		State:   addr.State,
+		Country: addr.Country,
+		ZipCode: addr.Postcode,
+	}, nil
+}
+
+// AutocompleteRequest builds a Nominatim search request for suggestions
+func (p *NominatimProvider) AutocompleteRequest(ctx context.Context, query string) (*http.Request, error) {
+	params := url.Values{}
+	params.Add("format", "json")
+	params.Add("q", query)
+	params.Add("limit", "5") // Get up to 5 suggestions
+	params.Add("addressdetails", "1")
+
+	return http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/search?%s", p.baseURL, params.Encode()), nil)
+}
+
+// ParseAutocomplete parses Nominatim search results into suggestions
+func (p *NominatimProvider) ParseAutocomplete(body []byte) ([]AutocompleteResult, error) {
+	var results []NominatimResponse
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	suggestions := []AutocompleteResult{}
+	for _, result := range results {
+		suggestions = append(suggestions, AutocompleteResult{
+			Text:        result.DisplayName,
+			Description: fmt.Sprintf("%s (%s)", result.Addresstype, result.Type),
+		})
+	}
+	return suggestions, nil
+}
+
+// parseFloat safely parses a string to float64
+func parseFloat(s string) (float64, error) {
+	var f float64
+	_, err := fmt.Sscanf(s, "%f", &f)
+	return f, err
+}
@@ -0,0 +1,271 @@
+package geocoding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+func newProviderTestService(t *testing.T, provider Provider) *HTTPGeocodingService {
+	return NewProviderGeocodingService(provider, config.GeocodingConfig{
+		CacheSize:         100,
+		CacheTTL:          time.Hour,
+		RequestsPerSecond: 1000,
+	}, &logger.Logger{Logger: zaptest.NewLogger(t)})
+}
+
+func TestMapboxProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") != "mb-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/geocoding/v5/mapbox.places/New York.json":
+			if r.URL.Query().Get("autocomplete") == "true" {
+				w.Write([]byte(`{"features":[{"place_name":"New York, New York, United States","place_type":["place"]},{"place_name":"New York Mills, Minnesota, United States","place_type":["place"]}]}`))
+				return
+			}
+			w.Write([]byte(`{"features":[{"place_name":"New York, New York, United States","center":[-74.006,40.7128],"context":[{"id":"postcode.1","text":"10001"},{"id":"region.2","text":"New York"},{"id":"country.3","text":"United States"}]}]}`))
+		case "/geocoding/v5/mapbox.places/-74.006000,40.712800.json":
+			w.Write([]byte(`{"features":[{"place_name":"123 Main St, New York, New York 10001, United States","context":[{"id":"place.1","text":"New York"},{"id":"postcode.2","text":"10001"}]}]}`))
+		default:
+			w.Write([]byte(`{"features":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	svc := newProviderTestService(t, NewMapboxProvider(server.URL, "mb-token"))
+	ctx := context.Background()
+
+	forward, err := svc.ForwardGeocode(ctx, "New York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if forward.Latitude != 40.7128 || forward.Longitude != -74.006 {
+		t.Errorf("expected [lng,lat] center to be swapped, got %+v", forward)
+	}
+	if forward.ZipCode != "10001" || forward.State != "New York" || forward.Country != "United States" {
+		t.Errorf("unexpected address components: %+v", forward)
+	}
+
+	reverse, err := svc.ReverseGeocode(ctx, 40.7128, -74.006)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reverse.City != "New York" || reverse.ZipCode != "10001" {
+		t.Errorf("unexpected reverse result: %+v", reverse)
+	}
+
+	suggestions, err := svc.Autocomplete(ctx, "New York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(suggestions) != 2 {
+		t.Errorf("expected 2 suggestions, got %d", len(suggestions))
+	}
+
+	if _, err := svc.ForwardGeocode(ctx, "nowhere"); !errors.Is(err, ErrNoResults) {
+		t.Errorf("expected ErrNoResults, got %v", err)
+	}
+}
+
+func TestGoogleProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		if q.Get("key") != "g-key" {
+			w.Write([]byte(`{"status":"REQUEST_DENIED","error_message":"The provided API key is invalid."}`))
+			return
+		}
+		switch {
+		case r.URL.Path == "/maps/api/geocode/json" && q.Get("address") == "New York":
+			w.Write([]byte(`{"status":"OK","results":[{"formatted_address":"New York, NY 10001, USA","geometry":{"location":{"lat":40.7128,"lng":-74.006}},"address_components":[{"long_name":"New York","short_name":"New York","types":["locality","political"]},{"long_name":"New York","short_name":"NY","types":["administrative_area_level_1","political"]},{"long_name":"United States","short_name":"US","types":["country","political"]},{"long_name":"10001","short_name":"10001","types":["postal_code"]}]}]}`))
+		case r.URL.Path == "/maps/api/geocode/json" && q.Get("latlng") == "40.712800,-74.006000":
+			w.Write([]byte(`{"status":"OK","results":[{"formatted_address":"123 Main St, New York, NY 10001, USA","address_components":[{"long_name":"New York","types":["locality"]}]}]}`))
+		case r.URL.Path == "/maps/api/place/autocomplete/json":
+			w.Write([]byte(`{"status":"OK","predictions":[{"description":"123 Main St, New York, NY, USA","structured_formatting":{"main_text":"123 Main St","secondary_text":"New York, NY, USA"}}]}`))
+		default:
+			w.Write([]byte(`{"status":"ZERO_RESULTS","results":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	svc := newProviderTestService(t, NewGoogleProvider(server.URL, "g-key"))
+	ctx := context.Background()
+
+	forward, err := svc.ForwardGeocode(ctx, "New York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if forward.Latitude != 40.7128 || forward.City != "New York" || forward.State != "NY" || forward.ZipCode != "10001" {
+		t.Errorf("unexpected forward result: %+v", forward)
+	}
+
+	reverse, err := svc.ReverseGeocode(ctx, 40.7128, -74.006)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reverse.Address != "123 Main St, New York, NY 10001, USA" {
+		t.Errorf("unexpected reverse result: %+v", reverse)
+	}
+
+	suggestions, err := svc.Autocomplete(ctx, "123 Main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Description != "New York, NY, USA" {
+		t.Errorf("unexpected suggestions: %+v", suggestions)
+	}
+
+	if _, err := svc.ForwardGeocode(ctx, "nowhere"); !errors.Is(err, ErrNoResults) {
+		t.Errorf("expected ErrNoResults for ZERO_RESULTS, got %v", err)
+	}
+
+	denied := newProviderTestService(t, NewGoogleProvider(server.URL, "wrong"))
+	if _, err := denied.ForwardGeocode(ctx, "New York"); err == nil || errors.Is(err, ErrNoResults) {
+		t.Errorf("expected REQUEST_DENIED error, got %v", err)
+	}
+}
+
+// stubGeocodingService returns canned results and counts calls
+type stubGeocodingService struct {
+	forward     *GeocodeResult
+	reverse     *ReverseGeocodeResult
+	suggestions []AutocompleteResult
+	err         error
+	calls       int
+}
+
+func (s *stubGeocodingService) ForwardGeocode(ctx context.Context, address string) (*GeocodeResult, error) {
+	s.calls++
+	return s.forward, s.err
+}
+
+func (s *stubGeocodingService) ReverseGeocode(ctx context.Context, lat, lng float64) (*ReverseGeocodeResult, error) {
+	s.calls++
+	return s.reverse, s.err
+}
+
+func (s *stubGeocodingService) Autocomplete(ctx context.Context, query string) ([]AutocompleteResult, error) {
+	s.calls++
+	return s.suggestions, s.err
+}
+
+func TestFallbackGeocodingService(t *testing.T) {
+	lg := &logger.Logger{Logger: zaptest.NewLogger(t)}
+	ctx := context.Background()
+
+	t.Run("primary success skips secondary", func(t *testing.T) {
+		primary := &stubGeocodingService{forward: &GeocodeResult{Address: "primary"}}
+		secondary := &stubGeocodingService{forward: &GeocodeResult{Address: "secondary"}}
+		svc := NewFallbackGeocodingService(primary, secondary, lg)
+
+		result, err := svc.ForwardGeocode(ctx, "addr")
+		if err != nil || result.Address != "primary" {
+			t.Fatalf("expected primary result, got %+v, %v", result, err)
+		}
+		if secondary.calls != 0 {
+			t.Errorf("expected secondary not to be called, got %d calls", secondary.calls)
+		}
+	})
+
+	t.Run("primary error falls back", func(t *testing.T) {
+		primary := &stubGeocodingService{err: errors.New("quota exceeded")}
+		secondary := &stubGeocodingService{reverse: &ReverseGeocodeResult{Address: "secondary"}}
+		svc := NewFallbackGeocodingService(primary, secondary, lg)
+
+		result, err := svc.ReverseGeocode(ctx, 1, 2)
+		if err != nil || result.Address != "secondary" {
+			t.Fatalf("expected secondary result, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("empty suggestions do not fall back", func(t *testing.T) {
+		primary := &stubGeocodingService{suggestions: []AutocompleteResult{}}
+		secondary := &stubGeocodingService{suggestions: []AutocompleteResult{{Text: "secondary"}}}
+		svc := NewFallbackGeocodingService(primary, secondary, lg)
+
+		results, err := svc.Autocomplete(ctx, "ab")
+		if err != nil || len(results) != 0 {
+			t.Fatalf("expected no suggestions, got %+v, %v", results, err)
+		}
+		if secondary.calls != 0 {
+			t.Errorf("expected secondary not to be called, got %d calls", secondary.calls)
+		}
+	})
+
+	t.Run("autocomplete error falls back", func(t *testing.T) {
+		primary := &stubGeocodingService{err: errors.New("quota exceeded")}
+		secondary := &stubGeocodingService{suggestions: []AutocompleteResult{{Text: "secondary"}}}
+		svc := NewFallbackGeocodingService(primary, secondary, lg)
+
+		results, err := svc.Autocomplete(ctx, "query")
+		if err != nil || len(results) != 1 {
+			t.Fatalf("expected secondary suggestions, got %+v, %v", results, err)
+		}
+	})
+
+	t.Run("both fail returns secondary error", func(t *testing.T) {
+		secondaryErr := errors.New("secondary down")
+		svc := NewFallbackGeocodingService(
+			&stubGeocodingService{err: errors.New("primary down")},
+			&stubGeocodingService{err: secondaryErr}, lg)
+
+		if _, err := svc.ForwardGeocode(ctx, "addr"); !errors.Is(err, secondaryErr) {
+			t.Errorf("expected secondary error, got %v", err)
+		}
+	})
+}
+
+func TestNewGeocodingService(t *testing.T) {
+	lg := &logger.Logger{Logger: zaptest.NewLogger(t)}
+
+	tests := []struct {
+		name    string
+		cfg     config.GeocodingConfig
+		wantErr bool
+		want    string
+	}{
+		{"default is nominatim", config.GeocodingConfig{}, false, "nominatim"},
+		{"mapbox", config.GeocodingConfig{Provider: "mapbox", MapboxToken: "t"}, false, "mapbox"},
+		{"google", config.GeocodingConfig{Provider: "Google", GoogleAPIKey: "k"}, false, "google"},
+		{"mapbox without token", config.GeocodingConfig{Provider: "mapbox"}, true, ""},
+		{"unknown provider", config.GeocodingConfig{Provider: "bing"}, true, ""},
+		{"with fallback", config.GeocodingConfig{Provider: "google", GoogleAPIKey: "k", FallbackProvider: "nominatim"}, false, "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, err := NewGeocodingService(tt.cfg, lg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			switch s := svc.(type) {
+			case *FallbackGeocodingService:
+				if tt.want != "fallback" {
+					t.Errorf("expected %s provider, got fallback", tt.want)
+				}
+			case *HTTPGeocodingService:
+				if s.provider.Name() != tt.want {
+					t.Errorf("expected %s provider, got %s", tt.want, s.provider.Name())
+				}
+			}
+		})
+	}
+}
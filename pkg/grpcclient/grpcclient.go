@@ -0,0 +1,166 @@
+// Package grpcclient builds gRPC client connections between services from
+// configuration, with default deadlines, retries for idempotent reads,
+// optional TLS, keepalive and a startup connectivity check.
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// New creates a client connection to the named service at target. Unless
+// cfg.ConnectTimeout is zero it waits for the connection to become ready and
+// fails with an error naming the service otherwise.
+func New(ctx context.Context, name, target string, cfg config.GRPCConfig, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if strings.TrimSpace(target) == "" {
+		return nil, fmt.Errorf("%s service address is not configured", name)
+	}
+
+	creds, err := transportCredentials(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("%s service TLS: %w", name, err)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(
+			TimeoutUnaryClientInterceptor(cfg.Timeout),
+			RetryUnaryClientInterceptor(cfg),
+			grpcinterceptors.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(grpcinterceptors.StreamClientInterceptor()),
+	}
+	if cfg.KeepaliveTime > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}))
+	}
+	dialOpts = append(dialOpts, opts...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s service address %q: %w", name, target, err)
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		if err := waitForReady(ctx, conn, cfg.ConnectTimeout); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s service at %q is not reachable: %w", name, target, err)
+		}
+	}
+	return conn, nil
+}
+
+// waitForReady connects conn and blocks until it is ready or timeout elapses
+func waitForReady(ctx context.Context, conn *grpc.ClientConn, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("no connection after %s (last state %s)", timeout, state)
+		}
+	}
+}
+
+// transportCredentials returns TLS credentials when enabled, insecure otherwise
+func transportCredentials(cfg config.GRPCTLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// TimeoutUnaryClientInterceptor applies timeout to calls made without a deadline
+func TimeoutUnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// RetryUnaryClientInterceptor retries idempotent calls that fail with
+// Unavailable, backing off exponentially between attempts. Retries stop when
+// the call's deadline expires.
+func RetryUnaryClientInterceptor(cfg config.GRPCConfig) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cfg.MaxRetries <= 0 || !isIdempotent(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		delay := cfg.RetryBackoff
+		for attempt := 0; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt == cfg.MaxRetries || status.Code(err) != codes.Unavailable {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+
+			delay *= 2
+			if cfg.MaxRetryBackoff > 0 && delay > cfg.MaxRetryBackoff {
+				delay = cfg.MaxRetryBackoff
+			}
+		}
+	}
+}
+
+// isIdempotent reports whether a full method name is a read that is safe to retry
+func isIdempotent(method string) bool {
+	name := method[strings.LastIndex(method, "/")+1:]
+	return strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List")
+}
@@ -0,0 +1,137 @@
+package grpcclient_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testConfig() config.GRPCConfig {
+	return config.GRPCConfig{
+		Timeout:         time.Second,
+		MaxRetries:      2,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: 5 * time.Millisecond,
+		ConnectTimeout:  time.Second,
+	}
+}
+
+func TestRetryUnaryClientInterceptor(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		err          error
+		wantAttempts int
+	}{
+		{"retries idempotent read", "/delivertrack.delivery.DeliveryService/GetDelivery", status.Error(codes.Unavailable, "down"), 3},
+		{"retries list", "/delivertrack.delivery.DeliveryService/ListDeliveries", status.Error(codes.Unavailable, "down"), 3},
+		{"does not retry writes", "/delivertrack.delivery.DeliveryService/CreateDelivery", status.Error(codes.Unavailable, "down"), 1},
+		{"does not retry other codes", "/delivertrack.delivery.DeliveryService/GetDelivery", status.Error(codes.NotFound, "missing"), 1},
+	}
+
+	interceptor := grpcclient.RetryUnaryClientInterceptor(testConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				attempts++
+				return tt.err
+			}
+
+			err := interceptor(context.Background(), tt.method, nil, nil, nil, invoker)
+			if status.Code(err) != status.Code(tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryUnaryClientInterceptor_SucceedsAfterRetry(t *testing.T) {
+	attempts := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		if attempts == 1 {
+			return status.Error(codes.Unavailable, "down")
+		}
+		return nil
+	}
+
+	interceptor := grpcclient.RetryUnaryClientInterceptor(testConfig())
+	if err := interceptor(context.Background(), "/svc/GetThing", nil, nil, nil, invoker); err != nil {
+		t.Errorf("expected success, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestTimeoutUnaryClientInterceptor(t *testing.T) {
+	interceptor := grpcclient.TimeoutUnaryClientInterceptor(time.Second)
+
+	var deadline time.Time
+	var hasDeadline bool
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, hasDeadline = ctx.Deadline()
+		return nil
+	}
+
+	interceptor(context.Background(), "/svc/GetThing", nil, nil, nil, invoker)
+	if !hasDeadline || time.Until(deadline) > time.Second {
+		t.Errorf("expected default deadline, got %v (set %v)", deadline, hasDeadline)
+	}
+
+	// A caller's own deadline is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	interceptor(ctx, "/svc/GetThing", nil, nil, nil, invoker)
+	if !deadline.Equal(want) {
+		t.Errorf("expected caller deadline %v, got %v", want, deadline)
+	}
+}
+
+func TestNew_ConnectivityCheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpcclient.New(context.Background(), "delivery", lis.Addr().String(), testConfig())
+	if err != nil {
+		t.Fatalf("expected reachable server, got %v", err)
+	}
+	conn.Close()
+
+	// Nothing listens on a closed listener's address
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := closed.Addr().String()
+	closed.Close()
+
+	cfg := testConfig()
+	cfg.ConnectTimeout = 200 * time.Millisecond
+	_, err = grpcclient.New(context.Background(), "delivery", addr, cfg)
+	if err == nil || !strings.Contains(err.Error(), "delivery service at") {
+		t.Errorf("expected unreachable error naming the service, got %v", err)
+	}
+}
+
+func TestNew_RejectsMissingAddress(t *testing.T) {
+	_, err := grpcclient.New(context.Background(), "delivery", "", testConfig())
+	if err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("expected missing address error, got %v", err)
+	}
+}
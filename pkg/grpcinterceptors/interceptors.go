@@ -2,171 +2,129 @@ package grpcinterceptors
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/domainerr"
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"github.com/Keneke-Einar/delivertrack/pkg/tracing"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
-// TraceContextKey is the key used to store trace context in gRPC metadata
-const TraceContextKey = "trace-context"
-
 // AuthorizationMetadataKey is the key used for authorization header in gRPC metadata
 const AuthorizationMetadataKey = "authorization"
 
+// APIKeyMetadataKey is the metadata key API keys may be sent in instead of
+// an "ApiKey <key>" authorization
+const APIKeyMetadataKey = "x-api-key"
+
 // UserClaimsContextKey is the key used to store user claims in gRPC context
 const UserClaimsContextKey = "user-claims"
 
-// UnaryServerInterceptor extracts trace context from incoming gRPC requests
-func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Extract trace context from metadata
-		if md, ok := metadata.FromIncomingContext(ctx); ok {
-			if traceHeaders := md.Get(TraceContextKey); len(traceHeaders) > 0 {
-				// Parse trace context from header (format: trace_id:span_id:parent_span_id)
-				parts := strings.Split(traceHeaders[0], ":")
-				if len(parts) >= 2 {
-					traceID := parts[0]
-					spanID := parts[1]
-					var parentSpanID string
-					if len(parts) >= 3 {
-						parentSpanID = parts[2]
-					}
-
-					// Add to context
-					ctx = context.WithValue(ctx, "trace_id", traceID)
-					ctx = context.WithValue(ctx, "span_id", spanID)
-					if parentSpanID != "" {
-						ctx = context.WithValue(ctx, "parent_span_id", parentSpanID)
-					}
-				}
-			}
-		}
-
-		return handler(ctx, req)
-	}
+// NewServer creates a gRPC server running the interceptor chain every
+// service uses: status conversion outermost, then request IDs, logging and
+// authentication. Requests taking at least slowThreshold are logged at Warn;
+// zero disables it. opts are applied before the interceptors.
+func NewServer(lg *logger.Logger, authService ports.AuthService, slowThreshold time.Duration, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(
+			ErrorHandlingUnaryServerInterceptor(),
+			RequestIDUnaryServerInterceptor(),
+			LoggingUnaryServerInterceptor(lg, slowThreshold),
+			AuthUnaryServerInterceptor(authService),
+		),
+		grpc.ChainStreamInterceptor(
+			ErrorHandlingStreamServerInterceptor(),
+			RequestIDStreamServerInterceptor(),
+			LoggingStreamServerInterceptor(lg, slowThreshold),
+			AuthStreamServerInterceptor(authService),
+		),
+	)
+	return grpc.NewServer(opts...)
 }
 
-// UnaryClientInterceptor injects trace context into outgoing gRPC requests
+// UnaryClientInterceptor forwards the caller's authorization and request ID
+// to outgoing gRPC requests. Trace context is propagated by the otelgrpc stats
+// handler.
 func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		// Extract trace context from context
-		traceID := getValueFromContext(ctx, "trace_id")
-		spanID := getValueFromContext(ctx, "span_id")
-		authHeader := getValueFromContext(ctx, "authorization")
-
-		md := metadata.MD{}
-
-		if traceID != "" && spanID != "" {
-			// Create new span ID for this call
-			newSpanID := generateSpanID()
-
-			// Add trace context to metadata
-			traceContext := traceID + ":" + newSpanID + ":" + spanID
-			md.Set(TraceContextKey, traceContext)
-
-			// Update context with new span
-			ctx = context.WithValue(ctx, "span_id", newSpanID)
-			ctx = context.WithValue(ctx, "parent_span_id", spanID)
-		}
-
-		if authHeader != "" {
-			md.Set(AuthorizationMetadataKey, authHeader)
-		}
-
-		if len(md) > 0 {
-			ctx = metadata.NewOutgoingContext(ctx, md)
-		}
-
-		return invoker(ctx, method, req, reply, cc, opts...)
+		return invoker(withOutgoingMetadata(ctx), method, req, reply, cc, opts...)
 	}
 }
 
-// StreamServerInterceptor extracts trace context from incoming gRPC streams
-func StreamServerInterceptor() grpc.StreamServerInterceptor {
-	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		ctx := stream.Context()
-
-		// Extract trace context from metadata
-		if md, ok := metadata.FromIncomingContext(ctx); ok {
-			if traceHeaders := md.Get(TraceContextKey); len(traceHeaders) > 0 {
-				parts := strings.Split(traceHeaders[0], ":")
-				if len(parts) >= 2 {
-					traceID := parts[0]
-					spanID := parts[1]
-					var parentSpanID string
-					if len(parts) >= 3 {
-						parentSpanID = parts[2]
-					}
-
-					ctx = context.WithValue(ctx, "trace_id", traceID)
-					ctx = context.WithValue(ctx, "span_id", spanID)
-					if parentSpanID != "" {
-						ctx = context.WithValue(ctx, "parent_span_id", parentSpanID)
-					}
-				}
-			}
-		}
-
-		wrappedStream := &wrappedServerStream{
-			ServerStream: stream,
-			ctx:          ctx,
-		}
-
-		return handler(srv, wrappedStream)
-	}
-}
-
-// StreamClientInterceptor injects trace context into outgoing gRPC streams
+// StreamClientInterceptor forwards the caller's authorization and request ID
+// to outgoing gRPC streams
 func StreamClientInterceptor() grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-		// Extract trace context from context
-		traceID := getValueFromContext(ctx, "trace_id")
-		spanID := getValueFromContext(ctx, "span_id")
-
-		if traceID != "" && spanID != "" {
-			// Create new span ID for this call
-			newSpanID := generateSpanID()
-
-			// Add trace context to metadata
-			traceContext := traceID + ":" + newSpanID + ":" + spanID
-			md := metadata.Pairs(TraceContextKey, traceContext)
-			ctx = metadata.NewOutgoingContext(ctx, md)
-
-			// Update context with new span
-			ctx = context.WithValue(ctx, "span_id", newSpanID)
-			ctx = context.WithValue(ctx, "parent_span_id", spanID)
-		}
+		return streamer(withOutgoingMetadata(ctx), desc, cc, method, opts...)
+	}
+}
 
-		return streamer(ctx, desc, cc, method, opts...)
+// withOutgoingMetadata adds the authorization header and request ID carried
+// by ctx to the outgoing metadata
+func withOutgoingMetadata(ctx context.Context) context.Context {
+	if authHeader := authctx.AuthorizationFrom(ctx); authHeader != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, AuthorizationMetadataKey, authHeader)
 	}
+	if id := requestid.FromContext(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey, id)
+	}
+	return ctx
 }
 
-// wrappedServerStream wraps grpc.ServerStream to provide context
-type wrappedServerStream struct {
-	grpc.ServerStream
-	ctx context.Context
+// RequestIDUnaryServerInterceptor gives every request an ID: a valid one sent
+// in x-request-id metadata, or a generated one. The ID is stored in the
+// context, added to the request logger's fields and returned in the response
+// header metadata.
+func RequestIDUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, id := withRequestID(ctx)
+		// Fails only outside a real transport, e.g. when called directly in tests
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id))
+		return handler(ctx, req)
+	}
 }
 
-func (w *wrappedServerStream) Context() context.Context {
-	return w.ctx
+// RequestIDStreamServerInterceptor is RequestIDUnaryServerInterceptor for streams
+func RequestIDStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, id := withRequestID(stream.Context())
+		_ = stream.SetHeader(metadata.Pairs(requestid.MetadataKey, id))
+		return handler(srv, &wrappedServerStream{ServerStream: stream, ctx: ctx})
+	}
 }
 
-func generateSpanID() string {
-	// Simple span ID generation - in production, use a proper ID generator
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+// withRequestID resolves the incoming request ID and attaches it to ctx
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var incoming string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestid.MetadataKey); len(ids) > 0 {
+			incoming = ids[0]
+		}
+	}
+	id := requestid.Resolve(incoming)
+	ctx = requestid.WithID(ctx, id)
+	return logger.WithContext(ctx, zap.String("request_id", id)), id
 }
 
-// LoggingUnaryServerInterceptor logs gRPC requests with correlation IDs
-func LoggingUnaryServerInterceptor(lg *logger.Logger) grpc.UnaryServerInterceptor {
+// LoggingUnaryServerInterceptor logs gRPC requests with correlation IDs. The
+// completion line carries the method, resulting status code, duration, peer,
+// caller and payload sizes, never payload contents. It is logged at Warn when
+// the request took at least slowThreshold (zero disables this) and at Error
+// for server-side failures.
+func LoggingUnaryServerInterceptor(lg *logger.Logger, slowThreshold time.Duration) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
 		correlationID := getCorrelationID(ctx)
@@ -176,20 +134,18 @@ func LoggingUnaryServerInterceptor(lg *logger.Logger) grpc.UnaryServerIntercepto
 			zap.String("correlation_id", correlationID),
 		).Info("gRPC request started")
 
-		resp, err := handler(ctx, req)
+		caller := &callerInfo{}
+		ctx = context.WithValue(ctx, callerInfoKey{}, caller)
+		resp, err := handler(logger.WithContext(ctx, zap.String("grpc_method", info.FullMethod)), req)
 
 		duration := time.Since(start)
-		status := "success"
-		if err != nil {
-			status = "error"
-		}
-
-		lg.WithContext(ctx).WithFields(
-			zap.String("method", info.FullMethod),
-			zap.String("correlation_id", correlationID),
-			zap.String("status", status),
-			zap.Duration("duration", duration),
-		).Info("gRPC request completed")
+		code := status.Code(convertErrorToGRPCStatus(err))
+		fields := requestFields(ctx, info.FullMethod, correlationID, code, duration, caller, err)
+		fields = append(fields,
+			zap.Int("request_bytes", messageSize(req)),
+			zap.Int("response_bytes", messageSize(resp)),
+		)
+		logCompleted(lg.WithContext(ctx), "gRPC request completed", code, duration, slowThreshold, fields)
 
 		return resp, err
 	}
@@ -217,30 +173,26 @@ func ErrorHandlingStreamServerInterceptor() grpc.StreamServerInterceptor {
 	}
 }
 
-// convertErrorToGRPCStatus converts domain errors to gRPC status codes
+// convertErrorToGRPCStatus converts domain errors to gRPC status codes.
+// Status errors pass through, errors implementing domainerr.Coder anywhere in
+// their chain map to their code, and anything else is Internal.
 func convertErrorToGRPCStatus(err error) error {
 	if err == nil {
 		return nil
 	}
 
-	// Check for domain-specific errors
-	switch err {
-	case domain.ErrInvalidCredentials, domain.ErrInvalidToken, domain.ErrExpiredToken:
-		return status.Error(codes.Unauthenticated, err.Error())
-	case domain.ErrUnauthorized, domain.ErrForbidden:
-		return status.Error(codes.PermissionDenied, err.Error())
-	case domain.ErrUserNotFound, domain.ErrInvalidUserData:
-		return status.Error(codes.InvalidArgument, err.Error())
-	case domain.ErrUserExists:
-		return status.Error(codes.AlreadyExists, err.Error())
-	default:
-		// Check if it's already a gRPC status error
-		if st, ok := status.FromError(err); ok {
-			return st.Err()
-		}
-		// Default to internal error
-		return status.Error(codes.Internal, err.Error())
+	// Check if it's already a gRPC status error
+	if st, ok := status.FromError(err); ok {
+		return st.Err()
 	}
+
+	var coder domainerr.Coder
+	if errors.As(err, &coder) {
+		return status.Error(coder.GRPCCode(), err.Error())
+	}
+
+	// Default to internal error
+	return status.Error(codes.Internal, err.Error())
 }
 
 // GetUserClaimsFromContext extracts user claims from gRPC context
@@ -249,46 +201,147 @@ func GetUserClaimsFromContext(ctx context.Context) (*domain.Claims, bool) {
 	return claims, ok
 }
 
-// LoggingStreamServerInterceptor logs gRPC streaming requests with correlation IDs
-func LoggingStreamServerInterceptor(lg *logger.Logger) grpc.StreamServerInterceptor {
+// LoggingStreamServerInterceptor logs gRPC streaming requests with
+// correlation IDs like LoggingUnaryServerInterceptor, with message counts and
+// total sizes in each direction instead of payload sizes
+func LoggingStreamServerInterceptor(lg *logger.Logger, slowThreshold time.Duration) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		start := time.Now()
-		correlationID := getCorrelationID(stream.Context())
+		ctx := stream.Context()
+		correlationID := getCorrelationID(ctx)
 
-		lg.WithContext(stream.Context()).WithFields(
+		lg.WithContext(ctx).WithFields(
 			zap.String("method", info.FullMethod),
 			zap.String("correlation_id", correlationID),
 		).Info("gRPC stream request started")
 
-		err := handler(srv, stream)
+		caller := &callerInfo{}
+		ctx = context.WithValue(ctx, callerInfoKey{}, caller)
+		counted := &countingServerStream{
+			ServerStream: stream,
+			ctx:          logger.WithContext(ctx, zap.String("grpc_method", info.FullMethod)),
+		}
+		err := handler(srv, counted)
 
 		duration := time.Since(start)
-		status := "success"
-		if err != nil {
-			status = "error"
-		}
+		code := status.Code(convertErrorToGRPCStatus(err))
+		fields := requestFields(ctx, info.FullMethod, correlationID, code, duration, caller, err)
+		fields = append(fields,
+			zap.Int("messages_received", counted.received),
+			zap.Int("received_bytes", counted.receivedBytes),
+			zap.Int("messages_sent", counted.sent),
+			zap.Int("sent_bytes", counted.sentBytes),
+		)
+		logCompleted(lg.WithContext(ctx), "gRPC stream request completed", code, duration, slowThreshold, fields)
 
-		lg.WithContext(stream.Context()).WithFields(
-			zap.String("method", info.FullMethod),
-			zap.String("correlation_id", correlationID),
-			zap.String("status", status),
-			zap.Duration("duration", duration),
-		).Info("gRPC stream request completed")
+		return err
+	}
+}
+
+// callerInfoKey is the context key of the callerInfo the logging
+// interceptors share with the authentication interceptors
+type callerInfoKey struct{}
+
+// callerInfo is filled in by the authentication interceptors, which run
+// inside the logging ones, so the completion line can name the caller
+type callerInfo struct {
+	claims *domain.Claims
+}
+
+// recordCaller stores the authenticated caller for the logging interceptors
+func recordCaller(ctx context.Context, claims *domain.Claims) {
+	if caller, ok := ctx.Value(callerInfoKey{}).(*callerInfo); ok {
+		caller.claims = claims
+	}
+}
+
+// requestFields returns the fields every completion line carries
+func requestFields(ctx context.Context, method, correlationID string, code codes.Code, duration time.Duration, caller *callerInfo, err error) []zap.Field {
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("correlation_id", correlationID),
+		zap.String("code", code.String()),
+		zap.Duration("duration", duration),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer", p.Addr.String()))
+	}
+	if caller.claims != nil {
+		fields = append(fields, zap.Int("user_id", caller.claims.UserID), zap.String("role", caller.claims.Role))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	return fields
+}
+
+// logCompleted logs a completion line at Error for server-side failures, at
+// Warn for slow requests and at Info otherwise
+func logCompleted(lg *logger.Logger, msg string, code codes.Code, duration, slowThreshold time.Duration, fields []zap.Field) {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		lg.Error(msg, fields...)
+		return
+	}
+	if slowThreshold > 0 && duration >= slowThreshold {
+		lg.Warn(msg, append(fields, zap.Bool("slow", true))...)
+		return
+	}
+	lg.Info(msg, fields...)
+}
+
+// messageSize returns the encoded size of a protobuf message, zero for
+// anything else
+func messageSize(msg interface{}) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
+
+// countingServerStream counts the messages passing through a stream and
+// provides a derived context
+type countingServerStream struct {
+	grpc.ServerStream
+	ctx           context.Context
+	received      int
+	receivedBytes int
+	sent          int
+	sentBytes     int
+}
+
+func (c *countingServerStream) Context() context.Context {
+	return c.ctx
+}
 
+func (c *countingServerStream) RecvMsg(m interface{}) error {
+	if err := c.ServerStream.RecvMsg(m); err != nil {
 		return err
 	}
+	c.received++
+	c.receivedBytes += messageSize(m)
+	return nil
 }
 
-// getCorrelationID extracts correlation ID from context (uses trace_id if available)
+func (c *countingServerStream) SendMsg(m interface{}) error {
+	if err := c.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	c.sent++
+	c.sentBytes += messageSize(m)
+	return nil
+}
+
+// getCorrelationID extracts correlation ID from context (uses the trace ID if available)
 func getCorrelationID(ctx context.Context) string {
-	if traceID := getValueFromContext(ctx, "trace_id"); traceID != "" {
+	if traceID := tracing.TraceID(ctx); traceID != "" {
 		return traceID
 	}
 	// Fallback to generating a simple correlation ID
 	return fmt.Sprintf("corr-%d", time.Now().UnixNano())
 }
 
-// AuthUnaryServerInterceptor validates JWT tokens and extracts user claims
+// AuthUnaryServerInterceptor validates JWT tokens or API keys and extracts user claims
 func AuthUnaryServerInterceptor(authService ports.AuthService) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		// Skip authentication for certain methods if needed
@@ -302,34 +355,23 @@ func AuthUnaryServerInterceptor(authService ports.AuthService) grpc.UnaryServerI
 			return nil, status.Error(codes.Unauthenticated, "missing metadata")
 		}
 
-		authHeaders := md.Get(AuthorizationMetadataKey)
-		if len(authHeaders) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing authorization header")
-		}
-
-		// Extract token from "Bearer <token>" format
-		tokenString := strings.TrimPrefix(authHeaders[0], "Bearer ")
-		if tokenString == authHeaders[0] {
-			return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
-		}
-
-		// Validate token
-		claims, err := authService.ValidateToken(ctx, tokenString)
+		// Validate the bearer token or API key
+		claims, err := authenticate(ctx, md, authService)
 		if err != nil {
-			if err == domain.ErrExpiredToken {
-				return nil, status.Error(codes.Unauthenticated, "token expired")
-			}
-			return nil, status.Error(codes.Unauthenticated, "invalid token")
+			return nil, err
 		}
 
 		// Add claims to context
+		recordCaller(ctx, claims)
 		ctx = context.WithValue(ctx, UserClaimsContextKey, claims)
+		ctx = authctx.WithClaims(ctx, claims)
+		ctx = logger.WithUser(ctx, claims.UserID, claims.Role)
 
 		return handler(ctx, req)
 	}
 }
 
-// AuthStreamServerInterceptor validates JWT tokens for streaming calls
+// AuthStreamServerInterceptor validates JWT tokens or API keys for streaming calls
 func AuthStreamServerInterceptor(authService ports.AuthService) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		// Skip authentication for certain methods if needed
@@ -345,29 +387,18 @@ func AuthStreamServerInterceptor(authService ports.AuthService) grpc.StreamServe
 			return status.Error(codes.Unauthenticated, "missing metadata")
 		}
 
-		authHeaders := md.Get(AuthorizationMetadataKey)
-		if len(authHeaders) == 0 {
-			return status.Error(codes.Unauthenticated, "missing authorization header")
-		}
-
-		// Extract token from "Bearer <token>" format
-		tokenString := strings.TrimPrefix(authHeaders[0], "Bearer ")
-		if tokenString == authHeaders[0] {
-			return status.Error(codes.Unauthenticated, "invalid authorization header format")
-		}
-
-		// Validate token
-		claims, err := authService.ValidateToken(ctx, tokenString)
+		// Validate the bearer token or API key
+		claims, err := authenticate(ctx, md, authService)
 		if err != nil {
-			if err == domain.ErrExpiredToken {
-				return status.Error(codes.Unauthenticated, "token expired")
-			}
-			return status.Error(codes.Unauthenticated, "invalid token")
+			return err
 		}
 
 		// Add claims to context
+		recordCaller(ctx, claims)
 		ctx = context.WithValue(ctx, UserClaimsContextKey, claims)
-		wrappedStream := &authWrappedServerStream{
+		ctx = authctx.WithClaims(ctx, claims)
+		ctx = logger.WithUser(ctx, claims.UserID, claims.Role)
+		wrappedStream := &wrappedServerStream{
 			ServerStream: stream,
 			ctx:          ctx,
 		}
@@ -376,13 +407,60 @@ func AuthStreamServerInterceptor(authService ports.AuthService) grpc.StreamServe
 	}
 }
 
-// authWrappedServerStream wraps grpc.ServerStream to provide authenticated context
-type authWrappedServerStream struct {
+// authenticate resolves the caller's claims from "Bearer <token>" or
+// "ApiKey <key>" authorization metadata, or from x-api-key metadata, returning
+// an Unauthenticated status error when they are missing or invalid
+func authenticate(ctx context.Context, md metadata.MD, authService ports.AuthService) (*domain.Claims, error) {
+	if apiKeys := md.Get(APIKeyMetadataKey); len(apiKeys) > 0 {
+		return validateAPIKey(ctx, authService, apiKeys[0])
+	}
+
+	authHeaders := md.Get(AuthorizationMetadataKey)
+	if len(authHeaders) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+
+	scheme, credential, ok := strings.Cut(authHeaders[0], " ")
+	if !ok || credential == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
+	}
+
+	switch scheme {
+	case "Bearer":
+		claims, err := authService.ValidateToken(ctx, credential)
+		if err != nil {
+			if err == domain.ErrExpiredToken {
+				return nil, status.Error(codes.Unauthenticated, "token expired")
+			}
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return claims, nil
+	case domain.APIKeyScheme:
+		return validateAPIKey(ctx, authService, credential)
+	default:
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
+	}
+}
+
+// validateAPIKey resolves an API key to its owner's claims
+func validateAPIKey(ctx context.Context, authService ports.AuthService, key string) (*domain.Claims, error) {
+	claims, err := authService.ValidateAPIKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyExpired) {
+			return nil, status.Error(codes.Unauthenticated, "api key expired")
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid api key")
+	}
+	return claims, nil
+}
+
+// wrappedServerStream wraps grpc.ServerStream to provide a derived context
+type wrappedServerStream struct {
 	grpc.ServerStream
 	ctx context.Context
 }
 
-func (w *authWrappedServerStream) Context() context.Context {
+func (w *wrappedServerStream) Context() context.Context {
 	return w.ctx
 }
 
@@ -391,6 +469,8 @@ func shouldSkipAuth(method string) bool {
 	// Add methods that don't require authentication
 	skipMethods := []string{
 		// Add any public methods here, e.g., health checks, login, etc.
+		// Probes from Kubernetes and other services carry no credentials
+		"/grpc.health.v1.Health/",
 	}
 
 	for _, skip := range skipMethods {
@@ -399,12 +479,4 @@ func shouldSkipAuth(method string) bool {
 		}
 	}
 	return false
-}
-
-// Helper functions
-func getValueFromContext(ctx context.Context, key string) string {
-	if val := ctx.Value(key); val != nil {
-		return val.(string)
-	}
-	return ""
 }
\ No newline at end of file
@@ -3,23 +3,41 @@ package grpcinterceptors_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
+	deliveryDomain "github.com/Keneke-Einar/delivertrack/internal/delivery/domain"
+	"github.com/Keneke-Einar/delivertrack/internal/testsupport"
+	trackingDomain "github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
 	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // Mock auth service for testing
 type mockAuthService struct {
 	validateTokenFunc func(ctx context.Context, tokenString string) (*domain.Claims, error)
+	apiKeys           map[string]*domain.Claims
 }
 
-func (m *mockAuthService) Register(ctx context.Context, username, email, password, role string, customerID, courierID *int) (*domain.User, error) {
+func (m *mockAuthService) Register(ctx context.Context, username, email, password, role string, orgID int, customerID, courierID *int) (*domain.User, error) {
 	return nil, errors.New("not implemented")
 }
 
@@ -38,6 +56,48 @@ func (m *mockAuthService) GetUser(ctx context.Context, id int) (*domain.User, er
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockAuthService) UpdateEmail(ctx context.Context, id int, email string) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockAuthService) SetUserActive(ctx context.Context, id int, active bool) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) SetUserOrganization(ctx context.Context, id, orgID int) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) UnlockUser(ctx context.Context, id int) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockAuthService) IssueAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	return nil, "", errors.New("not implemented")
+}
+
+func (m *mockAuthService) ListAPIKeys(ctx context.Context, userID int) ([]*domain.APIKey, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockAuthService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockAuthService) ValidateAPIKey(ctx context.Context, key string) (*domain.Claims, error) {
+	if key == "expired-key" {
+		return nil, domain.ErrAPIKeyExpired
+	}
+	if claims, ok := m.apiKeys[key]; ok {
+		return claims, nil
+	}
+	return nil, domain.ErrInvalidAPIKey
+}
+
 func TestAuthUnaryServerInterceptor_ValidToken(t *testing.T) {
 	mockAuth := &mockAuthService{
 		validateTokenFunc: func(ctx context.Context, tokenString string) (*domain.Claims, error) {
@@ -128,6 +188,64 @@ func TestAuthUnaryServerInterceptor_MissingAuthHeader(t *testing.T) {
 	}
 }
 
+func TestAuthUnaryServerInterceptor_HealthCheckSkipsAuth(t *testing.T) {
+	mockAuth := &mockAuthService{}
+
+	interceptor := grpcinterceptors.AuthUnaryServerInterceptor(mockAuth)
+
+	resp, err := interceptor(context.Background(), "test-req", &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "serving", nil
+	})
+
+	if err != nil || resp != "serving" {
+		t.Errorf("Expected health check without credentials to pass, got: %v, %v", resp, err)
+	}
+}
+
+func TestAuthUnaryServerInterceptor_APIKey(t *testing.T) {
+	customerID := 7
+	mockAuth := &mockAuthService{apiKeys: map[string]*domain.Claims{
+		"partner-key": {UserID: 3, Role: domain.RoleCustomer, CustomerID: &customerID, APIKeyID: 11},
+	}}
+	interceptor := grpcinterceptors.AuthUnaryServerInterceptor(mockAuth)
+
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		expectedMsg string
+	}{
+		{"authorization scheme", metadata.Pairs("authorization", "ApiKey partner-key"), ""},
+		{"x-api-key metadata", metadata.Pairs("x-api-key", "partner-key"), ""},
+		{"unknown key", metadata.Pairs("authorization", "ApiKey other-key"), "invalid api key"},
+		{"expired key", metadata.Pairs("x-api-key", "expired-key"), "api key expired"},
+		{"unknown scheme", metadata.Pairs("authorization", "Basic partner-key"), "invalid authorization header format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			_, err := interceptor(ctx, "test-req", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Test"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				claims, ok := grpcinterceptors.GetUserClaimsFromContext(ctx)
+				if !ok || claims.UserID != 3 || claims.APIKeyID != 11 || claims.CustomerID == nil || *claims.CustomerID != customerID {
+					t.Errorf("Unexpected claims: %+v", claims)
+				}
+				return "success", nil
+			})
+
+			if tt.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			st, ok := status.FromError(err)
+			if !ok || st.Code() != codes.Unauthenticated || st.Message() != tt.expectedMsg {
+				t.Errorf("Expected Unauthenticated %q, got: %v", tt.expectedMsg, err)
+			}
+		})
+	}
+}
+
 func TestErrorHandlingUnaryServerInterceptor(t *testing.T) {
 	interceptor := grpcinterceptors.ErrorHandlingUnaryServerInterceptor()
 
@@ -161,6 +279,72 @@ func TestErrorHandlingUnaryServerInterceptor(t *testing.T) {
 			expectedCode:   codes.NotFound,
 			expectedMsgContains: "not found",
 		},
+		{
+			name:           "wrapped grpc status error preserved",
+			handlerError:   fmt.Errorf("lookup: %w", status.Error(codes.Unavailable, "backend down")),
+			expectedCode:   codes.Unavailable,
+			expectedMsgContains: "backend down",
+		},
+		{
+			name:           "delivery not found",
+			handlerError:   deliveryDomain.ErrDeliveryNotFound,
+			expectedCode:   codes.NotFound,
+			expectedMsgContains: "delivery not found",
+		},
+		{
+			name:           "wrapped delivery not found",
+			handlerError:   fmt.Errorf("failed to get delivery 42: %w", deliveryDomain.ErrDeliveryNotFound),
+			expectedCode:   codes.NotFound,
+			expectedMsgContains: "failed to get delivery 42: delivery not found",
+		},
+		{
+			name:           "wrapped delivery invalid status",
+			handlerError:   fmt.Errorf("%w: cannot move from delivered to pending", deliveryDomain.ErrInvalidStatus),
+			expectedCode:   codes.InvalidArgument,
+			expectedMsgContains: "invalid delivery status",
+		},
+		{
+			name:           "doubly wrapped delivery unauthorized",
+			handlerError:   fmt.Errorf("update: %w", fmt.Errorf("check owner: %w", deliveryDomain.ErrUnauthorized)),
+			expectedCode:   codes.PermissionDenied,
+			expectedMsgContains: "unauthorized access",
+		},
+		{
+			name:           "wrapped delivery not cancellable",
+			handlerError:   fmt.Errorf("cancel: %w", deliveryDomain.ErrNotCancellable),
+			expectedCode:   codes.FailedPrecondition,
+			expectedMsgContains: "can no longer be cancelled",
+		},
+		{
+			name:           "joined tracking invalid location",
+			handlerError:   errors.Join(errors.New("latitude out of range"), trackingDomain.ErrInvalidLocation),
+			expectedCode:   codes.InvalidArgument,
+			expectedMsgContains: "invalid location data",
+		},
+		{
+			name:           "wrapped tracking delivery closed",
+			handlerError:   fmt.Errorf("record location: %w", trackingDomain.ErrDeliveryClosed),
+			expectedCode:   codes.FailedPrecondition,
+			expectedMsgContains: "no longer accepting locations",
+		},
+		{
+			name:           "wrapped tracking unauthorized",
+			handlerError:   fmt.Errorf("history: %w", trackingDomain.ErrUnauthorized),
+			expectedCode:   codes.PermissionDenied,
+			expectedMsgContains: "unauthorized access",
+		},
+		{
+			name:           "account locked",
+			handlerError:   fmt.Errorf("login: %w", &domain.LockedError{RetryAfter: time.Minute}),
+			expectedCode:   codes.ResourceExhausted,
+			expectedMsgContains: "retry in 1m0s",
+		},
+		{
+			name:           "wrapped generic error",
+			handlerError:   fmt.Errorf("query: %w", errors.New("connection refused")),
+			expectedCode:   codes.Internal,
+			expectedMsgContains: "connection refused",
+		},
 	}
 
 	for _, tt := range tests {
@@ -191,6 +375,18 @@ func TestErrorHandlingUnaryServerInterceptor(t *testing.T) {
 	}
 }
 
+func TestErrorHandlingStreamServerInterceptor_WrappedDomainError(t *testing.T) {
+	interceptor := grpcinterceptors.ErrorHandlingStreamServerInterceptor()
+
+	err := interceptor(nil, nil, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		return fmt.Errorf("track delivery 42: %w", deliveryDomain.ErrDeliveryNotFound)
+	})
+
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected code %v, got %v", codes.NotFound, err)
+	}
+}
+
 func TestGetUserClaimsFromContext(t *testing.T) {
 	claims := &domain.Claims{
 		UserID:   123,
@@ -219,4 +415,257 @@ func TestGetUserClaimsFromContext_NoClaims(t *testing.T) {
 	if ok {
 		t.Error("Expected no claims in context")
 	}
-}
\ No newline at end of file
+}
+// observedLogger returns a logger whose entries the test can inspect
+func observedLogger() (*logger.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &logger.Logger{Logger: zap.New(core)}, logs
+}
+
+// completedEntry returns the single entry logged with msg
+func completedEntry(t *testing.T, logs *observer.ObservedLogs, msg string) observer.LoggedEntry {
+	t.Helper()
+	entries := logs.FilterMessage(msg).All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected one %q entry, got %d", msg, len(entries))
+	}
+	return entries[0]
+}
+
+func TestLoggingUnaryServerInterceptor_Fields(t *testing.T) {
+	lg, logs := observedLogger()
+	logging := grpcinterceptors.LoggingUnaryServerInterceptor(lg, time.Second)
+	auth := grpcinterceptors.AuthUnaryServerInterceptor(&mockAuthService{
+		validateTokenFunc: func(ctx context.Context, tokenString string) (*domain.Claims, error) {
+			return &domain.Claims{UserID: 42, Role: domain.RoleCourier}, nil
+		},
+	})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid-token"))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 5000}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Test"}
+	req := wrapperspb.String("secret request payload")
+
+	_, err := logging(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return auth(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return wrapperspb.String("secret response payload"), nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	entry := completedEntry(t, logs, "gRPC request completed")
+	if entry.Level != zapcore.InfoLevel {
+		t.Errorf("Expected Info, got %v", entry.Level)
+	}
+	fields := entry.ContextMap()
+	expected := map[string]interface{}{
+		"method":         "/test.Service/Test",
+		"code":           "OK",
+		"user_id":        int64(42),
+		"role":           domain.RoleCourier,
+		"peer":           "10.0.0.7:5000",
+		"request_bytes":  int64(proto.Size(req)),
+		"response_bytes": int64(proto.Size(wrapperspb.String("secret response payload"))),
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, fields[key])
+		}
+	}
+	if _, ok := fields["duration"]; !ok {
+		t.Error("Expected a duration field")
+	}
+
+	for _, entry := range logs.All() {
+		for key, value := range entry.ContextMap() {
+			if str, ok := value.(string); ok && strings.Contains(str, "secret") {
+				t.Errorf("Expected no payload contents in logs, got %s=%q", key, str)
+			}
+		}
+	}
+}
+
+func TestLoggingUnaryServerInterceptor_Levels(t *testing.T) {
+	tests := []struct {
+		name          string
+		delay         time.Duration
+		handlerError  error
+		expectedLevel zapcore.Level
+		expectedCode  string
+	}{
+		{name: "fast", expectedLevel: zapcore.InfoLevel, expectedCode: "OK"},
+		{name: "slow", delay: 20 * time.Millisecond, expectedLevel: zapcore.WarnLevel, expectedCode: "OK"},
+		{name: "client error", handlerError: fmt.Errorf("get: %w", deliveryDomain.ErrDeliveryNotFound), expectedLevel: zapcore.InfoLevel, expectedCode: "NotFound"},
+		{name: "server error", handlerError: errors.New("database down"), expectedLevel: zapcore.ErrorLevel, expectedCode: "Internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg, logs := observedLogger()
+			interceptor := grpcinterceptors.LoggingUnaryServerInterceptor(lg, 10*time.Millisecond)
+
+			interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Test"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				time.Sleep(tt.delay)
+				return nil, tt.handlerError
+			})
+
+			entry := completedEntry(t, logs, "gRPC request completed")
+			if entry.Level != tt.expectedLevel {
+				t.Errorf("Expected %v, got %v", tt.expectedLevel, entry.Level)
+			}
+			if code := entry.ContextMap()["code"]; code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %v", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestLoggingUnaryServerInterceptor_SlowThresholdDisabled(t *testing.T) {
+	lg, logs := observedLogger()
+	interceptor := grpcinterceptors.LoggingUnaryServerInterceptor(lg, 0)
+
+	interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(5 * time.Millisecond)
+		return nil, nil
+	})
+
+	if entry := completedEntry(t, logs, "gRPC request completed"); entry.Level != zapcore.InfoLevel {
+		t.Errorf("Expected Info, got %v", entry.Level)
+	}
+}
+
+// fakeServerStream receives the queued messages and records sent ones
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	incoming []proto.Message
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeServerStream) RecvMsg(m interface{}) error {
+	if len(f.incoming) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), f.incoming[0])
+	f.incoming = f.incoming[1:]
+	return nil
+}
+
+func (f *fakeServerStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+func TestLoggingStreamServerInterceptor_CountsMessages(t *testing.T) {
+	lg, logs := observedLogger()
+	interceptor := grpcinterceptors.LoggingStreamServerInterceptor(lg, time.Second)
+	received := wrapperspb.String("subscribe")
+	stream := &fakeServerStream{ctx: context.Background(), incoming: []proto.Message{received}}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(srv interface{}, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&wrapperspb.StringValue{}); err != nil {
+			return err
+		}
+		if err := stream.RecvMsg(&wrapperspb.StringValue{}); err != io.EOF {
+			return fmt.Errorf("expected EOF, got %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := stream.SendMsg(wrapperspb.String("update")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	fields := completedEntry(t, logs, "gRPC stream request completed").ContextMap()
+	expected := map[string]interface{}{
+		"method":            "/test.Service/Stream",
+		"code":              "OK",
+		"messages_received": int64(1),
+		"received_bytes":    int64(proto.Size(received)),
+		"messages_sent":     int64(2),
+		"sent_bytes":        int64(2 * proto.Size(wrapperspb.String("update"))),
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, fields[key])
+		}
+	}
+}
+
+func TestRequestIDUnaryServerInterceptor(t *testing.T) {
+	interceptor := grpcinterceptors.RequestIDUnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Test"}
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "valid incoming ID", incoming: "client-req-42", keep: true},
+		{name: "missing ID"},
+		{name: "invalid ID", incoming: "not valid!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.incoming != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(requestid.MetadataKey, tt.incoming))
+			}
+
+			var id string
+			interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				id = requestid.FromContext(ctx)
+				return nil, nil
+			})
+
+			if tt.keep && id != tt.incoming {
+				t.Errorf("Expected the incoming ID, got %q", id)
+			}
+			if !tt.keep && (id == tt.incoming || !requestid.Valid(id)) {
+				t.Errorf("Expected a generated ID, got %q", id)
+			}
+		})
+	}
+}
+
+func TestRequestID_RoundTrip(t *testing.T) {
+	conn := testsupport.DialGRPCServer(t, testsupport.NewAuthService(), func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestid.MetadataKey, "client-req-42")
+	_, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if ids := header.Get(requestid.MetadataKey); len(ids) != 1 || ids[0] != "client-req-42" {
+		t.Errorf("Expected the forwarded ID in the response header, got %v", ids)
+	}
+}
+
+func TestUnaryClientInterceptor_ForwardsRequestID(t *testing.T) {
+	interceptor := grpcinterceptors.UnaryClientInterceptor()
+	ctx := requestid.WithID(context.Background(), "client-req-42")
+
+	var outgoing metadata.MD
+	err := interceptor(ctx, "/test.Service/Test", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if ids := outgoing.Get(requestid.MetadataKey); len(ids) != 1 || ids[0] != "client-req-42" {
+		t.Errorf("Expected the request ID in outgoing metadata, got %v", ids)
+	}
+}
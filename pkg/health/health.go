@@ -0,0 +1,284 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// DefaultTimeout bounds a single dependency check
+	DefaultTimeout = 2 * time.Second
+	// DefaultInterval is how often Run re-checks dependencies
+	DefaultInterval = 10 * time.Second
+)
+
+// Report statuses
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// errShuttingDown fails readiness once the service started draining
+var errShuttingDown = errors.New("service is shutting down")
+
+// CheckFunc reports whether a dependency is usable; it must return once ctx ends
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+}
+
+// DependencyStatus is a dependency's entry in a Report
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report is the result of checking every dependency. Status is "unavailable"
+// when a critical dependency failed and "degraded" when only optional ones did.
+type Report struct {
+	Status       string                      `json:"status"`
+	Service      string                      `json:"service"`
+	Error        string                      `json:"error,omitempty"`
+	CheckedAt    time.Time                   `json:"checked_at"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Ready reports whether the service should receive traffic
+func (r Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+// Checker checks a service's dependencies for the /health/ready endpoint and
+// mirrors the result into the gRPC health server, so HTTP and gRPC probes
+// always agree
+type Checker struct {
+	service string
+	timeout time.Duration
+
+	mu       sync.Mutex
+	checks   []check
+	grpc     *grpchealth.Server
+	draining bool
+}
+
+// NewChecker creates a checker for a service; a timeout of zero or less means
+// DefaultTimeout
+func NewChecker(service string, timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{service: service, timeout: timeout}
+}
+
+// Add registers a dependency the service cannot work without; readiness
+// fails while it is down
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn, critical: true})
+}
+
+// AddOptional registers a dependency the service degrades without, such as a
+// cache with a fallback or a downstream service. Its failures are reported
+// but keep the service ready, which also keeps services depending on each
+// other from holding each other out of rotation.
+func (c *Checker) AddOptional(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn, critical: false})
+}
+
+// SetGRPCServer mirrors readiness into the overall status of server. It
+// starts NOT_SERVING until the first check passes.
+func (c *Checker) SetGRPCServer(server *grpchealth.Server) {
+	c.mu.Lock()
+	c.grpc = server
+	c.mu.Unlock()
+	server.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+}
+
+// Check runs every check in parallel, each bounded by the checker's timeout,
+// and updates the gRPC health status
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]check(nil), c.checks...)
+	draining := c.draining
+	c.mu.Unlock()
+
+	report := Report{
+		Status:       StatusOK,
+		Service:      c.service,
+		CheckedAt:    time.Now(),
+		Dependencies: make(map[string]DependencyStatus, len(checks)),
+	}
+
+	results := make([]DependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	for i, chk := range checks {
+		result := results[i]
+		report.Dependencies[chk.name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if chk.critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	if draining {
+		report.Status = StatusUnavailable
+		report.Error = errShuttingDown.Error()
+	}
+
+	c.setGRPCStatus(report.Ready())
+	return report
+}
+
+// run executes a single check with the checker's timeout
+func (c *Checker) run(ctx context.Context, chk check) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- chk.fn(ctx)
+	}()
+
+	// A check ignoring ctx still cannot hold up the report
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	status := DependencyStatus{
+		Status:    StatusOK,
+		Critical:  chk.critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = StatusUnavailable
+		if errors.Is(err, context.DeadlineExceeded) {
+			status.Error = fmt.Sprintf("timed out after %s", c.timeout)
+		} else {
+			status.Error = err.Error()
+		}
+	}
+	return status
+}
+
+func (c *Checker) setGRPCStatus(ready bool) {
+	c.mu.Lock()
+	server := c.grpc
+	c.mu.Unlock()
+	if server == nil {
+		return
+	}
+
+	status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	server.SetServingStatus("", status)
+}
+
+// Run checks dependencies every interval until ctx ends, so the gRPC status
+// follows dependency outages between HTTP probes. A zero or negative interval
+// means DefaultInterval.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	c.Check(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Shutdown fails readiness from now on, so load balancers stop sending
+// traffic while the service drains
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	c.draining = true
+	server := c.grpc
+	c.mu.Unlock()
+	if server != nil {
+		server.Shutdown()
+	}
+}
+
+// LiveHandler serves /health/live: the process is up and serving HTTP
+func (c *Checker) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK, "service": c.service})
+}
+
+// ReadyHandler serves /health/ready: 200 while every critical dependency is
+// healthy and 503 otherwise, with the status of each dependency
+func (c *Checker) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	code := http.StatusOK
+	if !report.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, report)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// GRPC checks a downstream service through the standard gRPC health service
+func GRPC(conn grpc.ClientConnInterface) CheckFunc {
+	client := grpc_health_v1.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("service reports %s", resp.GetStatus())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,208 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func servingStatus(t *testing.T, server *grpchealth.Server) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := server.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	return resp.Status
+}
+
+func ready(t *testing.T, checker *Checker) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	checker.ReadyHandler(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestChecker_Ready(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(c *Checker)
+		wantCode   int
+		wantStatus string
+		wantGRPC   grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name: "all healthy",
+			setup: func(c *Checker) {
+				c.Add("postgres", ok)
+				c.AddOptional("tracking", ok)
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusOK,
+			wantGRPC:   grpc_health_v1.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "optional dependency down",
+			setup: func(c *Checker) {
+				c.Add("postgres", ok)
+				c.AddOptional("tracking", failing)
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusDegraded,
+			wantGRPC:   grpc_health_v1.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "critical dependency down",
+			setup: func(c *Checker) {
+				c.Add("postgres", failing)
+				c.AddOptional("tracking", ok)
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusUnavailable,
+			wantGRPC:   grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker("delivery", time.Second)
+			server := grpchealth.NewServer()
+			checker.SetGRPCServer(server)
+			tt.setup(checker)
+
+			code, report := ready(t, checker)
+			if code != tt.wantCode {
+				t.Errorf("code = %d, want %d", code, tt.wantCode)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if len(report.Dependencies) != 2 {
+				t.Errorf("got %d dependencies, want 2", len(report.Dependencies))
+			}
+			if got := servingStatus(t, server); got != tt.wantGRPC {
+				t.Errorf("gRPC status = %v, want %v", got, tt.wantGRPC)
+			}
+		})
+	}
+}
+
+func TestChecker_ReportsFailureDetail(t *testing.T) {
+	checker := NewChecker("tracking", time.Second)
+	checker.Add("mongodb", failing)
+
+	_, report := ready(t, checker)
+	dep := report.Dependencies["mongodb"]
+	if dep.Status != StatusUnavailable || !dep.Critical || dep.Error != "connection refused" {
+		t.Errorf("mongodb = %+v, want a critical failure with its error", dep)
+	}
+}
+
+func TestChecker_TimesOutSlowChecks(t *testing.T) {
+	checker := NewChecker("tracking", 20*time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	// Ignores ctx, so only the checker's own timeout ends it
+	checker.Add("rabbitmq", func(context.Context) error {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	code, report := ready(t, checker)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ready took %s, want the check to time out", elapsed)
+	}
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("code = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if got := report.Dependencies["rabbitmq"].Error; got != "timed out after 20ms" {
+		t.Errorf("error = %q, want a timeout", got)
+	}
+}
+
+func TestChecker_GRPCStartsNotServing(t *testing.T) {
+	checker := NewChecker("analytics", time.Second)
+	server := grpchealth.NewServer()
+	checker.SetGRPCServer(server)
+
+	if got := servingStatus(t, server); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("gRPC status before the first check = %v, want NOT_SERVING", got)
+	}
+}
+
+func TestChecker_Shutdown(t *testing.T) {
+	checker := NewChecker("tracking", time.Second)
+	server := grpchealth.NewServer()
+	checker.SetGRPCServer(server)
+	checker.Add("postgres", ok)
+	checker.Check(context.Background())
+
+	checker.Shutdown()
+
+	code, report := ready(t, checker)
+	if code != http.StatusServiceUnavailable || report.Error == "" {
+		t.Errorf("ready after shutdown = %d %+v, want 503 with an error", code, report)
+	}
+	if got := servingStatus(t, server); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("gRPC status after shutdown = %v, want NOT_SERVING", got)
+	}
+
+	rec := httptest.NewRecorder()
+	checker.LiveHandler(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("live after shutdown = %d, want 200", rec.Code)
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	downstream := grpchealth.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, downstream)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	check := GRPC(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := check(ctx); err != nil {
+		t.Errorf("check of a serving service = %v, want nil", err)
+	}
+
+	downstream.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if err := check(ctx); err == nil {
+		t.Error("check of a not serving service = nil, want an error")
+	}
+}
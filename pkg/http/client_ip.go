@@ -0,0 +1,83 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies are the networks of the proxies in front of a server, such
+// as the gateway or a load balancer. Forwarding headers are only believed on
+// requests arriving from one of them.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDRs and bare IP addresses into TrustedProxies
+func ParseTrustedProxies(values []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// trusts reports whether ip is the address of a trusted proxy
+func (t TrustedProxies) trusts(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client that sent r. It is RemoteAddr
+// unless that is a trusted proxy; X-Forwarded-For is then walked from the
+// right, past the trusted proxies that appended to it, to the first address
+// a client could not have forged. X-Real-IP is used when a trusted proxy sent
+// no X-Forwarded-For.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !t.trusts(ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !t.trusts(hop) {
+			return hop
+		}
+		ip = hop
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" && len(r.Header.Values("X-Forwarded-For")) == 0 {
+		return realIP
+	}
+	return ip
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("failed to parse proxies: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		expected     string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:1234", expected: "203.0.113.7"},
+		{name: "headers from an untrusted client", remoteAddr: "203.0.113.7:1234", forwardedFor: "198.51.100.1", realIP: "198.51.100.2", expected: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.5:1234", forwardedFor: "198.51.100.1", expected: "198.51.100.1"},
+		{name: "spoofed hop before the proxy", remoteAddr: "10.0.0.5:1234", forwardedFor: "192.0.2.99, 198.51.100.1", expected: "198.51.100.1"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.5:1234", forwardedFor: "198.51.100.1, 192.168.1.1", expected: "198.51.100.1"},
+		{name: "real IP from a trusted proxy", remoteAddr: "192.168.1.1:1234", realIP: "198.51.100.3", expected: "198.51.100.3"},
+		{name: "trusted proxy without headers", remoteAddr: "10.0.0.5:1234", expected: "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := proxies.ClientIP(req); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("expected an invalid proxy to be rejected")
+	}
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WeakETag builds a weak entity tag from the values identifying a
+// representation's version, such as an update time and a version number.
+// Times are encoded to the nanosecond so any change yields a new tag.
+func WeakETag(parts ...interface{}) string {
+	encoded := make([]string, len(parts))
+	for i, part := range parts {
+		switch v := part.(type) {
+		case time.Time:
+			if v.IsZero() {
+				encoded[i] = "0"
+			} else {
+				encoded[i] = strconv.FormatInt(v.UnixNano(), 36)
+			}
+		case int:
+			encoded[i] = strconv.Itoa(v)
+		case int64:
+			encoded[i] = strconv.FormatInt(v, 10)
+		case string:
+			encoded[i] = v
+		default:
+			encoded[i] = "?"
+		}
+	}
+	return `W/"` + strings.Join(encoded, "-") + `"`
+}
+
+// ServeConditional sets the ETag of the response and answers the requests
+// that need no body: a 304 when If-None-Match lists the tag, and the headers
+// alone for HEAD. It reports whether the response is complete, so handlers
+// return before loading or serializing the body. Headers such as the
+// Content-Type should be set before calling it.
+func ServeConditional(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		// A 304 carries no body, so drop the headers describing one
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match value lists etag, using the
+// weak comparison RFC 9110 requires for this header
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServeConditional(t *testing.T) {
+	etag := WeakETag(time.Unix(1700000000, 0), 3)
+
+	tests := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		wantDone    bool
+		wantStatus  int
+	}{
+		{name: "no condition", method: http.MethodGet},
+		{name: "changed", method: http.MethodGet, ifNoneMatch: WeakETag(time.Unix(1700000000, 0), 2)},
+		{name: "unchanged", method: http.MethodGet, ifNoneMatch: etag, wantDone: true, wantStatus: http.StatusNotModified},
+		{name: "strong form of the tag", method: http.MethodGet, ifNoneMatch: etag[2:], wantDone: true, wantStatus: http.StatusNotModified},
+		{name: "one of several", method: http.MethodGet, ifNoneMatch: `W/"other", ` + etag, wantDone: true, wantStatus: http.StatusNotModified},
+		{name: "any", method: http.MethodGet, ifNoneMatch: "*", wantDone: true, wantStatus: http.StatusNotModified},
+		{name: "HEAD", method: http.MethodHead, wantDone: true, wantStatus: http.StatusOK},
+		{name: "unchanged HEAD", method: http.MethodHead, ifNoneMatch: etag, wantDone: true, wantStatus: http.StatusNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/deliveries/1", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", "application/json")
+
+			done := ServeConditional(rec, req, etag)
+
+			if done != tt.wantDone {
+				t.Fatalf("expected done %v, got %v", tt.wantDone, done)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Errorf("expected ETag %s, got %q", etag, rec.Header().Get("ETag"))
+			}
+			if done && rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if done && rec.Body.Len() != 0 {
+				t.Errorf("expected no body, got %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWeakETag(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if WeakETag(at, 1) != WeakETag(at, 1) {
+		t.Error("expected equal versions to share a tag")
+	}
+	for _, other := range []string{WeakETag(at, 2), WeakETag(at.Add(time.Millisecond), 1), WeakETag(time.Time{}, 1)} {
+		if other == WeakETag(at, 1) {
+			t.Errorf("expected a changed version to change the tag, got %s", other)
+		}
+	}
+	if got := WeakETag(time.Time{}, int64(0)); got != `W/"0-0"` {
+		t.Errorf("expected the empty version tag, got %s", got)
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodyBytes bounds JSON request bodies unless a route sets its own limit
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// BodyError is a request body that could not be decoded, with the status and
+// message to report to the client
+type BodyError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *BodyError) Error() string {
+	return e.Message
+}
+
+// DecodeJSON decodes a single JSON document of at most DefaultMaxBodyBytes
+// into dst, rejecting unknown fields. Errors are *BodyError.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	return DecodeJSONLimit(w, r, dst, DefaultMaxBodyBytes)
+}
+
+// DecodeJSONLimit is DecodeJSON with a route-specific size limit; a limit of
+// zero or less means DefaultMaxBodyBytes
+func DecodeJSONLimit(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return bodyError(err)
+	}
+
+	// A second document, or anything but whitespace, after the first is rejected
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return bodyError(err)
+		}
+		return &BodyError{StatusCode: http.StatusBadRequest, Message: "Request body must contain a single JSON document"}
+	}
+
+	return nil
+}
+
+// bodyError describes why decoding failed
+func bodyError(err error) *BodyError {
+	var maxErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxErr):
+		return &BodyError{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Message:    fmt.Sprintf("Request body too large, the limit is %d bytes", maxErr.Limit),
+		}
+	case errors.Is(err, io.EOF):
+		return &BodyError{StatusCode: http.StatusBadRequest, Message: "Request body is empty"}
+	case errors.As(err, &syntaxErr):
+		return &BodyError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("Malformed JSON at offset %d", syntaxErr.Offset)}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BodyError{StatusCode: http.StatusBadRequest, Message: "Malformed JSON, the body ended early"}
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return &BodyError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("Invalid value for field %q, expected %s", typeErr.Field, typeErr.Type)}
+		}
+		return &BodyError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("Invalid JSON value, expected %s", typeErr.Type)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		return &BodyError{StatusCode: http.StatusBadRequest, Message: "Unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")}
+	default:
+		return &BodyError{StatusCode: http.StatusBadRequest, Message: "Invalid request body"}
+	}
+}
+
+// SendBodyError sends the response for a DecodeJSON error
+func SendBodyError(w http.ResponseWriter, err error) {
+	var bodyErr *BodyError
+	if errors.As(err, &bodyErr) {
+		SendErrorResponse(w, bodyErr.Message, bodyErr.StatusCode)
+		return
+	}
+	SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+}
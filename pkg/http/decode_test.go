@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type decodeTarget struct {
+	CourierID int    `json:"courier_id"`
+	Notes     string `json:"notes"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		limit      int64
+		statusCode int
+		message    string
+	}{
+		{"valid", `{"courier_id": 4, "notes": "back door"}`, 0, http.StatusOK, ""},
+		{"trailing whitespace", "{\"courier_id\": 4}\n", 0, http.StatusOK, ""},
+		{"unknown field", `{"curier_id": 4}`, 0, http.StatusBadRequest, `Unknown field "curier_id"`},
+		{"malformed", `{"courier_id": 4,}`, 0, http.StatusBadRequest, "Malformed JSON at offset 18"},
+		{"truncated", `{"courier_id": 4`, 0, http.StatusBadRequest, "Malformed JSON, the body ended early"},
+		{"wrong type", `{"courier_id": "4"}`, 0, http.StatusBadRequest, `Invalid value for field "courier_id", expected int`},
+		{"empty", ``, 0, http.StatusBadRequest, "Request body is empty"},
+		{"second document", `{"courier_id": 4}{"courier_id": 5}`, 0, http.StatusBadRequest, "Request body must contain a single JSON document"},
+		{"too large", `{"notes": "` + strings.Repeat("x", 64) + `"}`, 32, http.StatusRequestEntityTooLarge, "Request body too large, the limit is 32 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			var dst decodeTarget
+			err := DecodeJSONLimit(rec, req, &dst, tt.limit)
+			if tt.statusCode == http.StatusOK {
+				if err != nil || dst.CourierID != 4 {
+					t.Fatalf("expected the body to decode, got %+v, %v", dst, err)
+				}
+				return
+			}
+
+			SendBodyError(rec, err)
+			var resp ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("expected a JSON error response: %v", err)
+			}
+			if rec.Code != tt.statusCode || resp.Message != tt.message {
+				t.Errorf("expected %d %q, got %d %q", tt.statusCode, tt.message, rec.Code, resp.Message)
+			}
+		})
+	}
+}
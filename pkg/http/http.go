@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ErrorResponse represents a standardized error response
@@ -24,50 +26,61 @@ func SendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	})
 }
 
+// SendErrorCode sends a JSON error response with a machine-readable error code
+// such as "courier_not_active" in place of the status text
+func SendErrorCode(w http.ResponseWriter, code, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   code,
+		Message: message,
+	})
+}
+
 // UserContext represents extracted user information from request context
 type UserContext struct {
-	Role       string
-	CustomerID *int
-	CourierID  *int
+	UserID        int
+	Role          string
+	CustomerID    *int
+	CourierID     *int
+	Authenticated bool
 }
 
-// ExtractUserContext extracts user information from request context
+// ExtractUserContext extracts user information from request context. When the
+// auth middleware did not run, the zero UserContext is returned.
 func ExtractUserContext(r *http.Request) UserContext {
-	userRole, _ := r.Context().Value("role").(string)
-	customerID, _ := r.Context().Value("customer_id").(*int)
-	courierID, _ := r.Context().Value("courier_id").(*int)
+	claims, ok := authctx.ClaimsFrom(r.Context())
+	if !ok {
+		return UserContext{}
+	}
 
 	return UserContext{
-		Role:       userRole,
-		CustomerID: customerID,
-		CourierID:  courierID,
+		UserID:        claims.UserID,
+		Role:          claims.Role,
+		CustomerID:    claims.CustomerID,
+		CourierID:     claims.CourierID,
+		Authenticated: true,
 	}
 }
 
-// ExtractTraceContext extracts trace context from HTTP headers and creates a context
-func ExtractTraceContext(r *http.Request, serviceName, operation string) context.Context {
-	// Extract trace context from headers
-	traceID := r.Header.Get("X-Trace-ID")
-	spanID := r.Header.Get("X-Span-ID")
-	parentSpanID := r.Header.Get("X-Parent-Span-ID")
-
-	// Generate new IDs if not present
-	if traceID == "" {
-		traceID = messaging.GenerateTraceID()
-	}
-	if spanID == "" {
-		spanID = messaging.GenerateSpanID()
-	}
-
-	// Create trace context
-	traceCtx := &messaging.TraceContext{
-		TraceID:      traceID,
-		SpanID:       spanID,
-		ParentSpanID: parentSpanID,
-		ServiceName:  serviceName,
-		Operation:    operation,
+// RequireUserContext extracts user information from request context and sends
+// a 401 response if no claims are present
+func RequireUserContext(w http.ResponseWriter, r *http.Request) (UserContext, bool) {
+	userCtx := ExtractUserContext(r)
+	if !userCtx.Authenticated {
+		SendErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+		return userCtx, false
 	}
+	return userCtx, true
+}
 
-	// Add to context
-	return messaging.ContextWithTraceContext(r.Context(), traceCtx)
+// ExtractTraceContext returns the request context, whose span was started
+// from the incoming trace headers by tracing.HTTPHandler, and labels that span
+// with the service and operation handling the request
+func ExtractTraceContext(r *http.Request, serviceName, operation string) context.Context {
+	trace.SpanFromContext(r.Context()).SetAttributes(
+		attribute.String("delivertrack.operation", operation),
+		attribute.String("delivertrack.service", serviceName),
+	)
+	return r.Context()
 }
\ No newline at end of file
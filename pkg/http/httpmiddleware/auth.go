@@ -0,0 +1,151 @@
+// Package httpmiddleware authenticates HTTP requests for the services and the
+// gateway. Callers are identified by a bearer token, an API key, or the
+// identity headers of a trusted gateway, and their claims are put in the
+// request context for handlers to read through pkg/auth/authctx.
+package httpmiddleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+)
+
+// Validator is the part of the auth service requests are authenticated with
+type Validator interface {
+	// ValidateToken validates a JWT token and returns its claims
+	ValidateToken(ctx context.Context, token string) (*domain.Claims, error)
+
+	// ValidateAPIKey validates an API key and returns the claims of its user
+	ValidateAPIKey(ctx context.Context, key string) (*domain.Claims, error)
+}
+
+// Check runs once a caller is authenticated. Returning false stops the
+// request; the check has then written the response.
+type Check func(w http.ResponseWriter, r *http.Request, claims *domain.Claims) bool
+
+// Option configures Auth
+type Option func(*options)
+
+type options struct {
+	gateway       *identity.Verifier
+	roles         []string
+	forwardHeader bool
+	checks        []Check
+}
+
+// WithTrustedGateway accepts the identity headers of requests forwarded by a
+// gateway holding the verifier's secret, without validating the token again.
+// A nil verifier trusts no gateway.
+func WithTrustedGateway(v *identity.Verifier) Option {
+	return func(o *options) { o.gateway = v }
+}
+
+// WithRequiredRoles rejects callers in none of roles with 403
+func WithRequiredRoles(roles ...string) Option {
+	return func(o *options) { o.roles = append(o.roles, roles...) }
+}
+
+// WithForwardedAuthorization keeps the caller's credentials in the context,
+// as an Authorization value, for outgoing gRPC calls to forward
+func WithForwardedAuthorization() Option {
+	return func(o *options) { o.forwardHeader = true }
+}
+
+// WithCheck runs check after authentication and any role requirement, such
+// as a per-caller rate limit
+func WithCheck(check Check) Option {
+	return func(o *options) { o.checks = append(o.checks, check) }
+}
+
+// Auth returns middleware that authenticates every request before calling
+// the next handler, answering 401 when the caller cannot be identified
+func Auth(validator Validator, opts ...Option) func(http.HandlerFunc) http.HandlerFunc {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, authorization, ok := o.authenticate(w, r, validator)
+			if !ok {
+				return
+			}
+
+			if len(o.roles) > 0 && !slices.Contains(o.roles, claims.Role) {
+				httputil.SendErrorCode(w, "forbidden", "Insufficient role for this endpoint", http.StatusForbidden)
+				return
+			}
+			for _, check := range o.checks {
+				if !check(w, r, claims) {
+					return
+				}
+			}
+
+			ctx := authctx.WithClaims(r.Context(), claims)
+			ctx = logger.WithUser(ctx, claims.UserID, claims.Role)
+			if o.forwardHeader {
+				ctx = authctx.WithAuthorization(ctx, authorization)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// authenticate identifies the caller, writing a 401 when it cannot. It also
+// returns the caller's credentials as an Authorization value.
+func (o *options) authenticate(w http.ResponseWriter, r *http.Request, validator Validator) (*domain.Claims, string, bool) {
+	apiKey := r.Header.Get(domain.APIKeyHeader)
+	authorization := r.Header.Get("Authorization")
+	if apiKey != "" {
+		authorization = domain.APIKeyScheme + " " + apiKey
+	}
+
+	// Requests forwarded by a trusted gateway carry the identity it verified
+	forwarded, err := o.gateway.Claims(r)
+	if err != nil {
+		unauthorized(w, "Invalid gateway identity headers")
+		return nil, "", false
+	}
+	if forwarded != nil {
+		return forwarded, authorization, true
+	}
+
+	// Partner backends authenticate with an API key instead of a token
+	if apiKey != "" {
+		claims, err := validator.ValidateAPIKey(r.Context(), apiKey)
+		if err != nil {
+			unauthorized(w, "Invalid, expired or revoked API key")
+			return nil, "", false
+		}
+		return claims, authorization, true
+	}
+
+	if authorization == "" {
+		unauthorized(w, "Authorization header required")
+		return nil, "", false
+	}
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" || strings.Contains(token, " ") {
+		unauthorized(w, "Invalid authorization header format")
+		return nil, "", false
+	}
+
+	claims, err := validator.ValidateToken(r.Context(), token)
+	if err != nil {
+		unauthorized(w, "Invalid or expired token")
+		return nil, "", false
+	}
+	return claims, authorization, true
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	httputil.SendErrorCode(w, "unauthorized", message, http.StatusUnauthorized)
+}
@@ -0,0 +1,214 @@
+package httpmiddleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/identity"
+)
+
+const gatewaySecret = "gateway-secret"
+
+// stubValidator accepts "valid-token" for a customer, "admin-token" for an
+// admin and "valid-key" for a partner's API key; any other token is expired
+type stubValidator struct{}
+
+func (stubValidator) ValidateToken(ctx context.Context, token string) (*domain.Claims, error) {
+	switch token {
+	case "valid-token":
+		return &domain.Claims{UserID: 1, Role: domain.RoleCustomer}, nil
+	case "admin-token":
+		return &domain.Claims{UserID: 2, Role: domain.RoleAdmin}, nil
+	}
+	return nil, errors.New("token is expired")
+}
+
+func (stubValidator) ValidateAPIKey(ctx context.Context, key string) (*domain.Claims, error) {
+	if key == "valid-key" {
+		return &domain.Claims{UserID: 3, Role: domain.RoleCustomer, APIKeyID: 7}, nil
+	}
+	return nil, errors.New("api key revoked")
+}
+
+func TestAuth(t *testing.T) {
+	rejectPartners := WithCheck(func(w http.ResponseWriter, r *http.Request, claims *domain.Claims) bool {
+		if claims.APIKeyID != 0 {
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return false
+		}
+		return true
+	})
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		headers       map[string]string
+		wantStatus    int
+		wantMessage   string
+		wantUserID    int
+		wantForwarded string
+	}{
+		{
+			name:        "missing header",
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "Authorization header required",
+		},
+		{
+			name:        "bad scheme",
+			headers:     map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "Invalid authorization header format",
+		},
+		{
+			name:        "missing token",
+			headers:     map[string]string{"Authorization": "Bearer "},
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "Invalid authorization header format",
+		},
+		{
+			name:        "expired token",
+			headers:     map[string]string{"Authorization": "Bearer expired-token"},
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "Invalid or expired token",
+		},
+		{
+			name:       "valid token",
+			headers:    map[string]string{"Authorization": "Bearer valid-token"},
+			wantStatus: http.StatusOK,
+			wantUserID: 1,
+		},
+		{
+			name:       "scheme is case-insensitive",
+			headers:    map[string]string{"Authorization": "bearer valid-token"},
+			wantStatus: http.StatusOK,
+			wantUserID: 1,
+		},
+		{
+			name:        "role mismatch",
+			opts:        []Option{WithRequiredRoles(domain.RoleAdmin)},
+			headers:     map[string]string{"Authorization": "Bearer valid-token"},
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "Insufficient role for this endpoint",
+		},
+		{
+			name:       "required role",
+			opts:       []Option{WithRequiredRoles(domain.RoleAdmin)},
+			headers:    map[string]string{"Authorization": "Bearer admin-token"},
+			wantStatus: http.StatusOK,
+			wantUserID: 2,
+		},
+		{
+			name:        "revoked API key",
+			headers:     map[string]string{domain.APIKeyHeader: "revoked-key"},
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "Invalid, expired or revoked API key",
+		},
+		{
+			name:          "API key is forwarded",
+			opts:          []Option{WithForwardedAuthorization()},
+			headers:       map[string]string{domain.APIKeyHeader: "valid-key"},
+			wantStatus:    http.StatusOK,
+			wantUserID:    3,
+			wantForwarded: domain.APIKeyScheme + " valid-key",
+		},
+		{
+			name:          "token is forwarded",
+			opts:          []Option{WithForwardedAuthorization()},
+			headers:       map[string]string{"Authorization": "Bearer valid-token"},
+			wantStatus:    http.StatusOK,
+			wantUserID:    1,
+			wantForwarded: "Bearer valid-token",
+		},
+		{
+			name:       "check rejects",
+			opts:       []Option{rejectPartners},
+			headers:    map[string]string{domain.APIKeyHeader: "valid-key"},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "trusted gateway identity",
+			opts: []Option{WithTrustedGateway(identity.NewVerifier(gatewaySecret))},
+			headers: map[string]string{
+				identity.UserIDHeader:   "9",
+				identity.UserRoleHeader: domain.RoleCourier,
+				identity.SecretHeader:   gatewaySecret,
+			},
+			wantStatus: http.StatusOK,
+			wantUserID: 9,
+		},
+		{
+			name: "untrusted gateway identity",
+			opts: []Option{WithTrustedGateway(identity.NewVerifier(gatewaySecret))},
+			headers: map[string]string{
+				identity.UserIDHeader:   "9",
+				identity.UserRoleHeader: domain.RoleCourier,
+				identity.SecretHeader:   "wrong-secret",
+			},
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "Invalid gateway identity headers",
+		},
+		{
+			name: "gateway headers without a trusted gateway",
+			headers: map[string]string{
+				identity.UserIDHeader:   "9",
+				identity.UserRoleHeader: domain.RoleCourier,
+				identity.SecretHeader:   gatewaySecret,
+			},
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "Authorization header required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims *domain.Claims
+			var forwarded string
+			handler := Auth(stubValidator{}, tt.opts...)(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ = authctx.ClaimsFrom(r.Context())
+				forwarded = authctx.AuthorizationFrom(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/deliveries", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantMessage != "" {
+				var body struct {
+					Error   string `json:"error"`
+					Message string `json:"message"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode error body: %v", err)
+				}
+				if body.Message != tt.wantMessage {
+					t.Errorf("expected message %q, got %q", tt.wantMessage, body.Message)
+				}
+			}
+			if tt.wantStatus != http.StatusOK {
+				if claims != nil {
+					t.Error("expected the handler not to be called")
+				}
+				return
+			}
+
+			if claims == nil || claims.UserID != tt.wantUserID {
+				t.Fatalf("expected claims for user %d in the context, got %+v", tt.wantUserID, claims)
+			}
+			if forwarded != tt.wantForwarded {
+				t.Errorf("expected forwarded authorization %q, got %q", tt.wantForwarded, forwarded)
+			}
+		})
+	}
+}
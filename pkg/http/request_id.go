@@ -0,0 +1,28 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// RequestID gives every request an ID clients can quote: a valid X-Request-ID
+// they sent, or a generated one. The ID is stored in the request context,
+// added to the request logger's fields and the active span, set on the request
+// headers so proxies forward it, and returned in the response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestid.Resolve(r.Header.Get(requestid.Header))
+		r.Header.Set(requestid.Header, id)
+		w.Header().Set(requestid.Header, id)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("delivertrack.request_id", id))
+
+		ctx := requestid.WithID(r.Context(), id)
+		ctx = logger.WithContext(ctx, zap.String("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
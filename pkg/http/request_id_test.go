@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "valid incoming ID", incoming: "client-req-42", keep: true},
+		{name: "missing ID", incoming: ""},
+		{name: "invalid ID", incoming: "bad id\r\nX-Injected: 1"},
+		{name: "overlong ID", incoming: strings.Repeat("a", requestid.MaxLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.InfoLevel)
+			logger.SetGlobal(&logger.Logger{Logger: zap.New(core)})
+			t.Cleanup(func() { logger.SetGlobal(nil) })
+
+			var ctxID, headerID string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = requestid.FromContext(r.Context())
+				headerID = r.Header.Get(requestid.Header)
+				logger.FromContext(r.Context()).Info("handled")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/deliveries", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.Header, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(requestid.Header)
+			if tt.keep && id != tt.incoming {
+				t.Errorf("expected the incoming ID to be returned, got %q", id)
+			}
+			if !tt.keep && (id == tt.incoming || !requestid.Valid(id)) {
+				t.Errorf("expected a generated ID, got %q", id)
+			}
+			if ctxID != id || headerID != id {
+				t.Errorf("expected the context and forwarded header to carry %q, got %q and %q", id, ctxID, headerID)
+			}
+			entries := logs.All()
+			if len(entries) != 1 || entries[0].ContextMap()["request_id"] != id {
+				t.Errorf("expected the request logger to carry request_id %q, got %v", id, entries)
+			}
+		})
+	}
+}
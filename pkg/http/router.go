@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+)
+
+// Router is an http.ServeMux for method and path-parameter patterns such as
+// "GET /deliveries/{id}/track". Unknown paths get a 404 and known paths
+// requested with another method a 405 with an Allow header, both with the
+// standard JSON error body instead of the mux's plain text.
+type Router struct {
+	*http.ServeMux
+}
+
+// NewRouter creates an empty router
+func NewRouter() *Router {
+	return &Router{ServeMux: http.NewServeMux()}
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern matches it
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The mux reports no pattern only for its own 404 and 405 handlers
+	if h, pattern := rt.Handler(r); pattern == "" {
+		h.ServeHTTP(&unmatchedWriter{ResponseWriter: w}, r)
+		return
+	}
+	rt.ServeMux.ServeHTTP(w, r)
+}
+
+// unmatchedWriter replaces the mux's plain text error with the JSON one,
+// keeping headers such as Allow
+type unmatchedWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *unmatchedWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.Header().Del("X-Content-Type-Options")
+	message := "No route matches this path"
+	if statusCode == http.StatusMethodNotAllowed {
+		message = "Method not allowed"
+	}
+	SendErrorResponse(w.ResponseWriter, message, statusCode)
+}
+
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	return len(b), nil
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("GET /deliveries/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("get " + r.PathValue("id")))
+	})
+	router.HandleFunc("PUT /deliveries/{id}/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("status " + r.PathValue("id")))
+	})
+	router.HandleFunc("POST /deliveries/{id}/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("status " + r.PathValue("id")))
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{name: "path parameter", method: http.MethodGet, path: "/deliveries/12", wantStatus: http.StatusOK, wantBody: "get 12"},
+		{name: "sub-resource", method: http.MethodPut, path: "/deliveries/12/status", wantStatus: http.StatusOK, wantBody: "status 12"},
+		{name: "extra segment", method: http.MethodGet, path: "/deliveries/12/track/extra", wantStatus: http.StatusNotFound},
+		{name: "unknown path", method: http.MethodGet, path: "/couriers", wantStatus: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodDelete, path: "/deliveries/12/status", wantStatus: http.StatusMethodNotAllowed, wantAllow: "POST, PUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if rec.Body.String() != tt.wantBody {
+					t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+				}
+				return
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("expected a JSON error body: %v", err)
+			}
+			if body.Error != http.StatusText(tt.wantStatus) {
+				t.Errorf("error = %q, want %q", body.Error, http.StatusText(tt.wantStatus))
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
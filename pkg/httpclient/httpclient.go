@@ -0,0 +1,259 @@
+// Package httpclient sends outbound HTTP requests with retries for transient
+// failures. Network errors and 429, 502, 503 and 504 responses are retried
+// with exponential backoff and jitter, waiting at least as long as the
+// server's Retry-After asks. Only GET, HEAD and OPTIONS requests and requests
+// marked with MarkRetryable are retried.
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Doer sends an HTTP request. *http.Client and *Client implement it, so
+// tests can inject failures underneath a Client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config holds retry and timeout settings
+type Config struct {
+	MaxRetries      int           // retries after the first attempt; zero disables retrying
+	Timeout         time.Duration // bound on all attempts and the waits between them; zero leaves it to the request context
+	AttemptTimeout  time.Duration // bound on a single attempt, including reading the body; zero leaves it to Timeout
+	RetryBackoff    time.Duration // wait before the first retry, doubled for each further one
+	MaxRetryBackoff time.Duration // cap on the backoff; a longer Retry-After is not waited for
+}
+
+// DefaultConfig returns the settings used for third-party APIs
+func DefaultConfig() Config {
+	return Config{
+		MaxRetries:      2,
+		Timeout:         15 * time.Second,
+		AttemptTimeout:  5 * time.Second,
+		RetryBackoff:    500 * time.Millisecond,
+		MaxRetryBackoff: 5 * time.Second,
+	}
+}
+
+// Client sends requests through a Doer, retrying transient failures
+type Client struct {
+	doer   Doer
+	config Config
+	logger *logger.Logger
+
+	// Replaced in tests to skip the waits
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New creates a client sending through doer, or a plain http.Client when nil
+func New(doer Doer, config Config, logger *logger.Logger) *Client {
+	if doer == nil {
+		doer = &http.Client{}
+	}
+	return &Client{doer: doer, config: config, logger: logger, sleep: sleep}
+}
+
+type retryableKey struct{}
+
+// MarkRetryable returns req marked as safe to retry, for non-idempotent
+// methods whose server deduplicates them. The body must be rewindable, which
+// it is when built by http.NewRequest from a bytes or strings reader.
+func MarkRetryable(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), retryableKey{}, true))
+}
+
+// retryable reports whether req may be sent more than once
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	marked, _ := req.Context().Value(retryableKey{}).(bool)
+	return marked
+}
+
+// Do sends req, retrying transient failures. Like http.Client it returns the
+// last response when every attempt got a retryable status, and the caller
+// must close its body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if c.config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+	}
+
+	retries := 0
+	if retryable(req) {
+		retries = c.config.MaxRetries
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(ctx, req, attempt)
+
+		delay, retry := c.retryDelay(ctx, attempt, resp, err)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			retry = false // the retry could not finish in time
+		}
+		retry = retry && attempt <= retries
+		c.logAttempt(req, attempt, resp, err, retry, delay)
+
+		if !retry {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			cancel()
+			return nil, fmt.Errorf("%s %s: gave up waiting to retry: %w", req.Method, req.URL.Host, err)
+		}
+	}
+}
+
+// attempt sends one copy of req bounded by the attempt timeout. The timeout
+// keeps running until the response body is closed.
+func (c *Client) attempt(ctx context.Context, req *http.Request, attempt int) (*http.Response, error) {
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.config.AttemptTimeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, c.config.AttemptTimeout)
+	}
+
+	r := req.Clone(attemptCtx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		r.Body = body
+	}
+
+	resp, err := c.doer.Do(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryDelay reports whether an attempt's outcome is worth retrying and how
+// long to wait first
+func (c *Client) retryDelay(ctx context.Context, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		// Per-attempt timeouts are retried, the caller's deadline is not
+		return c.backoff(attempt), ctx.Err() == nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+
+	if wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if c.config.MaxRetryBackoff > 0 && wait > c.config.MaxRetryBackoff {
+			return wait, false
+		}
+		return wait, true
+	}
+	return c.backoff(attempt), true
+}
+
+// backoff doubles the wait for each retry up to the cap, then picks a point in
+// its upper half so clients that failed together do not retry together
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.config.RetryBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if c.config.MaxRetryBackoff > 0 && delay >= c.config.MaxRetryBackoff {
+			delay = c.config.MaxRetryBackoff
+			break
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// logAttempt logs one attempt's outcome with the request's trace ID
+func (c *Client) logAttempt(req *http.Request, attempt int, resp *http.Response, err error, retry bool, delay time.Duration) {
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.String("host", req.URL.Host),
+		zap.String("path", req.URL.Path), // the query may carry API keys
+		zap.Int("attempt", attempt),
+	}
+	if err == nil && resp.StatusCode < http.StatusBadRequest {
+		c.logger.DebugWithFields(req.Context(), "Outbound HTTP request succeeded", append(fields, zap.Int("status", resp.StatusCode))...)
+		return
+	}
+
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	} else {
+		fields = append(fields, zap.Int("status", resp.StatusCode))
+	}
+	if retry {
+		fields = append(fields, zap.Duration("retry_in", delay))
+	}
+	fields = append(fields, zap.Bool("retrying", retry))
+	c.logger.WarnWithFields(req.Context(), "Outbound HTTP request failed", fields...)
+}
+
+// cancelBody releases a request's context once its response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
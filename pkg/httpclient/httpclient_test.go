@@ -0,0 +1,246 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap/zaptest"
+)
+
+// scriptedDoer answers each attempt with the next status, or fails it when
+// the status is zero, and records the bodies it was sent
+type scriptedDoer struct {
+	statuses   []int
+	retryAfter string
+	bodies     []string
+}
+
+func (d *scriptedDoer) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		d.bodies = append(d.bodies, string(body))
+	} else {
+		d.bodies = append(d.bodies, "")
+	}
+
+	status := d.statuses[0]
+	if len(d.statuses) > 1 {
+		d.statuses = d.statuses[1:]
+	}
+	if status == 0 {
+		return nil, errors.New("connection reset")
+	}
+
+	resp := &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok"))}
+	if d.retryAfter != "" {
+		resp.Header.Set("Retry-After", d.retryAfter)
+	}
+	return resp, nil
+}
+
+// newTestClient returns a client over doer that records its waits instead of sleeping
+func newTestClient(t *testing.T, doer Doer, config Config) (*Client, *[]time.Duration) {
+	client := New(doer, config, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	var waits []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return client, &waits
+}
+
+func testConfig() Config {
+	return Config{
+		MaxRetries:      3,
+		RetryBackoff:    100 * time.Millisecond,
+		MaxRetryBackoff: time.Second,
+	}
+}
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		attempts int
+		status   int
+	}{
+		{"succeeds after 503 and network error", http.MethodGet, []int{503, 0, 200}, 3, 200},
+		{"retries 429, 502 and 504", http.MethodGet, []int{429, 502, 504, 200}, 4, 200},
+		{"returns the last response when retries run out", http.MethodGet, []int{503}, 4, 503},
+		{"does not retry client errors", http.MethodGet, []int{404}, 1, 404},
+		{"does not retry POST", http.MethodPost, []int{503, 200}, 1, 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doer := &scriptedDoer{statuses: tt.statuses}
+			client, _ := newTestClient(t, doer, testConfig())
+
+			req, _ := http.NewRequest(tt.method, "http://example.com/search", nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if len(doer.bodies) != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, len(doer.bodies))
+			}
+		})
+	}
+}
+
+func TestClient_NetworkErrorAfterLastRetry(t *testing.T) {
+	client, _ := newTestClient(t, &scriptedDoer{statuses: []int{0}}, testConfig())
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/search", nil)
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("expected the last attempt's error, got %v", err)
+	}
+}
+
+func TestClient_MarkRetryableRewindsBody(t *testing.T) {
+	doer := &scriptedDoer{statuses: []int{503, 200}}
+	client, _ := newTestClient(t, doer, testConfig())
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/send", strings.NewReader(`{"id":1}`))
+	resp, err := client.Do(MarkRetryable(req))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(doer.bodies) != 2 || doer.bodies[0] != `{"id":1}` || doer.bodies[1] != `{"id":1}` {
+		t.Errorf("expected the body sent twice, got %q", doer.bodies)
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	doer := &scriptedDoer{statuses: []int{429, 200}, retryAfter: "1"}
+	client, waits := newTestClient(t, doer, testConfig())
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/search", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if len(*waits) != 1 || (*waits)[0] != time.Second {
+		t.Errorf("expected to wait the requested second, waited %v", *waits)
+	}
+
+	// Longer than the backoff cap is not waited for
+	doer = &scriptedDoer{statuses: []int{429, 200}, retryAfter: "120"}
+	client, _ = newTestClient(t, doer, testConfig())
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || len(doer.bodies) != 1 {
+		t.Errorf("expected 429 without retrying, got %d after %d attempts", resp.StatusCode, len(doer.bodies))
+	}
+}
+
+func TestClient_BackoffGrowsWithJitter(t *testing.T) {
+	client, waits := newTestClient(t, &scriptedDoer{statuses: []int{503}}, testConfig())
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/search", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	bounds := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	if len(*waits) != len(bounds) {
+		t.Fatalf("expected %d waits, got %v", len(bounds), *waits)
+	}
+	for i, wait := range *waits {
+		if wait < bounds[i]/2 || wait > bounds[i] {
+			t.Errorf("wait %d: expected between %v and %v, got %v", i, bounds[i]/2, bounds[i], wait)
+		}
+	}
+}
+
+func TestClient_StopsAtDeadline(t *testing.T) {
+	doer := &scriptedDoer{statuses: []int{503}}
+	client, _ := newTestClient(t, doer, testConfig())
+
+	// Retrying would wait past the caller's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com/search", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if len(doer.bodies) != 1 {
+		t.Errorf("expected a single attempt, got %d", len(doer.bodies))
+	}
+}
+
+// slowDoer blocks until the attempt's context is done, then succeeds once it
+// has been called enough times
+type slowDoer struct {
+	calls int
+}
+
+func (d *slowDoer) Do(req *http.Request) (*http.Response, error) {
+	d.calls++
+	if d.calls > 1 {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	}
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestClient_RetriesAttemptTimeout(t *testing.T) {
+	doer := &slowDoer{}
+	config := testConfig()
+	config.AttemptTimeout = 10 * time.Millisecond
+	client, _ := newTestClient(t, doer, config)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/search", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || doer.calls != 2 {
+		t.Errorf("expected success on the second attempt, got %d after %d", resp.StatusCode, doer.calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"3", 3 * time.Second, true},
+		{now.Add(2 * time.Second).Format(http.TimeFormat), 2 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value, now)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = (%v, %v), expected (%v, %v)", tt.value, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
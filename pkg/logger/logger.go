@@ -4,9 +4,11 @@ import (
 	"context"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -14,7 +16,13 @@ import (
 
 type contextKey string
 
-const correlationIDKey contextKey = "correlation_id"
+const (
+	correlationIDKey contextKey = "correlation_id"
+	fieldsKey        contextKey = "log_fields"
+)
+
+// global is the logger FromContext derives from; a no-op logger until SetGlobal
+var global atomic.Pointer[Logger]
 
 // Logger wraps zap.Logger
 type Logger struct {
@@ -64,12 +72,83 @@ func NewLogger(cfg config.LoggingConfig, serviceName string) (*Logger, error) {
 	return &Logger{Logger: zapLogger}, nil
 }
 
-// WithContext adds correlation ID from context
+// SetGlobal sets the logger used by FromContext, normally the service logger
+func SetGlobal(l *Logger) {
+	global.Store(l)
+}
+
+// Global returns the logger set by SetGlobal, or a no-op logger
+func Global() *Logger {
+	if l := global.Load(); l != nil {
+		return l
+	}
+	return &Logger{Logger: zap.NewNop()}
+}
+
+// WithContext returns a copy of ctx whose request logger carries fields in
+// addition to those already attached, e.g. the caller's identity or the
+// delivery being handled. A field replaces an attached one with the same key.
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	existing := contextFields(ctx)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	for _, field := range existing {
+		if !hasKey(fields, field.Key) {
+			merged = append(merged, field)
+		}
+	}
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey, merged)
+}
+
+// hasKey reports whether fields contains a field named key
+func hasKey(fields []zap.Field, key string) bool {
+	for _, field := range fields {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// WithUser attaches the authenticated caller to the request logger
+func WithUser(ctx context.Context, userID int, role string) context.Context {
+	return WithContext(ctx, zap.Int("user_id", userID), zap.String("role", role))
+}
+
+// FromContext returns the request logger for ctx: the global logger with the
+// fields attached to ctx and the active span's IDs. Without any it is the
+// global logger itself, so code outside a request can always log.
+func FromContext(ctx context.Context) *Logger {
+	return Global().WithContext(ctx)
+}
+
+// contextFields returns the fields attached with WithContext
+func contextFields(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(fieldsKey).([]zap.Field)
+	return fields
+}
+
+// WithContext adds the correlation ID, the active span's trace and span IDs,
+// and any fields attached with the package-level WithContext
 func (l *Logger) WithContext(ctx context.Context) *Logger {
+	fields := append([]zap.Field(nil), contextFields(ctx)...)
 	if correlationID, ok := ctx.Value(correlationIDKey).(string); ok {
-		return &Logger{Logger: l.Logger.With(zap.String("correlation_id", correlationID))}
+		fields = append(fields, zap.String("correlation_id", correlationID))
 	}
-	return l
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	return &Logger{Logger: l.Logger.With(fields...)}
 }
 
 // WithFields adds structured fields
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromContext_WithoutLoggerFallsBack(t *testing.T) {
+	// No global logger and no request fields; must not panic
+	FromContext(context.Background()).Info("ignored")
+}
+
+func TestWithContext_AttachesRequestFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	SetGlobal(&Logger{Logger: zap.New(core)})
+	defer global.Store(nil)
+
+	ctx := WithUser(context.Background(), 7, "courier")
+	ctx = WithContext(ctx, zap.Int("delivery_id", 1))
+	ctx = WithContext(ctx, zap.Int("delivery_id", 2))
+
+	FromContext(ctx).Info("request log")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["user_id"] != int64(7) || fields["role"] != "courier" {
+		t.Errorf("expected caller fields, got %v", fields)
+	}
+	if fields["delivery_id"] != int64(2) {
+		t.Errorf("expected delivery_id to be replaced, got %v", fields["delivery_id"])
+	}
+	if n := len(entries[0].Context); n != 3 {
+		t.Errorf("expected 3 fields, got %d", n)
+	}
+}
+
+func TestLoggerWithContext_UsesRequestFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	serviceLogger := &Logger{Logger: zap.New(core)}
+
+	ctx := WithUser(context.Background(), 3, "admin")
+	serviceLogger.InfoWithFields(ctx, "service log", zap.String("status", "ok"))
+
+	fields := logs.All()[0].ContextMap()
+	if fields["user_id"] != int64(3) || fields["status"] != "ok" {
+		t.Errorf("expected request and call fields, got %v", fields)
+	}
+}
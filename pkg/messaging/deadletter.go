@@ -0,0 +1,178 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDeadLetterNotFound  = errors.New("dead letter not found")
+	ErrDeadLetterExhausted = errors.New("dead letter exceeded its retry limit")
+	ErrDeadLetterRetried   = errors.New("dead letter was already retried")
+)
+
+// Headers set when a consumer dead-letters a message it failed to handle
+const (
+	HeaderOriginalExchange   = "x-original-exchange"
+	HeaderOriginalRoutingKey = "x-original-routing-key"
+	HeaderOriginalQueue      = "x-original-queue"
+	HeaderFailureReason      = "x-failure-reason"
+)
+
+// DeadLetterStatus tracks what happened to a dead-lettered message
+type DeadLetterStatus string
+
+const (
+	DeadLetterStatusPending DeadLetterStatus = "pending"
+	DeadLetterStatusRetried DeadLetterStatus = "retried"
+	DeadLetterStatusFailed  DeadLetterStatus = "failed"
+)
+
+// DeadLetter is a message that could not be handled by its consumer
+type DeadLetter struct {
+	ID             int              `json:"id"`
+	EventID        string           `json:"event_id"`
+	EventType      string           `json:"event_type"`
+	Exchange       string           `json:"exchange"`
+	RoutingKey     string           `json:"routing_key"`
+	Queue          string           `json:"queue"`
+	Reason         string           `json:"reason"`
+	RetryCount     int              `json:"retry_count"`
+	Status         DeadLetterStatus `json:"status"`
+	Body           json.RawMessage  `json:"body"`
+	DeadLetteredAt time.Time        `json:"dead_lettered_at"`
+}
+
+// DeadLetterStats summarizes the stored dead letters
+type DeadLetterStats struct {
+	Total             int            `json:"total"`
+	Pending           int            `json:"pending"`
+	PermanentlyFailed int            `json:"permanently_failed"`
+	ByRoutingKey      map[string]int `json:"by_routing_key"`
+}
+
+// DeadLetterStore keeps dead letters for inspection and retry
+type DeadLetterStore interface {
+	// Save stores a new dead letter, setting its ID
+	Save(ctx context.Context, letter *DeadLetter) error
+
+	// Get returns a dead letter, or ErrDeadLetterNotFound
+	Get(ctx context.Context, id int) (*DeadLetter, error)
+
+	// List returns up to limit dead letters, most recent first
+	List(ctx context.Context, limit int) ([]DeadLetter, error)
+
+	// Stats counts the stored dead letters
+	Stats(ctx context.Context) (DeadLetterStats, error)
+
+	// Transition moves a dead letter from one status to another, reporting
+	// false when it was not in the from status
+	Transition(ctx context.Context, id int, from, to DeadLetterStatus) (bool, error)
+}
+
+// DeadLetterManager stores dead-lettered messages and republishes them on
+// request. Messages that come back after maxRetries retries are marked
+// permanently failed.
+type DeadLetterManager struct {
+	store      DeadLetterStore
+	publisher  Publisher
+	logger     *logger.Logger
+	maxRetries int
+}
+
+// NewDeadLetterManager creates a manager keeping dead letters in store
+func NewDeadLetterManager(store DeadLetterStore, publisher Publisher, maxRetries int, logger *logger.Logger) *DeadLetterManager {
+	return &DeadLetterManager{
+		store:      store,
+		publisher:  publisher,
+		logger:     logger,
+		maxRetries: maxRetries,
+	}
+}
+
+// Record stores a dead letter. The message must not be acknowledged unless
+// this succeeds.
+func (m *DeadLetterManager) Record(ctx context.Context, letter DeadLetter) error {
+	letter.Status = DeadLetterStatusPending
+	if letter.RetryCount >= m.maxRetries {
+		letter.Status = DeadLetterStatusFailed
+	}
+	if letter.DeadLetteredAt.IsZero() {
+		letter.DeadLetteredAt = time.Now()
+	}
+
+	if err := m.store.Save(ctx, &letter); err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+
+	m.logger.WithFields(
+		zap.Int("dead_letter_id", letter.ID),
+		zap.String("event_id", letter.EventID),
+		zap.String("routing_key", letter.RoutingKey),
+		zap.String("reason", letter.Reason),
+		zap.Int("retry_count", letter.RetryCount),
+		zap.String("status", string(letter.Status)),
+	).Warn("Recorded dead-lettered message")
+	return nil
+}
+
+// List returns up to limit stored dead letters, most recent first
+func (m *DeadLetterManager) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	return m.store.List(ctx, limit)
+}
+
+// Stats counts the stored dead letters
+func (m *DeadLetterManager) Stats(ctx context.Context) (DeadLetterStats, error) {
+	return m.store.Stats(ctx)
+}
+
+// Retry republishes a dead letter to its original exchange and routing key.
+// The letter is claimed before publishing so concurrent retries publish it
+// once, and released again if publishing fails.
+func (m *DeadLetterManager) Retry(ctx context.Context, id int) (*DeadLetter, error) {
+	letter, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch letter.Status {
+	case DeadLetterStatusFailed:
+		return nil, ErrDeadLetterExhausted
+	case DeadLetterStatusRetried:
+		return nil, ErrDeadLetterRetried
+	}
+
+	var event Event
+	if err := json.Unmarshal(letter.Body, &event); err != nil {
+		// A body that cannot be decoded would fail the same way again
+		if _, terr := m.store.Transition(ctx, id, DeadLetterStatusPending, DeadLetterStatusFailed); terr != nil {
+			return nil, fmt.Errorf("failed to mark dead letter failed: %w", terr)
+		}
+		return nil, fmt.Errorf("%w: undecodable body: %v", ErrDeadLetterExhausted, err)
+	}
+	event.RetryCount = letter.RetryCount + 1
+
+	claimed, err := m.store.Transition(ctx, id, DeadLetterStatusPending, DeadLetterStatusRetried)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim dead letter: %w", err)
+	}
+	if !claimed {
+		return nil, ErrDeadLetterRetried
+	}
+
+	if err := m.publisher.Publish(ctx, letter.Exchange, letter.RoutingKey, event); err != nil {
+		if _, terr := m.store.Transition(context.WithoutCancel(ctx), id, DeadLetterStatusRetried, DeadLetterStatusPending); terr != nil {
+			m.logger.WithFields(zap.Int("dead_letter_id", id), zap.Error(terr)).Error("Failed to release dead letter after a failed retry")
+		}
+		return nil, fmt.Errorf("failed to republish dead letter: %w", err)
+	}
+
+	letter.Status = DeadLetterStatusRetried
+	return letter, nil
+}
@@ -0,0 +1,275 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// NewDeadLetterStore creates the dead letter store named by kind: "postgres"
+// (the default) keeps dead letters across restarts, "memory" keeps the most
+// recent capacity of them per process
+func NewDeadLetterStore(kind string, db *sql.DB, capacity int) (DeadLetterStore, error) {
+	switch kind {
+	case "", "postgres":
+		return NewPostgresDeadLetterStore(db), nil
+	case "memory":
+		return NewMemoryDeadLetterStore(capacity), nil
+	default:
+		return nil, fmt.Errorf("unknown dead letter store %q, expected postgres or memory", kind)
+	}
+}
+
+// MemoryDeadLetterStore keeps a sample of the most recent dead letters in
+// memory while counting every one it has seen
+type MemoryDeadLetterStore struct {
+	mu           sync.Mutex
+	capacity     int
+	letters      []*DeadLetter
+	nextID       int
+	total        int
+	failed       int
+	byRoutingKey map[string]int
+}
+
+// NewMemoryDeadLetterStore creates a store sampling up to capacity dead letters
+func NewMemoryDeadLetterStore(capacity int) *MemoryDeadLetterStore {
+	if capacity <= 0 {
+		capacity = 100
+	}
+	return &MemoryDeadLetterStore{
+		capacity:     capacity,
+		nextID:       1,
+		byRoutingKey: make(map[string]int),
+	}
+}
+
+// Save stores a dead letter, evicting the oldest sample when full
+func (s *MemoryDeadLetterStore) Save(ctx context.Context, letter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letter.ID = s.nextID
+	s.nextID++
+	s.total++
+	s.byRoutingKey[letter.RoutingKey]++
+	if letter.Status == DeadLetterStatusFailed {
+		s.failed++
+	}
+
+	stored := *letter
+	s.letters = append(s.letters, &stored)
+	if len(s.letters) > s.capacity {
+		s.letters = s.letters[len(s.letters)-s.capacity:]
+	}
+	return nil
+}
+
+// Get returns a sampled dead letter
+func (s *MemoryDeadLetterStore) Get(ctx context.Context, id int) (*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, letter := range s.letters {
+		if letter.ID == id {
+			found := *letter
+			return &found, nil
+		}
+	}
+	return nil, ErrDeadLetterNotFound
+}
+
+// List returns up to limit sampled dead letters, most recent first
+func (s *MemoryDeadLetterStore) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit <= 0 || limit > len(s.letters) {
+		limit = len(s.letters)
+	}
+
+	letters := make([]DeadLetter, 0, limit)
+	for i := len(s.letters) - 1; i >= 0 && len(letters) < limit; i-- {
+		letters = append(letters, *s.letters[i])
+	}
+	return letters, nil
+}
+
+// Stats returns counters over every dead letter seen since startup
+func (s *MemoryDeadLetterStore) Stats(ctx context.Context) (DeadLetterStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := DeadLetterStats{
+		Total:             s.total,
+		PermanentlyFailed: s.failed,
+		ByRoutingKey:      make(map[string]int, len(s.byRoutingKey)),
+	}
+	for key, count := range s.byRoutingKey {
+		stats.ByRoutingKey[key] = count
+	}
+	for _, letter := range s.letters {
+		if letter.Status == DeadLetterStatusPending {
+			stats.Pending++
+		}
+	}
+	return stats, nil
+}
+
+// Transition moves a sampled dead letter between statuses
+func (s *MemoryDeadLetterStore) Transition(ctx context.Context, id int, from, to DeadLetterStatus) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, letter := range s.letters {
+		if letter.ID != id {
+			continue
+		}
+		if letter.Status != from {
+			return false, nil
+		}
+		letter.Status = to
+		if to == DeadLetterStatusFailed {
+			s.failed++
+		}
+		return true, nil
+	}
+	return false, ErrDeadLetterNotFound
+}
+
+// PostgresDeadLetterStore implements the DeadLetterStore interface using PostgreSQL
+type PostgresDeadLetterStore struct {
+	db *sql.DB
+}
+
+// NewPostgresDeadLetterStore creates a new PostgreSQL dead letter store
+func NewPostgresDeadLetterStore(db *sql.DB) *PostgresDeadLetterStore {
+	return &PostgresDeadLetterStore{db: db}
+}
+
+const deadLetterColumns = `id, event_id, event_type, exchange, routing_key, queue, reason,
+	retry_count, status, body, dead_lettered_at`
+
+// Save inserts a dead letter, setting its ID
+func (s *PostgresDeadLetterStore) Save(ctx context.Context, letter *DeadLetter) error {
+	query := `
+		INSERT INTO dead_letters (event_id, event_type, exchange, routing_key, queue, reason,
+			retry_count, status, body, dead_lettered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	return s.db.QueryRowContext(ctx, query,
+		letter.EventID, letter.EventType, letter.Exchange, letter.RoutingKey, letter.Queue, letter.Reason,
+		letter.RetryCount, letter.Status, []byte(letter.Body), letter.DeadLetteredAt,
+	).Scan(&letter.ID)
+}
+
+// Get returns a dead letter by ID
+func (s *PostgresDeadLetterStore) Get(ctx context.Context, id int) (*DeadLetter, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters WHERE id = $1`
+
+	letter, err := scanDeadLetter(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return letter, nil
+}
+
+// List returns up to limit dead letters, most recent first
+func (s *PostgresDeadLetterStore) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters ORDER BY id DESC LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	letters := []DeadLetter{}
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, *letter)
+	}
+	return letters, rows.Err()
+}
+
+// Stats counts the stored dead letters by status and routing key
+func (s *PostgresDeadLetterStore) Stats(ctx context.Context) (DeadLetterStats, error) {
+	query := `
+		SELECT routing_key,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'pending'),
+			COUNT(*) FILTER (WHERE status = 'failed')
+		FROM dead_letters
+		GROUP BY routing_key
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return DeadLetterStats{}, err
+	}
+	defer rows.Close()
+
+	stats := DeadLetterStats{ByRoutingKey: make(map[string]int)}
+	for rows.Next() {
+		var routingKey string
+		var total, pending, failed int
+		if err := rows.Scan(&routingKey, &total, &pending, &failed); err != nil {
+			return DeadLetterStats{}, err
+		}
+		stats.Total += total
+		stats.Pending += pending
+		stats.PermanentlyFailed += failed
+		stats.ByRoutingKey[routingKey] = total
+	}
+	return stats, rows.Err()
+}
+
+// Transition updates a dead letter's status only while it is in from
+func (s *PostgresDeadLetterStore) Transition(ctx context.Context, id int, from, to DeadLetterStatus) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE dead_letters SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`, id, from, to)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if updated == 0 {
+		var exists bool
+		if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM dead_letters WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, ErrDeadLetterNotFound
+		}
+	}
+	return updated > 0, nil
+}
+
+type deadLetterScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeadLetter(row deadLetterScanner) (*DeadLetter, error) {
+	var letter DeadLetter
+	var body []byte
+	err := row.Scan(&letter.ID, &letter.EventID, &letter.EventType, &letter.Exchange, &letter.RoutingKey,
+		&letter.Queue, &letter.Reason, &letter.RetryCount, &letter.Status, &body, &letter.DeadLetteredAt)
+	if err != nil {
+		return nil, err
+	}
+	letter.Body = body
+	return &letter, nil
+}
@@ -0,0 +1,210 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap/zaptest"
+)
+
+// MockPublisher records published events
+type MockPublisher struct {
+	published []publishedEvent
+	err       error
+}
+
+type publishedEvent struct {
+	exchange   string
+	routingKey string
+	event      Event
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, exchange, routingKey string, event Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.published = append(m.published, publishedEvent{exchange, routingKey, event})
+	return nil
+}
+
+func (m *MockPublisher) Close() error {
+	return nil
+}
+
+func deadLetterBody(t *testing.T, retryCount int) json.RawMessage {
+	body, err := json.Marshal(Event{ID: "evt_1", Type: EventTypeDeliveryCreated, RetryCount: retryCount})
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	return body
+}
+
+func TestDeadLetterManager_ListAndStats(t *testing.T) {
+	manager := NewDeadLetterManager(NewMemoryDeadLetterStore(2), &MockPublisher{}, 3, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	ctx := context.Background()
+
+	for _, letter := range []DeadLetter{
+		{RoutingKey: "delivery.created"},
+		{RoutingKey: "delivery.created"},
+		{RoutingKey: "location.updated", RetryCount: 3},
+	} {
+		if err := manager.Record(ctx, letter); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	letters, err := manager.List(ctx, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(letters) != 2 {
+		t.Fatalf("expected the sample to be capped at 2, got %d", len(letters))
+	}
+	if letters[0].ID != 3 || letters[1].ID != 2 {
+		t.Errorf("expected most recent first, got IDs %d and %d", letters[0].ID, letters[1].ID)
+	}
+	if letters[0].Status != DeadLetterStatusFailed {
+		t.Errorf("expected a message past the retry limit to be permanently failed, got %s", letters[0].Status)
+	}
+
+	stats, err := manager.Stats(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Total != 3 || stats.Pending != 1 || stats.PermanentlyFailed != 1 || stats.ByRoutingKey["delivery.created"] != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestDeadLetterManager_Retry(t *testing.T) {
+	publisher := &MockPublisher{}
+	manager := NewDeadLetterManager(NewMemoryDeadLetterStore(10), publisher, 3, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	ctx := context.Background()
+
+	manager.Record(ctx, DeadLetter{Exchange: "delivery-events", RoutingKey: "delivery.created", RetryCount: 1, Body: deadLetterBody(t, 1)})
+	manager.Record(ctx, DeadLetter{Exchange: "delivery-events", RoutingKey: "delivery.created", RetryCount: 3, Body: deadLetterBody(t, 3)})
+
+	retried, err := manager.Retry(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retried.Status != DeadLetterStatusRetried {
+		t.Errorf("expected status retried, got %s", retried.Status)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("expected 1 published event, got %d", len(publisher.published))
+	}
+	published := publisher.published[0]
+	if published.exchange != "delivery-events" || published.routingKey != "delivery.created" || published.event.RetryCount != 2 {
+		t.Errorf("unexpected republished event: %+v", published)
+	}
+
+	tests := []struct {
+		name     string
+		id       int
+		expected error
+	}{
+		{name: "already retried", id: 1, expected: ErrDeadLetterRetried},
+		{name: "retry limit reached", id: 2, expected: ErrDeadLetterExhausted},
+		{name: "unknown id", id: 99, expected: ErrDeadLetterNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.Retry(ctx, tt.id); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetterManager_RetryReleasesOnPublishFailure(t *testing.T) {
+	publisher := &MockPublisher{err: errors.New("broker unavailable")}
+	manager := NewDeadLetterManager(NewMemoryDeadLetterStore(10), publisher, 3, &logger.Logger{Logger: zaptest.NewLogger(t)})
+	ctx := context.Background()
+
+	manager.Record(ctx, DeadLetter{Exchange: "delivery-events", RoutingKey: "delivery.created", Body: deadLetterBody(t, 0)})
+
+	if _, err := manager.Retry(ctx, 1); err == nil {
+		t.Fatal("expected the publish failure to be returned")
+	}
+
+	publisher.err = nil
+	if _, err := manager.Retry(ctx, 1); err != nil {
+		t.Fatalf("expected the dead letter to be retryable again, got %v", err)
+	}
+	if len(publisher.published) != 1 {
+		t.Errorf("expected 1 published event, got %d", len(publisher.published))
+	}
+}
+
+func TestDeadLetterFromDelivery(t *testing.T) {
+	body := deadLetterBody(t, 2)
+
+	tests := []struct {
+		name     string
+		delivery amqp.Delivery
+		expected DeadLetter
+	}{
+		{
+			name: "forwarded by consumer",
+			delivery: amqp.Delivery{
+				RoutingKey: "delivery.created",
+				Body:       body,
+				Headers: amqp.Table{
+					HeaderOriginalExchange:   "delivery-events",
+					HeaderOriginalRoutingKey: "delivery.created",
+					HeaderOriginalQueue:      "notification-events",
+					HeaderFailureReason:      "invalid event data: missing customer_id",
+				},
+			},
+			expected: DeadLetter{
+				Exchange:   "delivery-events",
+				RoutingKey: "delivery.created",
+				Queue:      "notification-events",
+				Reason:     "invalid event data: missing customer_id",
+				RetryCount: 2,
+			},
+		},
+		{
+			name: "rejected by broker",
+			delivery: amqp.Delivery{
+				RoutingKey: "delivery.created",
+				Body:       body,
+				Headers: amqp.Table{
+					"x-death": []interface{}{amqp.Table{
+						"exchange":     "delivery-events",
+						"queue":        "analytics-delivery-events",
+						"reason":       "rejected",
+						"routing-keys": []interface{}{"delivery.created"},
+					}},
+				},
+			},
+			expected: DeadLetter{
+				Exchange:   "delivery-events",
+				RoutingKey: "delivery.created",
+				Queue:      "analytics-delivery-events",
+				Reason:     "rejected",
+				RetryCount: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			letter := deadLetterFromDelivery(tt.delivery)
+			if letter.Exchange != tt.expected.Exchange || letter.RoutingKey != tt.expected.RoutingKey ||
+				letter.Queue != tt.expected.Queue || letter.Reason != tt.expected.Reason ||
+				letter.RetryCount != tt.expected.RetryCount {
+				t.Errorf("expected %+v, got %+v", tt.expected, letter)
+			}
+			if letter.EventType != EventTypeDeliveryCreated || letter.EventID != "evt_1" {
+				t.Errorf("expected event metadata from the body, got %q %q", letter.EventType, letter.EventID)
+			}
+		})
+	}
+}
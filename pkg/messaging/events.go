@@ -0,0 +1,479 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaVersions are the versions stamped on newly published payloads, per
+// event type. Payloads without a version predate versioning and are decoded
+// leniently. Versions only ever add optional fields, so consumers decode
+// payloads newer than they know and ignore what they don't; a change that
+// is not additive needs a new event type instead. Delivery events were
+// numbered together up to version 4: version 3 added route endpoints to
+// status and confirmation events, version 4 organization IDs to all of them.
+var schemaVersions = map[string]int{
+	EventTypeDeliveryCreated:       4,
+	EventTypeDeliveryStatusChanged: 4,
+	EventTypeDeliveryConfirmed:     4,
+	EventTypeDeliveryLate:          4,
+	EventTypeDeliveryCancelled:     4,
+	EventTypeLocationUpdated:       2, // delivery context and distances
+	EventTypeZoneEntered:           1,
+	EventTypeZoneExited:            1,
+	EventTypeCourierStale:          1,
+	EventTypeDeliveryETAUpdated:    1,
+	EventTypeTrackErased:           1,
+}
+
+// SchemaVersionOf returns the version stamped on newly published payloads of eventType
+func SchemaVersionOf(eventType string) int {
+	return schemaVersions[eventType]
+}
+
+var (
+	ErrUnsupportedSchemaVersion = errors.New("unsupported event schema version")
+	ErrInvalidEventData         = errors.New("invalid event data")
+)
+
+// Event types published on the delivery and tracking exchanges
+const (
+	EventTypeDeliveryCreated       = "delivery.created"
+	EventTypeDeliveryStatusChanged = "delivery.status_changed"
+	EventTypeDeliveryConfirmed     = "delivery.confirmed"
+	EventTypeDeliveryLate          = "delivery.late"
+	EventTypeDeliveryCancelled     = "delivery.cancelled"
+	EventTypeLocationUpdated       = "location.updated"
+	EventTypeZoneEntered           = "courier.zone_entered"
+	EventTypeZoneExited            = "courier.zone_exited"
+	EventTypeCourierStale          = "courier.stale"
+	EventTypeDeliveryETAUpdated    = "delivery.eta_updated"
+	EventTypeTrackErased           = "delivery.track_erased"
+)
+
+// Payload is a typed event body carried in Event.Data
+type Payload interface {
+	Validate() error
+}
+
+// DeliveryCreatedEvent is published when a delivery is created
+type DeliveryCreatedEvent struct {
+	SchemaVersion    int        `json:"schema_version"`
+	DeliveryID       int        `json:"delivery_id"`
+	OrgID            int        `json:"org_id,omitempty"` // the default organization when 0
+	TrackingNumber   string     `json:"tracking_number,omitempty"`
+	CustomerID       int        `json:"customer_id"`
+	CourierID        *int       `json:"courier_id"`
+	PickupLocation   string     `json:"pickup_location"`
+	DeliveryLocation string     `json:"delivery_location"`
+	Status           string     `json:"status"`
+	ScheduledDate    *time.Time `json:"scheduled_date"`
+	ScheduledEnd     *time.Time `json:"scheduled_end,omitempty"`
+	Notes            string     `json:"notes"`
+}
+
+// Validate checks required fields
+func (e DeliveryCreatedEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"customer_id", e.CustomerID > 0},
+	)
+}
+
+// Coordinates is a point in decimal degrees
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// DeliveryStatusChangedEvent is published when a delivery changes status.
+// Pickup and Dropoff are nil while the addresses are not geocoded; the zone
+// is the drop-off city.
+type DeliveryStatusChangedEvent struct {
+	SchemaVersion int          `json:"schema_version"`
+	DeliveryID    int          `json:"delivery_id"`
+	OrgID         int          `json:"org_id,omitempty"`
+	CustomerID    int          `json:"customer_id"`
+	CourierID     *int         `json:"courier_id"`
+	OldStatus     string       `json:"old_status"`
+	NewStatus     string       `json:"new_status"`
+	ScheduledDate *time.Time   `json:"scheduled_date"`
+	Notes         string       `json:"notes"`
+	UpdatedByRole string       `json:"updated_by_role"`
+	Pickup        *Coordinates `json:"pickup,omitempty"`
+	Dropoff       *Coordinates `json:"dropoff,omitempty"`
+	DeliveryZone  string       `json:"delivery_zone,omitempty"`
+}
+
+// Validate checks required fields
+func (e DeliveryStatusChangedEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"customer_id", e.CustomerID > 0},
+		requiredField{"new_status", e.NewStatus != ""},
+	)
+}
+
+// DeliveryConfirmedEvent is published when a courier confirms proof of
+// delivery. The route endpoints are as in DeliveryStatusChangedEvent.
+type DeliveryConfirmedEvent struct {
+	SchemaVersion int          `json:"schema_version"`
+	DeliveryID    int          `json:"delivery_id"`
+	OrgID         int          `json:"org_id,omitempty"`
+	CustomerID    int          `json:"customer_id"`
+	CourierID     *int         `json:"courier_id"`
+	RecipientName string       `json:"recipient_name"`
+	HasPhoto      bool         `json:"has_photo"`
+	HasSignature  bool         `json:"has_signature"`
+	DeliveredDate *time.Time   `json:"delivered_date"`
+	ScheduledDate *time.Time   `json:"scheduled_date"`
+	Pickup        *Coordinates `json:"pickup,omitempty"`
+	Dropoff       *Coordinates `json:"dropoff,omitempty"`
+	DeliveryZone  string       `json:"delivery_zone,omitempty"`
+}
+
+// Validate checks required fields
+func (e DeliveryConfirmedEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"customer_id", e.CustomerID > 0},
+		requiredField{"courier_id", e.CourierID != nil && *e.CourierID > 0},
+	)
+}
+
+// DeliveryLateEvent is published when a delivery is still not delivered after
+// its scheduled window ended
+type DeliveryLateEvent struct {
+	SchemaVersion int        `json:"schema_version"`
+	DeliveryID    int        `json:"delivery_id"`
+	OrgID         int        `json:"org_id,omitempty"`
+	CustomerID    int        `json:"customer_id"`
+	CourierID     *int       `json:"courier_id"`
+	Status        string     `json:"status"`
+	ScheduledDate *time.Time `json:"scheduled_date"`
+	ScheduledEnd  *time.Time `json:"scheduled_end"`
+}
+
+// Validate checks required fields
+func (e DeliveryLateEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"customer_id", e.CustomerID > 0},
+		requiredField{"scheduled_end", e.ScheduledEnd != nil},
+	)
+}
+
+// DeliveryCancelledEvent is published when a delivery is cancelled
+type DeliveryCancelledEvent struct {
+	SchemaVersion   int        `json:"schema_version"`
+	DeliveryID      int        `json:"delivery_id"`
+	OrgID           int        `json:"org_id,omitempty"`
+	CustomerID      int        `json:"customer_id"`
+	CourierID       *int       `json:"courier_id"`
+	PreviousStatus  string     `json:"previous_status"`
+	Reason          string     `json:"reason"`
+	ReasonCode      string     `json:"reason_code,omitempty"`
+	CancelledByRole string     `json:"cancelled_by_role"`
+	CancelledAt     *time.Time `json:"cancelled_at"`
+}
+
+// Validate checks required fields
+func (e DeliveryCancelledEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"customer_id", e.CustomerID > 0},
+		requiredField{"reason", e.Reason != ""},
+	)
+}
+
+// LocationRecordedEvent is published when a courier location is recorded.
+// The delivery's status, customer and assigned courier are as the tracking
+// service last saw them, at most a few seconds old, and are empty when it
+// could not reach the delivery service.
+type LocationRecordedEvent struct {
+	SchemaVersion          int      `json:"schema_version"`
+	DeliveryID             int      `json:"delivery_id"`
+	CourierID              int      `json:"courier_id"`
+	Latitude               float64  `json:"latitude"`
+	Longitude              float64  `json:"longitude"`
+	Accuracy               *float64 `json:"accuracy"`
+	Speed                  *float64 `json:"speed"`
+	Heading                *float64 `json:"heading"`
+	Altitude               *float64 `json:"altitude"`
+	DeliveryStatus         string   `json:"delivery_status,omitempty"` // e.g. "in_transit"
+	CustomerID             int      `json:"customer_id,omitempty"`
+	AssignedCourierID      *int     `json:"assigned_courier_id,omitempty"`
+	DistanceFromPreviousKm float64  `json:"distance_from_previous_km"` // 0 for the delivery's first point
+	CumulativeDistanceKm   float64  `json:"cumulative_distance_km"`
+}
+
+// Validate checks required fields
+func (e LocationRecordedEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"courier_id", e.CourierID > 0},
+	)
+}
+
+// ZoneTransitionEvent is published when a courier enters or exits a zone
+type ZoneTransitionEvent struct {
+	SchemaVersion int     `json:"schema_version"`
+	DeliveryID    int     `json:"delivery_id"`
+	CourierID     int     `json:"courier_id"`
+	ZoneName      string  `json:"zone_name"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+}
+
+// Validate checks required fields
+func (e ZoneTransitionEvent) Validate() error {
+	return requireFields(
+		requiredField{"courier_id", e.CourierID > 0},
+		requiredField{"zone_name", e.ZoneName != ""},
+	)
+}
+
+// CourierStaleEvent is published when the courier of an in-transit delivery
+// stops reporting locations
+type CourierStaleEvent struct {
+	SchemaVersion int        `json:"schema_version"`
+	DeliveryID    int        `json:"delivery_id"`
+	CourierID     int        `json:"courier_id"`
+	CustomerID    int        `json:"customer_id"`
+	LastSeenAt    *time.Time `json:"last_seen_at"` // nil if the courier never reported
+}
+
+// Validate checks required fields
+func (e CourierStaleEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"courier_id", e.CourierID > 0},
+	)
+}
+
+// DeliveryETAUpdatedEvent is published when a delivery's recomputed ETA is
+// pushed to its customer
+type DeliveryETAUpdatedEvent struct {
+	SchemaVersion       int       `json:"schema_version"`
+	DeliveryID          int       `json:"delivery_id"`
+	CourierID           int       `json:"courier_id"`
+	CustomerID          int       `json:"customer_id"`
+	ETASeconds          int64     `json:"eta_seconds"`
+	EstimatedArrival    time.Time `json:"estimated_arrival"`
+	DistanceRemainingKm float64   `json:"distance_remaining_km"`
+}
+
+// Validate checks required fields
+func (e DeliveryETAUpdatedEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"customer_id", e.CustomerID > 0},
+		requiredField{"estimated_arrival", !e.EstimatedArrival.IsZero()},
+	)
+}
+
+// TrackErasedEvent is published, for audit, when a delivery's raw track is
+// deleted on request
+type TrackErasedEvent struct {
+	SchemaVersion int    `json:"schema_version"`
+	DeliveryID    int    `json:"delivery_id"`
+	RequestedBy   int    `json:"requested_by"` // user ID of the admin who asked
+	PointsDeleted int64  `json:"points_deleted"`
+	Reason        string `json:"reason"`
+}
+
+// Validate checks required fields
+func (e TrackErasedEvent) Validate() error {
+	return requireFields(
+		requiredField{"delivery_id", e.DeliveryID > 0},
+		requiredField{"reason", e.Reason != ""},
+	)
+}
+
+// NewDeliveryCreatedEvent wraps a delivery created payload into an Event
+func NewDeliveryCreatedEvent(data DeliveryCreatedEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeDeliveryCreated)
+	return newTypedEvent(EventTypeDeliveryCreated, "delivery-service", "create_delivery", data, traceCtx)
+}
+
+// NewDeliveryStatusChangedEvent wraps a status changed payload into an Event
+func NewDeliveryStatusChangedEvent(data DeliveryStatusChangedEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeDeliveryStatusChanged)
+	return newTypedEvent(EventTypeDeliveryStatusChanged, "delivery-service", "update_delivery_status", data, traceCtx)
+}
+
+// NewDeliveryConfirmedEvent wraps a delivery confirmed payload into an Event
+func NewDeliveryConfirmedEvent(data DeliveryConfirmedEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeDeliveryConfirmed)
+	return newTypedEvent(EventTypeDeliveryConfirmed, "delivery-service", "confirm_delivery", data, traceCtx)
+}
+
+// NewDeliveryLateEvent wraps a late delivery payload into an Event
+func NewDeliveryLateEvent(data DeliveryLateEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeDeliveryLate)
+	return newTypedEvent(EventTypeDeliveryLate, "delivery-service", "late_delivery_check", data, traceCtx)
+}
+
+// NewDeliveryCancelledEvent wraps a delivery cancelled payload into an Event
+func NewDeliveryCancelledEvent(data DeliveryCancelledEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeDeliveryCancelled)
+	return newTypedEvent(EventTypeDeliveryCancelled, "delivery-service", "cancel_delivery", data, traceCtx)
+}
+
+// NewLocationRecordedEvent wraps a location payload into an Event
+func NewLocationRecordedEvent(data LocationRecordedEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeLocationUpdated)
+	return newTypedEvent(EventTypeLocationUpdated, "tracking-service", "record_location", data, traceCtx)
+}
+
+// NewZoneTransitionEvent wraps a zone transition payload into an Event of the
+// given type, either EventTypeZoneEntered or EventTypeZoneExited
+func NewZoneTransitionEvent(eventType string, data ZoneTransitionEvent, traceCtx *TraceContext) (Event, error) {
+	if eventType != EventTypeZoneEntered && eventType != EventTypeZoneExited {
+		return Event{}, fmt.Errorf("%w: unknown zone event type %q", ErrInvalidEventData, eventType)
+	}
+	data.SchemaVersion = SchemaVersionOf(eventType)
+	return newTypedEvent(eventType, "tracking-service", "zone_transition", data, traceCtx)
+}
+
+// NewCourierStaleEvent wraps a stale courier payload into an Event
+func NewCourierStaleEvent(data CourierStaleEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeCourierStale)
+	return newTypedEvent(EventTypeCourierStale, "tracking-service", "stale_courier_check", data, traceCtx)
+}
+
+// NewDeliveryETAUpdatedEvent wraps an ETA update payload into an Event
+func NewDeliveryETAUpdatedEvent(data DeliveryETAUpdatedEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeDeliveryETAUpdated)
+	return newTypedEvent(EventTypeDeliveryETAUpdated, "tracking-service", "eta_update", data, traceCtx)
+}
+
+// NewTrackErasedEvent wraps a track erasure payload into an Event
+func NewTrackErasedEvent(data TrackErasedEvent, traceCtx *TraceContext) (Event, error) {
+	data.SchemaVersion = SchemaVersionOf(EventTypeTrackErased)
+	return newTypedEvent(EventTypeTrackErased, "tracking-service", "erase_delivery_track", data, traceCtx)
+}
+
+// newTypedEvent validates a payload and stores it in the Event envelope
+func newTypedEvent(eventType, source, operation string, payload Payload, traceCtx *TraceContext) (Event, error) {
+	if err := payload.Validate(); err != nil {
+		return Event{}, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Event{}, fmt.Errorf("failed to unmarshal %s payload: %w", eventType, err)
+	}
+
+	return NewEventWithTrace(eventType, source, operation, data, traceCtx), nil
+}
+
+// DecodeData decodes an event's data into its typed payload and validates it.
+// Versions newer than the consumer knows are decoded too, their added fields
+// ignored; versions below 1 are rejected with ErrUnsupportedSchemaVersion.
+// Version-less events are accepted and numeric IDs sent as strings are converted.
+func DecodeData[T Payload](event Event) (T, error) {
+	var payload T
+
+	version, err := schemaVersion(event.Data)
+	if err != nil {
+		return payload, err
+	}
+
+	data := event.Data
+	if version == 0 {
+		if data, err = normalizeLegacyData(data, reflect.TypeOf(payload)); err != nil {
+			return payload, err
+		}
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return payload, fmt.Errorf("%w: %v", ErrInvalidEventData, err)
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return payload, fmt.Errorf("%w: %s: %v", ErrInvalidEventData, event.Type, err)
+	}
+
+	if err := payload.Validate(); err != nil {
+		return payload, fmt.Errorf("%s: %w", event.Type, err)
+	}
+
+	return payload, nil
+}
+
+// schemaVersion reads the payload version, returning 0 for version-less events
+func schemaVersion(data map[string]interface{}) (int, error) {
+	raw, ok := data["schema_version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+
+	version, ok := raw.(float64)
+	if !ok || version != float64(int(version)) {
+		return 0, fmt.Errorf("%w: schema_version %v", ErrInvalidEventData, raw)
+	}
+	if version < 1 {
+		return 0, fmt.Errorf("%w: %v", ErrUnsupportedSchemaVersion, version)
+	}
+	return int(version), nil
+}
+
+// normalizeLegacyData converts string values of integer fields to numbers.
+// Version-less events sent IDs both as strings and as numbers.
+func normalizeLegacyData(data map[string]interface{}, payloadType reflect.Type) (map[string]interface{}, error) {
+	normalized := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		normalized[k] = v
+	}
+
+	for i := 0; i < payloadType.NumField(); i++ {
+		field := payloadType.Field(i)
+		kind := field.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = field.Type.Elem().Kind()
+		}
+		if kind != reflect.Int {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		s, ok := normalized[name].(string)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %q is not an integer", ErrInvalidEventData, name, s)
+		}
+		normalized[name] = n
+	}
+
+	return normalized, nil
+}
+
+// requiredField pairs a field name with whether it is set
+type requiredField struct {
+	name string
+	ok   bool
+}
+
+// requireFields reports the first missing field
+func requireFields(fields ...requiredField) error {
+	for _, f := range fields {
+		if !f.ok {
+			return fmt.Errorf("%w: missing %s", ErrInvalidEventData, f.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// roundTrip simulates an event passing through the broker
+func roundTrip(t *testing.T, event Event) Event {
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	var decoded Event
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal event: %v", err)
+	}
+	return decoded
+}
+
+func TestDecodeData_RoundTrip(t *testing.T) {
+	courierID := 7
+	scheduled := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	event, err := NewDeliveryStatusChangedEvent(DeliveryStatusChangedEvent{
+		DeliveryID:    10,
+		CustomerID:    3,
+		CourierID:     &courierID,
+		NewStatus:     "in_transit",
+		ScheduledDate: &scheduled,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Type != EventTypeDeliveryStatusChanged {
+		t.Errorf("expected type %s, got %s", EventTypeDeliveryStatusChanged, event.Type)
+	}
+
+	data, err := DecodeData[DeliveryStatusChangedEvent](roundTrip(t, event))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.SchemaVersion != SchemaVersionOf(EventTypeDeliveryStatusChanged) || data.DeliveryID != 10 || data.CustomerID != 3 || data.NewStatus != "in_transit" {
+		t.Errorf("unexpected payload: %+v", data)
+	}
+	if data.CourierID == nil || *data.CourierID != 7 {
+		t.Errorf("expected courier 7, got %v", data.CourierID)
+	}
+	if data.ScheduledDate == nil || !data.ScheduledDate.Equal(scheduled) {
+		t.Errorf("expected scheduled date %v, got %v", scheduled, data.ScheduledDate)
+	}
+}
+
+func TestDecodeData_LegacyEvents(t *testing.T) {
+	// Version-less events sent IDs as strings or numbers
+	event := roundTrip(t, Event{
+		Type: EventTypeDeliveryStatusChanged,
+		Data: map[string]interface{}{
+			"delivery_id": "10",
+			"customer_id": 3,
+			"courier_id":  "7",
+			"new_status":  "delivered",
+		},
+	})
+
+	data, err := DecodeData[DeliveryStatusChangedEvent](event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.DeliveryID != 10 || data.CustomerID != 3 || data.CourierID == nil || *data.CourierID != 7 {
+		t.Errorf("unexpected payload: %+v", data)
+	}
+}
+
+func TestDecodeData_NewerVersions(t *testing.T) {
+	// A newer producer's added fields are ignored by older consumers
+	event := roundTrip(t, Event{
+		Type: EventTypeDeliveryCreated,
+		Data: map[string]interface{}{
+			"schema_version": SchemaVersionOf(EventTypeDeliveryCreated) + 1,
+			"delivery_id":    10,
+			"customer_id":    3,
+			"priority":       "express",
+		},
+	})
+
+	data, err := DecodeData[DeliveryCreatedEvent](event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.DeliveryID != 10 || data.CustomerID != 3 {
+		t.Errorf("unexpected payload: %+v", data)
+	}
+}
+
+func TestSchemaVersionOf(t *testing.T) {
+	if v := SchemaVersionOf(EventTypeDeliveryCreated); v != 4 {
+		t.Errorf("expected delivery events at version 4, got %d", v)
+	}
+	if v := SchemaVersionOf(EventTypeLocationUpdated); v != 2 {
+		t.Errorf("expected location events at version 2, got %d", v)
+	}
+	if v := SchemaVersionOf(EventTypeCourierStale); v != 1 {
+		t.Errorf("expected courier stale events at version 1, got %d", v)
+	}
+}
+
+func TestDecodeData_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		expected error
+	}{
+		{
+			name:     "invalid version",
+			data:     map[string]interface{}{"schema_version": 0, "delivery_id": 10, "customer_id": 3},
+			expected: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:     "missing required field",
+			data:     map[string]interface{}{"schema_version": 4, "delivery_id": 10},
+			expected: ErrInvalidEventData,
+		},
+		{
+			name:     "misspelled key",
+			data:     map[string]interface{}{"schema_version": 4, "delivery_id": 10, "customer": 3},
+			expected: ErrInvalidEventData,
+		},
+		{
+			name:     "legacy non-numeric id",
+			data:     map[string]interface{}{"delivery_id": "abc", "customer_id": 3},
+			expected: ErrInvalidEventData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := roundTrip(t, Event{Type: EventTypeDeliveryCreated, Data: tt.data})
+			if _, err := DecodeData[DeliveryCreatedEvent](event); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestNewDeliveryCreatedEvent_RejectsInvalidPayload(t *testing.T) {
+	if _, err := NewDeliveryCreatedEvent(DeliveryCreatedEvent{CustomerID: 3}, nil); !errors.Is(err, ErrInvalidEventData) {
+		t.Errorf("expected ErrInvalidEventData, got %v", err)
+	}
+}
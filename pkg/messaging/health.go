@@ -0,0 +1,62 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// errChannelClosed is recorded when a channel closes without a server error,
+// as it does on Close
+var errChannelClosed = errors.New("channel closed")
+
+// channelState records why a channel closed; the broker closes channels on
+// errors without closing the connection
+type channelState struct {
+	mu  sync.Mutex
+	err error
+}
+
+func watchChannel(channel *amqp.Channel) *channelState {
+	state := &channelState{}
+	closed := channel.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		amqpErr, ok := <-closed
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		if ok && amqpErr != nil {
+			state.err = amqpErr
+		} else {
+			state.err = errChannelClosed
+		}
+	}()
+	return state
+}
+
+// checkConnection reports a closed connection or channel
+func checkConnection(conn *amqp.Connection, state *channelState) error {
+	if conn == nil || conn.IsClosed() {
+		return errors.New("rabbitmq connection is closed")
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.err != nil {
+		return fmt.Errorf("rabbitmq channel is closed: %w", state.err)
+	}
+	return nil
+}
+
+// Check reports whether the publisher's connection and channel are open, for
+// readiness checks
+func (p *RabbitMQPublisher) Check(ctx context.Context) error {
+	return checkConnection(p.conn, p.state)
+}
+
+// Check reports whether the consumer's connection and channel are open, for
+// readiness checks
+func (c *RabbitMQConsumer) Check(ctx context.Context) error {
+	return checkConnection(c.conn, c.state)
+}
@@ -8,7 +8,12 @@ import (
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
 	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -19,8 +24,15 @@ type Event struct {
 	Source    string                 `json:"source"`
 	Timestamp int64                  `json:"timestamp"`
 	Data      map[string]interface{} `json:"data"`
+	// RetryCount is how many times the event was republished from the dead letter queue
+	RetryCount int `json:"retry_count,omitempty"`
+	// Replayed marks an event republished by an admin replay rather than
+	// emitted as it happened; its ID and Timestamp are the original ones
+	Replayed bool `json:"replayed,omitempty"`
 	// Tracing context for distributed tracing
 	TraceContext *TraceContext `json:"trace_context,omitempty"`
+	// RequestID is the ID of the client request that caused the event, see WithRequestID
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // TraceContext holds distributed tracing information
@@ -51,72 +63,90 @@ func NewEventWithTrace(eventType, source, operation string, data map[string]inte
 	return event
 }
 
-// ExtractTraceContextFromContext extracts trace context from Go context
+// WithRequestID returns event with RequestID set from ctx, unless the event
+// already carries one
+func WithRequestID(ctx context.Context, event Event) Event {
+	if event.RequestID == "" {
+		event.RequestID = requestid.FromContext(ctx)
+	}
+	return event
+}
+
+// ExtractTraceContextFromContext captures the active span from ctx so the
+// event can be correlated with the request that produced it. Without an active
+// span a new trace is started.
 func ExtractTraceContextFromContext(ctx context.Context, serviceName, operation string) *TraceContext {
-	// Check for trace context in context (you would implement this based on your tracing framework)
-	traceID := getTraceIDFromContext(ctx)
-	spanID := getSpanIDFromContext(ctx)
-	parentSpanID := getParentSpanIDFromContext(ctx)
-	
-	if traceID == "" {
-		// Generate new trace if none exists
-		traceID = GenerateTraceID()
-		spanID = GenerateSpanID()
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return &TraceContext{
+			TraceID:     newTraceID().String(),
+			SpanID:      newSpanID().String(),
+			ServiceName: serviceName,
+			Operation:   operation,
+		}
 	}
-	
+
 	return &TraceContext{
-		TraceID:      traceID,
-		SpanID:       spanID,
-		ParentSpanID: parentSpanID,
-		ServiceName:  serviceName,
-		Operation:    operation,
+		TraceID:     sc.TraceID().String(),
+		SpanID:      sc.SpanID().String(),
+		ServiceName: serviceName,
+		Operation:   operation,
 	}
 }
 
-// ContextWithTraceContext adds trace context to Go context
+// ContextWithTraceContext makes traceCtx the remote parent of spans started
+// from the returned context. Trace contexts with invalid IDs, such as those
+// stored before tracing used W3C IDs, are ignored.
 func ContextWithTraceContext(ctx context.Context, traceCtx *TraceContext) context.Context {
 	if traceCtx == nil {
 		return ctx
 	}
-	
-	// Add trace context to context (implement based on your tracing framework)
-	ctx = context.WithValue(ctx, "trace_id", traceCtx.TraceID)
-	ctx = context.WithValue(ctx, "span_id", traceCtx.SpanID)
-	if traceCtx.ParentSpanID != "" {
-		ctx = context.WithValue(ctx, "parent_span_id", traceCtx.ParentSpanID)
-	}
-	
-	return ctx
-}
 
-// Helper functions (implement based on your tracing framework)
-func getTraceIDFromContext(ctx context.Context) string {
-	if val := ctx.Value("trace_id"); val != nil {
-		return val.(string)
+	traceID, err := trace.TraceIDFromHex(traceCtx.TraceID)
+	if err != nil {
+		return ctx
 	}
-	return ""
+	spanID, err := trace.SpanIDFromHex(traceCtx.SpanID)
+	if err != nil {
+		return ctx
+	}
+
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
 }
 
-func getSpanIDFromContext(ctx context.Context) string {
-	if val := ctx.Value("span_id"); val != nil {
-		return val.(string)
-	}
-	return ""
+type eventIDKey struct{}
+
+// ContextWithEventID records the ID of the event being handled, so rows written
+// while handling it can be recognised when the event is redelivered
+func ContextWithEventID(ctx context.Context, eventID string) context.Context {
+	return context.WithValue(ctx, eventIDKey{}, eventID)
 }
 
-func getParentSpanIDFromContext(ctx context.Context) string {
-	if val := ctx.Value("parent_span_id"); val != nil {
-		return val.(string)
-	}
-	return ""
+// EventIDFromContext returns the ID stored by ContextWithEventID, or "" outside event handling
+func EventIDFromContext(ctx context.Context) string {
+	eventID, _ := ctx.Value(eventIDKey{}).(string)
+	return eventID
 }
 
-func GenerateTraceID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+type replayKey struct{}
+
+// ContextWithReplay marks the event being handled as a replay of one that
+// occurred at occurredAt, so handlers can rebuild state without repeating
+// side effects such as push or email
+func ContextWithReplay(ctx context.Context, occurredAt time.Time) context.Context {
+	return context.WithValue(ctx, replayKey{}, occurredAt)
 }
 
-func GenerateSpanID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+// ReplayFromContext returns when a replayed event occurred, and false outside
+// the handling of a replay
+func ReplayFromContext(ctx context.Context) (time.Time, bool) {
+	occurredAt, ok := ctx.Value(replayKey{}).(time.Time)
+	return occurredAt, ok
 }
 
 func generateEventID() string {
@@ -135,10 +165,17 @@ type Consumer interface {
 	Close() error
 }
 
+// Subscriber receives events on a queue of its own, so every instance of a
+// service sees them rather than sharing them out like a Consumer's queue
+type Subscriber interface {
+	Subscribe(exchange string, routingKeys []string, handler func(Event) error) error
+}
+
 // RabbitMQPublisher implements Publisher interface
 type RabbitMQPublisher struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
+	state   *channelState
 	logger  *logger.Logger
 }
 
@@ -158,6 +195,7 @@ func NewRabbitMQPublisher(url string, logger *logger.Logger) (*RabbitMQPublisher
 	return &RabbitMQPublisher{
 		conn:    conn,
 		channel: channel,
+		state:   watchChannel(channel),
 		logger:  logger,
 	}, nil
 }
@@ -178,12 +216,27 @@ func (p *RabbitMQPublisher) Publish(ctx context.Context, exchange, routingKey st
 		return fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
+	ctx, span := tracer.Start(ctx, exchange+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", routingKey),
+			attribute.String("messaging.message.id", event.ID),
+		),
+	)
+	defer span.End()
+
 	// Serialize event to JSON
+	event = WithRequestID(ctx, event)
 	body, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+
 	// Publish message
 	err = p.channel.Publish(
 		exchange,   // exchange
@@ -191,18 +244,22 @@ func (p *RabbitMQPublisher) Publish(ctx context.Context, exchange, routingKey st
 		false,      // mandatory
 		false,      // immediate
 		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			Timestamp:    time.Now(),
-			MessageId:    event.ID,
+			ContentType:   "application/json",
+			Body:          body,
+			Timestamp:     time.Now(),
+			MessageId:     event.ID,
 			CorrelationId: getCorrelationID(ctx),
+			Headers:       headers,
 		})
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
 	p.logger.WithContext(ctx).WithFields(
 		zap.String("event_id", event.ID),
+		zap.String("request_id", event.RequestID),
 		zap.String("exchange", exchange),
 		zap.String("routing_key", routingKey),
 		zap.String("event_type", event.Type),
@@ -223,9 +280,11 @@ func (p *RabbitMQPublisher) Close() error {
 
 // RabbitMQConsumer implements Consumer interface
 type RabbitMQConsumer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	logger  *logger.Logger
+	conn        *amqp.Connection
+	channel     *amqp.Channel
+	state       *channelState
+	logger      *logger.Logger
+	concurrency int // handlers running at once; 1 handles messages in order
 }
 
 // NewRabbitMQConsumer creates a new RabbitMQ consumer
@@ -242,12 +301,24 @@ func NewRabbitMQConsumer(url string, logger *logger.Logger) (*RabbitMQConsumer,
 	}
 
 	return &RabbitMQConsumer{
-		conn:    conn,
-		channel: channel,
-		logger:  logger,
+		conn:        conn,
+		channel:     channel,
+		state:       watchChannel(channel),
+		logger:      logger,
+		concurrency: 1,
 	}, nil
 }
 
+// SetConcurrency lets up to n messages be handled at once, for handlers that
+// wait on batched work. Messages are then no longer handled in order. It
+// applies to queues consumed afterwards.
+func (c *RabbitMQConsumer) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.concurrency = n
+}
+
 // Consume starts consuming messages from a queue
 func (c *RabbitMQConsumer) Consume(queue string, handler func(Event) error) error {
 	// Declare queue
@@ -258,13 +329,20 @@ func (c *RabbitMQConsumer) Consume(queue string, handler func(Event) error) erro
 		false, // exclusive
 		false, // no-wait
 		amqp.Table{
-			"x-dead-letter-exchange": "dead-letter-exchange",
+			"x-dead-letter-exchange": deadLetterExchange,
 		}, // arguments
 	)
 	if err != nil {
 		return fmt.Errorf("failed to declare queue: %w", err)
 	}
 
+	// Concurrent handlers need that many unacked messages in flight
+	if c.concurrency > 1 {
+		if err := c.channel.Qos(c.concurrency, 0, false); err != nil {
+			return fmt.Errorf("failed to set prefetch: %w", err)
+		}
+	}
+
 	// Start consuming
 	msgs, err := c.channel.Consume(
 		queue, // queue
@@ -279,6 +357,69 @@ func (c *RabbitMQConsumer) Consume(queue string, handler func(Event) error) erro
 		return fmt.Errorf("failed to register consumer: %w", err)
 	}
 
+	for i := 0; i < c.concurrency; i++ {
+		go func() {
+			for d := range msgs {
+				var event Event
+				if err := json.Unmarshal(d.Body, &event); err != nil {
+					c.logger.WithFields(
+						zap.Error(err),
+						zap.String("message_id", d.MessageId),
+					).Error("Failed to unmarshal event")
+					d.Nack(false, false) // Don't requeue
+					continue
+				}
+
+				c.handle(d, queue, event, handler)
+			}
+		}()
+	}
+
+	c.logger.WithFields(zap.String("queue", queue), zap.Int("concurrency", c.concurrency)).Info("Started consuming from queue")
+	return nil
+}
+
+// Subscribe binds a server-named queue to exchange for routingKeys and
+// consumes it. The queue is exclusive to this consumer's connection and
+// deleted with it, so events published while the connection is down are
+// missed.
+func (c *RabbitMQConsumer) Subscribe(exchange string, routingKeys []string, handler func(Event) error) error {
+	if err := c.channel.ExchangeDeclare(exchange, "topic", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	queue, err := c.channel.QueueDeclare(
+		"",    // name, chosen by the server
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		amqp.Table{
+			"x-dead-letter-exchange": deadLetterExchange,
+		}, // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	for _, routingKey := range routingKeys {
+		if err := c.channel.QueueBind(queue.Name, routingKey, exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue to %s: %w", routingKey, err)
+		}
+	}
+
+	msgs, err := c.channel.Consume(
+		queue.Name, // queue
+		"",         // consumer
+		false,      // auto-ack
+		true,       // exclusive
+		false,      // no-local
+		false,      // no-wait
+		nil,        // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register consumer: %w", err)
+	}
+
 	go func() {
 		for d := range msgs {
 			var event Event
@@ -291,24 +432,180 @@ func (c *RabbitMQConsumer) Consume(queue string, handler func(Event) error) erro
 				continue
 			}
 
-			if err := handler(event); err != nil {
+			c.handle(d, queue.Name, event, handler)
+		}
+	}()
+
+	c.logger.WithFields(zap.String("exchange", exchange), zap.String("queue", queue.Name)).Info("Subscribed to exchange")
+	return nil
+}
+
+// handle runs handler inside a consumer span continuing the publisher's trace.
+// The trace is read from the message headers, falling back to the event's own
+// trace context for messages published without them. The handler sees the
+// consumer span through event.TraceContext.
+func (c *RabbitMQConsumer) handle(d amqp.Delivery, queue string, event Event, handler func(Event) error) {
+	ctx := ContextWithTraceContext(context.Background(), event.TraceContext)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier(d.Headers))
+
+	ctx, span := tracer.Start(ctx, queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", d.Exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", d.RoutingKey),
+			attribute.String("messaging.message.id", event.ID),
+		),
+	)
+	defer span.End()
+
+	serviceName, operation := "", ""
+	if event.TraceContext != nil {
+		serviceName, operation = event.TraceContext.ServiceName, event.TraceContext.Operation
+	}
+	event.TraceContext = ExtractTraceContextFromContext(ctx, serviceName, operation)
+
+	if err := handler(event); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.logger.WithContext(ctx).WithFields(
+			zap.Error(err),
+			zap.String("event_id", event.ID),
+			zap.String("event_type", event.Type),
+			zap.String("request_id", event.RequestID),
+		).Error("Failed to handle event")
+		c.deadLetter(d, queue, err)
+		return
+	}
+
+	d.Ack(false)
+}
+
+// deadLetter forwards a message that failed handling to the dead letter
+// exchange together with the failure reason. If that fails the message is
+// rejected so the queue's own dead letter exchange still receives it.
+func (c *RabbitMQConsumer) deadLetter(d amqp.Delivery, queue string, reason error) {
+	err := c.channel.ExchangeDeclare(deadLetterExchange, "topic", true, false, false, false, nil)
+	if err == nil {
+		err = c.channel.Publish(deadLetterExchange, d.RoutingKey, false, false, amqp.Publishing{
+			ContentType:   d.ContentType,
+			Body:          d.Body,
+			Timestamp:     time.Now(),
+			MessageId:     d.MessageId,
+			CorrelationId: d.CorrelationId,
+			Headers: amqp.Table{
+				HeaderOriginalExchange:   d.Exchange,
+				HeaderOriginalRoutingKey: d.RoutingKey,
+				HeaderOriginalQueue:      queue,
+				HeaderFailureReason:      reason.Error(),
+			},
+		})
+	}
+	if err != nil {
+		c.logger.WithFields(
+			zap.Error(err),
+			zap.String("message_id", d.MessageId),
+		).Error("Failed to forward message to dead letter exchange")
+		d.Nack(false, false) // Don't requeue, send to dead letter queue
+		return
+	}
+	d.Ack(false)
+}
+
+// deadLetterStoreRetryDelay is how long a dead letter that could not be
+// stored waits before it is requeued, so an unavailable store is not retried
+// in a tight loop
+const deadLetterStoreRetryDelay = 5 * time.Second
+
+// ConsumeDeadLetters reads dead-lettered messages from queue and passes them
+// to handler. Messages are acknowledged only once handler has stored them and
+// requeued otherwise, so none are lost while the store is unavailable.
+func (c *RabbitMQConsumer) ConsumeDeadLetters(queue string, handler func(context.Context, DeadLetter) error) error {
+	msgs, err := c.channel.Consume(
+		queue, // queue
+		"",    // consumer
+		false, // auto-ack
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register dead letter consumer: %w", err)
+	}
+
+	go func() {
+		for d := range msgs {
+			if err := handler(context.Background(), deadLetterFromDelivery(d)); err != nil {
 				c.logger.WithFields(
 					zap.Error(err),
-					zap.String("event_id", event.ID),
-					zap.String("event_type", event.Type),
-				).Error("Failed to handle event")
-				d.Nack(false, false) // Don't requeue, send to dead letter queue
+					zap.String("message_id", d.MessageId),
+				).Error("Failed to store dead letter, requeueing")
+				time.Sleep(deadLetterStoreRetryDelay)
+				d.Nack(false, true)
 				continue
 			}
-
 			d.Ack(false)
 		}
 	}()
 
-	c.logger.WithFields(zap.String("queue", queue)).Info("Started consuming from queue")
+	c.logger.WithFields(zap.String("queue", queue)).Info("Started consuming dead letters")
 	return nil
 }
 
+// deadLetterFromDelivery reads the original destination and failure reason
+// from our own headers, falling back to the broker's x-death header
+func deadLetterFromDelivery(d amqp.Delivery) DeadLetter {
+	letter := DeadLetter{
+		EventID:        d.MessageId,
+		Exchange:       headerString(d.Headers, HeaderOriginalExchange),
+		RoutingKey:     headerString(d.Headers, HeaderOriginalRoutingKey),
+		Queue:          headerString(d.Headers, HeaderOriginalQueue),
+		Reason:         headerString(d.Headers, HeaderFailureReason),
+		Body:           json.RawMessage(d.Body),
+		DeadLetteredAt: d.Timestamp,
+	}
+
+	if deaths, ok := d.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if letter.Exchange == "" {
+				letter.Exchange = headerString(death, "exchange")
+			}
+			if letter.Queue == "" {
+				letter.Queue = headerString(death, "queue")
+			}
+			if letter.Reason == "" {
+				letter.Reason = headerString(death, "reason")
+			}
+			if keys, ok := death["routing-keys"].([]interface{}); ok && len(keys) > 0 && letter.RoutingKey == "" {
+				letter.RoutingKey, _ = keys[0].(string)
+			}
+		}
+	}
+	if letter.RoutingKey == "" {
+		letter.RoutingKey = d.RoutingKey
+	}
+
+	var event Event
+	if err := json.Unmarshal(d.Body, &event); err == nil {
+		letter.EventType = event.Type
+		letter.RetryCount = event.RetryCount
+		if letter.EventID == "" {
+			letter.EventID = event.ID
+		}
+	} else if letter.Reason == "" {
+		letter.Reason = "undecodable message"
+	}
+
+	return letter
+}
+
+// headerString reads a string value from AMQP headers
+func headerString(headers amqp.Table, key string) string {
+	v, _ := headers[key].(string)
+	return v
+}
+
 // Close closes the consumer
 func (c *RabbitMQConsumer) Close() error {
 	if c.channel != nil {
@@ -328,6 +625,12 @@ func getCorrelationID(ctx context.Context) string {
 	return ""
 }
 
+// Dead letter exchange and queue declared by SetupDeadLetterExchange
+const (
+	deadLetterExchange = "dead-letter-exchange"
+	DeadLetterQueue    = "dead-letter-queue"
+)
+
 // SetupDeadLetterExchange sets up dead letter exchange and queue
 func SetupDeadLetterExchange(url string) error {
 	conn, err := amqp.Dial(url)
@@ -0,0 +1,49 @@
+package messaging
+
+import (
+	"crypto/rand"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the publish and consume spans
+var tracer = otel.Tracer("github.com/Keneke-Einar/delivertrack/pkg/messaging")
+
+// headerCarrier adapts AMQP message headers to a propagation.TextMapCarrier
+type headerCarrier amqp.Table
+
+// Get returns the string value stored under key
+func (c headerCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+// Set stores value under key
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys lists the header names
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// newTraceID returns a random W3C trace ID
+func newTraceID() trace.TraceID {
+	var id trace.TraceID
+	rand.Read(id[:])
+	return id
+}
+
+// newSpanID returns a random W3C span ID
+func newSpanID() trace.SpanID {
+	var id trace.SpanID
+	rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,57 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContext_HeaderRoundTrip(t *testing.T) {
+	traceCtx := ExtractTraceContextFromContext(context.Background(), "delivery-service", "create_delivery")
+
+	ctx := ContextWithTraceContext(context.Background(), traceCtx)
+	headers := amqp.Table{}
+	propagation.TraceContext{}.Inject(ctx, headerCarrier(headers))
+
+	if _, ok := headers["traceparent"].(string); !ok {
+		t.Fatalf("expected traceparent header, got %v", headers)
+	}
+
+	extracted := propagation.TraceContext{}.Extract(context.Background(), headerCarrier(headers))
+	sc := trace.SpanContextFromContext(extracted)
+	if sc.TraceID().String() != traceCtx.TraceID {
+		t.Errorf("expected trace ID %s, got %s", traceCtx.TraceID, sc.TraceID())
+	}
+	if sc.SpanID().String() != traceCtx.SpanID {
+		t.Errorf("expected span ID %s, got %s", traceCtx.SpanID, sc.SpanID())
+	}
+}
+
+func TestContextWithTraceContext_IgnoresLegacyIDs(t *testing.T) {
+	ctx := ContextWithTraceContext(context.Background(), &TraceContext{
+		TraceID: "1700000000000000000",
+		SpanID:  "1700000000000000001",
+	})
+
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("expected legacy IDs to be ignored")
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	ctx := requestid.WithID(context.Background(), "req-1")
+
+	if event := WithRequestID(ctx, Event{ID: "evt"}); event.RequestID != "req-1" {
+		t.Errorf("expected the context's request ID, got %q", event.RequestID)
+	}
+	if event := WithRequestID(ctx, Event{RequestID: "req-0"}); event.RequestID != "req-0" {
+		t.Errorf("expected the event's own request ID to be kept, got %q", event.RequestID)
+	}
+	if event := WithRequestID(context.Background(), Event{}); event.RequestID != "" {
+		t.Errorf("expected no request ID, got %q", event.RequestID)
+	}
+}
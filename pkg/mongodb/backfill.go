@@ -0,0 +1,66 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BackfillLocationPoints adds the GeoJSON location field to courier location
+// documents that only carry scalar latitude/longitude fields. Documents are
+// updated batchSize at a time; the number of updated documents is returned.
+func (m *MongoDB) BackfillLocationPoints(ctx context.Context, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	filter := bson.M{
+		"location":  bson.M{"$exists": false},
+		"latitude":  bson.M{"$type": "number"},
+		"longitude": bson.M{"$type": "number"},
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"latitude": 1, "longitude": 1}).
+		SetLimit(int64(batchSize))
+
+	var total int64
+	for {
+		cursor, err := m.CourierLocationsCollection().Find(ctx, filter, opts)
+		if err != nil {
+			return total, fmt.Errorf("failed to find courier locations to backfill: %w", err)
+		}
+
+		var batch []struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			Latitude  float64            `bson:"latitude"`
+			Longitude float64            `bson:"longitude"`
+		}
+		if err := cursor.All(ctx, &batch); err != nil {
+			return total, fmt.Errorf("failed to decode courier locations to backfill: %w", err)
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+
+		models := make([]mongo.WriteModel, len(batch))
+		for i, doc := range batch {
+			models[i] = mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": doc.ID}).
+				SetUpdate(bson.M{"$set": bson.M{"location": NewPoint(doc.Longitude, doc.Latitude)}})
+		}
+
+		result, err := m.CourierLocationsCollection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return total, fmt.Errorf("failed to backfill courier locations: %w", err)
+		}
+		total += result.ModifiedCount
+
+		if len(batch) < batchSize {
+			return total, nil
+		}
+	}
+}
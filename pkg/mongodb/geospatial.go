@@ -2,13 +2,19 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrDuplicateClientPoint is returned by InsertCourierLocation for a location
+// whose courier already stored one with the same client point ID
+var ErrDuplicateClientPoint = errors.New("courier location already stored under this client point ID")
+
 // InsertCourierLocation inserts a new courier location record
 func (m *MongoDB) InsertCourierLocation(ctx context.Context, location *CourierLocation) error {
 	location.CreatedAt = time.Now()
@@ -17,12 +23,29 @@ func (m *MongoDB) InsertCourierLocation(ctx context.Context, location *CourierLo
 	}
 
 	_, err := m.CourierLocationsCollection().InsertOne(ctx, location)
+	if mongo.IsDuplicateKeyError(err) && location.ClientPointID != "" {
+		return ErrDuplicateClientPoint
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert courier location: %w", err)
 	}
 	return nil
 }
 
+// GetCourierLocationByClientPointID returns the location a courier stored
+// under a client point ID, or mongo.ErrNoDocuments
+func (m *MongoDB) GetCourierLocationByClientPointID(ctx context.Context, courierID int64, clientPointID string) (*CourierLocation, error) {
+	var location CourierLocation
+	err := m.CourierLocationsCollection().FindOne(ctx, bson.M{
+		"courier_id":      courierID,
+		"client_point_id": clientPointID,
+	}).Decode(&location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier location by client point ID: %w", err)
+	}
+	return &location, nil
+}
+
 // GetLatestCourierLocation returns the most recent location for a courier
 func (m *MongoDB) GetLatestCourierLocation(ctx context.Context, courierID int64) (*CourierLocation, error) {
 	opts := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})
@@ -59,6 +82,38 @@ func (m *MongoDB) GetLatestLocationByDeliveryID(ctx context.Context, deliveryID
 	return &location, nil
 }
 
+// GetDeliveryTrackVersion counts a delivery's locations and returns the
+// latest timestamp among them, zero when there are none
+func (m *MongoDB) GetDeliveryTrackVersion(ctx context.Context, deliveryID int64) (int64, time.Time, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"delivery_id": deliveryID}},
+		{"$group": bson.M{
+			"_id":       nil,
+			"points":    bson.M{"$sum": 1},
+			"latest_at": bson.M{"$max": "$timestamp"},
+		}},
+	}
+
+	cursor, err := m.CourierLocationsCollection().Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get delivery track version: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var versions []struct {
+		Points   int64     `bson:"points"`
+		LatestAt time.Time `bson:"latest_at"`
+	}
+	if err := cursor.All(ctx, &versions); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to decode delivery track version: %w", err)
+	}
+	if len(versions) == 0 {
+		return 0, time.Time{}, nil
+	}
+
+	return versions[0].Points, versions[0].LatestAt, nil
+}
+
 // GetCourierLocationHistory returns location history for a courier within a time range
 func (m *MongoDB) GetCourierLocationHistory(ctx context.Context, courierID int64, since time.Time, limit int64) ([]CourierLocation, error) {
 	filter := bson.M{
@@ -109,6 +164,187 @@ func (m *MongoDB) GetLocationHistoryByDeliveryID(ctx context.Context, deliveryID
 	return locations, nil
 }
 
+// GetLocationsByDeliveryID returns up to limit of a delivery's most recent locations
+// in the time window, newest first. A nil from or to leaves that end of the window open.
+func (m *MongoDB) GetLocationsByDeliveryID(ctx context.Context, deliveryID int64, from, to *time.Time, limit int64) ([]CourierLocation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := m.CourierLocationsCollection().Find(ctx, deliveryWindowFilter(deliveryID, from, to), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location history for delivery: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var locations []CourierLocation
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, fmt.Errorf("failed to decode locations for delivery: %w", err)
+	}
+
+	return locations, nil
+}
+
+// deliveryWindowFilter matches a delivery's locations at or after from and before to
+func deliveryWindowFilter(deliveryID int64, from, to *time.Time) bson.M {
+	return withTimeWindow(bson.M{"delivery_id": deliveryID}, from, to)
+}
+
+// withTimeWindow narrows filter to locations at or after from and before to
+func withTimeWindow(filter bson.M, from, to *time.Time) bson.M {
+	window := bson.M{}
+	if from != nil {
+		window["$gte"] = *from
+	}
+	if to != nil {
+		window["$lt"] = *to
+	}
+	if len(window) > 0 {
+		filter["timestamp"] = window
+	}
+	return filter
+}
+
+// StreamLocationsByDeliveryID passes a delivery's locations to fn oldest first,
+// decoding one document at a time so long tracks are never held in memory.
+// A nil from or to leaves that end of the time window open.
+func (m *MongoDB) StreamLocationsByDeliveryID(ctx context.Context, deliveryID int64, from, to *time.Time, fn func(*CourierLocation) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := m.CourierLocationsCollection().Find(ctx, deliveryWindowFilter(deliveryID, from, to), opts)
+	if err != nil {
+		return fmt.Errorf("failed to stream locations for delivery: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var location CourierLocation
+		if err := cursor.Decode(&location); err != nil {
+			return fmt.Errorf("failed to decode location for delivery: %w", err)
+		}
+		if err := fn(&location); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// StreamCourierLocations passes a courier's locations to fn oldest first,
+// decoding one document at a time. A nil from or to leaves that end of the
+// time window open.
+func (m *MongoDB) StreamCourierLocations(ctx context.Context, courierID int64, from, to *time.Time, fn func(*CourierLocation) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := m.CourierLocationsCollection().Find(ctx, withTimeWindow(bson.M{"courier_id": courierID}, from, to), opts)
+	if err != nil {
+		return fmt.Errorf("failed to stream courier locations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var location CourierLocation
+		if err := cursor.Decode(&location); err != nil {
+			return fmt.Errorf("failed to decode courier location: %w", err)
+		}
+		if err := fn(&location); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// BoundingBox is a rectangle of longitudes and latitudes
+type BoundingBox struct {
+	MinLongitude, MinLatitude, MaxLongitude, MaxLatitude float64
+}
+
+// polygon returns the box as a closed GeoJSON polygon ring
+func (b BoundingBox) polygon() bson.M {
+	return bson.M{
+		"type": "Polygon",
+		"coordinates": [][][]float64{{
+			{b.MinLongitude, b.MinLatitude},
+			{b.MaxLongitude, b.MinLatitude},
+			{b.MaxLongitude, b.MaxLatitude},
+			{b.MinLongitude, b.MaxLatitude},
+			{b.MinLongitude, b.MinLatitude},
+		}},
+	}
+}
+
+// GetLatestCourierLocations returns the latest location of each courier that
+// reported since since, ordered by courier ID, in one aggregation. Only the
+// listed couriers are considered unless courierIDs is nil, and only points
+// recorded in one of the listed organizations unless orgIDs is nil; an orgID
+// of 0 matches points stored without one. The box is matched
+// first, so the geo index narrows the points grouped, and a courier whose
+// latest point in the box has a newer one outside it is dropped rather than
+// shown where they used to be.
+func (m *MongoDB) GetLatestCourierLocations(ctx context.Context, since time.Time, courierIDs, orgIDs []int64, box *BoundingBox, limit int64) ([]CourierLocation, error) {
+	match := bson.M{"timestamp": bson.M{"$gte": since}}
+	if courierIDs != nil {
+		match["courier_id"] = bson.M{"$in": courierIDs}
+	}
+	if orgIDs != nil {
+		orgs := make(bson.A, len(orgIDs))
+		for i, orgID := range orgIDs {
+			if orgID == 0 {
+				orgs[i] = nil // also matches documents without the field
+			} else {
+				orgs[i] = orgID
+			}
+		}
+		match["org_id"] = bson.M{"$in": orgs}
+	}
+	if box != nil {
+		match["location"] = bson.M{"$geoWithin": bson.M{"$geometry": box.polygon()}}
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": bson.D{{Key: "courier_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{"$group": bson.M{"_id": "$courier_id", "location": bson.M{"$first": "$$ROOT"}}},
+		{"$replaceRoot": bson.M{"newRoot": "$location"}},
+	}
+	if box != nil {
+		// Each candidate is checked for a newer point on the courier and
+		// timestamp index
+		pipeline = append(pipeline,
+			bson.M{"$lookup": bson.M{
+				"from": m.CourierLocationsCollection().Name(),
+				"let":  bson.M{"courier": "$courier_id", "at": "$timestamp"},
+				"pipeline": []bson.M{
+					{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$courier_id", "$$courier"}},
+						bson.M{"$gt": bson.A{"$timestamp", "$$at"}},
+					}}}},
+					{"$limit": 1},
+					{"$project": bson.M{"_id": 1}},
+				},
+				"as": "newer",
+			}},
+			bson.M{"$match": bson.M{"newer": bson.M{"$size": 0}}},
+			bson.M{"$project": bson.M{"newer": 0}},
+		)
+	}
+	pipeline = append(pipeline, bson.M{"$sort": bson.M{"courier_id": 1}}, bson.M{"$limit": limit})
+
+	cursor, err := m.CourierLocationsCollection().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest courier locations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var locations []CourierLocation
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, fmt.Errorf("failed to decode latest courier locations: %w", err)
+	}
+
+	return locations, nil
+}
+
 // FindCouriersNearPoint finds couriers within a specified radius (in meters) of a point
 func (m *MongoDB) FindCouriersNearPoint(ctx context.Context, longitude, latitude float64, radiusMeters float64, limit int64) ([]CourierLocation, error) {
 	// Use $geoNear aggregation for finding nearby couriers
@@ -290,6 +526,39 @@ func (m *MongoDB) DeleteOldCourierLocations(ctx context.Context, olderThan time.
 	return result.DeletedCount, nil
 }
 
+// DeleteLocationsByDeliveryID removes every location record of a delivery
+func (m *MongoDB) DeleteLocationsByDeliveryID(ctx context.Context, deliveryID int64) (int64, error) {
+	result, err := m.CourierLocationsCollection().DeleteMany(ctx, bson.M{"delivery_id": deliveryID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete delivery locations: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// UpsertTrackSummary stores a delivery's track summary, replacing any earlier one
+func (m *MongoDB) UpsertTrackSummary(ctx context.Context, summary *TrackSummary) error {
+	_, err := m.TrackSummariesCollection().ReplaceOne(
+		ctx,
+		bson.M{"delivery_id": summary.DeliveryID},
+		summary,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store track summary: %w", err)
+	}
+	return nil
+}
+
+// GetTrackSummary returns the summary kept for a purged delivery track
+func (m *MongoDB) GetTrackSummary(ctx context.Context, deliveryID int64) (*TrackSummary, error) {
+	var summary TrackSummary
+	err := m.TrackSummariesCollection().FindOne(ctx, bson.M{"delivery_id": deliveryID}).Decode(&summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track summary: %w", err)
+	}
+	return &summary, nil
+}
+
 // GetCourierLocationCount returns the total number of location records for a courier
 func (m *MongoDB) GetCourierLocationCount(ctx context.Context, courierID int64) (int64, error) {
 	count, err := m.CourierLocationsCollection().CountDocuments(
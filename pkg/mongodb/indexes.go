@@ -0,0 +1,136 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index names, fixed so that repeated startups recognise existing indexes
+const (
+	locationGeoIndex         = "location_2dsphere"
+	courierTimestampIndex    = "courier_id_timestamp"
+	deliveryTimestampIndex   = "delivery_id_timestamp"
+	timestampIndex           = "timestamp_-1" // the name scripts/mongo-init.js gives it
+	clientPointIndex         = "courier_id_client_point_id_unique"
+	locationRetentionIndex   = "created_at_ttl"
+	zoneGeometryIndex        = "geometry_2dsphere"
+	summaryDeliveryIndex     = "delivery_id_unique"
+	indexNotFoundCode        = 27
+	indexOptionsConflictCode = 85
+)
+
+// EnsureIndexes creates the indexes the geospatial queries rely on. It is safe
+// to call on every startup. A positive locationRetention adds a TTL index that
+// expires courier locations that long after they were stored; zero disables
+// expiry and removes a previously created TTL index.
+func (m *MongoDB) EnsureIndexes(ctx context.Context, locationRetention time.Duration) error {
+	locations := m.CourierLocationsCollection()
+
+	_, err := locations.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "location", Value: "2dsphere"}},
+			Options: options.Index().SetName(locationGeoIndex),
+		},
+		{
+			Keys:    bson.D{{Key: "courier_id", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName(courierTimestampIndex),
+		},
+		{
+			Keys:    bson.D{{Key: "delivery_id", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName(deliveryTimestampIndex),
+		},
+		{
+			// Windows the fleet map's latest-per-courier aggregation
+			Keys:    bson.D{{Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName(timestampIndex),
+		},
+		{
+			// Dedupes re-uploaded points; points without a client ID are not indexed
+			Keys: bson.D{{Key: "courier_id", Value: 1}, {Key: "client_point_id", Value: 1}},
+			Options: options.Index().
+				SetName(clientPointIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"client_point_id": bson.M{"$type": "string"}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create courier location indexes: %w", err)
+	}
+
+	if err := m.ensureLocationRetention(ctx, locationRetention); err != nil {
+		return err
+	}
+
+	_, err = m.DeliveryZonesCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "geometry", Value: "2dsphere"}},
+		Options: options.Index().SetName(zoneGeometryIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create delivery zone indexes: %w", err)
+	}
+
+	_, err = m.TrackSummariesCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "delivery_id", Value: 1}},
+		Options: options.Index().SetName(summaryDeliveryIndex).SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create track summary indexes: %w", err)
+	}
+
+	return nil
+}
+
+// ensureLocationRetention creates, updates or drops the courier location TTL index
+func (m *MongoDB) ensureLocationRetention(ctx context.Context, retention time.Duration) error {
+	locations := m.CourierLocationsCollection()
+
+	if retention <= 0 {
+		_, err := locations.Indexes().DropOne(ctx, locationRetentionIndex)
+		if err != nil && !hasErrorCode(err, indexNotFoundCode) {
+			return fmt.Errorf("failed to drop courier location TTL index: %w", err)
+		}
+		return nil
+	}
+
+	seconds := int32(retention / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	_, err := locations.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetName(locationRetentionIndex).SetExpireAfterSeconds(seconds),
+	})
+	if err == nil {
+		return nil
+	}
+	if !hasErrorCode(err, indexOptionsConflictCode) {
+		return fmt.Errorf("failed to create courier location TTL index: %w", err)
+	}
+
+	// The retention changed since the index was created
+	err = m.Database.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: locations.Name()},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: locationRetentionIndex},
+			{Key: "expireAfterSeconds", Value: seconds},
+		}},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to update courier location TTL index: %w", err)
+	}
+
+	return nil
+}
+
+// hasErrorCode reports whether err is a server error with the given code
+func hasErrorCode(err error, code int) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(code)
+}
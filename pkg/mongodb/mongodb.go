@@ -6,8 +6,12 @@ import (
 	"log"
 	"time"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
 
 // MongoDB represents a MongoDB connection
@@ -16,17 +20,27 @@ type MongoDB struct {
 	Database *mongo.Database
 }
 
-// CourierLocation represents a courier's location with GeoJSON format
+// CourierLocation represents a courier's location with GeoJSON format.
+// Latitude and Longitude duplicate Location while older readers migrate.
 type CourierLocation struct {
 	CourierID  int64     `bson:"courier_id" json:"courier_id"`
 	DeliveryID int64     `bson:"delivery_id" json:"delivery_id"`
 	Location   GeoJSON   `bson:"location" json:"location"`
+	Latitude   float64   `bson:"latitude" json:"latitude"`
+	Longitude  float64   `bson:"longitude" json:"longitude"`
 	Timestamp  time.Time `bson:"timestamp" json:"timestamp"`
-	Speed      float64   `bson:"speed,omitempty" json:"speed,omitempty"`         // km/h
-	Heading    float64   `bson:"heading,omitempty" json:"heading,omitempty"`     // degrees
-	Accuracy   float64   `bson:"accuracy,omitempty" json:"accuracy,omitempty"`   // meters
-	Altitude   float64   `bson:"altitude,omitempty" json:"altitude,omitempty"`   // meters
+	Speed      float64   `bson:"speed,omitempty" json:"speed,omitempty"`             // km/h
+	Heading    float64   `bson:"heading,omitempty" json:"heading,omitempty"`         // degrees
+	Accuracy   float64   `bson:"accuracy,omitempty" json:"accuracy,omitempty"`       // meters
+	Altitude   float64   `bson:"altitude,omitempty" json:"altitude,omitempty"`       // meters
+	DistanceKm float64   `bson:"distance_km,omitempty" json:"distance_km,omitempty"` // travelled along the delivery up to this point
 	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	OrgID      int64     `bson:"org_id,omitempty" json:"org_id,omitempty"` // unset on points stored before organizations
+
+	// Set for points the courier app may upload more than once. Timestamp
+	// is RecordedAt when the app sent one; CreatedAt stays the server's time.
+	ClientPointID string     `bson:"client_point_id,omitempty" json:"client_point_id,omitempty"` // unique per courier
+	RecordedAt    *time.Time `bson:"recorded_at,omitempty" json:"recorded_at,omitempty"`
 }
 
 // DeliveryZone represents a geofenced delivery zone
@@ -40,6 +54,21 @@ type DeliveryZone struct {
 	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
 }
 
+// TrackSummary is kept for a delivery after its courier locations are purged
+type TrackSummary struct {
+	DeliveryID      int64     `bson:"delivery_id" json:"delivery_id"`
+	CourierID       int64     `bson:"courier_id" json:"courier_id"`
+	Start           GeoJSON   `bson:"start" json:"start"`
+	StartedAt       time.Time `bson:"started_at" json:"started_at"`
+	End             GeoJSON   `bson:"end" json:"end"`
+	EndedAt         time.Time `bson:"ended_at" json:"ended_at"`
+	PointCount      int64     `bson:"point_count" json:"point_count"`
+	DistanceKm      float64   `bson:"distance_km" json:"distance_km"`
+	DurationSeconds float64   `bson:"duration_seconds" json:"duration_seconds"`
+	Reason          string    `bson:"reason" json:"reason"`
+	PurgedAt        time.Time `bson:"purged_at" json:"purged_at"`
+}
+
 // GeoJSON represents a GeoJSON object for MongoDB geospatial queries
 type GeoJSON struct {
 	Type        string      `bson:"type" json:"type"`                   // "Point", "Polygon", etc.
@@ -53,13 +82,76 @@ type ZoneProps struct {
 	AvgDeliveryFee float64 `bson:"avg_delivery_fee,omitempty" json:"avg_delivery_fee,omitempty"`
 }
 
+// Options configures the MongoDB client. Zero fields take the defaults.
+type Options struct {
+	MaxPoolSize            uint64
+	ServerSelectionTimeout time.Duration
+	// ConnectTimeout bounds connecting and the connectivity check in New
+	ConnectTimeout time.Duration
+	// ReadPreference is a read preference mode such as "secondaryPreferred"
+	ReadPreference string
+}
+
+// DefaultOptions returns the client settings used when none are configured,
+// the driver's own defaults
+func DefaultOptions() Options {
+	return Options{
+		MaxPoolSize:            100,
+		ServerSelectionTimeout: 30 * time.Second,
+		ConnectTimeout:         10 * time.Second,
+		ReadPreference:         readpref.PrimaryMode.String(),
+	}
+}
+
+// OptionsFromConfig returns the client settings of a service's MongoDB configuration
+func OptionsFromConfig(cfg config.MongoDBConfig) Options {
+	return Options{
+		MaxPoolSize:            cfg.MaxPoolSize,
+		ServerSelectionTimeout: cfg.ServerSelectionTimeout,
+		ConnectTimeout:         cfg.ConnectTimeout,
+		ReadPreference:         cfg.ReadPreference,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if o.MaxPoolSize == 0 {
+		o.MaxPoolSize = defaults.MaxPoolSize
+	}
+	if o.ServerSelectionTimeout <= 0 {
+		o.ServerSelectionTimeout = defaults.ServerSelectionTimeout
+	}
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if o.ReadPreference == "" {
+		o.ReadPreference = defaults.ReadPreference
+	}
+	return o
+}
+
 // New creates a new MongoDB connection
-func New(mongoURL string) (*MongoDB, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func New(mongoURL string, opts Options) (*MongoDB, error) {
+	opts = opts.withDefaults()
+
+	mode, err := readpref.ModeFromString(opts.ReadPreference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB read preference %q: %w", opts.ReadPreference, err)
+	}
+	readPreference, err := readpref.New(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB read preference %q: %w", opts.ReadPreference, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
 	defer cancel()
 
 	// Create client options
-	clientOptions := options.Client().ApplyURI(mongoURL)
+	clientOptions := options.Client().ApplyURI(mongoURL).
+		SetMaxPoolSize(opts.MaxPoolSize).
+		SetServerSelectionTimeout(opts.ServerSelectionTimeout).
+		SetConnectTimeout(opts.ConnectTimeout).
+		SetReadPreference(readPreference)
 
 	// Connect to MongoDB
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -69,13 +161,18 @@ func New(mongoURL string) (*MongoDB, error) {
 
 	// Ping the database to verify connection
 	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
-	// Extract database name from URL
+	// Use the database named in the URL, if any
 	dbName := "delivertrack"
-	
-	log.Printf("MongoDB connected successfully to database: %s", dbName)
+	if cs, err := connstring.ParseAndValidate(mongoURL); err == nil && cs.Database != "" {
+		dbName = cs.Database
+	}
+
+	log.Printf("MongoDB connected successfully to database: %s (max_pool_size=%d, server_selection_timeout=%s, connect_timeout=%s, read_preference=%s)",
+		dbName, opts.MaxPoolSize, opts.ServerSelectionTimeout, opts.ConnectTimeout, opts.ReadPreference)
 
 	return &MongoDB{
 		Client:   client,
@@ -88,6 +185,11 @@ func (m *MongoDB) Close(ctx context.Context) error {
 	return m.Client.Disconnect(ctx)
 }
 
+// Ping checks that the primary is reachable, for readiness checks
+func (m *MongoDB) Ping(ctx context.Context) error {
+	return m.Client.Ping(ctx, readpref.Primary())
+}
+
 // GetCollection returns a collection from the database
 func (m *MongoDB) GetCollection(name string) *mongo.Collection {
 	return m.Database.Collection(name)
@@ -103,6 +205,11 @@ func (m *MongoDB) DeliveryZonesCollection() *mongo.Collection {
 	return m.GetCollection("delivery_zones")
 }
 
+// TrackSummariesCollection returns the track_summaries collection
+func (m *MongoDB) TrackSummariesCollection() *mongo.Collection {
+	return m.GetCollection("track_summaries")
+}
+
 // NewPoint creates a GeoJSON Point from longitude and latitude
 func NewPoint(longitude, latitude float64) GeoJSON {
 	return GeoJSON{
@@ -111,6 +218,66 @@ func NewPoint(longitude, latitude float64) GeoJSON {
 	}
 }
 
+// PointCoordinates returns the longitude and latitude of a GeoJSON Point
+func (g GeoJSON) PointCoordinates() (longitude, latitude float64, err error) {
+	if g.Type != "Point" {
+		return 0, 0, fmt.Errorf("expected GeoJSON Point, got %q", g.Type)
+	}
+
+	var coords []interface{}
+	switch c := g.Coordinates.(type) {
+	case []float64:
+		for _, v := range c {
+			coords = append(coords, v)
+		}
+	case primitive.A:
+		coords = c
+	case []interface{}:
+		coords = c
+	}
+	if len(coords) != 2 {
+		return 0, 0, fmt.Errorf("invalid GeoJSON Point coordinates: %v", g.Coordinates)
+	}
+
+	longitude, lngOK := toFloat64(coords[0])
+	latitude, latOK := toFloat64(coords[1])
+	if !lngOK || !latOK {
+		return 0, 0, fmt.Errorf("invalid GeoJSON Point coordinates: %v", g.Coordinates)
+	}
+	return longitude, latitude, nil
+}
+
+// SetPoint stores a position both as a GeoJSON Point and as scalar fields
+func (cl *CourierLocation) SetPoint(longitude, latitude float64) {
+	cl.Location = NewPoint(longitude, latitude)
+	cl.Latitude = latitude
+	cl.Longitude = longitude
+}
+
+// Point returns the stored position as latitude and longitude
+func (cl *CourierLocation) Point() (latitude, longitude float64, err error) {
+	longitude, latitude, err = cl.Location.PointCoordinates()
+	if err != nil {
+		return 0, 0, fmt.Errorf("courier location for courier %d: %w", cl.CourierID, err)
+	}
+	return latitude, longitude, nil
+}
+
+// toFloat64 converts a decoded BSON number to float64
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
 // NewPolygon creates a GeoJSON Polygon from coordinates
 // coordinates should be [][][]float64 where:
 // - First dimension: array of linear rings (outer ring + holes)
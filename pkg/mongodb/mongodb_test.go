@@ -0,0 +1,73 @@
+package mongodb
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCourierLocation_PointRoundTrip(t *testing.T) {
+	var stored CourierLocation
+	stored.SetPoint(-74.006, 40.7128)
+
+	if stored.Latitude != 40.7128 || stored.Longitude != -74.006 {
+		t.Errorf("expected scalar fields to be written, got %f, %f", stored.Latitude, stored.Longitude)
+	}
+
+	// Decoding yields primitive.A coordinates rather than []float64
+	raw, err := bson.Marshal(stored)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var decoded CourierLocation
+	if err := bson.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	lat, lng, err := decoded.Point()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lat != 40.7128 || lng != -74.006 {
+		t.Errorf("expected 40.7128, -74.006, got %f, %f", lat, lng)
+	}
+}
+
+func TestGeoJSON_PointCoordinates_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		point GeoJSON
+	}{
+		{name: "missing", point: GeoJSON{}},
+		{name: "polygon", point: NewPolygon([][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}})},
+		{name: "too few coordinates", point: GeoJSON{Type: "Point", Coordinates: []float64{1}}},
+		{name: "non-numeric", point: GeoJSON{Type: "Point", Coordinates: []interface{}{"1", "2"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.point.PointCoordinates(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestNew_InvalidReadPreference(t *testing.T) {
+	_, err := New("mongodb://localhost:27017", Options{ReadPreference: "closest"})
+	if err == nil || !strings.Contains(err.Error(), "read preference") {
+		t.Errorf("New() error = %v, want an invalid read preference error", err)
+	}
+}
+
+func TestOptions_WithDefaults(t *testing.T) {
+	got := Options{MaxPoolSize: 20, ReadPreference: "secondaryPreferred"}.withDefaults()
+
+	want := DefaultOptions()
+	want.MaxPoolSize = 20
+	want.ReadPreference = "secondaryPreferred"
+	if got != want {
+		t.Errorf("withDefaults() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,403 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockID is the advisory lock held while migrating, so services starting at
+// the same time against one database apply each migration once
+const lockID = 7310421
+
+// fileName matches migration files: 001_create_tables.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// PendingError is returned when the schema is behind and migrations may not
+// be applied
+type PendingError struct {
+	Pending []Migration
+}
+
+func (e *PendingError) Error() string {
+	names := make([]string, len(e.Pending))
+	for i, m := range e.Pending {
+		names[i] = m.String()
+	}
+	return fmt.Sprintf("database schema is behind, %d pending migration(s): %s", len(e.Pending), strings.Join(names, ", "))
+}
+
+// Applied is a migration recorded in schema_migrations
+type Applied struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations of a file system to a database and tracks
+// them in the schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New loads the migrations in fsys. Files are named
+// <version>_<name>.up.sql, with an optional matching .down.sql.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads and orders the migrations in fsys
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("migration %s: version must be positive", entry.Name())
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %s: version %d is already used by %s", entry.Name(), version, m)
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %s: missing or empty up migration", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Migrations returns every known migration in order
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// Applied returns the migrations recorded as applied, in order
+func (m *Migrator) Applied(ctx context.Context) ([]Applied, error) {
+	exists, err := m.tableExists(ctx)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return readApplied(ctx, m.db)
+}
+
+// Pending returns the migrations not applied yet, in order. Versions are
+// compared individually, so a migration merged below the latest applied
+// version still runs.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(applied), nil
+}
+
+func (m *Migrator) pending(applied []Applied) []Migration {
+	done := make(map[int]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones applied. A failed migration is rolled back and stops
+// the run.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		pending, err := m.pendingOn(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			if err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				return apply(ctx, tx, migration)
+			}); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// DryRun applies every pending migration in a single transaction that is
+// rolled back, checking they run against the current schema without changing
+// it. It returns the migrations Up would apply.
+func (m *Migrator) DryRun(ctx context.Context) ([]Migration, error) {
+	var pending []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		pending, err = m.pendingOn(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for _, migration := range pending {
+			if err := apply(ctx, tx, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// Down reverts the latest applied migration with its down migration and
+// returns it, or nil when nothing is applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var version int
+		err := conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+		if err != nil {
+			return fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		if version == 0 {
+			return nil
+		}
+
+		migration, ok := m.find(version)
+		if !ok {
+			return fmt.Errorf("applied migration %d is not known to this build", version)
+		}
+		if strings.TrimSpace(migration.Down) == "" {
+			return fmt.Errorf("migration %s has no down migration", migration)
+		}
+
+		err = inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return fmt.Errorf("migration %s down failed: %w", migration, err)
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			return err
+		})
+		if err == nil {
+			reverted = &migration
+		}
+		return err
+	})
+	return reverted, err
+}
+
+// Baseline records every migration up to version as applied without running
+// it, for databases whose schema was created before migrations were tracked
+func (m *Migrator) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	var recorded []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		pending, err := m.pendingOn(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return inTx(ctx, conn, func(tx *sql.Tx) error {
+			for _, migration := range pending {
+				if migration.Version > version {
+					break
+				}
+				if err := record(ctx, tx, migration); err != nil {
+					return err
+				}
+				recorded = append(recorded, migration)
+			}
+			return nil
+		})
+	})
+	return recorded, err
+}
+
+// Ensure brings the schema up to date when autoMigrate is set and otherwise
+// returns a *PendingError while migrations are pending, so services refuse to
+// start against an old schema
+func Ensure(ctx context.Context, db *sql.DB, fsys fs.FS, autoMigrate bool) ([]Migration, error) {
+	m, err := New(db, fsys)
+	if err != nil {
+		return nil, err
+	}
+	if autoMigrate {
+		return m.Up(ctx)
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, &PendingError{Pending: pending}
+	}
+	return nil, nil
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// locked runs fn on a single connection holding the migration lock, after
+// creating schema_migrations if needed
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// pendingOn reads the pending migrations through the locked connection, so
+// the list cannot change before they are applied
+func (m *Migrator) pendingOn(ctx context.Context, conn *sql.Conn) ([]Migration, error) {
+	applied, err := readApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(applied), nil
+}
+
+// queryer is a *sql.DB or *sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func readApplied(ctx context.Context, q queryer) ([]Applied, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []Applied
+	for rows.Next() {
+		var a Applied
+		if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) tableExists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := m.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	return exists, nil
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func apply(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("migration %s failed: %w", migration, err)
+	}
+	return record(ctx, tx, migration)
+}
+
+func record(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration, err)
+	}
+	return nil
+}
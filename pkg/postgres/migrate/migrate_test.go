@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Keneke-Einar/delivertrack/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_create_users.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"002_create_users.down.sql":  {Data: []byte("DROP TABLE users;")},
+		"001_create_tables.up.sql":   {Data: []byte("CREATE TABLE deliveries ();")},
+		"010_add_index.up.sql":       {Data: []byte("CREATE INDEX idx ON users (id);")},
+		"README.md":                  {Data: []byte("not a migration")},
+		"001_create_tables.down.sql": {Data: []byte("DROP TABLE deliveries;")},
+	}
+
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []string{"001_create_tables", "002_create_users", "010_add_index"}
+	if len(got) != len(want) {
+		t.Fatalf("Load() returned %d migrations, want %d", len(got), len(want))
+	}
+	for i, m := range got {
+		if m.String() != want[i] {
+			t.Errorf("migration %d = %s, want %s", i, m, want[i])
+		}
+	}
+	if got[1].Down != "DROP TABLE users;" {
+		t.Errorf("down migration = %q, want it paired with its up migration", got[1].Down)
+	}
+	if got[2].Down != "" {
+		t.Errorf("down migration = %q, want none", got[2].Down)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "unversioned file",
+			fsys:    fstest.MapFS{"create_tables.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "expected <version>_<name>.up.sql",
+		},
+		{
+			name: "version used twice",
+			fsys: fstest.MapFS{
+				"003_add_column.up.sql": {Data: []byte("SELECT 1;")},
+				"003_add_index.up.sql":  {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "version 3 is already used",
+		},
+		{
+			name:    "down without up",
+			fsys:    fstest.MapFS{"004_drop_table.down.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "missing or empty up migration",
+		},
+		{
+			name:    "zero version",
+			fsys:    fstest.MapFS{"000_init.up.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "version must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrator_Pending(t *testing.T) {
+	m := &Migrator{migrations: []Migration{
+		{Version: 1, Name: "create_tables"},
+		{Version: 2, Name: "create_users"},
+		{Version: 3, Name: "add_index"},
+	}}
+
+	// Version 2 was merged after 3 had been applied
+	pending := m.pending([]Applied{{Version: 1}, {Version: 3}})
+	if len(pending) != 1 || pending[0].Version != 2 {
+		t.Errorf("pending = %v, want only version 2", pending)
+	}
+}
+
+func TestPendingError(t *testing.T) {
+	err := &PendingError{Pending: []Migration{{Version: 18, Name: "create_login_attempts"}, {Version: 19, Name: "create_api_keys"}}}
+
+	want := "database schema is behind, 2 pending migration(s): 018_create_login_attempts, 019_create_api_keys"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+// The embedded set must load and stay contiguous and reversible
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(all) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, m := range all {
+		if m.Version != i+1 {
+			t.Errorf("migration %s, want version %d", m, i+1)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %s has no down migration", m)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
 	_ "github.com/lib/pq"
 )
 
@@ -13,23 +16,80 @@ type DB struct {
 	*sql.DB
 }
 
+// Options configures the connection pool. Zero fields take the defaults.
+type Options struct {
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections after this long; zero keeps them
+	ConnMaxLifetime time.Duration
+	// ConnectTimeout bounds the connectivity check in New
+	ConnectTimeout time.Duration
+}
+
+// DefaultOptions returns the pool settings used when none are configured
+func DefaultOptions() Options {
+	return Options{
+		MaxOpenConns:   25,
+		MaxIdleConns:   5,
+		ConnectTimeout: 10 * time.Second,
+	}
+}
+
+// OptionsFromConfig returns the pool settings of a service's database configuration
+func OptionsFromConfig(cfg config.DatabaseConfig) Options {
+	return Options{
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnectTimeout:  cfg.ConnectTimeout,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if o.MaxOpenConns <= 0 {
+		o.MaxOpenConns = defaults.MaxOpenConns
+	}
+	if o.MaxIdleConns <= 0 {
+		o.MaxIdleConns = defaults.MaxIdleConns
+	}
+	// More idle than open connections would never be used
+	if o.MaxIdleConns > o.MaxOpenConns {
+		o.MaxIdleConns = o.MaxOpenConns
+	}
+	if o.ConnMaxLifetime < 0 {
+		o.ConnMaxLifetime = 0
+	}
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = defaults.ConnectTimeout
+	}
+	return o
+}
+
 // New creates a new PostgreSQL database connection
-func New(databaseURL string) (*DB, error) {
+func New(databaseURL string, opts Options) (*DB, error) {
+	opts = opts.withDefaults()
+
 	sqlDB, err := sql.Open("postgres", databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// Set connection pool settings
+	sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+
 	// Test the connection
-	if err := sqlDB.Ping(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Set connection pool settings
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(5)
-
-	log.Println("PostgreSQL connected successfully")
+	log.Printf("PostgreSQL connected successfully (max_open_conns=%d, max_idle_conns=%d, conn_max_lifetime=%s, connect_timeout=%s)",
+		opts.MaxOpenConns, opts.MaxIdleConns, opts.ConnMaxLifetime, opts.ConnectTimeout)
 
 	return &DB{sqlDB}, nil
 }
@@ -22,7 +22,7 @@ func TestIntegration_CreateTables(t *testing.T) {
 		t.Skip("DATABASE_URL not set, skipping integration test")
 	}
 
-	db, err := postgres.New(dbURL)
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -85,7 +85,7 @@ func TestIntegration_CRUD(t *testing.T) {
 		t.Skip("DATABASE_URL not set, skipping integration test")
 	}
 
-	db, err := postgres.New(dbURL)
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -187,7 +187,7 @@ func TestIntegration_Concurrency(t *testing.T) {
 		t.Skip("DATABASE_URL not set, skipping integration test")
 	}
 
-	db, err := postgres.New(dbURL)
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -241,7 +241,7 @@ func TestIntegration_TransactionIsolation(t *testing.T) {
 		t.Skip("DATABASE_URL not set, skipping integration test")
 	}
 
-	db, err := postgres.New(dbURL)
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -325,7 +325,7 @@ func TestIntegration_LargeResultSet(t *testing.T) {
 		t.Skip("DATABASE_URL not set, skipping integration test")
 	}
 
-	db, err := postgres.New(dbURL)
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -384,7 +384,7 @@ func TestIntegration_DataTypes(t *testing.T) {
 		t.Skip("DATABASE_URL not set, skipping integration test")
 	}
 
-	db, err := postgres.New(dbURL)
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -463,7 +463,7 @@ func TestIntegration_ErrorHandling(t *testing.T) {
 		t.Skip("DATABASE_URL not set, skipping integration test")
 	}
 
-	db, err := postgres.New(dbURL)
+	db, err := postgres.New(dbURL, postgres.DefaultOptions())
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
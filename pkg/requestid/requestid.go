@@ -0,0 +1,65 @@
+// Package requestid carries the ID clients can quote for a request in bug
+// reports. An ID sent by the client is kept when valid, otherwise one is
+// generated; it is returned on responses, attached to logs, forwarded on
+// outgoing gRPC calls and stored in the events a request publishes.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID on HTTP requests and responses
+const Header = "X-Request-ID"
+
+// MetadataKey carries the request ID in gRPC metadata
+const MetadataKey = "x-request-id"
+
+// MaxLength is the longest client-supplied ID accepted
+const MaxLength = 128
+
+// contextKey is unexported to prevent collisions with keys from other packages
+type contextKey struct{}
+
+// New generates a random (version 4) UUID request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether a client-supplied ID may be used as is: non-empty, at
+// most MaxLength long and made of letters, digits and "-", "_", ".", ":"
+// only, so it is safe to echo in headers and logs
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve returns id when it is valid and a new ID otherwise
+func Resolve(id string) string {
+	if Valid(id) {
+		return id
+	}
+	return New()
+}
+
+// WithID returns a copy of ctx carrying the request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or ""
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,55 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{id: "3f2b8c1e-9d4a-4e7b-8f6a-1c2d3e4f5a6b", valid: true},
+		{id: "client:req_42.retry-1", valid: true},
+		{id: strings.Repeat("a", MaxLength), valid: true},
+		{id: "", valid: false},
+		{id: strings.Repeat("a", MaxLength+1), valid: false},
+		{id: "has space", valid: false},
+		{id: "line\nbreak", valid: false},
+		{id: "naïve", valid: false},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.valid {
+			t.Errorf("Valid(%q) = %v, want %v", tt.id, got, tt.valid)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if got := Resolve("client-id"); got != "client-id" {
+		t.Errorf("expected a valid ID to be kept, got %q", got)
+	}
+
+	generated := Resolve("bad id")
+	parsed, err := uuid.Parse(generated)
+	if err != nil || parsed.Version() != 4 {
+		t.Errorf("expected a generated UUIDv4 for an invalid ID, got %q", generated)
+	}
+	if Resolve("") == generated {
+		t.Error("expected generated IDs to differ")
+	}
+}
+
+func TestContext(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("expected no ID, got %q", id)
+	}
+	if id := FromContext(WithID(context.Background(), "req-1")); id != "req-1" {
+		t.Errorf("expected req-1, got %q", id)
+	}
+}
@@ -3,8 +3,12 @@ package resilience
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"google.golang.org/grpc/codes"
 )
 
 // CircuitBreakerState represents the state of the circuit breaker
@@ -16,6 +20,59 @@ const (
 	StateHalfOpen
 )
 
+// String returns the state name used in logs and health reports
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Errors matched by the OpenError Call returns when the function is not executed
+var (
+	ErrCircuitOpen   = errors.New("circuit breaker is open")
+	ErrHalfOpenLimit = errors.New("circuit breaker half-open call limit exceeded")
+)
+
+// OpenError is returned by Call while the circuit rejects calls. It matches
+// ErrCircuitOpen, or ErrHalfOpenLimit while probes are in flight.
+type OpenError struct {
+	Breaker    string
+	RetryAfter time.Duration // until the circuit admits probes again; zero while probing
+	halfOpen   bool
+}
+
+func (e *OpenError) Error() string {
+	if e.halfOpen {
+		return fmt.Sprintf("%s: %s", e.Breaker, ErrHalfOpenLimit)
+	}
+	return fmt.Sprintf("%s: %s, retry in %s", e.Breaker, ErrCircuitOpen, e.RetryAfter.Round(time.Second))
+}
+
+// Is makes errors.Is match ErrCircuitOpen or ErrHalfOpenLimit
+func (e *OpenError) Is(target error) bool {
+	if e.halfOpen {
+		return target == ErrHalfOpenLimit
+	}
+	return target == ErrCircuitOpen
+}
+
+// GRPCCode implements domainerr.Coder
+func (e *OpenError) GRPCCode() codes.Code {
+	return codes.Unavailable
+}
+
+// Default circuit breaker settings, used for zero config values
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenMaxCalls = 3
+)
+
 // CircuitBreaker implements the circuit breaker pattern
 type CircuitBreaker struct {
 	name string
@@ -24,37 +81,105 @@ type CircuitBreaker struct {
 	failureThreshold int
 	resetTimeout     time.Duration
 	halfOpenMaxCalls int
+	successThreshold int
 
 	// State
-	state            CircuitBreakerState
-	failures         int
-	lastFailureTime  time.Time
-	halfOpenCalls    int
+	state             CircuitBreakerState
+	failures          int
+	lastFailureTime   time.Time
+	halfOpenCalls     int // probes in flight
 	halfOpenSuccesses int
 
+	onStateChange func(name string, from, to CircuitBreakerState)
+
 	mutex sync.RWMutex
 }
 
 // NewCircuitBreaker creates a new circuit breaker
 func NewCircuitBreaker(name string, failureThreshold int, resetTimeout time.Duration) *CircuitBreaker {
-	return &CircuitBreaker{
+	return NewCircuitBreakerWithConfig(name, config.CircuitBreakerConfig{
+		FailureThreshold: failureThreshold,
+		OpenTimeout:      resetTimeout,
+	})
+}
+
+// NewCircuitBreakerWithConfig creates a circuit breaker that opens after
+// cfg.FailureThreshold consecutive failures, admits up to cfg.HalfOpenMaxCalls
+// probes at a time once cfg.OpenTimeout has passed, and closes again after
+// cfg.SuccessThreshold of them succeed. Zero values fall back to defaults;
+// the success threshold defaults to the probe count.
+func NewCircuitBreakerWithConfig(name string, cfg config.CircuitBreakerConfig) *CircuitBreaker {
+	cb := &CircuitBreaker{
 		name:             name,
-		failureThreshold: failureThreshold,
-		resetTimeout:     resetTimeout,
-		halfOpenMaxCalls: 3, // Allow 3 calls in half-open state
+		failureThreshold: cfg.FailureThreshold,
+		resetTimeout:     cfg.OpenTimeout,
+		halfOpenMaxCalls: cfg.HalfOpenMaxCalls,
+		successThreshold: cfg.SuccessThreshold,
 		state:            StateClosed,
 	}
+	if cb.failureThreshold <= 0 {
+		cb.failureThreshold = defaultFailureThreshold
+	}
+	if cb.resetTimeout <= 0 {
+		cb.resetTimeout = defaultOpenTimeout
+	}
+	if cb.halfOpenMaxCalls <= 0 {
+		cb.halfOpenMaxCalls = defaultHalfOpenMaxCalls
+	}
+	if cb.successThreshold <= 0 {
+		cb.successThreshold = cb.halfOpenMaxCalls
+	}
+	return cb
 }
 
-// Call executes the given function with circuit breaker protection
-func (cb *CircuitBreaker) Call(ctx context.Context, fn func() error) error {
+// OnStateChange registers fn to be called after each state transition. It
+// runs outside the breaker's lock, so it may read State.
+func (cb *CircuitBreaker) OnStateChange(fn func(name string, from, to CircuitBreakerState)) {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
+	cb.onStateChange = fn
+}
+
+// Call executes the given function with circuit breaker protection. The lock
+// is not held while fn runs, so concurrent calls proceed in parallel. Calls
+// whose context was cancelled count as neither success nor failure: the
+// caller gave up, which says nothing about the protected service.
+func (cb *CircuitBreaker) Call(ctx context.Context, fn func() error) error {
+	probe, err := cb.admit()
+	if err != nil {
+		return err
+	}
+
+	// Execute the function
+	err = fn()
+
+	cb.mutex.Lock()
+	from := cb.state
+	if probe && cb.state == StateHalfOpen {
+		cb.halfOpenCalls--
+	}
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+	case err != nil:
+		cb.onFailure()
+	default:
+		cb.onSuccess()
+	}
+	cb.notify(from)
+	return err
+}
+
+// admit decides whether a call may run, moving an open circuit to half-open
+// once the reset timeout has passed. It reports whether the call is a probe.
+func (cb *CircuitBreaker) admit() (bool, error) {
+	cb.mutex.Lock()
+	from := cb.state
+	defer cb.notify(from)
 
 	// Check if circuit is open
 	if cb.state == StateOpen {
-		if time.Since(cb.lastFailureTime) < cb.resetTimeout {
-			return errors.New("circuit breaker is open")
+		if wait := cb.resetTimeout - time.Since(cb.lastFailureTime); wait > 0 {
+			return false, &OpenError{Breaker: cb.name, RetryAfter: wait}
 		}
 		// Transition to half-open
 		cb.state = StateHalfOpen
@@ -65,21 +190,23 @@ func (cb *CircuitBreaker) Call(ctx context.Context, fn func() error) error {
 	// Check half-open call limit
 	if cb.state == StateHalfOpen {
 		if cb.halfOpenCalls >= cb.halfOpenMaxCalls {
-			return errors.New("circuit breaker half-open call limit exceeded")
+			return false, &OpenError{Breaker: cb.name, halfOpen: true}
 		}
 		cb.halfOpenCalls++
+		return true, nil
 	}
 
-	// Execute the function
-	err := fn()
+	return false, nil
+}
 
-	if err != nil {
-		cb.onFailure()
-		return err
+// notify releases the lock and reports a transition away from the state the
+// caller saw when it took the lock
+func (cb *CircuitBreaker) notify(from CircuitBreakerState) {
+	to, fn := cb.state, cb.onStateChange
+	cb.mutex.Unlock()
+	if fn != nil && to != from {
+		fn(cb.name, from, to)
 	}
-
-	cb.onSuccess()
-	return nil
 }
 
 // onFailure handles failure scenarios
@@ -105,7 +232,7 @@ func (cb *CircuitBreaker) onSuccess() {
 	case StateHalfOpen:
 		cb.halfOpenSuccesses++
 		// If we have enough successes in half-open state, close the circuit
-		if cb.halfOpenSuccesses >= cb.halfOpenMaxCalls {
+		if cb.halfOpenSuccesses >= cb.successThreshold {
 			cb.state = StateClosed
 			cb.failures = 0
 		}
@@ -115,6 +242,11 @@ func (cb *CircuitBreaker) onSuccess() {
 	}
 }
 
+// Name returns the name the circuit breaker was created with
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
 // State returns the current state of the circuit breaker
 func (cb *CircuitBreaker) State() CircuitBreakerState {
 	cb.mutex.RLock()
@@ -0,0 +1,138 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+)
+
+var errUpstream = errors.New("upstream failed")
+
+func fail() error    { return errUpstream }
+func succeed() error { return nil }
+
+func TestCircuitBreaker_OpensAfterFailureThreshold(t *testing.T) {
+	cb := NewCircuitBreakerWithConfig("delivery", config.CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	ctx := context.Background()
+
+	cb.Call(ctx, fail)
+	if !cb.IsClosed() {
+		t.Fatalf("expected closed after one failure, got %s", cb.State())
+	}
+	cb.Call(ctx, fail)
+	if !cb.IsOpen() {
+		t.Fatalf("expected open after two failures, got %s", cb.State())
+	}
+
+	called := false
+	err := cb.Call(ctx, func() error { called = true; return nil })
+	if called {
+		t.Error("expected the open circuit not to run the call")
+	}
+	var open *OpenError
+	if !errors.As(err, &open) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected an OpenError matching ErrCircuitOpen, got %v", err)
+	}
+	if open.Breaker != "delivery" || open.RetryAfter <= 0 || open.RetryAfter > time.Minute {
+		t.Errorf("unexpected open error %+v", open)
+	}
+}
+
+func TestCircuitBreaker_SuccessThresholdCloses(t *testing.T) {
+	cb := NewCircuitBreakerWithConfig("delivery", config.CircuitBreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Millisecond,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 2,
+	})
+	ctx := context.Background()
+
+	var transitions []string
+	cb.OnStateChange(func(name string, from, to CircuitBreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	cb.Call(ctx, fail)
+	time.Sleep(2 * time.Millisecond)
+
+	// One probe at a time: a second call while the first runs is rejected
+	err := cb.Call(ctx, func() error {
+		if err := cb.Call(ctx, succeed); !errors.Is(err, ErrHalfOpenLimit) {
+			t.Errorf("expected ErrHalfOpenLimit while probing, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected probe error: %v", err)
+	}
+	if !cb.IsHalfOpen() {
+		t.Fatalf("expected half-open after one of two successes, got %s", cb.State())
+	}
+
+	cb.Call(ctx, succeed)
+	if !cb.IsClosed() {
+		t.Fatalf("expected closed after two successes, got %s", cb.State())
+	}
+
+	expected := []string{"closed->open", "open->half_open", "half_open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected transitions %v, got %v", expected, transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("transition %d: expected %s, got %s", i, expected[i], transitions[i])
+		}
+	}
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	cb := NewCircuitBreakerWithConfig("delivery", config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Millisecond})
+	ctx := context.Background()
+
+	cb.Call(ctx, fail)
+	time.Sleep(2 * time.Millisecond)
+	if err := cb.Call(ctx, fail); !errors.Is(err, errUpstream) {
+		t.Fatalf("expected the probe to run and fail, got %v", err)
+	}
+	if !cb.IsOpen() {
+		t.Errorf("expected open after a failed probe, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreaker_IgnoresCancelledCalls(t *testing.T) {
+	cb := NewCircuitBreakerWithConfig("delivery", config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Millisecond, SuccessThreshold: 1})
+
+	cb.Call(context.Background(), fail)
+	time.Sleep(2 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cb.Call(ctx, succeed)
+	if !cb.IsHalfOpen() {
+		t.Fatalf("expected a cancelled probe not to close the circuit, got %s", cb.State())
+	}
+	cb.Call(context.Background(), func() error { return context.Canceled })
+	if !cb.IsHalfOpen() {
+		t.Fatalf("expected a cancelled probe not to reopen the circuit, got %s", cb.State())
+	}
+
+	if err := cb.Call(context.Background(), succeed); err != nil {
+		t.Fatalf("expected the probe slots to be released, got %v", err)
+	}
+	if !cb.IsClosed() {
+		t.Errorf("expected closed after a successful probe, got %s", cb.State())
+	}
+}
+
+func TestNewCircuitBreakerWithConfig_Defaults(t *testing.T) {
+	cb := NewCircuitBreakerWithConfig("delivery", config.CircuitBreakerConfig{HalfOpenMaxCalls: 2})
+	if cb.failureThreshold != defaultFailureThreshold || cb.resetTimeout != defaultOpenTimeout {
+		t.Errorf("expected default threshold and timeout, got %d and %v", cb.failureThreshold, cb.resetTimeout)
+	}
+	if cb.successThreshold != 2 {
+		t.Errorf("expected the success threshold to default to the probe count, got %d", cb.successThreshold)
+	}
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader carries the trace ID on HTTP responses for log correlation
+const TraceIDHeader = "X-Trace-ID"
+
+// Init installs the global tracer provider and W3C trace context propagation.
+// Spans are exported over OTLP/gRPC when OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set, configured by the standard OTEL_*
+// variables; otherwise they are only kept locally so trace IDs still show up
+// in logs. The returned function flushes and stops the provider.
+func Init(ctx context.Context, serviceName, version string) (func(context.Context) error, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporterConfigured() {
+		exporter, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// exporterConfigured reports whether an OTLP endpoint was provided
+func exporterConfigured() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Tracer returns a named tracer from the global provider
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// TraceID returns the trace ID of the span in ctx, or "" when there is none
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// SpanID returns the span ID of the span in ctx, or "" when there is none
+func SpanID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+	return ""
+}
+
+// HTTPHandler wraps a handler with a server span per request and sets the
+// X-Trace-ID response header from that span
+func HTTPHandler(next http.Handler, serviceName string) http.Handler {
+	withTraceID := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if traceID := TraceID(r.Context()); traceID != "" {
+			w.Header().Set(TraceIDHeader, traceID)
+		}
+		next.ServeHTTP(w, r)
+	})
+	return otelhttp.NewHandler(withTraceID, serviceName,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
+
+// HTTPTransport wraps a round tripper so outgoing requests carry the trace context
+func HTTPTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPHandler_SetsTraceIDHeader(t *testing.T) {
+	shutdown, err := Init(context.Background(), "test-service", "test")
+	if err != nil {
+		t.Fatalf("failed to init tracing: %v", err)
+	}
+	defer shutdown(context.Background())
+
+	var handlerTraceID string
+	handler := HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerTraceID = TraceID(r.Context())
+	}), "test-service")
+
+	// Continue a trace started upstream
+	req := httptest.NewRequest(http.MethodGet, "/deliveries/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if handlerTraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected incoming trace to be continued, got %q", handlerTraceID)
+	}
+	if got := rec.Header().Get(TraceIDHeader); got != handlerTraceID {
+		t.Errorf("expected %s header %q, got %q", TraceIDHeader, handlerTraceID, got)
+	}
+}
@@ -0,0 +1,133 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EnvelopeVersion is the version of the envelope format sent in Envelope.Version
+const EnvelopeVersion = 1
+
+// Envelope types
+const (
+	TypeLocation     = "location"
+	TypeNotification = "notification"
+	TypeETA          = "eta"
+	TypeError        = "error"
+	TypePong         = "pong"
+	TypeSubscribed   = "subscribed"
+	TypeUnsubscribed = "unsubscribed"
+	TypeResumed      = "resumed"
+)
+
+// Envelope wraps every message the server sends, e.g.
+// {"v":1,"type":"location","seq":7,"sent_at":"...","payload":{...}}. Seq
+// starts at 1 on each connection and increases by one per message queued for
+// it, so a client that sees a jump knows messages were dropped.
+type Envelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	Seq     uint64          `json:"seq"`
+	SentAt  time.Time       `json:"sent_at"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// DecodeEnvelope parses one server frame
+func DecodeEnvelope(data []byte) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("decode envelope: %w", err)
+	}
+	if env.Type == "" {
+		return nil, fmt.Errorf("decode envelope: missing type")
+	}
+	return &env, nil
+}
+
+// DecodePayload unmarshals the envelope's payload into v
+func (e *Envelope) DecodePayload(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Location returns the payload of a location envelope
+func (e *Envelope) Location() (*LocationMessage, error) {
+	var msg LocationMessage
+	if err := e.decodeAs(TypeLocation, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Notification returns the payload of a notification or eta envelope
+func (e *Envelope) Notification() (*NotificationMessage, error) {
+	if e.Type != TypeNotification && e.Type != TypeETA {
+		return nil, fmt.Errorf("expected a %s envelope, got %s", TypeNotification, e.Type)
+	}
+	var msg NotificationMessage
+	if err := e.DecodePayload(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ErrorReply returns the payload of an error envelope
+func (e *Envelope) ErrorReply() (*ErrorMessage, error) {
+	var msg ErrorMessage
+	if err := e.decodeAs(TypeError, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Resumed returns the payload of a resumed envelope
+func (e *Envelope) Resumed() (*ResumeMessage, error) {
+	var msg ResumeMessage
+	if err := e.decodeAs(TypeResumed, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (e *Envelope) decodeAs(msgType string, v interface{}) error {
+	if e.Type != msgType {
+		return fmt.Errorf("expected a %s envelope, got %s", msgType, e.Type)
+	}
+	return e.DecodePayload(v)
+}
+
+// notificationType returns the envelope type of a customer notification:
+// ETA updates get their own so clients can route them without inspecting
+// the payload
+func notificationType(kind string) string {
+	if kind == "eta_update" {
+		return TypeETA
+	}
+	return TypeNotification
+}
+
+// outbound is a message queued for a client, stamped with its sequence
+// number when queued and wrapped in an Envelope when written
+type outbound struct {
+	Type    string
+	Seq     uint64
+	Payload interface{}
+}
+
+// envelope wraps the message for writing, stamping it with now
+func (m *outbound) envelope(now time.Time) (*Envelope, error) {
+	payload, err := json.Marshal(m.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Version: EnvelopeVersion,
+		Type:    m.Type,
+		Seq:     m.Seq,
+		SentAt:  now.UTC(),
+		Payload: payload,
+	}, nil
+}
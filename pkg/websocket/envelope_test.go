@@ -0,0 +1,321 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/gorilla/websocket"
+)
+
+// dialCustomer connects a customer notification client to a running hub
+func dialCustomer(t *testing.T) (*Hub, *websocket.Conn) {
+	t.Helper()
+	hub := NewHub(&MockAuthService{})
+	go hub.Run()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleCustomerWebSocket))
+	t.Cleanup(server.Close)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/customers/notifications"
+	conn, _, err := bearerDialer("test-token").Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// Broadcasts made before the hub loop registers the client reach no one
+	waitForConnections(t, hub, 1)
+	return hub, conn
+}
+
+func waitForConnections(t *testing.T, hub *Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for hub.GetConnectionCount() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d connections, got %d", n, hub.GetConnectionCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEnvelope_Shape(t *testing.T) {
+	hub, conn := dialTracker(t, 42)
+	waitForConnections(t, hub, 1)
+
+	before := time.Now().Add(-time.Second)
+	hub.BroadcastLocation(42, &domain.Location{DeliveryID: 42, CourierID: 7, Latitude: 40.7128, Longitude: -74.0060})
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var frame map[string]json.RawMessage
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+	for _, key := range []string{"v", "type", "seq", "sent_at", "payload"} {
+		if _, ok := frame[key]; !ok {
+			t.Errorf("expected envelope key %q, got %v", key, frame)
+		}
+	}
+	if len(frame) != 5 {
+		t.Errorf("expected only envelope keys, got %v", frame)
+	}
+
+	var sentAt string
+	json.Unmarshal(frame["sent_at"], &sentAt)
+	at, err := time.Parse(time.RFC3339, sentAt)
+	if err != nil || at.Before(before) {
+		t.Errorf("expected an RFC 3339 sent_at, got %q (%v)", sentAt, err)
+	}
+	if string(frame["v"]) != "1" || string(frame["type"]) != `"location"` || string(frame["seq"]) != "1" {
+		t.Errorf("expected a version 1 location envelope with seq 1, got %v", frame)
+	}
+
+	var payload LocationMessage
+	if err := json.Unmarshal(frame["payload"], &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload.DeliveryID != 42 || payload.Location == nil || payload.Location.CourierID != 7 {
+		t.Errorf("expected delivery 42's location as the payload, got %s", frame["payload"])
+	}
+}
+
+func TestEnvelope_NotificationTypes(t *testing.T) {
+	hub, conn := dialCustomer(t)
+
+	hub.BroadcastCustomerNotification(1, "courier_nearby", "Your courier is nearby", nil)
+	hub.BroadcastCustomerNotification(1, "eta_update", "ETA updated", map[string]int{"eta_minutes": 5})
+
+	tests := []struct {
+		expectedType string
+		kind         string
+	}{
+		{TypeNotification, "courier_nearby"},
+		{TypeETA, "eta_update"},
+	}
+	for i, tt := range tests {
+		env := readFrame(t, conn)
+		if env.Type != tt.expectedType || env.Seq != uint64(i+1) {
+			t.Errorf("expected %s envelope %d, got %s %d", tt.expectedType, i+1, env.Type, env.Seq)
+		}
+		msg, err := env.Notification()
+		if err != nil {
+			t.Fatalf("failed to decode notification: %v", err)
+		}
+		if msg.Type != tt.kind || msg.CustomerID != 1 {
+			t.Errorf("expected a %s notification for customer 1, got %+v", tt.kind, msg)
+		}
+	}
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	if _, err := DecodeEnvelope([]byte(`{"delivery_id":1}`)); err == nil {
+		t.Error("expected an error for a frame without a type")
+	}
+	if _, err := DecodeEnvelope([]byte(`not json`)); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+
+	env, err := DecodeEnvelope([]byte(`{"v":1,"type":"pong","seq":3,"sent_at":"2026-01-02T03:04:05Z","payload":{"server_time":"2026-01-02T03:04:05Z"}}`))
+	if err != nil {
+		t.Fatalf("failed to decode envelope: %v", err)
+	}
+	if env.Version != EnvelopeVersion || env.Seq != 3 || !env.SentAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+	if _, err := env.Location(); err == nil {
+		t.Error("expected decoding a pong as a location to fail")
+	}
+	var pong PongMessage
+	if err := env.DecodePayload(&pong); err != nil || pong.ServerTime.IsZero() {
+		t.Errorf("expected the pong payload, got %+v, %v", pong, err)
+	}
+}
+
+// TestClient_SequenceUnderConcurrentSends queues from the hub loop and from
+// replies at once; run it with -race. Every message must get its own number,
+// in the order it was queued.
+func TestClient_SequenceUnderConcurrentSends(t *testing.T) {
+	hub := NewHub(&MockAuthService{})
+	go hub.Run()
+
+	const senders, perSender = 10, 50
+	client := &Client{
+		clientType: "delivery_tracker",
+		deliveries: map[int]bool{1: true},
+		send:       make(chan *outbound, 2*senders*perSender),
+		hub:        hub,
+	}
+	hub.register <- client
+
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perSender; j++ {
+				hub.BroadcastLocation(1, &domain.Location{DeliveryID: 1})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perSender; j++ {
+				client.reply(TypePong, &PongMessage{ServerTime: time.Now()})
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := uint64(2 * senders * perSender)
+	var last uint64
+	deadline := time.After(5 * time.Second)
+	for last < want {
+		select {
+		case msg := <-client.send:
+			if msg.Seq != last+1 {
+				t.Fatalf("expected seq %d, got %d", last+1, msg.Seq)
+			}
+			last = msg.Seq
+		case <-deadline:
+			t.Fatalf("expected %d messages, got %d", want, last)
+		}
+	}
+}
+
+func TestHub_SequenceOverTheWire(t *testing.T) {
+	hub, conn := dialTracker(t, 42)
+	waitForConnections(t, hub, 1)
+
+	// Broadcasts from many goroutines interleave with pings from the client
+	const broadcasters, perBroadcaster, pings = 5, 20, 20
+	var wg sync.WaitGroup
+	for i := 0; i < broadcasters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perBroadcaster; j++ {
+				hub.BroadcastLocation(42, &domain.Location{DeliveryID: 42})
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < pings; i++ {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"ping"}`))
+		}
+	}()
+
+	counts := map[string]int{}
+	for seq := uint64(1); seq <= broadcasters*perBroadcaster+pings; seq++ {
+		env := readFrame(t, conn)
+		if env.Seq != seq {
+			t.Fatalf("expected seq %d, got %d", seq, env.Seq)
+		}
+		counts[env.Type]++
+	}
+	wg.Wait()
+
+	if counts[TypeLocation] != broadcasters*perBroadcaster || counts[TypePong] != pings {
+		t.Errorf("expected every broadcast and pong, got %v", counts)
+	}
+}
+
+func TestHub_Resume(t *testing.T) {
+	hub, conn := dialTracker(t, 42, 43, 44)
+	var gotDeliveries []int
+	var mu sync.Mutex
+	hub.SetLocationSnapshot(func(ctx context.Context, deliveryID int) (*domain.Location, error) {
+		mu.Lock()
+		gotDeliveries = append(gotDeliveries, deliveryID)
+		mu.Unlock()
+		switch deliveryID {
+		case 42:
+			return &domain.Location{ID: 9, DeliveryID: 42, Latitude: 40.7128, Longitude: -74.0060}, nil
+		case 43:
+			return nil, nil
+		default:
+			return nil, errors.New("tracking store unavailable")
+		}
+	})
+
+	for _, id := range []int{43, 44} {
+		exchange(t, conn, fmt.Sprintf(`{"action":"subscribe","delivery_id":%d}`, id))
+	}
+
+	env := exchange(t, conn, `{"action":"resume","last_seq":17}`)
+	if env.Seq != 3 {
+		t.Errorf("expected the resume reply to continue this connection's sequence, got seq %d", env.Seq)
+	}
+	resumed, err := env.Resumed()
+	if err != nil {
+		t.Fatalf("failed to decode resume reply: %v", err)
+	}
+	if resumed.LastSeq != 17 || !resumed.ResumeGap {
+		t.Errorf("expected last_seq 17 with resume_gap set, got %+v", resumed)
+	}
+
+	// Deliveries without a location, or whose lookup failed, are left out
+	if len(resumed.Locations) != 1 || resumed.Locations[0].DeliveryID != 42 || resumed.Locations[0].Location.ID != 9 {
+		t.Errorf("expected delivery 42's latest location, got %+v", resumed.Locations)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(gotDeliveries) != "[42 43 44]" {
+		t.Errorf("expected a lookup for every tracked delivery, got %v", gotDeliveries)
+	}
+}
+
+func TestHub_ResumeNotifications(t *testing.T) {
+	_, conn := dialCustomer(t)
+
+	resumed, err := exchange(t, conn, `{"action":"resume","last_seq":5}`).Resumed()
+	if err != nil {
+		t.Fatalf("failed to decode resume reply: %v", err)
+	}
+	if resumed.LastSeq != 5 || !resumed.ResumeGap || len(resumed.Locations) != 0 {
+		t.Errorf("expected a gap without locations, got %+v", resumed)
+	}
+}
+
+func TestHub_ResumeDoesNotBlockReads(t *testing.T) {
+	hub, conn := dialTracker(t, 42)
+	release := make(chan struct{})
+	hub.SetLocationSnapshot(func(ctx context.Context, deliveryID int) (*domain.Location, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return &domain.Location{ID: 9, DeliveryID: deliveryID}, nil
+	})
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"resume","last_seq":3}`)); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+
+	// A slow lookup neither holds up other messages nor stacks up resumes
+	if env := exchange(t, conn, `{"action":"ping"}`); env.Type != TypePong {
+		t.Fatalf("expected a pong while the resume is answered, got %s", env.Type)
+	}
+	errMsg, err := exchange(t, conn, `{"action":"resume","last_seq":3}`).ErrorReply()
+	if err != nil || errMsg.Code != ErrCodeResumeInProgress {
+		t.Fatalf("expected a resume_in_progress error, got %+v (%v)", errMsg, err)
+	}
+
+	close(release)
+	resumed, err := readFrame(t, conn).Resumed()
+	if err != nil {
+		t.Fatalf("failed to decode resume reply: %v", err)
+	}
+	if len(resumed.Locations) != 1 || resumed.Locations[0].DeliveryID != 42 {
+		t.Errorf("expected delivery 42's latest location, got %+v", resumed.Locations)
+	}
+}
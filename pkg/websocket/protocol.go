@@ -0,0 +1,203 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
+	"github.com/gorilla/websocket"
+)
+
+// Actions a client may send
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+	ActionPing        = "ping"
+	ActionResume      = "resume"
+)
+
+// Error codes sent in ErrorMessage frames
+const (
+	ErrCodeInvalidMessage    = "invalid_message"
+	ErrCodeUnknownAction     = "unknown_action"
+	ErrCodeInvalidDeliveryID = "invalid_delivery_id"
+	ErrCodeUnsupported       = "unsupported_action"
+	ErrCodeForbidden         = "forbidden"
+	ErrCodeSubscriptionLimit = "subscription_limit"
+	ErrCodeNotSubscribed     = "not_subscribed"
+	ErrCodeResumeInProgress  = "resume_in_progress"
+)
+
+// MaxSubscriptionsPerClient caps how many deliveries one connection may track
+const MaxSubscriptionsPerClient = 20
+
+// authorizeTimeout bounds the authorizer call made for a subscribe message
+const authorizeTimeout = 10 * time.Second
+
+// snapshotTimeout bounds all the location lookups made for a resume message together
+const snapshotTimeout = 3 * time.Second
+
+// ClientMessage is a message a client sends over a WebSocket, e.g.
+// {"action":"subscribe","delivery_id":123}, {"action":"ping"} or, after a
+// reconnect, {"action":"resume","last_seq":41}
+type ClientMessage struct {
+	Action     string `json:"action"`
+	DeliveryID int    `json:"delivery_id,omitempty"`
+	LastSeq    uint64 `json:"last_seq,omitempty"`
+}
+
+// SubscriptionMessage is the payload confirming a subscribe or unsubscribe action
+type SubscriptionMessage struct {
+	DeliveryID int `json:"delivery_id"`
+}
+
+// PongMessage is the payload answering a ping action
+type PongMessage struct {
+	ServerTime time.Time `json:"server_time"`
+}
+
+// ResumeMessage is the payload answering a resume action. Messages sent on
+// a previous connection are not replayed, so ResumeGap is always set; a
+// tracker instead gets the latest location of each delivery it tracks.
+type ResumeMessage struct {
+	LastSeq   uint64             `json:"last_seq"`
+	ResumeGap bool               `json:"resume_gap"`
+	Locations []*LocationMessage `json:"locations,omitempty"`
+}
+
+// ErrorMessage is the payload reporting a client message the server could
+// not act on
+type ErrorMessage struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Action     string `json:"action,omitempty"`
+	DeliveryID int    `json:"delivery_id,omitempty"`
+}
+
+// handleMessage acts on one message read from the client
+func (c *Client) handleMessage(messageType int, data []byte) {
+	if messageType != websocket.TextMessage {
+		c.reply(TypeError, &ErrorMessage{Code: ErrCodeInvalidMessage, Message: "messages must be JSON text frames"})
+		return
+	}
+
+	var msg ClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.reply(TypeError, &ErrorMessage{Code: ErrCodeInvalidMessage, Message: "malformed JSON message"})
+		return
+	}
+
+	switch msg.Action {
+	case ActionPing:
+		c.reply(TypePong, &PongMessage{ServerTime: time.Now().UTC()})
+	case ActionSubscribe, ActionUnsubscribe:
+		c.handleSubscription(msg)
+	case ActionResume:
+		// The lookups run off the read loop so pings and pongs are still
+		// read meanwhile; one resume is answered at a time
+		if !c.resuming.CompareAndSwap(false, true) {
+			c.reply(TypeError, &ErrorMessage{Code: ErrCodeResumeInProgress, Message: "a resume is already being answered", Action: msg.Action})
+			return
+		}
+		go func() {
+			defer c.resuming.Store(false)
+			c.handleResume(msg)
+		}()
+	case "":
+		c.reply(TypeError, &ErrorMessage{Code: ErrCodeInvalidMessage, Message: "action is required"})
+	default:
+		c.reply(TypeError, &ErrorMessage{Code: ErrCodeUnknownAction, Message: "unknown action", Action: msg.Action})
+	}
+}
+
+// handleSubscription adds or removes one of the client's deliveries, running
+// the same authorization as the initial connect before subscribing
+func (c *Client) handleSubscription(msg ClientMessage) {
+	fail := func(code, message string) {
+		c.reply(TypeError, &ErrorMessage{Code: code, Message: message, Action: msg.Action, DeliveryID: msg.DeliveryID})
+	}
+
+	if c.clientType != "delivery_tracker" {
+		fail(ErrCodeUnsupported, "only delivery tracking connections can subscribe to deliveries")
+		return
+	}
+	if msg.DeliveryID <= 0 {
+		fail(ErrCodeInvalidDeliveryID, "delivery_id must be a positive integer")
+		return
+	}
+
+	if msg.Action == ActionUnsubscribe {
+		if !c.hub.removeSubscription(c, msg.DeliveryID) {
+			fail(ErrCodeNotSubscribed, "not subscribed to this delivery")
+			return
+		}
+		c.reply(TypeUnsubscribed, &SubscriptionMessage{DeliveryID: msg.DeliveryID})
+		return
+	}
+
+	if c.hub.subscriptionCount(c) >= MaxSubscriptionsPerClient && !c.hub.isSubscribed(c, msg.DeliveryID) {
+		fail(ErrCodeSubscriptionLimit, "too many subscriptions on this connection")
+		return
+	}
+
+	// The upgrade request's context ended with the handshake
+	ctx, cancel := context.WithTimeout(requestid.WithID(context.Background(), c.requestID), authorizeTimeout)
+	defer cancel()
+	if !c.hub.canTrack(c.authorizedContext(ctx), c.claims, msg.DeliveryID) {
+		fail(ErrCodeForbidden, "not allowed to track this delivery")
+		return
+	}
+
+	if !c.hub.addSubscription(c, msg.DeliveryID) {
+		fail(ErrCodeSubscriptionLimit, "too many subscriptions on this connection")
+		return
+	}
+	c.reply(TypeSubscribed, &SubscriptionMessage{DeliveryID: msg.DeliveryID})
+}
+
+// handleResume answers a client that reconnected after seeing last_seq on
+// its previous connection. Nothing is replayed: trackers get the latest
+// location of each delivery they track, flagged as a possible gap.
+func (c *Client) handleResume(msg ClientMessage) {
+	resumed := &ResumeMessage{LastSeq: msg.LastSeq, ResumeGap: true}
+
+	snapshot := c.hub.locationSnapshot()
+	if c.clientType == "delivery_tracker" && snapshot != nil {
+		ctx, cancel := context.WithTimeout(requestid.WithID(context.Background(), c.requestID), snapshotTimeout)
+		defer cancel()
+		for _, deliveryID := range c.hub.subscriptions(c) {
+			location, err := snapshot(c.authorizedContext(ctx), deliveryID)
+			if err != nil {
+				log.Printf("Failed to load latest location of delivery %d for resume: %v [request %s]", deliveryID, err, c.requestID)
+				continue
+			}
+			if location != nil {
+				resumed.Locations = append(resumed.Locations, &LocationMessage{DeliveryID: deliveryID, Location: location})
+			}
+		}
+	}
+
+	c.reply(TypeResumed, resumed)
+}
+
+// reply queues a message for the client without waiting on a full buffer.
+// The write lock keeps the hub from closing send meanwhile.
+func (c *Client) reply(msgType string, payload interface{}) {
+	c.hub.mutex.Lock()
+	defer c.hub.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	if !c.enqueue(msgType, payload) {
+		log.Printf("Dropping %s reply to WebSocket client: send buffer full [request %s]", msgType, c.requestID)
+	}
+}
+
+// authorizedContext returns ctx carrying the client's bearer token, so the
+// authorizer can call other services on the client's behalf
+func (c *Client) authorizedContext(ctx context.Context) context.Context {
+	return authctx.WithAuthorization(ctx, "Bearer "+c.token)
+}
@@ -1,19 +1,30 @@
 package websocket
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
 	authPorts "github.com/Keneke-Einar/delivertrack/pkg/auth/ports"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
 	"github.com/gorilla/websocket"
 )
 
+// BearerSubprotocol is offered ahead of the JWT in the Sec-WebSocket-Protocol
+// header, as in "bearer, <token>", and echoed back on accepted upgrades
+const BearerSubprotocol = "bearer"
+
 // WebSocket connection upgrader
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
@@ -21,8 +32,68 @@ var upgrader = websocket.Upgrader{
 		// In production, you should check the origin
 		return true
 	},
+	Subprotocols: []string{BearerSubprotocol},
+}
+
+// Hub defaults
+const (
+	// DefaultBroadcastBuffer is how many broadcasts may wait for the hub loop
+	DefaultBroadcastBuffer = 1024
+	// DefaultSendBuffer is how many messages may wait for a client's writer
+	DefaultSendBuffer = 256
+	// DefaultReadLimit is the largest message read from a client, in bytes
+	DefaultReadLimit = 512
+	// DefaultPongTimeout is how long a client may go without answering pings
+	DefaultPongTimeout = 60 * time.Second
+)
+
+// writeWait bounds a single write to a client
+const writeWait = 10 * time.Second
+
+var (
+	// ErrHubNotRunning is returned by broadcasts made while Run is not running
+	ErrHubNotRunning = errors.New("websocket hub is not running")
+
+	// ErrBroadcastBufferFull is returned by broadcasts dropped because the hub
+	// loop is too far behind
+	ErrBroadcastBufferFull = errors.New("websocket hub broadcast buffer is full")
+
+	errConnectionLimit     = errors.New("websocket connection limit reached")
+	errUserConnectionLimit = errors.New("websocket connection limit per user reached")
+)
+
+// HubConfig sizes the hub's queues and limits its connections. Zero sizes
+// and timeouts take the defaults; zero connection limits disable them.
+type HubConfig struct {
+	BroadcastBuffer       int           // broadcasts queued for the hub loop before new ones are dropped
+	SendBuffer            int           // messages queued for a client before it is dropped
+	ReadLimit             int64         // largest message read from a client, in bytes
+	PongTimeout           time.Duration // silence after which a client is disconnected; pings go out at 9/10 of it
+	MaxConnections        int           // open connections across all users
+	MaxConnectionsPerUser int           // open connections of a single user
+	AllowQueryToken       bool          // also accept the deprecated token query parameter
 }
 
+// DefaultHubConfig returns the configuration NewHub uses
+func DefaultHubConfig() HubConfig {
+	return HubConfig{
+		BroadcastBuffer: DefaultBroadcastBuffer,
+		SendBuffer:      DefaultSendBuffer,
+		ReadLimit:       DefaultReadLimit,
+		PongTimeout:     DefaultPongTimeout,
+		AllowQueryToken: true,
+	}
+}
+
+// Authorizer reports whether an authenticated user may track a delivery. ctx
+// carries the user's authorization so the check can query other services on
+// their behalf.
+type Authorizer func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error)
+
+// LocationSnapshot returns a delivery's latest location, or nil when none has
+// been reported. It is sent to trackers that resume after a reconnect.
+type LocationSnapshot func(ctx context.Context, deliveryID int) (*domain.Location, error)
+
 // Hub manages WebSocket connections and broadcasts messages
 type Hub struct {
 	clients         map[int]map[*Client]bool // Registered clients by delivery ID
@@ -32,8 +103,17 @@ type Hub struct {
 	register        chan *Client             // Register requests from clients
 	unregister      chan *Client             // Unregister requests from clients
 	authService     authPorts.AuthService    // Auth service for token validation
+	authorizer      Authorizer               // Decides who may track a delivery
+	snapshot        LocationSnapshot         // Latest locations for resuming trackers
 	connectionCount int                      // Connection count for metrics
+	reserved        int                      // Connections counted against the limits, upgraded or about to be
+	userConnections map[int]int              // Reserved connections by user ID
+	config          HubConfig                // Buffer sizes, timeouts and limits
 	mutex           sync.RWMutex             // Mutex for thread safety
+
+	running  atomic.Bool  // Whether Run is consuming the queues
+	dropped  atomic.Int64 // Broadcasts dropped on a full buffer
+	rejected atomic.Int64 // Connections refused over a limit
 }
 
 // LocationMessage represents a location update message
@@ -53,10 +133,13 @@ type Client struct {
 	// The WebSocket connection
 	conn *websocket.Conn
 
-	// The delivery ID this client is tracking (for delivery tracking)
-	deliveryID int
+	// The delivery IDs this client is tracking (for delivery tracking),
+	// guarded by the hub's mutex
+	deliveries map[int]bool
 
 	// User information for authorization
+	claims     *authDomain.Claims
+	token      string
 	userID     int
 	username   string
 	role       string
@@ -66,46 +149,101 @@ type Client struct {
 	// Client type: "delivery_tracker" or "customer_notifications"
 	clientType string
 
+	// ID of the upgrade request, included in log lines about the client
+	requestID string
+
 	// Buffered channel of outbound messages
-	send chan interface{}
+	send chan *outbound
+
+	// Sequence number of the last message queued on send, guarded by sendMu
+	// so numbers are handed out in the order messages are queued
+	seq    uint64
+	sendMu sync.Mutex
+
+	// Whether send has been closed, guarded by the hub's mutex
+	closed bool
+
+	// Whether a resume message is being answered
+	resuming atomic.Bool
 
 	// Reference to the hub
 	hub *Hub
 }
 
-// NewHub creates a new WebSocket hub
+// NewHub creates a new WebSocket hub with the default configuration
 func NewHub(authService authPorts.AuthService) *Hub {
+	return NewHubWithConfig(authService, DefaultHubConfig())
+}
+
+// NewHubWithConfig creates a new WebSocket hub. Broadcasts are dropped until
+// Run is started.
+func NewHubWithConfig(authService authPorts.AuthService, cfg HubConfig) *Hub {
+	if cfg.BroadcastBuffer <= 0 {
+		cfg.BroadcastBuffer = DefaultBroadcastBuffer
+	}
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = DefaultSendBuffer
+	}
+	if cfg.ReadLimit <= 0 {
+		cfg.ReadLimit = DefaultReadLimit
+	}
+	if cfg.PongTimeout <= 0 {
+		cfg.PongTimeout = DefaultPongTimeout
+	}
 	return &Hub{
 		clients:           make(map[int]map[*Client]bool),
 		customerClients:   make(map[int]map[*Client]bool),
-		broadcast:         make(chan *LocationMessage),
-		customerBroadcast: make(chan *NotificationMessage),
+		broadcast:         make(chan *LocationMessage, cfg.BroadcastBuffer),
+		customerBroadcast: make(chan *NotificationMessage, cfg.BroadcastBuffer),
 		register:          make(chan *Client),
 		unregister:        make(chan *Client),
 		authService:       authService,
 		connectionCount:   0,
+		userConnections:   make(map[int]int),
+		config:            cfg,
 	}
 }
 
+// SetAuthorizer sets the check run before a delivery tracker is accepted.
+// Without one, every delivery tracking connection is refused.
+func (h *Hub) SetAuthorizer(authorizer Authorizer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.authorizer = authorizer
+}
+
+// SetLocationSnapshot sets the lookup answering resume actions. Without one,
+// resumed trackers are only told that they may have missed messages.
+func (h *Hub) SetLocationSnapshot(snapshot LocationSnapshot) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.snapshot = snapshot
+}
+
 // Run starts the hub and handles client registration/unregistration and broadcasting
 func (h *Hub) Run() {
+	h.running.Store(true)
+	defer h.running.Store(false)
+
 	for {
 		select {
 		case client := <-h.register:
 			h.mutex.Lock()
 			if client.clientType == "delivery_tracker" {
-				if h.clients[client.deliveryID] == nil {
-					h.clients[client.deliveryID] = make(map[*Client]bool)
+				for deliveryID := range client.deliveries {
+					if h.clients[deliveryID] == nil {
+						h.clients[deliveryID] = make(map[*Client]bool)
+					}
+					h.clients[deliveryID][client] = true
+					log.Printf("Delivery tracker registered for delivery %d. Total clients: %d [request %s]", deliveryID, len(h.clients[deliveryID]), client.requestID)
 				}
-				h.clients[client.deliveryID][client] = true
-				log.Printf("Delivery tracker registered for delivery %d. Total clients: %d", client.deliveryID, len(h.clients[client.deliveryID]))
 			} else if client.clientType == "customer_notifications" {
 				if client.customerID != nil {
 					if h.customerClients[*client.customerID] == nil {
 						h.customerClients[*client.customerID] = make(map[*Client]bool)
 					}
 					h.customerClients[*client.customerID][client] = true
-					log.Printf("Customer notification client registered for customer %d. Total clients: %d", *client.customerID, len(h.customerClients[*client.customerID]))
+					log.Printf("Customer notification client registered for customer %d. Total clients: %d [request %s]", *client.customerID, len(h.customerClients[*client.customerID]), client.requestID)
 				}
 			}
 			h.connectionCount++
@@ -115,25 +253,20 @@ func (h *Hub) Run() {
 		case client := <-h.unregister:
 			h.mutex.Lock()
 			if client.clientType == "delivery_tracker" {
-				if clients, ok := h.clients[client.deliveryID]; ok {
-					if _, ok := clients[client]; ok {
-						delete(clients, client)
-						close(client.send)
-						log.Printf("Delivery tracker unregistered from delivery %d. Remaining clients: %d", client.deliveryID, len(clients))
-
-						// Clean up empty delivery maps
-						if len(clients) == 0 {
-							delete(h.clients, client.deliveryID)
-						}
+				for deliveryID := range client.deliveries {
+					if h.removeTrackerLocked(client, deliveryID) {
+						log.Printf("Delivery tracker unregistered from delivery %d. Remaining clients: %d [request %s]", deliveryID, len(h.clients[deliveryID]), client.requestID)
 					}
 				}
+				client.closeSend()
+				client.deliveries = nil
 			} else if client.clientType == "customer_notifications" {
 				if client.customerID != nil {
 					if clients, ok := h.customerClients[*client.customerID]; ok {
 						if _, ok := clients[client]; ok {
 							delete(clients, client)
-							close(client.send)
-							log.Printf("Customer notification client unregistered from customer %d. Remaining clients: %d", *client.customerID, len(clients))
+							client.closeSend()
+							log.Printf("Customer notification client unregistered from customer %d. Remaining clients: %d [request %s]", *client.customerID, len(clients), client.requestID)
 
 							// Clean up empty customer maps
 							if len(clients) == 0 {
@@ -144,60 +277,230 @@ func (h *Hub) Run() {
 				}
 			}
 			h.connectionCount--
+			h.releaseConnectionLocked(client.userID)
 			log.Printf("Total connections: %d", h.connectionCount)
 			h.mutex.Unlock()
 
 		case message := <-h.broadcast:
 			h.mutex.RLock()
-			if clients, ok := h.clients[message.DeliveryID]; ok {
-				for client := range clients {
-					select {
-					case client.send <- message:
-					default:
-						// Client send channel is full, close the connection
-						close(client.send)
-						delete(clients, client)
-					}
+			var stuck []*Client
+			for client := range h.clients[message.DeliveryID] {
+				if !client.enqueue(TypeLocation, message) {
+					stuck = append(stuck, client)
 				}
 			}
 			h.mutex.RUnlock()
+			h.dropClients(stuck)
 
 		case notification := <-h.customerBroadcast:
 			h.mutex.RLock()
-			if clients, ok := h.customerClients[notification.CustomerID]; ok {
-				for client := range clients {
-					select {
-					case client.send <- notification:
-					default:
-						// Client send channel is full, close the connection
-						close(client.send)
-						delete(clients, client)
-					}
+			var stuck []*Client
+			msgType := notificationType(notification.Type)
+			for client := range h.customerClients[notification.CustomerID] {
+				if !client.enqueue(msgType, notification) {
+					stuck = append(stuck, client)
 				}
 			}
 			h.mutex.RUnlock()
+			h.dropClients(stuck)
+		}
+	}
+}
+
+// dropClients disconnects clients whose send channel was full: they are
+// removed from every delivery and customer they were registered for, and
+// their send channel is closed so writePump closes the connection. The
+// broadcast loops collect them under the read lock and call this after
+// releasing it, as the maps may only change under the write lock.
+func (h *Hub) dropClients(clients []*Client) {
+	if len(clients) == 0 {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, client := range clients {
+		if client.closed {
+			// Already dropped or unregistered
+			continue
 		}
+		for deliveryID := range client.deliveries {
+			h.removeTrackerLocked(client, deliveryID)
+		}
+		if client.customerID != nil {
+			if clients := h.customerClients[*client.customerID]; clients[client] {
+				delete(clients, client)
+				if len(clients) == 0 {
+					delete(h.customerClients, *client.customerID)
+				}
+			}
+		}
+		client.closeSend()
+		log.Printf("Dropped WebSocket client: send buffer full [request %s]", client.requestID)
+	}
+}
+
+// enqueue stamps a message with the client's next sequence number and queues
+// it without blocking, reporting whether there was room. A message that does
+// not fit still uses its number, so the client sees the gap. The caller holds
+// the hub's lock, read or write, so send is not closed meanwhile.
+func (c *Client) enqueue(msgType string, payload interface{}) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.seq++
+	select {
+	case c.send <- &outbound{Type: msgType, Seq: c.seq, Payload: payload}:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeSend closes the client's send channel once, whichever of unregister and
+// dropClients gets there first. The caller holds the hub's write lock.
+func (c *Client) closeSend() {
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// addSubscription adds deliveryID to a registered tracker's deliveries. It
+// returns false when the client already tracks MaxSubscriptionsPerClient
+// other deliveries.
+func (h *Hub) addSubscription(client *Client, deliveryID int) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if client.closed {
+		return false
+	}
+	if client.deliveries[deliveryID] {
+		return true
+	}
+	if len(client.deliveries) >= MaxSubscriptionsPerClient {
+		return false
+	}
+	if client.deliveries == nil {
+		client.deliveries = make(map[int]bool)
+	}
+	client.deliveries[deliveryID] = true
+	if h.clients[deliveryID] == nil {
+		h.clients[deliveryID] = make(map[*Client]bool)
+	}
+	h.clients[deliveryID][client] = true
+	log.Printf("Delivery tracker subscribed to delivery %d. Total clients: %d [request %s]", deliveryID, len(h.clients[deliveryID]), client.requestID)
+	return true
+}
+
+// removeSubscription removes deliveryID from a tracker's deliveries,
+// reporting whether it was subscribed. The connection stays open.
+func (h *Hub) removeSubscription(client *Client, deliveryID int) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if !client.deliveries[deliveryID] {
+		return false
+	}
+	delete(client.deliveries, deliveryID)
+	h.removeTrackerLocked(client, deliveryID)
+	log.Printf("Delivery tracker unsubscribed from delivery %d [request %s]", deliveryID, client.requestID)
+	return true
+}
+
+// removeTrackerLocked removes client from deliveryID's trackers, reporting
+// whether it was there. The caller holds the write lock.
+func (h *Hub) removeTrackerLocked(client *Client, deliveryID int) bool {
+	clients, ok := h.clients[deliveryID]
+	if !ok || !clients[client] {
+		return false
+	}
+	delete(clients, client)
+
+	// Clean up empty delivery maps
+	if len(clients) == 0 {
+		delete(h.clients, deliveryID)
+	}
+	return true
+}
+
+// isSubscribed reports whether client tracks deliveryID
+func (h *Hub) isSubscribed(client *Client, deliveryID int) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return client.deliveries[deliveryID]
+}
+
+// subscriptions returns the deliveries client tracks, in ascending order
+func (h *Hub) subscriptions(client *Client) []int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	ids := make([]int, 0, len(client.deliveries))
+	for deliveryID := range client.deliveries {
+		ids = append(ids, deliveryID)
 	}
+	sort.Ints(ids)
+	return ids
+}
+
+// subscriptionCount returns how many deliveries client tracks
+func (h *Hub) subscriptionCount(client *Client) int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(client.deliveries)
 }
 
-// BroadcastLocation broadcasts a location update to all clients tracking the delivery
-func (h *Hub) BroadcastLocation(deliveryID int, location *domain.Location) {
+// BroadcastLocation broadcasts a location update to all clients tracking the
+// delivery. It never blocks: the update is dropped when the hub is not
+// running or too far behind.
+func (h *Hub) BroadcastLocation(deliveryID int, location *domain.Location) error {
 	message := &LocationMessage{
 		DeliveryID: deliveryID,
 		Location:   location,
 	}
-	h.broadcast <- message
+	if !h.running.Load() {
+		return ErrHubNotRunning
+	}
+	select {
+	case h.broadcast <- message:
+		return nil
+	default:
+		h.dropBroadcast("location", fmt.Sprintf("delivery %d", deliveryID))
+		return ErrBroadcastBufferFull
+	}
 }
 
-// BroadcastCustomerNotification broadcasts a notification to all clients subscribed to the customer
-func (h *Hub) BroadcastCustomerNotification(customerID int, notificationType, message string, data interface{}) {
+// BroadcastCustomerNotification broadcasts a notification to all clients
+// subscribed to the customer. Like BroadcastLocation it never blocks.
+func (h *Hub) BroadcastCustomerNotification(customerID int, notificationType, message string, data interface{}) error {
 	notification := &NotificationMessage{
 		CustomerID: customerID,
 		Type:       notificationType,
 		Message:    message,
 		Data:       data,
 	}
-	h.customerBroadcast <- notification
+	if !h.running.Load() {
+		return ErrHubNotRunning
+	}
+	select {
+	case h.customerBroadcast <- notification:
+		return nil
+	default:
+		h.dropBroadcast(notificationType+" notification", fmt.Sprintf("customer %d", customerID))
+		return ErrBroadcastBufferFull
+	}
+}
+
+// dropBroadcast counts and logs a broadcast dropped on a full buffer
+func (h *Hub) dropBroadcast(kind, target string) {
+	dropped := h.dropped.Add(1)
+	log.Printf("WARN: WebSocket broadcast buffer full, dropped %s for %s (%d dropped in total)", kind, target, dropped)
+}
+
+// DroppedBroadcasts returns how many broadcasts were dropped on a full buffer
+func (h *Hub) DroppedBroadcasts() int64 {
+	return h.dropped.Load()
 }
 
 // GetConnectionCount returns the current number of active WebSocket connections
@@ -207,6 +510,88 @@ func (h *Hub) GetConnectionCount() int {
 	return h.connectionCount
 }
 
+// RejectedConnections returns how many connections were refused over the
+// global or per-user connection limit
+func (h *Hub) RejectedConnections() int64 {
+	return h.rejected.Load()
+}
+
+// reserveConnection counts a connection of userID against the limits before
+// it is upgraded, so concurrent handshakes cannot overshoot them. Refusals
+// are counted.
+func (h *Hub) reserveConnection(userID int) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var err error
+	switch {
+	case h.config.MaxConnections > 0 && h.reserved >= h.config.MaxConnections:
+		err = errConnectionLimit
+	case h.config.MaxConnectionsPerUser > 0 && h.userConnections[userID] >= h.config.MaxConnectionsPerUser:
+		err = errUserConnectionLimit
+	}
+	if err != nil {
+		rejected := h.rejected.Add(1)
+		log.Printf("WARN: Refused WebSocket connection for user %d: %v (%d refused in total)", userID, err, rejected)
+		return err
+	}
+
+	h.reserved++
+	h.userConnections[userID]++
+	return nil
+}
+
+// releaseConnection gives back a connection reserved for userID
+func (h *Hub) releaseConnection(userID int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.releaseConnectionLocked(userID)
+}
+
+// releaseConnectionLocked gives back a connection reserved for userID. The
+// caller holds the write lock.
+func (h *Hub) releaseConnectionLocked(userID int) {
+	h.reserved--
+	if h.userConnections[userID]--; h.userConnections[userID] <= 0 {
+		delete(h.userConnections, userID)
+	}
+}
+
+// requestToken returns the JWT offered after BearerSubprotocol in the
+// Sec-WebSocket-Protocol header or, when the hub allows it, in the deprecated
+// token query parameter, which ends up in access logs
+func (h *Hub) requestToken(r *http.Request) string {
+	if protocols := websocket.Subprotocols(r); len(protocols) >= 2 && protocols[0] == BearerSubprotocol {
+		return protocols[1]
+	}
+	if !h.config.AllowQueryToken {
+		return ""
+	}
+	token := r.URL.Query().Get("token")
+	if token != "" {
+		log.Printf("WARN: WebSocket token passed in the deprecated query parameter [request %s]", requestIDFor(r))
+	}
+	return token
+}
+
+// upgrade takes a connection slot for claims' user and upgrades the request,
+// answering 429 when a connection limit is reached. The slot is released
+// again when the upgrade fails.
+func (h *Hub) upgrade(w http.ResponseWriter, r *http.Request, claims *authDomain.Claims, requestID string) (*websocket.Conn, bool) {
+	if err := h.reserveConnection(claims.UserID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"too_many_connections","message":%q}`, err.Error()), http.StatusTooManyRequests)
+		return nil, false
+	}
+
+	conn, err := upgrader.Upgrade(w, r, http.Header{requestid.Header: {requestID}})
+	if err != nil {
+		h.releaseConnection(claims.UserID)
+		log.Printf("Failed to upgrade connection: %v [request %s]", err, requestID)
+		return nil, false
+	}
+	return conn, true
+}
+
 // HandleWebSocket handles WebSocket connections for live tracking
 func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Extract delivery ID from URL path
@@ -225,10 +610,10 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract and validate JWT token from query parameters
-	token := r.URL.Query().Get("token")
+	// Extract and validate JWT token from the subprotocol header
+	token := h.requestToken(r)
 	if token == "" {
-		http.Error(w, `{"error":"unauthorized","message":"Token required in query parameter"}`, http.StatusUnauthorized)
+		http.Error(w, `{"error":"unauthorized","message":"Token required in Sec-WebSocket-Protocol header"}`, http.StatusUnauthorized)
 		return
 	}
 
@@ -239,28 +624,37 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Upgrade HTTP connection to WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("Failed to upgrade connection: %v", err)
+	// Only the delivery's owner may watch it; failed checks refuse the connection
+	if !h.canTrack(authctx.WithAuthorization(r.Context(), "Bearer "+token), claims, deliveryID) {
+		http.Error(w, `{"error":"forbidden","message":"Not allowed to track this delivery"}`, http.StatusForbidden)
+		return
+	}
+
+	// Upgrade HTTP connection to WebSocket within the connection limits
+	requestID := requestIDFor(r)
+	conn, ok := h.upgrade(w, r, claims, requestID)
+	if !ok {
 		return
 	}
 
 	// Create client with user information
 	client := &Client{
 		conn:       conn,
-		deliveryID: deliveryID,
+		deliveries: map[int]bool{deliveryID: true},
+		claims:     claims,
+		token:      token,
 		userID:     claims.UserID,
 		username:   claims.Username,
 		role:       claims.Role,
 		customerID: claims.CustomerID,
 		courierID:  claims.CourierID,
 		clientType: "delivery_tracker",
-		send:       make(chan interface{}, 256),
+		requestID:  requestID,
+		send:       make(chan *outbound, h.config.SendBuffer),
 		hub:        h,
 	}
 
-	log.Printf("WebSocket authenticated: user %s (%s) tracking delivery %d", claims.Username, claims.Role, deliveryID)
+	log.Printf("WebSocket authenticated: user %s (%s) tracking delivery %d [request %s]", claims.Username, claims.Role, deliveryID, requestID)
 
 	// Register client
 	client.hub.register <- client
@@ -270,12 +664,71 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	go client.readPump()
 }
 
+// requestIDFor returns the ID the request ID middleware gave the upgrade
+// request, or a new one when the hub is served without the middleware
+func requestIDFor(r *http.Request) string {
+	if id := requestid.FromContext(r.Context()); id != "" {
+		return id
+	}
+	return requestid.Resolve(r.Header.Get(requestid.Header))
+}
+
+// locationSnapshot returns the snapshot lookup, or nil when none is set
+func (h *Hub) locationSnapshot() LocationSnapshot {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.snapshot
+}
+
+// canTrack runs the authorizer for a delivery tracker, failing closed when it
+// is missing or errors. ctx carries the tracker's authorization.
+func (h *Hub) canTrack(ctx context.Context, claims *authDomain.Claims, deliveryID int) bool {
+	h.mutex.RLock()
+	authorizer := h.authorizer
+	h.mutex.RUnlock()
+
+	if authorizer == nil {
+		log.Printf("No WebSocket authorizer configured; refusing tracker for delivery %d", deliveryID)
+		return false
+	}
+
+	allowed, err := authorizer(ctx, claims, deliveryID)
+	if err != nil {
+		log.Printf("Failed to authorize user %s for delivery %d: %v", claims.Username, deliveryID, err)
+		return false
+	}
+	return allowed
+}
+
+// ReauthorizeDelivery runs the authorizer again for every tracker of a
+// delivery, for example after it was reassigned. Trackers no longer allowed
+// to watch it are unsubscribed and sent a forbidden error; their connections
+// stay open.
+func (h *Hub) ReauthorizeDelivery(deliveryID int) {
+	h.mutex.RLock()
+	trackers := make([]*Client, 0, len(h.clients[deliveryID]))
+	for client := range h.clients[deliveryID] {
+		trackers = append(trackers, client)
+	}
+	h.mutex.RUnlock()
+
+	for _, client := range trackers {
+		ctx, cancel := context.WithTimeout(requestid.WithID(context.Background(), client.requestID), authorizeTimeout)
+		allowed := h.canTrack(client.authorizedContext(ctx), client.claims, deliveryID)
+		cancel()
+		if allowed || !h.removeSubscription(client, deliveryID) {
+			continue
+		}
+		client.reply(TypeError, &ErrorMessage{Code: ErrCodeForbidden, Message: "no longer allowed to track this delivery", DeliveryID: deliveryID})
+	}
+}
+
 // HandleCustomerWebSocket handles WebSocket connections for customer notifications
 func (h *Hub) HandleCustomerWebSocket(w http.ResponseWriter, r *http.Request) {
-	// Extract and validate JWT token from query parameters
-	token := r.URL.Query().Get("token")
+	// Extract and validate JWT token from the subprotocol header
+	token := h.requestToken(r)
 	if token == "" {
-		http.Error(w, `{"error":"unauthorized","message":"Token required in query parameter"}`, http.StatusUnauthorized)
+		http.Error(w, `{"error":"unauthorized","message":"Token required in Sec-WebSocket-Protocol header"}`, http.StatusUnauthorized)
 		return
 	}
 
@@ -292,24 +745,26 @@ func (h *Hub) HandleCustomerWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Upgrade HTTP connection to WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("Failed to upgrade connection: %v", err)
+	// Upgrade HTTP connection to WebSocket within the connection limits
+	requestID := requestIDFor(r)
+	conn, ok := h.upgrade(w, r, claims, requestID)
+	if !ok {
 		return
 	}
 
 	// Create client with user information
 	client := &Client{
 		conn:       conn,
-		deliveryID: 0, // Not tracking a specific delivery
+		claims:     claims,
+		token:      token,
 		userID:     claims.UserID,
 		username:   claims.Username,
 		role:       claims.Role,
 		customerID: claims.CustomerID,
 		courierID:  claims.CourierID,
 		clientType: "customer_notifications",
-		send:       make(chan interface{}, 256),
+		requestID:  requestID,
+		send:       make(chan *outbound, h.config.SendBuffer),
 		hub:        h,
 	}
 
@@ -317,7 +772,7 @@ func (h *Hub) HandleCustomerWebSocket(w http.ResponseWriter, r *http.Request) {
 	if claims.CustomerID != nil {
 		customerIDStr = fmt.Sprintf("%d", *claims.CustomerID)
 	}
-	log.Printf("Customer WebSocket authenticated: user %s (customer %s) subscribed to notifications", claims.Username, customerIDStr)
+	log.Printf("Customer WebSocket authenticated: user %s (customer %s) subscribed to notifications [request %s]", claims.Username, customerIDStr, requestID)
 
 	// Register client
 	client.hub.register <- client
@@ -334,29 +789,30 @@ func (c *Client) readPump() {
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(512)
-	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	pongTimeout := c.hub.config.PongTimeout
+	c.conn.SetReadLimit(c.hub.config.ReadLimit)
+	c.conn.SetReadDeadline(time.Now().Add(pongTimeout))
 	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		c.conn.SetReadDeadline(time.Now().Add(pongTimeout))
 		return nil
 	})
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		messageType, data, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+				log.Printf("WebSocket error: %v [request %s]", err, c.requestID)
 			}
 			break
 		}
-		// For now, we don't handle messages from clients
-		// In the future, we could handle client-specific requests
+		c.handleMessage(messageType, data)
 	}
 }
 
 // writePump pumps messages to the WebSocket connection
 func (c *Client) writePump() {
-	ticker := time.NewTicker(54 * time.Second)
+	// Ping early enough for the pong to arrive before the read deadline
+	ticker := time.NewTicker(c.hub.config.PongTimeout * 9 / 10)
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
@@ -365,19 +821,24 @@ func (c *Client) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
-			if err := c.conn.WriteJSON(message); err != nil {
-				log.Printf("Error writing JSON to WebSocket: %v", err)
+			env, err := message.envelope(time.Now())
+			if err != nil {
+				log.Printf("Error encoding %s message for WebSocket: %v [request %s]", message.Type, err, c.requestID)
+				continue
+			}
+			if err := c.conn.WriteJSON(env); err != nil {
+				log.Printf("Error writing JSON to WebSocket: %v [request %s]", err, c.requestID)
 				return
 			}
 
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
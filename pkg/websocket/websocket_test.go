@@ -3,21 +3,26 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/auth/authctx"
 	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/requestid"
 	"github.com/gorilla/websocket"
 )
 
 // MockAuthService implements authPorts.AuthService for testing
 type MockAuthService struct{}
 
-func (m *MockAuthService) Register(ctx context.Context, username, email, password, role string, customerID, courierID *int) (*authDomain.User, error) {
+func (m *MockAuthService) Register(ctx context.Context, username, email, password, role string, orgID int, customerID, courierID *int) (*authDomain.User, error) {
 	return nil, nil
 }
 
@@ -40,6 +45,42 @@ func (m *MockAuthService) GetUser(ctx context.Context, id int) (*authDomain.User
 	return nil, nil
 }
 
+func (m *MockAuthService) UpdateEmail(ctx context.Context, id int, email string) (*authDomain.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	return nil
+}
+
+func (m *MockAuthService) SetUserActive(ctx context.Context, id int, active bool) (*authDomain.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) SetUserOrganization(ctx context.Context, id, orgID int) (*authDomain.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) UnlockUser(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *MockAuthService) IssueAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (*authDomain.APIKey, string, error) {
+	return nil, "", nil
+}
+
+func (m *MockAuthService) ListAPIKeys(ctx context.Context, userID int) ([]*authDomain.APIKey, error) {
+	return nil, nil
+}
+
+func (m *MockAuthService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	return nil
+}
+
+func (m *MockAuthService) ValidateAPIKey(ctx context.Context, key string) (*authDomain.Claims, error) {
+	return nil, nil
+}
+
 func TestHub_Run(t *testing.T) {
 	hub := NewHub(&MockAuthService{})
 	go hub.Run()
@@ -74,6 +115,127 @@ func TestHub_BroadcastLocation(t *testing.T) {
 	hub.BroadcastLocation(1, location)
 }
 
+func TestHub_BroadcastWithoutRun(t *testing.T) {
+	hub := NewHub(&MockAuthService{})
+
+	done := make(chan error, 2)
+	go func() {
+		done <- hub.BroadcastLocation(1, &domain.Location{DeliveryID: 1})
+		done <- hub.BroadcastCustomerNotification(1, "eta_update", "ETA updated", nil)
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrHubNotRunning) {
+				t.Errorf("expected ErrHubNotRunning, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("broadcast blocked on a hub that was never run")
+		}
+	}
+}
+
+func TestHub_BroadcastBufferFull(t *testing.T) {
+	hub := NewHubWithConfig(&MockAuthService{}, HubConfig{BroadcastBuffer: 2})
+	// A running hub whose loop is stuck
+	hub.running.Store(true)
+
+	for i := 0; i < 2; i++ {
+		if err := hub.BroadcastLocation(1, &domain.Location{DeliveryID: 1}); err != nil {
+			t.Fatalf("expected broadcast %d to be queued, got %v", i, err)
+		}
+	}
+	if err := hub.BroadcastLocation(1, &domain.Location{DeliveryID: 1}); !errors.Is(err, ErrBroadcastBufferFull) {
+		t.Errorf("expected ErrBroadcastBufferFull, got %v", err)
+	}
+	hub.BroadcastCustomerNotification(1, "eta_update", "ETA updated", nil)
+	hub.BroadcastCustomerNotification(1, "eta_update", "ETA updated", nil)
+	if err := hub.BroadcastCustomerNotification(1, "eta_update", "ETA updated", nil); !errors.Is(err, ErrBroadcastBufferFull) {
+		t.Errorf("expected ErrBroadcastBufferFull, got %v", err)
+	}
+
+	if got := hub.DroppedBroadcasts(); got != 2 {
+		t.Errorf("expected 2 dropped broadcasts, got %d", got)
+	}
+}
+
+// TestHub_ConcurrentUse hammers the hub from many goroutines; run it with
+// -race. Clients have one-message buffers and only some drain them, so slow
+// clients are dropped by broadcasts while they unregister concurrently.
+func TestHub_ConcurrentUse(t *testing.T) {
+	hub := NewHub(&MockAuthService{})
+	go hub.Run()
+
+	const workers = 50
+	var workersDone, drained sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		customerID := i % 5
+		client := &Client{
+			customerID: &customerID,
+			requestID:  fmt.Sprintf("req-%d", i),
+			send:       make(chan *outbound, 1),
+			hub:        hub,
+		}
+		if i%2 == 0 {
+			client.clientType = "delivery_tracker"
+			client.deliveries = map[int]bool{i % 5: true}
+		} else {
+			client.clientType = "customer_notifications"
+		}
+
+		drained.Add(1)
+		go func(slow bool) {
+			defer drained.Done()
+			if slow {
+				// Never read until send is closed by a drop or unregister
+				time.Sleep(10 * time.Millisecond)
+			}
+			for range client.send {
+			}
+		}(i%3 == 0)
+
+		workersDone.Add(1)
+		go func() {
+			defer workersDone.Done()
+			hub.register <- client
+			for j := 0; j < 20; j++ {
+				hub.BroadcastLocation(j%5, &domain.Location{DeliveryID: j % 5})
+				hub.BroadcastCustomerNotification(j%5, "eta_update", "ETA updated", nil)
+				if client.clientType == "delivery_tracker" {
+					hub.addSubscription(client, j%7)
+					hub.removeSubscription(client, (j+3)%7)
+				}
+				client.reply(TypePong, &PongMessage{ServerTime: time.Now()})
+				hub.GetConnectionCount()
+			}
+			hub.unregister <- client
+		}()
+	}
+	workersDone.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		drained.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected every client's send channel to be closed")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for hub.GetConnectionCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+	if hub.connectionCount != 0 || len(hub.clients) != 0 || len(hub.customerClients) != 0 {
+		t.Errorf("expected an empty hub, got %d connections, %d deliveries, %d customers",
+			hub.connectionCount, len(hub.clients), len(hub.customerClients))
+	}
+}
+
 func TestHub_HandleWebSocket_Upgrade(t *testing.T) {
 	hub := NewHub(&MockAuthService{})
 	go hub.Run()
@@ -86,7 +248,7 @@ func TestHub_HandleWebSocket_Upgrade(t *testing.T) {
 	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/deliveries/1/track"
 
 	// Try to connect with WebSocket
-	_, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	_, _, err := bearerDialer("test-token").Dial(wsURL, nil)
 	if err != nil {
 		// This is expected to fail because we don't have proper WebSocket upgrade handling in test
 		// But we can check that the endpoint exists and responds
@@ -103,6 +265,411 @@ func TestHub_HandleWebSocket_Upgrade(t *testing.T) {
 	}
 }
 
+func TestHub_HandleWebSocket_Authorization(t *testing.T) {
+	tests := []struct {
+		name           string
+		authorizer     Authorizer
+		expectedStatus int
+	}{
+		{
+			name: "allowed",
+			authorizer: func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+				return true, nil
+			},
+			expectedStatus: http.StatusSwitchingProtocols,
+		},
+		{
+			name: "denied",
+			authorizer: func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+				return false, nil
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "authorizer error fails closed",
+			authorizer: func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+				return true, errors.New("delivery service unavailable")
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{name: "no authorizer", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(&MockAuthService{})
+			go hub.Run()
+
+			var gotDeliveryID int
+			var gotAuthorization string
+			if tt.authorizer != nil {
+				hub.SetAuthorizer(func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+					gotDeliveryID = deliveryID
+					gotAuthorization = authctx.AuthorizationFrom(ctx)
+					return tt.authorizer(ctx, claims, deliveryID)
+				})
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+			defer server.Close()
+
+			wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/deliveries/42/track"
+			conn, resp, err := bearerDialer("test-token").Dial(wsURL, nil)
+			if conn != nil {
+				conn.Close()
+			}
+			if resp == nil {
+				t.Fatalf("expected a handshake response, got error %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+
+			if tt.authorizer != nil {
+				if gotDeliveryID != 42 {
+					t.Errorf("expected authorizer to check delivery 42, got %d", gotDeliveryID)
+				}
+				if gotAuthorization != "Bearer test-token" {
+					t.Errorf("expected the caller's token to be forwarded, got %q", gotAuthorization)
+				}
+			}
+		})
+	}
+}
+
+func TestHub_HandleWebSocket_RequestID(t *testing.T) {
+	hub := NewHub(&MockAuthService{})
+	go hub.Run()
+	hub.SetAuthorizer(func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+		return true, nil
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/deliveries/42/track"
+	conn, resp, err := bearerDialer("test-token").Dial(wsURL, http.Header{requestid.Header: {"client-req-42"}})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	conn.Close()
+
+	if id := resp.Header.Get(requestid.Header); id != "client-req-42" {
+		t.Errorf("expected the handshake to return the request ID, got %q", id)
+	}
+}
+
+func TestHub_TokenSources(t *testing.T) {
+	tests := []struct {
+		name             string
+		allowQueryToken  bool
+		query            string
+		subprotocols     []string
+		expectedStatus   int
+		expectedProtocol string
+	}{
+		{name: "subprotocol", subprotocols: []string{BearerSubprotocol, "test-token"}, expectedStatus: http.StatusSwitchingProtocols, expectedProtocol: BearerSubprotocol},
+		{name: "subprotocol with query fallback allowed", allowQueryToken: true, subprotocols: []string{BearerSubprotocol, "test-token"}, expectedStatus: http.StatusSwitchingProtocols, expectedProtocol: BearerSubprotocol},
+		{name: "query fallback allowed", allowQueryToken: true, query: "?token=test-token", expectedStatus: http.StatusSwitchingProtocols},
+		{name: "query fallback disabled", query: "?token=test-token", expectedStatus: http.StatusUnauthorized},
+		{name: "subprotocol without token", subprotocols: []string{BearerSubprotocol}, expectedStatus: http.StatusUnauthorized},
+		{name: "no token", allowQueryToken: true, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHubWithConfig(&MockAuthService{}, HubConfig{AllowQueryToken: tt.allowQueryToken})
+			go hub.Run()
+
+			server := httptest.NewServer(http.HandlerFunc(hub.HandleCustomerWebSocket))
+			defer server.Close()
+
+			wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/customers/notifications" + tt.query
+			dialer := &websocket.Dialer{Subprotocols: tt.subprotocols}
+			conn, resp, err := dialer.Dial(wsURL, nil)
+			if conn != nil {
+				defer conn.Close()
+			}
+			if resp == nil {
+				t.Fatalf("expected a handshake response, got error %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if conn != nil && conn.Subprotocol() != tt.expectedProtocol {
+				t.Errorf("expected subprotocol %q, got %q", tt.expectedProtocol, conn.Subprotocol())
+			}
+		})
+	}
+}
+
+// userAuthService validates tokens named user-<id> as that customer user
+type userAuthService struct {
+	MockAuthService
+}
+
+func (m *userAuthService) ValidateToken(ctx context.Context, token string) (*authDomain.Claims, error) {
+	var userID int
+	if _, err := fmt.Sscanf(token, "user-%d", &userID); err != nil {
+		return nil, err
+	}
+	return &authDomain.Claims{UserID: userID, Username: token, Role: "customer", CustomerID: &userID}, nil
+}
+
+func TestHub_ConnectionLimits(t *testing.T) {
+	hub := NewHubWithConfig(&userAuthService{}, HubConfig{MaxConnections: 3, MaxConnectionsPerUser: 2})
+	go hub.Run()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleCustomerWebSocket))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/customers/notifications"
+
+	dial := func(token string, expectedStatus int) *websocket.Conn {
+		t.Helper()
+		conn, resp, err := bearerDialer(token).Dial(wsURL, nil)
+		if resp == nil {
+			t.Fatalf("expected a handshake response for %s, got error %v", token, err)
+		}
+		if resp.StatusCode != expectedStatus {
+			t.Fatalf("expected status %d for %s, got %d", expectedStatus, token, resp.StatusCode)
+		}
+		if conn != nil {
+			t.Cleanup(func() { conn.Close() })
+		}
+		return conn
+	}
+
+	first := dial("user-1", http.StatusSwitchingProtocols)
+	dial("user-1", http.StatusSwitchingProtocols)
+	dial("user-1", http.StatusTooManyRequests) // over the per-user limit
+	dial("user-2", http.StatusSwitchingProtocols)
+	dial("user-3", http.StatusTooManyRequests) // over the global limit
+	if got := hub.RejectedConnections(); got != 2 {
+		t.Errorf("expected 2 rejected connections, got %d", got)
+	}
+
+	// A closed connection frees its slot once the hub unregisters it
+	waitForConnections(t, hub, 3)
+	first.Close()
+	waitForConnections(t, hub, 2)
+	dial("user-3", http.StatusSwitchingProtocols)
+	if got := hub.RejectedConnections(); got != 2 {
+		t.Errorf("expected 2 rejected connections, got %d", got)
+	}
+}
+
+func TestHub_ReadLimit(t *testing.T) {
+	hub := NewHubWithConfig(&MockAuthService{}, HubConfig{ReadLimit: 64})
+	go hub.Run()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleCustomerWebSocket))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/customers/notifications"
+	conn, _, err := bearerDialer("test-token").Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	message := fmt.Sprintf(`{"action":"ping","padding":%q}`, strings.Repeat("x", 64))
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("expected the connection to be closed as too big, got %v", err)
+	}
+}
+
+// bearerDialer offers token in the Sec-WebSocket-Protocol header
+func bearerDialer(token string) *websocket.Dialer {
+	return &websocket.Dialer{Subprotocols: []string{BearerSubprotocol, token}}
+}
+
+// dialTracker connects a delivery tracker for delivery 42 to a running hub
+// whose authorizer allows the given deliveries
+func dialTracker(t *testing.T, allowed ...int) (*Hub, *websocket.Conn) {
+	t.Helper()
+	hub := NewHub(&MockAuthService{})
+	go hub.Run()
+	hub.SetAuthorizer(func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+		if authctx.AuthorizationFrom(ctx) != "Bearer test-token" {
+			return false, errors.New("missing caller authorization")
+		}
+		for _, id := range allowed {
+			if id == deliveryID {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	t.Cleanup(server.Close)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/deliveries/42/track"
+	conn, _, err := bearerDialer("test-token").Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return hub, conn
+}
+
+// exchange sends a client message and decodes the server's next frame
+func exchange(t *testing.T, conn *websocket.Conn, message string) *Envelope {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+	return readFrame(t, conn)
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn) *Envelope {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+	env, err := DecodeEnvelope(data)
+	if err != nil {
+		t.Fatalf("failed to decode frame %s: %v", data, err)
+	}
+	return env
+}
+
+// payloadOf decodes an envelope's payload into a map
+func payloadOf(t *testing.T, env *Envelope) map[string]interface{} {
+	t.Helper()
+	var payload map[string]interface{}
+	if err := env.DecodePayload(&payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	return payload
+}
+
+func TestHub_ClientMessages(t *testing.T) {
+	hub, conn := dialTracker(t, 42, 43)
+
+	tests := []struct {
+		name         string
+		message      string
+		expectedType string
+		expected     map[string]interface{}
+	}{
+		{name: "subscribe", message: `{"action":"subscribe","delivery_id":43}`, expectedType: TypeSubscribed, expected: map[string]interface{}{"delivery_id": float64(43)}},
+		{name: "subscribe twice", message: `{"action":"subscribe","delivery_id":43}`, expectedType: TypeSubscribed, expected: map[string]interface{}{"delivery_id": float64(43)}},
+		{name: "subscribe to a delivery of someone else", message: `{"action":"subscribe","delivery_id":99}`, expectedType: TypeError, expected: map[string]interface{}{"code": ErrCodeForbidden, "delivery_id": float64(99)}},
+		{name: "subscribe without delivery_id", message: `{"action":"subscribe"}`, expectedType: TypeError, expected: map[string]interface{}{"code": ErrCodeInvalidDeliveryID}},
+		{name: "malformed JSON", message: `{"action":`, expectedType: TypeError, expected: map[string]interface{}{"code": ErrCodeInvalidMessage}},
+		{name: "missing action", message: `{}`, expectedType: TypeError, expected: map[string]interface{}{"code": ErrCodeInvalidMessage}},
+		{name: "unknown action", message: `{"action":"dance"}`, expectedType: TypeError, expected: map[string]interface{}{"code": ErrCodeUnknownAction, "action": "dance"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := exchange(t, conn, tt.message)
+			if frame.Type != tt.expectedType {
+				t.Errorf("expected type %s, got %s", tt.expectedType, frame.Type)
+			}
+			payload := payloadOf(t, frame)
+			for key, want := range tt.expected {
+				if payload[key] != want {
+					t.Errorf("expected %s %v, got payload %v", key, want, payload)
+				}
+			}
+		})
+	}
+
+	// Both the path delivery and the subscribed one are broadcast to
+	for _, deliveryID := range []int{42, 43} {
+		hub.BroadcastLocation(deliveryID, &domain.Location{DeliveryID: deliveryID, Latitude: 40.7128, Longitude: -74.0060})
+		msg, err := readFrame(t, conn).Location()
+		if err != nil || msg.DeliveryID != deliveryID {
+			t.Errorf("expected location for delivery %d, got %v, %v", deliveryID, msg, err)
+		}
+	}
+
+	if frame := exchange(t, conn, `{"action":"unsubscribe","delivery_id":43}`); frame.Type != TypeUnsubscribed {
+		t.Errorf("expected unsubscribed, got %s", frame.Type)
+	}
+	if reply, err := exchange(t, conn, `{"action":"unsubscribe","delivery_id":43}`).ErrorReply(); err != nil || reply.Code != ErrCodeNotSubscribed {
+		t.Errorf("expected %s, got %v, %v", ErrCodeNotSubscribed, reply, err)
+	}
+
+	// Nothing more is sent for 43, so the next frame is the pong
+	hub.BroadcastLocation(43, &domain.Location{DeliveryID: 43})
+	frame := exchange(t, conn, `{"action":"ping"}`)
+	if frame.Type != TypePong {
+		t.Fatalf("expected pong, got %s", frame.Type)
+	}
+	if serverTime, _ := payloadOf(t, frame)["server_time"].(string); serverTime == "" {
+		t.Errorf("expected the pong to carry the server time, got %s", frame.Payload)
+	}
+}
+
+func TestHub_SubscriptionLimit(t *testing.T) {
+	allowed := make([]int, 0, MaxSubscriptionsPerClient+1)
+	for id := 42; id <= 42+MaxSubscriptionsPerClient; id++ {
+		allowed = append(allowed, id)
+	}
+	_, conn := dialTracker(t, allowed...)
+
+	// The path delivery counts towards the limit
+	for _, id := range allowed[1 : len(allowed)-1] {
+		if frame := exchange(t, conn, fmt.Sprintf(`{"action":"subscribe","delivery_id":%d}`, id)); frame.Type != TypeSubscribed {
+			t.Fatalf("expected subscribed to %d, got %s %s", id, frame.Type, frame.Payload)
+		}
+	}
+
+	last := allowed[len(allowed)-1]
+	if reply, err := exchange(t, conn, fmt.Sprintf(`{"action":"subscribe","delivery_id":%d}`, last)).ErrorReply(); err != nil || reply.Code != ErrCodeSubscriptionLimit {
+		t.Errorf("expected %s, got %v, %v", ErrCodeSubscriptionLimit, reply, err)
+	}
+}
+
+func TestHub_ReauthorizeDelivery(t *testing.T) {
+	hub, conn := dialTracker(t, 42, 43)
+	if frame := exchange(t, conn, `{"action":"subscribe","delivery_id":43}`); frame.Type != TypeSubscribed {
+		t.Fatalf("expected subscribed, got %s %s", frame.Type, frame.Payload)
+	}
+
+	// Delivery 43 is reassigned away from the tracker
+	hub.SetAuthorizer(func(ctx context.Context, claims *authDomain.Claims, deliveryID int) (bool, error) {
+		return deliveryID == 42, nil
+	})
+	hub.ReauthorizeDelivery(42)
+	hub.ReauthorizeDelivery(43)
+
+	reply, err := readFrame(t, conn).ErrorReply()
+	if err != nil || reply.Code != ErrCodeForbidden || reply.DeliveryID != 43 {
+		t.Fatalf("expected a forbidden error for delivery 43, got %+v, %v", reply, err)
+	}
+	if ids := hub.subscriptions(trackerOf(t, hub, 42)); fmt.Sprint(ids) != "[42]" {
+		t.Errorf("expected only delivery 42 left, got %v", ids)
+	}
+	// The connection stays open for the deliveries still allowed
+	if frame := exchange(t, conn, `{"action":"ping"}`); frame.Type != TypePong {
+		t.Errorf("expected a pong, got %s", frame.Type)
+	}
+}
+
+// trackerOf returns the only tracker of a delivery
+func trackerOf(t *testing.T, h *Hub, deliveryID int) *Client {
+	t.Helper()
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if len(h.clients[deliveryID]) != 1 {
+		t.Fatalf("expected one tracker of delivery %d, got %d", deliveryID, len(h.clients[deliveryID]))
+	}
+	for client := range h.clients[deliveryID] {
+		return client
+	}
+	return nil
+}
+
 func TestHub_HandleWebSocket_InvalidPath(t *testing.T) {
 	hub := NewHub(&MockAuthService{})
 
@@ -172,13 +739,13 @@ func TestClient_Creation(t *testing.T) {
 	// Create a mock WebSocket connection (we can't easily create a real one in tests)
 	// So we'll just test the client struct creation
 	client := &Client{
-		deliveryID: 1,
-		send:       make(chan interface{}, 256),
+		deliveries: map[int]bool{1: true},
+		send:       make(chan *outbound, 256),
 		hub:        hub,
 	}
 
-	if client.deliveryID != 1 {
-		t.Errorf("expected delivery ID 1, got %d", client.deliveryID)
+	if !client.deliveries[1] {
+		t.Errorf("expected delivery 1 to be tracked, got %v", client.deliveries)
 	}
 
 	if client.send == nil {
@@ -1229,12 +1229,14 @@ type CustomerAnalytics struct {
 	TotalDeliveries         int32                  `protobuf:"varint,2,opt,name=total_deliveries,json=totalDeliveries,proto3" json:"total_deliveries,omitempty"`
 	SuccessfulDeliveries    int32                  `protobuf:"varint,3,opt,name=successful_deliveries,json=successfulDeliveries,proto3" json:"successful_deliveries,omitempty"`
 	FailedDeliveries        int32                  `protobuf:"varint,4,opt,name=failed_deliveries,json=failedDeliveries,proto3" json:"failed_deliveries,omitempty"`
-	AverageDeliveryTime     float64                `protobuf:"fixed64,5,opt,name=average_delivery_time,json=averageDeliveryTime,proto3" json:"average_delivery_time,omitempty"` // minutes
-	FrequentLocations       []*common.Location     `protobuf:"bytes,6,rep,name=frequent_locations,json=frequentLocations,proto3" json:"frequent_locations,omitempty"`
+	AverageDeliveryTime     float64                `protobuf:"fixed64,5,opt,name=average_delivery_time,json=averageDeliveryTime,proto3" json:"average_delivery_time,omitempty"`                                                                                      // minutes
+	FrequentLocations       []*common.Location     `protobuf:"bytes,6,rep,name=frequent_locations,json=frequentLocations,proto3" json:"frequent_locations,omitempty"`                                                                                                // top drop-off zones, by city
 	DeliveryTimePreferences map[string]int32       `protobuf:"bytes,7,rep,name=delivery_time_preferences,json=deliveryTimePreferences,proto3" json:"delivery_time_preferences,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // time slot -> count
 	TotalSpent              float64                `protobuf:"fixed64,8,opt,name=total_spent,json=totalSpent,proto3" json:"total_spent,omitempty"`
 	CustomerSince           int64                  `protobuf:"varint,9,opt,name=customer_since,json=customerSince,proto3" json:"customer_since,omitempty"`
 	SatisfactionScore       float64                `protobuf:"fixed64,10,opt,name=satisfaction_score,json=satisfactionScore,proto3" json:"satisfaction_score,omitempty"`
+	OnTimeRate              float64                `protobuf:"fixed64,11,opt,name=on_time_rate,json=onTimeRate,proto3" json:"on_time_rate,omitempty"` // percentage of deliveries with a scheduled window
+	Monthly                 []*TimeSeriesPoint     `protobuf:"bytes,12,rep,name=monthly,proto3" json:"monthly,omitempty"`                             // metrics: created, delivered, cancelled
 	unknownFields           protoimpl.UnknownFields
 	sizeCache               protoimpl.SizeCache
 }
@@ -1339,6 +1341,20 @@ func (x *CustomerAnalytics) GetSatisfactionScore() float64 {
 	return 0
 }
 
+func (x *CustomerAnalytics) GetOnTimeRate() float64 {
+	if x != nil {
+		return x.OnTimeRate
+	}
+	return 0
+}
+
+func (x *CustomerAnalytics) GetMonthly() []*TimeSeriesPoint {
+	if x != nil {
+		return x.Monthly
+	}
+	return nil
+}
+
 type GetSystemMetricsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TimeRange     *common.TimeRange      `protobuf:"bytes,1,opt,name=time_range,json=timeRange,proto3" json:"time_range,omitempty"`
@@ -2312,17 +2328,23 @@ func (x *GetRouteEfficiencyResponse) GetEfficiency() *RouteEfficiency {
 }
 
 type RouteEfficiency struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	TotalDistance   float64                `protobuf:"fixed64,1,opt,name=total_distance,json=totalDistance,proto3" json:"total_distance,omitempty"`       // km
-	OptimalDistance float64                `protobuf:"fixed64,2,opt,name=optimal_distance,json=optimalDistance,proto3" json:"optimal_distance,omitempty"` // km
-	EfficiencyScore float64                `protobuf:"fixed64,3,opt,name=efficiency_score,json=efficiencyScore,proto3" json:"efficiency_score,omitempty"` // 0-100
-	AverageSpeed    float64                `protobuf:"fixed64,4,opt,name=average_speed,json=averageSpeed,proto3" json:"average_speed,omitempty"`          // km/h
-	TotalStops      int32                  `protobuf:"varint,5,opt,name=total_stops,json=totalStops,proto3" json:"total_stops,omitempty"`
-	IdleTime        float64                `protobuf:"fixed64,6,opt,name=idle_time,json=idleTime,proto3" json:"idle_time,omitempty"`                   // minutes
-	DrivingTime     float64                `protobuf:"fixed64,7,opt,name=driving_time,json=drivingTime,proto3" json:"driving_time,omitempty"`          // minutes
-	FuelEfficiency  float64                `protobuf:"fixed64,8,opt,name=fuel_efficiency,json=fuelEfficiency,proto3" json:"fuel_efficiency,omitempty"` // km/l
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state                  protoimpl.MessageState  `protogen:"open.v1"`
+	TotalDistance          float64                 `protobuf:"fixed64,1,opt,name=total_distance,json=totalDistance,proto3" json:"total_distance,omitempty"`       // km
+	OptimalDistance        float64                 `protobuf:"fixed64,2,opt,name=optimal_distance,json=optimalDistance,proto3" json:"optimal_distance,omitempty"` // km
+	EfficiencyScore        float64                 `protobuf:"fixed64,3,opt,name=efficiency_score,json=efficiencyScore,proto3" json:"efficiency_score,omitempty"` // 0-100
+	AverageSpeed           float64                 `protobuf:"fixed64,4,opt,name=average_speed,json=averageSpeed,proto3" json:"average_speed,omitempty"`          // km/h
+	TotalStops             int32                   `protobuf:"varint,5,opt,name=total_stops,json=totalStops,proto3" json:"total_stops,omitempty"`
+	IdleTime               float64                 `protobuf:"fixed64,6,opt,name=idle_time,json=idleTime,proto3" json:"idle_time,omitempty"`                   // minutes
+	DrivingTime            float64                 `protobuf:"fixed64,7,opt,name=driving_time,json=drivingTime,proto3" json:"driving_time,omitempty"`          // minutes
+	FuelEfficiency         float64                 `protobuf:"fixed64,8,opt,name=fuel_efficiency,json=fuelEfficiency,proto3" json:"fuel_efficiency,omitempty"` // km/l
+	DistanceRatio          float64                 `protobuf:"fixed64,9,opt,name=distance_ratio,json=distanceRatio,proto3" json:"distance_ratio,omitempty"`    // total_distance / optimal_distance, 1 for direct routes
+	MeasuredDeliveries     int32                   `protobuf:"varint,10,opt,name=measured_deliveries,json=measuredDeliveries,proto3" json:"measured_deliveries,omitempty"`
+	SparseTrackDeliveries  int32                   `protobuf:"varint,11,opt,name=sparse_track_deliveries,json=sparseTrackDeliveries,proto3" json:"sparse_track_deliveries,omitempty"`  // fewer than two recorded points, excluded
+	UnmeasurableDeliveries int32                   `protobuf:"varint,12,opt,name=unmeasurable_deliveries,json=unmeasurableDeliveries,proto3" json:"unmeasurable_deliveries,omitempty"` // pickup or drop-off not located, excluded
+	Drivers                []*RouteEfficiencyGroup `protobuf:"bytes,13,rep,name=drivers,proto3" json:"drivers,omitempty"`
+	Zones                  []*RouteEfficiencyGroup `protobuf:"bytes,14,rep,name=zones,proto3" json:"zones,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
 func (x *RouteEfficiency) Reset() {
@@ -2411,6 +2433,140 @@ func (x *RouteEfficiency) GetFuelEfficiency() float64 {
 	return 0
 }
 
+func (x *RouteEfficiency) GetDistanceRatio() float64 {
+	if x != nil {
+		return x.DistanceRatio
+	}
+	return 0
+}
+
+func (x *RouteEfficiency) GetMeasuredDeliveries() int32 {
+	if x != nil {
+		return x.MeasuredDeliveries
+	}
+	return 0
+}
+
+func (x *RouteEfficiency) GetSparseTrackDeliveries() int32 {
+	if x != nil {
+		return x.SparseTrackDeliveries
+	}
+	return 0
+}
+
+func (x *RouteEfficiency) GetUnmeasurableDeliveries() int32 {
+	if x != nil {
+		return x.UnmeasurableDeliveries
+	}
+	return 0
+}
+
+func (x *RouteEfficiency) GetDrivers() []*RouteEfficiencyGroup {
+	if x != nil {
+		return x.Drivers
+	}
+	return nil
+}
+
+func (x *RouteEfficiency) GetZones() []*RouteEfficiencyGroup {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+type RouteEfficiencyGroup struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Key                    string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`                                                  // driver ID or zone
+	TotalDistance          float64                `protobuf:"fixed64,2,opt,name=total_distance,json=totalDistance,proto3" json:"total_distance,omitempty"`       // km
+	OptimalDistance        float64                `protobuf:"fixed64,3,opt,name=optimal_distance,json=optimalDistance,proto3" json:"optimal_distance,omitempty"` // km
+	DistanceRatio          float64                `protobuf:"fixed64,4,opt,name=distance_ratio,json=distanceRatio,proto3" json:"distance_ratio,omitempty"`
+	MeasuredDeliveries     int32                  `protobuf:"varint,5,opt,name=measured_deliveries,json=measuredDeliveries,proto3" json:"measured_deliveries,omitempty"`
+	SparseTrackDeliveries  int32                  `protobuf:"varint,6,opt,name=sparse_track_deliveries,json=sparseTrackDeliveries,proto3" json:"sparse_track_deliveries,omitempty"`
+	UnmeasurableDeliveries int32                  `protobuf:"varint,7,opt,name=unmeasurable_deliveries,json=unmeasurableDeliveries,proto3" json:"unmeasurable_deliveries,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *RouteEfficiencyGroup) Reset() {
+	*x = RouteEfficiencyGroup{}
+	mi := &file_analytics_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteEfficiencyGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteEfficiencyGroup) ProtoMessage() {}
+
+func (x *RouteEfficiencyGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteEfficiencyGroup.ProtoReflect.Descriptor instead.
+func (*RouteEfficiencyGroup) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RouteEfficiencyGroup) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RouteEfficiencyGroup) GetTotalDistance() float64 {
+	if x != nil {
+		return x.TotalDistance
+	}
+	return 0
+}
+
+func (x *RouteEfficiencyGroup) GetOptimalDistance() float64 {
+	if x != nil {
+		return x.OptimalDistance
+	}
+	return 0
+}
+
+func (x *RouteEfficiencyGroup) GetDistanceRatio() float64 {
+	if x != nil {
+		return x.DistanceRatio
+	}
+	return 0
+}
+
+func (x *RouteEfficiencyGroup) GetMeasuredDeliveries() int32 {
+	if x != nil {
+		return x.MeasuredDeliveries
+	}
+	return 0
+}
+
+func (x *RouteEfficiencyGroup) GetSparseTrackDeliveries() int32 {
+	if x != nil {
+		return x.SparseTrackDeliveries
+	}
+	return 0
+}
+
+func (x *RouteEfficiencyGroup) GetUnmeasurableDeliveries() int32 {
+	if x != nil {
+		return x.UnmeasurableDeliveries
+	}
+	return 0
+}
+
 var File_analytics_proto protoreflect.FileDescriptor
 
 const file_analytics_proto_rawDesc = "" +
@@ -2492,7 +2648,7 @@ const file_analytics_proto_rawDesc = "" +
 	"\n" +
 	"time_range\x18\x02 \x01(\v2\x1e.delivertrack.common.TimeRangeR\ttimeRange\"g\n" +
 	"\x1cGetCustomerAnalyticsResponse\x12G\n" +
-	"\tanalytics\x18\x01 \x01(\v2).delivertrack.analytics.CustomerAnalyticsR\tanalytics\"\x8b\x05\n" +
+	"\tanalytics\x18\x01 \x01(\v2).delivertrack.analytics.CustomerAnalyticsR\tanalytics\"\xf0\x05\n" +
 	"\x11CustomerAnalytics\x12\x1f\n" +
 	"\vcustomer_id\x18\x01 \x01(\tR\n" +
 	"customerId\x12)\n" +
@@ -2506,7 +2662,10 @@ const file_analytics_proto_rawDesc = "" +
 	"totalSpent\x12%\n" +
 	"\x0ecustomer_since\x18\t \x01(\x03R\rcustomerSince\x12-\n" +
 	"\x12satisfaction_score\x18\n" +
-	" \x01(\x01R\x11satisfactionScore\x1aJ\n" +
+	" \x01(\x01R\x11satisfactionScore\x12 \n" +
+	"\fon_time_rate\x18\v \x01(\x01R\n" +
+	"onTimeRate\x12A\n" +
+	"\amonthly\x18\f \x03(\v2'.delivertrack.analytics.TimeSeriesPointR\amonthly\x1aJ\n" +
 	"\x1cDeliveryTimePreferencesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"X\n" +
@@ -2599,7 +2758,7 @@ const file_analytics_proto_rawDesc = "" +
 	"\x1aGetRouteEfficiencyResponse\x12G\n" +
 	"\n" +
 	"efficiency\x18\x01 \x01(\v2'.delivertrack.analytics.RouteEfficiencyR\n" +
-	"efficiency\"\xbd\x02\n" +
+	"efficiency\"\x92\x05\n" +
 	"\x0fRouteEfficiency\x12%\n" +
 	"\x0etotal_distance\x18\x01 \x01(\x01R\rtotalDistance\x12)\n" +
 	"\x10optimal_distance\x18\x02 \x01(\x01R\x0foptimalDistance\x12)\n" +
@@ -2609,7 +2768,22 @@ const file_analytics_proto_rawDesc = "" +
 	"totalStops\x12\x1b\n" +
 	"\tidle_time\x18\x06 \x01(\x01R\bidleTime\x12!\n" +
 	"\fdriving_time\x18\a \x01(\x01R\vdrivingTime\x12'\n" +
-	"\x0ffuel_efficiency\x18\b \x01(\x01R\x0efuelEfficiency*\xad\x01\n" +
+	"\x0ffuel_efficiency\x18\b \x01(\x01R\x0efuelEfficiency\x12%\n" +
+	"\x0edistance_ratio\x18\t \x01(\x01R\rdistanceRatio\x12/\n" +
+	"\x13measured_deliveries\x18\n" +
+	" \x01(\x05R\x12measuredDeliveries\x126\n" +
+	"\x17sparse_track_deliveries\x18\v \x01(\x05R\x15sparseTrackDeliveries\x127\n" +
+	"\x17unmeasurable_deliveries\x18\f \x01(\x05R\x16unmeasurableDeliveries\x12F\n" +
+	"\adrivers\x18\r \x03(\v2,.delivertrack.analytics.RouteEfficiencyGroupR\adrivers\x12B\n" +
+	"\x05zones\x18\x0e \x03(\v2,.delivertrack.analytics.RouteEfficiencyGroupR\x05zones\"\xc3\x02\n" +
+	"\x14RouteEfficiencyGroup\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12%\n" +
+	"\x0etotal_distance\x18\x02 \x01(\x01R\rtotalDistance\x12)\n" +
+	"\x10optimal_distance\x18\x03 \x01(\x01R\x0foptimalDistance\x12%\n" +
+	"\x0edistance_ratio\x18\x04 \x01(\x01R\rdistanceRatio\x12/\n" +
+	"\x13measured_deliveries\x18\x05 \x01(\x05R\x12measuredDeliveries\x126\n" +
+	"\x17sparse_track_deliveries\x18\x06 \x01(\x05R\x15sparseTrackDeliveries\x127\n" +
+	"\x17unmeasurable_deliveries\x18\a \x01(\x05R\x16unmeasurableDeliveries*\xad\x01\n" +
 	"\x10AggregationLevel\x12!\n" +
 	"\x1dAGGREGATION_LEVEL_UNSPECIFIED\x10\x00\x12\x1c\n" +
 	"\x18AGGREGATION_LEVEL_HOURLY\x10\x01\x12\x1b\n" +
@@ -2678,7 +2852,7 @@ func file_analytics_proto_rawDescGZIP() []byte {
 }
 
 var file_analytics_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
-var file_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
+var file_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_analytics_proto_goTypes = []any{
 	(AggregationLevel)(0),                // 0: delivertrack.analytics.AggregationLevel
 	(ReportType)(0),                      // 1: delivertrack.analytics.ReportType
@@ -2716,71 +2890,75 @@ var file_analytics_proto_goTypes = []any{
 	(*GetRouteEfficiencyRequest)(nil),    // 33: delivertrack.analytics.GetRouteEfficiencyRequest
 	(*GetRouteEfficiencyResponse)(nil),   // 34: delivertrack.analytics.GetRouteEfficiencyResponse
 	(*RouteEfficiency)(nil),              // 35: delivertrack.analytics.RouteEfficiency
-	nil,                                  // 36: delivertrack.analytics.RecordEventRequest.PropertiesEntry
-	nil,                                  // 37: delivertrack.analytics.CustomerAnalytics.DeliveryTimePreferencesEntry
-	nil,                                  // 38: delivertrack.analytics.SystemMetrics.ApiCallCountsEntry
-	nil,                                  // 39: delivertrack.analytics.GenerateReportRequest.FiltersEntry
-	nil,                                  // 40: delivertrack.analytics.TimeSeriesPoint.MetricsEntry
-	(*common.TimeRange)(nil),             // 41: delivertrack.common.TimeRange
-	(*common.Location)(nil),              // 42: delivertrack.common.Location
+	(*RouteEfficiencyGroup)(nil),         // 36: delivertrack.analytics.RouteEfficiencyGroup
+	nil,                                  // 37: delivertrack.analytics.RecordEventRequest.PropertiesEntry
+	nil,                                  // 38: delivertrack.analytics.CustomerAnalytics.DeliveryTimePreferencesEntry
+	nil,                                  // 39: delivertrack.analytics.SystemMetrics.ApiCallCountsEntry
+	nil,                                  // 40: delivertrack.analytics.GenerateReportRequest.FiltersEntry
+	nil,                                  // 41: delivertrack.analytics.TimeSeriesPoint.MetricsEntry
+	(*common.TimeRange)(nil),             // 42: delivertrack.common.TimeRange
+	(*common.Location)(nil),              // 43: delivertrack.common.Location
 }
 var file_analytics_proto_depIdxs = []int32{
-	36, // 0: delivertrack.analytics.RecordEventRequest.properties:type_name -> delivertrack.analytics.RecordEventRequest.PropertiesEntry
+	37, // 0: delivertrack.analytics.RecordEventRequest.properties:type_name -> delivertrack.analytics.RecordEventRequest.PropertiesEntry
 	7,  // 1: delivertrack.analytics.BatchRecordEventsRequest.events:type_name -> delivertrack.analytics.RecordEventRequest
-	41, // 2: delivertrack.analytics.GetDeliveryMetricsRequest.time_range:type_name -> delivertrack.common.TimeRange
+	42, // 2: delivertrack.analytics.GetDeliveryMetricsRequest.time_range:type_name -> delivertrack.common.TimeRange
 	0,  // 3: delivertrack.analytics.GetDeliveryMetricsRequest.aggregation:type_name -> delivertrack.analytics.AggregationLevel
 	13, // 4: delivertrack.analytics.GetDeliveryMetricsResponse.metrics:type_name -> delivertrack.analytics.DeliveryMetrics
 	31, // 5: delivertrack.analytics.GetDeliveryMetricsResponse.time_series:type_name -> delivertrack.analytics.TimeSeriesPoint
-	41, // 6: delivertrack.analytics.GetDriverPerformanceRequest.time_range:type_name -> delivertrack.common.TimeRange
+	42, // 6: delivertrack.analytics.GetDriverPerformanceRequest.time_range:type_name -> delivertrack.common.TimeRange
 	16, // 7: delivertrack.analytics.GetDriverPerformanceResponse.performance:type_name -> delivertrack.analytics.DriverPerformance
-	41, // 8: delivertrack.analytics.GetCustomerAnalyticsRequest.time_range:type_name -> delivertrack.common.TimeRange
+	42, // 8: delivertrack.analytics.GetCustomerAnalyticsRequest.time_range:type_name -> delivertrack.common.TimeRange
 	19, // 9: delivertrack.analytics.GetCustomerAnalyticsResponse.analytics:type_name -> delivertrack.analytics.CustomerAnalytics
-	42, // 10: delivertrack.analytics.CustomerAnalytics.frequent_locations:type_name -> delivertrack.common.Location
-	37, // 11: delivertrack.analytics.CustomerAnalytics.delivery_time_preferences:type_name -> delivertrack.analytics.CustomerAnalytics.DeliveryTimePreferencesEntry
-	41, // 12: delivertrack.analytics.GetSystemMetricsRequest.time_range:type_name -> delivertrack.common.TimeRange
-	22, // 13: delivertrack.analytics.GetSystemMetricsResponse.metrics:type_name -> delivertrack.analytics.SystemMetrics
-	38, // 14: delivertrack.analytics.SystemMetrics.api_call_counts:type_name -> delivertrack.analytics.SystemMetrics.ApiCallCountsEntry
-	23, // 15: delivertrack.analytics.SystemMetrics.resource_usage:type_name -> delivertrack.analytics.ResourceUsage
-	1,  // 16: delivertrack.analytics.GenerateReportRequest.type:type_name -> delivertrack.analytics.ReportType
-	41, // 17: delivertrack.analytics.GenerateReportRequest.time_range:type_name -> delivertrack.common.TimeRange
-	2,  // 18: delivertrack.analytics.GenerateReportRequest.format:type_name -> delivertrack.analytics.ReportFormat
-	39, // 19: delivertrack.analytics.GenerateReportRequest.filters:type_name -> delivertrack.analytics.GenerateReportRequest.FiltersEntry
-	3,  // 20: delivertrack.analytics.GetDashboardRequest.type:type_name -> delivertrack.analytics.DashboardType
-	28, // 21: delivertrack.analytics.GetDashboardResponse.dashboard:type_name -> delivertrack.analytics.Dashboard
-	13, // 22: delivertrack.analytics.Dashboard.delivery_summary:type_name -> delivertrack.analytics.DeliveryMetrics
-	29, // 23: delivertrack.analytics.Dashboard.kpis:type_name -> delivertrack.analytics.KPI
-	30, // 24: delivertrack.analytics.Dashboard.charts:type_name -> delivertrack.analytics.Chart
-	32, // 25: delivertrack.analytics.Dashboard.alerts:type_name -> delivertrack.analytics.Alert
-	5,  // 26: delivertrack.analytics.KPI.trend:type_name -> delivertrack.analytics.Trend
-	4,  // 27: delivertrack.analytics.Chart.type:type_name -> delivertrack.analytics.ChartType
-	31, // 28: delivertrack.analytics.Chart.data:type_name -> delivertrack.analytics.TimeSeriesPoint
-	40, // 29: delivertrack.analytics.TimeSeriesPoint.metrics:type_name -> delivertrack.analytics.TimeSeriesPoint.MetricsEntry
-	6,  // 30: delivertrack.analytics.Alert.severity:type_name -> delivertrack.analytics.AlertSeverity
-	41, // 31: delivertrack.analytics.GetRouteEfficiencyRequest.time_range:type_name -> delivertrack.common.TimeRange
-	35, // 32: delivertrack.analytics.GetRouteEfficiencyResponse.efficiency:type_name -> delivertrack.analytics.RouteEfficiency
-	7,  // 33: delivertrack.analytics.AnalyticsService.RecordEvent:input_type -> delivertrack.analytics.RecordEventRequest
-	9,  // 34: delivertrack.analytics.AnalyticsService.BatchRecordEvents:input_type -> delivertrack.analytics.BatchRecordEventsRequest
-	11, // 35: delivertrack.analytics.AnalyticsService.GetDeliveryMetrics:input_type -> delivertrack.analytics.GetDeliveryMetricsRequest
-	14, // 36: delivertrack.analytics.AnalyticsService.GetDriverPerformance:input_type -> delivertrack.analytics.GetDriverPerformanceRequest
-	17, // 37: delivertrack.analytics.AnalyticsService.GetCustomerAnalytics:input_type -> delivertrack.analytics.GetCustomerAnalyticsRequest
-	20, // 38: delivertrack.analytics.AnalyticsService.GetSystemMetrics:input_type -> delivertrack.analytics.GetSystemMetricsRequest
-	24, // 39: delivertrack.analytics.AnalyticsService.GenerateReport:input_type -> delivertrack.analytics.GenerateReportRequest
-	26, // 40: delivertrack.analytics.AnalyticsService.GetDashboard:input_type -> delivertrack.analytics.GetDashboardRequest
-	33, // 41: delivertrack.analytics.AnalyticsService.GetRouteEfficiency:input_type -> delivertrack.analytics.GetRouteEfficiencyRequest
-	8,  // 42: delivertrack.analytics.AnalyticsService.RecordEvent:output_type -> delivertrack.analytics.RecordEventResponse
-	10, // 43: delivertrack.analytics.AnalyticsService.BatchRecordEvents:output_type -> delivertrack.analytics.BatchRecordEventsResponse
-	12, // 44: delivertrack.analytics.AnalyticsService.GetDeliveryMetrics:output_type -> delivertrack.analytics.GetDeliveryMetricsResponse
-	15, // 45: delivertrack.analytics.AnalyticsService.GetDriverPerformance:output_type -> delivertrack.analytics.GetDriverPerformanceResponse
-	18, // 46: delivertrack.analytics.AnalyticsService.GetCustomerAnalytics:output_type -> delivertrack.analytics.GetCustomerAnalyticsResponse
-	21, // 47: delivertrack.analytics.AnalyticsService.GetSystemMetrics:output_type -> delivertrack.analytics.GetSystemMetricsResponse
-	25, // 48: delivertrack.analytics.AnalyticsService.GenerateReport:output_type -> delivertrack.analytics.GenerateReportResponse
-	27, // 49: delivertrack.analytics.AnalyticsService.GetDashboard:output_type -> delivertrack.analytics.GetDashboardResponse
-	34, // 50: delivertrack.analytics.AnalyticsService.GetRouteEfficiency:output_type -> delivertrack.analytics.GetRouteEfficiencyResponse
-	42, // [42:51] is the sub-list for method output_type
-	33, // [33:42] is the sub-list for method input_type
-	33, // [33:33] is the sub-list for extension type_name
-	33, // [33:33] is the sub-list for extension extendee
-	0,  // [0:33] is the sub-list for field type_name
+	43, // 10: delivertrack.analytics.CustomerAnalytics.frequent_locations:type_name -> delivertrack.common.Location
+	38, // 11: delivertrack.analytics.CustomerAnalytics.delivery_time_preferences:type_name -> delivertrack.analytics.CustomerAnalytics.DeliveryTimePreferencesEntry
+	31, // 12: delivertrack.analytics.CustomerAnalytics.monthly:type_name -> delivertrack.analytics.TimeSeriesPoint
+	42, // 13: delivertrack.analytics.GetSystemMetricsRequest.time_range:type_name -> delivertrack.common.TimeRange
+	22, // 14: delivertrack.analytics.GetSystemMetricsResponse.metrics:type_name -> delivertrack.analytics.SystemMetrics
+	39, // 15: delivertrack.analytics.SystemMetrics.api_call_counts:type_name -> delivertrack.analytics.SystemMetrics.ApiCallCountsEntry
+	23, // 16: delivertrack.analytics.SystemMetrics.resource_usage:type_name -> delivertrack.analytics.ResourceUsage
+	1,  // 17: delivertrack.analytics.GenerateReportRequest.type:type_name -> delivertrack.analytics.ReportType
+	42, // 18: delivertrack.analytics.GenerateReportRequest.time_range:type_name -> delivertrack.common.TimeRange
+	2,  // 19: delivertrack.analytics.GenerateReportRequest.format:type_name -> delivertrack.analytics.ReportFormat
+	40, // 20: delivertrack.analytics.GenerateReportRequest.filters:type_name -> delivertrack.analytics.GenerateReportRequest.FiltersEntry
+	3,  // 21: delivertrack.analytics.GetDashboardRequest.type:type_name -> delivertrack.analytics.DashboardType
+	28, // 22: delivertrack.analytics.GetDashboardResponse.dashboard:type_name -> delivertrack.analytics.Dashboard
+	13, // 23: delivertrack.analytics.Dashboard.delivery_summary:type_name -> delivertrack.analytics.DeliveryMetrics
+	29, // 24: delivertrack.analytics.Dashboard.kpis:type_name -> delivertrack.analytics.KPI
+	30, // 25: delivertrack.analytics.Dashboard.charts:type_name -> delivertrack.analytics.Chart
+	32, // 26: delivertrack.analytics.Dashboard.alerts:type_name -> delivertrack.analytics.Alert
+	5,  // 27: delivertrack.analytics.KPI.trend:type_name -> delivertrack.analytics.Trend
+	4,  // 28: delivertrack.analytics.Chart.type:type_name -> delivertrack.analytics.ChartType
+	31, // 29: delivertrack.analytics.Chart.data:type_name -> delivertrack.analytics.TimeSeriesPoint
+	41, // 30: delivertrack.analytics.TimeSeriesPoint.metrics:type_name -> delivertrack.analytics.TimeSeriesPoint.MetricsEntry
+	6,  // 31: delivertrack.analytics.Alert.severity:type_name -> delivertrack.analytics.AlertSeverity
+	42, // 32: delivertrack.analytics.GetRouteEfficiencyRequest.time_range:type_name -> delivertrack.common.TimeRange
+	35, // 33: delivertrack.analytics.GetRouteEfficiencyResponse.efficiency:type_name -> delivertrack.analytics.RouteEfficiency
+	36, // 34: delivertrack.analytics.RouteEfficiency.drivers:type_name -> delivertrack.analytics.RouteEfficiencyGroup
+	36, // 35: delivertrack.analytics.RouteEfficiency.zones:type_name -> delivertrack.analytics.RouteEfficiencyGroup
+	7,  // 36: delivertrack.analytics.AnalyticsService.RecordEvent:input_type -> delivertrack.analytics.RecordEventRequest
+	9,  // 37: delivertrack.analytics.AnalyticsService.BatchRecordEvents:input_type -> delivertrack.analytics.BatchRecordEventsRequest
+	11, // 38: delivertrack.analytics.AnalyticsService.GetDeliveryMetrics:input_type -> delivertrack.analytics.GetDeliveryMetricsRequest
+	14, // 39: delivertrack.analytics.AnalyticsService.GetDriverPerformance:input_type -> delivertrack.analytics.GetDriverPerformanceRequest
+	17, // 40: delivertrack.analytics.AnalyticsService.GetCustomerAnalytics:input_type -> delivertrack.analytics.GetCustomerAnalyticsRequest
+	20, // 41: delivertrack.analytics.AnalyticsService.GetSystemMetrics:input_type -> delivertrack.analytics.GetSystemMetricsRequest
+	24, // 42: delivertrack.analytics.AnalyticsService.GenerateReport:input_type -> delivertrack.analytics.GenerateReportRequest
+	26, // 43: delivertrack.analytics.AnalyticsService.GetDashboard:input_type -> delivertrack.analytics.GetDashboardRequest
+	33, // 44: delivertrack.analytics.AnalyticsService.GetRouteEfficiency:input_type -> delivertrack.analytics.GetRouteEfficiencyRequest
+	8,  // 45: delivertrack.analytics.AnalyticsService.RecordEvent:output_type -> delivertrack.analytics.RecordEventResponse
+	10, // 46: delivertrack.analytics.AnalyticsService.BatchRecordEvents:output_type -> delivertrack.analytics.BatchRecordEventsResponse
+	12, // 47: delivertrack.analytics.AnalyticsService.GetDeliveryMetrics:output_type -> delivertrack.analytics.GetDeliveryMetricsResponse
+	15, // 48: delivertrack.analytics.AnalyticsService.GetDriverPerformance:output_type -> delivertrack.analytics.GetDriverPerformanceResponse
+	18, // 49: delivertrack.analytics.AnalyticsService.GetCustomerAnalytics:output_type -> delivertrack.analytics.GetCustomerAnalyticsResponse
+	21, // 50: delivertrack.analytics.AnalyticsService.GetSystemMetrics:output_type -> delivertrack.analytics.GetSystemMetricsResponse
+	25, // 51: delivertrack.analytics.AnalyticsService.GenerateReport:output_type -> delivertrack.analytics.GenerateReportResponse
+	27, // 52: delivertrack.analytics.AnalyticsService.GetDashboard:output_type -> delivertrack.analytics.GetDashboardResponse
+	34, // 53: delivertrack.analytics.AnalyticsService.GetRouteEfficiency:output_type -> delivertrack.analytics.GetRouteEfficiencyResponse
+	45, // [45:54] is the sub-list for method output_type
+	36, // [36:45] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
 }
 
 func init() { file_analytics_proto_init() }
@@ -2794,7 +2972,7 @@ func file_analytics_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_analytics_proto_rawDesc), len(file_analytics_proto_rawDesc)),
 			NumEnums:      7,
-			NumMessages:   34,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
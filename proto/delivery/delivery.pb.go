@@ -316,10 +316,12 @@ func (x *CreateDeliveryResponse) GetCreatedAt() int64 {
 }
 
 type GetDeliveryRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId      string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	IncludeLocation bool                   `protobuf:"varint,2,opt,name=include_location,json=includeLocation,proto3" json:"include_location,omitempty"` // look up the latest location in the tracking service
+	IncludeEta      bool                   `protobuf:"varint,3,opt,name=include_eta,json=includeEta,proto3" json:"include_eta,omitempty"`                // estimate the arrival at the delivery location
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *GetDeliveryRequest) Reset() {
@@ -359,11 +361,28 @@ func (x *GetDeliveryRequest) GetDeliveryId() string {
 	return ""
 }
 
+func (x *GetDeliveryRequest) GetIncludeLocation() bool {
+	if x != nil {
+		return x.IncludeLocation
+	}
+	return false
+}
+
+func (x *GetDeliveryRequest) GetIncludeEta() bool {
+	if x != nil {
+		return x.IncludeEta
+	}
+	return false
+}
+
 type GetDeliveryResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Delivery      *Delivery              `protobuf:"bytes,1,opt,name=delivery,proto3" json:"delivery,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Delivery        *Delivery              `protobuf:"bytes,1,opt,name=delivery,proto3" json:"delivery,omitempty"`
+	CurrentLocation *TrackedLocation       `protobuf:"bytes,2,opt,name=current_location,json=currentLocation,proto3" json:"current_location,omitempty"` // unset unless requested and known
+	Eta             *DeliveryETA           `protobuf:"bytes,3,opt,name=eta,proto3" json:"eta,omitempty"`                                                // unset unless requested and known
+	Partial         bool                   `protobuf:"varint,4,opt,name=partial,proto3" json:"partial,omitempty"`                                       // a requested lookup failed or timed out
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *GetDeliveryResponse) Reset() {
@@ -403,6 +422,139 @@ func (x *GetDeliveryResponse) GetDelivery() *Delivery {
 	return nil
 }
 
+func (x *GetDeliveryResponse) GetCurrentLocation() *TrackedLocation {
+	if x != nil {
+		return x.CurrentLocation
+	}
+	return nil
+}
+
+func (x *GetDeliveryResponse) GetEta() *DeliveryETA {
+	if x != nil {
+		return x.Eta
+	}
+	return nil
+}
+
+func (x *GetDeliveryResponse) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+type TrackedLocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Location      *common.Location       `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix seconds of the latest point
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackedLocation) Reset() {
+	*x = TrackedLocation{}
+	mi := &file_delivery_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackedLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackedLocation) ProtoMessage() {}
+
+func (x *TrackedLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_delivery_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackedLocation.ProtoReflect.Descriptor instead.
+func (*TrackedLocation) Descriptor() ([]byte, []int) {
+	return file_delivery_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TrackedLocation) GetLocation() *common.Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *TrackedLocation) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type DeliveryETA struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	EtaSeconds       int64                  `protobuf:"varint,1,opt,name=eta_seconds,json=etaSeconds,proto3" json:"eta_seconds,omitempty"`
+	DistanceKm       float64                `protobuf:"fixed64,2,opt,name=distance_km,json=distanceKm,proto3" json:"distance_km,omitempty"`
+	EstimatedArrival int64                  `protobuf:"varint,3,opt,name=estimated_arrival,json=estimatedArrival,proto3" json:"estimated_arrival,omitempty"` // unix seconds
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *DeliveryETA) Reset() {
+	*x = DeliveryETA{}
+	mi := &file_delivery_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeliveryETA) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliveryETA) ProtoMessage() {}
+
+func (x *DeliveryETA) ProtoReflect() protoreflect.Message {
+	mi := &file_delivery_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliveryETA.ProtoReflect.Descriptor instead.
+func (*DeliveryETA) Descriptor() ([]byte, []int) {
+	return file_delivery_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeliveryETA) GetEtaSeconds() int64 {
+	if x != nil {
+		return x.EtaSeconds
+	}
+	return 0
+}
+
+func (x *DeliveryETA) GetDistanceKm() float64 {
+	if x != nil {
+		return x.DistanceKm
+	}
+	return 0
+}
+
+func (x *DeliveryETA) GetEstimatedArrival() int64 {
+	if x != nil {
+		return x.EstimatedArrival
+	}
+	return 0
+}
+
 type Delivery struct {
 	state               protoimpl.MessageState `protogen:"open.v1"`
 	DeliveryId          string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
@@ -424,13 +576,14 @@ type Delivery struct {
 	PhotoUrls           []string               `protobuf:"bytes,17,rep,name=photo_urls,json=photoUrls,proto3" json:"photo_urls,omitempty"`
 	CreatedAt           int64                  `protobuf:"varint,18,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt           int64                  `protobuf:"varint,19,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Version             int32                  `protobuf:"varint,20,opt,name=version,proto3" json:"version,omitempty"` // bumped by every update
 	unknownFields       protoimpl.UnknownFields
 	sizeCache           protoimpl.SizeCache
 }
 
 func (x *Delivery) Reset() {
 	*x = Delivery{}
-	mi := &file_delivery_proto_msgTypes[4]
+	mi := &file_delivery_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -442,7 +595,7 @@ func (x *Delivery) String() string {
 func (*Delivery) ProtoMessage() {}
 
 func (x *Delivery) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[4]
+	mi := &file_delivery_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -455,7 +608,7 @@ func (x *Delivery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Delivery.ProtoReflect.Descriptor instead.
 func (*Delivery) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{4}
+	return file_delivery_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Delivery) GetDeliveryId() string {
@@ -591,19 +744,27 @@ func (x *Delivery) GetUpdatedAt() int64 {
 	return 0
 }
 
+func (x *Delivery) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type UpdateDeliveryStatusRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
-	Status        DeliveryStatus         `protobuf:"varint,2,opt,name=status,proto3,enum=delivertrack.delivery.DeliveryStatus" json:"status,omitempty"`
-	Notes         string                 `protobuf:"bytes,3,opt,name=notes,proto3" json:"notes,omitempty"`
-	Location      *common.Location       `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId      string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	Status          DeliveryStatus         `protobuf:"varint,2,opt,name=status,proto3,enum=delivertrack.delivery.DeliveryStatus" json:"status,omitempty"`
+	Notes           string                 `protobuf:"bytes,3,opt,name=notes,proto3" json:"notes,omitempty"`
+	Location        *common.Location       `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	ExpectedVersion int32                  `protobuf:"varint,5,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // ABORTED unless the delivery is still at this version; 0 skips the check
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *UpdateDeliveryStatusRequest) Reset() {
 	*x = UpdateDeliveryStatusRequest{}
-	mi := &file_delivery_proto_msgTypes[5]
+	mi := &file_delivery_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -615,7 +776,7 @@ func (x *UpdateDeliveryStatusRequest) String() string {
 func (*UpdateDeliveryStatusRequest) ProtoMessage() {}
 
 func (x *UpdateDeliveryStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[5]
+	mi := &file_delivery_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -628,7 +789,7 @@ func (x *UpdateDeliveryStatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateDeliveryStatusRequest.ProtoReflect.Descriptor instead.
 func (*UpdateDeliveryStatusRequest) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{5}
+	return file_delivery_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *UpdateDeliveryStatusRequest) GetDeliveryId() string {
@@ -659,17 +820,25 @@ func (x *UpdateDeliveryStatusRequest) GetLocation() *common.Location {
 	return nil
 }
 
+func (x *UpdateDeliveryStatusRequest) GetExpectedVersion() int32 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type UpdateDeliveryStatusResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	UpdatedAt     int64                  `protobuf:"varint,2,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Version       int32                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdateDeliveryStatusResponse) Reset() {
 	*x = UpdateDeliveryStatusResponse{}
-	mi := &file_delivery_proto_msgTypes[6]
+	mi := &file_delivery_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -681,7 +850,7 @@ func (x *UpdateDeliveryStatusResponse) String() string {
 func (*UpdateDeliveryStatusResponse) ProtoMessage() {}
 
 func (x *UpdateDeliveryStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[6]
+	mi := &file_delivery_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -694,7 +863,7 @@ func (x *UpdateDeliveryStatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateDeliveryStatusResponse.ProtoReflect.Descriptor instead.
 func (*UpdateDeliveryStatusResponse) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{6}
+	return file_delivery_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *UpdateDeliveryStatusResponse) GetSuccess() bool {
@@ -711,6 +880,13 @@ func (x *UpdateDeliveryStatusResponse) GetUpdatedAt() int64 {
 	return 0
 }
 
+func (x *UpdateDeliveryStatusResponse) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type AssignDriverRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
@@ -721,7 +897,7 @@ type AssignDriverRequest struct {
 
 func (x *AssignDriverRequest) Reset() {
 	*x = AssignDriverRequest{}
-	mi := &file_delivery_proto_msgTypes[7]
+	mi := &file_delivery_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -733,7 +909,7 @@ func (x *AssignDriverRequest) String() string {
 func (*AssignDriverRequest) ProtoMessage() {}
 
 func (x *AssignDriverRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[7]
+	mi := &file_delivery_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -746,7 +922,7 @@ func (x *AssignDriverRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AssignDriverRequest.ProtoReflect.Descriptor instead.
 func (*AssignDriverRequest) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{7}
+	return file_delivery_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *AssignDriverRequest) GetDeliveryId() string {
@@ -773,7 +949,7 @@ type AssignDriverResponse struct {
 
 func (x *AssignDriverResponse) Reset() {
 	*x = AssignDriverResponse{}
-	mi := &file_delivery_proto_msgTypes[8]
+	mi := &file_delivery_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -785,7 +961,7 @@ func (x *AssignDriverResponse) String() string {
 func (*AssignDriverResponse) ProtoMessage() {}
 
 func (x *AssignDriverResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[8]
+	mi := &file_delivery_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -798,7 +974,7 @@ func (x *AssignDriverResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AssignDriverResponse.ProtoReflect.Descriptor instead.
 func (*AssignDriverResponse) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{8}
+	return file_delivery_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *AssignDriverResponse) GetSuccess() bool {
@@ -822,13 +998,16 @@ type ListDeliveriesRequest struct {
 	CustomerId    string                 `protobuf:"bytes,3,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
 	TimeRange     *common.TimeRange      `protobuf:"bytes,4,opt,name=time_range,json=timeRange,proto3" json:"time_range,omitempty"`
 	Pagination    *common.Pagination     `protobuf:"bytes,5,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	Statuses      []DeliveryStatus       `protobuf:"varint,6,rep,packed,name=statuses,proto3,enum=delivertrack.delivery.DeliveryStatus" json:"statuses,omitempty"` // any of these, in addition to status
+	Sort          string                 `protobuf:"bytes,7,opt,name=sort,proto3" json:"sort,omitempty"`                                                           // created_at (default), updated_at or scheduled_date
+	Order         string                 `protobuf:"bytes,8,opt,name=order,proto3" json:"order,omitempty"`                                                         // asc or desc (default)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListDeliveriesRequest) Reset() {
 	*x = ListDeliveriesRequest{}
-	mi := &file_delivery_proto_msgTypes[9]
+	mi := &file_delivery_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -840,7 +1019,7 @@ func (x *ListDeliveriesRequest) String() string {
 func (*ListDeliveriesRequest) ProtoMessage() {}
 
 func (x *ListDeliveriesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[9]
+	mi := &file_delivery_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -853,7 +1032,7 @@ func (x *ListDeliveriesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListDeliveriesRequest.ProtoReflect.Descriptor instead.
 func (*ListDeliveriesRequest) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{9}
+	return file_delivery_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ListDeliveriesRequest) GetStatus() DeliveryStatus {
@@ -891,6 +1070,27 @@ func (x *ListDeliveriesRequest) GetPagination() *common.Pagination {
 	return nil
 }
 
+func (x *ListDeliveriesRequest) GetStatuses() []DeliveryStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *ListDeliveriesRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListDeliveriesRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
 type ListDeliveriesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Deliveries    []*Delivery            `protobuf:"bytes,1,rep,name=deliveries,proto3" json:"deliveries,omitempty"`
@@ -903,7 +1103,7 @@ type ListDeliveriesResponse struct {
 
 func (x *ListDeliveriesResponse) Reset() {
 	*x = ListDeliveriesResponse{}
-	mi := &file_delivery_proto_msgTypes[10]
+	mi := &file_delivery_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -915,7 +1115,7 @@ func (x *ListDeliveriesResponse) String() string {
 func (*ListDeliveriesResponse) ProtoMessage() {}
 
 func (x *ListDeliveriesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[10]
+	mi := &file_delivery_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -928,7 +1128,7 @@ func (x *ListDeliveriesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListDeliveriesResponse.ProtoReflect.Descriptor instead.
 func (*ListDeliveriesResponse) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{10}
+	return file_delivery_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ListDeliveriesResponse) GetDeliveries() []*Delivery {
@@ -969,7 +1169,7 @@ type CancelDeliveryRequest struct {
 
 func (x *CancelDeliveryRequest) Reset() {
 	*x = CancelDeliveryRequest{}
-	mi := &file_delivery_proto_msgTypes[11]
+	mi := &file_delivery_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -981,7 +1181,7 @@ func (x *CancelDeliveryRequest) String() string {
 func (*CancelDeliveryRequest) ProtoMessage() {}
 
 func (x *CancelDeliveryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[11]
+	mi := &file_delivery_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -994,7 +1194,7 @@ func (x *CancelDeliveryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelDeliveryRequest.ProtoReflect.Descriptor instead.
 func (*CancelDeliveryRequest) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{11}
+	return file_delivery_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *CancelDeliveryRequest) GetDeliveryId() string {
@@ -1021,7 +1221,7 @@ type CancelDeliveryResponse struct {
 
 func (x *CancelDeliveryResponse) Reset() {
 	*x = CancelDeliveryResponse{}
-	mi := &file_delivery_proto_msgTypes[12]
+	mi := &file_delivery_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1033,7 +1233,7 @@ func (x *CancelDeliveryResponse) String() string {
 func (*CancelDeliveryResponse) ProtoMessage() {}
 
 func (x *CancelDeliveryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[12]
+	mi := &file_delivery_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1046,7 +1246,7 @@ func (x *CancelDeliveryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelDeliveryResponse.ProtoReflect.Descriptor instead.
 func (*CancelDeliveryResponse) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{12}
+	return file_delivery_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *CancelDeliveryResponse) GetSuccess() bool {
@@ -1074,7 +1274,7 @@ type GetDriverDeliveriesRequest struct {
 
 func (x *GetDriverDeliveriesRequest) Reset() {
 	*x = GetDriverDeliveriesRequest{}
-	mi := &file_delivery_proto_msgTypes[13]
+	mi := &file_delivery_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1086,7 +1286,7 @@ func (x *GetDriverDeliveriesRequest) String() string {
 func (*GetDriverDeliveriesRequest) ProtoMessage() {}
 
 func (x *GetDriverDeliveriesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[13]
+	mi := &file_delivery_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1099,7 +1299,7 @@ func (x *GetDriverDeliveriesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDriverDeliveriesRequest.ProtoReflect.Descriptor instead.
 func (*GetDriverDeliveriesRequest) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{13}
+	return file_delivery_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *GetDriverDeliveriesRequest) GetDriverId() string {
@@ -1133,7 +1333,7 @@ type GetDriverDeliveriesResponse struct {
 
 func (x *GetDriverDeliveriesResponse) Reset() {
 	*x = GetDriverDeliveriesResponse{}
-	mi := &file_delivery_proto_msgTypes[14]
+	mi := &file_delivery_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1145,7 +1345,7 @@ func (x *GetDriverDeliveriesResponse) String() string {
 func (*GetDriverDeliveriesResponse) ProtoMessage() {}
 
 func (x *GetDriverDeliveriesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[14]
+	mi := &file_delivery_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1158,7 +1358,7 @@ func (x *GetDriverDeliveriesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDriverDeliveriesResponse.ProtoReflect.Descriptor instead.
 func (*GetDriverDeliveriesResponse) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{14}
+	return file_delivery_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *GetDriverDeliveriesResponse) GetDeliveries() []*Delivery {
@@ -1186,7 +1386,7 @@ type OptimizeRouteRequest struct {
 
 func (x *OptimizeRouteRequest) Reset() {
 	*x = OptimizeRouteRequest{}
-	mi := &file_delivery_proto_msgTypes[15]
+	mi := &file_delivery_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1198,7 +1398,7 @@ func (x *OptimizeRouteRequest) String() string {
 func (*OptimizeRouteRequest) ProtoMessage() {}
 
 func (x *OptimizeRouteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[15]
+	mi := &file_delivery_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1211,7 +1411,7 @@ func (x *OptimizeRouteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OptimizeRouteRequest.ProtoReflect.Descriptor instead.
 func (*OptimizeRouteRequest) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{15}
+	return file_delivery_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *OptimizeRouteRequest) GetDriverId() string {
@@ -1246,7 +1446,7 @@ type OptimizeRouteResponse struct {
 
 func (x *OptimizeRouteResponse) Reset() {
 	*x = OptimizeRouteResponse{}
-	mi := &file_delivery_proto_msgTypes[16]
+	mi := &file_delivery_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1258,7 +1458,7 @@ func (x *OptimizeRouteResponse) String() string {
 func (*OptimizeRouteResponse) ProtoMessage() {}
 
 func (x *OptimizeRouteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[16]
+	mi := &file_delivery_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1271,7 +1471,7 @@ func (x *OptimizeRouteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OptimizeRouteResponse.ProtoReflect.Descriptor instead.
 func (*OptimizeRouteResponse) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{16}
+	return file_delivery_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *OptimizeRouteResponse) GetRoute() []*RouteStop {
@@ -1308,7 +1508,7 @@ type RouteStop struct {
 
 func (x *RouteStop) Reset() {
 	*x = RouteStop{}
-	mi := &file_delivery_proto_msgTypes[17]
+	mi := &file_delivery_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1320,7 +1520,7 @@ func (x *RouteStop) String() string {
 func (*RouteStop) ProtoMessage() {}
 
 func (x *RouteStop) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[17]
+	mi := &file_delivery_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1333,7 +1533,7 @@ func (x *RouteStop) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RouteStop.ProtoReflect.Descriptor instead.
 func (*RouteStop) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{17}
+	return file_delivery_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *RouteStop) GetDeliveryId() string {
@@ -1386,7 +1586,7 @@ type ConfirmDeliveryRequest struct {
 
 func (x *ConfirmDeliveryRequest) Reset() {
 	*x = ConfirmDeliveryRequest{}
-	mi := &file_delivery_proto_msgTypes[18]
+	mi := &file_delivery_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1398,7 +1598,7 @@ func (x *ConfirmDeliveryRequest) String() string {
 func (*ConfirmDeliveryRequest) ProtoMessage() {}
 
 func (x *ConfirmDeliveryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[18]
+	mi := &file_delivery_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1411,7 +1611,7 @@ func (x *ConfirmDeliveryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConfirmDeliveryRequest.ProtoReflect.Descriptor instead.
 func (*ConfirmDeliveryRequest) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{18}
+	return file_delivery_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *ConfirmDeliveryRequest) GetDeliveryId() string {
@@ -1475,7 +1675,7 @@ type ConfirmDeliveryResponse struct {
 
 func (x *ConfirmDeliveryResponse) Reset() {
 	*x = ConfirmDeliveryResponse{}
-	mi := &file_delivery_proto_msgTypes[19]
+	mi := &file_delivery_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1487,7 +1687,7 @@ func (x *ConfirmDeliveryResponse) String() string {
 func (*ConfirmDeliveryResponse) ProtoMessage() {}
 
 func (x *ConfirmDeliveryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[19]
+	mi := &file_delivery_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1500,7 +1700,7 @@ func (x *ConfirmDeliveryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConfirmDeliveryResponse.ProtoReflect.Descriptor instead.
 func (*ConfirmDeliveryResponse) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{19}
+	return file_delivery_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *ConfirmDeliveryResponse) GetSuccess() bool {
@@ -1547,7 +1747,7 @@ type PackageDetails struct {
 
 func (x *PackageDetails) Reset() {
 	*x = PackageDetails{}
-	mi := &file_delivery_proto_msgTypes[20]
+	mi := &file_delivery_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1559,7 +1759,7 @@ func (x *PackageDetails) String() string {
 func (*PackageDetails) ProtoMessage() {}
 
 func (x *PackageDetails) ProtoReflect() protoreflect.Message {
-	mi := &file_delivery_proto_msgTypes[20]
+	mi := &file_delivery_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1572,7 +1772,7 @@ func (x *PackageDetails) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PackageDetails.ProtoReflect.Descriptor instead.
 func (*PackageDetails) Descriptor() ([]byte, []int) {
-	return file_delivery_proto_rawDescGZIP(), []int{20}
+	return file_delivery_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *PackageDetails) GetWeight() float64 {
@@ -1653,12 +1853,27 @@ const file_delivery_proto_rawDesc = "" +
 	"deliveryId\x12'\n" +
 	"\x0ftracking_number\x18\x02 \x01(\tR\x0etrackingNumber\x12\x1d\n" +
 	"\n" +
-	"created_at\x18\x03 \x01(\x03R\tcreatedAt\"5\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\"\x81\x01\n" +
 	"\x12GetDeliveryRequest\x12\x1f\n" +
 	"\vdelivery_id\x18\x01 \x01(\tR\n" +
-	"deliveryId\"R\n" +
+	"deliveryId\x12)\n" +
+	"\x10include_location\x18\x02 \x01(\bR\x0fincludeLocation\x12\x1f\n" +
+	"\vinclude_eta\x18\x03 \x01(\bR\n" +
+	"includeEta\"\xf5\x01\n" +
 	"\x13GetDeliveryResponse\x12;\n" +
-	"\bdelivery\x18\x01 \x01(\v2\x1f.delivertrack.delivery.DeliveryR\bdelivery\"\xf6\x06\n" +
+	"\bdelivery\x18\x01 \x01(\v2\x1f.delivertrack.delivery.DeliveryR\bdelivery\x12Q\n" +
+	"\x10current_location\x18\x02 \x01(\v2&.delivertrack.delivery.TrackedLocationR\x0fcurrentLocation\x124\n" +
+	"\x03eta\x18\x03 \x01(\v2\".delivertrack.delivery.DeliveryETAR\x03eta\x12\x18\n" +
+	"\apartial\x18\x04 \x01(\bR\apartial\"j\n" +
+	"\x0fTrackedLocation\x129\n" +
+	"\blocation\x18\x01 \x01(\v2\x1d.delivertrack.common.LocationR\blocation\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"|\n" +
+	"\vDeliveryETA\x12\x1f\n" +
+	"\veta_seconds\x18\x01 \x01(\x03R\n" +
+	"etaSeconds\x12\x1f\n" +
+	"\vdistance_km\x18\x02 \x01(\x01R\n" +
+	"distanceKm\x12+\n" +
+	"\x11estimated_arrival\x18\x03 \x01(\x03R\x10estimatedArrival\"\x90\a\n" +
 	"\bDelivery\x12\x1f\n" +
 	"\vdelivery_id\x18\x01 \x01(\tR\n" +
 	"deliveryId\x12\x1d\n" +
@@ -1685,17 +1900,20 @@ const file_delivery_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x12 \x01(\x03R\tcreatedAt\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\x13 \x01(\x03R\tupdatedAt\"\xce\x01\n" +
+	"updated_at\x18\x13 \x01(\x03R\tupdatedAt\x12\x18\n" +
+	"\aversion\x18\x14 \x01(\x05R\aversion\"\xf9\x01\n" +
 	"\x1bUpdateDeliveryStatusRequest\x12\x1f\n" +
 	"\vdelivery_id\x18\x01 \x01(\tR\n" +
 	"deliveryId\x12=\n" +
 	"\x06status\x18\x02 \x01(\x0e2%.delivertrack.delivery.DeliveryStatusR\x06status\x12\x14\n" +
 	"\x05notes\x18\x03 \x01(\tR\x05notes\x129\n" +
-	"\blocation\x18\x04 \x01(\v2\x1d.delivertrack.common.LocationR\blocation\"W\n" +
+	"\blocation\x18\x04 \x01(\v2\x1d.delivertrack.common.LocationR\blocation\x12)\n" +
+	"\x10expected_version\x18\x05 \x01(\x05R\x0fexpectedVersion\"q\n" +
 	"\x1cUpdateDeliveryStatusResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\x02 \x01(\x03R\tupdatedAt\"S\n" +
+	"updated_at\x18\x02 \x01(\x03R\tupdatedAt\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x05R\aversion\"S\n" +
 	"\x13AssignDriverRequest\x12\x1f\n" +
 	"\vdelivery_id\x18\x01 \x01(\tR\n" +
 	"deliveryId\x12\x1b\n" +
@@ -1703,7 +1921,7 @@ const file_delivery_proto_rawDesc = "" +
 	"\x14AssignDriverResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1f\n" +
 	"\vassigned_at\x18\x02 \x01(\x03R\n" +
-	"assignedAt\"\x94\x02\n" +
+	"assignedAt\"\x81\x03\n" +
 	"\x15ListDeliveriesRequest\x12=\n" +
 	"\x06status\x18\x01 \x01(\x0e2%.delivertrack.delivery.DeliveryStatusR\x06status\x12\x1b\n" +
 	"\tdriver_id\x18\x02 \x01(\tR\bdriverId\x12\x1f\n" +
@@ -1713,7 +1931,10 @@ const file_delivery_proto_rawDesc = "" +
 	"time_range\x18\x04 \x01(\v2\x1e.delivertrack.common.TimeRangeR\ttimeRange\x12?\n" +
 	"\n" +
 	"pagination\x18\x05 \x01(\v2\x1f.delivertrack.common.PaginationR\n" +
-	"pagination\"\xab\x01\n" +
+	"pagination\x12A\n" +
+	"\bstatuses\x18\x06 \x03(\x0e2%.delivertrack.delivery.DeliveryStatusR\bstatuses\x12\x12\n" +
+	"\x04sort\x18\a \x01(\tR\x04sort\x12\x14\n" +
+	"\x05order\x18\b \x01(\tR\x05order\"\xab\x01\n" +
 	"\x16ListDeliveriesResponse\x12?\n" +
 	"\n" +
 	"deliveries\x18\x01 \x03(\v2\x1f.delivertrack.delivery.DeliveryR\n" +
@@ -1820,7 +2041,7 @@ func file_delivery_proto_rawDescGZIP() []byte {
 }
 
 var file_delivery_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_delivery_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_delivery_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_delivery_proto_goTypes = []any{
 	(DeliveryStatus)(0),                  // 0: delivertrack.delivery.DeliveryStatus
 	(DeliveryPriority)(0),                // 1: delivertrack.delivery.DeliveryPriority
@@ -1828,73 +2049,79 @@ var file_delivery_proto_goTypes = []any{
 	(*CreateDeliveryResponse)(nil),       // 3: delivertrack.delivery.CreateDeliveryResponse
 	(*GetDeliveryRequest)(nil),           // 4: delivertrack.delivery.GetDeliveryRequest
 	(*GetDeliveryResponse)(nil),          // 5: delivertrack.delivery.GetDeliveryResponse
-	(*Delivery)(nil),                     // 6: delivertrack.delivery.Delivery
-	(*UpdateDeliveryStatusRequest)(nil),  // 7: delivertrack.delivery.UpdateDeliveryStatusRequest
-	(*UpdateDeliveryStatusResponse)(nil), // 8: delivertrack.delivery.UpdateDeliveryStatusResponse
-	(*AssignDriverRequest)(nil),          // 9: delivertrack.delivery.AssignDriverRequest
-	(*AssignDriverResponse)(nil),         // 10: delivertrack.delivery.AssignDriverResponse
-	(*ListDeliveriesRequest)(nil),        // 11: delivertrack.delivery.ListDeliveriesRequest
-	(*ListDeliveriesResponse)(nil),       // 12: delivertrack.delivery.ListDeliveriesResponse
-	(*CancelDeliveryRequest)(nil),        // 13: delivertrack.delivery.CancelDeliveryRequest
-	(*CancelDeliveryResponse)(nil),       // 14: delivertrack.delivery.CancelDeliveryResponse
-	(*GetDriverDeliveriesRequest)(nil),   // 15: delivertrack.delivery.GetDriverDeliveriesRequest
-	(*GetDriverDeliveriesResponse)(nil),  // 16: delivertrack.delivery.GetDriverDeliveriesResponse
-	(*OptimizeRouteRequest)(nil),         // 17: delivertrack.delivery.OptimizeRouteRequest
-	(*OptimizeRouteResponse)(nil),        // 18: delivertrack.delivery.OptimizeRouteResponse
-	(*RouteStop)(nil),                    // 19: delivertrack.delivery.RouteStop
-	(*ConfirmDeliveryRequest)(nil),       // 20: delivertrack.delivery.ConfirmDeliveryRequest
-	(*ConfirmDeliveryResponse)(nil),      // 21: delivertrack.delivery.ConfirmDeliveryResponse
-	(*PackageDetails)(nil),               // 22: delivertrack.delivery.PackageDetails
-	(*common.Location)(nil),              // 23: delivertrack.common.Location
-	(*common.TimeRange)(nil),             // 24: delivertrack.common.TimeRange
-	(*common.Pagination)(nil),            // 25: delivertrack.common.Pagination
+	(*TrackedLocation)(nil),              // 6: delivertrack.delivery.TrackedLocation
+	(*DeliveryETA)(nil),                  // 7: delivertrack.delivery.DeliveryETA
+	(*Delivery)(nil),                     // 8: delivertrack.delivery.Delivery
+	(*UpdateDeliveryStatusRequest)(nil),  // 9: delivertrack.delivery.UpdateDeliveryStatusRequest
+	(*UpdateDeliveryStatusResponse)(nil), // 10: delivertrack.delivery.UpdateDeliveryStatusResponse
+	(*AssignDriverRequest)(nil),          // 11: delivertrack.delivery.AssignDriverRequest
+	(*AssignDriverResponse)(nil),         // 12: delivertrack.delivery.AssignDriverResponse
+	(*ListDeliveriesRequest)(nil),        // 13: delivertrack.delivery.ListDeliveriesRequest
+	(*ListDeliveriesResponse)(nil),       // 14: delivertrack.delivery.ListDeliveriesResponse
+	(*CancelDeliveryRequest)(nil),        // 15: delivertrack.delivery.CancelDeliveryRequest
+	(*CancelDeliveryResponse)(nil),       // 16: delivertrack.delivery.CancelDeliveryResponse
+	(*GetDriverDeliveriesRequest)(nil),   // 17: delivertrack.delivery.GetDriverDeliveriesRequest
+	(*GetDriverDeliveriesResponse)(nil),  // 18: delivertrack.delivery.GetDriverDeliveriesResponse
+	(*OptimizeRouteRequest)(nil),         // 19: delivertrack.delivery.OptimizeRouteRequest
+	(*OptimizeRouteResponse)(nil),        // 20: delivertrack.delivery.OptimizeRouteResponse
+	(*RouteStop)(nil),                    // 21: delivertrack.delivery.RouteStop
+	(*ConfirmDeliveryRequest)(nil),       // 22: delivertrack.delivery.ConfirmDeliveryRequest
+	(*ConfirmDeliveryResponse)(nil),      // 23: delivertrack.delivery.ConfirmDeliveryResponse
+	(*PackageDetails)(nil),               // 24: delivertrack.delivery.PackageDetails
+	(*common.Location)(nil),              // 25: delivertrack.common.Location
+	(*common.TimeRange)(nil),             // 26: delivertrack.common.TimeRange
+	(*common.Pagination)(nil),            // 27: delivertrack.common.Pagination
 }
 var file_delivery_proto_depIdxs = []int32{
-	23, // 0: delivertrack.delivery.CreateDeliveryRequest.pickup_location:type_name -> delivertrack.common.Location
-	23, // 1: delivertrack.delivery.CreateDeliveryRequest.delivery_location:type_name -> delivertrack.common.Location
+	25, // 0: delivertrack.delivery.CreateDeliveryRequest.pickup_location:type_name -> delivertrack.common.Location
+	25, // 1: delivertrack.delivery.CreateDeliveryRequest.delivery_location:type_name -> delivertrack.common.Location
 	1,  // 2: delivertrack.delivery.CreateDeliveryRequest.priority:type_name -> delivertrack.delivery.DeliveryPriority
-	22, // 3: delivertrack.delivery.CreateDeliveryRequest.package_details:type_name -> delivertrack.delivery.PackageDetails
-	6,  // 4: delivertrack.delivery.GetDeliveryResponse.delivery:type_name -> delivertrack.delivery.Delivery
-	23, // 5: delivertrack.delivery.Delivery.pickup_location:type_name -> delivertrack.common.Location
-	23, // 6: delivertrack.delivery.Delivery.delivery_location:type_name -> delivertrack.common.Location
-	0,  // 7: delivertrack.delivery.Delivery.status:type_name -> delivertrack.delivery.DeliveryStatus
-	1,  // 8: delivertrack.delivery.Delivery.priority:type_name -> delivertrack.delivery.DeliveryPriority
-	22, // 9: delivertrack.delivery.Delivery.package_details:type_name -> delivertrack.delivery.PackageDetails
-	0,  // 10: delivertrack.delivery.UpdateDeliveryStatusRequest.status:type_name -> delivertrack.delivery.DeliveryStatus
-	23, // 11: delivertrack.delivery.UpdateDeliveryStatusRequest.location:type_name -> delivertrack.common.Location
-	0,  // 12: delivertrack.delivery.ListDeliveriesRequest.status:type_name -> delivertrack.delivery.DeliveryStatus
-	24, // 13: delivertrack.delivery.ListDeliveriesRequest.time_range:type_name -> delivertrack.common.TimeRange
-	25, // 14: delivertrack.delivery.ListDeliveriesRequest.pagination:type_name -> delivertrack.common.Pagination
-	6,  // 15: delivertrack.delivery.ListDeliveriesResponse.deliveries:type_name -> delivertrack.delivery.Delivery
-	0,  // 16: delivertrack.delivery.GetDriverDeliveriesRequest.status:type_name -> delivertrack.delivery.DeliveryStatus
-	6,  // 17: delivertrack.delivery.GetDriverDeliveriesResponse.deliveries:type_name -> delivertrack.delivery.Delivery
-	23, // 18: delivertrack.delivery.OptimizeRouteRequest.start_location:type_name -> delivertrack.common.Location
-	19, // 19: delivertrack.delivery.OptimizeRouteResponse.route:type_name -> delivertrack.delivery.RouteStop
-	23, // 20: delivertrack.delivery.RouteStop.location:type_name -> delivertrack.common.Location
-	23, // 21: delivertrack.delivery.ConfirmDeliveryRequest.delivery_location:type_name -> delivertrack.common.Location
-	2,  // 22: delivertrack.delivery.DeliveryService.CreateDelivery:input_type -> delivertrack.delivery.CreateDeliveryRequest
-	4,  // 23: delivertrack.delivery.DeliveryService.GetDelivery:input_type -> delivertrack.delivery.GetDeliveryRequest
-	7,  // 24: delivertrack.delivery.DeliveryService.UpdateDeliveryStatus:input_type -> delivertrack.delivery.UpdateDeliveryStatusRequest
-	9,  // 25: delivertrack.delivery.DeliveryService.AssignDriver:input_type -> delivertrack.delivery.AssignDriverRequest
-	11, // 26: delivertrack.delivery.DeliveryService.ListDeliveries:input_type -> delivertrack.delivery.ListDeliveriesRequest
-	13, // 27: delivertrack.delivery.DeliveryService.CancelDelivery:input_type -> delivertrack.delivery.CancelDeliveryRequest
-	15, // 28: delivertrack.delivery.DeliveryService.GetDriverDeliveries:input_type -> delivertrack.delivery.GetDriverDeliveriesRequest
-	17, // 29: delivertrack.delivery.DeliveryService.OptimizeRoute:input_type -> delivertrack.delivery.OptimizeRouteRequest
-	20, // 30: delivertrack.delivery.DeliveryService.ConfirmDelivery:input_type -> delivertrack.delivery.ConfirmDeliveryRequest
-	3,  // 31: delivertrack.delivery.DeliveryService.CreateDelivery:output_type -> delivertrack.delivery.CreateDeliveryResponse
-	5,  // 32: delivertrack.delivery.DeliveryService.GetDelivery:output_type -> delivertrack.delivery.GetDeliveryResponse
-	8,  // 33: delivertrack.delivery.DeliveryService.UpdateDeliveryStatus:output_type -> delivertrack.delivery.UpdateDeliveryStatusResponse
-	10, // 34: delivertrack.delivery.DeliveryService.AssignDriver:output_type -> delivertrack.delivery.AssignDriverResponse
-	12, // 35: delivertrack.delivery.DeliveryService.ListDeliveries:output_type -> delivertrack.delivery.ListDeliveriesResponse
-	14, // 36: delivertrack.delivery.DeliveryService.CancelDelivery:output_type -> delivertrack.delivery.CancelDeliveryResponse
-	16, // 37: delivertrack.delivery.DeliveryService.GetDriverDeliveries:output_type -> delivertrack.delivery.GetDriverDeliveriesResponse
-	18, // 38: delivertrack.delivery.DeliveryService.OptimizeRoute:output_type -> delivertrack.delivery.OptimizeRouteResponse
-	21, // 39: delivertrack.delivery.DeliveryService.ConfirmDelivery:output_type -> delivertrack.delivery.ConfirmDeliveryResponse
-	31, // [31:40] is the sub-list for method output_type
-	22, // [22:31] is the sub-list for method input_type
-	22, // [22:22] is the sub-list for extension type_name
-	22, // [22:22] is the sub-list for extension extendee
-	0,  // [0:22] is the sub-list for field type_name
+	24, // 3: delivertrack.delivery.CreateDeliveryRequest.package_details:type_name -> delivertrack.delivery.PackageDetails
+	8,  // 4: delivertrack.delivery.GetDeliveryResponse.delivery:type_name -> delivertrack.delivery.Delivery
+	6,  // 5: delivertrack.delivery.GetDeliveryResponse.current_location:type_name -> delivertrack.delivery.TrackedLocation
+	7,  // 6: delivertrack.delivery.GetDeliveryResponse.eta:type_name -> delivertrack.delivery.DeliveryETA
+	25, // 7: delivertrack.delivery.TrackedLocation.location:type_name -> delivertrack.common.Location
+	25, // 8: delivertrack.delivery.Delivery.pickup_location:type_name -> delivertrack.common.Location
+	25, // 9: delivertrack.delivery.Delivery.delivery_location:type_name -> delivertrack.common.Location
+	0,  // 10: delivertrack.delivery.Delivery.status:type_name -> delivertrack.delivery.DeliveryStatus
+	1,  // 11: delivertrack.delivery.Delivery.priority:type_name -> delivertrack.delivery.DeliveryPriority
+	24, // 12: delivertrack.delivery.Delivery.package_details:type_name -> delivertrack.delivery.PackageDetails
+	0,  // 13: delivertrack.delivery.UpdateDeliveryStatusRequest.status:type_name -> delivertrack.delivery.DeliveryStatus
+	25, // 14: delivertrack.delivery.UpdateDeliveryStatusRequest.location:type_name -> delivertrack.common.Location
+	0,  // 15: delivertrack.delivery.ListDeliveriesRequest.status:type_name -> delivertrack.delivery.DeliveryStatus
+	26, // 16: delivertrack.delivery.ListDeliveriesRequest.time_range:type_name -> delivertrack.common.TimeRange
+	27, // 17: delivertrack.delivery.ListDeliveriesRequest.pagination:type_name -> delivertrack.common.Pagination
+	0,  // 18: delivertrack.delivery.ListDeliveriesRequest.statuses:type_name -> delivertrack.delivery.DeliveryStatus
+	8,  // 19: delivertrack.delivery.ListDeliveriesResponse.deliveries:type_name -> delivertrack.delivery.Delivery
+	0,  // 20: delivertrack.delivery.GetDriverDeliveriesRequest.status:type_name -> delivertrack.delivery.DeliveryStatus
+	8,  // 21: delivertrack.delivery.GetDriverDeliveriesResponse.deliveries:type_name -> delivertrack.delivery.Delivery
+	25, // 22: delivertrack.delivery.OptimizeRouteRequest.start_location:type_name -> delivertrack.common.Location
+	21, // 23: delivertrack.delivery.OptimizeRouteResponse.route:type_name -> delivertrack.delivery.RouteStop
+	25, // 24: delivertrack.delivery.RouteStop.location:type_name -> delivertrack.common.Location
+	25, // 25: delivertrack.delivery.ConfirmDeliveryRequest.delivery_location:type_name -> delivertrack.common.Location
+	2,  // 26: delivertrack.delivery.DeliveryService.CreateDelivery:input_type -> delivertrack.delivery.CreateDeliveryRequest
+	4,  // 27: delivertrack.delivery.DeliveryService.GetDelivery:input_type -> delivertrack.delivery.GetDeliveryRequest
+	9,  // 28: delivertrack.delivery.DeliveryService.UpdateDeliveryStatus:input_type -> delivertrack.delivery.UpdateDeliveryStatusRequest
+	11, // 29: delivertrack.delivery.DeliveryService.AssignDriver:input_type -> delivertrack.delivery.AssignDriverRequest
+	13, // 30: delivertrack.delivery.DeliveryService.ListDeliveries:input_type -> delivertrack.delivery.ListDeliveriesRequest
+	15, // 31: delivertrack.delivery.DeliveryService.CancelDelivery:input_type -> delivertrack.delivery.CancelDeliveryRequest
+	17, // 32: delivertrack.delivery.DeliveryService.GetDriverDeliveries:input_type -> delivertrack.delivery.GetDriverDeliveriesRequest
+	19, // 33: delivertrack.delivery.DeliveryService.OptimizeRoute:input_type -> delivertrack.delivery.OptimizeRouteRequest
+	22, // 34: delivertrack.delivery.DeliveryService.ConfirmDelivery:input_type -> delivertrack.delivery.ConfirmDeliveryRequest
+	3,  // 35: delivertrack.delivery.DeliveryService.CreateDelivery:output_type -> delivertrack.delivery.CreateDeliveryResponse
+	5,  // 36: delivertrack.delivery.DeliveryService.GetDelivery:output_type -> delivertrack.delivery.GetDeliveryResponse
+	10, // 37: delivertrack.delivery.DeliveryService.UpdateDeliveryStatus:output_type -> delivertrack.delivery.UpdateDeliveryStatusResponse
+	12, // 38: delivertrack.delivery.DeliveryService.AssignDriver:output_type -> delivertrack.delivery.AssignDriverResponse
+	14, // 39: delivertrack.delivery.DeliveryService.ListDeliveries:output_type -> delivertrack.delivery.ListDeliveriesResponse
+	16, // 40: delivertrack.delivery.DeliveryService.CancelDelivery:output_type -> delivertrack.delivery.CancelDeliveryResponse
+	18, // 41: delivertrack.delivery.DeliveryService.GetDriverDeliveries:output_type -> delivertrack.delivery.GetDriverDeliveriesResponse
+	20, // 42: delivertrack.delivery.DeliveryService.OptimizeRoute:output_type -> delivertrack.delivery.OptimizeRouteResponse
+	23, // 43: delivertrack.delivery.DeliveryService.ConfirmDelivery:output_type -> delivertrack.delivery.ConfirmDeliveryResponse
+	35, // [35:44] is the sub-list for method output_type
+	26, // [26:35] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_delivery_proto_init() }
@@ -1908,7 +2135,7 @@ func file_delivery_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_delivery_proto_rawDesc), len(file_delivery_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   21,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
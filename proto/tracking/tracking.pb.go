@@ -914,6 +914,602 @@ func (x *StreamLocationRequest) GetTrackingNumber() string {
 	return ""
 }
 
+type TrackDeliveryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackDeliveryRequest) Reset() {
+	*x = TrackDeliveryRequest{}
+	mi := &file_tracking_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackDeliveryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackDeliveryRequest) ProtoMessage() {}
+
+func (x *TrackDeliveryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackDeliveryRequest.ProtoReflect.Descriptor instead.
+func (*TrackDeliveryRequest) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TrackDeliveryRequest) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+type GetCourierStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourierId     string                 `protobuf:"bytes,1,opt,name=courier_id,json=courierId,proto3" json:"courier_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCourierStatusRequest) Reset() {
+	*x = GetCourierStatusRequest{}
+	mi := &file_tracking_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCourierStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCourierStatusRequest) ProtoMessage() {}
+
+func (x *GetCourierStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCourierStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetCourierStatusRequest) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetCourierStatusRequest) GetCourierId() string {
+	if x != nil {
+		return x.CourierId
+	}
+	return ""
+}
+
+type GetCourierStatusResponse struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	CourierId             string                 `protobuf:"bytes,1,opt,name=courier_id,json=courierId,proto3" json:"courier_id,omitempty"`
+	Status                string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                               // active, stale or offline
+	LastSeenAt            int64                  `protobuf:"varint,3,opt,name=last_seen_at,json=lastSeenAt,proto3" json:"last_seen_at,omitempty"`  // unix seconds, 0 if the courier never reported
+	StatusSince           int64                  `protobuf:"varint,4,opt,name=status_since,json=statusSince,proto3" json:"status_since,omitempty"` // unix seconds, 0 if unknown
+	StatusDurationSeconds int64                  `protobuf:"varint,5,opt,name=status_duration_seconds,json=statusDurationSeconds,proto3" json:"status_duration_seconds,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *GetCourierStatusResponse) Reset() {
+	*x = GetCourierStatusResponse{}
+	mi := &file_tracking_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCourierStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCourierStatusResponse) ProtoMessage() {}
+
+func (x *GetCourierStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCourierStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetCourierStatusResponse) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetCourierStatusResponse) GetCourierId() string {
+	if x != nil {
+		return x.CourierId
+	}
+	return ""
+}
+
+func (x *GetCourierStatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetCourierStatusResponse) GetLastSeenAt() int64 {
+	if x != nil {
+		return x.LastSeenAt
+	}
+	return 0
+}
+
+func (x *GetCourierStatusResponse) GetStatusSince() int64 {
+	if x != nil {
+		return x.StatusSince
+	}
+	return 0
+}
+
+func (x *GetCourierStatusResponse) GetStatusDurationSeconds() int64 {
+	if x != nil {
+		return x.StatusDurationSeconds
+	}
+	return 0
+}
+
+type GetCourierLocationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourierId     string                 `protobuf:"bytes,1,opt,name=courier_id,json=courierId,proto3" json:"courier_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCourierLocationRequest) Reset() {
+	*x = GetCourierLocationRequest{}
+	mi := &file_tracking_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCourierLocationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCourierLocationRequest) ProtoMessage() {}
+
+func (x *GetCourierLocationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCourierLocationRequest.ProtoReflect.Descriptor instead.
+func (*GetCourierLocationRequest) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetCourierLocationRequest) GetCourierId() string {
+	if x != nil {
+		return x.CourierId
+	}
+	return ""
+}
+
+type GetCourierLocationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourierId     string                 `protobuf:"bytes,1,opt,name=courier_id,json=courierId,proto3" json:"courier_id,omitempty"`
+	Location      *common.Location       `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                            // unix seconds of the latest point
+	AverageSpeed  float64                `protobuf:"fixed64,4,opt,name=average_speed,json=averageSpeed,proto3" json:"average_speed,omitempty"` // km/h over recent points, 0 if unknown
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCourierLocationResponse) Reset() {
+	*x = GetCourierLocationResponse{}
+	mi := &file_tracking_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCourierLocationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCourierLocationResponse) ProtoMessage() {}
+
+func (x *GetCourierLocationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCourierLocationResponse.ProtoReflect.Descriptor instead.
+func (*GetCourierLocationResponse) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetCourierLocationResponse) GetCourierId() string {
+	if x != nil {
+		return x.CourierId
+	}
+	return ""
+}
+
+func (x *GetCourierLocationResponse) GetLocation() *common.Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *GetCourierLocationResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *GetCourierLocationResponse) GetAverageSpeed() float64 {
+	if x != nil {
+		return x.AverageSpeed
+	}
+	return 0
+}
+
+type GetDeliveryLocationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeliveryLocationRequest) Reset() {
+	*x = GetDeliveryLocationRequest{}
+	mi := &file_tracking_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeliveryLocationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeliveryLocationRequest) ProtoMessage() {}
+
+func (x *GetDeliveryLocationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeliveryLocationRequest.ProtoReflect.Descriptor instead.
+func (*GetDeliveryLocationRequest) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetDeliveryLocationRequest) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+type GetDeliveryLocationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	CourierId     string                 `protobuf:"bytes,2,opt,name=courier_id,json=courierId,proto3" json:"courier_id,omitempty"`
+	Location      *common.Location       `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix seconds of the latest point
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeliveryLocationResponse) Reset() {
+	*x = GetDeliveryLocationResponse{}
+	mi := &file_tracking_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeliveryLocationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeliveryLocationResponse) ProtoMessage() {}
+
+func (x *GetDeliveryLocationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeliveryLocationResponse.ProtoReflect.Descriptor instead.
+func (*GetDeliveryLocationResponse) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetDeliveryLocationResponse) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+func (x *GetDeliveryLocationResponse) GetCourierId() string {
+	if x != nil {
+		return x.CourierId
+	}
+	return ""
+}
+
+func (x *GetDeliveryLocationResponse) GetLocation() *common.Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *GetDeliveryLocationResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type GetDeliveryETARequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	Destination   *common.Location       `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeliveryETARequest) Reset() {
+	*x = GetDeliveryETARequest{}
+	mi := &file_tracking_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeliveryETARequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeliveryETARequest) ProtoMessage() {}
+
+func (x *GetDeliveryETARequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeliveryETARequest.ProtoReflect.Descriptor instead.
+func (*GetDeliveryETARequest) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetDeliveryETARequest) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+func (x *GetDeliveryETARequest) GetDestination() *common.Location {
+	if x != nil {
+		return x.Destination
+	}
+	return nil
+}
+
+type GetDeliveryETAResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	EtaSeconds    int64                  `protobuf:"varint,2,opt,name=eta_seconds,json=etaSeconds,proto3" json:"eta_seconds,omitempty"`
+	DistanceKm    float64                `protobuf:"fixed64,3,opt,name=distance_km,json=distanceKm,proto3" json:"distance_km,omitempty"`       // remaining straight-line distance
+	AverageSpeed  float64                `protobuf:"fixed64,4,opt,name=average_speed,json=averageSpeed,proto3" json:"average_speed,omitempty"` // km/h the estimate assumes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeliveryETAResponse) Reset() {
+	*x = GetDeliveryETAResponse{}
+	mi := &file_tracking_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeliveryETAResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeliveryETAResponse) ProtoMessage() {}
+
+func (x *GetDeliveryETAResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeliveryETAResponse.ProtoReflect.Descriptor instead.
+func (*GetDeliveryETAResponse) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetDeliveryETAResponse) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+func (x *GetDeliveryETAResponse) GetEtaSeconds() int64 {
+	if x != nil {
+		return x.EtaSeconds
+	}
+	return 0
+}
+
+func (x *GetDeliveryETAResponse) GetDistanceKm() float64 {
+	if x != nil {
+		return x.DistanceKm
+	}
+	return 0
+}
+
+func (x *GetDeliveryETAResponse) GetAverageSpeed() float64 {
+	if x != nil {
+		return x.AverageSpeed
+	}
+	return 0
+}
+
+type FindZonesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Location      *common.Location       `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindZonesRequest) Reset() {
+	*x = FindZonesRequest{}
+	mi := &file_tracking_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindZonesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindZonesRequest) ProtoMessage() {}
+
+func (x *FindZonesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindZonesRequest.ProtoReflect.Descriptor instead.
+func (*FindZonesRequest) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *FindZonesRequest) GetLocation() *common.Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+type FindZonesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Zones         []string               `protobuf:"bytes,1,rep,name=zones,proto3" json:"zones,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindZonesResponse) Reset() {
+	*x = FindZonesResponse{}
+	mi := &file_tracking_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindZonesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindZonesResponse) ProtoMessage() {}
+
+func (x *FindZonesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracking_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindZonesResponse.ProtoReflect.Descriptor instead.
+func (*FindZonesResponse) Descriptor() ([]byte, []int) {
+	return file_tracking_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *FindZonesResponse) GetZones() []string {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
 type LocationUpdate struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	TrackingNumber string                 `protobuf:"bytes,1,opt,name=tracking_number,json=trackingNumber,proto3" json:"tracking_number,omitempty"`
@@ -927,7 +1523,7 @@ type LocationUpdate struct {
 
 func (x *LocationUpdate) Reset() {
 	*x = LocationUpdate{}
-	mi := &file_tracking_proto_msgTypes[13]
+	mi := &file_tracking_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -939,7 +1535,7 @@ func (x *LocationUpdate) String() string {
 func (*LocationUpdate) ProtoMessage() {}
 
 func (x *LocationUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_tracking_proto_msgTypes[13]
+	mi := &file_tracking_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -952,7 +1548,7 @@ func (x *LocationUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LocationUpdate.ProtoReflect.Descriptor instead.
 func (*LocationUpdate) Descriptor() ([]byte, []int) {
-	return file_tracking_proto_rawDescGZIP(), []int{13}
+	return file_tracking_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *LocationUpdate) GetTrackingNumber() string {
@@ -999,7 +1595,7 @@ type BatchUpdateLocationsRequest struct {
 
 func (x *BatchUpdateLocationsRequest) Reset() {
 	*x = BatchUpdateLocationsRequest{}
-	mi := &file_tracking_proto_msgTypes[14]
+	mi := &file_tracking_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1011,7 +1607,7 @@ func (x *BatchUpdateLocationsRequest) String() string {
 func (*BatchUpdateLocationsRequest) ProtoMessage() {}
 
 func (x *BatchUpdateLocationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_tracking_proto_msgTypes[14]
+	mi := &file_tracking_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1024,7 +1620,7 @@ func (x *BatchUpdateLocationsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchUpdateLocationsRequest.ProtoReflect.Descriptor instead.
 func (*BatchUpdateLocationsRequest) Descriptor() ([]byte, []int) {
-	return file_tracking_proto_rawDescGZIP(), []int{14}
+	return file_tracking_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *BatchUpdateLocationsRequest) GetUpdates() []*UpdateLocationRequest {
@@ -1045,7 +1641,7 @@ type BatchUpdateLocationsResponse struct {
 
 func (x *BatchUpdateLocationsResponse) Reset() {
 	*x = BatchUpdateLocationsResponse{}
-	mi := &file_tracking_proto_msgTypes[15]
+	mi := &file_tracking_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1057,7 +1653,7 @@ func (x *BatchUpdateLocationsResponse) String() string {
 func (*BatchUpdateLocationsResponse) ProtoMessage() {}
 
 func (x *BatchUpdateLocationsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_tracking_proto_msgTypes[15]
+	mi := &file_tracking_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1070,7 +1666,7 @@ func (x *BatchUpdateLocationsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchUpdateLocationsResponse.ProtoReflect.Descriptor instead.
 func (*BatchUpdateLocationsResponse) Descriptor() ([]byte, []int) {
-	return file_tracking_proto_rawDescGZIP(), []int{15}
+	return file_tracking_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *BatchUpdateLocationsResponse) GetSuccessCount() int32 {
@@ -1175,7 +1771,56 @@ const file_tracking_proto_rawDesc = "" +
 	"\x1aGetTrackingHistoryResponse\x12<\n" +
 	"\x06events\x18\x01 \x03(\v2$.delivertrack.tracking.TrackingEventR\x06events\"@\n" +
 	"\x15StreamLocationRequest\x12'\n" +
-	"\x0ftracking_number\x18\x01 \x01(\tR\x0etrackingNumber\"\xc2\x01\n" +
+	"\x0ftracking_number\x18\x01 \x01(\tR\x0etrackingNumber\"7\n" +
+	"\x14TrackDeliveryRequest\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\"8\n" +
+	"\x17GetCourierStatusRequest\x12\x1d\n" +
+	"\n" +
+	"courier_id\x18\x01 \x01(\tR\tcourierId\"\xce\x01\n" +
+	"\x18GetCourierStatusResponse\x12\x1d\n" +
+	"\n" +
+	"courier_id\x18\x01 \x01(\tR\tcourierId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12 \n" +
+	"\flast_seen_at\x18\x03 \x01(\x03R\n" +
+	"lastSeenAt\x12!\n" +
+	"\fstatus_since\x18\x04 \x01(\x03R\vstatusSince\x126\n" +
+	"\x17status_duration_seconds\x18\x05 \x01(\x03R\x15statusDurationSeconds\":\n" +
+	"\x19GetCourierLocationRequest\x12\x1d\n" +
+	"\n" +
+	"courier_id\x18\x01 \x01(\tR\tcourierId\"\xb9\x01\n" +
+	"\x1aGetCourierLocationResponse\x12\x1d\n" +
+	"\n" +
+	"courier_id\x18\x01 \x01(\tR\tcourierId\x129\n" +
+	"\blocation\x18\x02 \x01(\v2\x1d.delivertrack.common.LocationR\blocation\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12#\n" +
+	"\raverage_speed\x18\x04 \x01(\x01R\faverageSpeed\"=\n" +
+	"\x1aGetDeliveryLocationRequest\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\"\xb6\x01\n" +
+	"\x1bGetDeliveryLocationResponse\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\x12\x1d\n" +
+	"\n" +
+	"courier_id\x18\x02 \x01(\tR\tcourierId\x129\n" +
+	"\blocation\x18\x03 \x01(\v2\x1d.delivertrack.common.LocationR\blocation\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"y\n" +
+	"\x15GetDeliveryETARequest\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\x12?\n" +
+	"\vdestination\x18\x02 \x01(\v2\x1d.delivertrack.common.LocationR\vdestination\"\xa0\x01\n" +
+	"\x16GetDeliveryETAResponse\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\x12\x1f\n" +
+	"\veta_seconds\x18\x02 \x01(\x03R\n" +
+	"etaSeconds\x12\x1f\n" +
+	"\vdistance_km\x18\x03 \x01(\x01R\n" +
+	"distanceKm\x12#\n" +
+	"\raverage_speed\x18\x04 \x01(\x01R\faverageSpeed\"M\n" +
+	"\x10FindZonesRequest\x129\n" +
+	"\blocation\x18\x01 \x01(\v2\x1d.delivertrack.common.LocationR\blocation\")\n" +
+	"\x11FindZonesResponse\x12\x14\n" +
+	"\x05zones\x18\x01 \x03(\tR\x05zones\"\xc2\x01\n" +
 	"\x0eLocationUpdate\x12'\n" +
 	"\x0ftracking_number\x18\x01 \x01(\tR\x0etrackingNumber\x129\n" +
 	"\blocation\x18\x02 \x01(\v2\x1d.delivertrack.common.LocationR\blocation\x12\x1c\n" +
@@ -1196,14 +1841,20 @@ const file_tracking_proto_rawDesc = "" +
 	" TRACKING_STATUS_OUT_FOR_DELIVERY\x10\x04\x12\x1d\n" +
 	"\x19TRACKING_STATUS_DELIVERED\x10\x05\x12\x1a\n" +
 	"\x16TRACKING_STATUS_FAILED\x10\x06\x12\x1c\n" +
-	"\x18TRACKING_STATUS_RETURNED\x10\a2\xaf\x06\n" +
+	"\x18TRACKING_STATUS_RETURNED\x10\a2\xd3\v\n" +
 	"\x0fTrackingService\x12m\n" +
 	"\x0eCreateTracking\x12,.delivertrack.tracking.CreateTrackingRequest\x1a-.delivertrack.tracking.CreateTrackingResponse\x12d\n" +
 	"\vGetTracking\x12).delivertrack.tracking.GetTrackingRequest\x1a*.delivertrack.tracking.GetTrackingResponse\x12m\n" +
 	"\x0eUpdateLocation\x12,.delivertrack.tracking.UpdateLocationRequest\x1a-.delivertrack.tracking.UpdateLocationResponse\x12s\n" +
 	"\x10AddTrackingEvent\x12..delivertrack.tracking.AddTrackingEventRequest\x1a/.delivertrack.tracking.AddTrackingEventResponse\x12y\n" +
 	"\x12GetTrackingHistory\x120.delivertrack.tracking.GetTrackingHistoryRequest\x1a1.delivertrack.tracking.GetTrackingHistoryResponse\x12g\n" +
-	"\x0eStreamLocation\x12,.delivertrack.tracking.StreamLocationRequest\x1a%.delivertrack.tracking.LocationUpdate0\x01\x12\x7f\n" +
+	"\x0eStreamLocation\x12,.delivertrack.tracking.StreamLocationRequest\x1a%.delivertrack.tracking.LocationUpdate0\x01\x12e\n" +
+	"\rTrackDelivery\x12+.delivertrack.tracking.TrackDeliveryRequest\x1a%.delivertrack.tracking.LocationUpdate0\x01\x12s\n" +
+	"\x10GetCourierStatus\x12..delivertrack.tracking.GetCourierStatusRequest\x1a/.delivertrack.tracking.GetCourierStatusResponse\x12y\n" +
+	"\x12GetCourierLocation\x120.delivertrack.tracking.GetCourierLocationRequest\x1a1.delivertrack.tracking.GetCourierLocationResponse\x12|\n" +
+	"\x13GetDeliveryLocation\x121.delivertrack.tracking.GetDeliveryLocationRequest\x1a2.delivertrack.tracking.GetDeliveryLocationResponse\x12m\n" +
+	"\x0eGetDeliveryETA\x12,.delivertrack.tracking.GetDeliveryETARequest\x1a-.delivertrack.tracking.GetDeliveryETAResponse\x12^\n" +
+	"\tFindZones\x12'.delivertrack.tracking.FindZonesRequest\x1a(.delivertrack.tracking.FindZonesResponse\x12\x7f\n" +
 	"\x14BatchUpdateLocations\x122.delivertrack.tracking.BatchUpdateLocationsRequest\x1a3.delivertrack.tracking.BatchUpdateLocationsResponseB5Z3github.com/Keneke-Einar/delivertrack/proto/trackingb\x06proto3"
 
 var (
@@ -1219,7 +1870,7 @@ func file_tracking_proto_rawDescGZIP() []byte {
 }
 
 var file_tracking_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_tracking_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_tracking_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_tracking_proto_goTypes = []any{
 	(TrackingStatus)(0),                  // 0: delivertrack.tracking.TrackingStatus
 	(*CreateTrackingRequest)(nil),        // 1: delivertrack.tracking.CreateTrackingRequest
@@ -1235,51 +1886,78 @@ var file_tracking_proto_goTypes = []any{
 	(*GetTrackingHistoryRequest)(nil),    // 11: delivertrack.tracking.GetTrackingHistoryRequest
 	(*GetTrackingHistoryResponse)(nil),   // 12: delivertrack.tracking.GetTrackingHistoryResponse
 	(*StreamLocationRequest)(nil),        // 13: delivertrack.tracking.StreamLocationRequest
-	(*LocationUpdate)(nil),               // 14: delivertrack.tracking.LocationUpdate
-	(*BatchUpdateLocationsRequest)(nil),  // 15: delivertrack.tracking.BatchUpdateLocationsRequest
-	(*BatchUpdateLocationsResponse)(nil), // 16: delivertrack.tracking.BatchUpdateLocationsResponse
-	nil,                                  // 17: delivertrack.tracking.AddTrackingEventRequest.MetadataEntry
-	nil,                                  // 18: delivertrack.tracking.TrackingEvent.MetadataEntry
-	(*common.Location)(nil),              // 19: delivertrack.common.Location
-	(*common.TimeRange)(nil),             // 20: delivertrack.common.TimeRange
+	(*TrackDeliveryRequest)(nil),         // 14: delivertrack.tracking.TrackDeliveryRequest
+	(*GetCourierStatusRequest)(nil),      // 15: delivertrack.tracking.GetCourierStatusRequest
+	(*GetCourierStatusResponse)(nil),     // 16: delivertrack.tracking.GetCourierStatusResponse
+	(*GetCourierLocationRequest)(nil),    // 17: delivertrack.tracking.GetCourierLocationRequest
+	(*GetCourierLocationResponse)(nil),   // 18: delivertrack.tracking.GetCourierLocationResponse
+	(*GetDeliveryLocationRequest)(nil),   // 19: delivertrack.tracking.GetDeliveryLocationRequest
+	(*GetDeliveryLocationResponse)(nil),  // 20: delivertrack.tracking.GetDeliveryLocationResponse
+	(*GetDeliveryETARequest)(nil),        // 21: delivertrack.tracking.GetDeliveryETARequest
+	(*GetDeliveryETAResponse)(nil),       // 22: delivertrack.tracking.GetDeliveryETAResponse
+	(*FindZonesRequest)(nil),             // 23: delivertrack.tracking.FindZonesRequest
+	(*FindZonesResponse)(nil),            // 24: delivertrack.tracking.FindZonesResponse
+	(*LocationUpdate)(nil),               // 25: delivertrack.tracking.LocationUpdate
+	(*BatchUpdateLocationsRequest)(nil),  // 26: delivertrack.tracking.BatchUpdateLocationsRequest
+	(*BatchUpdateLocationsResponse)(nil), // 27: delivertrack.tracking.BatchUpdateLocationsResponse
+	nil,                                  // 28: delivertrack.tracking.AddTrackingEventRequest.MetadataEntry
+	nil,                                  // 29: delivertrack.tracking.TrackingEvent.MetadataEntry
+	(*common.Location)(nil),              // 30: delivertrack.common.Location
+	(*common.TimeRange)(nil),             // 31: delivertrack.common.TimeRange
 }
 var file_tracking_proto_depIdxs = []int32{
-	19, // 0: delivertrack.tracking.CreateTrackingRequest.origin:type_name -> delivertrack.common.Location
-	19, // 1: delivertrack.tracking.CreateTrackingRequest.destination:type_name -> delivertrack.common.Location
+	30, // 0: delivertrack.tracking.CreateTrackingRequest.origin:type_name -> delivertrack.common.Location
+	30, // 1: delivertrack.tracking.CreateTrackingRequest.destination:type_name -> delivertrack.common.Location
 	5,  // 2: delivertrack.tracking.GetTrackingResponse.tracking:type_name -> delivertrack.tracking.TrackingInfo
-	19, // 3: delivertrack.tracking.TrackingInfo.current_location:type_name -> delivertrack.common.Location
-	19, // 4: delivertrack.tracking.TrackingInfo.origin:type_name -> delivertrack.common.Location
-	19, // 5: delivertrack.tracking.TrackingInfo.destination:type_name -> delivertrack.common.Location
+	30, // 3: delivertrack.tracking.TrackingInfo.current_location:type_name -> delivertrack.common.Location
+	30, // 4: delivertrack.tracking.TrackingInfo.origin:type_name -> delivertrack.common.Location
+	30, // 5: delivertrack.tracking.TrackingInfo.destination:type_name -> delivertrack.common.Location
 	0,  // 6: delivertrack.tracking.TrackingInfo.status:type_name -> delivertrack.tracking.TrackingStatus
 	10, // 7: delivertrack.tracking.TrackingInfo.events:type_name -> delivertrack.tracking.TrackingEvent
-	19, // 8: delivertrack.tracking.UpdateLocationRequest.location:type_name -> delivertrack.common.Location
-	19, // 9: delivertrack.tracking.AddTrackingEventRequest.location:type_name -> delivertrack.common.Location
-	17, // 10: delivertrack.tracking.AddTrackingEventRequest.metadata:type_name -> delivertrack.tracking.AddTrackingEventRequest.MetadataEntry
-	19, // 11: delivertrack.tracking.TrackingEvent.location:type_name -> delivertrack.common.Location
-	18, // 12: delivertrack.tracking.TrackingEvent.metadata:type_name -> delivertrack.tracking.TrackingEvent.MetadataEntry
-	20, // 13: delivertrack.tracking.GetTrackingHistoryRequest.time_range:type_name -> delivertrack.common.TimeRange
+	30, // 8: delivertrack.tracking.UpdateLocationRequest.location:type_name -> delivertrack.common.Location
+	30, // 9: delivertrack.tracking.AddTrackingEventRequest.location:type_name -> delivertrack.common.Location
+	28, // 10: delivertrack.tracking.AddTrackingEventRequest.metadata:type_name -> delivertrack.tracking.AddTrackingEventRequest.MetadataEntry
+	30, // 11: delivertrack.tracking.TrackingEvent.location:type_name -> delivertrack.common.Location
+	29, // 12: delivertrack.tracking.TrackingEvent.metadata:type_name -> delivertrack.tracking.TrackingEvent.MetadataEntry
+	31, // 13: delivertrack.tracking.GetTrackingHistoryRequest.time_range:type_name -> delivertrack.common.TimeRange
 	10, // 14: delivertrack.tracking.GetTrackingHistoryResponse.events:type_name -> delivertrack.tracking.TrackingEvent
-	19, // 15: delivertrack.tracking.LocationUpdate.location:type_name -> delivertrack.common.Location
-	6,  // 16: delivertrack.tracking.BatchUpdateLocationsRequest.updates:type_name -> delivertrack.tracking.UpdateLocationRequest
-	1,  // 17: delivertrack.tracking.TrackingService.CreateTracking:input_type -> delivertrack.tracking.CreateTrackingRequest
-	3,  // 18: delivertrack.tracking.TrackingService.GetTracking:input_type -> delivertrack.tracking.GetTrackingRequest
-	6,  // 19: delivertrack.tracking.TrackingService.UpdateLocation:input_type -> delivertrack.tracking.UpdateLocationRequest
-	8,  // 20: delivertrack.tracking.TrackingService.AddTrackingEvent:input_type -> delivertrack.tracking.AddTrackingEventRequest
-	11, // 21: delivertrack.tracking.TrackingService.GetTrackingHistory:input_type -> delivertrack.tracking.GetTrackingHistoryRequest
-	13, // 22: delivertrack.tracking.TrackingService.StreamLocation:input_type -> delivertrack.tracking.StreamLocationRequest
-	15, // 23: delivertrack.tracking.TrackingService.BatchUpdateLocations:input_type -> delivertrack.tracking.BatchUpdateLocationsRequest
-	2,  // 24: delivertrack.tracking.TrackingService.CreateTracking:output_type -> delivertrack.tracking.CreateTrackingResponse
-	4,  // 25: delivertrack.tracking.TrackingService.GetTracking:output_type -> delivertrack.tracking.GetTrackingResponse
-	7,  // 26: delivertrack.tracking.TrackingService.UpdateLocation:output_type -> delivertrack.tracking.UpdateLocationResponse
-	9,  // 27: delivertrack.tracking.TrackingService.AddTrackingEvent:output_type -> delivertrack.tracking.AddTrackingEventResponse
-	12, // 28: delivertrack.tracking.TrackingService.GetTrackingHistory:output_type -> delivertrack.tracking.GetTrackingHistoryResponse
-	14, // 29: delivertrack.tracking.TrackingService.StreamLocation:output_type -> delivertrack.tracking.LocationUpdate
-	16, // 30: delivertrack.tracking.TrackingService.BatchUpdateLocations:output_type -> delivertrack.tracking.BatchUpdateLocationsResponse
-	24, // [24:31] is the sub-list for method output_type
-	17, // [17:24] is the sub-list for method input_type
-	17, // [17:17] is the sub-list for extension type_name
-	17, // [17:17] is the sub-list for extension extendee
-	0,  // [0:17] is the sub-list for field type_name
+	30, // 15: delivertrack.tracking.GetCourierLocationResponse.location:type_name -> delivertrack.common.Location
+	30, // 16: delivertrack.tracking.GetDeliveryLocationResponse.location:type_name -> delivertrack.common.Location
+	30, // 17: delivertrack.tracking.GetDeliveryETARequest.destination:type_name -> delivertrack.common.Location
+	30, // 18: delivertrack.tracking.FindZonesRequest.location:type_name -> delivertrack.common.Location
+	30, // 19: delivertrack.tracking.LocationUpdate.location:type_name -> delivertrack.common.Location
+	6,  // 20: delivertrack.tracking.BatchUpdateLocationsRequest.updates:type_name -> delivertrack.tracking.UpdateLocationRequest
+	1,  // 21: delivertrack.tracking.TrackingService.CreateTracking:input_type -> delivertrack.tracking.CreateTrackingRequest
+	3,  // 22: delivertrack.tracking.TrackingService.GetTracking:input_type -> delivertrack.tracking.GetTrackingRequest
+	6,  // 23: delivertrack.tracking.TrackingService.UpdateLocation:input_type -> delivertrack.tracking.UpdateLocationRequest
+	8,  // 24: delivertrack.tracking.TrackingService.AddTrackingEvent:input_type -> delivertrack.tracking.AddTrackingEventRequest
+	11, // 25: delivertrack.tracking.TrackingService.GetTrackingHistory:input_type -> delivertrack.tracking.GetTrackingHistoryRequest
+	13, // 26: delivertrack.tracking.TrackingService.StreamLocation:input_type -> delivertrack.tracking.StreamLocationRequest
+	14, // 27: delivertrack.tracking.TrackingService.TrackDelivery:input_type -> delivertrack.tracking.TrackDeliveryRequest
+	15, // 28: delivertrack.tracking.TrackingService.GetCourierStatus:input_type -> delivertrack.tracking.GetCourierStatusRequest
+	17, // 29: delivertrack.tracking.TrackingService.GetCourierLocation:input_type -> delivertrack.tracking.GetCourierLocationRequest
+	19, // 30: delivertrack.tracking.TrackingService.GetDeliveryLocation:input_type -> delivertrack.tracking.GetDeliveryLocationRequest
+	21, // 31: delivertrack.tracking.TrackingService.GetDeliveryETA:input_type -> delivertrack.tracking.GetDeliveryETARequest
+	23, // 32: delivertrack.tracking.TrackingService.FindZones:input_type -> delivertrack.tracking.FindZonesRequest
+	26, // 33: delivertrack.tracking.TrackingService.BatchUpdateLocations:input_type -> delivertrack.tracking.BatchUpdateLocationsRequest
+	2,  // 34: delivertrack.tracking.TrackingService.CreateTracking:output_type -> delivertrack.tracking.CreateTrackingResponse
+	4,  // 35: delivertrack.tracking.TrackingService.GetTracking:output_type -> delivertrack.tracking.GetTrackingResponse
+	7,  // 36: delivertrack.tracking.TrackingService.UpdateLocation:output_type -> delivertrack.tracking.UpdateLocationResponse
+	9,  // 37: delivertrack.tracking.TrackingService.AddTrackingEvent:output_type -> delivertrack.tracking.AddTrackingEventResponse
+	12, // 38: delivertrack.tracking.TrackingService.GetTrackingHistory:output_type -> delivertrack.tracking.GetTrackingHistoryResponse
+	25, // 39: delivertrack.tracking.TrackingService.StreamLocation:output_type -> delivertrack.tracking.LocationUpdate
+	25, // 40: delivertrack.tracking.TrackingService.TrackDelivery:output_type -> delivertrack.tracking.LocationUpdate
+	16, // 41: delivertrack.tracking.TrackingService.GetCourierStatus:output_type -> delivertrack.tracking.GetCourierStatusResponse
+	18, // 42: delivertrack.tracking.TrackingService.GetCourierLocation:output_type -> delivertrack.tracking.GetCourierLocationResponse
+	20, // 43: delivertrack.tracking.TrackingService.GetDeliveryLocation:output_type -> delivertrack.tracking.GetDeliveryLocationResponse
+	22, // 44: delivertrack.tracking.TrackingService.GetDeliveryETA:output_type -> delivertrack.tracking.GetDeliveryETAResponse
+	24, // 45: delivertrack.tracking.TrackingService.FindZones:output_type -> delivertrack.tracking.FindZonesResponse
+	27, // 46: delivertrack.tracking.TrackingService.BatchUpdateLocations:output_type -> delivertrack.tracking.BatchUpdateLocationsResponse
+	34, // [34:47] is the sub-list for method output_type
+	21, // [21:34] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_tracking_proto_init() }
@@ -1293,7 +1971,7 @@ func file_tracking_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tracking_proto_rawDesc), len(file_tracking_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   18,
+			NumMessages:   29,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -25,6 +25,12 @@ const (
 	TrackingService_AddTrackingEvent_FullMethodName     = "/delivertrack.tracking.TrackingService/AddTrackingEvent"
 	TrackingService_GetTrackingHistory_FullMethodName   = "/delivertrack.tracking.TrackingService/GetTrackingHistory"
 	TrackingService_StreamLocation_FullMethodName       = "/delivertrack.tracking.TrackingService/StreamLocation"
+	TrackingService_TrackDelivery_FullMethodName        = "/delivertrack.tracking.TrackingService/TrackDelivery"
+	TrackingService_GetCourierStatus_FullMethodName     = "/delivertrack.tracking.TrackingService/GetCourierStatus"
+	TrackingService_GetCourierLocation_FullMethodName   = "/delivertrack.tracking.TrackingService/GetCourierLocation"
+	TrackingService_GetDeliveryLocation_FullMethodName  = "/delivertrack.tracking.TrackingService/GetDeliveryLocation"
+	TrackingService_GetDeliveryETA_FullMethodName       = "/delivertrack.tracking.TrackingService/GetDeliveryETA"
+	TrackingService_FindZones_FullMethodName            = "/delivertrack.tracking.TrackingService/FindZones"
 	TrackingService_BatchUpdateLocations_FullMethodName = "/delivertrack.tracking.TrackingService/BatchUpdateLocations"
 )
 
@@ -44,6 +50,18 @@ type TrackingServiceClient interface {
 	GetTrackingHistory(ctx context.Context, in *GetTrackingHistoryRequest, opts ...grpc.CallOption) (*GetTrackingHistoryResponse, error)
 	// Stream real-time location updates
 	StreamLocation(ctx context.Context, in *StreamLocationRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LocationUpdate], error)
+	// Stream a delivery's last known location, then each new one until it finishes
+	TrackDelivery(ctx context.Context, in *TrackDeliveryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LocationUpdate], error)
+	// Get a courier's last-seen time and derived active/stale/offline status
+	GetCourierStatus(ctx context.Context, in *GetCourierStatusRequest, opts ...grpc.CallOption) (*GetCourierStatusResponse, error)
+	// Get a courier's latest location and their average recent speed
+	GetCourierLocation(ctx context.Context, in *GetCourierLocationRequest, opts ...grpc.CallOption) (*GetCourierLocationResponse, error)
+	// Get a delivery's latest location
+	GetDeliveryLocation(ctx context.Context, in *GetDeliveryLocationRequest, opts ...grpc.CallOption) (*GetDeliveryLocationResponse, error)
+	// Estimate when a delivery reaches a destination from its latest location
+	GetDeliveryETA(ctx context.Context, in *GetDeliveryETARequest, opts ...grpc.CallOption) (*GetDeliveryETAResponse, error)
+	// Get the names of the active delivery zones containing a point
+	FindZones(ctx context.Context, in *FindZonesRequest, opts ...grpc.CallOption) (*FindZonesResponse, error)
 	// Batch update locations
 	BatchUpdateLocations(ctx context.Context, in *BatchUpdateLocationsRequest, opts ...grpc.CallOption) (*BatchUpdateLocationsResponse, error)
 }
@@ -125,6 +143,75 @@ func (c *trackingServiceClient) StreamLocation(ctx context.Context, in *StreamLo
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type TrackingService_StreamLocationClient = grpc.ServerStreamingClient[LocationUpdate]
 
+func (c *trackingServiceClient) TrackDelivery(ctx context.Context, in *TrackDeliveryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LocationUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TrackingService_ServiceDesc.Streams[1], TrackingService_TrackDelivery_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TrackDeliveryRequest, LocationUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TrackingService_TrackDeliveryClient = grpc.ServerStreamingClient[LocationUpdate]
+
+func (c *trackingServiceClient) GetCourierStatus(ctx context.Context, in *GetCourierStatusRequest, opts ...grpc.CallOption) (*GetCourierStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCourierStatusResponse)
+	err := c.cc.Invoke(ctx, TrackingService_GetCourierStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackingServiceClient) GetCourierLocation(ctx context.Context, in *GetCourierLocationRequest, opts ...grpc.CallOption) (*GetCourierLocationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCourierLocationResponse)
+	err := c.cc.Invoke(ctx, TrackingService_GetCourierLocation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackingServiceClient) GetDeliveryLocation(ctx context.Context, in *GetDeliveryLocationRequest, opts ...grpc.CallOption) (*GetDeliveryLocationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeliveryLocationResponse)
+	err := c.cc.Invoke(ctx, TrackingService_GetDeliveryLocation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackingServiceClient) GetDeliveryETA(ctx context.Context, in *GetDeliveryETARequest, opts ...grpc.CallOption) (*GetDeliveryETAResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeliveryETAResponse)
+	err := c.cc.Invoke(ctx, TrackingService_GetDeliveryETA_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackingServiceClient) FindZones(ctx context.Context, in *FindZonesRequest, opts ...grpc.CallOption) (*FindZonesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindZonesResponse)
+	err := c.cc.Invoke(ctx, TrackingService_FindZones_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trackingServiceClient) BatchUpdateLocations(ctx context.Context, in *BatchUpdateLocationsRequest, opts ...grpc.CallOption) (*BatchUpdateLocationsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(BatchUpdateLocationsResponse)
@@ -151,6 +238,18 @@ type TrackingServiceServer interface {
 	GetTrackingHistory(context.Context, *GetTrackingHistoryRequest) (*GetTrackingHistoryResponse, error)
 	// Stream real-time location updates
 	StreamLocation(*StreamLocationRequest, grpc.ServerStreamingServer[LocationUpdate]) error
+	// Stream a delivery's last known location, then each new one until it finishes
+	TrackDelivery(*TrackDeliveryRequest, grpc.ServerStreamingServer[LocationUpdate]) error
+	// Get a courier's last-seen time and derived active/stale/offline status
+	GetCourierStatus(context.Context, *GetCourierStatusRequest) (*GetCourierStatusResponse, error)
+	// Get a courier's latest location and their average recent speed
+	GetCourierLocation(context.Context, *GetCourierLocationRequest) (*GetCourierLocationResponse, error)
+	// Get a delivery's latest location
+	GetDeliveryLocation(context.Context, *GetDeliveryLocationRequest) (*GetDeliveryLocationResponse, error)
+	// Estimate when a delivery reaches a destination from its latest location
+	GetDeliveryETA(context.Context, *GetDeliveryETARequest) (*GetDeliveryETAResponse, error)
+	// Get the names of the active delivery zones containing a point
+	FindZones(context.Context, *FindZonesRequest) (*FindZonesResponse, error)
 	// Batch update locations
 	BatchUpdateLocations(context.Context, *BatchUpdateLocationsRequest) (*BatchUpdateLocationsResponse, error)
 	mustEmbedUnimplementedTrackingServiceServer()
@@ -181,6 +280,24 @@ func (UnimplementedTrackingServiceServer) GetTrackingHistory(context.Context, *G
 func (UnimplementedTrackingServiceServer) StreamLocation(*StreamLocationRequest, grpc.ServerStreamingServer[LocationUpdate]) error {
 	return status.Error(codes.Unimplemented, "method StreamLocation not implemented")
 }
+func (UnimplementedTrackingServiceServer) TrackDelivery(*TrackDeliveryRequest, grpc.ServerStreamingServer[LocationUpdate]) error {
+	return status.Error(codes.Unimplemented, "method TrackDelivery not implemented")
+}
+func (UnimplementedTrackingServiceServer) GetCourierStatus(context.Context, *GetCourierStatusRequest) (*GetCourierStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCourierStatus not implemented")
+}
+func (UnimplementedTrackingServiceServer) GetCourierLocation(context.Context, *GetCourierLocationRequest) (*GetCourierLocationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCourierLocation not implemented")
+}
+func (UnimplementedTrackingServiceServer) GetDeliveryLocation(context.Context, *GetDeliveryLocationRequest) (*GetDeliveryLocationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDeliveryLocation not implemented")
+}
+func (UnimplementedTrackingServiceServer) GetDeliveryETA(context.Context, *GetDeliveryETARequest) (*GetDeliveryETAResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDeliveryETA not implemented")
+}
+func (UnimplementedTrackingServiceServer) FindZones(context.Context, *FindZonesRequest) (*FindZonesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FindZones not implemented")
+}
 func (UnimplementedTrackingServiceServer) BatchUpdateLocations(context.Context, *BatchUpdateLocationsRequest) (*BatchUpdateLocationsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method BatchUpdateLocations not implemented")
 }
@@ -306,6 +423,107 @@ func _TrackingService_StreamLocation_Handler(srv interface{}, stream grpc.Server
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type TrackingService_StreamLocationServer = grpc.ServerStreamingServer[LocationUpdate]
 
+func _TrackingService_TrackDelivery_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TrackDeliveryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrackingServiceServer).TrackDelivery(m, &grpc.GenericServerStream[TrackDeliveryRequest, LocationUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TrackingService_TrackDeliveryServer = grpc.ServerStreamingServer[LocationUpdate]
+
+func _TrackingService_GetCourierStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCourierStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackingServiceServer).GetCourierStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackingService_GetCourierStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackingServiceServer).GetCourierStatus(ctx, req.(*GetCourierStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackingService_GetCourierLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCourierLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackingServiceServer).GetCourierLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackingService_GetCourierLocation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackingServiceServer).GetCourierLocation(ctx, req.(*GetCourierLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackingService_GetDeliveryLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeliveryLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackingServiceServer).GetDeliveryLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackingService_GetDeliveryLocation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackingServiceServer).GetDeliveryLocation(ctx, req.(*GetDeliveryLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackingService_GetDeliveryETA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeliveryETARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackingServiceServer).GetDeliveryETA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackingService_GetDeliveryETA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackingServiceServer).GetDeliveryETA(ctx, req.(*GetDeliveryETARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackingService_FindZones_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindZonesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackingServiceServer).FindZones(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackingService_FindZones_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackingServiceServer).FindZones(ctx, req.(*FindZonesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrackingService_BatchUpdateLocations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BatchUpdateLocationsRequest)
 	if err := dec(in); err != nil {
@@ -351,6 +569,26 @@ var TrackingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTrackingHistory",
 			Handler:    _TrackingService_GetTrackingHistory_Handler,
 		},
+		{
+			MethodName: "GetCourierStatus",
+			Handler:    _TrackingService_GetCourierStatus_Handler,
+		},
+		{
+			MethodName: "GetCourierLocation",
+			Handler:    _TrackingService_GetCourierLocation_Handler,
+		},
+		{
+			MethodName: "GetDeliveryLocation",
+			Handler:    _TrackingService_GetDeliveryLocation_Handler,
+		},
+		{
+			MethodName: "GetDeliveryETA",
+			Handler:    _TrackingService_GetDeliveryETA_Handler,
+		},
+		{
+			MethodName: "FindZones",
+			Handler:    _TrackingService_FindZones_Handler,
+		},
 		{
 			MethodName: "BatchUpdateLocations",
 			Handler:    _TrackingService_BatchUpdateLocations_Handler,
@@ -362,6 +600,11 @@ var TrackingService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _TrackingService_StreamLocation_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "TrackDelivery",
+			Handler:       _TrackingService_TrackDelivery_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "tracking.proto",
 }
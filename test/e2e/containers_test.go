@@ -0,0 +1,274 @@
+//go:build e2e
+
+// Package e2e runs the delivery, tracking, notification and analytics
+// services in-process against PostgreSQL, MongoDB and RabbitMQ containers
+// and drives them through the Go client SDK. The suite needs Docker and is
+// built only with the e2e tag:
+//
+//	make test-e2e
+package e2e
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/testcontainers/testcontainers-go"
+	tcmongodb "github.com/testcontainers/testcontainers-go/modules/mongodb"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcrabbitmq "github.com/testcontainers/testcontainers-go/modules/rabbitmq"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	_ "github.com/lib/pq"
+)
+
+// Images match docker-compose.yml
+const (
+	postgresImage = "postgres:16-alpine"
+	mongoImage    = "mongo:7-jammy"
+	rabbitImage   = "rabbitmq:3-management-alpine"
+)
+
+// startupTimeout bounds starting all three containers, image pulls included
+const startupTimeout = 5 * time.Minute
+
+// containers are started once and shared by the tests in the package; each
+// test gets its own databases and vhost from newEnvironment
+type containers struct {
+	postgres *tcpostgres.PostgresContainer
+	mongo    *tcmongodb.MongoDBContainer
+	rabbit   *tcrabbitmq.RabbitMQContainer
+
+	postgresURL string
+	mongoURL    string
+	amqpURL     string
+}
+
+var (
+	shared     *containers
+	sharedErr  error
+	sharedOnce sync.Once
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if shared != nil {
+		shared.terminate()
+	}
+	os.Exit(code)
+}
+
+// startContainers returns the shared containers, starting them on first use.
+// Tests are skipped when no Docker provider is reachable.
+func startContainers(t *testing.T) *containers {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping end-to-end test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	sharedOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+		defer cancel()
+		shared, sharedErr = runContainers(ctx)
+	})
+	if sharedErr != nil {
+		t.Fatalf("Failed to start containers: %v", sharedErr)
+	}
+	return shared
+}
+
+func runContainers(ctx context.Context) (*containers, error) {
+	c := &containers{}
+	var err error
+
+	c.postgres, err = tcpostgres.Run(ctx, postgresImage,
+		tcpostgres.WithDatabase("delivertrack"),
+		tcpostgres.WithUsername("delivertrack"),
+		tcpostgres.WithPassword("delivertrack"),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		c.terminate()
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	if c.postgresURL, err = c.postgres.ConnectionString(ctx, "sslmode=disable"); err != nil {
+		c.terminate()
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+
+	c.mongo, err = tcmongodb.Run(ctx, mongoImage)
+	if err != nil {
+		c.terminate()
+		return nil, fmt.Errorf("mongodb: %w", err)
+	}
+	if c.mongoURL, err = c.mongo.ConnectionString(ctx); err != nil {
+		c.terminate()
+		return nil, fmt.Errorf("mongodb: %w", err)
+	}
+
+	c.rabbit, err = tcrabbitmq.Run(ctx, rabbitImage)
+	if err != nil {
+		c.terminate()
+		return nil, fmt.Errorf("rabbitmq: %w", err)
+	}
+	if c.amqpURL, err = c.rabbit.AmqpURL(ctx); err != nil {
+		c.terminate()
+		return nil, fmt.Errorf("rabbitmq: %w", err)
+	}
+
+	return c, nil
+}
+
+func (c *containers) terminate() {
+	for _, ctr := range []testcontainers.Container{c.postgres, c.mongo, c.rabbit} {
+		if ctr != nil {
+			testcontainers.TerminateContainer(ctr)
+		}
+	}
+}
+
+// environment is one test's isolated slice of the containers: a PostgreSQL
+// database, a MongoDB database and a RabbitMQ vhost named after the run, so
+// reruns and parallel tests never see each other's rows or messages
+type environment struct {
+	DatabaseURL string
+	MongoURL    string
+	RabbitMQURL string
+}
+
+// newEnvironment creates a fresh database, Mongo database and vhost, and
+// removes them when the test ends
+func newEnvironment(t *testing.T, c *containers) *environment {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	name := "e2e_" + randomSuffix(t)
+
+	// PostgreSQL
+	admin, err := sql.Open("postgres", c.postgresURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	if _, err := admin.ExecContext(ctx, "CREATE DATABASE "+name); err != nil {
+		t.Fatalf("Failed to create database %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := admin.ExecContext(ctx, "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)"); err != nil {
+			t.Logf("Failed to drop database %s: %v", name, err)
+		}
+	})
+
+	// MongoDB creates the database on first write; drop it afterwards
+	mongoURL := withPath(t, c.mongoURL, name)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(c.mongoURL))
+		if err != nil {
+			t.Logf("Failed to connect to MongoDB: %v", err)
+			return
+		}
+		defer client.Disconnect(ctx)
+		if err := client.Database(name).Drop(ctx); err != nil {
+			t.Logf("Failed to drop Mongo database %s: %v", name, err)
+		}
+	})
+
+	// RabbitMQ
+	rabbitctl(t, c, "add_vhost", name)
+	rabbitctl(t, c, "set_permissions", "-p", name, c.rabbit.AdminUsername, ".*", ".*", ".*")
+	t.Cleanup(func() { rabbitctl(t, c, "delete_vhost", name) })
+
+	return &environment{
+		DatabaseURL: withPath(t, c.postgresURL, name),
+		MongoURL:    mongoURL,
+		RabbitMQURL: withPath(t, c.amqpURL, name),
+	}
+}
+
+// rabbitctl runs rabbitmqctl in the RabbitMQ container
+func rabbitctl(t *testing.T, c *containers, args ...string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	code, _, err := c.rabbit.Exec(ctx, append([]string{"rabbitmqctl"}, args...))
+	if err != nil || code != 0 {
+		t.Fatalf("Failed to run rabbitmqctl %v: exit code %d, %v", args, code, err)
+	}
+}
+
+// queueBindings route events to the consumers' queues. The services only
+// declare their queues; deployments bind them to the exchanges with the
+// broker's definitions, so the suite does the same. The tracking service
+// binds a queue of its own per instance.
+var queueBindings = []struct {
+	queue    string
+	exchange string
+}{
+	{"notification-events", "delivery-events"},
+	{"notification-events", "tracking-events"},
+	{"analytics-delivery-events", "delivery-events"},
+	{"analytics-delivery-events", "tracking-events"},
+}
+
+// bindQueues binds the consumers' queues, which must already be declared,
+// to the event exchanges
+func bindQueues(t *testing.T, rabbitMQURL string) {
+	t.Helper()
+	conn, err := amqp.Dial(rabbitMQURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		t.Fatalf("Failed to open RabbitMQ channel: %v", err)
+	}
+	defer ch.Close()
+
+	for _, b := range queueBindings {
+		// Declared as the publishers declare them
+		if err := ch.ExchangeDeclare(b.exchange, "topic", true, false, false, false, nil); err != nil {
+			t.Fatalf("Failed to declare exchange %s: %v", b.exchange, err)
+		}
+		if err := ch.QueueBind(b.queue, "#", b.exchange, false, nil); err != nil {
+			t.Fatalf("Failed to bind %s to %s: %v", b.queue, b.exchange, err)
+		}
+	}
+}
+
+// withPath returns rawURL with its path replaced by name, keeping the query
+func withPath(t *testing.T, rawURL, name string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", rawURL, err)
+	}
+	u.Path = "/" + name
+	u.RawPath = ""
+	return u.String()
+}
+
+func randomSuffix(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("Failed to generate a run name: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,235 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Keneke-Einar/delivertrack/pkg/client"
+)
+
+const password = "E2ePass123!"
+
+// Deadlines for effects that travel through the outbox and RabbitMQ
+const (
+	eventTimeout = 30 * time.Second
+	pollInterval = 200 * time.Millisecond
+)
+
+// TestGoldenPath follows a delivery from creation to confirmation and checks
+// that its events reach the notification and analytics services
+func TestGoldenPath(t *testing.T) {
+	s := startStack(t, newEnvironment(t, startContainers(t)))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	// Notifications are stored against users(id) by customer ID; registering
+	// the customer first on the fresh database keeps the two the same
+	customer, customers := signUp(t, ctx, s, "customer")
+	courier, couriers := signUp(t, ctx, s, "courier")
+	if customer.CustomerID == nil || courier.CourierID == nil {
+		t.Fatalf("Expected registration to create profiles, got customer %v and courier %v", customer.CustomerID, courier.CourierID)
+	}
+	courierID := *courier.CourierID
+
+	d, err := customers.CreateDelivery(ctx, client.CreateDeliveryRequest{
+		CustomerID:       *customer.CustomerID,
+		PickupLocation:   "(-122.4194,37.7749)",
+		DeliveryLocation: "(-122.4089,37.7849)",
+		Notes:            "End-to-end test",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create delivery: %v", err)
+	}
+
+	// The courier takes the delivery and sets off
+	if err := send(ctx, s, couriers, http.MethodPut, "/api/delivery/couriers/me/status", map[string]string{"status": "available"}); err != nil {
+		t.Fatalf("Failed to make the courier available: %v", err)
+	}
+	if _, err := couriers.UpdateStatus(ctx, d.ID, client.UpdateStatusRequest{Status: client.StatusAssigned}); err != nil {
+		t.Fatalf("Failed to assign the delivery: %v", err)
+	}
+	if _, err := couriers.UpdateStatus(ctx, d.ID, client.UpdateStatusRequest{Status: client.StatusInTransit}); err != nil {
+		t.Fatalf("Failed to start the delivery: %v", err)
+	}
+
+	// The customer watches the track
+	broadcasts := make(chan *client.Location, 16)
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	watched := make(chan error, 1)
+	go func() {
+		watched <- customers.SubscribeTrack(watchCtx, d.ID, func(loc *client.Location) error {
+			select {
+			case broadcasts <- loc:
+			default:
+			}
+			return nil
+		})
+	}()
+	defer func() {
+		stopWatching()
+		<-watched
+	}()
+
+	// Points recorded before the subscription is up are not broadcast, so the
+	// courier keeps moving, about 11 m a step, until the customer sees one
+	lat, recorded := 37.7749, 0
+	eventually(t, eventTimeout, 0, func() error {
+		lat += 0.0001
+		if _, err := couriers.RecordLocation(ctx, client.RecordLocationRequest{
+			DeliveryID: d.ID,
+			CourierID:  courierID,
+			Latitude:   lat,
+			Longitude:  -122.4194,
+		}); err != nil {
+			return fmt.Errorf("failed to record location: %w", err)
+		}
+		recorded++
+
+		select {
+		case loc := <-broadcasts:
+			if loc.DeliveryID != d.ID || loc.CourierID != courierID {
+				return fmt.Errorf("unexpected broadcast %+v", loc)
+			}
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("no location broadcast yet")
+		}
+	})
+
+	track, err := customers.GetTrack(ctx, d.ID, client.TrackOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get track: %v", err)
+	}
+	if track.PointCount != recorded {
+		t.Errorf("Expected %d points on the track, got %d", recorded, track.PointCount)
+	}
+
+	// The customer hands the courier their confirmation code
+	code, err := customers.GetConfirmationCode(ctx, d.ID)
+	if err != nil {
+		t.Fatalf("Failed to get the confirmation code: %v", err)
+	}
+	if err := send(ctx, s, couriers, http.MethodPost, "/api/delivery/deliveries/"+strconv.Itoa(d.ID)+"/confirm",
+		map[string]string{"recipient_name": "E2E Customer", "confirmation_code": code}); err != nil {
+		t.Fatalf("Failed to confirm delivery: %v", err)
+	}
+	got, err := customers.GetDelivery(ctx, d.ID)
+	if err != nil {
+		t.Fatalf("Failed to get delivery: %v", err)
+	}
+	if got.Status != client.StatusDelivered {
+		t.Errorf("Expected a delivered delivery, got %q", got.Status)
+	}
+
+	// The notification service tells the customer of each change
+	want := []string{
+		fmt.Sprintf("Your delivery %s has been created and is being processed.", d.TrackingNumber),
+		fmt.Sprintf("Your delivery %d status has been updated to: %s", d.ID, client.StatusAssigned),
+		fmt.Sprintf("Your delivery %d status has been updated to: %s", d.ID, client.StatusInTransit),
+	}
+	eventually(t, eventTimeout, pollInterval, func() error {
+		messages, err := notificationMessages(ctx, s, *customer.CustomerID)
+		if err != nil {
+			return err
+		}
+		for _, message := range want {
+			if !slices.Contains(messages, message) {
+				return fmt.Errorf("missing notification %q in %q", message, messages)
+			}
+		}
+		return nil
+	})
+
+	// The analytics service counts the confirmed delivery for the courier
+	eventually(t, eventTimeout, pollInterval, func() error {
+		perf, err := s.Analytics.GetCourierPerformance(ctx, courierID, "day")
+		if err != nil {
+			return err
+		}
+		if perf.DeliveriesCompleted != 1 {
+			return fmt.Errorf("expected 1 completed delivery, got %d", perf.DeliveriesCompleted)
+		}
+		return nil
+	})
+}
+
+// signUp registers and logs in an account with role, returning it and a
+// client using it
+func signUp(t *testing.T, ctx context.Context, s *stack, role string) (*client.User, *client.Client) {
+	t.Helper()
+	c, err := client.New(client.Config{BaseURL: s.URL, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	username := "e2e_" + role
+	user, err := c.Register(ctx, client.RegisterRequest{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: password,
+		Role:     role,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register %s: %v", role, err)
+	}
+	if _, err := c.Login(ctx, username, password); err != nil {
+		t.Fatalf("Failed to log in as %s: %v", role, err)
+	}
+	return user, c
+}
+
+// send makes an authenticated request the SDK has no method for
+func send(ctx context.Context, s *stack, c *client.Client, method, path string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, msg)
+	}
+	return nil
+}
+
+// notificationMessages returns the messages stored for a customer; event
+// notifications do not record their delivery
+func notificationMessages(ctx context.Context, s *stack, customerID int) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT message FROM notifications WHERE user_id = $1", customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []string
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
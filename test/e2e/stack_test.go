@@ -0,0 +1,331 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	analyticsAdapters "github.com/Keneke-Einar/delivertrack/internal/analytics/adapters"
+	analyticsApp "github.com/Keneke-Einar/delivertrack/internal/analytics/app"
+	deliveryAdapters "github.com/Keneke-Einar/delivertrack/internal/delivery/adapters"
+	deliveryApp "github.com/Keneke-Einar/delivertrack/internal/delivery/app"
+	notificationAdapters "github.com/Keneke-Einar/delivertrack/internal/notification/adapters"
+	notificationApp "github.com/Keneke-Einar/delivertrack/internal/notification/app"
+	trackingAdapters "github.com/Keneke-Einar/delivertrack/internal/tracking/adapters"
+	trackingApp "github.com/Keneke-Einar/delivertrack/internal/tracking/app"
+	trackingDomain "github.com/Keneke-Einar/delivertrack/internal/tracking/domain"
+	"github.com/Keneke-Einar/delivertrack/migrations"
+	authAdapters "github.com/Keneke-Einar/delivertrack/pkg/auth/adapters"
+	authApp "github.com/Keneke-Einar/delivertrack/pkg/auth/app"
+	authDomain "github.com/Keneke-Einar/delivertrack/pkg/auth/domain"
+	"github.com/Keneke-Einar/delivertrack/pkg/config"
+	"github.com/Keneke-Einar/delivertrack/pkg/geocoding"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcclient"
+	"github.com/Keneke-Einar/delivertrack/pkg/grpcinterceptors"
+	httputil "github.com/Keneke-Einar/delivertrack/pkg/http"
+	"github.com/Keneke-Einar/delivertrack/pkg/http/httpmiddleware"
+	"github.com/Keneke-Einar/delivertrack/pkg/logger"
+	"github.com/Keneke-Einar/delivertrack/pkg/messaging"
+	"github.com/Keneke-Einar/delivertrack/pkg/mongodb"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres"
+	"github.com/Keneke-Einar/delivertrack/pkg/postgres/migrate"
+	"github.com/Keneke-Einar/delivertrack/pkg/websocket"
+	"github.com/Keneke-Einar/delivertrack/proto/delivery"
+	"github.com/Keneke-Einar/delivertrack/proto/tracking"
+	"google.golang.org/grpc"
+)
+
+// stack is the services of one test, wired as their cmd/ mains wire them,
+// behind a front door routed like the gateway so pkg/client can drive it
+type stack struct {
+	URL       string  // gateway-style base URL
+	DB        *sql.DB // the services' PostgreSQL database
+	Analytics *analyticsApp.AnalyticsService
+}
+
+// grpcConfig bounds and retries the services' calls to each other
+var grpcConfig = config.GRPCConfig{
+	Timeout:         10 * time.Second,
+	MaxRetries:      3,
+	RetryBackoff:    100 * time.Millisecond,
+	MaxRetryBackoff: time.Second,
+}
+
+// startStack boots the services against env and stops them when the test ends
+func startStack(t *testing.T, env *environment) *stack {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	lg := newLogger(t)
+
+	db, err := postgres.New(env.DatabaseURL, postgres.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrate.Ensure(ctx, db.DB, migrations.FS, true); err != nil {
+		t.Fatalf("Failed to migrate the database: %v", err)
+	}
+
+	// One auth layer stands in for each service's own copy; they share the
+	// users table and the JWT secret
+	authCfg := config.AuthConfig{JWTSecret: "e2e-secret", JWTExpiration: time.Hour}
+	tokenService, err := authAdapters.NewJWTTokenServiceFromConfig(authCfg)
+	if err != nil {
+		t.Fatalf("Failed to configure JWT keys: %v", err)
+	}
+	authService := authApp.NewAuthService(authAdapters.NewPostgresUserRepository(db.DB), tokenService)
+	authHandler := authAdapters.NewHTTPHandler(authService, authCfg.JWTExpiration)
+	protected := httpmiddleware.Auth(authService, httpmiddleware.WithForwardedAuthorization())
+
+	// The consumers declare their queues, which are bound before anything publishes
+	startNotification(t, env, db, lg)
+	analyticsService := startAnalytics(t, env, db, lg)
+
+	// The two gRPC services call each other, so both listen before either dials
+	deliveryListener := listen(t)
+	trackingListener := listen(t)
+
+	deliveryMux := startDelivery(t, ctx, env, db, lg, authService, protected, deliveryListener, trackingListener.Addr().String())
+	trackingMux := startTracking(t, env, lg, authService, tokenService, protected, trackingListener, deliveryListener.Addr().String())
+	bindQueues(t, env.RabbitMQURL)
+
+	front := http.NewServeMux()
+	front.HandleFunc("POST /login", authHandler.Login)
+	front.HandleFunc("POST /register", authHandler.Register)
+	front.Handle("/api/delivery/", http.StripPrefix("/api/delivery", deliveryMux))
+	front.Handle("/api/tracking/", http.StripPrefix("/api/tracking", trackingMux))
+	server := httptest.NewServer(httputil.RequestID(front))
+	t.Cleanup(server.Close)
+
+	return &stack{URL: server.URL, DB: db.DB, Analytics: analyticsService}
+}
+
+func startDelivery(t *testing.T, ctx context.Context, env *environment, db *postgres.DB, lg *logger.Logger,
+	authService *authApp.AuthService, protected func(http.HandlerFunc) http.HandlerFunc,
+	lis net.Listener, trackingAddr string,
+) http.Handler {
+	t.Helper()
+
+	publisher, err := messaging.NewRabbitMQPublisher(env.RabbitMQURL, lg)
+	if err != nil {
+		t.Fatalf("Failed to create RabbitMQ publisher: %v", err)
+	}
+	t.Cleanup(func() { publisher.Close() })
+
+	blobStore, err := deliveryAdapters.NewFilesystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	deliveryService := deliveryApp.NewDeliveryService(deliveryAdapters.NewPostgresDeliveryRepository(db.DB), geocoder{}, blobStore, lg)
+	courierRepo := deliveryAdapters.NewPostgresCourierRepository(db.DB)
+	courierService := deliveryApp.NewCourierService(courierRepo, lg)
+	deliveryService.SetCourierRepository(courierRepo)
+
+	trackingConn, err := grpcclient.New(ctx, "tracking", trackingAddr, grpcConfig)
+	if err != nil {
+		t.Fatalf("Failed to configure tracking service client: %v", err)
+	}
+	t.Cleanup(func() { trackingConn.Close() })
+	trackingClient := tracking.NewTrackingServiceClient(trackingConn)
+	deliveryService.SetCourierLocator(deliveryAdapters.NewTrackingCourierLocator(trackingClient))
+	deliveryService.SetDeliveryTracker(deliveryAdapters.NewTrackingDeliveryTracker(trackingClient), 2*time.Second)
+
+	// Poll quickly so events reach the consumers well inside the test's deadlines
+	outboxConfig := deliveryApp.DefaultOutboxDispatcherConfig()
+	outboxConfig.PollInterval = 100 * time.Millisecond
+	dispatcher := deliveryApp.NewOutboxDispatcher(deliveryAdapters.NewPostgresOutboxRepository(db.DB), publisher, outboxConfig, lg)
+	go dispatcher.Run(ctx)
+
+	grpcServer := grpcinterceptors.NewServer(lg, authService, time.Second)
+	delivery.RegisterDeliveryServiceServer(grpcServer, deliveryAdapters.NewGRPCHandler(deliveryService))
+	serve(t, grpcServer, lis)
+
+	deliveryHandler := deliveryAdapters.NewHTTPHandler(deliveryService)
+	courierHandler := deliveryAdapters.NewCourierHTTPHandler(courierService)
+	mux := httputil.NewRouter()
+	mux.HandleFunc("GET /deliveries", protected(deliveryHandler.ListDeliveries))
+	mux.HandleFunc("POST /deliveries", protected(deliveryHandler.CreateDelivery))
+	mux.HandleFunc("GET /deliveries/{id}", protected(deliveryHandler.GetDelivery))
+	mux.HandleFunc("PUT /deliveries/{id}/status", protected(deliveryHandler.UpdateDeliveryStatus))
+	mux.HandleFunc("GET /deliveries/{id}/confirmation-code", protected(deliveryHandler.GetConfirmationCode))
+	mux.HandleFunc("POST /deliveries/{id}/confirm", protected(deliveryHandler.ConfirmDelivery))
+	mux.HandleFunc("PUT /couriers/me/status", protected(courierHandler.UpdateMyStatus))
+	return mux
+}
+
+func startTracking(t *testing.T, env *environment, lg *logger.Logger,
+	authService *authApp.AuthService, tokenService *authAdapters.JWTTokenService, protected func(http.HandlerFunc) http.HandlerFunc,
+	lis net.Listener, deliveryAddr string,
+) http.Handler {
+	t.Helper()
+
+	mongoClient, err := mongodb.New(env.MongoURL, mongodb.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	t.Cleanup(func() { mongoClient.Close(context.Background()) })
+	indexCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := mongoClient.EnsureIndexes(indexCtx, 0); err != nil {
+		t.Fatalf("Failed to ensure MongoDB indexes: %v", err)
+	}
+
+	publisher, err := messaging.NewRabbitMQPublisher(env.RabbitMQURL, lg)
+	if err != nil {
+		t.Fatalf("Failed to create RabbitMQ publisher: %v", err)
+	}
+	t.Cleanup(func() { publisher.Close() })
+
+	deliveryConn, err := grpcclient.New(context.Background(), "delivery", deliveryAddr, grpcConfig)
+	if err != nil {
+		t.Fatalf("Failed to configure delivery service client: %v", err)
+	}
+	t.Cleanup(func() { deliveryConn.Close() })
+
+	trackingService := trackingApp.NewTrackingService(trackingAdapters.NewMongoDBLocationRepository(mongoClient),
+		publisher, delivery.NewDeliveryServiceClient(deliveryConn), authService, geocoder{}, lg)
+	trackingService.SetZoneRepository(trackingAdapters.NewMongoDBZoneRepository(mongoClient))
+	trackingService.SetTrackSummaryRepository(trackingAdapters.NewMongoDBTrackSummaryRepository(mongoClient))
+	trackingService.SetJitterFilter(trackingDomain.DefaultJitterFilter())
+	trackingService.SetServiceToken(func() (string, error) {
+		return tokenService.GenerateToken(&authDomain.User{Username: "tracking-service", Role: authDomain.RoleService})
+	})
+	t.Cleanup(trackingService.Shutdown)
+
+	consumer, err := messaging.NewRabbitMQConsumer(env.RabbitMQURL, lg)
+	if err != nil {
+		t.Fatalf("Failed to create RabbitMQ consumer: %v", err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+	if err := trackingService.StartDeliveryEventConsumption(consumer); err != nil {
+		t.Fatalf("Failed to start tracking event consumption: %v", err)
+	}
+
+	hub := websocket.NewHubWithConfig(authService, websocket.HubConfig{BroadcastBuffer: 64})
+	trackingService.SetWebSocketHub(hub)
+	go hub.Run()
+
+	grpcServer := grpcinterceptors.NewServer(lg, authService, time.Second)
+	tracking.RegisterTrackingServiceServer(grpcServer, trackingAdapters.NewGRPCHandler(trackingService))
+	serve(t, grpcServer, lis)
+
+	trackingHandler := trackingAdapters.NewHTTPHandler(trackingService)
+	mux := httputil.NewRouter()
+	mux.HandleFunc("POST /locations", protected(trackingHandler.RecordLocation))
+	mux.HandleFunc("GET /deliveries/{id}/track", protected(trackingHandler.GetDeliveryTrack))
+	mux.HandleFunc("GET /deliveries/{id}/location", protected(trackingHandler.GetCurrentLocation))
+	mux.HandleFunc("GET /ws/deliveries/{id}/track", hub.HandleWebSocket)
+	return mux
+}
+
+func startNotification(t *testing.T, env *environment, db *postgres.DB, lg *logger.Logger) {
+	t.Helper()
+
+	consumer, err := messaging.NewRabbitMQConsumer(env.RabbitMQURL, lg)
+	if err != nil {
+		t.Fatalf("Failed to create RabbitMQ consumer: %v", err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	service := notificationApp.NewNotificationService(notificationAdapters.NewPostgresNotificationRepository(db.DB), consumer, lg)
+	t.Cleanup(service.Shutdown)
+	if err := service.StartEventConsumption(); err != nil {
+		t.Fatalf("Failed to start notification event consumption: %v", err)
+	}
+}
+
+func startAnalytics(t *testing.T, env *environment, db *postgres.DB, lg *logger.Logger) *analyticsApp.AnalyticsService {
+	t.Helper()
+
+	consumer, err := messaging.NewRabbitMQConsumer(env.RabbitMQURL, lg)
+	if err != nil {
+		t.Fatalf("Failed to create RabbitMQ consumer: %v", err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	service := analyticsApp.NewAnalyticsService(analyticsAdapters.NewPostgresMetricRepository(db.DB),
+		analyticsAdapters.NewPostgresCourierStatsRepository(db.DB), consumer, lg)
+	service.SetRouteStats(analyticsAdapters.NewPostgresRouteStatsRepository(db.DB))
+	t.Cleanup(service.Shutdown)
+	if err := service.StartEventConsumption(); err != nil {
+		t.Fatalf("Failed to start analytics event consumption: %v", err)
+	}
+	return service
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	return lis
+}
+
+// serve runs a gRPC server on lis until the test ends
+func serve(t *testing.T, server *grpc.Server, lis net.Listener) {
+	t.Helper()
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+}
+
+// newLogger logs warnings and errors to stdout, which go test shows for
+// failed tests. Services keep logging from background goroutines after a
+// test ends, so the logger must not write through t.
+func newLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	lg, err := logger.NewLogger(config.LoggingConfig{
+		Level:    "warn",
+		Format:   "console",
+		Output:   "stdout",
+		Sampling: config.SamplingConfig{Initial: 100, Thereafter: 100},
+	}, "e2e")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return lg
+}
+
+// geocoder resolves every address to one point so the suite never calls a
+// geocoding provider
+type geocoder struct{}
+
+func (geocoder) ForwardGeocode(ctx context.Context, address string) (*geocoding.GeocodeResult, error) {
+	return &geocoding.GeocodeResult{Latitude: 37.7749, Longitude: -122.4194, Address: address, City: "San Francisco", Country: "US"}, nil
+}
+
+func (geocoder) ReverseGeocode(ctx context.Context, lat, lng float64) (*geocoding.ReverseGeocodeResult, error) {
+	return &geocoding.ReverseGeocodeResult{Address: fmt.Sprintf("%.4f, %.4f", lat, lng), City: "San Francisco", Country: "US"}, nil
+}
+
+func (geocoder) Autocomplete(ctx context.Context, query string) ([]geocoding.AutocompleteResult, error) {
+	return nil, nil
+}
+
+// eventually calls fn every interval until it succeeds, failing the test
+// with fn's last error once timeout has passed. Asynchronous effects are
+// polled for instead of slept on.
+func eventually(t *testing.T, timeout, interval time.Duration, fn func() error) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		err := fn()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Condition not met after %s: %v", timeout, err)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -256,6 +256,7 @@ document.addEventListener('alpine:init', () => {
         routePolyline: null,
         ws: null,
         wsStatus: 'connecting', // 'connecting' | 'connected' | 'disconnected'
+        wsSeq: null,            // last envelope seq seen, sent back to resume after a reconnect
         lastUpdated: null,
         updateCount: 0,
         loading: true,
@@ -268,7 +269,7 @@ document.addEventListener('alpine:init', () => {
                 this.delivery = await api.get('/api/delivery/deliveries/' + id);
 
                 var trackData = null;
-                try { trackData = await api.get('/api/tracking/deliveries/' + id + '/track'); } catch (_) {}
+                try { trackData = await api.get('/api/tracking/deliveries/' + id + '/track?order=asc&simplify=5'); } catch (_) {}
                 this.locations = (trackData && trackData.locations) ? trackData.locations : [];
 
                 try { this.currentLocation = await api.get('/api/tracking/deliveries/' + id + '/location'); } catch (_) {}
@@ -361,49 +362,31 @@ document.addEventListener('alpine:init', () => {
             var self = this;
             var token = Alpine.store('auth').token;
             var protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-            var url = protocol + '//' + window.location.host + '/ws/deliveries/' + deliveryId + '/track?token=' + token;
+            var url = protocol + '//' + window.location.host + '/ws/deliveries/' + deliveryId + '/track';
 
             this.wsStatus = 'connecting';
-            this.ws = new WebSocket(url);
+            this.ws = new WebSocket(url, ['bearer', token]);
 
             this.ws.onopen = function () {
                 self.wsStatus = 'connected';
+                if (self.wsSeq !== null) {
+                    self.ws.send(JSON.stringify({ action: 'resume', last_seq: self.wsSeq }));
+                }
+                self.wsSeq = 0;
             };
 
+            // Messages arrive as {type, seq, sent_at, payload}; a resume is
+            // answered with the latest location instead of a replay
             this.ws.onmessage = function (event) {
                 try {
-                    var data = JSON.parse(event.data);
-                    if (data.location) {
-                        var loc = data.location;
-                        self.currentLocation = loc;
-                        self.lastUpdated = new Date();
-                        self.updateCount++;
-
-                        // Animate the courier marker to new position
-                        if (self.courierMarker) {
-                            self.animateMarker(self.courierMarker, loc.Latitude, loc.Longitude);
-                        } else if (self.map) {
-                            MapHelper.init();
-                            self.courierMarker = MapHelper.addMarker(
-                                self.map, loc.Latitude, loc.Longitude,
-                                MapHelper.courierIcon, 'Courier'
-                            );
-                        }
-
-                        // Extend the live route trail
-                        if (self.routePolyline) {
-                            self.routePolyline.addLatLng([loc.Latitude, loc.Longitude]);
-                        } else if (self.map) {
-                            self.routePolyline = MapHelper.drawRoute(self.map, [[loc.Latitude, loc.Longitude]]);
-                        }
-
-                        // Smooth pan map to follow courier
-                        if (self.map) {
-                            self.map.panTo([loc.Latitude, loc.Longitude], { animate: true, duration: 0.5 });
-                        }
-
-                        // Debounced ETA refresh after location updates
-                        self.debouncedRefreshETA();
+                    var msg = JSON.parse(event.data);
+                    self.wsSeq = msg.seq;
+                    if (msg.type === 'location') {
+                        self.showLocation(msg.payload.location);
+                    } else if (msg.type === 'resumed') {
+                        (msg.payload.locations || []).forEach(function (update) {
+                            self.showLocation(update.location);
+                        });
                     }
                 } catch (_) {}
             };
@@ -422,6 +405,41 @@ document.addEventListener('alpine:init', () => {
             };
         },
 
+        /** Move the courier marker and route trail to a broadcast location */
+        showLocation(loc) {
+            var self = this;
+            if (!loc) return;
+            self.currentLocation = loc;
+            self.lastUpdated = new Date();
+            self.updateCount++;
+
+            // Animate the courier marker to new position
+            if (self.courierMarker) {
+                self.animateMarker(self.courierMarker, loc.Latitude, loc.Longitude);
+            } else if (self.map) {
+                MapHelper.init();
+                self.courierMarker = MapHelper.addMarker(
+                    self.map, loc.Latitude, loc.Longitude,
+                    MapHelper.courierIcon, 'Courier'
+                );
+            }
+
+            // Extend the live route trail
+            if (self.routePolyline) {
+                self.routePolyline.addLatLng([loc.Latitude, loc.Longitude]);
+            } else if (self.map) {
+                self.routePolyline = MapHelper.drawRoute(self.map, [[loc.Latitude, loc.Longitude]]);
+            }
+
+            // Smooth pan map to follow courier
+            if (self.map) {
+                self.map.panTo([loc.Latitude, loc.Longitude], { animate: true, duration: 0.5 });
+            }
+
+            // Debounced ETA refresh after location updates
+            self.debouncedRefreshETA();
+        },
+
         /** Human-readable "X seconds ago" for last update */
         lastUpdatedText() {
             if (!this.lastUpdated) return 'Waiting for updates…';
@@ -467,12 +485,14 @@ document.addEventListener('alpine:init', () => {
             var self = this;
             var token = Alpine.store('auth').token;
             var protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-            var url = protocol + '//' + window.location.host + '/ws/notifications?token=' + token;
+            var url = protocol + '//' + window.location.host + '/ws/notifications';
 
-            this.ws = new WebSocket(url);
+            this.ws = new WebSocket(url, ['bearer', token]);
             this.ws.onmessage = function (event) {
                 try {
-                    var data = JSON.parse(event.data);
+                    var msg = JSON.parse(event.data);
+                    if (msg.type !== 'notification' && msg.type !== 'eta') return;
+                    var data = msg.payload;
                     self.notifications.unshift({
                         ID: Date.now(),
                         Type: data.type || 'push',